@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/database/migrations"
+	"github.com/omriShneor/project_alfred/internal/export"
+)
+
+// localBackend operates on the SQLite database directly. It changes only what is
+// stored, so a running server keeps its connected clients until restarted.
+type localBackend struct {
+	db *database.DB
+}
+
+// openLocalBackend opens the database without migrating it, refusing a schema that
+// doesn't match this build
+func openLocalBackend(dbPath string) (*localBackend, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("database %s: %w", dbPath, err)
+	}
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrations.Verify(db.DB); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w; run alfredctl migrate up", err)
+	}
+
+	// Stored messages are encrypted with the server's key; exports need it to read them
+	if encryptor, err := auth.NewEncryptor(nil); err == nil {
+		db.SetMessageCipher(encryptor)
+	}
+	return &localBackend{db: db}, nil
+}
+
+func (b *localBackend) ListUsers() ([]database.AdminUser, error) {
+	return b.db.ListAdminUsers(time.Now())
+}
+
+func (b *localBackend) ResetOnboarding(userID int64) error {
+	if err := b.checkUser(userID); err != nil {
+		return err
+	}
+	return b.db.ResetOnboarding(userID)
+}
+
+func (b *localBackend) RevokeTokens(userID int64) (int64, int64, error) {
+	if err := b.checkUser(userID); err != nil {
+		return 0, 0, err
+	}
+	return b.db.RevokeUserTokens(userID)
+}
+
+func (b *localBackend) Backfill(userID int64, days int) (int, int, error) {
+	return 0, 0, fmt.Errorf("backfill runs the analysis agents, so it needs a running server: use --url")
+}
+
+func (b *localBackend) Export(userID int64) ([]byte, error) {
+	if err := b.checkUser(userID); err != nil {
+		return nil, err
+	}
+	return export.NewExporter(b.db, nil).Build(userID, nil)
+}
+
+func (b *localBackend) Close() error {
+	return b.db.Close()
+}
+
+func (b *localBackend) checkUser(userID int64) error {
+	if _, err := b.db.GetUserEmail(userID); err != nil {
+		return fmt.Errorf("user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// migrate runs a schema command on --db. Unlike other commands it works on a database
+// whose schema doesn't match this build, which is what it's for.
+func (c *cli) migrate(args []string) error {
+	if c.url != "" {
+		return fmt.Errorf("migrate works on the database file (--db); a running server applies pending migrations when it starts")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("%w: migrate needs up, status, verify or down", errUsage)
+	}
+
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	target := fs.Int("to", -1, "target version for down")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+
+	db, err := database.Open(c.dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return migrations.RunCommand(db.DB, args[0], *target, c.out)
+}
@@ -0,0 +1,270 @@
+// Command alfredctl manages users and data of a self-hosted Alfred instance.
+//
+// It works against the SQLite database directly (--db, the default) or against a
+// running server's admin API (--url with --token, an access token of a user listed in
+// ALFRED_ADMIN_EMAILS). Prefer --url while the server is running, so connected
+// WhatsApp/Telegram clients and Gmail workers see the change.
+//
+// Usage:
+//
+//	alfredctl [--db path | --url url --token token] [--json] <command> [args]
+//
+// Commands:
+//
+//	users                               list users
+//	reset-onboarding <user>             purge a user's data and restart onboarding
+//	revoke-tokens <user>                delete a user's sessions and API keys
+//	backfill [-days n] <user>           re-analyze a user's recent history (--url only)
+//	migrate up|status|verify|down [-to n]  manage the schema (--db only)
+//	export [-o file] <user>             write a user's data export ZIP
+//
+// A <user> is a user ID or email address.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// backend performs admin operations against the database or a running server
+type backend interface {
+	ListUsers() ([]database.AdminUser, error)
+	ResetOnboarding(userID int64) error
+	RevokeTokens(userID int64) (sessions, apiKeys int64, err error)
+	Backfill(userID int64, days int) (channels, emailSources int, err error)
+	Export(userID int64) ([]byte, error)
+	Close() error
+}
+
+// errUsage reports a malformed command line; main prints the usage after it
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	fs := flag.NewFlagSet("alfredctl", flag.ExitOnError)
+	fs.Usage = func() { usage(fs.Output()) }
+	dbPath := fs.String("db", envOr("ALFRED_DB_PATH", "./alfred.db"), "SQLite database to operate on directly")
+	url := fs.String("url", os.Getenv("ALFRED_URL"), "base URL of a running server; overrides --db")
+	token := fs.String("token", os.Getenv("ALFRED_TOKEN"), "admin access token for --url")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	fs.Parse(os.Args[1:])
+
+	c := &cli{dbPath: *dbPath, url: *url, token: *token, json: *asJSON, out: os.Stdout}
+	if err := c.run(fs.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "alfredctl:", err)
+		if errors.Is(err, errUsage) {
+			usage(os.Stderr)
+		}
+		os.Exit(1)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage: alfredctl [--db path | --url url --token token] [--json] <command> [args]
+
+Commands:
+  users                                  list users
+  reset-onboarding <user>                purge a user's data and restart onboarding
+  revoke-tokens <user>                   delete a user's sessions and API keys
+  backfill [-days n] <user>              re-analyze a user's recent history (--url only)
+  migrate up|status|verify|down [-to n]  manage the schema (--db only)
+  export [-o file] <user>                write a user's data export ZIP
+
+A <user> is a user ID or email address. Flags default to ALFRED_DB_PATH, ALFRED_URL
+and ALFRED_TOKEN.
+`)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// cli holds the global flags shared by every command
+type cli struct {
+	dbPath string
+	url    string
+	token  string
+	json   bool
+	out    io.Writer
+}
+
+func (c *cli) run(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	cmd, args := args[0], args[1:]
+
+	if cmd == "migrate" {
+		return c.migrate(args)
+	}
+
+	b, err := c.backend()
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	switch cmd {
+	case "users":
+		return c.users(b)
+	case "reset-onboarding":
+		return c.resetOnboarding(b, args)
+	case "revoke-tokens":
+		return c.revokeTokens(b, args)
+	case "backfill":
+		return c.backfill(b, args)
+	case "export":
+		return c.export(b, args)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, cmd)
+	}
+}
+
+func (c *cli) backend() (backend, error) {
+	if c.url != "" {
+		if c.token == "" {
+			return nil, fmt.Errorf("--url requires --token (or ALFRED_TOKEN)")
+		}
+		return newRemoteBackend(c.url, c.token), nil
+	}
+	return openLocalBackend(c.dbPath)
+}
+
+func (c *cli) users(b backend) error {
+	users, err := b.ListUsers()
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(users)
+	}
+
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tEMAIL\tNAME\tONBOARDED\tSESSIONS\tAPI KEYS\tCHANNELS\tLAST LOGIN")
+	for _, u := range users {
+		lastLogin := "never"
+		if u.LastLoginAt != nil {
+			lastLogin = u.LastLoginAt.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%t\t%d\t%d\t%d\t%s\n",
+			u.ID, u.Email, u.Name, u.OnboardingComplete, u.Sessions, u.APIKeys, u.Channels, lastLogin)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) resetOnboarding(b backend, args []string) error {
+	userID, err := c.userArg(b, args)
+	if err != nil {
+		return err
+	}
+	if err := b.ResetOnboarding(userID); err != nil {
+		return err
+	}
+	return c.report(map[string]any{"user_id": userID, "onboarding_complete": false},
+		"Reset onboarding for user %d", userID)
+}
+
+func (c *cli) revokeTokens(b backend, args []string) error {
+	userID, err := c.userArg(b, args)
+	if err != nil {
+		return err
+	}
+	sessions, apiKeys, err := b.RevokeTokens(userID)
+	if err != nil {
+		return err
+	}
+	return c.report(map[string]any{"user_id": userID, "sessions": sessions, "api_keys": apiKeys},
+		"Revoked %d sessions and %d API keys of user %d", sessions, apiKeys, userID)
+}
+
+func (c *cli) backfill(b backend, args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	days := fs.Int("days", 0, "days of chat history to re-analyze (1-90, default the server's window)")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	userID, err := c.userArg(b, fs.Args())
+	if err != nil {
+		return err
+	}
+	channels, emailSources, err := b.Backfill(userID, *days)
+	if err != nil {
+		return err
+	}
+	return c.report(map[string]any{"user_id": userID, "channels": channels, "email_sources": emailSources},
+		"Started backfill of %d channels and %d email sources for user %d", channels, emailSources, userID)
+}
+
+func (c *cli) export(b backend, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := fs.String("o", "", "output file (default alfred-export-user-<id>.zip)")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	userID, err := c.userArg(b, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	archive, err := b.Export(userID)
+	if err != nil {
+		return err
+	}
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("alfred-export-user-%d.zip", userID)
+	}
+	if err := os.WriteFile(path, archive, 0o600); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return c.report(map[string]any{"user_id": userID, "path": path, "size_bytes": len(archive)},
+		"Wrote %s (%d bytes)", path, len(archive))
+}
+
+// userArg resolves the single <user> argument, a user ID or an email address
+func (c *cli) userArg(b backend, args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%w: expected one user ID or email", errUsage)
+	}
+	if id, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+		return id, nil
+	}
+
+	users, err := b.ListUsers()
+	if err != nil {
+		return 0, err
+	}
+	for _, u := range users {
+		if strings.EqualFold(u.Email, args[0]) {
+			return u.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("no user with email %s", args[0])
+}
+
+// report prints result as JSON with --json, otherwise the formatted message
+func (c *cli) report(result any, format string, args ...any) error {
+	if c.json {
+		return c.printJSON(result)
+	}
+	_, err := fmt.Fprintf(c.out, format+"\n", args...)
+	return err
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCLI(t *testing.T) (*cli, *bytes.Buffer, *database.DB) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "alfred.db")
+	db, err := database.New(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	var out bytes.Buffer
+	return &cli{dbPath: path, json: true, out: &out}, &out, db
+}
+
+func TestUsersAndRevokeTokensByEmail(t *testing.T) {
+	c, out, db := newTestCLI(t)
+	user := database.CreateTestUserWithEmail(t, db, "someone@example.com")
+
+	require.NoError(t, c.run([]string{"users"}))
+	var users []database.AdminUser
+	require.NoError(t, json.Unmarshal(out.Bytes(), &users))
+	require.Len(t, users, 1)
+	assert.Equal(t, user.ID, users[0].ID)
+
+	out.Reset()
+	require.NoError(t, c.run([]string{"revoke-tokens", "Someone@Example.com"}))
+	var result map[string]int64
+	require.NoError(t, json.Unmarshal(out.Bytes(), &result))
+	assert.Equal(t, user.ID, result["user_id"])
+
+	assert.Error(t, c.run([]string{"revoke-tokens", "nobody@example.com"}))
+	assert.ErrorIs(t, c.run([]string{"revoke-tokens"}), errUsage)
+}
+
+func TestBackfillNeedsServer(t *testing.T) {
+	c, _, db := newTestCLI(t)
+	user := database.CreateTestUser(t, db)
+
+	err := c.run([]string{"backfill", "-days", "7", user.Email})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--url")
+}
+
+func TestMigrate(t *testing.T) {
+	c, out, _ := newTestCLI(t)
+
+	require.NoError(t, c.run([]string{"migrate", "verify"}))
+	assert.Contains(t, out.String(), "up to date")
+
+	assert.Error(t, c.run([]string{"migrate", "down"}), "down needs -to")
+
+	c.url = "http://localhost:8080"
+	assert.Error(t, c.run([]string{"migrate", "status"}))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// remoteBackend calls a running server's /api/admin endpoints
+type remoteBackend struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newRemoteBackend(baseURL, token string) *remoteBackend {
+	return &remoteBackend{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (b *remoteBackend) ListUsers() ([]database.AdminUser, error) {
+	var users []database.AdminUser
+	err := b.call(http.MethodGet, "/api/admin/users", nil, &users)
+	return users, err
+}
+
+func (b *remoteBackend) ResetOnboarding(userID int64) error {
+	return b.call(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/reset-onboarding", userID), nil, nil)
+}
+
+func (b *remoteBackend) RevokeTokens(userID int64) (int64, int64, error) {
+	var result struct {
+		Sessions int64 `json:"sessions"`
+		APIKeys  int64 `json:"api_keys"`
+	}
+	err := b.call(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/revoke-tokens", userID), nil, &result)
+	return result.Sessions, result.APIKeys, err
+}
+
+func (b *remoteBackend) Backfill(userID int64, days int) (int, int, error) {
+	var result struct {
+		Channels     int `json:"channels"`
+		EmailSources int `json:"email_sources"`
+	}
+	body := map[string]int{"days": days}
+	err := b.call(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/backfill", userID), body, &result)
+	return result.Channels, result.EmailSources, err
+}
+
+func (b *remoteBackend) Export(userID int64) ([]byte, error) {
+	resp, err := b.do(http.MethodGet, fmt.Sprintf("/api/admin/users/%d/export", userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	archive, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export: %w", err)
+	}
+	return archive, nil
+}
+
+func (b *remoteBackend) Close() error {
+	return nil
+}
+
+// call sends body as JSON and decodes a successful response into out, if not nil
+func (b *remoteBackend) call(method, path string, body, out any) error {
+	resp, err := b.do(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// do sends the request and turns a non-2xx response into an error carrying the
+// server's message
+func (b *remoteBackend) do(method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, b.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	req.Header.Set("X-Error-Format", "envelope")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var apiErr struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error.Message != "" {
+		return nil, fmt.Errorf("%s %s: %s (%d %s)", method, path, apiErr.Error.Message, resp.StatusCode, apiErr.Error.Code)
+	}
+	return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+}
@@ -0,0 +1,99 @@
+// Command loadgen pushes synthetic messages through the message processor and reports
+// throughput, queue latency and database contention. Use it to size
+// ALFRED_PROCESSOR_WORKERS before raising it in production.
+//
+// Analysis uses the deterministic mock analyzers, delayed by -delay to stand in for the
+// LLM call, so runs cost nothing and need no API key. Everything else (intake, durable
+// queue, workers, history, event and reminder persistence) is the real code on a
+// SQLite file.
+//
+// Usage:
+//
+//	go run ./cmd/loadgen [-messages n] [-workers n] [-users n] [-channels n] [-rate n] [-delay d] [-batch-window d] [-db path] [-json]
+//
+// Example: compare worker counts for 5000 messages at 1.5s per LLM call
+//
+//	for w in 2 4 8; do go run ./cmd/loadgen -messages 5000 -workers $w -delay 1.5s; done
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/loadgen"
+)
+
+func main() {
+	cfg := loadgen.DefaultConfig()
+	flag.IntVar(&cfg.Messages, "messages", cfg.Messages, "messages to inject")
+	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "processor workers")
+	flag.IntVar(&cfg.Users, "users", cfg.Users, "users to spread messages over")
+	flag.IntVar(&cfg.ChannelsPerUser, "channels", cfg.ChannelsPerUser, "tracked contacts per user")
+	flag.IntVar(&cfg.Rate, "rate", 0, "messages per second to inject (0 = as fast as possible)")
+	flag.DurationVar(&cfg.AnalyzerDelay, "delay", cfg.AnalyzerDelay, "simulated LLM latency per analyzer call")
+	flag.DurationVar(&cfg.BatchWindow, "batch-window", 0, "burst batching window (0 = analyze each message)")
+	flag.DurationVar(&cfg.Timeout, "timeout", 10*time.Minute, "give up if the queue hasn't drained by then")
+	dbPath := flag.String("db", "", "SQLite file to use (default: a temporary file)")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	verbose := flag.Bool("v", false, "show processor logs")
+	flag.Parse()
+
+	if !*verbose {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+
+	if err := run(cfg, *dbPath, *asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg loadgen.Config, dbPath string, asJSON bool) error {
+	if dbPath == "" {
+		dir, err := os.MkdirTemp("", "alfred-loadgen-")
+		if err != nil {
+			return fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		defer os.RemoveAll(dir)
+		dbPath = filepath.Join(dir, "loadgen.db")
+	}
+
+	db, err := database.New(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !asJSON {
+		fmt.Fprintf(os.Stderr, "Injecting %d messages over %d users x %d channels with %d workers...\n",
+			cfg.Messages, cfg.Users, cfg.ChannelsPerUser, cfg.Workers)
+	}
+	report, err := loadgen.Run(ctx, db, cfg)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	report.WriteText(os.Stdout)
+	if report.TimedOut {
+		return fmt.Errorf("queue did not drain: %d of %d messages analyzed", report.Analyzed, report.Injected)
+	}
+	return nil
+}
@@ -5,6 +5,10 @@
 // Usage:
 //
 //	ANTHROPIC_API_KEY=sk-... go run cmd/testserver/main.go
+//	go run cmd/testserver/main.go --mock-llm [--mock-llm-rules rules.json]
+//
+// With --mock-llm, events and reminders come from deterministic keyword rules
+// (internal/agent/mockagent) instead of the LLM API, so suites run without API costs.
 //
 // The server exposes additional test control endpoints:
 //   - POST /api/test/reset - Reset all data
@@ -14,6 +18,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
@@ -23,6 +28,7 @@ import (
 
 	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/agent/event"
+	"github.com/omriShneor/project_alfred/internal/agent/mockagent"
 	"github.com/omriShneor/project_alfred/internal/config"
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/notify"
@@ -30,18 +36,31 @@ import (
 	"github.com/omriShneor/project_alfred/internal/server"
 	"github.com/omriShneor/project_alfred/internal/source"
 	"github.com/omriShneor/project_alfred/internal/sse"
+	"github.com/omriShneor/project_alfred/internal/webhook"
 )
 
 func main() {
+	mockLLM := flag.Bool("mock-llm", false, "use deterministic keyword rules instead of the LLM API")
+	mockRules := flag.String("mock-llm-rules", "", "JSON file of mock rules (default: built-in rules)")
+	flag.Parse()
+
 	fmt.Println("Starting Project Alfred Test Server...")
-	fmt.Println("This server uses in-memory SQLite and real Claude API for E2E testing.")
+	if *mockLLM {
+		fmt.Println("This server uses in-memory SQLite and mock analyzers for E2E testing.")
+	} else {
+		fmt.Println("This server uses in-memory SQLite and real Claude API for E2E testing.")
+	}
 
 	// Load config
-	cfg := config.LoadFromEnv()
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Check for required env vars
-	if cfg.AnthropicAPIKey == "" {
-		fmt.Println("Warning: ANTHROPIC_API_KEY not set. Event detection will not work.")
+	if apiKey, _, keyEnv := cfg.LLMCredentials(); apiKey == "" && !*mockLLM {
+		fmt.Printf("Warning: %s not set. Event detection will not work.\n", keyEnv)
 	}
 
 	// Create in-memory database
@@ -54,25 +73,49 @@ func main() {
 
 	fmt.Println("In-memory database initialized")
 
-	// Create SSE state for onboarding
+	// Create SSE state for onboarding and the per-user stream bus
 	state := sse.NewState()
+	streams := sse.NewStateManager()
 
 	// Create notify service (with push notifier)
 	pushNotifier := notify.NewExpoPushNotifier()
 	notifyService := notify.NewService(db, nil, pushNotifier)
+	notifyService.SetStreams(streams)
+	webhooks := webhook.NewDispatcher(db)
+	notifyService.SetWebhooks(webhooks)
 	notifyCtx, stopNotifyWorker := context.WithCancel(context.Background())
 	notifyService.StartDueReminderWorker(notifyCtx, time.Minute)
+	notifyService.StartEventNotificationWorker(notifyCtx, time.Minute)
+	webhooks.Start(notifyCtx, 30*time.Second)
 	fmt.Println("Push notification service configured")
 
-	// Create event analyzer (uses real Claude API if ANTHROPIC_API_KEY is set)
+	// Create analyzers: mock rules with --mock-llm, else the real LLM API if the
+	// provider's key is set
 	var eventAnalyzer agent.EventAnalyzer
-	if cfg.AnthropicAPIKey != "" {
+	var reminderAnalyzer agent.ReminderAnalyzer
+	if *mockLLM {
+		rules := mockagent.DefaultRules()
+		if *mockRules != "" {
+			rules, err = mockagent.LoadRules(*mockRules)
+			if err != nil {
+				fmt.Printf("Failed to load mock rules: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		eventAnalyzer = mockagent.NewEventAnalyzer(rules)
+		reminderAnalyzer = mockagent.NewReminderAnalyzer(rules)
+		fmt.Printf("Mock analyzers configured with %d rules\n", len(rules))
+	} else if apiKey, model, _ := cfg.LLMCredentials(); apiKey != "" {
 		eventAnalyzer = event.NewAgent(event.Config{
-			APIKey:      cfg.AnthropicAPIKey,
-			Model:       cfg.ClaudeModel,
+			Provider:    cfg.LLMProvider,
+			APIKey:      apiKey,
+			Model:       model,
+			FastModel:   cfg.LLMFastModel(),
 			Temperature: cfg.ClaudeTemperature,
+			Calendar:    db,
+			Contacts:    db,
 		})
-		fmt.Println("Claude API configured for event detection")
+		fmt.Printf("%s API configured for event detection\n", cfg.LLMProvider)
 	}
 
 	// Create message channel for processor
@@ -81,7 +124,7 @@ func main() {
 	// Create message processor
 	var messageProcessor *processor.Processor
 	if eventAnalyzer != nil {
-		messageProcessor = processor.New(db, eventAnalyzer, nil, msgChan, cfg.MessageHistorySize, notifyService)
+		messageProcessor = processor.New(db, eventAnalyzer, reminderAnalyzer, msgChan, cfg.MessageHistorySize, notifyService)
 		if err := messageProcessor.Start(); err != nil {
 			fmt.Printf("Warning: processor failed to start: %v\n", err)
 		} else {
@@ -93,14 +136,16 @@ func main() {
 	serverCfg := server.ServerConfig{
 		DB:              db,
 		OnboardingState: state,
+		Streams:         streams,
 		Port:            cfg.HTTPPort,
 	}
 	srv := server.New(serverCfg)
 
 	// Initialize clients with mock services
 	clientsCfg := server.ClientsConfig{
-		NotifyService: notifyService,
-		EventAnalyzer: eventAnalyzer,
+		NotifyService:    notifyService,
+		EventAnalyzer:    eventAnalyzer,
+		ReminderAnalyzer: reminderAnalyzer,
 	}
 	srv.InitializeClients(clientsCfg)
 
@@ -134,7 +179,7 @@ func main() {
 		}
 
 		if messageProcessor == nil {
-			http.Error(w, "Message processor not configured (missing ANTHROPIC_API_KEY)", http.StatusServiceUnavailable)
+			http.Error(w, "Message processor not configured (set ANTHROPIC_API_KEY or use --mock-llm)", http.StatusServiceUnavailable)
 			return
 		}
 
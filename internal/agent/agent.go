@@ -3,12 +3,54 @@ package agent
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
 )
 
+// LLM providers selectable via AgentConfig.Provider
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderOpenAI    = "openai"
+)
+
+// LLMClient is a chat API capable of tool calling. Conversations are always
+// expressed in the Anthropic message format; other providers translate.
+type LLMClient interface {
+	Call(ctx context.Context, messages []Message, opts CallOptions) (*APIResponse, error)
+	IsConfigured() bool
+}
+
+// NewLLMClient creates the client for the given provider ("" means Anthropic)
+func NewLLMClient(provider, apiKey, model string, temperature float64) (LLMClient, error) {
+	switch provider {
+	case "", ProviderAnthropic:
+		return NewAPIClient(apiKey, model, temperature), nil
+	case ProviderOpenAI:
+		return NewOpenAIClient(apiKey, model, temperature), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %q", provider)
+	}
+}
+
+// ProviderURL returns the chat API endpoint of provider ("" means Anthropic), for
+// reachability checks
+func ProviderURL(provider string) (string, error) {
+	switch provider {
+	case "", ProviderAnthropic:
+		return defaultAPIURL, nil
+	case ProviderOpenAI:
+		return defaultOpenAIURL, nil
+	default:
+		return "", fmt.Errorf("unknown LLM provider: %q", provider)
+	}
+}
+
 // Agent represents an LLM-powered agent with tools
 type Agent struct {
 	name         string
-	apiClient    *APIClient
+	apiClient    LLMClient
+	fastModel    string
 	registry     *ToolRegistry
 	systemPrompt string
 }
@@ -16,17 +58,35 @@ type Agent struct {
 // AgentConfig configures an agent
 type AgentConfig struct {
 	Name         string
+	Provider     string // "anthropic" (default) or "openai"
 	APIKey       string
 	Model        string
+	FastModel    string // used for users on the "fast" tier; empty = Model
 	Temperature  float64
 	SystemPrompt string
+	// Transport replaces the HTTP transport of the LLM client, e.g. a Recorder in tests
+	Transport http.RoundTripper
+}
+
+// transportSetter is implemented by LLM clients whose HTTP transport can be replaced
+type transportSetter interface {
+	setTransport(rt http.RoundTripper)
 }
 
 // NewAgent creates a new agent with the given configuration
+// An unknown provider yields an agent that reports itself as not configured.
 func NewAgent(cfg AgentConfig) *Agent {
+	client, err := NewLLMClient(cfg.Provider, cfg.APIKey, cfg.Model, cfg.Temperature)
+	if err != nil {
+		slog.Error("Agent: failed to create LLM client", "name", cfg.Name, "error", err)
+	}
+	if setter, ok := client.(transportSetter); ok && cfg.Transport != nil {
+		setter.setTransport(cfg.Transport)
+	}
 	return &Agent{
 		name:         cfg.Name,
-		apiClient:    NewAPIClient(cfg.APIKey, cfg.Model, cfg.Temperature),
+		apiClient:    client,
+		fastModel:    cfg.FastModel,
 		registry:     NewToolRegistry(),
 		systemPrompt: cfg.SystemPrompt,
 	}
@@ -57,7 +117,11 @@ func (a *Agent) Execute(ctx context.Context, input AgentInput) (*AgentOutput, er
 	return a.executeWithPrompt(ctx, input, a.systemPrompt)
 }
 
-func (a *Agent) executeWithPrompt(ctx context.Context, input AgentInput, systemPrompt string) (*AgentOutput, error) {
+func (a *Agent) executeWithPrompt(ctx context.Context, input AgentInput, systemPrompt string) (_ *AgentOutput, err error) {
+	if a.apiClient == nil {
+		return nil, fmt.Errorf("agent %s has no LLM client configured", a.name)
+	}
+
 	maxTurns := input.MaxTurns
 	if maxTurns <= 0 {
 		maxTurns = 1 // Default to single-shot
@@ -66,17 +130,43 @@ func (a *Agent) executeWithPrompt(ctx context.Context, input AgentInput, systemP
 	messages := make([]Message, len(input.Messages))
 	copy(messages, input.Messages)
 
+	callOpts := CallOptions{
+		System: systemPrompt,
+		Tools:  a.registry.Tools(),
+	}
+	if settings, ok := ModelSettingsFromContext(ctx); ok {
+		if settings.Tier == ModelTierFast {
+			callOpts.Model = a.fastModel
+		}
+		callOpts.Temperature = settings.Temperature
+	}
+
 	var totalUsage UsageStats
 	var allToolCalls []ToolCall
+	model := callOpts.Model
+	start := time.Now()
+	defer func() {
+		if totalUsage.TotalTokens > 0 {
+			ReportUsage(ctx, UsageRecord{Agent: a.name, Model: model, Usage: totalUsage})
+		}
+		if logRun := runLogger(ctx); logRun != nil {
+			logRun(RunLog{
+				Agent:     a.name,
+				Model:     model,
+				InputHash: inputHash(systemPrompt, input.Messages),
+				ToolCalls: allToolCalls,
+				Usage:     totalUsage,
+				Latency:   time.Since(start),
+				Err:       err,
+			})
+		}
+	}()
 	turnsUsed := 0
 	lastStopReason := ""
 
 	for turn := 0; turn < maxTurns; turn++ {
 		// Make API call
-		response, err := a.apiClient.Call(ctx, messages, CallOptions{
-			System: systemPrompt,
-			Tools:  a.registry.Tools(),
-		})
+		response, err := a.apiClient.Call(ctx, messages, callOpts)
 		if err != nil {
 			a.logExecutionSummary("failure", turn+1, maxTurns, len(allToolCalls), totalUsage, "api_error", err)
 			return nil, fmt.Errorf("API call failed on turn %d: %w", turn+1, err)
@@ -84,6 +174,7 @@ func (a *Agent) executeWithPrompt(ctx context.Context, input AgentInput, systemP
 		turnsUsed = turn + 1
 		lastStopReason = response.StopReason
 		totalUsage.Add(response.Usage)
+		model = response.Model
 
 		// Check stop reason
 		switch response.StopReason {
@@ -123,7 +214,7 @@ func (a *Agent) executeWithPrompt(ctx context.Context, input AgentInput, systemP
 		Conversation: messages,
 		Usage:        totalUsage,
 	}
-	err := fmt.Errorf("max turns (%d) exceeded", maxTurns)
+	err = fmt.Errorf("max turns (%d) exceeded", maxTurns)
 	stopReason := lastStopReason
 	if stopReason == "" {
 		stopReason = "max_turns_exceeded"
@@ -142,34 +233,11 @@ func (a *Agent) logExecutionSummary(
 	err error,
 ) {
 	if err != nil {
-		fmt.Printf(
-			"Agent run: name=%s status=%s turns=%d/%d tool_calls=%d stop_reason=%s tokens_in=%d tokens_out=%d tokens_total=%d error=%v\n",
-			a.name,
-			status,
-			turnsUsed,
-			maxTurns,
-			toolCalls,
-			stopReason,
-			usage.InputTokens,
-			usage.OutputTokens,
-			usage.TotalTokens,
-			err,
-		)
+		slog.Error("Agent run", "name", a.name, "status", status, "turns", turnsUsed, "max_turns", maxTurns, "tool_calls", toolCalls, "stop_reason", stopReason, "tokens_in", usage.InputTokens, "tokens_out", usage.OutputTokens, "tokens_total", usage.TotalTokens, "error", err)
 		return
 	}
 
-	fmt.Printf(
-		"Agent run: name=%s status=%s turns=%d/%d tool_calls=%d stop_reason=%s tokens_in=%d tokens_out=%d tokens_total=%d\n",
-		a.name,
-		status,
-		turnsUsed,
-		maxTurns,
-		toolCalls,
-		stopReason,
-		usage.InputTokens,
-		usage.OutputTokens,
-		usage.TotalTokens,
-	)
+	slog.Info("Agent run", "name", a.name, "status", status, "turns", turnsUsed, "max_turns", maxTurns, "tool_calls", toolCalls, "stop_reason", stopReason, "tokens_in", usage.InputTokens, "tokens_out", usage.OutputTokens, "tokens_total", usage.TotalTokens)
 }
 
 // executeTools runs all tool_use blocks and returns results
@@ -32,7 +32,11 @@ type EmailContent struct {
 	To            string
 	Date          string
 	Body          string
+	ThreadID      string // Gmail thread ID, "" if unknown
 	ThreadHistory []EmailThreadMessage
+	// ThreadEvents are the live events already detected in earlier messages of the
+	// thread, so a reply ("moved to 4pm") updates them instead of creating new ones
+	ThreadEvents []database.CalendarEvent
 }
 
 // EmailThreadMessage represents a message in thread history
@@ -9,7 +9,6 @@ import (
 	"io"
 	"net/http"
 	"strings"
-	"time"
 )
 
 const (
@@ -46,12 +45,14 @@ func NewAPIClient(apiKey, model string, temperature float64) *APIClient {
 		model:       model,
 		apiURL:      defaultAPIURL,
 		temperature: temperature,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second, // Longer timeout for tool use
-		},
+		httpClient:  &http.Client{}, // Each attempt is bounded by the provider's retry policy
 	}
 }
 
+func (c *APIClient) setTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
 // apiRequest represents the Anthropic API request with tools
 type apiRequest struct {
 	Model       string           `json:"model"`
@@ -112,6 +113,7 @@ type APIResponse struct {
 	Content    []ContentBlock
 	StopReason string
 	Usage      UsageStats
+	Model      string // model that served the request
 }
 
 // CallOptions configures an API call
@@ -120,6 +122,23 @@ type CallOptions struct {
 	Tools      []Tool
 	ToolChoice string // "auto", "any", or specific tool name
 	MaxTokens  int
+	// Model and Temperature override the client defaults when set
+	Model       string
+	Temperature *float64
+}
+
+func (o CallOptions) model(fallback string) string {
+	if o.Model != "" {
+		return o.Model
+	}
+	return fallback
+}
+
+func (o CallOptions) temperature(fallback float64) float64 {
+	if o.Temperature != nil {
+		return *o.Temperature
+	}
+	return fallback
 }
 
 // Call makes a request to the Anthropic API
@@ -152,9 +171,9 @@ func (c *APIClient) Call(ctx context.Context, messages []Message, opts CallOptio
 	}
 
 	req := apiRequest{
-		Model:       c.model,
+		Model:       opts.model(c.model),
 		MaxTokens:   maxTokens,
-		Temperature: c.temperature,
+		Temperature: opts.temperature(c.temperature),
 		System:      opts.System,
 		Tools:       apiTools,
 		Messages:    apiMessages,
@@ -176,31 +195,37 @@ func (c *APIClient) Call(ctx context.Context, messages []Message, opts CallOptio
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewReader(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	body, err := sendWithRetry(ctx, ProviderAnthropic, func(ctx context.Context) ([]byte, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", c.apiKey)
-	httpReq.Header.Set("anthropic-version", anthropicVersion)
-	if len(opts.Tools) > 0 {
-		httpReq.Header.Set("anthropic-beta", anthropicBetaHeader)
-	}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("x-api-key", c.apiKey)
+		httpReq.Header.Set("anthropic-version", anthropicVersion)
+		if len(opts.Tools) > 0 {
+			httpReq.Header.Set("anthropic-beta", anthropicBetaHeader)
+		}
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, formatAPIError(resp.StatusCode, body)
+		if resp.StatusCode != http.StatusOK {
+			return nil, newAPIStatusError(resp, formatAPIError(resp.StatusCode, body))
+		}
+		return body, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	var apiResp apiResponse
@@ -231,6 +256,7 @@ func (c *APIClient) Call(ctx context.Context, messages []Message, opts CallOptio
 	return &APIResponse{
 		Content:    content,
 		StopReason: apiResp.StopReason,
+		Model:      req.Model,
 		Usage: UsageStats{
 			InputTokens:  apiResp.Usage.InputTokens,
 			OutputTokens: apiResp.Usage.OutputTokens,
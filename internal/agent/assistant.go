@@ -0,0 +1,24 @@
+package agent
+
+import "context"
+
+// Assistant answers the user's questions about their schedule and reminders
+type Assistant interface {
+	// Ask answers question, given the earlier turns of the conversation (oldest first)
+	Ask(ctx context.Context, question string, history []ChatTurn) (*AssistantReply, error)
+
+	// IsConfigured returns true if the assistant is properly configured
+	IsConfigured() bool
+}
+
+// ChatTurn is one message of an assistant conversation
+type ChatTurn struct {
+	Role    string `json:"role"` // "user" or "assistant"
+	Content string `json:"content"`
+}
+
+// AssistantReply is the assistant's answer to a question
+type AssistantReply struct {
+	Answer    string   `json:"answer"`
+	ToolsUsed []string `json:"tools_used"` // lookups the answer is based on, in call order
+}
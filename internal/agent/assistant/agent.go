@@ -0,0 +1,143 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/tools"
+)
+
+// Agent answers questions about the user's schedule and reminders using read-only tools
+type Agent struct {
+	*agent.Agent
+}
+
+// Config configures the assistant. Each lookup tool is registered only when its store is set.
+type Config struct {
+	Provider    string // "anthropic" (default) or "openai"
+	APIKey      string
+	Model       string
+	FastModel   string // model for users on the "fast" tier
+	Temperature float64
+	// Calendar enables the list_calendar_events tool when set
+	Calendar tools.CalendarEventLister
+	// GoogleCalendar enables the list_google_calendar_events tool when set
+	GoogleCalendar tools.GoogleCalendarLister
+	// Reminders enables the list_reminders tool when set
+	Reminders tools.ReminderLister
+	// History enables the search_history tool when set
+	History tools.HistorySearcher
+	// Contacts enables the lookup_contact tool when set
+	Contacts tools.ContactSearcher
+}
+
+// NewAgent creates a new assistant agent
+func NewAgent(cfg Config) *Agent {
+	baseAgent := agent.NewAgent(agent.AgentConfig{
+		Name:         "assistant",
+		Provider:     cfg.Provider,
+		APIKey:       cfg.APIKey,
+		Model:        cfg.Model,
+		FastModel:    cfg.FastModel,
+		Temperature:  cfg.Temperature,
+		SystemPrompt: AssistantSystemPrompt,
+	})
+
+	baseAgent.MustRegisterTool(tools.GetCurrentDateTimeTool, tools.HandleGetCurrentDateTime)
+	if cfg.Calendar != nil {
+		baseAgent.MustRegisterTool(tools.ListCalendarEventsTool, tools.NewListCalendarEventsHandler(cfg.Calendar))
+	}
+	if cfg.GoogleCalendar != nil {
+		baseAgent.MustRegisterTool(tools.ListGoogleCalendarEventsTool, tools.NewListGoogleCalendarEventsHandler(cfg.GoogleCalendar))
+	}
+	if cfg.Reminders != nil {
+		baseAgent.MustRegisterTool(tools.ListRemindersTool, tools.NewListRemindersHandler(cfg.Reminders))
+	}
+	if cfg.History != nil {
+		baseAgent.MustRegisterTool(tools.SearchHistoryTool, tools.NewSearchHistoryHandler(cfg.History))
+	}
+	if cfg.Contacts != nil {
+		baseAgent.MustRegisterTool(tools.LookupContactTool, tools.NewLookupContactHandler(cfg.Contacts))
+	}
+
+	return &Agent{Agent: baseAgent}
+}
+
+// Ask answers a question about the user's schedule. The user must be attached to ctx
+// (agent.WithUserID) for the lookup tools to work.
+// Implements agent.Assistant interface
+func (a *Agent) Ask(ctx context.Context, question string, history []agent.ChatTurn) (*agent.AssistantReply, error) {
+	input := agent.AgentInput{
+		Messages: buildConversation(history, question),
+		MaxTurns: 8, // Allow datetime lookup + several searches + the answer
+	}
+
+	output, err := a.Execute(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("agent execution failed: %w", err)
+	}
+
+	return parseAgentOutput(output)
+}
+
+// IsConfigured returns true if the agent is properly configured
+func (a *Agent) IsConfigured() bool {
+	return a.Agent.IsConfigured()
+}
+
+// buildConversation turns the earlier turns and the new question into LLM messages.
+// The conversation must start with the user and alternate roles, so leading assistant
+// turns are dropped and consecutive turns from the same role are joined.
+func buildConversation(history []agent.ChatTurn, question string) []agent.Message {
+	turns := append(append([]agent.ChatTurn{}, history...), agent.ChatTurn{Role: "user", Content: question})
+
+	var messages []agent.Message
+	var texts []string
+	role := ""
+	flush := func() {
+		if len(texts) > 0 {
+			messages = append(messages, agent.Message{
+				Role:    role,
+				Content: []agent.ContentBlock{agent.TextBlock{Type: "text", Text: strings.Join(texts, "\n\n")}},
+			})
+		}
+		texts = nil
+	}
+	for _, turn := range turns {
+		content := strings.TrimSpace(turn.Content)
+		if content == "" || (turn.Role != "user" && turn.Role != "assistant") {
+			continue
+		}
+		if role == "" && turn.Role != "user" {
+			continue
+		}
+		if turn.Role != role {
+			flush()
+			role = turn.Role
+		}
+		texts = append(texts, content)
+	}
+	flush()
+
+	return messages
+}
+
+// parseAgentOutput converts agent output to an AssistantReply
+func parseAgentOutput(output *agent.AgentOutput) (*agent.AssistantReply, error) {
+	answer := strings.TrimSpace(output.FinalText)
+	if answer == "" {
+		return nil, fmt.Errorf("assistant returned no answer")
+	}
+
+	reply := &agent.AssistantReply{Answer: answer, ToolsUsed: []string{}}
+	seen := make(map[string]bool)
+	for _, call := range output.ToolCalls {
+		if !seen[call.Name] {
+			seen[call.Name] = true
+			reply.ToolsUsed = append(reply.ToolsUsed, call.Name)
+		}
+	}
+	return reply, nil
+}
@@ -0,0 +1,51 @@
+package assistant
+
+import (
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func messageText(t *testing.T, msg agent.Message) string {
+	t.Helper()
+	require.Len(t, msg.Content, 1)
+	block, ok := msg.Content[0].(agent.TextBlock)
+	require.True(t, ok)
+	return block.Text
+}
+
+func TestBuildConversation(t *testing.T) {
+	messages := buildConversation([]agent.ChatTurn{
+		{Role: "assistant", Content: "Hi! How can I help?"},
+		{Role: "user", Content: "What's on Thursday?"},
+		{Role: "assistant", Content: "Dinner at Luigi's at 8pm."},
+		{Role: "user", Content: "  "},
+		{Role: "user", Content: "And Friday?"},
+	}, "Also, when is the dentist?")
+
+	require.Len(t, messages, 3)
+	assert.Equal(t, "user", messages[0].Role)
+	assert.Equal(t, "What's on Thursday?", messageText(t, messages[0]))
+	assert.Equal(t, "assistant", messages[1].Role)
+	assert.Equal(t, "user", messages[2].Role)
+	assert.Equal(t, "And Friday?\n\nAlso, when is the dentist?", messageText(t, messages[2]))
+}
+
+func TestParseAgentOutput(t *testing.T) {
+	reply, err := parseAgentOutput(&agent.AgentOutput{
+		FinalText: " Dinner with Dana on Thursday at 8pm. ",
+		ToolCalls: []agent.ToolCall{
+			{Name: "get_current_datetime"},
+			{Name: "list_calendar_events"},
+			{Name: "get_current_datetime"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Dinner with Dana on Thursday at 8pm.", reply.Answer)
+	assert.Equal(t, []string{"get_current_datetime", "list_calendar_events"}, reply.ToolsUsed)
+
+	_, err = parseAgentOutput(&agent.AgentOutput{})
+	assert.Error(t, err)
+}
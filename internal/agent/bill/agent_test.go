@@ -0,0 +1,62 @@
+package bill
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgentOutput_CreateBill(t *testing.T) {
+	toolOutput, err := tools.HandleCreateBillReminder(context.Background(), map[string]any{
+		"payee":      "Bezeq",
+		"amount":     99.9,
+		"currency":   "ILS",
+		"due_date":   "2030-04-01T09:00:00",
+		"confidence": 0.88,
+		"reasoning":  "Monthly phone bill",
+	})
+	require.NoError(t, err)
+
+	result, err := parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "get_current_datetime", Output: `{}`},
+		{Name: "create_bill_reminder", Output: toolOutput},
+	}})
+	require.NoError(t, err)
+
+	assert.True(t, result.HasBill)
+	assert.Equal(t, "create", result.Action)
+	assert.Equal(t, 0.88, result.Confidence)
+	assert.Equal(t, "Monthly phone bill", result.Reasoning)
+	require.NotNil(t, result.Bill)
+	assert.Equal(t, "Bezeq", result.Bill.Payee)
+	require.NotNil(t, result.Bill.Amount)
+	assert.Equal(t, 99.9, *result.Bill.Amount)
+}
+
+func TestParseAgentOutput_NoAction(t *testing.T) {
+	result, err := parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "no_bill_action", Output: `{"status":"success","action":"none","reasoning":"Payment receipt","confidence":0.8}`},
+	}})
+	require.NoError(t, err)
+	assert.False(t, result.HasBill)
+	assert.Equal(t, "none", result.Action)
+	assert.Equal(t, "Payment receipt", result.Reasoning)
+}
+
+func TestParseAgentOutput_AmbiguousOrMissingAction(t *testing.T) {
+	result, err := parseAgentOutput(&agent.AgentOutput{})
+	require.NoError(t, err)
+	assert.Equal(t, "none", result.Action)
+
+	result, err = parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "no_bill_action", Output: `{"action":"none"}`},
+		{Name: "create_bill_reminder", Output: `{"action":"create"}`},
+	}})
+	require.NoError(t, err)
+	assert.False(t, result.HasBill)
+	assert.Contains(t, result.Reasoning, "multiple action tools")
+}
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// BillAnalyzer is the interface for bill and payment-due detection
+type BillAnalyzer interface {
+	// AnalyzeMessages analyzes chat messages for bills
+	AnalyzeMessages(
+		ctx context.Context,
+		history []database.MessageRecord,
+		newMessage database.MessageRecord,
+		existingReminders []database.Reminder,
+	) (*BillAnalysis, error)
+
+	// AnalyzeEmail analyzes an email for bills
+	AnalyzeEmail(ctx context.Context, email EmailContent) (*BillAnalysis, error)
+
+	// IsConfigured returns true if the analyzer is properly configured
+	IsConfigured() bool
+}
+
+// BillAnalysis represents the result of bill analysis
+type BillAnalysis struct {
+	HasBill    bool      `json:"has_bill"`
+	Action     string    `json:"action"` // "create", "none"
+	Bill       *BillData `json:"bill,omitempty"`
+	Reasoning  string    `json:"reasoning"`
+	Confidence float64   `json:"confidence"`
+}
+
+// BillData contains the extracted bill details
+type BillData struct {
+	Payee         string   `json:"payee"`                    // Who is owed (e.g., "Electric Company")
+	Amount        *float64 `json:"amount,omitempty"`         // Amount due, if stated
+	Currency      string   `json:"currency,omitempty"`       // ISO 4217 code
+	DueDate       string   `json:"due_date"`                 // ISO 8601 format
+	InvoiceNumber string   `json:"invoice_number,omitempty"` // Invoice or account reference
+	Description   string   `json:"description,omitempty"`    // What the bill is for
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its circuit breaker is
+// open. Like other API failures it is retried later through the failed-analysis queue.
+var ErrCircuitOpen = errors.New("LLM API unavailable: circuit breaker is open")
+
+// CircuitState is where a circuit breaker is in its closed → open → half-open cycle
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // requests flow normally
+	CircuitOpen     CircuitState = "open"      // requests fail fast until the cooldown ends
+	CircuitHalfOpen CircuitState = "half_open" // one trial request decides whether to close
+)
+
+// CircuitStatus is a snapshot of a circuit breaker for health checks and stats
+type CircuitStatus struct {
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenUntil           *time.Time   `json:"open_until,omitempty"`
+	Opens               int64        `json:"opens"`    // times the breaker opened since startup
+	Rejected            int64        `json:"rejected"` // requests failed fast while open
+}
+
+// CircuitBreaker stops requests to a provider after threshold consecutive calls failed
+// with the API unavailable (429, 5xx, timeouts). After cooldown a single trial request
+// is let through: success closes the breaker, failure opens it for another cooldown.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool // the half-open trial request is in flight
+	opens    int64
+	rejected int64
+}
+
+// NewCircuitBreaker opens after threshold consecutive failures and stays open for cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     CircuitClosed,
+	}
+}
+
+// Allow reports whether a request may be sent, returning ErrCircuitOpen when it may not.
+// Every allowed request must be followed by Succeed, Fail or Cancel.
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.state = CircuitHalfOpen
+	}
+	switch {
+	case b.state == CircuitOpen, b.state == CircuitHalfOpen && b.trial:
+		b.rejected++
+		return ErrCircuitOpen
+	case b.state == CircuitHalfOpen:
+		b.trial = true
+	}
+	return nil
+}
+
+// Available reports whether a request would be allowed now, without claiming the
+// half-open trial
+func (b *CircuitBreaker) Available() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		return b.now().Sub(b.openedAt) >= b.cooldown
+	case CircuitHalfOpen:
+		return !b.trial
+	}
+	return true
+}
+
+// Succeed records that the provider answered, which closes the breaker
+func (b *CircuitBreaker) Succeed() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = CircuitClosed
+	b.failures = 0
+	b.trial = false
+}
+
+// Fail records a call that failed with the API unavailable, opening the breaker once
+// threshold calls in a row have failed or when the half-open trial fails
+func (b *CircuitBreaker) Fail() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.trial = false
+	if b.state == CircuitHalfOpen || (b.state == CircuitClosed && b.failures >= b.threshold) {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+		b.opens++
+	}
+}
+
+// Cancel records a call abandoned by its caller, which says nothing about the provider
+func (b *CircuitBreaker) Cancel() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// Status returns a snapshot of the breaker
+func (b *CircuitBreaker) Status() CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := CircuitStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Opens:               b.opens,
+		Rejected:            b.rejected,
+	}
+	if b.state == CircuitOpen {
+		openUntil := b.openedAt.Add(b.cooldown)
+		if b.now().Before(openUntil) {
+			status.OpenUntil = &openUntil
+		} else {
+			status.State = CircuitHalfOpen
+		}
+	}
+	return status
+}
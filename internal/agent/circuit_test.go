@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	require.NoError(t, b.Allow())
+	b.Fail()
+	require.NoError(t, b.Allow())
+	b.Succeed()
+	assert.Equal(t, 0, b.Status().ConsecutiveFailures, "success resets the count")
+
+	for range 2 {
+		require.NoError(t, b.Allow())
+		b.Fail()
+	}
+	status := b.Status()
+	assert.Equal(t, CircuitOpen, status.State)
+	require.NotNil(t, status.OpenUntil)
+	assert.Equal(t, now.Add(time.Minute), *status.OpenUntil)
+	assert.False(t, b.Available())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+	// After the cooldown one trial request is let through at a time
+	now = now.Add(time.Minute)
+	assert.True(t, b.Available())
+	require.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	b.Fail()
+	assert.Equal(t, CircuitOpen, b.Status().State, "a failed trial reopens the breaker")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, b.Allow())
+	b.Cancel()
+	require.NoError(t, b.Allow(), "a canceled trial frees the slot")
+	b.Succeed()
+
+	status = b.Status()
+	assert.Equal(t, CircuitClosed, status.State)
+	assert.Equal(t, int64(2), status.Opens)
+	assert.Equal(t, int64(2), status.Rejected)
+
+	var nilBreaker *CircuitBreaker
+	assert.NoError(t, nilBreaker.Allow())
+	assert.True(t, nilBreaker.Available())
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"context"
+	"time"
+)
+
+type messageTimeKey struct{}
+
+type timezoneKey struct{}
+
+// WithMessageTime returns a context that makes agents treat t, the time the analyzed
+// message was sent, as "now" when resolving relative dates
+func WithMessageTime(ctx context.Context, t time.Time) context.Context {
+	if t.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, messageTimeKey{}, t)
+}
+
+// MessageTimeFromContext returns the time attached by WithMessageTime
+func MessageTimeFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(messageTimeKey{}).(time.Time)
+	return t, ok
+}
+
+// WithTimezone returns a context that makes agents resolve dates in the user's timezone
+func WithTimezone(ctx context.Context, loc *time.Location) context.Context {
+	if loc == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, timezoneKey{}, loc)
+}
+
+// TimezoneFromContext returns the timezone attached by WithTimezone
+func TimezoneFromContext(ctx context.Context) (*time.Location, bool) {
+	loc, ok := ctx.Value(timezoneKey{}).(*time.Location)
+	return loc, ok
+}
@@ -0,0 +1,24 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+type eventCorrectionsKey struct{}
+
+// WithEventCorrections returns a context carrying the user's recent corrections of
+// detected events, which the event agent shows as examples of what to avoid
+func WithEventCorrections(ctx context.Context, corrections []database.EventCorrection) context.Context {
+	if len(corrections) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, eventCorrectionsKey{}, corrections)
+}
+
+// EventCorrectionsFromContext returns the corrections attached by WithEventCorrections
+func EventCorrectionsFromContext(ctx context.Context) []database.EventCorrection {
+	corrections, _ := ctx.Value(eventCorrectionsKey{}).([]database.EventCorrection)
+	return corrections
+}
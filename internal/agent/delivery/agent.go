@@ -0,0 +1,157 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/tools"
+)
+
+// Agent handles package delivery tracking using tool calling
+type Agent struct {
+	*agent.Agent
+}
+
+// Config configures the delivery agent
+type Config struct {
+	Provider    string // "anthropic" (default) or "openai"
+	APIKey      string
+	Model       string
+	FastModel   string // model for users on the "fast" tier
+	Temperature float64
+}
+
+// NewAgent creates a new delivery tracking agent
+func NewAgent(cfg Config) *Agent {
+	baseAgent := agent.NewAgent(agent.AgentConfig{
+		Name:         "delivery-tracker",
+		Provider:     cfg.Provider,
+		APIKey:       cfg.APIKey,
+		Model:        cfg.Model,
+		FastModel:    cfg.FastModel,
+		Temperature:  cfg.Temperature,
+		SystemPrompt: DeliveryAnalyzerSystemPrompt,
+	})
+
+	baseAgent.MustRegisterTool(tools.GetCurrentDateTimeTool, tools.HandleGetCurrentDateTime)
+	baseAgent.MustRegisterTool(tools.ExtractDateTimeTool, tools.HandleExtractDateTime)
+
+	baseAgent.MustRegisterTool(tools.TrackShipmentTool, tools.HandleTrackShipment)
+	baseAgent.MustRegisterTool(tools.NoShipmentActionTool, tools.HandleNoShipmentAction)
+
+	return &Agent{Agent: baseAgent}
+}
+
+// AnalyzeEmail analyzes an email for shipment updates
+// Implements agent.DeliveryAnalyzer interface
+func (a *Agent) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.DeliveryAnalysis, error) {
+	input := agent.AgentInput{
+		Messages: []agent.Message{
+			{
+				Role: "user",
+				Content: []agent.ContentBlock{
+					agent.TextBlock{Type: "text", Text: buildEmailPrompt(email)},
+				},
+			},
+		},
+		MaxTurns: 6, // Allow extraction + action + final response
+	}
+
+	output, err := a.Execute(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("email analysis failed: %w", err)
+	}
+
+	return parseAgentOutput(output)
+}
+
+// IsConfigured returns true if the agent is properly configured
+func (a *Agent) IsConfigured() bool {
+	return a.Agent.IsConfigured()
+}
+
+// buildEmailPrompt constructs the prompt for email analysis
+func buildEmailPrompt(email agent.EmailContent) string {
+	var prompt bytes.Buffer
+
+	if len(email.ThreadHistory) > 0 {
+		prompt.WriteString("## Email Thread History (chronological order)\n\n")
+		for _, msg := range email.ThreadHistory {
+			prompt.WriteString(fmt.Sprintf("[%s] From: %s\n", msg.Date, msg.From))
+			prompt.WriteString(fmt.Sprintf("Subject: %s\n", msg.Subject))
+			prompt.WriteString(fmt.Sprintf("Body:\n%s\n\n---\n\n", truncateBody(msg.Body, 2000)))
+		}
+	}
+
+	prompt.WriteString("## Email to Analyze (latest in thread)\n\n")
+	prompt.WriteString(fmt.Sprintf("**From:** %s\n", email.From))
+	prompt.WriteString(fmt.Sprintf("**To:** %s\n", email.To))
+	prompt.WriteString(fmt.Sprintf("**Date:** %s\n", email.Date))
+	prompt.WriteString(fmt.Sprintf("**Subject:** %s\n\n", email.Subject))
+	prompt.WriteString("**Body:**\n")
+	prompt.WriteString(truncateBody(email.Body, 8000))
+	prompt.WriteString("\n")
+
+	prompt.WriteString("\n## Current Date/Time Reference\n\n")
+	now := time.Now()
+	prompt.WriteString(fmt.Sprintf("Current time: %s (%s)\n", now.Format("2006-01-02 15:04:05 Monday -07:00"), now.Location().String()))
+
+	prompt.WriteString("\nAnalyze this email using the available tools and record any shipment update.")
+
+	return prompt.String()
+}
+
+func truncateBody(body string, maxLen int) string {
+	if len(body) <= maxLen {
+		return body
+	}
+	return body[:maxLen] + "\n\n[... content truncated ...]"
+}
+
+// parseAgentOutput converts agent output to DeliveryAnalysis
+func parseAgentOutput(output *agent.AgentOutput) (*agent.DeliveryAnalysis, error) {
+	actionCalls := make([]*agent.ToolCall, 0, 1)
+	for i := range output.ToolCalls {
+		call := &output.ToolCalls[i]
+		switch call.Name {
+		case tools.TrackShipmentTool.Name, tools.NoShipmentActionTool.Name:
+			actionCalls = append(actionCalls, call)
+		}
+	}
+
+	switch {
+	case len(actionCalls) == 0:
+		return &agent.DeliveryAnalysis{Action: "none", Reasoning: "No action tool was called"}, nil
+	case len(actionCalls) > 1:
+		return &agent.DeliveryAnalysis{Action: "none", Reasoning: "Ambiguous tool output: multiple action tools called"}, nil
+	case actionCalls[0].Error != nil:
+		return &agent.DeliveryAnalysis{Action: "none", Reasoning: fmt.Sprintf("Action tool failed: %v", actionCalls[0].Error)}, nil
+	}
+
+	var result struct {
+		Action     string                    `json:"action"`
+		Shipment   *tools.TrackShipmentInput `json:"shipment"`
+		Reasoning  string                    `json:"reasoning"`
+		Confidence float64                   `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(actionCalls[0].Output), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse action result: %w", err)
+	}
+
+	analysis := &agent.DeliveryAnalysis{
+		Action:     result.Action,
+		Reasoning:  result.Reasoning,
+		Confidence: result.Confidence,
+	}
+	if result.Action == "track" && result.Shipment != nil {
+		analysis.HasShipment = true
+		analysis.Shipment = &result.Shipment.ShipmentData
+		analysis.Reasoning = result.Shipment.Reasoning
+		analysis.Confidence = result.Shipment.Confidence
+	}
+	return analysis, nil
+}
@@ -0,0 +1,61 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgentOutput_TrackShipment(t *testing.T) {
+	toolOutput, err := tools.HandleTrackShipment(context.Background(), map[string]any{
+		"tracking_number": "RR123456785IL",
+		"carrier":         "Israel Post",
+		"status":          "shipped",
+		"eta_start":       "2030-05-12T09:00:00",
+		"confidence":      0.85,
+		"reasoning":       "Shipping confirmation with tracking number",
+	})
+	require.NoError(t, err)
+
+	result, err := parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "get_current_datetime", Output: `{}`},
+		{Name: "track_shipment", Output: toolOutput},
+	}})
+	require.NoError(t, err)
+
+	assert.True(t, result.HasShipment)
+	assert.Equal(t, "track", result.Action)
+	assert.Equal(t, 0.85, result.Confidence)
+	assert.Equal(t, "Shipping confirmation with tracking number", result.Reasoning)
+	require.NotNil(t, result.Shipment)
+	assert.Equal(t, "RR123456785IL", result.Shipment.TrackingNumber)
+	assert.Equal(t, agent.ShipmentShipped, result.Shipment.Status)
+}
+
+func TestParseAgentOutput_NoAction(t *testing.T) {
+	result, err := parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "no_shipment_action", Output: `{"status":"success","action":"none","reasoning":"Newsletter","confidence":0.9}`},
+	}})
+	require.NoError(t, err)
+	assert.False(t, result.HasShipment)
+	assert.Equal(t, "none", result.Action)
+	assert.Equal(t, "Newsletter", result.Reasoning)
+}
+
+func TestParseAgentOutput_AmbiguousOrMissingAction(t *testing.T) {
+	result, err := parseAgentOutput(&agent.AgentOutput{})
+	require.NoError(t, err)
+	assert.Equal(t, "none", result.Action)
+
+	result, err = parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "no_shipment_action", Output: `{"action":"none"}`},
+		{Name: "track_shipment", Output: `{"action":"track"}`},
+	}})
+	require.NoError(t, err)
+	assert.False(t, result.HasShipment)
+	assert.Contains(t, result.Reasoning, "multiple action tools")
+}
@@ -0,0 +1,42 @@
+package agent
+
+import "context"
+
+// DeliveryAnalyzer is the interface for package delivery tracking
+// (shipping confirmations and carrier delivery-window updates). Emails only.
+type DeliveryAnalyzer interface {
+	// AnalyzeEmail analyzes an email for shipment updates
+	AnalyzeEmail(ctx context.Context, email EmailContent) (*DeliveryAnalysis, error)
+
+	// IsConfigured returns true if the analyzer is properly configured
+	IsConfigured() bool
+}
+
+// Shipment statuses reported by the delivery agent
+const (
+	ShipmentShipped        = "shipped"
+	ShipmentOutForDelivery = "out_for_delivery"
+	ShipmentDelayed        = "delayed"
+	ShipmentDelivered      = "delivered"
+	ShipmentCancelled      = "cancelled"
+)
+
+// DeliveryAnalysis represents the result of delivery tracking analysis
+type DeliveryAnalysis struct {
+	HasShipment bool          `json:"has_shipment"`
+	Action      string        `json:"action"` // "track", "none"
+	Shipment    *ShipmentData `json:"shipment,omitempty"`
+	Reasoning   string        `json:"reasoning"`
+	Confidence  float64       `json:"confidence"`
+}
+
+// ShipmentData contains a carrier update for one package
+type ShipmentData struct {
+	TrackingNumber string `json:"tracking_number"`
+	Carrier        string `json:"carrier,omitempty"`     // UPS, DHL, Israel Post...
+	Merchant       string `json:"merchant,omitempty"`    // Who sent the package
+	Description    string `json:"description,omitempty"` // What is in it
+	Status         string `json:"status"`                // shipped, out_for_delivery, delayed, delivered, cancelled
+	ETAStart       string `json:"eta_start,omitempty"`   // Start of the expected delivery window, ISO 8601
+	ETAEnd         string `json:"eta_end,omitempty"`     // End of the expected delivery window, ISO 8601
+}
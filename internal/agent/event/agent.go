@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"strings"
 	"time"
 
@@ -21,25 +23,49 @@ type Agent struct {
 
 // Config configures the event agent
 type Config struct {
+	Provider    string // "anthropic" (default) or "openai"
 	APIKey      string
 	Model       string
+	FastModel   string // model for users on the "fast" tier
 	Temperature float64
+	// SystemPrompt replaces EventAnalyzerSystemPrompt when set (e.g. a candidate prompt in shadow mode)
+	SystemPrompt string
+	// Calendar enables the list_calendar_events tool when set
+	Calendar tools.CalendarEventLister
+	// Contacts enables the lookup_contact tool when set
+	Contacts tools.ContactSearcher
+	// Transport replaces the LLM client's HTTP transport, e.g. an agent.Recorder in tests
+	Transport http.RoundTripper
 }
 
 // NewAgent creates a new event scheduling agent
 func NewAgent(cfg Config) *Agent {
+	systemPrompt := cfg.SystemPrompt
+	if systemPrompt == "" {
+		systemPrompt = EventAnalyzerSystemPrompt
+	}
 	baseAgent := agent.NewAgent(agent.AgentConfig{
 		Name:         "event-scheduler",
+		Provider:     cfg.Provider,
 		APIKey:       cfg.APIKey,
 		Model:        cfg.Model,
+		FastModel:    cfg.FastModel,
 		Temperature:  cfg.Temperature,
-		SystemPrompt: EventAnalyzerSystemPrompt,
+		SystemPrompt: systemPrompt,
+		Transport:    cfg.Transport,
 	})
 
 	// Register extraction tools
+	baseAgent.MustRegisterTool(tools.GetCurrentDateTimeTool, tools.HandleGetCurrentDateTime)
 	baseAgent.MustRegisterTool(tools.ExtractDateTimeTool, tools.HandleExtractDateTime)
 	baseAgent.MustRegisterTool(tools.ExtractLocationTool, tools.HandleExtractLocation)
 	baseAgent.MustRegisterTool(tools.ExtractAttendeesTool, tools.HandleExtractAttendees)
+	if cfg.Calendar != nil {
+		baseAgent.MustRegisterTool(tools.ListCalendarEventsTool, tools.NewListCalendarEventsHandler(cfg.Calendar))
+	}
+	if cfg.Contacts != nil {
+		baseAgent.MustRegisterTool(tools.LookupContactTool, tools.NewLookupContactHandler(cfg.Contacts))
+	}
 
 	// Register calendar action tools
 	baseAgent.MustRegisterTool(tools.CreateCalendarEventTool, tools.HandleCreateCalendarEvent)
@@ -58,19 +84,17 @@ func (a *Agent) AnalyzeMessages(
 	newMessage database.MessageRecord,
 	existingEvents []database.CalendarEvent,
 ) (*agent.EventAnalysis, error) {
-	targetLanguage := langpolicy.DetectTargetLanguage(newMessage.MessageText)
+	targetLanguage := langpolicy.Resolve(newMessage.MessageText, agent.ChannelLanguageFromContext(ctx), agent.LocaleFromContext(ctx))
 	languageInstruction := langpolicy.BuildLanguageInstruction(targetLanguage)
 	if targetLanguage.Reliable {
-		fmt.Printf(
-			"LanguagePolicy[event]: target=%s script=%s confidence=%.2f source=message\n",
-			targetLanguage.Code,
-			targetLanguage.Script,
-			targetLanguage.Confidence,
-		)
+		slog.Info("LanguagePolicy[event]: source=message", "target", targetLanguage.Code, "script", targetLanguage.Script, "confidence", targetLanguage.Confidence, "preferred", targetLanguage.Preferred)
 	}
 
-	analysis, err := a.executePromptAndParse(ctx, buildUserPrompt(
+	related := agent.RelatedMessagesFromContext(ctx)
+	examples := buildCorrectionExamples(agent.EventCorrectionsFromContext(ctx))
+	analysis, err := a.executePromptAndParse(ctx, examples+buildUserPrompt(
 		history,
+		related,
 		newMessage,
 		existingEvents,
 		languageInstruction,
@@ -81,8 +105,9 @@ func (a *Agent) AnalyzeMessages(
 	}
 
 	return a.enforceLanguagePolicy(ctx, targetLanguage, analysis, func(correction string) string {
-		return buildUserPrompt(
+		return examples + buildUserPrompt(
 			history,
+			related,
 			newMessage,
 			existingEvents,
 			languageInstruction,
@@ -96,24 +121,20 @@ func (a *Agent) AnalyzeMessages(
 func (a *Agent) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.EventAnalysis, error) {
 	// Detect language from subject+body so a single localized token in the body
 	// doesn't incorrectly flip the entire output language.
-	targetLanguage := langpolicy.DetectTargetLanguage(strings.TrimSpace(email.Subject + "\n" + email.Body))
+	targetLanguage := langpolicy.Resolve(strings.TrimSpace(email.Subject+"\n"+email.Body), "", agent.LocaleFromContext(ctx))
 	languageInstruction := langpolicy.BuildLanguageInstruction(targetLanguage)
 	if targetLanguage.Reliable {
-		fmt.Printf(
-			"LanguagePolicy[event]: target=%s script=%s confidence=%.2f source=email\n",
-			targetLanguage.Code,
-			targetLanguage.Script,
-			targetLanguage.Confidence,
-		)
+		slog.Info("LanguagePolicy[event]: source=email", "target", targetLanguage.Code, "script", targetLanguage.Script, "confidence", targetLanguage.Confidence, "preferred", targetLanguage.Preferred)
 	}
 
-	analysis, err := a.executePromptAndParse(ctx, buildEmailPrompt(email, languageInstruction, ""))
+	examples := buildCorrectionExamples(agent.EventCorrectionsFromContext(ctx))
+	analysis, err := a.executePromptAndParse(ctx, examples+buildEmailPrompt(email, languageInstruction, ""))
 	if err != nil {
 		return nil, fmt.Errorf("email analysis failed: %w", err)
 	}
 
 	return a.enforceLanguagePolicy(ctx, targetLanguage, analysis, func(correction string) string {
-		return buildEmailPrompt(email, languageInstruction, correction)
+		return examples + buildEmailPrompt(email, languageInstruction, correction)
 	})
 }
 
@@ -125,6 +146,7 @@ func (a *Agent) IsConfigured() bool {
 // buildUserPrompt constructs the prompt with message history and context
 func buildUserPrompt(
 	history []database.MessageRecord,
+	related []database.MessageRecord,
 	newMessage database.MessageRecord,
 	existingEvents []database.CalendarEvent,
 	languageInstruction string,
@@ -146,6 +168,17 @@ func buildUserPrompt(
 		))
 	}
 
+	if len(related) > 0 {
+		prompt.WriteString("\n## Related Earlier Messages (older messages from this channel that may give context, e.g. an address or plan referred to later)\n\n")
+		for _, msg := range related {
+			prompt.WriteString(fmt.Sprintf("[%s] %s: %s\n",
+				msg.Timestamp.Format("2006-01-02 15:04"),
+				msg.SenderName,
+				msg.MessageText,
+			))
+		}
+	}
+
 	prompt.WriteString("\n## New Message (just received)\n\n")
 	prompt.WriteString(fmt.Sprintf("[%s] %s: %s\n",
 		newMessage.Timestamp.Format("2006-01-02 15:04"),
@@ -153,32 +186,11 @@ func buildUserPrompt(
 		newMessage.MessageText,
 	))
 
+	prompt.WriteString("\n## Existing Calendar Events for this channel\n\n")
 	if len(existingEvents) > 0 {
-		prompt.WriteString("\n## Existing Calendar Events for this channel\n\n")
-		for _, event := range existingEvents {
-			endStr := ""
-			if event.EndTime != nil {
-				endStr = fmt.Sprintf(" - %s", event.EndTime.Format("2006-01-02 15:04"))
-			}
-			googleID := "none"
-			if event.GoogleEventID != nil && *event.GoogleEventID != "" {
-				googleID = *event.GoogleEventID
-			}
-			prompt.WriteString(fmt.Sprintf("- [AlfredID: %d, GoogleID: %s, Status: %s] %s @ %s%s",
-				event.ID,
-				googleID,
-				event.Status,
-				event.Title,
-				event.StartTime.Format("2006-01-02 15:04"),
-				endStr,
-			))
-			if event.Location != "" {
-				prompt.WriteString(fmt.Sprintf(" (Location: %s)", event.Location))
-			}
-			prompt.WriteString("\n")
-		}
+		writeExistingEvents(&prompt, existingEvents)
 	} else {
-		prompt.WriteString("\n## Existing Calendar Events for this channel\n\nNo existing events.\n")
+		prompt.WriteString("No existing events.\n")
 	}
 
 	prompt.WriteString("\n## Current Date/Time Reference\n\n")
@@ -221,6 +233,12 @@ func buildEmailPrompt(email agent.EmailContent, languageInstruction, retryInstru
 	prompt.WriteString("**Body:**\n")
 	prompt.WriteString(truncateBody(email.Body, 8000))
 
+	if len(email.ThreadEvents) > 0 {
+		prompt.WriteString("\n\n## Existing Calendar Events from this thread\n\n")
+		prompt.WriteString("These were detected in earlier messages of this thread. If the email to analyze changes or cancels one of them, update or delete that event instead of creating a new one.\n\n")
+		writeExistingEvents(&prompt, email.ThreadEvents)
+	}
+
 	prompt.WriteString("\n\n## Current Date/Time Reference\n\n")
 	now := time.Now()
 	prompt.WriteString(fmt.Sprintf("Current time: %s (%s)\n", now.Format("2006-01-02 15:04:05 Monday -07:00"), now.Location().String()))
@@ -239,6 +257,32 @@ func buildEmailPrompt(email agent.EmailContent, languageInstruction, retryInstru
 	return prompt.String()
 }
 
+// writeExistingEvents lists events with the IDs the agent uses to update or delete them
+func writeExistingEvents(prompt *bytes.Buffer, events []database.CalendarEvent) {
+	for _, event := range events {
+		endStr := ""
+		if event.EndTime != nil {
+			endStr = fmt.Sprintf(" - %s", event.EndTime.Format("2006-01-02 15:04"))
+		}
+		googleID := "none"
+		if event.GoogleEventID != nil && *event.GoogleEventID != "" {
+			googleID = *event.GoogleEventID
+		}
+		prompt.WriteString(fmt.Sprintf("- [AlfredID: %d, GoogleID: %s, Status: %s] %s @ %s%s",
+			event.ID,
+			googleID,
+			event.Status,
+			event.Title,
+			event.StartTime.Format("2006-01-02 15:04"),
+			endStr,
+		))
+		if event.Location != "" {
+			prompt.WriteString(fmt.Sprintf(" (Location: %s)", event.Location))
+		}
+		prompt.WriteString("\n")
+	}
+}
+
 func truncateBody(body string, maxLen int) string {
 	if len(body) <= maxLen {
 		return body
@@ -276,45 +320,27 @@ func (a *Agent) enforceLanguagePolicy(
 	shouldRetry, validation := shouldRetryEventForLanguage(target, initial)
 	if !shouldRetry {
 		if target.Reliable {
-			fmt.Printf(
-				"LanguagePolicy[event]: validation=pass action=%s checked=%d skipped=%d\n",
-				initial.Action,
-				validation.CheckedFields,
-				validation.SkippedFields,
-			)
+			slog.Info("LanguagePolicy[event]: validation=pass", "action", initial.Action, "checked", validation.CheckedFields, "skipped", validation.SkippedFields)
 		}
 		return initial, nil
 	}
 
-	fmt.Printf(
-		"LanguagePolicy[event]: validation=fail action=%s mismatches=%s retry=true\n",
-		initial.Action,
-		formatMismatches(validation),
-	)
+	slog.Error("LanguagePolicy[event]: validation=fail retry=true", "action", initial.Action, "mismatches", formatMismatches(validation))
 
 	retryPrompt := retryPromptBuilder(langpolicy.BuildCorrectiveRetryInstruction(target, validation))
 	retryAnalysis, err := a.executePromptAndParse(ctx, retryPrompt)
 	if err != nil {
-		fmt.Printf("LanguagePolicy[event]: retry_error=%v fallback=initial\n", err)
+		slog.Info("LanguagePolicy[event]: fallback=initial", "retry_error", err)
 		return initial, nil
 	}
 
 	retryNeeded, retryValidation := shouldRetryEventForLanguage(target, retryAnalysis)
 	if !retryNeeded {
-		fmt.Printf(
-			"LanguagePolicy[event]: retry_result=pass action=%s checked=%d skipped=%d\n",
-			retryAnalysis.Action,
-			retryValidation.CheckedFields,
-			retryValidation.SkippedFields,
-		)
+		slog.Info("LanguagePolicy[event]: retry_result=pass", "action", retryAnalysis.Action, "checked", retryValidation.CheckedFields, "skipped", retryValidation.SkippedFields)
 		return retryAnalysis, nil
 	}
 
-	fmt.Printf(
-		"LanguagePolicy[event]: retry_result=fail action=%s mismatches=%s fallback=retry\n",
-		retryAnalysis.Action,
-		formatMismatches(retryValidation),
-	)
+	slog.Error("LanguagePolicy[event]: retry_result=fail fallback=retry", "action", retryAnalysis.Action, "mismatches", formatMismatches(retryValidation))
 	return retryAnalysis, nil
 }
 
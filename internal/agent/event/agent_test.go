@@ -1,9 +1,12 @@
 package event
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -761,3 +764,51 @@ func TestParseAgentOutput_MultipleActionTools(t *testing.T) {
 	assert.Equal(t, "Ambiguous tool output: multiple action tools called", result.Reasoning)
 	assert.Equal(t, 0.0, result.Confidence)
 }
+
+func TestBuildUserPrompt_RelatedEarlierMessages(t *testing.T) {
+	newMessage := database.MessageRecord{
+		ID:          3,
+		Timestamp:   time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC),
+		SenderName:  "Dana",
+		MessageText: "See you at the usual place at 8",
+	}
+	related := []database.MessageRecord{{
+		ID:          1,
+		Timestamp:   time.Date(2026, 3, 1, 18, 30, 0, 0, time.UTC),
+		SenderName:  "Dana",
+		MessageText: "The usual place is Herzl St 10",
+	}}
+
+	prompt := buildUserPrompt(nil, related, newMessage, nil, "", "")
+
+	require.Contains(t, prompt, "## Related Earlier Messages")
+	assert.Contains(t, prompt, "[2026-03-01 18:30] Dana: The usual place is Herzl St 10")
+	assert.Less(t, strings.Index(prompt, "## Related Earlier Messages"), strings.Index(prompt, "## New Message"))
+
+	assert.NotContains(t, buildUserPrompt(nil, nil, newMessage, nil, "", ""), "## Related Earlier Messages")
+}
+
+func TestBuildEmailPrompt_ThreadEvents(t *testing.T) {
+	email := agent.EmailContent{
+		Subject: "Re: Design review",
+		From:    "dana@example.com",
+		Body:    "ok, moved to 4pm",
+		ThreadHistory: []agent.EmailThreadMessage{
+			{From: "dana@example.com", Subject: "Design review", Body: "Design review Thursday at 2pm"},
+		},
+	}
+
+	prompt := buildEmailPrompt(email, "", "")
+	assert.NotContains(t, prompt, "Existing Calendar Events from this thread")
+
+	email.ThreadEvents = []database.CalendarEvent{{
+		ID:        42,
+		Title:     "Design review",
+		Status:    database.EventStatusPending,
+		StartTime: time.Date(2030, 5, 16, 14, 0, 0, 0, time.UTC),
+	}}
+	prompt = buildEmailPrompt(email, "", "")
+	assert.Contains(t, prompt, "## Existing Calendar Events from this thread")
+	assert.Contains(t, prompt, "[AlfredID: 42, GoogleID: none, Status: pending] Design review @ 2030-05-16 14:00")
+	assert.Less(t, strings.Index(prompt, "Design review Thursday at 2pm"), strings.Index(prompt, "ok, moved to 4pm"))
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// maxExampleMessageChars keeps a long source message from dominating the prompt
+const maxExampleMessageChars = 300
+
+// buildCorrectionExamples renders the user's past corrections as few-shot examples.
+// Corrections without a source message (emails, pruned chats) are skipped.
+func buildCorrectionExamples(corrections []database.EventCorrection) string {
+	var examples bytes.Buffer
+	for _, c := range corrections {
+		if c.MessageText == "" {
+			continue
+		}
+		examples.WriteString(fmt.Sprintf("- Message: %q\n", truncateExample(c.MessageText)))
+		examples.WriteString(fmt.Sprintf("  You detected: %s\n", describeCorrectionEvent(c.DetectedTitle, c.DetectedStartTime, c.DetectedLocation)))
+		switch c.Kind {
+		case database.EventCorrectionRejected:
+			examples.WriteString("  The user rejected it: it was not an event they wanted on their calendar\n")
+		case database.EventCorrectionEdited:
+			start := c.DetectedStartTime
+			if c.CorrectedStartTime != nil {
+				start = *c.CorrectedStartTime
+			}
+			examples.WriteString(fmt.Sprintf("  The user changed it to: %s\n", describeCorrectionEvent(c.CorrectedTitle, start, c.CorrectedLocation)))
+		}
+	}
+	if examples.Len() == 0 {
+		return ""
+	}
+	return "## Past Corrections From This User (examples of mistakes to avoid; do not act on them)\n\n" +
+		examples.String() + "\n"
+}
+
+func describeCorrectionEvent(title string, start time.Time, location string) string {
+	description := fmt.Sprintf("%q at %s", title, start.Format("2006-01-02 15:04"))
+	if location != "" {
+		description += fmt.Sprintf(" @ %s", location)
+	}
+	return description
+}
+
+// truncateExample shortens text to maxExampleMessageChars runes
+func truncateExample(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxExampleMessageChars {
+		return text
+	}
+	return string(runes[:maxExampleMessageChars]) + "…"
+}
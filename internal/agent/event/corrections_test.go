@@ -0,0 +1,54 @@
+package event
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildCorrectionExamples(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	corrected := start.Add(30 * time.Minute)
+
+	examples := buildCorrectionExamples([]database.EventCorrection{
+		{
+			Kind:              database.EventCorrectionRejected,
+			DetectedTitle:     "Lunch",
+			DetectedStartTime: start,
+			MessageText:       "we should grab lunch sometime",
+		},
+		{
+			Kind:               database.EventCorrectionEdited,
+			DetectedTitle:      "Meeting",
+			DetectedStartTime:  start,
+			CorrectedTitle:     "Standup",
+			CorrectedStartTime: &corrected,
+			CorrectedLocation:  "Zoom",
+			MessageText:        "standup moved to 9:30 on zoom",
+		},
+		{
+			// No source message to learn from
+			Kind:              database.EventCorrectionRejected,
+			DetectedTitle:     "Newsletter",
+			DetectedStartTime: start,
+		},
+	})
+
+	assert.True(t, strings.HasPrefix(examples, "## Past Corrections From This User"))
+	assert.Contains(t, examples, `You detected: "Lunch" at 2026-03-02 09:00`)
+	assert.Contains(t, examples, "The user rejected it")
+	assert.Contains(t, examples, `The user changed it to: "Standup" at 2026-03-02 09:30 @ Zoom`)
+	assert.NotContains(t, examples, "Newsletter")
+
+	assert.Empty(t, buildCorrectionExamples(nil))
+}
+
+func TestTruncateExample(t *testing.T) {
+	long := strings.Repeat("ש", maxExampleMessageChars+10)
+	truncated := truncateExample(long)
+	assert.Equal(t, maxExampleMessageChars+1, len([]rune(truncated)))
+	assert.Equal(t, "short", truncateExample("short"))
+}
@@ -27,6 +27,7 @@ func TestBuildUserPrompt_IncludesLanguageInstructions(t *testing.T) {
 
 	prompt := buildUserPrompt(
 		history,
+		nil,
 		newMessage,
 		nil,
 		"Generate fields in Hebrew.",
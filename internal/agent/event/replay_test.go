@@ -0,0 +1,74 @@
+package event
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReplayAgent returns an agent whose API calls are replayed from testdata/name.
+// The synthetic_ fixtures are hand-written in the Messages API format: they test our
+// tool loop and output parsing, not what the model actually answers. Run with
+// ALFRED_RECORD_FIXTURES=1 and ANTHROPIC_API_KEY set to record real responses, and
+// drop the prefix from fixtures recorded that way.
+func newReplayAgent(t *testing.T, name string) *Agent {
+	t.Helper()
+	rec, err := agent.NewRecorder(filepath.Join("testdata", name), os.Getenv(agent.RecordFixturesEnv) != "")
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, rec.Save()) })
+
+	apiKey := "replay-key"
+	if rec.Recording() {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+		require.NotEmpty(t, apiKey, "recording needs ANTHROPIC_API_KEY")
+	}
+	return NewAgent(Config{APIKey: apiKey, Transport: rec})
+}
+
+func TestAnalyzeMessages_Replay(t *testing.T) {
+	sentAt := time.Date(2026, 3, 10, 18, 30, 0, 0, time.UTC)
+	ctx := agent.WithMessageTime(context.Background(), sentAt)
+
+	t.Run("create", func(t *testing.T) {
+		a := newReplayAgent(t, "synthetic_create_dinner.json")
+		history := []database.MessageRecord{
+			{Timestamp: sentAt.Add(-time.Minute), SenderName: "Dana", MessageText: "Are we still on for this week?"},
+		}
+		msg := database.MessageRecord{
+			Timestamp:   sentAt,
+			SenderName:  "Dana",
+			MessageText: "Let's do dinner at Luigi's tomorrow at 7pm",
+		}
+
+		analysis, err := a.AnalyzeMessages(ctx, history, msg, nil)
+		require.NoError(t, err)
+		assert.True(t, analysis.HasEvent)
+		assert.Equal(t, "create", analysis.Action)
+		require.NotNil(t, analysis.Event)
+		assert.Contains(t, analysis.Event.Title, "Dinner")
+		assert.Equal(t, "2026-03-11T19:00:00", analysis.Event.StartTime)
+		assert.Contains(t, analysis.Event.Location, "Luigi")
+		assert.Greater(t, analysis.Confidence, 0.5)
+	})
+
+	t.Run("no action", func(t *testing.T) {
+		a := newReplayAgent(t, "synthetic_no_action.json")
+		msg := database.MessageRecord{
+			Timestamp:   sentAt,
+			SenderName:  "Dana",
+			MessageText: "haha that's hilarious",
+		}
+
+		analysis, err := a.AnalyzeMessages(ctx, nil, msg, nil)
+		require.NoError(t, err)
+		assert.False(t, analysis.HasEvent)
+		assert.Equal(t, "none", analysis.Action)
+	})
+}
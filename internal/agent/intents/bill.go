@@ -0,0 +1,152 @@
+package intents
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+)
+
+// billHints are cues a payment is being requested. Like travel, the bill module runs on
+// every message, so it skips the LLM call when none are present.
+var billHints = []string{
+	"invoice", "bill", "payment", "amount due", "balance due", "pay by", "due date",
+	"statement", "overdue", "₪", "$", "€",
+	"חשבונית", "חשבון", "תשלום", "לתשלום", "יתרה",
+}
+
+// BillModule adapts a BillAnalyzer into an IntentModule. Bills are persisted as
+// high-priority pending reminders carrying payee and amount. Detection is opt-in:
+// Enabled is consulted for the user attached to the context (agent.WithUserID).
+type BillModule struct {
+	Analyzer agent.BillAnalyzer
+	Enabled  func(userID int64) (bool, error)
+}
+
+func (m *BillModule) IntentName() string { return "bill" }
+
+func (m *BillModule) AnalyzeMessages(ctx context.Context, in MessageInput) (*ModuleOutput, error) {
+	if m.Analyzer == nil {
+		return nil, fmt.Errorf("bill analyzer is not configured")
+	}
+
+	if reason := m.skipReason(ctx, in.NewMessage.MessageText); reason != "" {
+		return noBillOutput(reason), nil
+	}
+
+	analysis, err := m.Analyzer.AnalyzeMessages(ctx, in.History, in.NewMessage, in.ExistingReminders)
+	if err != nil {
+		return nil, err
+	}
+
+	return billOutput(analysis), nil
+}
+
+func (m *BillModule) AnalyzeEmail(ctx context.Context, in EmailInput) (*ModuleOutput, error) {
+	if m.Analyzer == nil {
+		return nil, fmt.Errorf("bill analyzer is not configured")
+	}
+
+	if reason := m.skipReason(ctx, in.Email.Subject+"\n"+in.Email.Body); reason != "" {
+		return noBillOutput(reason), nil
+	}
+
+	analysis, err := m.Analyzer.AnalyzeEmail(ctx, in.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return billOutput(analysis), nil
+}
+
+// skipReason returns why the LLM call can be skipped, or "" when the content should be analyzed
+func (m *BillModule) skipReason(ctx context.Context, text string) string {
+	if reason := disabledReason(ctx, m.Enabled, "bill detection"); reason != "" {
+		return reason
+	}
+
+	if !containsAny(strings.ToLower(text), billHints) {
+		return "no bill cues"
+	}
+	return ""
+}
+
+func billOutput(analysis *agent.BillAnalysis) *ModuleOutput {
+	return &ModuleOutput{
+		Intent:       "bill",
+		Action:       analysis.Action,
+		Confidence:   analysis.Confidence,
+		Reasoning:    analysis.Reasoning,
+		BillAnalysis: analysis,
+	}
+}
+
+func noBillOutput(reason string) *ModuleOutput {
+	return billOutput(&agent.BillAnalysis{Action: "none", Reasoning: reason, Confidence: 1})
+}
+
+func (m *BillModule) Validate(_ context.Context, out *ModuleOutput) error {
+	if out == nil || out.BillAnalysis == nil {
+		return fmt.Errorf("bill output is nil")
+	}
+	if out.BillAnalysis.Action == "none" || !out.BillAnalysis.HasBill {
+		return nil
+	}
+	if out.BillAnalysis.Action != "create" {
+		return fmt.Errorf("unknown bill action: %s", out.BillAnalysis.Action)
+	}
+	bill := out.BillAnalysis.Bill
+	if bill == nil || strings.TrimSpace(bill.Payee) == "" || strings.TrimSpace(bill.DueDate) == "" {
+		return fmt.Errorf("bill create action requires payee and due_date")
+	}
+	return nil
+}
+
+func (m *BillModule) Persist(ctx context.Context, out *ModuleOutput, persister Persister) error {
+	if out == nil || out.BillAnalysis == nil {
+		return fmt.Errorf("bill output is nil")
+	}
+	if out.BillAnalysis.Action == "none" || !out.BillAnalysis.HasBill {
+		return nil
+	}
+
+	return persister.PersistReminder(ctx, &agent.ReminderAnalysis{
+		HasReminder: true,
+		Action:      "create",
+		Reminder:    billReminder(out.BillAnalysis.Bill),
+		Reasoning:   out.BillAnalysis.Reasoning,
+		Confidence:  out.BillAnalysis.Confidence,
+	})
+}
+
+// billReminder builds the high-priority reminder for a bill
+func billReminder(bill *agent.BillData) *agent.ReminderData {
+	currency := strings.ToUpper(strings.TrimSpace(bill.Currency))
+
+	var details []string
+	if bill.Amount != nil {
+		amount := strconv.FormatFloat(*bill.Amount, 'f', 2, 64)
+		if currency != "" {
+			amount += " " + currency
+		}
+		details = append(details, "Amount: "+amount)
+	}
+	if bill.InvoiceNumber != "" {
+		details = append(details, "Invoice: "+bill.InvoiceNumber)
+	}
+	if bill.Description != "" {
+		details = append(details, bill.Description)
+	}
+
+	return &agent.ReminderData{
+		Title:       "Pay " + strings.TrimSpace(bill.Payee),
+		Description: strings.Join(details, "\n"),
+		DueDate:     bill.DueDate,
+		Priority:    "high",
+		Payee:       strings.TrimSpace(bill.Payee),
+		Amount:      bill.Amount,
+		Currency:    currency,
+	}
+}
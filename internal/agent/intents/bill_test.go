@@ -0,0 +1,122 @@
+package intents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBillAnalyzer struct {
+	analysis *agent.BillAnalysis
+	calls    int
+}
+
+func (f *fakeBillAnalyzer) AnalyzeMessages(context.Context, []database.MessageRecord, database.MessageRecord, []database.Reminder) (*agent.BillAnalysis, error) {
+	f.calls++
+	return f.analysis, nil
+}
+
+func (f *fakeBillAnalyzer) AnalyzeEmail(context.Context, agent.EmailContent) (*agent.BillAnalysis, error) {
+	f.calls++
+	return f.analysis, nil
+}
+
+func (f *fakeBillAnalyzer) IsConfigured() bool { return true }
+
+func enabledFor(userIDs ...int64) func(int64) (bool, error) {
+	return func(userID int64) (bool, error) {
+		for _, id := range userIDs {
+			if id == userID {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func TestBillModule_SkipsUsersWithoutBillDetection(t *testing.T) {
+	analyzer := &fakeBillAnalyzer{}
+	module := &BillModule{Analyzer: analyzer, Enabled: enabledFor(1)}
+
+	out, err := module.AnalyzeMessages(agent.WithUserID(context.Background(), 2), MessageInput{
+		NewMessage: database.MessageRecord{MessageText: "Your invoice is due on Friday"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "none", out.Action)
+	assert.Equal(t, "bill detection disabled", out.Reasoning)
+	assert.Zero(t, analyzer.calls)
+
+	// No user on the context means there is no setting to check
+	_, err = module.AnalyzeMessages(context.Background(), MessageInput{
+		NewMessage: database.MessageRecord{MessageText: "Your invoice is due on Friday"},
+	})
+	require.NoError(t, err)
+	assert.Zero(t, analyzer.calls)
+}
+
+func TestBillModule_SkipsMessagesWithoutBillCues(t *testing.T) {
+	analyzer := &fakeBillAnalyzer{}
+	module := &BillModule{Analyzer: analyzer, Enabled: enabledFor(1)}
+
+	out, err := module.AnalyzeMessages(agent.WithUserID(context.Background(), 1), MessageInput{
+		NewMessage: database.MessageRecord{MessageText: "see you at lunch tomorrow"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "none", out.Action)
+	assert.Zero(t, analyzer.calls)
+	require.NoError(t, module.Validate(context.Background(), out))
+}
+
+func TestBillModule_PersistsHighPriorityReminder(t *testing.T) {
+	amount := 412.5
+	analyzer := &fakeBillAnalyzer{analysis: &agent.BillAnalysis{
+		HasBill:    true,
+		Action:     "create",
+		Reasoning:  "Electricity bill",
+		Confidence: 0.92,
+		Bill: &agent.BillData{
+			Payee:         "Israel Electric Corp",
+			Amount:        &amount,
+			Currency:      "ils",
+			DueDate:       "2030-03-15T09:00:00",
+			InvoiceNumber: "INV-20931",
+		},
+	}}
+	module := &BillModule{Analyzer: analyzer, Enabled: enabledFor(1)}
+
+	out, err := module.AnalyzeEmail(agent.WithUserID(context.Background(), 1), EmailInput{
+		Email: agent.EmailContent{Subject: "Your electricity bill", Body: "Amount due: 412.50"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, analyzer.calls)
+	require.NoError(t, module.Validate(context.Background(), out))
+
+	persister := &recordingPersister{}
+	require.NoError(t, module.Persist(context.Background(), out, persister))
+	require.Len(t, persister.reminders, 1)
+
+	reminder := persister.reminders[0].Reminder
+	assert.Equal(t, "create", persister.reminders[0].Action)
+	assert.Equal(t, "Pay Israel Electric Corp", reminder.Title)
+	assert.Equal(t, "high", reminder.Priority)
+	assert.Equal(t, "2030-03-15T09:00:00", reminder.DueDate)
+	assert.Equal(t, "Israel Electric Corp", reminder.Payee)
+	assert.Equal(t, "ILS", reminder.Currency)
+	require.NotNil(t, reminder.Amount)
+	assert.Equal(t, 412.5, *reminder.Amount)
+	assert.Equal(t, "Amount: 412.50 ILS\nInvoice: INV-20931", reminder.Description)
+}
+
+func TestBillModule_ValidateRequiresPayeeAndDueDate(t *testing.T) {
+	module := &BillModule{}
+	err := module.Validate(context.Background(), billOutput(&agent.BillAnalysis{
+		HasBill: true,
+		Action:  "create",
+		Bill:    &agent.BillData{Payee: "Bezeq"},
+	}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,196 @@
+package intents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// deliveryHints are cues an email is about a shipment. Like travel, the delivery module
+// runs on every email, so it skips the LLM call when none are present.
+var deliveryHints = []string{
+	"shipped", "shipment", "tracking", "out for delivery", "delivery", "delivered",
+	"package", "parcel", "courier", "dispatched", "on its way",
+	"משלוח", "חבילה", "מספר מעקב", "שליח",
+}
+
+// ShipmentStore keeps the tracked shipments and finds the calendar event announcing each one.
+// Implemented by *database.DB.
+type ShipmentStore interface {
+	GetShipment(userID int64, trackingNumber string) (*database.Shipment, error)
+	UpsertShipment(shipment *database.Shipment) (*database.Shipment, error)
+	GetTrackedEventID(userID int64, trackingNumber string) (int64, error)
+}
+
+// DeliveryModule adapts a DeliveryAnalyzer into an IntentModule. Shipments are stored by
+// tracking number, and the expected delivery window becomes a "package arriving" pending
+// event that is adjusted (or cancelled) as the carrier sends updates. Emails only.
+type DeliveryModule struct {
+	Analyzer  agent.DeliveryAnalyzer
+	Shipments ShipmentStore
+}
+
+func (m *DeliveryModule) IntentName() string { return "delivery" }
+
+// AnalyzeMessages never calls the agent: carriers send their updates by email
+func (m *DeliveryModule) AnalyzeMessages(context.Context, MessageInput) (*ModuleOutput, error) {
+	return noDeliveryOutput("delivery tracking runs on emails only"), nil
+}
+
+func (m *DeliveryModule) AnalyzeEmail(ctx context.Context, in EmailInput) (*ModuleOutput, error) {
+	if m.Analyzer == nil {
+		return nil, fmt.Errorf("delivery analyzer is not configured")
+	}
+
+	if !containsAny(strings.ToLower(in.Email.Subject+"\n"+in.Email.Body), deliveryHints) {
+		return noDeliveryOutput("no delivery cues"), nil
+	}
+
+	analysis, err := m.Analyzer.AnalyzeEmail(ctx, in.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveryOutput(analysis), nil
+}
+
+func deliveryOutput(analysis *agent.DeliveryAnalysis) *ModuleOutput {
+	return &ModuleOutput{
+		Intent:           "delivery",
+		Action:           analysis.Action,
+		Confidence:       analysis.Confidence,
+		Reasoning:        analysis.Reasoning,
+		DeliveryAnalysis: analysis,
+	}
+}
+
+func noDeliveryOutput(reason string) *ModuleOutput {
+	return deliveryOutput(&agent.DeliveryAnalysis{Action: "none", Reasoning: reason, Confidence: 1})
+}
+
+func (m *DeliveryModule) Validate(_ context.Context, out *ModuleOutput) error {
+	if out == nil || out.DeliveryAnalysis == nil {
+		return fmt.Errorf("delivery output is nil")
+	}
+	if out.DeliveryAnalysis.Action == "none" || !out.DeliveryAnalysis.HasShipment {
+		return nil
+	}
+	if out.DeliveryAnalysis.Action != "track" {
+		return fmt.Errorf("unknown delivery action: %s", out.DeliveryAnalysis.Action)
+	}
+	shipment := out.DeliveryAnalysis.Shipment
+	if shipment == nil || strings.TrimSpace(shipment.TrackingNumber) == "" {
+		return fmt.Errorf("delivery track action requires tracking_number")
+	}
+	if shipment.Status == "" {
+		return fmt.Errorf("delivery track action requires status")
+	}
+	return nil
+}
+
+func (m *DeliveryModule) Persist(ctx context.Context, out *ModuleOutput, persister Persister) error {
+	if out == nil || out.DeliveryAnalysis == nil {
+		return fmt.Errorf("delivery output is nil")
+	}
+	if out.DeliveryAnalysis.Action == "none" || !out.DeliveryAnalysis.HasShipment {
+		return nil
+	}
+	if m.Shipments == nil {
+		return fmt.Errorf("shipment store is not configured")
+	}
+	userID, ok := agent.UserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("delivery persistence requires a user")
+	}
+
+	shipment := out.DeliveryAnalysis.Shipment
+	trackingNumber := strings.TrimSpace(shipment.TrackingNumber)
+
+	previous, err := m.Shipments.GetShipment(userID, trackingNumber)
+	if err != nil {
+		return err
+	}
+	stored, err := m.Shipments.UpsertShipment(&database.Shipment{
+		UserID:         userID,
+		TrackingNumber: trackingNumber,
+		Carrier:        shipment.Carrier,
+		Merchant:       shipment.Merchant,
+		Description:    shipment.Description,
+		Status:         database.ShipmentStatus(shipment.Status),
+		ETAStart:       shipment.ETAStart,
+		ETAEnd:         shipment.ETAEnd,
+	})
+	if err != nil {
+		return err
+	}
+
+	eventID, err := m.Shipments.GetTrackedEventID(userID, trackingNumber)
+	if err != nil {
+		return err
+	}
+
+	event := &agent.EventData{AlfredEventRef: eventID, TrackingNumber: trackingNumber}
+	action := "update"
+	switch {
+	case shipment.Status == agent.ShipmentDelivered:
+		// Nothing left to schedule
+		return nil
+	case shipment.Status == agent.ShipmentCancelled:
+		if eventID == 0 {
+			return nil
+		}
+		action = "delete"
+	case shipment.ETAStart == "":
+		// No delivery window yet; a later update will bring one
+		return nil
+	case eventID != 0 && previous != nil && previous.ETAStart == shipment.ETAStart && previous.ETAEnd == shipment.ETAEnd:
+		// The carrier repeated the window the calendar already has
+		return nil
+	default:
+		if eventID == 0 {
+			action = "create"
+		}
+		event.Title = packageEventTitle(stored)
+		event.Description = packageEventDescription(stored)
+		event.StartTime = shipment.ETAStart
+		event.EndTime = shipment.ETAEnd
+	}
+
+	return persister.PersistEvent(ctx, &agent.EventAnalysis{
+		HasEvent:   true,
+		Action:     action,
+		Event:      event,
+		Reasoning:  out.DeliveryAnalysis.Reasoning,
+		Confidence: out.DeliveryAnalysis.Confidence,
+	})
+}
+
+// packageEventTitle formats "Package arriving: Running shoes", falling back to the
+// merchant or carrier when the contents are unknown
+func packageEventTitle(shipment *database.Shipment) string {
+	for _, label := range []string{shipment.Description, shipment.Merchant, shipment.Carrier} {
+		if label != "" {
+			return "Package arriving: " + label
+		}
+	}
+	return "Package arriving"
+}
+
+// packageEventDescription lists the carrier details of a shipment, one per line
+func packageEventDescription(shipment *database.Shipment) string {
+	var details []string
+	if shipment.Carrier != "" {
+		details = append(details, "Carrier: "+shipment.Carrier)
+	}
+	details = append(details, "Tracking: "+shipment.TrackingNumber)
+	if shipment.Merchant != "" {
+		details = append(details, "From: "+shipment.Merchant)
+	}
+	if shipment.Status == database.ShipmentStatusDelayed {
+		details = append(details, "Delayed by the carrier")
+	}
+	return strings.Join(details, "\n")
+}
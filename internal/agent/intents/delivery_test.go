@@ -0,0 +1,152 @@
+package intents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDeliveryAnalyzer struct {
+	analysis *agent.DeliveryAnalysis
+	calls    int
+}
+
+func (f *fakeDeliveryAnalyzer) AnalyzeEmail(context.Context, agent.EmailContent) (*agent.DeliveryAnalysis, error) {
+	f.calls++
+	return f.analysis, nil
+}
+
+func (f *fakeDeliveryAnalyzer) IsConfigured() bool { return true }
+
+// fakeShipmentStore keeps shipments in memory; eventIDs maps tracking numbers to their event
+type fakeShipmentStore struct {
+	shipments map[string]*database.Shipment
+	eventIDs  map[string]int64
+}
+
+func newFakeShipmentStore() *fakeShipmentStore {
+	return &fakeShipmentStore{shipments: map[string]*database.Shipment{}, eventIDs: map[string]int64{}}
+}
+
+func (f *fakeShipmentStore) GetShipment(_ int64, trackingNumber string) (*database.Shipment, error) {
+	if shipment, ok := f.shipments[trackingNumber]; ok {
+		copied := *shipment
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeShipmentStore) UpsertShipment(shipment *database.Shipment) (*database.Shipment, error) {
+	stored := *shipment
+	if previous, ok := f.shipments[shipment.TrackingNumber]; ok && stored.Merchant == "" {
+		stored.Merchant = previous.Merchant
+	}
+	f.shipments[shipment.TrackingNumber] = &stored
+	return &stored, nil
+}
+
+func (f *fakeShipmentStore) GetTrackedEventID(_ int64, trackingNumber string) (int64, error) {
+	return f.eventIDs[trackingNumber], nil
+}
+
+func trackAnalysis(status, etaStart, etaEnd string) *agent.DeliveryAnalysis {
+	return &agent.DeliveryAnalysis{
+		HasShipment: true,
+		Action:      "track",
+		Confidence:  0.9,
+		Reasoning:   "carrier update",
+		Shipment: &agent.ShipmentData{
+			TrackingNumber: "1Z999AA10123456784",
+			Carrier:        "UPS",
+			Merchant:       "Amazon",
+			Status:         status,
+			ETAStart:       etaStart,
+			ETAEnd:         etaEnd,
+		},
+	}
+}
+
+func persistDelivery(t *testing.T, module *DeliveryModule, analysis *agent.DeliveryAnalysis) *recordingPersister {
+	t.Helper()
+	out := deliveryOutput(analysis)
+	require.NoError(t, module.Validate(context.Background(), out))
+	persister := &recordingPersister{}
+	require.NoError(t, module.Persist(agent.WithUserID(context.Background(), 1), out, persister))
+	return persister
+}
+
+func TestDeliveryModule_SkipsChatsAndEmailsWithoutDeliveryCues(t *testing.T) {
+	analyzer := &fakeDeliveryAnalyzer{}
+	module := &DeliveryModule{Analyzer: analyzer, Shipments: newFakeShipmentStore()}
+
+	out, err := module.AnalyzeMessages(context.Background(), MessageInput{
+		NewMessage: database.MessageRecord{MessageText: "the package arrived"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "none", out.Action)
+
+	out, err = module.AnalyzeEmail(context.Background(), EmailInput{Email: agent.EmailContent{Subject: "Team offsite agenda"}})
+	require.NoError(t, err)
+	assert.Equal(t, "none", out.Action)
+	assert.Zero(t, analyzer.calls)
+}
+
+func TestDeliveryModule_CreatesThenAdjustsArrivalEvent(t *testing.T) {
+	store := newFakeShipmentStore()
+	module := &DeliveryModule{Analyzer: &fakeDeliveryAnalyzer{}, Shipments: store}
+
+	persister := persistDelivery(t, module, trackAnalysis(agent.ShipmentShipped, "2030-05-12T09:00:00", "2030-05-12T18:00:00"))
+	require.Len(t, persister.events, 1)
+	created := persister.events[0]
+	assert.Equal(t, "create", created.Action)
+	assert.Equal(t, "Package arriving: Amazon", created.Event.Title)
+	assert.Equal(t, "Carrier: UPS\nTracking: 1Z999AA10123456784\nFrom: Amazon", created.Event.Description)
+	assert.Equal(t, "1Z999AA10123456784", created.Event.TrackingNumber)
+	assert.Equal(t, "2030-05-12T18:00:00", created.Event.EndTime)
+
+	store.eventIDs["1Z999AA10123456784"] = 42
+
+	// Same window again: nothing to change
+	persister = persistDelivery(t, module, trackAnalysis(agent.ShipmentOutForDelivery, "2030-05-12T09:00:00", "2030-05-12T18:00:00"))
+	assert.Empty(t, persister.events)
+
+	// The carrier moves the window: the existing event is updated
+	persister = persistDelivery(t, module, trackAnalysis(agent.ShipmentDelayed, "2030-05-14T10:00:00", "2030-05-14T14:00:00"))
+	require.Len(t, persister.events, 1)
+	updated := persister.events[0]
+	assert.Equal(t, "update", updated.Action)
+	assert.Equal(t, int64(42), updated.Event.AlfredEventRef)
+	assert.Equal(t, "2030-05-14T10:00:00", updated.Event.StartTime)
+	assert.Contains(t, updated.Event.Description, "Delayed by the carrier")
+	assert.Equal(t, agent.ShipmentDelayed, string(store.shipments["1Z999AA10123456784"].Status))
+}
+
+func TestDeliveryModule_CancelledShipmentDeletesEvent(t *testing.T) {
+	store := newFakeShipmentStore()
+	module := &DeliveryModule{Analyzer: &fakeDeliveryAnalyzer{}, Shipments: store}
+
+	// Nothing on the calendar yet: only the shipment is recorded
+	persister := persistDelivery(t, module, trackAnalysis(agent.ShipmentCancelled, "", ""))
+	assert.Empty(t, persister.events)
+	require.Contains(t, store.shipments, "1Z999AA10123456784")
+
+	store.eventIDs["1Z999AA10123456784"] = 7
+	persister = persistDelivery(t, module, trackAnalysis(agent.ShipmentCancelled, "", ""))
+	require.Len(t, persister.events, 1)
+	assert.Equal(t, "delete", persister.events[0].Action)
+	assert.Equal(t, int64(7), persister.events[0].Event.AlfredEventRef)
+
+	// Delivered packages need no calendar change
+	persister = persistDelivery(t, module, trackAnalysis(agent.ShipmentDelivered, "", ""))
+	assert.Empty(t, persister.events)
+}
+
+func TestDeliveryModule_PersistRequiresUser(t *testing.T) {
+	module := &DeliveryModule{Analyzer: &fakeDeliveryAnalyzer{}, Shipments: newFakeShipmentStore()}
+	err := module.Persist(context.Background(), deliveryOutput(trackAnalysis(agent.ShipmentShipped, "", "")), &recordingPersister{})
+	assert.Error(t, err)
+}
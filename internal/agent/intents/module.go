@@ -3,6 +3,7 @@ package intents
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/database"
@@ -14,6 +15,10 @@ type MessageInput struct {
 	NewMessage        database.MessageRecord
 	ExistingEvents    []database.CalendarEvent
 	ExistingReminders []database.Reminder
+	// Related holds earlier messages semantically close to NewMessage that are not in History
+	Related []database.MessageRecord
+	// ChannelLanguage is the language code last detected in the channel ("" if unknown)
+	ChannelLanguage string
 }
 
 // EmailInput contains email-based context used by intent modules.
@@ -29,6 +34,10 @@ type ModuleOutput struct {
 	Reasoning        string
 	EventAnalysis    *agent.EventAnalysis
 	ReminderAnalysis *agent.ReminderAnalysis
+	TravelAnalysis   *agent.TravelAnalysis
+	BillAnalysis     *agent.BillAnalysis
+	DeliveryAnalysis *agent.DeliveryAnalysis
+	OccasionAnalysis *agent.OccasionAnalysis
 }
 
 // Persister is implemented by orchestrators to persist module outputs.
@@ -46,6 +55,28 @@ type IntentModule interface {
 	Persist(ctx context.Context, out *ModuleOutput, persister Persister) error
 }
 
+// disabledReason checks an optional module's per-user switch for the user attached to the
+// context (agent.WithUserID). It returns why the module should skip the message, or ""
+// when it should run. A nil enabled func means the module is always on.
+func disabledReason(ctx context.Context, enabled func(userID int64) (bool, error), feature string) string {
+	if enabled == nil {
+		return ""
+	}
+	userID, ok := agent.UserIDFromContext(ctx)
+	if !ok {
+		return feature + " requires a user"
+	}
+	on, err := enabled(userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Intent module: failed to read feature flag", "feature", feature, "user_id", userID, "error", err)
+		return feature + " settings unavailable"
+	}
+	if !on {
+		return feature + " disabled"
+	}
+	return ""
+}
+
 // EventModule adapts an EventAnalyzer into an IntentModule.
 type EventModule struct {
 	Analyzer agent.EventAnalyzer
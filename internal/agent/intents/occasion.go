@@ -0,0 +1,210 @@
+package intents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// occasionHints are cues a birthday or anniversary is being discussed. Like travel, the
+// occasion module runs on every message, so it skips the LLM call when none are present.
+var occasionHints = []string{
+	"birthday", "bday", "b-day", "anniversary", "born on", "got married",
+	"יום הולדת", "יומולדת", "יום נישואין", "נולד", "התחתנו",
+}
+
+// occasionAdvanceNotice is how long before an occasion its reminder is due
+const occasionAdvanceNotice = 7 * 24 * time.Hour
+
+// OccasionStore records occasions so repeated mentions are only persisted once.
+// Implemented by *database.DB.
+type OccasionStore interface {
+	RecordOccasion(occasion *database.Occasion) (bool, error)
+}
+
+// OccasionModule adapts an OccasionAnalyzer into an IntentModule. A new birthday or
+// anniversary becomes a yearly pending event plus a pending reminder a week before
+// its next occurrence; occasions already recorded for the person are skipped.
+type OccasionModule struct {
+	Analyzer  agent.OccasionAnalyzer
+	Occasions OccasionStore
+}
+
+func (m *OccasionModule) IntentName() string { return "occasion" }
+
+func (m *OccasionModule) AnalyzeMessages(ctx context.Context, in MessageInput) (*ModuleOutput, error) {
+	if m.Analyzer == nil {
+		return nil, fmt.Errorf("occasion analyzer is not configured")
+	}
+
+	if !containsAny(strings.ToLower(in.NewMessage.MessageText), occasionHints) {
+		return noOccasionOutput(), nil
+	}
+
+	analysis, err := m.Analyzer.AnalyzeMessages(ctx, in.History, in.NewMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	return occasionOutput(analysis), nil
+}
+
+func (m *OccasionModule) AnalyzeEmail(ctx context.Context, in EmailInput) (*ModuleOutput, error) {
+	if m.Analyzer == nil {
+		return nil, fmt.Errorf("occasion analyzer is not configured")
+	}
+
+	if !containsAny(strings.ToLower(in.Email.Subject+"\n"+in.Email.Body), occasionHints) {
+		return noOccasionOutput(), nil
+	}
+
+	analysis, err := m.Analyzer.AnalyzeEmail(ctx, in.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return occasionOutput(analysis), nil
+}
+
+func occasionOutput(analysis *agent.OccasionAnalysis) *ModuleOutput {
+	return &ModuleOutput{
+		Intent:           "occasion",
+		Action:           analysis.Action,
+		Confidence:       analysis.Confidence,
+		Reasoning:        analysis.Reasoning,
+		OccasionAnalysis: analysis,
+	}
+}
+
+func noOccasionOutput() *ModuleOutput {
+	return occasionOutput(&agent.OccasionAnalysis{Action: "none", Reasoning: "no occasion cues", Confidence: 1})
+}
+
+func (m *OccasionModule) Validate(_ context.Context, out *ModuleOutput) error {
+	if out == nil || out.OccasionAnalysis == nil {
+		return fmt.Errorf("occasion output is nil")
+	}
+	if out.OccasionAnalysis.Action == "none" || !out.OccasionAnalysis.HasOccasion {
+		return nil
+	}
+	if out.OccasionAnalysis.Action != "create" {
+		return fmt.Errorf("unknown occasion action: %s", out.OccasionAnalysis.Action)
+	}
+	occasion := out.OccasionAnalysis.Occasion
+	if occasion == nil || strings.TrimSpace(occasion.Person) == "" || strings.TrimSpace(occasion.Title) == "" {
+		return fmt.Errorf("occasion create action requires person and title")
+	}
+	if occasion.Month < 1 || occasion.Month > 12 || occasion.Day < 1 || occasion.Day > 31 {
+		return fmt.Errorf("occasion create action requires a valid month and day")
+	}
+	return nil
+}
+
+func (m *OccasionModule) Persist(ctx context.Context, out *ModuleOutput, persister Persister) error {
+	if out == nil || out.OccasionAnalysis == nil {
+		return fmt.Errorf("occasion output is nil")
+	}
+	if out.OccasionAnalysis.Action == "none" || !out.OccasionAnalysis.HasOccasion {
+		return nil
+	}
+	if m.Occasions == nil {
+		return fmt.Errorf("occasion store is not configured")
+	}
+	userID, ok := agent.UserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("occasion persistence requires a user")
+	}
+
+	occasion := out.OccasionAnalysis.Occasion
+	record := &database.Occasion{
+		UserID: userID,
+		Kind:   database.OccasionKind(occasion.Kind),
+		Person: occasion.Person,
+		Month:  occasion.Month,
+		Day:    occasion.Day,
+	}
+	if occasion.Year > 0 {
+		record.Year = &occasion.Year
+	}
+	isNew, err := m.Occasions.RecordOccasion(record)
+	if err != nil {
+		return err
+	}
+	if !isNew {
+		slog.Info("Occasion already recorded", "person", occasion.Person, "kind", occasion.Kind)
+		return nil
+	}
+
+	now := time.Now()
+	if messageTime, ok := agent.MessageTimeFromContext(ctx); ok {
+		now = messageTime
+	}
+	if loc, ok := agent.TimezoneFromContext(ctx); ok {
+		now = now.In(loc)
+	}
+	next := nextOccurrence(now, occasion.Month, occasion.Day)
+
+	if err := persister.PersistEvent(ctx, &agent.EventAnalysis{
+		HasEvent: true,
+		Action:   "create",
+		Event: &agent.EventData{
+			Title:       occasion.Title,
+			Description: occasionDescription(occasion),
+			StartTime:   next.Format("2006-01-02T15:04:05"),
+			Recurrence:  "RRULE:FREQ=YEARLY",
+		},
+		Reasoning:  out.OccasionAnalysis.Reasoning,
+		Confidence: out.OccasionAnalysis.Confidence,
+	}); err != nil {
+		return err
+	}
+
+	advance := next.Add(-occasionAdvanceNotice)
+	if advance.Before(now) {
+		// Too close for an advance reminder; the event itself is enough
+		return nil
+	}
+	return persister.PersistReminder(ctx, &agent.ReminderAnalysis{
+		HasReminder: true,
+		Action:      "create",
+		Reminder: &agent.ReminderData{
+			Title:       occasion.Title,
+			Description: "On " + next.Format("Monday, January 2"),
+			DueDate:     advance.Format("2006-01-02T15:04:05"),
+			Priority:    "normal",
+		},
+		Reasoning:  out.OccasionAnalysis.Reasoning,
+		Confidence: out.OccasionAnalysis.Confidence,
+	})
+}
+
+// nextOccurrence returns 09:00 on the first month/day on or after now's date.
+// Feb 29 resolves to the next leap year.
+func nextOccurrence(now time.Time, month, day int) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for year := now.Year(); year <= now.Year()+8; year++ {
+		candidate := time.Date(year, time.Month(month), day, 9, 0, 0, 0, now.Location())
+		if candidate.Day() != day || candidate.Before(today) {
+			continue
+		}
+		return candidate
+	}
+	return time.Date(now.Year()+1, time.Month(month), day, 9, 0, 0, 0, now.Location())
+}
+
+// occasionDescription notes the year the occasion started, when known
+func occasionDescription(occasion *agent.OccasionData) string {
+	if occasion.Year <= 0 {
+		return ""
+	}
+	if occasion.Kind == string(database.OccasionAnniversary) {
+		return "Since " + strconv.Itoa(occasion.Year)
+	}
+	return "Born " + strconv.Itoa(occasion.Year)
+}
@@ -0,0 +1,130 @@
+package intents
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOccasionAnalyzer struct {
+	analysis *agent.OccasionAnalysis
+	calls    int
+}
+
+func (f *fakeOccasionAnalyzer) AnalyzeMessages(context.Context, []database.MessageRecord, database.MessageRecord) (*agent.OccasionAnalysis, error) {
+	f.calls++
+	return f.analysis, nil
+}
+
+func (f *fakeOccasionAnalyzer) AnalyzeEmail(context.Context, agent.EmailContent) (*agent.OccasionAnalysis, error) {
+	f.calls++
+	return f.analysis, nil
+}
+
+func (f *fakeOccasionAnalyzer) IsConfigured() bool { return true }
+
+// fakeOccasionStore dedups on kind and lowercased person, like the occasions table
+type fakeOccasionStore struct {
+	seen map[string]bool
+}
+
+func (f *fakeOccasionStore) RecordOccasion(occasion *database.Occasion) (bool, error) {
+	key := string(occasion.Kind) + "|" + strings.ToLower(occasion.Person)
+	if f.seen[key] {
+		return false, nil
+	}
+	f.seen[key] = true
+	return true, nil
+}
+
+func birthdayOutput(month, day int) *ModuleOutput {
+	return occasionOutput(&agent.OccasionAnalysis{
+		HasOccasion: true,
+		Action:      "create",
+		Confidence:  0.9,
+		Occasion:    &agent.OccasionData{Kind: "birthday", Person: "Mom", Title: "Mom's birthday", Month: month, Day: day, Year: 1960},
+	})
+}
+
+func occasionContext(now time.Time) context.Context {
+	ctx := agent.WithUserID(context.Background(), 7)
+	return agent.WithMessageTime(ctx, now)
+}
+
+func TestOccasionModule_SkipsMessagesWithoutOccasionCues(t *testing.T) {
+	analyzer := &fakeOccasionAnalyzer{}
+	module := &OccasionModule{Analyzer: analyzer}
+
+	out, err := module.AnalyzeMessages(context.Background(), MessageInput{
+		NewMessage: database.MessageRecord{MessageText: "dinner on Thursday?"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "none", out.Action)
+	assert.Zero(t, analyzer.calls)
+}
+
+func TestOccasionModule_PersistsYearlyEventAndAdvanceReminder(t *testing.T) {
+	module := &OccasionModule{Occasions: &fakeOccasionStore{seen: map[string]bool{}}}
+	out := birthdayOutput(3, 3)
+	require.NoError(t, module.Validate(context.Background(), out))
+
+	persister := &recordingPersister{}
+	now := time.Date(2026, time.January, 10, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, module.Persist(occasionContext(now), out, persister))
+
+	require.Len(t, persister.events, 1)
+	event := persister.events[0].Event
+	assert.Equal(t, "Mom's birthday", event.Title)
+	assert.Equal(t, "2026-03-03T09:00:00", event.StartTime)
+	assert.Equal(t, "RRULE:FREQ=YEARLY", event.Recurrence)
+	assert.Equal(t, "Born 1960", event.Description)
+
+	require.Len(t, persister.reminders, 1)
+	assert.Equal(t, "2026-02-24T09:00:00", persister.reminders[0].Reminder.DueDate)
+}
+
+func TestOccasionModule_RepeatedMentionIsNotPersistedAgain(t *testing.T) {
+	module := &OccasionModule{Occasions: &fakeOccasionStore{seen: map[string]bool{}}}
+	persister := &recordingPersister{}
+	ctx := occasionContext(time.Date(2026, time.January, 10, 12, 0, 0, 0, time.UTC))
+
+	require.NoError(t, module.Persist(ctx, birthdayOutput(3, 3), persister))
+	require.NoError(t, module.Persist(ctx, birthdayOutput(3, 3), persister))
+	assert.Len(t, persister.events, 1)
+	assert.Len(t, persister.reminders, 1)
+}
+
+func TestOccasionModule_PastDateRollsToNextYearAndSkipsLateReminder(t *testing.T) {
+	module := &OccasionModule{Occasions: &fakeOccasionStore{seen: map[string]bool{}}}
+	persister := &recordingPersister{}
+
+	// March 3rd already passed; the next occurrence is in 2027
+	require.NoError(t, module.Persist(occasionContext(time.Date(2026, time.March, 4, 8, 0, 0, 0, time.UTC)), birthdayOutput(3, 3), persister))
+	require.Len(t, persister.events, 1)
+	assert.Equal(t, "2027-03-03T09:00:00", persister.events[0].Event.StartTime)
+	require.Len(t, persister.reminders, 1)
+
+	// Mentioned three days ahead: too late for a week's notice
+	persister = &recordingPersister{}
+	module.Occasions = &fakeOccasionStore{seen: map[string]bool{}}
+	require.NoError(t, module.Persist(occasionContext(time.Date(2026, time.February, 28, 8, 0, 0, 0, time.UTC)), birthdayOutput(3, 3), persister))
+	require.Len(t, persister.events, 1)
+	assert.Empty(t, persister.reminders)
+}
+
+func TestNextOccurrence_LeapDay(t *testing.T) {
+	next := nextOccurrence(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), 2, 29)
+	assert.Equal(t, "2028-02-29T09:00:00", next.Format("2006-01-02T15:04:05"))
+}
+
+func TestOccasionModule_RequiresUser(t *testing.T) {
+	module := &OccasionModule{Occasions: &fakeOccasionStore{seen: map[string]bool{}}}
+	err := module.Persist(context.Background(), birthdayOutput(3, 3), &recordingPersister{})
+	assert.Error(t, err)
+}
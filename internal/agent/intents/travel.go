@@ -0,0 +1,213 @@
+package intents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+)
+
+// travelHints are cues a booking is being discussed. Every registered module runs on
+// each message, so the travel module skips the LLM call when none are present.
+var travelHints = []string{
+	"flight", "boarding", "airline", "itinerary", "e-ticket", "eticket", "pnr",
+	"hotel", "check-in", "check in", "reservation", "booking", "train", "departure",
+	"טיסה", "מלון", "רכבת", "הזמנה",
+}
+
+// TravelModule adapts a TravelAnalyzer into an IntentModule. Each itinerary leg is
+// persisted as its own pending calendar event. When Enabled is set, it is consulted for
+// the user attached to the context (agent.WithUserID).
+type TravelModule struct {
+	Analyzer agent.TravelAnalyzer
+	Enabled  func(userID int64) (bool, error)
+}
+
+func (m *TravelModule) IntentName() string { return "travel" }
+
+func (m *TravelModule) AnalyzeMessages(ctx context.Context, in MessageInput) (*ModuleOutput, error) {
+	if m.Analyzer == nil {
+		return nil, fmt.Errorf("travel analyzer is not configured")
+	}
+
+	if reason := m.skipReason(ctx, in.NewMessage.MessageText); reason != "" {
+		return noTravelOutput(reason), nil
+	}
+
+	analysis, err := m.Analyzer.AnalyzeMessages(ctx, in.History, in.NewMessage, in.ExistingEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ModuleOutput{
+		Intent:         "travel",
+		Action:         analysis.Action,
+		Confidence:     analysis.Confidence,
+		Reasoning:      analysis.Reasoning,
+		TravelAnalysis: analysis,
+	}, nil
+}
+
+func (m *TravelModule) AnalyzeEmail(ctx context.Context, in EmailInput) (*ModuleOutput, error) {
+	if m.Analyzer == nil {
+		return nil, fmt.Errorf("travel analyzer is not configured")
+	}
+
+	if reason := m.skipReason(ctx, in.Email.Subject+"\n"+in.Email.Body); reason != "" {
+		return noTravelOutput(reason), nil
+	}
+
+	analysis, err := m.Analyzer.AnalyzeEmail(ctx, in.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ModuleOutput{
+		Intent:         "travel",
+		Action:         analysis.Action,
+		Confidence:     analysis.Confidence,
+		Reasoning:      analysis.Reasoning,
+		TravelAnalysis: analysis,
+	}, nil
+}
+
+// skipReason returns why the LLM call can be skipped, or "" when the content should be analyzed
+func (m *TravelModule) skipReason(ctx context.Context, text string) string {
+	if reason := disabledReason(ctx, m.Enabled, "travel detection"); reason != "" {
+		return reason
+	}
+	if !containsAny(strings.ToLower(text), travelHints) {
+		return "no travel cues"
+	}
+	return ""
+}
+
+func noTravelOutput(reason string) *ModuleOutput {
+	analysis := &agent.TravelAnalysis{Action: "none", Reasoning: reason, Confidence: 1}
+	return &ModuleOutput{
+		Intent:         "travel",
+		Action:         analysis.Action,
+		Confidence:     analysis.Confidence,
+		Reasoning:      analysis.Reasoning,
+		TravelAnalysis: analysis,
+	}
+}
+
+func (m *TravelModule) Validate(_ context.Context, out *ModuleOutput) error {
+	if out == nil || out.TravelAnalysis == nil {
+		return fmt.Errorf("travel output is nil")
+	}
+	if out.TravelAnalysis.Action == "none" || !out.TravelAnalysis.HasTravel {
+		return nil
+	}
+	if out.TravelAnalysis.Action != "create" {
+		return fmt.Errorf("unknown travel action: %s", out.TravelAnalysis.Action)
+	}
+	if out.TravelAnalysis.Itinerary == nil || len(out.TravelAnalysis.Itinerary.Segments) == 0 {
+		return fmt.Errorf("travel create action requires at least one segment")
+	}
+	for i, segment := range out.TravelAnalysis.Itinerary.Segments {
+		if segment.StartTime == "" {
+			return fmt.Errorf("travel segment %d requires start_time", i+1)
+		}
+	}
+	return nil
+}
+
+func (m *TravelModule) Persist(ctx context.Context, out *ModuleOutput, persister Persister) error {
+	if out == nil || out.TravelAnalysis == nil {
+		return fmt.Errorf("travel output is nil")
+	}
+	if out.TravelAnalysis.Action == "none" || !out.TravelAnalysis.HasTravel {
+		return nil
+	}
+
+	itinerary := out.TravelAnalysis.Itinerary
+	var firstErr error
+	for i, segment := range itinerary.Segments {
+		err := persister.PersistEvent(ctx, &agent.EventAnalysis{
+			HasEvent:   true,
+			Action:     "create",
+			Event:      travelSegmentEvent(itinerary, i, segment),
+			Reasoning:  out.TravelAnalysis.Reasoning,
+			Confidence: out.TravelAnalysis.Confidence,
+		})
+		// Keep going so one bad leg doesn't drop the rest of the trip
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("travel segment %d: %w", i+1, err)
+		}
+	}
+	return firstErr
+}
+
+// travelSegmentEvent builds the calendar event for one itinerary leg
+func travelSegmentEvent(itinerary *agent.TravelItinerary, index int, segment agent.TravelSegment) *agent.EventData {
+	event := &agent.EventData{
+		StartTime: segment.StartTime,
+		EndTime:   segment.EndTime,
+	}
+
+	var details []string
+	confirmation := segment.ConfirmationNumber
+	if confirmation == "" {
+		confirmation = itinerary.ConfirmationNumber
+	}
+	if confirmation != "" {
+		details = append(details, "Confirmation: "+confirmation)
+	}
+
+	switch segment.Type {
+	case agent.TravelSegmentHotel:
+		name := segment.Provider
+		if name == "" {
+			name = segment.Address
+		}
+		event.Title = "Hotel: " + name
+		event.Location = segment.Address
+		if event.Location == "" {
+			event.Location = segment.Provider
+		}
+	default:
+		kind := "Flight"
+		providerLabel := "Airline"
+		if segment.Type == agent.TravelSegmentTrain {
+			kind, providerLabel = "Train", "Operator"
+		}
+		event.Title = travelLegTitle(kind, segment)
+		event.Location = segment.Origin
+		if segment.Provider != "" {
+			details = append(details, providerLabel+": "+segment.Provider)
+		}
+	}
+
+	if itinerary.Traveler != "" {
+		details = append(details, "Traveler: "+itinerary.Traveler)
+	}
+	if segment.Details != "" {
+		details = append(details, segment.Details)
+	}
+	if len(itinerary.Segments) > 1 {
+		details = append(details, fmt.Sprintf("Leg %d of %d", index+1, len(itinerary.Segments)))
+	}
+	event.Description = strings.Join(details, "\n")
+
+	return event
+}
+
+// travelLegTitle formats "Flight LY001 TLV → JFK", dropping the parts that are unknown
+func travelLegTitle(kind string, segment agent.TravelSegment) string {
+	title := kind
+	if segment.Number != "" {
+		title += " " + segment.Number
+	}
+	switch {
+	case segment.Origin != "" && segment.Destination != "":
+		title += " " + segment.Origin + " → " + segment.Destination
+	case segment.Destination != "":
+		title += " to " + segment.Destination
+	case segment.Origin != "":
+		title += " from " + segment.Origin
+	}
+	return title
+}
@@ -0,0 +1,114 @@
+package intents
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTravelAnalyzer struct {
+	analysis *agent.TravelAnalysis
+	calls    int
+}
+
+func (f *fakeTravelAnalyzer) AnalyzeMessages(context.Context, []database.MessageRecord, database.MessageRecord, []database.CalendarEvent) (*agent.TravelAnalysis, error) {
+	f.calls++
+	return f.analysis, nil
+}
+
+func (f *fakeTravelAnalyzer) AnalyzeEmail(context.Context, agent.EmailContent) (*agent.TravelAnalysis, error) {
+	f.calls++
+	return f.analysis, nil
+}
+
+func (f *fakeTravelAnalyzer) IsConfigured() bool { return true }
+
+type recordingPersister struct {
+	events    []*agent.EventAnalysis
+	reminders []*agent.ReminderAnalysis
+}
+
+func (r *recordingPersister) PersistEvent(_ context.Context, analysis *agent.EventAnalysis) error {
+	r.events = append(r.events, analysis)
+	return nil
+}
+
+func (r *recordingPersister) PersistReminder(_ context.Context, analysis *agent.ReminderAnalysis) error {
+	r.reminders = append(r.reminders, analysis)
+	return nil
+}
+
+func TestTravelModule_SkipsMessagesWithoutTravelCues(t *testing.T) {
+	analyzer := &fakeTravelAnalyzer{}
+	module := &TravelModule{Analyzer: analyzer}
+
+	out, err := module.AnalyzeMessages(context.Background(), MessageInput{
+		NewMessage: database.MessageRecord{MessageText: "see you at lunch tomorrow"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "none", out.Action)
+	assert.Zero(t, analyzer.calls)
+	require.NoError(t, module.Validate(context.Background(), out))
+}
+
+func TestTravelModule_SkipsUsersWithTravelDetectionOff(t *testing.T) {
+	analyzer := &fakeTravelAnalyzer{}
+	module := &TravelModule{Analyzer: analyzer, Enabled: enabledFor(1)}
+
+	out, err := module.AnalyzeEmail(agent.WithUserID(context.Background(), 2), EmailInput{
+		Email: agent.EmailContent{Subject: "Your flight itinerary", Body: "Boarding at 09:40"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "none", out.Action)
+	assert.Equal(t, "travel detection disabled", out.Reasoning)
+	assert.Zero(t, analyzer.calls)
+}
+
+func TestTravelModule_PersistsOneEventPerSegment(t *testing.T) {
+	analyzer := &fakeTravelAnalyzer{analysis: &agent.TravelAnalysis{
+		HasTravel:  true,
+		Action:     "create",
+		Reasoning:  "El Al e-ticket",
+		Confidence: 0.95,
+		Itinerary: &agent.TravelItinerary{
+			Traveler:           "Dana Levi",
+			ConfirmationNumber: "X7K2PQ",
+			Segments: []agent.TravelSegment{
+				{Type: agent.TravelSegmentFlight, Provider: "El Al", Number: "LY001", Origin: "TLV", Destination: "JFK", StartTime: "2030-05-10T08:30:00+03:00", EndTime: "2030-05-10T13:45:00-04:00", Details: "Seat 32A"},
+				{Type: agent.TravelSegmentHotel, Provider: "The Jane", Address: "113 Jane St, New York", StartTime: "2030-05-10T15:00:00-04:00", ConfirmationNumber: "H-5521"},
+			},
+		},
+	}}
+	module := &TravelModule{Analyzer: analyzer}
+
+	out, err := module.AnalyzeEmail(context.Background(), EmailInput{Email: agent.EmailContent{Subject: "Your El Al flight itinerary"}})
+	require.NoError(t, err)
+	require.NoError(t, module.Validate(context.Background(), out))
+
+	persister := &recordingPersister{}
+	require.NoError(t, module.Persist(context.Background(), out, persister))
+	require.Len(t, persister.events, 2)
+
+	flight := persister.events[0]
+	assert.Equal(t, "create", flight.Action)
+	assert.Equal(t, 0.95, flight.Confidence)
+	assert.Equal(t, "Flight LY001 TLV → JFK", flight.Event.Title)
+	assert.Equal(t, "TLV", flight.Event.Location)
+	assert.Equal(t, "2030-05-10T13:45:00-04:00", flight.Event.EndTime)
+	assert.Equal(t, "Confirmation: X7K2PQ\nAirline: El Al\nTraveler: Dana Levi\nSeat 32A\nLeg 1 of 2", flight.Event.Description)
+
+	hotel := persister.events[1]
+	assert.Equal(t, "Hotel: The Jane", hotel.Event.Title)
+	assert.Equal(t, "113 Jane St, New York", hotel.Event.Location)
+	assert.Contains(t, hotel.Event.Description, "Confirmation: H-5521")
+}
+
+func TestTravelModule_ValidateRejectsEmptyItinerary(t *testing.T) {
+	module := &TravelModule{}
+	err := module.Validate(context.Background(), &ModuleOutput{TravelAnalysis: &agent.TravelAnalysis{HasTravel: true, Action: "create"}})
+	assert.Error(t, err)
+}
@@ -14,6 +14,9 @@ type TargetLanguage struct {
 	Script     string
 	Confidence float64
 	Reliable   bool
+	// Preferred is set when the language is the user's chosen locale rather than the
+	// language of the conversation
+	Preferred bool
 }
 
 type FieldMismatch struct {
@@ -176,11 +179,23 @@ func BuildLanguageInstruction(target TargetLanguage) string {
 		return ""
 	}
 
-	return fmt.Sprintf(
-		"Generate all user-facing text fields (title, description, and location when applicable) in %s (%s), matching the latest triggering discussion language. Do not translate proper nouns, URLs, email addresses, or quoted literals.",
+	source := "matching the latest triggering discussion language"
+	if target.Preferred {
+		source = "the user's preferred language, translating from the discussion language when it differs"
+	}
+	instruction := fmt.Sprintf(
+		"Generate all user-facing text fields (title, description, and location when applicable) in %s (%s), %s. Do not translate proper nouns, URLs, email addresses, or quoted literals.",
 		target.Label,
 		target.Code,
+		source,
 	)
+	if IsRTL(target.Code) {
+		instruction += fmt.Sprintf(
+			" %s is written right-to-left: write it in logical (typed) order, never reverse characters or words, and do not add direction marks.",
+			target.Label,
+		)
+	}
+	return instruction
 }
 
 func BuildCorrectiveRetryInstruction(target TargetLanguage, validation ValidationResult) string {
@@ -111,3 +111,39 @@ func TestBuildLanguageInstructions(t *testing.T) {
 	assert.Contains(t, correction, "title, description")
 	assert.Contains(t, correction, "Hebrew")
 }
+
+func TestResolve(t *testing.T) {
+	t.Run("user locale wins over the message language", func(t *testing.T) {
+		target := Resolve("Meeting with the team tomorrow at 5", "en", "he")
+		assert.Equal(t, "he", target.Code)
+		assert.True(t, target.Reliable)
+		assert.True(t, target.Preferred)
+	})
+
+	t.Run("message language without a locale", func(t *testing.T) {
+		target := Resolve("נפגש מחר בשעה חמש במשרד", "en", "")
+		assert.Equal(t, "he", target.Code)
+		assert.False(t, target.Preferred)
+	})
+
+	t.Run("short message falls back to the channel language", func(t *testing.T) {
+		target := Resolve("ok 8pm?", "he", "")
+		assert.Equal(t, "he", target.Code)
+		assert.Equal(t, "hebrew", target.Script)
+		assert.True(t, target.Reliable)
+	})
+
+	t.Run("unsupported codes are ignored", func(t *testing.T) {
+		target := Resolve("ok 8pm?", "xx", "xx")
+		assert.False(t, target.Reliable)
+	})
+}
+
+func TestBuildLanguageInstruction_PreferredAndRTL(t *testing.T) {
+	instruction := BuildLanguageInstruction(ForLocale("he"))
+	assert.Contains(t, instruction, "the user's preferred language")
+	assert.Contains(t, instruction, "right-to-left")
+
+	instruction = BuildLanguageInstruction(ForLocale("es"))
+	assert.NotContains(t, instruction, "right-to-left")
+}
@@ -0,0 +1,66 @@
+package langpolicy
+
+import "strings"
+
+// supportedLocales are the language codes a user can choose as their locale, in the
+// order clients should list them
+var supportedLocales = []string{"en", "he", "ar", "ru", "es", "fr", "pt", "de", "it"}
+
+// SupportedLocales returns the language codes a user can choose as their locale
+func SupportedLocales() []string {
+	return append([]string(nil), supportedLocales...)
+}
+
+// IsSupportedLocale reports whether code is one of SupportedLocales
+func IsSupportedLocale(code string) bool {
+	for _, supported := range supportedLocales {
+		if code == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRTL reports whether the language is written right-to-left
+func IsRTL(code string) bool {
+	return code == "he" || code == "ar"
+}
+
+// ForLocale returns the target language for a user's chosen locale. It is unreliable
+// (no instruction, no validation) for unsupported codes.
+func ForLocale(code string) TargetLanguage {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if !IsSupportedLocale(code) {
+		return TargetLanguage{Label: "Unknown"}
+	}
+	target := buildTarget(code, scriptForCode(code), true, 1)
+	target.Preferred = true
+	return target
+}
+
+// Resolve picks the language agents should write in. The user's locale wins; otherwise
+// the language detected in text, falling back to the channel's last detected language
+// when text is too short or ambiguous to tell (e.g. "ok 8pm?").
+func Resolve(text, channelLanguage, userLocale string) TargetLanguage {
+	if target := ForLocale(userLocale); target.Reliable {
+		return target
+	}
+	detected := DetectTargetLanguage(text)
+	if detected.Reliable || !IsSupportedLocale(channelLanguage) {
+		return detected
+	}
+	return buildTarget(channelLanguage, scriptForCode(channelLanguage), true, 0.6)
+}
+
+func scriptForCode(code string) string {
+	switch code {
+	case "he":
+		return "hebrew"
+	case "ar":
+		return "arabic"
+	case "ru":
+		return "cyrillic"
+	default:
+		return "latin"
+	}
+}
@@ -0,0 +1,37 @@
+package agent
+
+import "context"
+
+type localeKey struct{}
+
+type channelLanguageKey struct{}
+
+// WithLocale returns a context that makes agents write user-facing text in the user's
+// preferred language instead of the conversation's
+func WithLocale(ctx context.Context, locale string) context.Context {
+	if locale == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext returns the locale attached by WithLocale, or ""
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// WithChannelLanguage returns a context carrying the language last detected in the
+// analyzed channel, used when the new message alone is too short to detect
+func WithChannelLanguage(ctx context.Context, language string) context.Context {
+	if language == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, channelLanguageKey{}, language)
+}
+
+// ChannelLanguageFromContext returns the language attached by WithChannelLanguage, or ""
+func ChannelLanguageFromContext(ctx context.Context) string {
+	language, _ := ctx.Value(channelLanguageKey{}).(string)
+	return language
+}
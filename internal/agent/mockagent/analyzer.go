@@ -0,0 +1,179 @@
+package mockagent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// EventAnalyzer answers event analyses from rules
+type EventAnalyzer struct {
+	rules []Rule
+}
+
+// NewEventAnalyzer creates an event analyzer using the event rules in rules
+func NewEventAnalyzer(rules []Rule) *EventAnalyzer {
+	return &EventAnalyzer{rules: rules}
+}
+
+// AnalyzeMessages matches the new message against the rules
+func (a *EventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	return a.analyze(ctx, newMessage.MessageText, newMessage.Timestamp, existingEvents), nil
+}
+
+// AnalyzeEmail matches the email's subject and body against the rules; updates and
+// deletes apply to events from earlier in the thread
+func (a *EventAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.EventAnalysis, error) {
+	return a.analyze(ctx, email.Subject+"\n"+email.Body, time.Time{}, email.ThreadEvents), nil
+}
+
+// IsConfigured always returns true; the analyzer needs no credentials
+func (a *EventAnalyzer) IsConfigured() bool {
+	return true
+}
+
+func (a *EventAnalyzer) analyze(ctx context.Context, text string, sentAt time.Time, existing []database.CalendarEvent) *agent.EventAnalysis {
+	rule, ok := findRule(a.rules, IntentEvent, text)
+	if !ok {
+		return &agent.EventAnalysis{Action: "none", Reasoning: "no mock rule matched"}
+	}
+	reasoning := fmt.Sprintf("mock rule %q matched", rule.Keyword)
+
+	// Rules were validated when loaded, so the durations parse
+	startIn, _ := parseDuration(rule.StartIn, defaultStartIn)
+	duration, _ := parseDuration(rule.Duration, defaultDuration)
+	start := baseTime(ctx, sentAt).Add(startIn)
+	data := &agent.EventData{
+		Title:       rule.Title,
+		Description: rule.Description,
+		Location:    rule.Location,
+		StartTime:   start.Format(time.RFC3339),
+		EndTime:     start.Add(duration).Format(time.RFC3339),
+	}
+
+	if action := rule.action(); action != ActionCreate {
+		target := findEvent(existing, rule)
+		if target == nil {
+			return &agent.EventAnalysis{Action: "none", Reasoning: reasoning + " but no existing event to " + action}
+		}
+		data.AlfredEventRef = target.ID
+		if data.Title == "" {
+			data.Title = target.Title
+		}
+		if rule.StartIn == "" {
+			// Keep the event's time unless the rule moves it
+			data.StartTime, data.EndTime = "", ""
+		}
+		return &agent.EventAnalysis{HasEvent: true, Action: action, Event: data, Reasoning: reasoning, Confidence: rule.confidence()}
+	}
+
+	return &agent.EventAnalysis{HasEvent: true, Action: ActionCreate, Event: data, Reasoning: reasoning, Confidence: rule.confidence()}
+}
+
+func findEvent(events []database.CalendarEvent, rule Rule) *database.CalendarEvent {
+	for i := range events {
+		if rule.titleMatches(events[i].Title) {
+			return &events[i]
+		}
+	}
+	return nil
+}
+
+// ReminderAnalyzer answers reminder analyses from rules
+type ReminderAnalyzer struct {
+	rules []Rule
+}
+
+// NewReminderAnalyzer creates a reminder analyzer using the reminder rules in rules
+func NewReminderAnalyzer(rules []Rule) *ReminderAnalyzer {
+	return &ReminderAnalyzer{rules: rules}
+}
+
+// AnalyzeMessages matches the new message against the rules
+func (a *ReminderAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingReminders []database.Reminder,
+) (*agent.ReminderAnalysis, error) {
+	return a.analyze(ctx, newMessage.MessageText, newMessage.Timestamp, existingReminders), nil
+}
+
+// AnalyzeEmail matches the email's subject and body against the rules
+func (a *ReminderAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.ReminderAnalysis, error) {
+	return a.analyze(ctx, email.Subject+"\n"+email.Body, time.Time{}, nil), nil
+}
+
+// IsConfigured always returns true; the analyzer needs no credentials
+func (a *ReminderAnalyzer) IsConfigured() bool {
+	return true
+}
+
+func (a *ReminderAnalyzer) analyze(ctx context.Context, text string, sentAt time.Time, existing []database.Reminder) *agent.ReminderAnalysis {
+	rule, ok := findRule(a.rules, IntentReminder, text)
+	if !ok {
+		return &agent.ReminderAnalysis{Action: "none", Reasoning: "no mock rule matched"}
+	}
+	reasoning := fmt.Sprintf("mock rule %q matched", rule.Keyword)
+
+	startIn, _ := parseDuration(rule.StartIn, defaultStartIn)
+	priority := rule.Priority
+	if priority == "" {
+		priority = "normal"
+	}
+	data := &agent.ReminderData{
+		Title:       rule.Title,
+		Description: rule.Description,
+		DueDate:     baseTime(ctx, sentAt).Add(startIn).Format(time.RFC3339),
+		Priority:    priority,
+	}
+
+	if action := rule.action(); action != ActionCreate {
+		target := findReminder(existing, rule)
+		if target == nil {
+			return &agent.ReminderAnalysis{Action: "none", Reasoning: reasoning + " but no existing reminder to " + action}
+		}
+		data.AlfredReminderRef = target.ID
+		if data.Title == "" {
+			data.Title = target.Title
+		}
+		if rule.StartIn == "" {
+			data.DueDate = ""
+		}
+		return &agent.ReminderAnalysis{HasReminder: true, Action: action, Reminder: data, Reasoning: reasoning, Confidence: rule.confidence()}
+	}
+
+	return &agent.ReminderAnalysis{HasReminder: true, Action: ActionCreate, Reminder: data, Reasoning: reasoning, Confidence: rule.confidence()}
+}
+
+func findReminder(reminders []database.Reminder, rule Rule) *database.Reminder {
+	for i := range reminders {
+		if rule.titleMatches(reminders[i].Title) {
+			return &reminders[i]
+		}
+	}
+	return nil
+}
+
+// baseTime is the time rule offsets count from: the analyzed message's send time, in
+// the user's timezone when known
+func baseTime(ctx context.Context, sentAt time.Time) time.Time {
+	base := time.Now()
+	if t, ok := agent.MessageTimeFromContext(ctx); ok {
+		base = t
+	} else if !sentAt.IsZero() {
+		base = sentAt
+	}
+	if loc, ok := agent.TimezoneFromContext(ctx); ok {
+		base = base.In(loc)
+	}
+	return base.Truncate(time.Minute)
+}
@@ -0,0 +1,130 @@
+package mockagent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ agent.EventAnalyzer    = (*EventAnalyzer)(nil)
+	_ agent.ReminderAnalyzer = (*ReminderAnalyzer)(nil)
+)
+
+func TestEventAnalyzer(t *testing.T) {
+	analyzer := NewEventAnalyzer(DefaultRules())
+	sentAt := time.Date(2026, 3, 10, 18, 30, 0, 0, time.UTC)
+
+	t.Run("creates an event relative to the message time", func(t *testing.T) {
+		msg := database.MessageRecord{MessageText: "Dinner at Luigi's tomorrow?", Timestamp: sentAt}
+		analysis, err := analyzer.AnalyzeMessages(context.Background(), nil, msg, nil)
+		require.NoError(t, err)
+
+		assert.True(t, analysis.HasEvent)
+		assert.Equal(t, ActionCreate, analysis.Action)
+		assert.Equal(t, "Dinner", analysis.Event.Title)
+		assert.Equal(t, "2026-03-11T18:30:00Z", analysis.Event.StartTime)
+		assert.Equal(t, "2026-03-11T20:30:00Z", analysis.Event.EndTime)
+		assert.Equal(t, defaultConfidence, analysis.Confidence)
+
+		again, err := analyzer.AnalyzeMessages(context.Background(), nil, msg, nil)
+		require.NoError(t, err)
+		assert.Equal(t, analysis, again, "the same message always gets the same answer")
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		msg := database.MessageRecord{MessageText: "how are you", Timestamp: sentAt}
+		analysis, err := analyzer.AnalyzeMessages(context.Background(), nil, msg, nil)
+		require.NoError(t, err)
+		assert.False(t, analysis.HasEvent)
+		assert.Equal(t, "none", analysis.Action)
+	})
+
+	t.Run("delete targets an existing event", func(t *testing.T) {
+		msg := database.MessageRecord{MessageText: "We have to cancel", Timestamp: sentAt}
+		analysis, err := analyzer.AnalyzeMessages(context.Background(), nil, msg, nil)
+		require.NoError(t, err)
+		assert.False(t, analysis.HasEvent, "nothing to delete")
+
+		existing := []database.CalendarEvent{{ID: 7, Title: "Dinner"}}
+		analysis, err = analyzer.AnalyzeMessages(context.Background(), nil, msg, existing)
+		require.NoError(t, err)
+		assert.True(t, analysis.HasEvent)
+		assert.Equal(t, ActionDelete, analysis.Action)
+		assert.Equal(t, int64(7), analysis.Event.AlfredEventRef)
+		assert.Empty(t, analysis.Event.StartTime)
+	})
+
+	t.Run("email uses subject and message time from context", func(t *testing.T) {
+		ctx := agent.WithMessageTime(context.Background(), sentAt)
+		analysis, err := analyzer.AnalyzeEmail(ctx, agent.EmailContent{Subject: "Team meeting", Body: "Agenda attached"})
+		require.NoError(t, err)
+		assert.Equal(t, "Meeting", analysis.Event.Title)
+		assert.Equal(t, "2026-03-12T18:30:00Z", analysis.Event.StartTime)
+	})
+}
+
+func TestReminderAnalyzer(t *testing.T) {
+	analyzer := NewReminderAnalyzer(DefaultRules())
+	sentAt := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	msg := database.MessageRecord{MessageText: "Don't forget to PAY the electricity", Timestamp: sentAt}
+	analysis, err := analyzer.AnalyzeMessages(context.Background(), nil, msg, nil)
+	require.NoError(t, err)
+	assert.True(t, analysis.HasReminder)
+	assert.Equal(t, "Pay bill", analysis.Reminder.Title)
+	assert.Equal(t, "2026-03-13T09:00:00Z", analysis.Reminder.DueDate)
+	assert.Equal(t, "high", analysis.Reminder.Priority)
+
+	msg.MessageText = "Dinner tomorrow"
+	analysis, err = analyzer.AnalyzeMessages(context.Background(), nil, msg, nil)
+	require.NoError(t, err)
+	assert.False(t, analysis.HasReminder, "event rules don't produce reminders")
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"keyword": "standup", "intent": "event", "title": "Standup", "start_in": "15h", "duration": "15m"},
+		{"keyword": "moved", "intent": "event", "action": "update", "title": "Standup", "start_in": "16h"}
+	]`), 0o600))
+	rules, err := LoadRules(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	analyzer := NewEventAnalyzer(rules)
+	existing := []database.CalendarEvent{{ID: 3, Title: "Lunch"}, {ID: 4, Title: "Daily standup"}}
+	msg := database.MessageRecord{MessageText: "standup moved", Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	analysis, err := analyzer.AnalyzeMessages(context.Background(), nil, msg, existing)
+	require.NoError(t, err)
+	assert.Equal(t, ActionCreate, analysis.Action, "rules are tried in order")
+
+	msg.MessageText = "it moved"
+	analysis, err = analyzer.AnalyzeMessages(context.Background(), nil, msg, existing)
+	require.NoError(t, err)
+	assert.Equal(t, ActionUpdate, analysis.Action)
+	assert.Equal(t, int64(4), analysis.Event.AlfredEventRef)
+	assert.Equal(t, "2026-01-01T16:00:00Z", analysis.Event.StartTime)
+
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`[
+		{"keyword": "", "intent": "event", "title": "x"},
+		{"keyword": "a", "intent": "travel", "title": "x"},
+		{"keyword": "b", "intent": "event"},
+		{"keyword": "c", "intent": "event", "title": "x", "start_in": "tomorrow"}
+	]`), 0o600))
+	_, err = LoadRules(bad)
+	require.Error(t, err)
+	for _, want := range []string{"keyword is required", "intent must be", "title is required", "start_in"} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
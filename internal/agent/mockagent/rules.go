@@ -0,0 +1,165 @@
+// Package mockagent provides rule-based event and reminder analyzers that return canned
+// results for messages containing configured keywords. End-to-end suites use them to
+// run without LLM API costs or nondeterministic answers.
+package mockagent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Intents a rule can produce
+const (
+	IntentEvent    = "event"
+	IntentReminder = "reminder"
+)
+
+// Actions a rule can take
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+const (
+	defaultStartIn    = 24 * time.Hour
+	defaultDuration   = time.Hour
+	defaultConfidence = 0.9
+)
+
+// Rule turns a message containing Keyword into a canned analysis. Times are relative
+// to when the message was sent, so the same message always produces the same result.
+type Rule struct {
+	// Keyword is matched case-insensitively in the message text, or an email's subject
+	// and body
+	Keyword string `json:"keyword"`
+	// Intent is "event" or "reminder"
+	Intent string `json:"intent"`
+	// Action is "create" (default), "update" or "delete". Update and delete apply to the
+	// first existing item whose title contains Title, or the first one if Title is empty.
+	Action      string `json:"action,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Location    string `json:"location,omitempty"`
+	// StartIn is a Go duration after the message: an event's start or a reminder's due
+	// date (default 24h)
+	StartIn string `json:"start_in,omitempty"`
+	// Duration is an event's length (default 1h)
+	Duration string `json:"duration,omitempty"`
+	// Priority is a reminder's priority: low, normal (default) or high
+	Priority   string  `json:"priority,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// DefaultRules are used when no rules file is given
+func DefaultRules() []Rule {
+	return []Rule{
+		{Keyword: "cancel", Intent: IntentEvent, Action: ActionDelete},
+		{Keyword: "dinner", Intent: IntentEvent, Title: "Dinner", StartIn: "24h", Duration: "2h", Location: "Restaurant"},
+		{Keyword: "lunch", Intent: IntentEvent, Title: "Lunch", StartIn: "24h", Duration: "1h"},
+		{Keyword: "meeting", Intent: IntentEvent, Title: "Meeting", StartIn: "48h", Duration: "1h"},
+		{Keyword: "remind me", Intent: IntentReminder, Title: "Reminder", StartIn: "24h"},
+		{Keyword: "pay", Intent: IntentReminder, Title: "Pay bill", StartIn: "72h", Priority: "high"},
+	}
+}
+
+// LoadRules reads a JSON array of rules from path
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock rules: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse mock rules %s: %w", path, err)
+	}
+	if err := ValidateRules(rules); err != nil {
+		return nil, fmt.Errorf("invalid mock rules %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ValidateRules reports every rule that can't produce an analysis
+func ValidateRules(rules []Rule) error {
+	var errs []error
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d (%q): %w", i, rule.Keyword, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r Rule) validate() error {
+	if strings.TrimSpace(r.Keyword) == "" {
+		return errors.New("keyword is required")
+	}
+	if r.Intent != IntentEvent && r.Intent != IntentReminder {
+		return fmt.Errorf("intent must be %s or %s", IntentEvent, IntentReminder)
+	}
+	switch r.action() {
+	case ActionCreate:
+		if r.Title == "" {
+			return errors.New("title is required to create")
+		}
+	case ActionUpdate, ActionDelete:
+	default:
+		return fmt.Errorf("action must be %s, %s or %s", ActionCreate, ActionUpdate, ActionDelete)
+	}
+	if _, err := parseDuration(r.StartIn, defaultStartIn); err != nil {
+		return fmt.Errorf("start_in: %w", err)
+	}
+	if _, err := parseDuration(r.Duration, defaultDuration); err != nil {
+		return fmt.Errorf("duration: %w", err)
+	}
+	switch r.Priority {
+	case "", "low", "normal", "high":
+	default:
+		return errors.New("priority must be low, normal or high")
+	}
+	return nil
+}
+
+func (r Rule) action() string {
+	if r.Action == "" {
+		return ActionCreate
+	}
+	return r.Action
+}
+
+func (r Rule) confidence() float64 {
+	if r.Confidence == 0 {
+		return defaultConfidence
+	}
+	return r.Confidence
+}
+
+func (r Rule) matches(text string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(r.Keyword))
+}
+
+// titleMatches reports whether an existing item is the rule's update or delete target
+func (r Rule) titleMatches(title string) bool {
+	return r.Title == "" || strings.Contains(strings.ToLower(title), strings.ToLower(r.Title))
+}
+
+func parseDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// findRule returns the first rule for intent matching text
+func findRule(rules []Rule, intent, text string) (Rule, bool) {
+	for _, rule := range rules {
+		if rule.Intent == intent && rule.matches(text) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
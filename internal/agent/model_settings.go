@@ -0,0 +1,34 @@
+package agent
+
+import "context"
+
+// Model tiers a user can choose between
+const (
+	ModelTierFast     = "fast"
+	ModelTierAccurate = "accurate"
+)
+
+// ModelSettings overrides the model and temperature for a single analysis.
+// Zero values fall back to the agent's configuration.
+type ModelSettings struct {
+	Tier        string   // ModelTierFast or ModelTierAccurate ("" = accurate)
+	Temperature *float64 // nil = agent default
+}
+
+type modelSettingsKey struct{}
+
+// WithModelSettings returns a context that makes agents use the given settings
+func WithModelSettings(ctx context.Context, settings ModelSettings) context.Context {
+	return context.WithValue(ctx, modelSettingsKey{}, settings)
+}
+
+// ModelSettingsFromContext returns the settings attached by WithModelSettings
+func ModelSettingsFromContext(ctx context.Context) (ModelSettings, bool) {
+	settings, ok := ctx.Value(modelSettingsKey{}).(ModelSettings)
+	return settings, ok
+}
+
+// IsValidModelTier reports whether tier is a known model tier
+func IsValidModelTier(tier string) bool {
+	return tier == ModelTierFast || tier == ModelTierAccurate
+}
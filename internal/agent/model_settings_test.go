@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentAppliesModelSettingsFromContext(t *testing.T) {
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, body)
+		_, _ = w.Write([]byte(`{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"done"}}],"usage":{}}`))
+	}))
+	defer server.Close()
+
+	a := NewAgent(AgentConfig{
+		Name:        "test",
+		Provider:    ProviderOpenAI,
+		APIKey:      "key",
+		Model:       "accurate-model",
+		FastModel:   "fast-model",
+		Temperature: 0.4,
+	})
+	a.apiClient.(*OpenAIClient).apiURL = server.URL
+
+	input := AgentInput{Messages: []Message{{Role: "user", Content: []ContentBlock{TextBlock{Type: "text", Text: "hi"}}}}}
+
+	_, err := a.Execute(context.Background(), input)
+	require.NoError(t, err)
+
+	zero := 0.0
+	ctx := WithModelSettings(context.Background(), ModelSettings{Tier: ModelTierFast, Temperature: &zero})
+	_, err = a.Execute(ctx, input)
+	require.NoError(t, err)
+
+	ctx = WithModelSettings(context.Background(), ModelSettings{Tier: ModelTierAccurate})
+	_, err = a.Execute(ctx, input)
+	require.NoError(t, err)
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, "accurate-model", requests[0]["model"])
+	assert.Equal(t, 0.4, requests[0]["temperature"])
+	assert.Equal(t, "fast-model", requests[1]["model"])
+	assert.Equal(t, 0.0, requests[1]["temperature"])
+	assert.Equal(t, "accurate-model", requests[2]["model"])
+	assert.Equal(t, 0.4, requests[2]["temperature"])
+}
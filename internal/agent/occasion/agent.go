@@ -0,0 +1,202 @@
+package occasion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/tools"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// Agent handles birthday and anniversary detection using tool calling
+type Agent struct {
+	*agent.Agent
+}
+
+// Config configures the occasion agent
+type Config struct {
+	Provider    string // "anthropic" (default) or "openai"
+	APIKey      string
+	Model       string
+	FastModel   string // model for users on the "fast" tier
+	Temperature float64
+}
+
+// NewAgent creates a new occasion detection agent
+func NewAgent(cfg Config) *Agent {
+	baseAgent := agent.NewAgent(agent.AgentConfig{
+		Name:         "occasion-detector",
+		Provider:     cfg.Provider,
+		APIKey:       cfg.APIKey,
+		Model:        cfg.Model,
+		FastModel:    cfg.FastModel,
+		Temperature:  cfg.Temperature,
+		SystemPrompt: OccasionAnalyzerSystemPrompt,
+	})
+
+	baseAgent.MustRegisterTool(tools.GetCurrentDateTimeTool, tools.HandleGetCurrentDateTime)
+
+	baseAgent.MustRegisterTool(tools.RecordOccasionTool, tools.HandleRecordOccasion)
+	baseAgent.MustRegisterTool(tools.NoOccasionActionTool, tools.HandleNoOccasionAction)
+
+	return &Agent{Agent: baseAgent}
+}
+
+// AnalyzeMessages analyzes chat messages for birthdays and anniversaries
+// Implements agent.OccasionAnalyzer interface
+func (a *Agent) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+) (*agent.OccasionAnalysis, error) {
+	analysis, err := a.executePromptAndParse(ctx, buildUserPrompt(history, newMessage))
+	if err != nil {
+		return nil, fmt.Errorf("agent execution failed: %w", err)
+	}
+	return analysis, nil
+}
+
+// AnalyzeEmail analyzes an email for birthdays and anniversaries
+// Implements agent.OccasionAnalyzer interface
+func (a *Agent) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.OccasionAnalysis, error) {
+	analysis, err := a.executePromptAndParse(ctx, buildEmailPrompt(email))
+	if err != nil {
+		return nil, fmt.Errorf("email analysis failed: %w", err)
+	}
+	return analysis, nil
+}
+
+// IsConfigured returns true if the agent is properly configured
+func (a *Agent) IsConfigured() bool {
+	return a.Agent.IsConfigured()
+}
+
+// buildUserPrompt constructs the prompt with message history
+func buildUserPrompt(history []database.MessageRecord, newMessage database.MessageRecord) string {
+	var prompt bytes.Buffer
+
+	prompt.WriteString("## Message History (last messages from this channel)\n\n")
+	for _, msg := range history {
+		if msg.ID == newMessage.ID {
+			continue
+		}
+		prompt.WriteString(fmt.Sprintf("[%s] %s: %s\n",
+			msg.Timestamp.Format("2006-01-02 15:04"),
+			msg.SenderName,
+			msg.MessageText,
+		))
+	}
+
+	prompt.WriteString("\n## New Message (just received)\n\n")
+	prompt.WriteString(fmt.Sprintf("[%s] %s: %s\n",
+		newMessage.Timestamp.Format("2006-01-02 15:04"),
+		newMessage.SenderName,
+		newMessage.MessageText,
+	))
+
+	writeDateTimeReference(&prompt)
+	prompt.WriteString("\nAnalyze these messages using the available tools and record any birthday or anniversary date.")
+
+	return prompt.String()
+}
+
+// buildEmailPrompt constructs the prompt for email analysis
+func buildEmailPrompt(email agent.EmailContent) string {
+	var prompt bytes.Buffer
+
+	prompt.WriteString("## Email to Analyze\n\n")
+	prompt.WriteString(fmt.Sprintf("**From:** %s\n", email.From))
+	prompt.WriteString(fmt.Sprintf("**To:** %s\n", email.To))
+	prompt.WriteString(fmt.Sprintf("**Date:** %s\n", email.Date))
+	prompt.WriteString(fmt.Sprintf("**Subject:** %s\n\n", email.Subject))
+	prompt.WriteString("**Body:**\n")
+	prompt.WriteString(truncateBody(email.Body, 8000))
+	prompt.WriteString("\n")
+
+	writeDateTimeReference(&prompt)
+	prompt.WriteString("\nAnalyze this email using the available tools and record any birthday or anniversary date.")
+
+	return prompt.String()
+}
+
+func writeDateTimeReference(prompt *bytes.Buffer) {
+	prompt.WriteString("\n## Current Date/Time Reference\n\n")
+	now := time.Now()
+	prompt.WriteString(fmt.Sprintf("Current time: %s (%s)\n", now.Format("2006-01-02 15:04:05 Monday -07:00"), now.Location().String()))
+}
+
+func truncateBody(body string, maxLen int) string {
+	if len(body) <= maxLen {
+		return body
+	}
+	return body[:maxLen] + "\n\n[... content truncated ...]"
+}
+
+func (a *Agent) executePromptAndParse(ctx context.Context, userPrompt string) (*agent.OccasionAnalysis, error) {
+	input := agent.AgentInput{
+		Messages: []agent.Message{
+			{
+				Role: "user",
+				Content: []agent.ContentBlock{
+					agent.TextBlock{Type: "text", Text: userPrompt},
+				},
+			},
+		},
+		MaxTurns: 4, // Allow datetime lookup + action + final response
+	}
+
+	output, err := a.Execute(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAgentOutput(output)
+}
+
+// parseAgentOutput converts agent output to OccasionAnalysis
+func parseAgentOutput(output *agent.AgentOutput) (*agent.OccasionAnalysis, error) {
+	actionCalls := make([]*agent.ToolCall, 0, 1)
+	for i := range output.ToolCalls {
+		call := &output.ToolCalls[i]
+		switch call.Name {
+		case tools.RecordOccasionTool.Name, tools.NoOccasionActionTool.Name:
+			actionCalls = append(actionCalls, call)
+		}
+	}
+
+	switch {
+	case len(actionCalls) == 0:
+		return &agent.OccasionAnalysis{Action: "none", Reasoning: "No action tool was called"}, nil
+	case len(actionCalls) > 1:
+		return &agent.OccasionAnalysis{Action: "none", Reasoning: "Ambiguous tool output: multiple action tools called"}, nil
+	case actionCalls[0].Error != nil:
+		return &agent.OccasionAnalysis{Action: "none", Reasoning: fmt.Sprintf("Action tool failed: %v", actionCalls[0].Error)}, nil
+	}
+
+	var result struct {
+		Action     string                     `json:"action"`
+		Occasion   *tools.RecordOccasionInput `json:"occasion"`
+		Reasoning  string                     `json:"reasoning"`
+		Confidence float64                    `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(actionCalls[0].Output), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse action result: %w", err)
+	}
+
+	analysis := &agent.OccasionAnalysis{
+		Action:     result.Action,
+		Reasoning:  result.Reasoning,
+		Confidence: result.Confidence,
+	}
+	if result.Action == "create" && result.Occasion != nil {
+		analysis.HasOccasion = true
+		analysis.Occasion = &result.Occasion.OccasionData
+		analysis.Reasoning = result.Occasion.Reasoning
+		analysis.Confidence = result.Occasion.Confidence
+	}
+	return analysis, nil
+}
@@ -0,0 +1,52 @@
+package occasion
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgentOutput_RecordOccasion(t *testing.T) {
+	toolOutput, err := tools.HandleRecordOccasion(context.Background(), map[string]any{
+		"kind":       "anniversary",
+		"person":     "Dana and Yoni",
+		"title":      "Dana & Yoni's anniversary",
+		"month":      6,
+		"day":        14,
+		"year":       2015,
+		"confidence": 0.85,
+		"reasoning":  "Wedding date mentioned",
+	})
+	require.NoError(t, err)
+
+	result, err := parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "get_current_datetime", Output: `{}`},
+		{Name: "record_occasion", Output: toolOutput},
+	}})
+	require.NoError(t, err)
+
+	assert.True(t, result.HasOccasion)
+	assert.Equal(t, "create", result.Action)
+	assert.Equal(t, 0.85, result.Confidence)
+	require.NotNil(t, result.Occasion)
+	assert.Equal(t, "anniversary", result.Occasion.Kind)
+	assert.Equal(t, 2015, result.Occasion.Year)
+}
+
+func TestParseAgentOutput_AmbiguousOrMissingAction(t *testing.T) {
+	result, err := parseAgentOutput(&agent.AgentOutput{})
+	require.NoError(t, err)
+	assert.Equal(t, "none", result.Action)
+
+	result, err = parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "no_occasion_action", Output: `{"action":"none"}`},
+		{Name: "record_occasion", Output: `{"action":"create"}`},
+	}})
+	require.NoError(t, err)
+	assert.False(t, result.HasOccasion)
+	assert.Contains(t, result.Reasoning, "multiple action tools")
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// OccasionAnalyzer is the interface for birthday and anniversary detection
+type OccasionAnalyzer interface {
+	// AnalyzeMessages analyzes chat messages for personal yearly dates
+	AnalyzeMessages(
+		ctx context.Context,
+		history []database.MessageRecord,
+		newMessage database.MessageRecord,
+	) (*OccasionAnalysis, error)
+
+	// AnalyzeEmail analyzes an email for personal yearly dates
+	AnalyzeEmail(ctx context.Context, email EmailContent) (*OccasionAnalysis, error)
+
+	// IsConfigured returns true if the analyzer is properly configured
+	IsConfigured() bool
+}
+
+// OccasionAnalysis represents the result of occasion analysis
+type OccasionAnalysis struct {
+	HasOccasion bool          `json:"has_occasion"`
+	Action      string        `json:"action"` // "create", "none"
+	Occasion    *OccasionData `json:"occasion,omitempty"`
+	Reasoning   string        `json:"reasoning"`
+	Confidence  float64       `json:"confidence"`
+}
+
+// OccasionData is a birthday or anniversary: a person and a day of the year
+type OccasionData struct {
+	Kind   string `json:"kind"`           // birthday, anniversary
+	Person string `json:"person"`         // Whose occasion, as the user refers to them ("Mom", "Dana and Avi")
+	Title  string `json:"title"`          // Calendar title in the conversation's language ("Mom's birthday")
+	Month  int    `json:"month"`          // 1-12
+	Day    int    `json:"day"`            // 1-31
+	Year   int    `json:"year,omitempty"` // Birth or wedding year, when mentioned
+}
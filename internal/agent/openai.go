@@ -0,0 +1,319 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOpenAIURL   = "https://api.openai.com/v1/chat/completions"
+	defaultOpenAIModel = "gpt-4o"
+)
+
+// OpenAIClient talks to the OpenAI Chat Completions API using function calling.
+// It translates the Anthropic-style Message/ContentBlock conversation used by
+// Agent to and from the OpenAI wire format, so agents run unchanged on either provider.
+type OpenAIClient struct {
+	apiKey      string
+	model       string
+	apiURL      string
+	httpClient  *http.Client
+	temperature float64
+}
+
+// NewOpenAIClient creates a new OpenAI API client
+func NewOpenAIClient(apiKey, model string, temperature float64) *OpenAIClient {
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	if temperature <= 0 {
+		temperature = 0.1
+	}
+
+	return &OpenAIClient{
+		apiKey:      apiKey,
+		model:       model,
+		apiURL:      defaultOpenAIURL,
+		temperature: temperature,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+func (c *OpenAIClient) setTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+type openAIRequest struct {
+	Model               string          `json:"model"`
+	Messages            []openAIMessage `json:"messages"`
+	Tools               []openAITool    `json:"tools,omitempty"`
+	ToolChoice          any             `json:"tool_choice,omitempty"`
+	Temperature         float64         `json:"temperature"`
+	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"` // Always "function"
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"` // Always "function"
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded object
+	} `json:"function"`
+}
+
+type openAIResponse struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+type openAIErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+// Call makes a request to the OpenAI API
+func (c *OpenAIClient) Call(ctx context.Context, messages []Message, opts CallOptions) (*APIResponse, error) {
+	req := openAIRequest{
+		Model:               opts.model(c.model),
+		Messages:            convertMessagesToOpenAI(opts.System, messages),
+		Temperature:         opts.temperature(c.temperature),
+		MaxCompletionTokens: opts.MaxTokens,
+	}
+	if req.MaxCompletionTokens <= 0 {
+		req.MaxCompletionTokens = defaultMaxTokens
+	}
+
+	if len(opts.Tools) > 0 {
+		req.Tools = make([]openAITool, len(opts.Tools))
+		for i, tool := range opts.Tools {
+			req.Tools[i] = openAITool{
+				Type: "function",
+				Function: openAIFunction{
+					Name:        tool.Name,
+					Description: tool.Description,
+					Parameters:  tool.InputSchema,
+				},
+			}
+		}
+
+		switch opts.ToolChoice {
+		case "":
+		case "auto":
+			req.ToolChoice = "auto"
+		case "any":
+			req.ToolChoice = "required"
+		default:
+			req.ToolChoice = map[string]any{
+				"type":     "function",
+				"function": map[string]string{"name": opts.ToolChoice},
+			}
+		}
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.apiURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	if err := waitForProvider(ctx, ProviderOpenAI); err != nil {
+		return nil, fmt.Errorf("rate limit wait canceled: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, formatOpenAIError(resp.StatusCode, body)
+	}
+
+	var apiResp openAIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(apiResp.Choices) == 0 {
+		return nil, fmt.Errorf("API error: response contained no choices")
+	}
+
+	choice := apiResp.Choices[0]
+	var content []ContentBlock
+	if choice.Message.Content != nil && *choice.Message.Content != "" {
+		content = append(content, TextBlock{Type: "text", Text: *choice.Message.Content})
+	}
+	for _, call := range choice.Message.ToolCalls {
+		input := map[string]any{}
+		if strings.TrimSpace(call.Function.Arguments) != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &input); err != nil {
+				return nil, fmt.Errorf("failed to parse arguments for tool %s: %w", call.Function.Name, err)
+			}
+		}
+		content = append(content, ToolUseBlock{
+			Type:  "tool_use",
+			ID:    call.ID,
+			Name:  call.Function.Name,
+			Input: input,
+		})
+	}
+
+	return &APIResponse{
+		Content:    content,
+		StopReason: openAIStopReason(choice.FinishReason, len(choice.Message.ToolCalls) > 0),
+		Model:      req.Model,
+		Usage: UsageStats{
+			InputTokens:  apiResp.Usage.PromptTokens,
+			OutputTokens: apiResp.Usage.CompletionTokens,
+			TotalTokens:  apiResp.Usage.PromptTokens + apiResp.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+// IsConfigured returns true if the client has an API key
+func (c *OpenAIClient) IsConfigured() bool {
+	return c.apiKey != ""
+}
+
+// openAIStopReason maps OpenAI finish reasons onto the Anthropic stop reasons
+// the agent loop understands.
+func openAIStopReason(finishReason string, hasToolCalls bool) string {
+	switch finishReason {
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "stop":
+		// Forced tool choice reports "stop" even though tool calls were returned
+		if hasToolCalls {
+			return "tool_use"
+		}
+		return "end_turn"
+	case "length":
+		return "max_tokens"
+	default:
+		return finishReason
+	}
+}
+
+// convertMessagesToOpenAI flattens the block-based conversation into OpenAI chat
+// messages: tool_use blocks become assistant tool_calls and each tool_result
+// becomes its own "tool" role message.
+func convertMessagesToOpenAI(system string, messages []Message) []openAIMessage {
+	var result []openAIMessage
+	if system != "" {
+		result = append(result, openAIMessage{Role: "system", Content: &system})
+	}
+
+	for _, msg := range messages {
+		var texts []string
+		var toolCalls []openAIToolCall
+		var toolResults []openAIMessage
+
+		for _, block := range msg.Content {
+			switch b := block.(type) {
+			case TextBlock:
+				texts = append(texts, b.Text)
+			case ToolUseBlock:
+				args, err := json.Marshal(b.Input)
+				if err != nil || b.Input == nil {
+					args = []byte("{}")
+				}
+				call := openAIToolCall{ID: b.ID, Type: "function"}
+				call.Function.Name = b.Name
+				call.Function.Arguments = string(args)
+				toolCalls = append(toolCalls, call)
+			case ToolResultBlock:
+				resultContent := b.Content
+				if b.IsError {
+					resultContent = "Error: " + resultContent
+				}
+				toolResults = append(toolResults, openAIMessage{
+					Role:       "tool",
+					Content:    &resultContent,
+					ToolCallID: b.ToolUseID,
+				})
+			}
+		}
+
+		// Tool results must directly follow the assistant message that requested them
+		result = append(result, toolResults...)
+
+		if len(texts) == 0 && len(toolCalls) == 0 {
+			continue
+		}
+		out := openAIMessage{Role: msg.Role, ToolCalls: toolCalls}
+		if len(texts) > 0 {
+			text := strings.Join(texts, "\n\n")
+			out.Content = &text
+		}
+		result = append(result, out)
+	}
+
+	return result
+}
+
+func formatOpenAIError(statusCode int, body []byte) error {
+	var parsed openAIErrorResponse
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error.Message != "" {
+		errorMessage := strings.TrimSpace(parsed.Error.Message)
+		errorType := strings.TrimSpace(parsed.Error.Type)
+		if parsed.Error.Code != "" {
+			return fmt.Errorf("API error (status %d, code=%s): %s - %s", statusCode, parsed.Error.Code, errorType, errorMessage)
+		}
+		return fmt.Errorf("API error (status %d): %s - %s", statusCode, errorType, errorMessage)
+	}
+
+	raw := strings.TrimSpace(string(body))
+	if raw == "" {
+		return fmt.Errorf("API error (status %d)", statusCode)
+	}
+	return fmt.Errorf("API error (status %d): %s", statusCode, raw)
+}
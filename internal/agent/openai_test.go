@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertMessagesToOpenAI(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: []ContentBlock{TextBlock{Type: "text", Text: "Dinner tomorrow at 8?"}}},
+		{Role: "assistant", Content: []ContentBlock{
+			ToolUseBlock{Type: "tool_use", ID: "call_1", Name: "extract_datetime", Input: map[string]any{"text": "tomorrow at 8"}},
+		}},
+		{Role: "user", Content: []ContentBlock{
+			ToolResultBlock{Type: "tool_result", ToolUseID: "call_1", Content: "bad input", IsError: true},
+		}},
+	}
+
+	out := convertMessagesToOpenAI("system prompt", messages)
+	require.Len(t, out, 4)
+
+	assert.Equal(t, "system", out[0].Role)
+	assert.Equal(t, "system prompt", *out[0].Content)
+
+	assert.Equal(t, "user", out[1].Role)
+	assert.Equal(t, "Dinner tomorrow at 8?", *out[1].Content)
+
+	assert.Equal(t, "assistant", out[2].Role)
+	assert.Nil(t, out[2].Content)
+	require.Len(t, out[2].ToolCalls, 1)
+	assert.Equal(t, "call_1", out[2].ToolCalls[0].ID)
+	assert.Equal(t, "extract_datetime", out[2].ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"text":"tomorrow at 8"}`, out[2].ToolCalls[0].Function.Arguments)
+
+	assert.Equal(t, "tool", out[3].Role)
+	assert.Equal(t, "call_1", out[3].ToolCallID)
+	assert.Equal(t, "Error: bad input", *out[3].Content)
+}
+
+func TestOpenAIStopReason(t *testing.T) {
+	assert.Equal(t, "tool_use", openAIStopReason("tool_calls", true))
+	assert.Equal(t, "tool_use", openAIStopReason("stop", true))
+	assert.Equal(t, "end_turn", openAIStopReason("stop", false))
+	assert.Equal(t, "max_tokens", openAIStopReason("length", false))
+}
+
+func TestOpenAIClientCall(t *testing.T) {
+	var captured map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&captured))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"choices": [{
+				"finish_reason": "tool_calls",
+				"message": {
+					"role": "assistant",
+					"content": null,
+					"tool_calls": [{"id": "call_9", "type": "function", "function": {"name": "no_action", "arguments": "{\"reasoning\":\"chit-chat\"}"}}]
+				}
+			}],
+			"usage": {"prompt_tokens": 120, "completion_tokens": 15, "total_tokens": 135}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient("sk-test", "gpt-4o-mini", 0.2)
+	client.apiURL = server.URL
+
+	tool := Tool{
+		Name:        "no_action",
+		Description: "Nothing to do",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{"reasoning": map[string]any{"type": "string"}}},
+	}
+	resp, err := client.Call(context.Background(), []Message{
+		{Role: "user", Content: []ContentBlock{TextBlock{Type: "text", Text: "hi"}}},
+	}, CallOptions{System: "be terse", Tools: []Tool{tool}, ToolChoice: "any"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "gpt-4o-mini", captured["model"])
+	assert.Equal(t, "required", captured["tool_choice"])
+	tools := captured["tools"].([]any)
+	require.Len(t, tools, 1)
+	fn := tools[0].(map[string]any)["function"].(map[string]any)
+	assert.Equal(t, "no_action", fn["name"])
+	assert.NotNil(t, fn["parameters"])
+
+	assert.Equal(t, "tool_use", resp.StopReason)
+	require.Len(t, resp.Content, 1)
+	toolUse, ok := resp.Content[0].(ToolUseBlock)
+	require.True(t, ok)
+	assert.Equal(t, "call_9", toolUse.ID)
+	assert.Equal(t, "chit-chat", toolUse.Input["reasoning"])
+	assert.Equal(t, UsageStats{InputTokens: 120, OutputTokens: 15, TotalTokens: 135}, resp.Usage)
+}
+
+func TestFormatOpenAIError(t *testing.T) {
+	err := formatOpenAIError(429, []byte(`{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`))
+	require.EqualError(t, err, "API error (status 429, code=insufficient_quota): insufficient_quota - You exceeded your current quota")
+
+	err = formatOpenAIError(502, []byte("bad gateway"))
+	require.EqualError(t, err, "API error (status 502): bad gateway")
+}
+
+func TestNewLLMClient(t *testing.T) {
+	client, err := NewLLMClient("", "key", "", 0)
+	require.NoError(t, err)
+	assert.IsType(t, &APIClient{}, client)
+
+	client, err = NewLLMClient(ProviderOpenAI, "key", "", 0)
+	require.NoError(t, err)
+	assert.IsType(t, &OpenAIClient{}, client)
+
+	_, err = NewLLMClient("gemini", "key", "", 0)
+	require.Error(t, err)
+
+	a := NewAgent(AgentConfig{Name: "test", Provider: "gemini", APIKey: "key"})
+	assert.False(t, a.IsConfigured())
+}
@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type backgroundKey struct{}
+
+// WithBackgroundPriority marks requests made with ctx as background work (backfills,
+// shadow runs). Background requests leave part of a provider's rate limit to realtime
+// messages, so a large backfill can't starve them.
+func WithBackgroundPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backgroundKey{}, true)
+}
+
+// IsBackgroundPriority reports whether ctx was marked by WithBackgroundPriority
+func IsBackgroundPriority(ctx context.Context) bool {
+	background, _ := ctx.Value(backgroundKey{}).(bool)
+	return background
+}
+
+// RateLimiter is a token bucket shared by every request to one provider. Realtime
+// requests may use the whole bucket; background requests only run while more than
+// the reserve is left.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // time to earn one token
+	burst    float64
+	reserve  float64
+	tokens   float64
+	last     time.Time
+}
+
+// NewRateLimiter allows requestsPerMinute on average, in bursts of up to a quarter of
+// that, a quarter of which is reserved for realtime requests
+func NewRateLimiter(requestsPerMinute int) *RateLimiter {
+	burst := math.Max(2, math.Floor(float64(requestsPerMinute)/4))
+	reserve := math.Max(1, math.Floor(burst/4))
+	return newRateLimiter(time.Minute/time.Duration(requestsPerMinute), burst, reserve)
+}
+
+func newRateLimiter(interval time.Duration, burst, reserve float64) *RateLimiter {
+	return &RateLimiter{
+		interval: interval,
+		burst:    burst,
+		reserve:  reserve,
+		tokens:   burst,
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until the request may be sent or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	need := 1.0
+	if IsBackgroundPriority(ctx) {
+		need += l.reserve
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens = math.Min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+		l.last = now
+		if l.tokens >= need {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((need - l.tokens) * float64(l.interval))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+var providerLimits = struct {
+	sync.RWMutex
+	limiters map[string]*RateLimiter
+}{limiters: make(map[string]*RateLimiter)}
+
+// SetProviderRateLimit caps the requests all agents together send to provider;
+// 0 removes the cap. Call it at startup, before agents make requests.
+func SetProviderRateLimit(provider string, requestsPerMinute int) {
+	providerLimits.Lock()
+	defer providerLimits.Unlock()
+	if requestsPerMinute <= 0 {
+		delete(providerLimits.limiters, provider)
+		return
+	}
+	providerLimits.limiters[provider] = NewRateLimiter(requestsPerMinute)
+}
+
+// waitForProvider blocks until provider's rate limit allows another request
+func waitForProvider(ctx context.Context, provider string) error {
+	providerLimits.RLock()
+	limiter := providerLimits.limiters[provider]
+	providerLimits.RUnlock()
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	l := NewRateLimiter(50)
+	assert.Equal(t, 1200*time.Millisecond, l.interval)
+	assert.Equal(t, 12.0, l.burst)
+	assert.Equal(t, 3.0, l.reserve)
+
+	small := NewRateLimiter(1)
+	assert.Equal(t, 2.0, small.burst)
+	assert.Equal(t, 1.0, small.reserve)
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	ctx := context.Background()
+	background := WithBackgroundPriority(ctx)
+	assert.True(t, IsBackgroundPriority(background))
+	assert.False(t, IsBackgroundPriority(ctx))
+
+	t.Run("bursts then waits for tokens", func(t *testing.T) {
+		l := newRateLimiter(50*time.Millisecond, 2, 1)
+		start := time.Now()
+		require.NoError(t, l.Wait(ctx))
+		require.NoError(t, l.Wait(ctx))
+		assert.Less(t, time.Since(start), 25*time.Millisecond)
+
+		require.NoError(t, l.Wait(ctx))
+		assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("background requests leave the reserve to realtime ones", func(t *testing.T) {
+		l := newRateLimiter(time.Hour, 3, 1)
+		require.NoError(t, l.Wait(background))
+		require.NoError(t, l.Wait(background))
+
+		waitCtx, cancel := context.WithTimeout(background, 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, l.Wait(waitCtx), context.DeadlineExceeded)
+
+		require.NoError(t, l.Wait(ctx), "realtime request uses the reserve")
+	})
+
+	t.Run("provider limits are shared and removable", func(t *testing.T) {
+		SetProviderRateLimit("test-provider", 60)
+		providerLimits.RLock()
+		limiter := providerLimits.limiters["test-provider"]
+		providerLimits.RUnlock()
+		require.NotNil(t, limiter)
+		require.NoError(t, waitForProvider(ctx, "test-provider"))
+
+		SetProviderRateLimit("test-provider", 0)
+		providerLimits.RLock()
+		_, ok := providerLimits.limiters["test-provider"]
+		providerLimits.RUnlock()
+		assert.False(t, ok)
+		require.NoError(t, waitForProvider(ctx, "unlimited-provider"))
+	})
+}
@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RecordFixturesEnv, when set to any value, makes fixture tests record fresh responses
+// from the live API instead of replaying their fixture files
+const RecordFixturesEnv = "ALFRED_RECORD_FIXTURES"
+
+// Interaction is one recorded API call. Request is kept so reviewers can see what
+// produced each response; replay doesn't compare it, since prompts embed the current time.
+type Interaction struct {
+	Request  json.RawMessage `json:"request"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+type fixtureFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that captures LLM API calls to a fixture file, or
+// replays a fixture's responses in order without network access. Only request and
+// response bodies are stored, never headers, so API keys stay out of fixtures.
+type Recorder struct {
+	path      string
+	recording bool
+	next      http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     int
+}
+
+// NewRecorder creates a recorder for the fixture at path. When recording, calls go to
+// the live API and Save writes them to path; otherwise the fixture is loaded for replay.
+func NewRecorder(path string, recording bool) (*Recorder, error) {
+	r := &Recorder{path: path, recording: recording, next: http.DefaultTransport}
+	if recording {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture (record it with %s=1): %w", RecordFixturesEnv, err)
+	}
+	var fixture fixtureFile
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	r.interactions = fixture.Interactions
+	return r, nil
+}
+
+// Recording reports whether the recorder calls the live API
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// RoundTrip records or replays one API call
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.recording {
+		if r.replayed >= len(r.interactions) {
+			return nil, fmt.Errorf("fixture %s has only %d interactions; re-record it with %s=1",
+				r.path, len(r.interactions), RecordFixturesEnv)
+		}
+		interaction := r.interactions[r.replayed]
+		r.replayed++
+		return fixtureResponse(req, interaction), nil
+	}
+
+	live := req.Clone(req.Context())
+	live.Body = io.NopCloser(bytes.NewReader(reqBody))
+	resp, err := r.next.RoundTrip(live)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	r.interactions = append(r.interactions, Interaction{
+		Request:  jsonOrString(reqBody),
+		Status:   resp.StatusCode,
+		Response: jsonOrString(respBody),
+	})
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// Save writes recorded interactions to the fixture file. When replaying it reports
+// interactions that were never requested, which means the agent made fewer calls than
+// when the fixture was recorded.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.recording {
+		if r.replayed < len(r.interactions) {
+			return fmt.Errorf("fixture %s: only %d of %d interactions were replayed",
+				r.path, r.replayed, len(r.interactions))
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(fixtureFile{Interactions: r.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+func fixtureResponse(req *http.Request, interaction Interaction) *http.Response {
+	body := []byte(interaction.Response)
+	var text string
+	if json.Unmarshal(interaction.Response, &text) == nil {
+		// A non-JSON body recorded as a string
+		body = []byte(text)
+	}
+	status := interaction.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// jsonOrString keeps JSON bodies readable in fixtures and stores anything else as a
+// JSON string
+func jsonOrString(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
@@ -0,0 +1,79 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"recorded answer"}],"stop_reason":"end_turn","usage":{"input_tokens":12,"output_tokens":3}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures", "answer.json")
+	input := AgentInput{Messages: []Message{{Role: "user", Content: []ContentBlock{TextBlock{Type: "text", Text: "hi"}}}}}
+
+	rec, err := NewRecorder(path, true)
+	require.NoError(t, err)
+	a := NewAgent(AgentConfig{Name: "test", APIKey: "secret-key", Transport: rec})
+	a.apiClient.(*APIClient).apiURL = server.URL
+	output, err := a.Execute(context.Background(), input)
+	require.NoError(t, err)
+	assert.Equal(t, "recorded answer", output.FinalText)
+	require.NoError(t, rec.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"hi"`, "the request is kept for review")
+	assert.NotContains(t, string(data), "secret-key", "headers are not recorded")
+
+	server.Close()
+	rec, err = NewRecorder(path, false)
+	require.NoError(t, err)
+	a = NewAgent(AgentConfig{Name: "test", APIKey: "replay-key", Transport: rec})
+	output, err = a.Execute(context.Background(), input)
+	require.NoError(t, err)
+	assert.Equal(t, "recorded answer", output.FinalText)
+	assert.Equal(t, 12, output.Usage.InputTokens)
+	require.NoError(t, rec.Save())
+	assert.Equal(t, 1, calls, "replay doesn't reach the server")
+
+	_, err = a.Execute(context.Background(), input)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "re-record")
+}
+
+func TestRecorderReplayErrors(t *testing.T) {
+	_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), RecordFixturesEnv)
+
+	path := filepath.Join(t.TempDir(), "two.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"interactions":[
+		{"request":{},"status":529,"response":{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}},
+		{"request":{},"status":200,"response":"not json"}
+	]}`), 0o644))
+	rec, err := NewRecorder(path, false)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: rec}
+	resp, err := client.Post("https://api.example.com", "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 529, resp.StatusCode)
+
+	err = rec.Save()
+	require.Error(t, err, "one interaction was never replayed")
+	assert.Contains(t, err.Error(), "1 of 2")
+}
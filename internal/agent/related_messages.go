@@ -0,0 +1,25 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+type relatedMessagesKey struct{}
+
+// WithRelatedMessages returns a context carrying earlier messages that were retrieved
+// because they are semantically close to the analyzed message (e.g. the address sent
+// two weeks before "see you at the usual place"), so agents can use them as context
+func WithRelatedMessages(ctx context.Context, messages []database.MessageRecord) context.Context {
+	if len(messages) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, relatedMessagesKey{}, messages)
+}
+
+// RelatedMessagesFromContext returns the messages attached by WithRelatedMessages
+func RelatedMessagesFromContext(ctx context.Context) []database.MessageRecord {
+	messages, _ := ctx.Value(relatedMessagesKey{}).([]database.MessageRecord)
+	return messages
+}
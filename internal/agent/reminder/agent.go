@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"strings"
 	"time"
 
@@ -21,23 +23,42 @@ type Agent struct {
 
 // Config configures the reminder agent
 type Config struct {
+	Provider    string // "anthropic" (default) or "openai"
 	APIKey      string
 	Model       string
+	FastModel   string // model for users on the "fast" tier
 	Temperature float64
+	// SystemPrompt replaces ReminderAnalyzerSystemPrompt when set (e.g. a candidate prompt in shadow mode)
+	SystemPrompt string
+	// Contacts enables the lookup_contact tool when set
+	Contacts tools.ContactSearcher
+	// Transport replaces the LLM client's HTTP transport, e.g. an agent.Recorder in tests
+	Transport http.RoundTripper
 }
 
 // NewAgent creates a new reminder scheduling agent
 func NewAgent(cfg Config) *Agent {
+	systemPrompt := cfg.SystemPrompt
+	if systemPrompt == "" {
+		systemPrompt = ReminderAnalyzerSystemPrompt
+	}
 	baseAgent := agent.NewAgent(agent.AgentConfig{
 		Name:         "reminder-scheduler",
+		Provider:     cfg.Provider,
 		APIKey:       cfg.APIKey,
 		Model:        cfg.Model,
+		FastModel:    cfg.FastModel,
 		Temperature:  cfg.Temperature,
-		SystemPrompt: ReminderAnalyzerSystemPrompt,
+		SystemPrompt: systemPrompt,
+		Transport:    cfg.Transport,
 	})
 
-	// REUSE extraction tool from event agent
+	// REUSE extraction tools from event agent
+	baseAgent.MustRegisterTool(tools.GetCurrentDateTimeTool, tools.HandleGetCurrentDateTime)
 	baseAgent.MustRegisterTool(tools.ExtractDateTimeTool, tools.HandleExtractDateTime)
+	if cfg.Contacts != nil {
+		baseAgent.MustRegisterTool(tools.LookupContactTool, tools.NewLookupContactHandler(cfg.Contacts))
+	}
 
 	// Register reminder-specific action tools
 	baseAgent.MustRegisterTool(tools.CreateReminderTool, tools.HandleCreateReminder)
@@ -56,15 +77,10 @@ func (a *Agent) AnalyzeMessages(
 	newMessage database.MessageRecord,
 	existingReminders []database.Reminder,
 ) (*agent.ReminderAnalysis, error) {
-	targetLanguage := langpolicy.DetectTargetLanguage(newMessage.MessageText)
+	targetLanguage := langpolicy.Resolve(newMessage.MessageText, agent.ChannelLanguageFromContext(ctx), agent.LocaleFromContext(ctx))
 	languageInstruction := langpolicy.BuildLanguageInstruction(targetLanguage)
 	if targetLanguage.Reliable {
-		fmt.Printf(
-			"LanguagePolicy[reminder]: target=%s script=%s confidence=%.2f source=message\n",
-			targetLanguage.Code,
-			targetLanguage.Script,
-			targetLanguage.Confidence,
-		)
+		slog.Info("LanguagePolicy[reminder]: source=message", "target", targetLanguage.Code, "script", targetLanguage.Script, "confidence", targetLanguage.Confidence, "preferred", targetLanguage.Preferred)
 	}
 
 	analysis, err := a.executePromptAndParse(ctx, buildUserPrompt(
@@ -94,15 +110,10 @@ func (a *Agent) AnalyzeMessages(
 func (a *Agent) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.ReminderAnalysis, error) {
 	// Detect language from subject+body so a single localized token in the body
 	// doesn't incorrectly flip the entire output language.
-	targetLanguage := langpolicy.DetectTargetLanguage(strings.TrimSpace(email.Subject + "\n" + email.Body))
+	targetLanguage := langpolicy.Resolve(strings.TrimSpace(email.Subject+"\n"+email.Body), "", agent.LocaleFromContext(ctx))
 	languageInstruction := langpolicy.BuildLanguageInstruction(targetLanguage)
 	if targetLanguage.Reliable {
-		fmt.Printf(
-			"LanguagePolicy[reminder]: target=%s script=%s confidence=%.2f source=email\n",
-			targetLanguage.Code,
-			targetLanguage.Script,
-			targetLanguage.Confidence,
-		)
+		slog.Info("LanguagePolicy[reminder]: source=email", "target", targetLanguage.Code, "script", targetLanguage.Script, "confidence", targetLanguage.Confidence, "preferred", targetLanguage.Preferred)
 	}
 
 	analysis, err := a.executePromptAndParse(ctx, buildEmailPrompt(email, languageInstruction, ""))
@@ -269,45 +280,27 @@ func (a *Agent) enforceLanguagePolicy(
 	shouldRetry, validation := shouldRetryReminderForLanguage(target, initial)
 	if !shouldRetry {
 		if target.Reliable {
-			fmt.Printf(
-				"LanguagePolicy[reminder]: validation=pass action=%s checked=%d skipped=%d\n",
-				initial.Action,
-				validation.CheckedFields,
-				validation.SkippedFields,
-			)
+			slog.Info("LanguagePolicy[reminder]: validation=pass", "action", initial.Action, "checked", validation.CheckedFields, "skipped", validation.SkippedFields)
 		}
 		return initial, nil
 	}
 
-	fmt.Printf(
-		"LanguagePolicy[reminder]: validation=fail action=%s mismatches=%s retry=true\n",
-		initial.Action,
-		formatMismatches(validation),
-	)
+	slog.Error("LanguagePolicy[reminder]: validation=fail retry=true", "action", initial.Action, "mismatches", formatMismatches(validation))
 
 	retryPrompt := retryPromptBuilder(langpolicy.BuildCorrectiveRetryInstruction(target, validation))
 	retryAnalysis, err := a.executePromptAndParse(ctx, retryPrompt)
 	if err != nil {
-		fmt.Printf("LanguagePolicy[reminder]: retry_error=%v fallback=initial\n", err)
+		slog.Info("LanguagePolicy[reminder]: fallback=initial", "retry_error", err)
 		return initial, nil
 	}
 
 	retryNeeded, retryValidation := shouldRetryReminderForLanguage(target, retryAnalysis)
 	if !retryNeeded {
-		fmt.Printf(
-			"LanguagePolicy[reminder]: retry_result=pass action=%s checked=%d skipped=%d\n",
-			retryAnalysis.Action,
-			retryValidation.CheckedFields,
-			retryValidation.SkippedFields,
-		)
+		slog.Info("LanguagePolicy[reminder]: retry_result=pass", "action", retryAnalysis.Action, "checked", retryValidation.CheckedFields, "skipped", retryValidation.SkippedFields)
 		return retryAnalysis, nil
 	}
 
-	fmt.Printf(
-		"LanguagePolicy[reminder]: retry_result=fail action=%s mismatches=%s fallback=retry\n",
-		retryAnalysis.Action,
-		formatMismatches(retryValidation),
-	)
+	slog.Error("LanguagePolicy[reminder]: retry_result=fail fallback=retry", "action", retryAnalysis.Action, "mismatches", formatMismatches(retryValidation))
 	return retryAnalysis, nil
 }
 
@@ -0,0 +1,53 @@
+package reminder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReplayAgent returns an agent whose API calls are replayed from testdata/name.
+// synthetic_ fixtures are hand-written responses, not recorded model output. Run with
+// ALFRED_RECORD_FIXTURES=1 and ANTHROPIC_API_KEY set to record real ones.
+func newReplayAgent(t *testing.T, name string) *Agent {
+	t.Helper()
+	rec, err := agent.NewRecorder(filepath.Join("testdata", name), os.Getenv(agent.RecordFixturesEnv) != "")
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, rec.Save()) })
+
+	apiKey := "replay-key"
+	if rec.Recording() {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+		require.NotEmpty(t, apiKey, "recording needs ANTHROPIC_API_KEY")
+	}
+	return NewAgent(Config{APIKey: apiKey, Transport: rec})
+}
+
+func TestAnalyzeMessages_Replay(t *testing.T) {
+	sentAt := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	ctx := agent.WithMessageTime(context.Background(), sentAt)
+
+	a := newReplayAgent(t, "synthetic_create_pay_rent.json")
+	msg := database.MessageRecord{
+		Timestamp:   sentAt,
+		SenderName:  "Noa",
+		MessageText: "Don't forget to pay the rent by Friday, it's urgent",
+	}
+
+	analysis, err := a.AnalyzeMessages(ctx, nil, msg, nil)
+	require.NoError(t, err)
+	assert.True(t, analysis.HasReminder)
+	assert.Equal(t, "create", analysis.Action)
+	require.NotNil(t, analysis.Reminder)
+	assert.Contains(t, analysis.Reminder.Title, "rent")
+	assert.Equal(t, "2026-03-13T09:00:00", analysis.Reminder.DueDate)
+	assert.Equal(t, "high", analysis.Reminder.Priority)
+	assert.Greater(t, analysis.Confidence, 0.5)
+}
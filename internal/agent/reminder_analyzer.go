@@ -41,4 +41,9 @@ type ReminderData struct {
 	ReminderTime      string `json:"reminder_time,omitempty"` // When to notify (optional)
 	Priority          string `json:"priority,omitempty"`  // low, normal, high
 	AlfredReminderRef int64  `json:"alfred_reminder_ref,omitempty"` // Internal DB ID for pending reminders
+
+	// Set by the bill agent
+	Payee    string   `json:"payee,omitempty"`
+	Amount   *float64 `json:"amount,omitempty"`
+	Currency string   `json:"currency,omitempty"`
 }
@@ -0,0 +1,236 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultRequestTimeout bounds one attempt when the retry policy sets no timeout.
+// Tool-use requests can take a while.
+const defaultRequestTimeout = 120 * time.Second
+
+// RetryPolicy configures how a provider's requests are retried when the API is
+// unavailable. The zero value sends each request once.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt
+	BaseDelay  time.Duration // backoff before the first retry, doubled for each later one
+	MaxDelay   time.Duration // cap on a single backoff, including a server's Retry-After
+	Timeout    time.Duration // per attempt; 0 = defaultRequestTimeout
+}
+
+// backoff returns the wait before retry number attempt (0-based): half the exponential
+// delay plus up to as much again at random, so clients that failed together don't all
+// retry together. A longer Retry-After from the server wins, up to MaxDelay.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if delay > 0 {
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+func (p RetryPolicy) timeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	return defaultRequestTimeout
+}
+
+// apiStatusError is a non-200 response from a provider. It unwraps to the formatted
+// API error, so errors.Is(err, ErrInsufficientCredits) still works.
+type apiStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	err        error
+}
+
+func (e *apiStatusError) Error() string { return e.err.Error() }
+func (e *apiStatusError) Unwrap() error { return e.err }
+
+// newAPIStatusError wraps err with the response's status and Retry-After header
+func newAPIStatusError(resp *http.Response, err error) error {
+	statusErr := &apiStatusError{StatusCode: resp.StatusCode, err: err}
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		statusErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return statusErr
+}
+
+// isUnavailableError reports whether err means the API couldn't serve the request right
+// now (rate limited, overloaded, server error, timeout, connection failure) rather than
+// rejecting it. Only these are retried and count against the circuit breaker.
+func isUnavailableError(err error) bool {
+	var statusErr *apiStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	return err != nil
+}
+
+// ProviderStats reports a provider's retries and circuit breaker, for health checks and
+// admin stats
+type ProviderStats struct {
+	Retries  int64          `json:"retries"`           // attempts repeated after the API was unavailable
+	Failures int64          `json:"failures"`          // requests that failed after every retry
+	Circuit  *CircuitStatus `json:"circuit,omitempty"` // nil without a circuit breaker
+}
+
+// providerResilience is the retry policy, circuit breaker and counters shared by every
+// request to one provider
+type providerResilience struct {
+	retry    RetryPolicy
+	breaker  *CircuitBreaker
+	retries  atomic.Int64
+	failures atomic.Int64
+}
+
+var providerResilienceSettings = struct {
+	sync.RWMutex
+	providers map[string]*providerResilience
+}{providers: make(map[string]*providerResilience)}
+
+// resilienceFor returns provider's settings, creating them on first use
+func resilienceFor(provider string) *providerResilience {
+	if provider == "" {
+		provider = ProviderAnthropic
+	}
+	providerResilienceSettings.RLock()
+	r := providerResilienceSettings.providers[provider]
+	providerResilienceSettings.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	providerResilienceSettings.Lock()
+	defer providerResilienceSettings.Unlock()
+	if r = providerResilienceSettings.providers[provider]; r == nil {
+		r = &providerResilience{}
+		providerResilienceSettings.providers[provider] = r
+	}
+	return r
+}
+
+// SetProviderRetryPolicy sets how requests to provider are retried. Call it at startup,
+// before agents make requests.
+func SetProviderRetryPolicy(provider string, policy RetryPolicy) {
+	r := resilienceFor(provider)
+	providerResilienceSettings.Lock()
+	defer providerResilienceSettings.Unlock()
+	r.retry = policy
+}
+
+// SetProviderCircuitBreaker opens provider's circuit after threshold consecutive failed
+// requests, for cooldown; a threshold of 0 removes the breaker. Call it at startup,
+// before agents make requests.
+func SetProviderCircuitBreaker(provider string, threshold int, cooldown time.Duration) {
+	r := resilienceFor(provider)
+	providerResilienceSettings.Lock()
+	defer providerResilienceSettings.Unlock()
+	if threshold <= 0 {
+		r.breaker = nil
+		return
+	}
+	r.breaker = NewCircuitBreaker(threshold, cooldown)
+}
+
+// ProviderAvailable reports whether the circuit breaker of provider ("" means Anthropic)
+// lets requests through. While it doesn't, callers can skip work that needs the LLM.
+func ProviderAvailable(provider string) bool {
+	r := resilienceFor(provider)
+	providerResilienceSettings.RLock()
+	breaker := r.breaker
+	providerResilienceSettings.RUnlock()
+	return breaker.Available()
+}
+
+// GetProviderStats returns the retry counters and circuit breaker state of provider (""
+// means Anthropic)
+func GetProviderStats(provider string) ProviderStats {
+	r := resilienceFor(provider)
+	providerResilienceSettings.RLock()
+	breaker := r.breaker
+	providerResilienceSettings.RUnlock()
+
+	stats := ProviderStats{Retries: r.retries.Load(), Failures: r.failures.Load()}
+	if breaker != nil {
+		status := breaker.Status()
+		stats.Circuit = &status
+	}
+	return stats
+}
+
+// sendWithRetry sends a request to provider through its circuit breaker, rate limit and
+// retry policy. send makes one attempt within the given context and returns the body of
+// a successful response.
+func sendWithRetry(ctx context.Context, provider string, send func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	r := resilienceFor(provider)
+	providerResilienceSettings.RLock()
+	policy, breaker := r.retry, r.breaker
+	providerResilienceSettings.RUnlock()
+
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := waitForProvider(ctx, provider); err != nil {
+			breaker.Cancel()
+			return nil, fmt.Errorf("rate limit wait canceled: %w", err)
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, policy.timeout())
+		body, err := send(attemptCtx)
+		cancel()
+		switch {
+		case err == nil:
+			breaker.Succeed()
+			return body, nil
+		case ctx.Err() != nil:
+			breaker.Cancel()
+			return nil, err
+		case !isUnavailableError(err):
+			// The API answered; the request itself was rejected
+			breaker.Succeed()
+			return nil, err
+		case attempt >= policy.MaxRetries:
+			r.failures.Add(1)
+			breaker.Fail()
+			return nil, err
+		}
+
+		var retryAfter time.Duration
+		var statusErr *apiStatusError
+		if errors.As(err, &statusErr) {
+			retryAfter = statusErr.RetryAfter
+		}
+		delay := policy.backoff(attempt, retryAfter)
+		r.retries.Add(1)
+		slog.WarnContext(ctx, "LLM request failed, retrying", "provider", provider, "attempt", attempt+1, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			breaker.Cancel()
+			return nil, fmt.Errorf("retry canceled: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
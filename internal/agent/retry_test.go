@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestResilience configures provider for a test and removes it afterwards
+func setTestResilience(t *testing.T, provider string, policy RetryPolicy, threshold int) {
+	t.Helper()
+	SetProviderRetryPolicy(provider, policy)
+	SetProviderCircuitBreaker(provider, threshold, time.Hour)
+	t.Cleanup(func() {
+		providerResilienceSettings.Lock()
+		delete(providerResilienceSettings.providers, provider)
+		providerResilienceSettings.Unlock()
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second} {
+		delay := policy.backoff(attempt, 0)
+		assert.GreaterOrEqual(t, delay, want/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, delay, want, "attempt %d", attempt)
+	}
+
+	assert.Equal(t, 700*time.Millisecond, policy.backoff(0, 700*time.Millisecond), "Retry-After wins")
+	assert.Equal(t, time.Second, policy.backoff(0, time.Minute), "capped at MaxDelay")
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.backoff(3, 0))
+}
+
+func TestSendWithRetry(t *testing.T) {
+	ctx := context.Background()
+	unavailable := &apiStatusError{StatusCode: http.StatusServiceUnavailable, err: errors.New("overloaded")}
+	policy := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	t.Run("retries while the API is unavailable", func(t *testing.T) {
+		setTestResilience(t, "retry-provider", policy, 5)
+		var attempts int
+		body, err := sendWithRetry(ctx, "retry-provider", func(context.Context) ([]byte, error) {
+			attempts++
+			if attempts < 3 {
+				return nil, unavailable
+			}
+			return []byte("ok"), nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(body))
+		assert.Equal(t, 3, attempts)
+
+		stats := GetProviderStats("retry-provider")
+		assert.Equal(t, int64(2), stats.Retries)
+		assert.Equal(t, int64(0), stats.Failures)
+		require.NotNil(t, stats.Circuit)
+		assert.Equal(t, CircuitClosed, stats.Circuit.State)
+	})
+
+	t.Run("rejected requests are not retried", func(t *testing.T) {
+		setTestResilience(t, "reject-provider", policy, 1)
+		var attempts int
+		_, err := sendWithRetry(ctx, "reject-provider", func(context.Context) ([]byte, error) {
+			attempts++
+			return nil, &apiStatusError{StatusCode: http.StatusBadRequest, err: errors.New("bad request")}
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, attempts)
+		assert.True(t, ProviderAvailable("reject-provider"), "a rejected request doesn't trip the breaker")
+	})
+
+	t.Run("breaker opens once retries run out", func(t *testing.T) {
+		setTestResilience(t, "down-provider", policy, 1)
+		var attempts int
+		send := func(context.Context) ([]byte, error) {
+			attempts++
+			return nil, unavailable
+		}
+		_, err := sendWithRetry(ctx, "down-provider", send)
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, 3, attempts)
+		assert.False(t, ProviderAvailable("down-provider"))
+
+		_, err = sendWithRetry(ctx, "down-provider", send)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 3, attempts, "open breaker fails fast")
+
+		stats := GetProviderStats("down-provider")
+		assert.Equal(t, int64(1), stats.Failures)
+		assert.Equal(t, CircuitOpen, stats.Circuit.State)
+		assert.Equal(t, int64(1), stats.Circuit.Rejected)
+	})
+
+	t.Run("attempts time out", func(t *testing.T) {
+		setTestResilience(t, "slow-provider", RetryPolicy{MaxRetries: 1, Timeout: 10 * time.Millisecond}, 0)
+		var attempts int
+		_, err := sendWithRetry(ctx, "slow-provider", func(ctx context.Context) ([]byte, error) {
+			attempts++
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 2, attempts)
+		assert.Nil(t, GetProviderStats("slow-provider").Circuit)
+	})
+}
+
+func TestAPIClientRetriesOverloadedAPI(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(529)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	setTestResilience(t, ProviderAnthropic, RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond}, 3)
+	client := NewAPIClient("key", "", 0)
+	client.apiURL = server.URL
+
+	resp, err := client.Call(context.Background(), []Message{{Role: "user", Content: []ContentBlock{TextBlock{Type: "text", Text: "hello"}}}}, CallOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+	require.Len(t, resp.Content, 1)
+	assert.Equal(t, "hi", resp.Content[0].(TextBlock).Text)
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// RunLog describes one agent run for the analysis log: a fingerprint of what the
+// model was given, the tools it called, and what the run cost
+type RunLog struct {
+	Agent     string
+	Model     string
+	InputHash string // SHA-256 of the system prompt and input messages
+	ToolCalls []ToolCall
+	Usage     UsageStats
+	Latency   time.Duration
+	Err       error
+}
+
+// RunLogger receives a RunLog after every agent run
+type RunLogger func(RunLog)
+
+type runLoggerKey struct{}
+
+// WithRunLogger returns a context whose agent runs are reported to fn. A nil fn keeps
+// runs from reaching a logger set further out, e.g. for shadow analyses.
+func WithRunLogger(ctx context.Context, fn RunLogger) context.Context {
+	return context.WithValue(ctx, runLoggerKey{}, fn)
+}
+
+func runLogger(ctx context.Context) RunLogger {
+	fn, _ := ctx.Value(runLoggerKey{}).(RunLogger)
+	return fn
+}
+
+// inputHash fingerprints an agent's input so identical analyses can be recognized
+// without storing the prompt
+func inputHash(systemPrompt string, messages []Message) string {
+	h := sha256.New()
+	h.Write([]byte(systemPrompt))
+	h.Write([]byte{0})
+	if b, err := json.Marshal(messages); err == nil {
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentReportsRunLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"done"}}],"usage":{"prompt_tokens":40,"completion_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	a := NewAgent(AgentConfig{Name: "test", Provider: ProviderOpenAI, APIKey: "key", Model: "gpt-4o", SystemPrompt: "be brief"})
+	a.apiClient.(*OpenAIClient).apiURL = server.URL
+	input := AgentInput{Messages: []Message{{Role: "user", Content: []ContentBlock{TextBlock{Type: "text", Text: "hi"}}}}}
+
+	var runs []RunLog
+	ctx := WithRunLogger(context.Background(), func(run RunLog) {
+		runs = append(runs, run)
+	})
+	_, err := a.Execute(ctx, input)
+	require.NoError(t, err)
+	_, err = a.Execute(ctx, input)
+	require.NoError(t, err)
+
+	require.Len(t, runs, 2)
+	assert.Equal(t, "test", runs[0].Agent)
+	assert.Equal(t, "gpt-4o", runs[0].Model)
+	assert.Equal(t, 42, runs[0].Usage.TotalTokens)
+	assert.Len(t, runs[0].InputHash, 64)
+	assert.Equal(t, runs[0].InputHash, runs[1].InputHash, "the same input hashes the same")
+	assert.NoError(t, runs[0].Err)
+
+	// A nil logger hides runs from an outer one
+	_, err = a.Execute(WithRunLogger(ctx, nil), input)
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+}
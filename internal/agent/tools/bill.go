@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+)
+
+// CreateBillReminderTool records a bill or payment deadline
+var CreateBillReminderTool = agent.Tool{
+	Name: "create_bill_reminder",
+	Description: `Records a bill, invoice or payment deadline the user has to pay. Use this tool when a message
+or email asks the user to pay a specific payee by a due date - utility bills, invoices, rent, tuition,
+credit card statements, fines. Copy the amount, currency and invoice number exactly as written.
+Do NOT use this tool for receipts of payments already made, automatic payments that need no action,
+or marketing offers.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"payee":          agent.PropertyString("Who the user has to pay (e.g., 'Israel Electric Corp', 'Landlord - Dana')"),
+		"amount":         agent.PropertyNumber("Amount due, if stated (e.g., 412.30)"),
+		"currency":       agent.PropertyString("ISO 4217 currency code of the amount (e.g., 'ILS', 'USD', 'EUR')"),
+		"due_date":       agent.PropertyString("Payment deadline in ISO 8601 format: YYYY-MM-DDTHH:MM:SS (use 09:00 when no time is given)"),
+		"invoice_number": agent.PropertyString("Invoice, bill or account reference (optional)"),
+		"description":    agent.PropertyString("What the bill is for, e.g. 'Electricity, March-April' (optional)"),
+		"confidence":     agent.PropertyNumber("Confidence score from 0.0 to 1.0 that this is a bill the user must pay"),
+		"reasoning":      agent.PropertyString("Brief explanation of why this is a bill"),
+	}, []string{"payee", "due_date", "confidence", "reasoning"}),
+}
+
+// NoBillActionTool indicates no bill was found
+var NoBillActionTool = agent.Tool{
+	Name: "no_bill_action",
+	Description: `Indicates that the analyzed messages don't contain a bill the user has to pay.
+Use this tool when messages:
+- Don't ask the user to pay anything
+- Are receipts or confirmations of payments already made
+- Describe automatic payments that need no action
+- Repeat a bill already in the existing reminders
+Always provide reasoning to explain why no action was taken.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"reasoning":  agent.PropertyString("Detailed explanation of why no bill action is needed"),
+		"confidence": agent.PropertyNumber("Confidence score from 0.0 to 1.0 that no action is correct"),
+	}, []string{"reasoning", "confidence"}),
+}
+
+// CreateBillReminderInput represents parsed input for create_bill_reminder
+type CreateBillReminderInput struct {
+	agent.BillData
+	Confidence float64 `json:"confidence"`
+	Reasoning  string  `json:"reasoning"`
+}
+
+// HandleCreateBillReminder processes the create_bill_reminder tool call
+func HandleCreateBillReminder(_ context.Context, input map[string]any) (string, error) {
+	parsed := CreateBillReminderInput{}
+
+	if v, ok := input["payee"].(string); ok {
+		parsed.Payee = strings.TrimSpace(v)
+	}
+	if v, ok := input["amount"].(float64); ok {
+		parsed.Amount = &v
+	}
+	if v, ok := input["currency"].(string); ok {
+		parsed.Currency = strings.ToUpper(strings.TrimSpace(v))
+	}
+	if v, ok := input["due_date"].(string); ok {
+		parsed.DueDate = v
+	}
+	if v, ok := input["invoice_number"].(string); ok {
+		parsed.InvoiceNumber = v
+	}
+	if v, ok := input["description"].(string); ok {
+		parsed.Description = v
+	}
+	if v, ok := input["confidence"].(float64); ok {
+		parsed.Confidence = v
+	}
+	if v, ok := input["reasoning"].(string); ok {
+		parsed.Reasoning = v
+	}
+
+	if parsed.Payee == "" {
+		return "", fmt.Errorf("payee is required")
+	}
+	if parsed.DueDate == "" {
+		return "", fmt.Errorf("due_date is required")
+	}
+	if parsed.Amount != nil && *parsed.Amount < 0 {
+		return "", fmt.Errorf("amount must not be negative")
+	}
+
+	result, err := json.Marshal(map[string]any{
+		"status": "success",
+		"action": "create",
+		"bill":   parsed,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// HandleNoBillAction processes the no_bill_action tool call
+func HandleNoBillAction(_ context.Context, input map[string]any) (string, error) {
+	reasoning, _ := input["reasoning"].(string)
+	confidence, _ := input["confidence"].(float64)
+
+	if reasoning == "" {
+		return "", fmt.Errorf("reasoning is required")
+	}
+
+	result, err := json.Marshal(map[string]any{
+		"status":     "success",
+		"action":     "none",
+		"reasoning":  reasoning,
+		"confidence": confidence,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
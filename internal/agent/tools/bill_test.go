@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleCreateBillReminder(t *testing.T) {
+	t.Run("utility bill", func(t *testing.T) {
+		out, err := HandleCreateBillReminder(context.Background(), map[string]any{
+			"payee":          " Israel Electric Corp ",
+			"amount":         412.5,
+			"currency":       "ils",
+			"due_date":       "2030-03-15T09:00:00",
+			"invoice_number": "INV-20931",
+			"confidence":     0.92,
+			"reasoning":      "Electricity bill with a due date",
+		})
+		require.NoError(t, err)
+
+		var result struct {
+			Action string                  `json:"action"`
+			Bill   CreateBillReminderInput `json:"bill"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, "create", result.Action)
+		assert.Equal(t, "Israel Electric Corp", result.Bill.Payee)
+		require.NotNil(t, result.Bill.Amount)
+		assert.Equal(t, 412.5, *result.Bill.Amount)
+		assert.Equal(t, "ILS", result.Bill.Currency)
+		assert.Equal(t, "INV-20931", result.Bill.InvoiceNumber)
+		assert.Equal(t, 0.92, result.Bill.Confidence)
+	})
+
+	invalid := []struct {
+		name  string
+		input map[string]any
+	}{
+		{name: "missing payee", input: map[string]any{"due_date": "2030-03-15"}},
+		{name: "missing due date", input: map[string]any{"payee": "Bezeq"}},
+		{name: "negative amount", input: map[string]any{"payee": "Bezeq", "due_date": "2030-03-15", "amount": -10.0}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := HandleCreateBillReminder(context.Background(), tt.input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHandleNoBillAction(t *testing.T) {
+	out, err := HandleNoBillAction(context.Background(), map[string]any{
+		"reasoning":  "Payment receipt, nothing to pay",
+		"confidence": 0.9,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, out, `"action":"none"`)
+
+	_, err = HandleNoBillAction(context.Background(), map[string]any{})
+	assert.Error(t, err)
+}
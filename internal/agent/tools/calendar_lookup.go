@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	defaultCalendarLookupDays = 14
+	maxCalendarLookupDays     = 90
+	maxCalendarLookupEvents   = 50
+)
+
+// ListCalendarEventsTool looks up what is already on the user's calendar
+var ListCalendarEventsTool = agent.Tool{
+	Name: "list_calendar_events",
+	Description: `Lists the events already on the user's calendar in a date range, across all of their
+chats and email, including events still pending review. Use this tool before taking an action
+when the messages may refer to an event that is not in the provided existing events list
+(e.g., "let's move Thursday's dinner"), or to check whether a proposed time conflicts with
+something already scheduled. Returns event IDs you can pass to update_calendar_event or
+delete_calendar_event. Defaults to the next 14 days.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"start_date": agent.PropertyString("First day to include, YYYY-MM-DD. Optional - defaults to today."),
+		"end_date":   agent.PropertyString("Last day to include, YYYY-MM-DD. Optional - defaults to 14 days after start_date. At most 90 days after start_date."),
+	}, nil),
+}
+
+// CalendarEventLister is the storage used by list_calendar_events
+type CalendarEventLister interface {
+	ListEventsInRange(userID int64, start, end time.Time, limit int) ([]database.CalendarEvent, error)
+}
+
+// ListedEvent is a calendar event as returned to the agent
+type ListedEvent struct {
+	AlfredEventID int64  `json:"alfred_event_id"`
+	GoogleEventID string `json:"google_event_id,omitempty"`
+	Title         string `json:"title"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time,omitempty"`
+	Location      string `json:"location,omitempty"`
+	Status        string `json:"status"`
+	Source        string `json:"source"`
+}
+
+// CalendarEventsListing represents the result of list_calendar_events
+type CalendarEventsListing struct {
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Events    []ListedEvent `json:"events"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+// NewListCalendarEventsHandler returns a list_calendar_events handler backed by store.
+// The user is taken from the context (agent.WithUserID).
+func NewListCalendarEventsHandler(store CalendarEventLister) agent.ToolHandler {
+	return func(ctx context.Context, input map[string]any) (string, error) {
+		return handleListCalendarEvents(ctx, store, userClock(ctx), input)
+	}
+}
+
+func handleListCalendarEvents(ctx context.Context, store CalendarEventLister, now time.Time, input map[string]any) (string, error) {
+	userID, ok := agent.UserIDFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("calendar lookup is not available for this analysis")
+	}
+
+	start, lastDay, err := parseLookupRange(input, now)
+	if err != nil {
+		return "", err
+	}
+
+	// Fetch one extra event to tell whether the listing was cut off
+	events, err := store.ListEventsInRange(userID, start, lastDay.AddDate(0, 0, 1), maxCalendarLookupEvents+1)
+	if err != nil {
+		return "", fmt.Errorf("failed to list calendar events: %w", err)
+	}
+
+	listing := CalendarEventsListing{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   lastDay.Format("2006-01-02"),
+		Events:    make([]ListedEvent, 0, len(events)),
+	}
+	if len(events) > maxCalendarLookupEvents {
+		events = events[:maxCalendarLookupEvents]
+		listing.Truncated = true
+	}
+	for _, e := range events {
+		listed := ListedEvent{
+			AlfredEventID: e.ID,
+			Title:         e.Title,
+			StartTime:     e.StartTime.In(now.Location()).Format("2006-01-02T15:04:05"),
+			Location:      e.Location,
+			Status:        string(e.Status),
+			Source:        e.ChannelName,
+		}
+		if e.GoogleEventID != nil {
+			listed.GoogleEventID = *e.GoogleEventID
+		}
+		if e.EndTime != nil {
+			listed.EndTime = e.EndTime.In(now.Location()).Format("2006-01-02T15:04:05")
+		}
+		listing.Events = append(listing.Events, listed)
+	}
+
+	result, err := json.Marshal(listing)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// parseLookupRange reads the optional start_date/end_date inputs shared by the calendar
+// lookup tools. Returns the first and last day to include, in now's location.
+func parseLookupRange(input map[string]any, now time.Time) (time.Time, time.Time, error) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if v, ok := input["start_date"].(string); ok && v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("start_date must be in YYYY-MM-DD format")
+		}
+		start = parsed
+	}
+	lastDay := start.AddDate(0, 0, defaultCalendarLookupDays)
+	if v, ok := input["end_date"].(string); ok && v != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("end_date must be in YYYY-MM-DD format")
+		}
+		lastDay = parsed
+	}
+	if lastDay.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_date must not be before start_date")
+	}
+	if lastDay.After(start.AddDate(0, 0, maxCalendarLookupDays)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range must be at most %d days", maxCalendarLookupDays)
+	}
+	return start, lastDay, nil
+}
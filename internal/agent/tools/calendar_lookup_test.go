@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCalendarEventLister struct {
+	events []database.CalendarEvent
+
+	userID int64
+	start  time.Time
+	end    time.Time
+	limit  int
+}
+
+func (f *fakeCalendarEventLister) ListEventsInRange(userID int64, start, end time.Time, limit int) ([]database.CalendarEvent, error) {
+	f.userID, f.start, f.end, f.limit = userID, start, end, limit
+	if len(f.events) > limit {
+		return f.events[:limit], nil
+	}
+	return f.events, nil
+}
+
+func TestHandleListCalendarEvents(t *testing.T) {
+	now := time.Date(2030, 5, 10, 15, 30, 0, 0, time.UTC)
+	ctx := agent.WithUserID(context.Background(), 7)
+	googleID := "g-123"
+	end := time.Date(2030, 5, 12, 21, 0, 0, 0, time.UTC)
+
+	t.Run("defaults to the next 14 days", func(t *testing.T) {
+		store := &fakeCalendarEventLister{events: []database.CalendarEvent{
+			{
+				ID:            3,
+				GoogleEventID: &googleID,
+				Title:         "Dinner at Luigi's",
+				StartTime:     time.Date(2030, 5, 12, 20, 0, 0, 0, time.UTC),
+				EndTime:       &end,
+				Status:        database.EventStatusSynced,
+				ChannelName:   "Dana",
+			},
+		}}
+
+		result, err := handleListCalendarEvents(ctx, store, now, map[string]any{})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(7), store.userID)
+		assert.Equal(t, time.Date(2030, 5, 10, 0, 0, 0, 0, time.UTC), store.start)
+		assert.Equal(t, time.Date(2030, 5, 25, 0, 0, 0, 0, time.UTC), store.end)
+		assert.Equal(t, maxCalendarLookupEvents+1, store.limit)
+
+		var listing CalendarEventsListing
+		require.NoError(t, json.Unmarshal([]byte(result), &listing))
+		assert.Equal(t, "2030-05-10", listing.StartDate)
+		assert.Equal(t, "2030-05-24", listing.EndDate)
+		assert.False(t, listing.Truncated)
+		assert.Equal(t, []ListedEvent{{
+			AlfredEventID: 3,
+			GoogleEventID: "g-123",
+			Title:         "Dinner at Luigi's",
+			StartTime:     "2030-05-12T20:00:00",
+			EndTime:       "2030-05-12T21:00:00",
+			Status:        "synced",
+			Source:        "Dana",
+		}}, listing.Events)
+	})
+
+	t.Run("explicit range", func(t *testing.T) {
+		store := &fakeCalendarEventLister{}
+		result, err := handleListCalendarEvents(ctx, store, now, map[string]any{
+			"start_date": "2030-05-15",
+			"end_date":   "2030-05-15",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2030, 5, 15, 0, 0, 0, 0, time.UTC), store.start)
+		assert.Equal(t, time.Date(2030, 5, 16, 0, 0, 0, 0, time.UTC), store.end)
+		assert.JSONEq(t, `{"start_date":"2030-05-15","end_date":"2030-05-15","events":[]}`, result)
+	})
+
+	t.Run("marks truncated listings", func(t *testing.T) {
+		events := make([]database.CalendarEvent, maxCalendarLookupEvents+5)
+		for i := range events {
+			events[i] = database.CalendarEvent{ID: int64(i + 1), StartTime: now}
+		}
+		result, err := handleListCalendarEvents(ctx, &fakeCalendarEventLister{events: events}, now, map[string]any{})
+		require.NoError(t, err)
+
+		var listing CalendarEventsListing
+		require.NoError(t, json.Unmarshal([]byte(result), &listing))
+		assert.True(t, listing.Truncated)
+		assert.Len(t, listing.Events, maxCalendarLookupEvents)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input map[string]any
+		}{
+			{"bad start date", map[string]any{"start_date": "May 15"}},
+			{"bad end date", map[string]any{"end_date": "2030/05/20"}},
+			{"end before start", map[string]any{"start_date": "2030-05-15", "end_date": "2030-05-14"}},
+			{"range too long", map[string]any{"start_date": "2030-05-15", "end_date": "2030-09-15"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := handleListCalendarEvents(ctx, &fakeCalendarEventLister{}, now, tt.input)
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("requires a user", func(t *testing.T) {
+		_, err := handleListCalendarEvents(context.Background(), &fakeCalendarEventLister{}, now, map[string]any{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const maxContactLookupResults = 5
+
+// LookupContactTool resolves a person mentioned in a message to the user's contacts
+var LookupContactTool = agent.Tool{
+	Name: "lookup_contact",
+	Description: `Looks up a person in the user's contacts (Gmail contacts and WhatsApp/Telegram chats)
+by name, nickname or email. Use this tool when a message mentions someone by name or
+relationship ("meet Yossi", "call Grandma") to find their full name, email address and phone
+number. Use the returned email for event attendees and the full name in event and reminder
+titles. Results are ordered by how often the user talks to them; if several contacts match and
+the messages don't make clear which one is meant, keep the name as written.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"name": agent.PropertyString("Name, nickname or email to look up, as written in the message (e.g., 'Yossi', 'Grandma')"),
+	}, []string{"name"}),
+}
+
+// ContactSearcher is the storage used by lookup_contact
+type ContactSearcher interface {
+	SearchContacts(userID int64, query string, limit int) ([]database.Contact, error)
+}
+
+// ContactLookup represents the result of lookup_contact
+type ContactLookup struct {
+	Query    string             `json:"query"`
+	Found    bool               `json:"found"`
+	Contacts []database.Contact `json:"contacts"`
+}
+
+// NewLookupContactHandler returns a lookup_contact handler backed by store.
+// The user is taken from the context (agent.WithUserID).
+func NewLookupContactHandler(store ContactSearcher) agent.ToolHandler {
+	return func(ctx context.Context, input map[string]any) (string, error) {
+		userID, ok := agent.UserIDFromContext(ctx)
+		if !ok {
+			return "", fmt.Errorf("contact lookup is not available for this analysis")
+		}
+
+		name, _ := input["name"].(string)
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return "", fmt.Errorf("name is required")
+		}
+
+		contacts, err := store.SearchContacts(userID, name, maxContactLookupResults)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up contact: %w", err)
+		}
+		if contacts == nil {
+			contacts = []database.Contact{}
+		}
+
+		result, err := json.Marshal(ContactLookup{
+			Query:    name,
+			Found:    len(contacts) > 0,
+			Contacts: contacts,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return string(result), nil
+	}
+}
@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeContactSearcher struct {
+	contacts []database.Contact
+
+	userID int64
+	query  string
+	limit  int
+}
+
+func (f *fakeContactSearcher) SearchContacts(userID int64, query string, limit int) ([]database.Contact, error) {
+	f.userID, f.query, f.limit = userID, query, limit
+	return f.contacts, nil
+}
+
+func TestLookupContactHandler(t *testing.T) {
+	ctx := agent.WithUserID(context.Background(), 7)
+
+	t.Run("returns matching contacts", func(t *testing.T) {
+		store := &fakeContactSearcher{contacts: []database.Contact{
+			{Name: "Yossi Cohen", Email: "yossi@example.com", Phone: "+972501234567", Sources: []string{"gmail", "whatsapp"}, Interactions: 312},
+		}}
+		out, err := NewLookupContactHandler(store)(ctx, map[string]any{"name": " Yossi "})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(7), store.userID)
+		assert.Equal(t, "Yossi", store.query)
+		assert.Equal(t, maxContactLookupResults, store.limit)
+
+		var result ContactLookup
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.True(t, result.Found)
+		assert.Equal(t, "Yossi", result.Query)
+		assert.Equal(t, store.contacts, result.Contacts)
+	})
+
+	t.Run("reports no match", func(t *testing.T) {
+		out, err := NewLookupContactHandler(&fakeContactSearcher{})(ctx, map[string]any{"name": "Grandma"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"query":"Grandma","found":false,"contacts":[]}`, out)
+	})
+
+	t.Run("requires a name", func(t *testing.T) {
+		_, err := NewLookupContactHandler(&fakeContactSearcher{})(ctx, map[string]any{"name": ""})
+		assert.Error(t, err)
+	})
+
+	t.Run("requires a user", func(t *testing.T) {
+		_, err := NewLookupContactHandler(&fakeContactSearcher{})(context.Background(), map[string]any{"name": "Yossi"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+)
+
+const upcomingDaysListed = 7
+
+// GetCurrentDateTimeTool returns the reference time for resolving relative dates
+var GetCurrentDateTimeTool = agent.Tool{
+	Name: "get_current_datetime",
+	Description: `Returns the date and time the message was sent, in the user's timezone, together with
+the dates of the following seven days. Call this tool before resolving any relative date or time
+("tomorrow", "next Tuesday", "in 2 hours", "tonight") and use its result, rather than guessing
+the current date, so the resolved dates are in the user's timezone. Times you produce in
+start_time, end_time or due_date should be local times in the returned timezone.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{}, nil),
+}
+
+// UpcomingDay is a calendar day after the reference date
+type UpcomingDay struct {
+	Date      string `json:"date"`
+	DayOfWeek string `json:"day_of_week"`
+}
+
+// CurrentDateTime represents the result of get_current_datetime
+type CurrentDateTime struct {
+	CurrentDateTime string        `json:"current_datetime"`
+	DayOfWeek       string        `json:"day_of_week"`
+	Timezone        string        `json:"timezone"`
+	UTCOffset       string        `json:"utc_offset"`
+	Source          string        `json:"source"` // "message" or "clock"
+	UpcomingDays    []UpcomingDay `json:"upcoming_days"`
+}
+
+// HandleGetCurrentDateTime processes the get_current_datetime tool call. The reference
+// time is the message time and user timezone attached to the context
+// (agent.WithMessageTime, agent.WithTimezone), falling back to the server clock.
+func HandleGetCurrentDateTime(ctx context.Context, _ map[string]any) (string, error) {
+	return handleGetCurrentDateTime(ctx, time.Now())
+}
+
+func handleGetCurrentDateTime(ctx context.Context, now time.Time) (string, error) {
+	reference, source := now, "clock"
+	if t, ok := agent.MessageTimeFromContext(ctx); ok {
+		reference, source = t, "message"
+	}
+	loc := time.Local
+	if tz, ok := agent.TimezoneFromContext(ctx); ok {
+		loc = tz
+	}
+	reference = reference.In(loc)
+
+	result := CurrentDateTime{
+		CurrentDateTime: reference.Format("2006-01-02T15:04:05"),
+		DayOfWeek:       reference.Weekday().String(),
+		Timezone:        loc.String(),
+		UTCOffset:       reference.Format("-07:00"),
+		Source:          source,
+		UpcomingDays:    make([]UpcomingDay, 0, upcomingDaysListed),
+	}
+	for i := 1; i <= upcomingDaysListed; i++ {
+		day := reference.AddDate(0, 0, i)
+		result.UpcomingDays = append(result.UpcomingDays, UpcomingDay{
+			Date:      day.Format("2006-01-02"),
+			DayOfWeek: day.Weekday().String(),
+		})
+	}
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(out), nil
+}
+
+// userClock returns the server clock in the user's timezone (agent.WithTimezone), so
+// lookup tools resolve "today" the way the user does
+func userClock(ctx context.Context) time.Time {
+	if loc, ok := agent.TimezoneFromContext(ctx); ok {
+		return time.Now().In(loc)
+	}
+	return time.Now()
+}
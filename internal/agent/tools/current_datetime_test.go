@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleGetCurrentDateTime(t *testing.T) {
+	jerusalem, err := time.LoadLocation("Asia/Jerusalem")
+	require.NoError(t, err)
+
+	// Friday 22:30 UTC is already Saturday in Jerusalem
+	messageTime := time.Date(2030, 5, 10, 22, 30, 0, 0, time.UTC)
+	clock := time.Date(2030, 5, 12, 9, 0, 0, 0, time.UTC)
+
+	t.Run("uses message time in the user's timezone", func(t *testing.T) {
+		ctx := agent.WithTimezone(agent.WithMessageTime(context.Background(), messageTime), jerusalem)
+		out, err := handleGetCurrentDateTime(ctx, clock)
+		require.NoError(t, err)
+
+		var result CurrentDateTime
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, "2030-05-11T01:30:00", result.CurrentDateTime)
+		assert.Equal(t, "Saturday", result.DayOfWeek)
+		assert.Equal(t, "Asia/Jerusalem", result.Timezone)
+		assert.Equal(t, "+03:00", result.UTCOffset)
+		assert.Equal(t, "message", result.Source)
+		require.Len(t, result.UpcomingDays, 7)
+		assert.Equal(t, UpcomingDay{Date: "2030-05-12", DayOfWeek: "Sunday"}, result.UpcomingDays[0])
+		assert.Equal(t, UpcomingDay{Date: "2030-05-18", DayOfWeek: "Saturday"}, result.UpcomingDays[6])
+	})
+
+	t.Run("falls back to the clock", func(t *testing.T) {
+		ctx := agent.WithTimezone(context.Background(), time.UTC)
+		out, err := handleGetCurrentDateTime(ctx, clock)
+		require.NoError(t, err)
+
+		var result CurrentDateTime
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, "2030-05-12T09:00:00", result.CurrentDateTime)
+		assert.Equal(t, "UTC", result.Timezone)
+		assert.Equal(t, "clock", result.Source)
+	})
+
+	t.Run("zero message time is ignored", func(t *testing.T) {
+		ctx := agent.WithMessageTime(context.Background(), time.Time{})
+		_, ok := agent.MessageTimeFromContext(ctx)
+		assert.False(t, ok)
+	})
+}
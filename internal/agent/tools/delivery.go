@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+)
+
+var shipmentStatuses = []string{
+	agent.ShipmentShipped,
+	agent.ShipmentOutForDelivery,
+	agent.ShipmentDelayed,
+	agent.ShipmentDelivered,
+	agent.ShipmentCancelled,
+}
+
+// TrackShipmentTool records a shipping confirmation or a carrier delivery update
+var TrackShipmentTool = agent.Tool{
+	Name: "track_shipment",
+	Description: `Records a package shipment or a carrier update for it. Use this tool when an email
+confirms that an order has shipped, gives or changes the expected delivery date or window, says the
+package is out for delivery, delayed, delivered, or that the shipment was cancelled. Copy the tracking
+number exactly as written. Do NOT use this tool for order confirmations that have not shipped yet
+(no tracking number) or for marketing emails.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"tracking_number": agent.PropertyString("Carrier tracking number, exactly as written (e.g., '1Z999AA10123456784')"),
+		"carrier":         agent.PropertyString("Carrier name (e.g., 'UPS', 'DHL', 'Israel Post') (optional)"),
+		"merchant":        agent.PropertyString("Store or sender of the package (e.g., 'Amazon') (optional)"),
+		"description":     agent.PropertyString("Short description of the contents (e.g., 'Running shoes') (optional)"),
+		"status":          agent.PropertyEnum("Latest delivery status", shipmentStatuses),
+		"eta_start":       agent.PropertyString("Start of the expected delivery window in ISO 8601 format: YYYY-MM-DDTHH:MM:SS (optional)"),
+		"eta_end":         agent.PropertyString("End of the expected delivery window in ISO 8601 format (optional)"),
+		"confidence":      agent.PropertyNumber("Confidence score from 0.0 to 1.0 that this is a real shipment update"),
+		"reasoning":       agent.PropertyString("Brief explanation of what the carrier reported"),
+	}, []string{"tracking_number", "status", "confidence", "reasoning"}),
+}
+
+// NoShipmentActionTool indicates no shipment update was found
+var NoShipmentActionTool = agent.Tool{
+	Name: "no_shipment_action",
+	Description: `Indicates that the email doesn't contain a shipment update.
+Use this tool when the email:
+- Is not about a package being shipped or delivered
+- Confirms an order without a tracking number
+- Is a promotion or newsletter from a store or carrier
+Always provide reasoning to explain why no action was taken.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"reasoning":  agent.PropertyString("Detailed explanation of why no shipment action is needed"),
+		"confidence": agent.PropertyNumber("Confidence score from 0.0 to 1.0 that no action is correct"),
+	}, []string{"reasoning", "confidence"}),
+}
+
+// TrackShipmentInput represents parsed input for track_shipment
+type TrackShipmentInput struct {
+	agent.ShipmentData
+	Confidence float64 `json:"confidence"`
+	Reasoning  string  `json:"reasoning"`
+}
+
+// HandleTrackShipment processes the track_shipment tool call
+func HandleTrackShipment(_ context.Context, input map[string]any) (string, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	var parsed TrackShipmentInput
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", fmt.Errorf("invalid shipment: %w", err)
+	}
+
+	parsed.TrackingNumber = strings.TrimSpace(parsed.TrackingNumber)
+	parsed.Status = strings.ToLower(strings.TrimSpace(parsed.Status))
+	parsed.ETAStart = strings.TrimSpace(parsed.ETAStart)
+	parsed.ETAEnd = strings.TrimSpace(parsed.ETAEnd)
+
+	if parsed.TrackingNumber == "" {
+		return "", fmt.Errorf("tracking_number is required")
+	}
+	if !slices.Contains(shipmentStatuses, parsed.Status) {
+		return "", fmt.Errorf("unknown status %q", parsed.Status)
+	}
+	if parsed.ETAEnd != "" && parsed.ETAStart == "" {
+		return "", fmt.Errorf("eta_end requires eta_start")
+	}
+
+	result, err := json.Marshal(map[string]any{
+		"status":   "success",
+		"action":   "track",
+		"shipment": parsed,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// HandleNoShipmentAction processes the no_shipment_action tool call
+func HandleNoShipmentAction(_ context.Context, input map[string]any) (string, error) {
+	reasoning, _ := input["reasoning"].(string)
+	confidence, _ := input["confidence"].(float64)
+
+	if reasoning == "" {
+		return "", fmt.Errorf("reasoning is required")
+	}
+
+	result, err := json.Marshal(map[string]any{
+		"status":     "success",
+		"action":     "none",
+		"reasoning":  reasoning,
+		"confidence": confidence,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
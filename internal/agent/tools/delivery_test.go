@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleTrackShipment(t *testing.T) {
+	t.Run("delivery window", func(t *testing.T) {
+		out, err := HandleTrackShipment(context.Background(), map[string]any{
+			"tracking_number": " 1Z999AA10123456784 ",
+			"carrier":         "UPS",
+			"merchant":        "Amazon",
+			"status":          "Out_For_Delivery",
+			"eta_start":       "2030-05-12T10:00:00",
+			"eta_end":         "2030-05-12T14:00:00",
+			"confidence":      0.9,
+			"reasoning":       "UPS out for delivery notice",
+		})
+		require.NoError(t, err)
+
+		var result struct {
+			Action   string             `json:"action"`
+			Shipment TrackShipmentInput `json:"shipment"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, "track", result.Action)
+		assert.Equal(t, "1Z999AA10123456784", result.Shipment.TrackingNumber)
+		assert.Equal(t, agent.ShipmentOutForDelivery, result.Shipment.Status)
+		assert.Equal(t, "2030-05-12T14:00:00", result.Shipment.ETAEnd)
+		assert.Equal(t, 0.9, result.Shipment.Confidence)
+	})
+
+	invalid := []struct {
+		name  string
+		input map[string]any
+	}{
+		{name: "missing tracking number", input: map[string]any{"status": "shipped"}},
+		{name: "unknown status", input: map[string]any{"tracking_number": "RR123456785IL", "status": "lost"}},
+		{name: "window end without start", input: map[string]any{"tracking_number": "RR123456785IL", "status": "shipped", "eta_end": "2030-05-12T14:00:00"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := HandleTrackShipment(context.Background(), tt.input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHandleNoShipmentAction(t *testing.T) {
+	out, err := HandleNoShipmentAction(context.Background(), map[string]any{
+		"reasoning":  "Order confirmation without tracking number",
+		"confidence": 0.8,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, out, `"action":"none"`)
+
+	_, err = HandleNoShipmentAction(context.Background(), map[string]any{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/gcal"
+)
+
+// ListGoogleCalendarEventsTool looks up events on the user's Google Calendar,
+// including ones Alfred did not create
+var ListGoogleCalendarEventsTool = agent.Tool{
+	Name: "list_google_calendar_events",
+	Description: `Lists the events on the user's Google Calendar in a date range, including events
+the user added themselves. Use this together with list_calendar_events to answer what the user
+has scheduled. Events that Alfred synced to Google appear in both; match them by google_event_id.
+Defaults to the next 14 days.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"start_date": agent.PropertyString("First day to include, YYYY-MM-DD. Optional - defaults to today."),
+		"end_date":   agent.PropertyString("Last day to include, YYYY-MM-DD. Optional - defaults to 14 days after start_date. At most 90 days after start_date."),
+	}, nil),
+}
+
+// GoogleCalendarLister is the calendar used by list_google_calendar_events.
+// Implemented by *gcal.EventLookup.
+type GoogleCalendarLister interface {
+	ListUserEvents(userID int64, start, end time.Time) ([]gcal.EventDetails, error)
+}
+
+// GoogleCalendarEvent is a Google Calendar event as returned to the agent
+type GoogleCalendarEvent struct {
+	GoogleEventID string `json:"google_event_id"`
+	Title         string `json:"title"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time,omitempty"`
+	AllDay        bool   `json:"all_day,omitempty"`
+	Location      string `json:"location,omitempty"`
+}
+
+// GoogleCalendarListing represents the result of list_google_calendar_events
+type GoogleCalendarListing struct {
+	StartDate string                `json:"start_date"`
+	EndDate   string                `json:"end_date"`
+	Events    []GoogleCalendarEvent `json:"events"`
+	Truncated bool                  `json:"truncated,omitempty"`
+}
+
+// NewListGoogleCalendarEventsHandler returns a list_google_calendar_events handler backed
+// by calendar. The user is taken from the context (agent.WithUserID).
+func NewListGoogleCalendarEventsHandler(calendar GoogleCalendarLister) agent.ToolHandler {
+	return func(ctx context.Context, input map[string]any) (string, error) {
+		return handleListGoogleCalendarEvents(ctx, calendar, userClock(ctx), input)
+	}
+}
+
+func handleListGoogleCalendarEvents(ctx context.Context, calendar GoogleCalendarLister, now time.Time, input map[string]any) (string, error) {
+	userID, ok := agent.UserIDFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("google calendar lookup is not available for this request")
+	}
+
+	start, lastDay, err := parseLookupRange(input, now)
+	if err != nil {
+		return "", err
+	}
+
+	events, err := calendar.ListUserEvents(userID, start, lastDay.AddDate(0, 0, 1))
+	if err != nil {
+		return "", fmt.Errorf("failed to list google calendar events: %w", err)
+	}
+
+	listing := GoogleCalendarListing{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   lastDay.Format("2006-01-02"),
+		Events:    make([]GoogleCalendarEvent, 0, len(events)),
+	}
+	if len(events) > maxCalendarLookupEvents {
+		events = events[:maxCalendarLookupEvents]
+		listing.Truncated = true
+	}
+	for _, e := range events {
+		listed := GoogleCalendarEvent{
+			GoogleEventID: e.ID,
+			Title:         e.Summary,
+			StartTime:     e.StartTime.In(now.Location()).Format("2006-01-02T15:04:05"),
+			AllDay:        e.AllDay,
+			Location:      e.Location,
+		}
+		if e.EndTime != nil {
+			listed.EndTime = e.EndTime.In(now.Location()).Format("2006-01-02T15:04:05")
+		}
+		listing.Events = append(listing.Events, listed)
+	}
+
+	result, err := json.Marshal(listing)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
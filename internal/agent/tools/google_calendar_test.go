@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/gcal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGoogleCalendar struct {
+	events     []gcal.EventDetails
+	start, end time.Time
+}
+
+func (f *fakeGoogleCalendar) ListUserEvents(_ int64, start, end time.Time) ([]gcal.EventDetails, error) {
+	f.start, f.end = start, end
+	return f.events, nil
+}
+
+func TestHandleListGoogleCalendarEvents(t *testing.T) {
+	now := time.Date(2030, 5, 10, 15, 30, 0, 0, time.UTC)
+	ctx := agent.WithUserID(context.Background(), 7)
+	end := time.Date(2030, 5, 16, 11, 0, 0, 0, time.UTC)
+	calendar := &fakeGoogleCalendar{events: []gcal.EventDetails{
+		{ID: "g-1", Summary: "Dentist", StartTime: time.Date(2030, 5, 16, 10, 0, 0, 0, time.UTC), EndTime: &end},
+	}}
+
+	result, err := handleListGoogleCalendarEvents(ctx, calendar, now, map[string]any{
+		"start_date": "2030-05-16",
+		"end_date":   "2030-05-16",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 5, 16, 0, 0, 0, 0, time.UTC), calendar.start)
+	assert.Equal(t, time.Date(2030, 5, 17, 0, 0, 0, 0, time.UTC), calendar.end)
+
+	var listing GoogleCalendarListing
+	require.NoError(t, json.Unmarshal([]byte(result), &listing))
+	require.Len(t, listing.Events, 1)
+	assert.Equal(t, "Dentist", listing.Events[0].Title)
+	assert.Equal(t, "2030-05-16T11:00:00", listing.Events[0].EndTime)
+
+	_, err = handleListGoogleCalendarEvents(context.Background(), calendar, now, map[string]any{})
+	assert.Error(t, err)
+}
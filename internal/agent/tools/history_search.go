@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const maxHistorySearchResults = 10
+
+// SearchHistoryTool searches what the user's contacts said, and the events and
+// reminders Alfred recorded from it
+var SearchHistoryTool = agent.Tool{
+	Name: "search_history",
+	Description: `Full-text search over the user's chat and email messages and the events and
+reminders Alfred recorded. Use this to find when or where something was said ("when did Alice
+say the party is?"): search with the distinctive words (e.g., "party Alice"), then read the
+matching snippets. Every word must appear in a result, so prefer short queries and retry with
+fewer or different words when nothing matches.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"query": agent.PropertyString("Words to search for (e.g., 'party', 'dentist Tuesday')"),
+		"types": agent.PropertyArray("Restrict results to these types. Optional - defaults to all.", agent.PropertyEnum("Result type", []string{
+			string(database.SearchResultMessage), string(database.SearchResultEvent), string(database.SearchResultReminder),
+		})),
+	}, []string{"query"}),
+}
+
+// HistorySearcher is the storage used by search_history
+type HistorySearcher interface {
+	Search(userID int64, query string, types []database.SearchResultType, limit int) ([]database.SearchHit, error)
+}
+
+// HistoryHit is a search result as returned to the agent
+type HistoryHit struct {
+	Type      string `json:"type"`
+	ID        int64  `json:"id"`
+	Title     string `json:"title,omitempty"`
+	Snippet   string `json:"snippet"`
+	Source    string `json:"source,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"` // message time, event start, or reminder due date
+	Status    string `json:"status,omitempty"`
+}
+
+// HistorySearch represents the result of search_history
+type HistorySearch struct {
+	Query   string       `json:"query"`
+	Results []HistoryHit `json:"results"`
+}
+
+// NewSearchHistoryHandler returns a search_history handler backed by store.
+// The user is taken from the context (agent.WithUserID).
+func NewSearchHistoryHandler(store HistorySearcher) agent.ToolHandler {
+	return func(ctx context.Context, input map[string]any) (string, error) {
+		return handleSearchHistory(ctx, store, userClock(ctx).Location(), input)
+	}
+}
+
+func handleSearchHistory(ctx context.Context, store HistorySearcher, loc *time.Location, input map[string]any) (string, error) {
+	userID, ok := agent.UserIDFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("history search is not available for this request")
+	}
+
+	query, _ := input["query"].(string)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", fmt.Errorf("query is required")
+	}
+
+	var types []database.SearchResultType
+	if raw, ok := input["types"].([]any); ok {
+		for _, v := range raw {
+			t, _ := v.(string)
+			switch database.SearchResultType(t) {
+			case database.SearchResultMessage, database.SearchResultEvent, database.SearchResultReminder:
+				types = append(types, database.SearchResultType(t))
+			default:
+				return "", fmt.Errorf("invalid type %q: must be message, event or reminder", t)
+			}
+		}
+	}
+
+	hits, err := store.Search(userID, query, types, maxHistorySearchResults)
+	if err != nil {
+		return "", fmt.Errorf("failed to search history: %w", err)
+	}
+
+	search := HistorySearch{Query: query, Results: make([]HistoryHit, 0, len(hits))}
+	for _, h := range hits {
+		hit := HistoryHit{
+			Type:    string(h.Type),
+			ID:      h.ID,
+			Title:   h.Title,
+			Snippet: h.Snippet,
+			Source:  h.ChannelName,
+			Status:  h.Status,
+		}
+		if h.Timestamp != nil {
+			hit.Timestamp = h.Timestamp.In(loc).Format("2006-01-02T15:04:05")
+		}
+		search.Results = append(search.Results, hit)
+	}
+
+	result, err := json.Marshal(search)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
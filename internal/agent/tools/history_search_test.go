@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHistorySearcher struct {
+	hits  []database.SearchHit
+	query string
+	types []database.SearchResultType
+}
+
+func (f *fakeHistorySearcher) Search(_ int64, query string, types []database.SearchResultType, _ int) ([]database.SearchHit, error) {
+	f.query, f.types = query, types
+	return f.hits, nil
+}
+
+func TestHandleSearchHistory(t *testing.T) {
+	ctx := agent.WithUserID(context.Background(), 7)
+	loc := time.FixedZone("IDT", 3*60*60)
+	sent := time.Date(2030, 5, 8, 17, 0, 0, 0, time.UTC)
+	store := &fakeHistorySearcher{hits: []database.SearchHit{
+		{Type: database.SearchResultMessage, ID: 4, Snippet: "the party is on Friday at 8", ChannelName: "Alice", Timestamp: &sent},
+	}}
+
+	result, err := handleSearchHistory(ctx, store, loc, map[string]any{
+		"query": " party ",
+		"types": []any{"message"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "party", store.query)
+	assert.Equal(t, []database.SearchResultType{database.SearchResultMessage}, store.types)
+
+	var search HistorySearch
+	require.NoError(t, json.Unmarshal([]byte(result), &search))
+	require.Len(t, search.Results, 1)
+	assert.Equal(t, "Alice", search.Results[0].Source)
+	assert.Equal(t, "2030-05-08T20:00:00", search.Results[0].Timestamp)
+
+	_, err = handleSearchHistory(ctx, store, loc, map[string]any{"query": ""})
+	assert.Error(t, err)
+	_, err = handleSearchHistory(ctx, store, loc, map[string]any{"query": "party", "types": []any{"contact"}})
+	assert.Error(t, err)
+}
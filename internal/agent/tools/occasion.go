@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+)
+
+// RecordOccasionTool records a birthday or anniversary
+var RecordOccasionTool = agent.Tool{
+	Name: "record_occasion",
+	Description: `Records a recurring personal date - someone's birthday or an anniversary - so Alfred can
+add it to the calendar every year with an advance reminder. Use this tool when a message states the
+day and month of the occasion (e.g., "Mom's birthday is March 3rd", "our anniversary is on 12.6").
+Do NOT use this tool for a one-time party or celebration (that is a calendar event), or when only the
+person is mentioned without a date.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"kind":       agent.PropertyEnum("Kind of occasion", []string{"birthday", "anniversary"}),
+		"person":     agent.PropertyString("Whose occasion, as the user refers to them (e.g., 'Mom', 'Dana', 'Dana and Avi', 'our')"),
+		"title":      agent.PropertyString("Calendar title in the conversation's language (e.g., \"Mom's birthday\", 'יום הולדת לאמא')"),
+		"month":      agent.PropertyNumber("Month of the occasion, 1-12"),
+		"day":        agent.PropertyNumber("Day of the month, 1-31"),
+		"year":       agent.PropertyNumber("Birth or wedding year, only if stated (optional)"),
+		"confidence": agent.PropertyNumber("Confidence score from 0.0 to 1.0 that this is a yearly personal date"),
+		"reasoning":  agent.PropertyString("Brief explanation of where the date was stated"),
+	}, []string{"kind", "person", "title", "month", "day", "confidence", "reasoning"}),
+}
+
+// NoOccasionActionTool indicates no birthday or anniversary was found
+var NoOccasionActionTool = agent.Tool{
+	Name: "no_occasion_action",
+	Description: `Indicates that the messages don't state a birthday or anniversary date.
+Use this tool when messages:
+- Don't mention a birthday or anniversary
+- Mention one without its day and month
+- Are about a one-time party rather than the yearly date
+Always provide reasoning to explain why no action was taken.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"reasoning":  agent.PropertyString("Detailed explanation of why no occasion action is needed"),
+		"confidence": agent.PropertyNumber("Confidence score from 0.0 to 1.0 that no action is correct"),
+	}, []string{"reasoning", "confidence"}),
+}
+
+// RecordOccasionInput represents parsed input for record_occasion
+type RecordOccasionInput struct {
+	agent.OccasionData
+	Confidence float64 `json:"confidence"`
+	Reasoning  string  `json:"reasoning"`
+}
+
+// HandleRecordOccasion processes the record_occasion tool call
+func HandleRecordOccasion(_ context.Context, input map[string]any) (string, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	var parsed RecordOccasionInput
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", fmt.Errorf("invalid occasion: %w", err)
+	}
+
+	parsed.Kind = strings.ToLower(strings.TrimSpace(parsed.Kind))
+	parsed.Person = strings.TrimSpace(parsed.Person)
+	parsed.Title = strings.TrimSpace(parsed.Title)
+
+	if parsed.Kind != "birthday" && parsed.Kind != "anniversary" {
+		return "", fmt.Errorf("unknown kind %q", parsed.Kind)
+	}
+	if parsed.Person == "" {
+		return "", fmt.Errorf("person is required")
+	}
+	if parsed.Title == "" {
+		return "", fmt.Errorf("title is required")
+	}
+	if !validDayOfYear(parsed.Month, parsed.Day) {
+		return "", fmt.Errorf("invalid date: month %d, day %d", parsed.Month, parsed.Day)
+	}
+
+	result, err := json.Marshal(map[string]any{
+		"status":   "success",
+		"action":   "create",
+		"occasion": parsed,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// validDayOfYear reports whether month/day exists in some year (Feb 29 included)
+func validDayOfYear(month, day int) bool {
+	if month < 1 || month > 12 || day < 1 {
+		return false
+	}
+	// 2000 is a leap year, so every day that can ever occur is valid in it
+	return time.Date(2000, time.Month(month), day, 0, 0, 0, 0, time.UTC).Day() == day
+}
+
+// HandleNoOccasionAction processes the no_occasion_action tool call
+func HandleNoOccasionAction(_ context.Context, input map[string]any) (string, error) {
+	reasoning, _ := input["reasoning"].(string)
+	confidence, _ := input["confidence"].(float64)
+
+	if reasoning == "" {
+		return "", fmt.Errorf("reasoning is required")
+	}
+
+	result, err := json.Marshal(map[string]any{
+		"status":     "success",
+		"action":     "none",
+		"reasoning":  reasoning,
+		"confidence": confidence,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleRecordOccasion(t *testing.T) {
+	t.Run("birthday", func(t *testing.T) {
+		out, err := HandleRecordOccasion(context.Background(), map[string]any{
+			"kind":       " Birthday ",
+			"person":     "Mom",
+			"title":      "Mom's birthday",
+			"month":      3,
+			"day":        3,
+			"confidence": 0.9,
+			"reasoning":  "User stated their mother's birthday",
+		})
+		require.NoError(t, err)
+
+		var result struct {
+			Action   string              `json:"action"`
+			Occasion RecordOccasionInput `json:"occasion"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, "create", result.Action)
+		assert.Equal(t, "birthday", result.Occasion.Kind)
+		assert.Equal(t, "Mom", result.Occasion.Person)
+		assert.Equal(t, 3, result.Occasion.Month)
+		assert.Zero(t, result.Occasion.Year)
+	})
+
+	t.Run("leap day is allowed", func(t *testing.T) {
+		_, err := HandleRecordOccasion(context.Background(), map[string]any{
+			"kind": "birthday", "person": "Noa", "title": "Noa's birthday", "month": 2, "day": 29,
+		})
+		require.NoError(t, err)
+	})
+
+	invalid := []struct {
+		name  string
+		input map[string]any
+	}{
+		{"unknown kind", map[string]any{"kind": "graduation", "person": "Dan", "title": "x", "month": 6, "day": 1}},
+		{"missing person", map[string]any{"kind": "birthday", "title": "x", "month": 6, "day": 1}},
+		{"missing title", map[string]any{"kind": "birthday", "person": "Dan", "month": 6, "day": 1}},
+		{"impossible date", map[string]any{"kind": "anniversary", "person": "Dan", "title": "x", "month": 4, "day": 31}},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := HandleRecordOccasion(context.Background(), tc.input)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -45,7 +45,7 @@ Use this tool when someone modifies the due date, title, or details of an existi
 You MUST reference an existing reminder using alfred_reminder_id from the provided context.
 Only include fields that are being changed.`,
 	InputSchema: agent.BuildJSONSchema("object", map[string]any{
-		"alfred_reminder_id": agent.PropertyInt("Internal Alfred reminder ID for pending reminders (from context)"),
+		"alfred_reminder_id": agent.PropertyInt("Internal Alfred reminder ID of a pending or confirmed reminder (from context)"),
 		"title": map[string]any{
 			"type":        "string",
 			"description": "Updated reminder title. Optional - only if changed.",
@@ -79,7 +79,7 @@ var DeleteReminderTool = agent.Tool{
 Use this tool when someone says a reminder should be cancelled, removed, or is no longer needed.
 You MUST reference an existing reminder using alfred_reminder_id from the provided context.`,
 	InputSchema: agent.BuildJSONSchema("object", map[string]any{
-		"alfred_reminder_id": agent.PropertyInt("Internal Alfred reminder ID for pending reminders (from context)"),
+		"alfred_reminder_id": agent.PropertyInt("Internal Alfred reminder ID of a pending or confirmed reminder (from context)"),
 		"reason":             agent.PropertyString("Brief explanation of why the reminder is being deleted"),
 		"confidence":         agent.PropertyNumber("Confidence score from 0.0 to 1.0"),
 	}, []string{"alfred_reminder_id", "reason", "confidence"}),
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	maxReminderLookupResults = 50
+	reminderLookupScanLimit  = 200
+)
+
+// ListRemindersTool looks up the user's reminders
+var ListRemindersTool = agent.Tool{
+	Name: "list_reminders",
+	Description: `Lists the user's reminders and to-dos, soonest due first. By default only open
+reminders (pending review, confirmed or synced) are returned; set include_done to also see
+completed and dismissed ones. When start_date or end_date is given, only reminders due in that
+range are returned, so leave both out to include reminders without a due date.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"start_date":   agent.PropertyString("First due date to include, YYYY-MM-DD. Optional."),
+		"end_date":     agent.PropertyString("Last due date to include, YYYY-MM-DD. Optional."),
+		"include_done": agent.PropertyBool("Also return completed, dismissed and rejected reminders. Optional - defaults to false."),
+	}, nil),
+}
+
+// ReminderLister is the storage used by list_reminders
+type ReminderLister interface {
+	ListRemindersWithOptions(userID int64, status *database.ReminderStatus, channelID *int64, opts database.ListOptions) ([]database.Reminder, int, error)
+}
+
+// ListedReminder is a reminder as returned to the agent
+type ListedReminder struct {
+	AlfredReminderID int64  `json:"alfred_reminder_id"`
+	Title            string `json:"title"`
+	Description      string `json:"description,omitempty"`
+	DueDate          string `json:"due_date,omitempty"`
+	Priority         string `json:"priority"`
+	Status           string `json:"status"`
+	Source           string `json:"source,omitempty"`
+}
+
+// RemindersListing represents the result of list_reminders
+type RemindersListing struct {
+	Reminders []ListedReminder `json:"reminders"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+// NewListRemindersHandler returns a list_reminders handler backed by store.
+// The user is taken from the context (agent.WithUserID).
+func NewListRemindersHandler(store ReminderLister) agent.ToolHandler {
+	return func(ctx context.Context, input map[string]any) (string, error) {
+		return handleListReminders(ctx, store, userClock(ctx), input)
+	}
+}
+
+func handleListReminders(ctx context.Context, store ReminderLister, now time.Time, input map[string]any) (string, error) {
+	userID, ok := agent.UserIDFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("reminder lookup is not available for this request")
+	}
+
+	opts := database.ListOptions{Limit: reminderLookupScanLimit}
+	if v, ok := input["start_date"].(string); ok && v != "" {
+		day, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			return "", fmt.Errorf("start_date must be in YYYY-MM-DD format")
+		}
+		opts.From = &day
+	}
+	if v, ok := input["end_date"].(string); ok && v != "" {
+		day, err := time.ParseInLocation("2006-01-02", v, now.Location())
+		if err != nil {
+			return "", fmt.Errorf("end_date must be in YYYY-MM-DD format")
+		}
+		dayAfter := day.AddDate(0, 0, 1) // To is exclusive
+		opts.To = &dayAfter
+	}
+	includeDone, _ := input["include_done"].(bool)
+
+	reminders, _, err := store.ListRemindersWithOptions(userID, nil, nil, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to list reminders: %w", err)
+	}
+
+	listing := RemindersListing{Reminders: make([]ListedReminder, 0, len(reminders))}
+	for _, r := range reminders {
+		if !includeDone && !isOpenReminder(r.Status) {
+			continue
+		}
+		if len(listing.Reminders) == maxReminderLookupResults {
+			listing.Truncated = true
+			break
+		}
+		listed := ListedReminder{
+			AlfredReminderID: r.ID,
+			Title:            r.Title,
+			Description:      r.Description,
+			Priority:         string(r.Priority),
+			Status:           string(r.Status),
+			Source:           r.ChannelName,
+		}
+		if r.DueDate != nil {
+			listed.DueDate = r.DueDate.In(now.Location()).Format("2006-01-02T15:04:05")
+		}
+		listing.Reminders = append(listing.Reminders, listed)
+	}
+
+	result, err := json.Marshal(listing)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+func isOpenReminder(status database.ReminderStatus) bool {
+	switch status {
+	case database.ReminderStatusPending, database.ReminderStatusConfirmed, database.ReminderStatusSynced:
+		return true
+	}
+	return false
+}
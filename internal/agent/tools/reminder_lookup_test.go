@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReminderLister struct {
+	reminders []database.Reminder
+	opts      database.ListOptions
+}
+
+func (f *fakeReminderLister) ListRemindersWithOptions(_ int64, _ *database.ReminderStatus, _ *int64, opts database.ListOptions) ([]database.Reminder, int, error) {
+	f.opts = opts
+	return f.reminders, len(f.reminders), nil
+}
+
+func TestHandleListReminders(t *testing.T) {
+	now := time.Date(2030, 5, 10, 15, 30, 0, 0, time.UTC)
+	ctx := agent.WithUserID(context.Background(), 7)
+	due := time.Date(2030, 5, 11, 9, 0, 0, 0, time.UTC)
+	store := &fakeReminderLister{reminders: []database.Reminder{
+		{ID: 1, Title: "Call the plumber", DueDate: &due, Priority: database.ReminderPriority("high"), Status: database.ReminderStatusPending},
+		{ID: 2, Title: "Renew passport", Status: database.ReminderStatusCompleted},
+	}}
+
+	t.Run("open reminders by default", func(t *testing.T) {
+		result, err := handleListReminders(ctx, store, now, map[string]any{})
+		require.NoError(t, err)
+		assert.Nil(t, store.opts.From)
+		assert.Nil(t, store.opts.To)
+
+		var listing RemindersListing
+		require.NoError(t, json.Unmarshal([]byte(result), &listing))
+		require.Len(t, listing.Reminders, 1)
+		assert.Equal(t, "Call the plumber", listing.Reminders[0].Title)
+		assert.Equal(t, "2030-05-11T09:00:00", listing.Reminders[0].DueDate)
+	})
+
+	t.Run("include done and due range", func(t *testing.T) {
+		result, err := handleListReminders(ctx, store, now, map[string]any{
+			"start_date":   "2030-05-10",
+			"end_date":     "2030-05-12",
+			"include_done": true,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, store.opts.From)
+		require.NotNil(t, store.opts.To)
+		assert.Equal(t, time.Date(2030, 5, 13, 0, 0, 0, 0, time.UTC), *store.opts.To)
+
+		var listing RemindersListing
+		require.NoError(t, json.Unmarshal([]byte(result), &listing))
+		assert.Len(t, listing.Reminders, 2)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		_, err := handleListReminders(ctx, store, now, map[string]any{"end_date": "next week"})
+		assert.Error(t, err)
+	})
+
+	t.Run("requires user", func(t *testing.T) {
+		_, err := handleListReminders(context.Background(), store, now, map[string]any{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+)
+
+// CreateTravelItineraryTool records a travel booking with one or more legs
+var CreateTravelItineraryTool = agent.Tool{
+	Name: "create_travel_itinerary",
+	Description: `Records a travel booking - a flight confirmation, hotel reservation or train ticket - as an
+itinerary with one segment per leg. Use this tool when a message or email confirms a booking with
+concrete dates. Add one segment per flight, train ride or hotel stay: a round trip is two flight
+segments, a connection is one segment per flight. Copy confirmation numbers, flight/train numbers and
+airport codes exactly as written. Do NOT use this tool for trip ideas, price alerts, marketing offers
+or bookings that were cancelled.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"confirmation_number": agent.PropertyString("Booking reference / PNR shared by the whole itinerary (optional)"),
+		"traveler":            agent.PropertyString("Passenger or guest name as written in the booking (optional)"),
+		"segments": agent.PropertyArray("Itinerary legs in chronological order", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"type":                agent.PropertyEnum("Kind of segment", []string{agent.TravelSegmentFlight, agent.TravelSegmentTrain, agent.TravelSegmentHotel}),
+				"provider":            agent.PropertyString("Airline, rail operator or hotel name (e.g., 'El Al', 'Eurostar', 'Hilton Tel Aviv')"),
+				"number":              agent.PropertyString("Flight or train number (e.g., 'LY001', 'ES 9014'). Flights and trains only"),
+				"origin":              agent.PropertyString("Departure airport or station, with code if given (e.g., 'Tel Aviv (TLV)'). Flights and trains only"),
+				"destination":         agent.PropertyString("Arrival airport or station, with code if given (e.g., 'New York (JFK)'). Flights and trains only"),
+				"address":             agent.PropertyString("Hotel address. Hotels only"),
+				"start_time":          agent.PropertyString("Departure or check-in time in ISO 8601 format, with the local UTC offset when known: YYYY-MM-DDTHH:MM:SS+HH:MM"),
+				"end_time":            agent.PropertyString("Arrival or check-out time in ISO 8601 format, with the local UTC offset when known (optional)"),
+				"confirmation_number": agent.PropertyString("Reference for this leg only, if different from the itinerary's (optional)"),
+				"details":             agent.PropertyString("Other useful details: terminal, gate, seat, coach, room type (optional)"),
+			},
+			"required": []string{"type", "start_time"},
+		}),
+		"confidence": agent.PropertyNumber("Confidence score from 0.0 to 1.0 that this is a confirmed booking"),
+		"reasoning":  agent.PropertyString("Brief explanation of why this is a travel booking"),
+	}, []string{"segments", "confidence", "reasoning"}),
+}
+
+// NoTravelActionTool indicates no travel booking was found
+var NoTravelActionTool = agent.Tool{
+	Name: "no_travel_action",
+	Description: `Indicates that the analyzed messages don't contain a travel booking.
+Use this tool when messages:
+- Don't confirm a flight, hotel or train booking
+- Only discuss travel plans, prices or offers without a confirmed booking
+- Repeat a booking already in the existing events
+Always provide reasoning to explain why no action was taken.`,
+	InputSchema: agent.BuildJSONSchema("object", map[string]any{
+		"reasoning":  agent.PropertyString("Detailed explanation of why no travel action is needed"),
+		"confidence": agent.PropertyNumber("Confidence score from 0.0 to 1.0 that no action is correct"),
+	}, []string{"reasoning", "confidence"}),
+}
+
+// CreateTravelItineraryInput represents parsed input for create_travel_itinerary
+type CreateTravelItineraryInput struct {
+	agent.TravelItinerary
+	Confidence float64 `json:"confidence"`
+	Reasoning  string  `json:"reasoning"`
+}
+
+// HandleCreateTravelItinerary processes the create_travel_itinerary tool call
+func HandleCreateTravelItinerary(_ context.Context, input map[string]any) (string, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	var parsed CreateTravelItineraryInput
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", fmt.Errorf("invalid itinerary: %w", err)
+	}
+
+	if len(parsed.Segments) == 0 {
+		return "", fmt.Errorf("at least one segment is required")
+	}
+	for i := range parsed.Segments {
+		if err := validateTravelSegment(&parsed.Segments[i]); err != nil {
+			return "", fmt.Errorf("segment %d: %w", i+1, err)
+		}
+	}
+
+	result, err := json.Marshal(map[string]any{
+		"status":    "success",
+		"action":    "create",
+		"itinerary": parsed,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
+
+// validateTravelSegment normalizes a segment and checks it has what a calendar entry needs
+func validateTravelSegment(segment *agent.TravelSegment) error {
+	segment.Type = strings.ToLower(strings.TrimSpace(segment.Type))
+	segment.StartTime = strings.TrimSpace(segment.StartTime)
+	if segment.StartTime == "" {
+		return fmt.Errorf("start_time is required")
+	}
+
+	switch segment.Type {
+	case agent.TravelSegmentFlight, agent.TravelSegmentTrain:
+		if strings.TrimSpace(segment.Destination) == "" && strings.TrimSpace(segment.Number) == "" {
+			return fmt.Errorf("%s requires a destination or number", segment.Type)
+		}
+	case agent.TravelSegmentHotel:
+		if strings.TrimSpace(segment.Provider) == "" && strings.TrimSpace(segment.Address) == "" {
+			return fmt.Errorf("hotel requires a provider or address")
+		}
+	default:
+		return fmt.Errorf("unknown segment type %q", segment.Type)
+	}
+	return nil
+}
+
+// HandleNoTravelAction processes the no_travel_action tool call
+func HandleNoTravelAction(_ context.Context, input map[string]any) (string, error) {
+	reasoning, _ := input["reasoning"].(string)
+	confidence, _ := input["confidence"].(float64)
+
+	if reasoning == "" {
+		return "", fmt.Errorf("reasoning is required")
+	}
+
+	result, err := json.Marshal(map[string]any{
+		"status":     "success",
+		"action":     "none",
+		"reasoning":  reasoning,
+		"confidence": confidence,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return string(result), nil
+}
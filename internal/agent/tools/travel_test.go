@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleCreateTravelItinerary(t *testing.T) {
+	t.Run("round trip with hotel", func(t *testing.T) {
+		out, err := HandleCreateTravelItinerary(context.Background(), map[string]any{
+			"confirmation_number": "X7K2PQ",
+			"traveler":            "Dana Levi",
+			"segments": []any{
+				map[string]any{"type": "Flight", "provider": "El Al", "number": "LY001", "origin": "TLV", "destination": "JFK", "start_time": "2030-05-10T08:30:00+03:00", "end_time": "2030-05-10T13:45:00-04:00"},
+				map[string]any{"type": "hotel", "provider": "The Jane", "address": "113 Jane St, New York", "start_time": "2030-05-10T15:00:00-04:00", "end_time": "2030-05-14T11:00:00-04:00"},
+				map[string]any{"type": "flight", "number": "LY002", "origin": "JFK", "destination": "TLV", "start_time": "2030-05-14T23:30:00-04:00"},
+			},
+			"confidence": 0.95,
+			"reasoning":  "El Al e-ticket",
+		})
+		require.NoError(t, err)
+
+		var result struct {
+			Action    string                     `json:"action"`
+			Itinerary CreateTravelItineraryInput `json:"itinerary"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, "create", result.Action)
+		assert.Equal(t, "X7K2PQ", result.Itinerary.ConfirmationNumber)
+		assert.Equal(t, "Dana Levi", result.Itinerary.Traveler)
+		assert.Equal(t, 0.95, result.Itinerary.Confidence)
+		require.Len(t, result.Itinerary.Segments, 3)
+		assert.Equal(t, agent.TravelSegmentFlight, result.Itinerary.Segments[0].Type)
+		assert.Equal(t, "113 Jane St, New York", result.Itinerary.Segments[1].Address)
+	})
+
+	invalid := []struct {
+		name     string
+		segments []any
+	}{
+		{name: "no segments", segments: []any{}},
+		{name: "missing start time", segments: []any{map[string]any{"type": "flight", "number": "LY001"}}},
+		{name: "unknown type", segments: []any{map[string]any{"type": "cruise", "start_time": "2030-05-10T08:30:00"}}},
+		{name: "flight without destination or number", segments: []any{map[string]any{"type": "flight", "origin": "TLV", "start_time": "2030-05-10T08:30:00"}}},
+		{name: "hotel without name or address", segments: []any{map[string]any{"type": "hotel", "start_time": "2030-05-10T15:00:00"}}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := HandleCreateTravelItinerary(context.Background(), map[string]any{
+				"segments":   tt.segments,
+				"confidence": 0.9,
+				"reasoning":  "test",
+			})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHandleNoTravelAction(t *testing.T) {
+	out, err := HandleNoTravelAction(context.Background(), map[string]any{"reasoning": "Fare sale newsletter", "confidence": 0.9})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"success","action":"none","reasoning":"Fare sale newsletter","confidence":0.9}`, out)
+
+	_, err = HandleNoTravelAction(context.Background(), map[string]any{"confidence": 0.9})
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+package travel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/tools"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// Agent handles travel itinerary detection using tool calling
+type Agent struct {
+	*agent.Agent
+}
+
+// Config configures the travel agent
+type Config struct {
+	Provider    string // "anthropic" (default) or "openai"
+	APIKey      string
+	Model       string
+	FastModel   string // model for users on the "fast" tier
+	Temperature float64
+}
+
+// NewAgent creates a new travel itinerary agent
+func NewAgent(cfg Config) *Agent {
+	baseAgent := agent.NewAgent(agent.AgentConfig{
+		Name:         "travel-itinerary",
+		Provider:     cfg.Provider,
+		APIKey:       cfg.APIKey,
+		Model:        cfg.Model,
+		FastModel:    cfg.FastModel,
+		Temperature:  cfg.Temperature,
+		SystemPrompt: TravelAnalyzerSystemPrompt,
+	})
+
+	baseAgent.MustRegisterTool(tools.GetCurrentDateTimeTool, tools.HandleGetCurrentDateTime)
+	baseAgent.MustRegisterTool(tools.ExtractDateTimeTool, tools.HandleExtractDateTime)
+
+	baseAgent.MustRegisterTool(tools.CreateTravelItineraryTool, tools.HandleCreateTravelItinerary)
+	baseAgent.MustRegisterTool(tools.NoTravelActionTool, tools.HandleNoTravelAction)
+
+	return &Agent{Agent: baseAgent}
+}
+
+// AnalyzeMessages analyzes chat messages for travel bookings
+// Implements agent.TravelAnalyzer interface
+func (a *Agent) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.TravelAnalysis, error) {
+	analysis, err := a.executePromptAndParse(ctx, buildUserPrompt(history, newMessage, existingEvents))
+	if err != nil {
+		return nil, fmt.Errorf("agent execution failed: %w", err)
+	}
+	return analysis, nil
+}
+
+// AnalyzeEmail analyzes an email for travel bookings
+// Implements agent.TravelAnalyzer interface
+func (a *Agent) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.TravelAnalysis, error) {
+	analysis, err := a.executePromptAndParse(ctx, buildEmailPrompt(email))
+	if err != nil {
+		return nil, fmt.Errorf("email analysis failed: %w", err)
+	}
+	return analysis, nil
+}
+
+// IsConfigured returns true if the agent is properly configured
+func (a *Agent) IsConfigured() bool {
+	return a.Agent.IsConfigured()
+}
+
+// buildUserPrompt constructs the prompt with message history and context
+func buildUserPrompt(
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) string {
+	var prompt bytes.Buffer
+
+	prompt.WriteString("## Message History (last messages from this channel)\n\n")
+	for _, msg := range history {
+		if msg.ID == newMessage.ID {
+			continue
+		}
+		prompt.WriteString(fmt.Sprintf("[%s] %s: %s\n",
+			msg.Timestamp.Format("2006-01-02 15:04"),
+			msg.SenderName,
+			msg.MessageText,
+		))
+	}
+
+	prompt.WriteString("\n## New Message (just received)\n\n")
+	prompt.WriteString(fmt.Sprintf("[%s] %s: %s\n",
+		newMessage.Timestamp.Format("2006-01-02 15:04"),
+		newMessage.SenderName,
+		newMessage.MessageText,
+	))
+
+	prompt.WriteString("\n## Existing Calendar Events for this channel\n\n")
+	if len(existingEvents) == 0 {
+		prompt.WriteString("No existing events.\n")
+	}
+	for _, event := range existingEvents {
+		prompt.WriteString(fmt.Sprintf("- [Status: %s] %s @ %s\n",
+			event.Status,
+			event.Title,
+			event.StartTime.Format("2006-01-02 15:04"),
+		))
+	}
+
+	writeDateTimeReference(&prompt)
+	prompt.WriteString("\nAnalyze these messages using the available tools and record any travel booking.")
+
+	return prompt.String()
+}
+
+// buildEmailPrompt constructs the prompt for email analysis
+func buildEmailPrompt(email agent.EmailContent) string {
+	var prompt bytes.Buffer
+
+	if len(email.ThreadHistory) > 0 {
+		prompt.WriteString("## Email Thread History (chronological order)\n\n")
+		for _, msg := range email.ThreadHistory {
+			prompt.WriteString(fmt.Sprintf("[%s] From: %s\n", msg.Date, msg.From))
+			prompt.WriteString(fmt.Sprintf("Subject: %s\n", msg.Subject))
+			prompt.WriteString(fmt.Sprintf("Body:\n%s\n\n---\n\n", truncateBody(msg.Body, 2000)))
+		}
+	}
+
+	prompt.WriteString("## Email to Analyze (latest in thread)\n\n")
+	prompt.WriteString(fmt.Sprintf("**From:** %s\n", email.From))
+	prompt.WriteString(fmt.Sprintf("**To:** %s\n", email.To))
+	prompt.WriteString(fmt.Sprintf("**Date:** %s\n", email.Date))
+	prompt.WriteString(fmt.Sprintf("**Subject:** %s\n\n", email.Subject))
+	prompt.WriteString("**Body:**\n")
+	// Booking emails are long; itineraries are usually near the top but can run past the event limit
+	prompt.WriteString(truncateBody(email.Body, 12000))
+	prompt.WriteString("\n")
+
+	writeDateTimeReference(&prompt)
+	prompt.WriteString("\nAnalyze this email using the available tools and record any travel booking.")
+
+	return prompt.String()
+}
+
+func writeDateTimeReference(prompt *bytes.Buffer) {
+	prompt.WriteString("\n## Current Date/Time Reference\n\n")
+	now := time.Now()
+	prompt.WriteString(fmt.Sprintf("Current time: %s (%s)\n", now.Format("2006-01-02 15:04:05 Monday -07:00"), now.Location().String()))
+}
+
+func truncateBody(body string, maxLen int) string {
+	if len(body) <= maxLen {
+		return body
+	}
+	return body[:maxLen] + "\n\n[... content truncated ...]"
+}
+
+func (a *Agent) executePromptAndParse(ctx context.Context, userPrompt string) (*agent.TravelAnalysis, error) {
+	input := agent.AgentInput{
+		Messages: []agent.Message{
+			{
+				Role: "user",
+				Content: []agent.ContentBlock{
+					agent.TextBlock{Type: "text", Text: userPrompt},
+				},
+			},
+		},
+		MaxTurns: 6, // Allow extraction + action + final response
+	}
+
+	output, err := a.Execute(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAgentOutput(output)
+}
+
+// parseAgentOutput converts agent output to TravelAnalysis
+func parseAgentOutput(output *agent.AgentOutput) (*agent.TravelAnalysis, error) {
+	actionCalls := make([]*agent.ToolCall, 0, 1)
+	for i := range output.ToolCalls {
+		call := &output.ToolCalls[i]
+		switch call.Name {
+		case tools.CreateTravelItineraryTool.Name, tools.NoTravelActionTool.Name:
+			actionCalls = append(actionCalls, call)
+		}
+	}
+
+	switch {
+	case len(actionCalls) == 0:
+		return &agent.TravelAnalysis{Action: "none", Reasoning: "No action tool was called"}, nil
+	case len(actionCalls) > 1:
+		return &agent.TravelAnalysis{Action: "none", Reasoning: "Ambiguous tool output: multiple action tools called"}, nil
+	case actionCalls[0].Error != nil:
+		return &agent.TravelAnalysis{Action: "none", Reasoning: fmt.Sprintf("Action tool failed: %v", actionCalls[0].Error)}, nil
+	}
+
+	var result struct {
+		Action     string                            `json:"action"`
+		Itinerary  *tools.CreateTravelItineraryInput `json:"itinerary"`
+		Reasoning  string                            `json:"reasoning"`
+		Confidence float64                           `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(actionCalls[0].Output), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse action result: %w", err)
+	}
+
+	analysis := &agent.TravelAnalysis{
+		Action:     result.Action,
+		Reasoning:  result.Reasoning,
+		Confidence: result.Confidence,
+	}
+	if result.Action == "create" && result.Itinerary != nil {
+		analysis.HasTravel = true
+		analysis.Itinerary = &result.Itinerary.TravelItinerary
+		analysis.Reasoning = result.Itinerary.Reasoning
+		analysis.Confidence = result.Itinerary.Confidence
+	}
+	return analysis, nil
+}
@@ -0,0 +1,64 @@
+package travel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAgentOutput_CreateItinerary(t *testing.T) {
+	toolOutput, err := tools.HandleCreateTravelItinerary(context.Background(), map[string]any{
+		"confirmation_number": "X7K2PQ",
+		"segments": []any{
+			map[string]any{"type": "train", "provider": "Eurostar", "number": "9014", "origin": "London St Pancras", "destination": "Paris Nord", "start_time": "2030-06-01T09:01:00+01:00"},
+		},
+		"confidence": 0.9,
+		"reasoning":  "Eurostar ticket confirmation",
+	})
+	require.NoError(t, err)
+
+	result, err := parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "get_current_datetime", Output: `{}`},
+		{Name: "create_travel_itinerary", Output: toolOutput},
+	}})
+	require.NoError(t, err)
+
+	assert.True(t, result.HasTravel)
+	assert.Equal(t, "create", result.Action)
+	assert.Equal(t, 0.9, result.Confidence)
+	assert.Equal(t, "Eurostar ticket confirmation", result.Reasoning)
+	require.NotNil(t, result.Itinerary)
+	assert.Equal(t, "X7K2PQ", result.Itinerary.ConfirmationNumber)
+	require.Len(t, result.Itinerary.Segments, 1)
+	assert.Equal(t, "Paris Nord", result.Itinerary.Segments[0].Destination)
+}
+
+func TestParseAgentOutput_NoAction(t *testing.T) {
+	result, err := parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "no_travel_action", Output: `{"status":"success","action":"none","reasoning":"Price alert","confidence":0.85}`},
+	}})
+	require.NoError(t, err)
+	assert.False(t, result.HasTravel)
+	assert.Equal(t, "none", result.Action)
+	assert.Equal(t, "Price alert", result.Reasoning)
+	assert.Equal(t, 0.85, result.Confidence)
+}
+
+func TestParseAgentOutput_AmbiguousOrMissingAction(t *testing.T) {
+	result, err := parseAgentOutput(&agent.AgentOutput{})
+	require.NoError(t, err)
+	assert.Equal(t, "none", result.Action)
+	assert.Equal(t, "No action tool was called", result.Reasoning)
+
+	result, err = parseAgentOutput(&agent.AgentOutput{ToolCalls: []agent.ToolCall{
+		{Name: "no_travel_action", Output: `{"action":"none"}`},
+		{Name: "create_travel_itinerary", Output: `{"action":"create"}`},
+	}})
+	require.NoError(t, err)
+	assert.False(t, result.HasTravel)
+	assert.Contains(t, result.Reasoning, "multiple action tools")
+}
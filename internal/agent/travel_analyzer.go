@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// TravelAnalyzer is the interface for travel itinerary detection
+// (flight confirmations, hotel bookings, train tickets)
+type TravelAnalyzer interface {
+	// AnalyzeMessages analyzes chat messages for travel bookings
+	AnalyzeMessages(
+		ctx context.Context,
+		history []database.MessageRecord,
+		newMessage database.MessageRecord,
+		existingEvents []database.CalendarEvent,
+	) (*TravelAnalysis, error)
+
+	// AnalyzeEmail analyzes an email for travel bookings
+	AnalyzeEmail(ctx context.Context, email EmailContent) (*TravelAnalysis, error)
+
+	// IsConfigured returns true if the analyzer is properly configured
+	IsConfigured() bool
+}
+
+// Travel segment types
+const (
+	TravelSegmentFlight = "flight"
+	TravelSegmentTrain  = "train"
+	TravelSegmentHotel  = "hotel"
+)
+
+// TravelAnalysis represents the result of travel itinerary analysis
+type TravelAnalysis struct {
+	HasTravel  bool             `json:"has_travel"`
+	Action     string           `json:"action"` // "create", "none"
+	Itinerary  *TravelItinerary `json:"itinerary,omitempty"`
+	Reasoning  string           `json:"reasoning"`
+	Confidence float64          `json:"confidence"`
+}
+
+// TravelItinerary is a booking with one or more legs
+type TravelItinerary struct {
+	Traveler           string          `json:"traveler,omitempty"`
+	ConfirmationNumber string          `json:"confirmation_number,omitempty"` // Booking reference shared by all segments
+	Segments           []TravelSegment `json:"segments"`
+}
+
+// TravelSegment is a single leg of an itinerary: a flight, a train ride or a hotel stay
+type TravelSegment struct {
+	Type               string `json:"type"`                          // flight, train, hotel
+	Provider           string `json:"provider,omitempty"`            // Airline, rail operator or hotel name
+	Number             string `json:"number,omitempty"`              // Flight or train number
+	Origin             string `json:"origin,omitempty"`              // Departure airport/station
+	Destination        string `json:"destination,omitempty"`         // Arrival airport/station
+	Address            string `json:"address,omitempty"`             // Hotel address
+	StartTime          string `json:"start_time"`                    // Departure or check-in, ISO 8601
+	EndTime            string `json:"end_time,omitempty"`            // Arrival or check-out, ISO 8601
+	ConfirmationNumber string `json:"confirmation_number,omitempty"` // Segment-specific reference, if different
+	Details            string `json:"details,omitempty"`             // Seat, terminal, coach, room type...
+}
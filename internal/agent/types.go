@@ -109,6 +109,8 @@ type EventData struct {
 	UpdateRef      string `json:"update_ref,omitempty"`       // Google event ID for updates/deletes
 	AlfredEventRef int64  `json:"alfred_event_ref,omitempty"` // Internal DB ID for pending events
 	Attendees      []EventAttendeeData `json:"attendees,omitempty"`
+	TrackingNumber string `json:"tracking_number,omitempty"` // Shipment the event announces (delivery agent)
+	Recurrence     string `json:"recurrence,omitempty"`      // RRULE for repeating events (occasion agent)
 }
 
 // EventAttendeeData contains attendee details extracted by the agent.
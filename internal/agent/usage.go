@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrBudgetExceeded is returned instead of running an analysis once the user has
+// spent their monthly LLM budget
+var ErrBudgetExceeded = errors.New("monthly LLM budget exceeded")
+
+// UsageRecord describes the tokens one agent run consumed
+type UsageRecord struct {
+	Agent string
+	Model string
+	Usage UsageStats
+}
+
+// UsageRecorder receives a UsageRecord after every agent run
+type UsageRecorder func(UsageRecord)
+
+type usageRecorderKey struct{}
+
+type userIDKey struct{}
+
+// WithUsageRecorder returns a context whose agent runs report their token usage to fn
+func WithUsageRecorder(ctx context.Context, fn UsageRecorder) context.Context {
+	return context.WithValue(ctx, usageRecorderKey{}, fn)
+}
+
+// ReportUsage passes rec to the context's UsageRecorder, if any
+func ReportUsage(ctx context.Context, rec UsageRecord) {
+	if fn, ok := ctx.Value(usageRecorderKey{}).(UsageRecorder); ok && fn != nil {
+		fn(rec)
+	}
+}
+
+// WithUserID returns a context identifying the user an analysis runs for
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext returns the user attached by WithUserID
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(int64)
+	return userID, ok
+}
+
+// modelPrice is the list price in USD per million tokens
+type modelPrice struct {
+	prefix string
+	input  float64
+	output float64
+}
+
+// modelPrices is matched by prefix in order, so more specific names come first
+var modelPrices = []modelPrice{
+	{prefix: "claude-opus-4", input: 15, output: 75},
+	{prefix: "claude-3-opus", input: 15, output: 75},
+	{prefix: "claude-sonnet-4", input: 3, output: 15},
+	{prefix: "claude-3-7-sonnet", input: 3, output: 15},
+	{prefix: "claude-3-5-sonnet", input: 3, output: 15},
+	{prefix: "claude-3-5-haiku", input: 0.8, output: 4},
+	{prefix: "claude-haiku-4", input: 1, output: 5},
+	{prefix: "claude-3-haiku", input: 0.25, output: 1.25},
+	{prefix: "gpt-4o-mini", input: 0.15, output: 0.6},
+	{prefix: "gpt-4o", input: 2.5, output: 10},
+	{prefix: "gpt-4.1-nano", input: 0.1, output: 0.4},
+	{prefix: "gpt-4.1-mini", input: 0.4, output: 1.6},
+	{prefix: "gpt-4.1", input: 2, output: 8},
+}
+
+// defaultModelPrice is used for unknown models so spend is never under-counted as free
+var defaultModelPrice = modelPrice{input: 3, output: 15}
+
+// EstimateCost returns the approximate USD cost of usage on the given model
+func EstimateCost(model string, usage UsageStats) float64 {
+	price := defaultModelPrice
+	for _, p := range modelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			price = p
+			break
+		}
+	}
+	return (float64(usage.InputTokens)*price.input + float64(usage.OutputTokens)*price.output) / 1_000_000
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateCost(t *testing.T) {
+	usage := UsageStats{InputTokens: 1_000_000, OutputTokens: 1_000_000}
+	assert.InDelta(t, 18.0, EstimateCost("claude-sonnet-4-20250514", usage), 1e-9)
+	assert.InDelta(t, 0.75, EstimateCost("gpt-4o-mini-2024-07-18", usage), 1e-9)
+	assert.InDelta(t, 12.5, EstimateCost("gpt-4o", usage), 1e-9)
+	// Unknown models are priced conservatively rather than as free
+	assert.InDelta(t, 18.0, EstimateCost("some-new-model", usage), 1e-9)
+}
+
+func TestAgentReportsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"done"}}],"usage":{"prompt_tokens":40,"completion_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	a := NewAgent(AgentConfig{Name: "test", Provider: ProviderOpenAI, APIKey: "key", Model: "gpt-4o"})
+	a.apiClient.(*OpenAIClient).apiURL = server.URL
+
+	var records []UsageRecord
+	ctx := WithUsageRecorder(context.Background(), func(rec UsageRecord) {
+		records = append(records, rec)
+	})
+	_, err := a.Execute(ctx, AgentInput{Messages: []Message{{Role: "user", Content: []ContentBlock{TextBlock{Type: "text", Text: "hi"}}}}})
+	require.NoError(t, err)
+
+	require.Len(t, records, 1)
+	assert.Equal(t, "test", records[0].Agent)
+	assert.Equal(t, "gpt-4o", records[0].Model)
+	assert.Equal(t, UsageStats{InputTokens: 40, OutputTokens: 2, TotalTokens: 42}, records[0].Usage)
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APIKeyPrefix marks bearer tokens that are API keys rather than session tokens
+const APIKeyPrefix = "alfred_"
+
+// apiKeyDisplayLength is how much of a key is kept in plaintext for identification
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// apiKeyLastUsedResolution limits last_used_at writes to one per key per interval
+const apiKeyLastUsedResolution = time.Minute
+
+// APIKeyScope controls what an API key may do
+type APIKeyScope string
+
+const (
+	// APIKeyScopeRead allows only safe (GET/HEAD/OPTIONS) requests
+	APIKeyScopeRead APIKeyScope = "read"
+	// APIKeyScopeReadWrite allows every request a session could make, except managing API keys
+	APIKeyScopeReadWrite APIKeyScope = "read_write"
+)
+
+// IsValid reports whether the scope is a known API key scope
+func (s APIKeyScope) IsValid() bool {
+	return s == APIKeyScopeRead || s == APIKeyScopeReadWrite
+}
+
+// APIKey is the stored metadata of an API key; the key itself is only returned on creation
+type APIKey struct {
+	ID         int64       `json:"id"`
+	UserID     int64       `json:"user_id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Scope      APIKeyScope `json:"scope"`
+	CreatedAt  time.Time   `json:"created_at"`
+	LastUsedAt *time.Time  `json:"last_used_at,omitempty"`
+}
+
+// IsAPIKey reports whether a bearer token looks like an API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// CreateAPIKey generates a new API key for a user. The returned key string is
+// shown once; only its hash is stored.
+func (s *Service) CreateAPIKey(userID int64, name string, scope APIKeyScope) (string, *APIKey, error) {
+	if !scope.IsValid() {
+		return "", nil, fmt.Errorf("invalid api key scope %q", scope)
+	}
+
+	keyBytes := make([]byte, 24)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key := APIKeyPrefix + hex.EncodeToString(keyBytes)
+	prefix := key[:apiKeyDisplayLength]
+
+	result, err := s.db.Exec(`
+		INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scope)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, name, prefix, hashAPIKey(key), scope)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get api key id: %w", err)
+	}
+
+	apiKey, err := s.getAPIKey(userID, id)
+	if err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+func (s *Service) getAPIKey(userID, id int64) (*APIKey, error) {
+	var apiKey APIKey
+	var lastUsedAt sql.NullTime
+	err := s.db.QueryRow(`
+		SELECT id, user_id, name, key_prefix, scope, created_at, last_used_at
+		FROM api_keys
+		WHERE id = ? AND user_id = ?
+	`, id, userID).Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.Scope, &apiKey.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if lastUsedAt.Valid {
+		apiKey.LastUsedAt = &lastUsedAt.Time
+	}
+	return &apiKey, nil
+}
+
+// ListAPIKeys returns a user's API keys, newest first
+func (s *Service) ListAPIKeys(userID int64) ([]APIKey, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, name, key_prefix, scope, created_at, last_used_at
+		FROM api_keys
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var apiKey APIKey
+		var lastUsedAt sql.NullTime
+		if err := rows.Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.Scope, &apiKey.CreatedAt, &lastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		if lastUsedAt.Valid {
+			apiKey.LastUsedAt = &lastUsedAt.Time
+		}
+		keys = append(keys, apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey deletes a user's API key. Returns false if no such key exists for the user.
+func (s *Service) RevokeAPIKey(userID, id int64) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM api_keys WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check revoked api key: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// ValidateAPIKey returns the user and scope for an API key and records its use
+func (s *Service) ValidateAPIKey(key string) (*User, APIKeyScope, error) {
+	keyHash := hashAPIKey(key)
+
+	var user User
+	var keyID int64
+	var scope APIKeyScope
+	var lastUsedAt sql.NullTime
+	err := s.db.QueryRow(`
+		SELECT u.id, u.google_id, u.email, COALESCE(u.name, ''), COALESCE(u.avatar_url, ''), COALESCE(u.timezone, 'UTC'),
+			k.id, k.scope, k.last_used_at
+		FROM api_keys k
+		JOIN users u ON k.user_id = u.id
+		WHERE k.key_hash = ?
+	`, keyHash).Scan(&user.ID, &user.GoogleID, &user.Email, &user.Name, &user.AvatarURL, &user.Timezone,
+		&keyID, &scope, &lastUsedAt)
+	if err == sql.ErrNoRows {
+		return nil, "", fmt.Errorf("invalid api key")
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if !lastUsedAt.Valid || now.Sub(lastUsedAt.Time) >= apiKeyLastUsedResolution {
+		s.db.Exec(`UPDATE api_keys SET last_used_at = ? WHERE id = ?`, now, keyID)
+	}
+
+	return &user, scope, nil
+}
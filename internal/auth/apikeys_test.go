@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func newTestAuthService(t *testing.T) (*Service, *database.DB) {
+	t.Helper()
+	os.Setenv("ALFRED_ENCRYPTION_KEY", "test-encryption-key-for-service-tests")
+	t.Cleanup(func() { os.Unsetenv("ALFRED_ENCRYPTION_KEY") })
+
+	db := database.NewTestDB(t)
+	service, err := NewService(db.DB, &oauth2.Config{ClientID: "test-client-id"})
+	require.NoError(t, err)
+	return service, db
+}
+
+func TestAPIKeys(t *testing.T) {
+	service, db := newTestAuthService(t)
+	user := database.CreateTestUser(t, db)
+	other := database.CreateTestUser(t, db)
+
+	key, apiKey, err := service.CreateAPIKey(user.ID, "home assistant", APIKeyScopeRead)
+	require.NoError(t, err)
+
+	t.Run("create returns the key once and stores only a prefix", func(t *testing.T) {
+		assert.True(t, IsAPIKey(key))
+		assert.True(t, strings.HasPrefix(key, apiKey.Prefix))
+		assert.Less(t, len(apiKey.Prefix), len(key))
+		assert.Equal(t, APIKeyScopeRead, apiKey.Scope)
+		assert.Nil(t, apiKey.LastUsedAt)
+
+		var stored string
+		require.NoError(t, db.QueryRow(`SELECT key_hash FROM api_keys WHERE id = ?`, apiKey.ID).Scan(&stored))
+		assert.NotEqual(t, key, stored)
+	})
+
+	t.Run("create rejects unknown scopes", func(t *testing.T) {
+		_, _, err := service.CreateAPIKey(user.ID, "admin", APIKeyScope("admin"))
+		assert.Error(t, err)
+	})
+
+	t.Run("validate resolves the user and records use", func(t *testing.T) {
+		resolved, scope, err := service.ValidateAPIKey(key)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, resolved.ID)
+		assert.Equal(t, APIKeyScopeRead, scope)
+
+		keys, err := service.ListAPIKeys(user.ID)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.NotNil(t, keys[0].LastUsedAt)
+
+		_, _, err = service.ValidateAPIKey(APIKeyPrefix + "unknown")
+		assert.Error(t, err)
+	})
+
+	t.Run("revoke is scoped to the owner", func(t *testing.T) {
+		revoked, err := service.RevokeAPIKey(other.ID, apiKey.ID)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		revoked, err = service.RevokeAPIKey(user.ID, apiKey.ID)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		_, _, err = service.ValidateAPIKey(key)
+		assert.Error(t, err)
+	})
+}
+
+func TestRequireAuthWithAPIKeys(t *testing.T) {
+	service, db := newTestAuthService(t)
+	user := database.CreateTestUser(t, db)
+
+	readKey, _, err := service.CreateAPIKey(user.ID, "reader", APIKeyScopeRead)
+	require.NoError(t, err)
+	writeKey, _, err := service.CreateAPIKey(user.ID, "writer", APIKeyScopeReadWrite)
+	require.NoError(t, err)
+
+	handler := NewMiddleware(service).RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := GetUserFromContext(r.Context())
+		require.NotNil(t, u)
+		assert.Equal(t, user.ID, u.ID)
+		w.Header().Set("X-Scope", string(GetAPIKeyScope(r.Context())))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(method, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/events", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("read key can read", func(t *testing.T) {
+		rec := do(http.MethodGet, readKey)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "read", rec.Header().Get("X-Scope"))
+	})
+
+	t.Run("read key cannot write", func(t *testing.T) {
+		rec := do(http.MethodPost, readKey)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("read_write key can write", func(t *testing.T) {
+		rec := do(http.MethodPost, writeKey)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "read_write", rec.Header().Get("X-Scope"))
+	})
+
+	t.Run("unknown key is rejected", func(t *testing.T) {
+		rec := do(http.MethodGet, APIKeyPrefix+"deadbeef")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
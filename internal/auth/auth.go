@@ -7,7 +7,12 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -18,7 +23,7 @@ import (
 )
 
 const (
-	// SessionDuration is how long session tokens are valid
+	// SessionDuration is how long a session's refresh token is valid; each refresh extends it
 	SessionDuration = 30 * 24 * time.Hour // 30 days
 )
 
@@ -46,20 +51,28 @@ type Service struct {
 	db        *sql.DB
 	config    *oauth2.Config
 	encryptor *Encryptor
+	jwtKey    []byte
 }
 
-// NewService creates a new authentication service
+// NewService creates a new authentication service, with the encryption key from the
+// environment
 func NewService(db *sql.DB, oauthConfig *oauth2.Config) (*Service, error) {
 	encryptor, err := NewEncryptor(nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create encryptor: %w", err)
 	}
+	return NewServiceWithEncryptor(db, oauthConfig, encryptor), nil
+}
 
+// NewServiceWithEncryptor creates a new authentication service that encrypts tokens
+// with encryptor and derives the access token signing key from it
+func NewServiceWithEncryptor(db *sql.DB, oauthConfig *oauth2.Config, encryptor *Encryptor) *Service {
 	return &Service{
 		db:        db,
 		config:    oauthConfig,
 		encryptor: encryptor,
-	}, nil
+		jwtKey:    jwtSigningKey(encryptor),
+	}
 }
 
 // GetAuthURL returns the Google OAuth authorization URL (with all scopes - for backward compatibility)
@@ -164,10 +177,10 @@ func mergeScopes(existing, newScopes []string) []string {
 }
 
 // ExchangeCodeAndAddScopes exchanges an OAuth code and merges new scopes with existing token
-// This is used for incremental authorization
-func (s *Service) ExchangeCodeAndAddScopes(ctx context.Context, userID int64, code string, newScopes []string) error {
+// This is used for incremental authorization; opts are the flow's ExchangeOptions
+func (s *Service) ExchangeCodeAndAddScopes(ctx context.Context, userID int64, code string, newScopes []string, opts ...oauth2.AuthCodeOption) error {
 	// Exchange code for new token
-	token, err := s.config.Exchange(ctx, code)
+	token, err := s.config.Exchange(ctx, code, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to exchange code: %w", err)
 	}
@@ -195,9 +208,10 @@ func (s *Service) GetOAuthConfig() *oauth2.Config {
 }
 
 // ExchangeCodeAndLogin exchanges an OAuth code for tokens and creates/updates the user
-// Returns the user and a session token
+// Returns the user and the new session's tokens
 // If redirectURI is provided, it will be used for the token exchange (must match the one used to generate the auth URL)
-func (s *Service) ExchangeCodeAndLogin(ctx context.Context, code string, deviceInfo string, redirectURI string) (*User, string, error) {
+// opts are the flow's ExchangeOptions
+func (s *Service) ExchangeCodeAndLogin(ctx context.Context, code string, client SessionClient, redirectURI string, opts ...oauth2.AuthCodeOption) (*User, *TokenPair, error) {
 	// Exchange code for token
 	// If a custom redirect URI was used for the auth URL, we need to use the same one for the exchange
 	var token *oauth2.Token
@@ -211,44 +225,44 @@ func (s *Service) ExchangeCodeAndLogin(ctx context.Context, code string, deviceI
 			RedirectURL:  redirectURI,
 			Scopes:       s.config.Scopes,
 		}
-		token, err = tempConfig.Exchange(ctx, code)
+		token, err = tempConfig.Exchange(ctx, code, opts...)
 	} else {
-		token, err = s.config.Exchange(ctx, code)
+		token, err = s.config.Exchange(ctx, code, opts...)
 	}
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to exchange code: %w", err)
+		return nil, nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
 	// Get user info from Google
 	googleUser, err := s.getGoogleUserInfo(ctx, token)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get user info: %w", err)
+		return nil, nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
 	// Create or update user in database
 	user, err := s.upsertUser(googleUser)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to upsert user: %w", err)
+		return nil, nil, fmt.Errorf("failed to upsert user: %w", err)
 	}
 
 	// Store Google tokens
 	if err := s.storeGoogleToken(user.ID, token); err != nil {
-		return nil, "", fmt.Errorf("failed to store token: %w", err)
+		return nil, nil, fmt.Errorf("failed to store token: %w", err)
 	}
 
 	// Create session
-	sessionToken, err := s.createSession(user.ID, deviceInfo)
+	tokens, err := s.CreateSession(user.ID, client)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create session: %w", err)
+		return nil, nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
 	// Initialize user settings if new
 	if err := s.initializeUserSettings(user.ID); err != nil {
 		// Log but don't fail - settings can be created later
-		fmt.Printf("Warning: failed to initialize user settings: %v\n", err)
+		slog.Warn("failed to initialize user settings", "error", err)
 	}
 
-	return user, sessionToken, nil
+	return user, tokens, nil
 }
 
 // getGoogleUserInfo fetches user profile from Google
@@ -358,6 +372,7 @@ func (s *Service) storeGoogleTokenWithScopes(userID int64, token *oauth2.Token,
 				token_type = excluded.token_type,
 				expiry = excluded.expiry,
 				scopes = excluded.scopes,
+				reauth_required_at = NULL,
 				updated_at = CURRENT_TIMESTAMP
 		`, userID, accessEncrypted, refreshEncrypted, token.TokenType, token.Expiry, string(scopesJSON))
 	} else {
@@ -368,6 +383,7 @@ func (s *Service) storeGoogleTokenWithScopes(userID int64, token *oauth2.Token,
 				token_type = ?,
 				expiry = ?,
 				scopes = ?,
+				reauth_required_at = NULL,
 				updated_at = CURRENT_TIMESTAMP
 			WHERE user_id = ?
 		`, accessEncrypted, token.TokenType, token.Expiry, string(scopesJSON), userID)
@@ -408,71 +424,218 @@ func (s *Service) GetGoogleToken(userID int64) (*oauth2.Token, error) {
 	}, nil
 }
 
-// createSession creates a new session for a user
-func (s *Service) createSession(userID int64, deviceInfo string) (string, error) {
-	// Generate random session token
+// googleRevokeURL is Google's OAuth token revocation endpoint
+var googleRevokeURL = "https://oauth2.googleapis.com/revoke"
+
+// RevokeGoogleToken revokes Alfred's Google grant for a token. Revoking the refresh
+// token also invalidates every access token issued from it.
+func RevokeGoogleToken(ctx context.Context, token *oauth2.Token) error {
+	value := token.RefreshToken
+	if value == "" {
+		value = token.AccessToken
+	}
+	if value == "" {
+		return nil
+	}
+
+	form := url.Values{"token": {value}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleRevokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create revoke request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to revoke google token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 400 means the token was already revoked or expired, which is the outcome we want
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("google token revocation returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// TokenPair is what a client receives on login and refresh. The access token is a
+// short-lived JWT sent as the bearer token; the refresh token is opaque, stored
+// hashed in user_sessions, and rotated on every refresh.
+type TokenPair struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired, or revoked
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// ErrRefreshTokenReused is returned when an already-rotated refresh token is presented.
+// The session it belonged to is revoked, since the token has likely leaked.
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+
+// generateRefreshToken returns a random refresh token and its storage hash
+func generateRefreshToken() (string, string, error) {
 	tokenBytes := make([]byte, 32)
 	if _, err := rand.Read(tokenBytes); err != nil {
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
 	}
 	token := base64.URLEncoding.EncodeToString(tokenBytes)
+	return token, hashToken(token), nil
+}
 
-	// Hash the token for storage
+// hashToken hashes a refresh token for storage
+func hashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
-	tokenHash := base64.StdEncoding.EncodeToString(hash[:])
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
 
-	expiresAt := time.Now().Add(SessionDuration)
+// newTokenPair issues an access token for a session and pairs it with its refresh token
+func (s *Service) newTokenPair(userID, sessionID int64, refreshToken string, now time.Time) (*TokenPair, error) {
+	accessToken, expiresAt, err := s.issueAccessToken(userID, sessionID, now)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(AccessTokenDuration.Seconds()),
+		ExpiresAt:    expiresAt,
+	}, nil
+}
 
-	_, err := s.db.Exec(`
-		INSERT INTO user_sessions (user_id, token_hash, expires_at, device_info)
-		VALUES (?, ?, ?, ?)
-	`, userID, tokenHash, expiresAt, deviceInfo)
+// CreateSession creates a new session for a user and returns its tokens
+func (s *Service) CreateSession(userID int64, client SessionClient) (*TokenPair, error) {
+	refreshToken, tokenHash, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := s.db.Exec(`
+		INSERT INTO user_sessions (user_id, token_hash, expires_at, device_info, ip_address, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, userID, tokenHash, now.Add(SessionDuration), client.DeviceInfo, client.IPAddress, now)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := result.LastInsertId()
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to get session id: %w", err)
 	}
 
-	return token, nil
+	return s.newTokenPair(userID, sessionID, refreshToken, now)
 }
 
-// ValidateSession validates a session token and returns the user
-func (s *Service) ValidateSession(token string) (*User, error) {
-	// Hash the token
-	hash := sha256.Sum256([]byte(token))
-	tokenHash := base64.StdEncoding.EncodeToString(hash[:])
+// ValidateAccessToken validates an access token and returns the user.
+// The session must still exist, so logging out revokes its access tokens immediately.
+func (s *Service) ValidateAccessToken(token string) (*User, error) {
+	now := time.Now()
+	claims, err := s.parseAccessToken(token, now, false)
+	if err != nil {
+		return nil, err
+	}
 
-	// Find session and user
 	var user User
-	var expiresAt time.Time
-
-	err := s.db.QueryRow(`
-		SELECT u.id, u.google_id, u.email, u.name, u.avatar_url, COALESCE(u.timezone, 'UTC'), s.expires_at
+	var lastSeen sql.NullTime
+	err = s.db.QueryRow(`
+		SELECT u.id, u.google_id, u.email, COALESCE(u.name, ''), COALESCE(u.avatar_url, ''), COALESCE(u.timezone, 'UTC'),
+			s.last_seen_at
 		FROM user_sessions s
 		JOIN users u ON s.user_id = u.id
-		WHERE s.token_hash = ?
-	`, tokenHash).Scan(&user.ID, &user.GoogleID, &user.Email, &user.Name, &user.AvatarURL, &user.Timezone, &expiresAt)
+		WHERE s.id = ? AND s.user_id = ? AND s.expires_at > ?
+	`, claims.SessionID, claims.Subject, now).Scan(&user.ID, &user.GoogleID, &user.Email, &user.Name, &user.AvatarURL,
+		&user.Timezone, &lastSeen)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("invalid session")
+		return nil, fmt.Errorf("session revoked or expired")
 	} else if err != nil {
 		return nil, err
 	}
-
-	// Check expiration
-	if time.Now().After(expiresAt) {
-		// Delete expired session
-		s.db.Exec(`DELETE FROM user_sessions WHERE token_hash = ?`, tokenHash)
-		return nil, fmt.Errorf("session expired")
-	}
+	user.SessionID = claims.SessionID
+	s.touchSession(claims.SessionID, lastSeen, now)
 
 	return &user, nil
 }
 
-// Logout invalidates a session token
-func (s *Service) Logout(token string) error {
-	hash := sha256.Sum256([]byte(token))
-	tokenHash := base64.StdEncoding.EncodeToString(hash[:])
+// RefreshSession exchanges a refresh token for a new token pair. The refresh token is
+// rotated and the session's expiry extended; presenting a rotated-out token revokes
+// the session. ipAddress is recorded as where the session was last used from.
+func (s *Service) RefreshSession(refreshToken, ipAddress string) (*TokenPair, error) {
+	tokenHash := hashToken(refreshToken)
+	now := time.Now()
 
-	_, err := s.db.Exec(`DELETE FROM user_sessions WHERE token_hash = ?`, tokenHash)
-	return err
+	var sessionID, userID int64
+	var expiresAt time.Time
+	err := s.db.QueryRow(`
+		SELECT id, user_id, expires_at FROM user_sessions WHERE token_hash = ?
+	`, tokenHash).Scan(&sessionID, &userID, &expiresAt)
+	if err == sql.ErrNoRows {
+		result, err := s.db.Exec(`DELETE FROM user_sessions WHERE previous_token_hash = ?`, tokenHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if revoked, _ := result.RowsAffected(); revoked > 0 {
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, ErrInvalidRefreshToken
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if now.After(expiresAt) {
+		s.db.Exec(`DELETE FROM user_sessions WHERE id = ?`, sessionID)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newToken, newHash, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Match on the old hash so two concurrent refreshes can't both rotate the token
+	result, err := s.db.Exec(`
+		UPDATE user_sessions
+		SET token_hash = ?, previous_token_hash = ?, refreshed_at = ?, expires_at = ?, last_seen_at = ?,
+			ip_address = COALESCE(NULLIF(?, ''), ip_address)
+		WHERE id = ? AND token_hash = ?
+	`, newHash, tokenHash, now, now.Add(SessionDuration), now, ipAddress, sessionID, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if rotated, _ := result.RowsAffected(); rotated == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.newTokenPair(userID, sessionID, newToken, now)
+}
+
+// Logout revokes the session identified by an access token (expired or not) or a
+// refresh token. Returns the session's user ID, or 0 if no session was found.
+func (s *Service) Logout(token string) (int64, error) {
+	var userID int64
+	var err error
+	if isAccessToken(token) {
+		claims, parseErr := s.parseAccessToken(token, time.Now(), true)
+		if parseErr != nil {
+			return 0, nil
+		}
+		err = s.db.QueryRow(`DELETE FROM user_sessions WHERE id = ? AND user_id = ? RETURNING user_id`,
+			claims.SessionID, claims.Subject).Scan(&userID)
+	} else {
+		err = s.db.QueryRow(`DELETE FROM user_sessions WHERE token_hash = ? RETURNING user_id`,
+			hashToken(token)).Scan(&userID)
+	}
+	if err == sql.ErrNoRows {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return userID, nil
 }
 
 // GetUserByID retrieves a user by their ID
@@ -2,7 +2,10 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -125,6 +128,32 @@ func TestEncryptorWithDerivedKey(t *testing.T) {
 	assert.Equal(t, plaintext, decrypted)
 }
 
+func TestDeriveEncryptionKey(t *testing.T) {
+	t.Setenv("ALFRED_ENCRYPTION_KEY", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	// Same key as NewEncryptor reading the environment, without touching it
+	for _, keys := range [][2]string{
+		{"test-encryption-key-for-tests", ""},
+		{base64.StdEncoding.EncodeToString(make([]byte, 32)), ""},
+		{"", "sk-test-key-12345"},
+	} {
+		key, err := DeriveEncryptionKey(keys[0], keys[1])
+		require.NoError(t, err)
+		explicit, err := NewEncryptor(key)
+		require.NoError(t, err)
+
+		t.Setenv("ALFRED_ENCRYPTION_KEY", keys[0])
+		t.Setenv("ANTHROPIC_API_KEY", keys[1])
+		fromEnv, err := NewEncryptor(nil)
+		require.NoError(t, err)
+		assert.Equal(t, fromEnv.key, explicit.key)
+	}
+
+	_, err := DeriveEncryptionKey("", "")
+	assert.ErrorContains(t, err, "no encryption key available")
+}
+
 func TestEncryptorNoKeyAvailable(t *testing.T) {
 	// Save and clear environment variables
 	savedEncKey := os.Getenv("ALFRED_ENCRYPTION_KEY")
@@ -770,3 +799,35 @@ func TestScopeMergingBehavior(t *testing.T) {
 		assert.Equal(t, "new-access-token-with-more-scopes", retrievedToken.AccessToken)
 	})
 }
+
+func TestRevokeGoogleToken(t *testing.T) {
+	var revoked []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		revoked = append(revoked, r.PostForm.Get("token"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	original := googleRevokeURL
+	googleRevokeURL = server.URL
+	defer func() { googleRevokeURL = original }()
+
+	ctx := context.Background()
+
+	t.Run("prefers the refresh token", func(t *testing.T) {
+		require.NoError(t, RevokeGoogleToken(ctx, &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}))
+		assert.Equal(t, []string{"refresh"}, revoked)
+	})
+
+	t.Run("already revoked tokens are not an error", func(t *testing.T) {
+		status = http.StatusBadRequest
+		assert.NoError(t, RevokeGoogleToken(ctx, &oauth2.Token{AccessToken: "access"}))
+	})
+
+	t.Run("server errors are reported", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		assert.Error(t, RevokeGoogleToken(ctx, &oauth2.Token{AccessToken: "access"}))
+	})
+}
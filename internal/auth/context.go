@@ -11,6 +11,8 @@ type contextKey string
 const (
 	// UserContextKey is the key used to store user info in request context
 	UserContextKey contextKey = "user"
+	// APIKeyScopeContextKey is set when the request was authenticated with an API key
+	APIKeyScopeContextKey contextKey = "api_key_scope"
 )
 
 // User represents the authenticated user in request context
@@ -21,6 +23,8 @@ type User struct {
 	Name      string `json:"name"`
 	AvatarURL string `json:"avatar_url,omitempty"`
 	Timezone  string `json:"timezone,omitempty"`
+
+	SessionID int64 `json:"-"` // 0 when authenticated with an API key
 }
 
 // GetUserFromContext extracts the authenticated user from the request context
@@ -41,7 +45,28 @@ func GetUserID(ctx context.Context) (int64, error) {
 	return user.ID, nil
 }
 
+// GetSessionID returns the ID of the session the request's access token belongs to, or 0
+// when the request was authenticated with an API key
+func GetSessionID(ctx context.Context) int64 {
+	if user := GetUserFromContext(ctx); user != nil {
+		return user.SessionID
+	}
+	return 0
+}
+
 // SetUserInContext returns a new context with the user set
 func SetUserInContext(ctx context.Context, user *User) context.Context {
 	return context.WithValue(ctx, UserContextKey, user)
 }
+
+// GetAPIKeyScope returns the scope of the API key that authenticated the request,
+// or "" when the request used a session token
+func GetAPIKeyScope(ctx context.Context) APIKeyScope {
+	scope, _ := ctx.Value(APIKeyScopeContextKey).(APIKeyScope)
+	return scope
+}
+
+// SetAPIKeyScopeInContext returns a new context marking the request as API key authenticated
+func SetAPIKeyScopeInContext(ctx context.Context, scope APIKeyScope) context.Context {
+	return context.WithValue(ctx, APIKeyScopeContextKey, scope)
+}
@@ -16,27 +16,16 @@ type Encryptor struct {
 	key []byte
 }
 
-// NewEncryptor creates a new encryptor using the provided key or ALFRED_ENCRYPTION_KEY env var
-// If no key is provided, generates a deterministic key from ANTHROPIC_API_KEY (for simplicity)
+// NewEncryptor creates a new encryptor using the provided key. Without one, the key is
+// derived from the ALFRED_ENCRYPTION_KEY or ANTHROPIC_API_KEY env var (for tools and tests;
+// the server passes its configured key, see DeriveEncryptionKey).
 func NewEncryptor(key []byte) (*Encryptor, error) {
 	if len(key) == 0 {
-		// Try to get from environment
-		if envKey := os.Getenv("ALFRED_ENCRYPTION_KEY"); envKey != "" {
-			decoded, err := base64.StdEncoding.DecodeString(envKey)
-			if err == nil && len(decoded) == 32 {
-				key = decoded
-			} else {
-				// Use as raw key material, hash to get 32 bytes
-				hash := sha256.Sum256([]byte(envKey))
-				key = hash[:]
-			}
-		} else if apiKey := os.Getenv("ANTHROPIC_API_KEY"); apiKey != "" {
-			// Derive a key from the API key (not ideal but works for development)
-			hash := sha256.Sum256([]byte("alfred-encryption-" + apiKey))
-			key = hash[:]
-		} else {
-			return nil, fmt.Errorf("no encryption key available: set ALFRED_ENCRYPTION_KEY or ANTHROPIC_API_KEY")
+		derived, err := DeriveEncryptionKey(os.Getenv("ALFRED_ENCRYPTION_KEY"), os.Getenv("ANTHROPIC_API_KEY"))
+		if err != nil {
+			return nil, err
 		}
+		key = derived
 	}
 
 	if len(key) != 32 {
@@ -48,6 +37,27 @@ func NewEncryptor(key []byte) (*Encryptor, error) {
 	return &Encryptor{key: key}, nil
 }
 
+// DeriveEncryptionKey returns the AES-256 key for an encryption key setting (base64 of
+// 32 bytes, or any string, which is hashed). If it is empty, a key is generated
+// deterministically from the Anthropic API key (for simplicity).
+func DeriveEncryptionKey(encryptionKey, anthropicAPIKey string) ([]byte, error) {
+	if encryptionKey != "" {
+		decoded, err := base64.StdEncoding.DecodeString(encryptionKey)
+		if err == nil && len(decoded) == 32 {
+			return decoded, nil
+		}
+		// Use as raw key material, hash to get 32 bytes
+		hash := sha256.Sum256([]byte(encryptionKey))
+		return hash[:], nil
+	}
+	if anthropicAPIKey != "" {
+		// Derive a key from the API key (not ideal but works for development)
+		hash := sha256.Sum256([]byte("alfred-encryption-" + anthropicAPIKey))
+		return hash[:], nil
+	}
+	return nil, fmt.Errorf("no encryption key available: set ALFRED_ENCRYPTION_KEY or ANTHROPIC_API_KEY")
+}
+
 // Encrypt encrypts plaintext using AES-256-GCM
 func (e *Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
 	block, err := aes.NewCipher(e.key)
@@ -126,3 +136,10 @@ func GenerateKey() ([]byte, error) {
 	}
 	return key, nil
 }
+
+// DeriveKey returns a 32-byte key for purpose derived from the encryption key, so other
+// signing keys need no extra setup
+func (e *Encryptor) DeriveKey(purpose string) []byte {
+	hash := sha256.Sum256(append([]byte("alfred-"+purpose+"-"), e.key...))
+	return hash[:]
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/gmail/v1"
+)
+
+const (
+	// GoogleTokenRefreshWindow is how long before expiry a Google access token is refreshed
+	GoogleTokenRefreshWindow = 15 * time.Minute
+
+	defaultGoogleRefreshInterval = 5 * time.Minute
+)
+
+// ErrGoogleReauthRequired is returned when Google no longer accepts a user's refresh
+// token, e.g. because access was revoked; the user has to connect Google again
+var ErrGoogleReauthRequired = errors.New("google authorization revoked or expired, reconnect required")
+
+// ListGoogleTokensDueForRefresh returns the users whose Google access token expires
+// before the given time and grants Calendar or Gmail access. Tokens already marked as
+// needing reauthorization are skipped.
+func (s *Service) ListGoogleTokensDueForRefresh(before time.Time) ([]int64, error) {
+	rows, err := s.db.Query(`
+		SELECT user_id, scopes FROM google_tokens
+		WHERE reauth_required_at IS NULL AND (expiry IS NULL OR expiry < ?)
+		ORDER BY expiry
+	`, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list google tokens due for refresh: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		var scopesJSON sql.NullString
+		if err := rows.Scan(&userID, &scopesJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan google token: %w", err)
+		}
+		var scopes []string
+		_ = json.Unmarshal([]byte(scopesJSON.String), &scopes)
+		if grantsServiceScope(scopes) {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating google tokens: %w", err)
+	}
+	return userIDs, nil
+}
+
+// grantsServiceScope reports whether scopes include Calendar or Gmail access. Tokens
+// with only profile scopes are used once at login and never need refreshing.
+func grantsServiceScope(scopes []string) bool {
+	for _, scope := range scopes {
+		if scope == calendar.CalendarScope || scope == gmail.GmailReadonlyScope {
+			return true
+		}
+	}
+	return false
+}
+
+// RefreshGoogleToken exchanges the user's refresh token for a new access token and
+// stores it. If Google rejects the refresh token, or there is none, the token is marked
+// as needing reauthorization and ErrGoogleReauthRequired is returned.
+func (s *Service) RefreshGoogleToken(ctx context.Context, userID int64) error {
+	token, err := s.GetGoogleToken(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load google token: %w", err)
+	}
+	if token.RefreshToken == "" {
+		return s.markGoogleReauthRequired(userID)
+	}
+
+	// An expired copy makes the token source refresh right away
+	fresh, err := s.config.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			return s.markGoogleReauthRequired(userID)
+		}
+		return fmt.Errorf("failed to refresh google token: %w", err)
+	}
+	if fresh.RefreshToken == "" {
+		fresh.RefreshToken = token.RefreshToken
+	}
+
+	accessEncrypted, err := s.encryptor.Encrypt([]byte(fresh.AccessToken))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt access token: %w", err)
+	}
+	refreshEncrypted, err := s.encryptor.Encrypt([]byte(fresh.RefreshToken))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt refresh token: %w", err)
+	}
+	_, err = s.db.Exec(`
+		UPDATE google_tokens SET
+			access_token_encrypted = ?,
+			refresh_token_encrypted = ?,
+			expiry = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ?
+	`, accessEncrypted, refreshEncrypted, fresh.Expiry, userID)
+	if err != nil {
+		return fmt.Errorf("failed to store refreshed google token: %w", err)
+	}
+	return nil
+}
+
+// markGoogleReauthRequired flags the user's Google token as unusable and returns
+// ErrGoogleReauthRequired
+func (s *Service) markGoogleReauthRequired(userID int64) error {
+	_, err := s.db.Exec(`
+		UPDATE google_tokens SET reauth_required_at = ?
+		WHERE user_id = ? AND reauth_required_at IS NULL
+	`, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark google token for reauthorization: %w", err)
+	}
+	return ErrGoogleReauthRequired
+}
+
+// GoogleTokenRefresher refreshes Google access tokens before they expire, so revoked
+// grants are found and reported by the job instead of by a failing sync
+type GoogleTokenRefresher struct {
+	service          *Service
+	onReauthRequired func(ctx context.Context, userID int64)
+	now              func() time.Time
+}
+
+// NewGoogleTokenRefresher returns a GoogleTokenRefresher. onReauthRequired is called once
+// for each user whose Google access has to be reconnected; it may be nil.
+func NewGoogleTokenRefresher(service *Service, onReauthRequired func(ctx context.Context, userID int64)) *GoogleTokenRefresher {
+	return &GoogleTokenRefresher{service: service, onReauthRequired: onReauthRequired, now: time.Now}
+}
+
+// Start refreshes immediately and then every pollInterval until ctx is cancelled
+func (r *GoogleTokenRefresher) Start(ctx context.Context, pollInterval time.Duration) {
+	if r == nil || r.service == nil {
+		return
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultGoogleRefreshInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		r.refresh(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.refresh(ctx)
+			}
+		}
+	}()
+}
+
+func (r *GoogleTokenRefresher) refresh(ctx context.Context) {
+	refreshed, reauth, err := r.RefreshOnce(ctx)
+	if err != nil {
+		slog.Error("Auth: Google token refresh failed", "error", err)
+	}
+	if refreshed > 0 || reauth > 0 {
+		slog.Info("Auth: Refreshed Google tokens", "refreshed", refreshed, "reauth_required", reauth)
+	}
+}
+
+// RefreshOnce refreshes every token expiring within GoogleTokenRefreshWindow and returns
+// how many were refreshed and how many need reauthorization. One user's failure doesn't
+// stop the others; the last error is returned.
+func (r *GoogleTokenRefresher) RefreshOnce(ctx context.Context) (refreshed, reauth int, err error) {
+	userIDs, err := r.service.ListGoogleTokensDueForRefresh(r.now().Add(GoogleTokenRefreshWindow))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var lastErr error
+	for _, userID := range userIDs {
+		err := r.service.RefreshGoogleToken(ctx, userID)
+		switch {
+		case err == nil:
+			refreshed++
+		case errors.Is(err, ErrGoogleReauthRequired):
+			reauth++
+			slog.Warn("Auth: Google access needs reconnecting", "user_id", userID)
+			if r.onReauthRequired != nil {
+				r.onReauthRequired(ctx, userID)
+			}
+		default:
+			lastErr = fmt.Errorf("user %d: %w", userID, err)
+		}
+	}
+	return refreshed, reauth, lastErr
+}
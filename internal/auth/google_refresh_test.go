@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestGoogleTokenRefresher(t *testing.T) {
+	service, db := newTestAuthService(t)
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("refresh_token") == "revoked-refresh-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"fresh-access-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+	service.config.Endpoint = oauth2.Endpoint{TokenURL: tokenServer.URL, AuthStyle: oauth2.AuthStyleInParams}
+
+	storeToken := func(userID int64, refreshToken string, expiry time.Time, scopes []string) {
+		require.NoError(t, service.storeGoogleTokenWithScopes(userID, &oauth2.Token{
+			AccessToken: "old-access-token", RefreshToken: refreshToken, TokenType: "Bearer", Expiry: expiry,
+		}, scopes))
+	}
+	expiring := database.CreateTestUser(t, db)
+	storeToken(expiring.ID, "good-refresh-token", time.Now().Add(5*time.Minute), CalendarScopes)
+	revoked := database.CreateTestUser(t, db)
+	storeToken(revoked.ID, "revoked-refresh-token", time.Now().Add(-time.Minute), GmailScopes)
+	fresh := database.CreateTestUser(t, db)
+	storeToken(fresh.ID, "good-refresh-token", time.Now().Add(time.Hour), CalendarScopes)
+	profileOnly := database.CreateTestUser(t, db)
+	storeToken(profileOnly.ID, "good-refresh-token", time.Now().Add(-time.Minute), ProfileScopes)
+
+	var reauthUsers []int64
+	refresher := NewGoogleTokenRefresher(service, func(ctx context.Context, userID int64) {
+		reauthUsers = append(reauthUsers, userID)
+	})
+
+	refreshed, reauth, err := refresher.RefreshOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, refreshed)
+	assert.Equal(t, 1, reauth)
+	assert.Equal(t, []int64{revoked.ID}, reauthUsers)
+
+	t.Run("refreshed token is stored and keeps its refresh token", func(t *testing.T) {
+		token, err := service.GetGoogleToken(expiring.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "fresh-access-token", token.AccessToken)
+		assert.Equal(t, "good-refresh-token", token.RefreshToken)
+		assert.True(t, token.Expiry.After(time.Now().Add(30*time.Minute)))
+
+		info, err := db.GetGoogleTokenInfo(expiring.ID)
+		require.NoError(t, err)
+		assert.Nil(t, info.ReauthRequiredAt)
+	})
+
+	t.Run("revoked token is flagged and reported once", func(t *testing.T) {
+		info, err := db.GetGoogleTokenInfo(revoked.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, info.ReauthRequiredAt)
+
+		_, reauth, err := refresher.RefreshOnce(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, reauth)
+		assert.Len(t, reauthUsers, 1)
+	})
+
+	t.Run("reconnecting clears the flag", func(t *testing.T) {
+		storeToken(revoked.ID, "new-refresh-token", time.Now().Add(time.Hour), GmailScopes)
+		info, err := db.GetGoogleTokenInfo(revoked.ID)
+		require.NoError(t, err)
+		assert.Nil(t, info.ReauthRequiredAt)
+	})
+
+	t.Run("tokens outside the window or without service scopes are left alone", func(t *testing.T) {
+		for _, userID := range []int64{fresh.ID, profileOnly.ID} {
+			token, err := service.GetGoogleToken(userID)
+			require.NoError(t, err)
+			assert.Equal(t, "old-access-token", token.AccessToken)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// AccessTokenDuration is how long access tokens are valid before they must be refreshed
+const AccessTokenDuration = 15 * time.Minute
+
+// jwtHeader is the fixed header of every access token (HS256)
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+var (
+	errMalformedToken   = errors.New("malformed token")
+	errInvalidSignature = errors.New("invalid token signature")
+	errTokenExpired     = errors.New("token expired")
+)
+
+// accessClaims are the claims carried by an access token
+type accessClaims struct {
+	Subject   int64 `json:"sub"`
+	SessionID int64 `json:"sid"`
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// jwtSigningKey returns the HMAC key for access tokens: ALFRED_JWT_SECRET if set,
+// otherwise a key derived from the encryption key so no extra setup is required.
+func jwtSigningKey(encryptor *Encryptor) []byte {
+	if secret := os.Getenv("ALFRED_JWT_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	hash := sha256.Sum256(append([]byte("alfred-jwt-"), encryptor.key...))
+	return hash[:]
+}
+
+func (s *Service) signJWT(signingInput string) string {
+	mac := hmac.New(sha256.New, s.jwtKey)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issueAccessToken creates a signed access token for a session
+func (s *Service) issueAccessToken(userID, sessionID int64, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(AccessTokenDuration)
+	payload, err := json.Marshal(accessClaims{
+		Subject:   userID,
+		SessionID: sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to encode token claims: %w", err)
+	}
+
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + s.signJWT(signingInput), expiresAt, nil
+}
+
+// parseAccessToken verifies an access token's signature and returns its claims.
+// Expiry is checked against now unless allowExpired is set (used by logout).
+func (s *Service) parseAccessToken(token string, now time.Time, allowExpired bool) (*accessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, errMalformedToken
+	}
+
+	expected := s.signJWT(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return nil, errInvalidSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	var claims accessClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errMalformedToken
+	}
+
+	if !allowExpired && now.Unix() >= claims.ExpiresAt {
+		return nil, errTokenExpired
+	}
+	return &claims, nil
+}
+
+// isAccessToken reports whether a bearer token has the shape of a JWT
+func isAccessToken(token string) bool {
+	return strings.Count(token, ".") == 2
+}
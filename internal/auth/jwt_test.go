@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessTokens(t *testing.T) {
+	service, _ := newTestAuthService(t)
+	now := time.Now()
+
+	token, expiresAt, err := service.issueAccessToken(42, 7, now)
+	require.NoError(t, err)
+	assert.True(t, isAccessToken(token))
+	assert.Equal(t, now.Add(AccessTokenDuration).Unix(), expiresAt.Unix())
+
+	t.Run("round trips claims", func(t *testing.T) {
+		claims, err := service.parseAccessToken(token, now, false)
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), claims.Subject)
+		assert.Equal(t, int64(7), claims.SessionID)
+	})
+
+	t.Run("rejects expired tokens unless allowed", func(t *testing.T) {
+		later := now.Add(AccessTokenDuration + time.Second)
+		_, err := service.parseAccessToken(token, later, false)
+		assert.ErrorIs(t, err, errTokenExpired)
+
+		claims, err := service.parseAccessToken(token, later, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), claims.SessionID)
+	})
+
+	t.Run("rejects tampered tokens", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		forged, _, err := service.issueAccessToken(1, 7, now)
+		require.NoError(t, err)
+		forgedParts := strings.Split(forged, ".")
+
+		_, err = service.parseAccessToken(parts[0]+"."+forgedParts[1]+"."+parts[2], now, false)
+		assert.ErrorIs(t, err, errInvalidSignature)
+
+		_, err = service.parseAccessToken("not-a-jwt", now, false)
+		assert.ErrorIs(t, err, errMalformedToken)
+	})
+
+	t.Run("rejects tokens signed with another key", func(t *testing.T) {
+		other := &Service{jwtKey: []byte("another-key")}
+		_, err := other.parseAccessToken(token, now, false)
+		assert.ErrorIs(t, err, errInvalidSignature)
+	})
+}
+
+func TestSessionRefreshFlow(t *testing.T) {
+	service, db := newTestAuthService(t)
+	user := database.CreateTestUser(t, db)
+
+	tokens, err := service.CreateSession(user.ID, SessionClient{DeviceInfo: "test-device"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, int64(AccessTokenDuration.Seconds()), tokens.ExpiresIn)
+
+	t.Run("access token authenticates the user", func(t *testing.T) {
+		validated, err := service.ValidateAccessToken(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, validated.ID)
+	})
+
+	t.Run("refresh token is not an access token", func(t *testing.T) {
+		_, err := service.ValidateAccessToken(tokens.RefreshToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("refresh rotates the refresh token", func(t *testing.T) {
+		refreshed, err := service.RefreshSession(tokens.RefreshToken, "")
+		require.NoError(t, err)
+		assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+		validated, err := service.ValidateAccessToken(refreshed.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, validated.ID)
+
+		var storedHash string
+		require.NoError(t, db.QueryRow(`SELECT token_hash FROM user_sessions WHERE user_id = ?`, user.ID).Scan(&storedHash))
+		assert.NotEqual(t, refreshed.RefreshToken, storedHash, "refresh tokens are stored hashed")
+		tokens = refreshed
+	})
+
+	t.Run("logout revokes outstanding access tokens", func(t *testing.T) {
+		userID, err := service.Logout(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+
+		_, err = service.ValidateAccessToken(tokens.AccessToken)
+		assert.Error(t, err)
+		_, err = service.RefreshSession(tokens.RefreshToken, "")
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+		userID, err = service.Logout(tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Zero(t, userID)
+	})
+
+	t.Run("logout accepts the refresh token", func(t *testing.T) {
+		session, err := service.CreateSession(user.ID, SessionClient{})
+		require.NoError(t, err)
+
+		userID, err := service.Logout(session.RefreshToken)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+		_, err = service.ValidateAccessToken(session.AccessToken)
+		assert.Error(t, err)
+	})
+}
+
+func TestSessionRefreshTokenReuse(t *testing.T) {
+	service, db := newTestAuthService(t)
+	user := database.CreateTestUser(t, db)
+
+	original, err := service.CreateSession(user.ID, SessionClient{})
+	require.NoError(t, err)
+	rotated, err := service.RefreshSession(original.RefreshToken, "")
+	require.NoError(t, err)
+
+	_, err = service.RefreshSession(original.RefreshToken, "")
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// The whole session is revoked, including the legitimately rotated tokens
+	_, err = service.RefreshSession(rotated.RefreshToken, "")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = service.ValidateAccessToken(rotated.AccessToken)
+	assert.Error(t, err)
+}
+
+func TestSessionRefreshExpired(t *testing.T) {
+	service, db := newTestAuthService(t)
+	user := database.CreateTestUser(t, db)
+
+	tokens, err := service.CreateSession(user.ID, SessionClient{})
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE user_sessions SET expires_at = ? WHERE user_id = ?`, time.Now().Add(-time.Hour), user.ID)
+	require.NoError(t, err)
+
+	_, err = service.RefreshSession(tokens.RefreshToken, "")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = service.ValidateAccessToken(tokens.AccessToken)
+	assert.Error(t, err)
+}
@@ -1,37 +1,74 @@
 package auth
 
 import (
+	"encoding/json"
 	"net/http"
 	"strings"
 )
 
+// ErrorResponder writes an authentication failure. code is a stable identifier such as
+// "invalid_token".
+type ErrorResponder func(w http.ResponseWriter, status int, code, message string)
+
 // Middleware provides HTTP middleware for authentication
 type Middleware struct {
 	service *Service
+	respond ErrorResponder
 }
 
 // NewMiddleware creates a new authentication middleware
 func NewMiddleware(service *Service) *Middleware {
 	return &Middleware{
 		service: service,
+		respond: respondError,
 	}
 }
 
-// RequireAuth is middleware that requires a valid session token
-// The user is extracted from the token and added to the request context
+// SetErrorResponder replaces how authentication failures are written, so they match
+// the server's other errors
+func (m *Middleware) SetErrorResponder(respond ErrorResponder) {
+	m.respond = respond
+}
+
+// respondError writes {"error": message, "code": code}
+func respondError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message, "code": code})
+}
+
+// RequireAuth is middleware that requires a valid access token or API key
+// The user is extracted from the token and added to the request context.
+// Read-only API keys are rejected on anything but safe methods.
 func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Extract token from Authorization header
 		token := extractBearerToken(r)
 		if token == "" {
-			http.Error(w, `{"error": "missing authorization token"}`, http.StatusUnauthorized)
+			m.respond(w, http.StatusUnauthorized, "authentication_required", "missing authorization token")
+			return
+		}
+
+		if IsAPIKey(token) {
+			user, scope, err := m.service.ValidateAPIKey(token)
+			if err != nil {
+				m.respond(w, http.StatusUnauthorized, "invalid_api_key", "invalid api key")
+				return
+			}
+			if scope == APIKeyScopeRead && !isSafeMethod(r.Method) {
+				m.respond(w, http.StatusForbidden, "api_key_read_only", "api key is read-only")
+				return
+			}
+
+			ctx := SetAPIKeyScopeInContext(SetUserInContext(r.Context(), user), scope)
+			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
 		// Validate token and get user
-		user, err := m.service.ValidateSession(token)
+		user, err := m.service.ValidateAccessToken(token)
 		if err != nil {
-			http.Error(w, `{"error": "invalid or expired token"}`, http.StatusUnauthorized)
+			m.respond(w, http.StatusUnauthorized, "invalid_token", "invalid or expired token")
 			return
 		}
 
@@ -46,8 +83,14 @@ func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 func (m *Middleware) OptionalAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := extractBearerToken(r)
-		if token != "" {
-			user, err := m.service.ValidateSession(token)
+		if token != "" && IsAPIKey(token) {
+			user, scope, err := m.service.ValidateAPIKey(token)
+			if err == nil && (scope != APIKeyScopeRead || isSafeMethod(r.Method)) {
+				ctx := SetAPIKeyScopeInContext(SetUserInContext(r.Context(), user), scope)
+				r = r.WithContext(ctx)
+			}
+		} else if token != "" {
+			user, err := m.service.ValidateAccessToken(token)
 			if err == nil {
 				ctx := SetUserInContext(r.Context(), user)
 				r = r.WithContext(ctx)
@@ -57,6 +100,11 @@ func (m *Middleware) OptionalAuth(next http.Handler) http.Handler {
 	})
 }
 
+// isSafeMethod reports whether a request method is read-only
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
 // extractBearerToken extracts the token from the Authorization header
 // Expects format: "Bearer <token>"
 func extractBearerToken(r *http.Request) string {
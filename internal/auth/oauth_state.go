@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// OAuthStateTTL is how long an authorization started with StartOAuthFlow can be completed
+const OAuthStateTTL = 10 * time.Minute
+
+// OAuthPurpose is the kind of Google authorization a state was issued for
+type OAuthPurpose string
+
+const (
+	// OAuthPurposeLogin signs a user in with profile scopes
+	OAuthPurposeLogin OAuthPurpose = "login"
+	// OAuthPurposeAddScopes grants a signed-in user additional scopes
+	OAuthPurposeAddScopes OAuthPurpose = "add_scopes"
+)
+
+// ErrInvalidOAuthState is returned when a callback's state is unknown, already used,
+// expired, or was issued for another flow or user
+var ErrInvalidOAuthState = errors.New("invalid or expired oauth state")
+
+// OAuthFlow is a pending Google authorization. UserID is 0 for logins. ScopeNames are the
+// names the client asked for (e.g. "gmail"), so the callback grants exactly those.
+type OAuthFlow struct {
+	Purpose     OAuthPurpose
+	UserID      int64
+	RedirectURI string
+	ScopeNames  []string
+
+	codeVerifier string
+}
+
+// ExchangeOptions returns the options the authorization code must be exchanged with: the
+// flow's PKCE verifier and the redirect URI its auth URL was issued for
+func (f *OAuthFlow) ExchangeOptions() []oauth2.AuthCodeOption {
+	return []oauth2.AuthCodeOption{
+		oauth2.VerifierOption(f.codeVerifier),
+		oauth2.SetAuthURLParam("redirect_uri", f.RedirectURI),
+	}
+}
+
+// StartOAuthFlow issues a one-time state for flow and returns the Google authorization
+// URL for scopes. The URL carries a PKCE challenge whose verifier stays on the server,
+// so a code can only be redeemed through ConsumeOAuthFlow with the same state.
+func (s *Service) StartOAuthFlow(flow OAuthFlow, scopes []string, opts ...oauth2.AuthCodeOption) (string, error) {
+	if flow.RedirectURI == "" {
+		flow.RedirectURI = s.config.RedirectURL
+	}
+	scopeNames := flow.ScopeNames
+	if scopeNames == nil {
+		scopeNames = []string{}
+	}
+	scopeNamesJSON, err := json.Marshal(scopeNames)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode scopes: %w", err)
+	}
+
+	stateBytes := make([]byte, 32)
+	if _, err := rand.Read(stateBytes); err != nil {
+		return "", fmt.Errorf("failed to generate oauth state: %w", err)
+	}
+	state := base64.RawURLEncoding.EncodeToString(stateBytes)
+	verifier := oauth2.GenerateVerifier()
+	encryptedVerifier, err := s.encryptor.EncryptString(verifier)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt code verifier: %w", err)
+	}
+
+	now := time.Now()
+	if _, err := s.db.Exec(`DELETE FROM oauth_states WHERE expires_at < ?`, now); err != nil {
+		return "", fmt.Errorf("failed to clean up oauth states: %w", err)
+	}
+	var userID sql.NullInt64
+	if flow.UserID != 0 {
+		userID = sql.NullInt64{Int64: flow.UserID, Valid: true}
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO oauth_states (state_hash, purpose, user_id, redirect_uri, scopes, code_verifier, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, hashToken(state), flow.Purpose, userID, flow.RedirectURI, string(scopeNamesJSON), encryptedVerifier,
+		now.Add(OAuthStateTTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to store oauth state: %w", err)
+	}
+
+	config := &oauth2.Config{
+		ClientID:     s.config.ClientID,
+		ClientSecret: s.config.ClientSecret,
+		Endpoint:     s.config.Endpoint,
+		RedirectURL:  flow.RedirectURI,
+		Scopes:       scopes,
+	}
+	opts = append([]oauth2.AuthCodeOption{
+		oauth2.AccessTypeOffline,
+		oauth2.ApprovalForce,
+		oauth2.S256ChallengeOption(verifier),
+	}, opts...)
+	return config.AuthCodeURL(state, opts...), nil
+}
+
+// ConsumeOAuthFlow redeems a state issued by StartOAuthFlow. The state is deleted whether
+// or not it matches, so each one can be tried once. userID is the signed-in user for
+// OAuthPurposeAddScopes and 0 for logins.
+func (s *Service) ConsumeOAuthFlow(state string, purpose OAuthPurpose, userID int64) (*OAuthFlow, error) {
+	if state == "" {
+		return nil, ErrInvalidOAuthState
+	}
+
+	var flow OAuthFlow
+	var storedUserID sql.NullInt64
+	var scopeNamesJSON, encryptedVerifier string
+	var expiresAt time.Time
+	err := s.db.QueryRow(`
+		DELETE FROM oauth_states WHERE state_hash = ?
+		RETURNING purpose, user_id, redirect_uri, scopes, code_verifier, expires_at
+	`, hashToken(state)).Scan(&flow.Purpose, &storedUserID, &flow.RedirectURI, &scopeNamesJSON,
+		&encryptedVerifier, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidOAuthState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume oauth state: %w", err)
+	}
+
+	flow.UserID = storedUserID.Int64
+	if time.Now().After(expiresAt) || flow.Purpose != purpose || flow.UserID != userID {
+		return nil, ErrInvalidOAuthState
+	}
+	if err := json.Unmarshal([]byte(scopeNamesJSON), &flow.ScopeNames); err != nil {
+		return nil, fmt.Errorf("failed to decode oauth state scopes: %w", err)
+	}
+	flow.codeVerifier, err = s.encryptor.DecryptString(encryptedVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt code verifier: %w", err)
+	}
+	return &flow, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// startTestFlow starts a flow and returns its state and the auth URL's query
+func startTestFlow(t *testing.T, service *Service, flow OAuthFlow, scopes []string) (string, url.Values) {
+	t.Helper()
+	authURL, err := service.StartOAuthFlow(flow, scopes)
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	require.NotEmpty(t, query.Get("state"))
+	return query.Get("state"), query
+}
+
+func TestOAuthFlow(t *testing.T) {
+	service, db := newTestAuthService(t)
+	service.config.RedirectURL = "https://alfred.example/api/auth/callback"
+	user := database.CreateTestUser(t, db)
+	other := database.CreateTestUser(t, db)
+
+	t.Run("auth url carries a PKCE challenge for the stored verifier", func(t *testing.T) {
+		state, query := startTestFlow(t, service, OAuthFlow{Purpose: OAuthPurposeLogin}, ProfileScopes)
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+		assert.Equal(t, service.config.RedirectURL, query.Get("redirect_uri"))
+
+		flow, err := service.ConsumeOAuthFlow(state, OAuthPurposeLogin, 0)
+		require.NoError(t, err)
+		sum := sha256.Sum256([]byte(flow.codeVerifier))
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:]), query.Get("code_challenge"))
+		assert.Equal(t, service.config.RedirectURL, flow.RedirectURI)
+	})
+
+	t.Run("a state can be used once", func(t *testing.T) {
+		state, _ := startTestFlow(t, service, OAuthFlow{
+			Purpose: OAuthPurposeAddScopes, UserID: user.ID, ScopeNames: []string{"gmail"},
+		}, GmailScopes)
+
+		flow, err := service.ConsumeOAuthFlow(state, OAuthPurposeAddScopes, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gmail"}, flow.ScopeNames)
+
+		_, err = service.ConsumeOAuthFlow(state, OAuthPurposeAddScopes, user.ID)
+		assert.ErrorIs(t, err, ErrInvalidOAuthState)
+	})
+
+	t.Run("state is bound to its user and purpose", func(t *testing.T) {
+		state, _ := startTestFlow(t, service, OAuthFlow{Purpose: OAuthPurposeAddScopes, UserID: user.ID}, CalendarScopes)
+		_, err := service.ConsumeOAuthFlow(state, OAuthPurposeAddScopes, other.ID)
+		assert.ErrorIs(t, err, ErrInvalidOAuthState)
+
+		// A mismatched attempt burns the state
+		_, err = service.ConsumeOAuthFlow(state, OAuthPurposeAddScopes, user.ID)
+		assert.ErrorIs(t, err, ErrInvalidOAuthState)
+
+		state, _ = startTestFlow(t, service, OAuthFlow{Purpose: OAuthPurposeLogin}, ProfileScopes)
+		_, err = service.ConsumeOAuthFlow(state, OAuthPurposeAddScopes, 0)
+		assert.ErrorIs(t, err, ErrInvalidOAuthState)
+	})
+
+	t.Run("expired and unknown states are rejected", func(t *testing.T) {
+		state, _ := startTestFlow(t, service, OAuthFlow{Purpose: OAuthPurposeLogin}, ProfileScopes)
+		_, err := db.Exec(`UPDATE oauth_states SET expires_at = datetime('now', '-1 minute') WHERE state_hash = ?`,
+			hashToken(state))
+		require.NoError(t, err)
+		_, err = service.ConsumeOAuthFlow(state, OAuthPurposeLogin, 0)
+		assert.ErrorIs(t, err, ErrInvalidOAuthState)
+
+		_, err = service.ConsumeOAuthFlow("made-up", OAuthPurposeLogin, 0)
+		assert.ErrorIs(t, err, ErrInvalidOAuthState)
+		_, err = service.ConsumeOAuthFlow("", OAuthPurposeLogin, 0)
+		assert.ErrorIs(t, err, ErrInvalidOAuthState)
+	})
+
+	t.Run("code exchange sends the verifier and the flow's redirect uri", func(t *testing.T) {
+		var form url.Values
+		tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			form = r.PostForm
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token":"new-access-token","token_type":"Bearer","expires_in":3600}`))
+		}))
+		defer tokenServer.Close()
+		service.config.Endpoint = oauth2.Endpoint{TokenURL: tokenServer.URL, AuthStyle: oauth2.AuthStyleInParams}
+
+		state, _ := startTestFlow(t, service, OAuthFlow{
+			Purpose: OAuthPurposeAddScopes, UserID: user.ID, RedirectURI: "alfred://oauth/callback",
+		}, CalendarScopes)
+		flow, err := service.ConsumeOAuthFlow(state, OAuthPurposeAddScopes, user.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, service.ExchangeCodeAndAddScopes(context.Background(), user.ID, "code", CalendarScopes,
+			flow.ExchangeOptions()...))
+		assert.Equal(t, flow.codeVerifier, form.Get("code_verifier"))
+		assert.Equal(t, "alfred://oauth/callback", form.Get("redirect_uri"))
+	})
+}
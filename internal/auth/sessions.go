@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// sessionLastSeenInterval is how stale a session's last_seen_at may get before a request
+// with one of its access tokens updates it, so each request doesn't write
+const sessionLastSeenInterval = 5 * time.Minute
+
+// ErrSessionNotFound is returned when revoking a session the user doesn't have
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionClient describes the client a session is created for
+type SessionClient struct {
+	DeviceInfo string // X-Device-Info header, or the User-Agent
+	IPAddress  string
+}
+
+// Session is an active sign-in, shown to the user so a lost device's access can be revoked
+type Session struct {
+	ID         int64     `json:"id"`
+	DeviceInfo string    `json:"device_info"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // the session the request was made with
+}
+
+// ListSessions returns the user's unexpired sessions, most recently used first.
+// currentSessionID marks the caller's own session; 0 marks none.
+func (s *Service) ListSessions(userID, currentSessionID int64) ([]Session, error) {
+	rows, err := s.db.Query(`
+		SELECT id, COALESCE(device_info, ''), COALESCE(ip_address, ''), created_at, last_seen_at, refreshed_at,
+			expires_at
+		FROM user_sessions
+		WHERE user_id = ? AND expires_at > ?
+		ORDER BY id DESC
+	`, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		var lastSeen, refreshed sql.NullTime
+		if err := rows.Scan(&session.ID, &session.DeviceInfo, &session.IPAddress, &session.CreatedAt,
+			&lastSeen, &refreshed, &session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		// Sessions from before last_seen_at was recorded were last seen when refreshed
+		switch {
+		case lastSeen.Valid:
+			session.LastSeenAt = lastSeen.Time
+		case refreshed.Valid:
+			session.LastSeenAt = refreshed.Time
+		default:
+			session.LastSeenAt = session.CreatedAt
+		}
+		session.Current = session.ID == currentSessionID
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession deletes one of the user's sessions. Its refresh token stops working and
+// its access tokens are rejected on their next use.
+func (s *Service) RevokeSession(userID, sessionID int64) error {
+	result, err := s.db.Exec(`DELETE FROM user_sessions WHERE id = ? AND user_id = ?`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if revoked, _ := result.RowsAffected(); revoked == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// touchSession records that a session was used, at most once per sessionLastSeenInterval
+func (s *Service) touchSession(sessionID int64, lastSeen sql.NullTime, now time.Time) {
+	if lastSeen.Valid && now.Sub(lastSeen.Time) < sessionLastSeenInterval {
+		return
+	}
+	if _, err := s.db.Exec(`UPDATE user_sessions SET last_seen_at = ? WHERE id = ?`, now, sessionID); err != nil {
+		slog.Warn("Auth: failed to update session last seen", "session_id", sessionID, "error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	service, db := newTestAuthService(t)
+	user := database.CreateTestUser(t, db)
+	other := database.CreateTestUser(t, db)
+
+	phone, err := service.CreateSession(user.ID, SessionClient{DeviceInfo: "Pixel 8", IPAddress: "203.0.113.7"})
+	require.NoError(t, err)
+	laptop, err := service.CreateSession(user.ID, SessionClient{DeviceInfo: "Firefox", IPAddress: "198.51.100.2"})
+	require.NoError(t, err)
+	_, err = service.CreateSession(other.ID, SessionClient{DeviceInfo: "iPhone"})
+	require.NoError(t, err)
+
+	laptopUser, err := service.ValidateAccessToken(laptop.AccessToken)
+	require.NoError(t, err)
+	require.NotZero(t, laptopUser.SessionID)
+
+	t.Run("lists the user's sessions and marks the current one", func(t *testing.T) {
+		sessions, err := service.ListSessions(user.ID, laptopUser.SessionID)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+
+		byDevice := map[string]Session{}
+		for _, session := range sessions {
+			byDevice[session.DeviceInfo] = session
+		}
+		assert.Equal(t, "203.0.113.7", byDevice["Pixel 8"].IPAddress)
+		assert.False(t, byDevice["Pixel 8"].Current)
+		assert.True(t, byDevice["Firefox"].Current)
+		assert.False(t, byDevice["Firefox"].LastSeenAt.IsZero())
+		assert.False(t, byDevice["Firefox"].CreatedAt.IsZero())
+	})
+
+	t.Run("refresh records last seen and the new address", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE user_sessions SET last_seen_at = ? WHERE device_info = 'Pixel 8'`,
+			time.Now().Add(-48*time.Hour))
+		require.NoError(t, err)
+
+		_, err = service.RefreshSession(phone.RefreshToken, "192.0.2.44")
+		require.NoError(t, err)
+
+		sessions, err := service.ListSessions(user.ID, 0)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+		assert.Equal(t, "Pixel 8", sessions[0].DeviceInfo, "most recently used first")
+		assert.Equal(t, "192.0.2.44", sessions[0].IPAddress)
+		assert.WithinDuration(t, time.Now(), sessions[0].LastSeenAt, time.Minute)
+	})
+
+	t.Run("revoking a session ends its access and refresh tokens", func(t *testing.T) {
+		sessions, err := service.ListSessions(user.ID, 0)
+		require.NoError(t, err)
+		var phoneID int64
+		for _, session := range sessions {
+			if session.DeviceInfo == "Pixel 8" {
+				phoneID = session.ID
+			}
+		}
+
+		assert.ErrorIs(t, service.RevokeSession(other.ID, phoneID), ErrSessionNotFound)
+		require.NoError(t, service.RevokeSession(user.ID, phoneID))
+		assert.ErrorIs(t, service.RevokeSession(user.ID, phoneID), ErrSessionNotFound)
+
+		_, err = service.ValidateAccessToken(phone.AccessToken)
+		assert.Error(t, err)
+		_, err = service.RefreshSession(phone.RefreshToken, "")
+		assert.Error(t, err)
+
+		_, err = service.ValidateAccessToken(laptop.AccessToken)
+		assert.NoError(t, err)
+	})
+}
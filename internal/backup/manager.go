@@ -0,0 +1,229 @@
+// Package backup takes online snapshots of the Alfred SQLite database, keeps them in
+// a local directory or S3 bucket, and restores the live database from one.
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/database/migrations"
+)
+
+const (
+	namePrefix    = "alfred-"
+	nameSuffix    = ".db"
+	nameTimestamp = "20060102T150405Z"
+
+	// DefaultKeep is how many backups are retained when no limit is configured
+	DefaultKeep = 7
+)
+
+// ErrInvalidName is returned for backup names the Manager did not generate
+var ErrInvalidName = errors.New("invalid backup name")
+
+var nameRegexp = regexp.MustCompile(`^alfred-\d{8}T\d{6}Z\.db$`)
+
+// backupName returns the store name for a snapshot taken at t
+func backupName(t time.Time) string {
+	return namePrefix + t.UTC().Format(nameTimestamp) + nameSuffix
+}
+
+// parseName extracts the snapshot time from a backup name
+func parseName(name string) (time.Time, bool) {
+	if !nameRegexp.MatchString(name) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(nameTimestamp, name[len(namePrefix):len(name)-len(nameSuffix)])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Manager runs scheduled backups and restores. Backup and Restore are serialized.
+type Manager struct {
+	db    *database.DB
+	store Store
+	keep  int
+	now   func() time.Time
+	mu    sync.Mutex
+	last  time.Time // time of the last backup taken by this process
+}
+
+// NewManager returns a Manager that keeps the newest keep backups in store
+func NewManager(db *database.DB, store Store, keep int) *Manager {
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	return &Manager{db: db, store: store, keep: keep, now: time.Now}
+}
+
+// Store returns where backups are kept
+func (m *Manager) Store() Store {
+	return m.store
+}
+
+// Start takes a backup every interval until ctx is cancelled. A backup is taken right
+// away if the newest existing one is already older than interval, so frequent
+// restarts neither skip nor pile up backups.
+func (m *Manager) Start(ctx context.Context, interval time.Duration) {
+	if m == nil || m.db == nil || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		if m.isDue(ctx, interval) {
+			m.runScheduled(ctx)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.runScheduled(ctx)
+			}
+		}
+	}()
+}
+
+func (m *Manager) isDue(ctx context.Context, interval time.Duration) bool {
+	backups, err := m.store.List(ctx)
+	if err != nil {
+		slog.Error("Backups: Failed to list backups", "error", err)
+		return true
+	}
+	return len(backups) == 0 || m.now().Sub(backups[0].CreatedAt) >= interval
+}
+
+func (m *Manager) runScheduled(ctx context.Context) {
+	info, err := m.Backup(ctx)
+	if err != nil {
+		slog.Error("Backups: Scheduled backup failed", "error", err)
+		return
+	}
+	slog.Info("Backups: Stored backup", "name", info.Name, "size_bytes", info.SizeBytes, "store", m.store)
+}
+
+// Backup snapshots the live database into the store and prunes old backups
+func (m *Manager) Backup(ctx context.Context) (*Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.backupLocked(ctx)
+}
+
+func (m *Manager) backupLocked(ctx context.Context) (*Info, error) {
+	tmpDir, err := os.MkdirTemp("", "alfred-backup-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Names have one-second resolution; never reuse one (e.g. the pre-restore backup
+	// right after a manual one), as the store would overwrite it.
+	createdAt := m.now().UTC().Truncate(time.Second)
+	if !createdAt.After(m.last) {
+		createdAt = m.last.Add(time.Second)
+	}
+	name := backupName(createdAt)
+	path := filepath.Join(tmpDir, name)
+
+	if err := Snapshot(ctx, m.db.DB, path); err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+	if err := m.store.Put(ctx, name, path); err != nil {
+		return nil, err
+	}
+	m.last = createdAt
+
+	if err := m.prune(ctx); err != nil {
+		slog.Error("Backups: Failed to prune old backups", "error", err)
+	}
+
+	return &Info{Name: name, SizeBytes: fi.Size(), CreatedAt: createdAt}, nil
+}
+
+// prune deletes all but the newest m.keep backups
+func (m *Manager) prune(ctx context.Context) error {
+	backups, err := m.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for i := m.keep; i < len(backups); i++ {
+		if err := m.store.Delete(ctx, backups[i].Name); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the stored backups, newest first
+func (m *Manager) List(ctx context.Context) ([]Info, error) {
+	return m.store.List(ctx)
+}
+
+// Restore replaces the live database with the named backup. The current database is
+// backed up first (and kept like any other backup) so a mistaken restore can be
+// undone. Migrations run afterwards in case the backup predates this build.
+// Returns the name of the pre-restore backup.
+func (m *Manager) Restore(ctx context.Context, name string) (string, error) {
+	if _, ok := parseName(name); !ok {
+		return "", ErrInvalidName
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tmpDir, err := os.MkdirTemp("", "alfred-restore-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, name)
+	if err := m.store.Fetch(ctx, name, path); err != nil {
+		return "", err
+	}
+	if err := checkSnapshot(ctx, path); err != nil {
+		return "", err
+	}
+
+	safety, err := m.backupLocked(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up current database before restore: %w", err)
+	}
+
+	snapshot, err := openSnapshot(path)
+	if err != nil {
+		return "", err
+	}
+	defer snapshot.Close()
+
+	if err := copyDatabase(ctx, m.db.DB, snapshot); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", name, err)
+	}
+	if err := migrations.RunMigrations(m.db.DB); err != nil {
+		return "", fmt.Errorf("failed to migrate restored database: %w", err)
+	}
+	if _, err := migrations.SetUpSearchIndex(m.db.DB); err != nil {
+		return "", fmt.Errorf("failed to set up search index: %w", err)
+	}
+
+	return safety.Name, nil
+}
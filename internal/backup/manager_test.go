@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+func newTestManager(t *testing.T, keep int) (*Manager, *database.DB) {
+	t.Helper()
+
+	db, err := database.New(filepath.Join(t.TempDir(), "alfred.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewDirStore(filepath.Join(t.TempDir(), "backups"))
+	require.NoError(t, err)
+
+	m := NewManager(db, store, keep)
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return m, db
+}
+
+func userCount(t *testing.T, db *database.DB) int {
+	t.Helper()
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count))
+	return count
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	m, db := newTestManager(t, 5)
+
+	database.CreateTestUserWithEmail(t, db, "first@example.com")
+	snapshot, err := m.Backup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "alfred-20260301T120100Z.db", snapshot.Name)
+	assert.Positive(t, snapshot.SizeBytes)
+
+	database.CreateTestUserWithEmail(t, db, "second@example.com")
+	require.Equal(t, 2, userCount(t, db))
+
+	preRestore, err := m.Restore(ctx, snapshot.Name)
+	require.NoError(t, err)
+	assert.Equal(t, 1, userCount(t, db))
+
+	// The pre-restore backup holds the state that was replaced
+	backups, err := m.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, preRestore, backups[0].Name)
+
+	_, err = m.Restore(ctx, preRestore)
+	require.NoError(t, err)
+	assert.Equal(t, 2, userCount(t, db))
+}
+
+func TestBackupPrunesOldBackups(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t, 2)
+
+	var names []string
+	for i := 0; i < 4; i++ {
+		info, err := m.Backup(ctx)
+		require.NoError(t, err)
+		names = append(names, info.Name)
+	}
+
+	backups, err := m.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, names[3], backups[0].Name)
+	assert.Equal(t, names[2], backups[1].Name)
+}
+
+func TestBackupNamesNeverCollide(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t, 5)
+	fixed := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return fixed }
+
+	first, err := m.Backup(ctx)
+	require.NoError(t, err)
+	second, err := m.Backup(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Name, second.Name)
+}
+
+func TestRestoreRejectsBadNames(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(t, 5)
+
+	_, err := m.Restore(ctx, "../alfred.db")
+	assert.ErrorIs(t, err, ErrInvalidName)
+
+	_, err = m.Restore(ctx, "alfred-20200101T000000Z.db")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
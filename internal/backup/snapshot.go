@@ -0,0 +1,98 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Snapshot writes a consistent copy of src to destPath using SQLite's online backup
+// API, so the live database keeps serving reads and writes while it runs.
+func Snapshot(ctx context.Context, src *sql.DB, destPath string) error {
+	dest, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot file: %w", err)
+	}
+	defer dest.Close()
+
+	return copyDatabase(ctx, dest, src)
+}
+
+// copyDatabase replaces the contents of dst with every page of src
+func copyDatabase(ctx context.Context, dst, src *sql.DB) error {
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get destination connection: %w", err)
+	}
+	defer dstConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get source connection: %w", err)
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dc any) error {
+		return srcConn.Raw(func(sc any) error {
+			d, ok := dc.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("destination is not a sqlite3 connection")
+			}
+			s, ok := sc.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("source is not a sqlite3 connection")
+			}
+
+			bk, err := d.Backup("main", s, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup: %w", err)
+			}
+			if _, err := bk.Step(-1); err != nil {
+				bk.Finish()
+				return fmt.Errorf("failed to copy pages: %w", err)
+			}
+			if err := bk.Finish(); err != nil {
+				return fmt.Errorf("failed to finish backup: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// openSnapshot opens a snapshot file read-only
+func openSnapshot(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	return db, nil
+}
+
+// checkSnapshot opens a downloaded snapshot and makes sure it is an intact Alfred
+// database before it is allowed to replace the live one.
+func checkSnapshot(ctx context.Context, path string) error {
+	db, err := openSnapshot(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("failed to check snapshot integrity: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("snapshot failed integrity check: %s", result)
+	}
+
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("snapshot is not an Alfred database: %w", err)
+	}
+	if version == 0 {
+		return fmt.Errorf("snapshot has no applied migrations")
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ErrNotFound is returned when a named backup does not exist in the store
+var ErrNotFound = errors.New("backup not found")
+
+// Info describes a stored backup
+type Info struct {
+	Name      string    `json:"name"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store is where snapshots are kept. Names are generated by the Manager and are
+// always plain file names.
+type Store interface {
+	// Put uploads the file at path under name
+	Put(ctx context.Context, name, path string) error
+	// Fetch downloads name into destPath
+	Fetch(ctx context.Context, name, destPath string) error
+	// List returns the stored backups, newest first
+	List(ctx context.Context) ([]Info, error)
+	Delete(ctx context.Context, name string) error
+	// String describes the store for logs
+	String() string
+}
+
+// DirStore keeps backups in a local directory
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates the directory if needed and returns a store backed by it
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+func (s *DirStore) String() string {
+	return s.dir
+}
+
+func (s *DirStore) Put(ctx context.Context, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer src.Close()
+
+	// Write under a temporary name so a partial copy is never listed as a backup
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to store backup file: %w", err)
+	}
+	return nil
+}
+
+func (s *DirStore) Fetch(ctx context.Context, name, destPath string) error {
+	src, err := os.Open(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create restore file: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to copy backup file: %w", err)
+	}
+	return dst.Close()
+}
+
+func (s *DirStore) List(ctx context.Context) ([]Info, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []Info
+	for _, entry := range entries {
+		createdAt, ok := parseName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Info{Name: entry.Name(), SizeBytes: fi.Size(), CreatedAt: createdAt})
+	}
+	sortNewestFirst(backups)
+	return backups, nil
+}
+
+func (s *DirStore) Delete(ctx context.Context, name string) error {
+	err := os.Remove(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func sortNewestFirst(backups []Info) {
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+}
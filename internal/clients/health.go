@@ -0,0 +1,302 @@
+package clients
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+const (
+	// DefaultIdleTTL is how long an unpaired client is kept without being used
+	DefaultIdleTTL = 30 * time.Minute
+	// DefaultHealthCheckInterval is how often the monitor checks client connections
+	DefaultHealthCheckInterval = 30 * time.Second
+
+	minReconnectBackoff = 30 * time.Second
+	maxReconnectBackoff = 30 * time.Minute
+)
+
+// Connection states reported by WhatsAppConnectionState and TelegramConnectionState
+const (
+	StateConnected        = "connected"
+	StateReconnecting     = "reconnecting"      // Paired but disconnected; the monitor reconnects it
+	StateNotAuthenticated = "not_authenticated" // Loaded but not paired or logged in
+	StateEvicted          = "evicted"           // Unloaded after being idle; loaded again on next use
+	StateNotLoaded        = "not_loaded"
+)
+
+// ConnectionState describes one user's WhatsApp or Telegram client
+type ConnectionState struct {
+	State             string     `json:"state"`
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`
+	ReconnectAttempts int        `json:"reconnect_attempts,omitempty"`
+	NextReconnectAt   *time.Time `json:"next_reconnect_at,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+}
+
+type healthKey struct {
+	source source.SourceType
+	userID int64
+}
+
+// clientHealth is what the monitor tracks about one client
+type clientHealth struct {
+	lastUsed      time.Time
+	everConnected bool // Seen authenticated, so a disconnect is worth reconnecting
+	disconnected  bool
+	attempts      int // Reconnect attempts since the client was last seen connected
+	nextAttempt   time.Time
+	lastError     string
+	evicted       bool
+}
+
+// reconnectBackoff doubles from minReconnectBackoff per attempt, up to maxReconnectBackoff
+func reconnectBackoff(attempts int) time.Duration {
+	backoff := minReconnectBackoff
+	for i := 1; i < attempts && backoff < maxReconnectBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxReconnectBackoff)
+}
+
+// touch records that a user's client was used, keeping it from idle eviction
+func (m *ClientManager) touch(src source.SourceType, userID int64) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	h := m.healthFor(src, userID)
+	h.lastUsed = m.now()
+	h.evicted = false
+}
+
+// healthFor returns the tracked health of a client, creating it. Callers hold healthMu.
+func (m *ClientManager) healthFor(src source.SourceType, userID int64) *clientHealth {
+	key := healthKey{src, userID}
+	h, ok := m.health[key]
+	if !ok {
+		h = &clientHealth{lastUsed: m.now()}
+		m.health[key] = h
+	}
+	return h
+}
+
+func (m *ClientManager) forgetHealth(src source.SourceType, userID int64) {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	delete(m.health, healthKey{src, userID})
+}
+
+// wasConnected reports whether the monitor has seen the client authenticated
+func (m *ClientManager) wasConnected(src source.SourceType, userID int64) bool {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	h, ok := m.health[healthKey{src, userID}]
+	return ok && h.everConnected
+}
+
+// countEvicted counts the users whose client of src was evicted and not loaded since
+func (m *ClientManager) countEvicted(src source.SourceType) int {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	count := 0
+	for key, h := range m.health {
+		if key.source == src && h.evicted {
+			count++
+		}
+	}
+	return count
+}
+
+// StartMonitor checks client connections every health check interval until Shutdown:
+// paired clients that dropped are reconnected with exponential backoff, and unpaired
+// clients unused for the idle TTL are unloaded (they are recreated on the next
+// Get*Client). Paired clients are never evicted, since they stream the user's messages.
+func (m *ClientManager) StartMonitor() {
+	interval := m.cfg.HealthCheckInterval
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	m.stopMonitor = cancel
+	m.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.checkClients()
+			}
+		}
+	}()
+}
+
+// monitoredClient is a client's status as seen by one monitor pass. A Telegram client
+// only reports itself logged in while connected.
+type monitoredClient struct {
+	src       source.SourceType
+	userID    int64
+	loggedIn  bool
+	connected bool
+	connect   func() error
+	destroy   func(userID int64) error
+}
+
+// checkClients runs one pass of the monitor
+func (m *ClientManager) checkClients() {
+	m.mu.RLock()
+	clients := make([]monitoredClient, 0, len(m.whatsappClients)+len(m.telegramClients))
+	for userID, client := range m.whatsappClients {
+		loggedIn := client.IsLoggedIn()
+		clients = append(clients, monitoredClient{
+			src: source.SourceTypeWhatsApp, userID: userID,
+			loggedIn: loggedIn, connected: loggedIn && client.IsConnected(),
+			connect: client.Connect, destroy: m.DestroyWhatsAppClient,
+		})
+	}
+	for userID, client := range m.telegramClients {
+		connected := client.IsConnected()
+		clients = append(clients, monitoredClient{
+			src: source.SourceTypeTelegram, userID: userID,
+			loggedIn: connected, connected: connected,
+			connect: client.Connect, destroy: m.DestroyTelegramClient,
+		})
+	}
+	m.mu.RUnlock()
+
+	for _, client := range clients {
+		if m.checkClient(client) {
+			m.evict(client)
+		}
+	}
+}
+
+// checkClient updates a client's health, reconnecting it when due, and reports whether
+// it should be evicted. A drop is recognized by the client having been connected before.
+func (m *ClientManager) checkClient(c monitoredClient) (evict bool) {
+	now := m.now()
+
+	m.healthMu.Lock()
+	h := m.healthFor(c.src, c.userID)
+	if c.connected {
+		h.everConnected = true
+		h.disconnected = false
+		h.attempts = 0
+		h.nextAttempt = time.Time{}
+		h.lastError = ""
+		m.healthMu.Unlock()
+		return false
+	}
+	if !c.loggedIn && !h.everConnected {
+		idleTTL := m.cfg.IdleTTL
+		if idleTTL == 0 {
+			idleTTL = DefaultIdleTTL
+		}
+		evict = idleTTL > 0 && now.Sub(h.lastUsed) >= idleTTL
+		m.healthMu.Unlock()
+		return evict
+	}
+
+	// Paired but disconnected; the first attempt waits a backoff step, giving the
+	// client's own reconnect logic a chance
+	if !h.disconnected {
+		h.disconnected = true
+		h.nextAttempt = now.Add(reconnectBackoff(1))
+	}
+	if now.Before(h.nextAttempt) {
+		m.healthMu.Unlock()
+		return false
+	}
+	h.attempts++
+	attempts := h.attempts
+	m.healthMu.Unlock()
+
+	slog.Info("ClientManager: Reconnecting client", "source", c.src, "user_id", c.userID, "attempt", attempts)
+	err := c.connect()
+
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	h.nextAttempt = m.now().Add(reconnectBackoff(attempts + 1))
+	if err != nil {
+		h.lastError = err.Error()
+		slog.Warn("ClientManager: Reconnect failed", "source", c.src, "user_id", c.userID,
+			"attempt", attempts, "next_attempt", h.nextAttempt, "error", err)
+	}
+	return false
+}
+
+// evict unloads an idle client, remembering it was evicted for status reports
+func (m *ClientManager) evict(c monitoredClient) {
+	slog.Info("ClientManager: Evicting idle client", "source", c.src, "user_id", c.userID)
+	if err := c.destroy(c.userID); err != nil {
+		slog.Warn("ClientManager: Failed to evict idle client", "source", c.src, "user_id", c.userID, "error", err)
+		return
+	}
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+	m.healthFor(c.src, c.userID).evicted = true
+}
+
+// WhatsAppConnectionState reports the state of a user's WhatsApp client without loading it
+func (m *ClientManager) WhatsAppConnectionState(userID int64) ConnectionState {
+	state := StateNotLoaded
+	if client, ok := m.PeekWhatsAppClient(userID); ok {
+		switch {
+		case client.IsLoggedIn() && client.IsConnected():
+			state = StateConnected
+		case client.IsLoggedIn():
+			state = StateReconnecting
+		default:
+			state = StateNotAuthenticated
+		}
+	}
+	return m.connectionState(source.SourceTypeWhatsApp, userID, state)
+}
+
+// TelegramConnectionState reports the state of a user's Telegram client without loading it
+func (m *ClientManager) TelegramConnectionState(userID int64) ConnectionState {
+	state := StateNotLoaded
+	if client, ok := m.PeekTelegramClient(userID); ok {
+		state = StateNotAuthenticated
+		if client.IsConnected() {
+			state = StateConnected
+		}
+	}
+	return m.connectionState(source.SourceTypeTelegram, userID, state)
+}
+
+// connectionState adds the monitor's bookkeeping to a client's state
+func (m *ClientManager) connectionState(src source.SourceType, userID int64, state string) ConnectionState {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	h, ok := m.health[healthKey{src, userID}]
+	if !ok {
+		return ConnectionState{State: state}
+	}
+	switch {
+	case state == StateNotLoaded && h.evicted:
+		state = StateEvicted
+	case state == StateNotAuthenticated && h.everConnected:
+		state = StateReconnecting
+	}
+
+	lastUsed := h.lastUsed
+	cs := ConnectionState{State: state, LastUsedAt: &lastUsed}
+	if state == StateReconnecting {
+		cs.ReconnectAttempts = h.attempts
+		cs.LastError = h.lastError
+		if !h.nextAttempt.IsZero() {
+			next := h.nextAttempt
+			cs.NextReconnectAt = &next
+		}
+	}
+	return cs
+}
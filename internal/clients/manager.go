@@ -3,11 +3,14 @@ package clients
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/notify"
+	"github.com/omriShneor/project_alfred/internal/sessionstore"
 	"github.com/omriShneor/project_alfred/internal/source"
 	"github.com/omriShneor/project_alfred/internal/sse"
 	"github.com/omriShneor/project_alfred/internal/telegram"
@@ -21,14 +24,22 @@ type ClientManager struct {
 	notifyService   *notify.Service
 	onboardingState *sse.State
 	backfillHook    whatsapp.HistorySyncBackfillHook
+	progressHook    whatsapp.HistorySyncProgressHook
+	selfChatHook    whatsapp.SelfChatCommandHook
 
 	// Shared message channel (all users' messages tagged with UserID)
 	msgChan chan source.Message
 
 	// Per-user client instances
 	mu              sync.RWMutex
-	whatsappClients map[int64]*whatsapp.Client
+	whatsappClients map[int64]whatsapp.Account
 	telegramClients map[int64]*telegram.Client
+
+	// Connection health and idle tracking for the monitor (see health.go)
+	healthMu    sync.Mutex
+	health      map[healthKey]*clientHealth
+	stopMonitor context.CancelFunc
+	now         func() time.Time
 }
 
 // ManagerConfig holds configuration for the ClientManager
@@ -41,8 +52,25 @@ type ManagerConfig struct {
 	TelegramAPIID   int
 	TelegramAPIHash string
 
+	// SessionCipher encrypts the WhatsApp and Telegram session stores; nil leaves them
+	// in plaintext
+	SessionCipher sessionstore.Cipher
+	// SessionFlushOnCommit writes an encrypted WhatsApp store to disk right after commits
+	// to its identity and session tables, instead of only every sessionstore.FlushInterval
+	SessionFlushOnCommit bool
+
 	// Feature flags
 	DebugAllMessages bool
+
+	// IdleTTL is how long an unpaired client is kept unused before StartMonitor's
+	// monitor unloads it (0 uses DefaultIdleTTL, negative never evicts)
+	IdleTTL time.Duration
+	// HealthCheckInterval is how often the monitor runs (0 uses DefaultHealthCheckInterval)
+	HealthCheckInterval time.Duration
+
+	// NewWhatsAppClient replaces the whatsmeow-backed client, e.g. with a mockwhatsapp
+	// fake in integration tests. The handler stores what the client receives.
+	NewWhatsAppClient func(userID int64, handler *whatsapp.Handler) (whatsapp.Account, error)
 }
 
 // NewClientManager creates a new client manager
@@ -53,8 +81,10 @@ func NewClientManager(db *database.DB, cfg *ManagerConfig, notifyService *notify
 		notifyService:   notifyService,
 		onboardingState: state,
 		msgChan:         make(chan source.Message, 1000), // Large buffer for multi-user
-		whatsappClients: make(map[int64]*whatsapp.Client),
+		whatsappClients: make(map[int64]whatsapp.Account),
 		telegramClients: make(map[int64]*telegram.Client),
+		health:          make(map[healthKey]*clientHealth),
+		now:             time.Now,
 	}
 }
 
@@ -77,9 +107,37 @@ func (m *ClientManager) SetWhatsAppHistorySyncBackfillHook(hook whatsapp.History
 	}
 }
 
+// SetWhatsAppHistorySyncProgressHook registers a callback that WhatsApp handlers
+// invoke as HistorySync checkpoints each conversation.
+func (m *ClientManager) SetWhatsAppHistorySyncProgressHook(hook whatsapp.HistorySyncProgressHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.progressHook = hook
+	for _, client := range m.whatsappClients {
+		if client != nil {
+			client.SetHistorySyncProgressHook(hook)
+		}
+	}
+}
+
+// SetWhatsAppSelfChatCommandHook registers a callback that runs the commands users send
+// to their own WhatsApp chat
+func (m *ClientManager) SetWhatsAppSelfChatCommandHook(hook whatsapp.SelfChatCommandHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.selfChatHook = hook
+	for _, client := range m.whatsappClients {
+		if client != nil {
+			client.SetSelfChatCommandHook(hook)
+		}
+	}
+}
+
 // PeekWhatsAppClient returns an in-memory WhatsApp client only if it already exists.
 // Unlike GetWhatsAppClient, this method never creates a new client.
-func (m *ClientManager) PeekWhatsAppClient(userID int64) (*whatsapp.Client, bool) {
+func (m *ClientManager) PeekWhatsAppClient(userID int64) (whatsapp.Account, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	client, ok := m.whatsappClients[userID]
@@ -95,10 +153,56 @@ func (m *ClientManager) PeekTelegramClient(userID int64) (*telegram.Client, bool
 	return client, ok
 }
 
+// ClientSummary counts the in-memory clients of one messaging source
+type ClientSummary struct {
+	Clients      int `json:"clients"`
+	Connected    int `json:"connected"`
+	Reconnecting int `json:"reconnecting"` // Paired but disconnected
+	Evicted      int `json:"evicted"`      // Unloaded by the monitor after being idle
+}
+
+// WhatsAppSummary counts WhatsApp clients and how many are paired and connected
+func (m *ClientManager) WhatsAppSummary() ClientSummary {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := ClientSummary{Clients: len(m.whatsappClients)}
+	for _, client := range m.whatsappClients {
+		switch {
+		case client.IsLoggedIn() && client.IsConnected():
+			summary.Connected++
+		case client.IsLoggedIn():
+			summary.Reconnecting++
+		}
+	}
+	summary.Evicted = m.countEvicted(source.SourceTypeWhatsApp)
+	return summary
+}
+
+// TelegramSummary counts Telegram clients and how many are connected and authenticated
+func (m *ClientManager) TelegramSummary() ClientSummary {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	summary := ClientSummary{Clients: len(m.telegramClients)}
+	for userID, client := range m.telegramClients {
+		if client.IsConnected() {
+			summary.Connected++
+		} else if m.wasConnected(source.SourceTypeTelegram, userID) {
+			summary.Reconnecting++
+		}
+	}
+	summary.Evicted = m.countEvicted(source.SourceTypeTelegram)
+	return summary
+}
+
 // ==================== WhatsApp Client Management ====================
 
 // GetWhatsAppClient returns an existing WhatsApp client for the user or creates a new one
-func (m *ClientManager) GetWhatsAppClient(userID int64) (*whatsapp.Client, error) {
+// (including after the monitor evicted it)
+func (m *ClientManager) GetWhatsAppClient(userID int64) (whatsapp.Account, error) {
+	m.touch(source.SourceTypeWhatsApp, userID)
+
 	m.mu.RLock()
 	client, exists := m.whatsappClients[userID]
 	m.mu.RUnlock()
@@ -111,7 +215,7 @@ func (m *ClientManager) GetWhatsAppClient(userID int64) (*whatsapp.Client, error
 }
 
 // CreateWhatsAppClient creates a new WhatsApp client for the user
-func (m *ClientManager) CreateWhatsAppClient(userID int64) (*whatsapp.Client, error) {
+func (m *ClientManager) CreateWhatsAppClient(userID int64) (whatsapp.Account, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -121,13 +225,13 @@ func (m *ClientManager) CreateWhatsAppClient(userID int64) (*whatsapp.Client, er
 	}
 
 	dbPath := m.getUserWhatsAppDBPath(userID)
-	fmt.Printf("ClientManager: Creating WhatsApp client for user %d with session path: %s\n", userID, dbPath)
+	slog.Info("ClientManager: Creating WhatsApp client with session path", "user_id", userID, "db_path", dbPath)
 
 	preferredDeviceJID := ""
 	if m.db != nil {
 		session, err := m.db.GetWhatsAppSession(userID)
 		if err != nil {
-			fmt.Printf("Warning: Failed to load WhatsApp session metadata for user %d: %v\n", userID, err)
+			slog.Warn("Failed to load WhatsApp session metadata", "user_id", userID, "error", err)
 		} else if session != nil && session.DeviceJID != "" {
 			preferredDeviceJID = session.DeviceJID
 		}
@@ -140,9 +244,17 @@ func (m *ClientManager) CreateWhatsAppClient(userID int64) (*whatsapp.Client, er
 	// This ensures all users' messages go to the same channel with UserID tags
 	handler.SetMessageChannel(m.msgChan)
 	handler.SetHistorySyncBackfillHook(m.backfillHook)
+	handler.SetHistorySyncProgressHook(m.progressHook)
+	handler.SetSelfChatCommandHook(m.selfChatHook)
 
 	// Create client with handler
-	client, err := whatsapp.NewClient(handler, dbPath, preferredDeviceJID, m.notifyService)
+	var client whatsapp.Account
+	var err error
+	if m.cfg.NewWhatsAppClient != nil {
+		client, err = m.cfg.NewWhatsAppClient(userID, handler)
+	} else {
+		client, err = whatsapp.NewClient(handler, dbPath, preferredDeviceJID, m.cfg.SessionCipher, m.cfg.SessionFlushOnCommit, m.notifyService)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create WhatsApp client for user %d: %w", userID, err)
 	}
@@ -150,22 +262,22 @@ func (m *ClientManager) CreateWhatsAppClient(userID int64) (*whatsapp.Client, er
 	// Set UserID on client
 	client.SetUserID(userID)
 
-	if m.db != nil && client.WAClient.Store.ID != nil {
-		_ = m.db.SaveWhatsAppSession(userID, "", client.WAClient.Store.ID.String(), true)
+	if deviceJID := client.DeviceJID(); m.db != nil && deviceJID != "" {
+		_ = m.db.SaveWhatsAppSession(userID, "", deviceJID, true)
 	}
 
 	m.whatsappClients[userID] = client
-	fmt.Printf("ClientManager: WhatsApp client created for user %d\n", userID)
+	slog.Info("ClientManager: WhatsApp client created", "user_id", userID)
 
 	// Auto-connect if client has a valid session (is logged in)
 	// This ensures HistorySync runs after server restarts, populating channels and message counts
 	if client.IsLoggedIn() {
 		go func() {
-			fmt.Printf("ClientManager: Auto-connecting WhatsApp for user %d...\n", userID)
-			if err := client.WAClient.Connect(); err != nil {
-				fmt.Printf("ClientManager: WhatsApp auto-connect failed for user %d: %v\n", userID, err)
+			slog.Info("ClientManager: Auto-connecting WhatsApp...", "user_id", userID)
+			if err := client.Connect(); err != nil {
+				slog.Error("ClientManager: WhatsApp auto-connect failed", "user_id", userID, "error", err)
 			} else {
-				fmt.Printf("ClientManager: WhatsApp auto-connected successfully for user %d\n", userID)
+				slog.Info("ClientManager: WhatsApp auto-connected successfully", "user_id", userID)
 			}
 		}()
 	}
@@ -184,15 +296,19 @@ func (m *ClientManager) DestroyWhatsAppClient(userID int64) error {
 		return nil // Already destroyed
 	}
 
-	fmt.Printf("ClientManager: Destroying WhatsApp client for user %d\n", userID)
+	slog.Info("ClientManager: Destroying WhatsApp client", "user_id", userID)
 
 	// Disconnect but don't delete session
-	if client.IsLoggedIn() {
+	if client.IsLoggedIn() || client.IsConnected() {
 		client.Disconnect()
 	}
+	if err := client.Close(); err != nil {
+		slog.Warn("ClientManager: Failed to close WhatsApp session store", "user_id", userID, "error", err)
+	}
 
 	delete(m.whatsappClients, userID)
-	fmt.Printf("ClientManager: WhatsApp client destroyed for user %d (session preserved)\n", userID)
+	m.forgetHealth(source.SourceTypeWhatsApp, userID)
+	slog.Info("ClientManager: WhatsApp client destroyed (session preserved)", "user_id", userID)
 
 	return nil
 }
@@ -213,18 +329,22 @@ func (m *ClientManager) LogoutWhatsApp(userID int64) error {
 		if err := os.Remove(sessionPath); err != nil && !os.IsNotExist(err) {
 			return fmt.Errorf("failed to delete WhatsApp session file: %w", err)
 		}
-		fmt.Printf("ClientManager: WhatsApp session file deleted for user %d\n", userID)
+		slog.Info("ClientManager: WhatsApp session file deleted", "user_id", userID)
 		return nil
 	}
 
-	fmt.Printf("ClientManager: Logging out WhatsApp for user %d\n", userID)
+	slog.Info("ClientManager: Logging out WhatsApp", "user_id", userID)
 
 	// Perform protocol-level logout
 	if client.IsLoggedIn() {
 		if err := client.Logout(); err != nil {
-			fmt.Printf("Warning: WhatsApp logout failed for user %d: %v\n", userID, err)
+			slog.Warn("WhatsApp logout failed", "user_id", userID, "error", err)
 		}
 	}
+	// Close the store first so a final flush can't recreate the deleted file
+	if err := client.Close(); err != nil {
+		slog.Warn("ClientManager: Failed to close WhatsApp session store", "user_id", userID, "error", err)
+	}
 
 	// Delete session file
 	sessionPath := m.getUserWhatsAppDBPath(userID)
@@ -236,8 +356,9 @@ func (m *ClientManager) LogoutWhatsApp(userID int64) error {
 	m.mu.Lock()
 	delete(m.whatsappClients, userID)
 	m.mu.Unlock()
+	m.forgetHealth(source.SourceTypeWhatsApp, userID)
 
-	fmt.Printf("ClientManager: WhatsApp fully logged out for user %d\n", userID)
+	slog.Info("ClientManager: WhatsApp fully logged out", "user_id", userID)
 	return nil
 }
 
@@ -249,7 +370,10 @@ func (m *ClientManager) getUserWhatsAppDBPath(userID int64) string {
 // ==================== Telegram Client Management ====================
 
 // GetTelegramClient returns an existing Telegram client for the user or creates a new one
+// (including after the monitor evicted it)
 func (m *ClientManager) GetTelegramClient(userID int64) (*telegram.Client, error) {
+	m.touch(source.SourceTypeTelegram, userID)
+
 	m.mu.RLock()
 	client, exists := m.telegramClients[userID]
 	m.mu.RUnlock()
@@ -272,7 +396,7 @@ func (m *ClientManager) CreateTelegramClient(userID int64) (*telegram.Client, er
 	}
 
 	sessionPath := m.getUserTelegramSessionPath(userID)
-	fmt.Printf("ClientManager: Creating Telegram client for user %d with session path: %s\n", userID, sessionPath)
+	slog.Info("ClientManager: Creating Telegram client with session path", "user_id", userID, "session_path", sessionPath)
 
 	// Create handler for this user first
 	handler := telegram.NewHandler(userID, m.db)
@@ -282,10 +406,11 @@ func (m *ClientManager) CreateTelegramClient(userID int64) (*telegram.Client, er
 
 	// Create client with handler using ClientConfig
 	client, err := telegram.NewClient(telegram.ClientConfig{
-		APIID:       m.cfg.TelegramAPIID,
-		APIHash:     m.cfg.TelegramAPIHash,
-		SessionPath: sessionPath,
-		Handler:     handler,
+		APIID:         m.cfg.TelegramAPIID,
+		APIHash:       m.cfg.TelegramAPIHash,
+		SessionPath:   sessionPath,
+		Handler:       handler,
+		SessionCipher: m.cfg.SessionCipher,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Telegram client for user %d: %w", userID, err)
@@ -295,7 +420,7 @@ func (m *ClientManager) CreateTelegramClient(userID int64) (*telegram.Client, er
 	client.SetUserID(userID)
 
 	m.telegramClients[userID] = client
-	fmt.Printf("ClientManager: Telegram client created for user %d\n", userID)
+	slog.Info("ClientManager: Telegram client created", "user_id", userID)
 
 	return client, nil
 }
@@ -311,15 +436,16 @@ func (m *ClientManager) DestroyTelegramClient(userID int64) error {
 		return nil // Already destroyed
 	}
 
-	fmt.Printf("ClientManager: Destroying Telegram client for user %d\n", userID)
+	slog.Info("ClientManager: Destroying Telegram client", "user_id", userID)
 
 	// Disconnect but don't delete session
-	if client.IsConnected() {
+	if client.IsStarted() {
 		client.Disconnect()
 	}
 
 	delete(m.telegramClients, userID)
-	fmt.Printf("ClientManager: Telegram client destroyed for user %d (session preserved)\n", userID)
+	m.forgetHealth(source.SourceTypeTelegram, userID)
+	slog.Info("ClientManager: Telegram client destroyed (session preserved)", "user_id", userID)
 
 	return nil
 }
@@ -340,11 +466,11 @@ func (m *ClientManager) LogoutTelegram(userID int64) error {
 		if err := os.Remove(sessionPath); err != nil && !os.IsNotExist(err) {
 			return fmt.Errorf("failed to delete Telegram session file: %w", err)
 		}
-		fmt.Printf("ClientManager: Telegram session file deleted for user %d\n", userID)
+		slog.Info("ClientManager: Telegram session file deleted", "user_id", userID)
 		return nil
 	}
 
-	fmt.Printf("ClientManager: Logging out Telegram for user %d\n", userID)
+	slog.Info("ClientManager: Logging out Telegram", "user_id", userID)
 
 	// Disconnect
 	if client.IsConnected() {
@@ -353,15 +479,16 @@ func (m *ClientManager) LogoutTelegram(userID int64) error {
 
 	// Delete session file
 	if err := client.DeleteSession(); err != nil {
-		fmt.Printf("Warning: Failed to delete Telegram session file for user %d: %v\n", userID, err)
+		slog.Warn("Failed to delete Telegram session file", "user_id", userID, "error", err)
 	}
 
 	// Remove from memory
 	m.mu.Lock()
 	delete(m.telegramClients, userID)
 	m.mu.Unlock()
+	m.forgetHealth(source.SourceTypeTelegram, userID)
 
-	fmt.Printf("ClientManager: Telegram fully logged out for user %d\n", userID)
+	slog.Info("ClientManager: Telegram fully logged out", "user_id", userID)
 	return nil
 }
 
@@ -375,7 +502,7 @@ func (m *ClientManager) getUserTelegramSessionPath(userID int64) string {
 // CleanupUser destroys all clients for a user (called on logout)
 // Preserves session files for reconnection
 func (m *ClientManager) CleanupUser(userID int64) error {
-	fmt.Printf("ClientManager: Cleaning up all clients for user %d\n", userID)
+	slog.Info("ClientManager: Cleaning up all clients", "user_id", userID)
 
 	var errs []error
 
@@ -391,14 +518,14 @@ func (m *ClientManager) CleanupUser(userID int64) error {
 		return fmt.Errorf("cleanup errors for user %d: %v", userID, errs)
 	}
 
-	fmt.Printf("ClientManager: All clients cleaned up for user %d\n", userID)
+	slog.Info("ClientManager: All clients cleaned up", "user_id", userID)
 	return nil
 }
 
 // ResetUserSessions performs a full logout for a user (deletes session files)
 // Used for onboarding reset and similar scenarios where complete cleanup is needed
 func (m *ClientManager) ResetUserSessions(userID int64) error {
-	fmt.Printf("ClientManager: Resetting all sessions for user %d\n", userID)
+	slog.Info("ClientManager: Resetting all sessions", "user_id", userID)
 
 	var errs []error
 
@@ -414,13 +541,13 @@ func (m *ClientManager) ResetUserSessions(userID int64) error {
 		return fmt.Errorf("reset errors for user %d: %v", userID, errs)
 	}
 
-	fmt.Printf("ClientManager: All sessions reset for user %d\n", userID)
+	slog.Info("ClientManager: All sessions reset", "user_id", userID)
 	return nil
 }
 
 // RestoreUserSessions restores sessions for all users on server startup
 func (m *ClientManager) RestoreUserSessions(ctx context.Context) error {
-	fmt.Println("ClientManager: Restoring user sessions...")
+	slog.Info("ClientManager: Restoring user sessions...")
 
 	// Get all users from database
 	users, err := m.db.GetAllUsers()
@@ -432,56 +559,63 @@ func (m *ClientManager) RestoreUserSessions(ctx context.Context) error {
 		// Restore WhatsApp session if session file exists
 		sessionPath := m.getUserWhatsAppDBPath(user.ID)
 		if _, err := os.Stat(sessionPath); err == nil {
-			fmt.Printf("ClientManager: Restoring WhatsApp session for user %d\n", user.ID)
+			slog.Info("ClientManager: Restoring WhatsApp session", "user_id", user.ID)
 			if _, err := m.CreateWhatsAppClient(user.ID); err != nil {
-				fmt.Printf("Warning: Failed to restore WhatsApp for user %d: %v\n", user.ID, err)
+				slog.Warn("Failed to restore WhatsApp", "user_id", user.ID, "error", err)
 			}
 		}
 
 		// Restore Telegram session if session file exists
 		sessionPath = m.getUserTelegramSessionPath(user.ID)
 		if _, err := os.Stat(sessionPath); err == nil {
-			fmt.Printf("ClientManager: Restoring Telegram session for user %d\n", user.ID)
+			slog.Info("ClientManager: Restoring Telegram session", "user_id", user.ID)
 			if _, err := m.CreateTelegramClient(user.ID); err != nil {
-				fmt.Printf("Warning: Failed to restore Telegram for user %d: %v\n", user.ID, err)
+				slog.Warn("Failed to restore Telegram", "user_id", user.ID, "error", err)
 			}
 		}
 	}
 
-	fmt.Println("ClientManager: Session restoration complete")
+	slog.Info("ClientManager: Session restoration complete")
 	return nil
 }
 
 // Shutdown gracefully shuts down all clients
 func (m *ClientManager) Shutdown(ctx context.Context) error {
-	fmt.Println("ClientManager: Shutting down all clients...")
+	slog.Info("ClientManager: Shutting down all clients...")
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.stopMonitor != nil {
+		m.stopMonitor()
+	}
+
 	// Disconnect all WhatsApp clients
 	for userID, client := range m.whatsappClients {
-		fmt.Printf("ClientManager: Disconnecting WhatsApp for user %d\n", userID)
+		slog.Info("ClientManager: Disconnecting WhatsApp", "user_id", userID)
 		if client.IsLoggedIn() {
 			client.Disconnect()
 		}
+		if err := client.Close(); err != nil {
+			slog.Warn("ClientManager: Failed to close WhatsApp session store", "user_id", userID, "error", err)
+		}
 	}
 
 	// Disconnect all Telegram clients
 	for userID, client := range m.telegramClients {
-		fmt.Printf("ClientManager: Disconnecting Telegram for user %d\n", userID)
-		if client.IsConnected() {
+		slog.Info("ClientManager: Disconnecting Telegram", "user_id", userID)
+		if client.IsStarted() {
 			client.Disconnect()
 		}
 	}
 
 	// Clear maps
-	m.whatsappClients = make(map[int64]*whatsapp.Client)
+	m.whatsappClients = make(map[int64]whatsapp.Account)
 	m.telegramClients = make(map[int64]*telegram.Client)
 
 	// Close message channel
 	close(m.msgChan)
 
-	fmt.Println("ClientManager: Shutdown complete")
+	slog.Info("ClientManager: Shutdown complete")
 	return nil
 }
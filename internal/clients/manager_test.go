@@ -1,11 +1,15 @@
 package clients
 
 import (
+	"errors"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/sse"
+	"github.com/omriShneor/project_alfred/internal/whatsapp"
+	"github.com/omriShneor/project_alfred/internal/whatsapp/mockwhatsapp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -51,3 +55,119 @@ func TestLogoutTelegramUpdatesSessionState(t *testing.T) {
 	require.NotNil(t, session)
 	assert.False(t, session.Connected)
 }
+
+// flakyAccount fails Connect with connectErr while it is set
+type flakyAccount struct {
+	*mockwhatsapp.Client
+	connectErr error
+	connects   int
+}
+
+func (a *flakyAccount) Connect() error {
+	a.connects++
+	if a.connectErr != nil {
+		return a.connectErr
+	}
+	return a.Client.Connect()
+}
+
+func newMonitoredManager(t *testing.T, db *database.DB, newClient func(*whatsapp.Handler) whatsapp.Account) (*ClientManager, *time.Time) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	manager := NewClientManager(db, &ManagerConfig{
+		WhatsAppDBBasePath: filepath.Join(tmpDir, "whatsapp.db"),
+		TelegramDBBasePath: filepath.Join(tmpDir, "telegram.db"),
+		IdleTTL:            time.Hour,
+		NewWhatsAppClient: func(userID int64, handler *whatsapp.Handler) (whatsapp.Account, error) {
+			return newClient(handler), nil
+		},
+	}, nil, sse.NewState())
+	now := time.Now()
+	manager.now = func() time.Time { return now }
+	return manager, &now
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, reconnectBackoff(1))
+	assert.Equal(t, time.Minute, reconnectBackoff(2))
+	assert.Equal(t, 2*time.Minute, reconnectBackoff(3))
+	assert.Equal(t, 30*time.Minute, reconnectBackoff(20))
+}
+
+func TestMonitorEvictsIdleUnpairedClients(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	manager, now := newMonitoredManager(t, db, func(handler *whatsapp.Handler) whatsapp.Account {
+		return mockwhatsapp.NewClient(handler)
+	})
+
+	_, err := manager.GetWhatsAppClient(user.ID)
+	require.NoError(t, err)
+
+	*now = now.Add(30 * time.Minute)
+	manager.checkClients()
+	_, loaded := manager.PeekWhatsAppClient(user.ID)
+	assert.True(t, loaded)
+
+	*now = now.Add(time.Hour)
+	manager.checkClients()
+	_, loaded = manager.PeekWhatsAppClient(user.ID)
+	assert.False(t, loaded)
+	assert.Equal(t, StateEvicted, manager.WhatsAppConnectionState(user.ID).State)
+	assert.Equal(t, 1, manager.WhatsAppSummary().Evicted)
+
+	// Loaded again on demand
+	_, err = manager.GetWhatsAppClient(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StateNotAuthenticated, manager.WhatsAppConnectionState(user.ID).State)
+	assert.Equal(t, 0, manager.WhatsAppSummary().Evicted)
+}
+
+func TestMonitorReconnectsWithBackoff(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	account := &flakyAccount{}
+	manager, now := newMonitoredManager(t, db, func(handler *whatsapp.Handler) whatsapp.Account {
+		account.Client = mockwhatsapp.NewClient(handler)
+		return account
+	})
+
+	_, err := manager.GetWhatsAppClient(user.ID)
+	require.NoError(t, err)
+	account.Client.CompletePairing()
+	manager.checkClients()
+	assert.Equal(t, StateConnected, manager.WhatsAppConnectionState(user.ID).State)
+
+	// Paired clients are never evicted, and a drop waits one backoff step
+	account.Client.Disconnect()
+	account.connectErr = errors.New("network unreachable")
+	*now = now.Add(2 * time.Hour)
+	manager.checkClients()
+	assert.Equal(t, 0, account.connects)
+	state := manager.WhatsAppConnectionState(user.ID)
+	assert.Equal(t, StateReconnecting, state.State)
+	assert.Equal(t, 1, manager.WhatsAppSummary().Reconnecting)
+
+	*now = now.Add(30 * time.Second)
+	manager.checkClients()
+	assert.Equal(t, 1, account.connects)
+	state = manager.WhatsAppConnectionState(user.ID)
+	assert.Equal(t, 1, state.ReconnectAttempts)
+	assert.Equal(t, "network unreachable", state.LastError)
+	require.NotNil(t, state.NextReconnectAt)
+	assert.Equal(t, now.Add(time.Minute), *state.NextReconnectAt)
+
+	*now = now.Add(30 * time.Second)
+	manager.checkClients()
+	assert.Equal(t, 1, account.connects, "still backing off")
+
+	account.connectErr = nil
+	*now = now.Add(30 * time.Second)
+	manager.checkClients()
+	assert.Equal(t, 2, account.connects)
+
+	manager.checkClients()
+	state = manager.WhatsAppConnectionState(user.ID)
+	assert.Equal(t, StateConnected, state.State)
+	assert.Zero(t, state.ReconnectAttempts)
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
+	"github.com/omriShneor/project_alfred/internal/secrets"
 )
 
 func init() {
@@ -17,20 +20,62 @@ type Config struct {
 	GoogleCredentialsFile string
 	GoogleCredentialsJSON string // JSON string (alternative to file)
 
+	// Key for encrypting stored tokens; derived from AnthropicAPIKey if empty
+	EncryptionKey string
+
 	// Optional with defaults
 	DBPath             string
 	WhatsAppDBPath     string
 	HTTPPort           int
 	DebugAllMessages   bool
 	ClaudeModel        string
+	ClaudeFastModel    string // model for users on the "fast" tier
 	ClaudeTemperature  float64
+	LLMProvider        string // "anthropic" or "openai"
+	OpenAIAPIKey       string
+	OpenAIModel        string
+	OpenAIFastModel    string
+	LLMMonthlyBudget   float64 // USD per month for users without their own budget (0 = unlimited)
 	MessageHistorySize int
-	DevMode            bool // Enables dev features like unauthenticated reset endpoint
+	// Days of message_history kept for users without their own setting (0 = forever)
+	MessageRetentionDays int
+	MessagePruneInterval int  // minutes between retention prune runs
+	DevMode              bool // Enables dev features like unauthenticated reset endpoint
+
+	// Logging (log/slog)
+	LogLevel  string // debug, info, warn or error
+	LogFormat string // text or json
 
 	// Notification server config (API keys only - user prefs in database)
 	ResendAPIKey string
 	EmailFrom    string
 
+	// SMTP server used for email when no Resend API key is set
+	SMTPHost     string
+	SMTPPort     int
+	SMTPSecurity string // starttls, tls or none
+	SMTPUsername string
+	SMTPPassword string
+
+	// PublicURL is where users' browsers reach the server, used for links in emails
+	PublicURL string
+	// EmailLinkSecret signs one-click links in emails; derived from the encryption key if empty
+	EmailLinkSecret string
+
+	// Reminder due-date notifications: minutes before due date (0 = at due time)
+	ReminderNotifyOffsets []int
+
+	// Pending event pushes detected within this many seconds of each other are sent as one (0 = no batching)
+	NotifyBatchWindowSeconds int
+
+	// Native push for devices registered with FCM/APNs tokens (Expo needs no config)
+	FCMCredentialsFile string // Firebase service account key
+	APNsKeyFile        string // .p8 signing key
+	APNsKeyID          string
+	APNsTeamID         string
+	APNsTopic          string // app bundle ID
+	APNsSandbox        bool
+
 	// Gmail integration config (enable/disable is in database settings, not here)
 	GmailPollInterval int // minutes between polls
 	GmailMaxEmails    int // max emails to process per poll
@@ -42,75 +87,402 @@ type Config struct {
 	TelegramAPIID   int    // API ID from my.telegram.org
 	TelegramAPIHash string // API Hash from my.telegram.org
 	TelegramDBPath  string // Session database path
+
+	// Write the encrypted WhatsApp session store right after commits to its identity and
+	// session tables, not only every few seconds
+	WhatsAppSessionFlushOnCommit bool
+
+	// Database backups (disabled unless a directory or S3 bucket is set)
+	BackupDir        string
+	BackupInterval   int // minutes between scheduled backups
+	BackupKeep       int // number of backups to retain
+	BackupS3Bucket   string
+	BackupS3Region   string
+	BackupS3Endpoint string // for S3-compatible services; defaults to AWS
+	BackupS3Prefix   string
+	AWSAccessKeyID   string
+	AWSSecretKey     string
+	AWSSessionToken  string
+
+	// Emails of users allowed to call /api/admin endpoints
+	AdminEmails []string
+
+	// Pre-filter that skips non-actionable chat messages before the LLM
+	PrefilterEnabled     bool
+	PrefilterMinChars    int      // skip messages with fewer letters/digits
+	PrefilterSkipPhrases []string // extra whole-message phrases to skip
+	PrefilterSkipPattern string   // regex; matching messages are skipped
+	PrefilterKeepPattern string   // regex; matching messages are always analyzed
+
+	// Conversation batching: rapid-fire messages from one channel are analyzed together
+	BatchWindowSeconds  int // quiet period that ends a burst (0 = analyze each message)
+	BatchMaxWaitSeconds int // longest a burst is held before it is analyzed anyway
+	BatchMaxMessages    int // burst size that triggers analysis immediately
+
+	// Processor concurrency and LLM request rate limits (requests per minute, 0 = unlimited)
+	ProcessorWorkers   int
+	AnthropicRateLimit int
+	OpenAIRateLimit    int
+
+	// Anthropic request resilience: retries on 429/5xx/timeouts, and a circuit breaker
+	// that pauses analysis (beyond the pre-filter) while the API is down
+	AnthropicMaxRetries             int // retries after the first attempt
+	AnthropicRetryBaseMS            int // backoff before the first retry, doubled for each later one
+	AnthropicRetryMaxMS             int // cap on a single backoff
+	AnthropicTimeoutSeconds         int // per attempt
+	AnthropicBreakerThreshold       int // consecutive failed requests that open the breaker (0 = no breaker)
+	AnthropicBreakerCooldownSeconds int // how long the breaker stays open before a trial request
+
+	// Semantic retrieval of related earlier messages for the event agent (needs OPENAI_API_KEY)
+	RAGEnabled             bool
+	EmbeddingModel         string
+	EmbeddingIndexInterval int // minutes between background indexing runs
+	RAGRelatedMessages     int // related messages added to the event agent's context
+
+	// Event location geocoding (off unless a geocoder is set; locations are sent to it)
+	Geocoder         string // "nominatim" or "google"
+	GeocoderURL      string // self-hosted Nominatim or Geocoding API endpoint (default: public)
+	GoogleMapsAPIKey string
+	GeocodeInterval  int // minutes between background geocoding runs
+
+	// Shadow mode: a second event/reminder configuration analyzes the same messages and
+	// its output is logged next to the live one, without creating items
+	ShadowEnabled            bool
+	ShadowLabel              string  // name comparisons are recorded under
+	ShadowProvider           string  // defaults to LLMProvider
+	ShadowModel              string  // defaults to the provider's model
+	ShadowTemperature        float64 // defaults to ClaudeTemperature
+	ShadowEventPromptFile    string  // candidate event system prompt (default: live prompt)
+	ShadowReminderPromptFile string  // candidate reminder system prompt (default: live prompt)
+	ShadowSampleRate         float64 // fraction of analyses shadowed (0-1]
+}
+
+// DefaultFile is the config file read from the working directory when no path is given
+const DefaultFile = "alfred.yaml"
+
+// Load reads settings from the environment and an optional YAML config file, with
+// environment variables taking precedence over the file. An empty path reads DefaultFile
+// if it exists. Every invalid value is reported in the returned error.
+func Load(path string) (*Config, error) {
+	l := &loader{used: make(map[string]bool), listKeys: make(map[string]bool)}
+	if err := l.readFile(path); err != nil {
+		return nil, err
+	}
+
+	cfg := l.load()
+	l.checkUnknownKeys()
+	l.problems = append(l.problems, cfg.validate()...)
+	if len(l.problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %s", strings.Join(l.problems, "; "))
+	}
+	return cfg, nil
 }
 
-func LoadFromEnv() *Config {
+// load resolves every setting. The keys it reads are the settings a config file may set.
+func (l *loader) load() *Config {
 	cfg := &Config{
 		// Required
-		AnthropicAPIKey:       os.Getenv("ANTHROPIC_API_KEY"),
-		GoogleCredentialsFile: getEnvOrDefault("GOOGLE_CREDENTIALS_FILE", "./credentials.json"),
-		GoogleCredentialsJSON: os.Getenv("GOOGLE_CREDENTIALS_JSON"), // Takes precedence over file
+		AnthropicAPIKey:       l.string("ANTHROPIC_API_KEY", ""),
+		GoogleCredentialsFile: l.string("GOOGLE_CREDENTIALS_FILE", "./credentials.json"),
+		GoogleCredentialsJSON: l.string("GOOGLE_CREDENTIALS_JSON", ""), // Takes precedence over file
+		EncryptionKey:         l.string("ALFRED_ENCRYPTION_KEY", ""),
 
 		// Optional with defaults
-		DBPath:             getEnvOrDefault("ALFRED_DB_PATH", "./alfred.db"),
-		WhatsAppDBPath:     getEnvOrDefault("ALFRED_WHATSAPP_DB_PATH", "./whatsapp.db"),
-		HTTPPort:           getEnvAsIntOrDefault("PORT", getEnvAsIntOrDefault("ALFRED_HTTP_PORT", 8080)),
-		DebugAllMessages:   getEnvAsBoolOrDefault("ALFRED_DEBUG_ALL_MESSAGES", false),
-		ClaudeModel:        getEnvOrDefault("ALFRED_CLAUDE_MODEL", "claude-sonnet-4-20250514"),
-		ClaudeTemperature:  getEnvAsFloatOrDefault("ALFRED_CLAUDE_TEMPERATURE", 0.1),
-		MessageHistorySize: getEnvAsIntOrDefault("ALFRED_MESSAGE_HISTORY_SIZE", 25),
-		DevMode:            getEnvAsBoolOrDefault("ALFRED_DEV_MODE", false),
+		DBPath:               l.string("ALFRED_DB_PATH", "./alfred.db"),
+		WhatsAppDBPath:       l.string("ALFRED_WHATSAPP_DB_PATH", "./whatsapp.db"),
+		HTTPPort:             l.int("PORT", l.int("ALFRED_HTTP_PORT", 8080)),
+		DebugAllMessages:     l.bool("ALFRED_DEBUG_ALL_MESSAGES", false),
+		ClaudeModel:          l.string("ALFRED_CLAUDE_MODEL", "claude-sonnet-4-20250514"),
+		ClaudeFastModel:      l.string("ALFRED_CLAUDE_FAST_MODEL", "claude-3-5-haiku-20241022"),
+		ClaudeTemperature:    l.float("ALFRED_CLAUDE_TEMPERATURE", 0.1),
+		LLMProvider:          strings.ToLower(l.string("ALFRED_LLM_PROVIDER", "anthropic")),
+		OpenAIAPIKey:         l.string("OPENAI_API_KEY", ""),
+		OpenAIModel:          l.string("ALFRED_OPENAI_MODEL", "gpt-4o"),
+		OpenAIFastModel:      l.string("ALFRED_OPENAI_FAST_MODEL", "gpt-4o-mini"),
+		LLMMonthlyBudget:     l.float("ALFRED_LLM_MONTHLY_BUDGET_USD", 0),
+		MessageHistorySize:   l.int("ALFRED_MESSAGE_HISTORY_SIZE", 25),
+		MessageRetentionDays: l.int("ALFRED_MESSAGE_RETENTION_DAYS", 90),
+		MessagePruneInterval: l.int("ALFRED_MESSAGE_PRUNE_INTERVAL", 60),
+		DevMode:              l.bool("ALFRED_DEV_MODE", false),
+
+		// Logging
+		LogLevel:  l.string("ALFRED_LOG_LEVEL", "info"),
+		LogFormat: l.string("ALFRED_LOG_FORMAT", "text"),
 
 		// Notification server config (API keys only)
-		ResendAPIKey: os.Getenv("ALFRED_RESEND_API_KEY"),
-		EmailFrom:    getEnvOrDefault("ALFRED_EMAIL_FROM", "Alfred <onboarding@resend.dev>"),
+		ResendAPIKey: l.string("ALFRED_RESEND_API_KEY", ""),
+		EmailFrom:    l.string("ALFRED_EMAIL_FROM", "Alfred <onboarding@resend.dev>"),
+
+		SMTPHost:     l.string("ALFRED_SMTP_HOST", ""),
+		SMTPPort:     l.int("ALFRED_SMTP_PORT", 587),
+		SMTPSecurity: l.string("ALFRED_SMTP_SECURITY", "starttls"),
+		SMTPUsername: l.string("ALFRED_SMTP_USERNAME", ""),
+		SMTPPassword: l.string("ALFRED_SMTP_PASSWORD", ""),
+
+		PublicURL:       strings.TrimSuffix(l.string("ALFRED_PUBLIC_URL", ""), "/"),
+		EmailLinkSecret: l.string("ALFRED_EMAIL_LINK_SECRET", ""),
+
+		// Reminder due-date notifications
+		ReminderNotifyOffsets: l.intList("ALFRED_REMINDER_NOTIFY_OFFSETS", []int{60, 0}),
+
+		// Pending event push batching
+		NotifyBatchWindowSeconds: l.int("ALFRED_NOTIFY_BATCH_WINDOW_SECONDS", 30),
+
+		// Native push providers
+		FCMCredentialsFile: l.string("ALFRED_FCM_CREDENTIALS_FILE", ""),
+		APNsKeyFile:        l.string("ALFRED_APNS_KEY_FILE", ""),
+		APNsKeyID:          l.string("ALFRED_APNS_KEY_ID", ""),
+		APNsTeamID:         l.string("ALFRED_APNS_TEAM_ID", ""),
+		APNsTopic:          l.string("ALFRED_APNS_TOPIC", ""),
+		APNsSandbox:        l.bool("ALFRED_APNS_SANDBOX", false),
 
 		// Gmail integration config (enable/disable is in database settings)
-		GmailPollInterval: getEnvAsIntOrDefault("ALFRED_GMAIL_POLL_INTERVAL", 1),
-		GmailMaxEmails:    getEnvAsIntOrDefault("ALFRED_GMAIL_MAX_EMAILS", 10),
+		GmailPollInterval: l.int("ALFRED_GMAIL_POLL_INTERVAL", 1),
+		GmailMaxEmails:    l.int("ALFRED_GMAIL_MAX_EMAILS", 10),
 
 		// Google Calendar sync worker
-		GCalPollInterval: getEnvAsIntOrDefault("ALFRED_GCAL_POLL_INTERVAL", 1),
+		GCalPollInterval: l.int("ALFRED_GCAL_POLL_INTERVAL", 1),
 
 		// Telegram integration config
-		TelegramAPIID:   getEnvAsIntOrDefault("ALFRED_TELEGRAM_API_ID", 0),
-		TelegramAPIHash: os.Getenv("ALFRED_TELEGRAM_API_HASH"),
-		TelegramDBPath:  getEnvOrDefault("ALFRED_TELEGRAM_DB_PATH", "./telegram.db"),
+		TelegramAPIID:   l.int("ALFRED_TELEGRAM_API_ID", 0),
+		TelegramAPIHash: l.string("ALFRED_TELEGRAM_API_HASH", ""),
+		TelegramDBPath:  l.string("ALFRED_TELEGRAM_DB_PATH", "./telegram.db"),
+
+		WhatsAppSessionFlushOnCommit: l.bool("ALFRED_WHATSAPP_SESSION_FLUSH_ON_COMMIT", true),
+
+		// Database backups
+		BackupDir:        l.string("ALFRED_BACKUP_DIR", ""),
+		BackupInterval:   l.int("ALFRED_BACKUP_INTERVAL", 360),
+		BackupKeep:       l.int("ALFRED_BACKUP_KEEP", 7),
+		BackupS3Bucket:   l.string("ALFRED_BACKUP_S3_BUCKET", ""),
+		BackupS3Region:   l.string("ALFRED_BACKUP_S3_REGION", l.string("AWS_REGION", "us-east-1")),
+		BackupS3Endpoint: l.string("ALFRED_BACKUP_S3_ENDPOINT", ""),
+		BackupS3Prefix:   l.string("ALFRED_BACKUP_S3_PREFIX", ""),
+		AWSAccessKeyID:   l.string("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretKey:     l.string("AWS_SECRET_ACCESS_KEY", ""),
+		AWSSessionToken:  l.string("AWS_SESSION_TOKEN", ""),
+
+		AdminEmails: l.list("ALFRED_ADMIN_EMAILS", nil),
+
+		// Pre-filter
+		PrefilterEnabled:     l.bool("ALFRED_PREFILTER_ENABLED", true),
+		PrefilterMinChars:    l.int("ALFRED_PREFILTER_MIN_CHARS", 2),
+		PrefilterSkipPhrases: l.list("ALFRED_PREFILTER_SKIP_PHRASES", nil),
+		PrefilterSkipPattern: l.string("ALFRED_PREFILTER_SKIP_PATTERN", ""),
+		PrefilterKeepPattern: l.string("ALFRED_PREFILTER_KEEP_PATTERN", ""),
+		BatchWindowSeconds:   l.int("ALFRED_BATCH_WINDOW_SECONDS", 5),
+		BatchMaxWaitSeconds:  l.int("ALFRED_BATCH_MAX_WAIT_SECONDS", 30),
+		BatchMaxMessages:     l.int("ALFRED_BATCH_MAX_MESSAGES", 10),
+		ProcessorWorkers:     l.int("ALFRED_PROCESSOR_WORKERS", 2),
+		AnthropicRateLimit:   l.int("ALFRED_ANTHROPIC_RPM", 50),
+		OpenAIRateLimit:      l.int("ALFRED_OPENAI_RPM", 0),
+
+		// Anthropic resilience
+		AnthropicMaxRetries:             l.int("ALFRED_ANTHROPIC_MAX_RETRIES", 3),
+		AnthropicRetryBaseMS:            l.int("ALFRED_ANTHROPIC_RETRY_BASE_MS", 1000),
+		AnthropicRetryMaxMS:             l.int("ALFRED_ANTHROPIC_RETRY_MAX_MS", 30000),
+		AnthropicTimeoutSeconds:         l.int("ALFRED_ANTHROPIC_TIMEOUT_SECONDS", 120),
+		AnthropicBreakerThreshold:       l.int("ALFRED_ANTHROPIC_BREAKER_THRESHOLD", 5),
+		AnthropicBreakerCooldownSeconds: l.int("ALFRED_ANTHROPIC_BREAKER_COOLDOWN_SECONDS", 60),
+
+		// Semantic retrieval
+		RAGEnabled:             l.bool("ALFRED_RAG_ENABLED", false),
+		EmbeddingModel:         l.string("ALFRED_EMBEDDING_MODEL", "text-embedding-3-small"),
+		EmbeddingIndexInterval: l.int("ALFRED_EMBEDDING_INDEX_INTERVAL", 1),
+		RAGRelatedMessages:     l.int("ALFRED_RAG_RELATED_MESSAGES", 5),
+
+		// Geocoding
+		Geocoder:         strings.ToLower(l.string("ALFRED_GEOCODER", "")),
+		GeocoderURL:      l.string("ALFRED_GEOCODER_URL", ""),
+		GoogleMapsAPIKey: l.string("GOOGLE_MAPS_API_KEY", ""),
+		GeocodeInterval:  l.int("ALFRED_GEOCODE_INTERVAL", 1),
+
+		// Shadow mode
+		ShadowEnabled:            l.bool("ALFRED_SHADOW_ENABLED", false),
+		ShadowLabel:              l.string("ALFRED_SHADOW_LABEL", "shadow"),
+		ShadowProvider:           strings.ToLower(l.string("ALFRED_SHADOW_PROVIDER", "")),
+		ShadowModel:              l.string("ALFRED_SHADOW_MODEL", ""),
+		ShadowEventPromptFile:    l.string("ALFRED_SHADOW_EVENT_PROMPT_FILE", ""),
+		ShadowReminderPromptFile: l.string("ALFRED_SHADOW_REMINDER_PROMPT_FILE", ""),
+		ShadowSampleRate:         l.float("ALFRED_SHADOW_SAMPLE_RATE", 1),
+	}
+	if cfg.ShadowProvider == "" {
+		cfg.ShadowProvider = cfg.LLMProvider
 	}
+	cfg.ShadowTemperature = l.float("ALFRED_SHADOW_TEMPERATURE", cfg.ClaudeTemperature)
 
 	return cfg
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// LLMCredentials returns the API key, model and key env var name for the
+// configured LLM provider.
+func (c *Config) LLMCredentials() (apiKey, model, keyEnv string) {
+	if c.LLMProvider == "openai" {
+		return c.OpenAIAPIKey, c.OpenAIModel, "OPENAI_API_KEY"
+	}
+	return c.AnthropicAPIKey, c.ClaudeModel, "ANTHROPIC_API_KEY"
+}
+
+// ShadowLLMCredentials returns the API key, model and key env var name for the shadow
+// configuration
+func (c *Config) ShadowLLMCredentials() (apiKey, model, keyEnv string) {
+	if c.ShadowProvider == "openai" {
+		apiKey, model, keyEnv = c.OpenAIAPIKey, c.OpenAIModel, "OPENAI_API_KEY"
+	} else {
+		apiKey, model, keyEnv = c.AnthropicAPIKey, c.ClaudeModel, "ANTHROPIC_API_KEY"
+	}
+	if c.ShadowModel != "" {
+		model = c.ShadowModel
+	}
+	return apiKey, model, keyEnv
+}
+
+// LLMFastModel returns the model used for users on the "fast" tier
+func (c *Config) LLMFastModel() string {
+	if c.LLMProvider == "openai" {
+		return c.OpenAIFastModel
+	}
+	return c.ClaudeFastModel
+}
+
+// loader resolves each setting from its environment variable, then the config file, then
+// its default. Invalid values are collected as problems rather than silently replaced.
+type loader struct {
+	fileName  string
+	file      map[string]string   // scalar file values by file key
+	fileLists map[string][]string // list file values by file key
+	used      map[string]bool     // file keys read by load
+	listKeys  map[string]bool     // file keys read as lists
+	problems  []string
+
+	secrets secretResolver // created when the first secret reference is read
+}
+
+// FileKey returns the config file key for an environment variable: lowercased, without
+// the ALFRED_ prefix (ALFRED_HTTP_PORT is http_port, ANTHROPIC_API_KEY is anthropic_api_key)
+func FileKey(envKey string) string {
+	return strings.ToLower(strings.TrimPrefix(envKey, "ALFRED_"))
+}
+
+// lookup returns the raw value for key and where it came from, or "" when unset. A
+// secret manager reference is replaced by the secret it points to.
+func (l *loader) lookup(key string) (value, source string) {
+	fileKey := FileKey(key)
+	l.used[fileKey] = true
+	if value = os.Getenv(key); value != "" {
+		source = key
+	} else if value = l.file[fileKey]; value != "" {
+		source = l.fileName + ": " + fileKey
+	}
+	if secrets.IsReference(value) {
+		value = l.resolveSecret(key, value, source)
+	}
+	return value, source
+}
+
+func (l *loader) invalid(source, value, want string) {
+	l.problems = append(l.problems, fmt.Sprintf("%s: %q is not %s", source, value, want))
+}
+
+func (l *loader) string(key, defaultValue string) string {
+	if value, _ := l.lookup(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
-func getEnvAsIntOrDefault(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
-		}
+func (l *loader) int(key string, defaultValue int) int {
+	value, source := l.lookup(key)
+	if value == "" {
+		return defaultValue
 	}
-	return defaultValue
+	intVal, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		l.invalid(source, value, "an integer")
+		return defaultValue
+	}
+	return intVal
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	value, source := l.lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolVal, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		l.invalid(source, value, "true or false")
+		return defaultValue
+	}
+	return boolVal
+}
+
+func (l *loader) float(key string, defaultValue float64) float64 {
+	value, source := l.lookup(key)
+	if value == "" {
+		return defaultValue
+	}
+	floatVal, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		l.invalid(source, value, "a number")
+		return defaultValue
+	}
+	return floatVal
 }
 
-func getEnvAsBoolOrDefault(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if boolVal, err := strconv.ParseBool(value); err == nil {
-			return boolVal
+// intList parses a list of non-negative integers, given as a comma-separated string or
+// a YAML list
+func (l *loader) intList(key string, defaultValue []int) []int {
+	parts, source := l.listParts(key)
+	if parts == nil {
+		return defaultValue
+	}
+
+	var result []int
+	for _, part := range parts {
+		intVal, err := strconv.Atoi(part)
+		if err != nil || intVal < 0 {
+			l.invalid(source, part, "a non-negative integer")
+			return defaultValue
 		}
+		result = append(result, intVal)
 	}
-	return defaultValue
+	if len(result) == 0 {
+		return defaultValue
+	}
+	return result
+}
+
+// list parses a comma-separated string or a YAML list, dropping empty entries
+func (l *loader) list(key string, defaultValue []string) []string {
+	parts, _ := l.listParts(key)
+	if len(parts) == 0 {
+		return defaultValue
+	}
+	return parts
 }
 
-func getEnvAsFloatOrDefault(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
-		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
-			return floatVal
+// listParts returns the trimmed, non-empty entries of a list setting, or nil when unset.
+// A YAML list keeps entries that contain commas intact.
+func (l *loader) listParts(key string) ([]string, string) {
+	var raw []string
+	fileKey := FileKey(key)
+	l.listKeys[fileKey] = true
+	value, source := l.lookup(key)
+	switch {
+	case value != "":
+		raw = strings.Split(value, ",")
+	case l.fileLists[fileKey] != nil:
+		raw, source = l.fileLists[fileKey], l.fileName+": "+fileKey
+	default:
+		return nil, ""
+	}
+
+	parts := []string{}
+	for _, part := range raw {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
 		}
 	}
-	return defaultValue
+	return parts, source
 }
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "alfred.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults without a file", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+
+		cfg, err := Load("")
+		require.NoError(t, err)
+		assert.Equal(t, "./alfred.db", cfg.DBPath)
+		assert.Equal(t, []int{60, 0}, cfg.ReminderNotifyOffsets)
+	})
+
+	t.Run("reads the file with environment overrides", func(t *testing.T) {
+		path := writeConfigFile(t, `
+db_path: /data/alfred.db
+http_port: 9090
+claude_temperature: 0.3
+dev_mode: true
+admin_emails:
+  - ops@example.com
+  - admin@example.com
+reminder_notify_offsets: [30, 0]
+prefilter_skip_phrases: ["ok, thanks", "noted"]
+anthropic_api_key: from-file
+`)
+		t.Setenv("ALFRED_DB_PATH", "/env/alfred.db")
+		t.Setenv("ANTHROPIC_API_KEY", "")
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, "/env/alfred.db", cfg.DBPath)
+		assert.Equal(t, 9090, cfg.HTTPPort)
+		assert.InDelta(t, 0.3, cfg.ClaudeTemperature, 0.0001)
+		assert.InDelta(t, 0.3, cfg.ShadowTemperature, 0.0001)
+		assert.True(t, cfg.DevMode)
+		assert.Equal(t, []string{"ops@example.com", "admin@example.com"}, cfg.AdminEmails)
+		assert.Equal(t, []int{30, 0}, cfg.ReminderNotifyOffsets)
+		assert.Equal(t, []string{"ok, thanks", "noted"}, cfg.PrefilterSkipPhrases)
+		assert.Equal(t, "from-file", cfg.AnthropicAPIKey)
+	})
+
+	t.Run("reads alfred.yaml from the working directory", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, DefaultFile), []byte("gmail_max_emails: 25\n"), 0o600))
+		t.Chdir(dir)
+
+		cfg, err := Load("")
+		require.NoError(t, err)
+		assert.Equal(t, 25, cfg.GmailMaxEmails)
+	})
+
+	t.Run("example file is valid", func(t *testing.T) {
+		_, err := Load(filepath.Join("..", "..", "alfred.example.yaml"))
+		require.NoError(t, err)
+	})
+
+	t.Run("missing explicit file", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+
+	t.Run("malformed file", func(t *testing.T) {
+		_, err := Load(writeConfigFile(t, "http_port: [1\n"))
+		assert.ErrorContains(t, err, "failed to parse config file")
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		path := writeConfigFile(t, `
+htp_port: 9090
+gmail_max_emails: lots
+llm_provider: gemini
+db_path: [a, b]
+smtp:
+  host: mail.example.com
+`)
+		t.Setenv("ALFRED_PROCESSOR_WORKERS", "0")
+		t.Setenv("ALFRED_APNS_SANDBOX", "maybe")
+		t.Setenv("ALFRED_GEOCODER", "google")
+
+		_, err := Load(path)
+		require.Error(t, err)
+		for _, want := range []string{
+			`unknown setting "htp_port" (did you mean "http_port"?)`,
+			path + `: gmail_max_emails: "lots" is not an integer`,
+			`ALFRED_LLM_PROVIDER (llm_provider): unknown provider "gemini"`,
+			"db_path: expected a single value, not a list",
+			"smtp: nested settings aren't supported",
+			"ALFRED_PROCESSOR_WORKERS (processor_workers): must be at least 1, got 0",
+			`ALFRED_APNS_SANDBOX: "maybe" is not true or false`,
+			"GOOGLE_MAPS_API_KEY (google_maps_api_key): required when ALFRED_GEOCODER is google",
+		} {
+			assert.Contains(t, err.Error(), want)
+		}
+	})
+}
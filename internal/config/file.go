@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readFile loads a flat YAML file of settings. Keys are FileKey names; values are
+// scalars or lists of scalars. An empty path reads DefaultFile if it exists.
+func (l *loader) readFile(path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	l.fileName = path
+	l.file = make(map[string]string, len(raw))
+	l.fileLists = make(map[string][]string)
+	for key, value := range raw {
+		switch v := value.(type) {
+		case nil:
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if isScalar(item) {
+					items = append(items, fmt.Sprint(item))
+				}
+			}
+			if len(items) < len(v) {
+				l.problems = append(l.problems, fmt.Sprintf("%s: %s: list entries must be single values", path, key))
+			}
+			l.fileLists[key] = items
+		case map[string]any:
+			l.problems = append(l.problems, fmt.Sprintf("%s: %s: nested settings aren't supported; use a single value", path, key))
+		default:
+			l.file[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case []any, map[string]any, nil:
+		return false
+	}
+	return true
+}
+
+// checkUnknownKeys reports file keys that no setting reads, suggesting the closest
+// known key for typos, and lists given for settings that take a single value
+func (l *loader) checkUnknownKeys() {
+	var keys []string
+	for key := range l.file {
+		keys = append(keys, key)
+	}
+	for key := range l.fileLists {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !l.used[key] {
+			problem := fmt.Sprintf("%s: unknown setting %q", l.fileName, key)
+			if suggestion := l.closestKey(key); suggestion != "" {
+				problem += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			l.problems = append(l.problems, problem)
+			continue
+		}
+		if _, isList := l.fileLists[key]; isList && !l.listKeys[key] {
+			l.problems = append(l.problems, fmt.Sprintf("%s: %s: expected a single value, not a list", l.fileName, key))
+		}
+	}
+}
+
+// closestKey returns the known key within a small edit distance of key, if any
+func (l *loader) closestKey(key string) string {
+	const maxDistance = 2
+	best, bestDistance := "", maxDistance+1
+	for known := range l.used {
+		d := editDistance(key, known)
+		if d < bestDistance || (d == bestDistance && known < best) {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
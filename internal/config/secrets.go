@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/secrets"
+)
+
+// secretKeys are the settings that may hold a secret manager reference (aws-sm://,
+// aws-kms:// or gcp-sm://) instead of the value itself
+var secretKeys = map[string]bool{
+	"ALFRED_ENCRYPTION_KEY":    true,
+	"ANTHROPIC_API_KEY":        true,
+	"OPENAI_API_KEY":           true,
+	"ALFRED_TELEGRAM_API_ID":   true,
+	"ALFRED_TELEGRAM_API_HASH": true,
+}
+
+// secretResolver fetches the value a secret reference points to
+type secretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// resolveSecret replaces a secret reference read for key with the secret it points to
+func (l *loader) resolveSecret(key, ref, source string) string {
+	if !secretKeys[key] {
+		names := make([]string, 0, len(secretKeys))
+		for name := range secretKeys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		l.problems = append(l.problems, fmt.Sprintf("%s: secret references are only supported for %s",
+			source, strings.Join(names, ", ")))
+		return ""
+	}
+
+	if l.secrets == nil {
+		// The AWS credentials and region are ordinary settings, read on first use
+		l.secrets = secrets.NewResolver(secrets.AWSConfig{
+			Region:                          l.string("AWS_REGION", "us-east-1"),
+			AccessKeyID:                     l.string("AWS_ACCESS_KEY_ID", ""),
+			SecretAccessKey:                 l.string("AWS_SECRET_ACCESS_KEY", ""),
+			SessionToken:                    l.string("AWS_SESSION_TOKEN", ""),
+			ContainerCredentialsRelativeURI: l.string("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", ""),
+			ContainerCredentialsFullURI:     l.string("AWS_CONTAINER_CREDENTIALS_FULL_URI", ""),
+			ContainerAuthorizationToken:     l.string("AWS_CONTAINER_AUTHORIZATION_TOKEN", ""),
+			ContainerAuthorizationTokenFile: l.string("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", ""),
+			DisableIMDS:                     l.bool("AWS_EC2_METADATA_DISABLED", false),
+		})
+	}
+	value, err := l.secrets.Resolve(context.Background(), ref)
+	if err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("%s: failed to fetch %s: %v", source, ref, err))
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
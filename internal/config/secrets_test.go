@@ -0,0 +1,65 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if value, ok := f[ref]; ok {
+		return value, nil
+	}
+	return "", errors.New("secret not found")
+}
+
+func TestSecretReferences(t *testing.T) {
+	newLoader := func(file map[string]string) *loader {
+		return &loader{
+			fileName: "alfred.yaml",
+			file:     file,
+			used:     make(map[string]bool),
+			listKeys: make(map[string]bool),
+			secrets: fakeResolver{
+				"aws-sm://alfred/prod#encryption_key":        "resolved-encryption-key",
+				"aws-kms://Y2lwaGVydGV4dA==":                 "sk-ant-resolved\n",
+				"gcp-sm://projects/alfred/secrets/tg-api-id": "12345",
+			},
+		}
+	}
+
+	t.Run("references are replaced by their secrets", func(t *testing.T) {
+		t.Setenv("ALFRED_ENCRYPTION_KEY", "aws-sm://alfred/prod#encryption_key")
+		t.Setenv("ANTHROPIC_API_KEY", "aws-kms://Y2lwaGVydGV4dA==")
+		l := newLoader(map[string]string{"telegram_api_id": "gcp-sm://projects/alfred/secrets/tg-api-id"})
+
+		cfg := l.load()
+		require.Empty(t, l.problems)
+		assert.Equal(t, "resolved-encryption-key", cfg.EncryptionKey)
+		assert.Equal(t, "sk-ant-resolved", cfg.AnthropicAPIKey)
+		assert.Equal(t, 12345, cfg.TelegramAPIID)
+	})
+
+	t.Run("failed fetches are reported", func(t *testing.T) {
+		t.Setenv("ALFRED_TELEGRAM_API_HASH", "aws-sm://alfred/missing")
+		l := newLoader(nil)
+
+		cfg := l.load()
+		assert.Empty(t, cfg.TelegramAPIHash)
+		require.Len(t, l.problems, 1)
+		assert.Contains(t, l.problems[0], "ALFRED_TELEGRAM_API_HASH: failed to fetch aws-sm://alfred/missing")
+	})
+
+	t.Run("references are only accepted for secret settings", func(t *testing.T) {
+		l := newLoader(map[string]string{"db_path": "aws-sm://alfred/db"})
+
+		l.load()
+		require.Len(t, l.problems, 1)
+		assert.Contains(t, l.problems[0], "alfred.yaml: db_path: secret references are only supported for")
+	})
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/logging"
+)
+
+// validate checks that settings are within their allowed ranges. Problems name the
+// environment variable and the config file key.
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(key, format string, args ...any) {
+		problems = append(problems, fmt.Sprintf("%s (%s): ", key, FileKey(key))+fmt.Sprintf(format, args...))
+	}
+
+	if c.LLMProvider != "anthropic" && c.LLMProvider != "openai" {
+		add("ALFRED_LLM_PROVIDER", "unknown provider %q (use anthropic or openai)", c.LLMProvider)
+	}
+	if c.ShadowEnabled {
+		if c.ShadowProvider != "anthropic" && c.ShadowProvider != "openai" {
+			add("ALFRED_SHADOW_PROVIDER", "unknown provider %q (use anthropic or openai)", c.ShadowProvider)
+		}
+		if c.ShadowSampleRate <= 0 || c.ShadowSampleRate > 1 {
+			add("ALFRED_SHADOW_SAMPLE_RATE", "must be greater than 0 and at most 1, got %g", c.ShadowSampleRate)
+		}
+	}
+
+	switch c.Geocoder {
+	case "", "nominatim":
+	case "google":
+		if c.GoogleMapsAPIKey == "" {
+			add("GOOGLE_MAPS_API_KEY", "required when ALFRED_GEOCODER is google")
+		}
+	default:
+		add("ALFRED_GEOCODER", "unknown geocoder %q (use nominatim or google)", c.Geocoder)
+	}
+	if c.HTTPPort < 1 || c.HTTPPort > 65535 {
+		add("ALFRED_HTTP_PORT", "must be between 1 and 65535, got %d", c.HTTPPort)
+	}
+	if c.SMTPHost != "" {
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			add("ALFRED_SMTP_PORT", "must be between 1 and 65535, got %d", c.SMTPPort)
+		}
+		switch c.SMTPSecurity {
+		case "starttls", "tls", "none":
+		default:
+			add("ALFRED_SMTP_SECURITY", "unknown mode %q (use starttls, tls or none)", c.SMTPSecurity)
+		}
+	}
+
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		add("ALFRED_LOG_LEVEL", "unknown level %q (use debug, info, warn or error)", c.LogLevel)
+	}
+	if format := strings.ToLower(strings.TrimSpace(c.LogFormat)); format != logging.FormatText && format != logging.FormatJSON {
+		add("ALFRED_LOG_FORMAT", "unknown format %q (use text or json)", c.LogFormat)
+	}
+
+	for _, setting := range []struct {
+		key   string
+		value int
+	}{
+		{"ALFRED_MESSAGE_HISTORY_SIZE", c.MessageHistorySize},
+		{"ALFRED_MESSAGE_PRUNE_INTERVAL", c.MessagePruneInterval},
+		{"ALFRED_GMAIL_POLL_INTERVAL", c.GmailPollInterval},
+		{"ALFRED_GMAIL_MAX_EMAILS", c.GmailMaxEmails},
+		{"ALFRED_GCAL_POLL_INTERVAL", c.GCalPollInterval},
+		{"ALFRED_BACKUP_INTERVAL", c.BackupInterval},
+		{"ALFRED_BACKUP_KEEP", c.BackupKeep},
+		{"ALFRED_BATCH_MAX_WAIT_SECONDS", c.BatchMaxWaitSeconds},
+		{"ALFRED_BATCH_MAX_MESSAGES", c.BatchMaxMessages},
+		{"ALFRED_PROCESSOR_WORKERS", c.ProcessorWorkers},
+		{"ALFRED_EMBEDDING_INDEX_INTERVAL", c.EmbeddingIndexInterval},
+		{"ALFRED_GEOCODE_INTERVAL", c.GeocodeInterval},
+		{"ALFRED_ANTHROPIC_TIMEOUT_SECONDS", c.AnthropicTimeoutSeconds},
+		{"ALFRED_ANTHROPIC_BREAKER_COOLDOWN_SECONDS", c.AnthropicBreakerCooldownSeconds},
+	} {
+		if setting.value < 1 {
+			add(setting.key, "must be at least 1, got %d", setting.value)
+		}
+	}
+	for _, setting := range []struct {
+		key   string
+		value int
+	}{
+		{"ALFRED_MESSAGE_RETENTION_DAYS", c.MessageRetentionDays},
+		{"ALFRED_NOTIFY_BATCH_WINDOW_SECONDS", c.NotifyBatchWindowSeconds},
+		{"ALFRED_BATCH_WINDOW_SECONDS", c.BatchWindowSeconds},
+		{"ALFRED_ANTHROPIC_RPM", c.AnthropicRateLimit},
+		{"ALFRED_OPENAI_RPM", c.OpenAIRateLimit},
+		{"ALFRED_ANTHROPIC_MAX_RETRIES", c.AnthropicMaxRetries},
+		{"ALFRED_ANTHROPIC_RETRY_BASE_MS", c.AnthropicRetryBaseMS},
+		{"ALFRED_ANTHROPIC_RETRY_MAX_MS", c.AnthropicRetryMaxMS},
+		{"ALFRED_ANTHROPIC_BREAKER_THRESHOLD", c.AnthropicBreakerThreshold},
+		{"ALFRED_RAG_RELATED_MESSAGES", c.RAGRelatedMessages},
+		{"ALFRED_PREFILTER_MIN_CHARS", c.PrefilterMinChars},
+	} {
+		if setting.value < 0 {
+			add(setting.key, "must not be negative, got %d", setting.value)
+		}
+	}
+	if c.LLMMonthlyBudget < 0 {
+		add("ALFRED_LLM_MONTHLY_BUDGET_USD", "must not be negative, got %g", c.LLMMonthlyBudget)
+	}
+
+	for _, setting := range []struct{ key, pattern string }{
+		{"ALFRED_PREFILTER_SKIP_PATTERN", c.PrefilterSkipPattern},
+		{"ALFRED_PREFILTER_KEEP_PATTERN", c.PrefilterKeepPattern},
+	} {
+		if setting.pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(setting.pattern); err != nil {
+			add(setting.key, "invalid regular expression: %v", err)
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// accountDeleteSteps removes what deleteUserData leaves behind when the user itself is
+// deleted, in dependency-safe order. Each query receives the user ID exactly once.
+var accountDeleteSteps = []struct {
+	name  string
+	query string
+}{
+	{
+		name:  "event start notifications",
+		query: `DELETE FROM event_start_notifications WHERE event_id IN (SELECT id FROM calendar_events WHERE user_id = ?)`,
+	},
+	{
+		name:  "reminder lead notifications",
+		query: `DELETE FROM reminder_lead_notifications WHERE reminder_id IN (SELECT id FROM reminders WHERE user_id = ?)`,
+	},
+	{name: "analysis traces", query: `DELETE FROM analysis_traces WHERE user_id = ?`},
+	{name: "shadow analyses", query: `DELETE FROM shadow_analyses WHERE user_id = ?`},
+	{name: "llm usage", query: `DELETE FROM llm_usage WHERE user_id = ?`},
+	{name: "notification queue", query: `DELETE FROM notification_queue WHERE user_id = ?`},
+	{name: "pending analysis", query: `DELETE FROM pending_analysis WHERE user_id = ?`},
+	{name: "failed analyses", query: `DELETE FROM failed_analyses WHERE user_id = ?`},
+	{
+		name: "webhook delivery attempts",
+		query: `DELETE FROM webhook_delivery_attempts WHERE delivery_id IN (
+			SELECT d.id FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id WHERE w.user_id = ?
+		)`,
+	},
+	{
+		name:  "webhook deliveries",
+		query: `DELETE FROM webhook_deliveries WHERE webhook_id IN (SELECT id FROM webhooks WHERE user_id = ?)`,
+	},
+}
+
+// SetAccountDeletionToken stores the hash of a new account deletion confirmation token,
+// replacing any earlier one
+func (d *DB) SetAccountDeletionToken(userID int64, tokenHash string, expiresAt time.Time) error {
+	_, err := d.Exec(`
+		INSERT INTO account_deletion_tokens (user_id, token_hash, expires_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			expires_at = excluded.expires_at,
+			created_at = CURRENT_TIMESTAMP
+	`, userID, tokenHash, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to store account deletion token: %w", err)
+	}
+	return nil
+}
+
+// ConsumeAccountDeletionToken reports whether tokenHash is the user's unexpired
+// confirmation token, invalidating it so it can only be used once
+func (d *DB) ConsumeAccountDeletionToken(userID int64, tokenHash string, now time.Time) (bool, error) {
+	result, err := d.Exec(`
+		DELETE FROM account_deletion_tokens
+		WHERE user_id = ? AND token_hash = ? AND expires_at > ?
+	`, userID, tokenHash, now.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to consume account deletion token: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume account deletion token: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeleteUserAccount permanently deletes a user and every row scoped to them
+func (d *DB) DeleteUserAccount(userID int64) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin account deletion transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, step := range accountDeleteSteps {
+		if _, err := tx.Exec(step.query, userID); err != nil {
+			return fmt.Errorf("failed to delete %s during account deletion: %w", step.name, err)
+		}
+	}
+
+	if err := deleteUserData(tx, userID, "account deletion"); err != nil {
+		return err
+	}
+
+	finalSteps := []struct {
+		name  string
+		query string
+	}{
+		{name: "feature settings", query: `DELETE FROM feature_settings WHERE user_id = ?`},
+		{name: "account deletion tokens", query: `DELETE FROM account_deletion_tokens WHERE user_id = ?`},
+		{name: "calendar feeds", query: `DELETE FROM calendar_feeds WHERE user_id = ?`},
+		{name: "user", query: `DELETE FROM users WHERE id = ?`},
+	}
+	for _, step := range finalSteps {
+		if _, err := tx.Exec(step.query, userID); err != nil {
+			return fmt.Errorf("failed to delete %s during account deletion: %w", step.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountDeletionTokens(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	now := time.Now()
+
+	require.NoError(t, db.SetAccountDeletionToken(user.ID, "first", now.Add(10*time.Minute)))
+	require.NoError(t, db.SetAccountDeletionToken(user.ID, "second", now.Add(10*time.Minute)))
+
+	ok, err := db.ConsumeAccountDeletionToken(user.ID, "first", now)
+	require.NoError(t, err)
+	assert.False(t, ok, "a newer token replaces the old one")
+
+	ok, err = db.ConsumeAccountDeletionToken(user.ID, "second", now.Add(11*time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok, "expired tokens are rejected")
+
+	ok, err = db.ConsumeAccountDeletionToken(user.ID, "second", now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = db.ConsumeAccountDeletionToken(user.ID, "second", now)
+	require.NoError(t, err)
+	assert.False(t, ok, "tokens can only be used once")
+}
+
+func TestDeleteUserAccount(t *testing.T) {
+	db := NewTestDB(t)
+	owner := CreateTestUser(t, db)
+	member := CreateTestUserWithEmail(t, db, "member@example.com")
+
+	channel, err := db.CreateSourceChannel(owner.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "family@s.whatsapp.net", "Family")
+	require.NoError(t, err)
+	message, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "family@s.whatsapp.net", "Mom", "Dinner Friday?", "", time.Now())
+	require.NoError(t, err)
+
+	event, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:        owner.ID,
+		ChannelID:     channel.ID,
+		CalendarID:    "primary",
+		Title:         "Family dinner",
+		StartTime:     time.Now().Add(72 * time.Hour),
+		ActionType:    EventActionCreate,
+		OriginalMsgID: &message.ID,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdatePushToken(owner.ID, "ExponentPushToken[owner]"))
+	_, err = db.CreateWebhook(owner.ID, "https://example.com/hook", "whsec_test", []string{"event.created"})
+	require.NoError(t, err)
+	_, err = db.CreateDataExport(owner.ID)
+	require.NoError(t, err)
+
+	// The member holds a copy of the owner's event, which references the owner's message
+	share, err := db.CreateChannelShare(owner.ID, channel.ID, member.Email)
+	require.NoError(t, err)
+	_, err = db.RespondToChannelShare(share.ID, member.ID, true)
+	require.NoError(t, err)
+	copies, err := db.ShareEventWithMembers(event.ID)
+	require.NoError(t, err)
+	require.Len(t, copies, 1)
+
+	memberChannel, err := db.CreateSourceChannel(member.ID, source.SourceTypeTelegram, source.ChannelTypeSender, "123", "Work")
+	require.NoError(t, err)
+
+	require.NoError(t, db.DeleteUserAccount(owner.ID))
+
+	var count int
+	for _, table := range []string{"channels", "message_history", "calendar_events", "webhooks", "devices", "data_exports", "user_notification_preferences"} {
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE user_id = ?`, owner.ID).Scan(&count))
+		assert.Zero(t, count, table)
+	}
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM users WHERE id = ?`, owner.ID).Scan(&count))
+	assert.Zero(t, count)
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM channel_shares`).Scan(&count))
+	assert.Zero(t, count)
+
+	// The member's own data is untouched; copies from the deleted channel are gone
+	kept, err := db.GetSourceChannelByID(member.ID, memberChannel.ID)
+	require.NoError(t, err)
+	require.NotNil(t, kept)
+	_, err = db.GetEventByID(copies[0].ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// AdminStats summarizes activity across all users since a point in time, for operators
+// of a hosted instance
+type AdminStats struct {
+	Since          time.Time            `json:"since"`
+	Users          AdminUserStats       `json:"users"`
+	MessagesPerDay []AdminDailyMessages `json:"messages_per_day"`
+	Events         AdminItemStats       `json:"events"`
+	Reminders      AdminItemStats       `json:"reminders"`
+	Agent          AdminAgentStats      `json:"agent"`
+	Queues         AdminQueueStats      `json:"queues"`
+}
+
+// AdminUserStats counts users overall, new sign-ups, and users with analyzed messages
+type AdminUserStats struct {
+	Total  int `json:"total"`
+	New    int `json:"new"`
+	Active int `json:"active"`
+}
+
+// AdminDailyMessages counts the chat and email messages that reached analysis on one UTC
+// day. Prefiltered messages were skipped before the agent ran.
+type AdminDailyMessages struct {
+	Date        string `json:"date"`
+	Processed   int    `json:"processed"`
+	Prefiltered int    `json:"prefiltered"`
+}
+
+// AdminItemStats counts detected events or reminders by their current status. Confirmed
+// includes items accepted and later synced, completed, dismissed or deleted.
+// ConfirmRate is confirmed / (confirmed + rejected), 0 when nothing was reviewed.
+type AdminItemStats struct {
+	Created     int     `json:"created"`
+	Pending     int     `json:"pending"`
+	Confirmed   int     `json:"confirmed"`
+	Rejected    int     `json:"rejected"`
+	ConfirmRate float64 `json:"confirm_rate"`
+}
+
+// AdminAgentStats counts agent calls and failures. Errors are analyses that failed and
+// were queued for retry; ErrorRate is errors / (analyses + errors).
+type AdminAgentStats struct {
+	Analyses           int     `json:"analyses"`
+	Errors             int     `json:"errors"`
+	ErrorRate          float64 `json:"error_rate"`
+	ValidationFailures int     `json:"validation_failures"`
+	CostUSD            float64 `json:"cost_usd"`
+}
+
+// AdminQueueStats reports the current depth of each background queue
+type AdminQueueStats struct {
+	PendingAnalysis   int `json:"pending_analysis"`
+	FailedAnalyses    int `json:"failed_analyses"`
+	DeadAnalyses      int `json:"dead_analyses"`
+	Notifications     int `json:"notifications"`
+	WebhookDeliveries int `json:"webhook_deliveries"`
+}
+
+// GetAdminStats collects instance-wide statistics for [since, now). Queue depths are
+// current regardless of since.
+func (d *DB) GetAdminStats(since, now time.Time) (*AdminStats, error) {
+	from := since.UTC().Format(llmUsageTimeFormat)
+	stats := &AdminStats{Since: since}
+
+	err := d.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN created_at >= ? THEN 1 ELSE 0 END), 0) FROM users
+	`, from).Scan(&stats.Users.Total, &stats.Users.New)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+	err = d.QueryRow(`
+		SELECT COUNT(DISTINCT user_id) FROM analysis_traces WHERE created_at >= ?
+	`, from).Scan(&stats.Users.Active)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active users: %w", err)
+	}
+
+	messages, err := d.adminDailyMessages(since, now)
+	if err != nil {
+		return nil, err
+	}
+	stats.MessagesPerDay = messages
+
+	// Imported Google Calendar events and copies shared from another user's channel
+	// weren't detected, so they are left out
+	err = d.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN e.status = 'pending' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN e.status IN ('confirmed', 'synced', 'deleted') THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN e.status = 'rejected' THEN 1 ELSE 0 END), 0)
+		FROM calendar_events e
+		LEFT JOIN channels c ON c.id = e.channel_id
+		WHERE e.created_at >= ? AND e.shared_from_event_id IS NULL
+			AND COALESCE(c.identifier, '') != ?
+	`, from, googleCalendarImportChannelID).Scan(
+		&stats.Events.Created, &stats.Events.Pending, &stats.Events.Confirmed, &stats.Events.Rejected,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	stats.Events.ConfirmRate = confirmRate(stats.Events)
+
+	err = d.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status IN ('confirmed', 'synced', 'completed', 'dismissed') THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status = 'rejected' THEN 1 ELSE 0 END), 0)
+		FROM reminders WHERE created_at >= ?
+	`, from).Scan(
+		&stats.Reminders.Created, &stats.Reminders.Pending, &stats.Reminders.Confirmed, &stats.Reminders.Rejected,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reminders: %w", err)
+	}
+	stats.Reminders.ConfirmRate = confirmRate(stats.Reminders)
+
+	err = d.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM llm_usage WHERE created_at >= ?),
+			(SELECT COALESCE(SUM(cost_usd), 0) FROM llm_usage WHERE created_at >= ?),
+			(SELECT COUNT(*) FROM failed_analyses WHERE created_at >= ?),
+			(SELECT COUNT(*) FROM analysis_traces WHERE created_at >= ? AND status = 'validation_failed')
+	`, from, from, from, from).Scan(
+		&stats.Agent.Analyses, &stats.Agent.CostUSD, &stats.Agent.Errors, &stats.Agent.ValidationFailures,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count agent analyses: %w", err)
+	}
+	if total := stats.Agent.Analyses + stats.Agent.Errors; total > 0 {
+		stats.Agent.ErrorRate = float64(stats.Agent.Errors) / float64(total)
+	}
+
+	err = d.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM pending_analysis),
+			(SELECT COUNT(*) FROM failed_analyses WHERE status = ?),
+			(SELECT COUNT(*) FROM failed_analyses WHERE status = ?),
+			(SELECT COUNT(*) FROM notification_queue WHERE status = ?),
+			(SELECT COUNT(*) FROM webhook_deliveries WHERE status = ?)
+	`, FailedAnalysisPending, FailedAnalysisDead, NotificationPending, WebhookDeliveryPending).Scan(
+		&stats.Queues.PendingAnalysis, &stats.Queues.FailedAnalyses, &stats.Queues.DeadAnalyses,
+		&stats.Queues.Notifications, &stats.Queues.WebhookDeliveries,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count queue depths: %w", err)
+	}
+
+	return stats, nil
+}
+
+// adminDailyMessages returns one entry per UTC day from since to now, including days
+// without messages. Each analysis writes one routed or prefiltered trace.
+func (d *DB) adminDailyMessages(since, now time.Time) ([]AdminDailyMessages, error) {
+	rows, err := d.Query(`
+		SELECT date(created_at) AS day, COUNT(*),
+			COALESCE(SUM(CASE WHEN status = 'prefiltered' THEN 1 ELSE 0 END), 0)
+		FROM analysis_traces
+		WHERE created_at >= ? AND status IN ('routed', 'prefiltered')
+		GROUP BY day
+	`, since.UTC().Format(llmUsageTimeFormat))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages per day: %w", err)
+	}
+	defer rows.Close()
+
+	byDay := make(map[string]AdminDailyMessages)
+	for rows.Next() {
+		var day AdminDailyMessages
+		if err := rows.Scan(&day.Date, &day.Processed, &day.Prefiltered); err != nil {
+			return nil, fmt.Errorf("failed to scan messages per day: %w", err)
+		}
+		byDay[day.Date] = day
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages per day: %w", err)
+	}
+
+	days := []AdminDailyMessages{}
+	last := now.UTC().Format("2006-01-02")
+	for day := since.UTC(); ; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		entry, ok := byDay[date]
+		if !ok {
+			entry = AdminDailyMessages{Date: date}
+		}
+		days = append(days, entry)
+		if date >= last {
+			break
+		}
+	}
+	return days, nil
+}
+
+func confirmRate(stats AdminItemStats) float64 {
+	reviewed := stats.Confirmed + stats.Rejected
+	if reviewed == 0 {
+		return 0
+	}
+	return float64(stats.Confirmed) / float64(reviewed)
+}
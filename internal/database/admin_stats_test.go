@@ -0,0 +1,78 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAdminStats(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	CreateTestUserWithEmail(t, db, "idle@example.com")
+	channel := createTestChannel(t, db, user.ID)
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+
+	for _, status := range []string{"routed", "routed", "prefiltered", "validation_failed"} {
+		require.NoError(t, db.CreateAnalysisTrace(AnalysisTrace{
+			UserID: user.ID, ChannelID: channel.ID, SourceType: "whatsapp", Intent: "event", Status: status,
+		}))
+	}
+
+	for _, status := range []EventStatus{EventStatusPending, EventStatusSynced, EventStatusConfirmed, EventStatusRejected} {
+		event, err := db.CreatePendingEvent(&CalendarEvent{
+			UserID: user.ID, ChannelID: channel.ID, CalendarID: "primary", Title: "Event",
+			StartTime: now.Add(time.Hour), ActionType: EventActionCreate,
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateEventStatus(event.ID, status))
+	}
+
+	reminder, err := db.CreatePendingReminder(&Reminder{
+		UserID: user.ID, ChannelID: channel.ID, CalendarID: "primary", Title: "Reminder",
+		ActionType: ReminderActionCreate, Priority: ReminderPriorityNormal,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateReminderStatus(reminder.ID, ReminderStatusCompleted))
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, db.RecordLLMUsage(LLMUsage{UserID: user.ID, Agent: "event", Model: "m", CostUSD: 0.01}))
+	}
+	_, err = db.CreateFailedAnalysis(FailedAnalysis{
+		UserID: user.ID, ChannelID: channel.ID, SourceType: "whatsapp", Intent: "event",
+		Input: "{}", LastError: "overloaded",
+	}, now.Add(time.Minute))
+	require.NoError(t, err)
+
+	stats, err := db.GetAdminStats(since, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, AdminUserStats{Total: 2, New: 2, Active: 1}, stats.Users)
+
+	require.Len(t, stats.MessagesPerDay, 2, "days without messages are included")
+	assert.Equal(t, AdminDailyMessages{Date: since.Format("2006-01-02")}, stats.MessagesPerDay[0])
+	assert.Equal(t, 3, stats.MessagesPerDay[1].Processed)
+	assert.Equal(t, 1, stats.MessagesPerDay[1].Prefiltered)
+
+	assert.Equal(t, 4, stats.Events.Created)
+	assert.Equal(t, 1, stats.Events.Pending)
+	assert.Equal(t, 2, stats.Events.Confirmed)
+	assert.Equal(t, 1, stats.Events.Rejected)
+	assert.InDelta(t, 2.0/3.0, stats.Events.ConfirmRate, 0.0001)
+
+	assert.Equal(t, 1, stats.Reminders.Confirmed)
+	assert.InDelta(t, 1.0, stats.Reminders.ConfirmRate, 0.0001)
+
+	assert.Equal(t, 3, stats.Agent.Analyses)
+	assert.Equal(t, 1, stats.Agent.Errors)
+	assert.InDelta(t, 0.25, stats.Agent.ErrorRate, 0.0001)
+	assert.Equal(t, 1, stats.Agent.ValidationFailures)
+	assert.InDelta(t, 0.03, stats.Agent.CostUSD, 0.0001)
+
+	assert.Equal(t, 1, stats.Queues.FailedAnalyses)
+	assert.Equal(t, 0, stats.Queues.DeadAnalyses)
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AdminUser summarizes one account for instance operators
+type AdminUser struct {
+	ID                 int64      `json:"id"`
+	Email              string     `json:"email"`
+	Name               string     `json:"name"`
+	CreatedAt          time.Time  `json:"created_at"`
+	LastLoginAt        *time.Time `json:"last_login_at,omitempty"`
+	OnboardingComplete bool       `json:"onboarding_complete"`
+	Sessions           int        `json:"sessions"`
+	APIKeys            int        `json:"api_keys"`
+	Channels           int        `json:"channels"`
+}
+
+// ListAdminUsers returns every user with their onboarding state, sessions still valid
+// at now, API keys, and enabled WhatsApp/Telegram channels
+func (d *DB) ListAdminUsers(now time.Time) ([]AdminUser, error) {
+	rows, err := d.Query(`
+		SELECT u.id, u.email, COALESCE(u.name, ''), u.created_at, u.last_login_at,
+			COALESCE(fs.onboarding_complete, 0),
+			(SELECT COUNT(*) FROM user_sessions s WHERE s.user_id = u.id AND s.expires_at > ?),
+			(SELECT COUNT(*) FROM api_keys k WHERE k.user_id = u.id),
+			(SELECT COUNT(*) FROM channels c WHERE c.user_id = u.id AND c.enabled = 1
+				AND c.source_type IN ('whatsapp', 'telegram'))
+		FROM users u
+		LEFT JOIN feature_settings fs ON fs.user_id = u.id
+		ORDER BY u.id
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []AdminUser{}
+	for rows.Next() {
+		var u AdminUser
+		var lastLogin sql.NullTime
+		if err := rows.Scan(
+			&u.ID, &u.Email, &u.Name, &u.CreatedAt, &lastLogin,
+			&u.OnboardingComplete, &u.Sessions, &u.APIKeys, &u.Channels,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		if lastLogin.Valid {
+			u.LastLoginAt = &lastLogin.Time
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+	return users, nil
+}
+
+// GetUserIDByEmail returns the ID of the user with the given email, ignoring case
+func (d *DB) GetUserIDByEmail(email string) (int64, error) {
+	var id int64
+	err := d.QueryRow(`SELECT id FROM users WHERE LOWER(email) = ?`, strings.ToLower(email)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user by email: %w", err)
+	}
+	return id, nil
+}
+
+// RevokeUserTokens deletes every session and API key of the user, signing them out
+// everywhere. Returns how many of each were revoked.
+func (d *DB) RevokeUserTokens(userID int64) (sessions, apiKeys int64, err error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin revoke tokens transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM user_sessions WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if sessions, err = result.RowsAffected(); err != nil {
+		return 0, 0, fmt.Errorf("failed to count revoked sessions: %w", err)
+	}
+
+	result, err = tx.Exec(`DELETE FROM api_keys WHERE user_id = ?`, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to revoke api keys: %w", err)
+	}
+	if apiKeys, err = result.RowsAffected(); err != nil {
+		return 0, 0, fmt.Errorf("failed to count revoked api keys: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit revoke tokens: %w", err)
+	}
+	return sessions, apiKeys, nil
+}
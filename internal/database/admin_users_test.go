@@ -0,0 +1,85 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addTestSession(t *testing.T, db *DB, userID int64, hash string, expiresAt time.Time) {
+	_, err := db.Exec(`INSERT INTO user_sessions (user_id, token_hash, expires_at) VALUES (?, ?, ?)`,
+		userID, hash, expiresAt)
+	require.NoError(t, err)
+}
+
+func addTestAPIKey(t *testing.T, db *DB, userID int64, hash string) {
+	_, err := db.Exec(`INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scope) VALUES (?, 'cli', 'alf_', ?, 'read')`,
+		userID, hash)
+	require.NoError(t, err)
+}
+
+func TestListAdminUsers(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUserWithEmail(t, db, "other@example.com")
+	createTestChannel(t, db, user.ID)
+	require.NoError(t, db.CompleteOnboarding(user.ID, true, false, false))
+
+	now := time.Now()
+	addTestSession(t, db, user.ID, "live", now.Add(time.Hour))
+	addTestSession(t, db, user.ID, "expired", now.Add(-time.Hour))
+	addTestAPIKey(t, db, user.ID, "key")
+
+	users, err := db.ListAdminUsers(now)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	assert.Equal(t, user.ID, users[0].ID)
+	assert.True(t, users[0].OnboardingComplete)
+	assert.Equal(t, 1, users[0].Sessions, "expired sessions are not counted")
+	assert.Equal(t, 1, users[0].APIKeys)
+	assert.Equal(t, 1, users[0].Channels)
+
+	assert.Equal(t, other.ID, users[1].ID)
+	assert.Equal(t, "other@example.com", users[1].Email)
+	assert.False(t, users[1].OnboardingComplete)
+	assert.Zero(t, users[1].Sessions)
+}
+
+func TestGetUserIDByEmail(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUserWithEmail(t, db, "Someone@Example.com")
+
+	id, err := db.GetUserIDByEmail("someone@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, id)
+
+	_, err = db.GetUserIDByEmail("nobody@example.com")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUserWithEmail(t, db, "other@example.com")
+
+	now := time.Now()
+	addTestSession(t, db, user.ID, "a", now.Add(time.Hour))
+	addTestSession(t, db, user.ID, "b", now.Add(time.Hour))
+	addTestAPIKey(t, db, user.ID, "key")
+	addTestSession(t, db, other.ID, "c", now.Add(time.Hour))
+
+	sessions, apiKeys, err := db.RevokeUserTokens(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), sessions)
+	assert.Equal(t, int64(1), apiKeys)
+
+	users, err := db.ListAdminUsers(now)
+	require.NoError(t, err)
+	assert.Zero(t, users[0].Sessions)
+	assert.Zero(t, users[0].APIKeys)
+	assert.Equal(t, 1, users[1].Sessions, "other users keep their sessions")
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// AnalysisToolCall is one tool the model called during an agent run
+type AnalysisToolCall struct {
+	Name   string         `json:"name"`
+	Input  map[string]any `json:"input"`
+	Output string         `json:"output,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// AnalysisLogEntry is one agent run on a message: the intent module it ran for, a hash
+// of its input, the tools the model called, the module's decision and the run's cost
+type AnalysisLogEntry struct {
+	ID           int64              `json:"id"`
+	UserID       int64              `json:"user_id"`
+	MessageID    int64              `json:"message_id"`
+	ChannelID    int64              `json:"channel_id"`
+	Intent       string             `json:"intent"`
+	Agent        string             `json:"agent"`
+	Model        string             `json:"model"`
+	InputHash    string             `json:"input_hash"`
+	ToolCalls    []AnalysisToolCall `json:"tool_calls"`
+	Action       string             `json:"action,omitempty"`
+	Confidence   float64            `json:"confidence"`
+	Reasoning    string             `json:"reasoning,omitempty"`
+	LatencyMS    int64              `json:"latency_ms"`
+	InputTokens  int                `json:"input_tokens"`
+	OutputTokens int                `json:"output_tokens"`
+	Error        string             `json:"error,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// CreateAnalysisLogEntry stores one agent run on a message
+func (d *DB) CreateAnalysisLogEntry(entry AnalysisLogEntry) error {
+	toolCalls := entry.ToolCalls
+	if toolCalls == nil {
+		toolCalls = []AnalysisToolCall{}
+	}
+	toolCallsJSON, err := json.Marshal(toolCalls)
+	if err != nil {
+		return fmt.Errorf("failed to encode tool calls: %w", err)
+	}
+
+	_, err = d.Exec(`
+		INSERT INTO analysis_log (
+			user_id, message_id, channel_id, intent, agent, model, input_hash, tool_calls,
+			action, confidence, reasoning, latency_ms, input_tokens, output_tokens, error
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.UserID, entry.MessageID, entry.ChannelID, entry.Intent, entry.Agent, entry.Model,
+		entry.InputHash, string(toolCallsJSON), entry.Action, entry.Confidence, entry.Reasoning,
+		entry.LatencyMS, entry.InputTokens, entry.OutputTokens, entry.Error)
+	if err != nil {
+		return fmt.Errorf("failed to create analysis log entry: %w", err)
+	}
+	return nil
+}
+
+// ListAnalysisLog returns the agent runs on one of the user's messages, oldest first
+func (d *DB) ListAnalysisLog(userID, messageID int64) ([]AnalysisLogEntry, error) {
+	rows, err := d.Query(`
+		SELECT id, user_id, message_id, channel_id, intent, agent, model, input_hash, tool_calls,
+			action, confidence, reasoning, latency_ms, input_tokens, output_tokens, error, created_at
+		FROM analysis_log
+		WHERE user_id = ? AND message_id = ?
+		ORDER BY id
+	`, userID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list analysis log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []AnalysisLogEntry{}
+	for rows.Next() {
+		var e AnalysisLogEntry
+		var toolCalls string
+		if err := rows.Scan(&e.ID, &e.UserID, &e.MessageID, &e.ChannelID, &e.Intent, &e.Agent, &e.Model,
+			&e.InputHash, &toolCalls, &e.Action, &e.Confidence, &e.Reasoning, &e.LatencyMS,
+			&e.InputTokens, &e.OutputTokens, &e.Error, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan analysis log entry: %w", err)
+		}
+		if err := json.Unmarshal([]byte(toolCalls), &e.ToolCalls); err != nil {
+			return nil, fmt.Errorf("failed to decode tool calls: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating analysis log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalysisLog(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUserWithEmail(t, db, "other@example.com")
+	channel := createTestChannelForMessages(t, db, user.ID)
+	msg, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "sender", "Dana", "Dinner Friday at 8?", "", time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, db.CreateAnalysisLogEntry(AnalysisLogEntry{
+		UserID: user.ID, MessageID: msg.ID, ChannelID: channel.ID,
+		Intent: "router", Agent: "intent-router", Model: "claude-haiku-4", InputHash: "abc",
+		LatencyMS: 120,
+	}))
+	require.NoError(t, db.CreateAnalysisLogEntry(AnalysisLogEntry{
+		UserID: user.ID, MessageID: msg.ID, ChannelID: channel.ID,
+		Intent: "event", Agent: "event-scheduler", Model: "claude-sonnet-4", InputHash: "def",
+		ToolCalls: []AnalysisToolCall{{
+			Name:  "create_calendar_event",
+			Input: map[string]any{"title": "Dinner"},
+		}},
+		Action: "create", Confidence: 0.9, Reasoning: "Dana proposed dinner",
+		LatencyMS: 900, InputTokens: 1200, OutputTokens: 80,
+	}))
+
+	entries, err := db.ListAnalysisLog(user.ID, msg.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "router", entries[0].Intent)
+	assert.Empty(t, entries[0].ToolCalls)
+	assert.Equal(t, "event", entries[1].Intent)
+	require.Len(t, entries[1].ToolCalls, 1)
+	assert.Equal(t, "create_calendar_event", entries[1].ToolCalls[0].Name)
+	assert.Equal(t, "Dinner", entries[1].ToolCalls[0].Input["title"])
+	assert.Equal(t, 0.9, entries[1].Confidence)
+	assert.Equal(t, 1200, entries[1].InputTokens)
+
+	t.Run("other users see nothing", func(t *testing.T) {
+		entries, err := db.ListAnalysisLog(other.ID, msg.ID)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("entries go with their message", func(t *testing.T) {
+		require.NoError(t, db.PruneSourceMessages(user.ID, source.SourceTypeWhatsApp, channel.ID, 0))
+		entries, err := db.ListAnalysisLog(user.ID, msg.ID)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
@@ -1,6 +1,10 @@
 package database
 
-import "fmt"
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
 
 // BackfillStatus represents the state of initial source backfill.
 type BackfillStatus string
@@ -72,3 +76,85 @@ func (d *DB) UpdateEmailSourceInitialBackfillStatus(userID, sourceID int64, stat
 	}
 	return nil
 }
+
+// BackfillProgress is how far a channel's initial backfill has got
+type BackfillProgress struct {
+	Status      BackfillStatus `json:"status"`
+	Days        int            `json:"days"`      // Lookback window in days
+	Processed   int            `json:"processed"` // Messages analyzed so far
+	Total       int            `json:"total"`     // Messages in the lookback window
+	Percent     int            `json:"percent"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+}
+
+// ProgressPercent returns processed as a whole percentage of total, or 0 before the
+// total is known
+func ProgressPercent(processed, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	if processed >= total {
+		return 100
+	}
+	return processed * 100 / total
+}
+
+// StartChannelBackfillProgress records a channel backfill's lookback window and message
+// count and resets its processed count
+func (d *DB) StartChannelBackfillProgress(userID, channelID int64, days, total int) error {
+	_, err := d.Exec(`
+		UPDATE channels
+		SET initial_backfill_days = ?, initial_backfill_total = ?, initial_backfill_processed = 0
+		WHERE id = ? AND user_id = ?
+	`, days, total, channelID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to start channel backfill progress: %w", err)
+	}
+	return nil
+}
+
+// UpdateChannelBackfillProgress records how many messages a channel backfill has analyzed
+func (d *DB) UpdateChannelBackfillProgress(userID, channelID int64, processed int) error {
+	_, err := d.Exec(`
+		UPDATE channels SET initial_backfill_processed = ? WHERE id = ? AND user_id = ?
+	`, processed, channelID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update channel backfill progress: %w", err)
+	}
+	return nil
+}
+
+// GetChannelBackfillProgress returns the user's channel's backfill progress, or nil if
+// the channel doesn't exist or has never been backfilled
+func (d *DB) GetChannelBackfillProgress(userID, channelID int64) (*BackfillProgress, error) {
+	var status sql.NullString
+	var days sql.NullInt64
+	var completedAt sql.NullTime
+	var progress BackfillProgress
+	err := d.QueryRow(`
+		SELECT initial_backfill_status, initial_backfill_days, initial_backfill_total,
+			initial_backfill_processed, initial_backfill_at
+		FROM channels WHERE id = ? AND user_id = ?
+	`, channelID, userID).Scan(&status, &days, &progress.Total, &progress.Processed, &completedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel backfill progress: %w", err)
+	}
+	if !status.Valid || status.String == "" {
+		return nil, nil
+	}
+
+	progress.Status = BackfillStatus(status.String)
+	progress.Days = int(days.Int64)
+	if completedAt.Valid {
+		progress.CompletedAt = &completedAt.Time
+	}
+	if progress.Status == BackfillStatusCompleted || progress.Status == BackfillStatusSkipped {
+		progress.Percent = 100
+	} else {
+		progress.Percent = ProgressPercent(progress.Processed, progress.Total)
+	}
+	return &progress, nil
+}
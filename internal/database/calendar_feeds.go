@@ -0,0 +1,127 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// CalendarFeed is a user's subscribable iCalendar feed. The token itself is only
+// shown when it is created.
+type CalendarFeed struct {
+	UserID         int64      `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+}
+
+// SetCalendarFeedToken stores the hash of a new feed token for the user, replacing any
+// earlier one so its URL stops working
+func (d *DB) SetCalendarFeedToken(userID int64, tokenHash string) error {
+	_, err := d.Exec(`
+		INSERT INTO calendar_feeds (user_id, token_hash)
+		VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			created_at = CURRENT_TIMESTAMP,
+			last_accessed_at = NULL
+	`, userID, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to store calendar feed token: %w", err)
+	}
+	return nil
+}
+
+// GetCalendarFeed returns the user's feed, or nil if they have none
+func (d *DB) GetCalendarFeed(userID int64) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	var lastAccessed sql.NullTime
+	err := d.QueryRow(`
+		SELECT user_id, created_at, last_accessed_at FROM calendar_feeds WHERE user_id = ?
+	`, userID).Scan(&feed.UserID, &feed.CreatedAt, &lastAccessed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	if lastAccessed.Valid {
+		feed.LastAccessedAt = &lastAccessed.Time
+	}
+	return &feed, nil
+}
+
+// UseCalendarFeedToken returns the ID of the user whose feed token hashes to tokenHash
+// and records the access, or 0 if no feed has that token
+func (d *DB) UseCalendarFeedToken(tokenHash string) (int64, error) {
+	var userID int64
+	err := d.QueryRow(`
+		UPDATE calendar_feeds SET last_accessed_at = ?
+		WHERE token_hash = ?
+		RETURNING user_id
+	`, time.Now().UTC(), tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up calendar feed token: %w", err)
+	}
+	return userID, nil
+}
+
+// DeleteCalendarFeed turns off the user's feed. Returns false if they had none.
+func (d *DB) DeleteCalendarFeed(userID int64) (bool, error) {
+	result, err := d.Exec(`DELETE FROM calendar_feeds WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListFeedEvents returns up to limit confirmed and synced events for the user's
+// calendar feed, by start time: those ending at or after since, and every recurring one
+func (d *DB) ListFeedEvents(userID int64, since time.Time, limit int) ([]CalendarEvent, error) {
+	rows, err := d.Query(`
+		SELECT e.id, e.user_id, e.channel_id, e.title, e.description, e.start_time, e.end_time,
+			e.location, e.status, COALESCE(e.recurrence, ''), e.created_at, e.updated_at,
+			e.latitude, e.longitude, COALESCE(e.maps_url, ''), COALESCE(c.name, 'Alfred')
+		FROM calendar_events e
+		LEFT JOIN channels c ON e.channel_id = c.id
+		WHERE e.user_id = ? AND e.status IN (?, ?)
+		  AND (COALESCE(e.end_time, e.start_time) >= ? OR COALESCE(e.recurrence, '') != '')
+		ORDER BY e.start_time ASC, e.id ASC
+		LIMIT ?
+	`, userID, EventStatusConfirmed, EventStatusSynced, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feed events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []CalendarEvent
+	for rows.Next() {
+		var event CalendarEvent
+		var endTimeNull sql.NullTime
+		var latitude, longitude sql.NullFloat64
+		if err := rows.Scan(
+			&event.ID, &event.UserID, &event.ChannelID, &event.Title, &event.Description, &event.StartTime, &endTimeNull,
+			&event.Location, &event.Status, &event.Recurrence, &event.CreatedAt, &event.UpdatedAt,
+			&latitude, &longitude, &event.MapsURL, &event.ChannelName,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if endTimeNull.Valid {
+			event.EndTime = &endTimeNull.Time
+		}
+		if latitude.Valid && longitude.Valid {
+			event.Latitude, event.Longitude = &latitude.Float64, &longitude.Float64
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+	return events, nil
+}
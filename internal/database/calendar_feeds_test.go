@@ -0,0 +1,85 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeeds(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	t.Run("token lifecycle", func(t *testing.T) {
+		feed, err := db.GetCalendarFeed(user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, feed)
+
+		require.NoError(t, db.SetCalendarFeedToken(user.ID, "hash-1"))
+		userID, err := db.UseCalendarFeedToken("hash-1")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+
+		feed, err = db.GetCalendarFeed(user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, feed)
+		assert.NotNil(t, feed.LastAccessedAt)
+
+		require.NoError(t, db.SetCalendarFeedToken(user.ID, "hash-2"))
+		userID, err = db.UseCalendarFeedToken("hash-1")
+		require.NoError(t, err)
+		assert.Zero(t, userID, "a rotated token stops working")
+
+		deleted, err := db.DeleteCalendarFeed(user.ID)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+		userID, err = db.UseCalendarFeedToken("hash-2")
+		require.NoError(t, err)
+		assert.Zero(t, userID)
+
+		deleted, err = db.DeleteCalendarFeed(user.ID)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+
+	t.Run("feed events", func(t *testing.T) {
+		now := time.Now()
+		create := func(title string, start time.Time, status EventStatus, recurrence string) {
+			event, err := db.CreatePendingEvent(&CalendarEvent{
+				UserID:     user.ID,
+				ChannelID:  channel.ID,
+				CalendarID: "primary",
+				Title:      title,
+				StartTime:  start,
+				ActionType: EventActionCreate,
+				Recurrence: recurrence,
+			})
+			require.NoError(t, err)
+			if status != EventStatusPending {
+				require.NoError(t, db.UpdateEventStatus(event.ID, status))
+			}
+		}
+		create("Dinner", now.Add(48*time.Hour), EventStatusSynced, "")
+		create("Standup", now.Add(24*time.Hour), EventStatusConfirmed, "")
+		create("Awaiting review", now.Add(24*time.Hour), EventStatusPending, "")
+		create("Rejected", now.Add(24*time.Hour), EventStatusRejected, "")
+		create("Last year", now.AddDate(-1, 0, 0), EventStatusConfirmed, "")
+		create("Dana's birthday", now.AddDate(-2, 0, 0), EventStatusConfirmed, "RRULE:FREQ=YEARLY")
+
+		events, err := db.ListFeedEvents(user.ID, now.Add(-30*24*time.Hour), 10)
+		require.NoError(t, err)
+		var titles []string
+		for _, e := range events {
+			titles = append(titles, e.Title)
+		}
+		assert.Equal(t, []string{"Dana's birthday", "Standup", "Dinner"}, titles)
+		assert.Equal(t, "RRULE:FREQ=YEARLY", events[0].Recurrence)
+
+		events, err = db.ListFeedEvents(user.ID, now.Add(-30*24*time.Hour), 1)
+		require.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+}
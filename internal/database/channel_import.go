@@ -0,0 +1,117 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// ChannelImport is one contact channel to create or enable with ImportSourceChannels
+type ChannelImport struct {
+	SourceType source.SourceType
+	Identifier string
+	// AltIdentifiers also match an existing channel, such as the JID form of a WhatsApp number
+	AltIdentifiers []string
+	// Name renames a channel being created or re-enabled; empty keeps the existing name
+	Name string
+}
+
+// ChannelImportStatus is what ImportSourceChannels did with one entry
+type ChannelImportStatus string
+
+const (
+	ChannelImportCreated   ChannelImportStatus = "created"
+	ChannelImportEnabled   ChannelImportStatus = "enabled"   // Existed but was disabled
+	ChannelImportUnchanged ChannelImportStatus = "unchanged" // Already tracked, or repeated in the import
+)
+
+// ChannelImportResult is the channel an import entry resolved to
+type ChannelImportResult struct {
+	Status  ChannelImportStatus `json:"status"`
+	Channel *SourceChannel      `json:"channel"`
+}
+
+// ImportSourceChannels creates or enables a contact channel for every entry in one
+// transaction, so either all of them are tracked or none are. Results are in entry order.
+func (d *DB) ImportSourceChannels(userID int64, imports []ChannelImport) ([]ChannelImportResult, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin channel import: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]int64, len(imports))
+	statuses := make([]ChannelImportStatus, len(imports))
+	for i, entry := range imports {
+		ids[i], statuses[i], err = importSourceChannel(tx, userID, entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit channel import: %w", err)
+	}
+
+	results := make([]ChannelImportResult, len(imports))
+	for i, id := range ids {
+		channel, err := d.GetSourceChannelByID(userID, id)
+		if err != nil {
+			return nil, err
+		}
+		if statuses[i] == ChannelImportCreated {
+			// Best effort: SyncContactsFromHistory links any channel missed here
+			_ = d.linkChannelContact(channel)
+		}
+		results[i] = ChannelImportResult{Status: statuses[i], Channel: channel}
+	}
+	return results, nil
+}
+
+func importSourceChannel(tx *sql.Tx, userID int64, entry ChannelImport) (int64, ChannelImportStatus, error) {
+	identifiers := append([]string{entry.Identifier}, entry.AltIdentifiers...)
+	args := []any{userID, entry.SourceType}
+	for _, identifier := range identifiers {
+		args = append(args, identifier)
+	}
+
+	var id int64
+	var enabled bool
+	err := tx.QueryRow(`
+		SELECT id, enabled FROM channels
+		WHERE user_id = ? AND source_type = ? AND identifier IN (?`+strings.Repeat(", ?", len(identifiers)-1)+`)
+		ORDER BY id LIMIT 1
+	`, args...).Scan(&id, &enabled)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result, err := tx.Exec(
+			`INSERT INTO channels (user_id, source_type, type, identifier, name) VALUES (?, ?, ?, ?, ?)`,
+			userID, entry.SourceType, source.ChannelTypeSender, entry.Identifier, entry.Name,
+		)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to create source channel: %w", err)
+		}
+		id, err = result.LastInsertId()
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to get last insert id: %w", err)
+		}
+		return id, ChannelImportCreated, nil
+	case err != nil:
+		return 0, "", fmt.Errorf("failed to look up source channel: %w", err)
+	case enabled:
+		return id, ChannelImportUnchanged, nil
+	}
+
+	_, err = tx.Exec(
+		`UPDATE channels SET enabled = 1, name = COALESCE(NULLIF(?, ''), name) WHERE id = ? AND user_id = ?`,
+		entry.Name, id, userID,
+	)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to enable source channel: %w", err)
+	}
+	return id, ChannelImportEnabled, nil
+}
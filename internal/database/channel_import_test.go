@@ -0,0 +1,53 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportSourceChannels(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+
+	tracked, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "15550001111", "Alice")
+	require.NoError(t, err)
+	disabled, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "15550002222@s.whatsapp.net", "Bob")
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateSourceChannel(user.ID, disabled.ID, disabled.Name, false))
+	_, err = db.CreateSourceChannel(other.ID, source.SourceTypeTelegram, source.ChannelTypeSender, "42", "Other's Carol")
+	require.NoError(t, err)
+
+	results, err := db.ImportSourceChannels(user.ID, []ChannelImport{
+		{SourceType: source.SourceTypeWhatsApp, Identifier: "15550001111", Name: "Alice Renamed"},
+		{SourceType: source.SourceTypeWhatsApp, Identifier: "15550002222", AltIdentifiers: []string{"15550002222@s.whatsapp.net"}, Name: "Bobby"},
+		{SourceType: source.SourceTypeTelegram, Identifier: "42", Name: "Carol"},
+		{SourceType: source.SourceTypeTelegram, Identifier: "42", Name: "Carol again"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, ChannelImportUnchanged, results[0].Status)
+	assert.Equal(t, tracked.ID, results[0].Channel.ID)
+	assert.Equal(t, "Alice", results[0].Channel.Name)
+
+	assert.Equal(t, ChannelImportEnabled, results[1].Status)
+	assert.Equal(t, disabled.ID, results[1].Channel.ID)
+	assert.True(t, results[1].Channel.Enabled)
+	assert.Equal(t, "Bobby", results[1].Channel.Name)
+
+	assert.Equal(t, ChannelImportCreated, results[2].Status)
+	assert.Equal(t, user.ID, results[2].Channel.UserID)
+	assert.Equal(t, source.ChannelTypeSender, results[2].Channel.Type)
+	assert.True(t, results[2].Channel.Enabled)
+
+	assert.Equal(t, ChannelImportUnchanged, results[3].Status)
+	assert.Equal(t, results[2].Channel.ID, results[3].Channel.ID)
+
+	channels, err := db.ListSourceChannels(user.ID, source.SourceTypeTelegram)
+	require.NoError(t, err)
+	assert.Len(t, channels, 1)
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrChannelLabelNotFound = errors.New("label not found")
+	ErrChannelLabelExists   = errors.New("a label with this name already exists")
+)
+
+// ChannelLabel is a user's tag for grouping channels ("Family", "Work"). Events detected
+// in a labeled channel go to the label's CalendarID when it's set.
+type ChannelLabel struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Name       string    `json:"name"`
+	CalendarID string    `json:"calendar_id,omitempty"`
+	ChannelIDs []int64   `json:"channel_ids"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateChannelLabel creates a label for the user
+func (d *DB) CreateChannelLabel(userID int64, name, calendarID string) (*ChannelLabel, error) {
+	name = strings.TrimSpace(name)
+	if exists, err := d.channelLabelNameTaken(userID, name, 0); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, ErrChannelLabelExists
+	}
+
+	result, err := d.Exec(`
+		INSERT INTO channel_labels (user_id, name, calendar_id) VALUES (?, ?, NULLIF(?, ''))
+	`, userID, name, strings.TrimSpace(calendarID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create label: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get label id: %w", err)
+	}
+	return d.GetChannelLabel(userID, id)
+}
+
+// UpdateChannelLabel renames a label and sets its calendar ("" routes its events to the
+// user's selected calendar again)
+func (d *DB) UpdateChannelLabel(userID, id int64, name, calendarID string) (*ChannelLabel, error) {
+	name = strings.TrimSpace(name)
+	if exists, err := d.channelLabelNameTaken(userID, name, id); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, ErrChannelLabelExists
+	}
+
+	result, err := d.Exec(`
+		UPDATE channel_labels SET name = ?, calendar_id = NULLIF(?, '')
+		WHERE id = ? AND user_id = ?
+	`, name, strings.TrimSpace(calendarID), id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update label: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update label: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, ErrChannelLabelNotFound
+	}
+	return d.GetChannelLabel(userID, id)
+}
+
+// channelLabelNameTaken reports whether the user has a label other than excludeID with
+// name (case-insensitive)
+func (d *DB) channelLabelNameTaken(userID int64, name string, excludeID int64) (bool, error) {
+	var exists bool
+	err := d.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM channel_labels WHERE user_id = ? AND LOWER(name) = LOWER(?) AND id != ?)
+	`, userID, name, excludeID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check label name: %w", err)
+	}
+	return exists, nil
+}
+
+// DeleteChannelLabel deletes a label and removes it from its channels
+func (d *DB) DeleteChannelLabel(userID, id int64) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin label transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM channel_labels WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrChannelLabelNotFound
+	}
+	if _, err := tx.Exec(`DELETE FROM channel_label_assignments WHERE label_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete label assignments: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit label deletion: %w", err)
+	}
+	return nil
+}
+
+// GetChannelLabel returns one of the user's labels
+func (d *DB) GetChannelLabel(userID, id int64) (*ChannelLabel, error) {
+	labels, err := d.queryChannelLabels(`WHERE l.user_id = ? AND l.id = ?`, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(labels) == 0 {
+		return nil, ErrChannelLabelNotFound
+	}
+	return labels[0], nil
+}
+
+// ListChannelLabels returns the user's labels ordered by name
+func (d *DB) ListChannelLabels(userID int64) ([]*ChannelLabel, error) {
+	return d.queryChannelLabels(`WHERE l.user_id = ?`, userID)
+}
+
+// ListLabelsForChannel returns the labels on one of the user's channels
+func (d *DB) ListLabelsForChannel(userID, channelID int64) ([]*ChannelLabel, error) {
+	return d.queryChannelLabels(`
+		WHERE l.user_id = ? AND l.id IN (SELECT label_id FROM channel_label_assignments WHERE channel_id = ?)
+	`, userID, channelID)
+}
+
+// queryChannelLabels loads the labels matching where (over channel_labels l) with their
+// channel IDs
+func (d *DB) queryChannelLabels(where string, args ...any) ([]*ChannelLabel, error) {
+	rows, err := d.Query(`
+		SELECT l.id, l.user_id, l.name, COALESCE(l.calendar_id, ''), l.created_at,
+			COALESCE((SELECT GROUP_CONCAT(a.channel_id) FROM channel_label_assignments a WHERE a.label_id = l.id), '')
+		FROM channel_labels l `+where+`
+		ORDER BY LOWER(l.name)
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	defer rows.Close()
+
+	labels := []*ChannelLabel{}
+	for rows.Next() {
+		label := &ChannelLabel{ChannelIDs: []int64{}}
+		var channelIDs string
+		if err := rows.Scan(&label.ID, &label.UserID, &label.Name, &label.CalendarID, &label.CreatedAt, &channelIDs); err != nil {
+			return nil, fmt.Errorf("failed to scan label: %w", err)
+		}
+		for _, raw := range strings.Split(channelIDs, ",") {
+			if channelID, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				label.ChannelIDs = append(label.ChannelIDs, channelID)
+			}
+		}
+		labels = append(labels, label)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating labels: %w", err)
+	}
+	return labels, nil
+}
+
+// SetChannelLabels replaces the labels on one of the user's channels. Every label must
+// belong to the user.
+func (d *DB) SetChannelLabels(userID, channelID int64, labelIDs []int64) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin label transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, labelID := range labelIDs {
+		var owned bool
+		err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM channel_labels WHERE id = ? AND user_id = ?)`, labelID, userID).Scan(&owned)
+		if err != nil {
+			return fmt.Errorf("failed to check label: %w", err)
+		}
+		if !owned {
+			return ErrChannelLabelNotFound
+		}
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM channel_label_assignments
+		WHERE channel_id = ? AND label_id IN (SELECT id FROM channel_labels WHERE user_id = ?)
+	`, channelID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to clear channel labels: %w", err)
+	}
+	for _, labelID := range labelIDs {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO channel_label_assignments (label_id, channel_id) VALUES (?, ?)
+		`, labelID, channelID)
+		if err != nil {
+			return fmt.Errorf("failed to add channel label: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit channel labels: %w", err)
+	}
+	return nil
+}
+
+// GetChannelLabelCalendarID returns the calendar events from a channel should go to
+// according to its labels, or "" if none of them has a calendar. When several do, the
+// first label by name wins.
+func (d *DB) GetChannelLabelCalendarID(userID, channelID int64) (string, error) {
+	var calendarID string
+	err := d.QueryRow(`
+		SELECT l.calendar_id
+		FROM channel_labels l
+		JOIN channel_label_assignments a ON a.label_id = l.id
+		WHERE l.user_id = ? AND a.channel_id = ? AND l.calendar_id IS NOT NULL
+		ORDER BY LOWER(l.name)
+		LIMIT 1
+	`, userID, channelID).Scan(&calendarID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get label calendar: %w", err)
+	}
+	return calendarID, nil
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelLabels(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+
+	family, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "family@s.whatsapp.net", "Family")
+	require.NoError(t, err)
+	work, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "work@s.whatsapp.net", "Work")
+	require.NoError(t, err)
+
+	familyLabel, err := db.CreateChannelLabel(user.ID, " Family ", "family-calendar")
+	require.NoError(t, err)
+	assert.Equal(t, "Family", familyLabel.Name)
+	assert.Equal(t, "family-calendar", familyLabel.CalendarID)
+	assert.Empty(t, familyLabel.ChannelIDs)
+
+	workLabel, err := db.CreateChannelLabel(user.ID, "Work", "")
+	require.NoError(t, err)
+	assert.Empty(t, workLabel.CalendarID)
+
+	otherLabel, err := db.CreateChannelLabel(other.ID, "Family", "")
+	require.NoError(t, err, "label names are unique per user")
+
+	t.Run("duplicate names are rejected", func(t *testing.T) {
+		_, err := db.CreateChannelLabel(user.ID, "family", "")
+		assert.ErrorIs(t, err, ErrChannelLabelExists)
+
+		_, err = db.UpdateChannelLabel(user.ID, workLabel.ID, "FAMILY", "")
+		assert.ErrorIs(t, err, ErrChannelLabelExists)
+	})
+
+	t.Run("labels must belong to the user", func(t *testing.T) {
+		err := db.SetChannelLabels(user.ID, family.ID, []int64{otherLabel.ID})
+		assert.ErrorIs(t, err, ErrChannelLabelNotFound)
+
+		_, err = db.UpdateChannelLabel(other.ID, familyLabel.ID, "Mine", "")
+		assert.ErrorIs(t, err, ErrChannelLabelNotFound)
+	})
+
+	require.NoError(t, db.SetChannelLabels(user.ID, family.ID, []int64{familyLabel.ID, workLabel.ID}))
+	require.NoError(t, db.SetChannelLabels(user.ID, work.ID, []int64{workLabel.ID}))
+
+	t.Run("channel labels", func(t *testing.T) {
+		labels, err := db.ListLabelsForChannel(user.ID, family.ID)
+		require.NoError(t, err)
+		require.Len(t, labels, 2)
+		assert.Equal(t, "Family", labels[0].Name)
+		assert.Equal(t, "Work", labels[1].Name)
+		assert.ElementsMatch(t, []int64{family.ID, work.ID}, labels[1].ChannelIDs)
+
+		// Setting labels replaces the previous ones
+		require.NoError(t, db.SetChannelLabels(user.ID, work.ID, []int64{workLabel.ID}))
+		labels, err = db.ListLabelsForChannel(user.ID, work.ID)
+		require.NoError(t, err)
+		require.Len(t, labels, 1)
+	})
+
+	t.Run("label calendar", func(t *testing.T) {
+		calendarID, err := db.GetChannelLabelCalendarID(user.ID, family.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "family-calendar", calendarID)
+
+		calendarID, err = db.GetChannelLabelCalendarID(user.ID, work.ID)
+		require.NoError(t, err)
+		assert.Empty(t, calendarID)
+	})
+
+	t.Run("filter events and reminders by label", func(t *testing.T) {
+		start := time.Now().Add(24 * time.Hour)
+		for _, channel := range []*SourceChannel{family, work} {
+			_, err := db.CreatePendingEvent(&CalendarEvent{
+				UserID:     user.ID,
+				ChannelID:  channel.ID,
+				CalendarID: "primary",
+				Title:      channel.Name + " event",
+				StartTime:  start,
+				ActionType: EventActionCreate,
+			})
+			require.NoError(t, err)
+			_, err = db.CreatePendingReminder(&Reminder{
+				UserID:     user.ID,
+				ChannelID:  channel.ID,
+				CalendarID: "primary",
+				Title:      channel.Name + " reminder",
+				DueDate:    &start,
+				ActionType: ReminderActionCreate,
+				Priority:   ReminderPriorityNormal,
+			})
+			require.NoError(t, err)
+		}
+
+		events, _, err := db.ListEventsWithOptions(user.ID, nil, nil, ListOptions{Label: familyLabel.ID})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "Family event", events[0].Title)
+
+		events, _, err = db.ListEventsWithOptions(user.ID, nil, nil, ListOptions{Label: workLabel.ID})
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+
+		reminders, _, err := db.ListRemindersWithOptions(user.ID, nil, nil, ListOptions{Label: familyLabel.ID})
+		require.NoError(t, err)
+		require.Len(t, reminders, 1)
+		assert.Equal(t, "Family reminder", reminders[0].Title)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, db.DeleteChannelLabel(user.ID, familyLabel.ID))
+		assert.ErrorIs(t, db.DeleteChannelLabel(user.ID, familyLabel.ID), ErrChannelLabelNotFound)
+
+		labels, err := db.ListLabelsForChannel(user.ID, family.ID)
+		require.NoError(t, err)
+		require.Len(t, labels, 1)
+		assert.Equal(t, workLabel.ID, labels[0].ID)
+
+		calendarID, err := db.GetChannelLabelCalendarID(user.ID, family.ID)
+		require.NoError(t, err)
+		assert.Empty(t, calendarID)
+	})
+}
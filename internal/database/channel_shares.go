@@ -0,0 +1,340 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// ChannelShareStatus represents the state of a channel share invitation
+type ChannelShareStatus string
+
+const (
+	ChannelSharePending  ChannelShareStatus = "pending"
+	ChannelShareAccepted ChannelShareStatus = "accepted"
+	ChannelShareDeclined ChannelShareStatus = "declined"
+)
+
+// ChannelShare grants another user (the member) access to events detected in a channel
+type ChannelShare struct {
+	ID           int64              `json:"id"`
+	ChannelID    int64              `json:"channel_id"`
+	OwnerUserID  int64              `json:"owner_user_id"`
+	InviteeEmail string             `json:"invitee_email"`
+	MemberUserID *int64             `json:"member_user_id,omitempty"`
+	Status       ChannelShareStatus `json:"status"`
+	CreatedAt    time.Time          `json:"created_at"`
+	RespondedAt  *time.Time         `json:"responded_at,omitempty"`
+	ChannelName  string             `json:"channel_name"`          // Joined from channels table
+	SourceType   source.SourceType  `json:"source_type"`           // Joined from channels.source_type
+	OwnerEmail   string             `json:"owner_email,omitempty"` // Joined from users table
+}
+
+const channelShareColumns = `
+	s.id, s.channel_id, s.owner_user_id, s.invitee_email, s.member_user_id, s.status,
+	s.created_at, s.responded_at, c.name, c.source_type, u.email
+`
+
+const channelShareJoins = `
+	FROM channel_shares s
+	JOIN channels c ON s.channel_id = c.id
+	JOIN users u ON s.owner_user_id = u.id
+`
+
+func scanChannelShare(scanner interface{ Scan(...any) error }) (*ChannelShare, error) {
+	var share ChannelShare
+	var memberUserID sql.NullInt64
+	var respondedAt sql.NullTime
+	err := scanner.Scan(
+		&share.ID, &share.ChannelID, &share.OwnerUserID, &share.InviteeEmail, &memberUserID, &share.Status,
+		&share.CreatedAt, &respondedAt, &share.ChannelName, &share.SourceType, &share.OwnerEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if memberUserID.Valid {
+		share.MemberUserID = &memberUserID.Int64
+	}
+	if respondedAt.Valid {
+		share.RespondedAt = &respondedAt.Time
+	}
+	return &share, nil
+}
+
+func (d *DB) queryChannelShares(where string, args ...any) ([]ChannelShare, error) {
+	rows, err := d.Query(`SELECT `+channelShareColumns+channelShareJoins+where+` ORDER BY s.created_at DESC, s.id DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channel shares: %w", err)
+	}
+	defer rows.Close()
+
+	shares := []ChannelShare{}
+	for rows.Next() {
+		share, err := scanChannelShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel share: %w", err)
+		}
+		shares = append(shares, *share)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating channel shares: %w", err)
+	}
+
+	return shares, nil
+}
+
+// normalizeShareEmail lowercases and trims an invitee email so invitations match user accounts
+func normalizeShareEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// CreateChannelShare invites email to a channel owned by ownerUserID. Inviting an address
+// that previously declined re-opens the invitation; an existing pending or accepted share
+// is returned unchanged.
+func (d *DB) CreateChannelShare(ownerUserID, channelID int64, email string) (*ChannelShare, error) {
+	email = normalizeShareEmail(email)
+
+	_, err := d.Exec(`
+		INSERT INTO channel_shares (channel_id, owner_user_id, invitee_email)
+		VALUES (?, ?, ?)
+		ON CONFLICT(channel_id, invitee_email) DO UPDATE SET
+			status = 'pending', member_user_id = NULL, responded_at = NULL, created_at = CURRENT_TIMESTAMP
+		WHERE channel_shares.status = 'declined'
+	`, channelID, ownerUserID, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create channel share: %w", err)
+	}
+
+	share, err := scanChannelShare(d.QueryRow(`SELECT `+channelShareColumns+channelShareJoins+`
+		WHERE s.channel_id = ? AND s.invitee_email = ?`, channelID, email))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel share: %w", err)
+	}
+	return share, nil
+}
+
+// GetChannelShareByID retrieves a channel share by its ID
+func (d *DB) GetChannelShareByID(id int64) (*ChannelShare, error) {
+	share, err := scanChannelShare(d.QueryRow(`SELECT `+channelShareColumns+channelShareJoins+` WHERE s.id = ?`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel share: %w", err)
+	}
+	return share, nil
+}
+
+// ListChannelShares returns all shares (any status) of a channel
+func (d *DB) ListChannelShares(channelID int64) ([]ChannelShare, error) {
+	return d.queryChannelShares(` WHERE s.channel_id = ?`, channelID)
+}
+
+// ListChannelShareInvitations returns pending invitations addressed to an email
+func (d *DB) ListChannelShareInvitations(email string) ([]ChannelShare, error) {
+	return d.queryChannelShares(` WHERE s.invitee_email = ? AND s.status = 'pending'`, normalizeShareEmail(email))
+}
+
+// ListSharedChannels returns the accepted shares where the user is the member
+func (d *DB) ListSharedChannels(memberUserID int64) ([]ChannelShare, error) {
+	return d.queryChannelShares(` WHERE s.member_user_id = ? AND s.status = 'accepted'`, memberUserID)
+}
+
+// RespondToChannelShare accepts or declines a pending invitation on behalf of memberUserID.
+// Returns false if the share is no longer pending.
+func (d *DB) RespondToChannelShare(id, memberUserID int64, accept bool) (bool, error) {
+	status := ChannelShareDeclined
+	if accept {
+		status = ChannelShareAccepted
+	}
+
+	result, err := d.Exec(`
+		UPDATE channel_shares
+		SET status = ?, member_user_id = ?, responded_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = 'pending'
+	`, status, memberUserID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to respond to channel share: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check channel share response: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// DeleteChannelShare removes a share. The member's shared copies that are still pending
+// are removed with it; copies they already confirmed stay in their calendar.
+func (d *DB) DeleteChannelShare(id int64) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin channel share transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var channelID int64
+	var memberUserID sql.NullInt64
+	err = tx.QueryRow(`DELETE FROM channel_shares WHERE id = ? RETURNING channel_id, member_user_id`, id).Scan(&channelID, &memberUserID)
+	if err != nil {
+		return fmt.Errorf("failed to delete channel share: %w", err)
+	}
+
+	if memberUserID.Valid {
+		_, err = tx.Exec(`
+			DELETE FROM calendar_events
+			WHERE user_id = ? AND channel_id = ? AND status = 'pending' AND shared_from_event_id IS NOT NULL
+		`, memberUserID.Int64, channelID)
+		if err != nil {
+			return fmt.Errorf("failed to remove shared events: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit channel share deletion: %w", err)
+	}
+	return nil
+}
+
+// HasChannelAccess reports whether a user owns a channel or is an accepted member of it
+func (d *DB) HasChannelAccess(userID, channelID int64) (bool, error) {
+	var exists bool
+	err := d.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM channels WHERE id = ? AND user_id = ?)
+			OR EXISTS(SELECT 1 FROM channel_shares WHERE channel_id = ? AND member_user_id = ? AND status = 'accepted')
+	`, channelID, userID, channelID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check channel access: %w", err)
+	}
+	return exists, nil
+}
+
+// IsChannelShared reports whether a channel has at least one accepted member
+func (d *DB) IsChannelShared(channelID int64) (bool, error) {
+	var shared bool
+	err := d.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM channel_shares WHERE channel_id = ? AND status = 'accepted')
+	`, channelID).Scan(&shared)
+	if err != nil {
+		return false, fmt.Errorf("failed to check channel shares: %w", err)
+	}
+	return shared, nil
+}
+
+// ShareEventWithMembers marks an owner's event as shared and copies it as a pending event
+// to every accepted member of its channel that doesn't have a copy yet. Each copy targets
+// the member's selected calendar. Returns the new copies.
+func (d *DB) ShareEventWithMembers(eventID int64) ([]*CalendarEvent, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin share transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE calendar_events SET shared = 1 WHERE id = ?`, eventID); err != nil {
+		return nil, fmt.Errorf("failed to mark event shared: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT s.member_user_id
+		FROM calendar_events e
+		JOIN channel_shares s ON s.channel_id = e.channel_id AND s.status = 'accepted'
+		WHERE e.id = ? AND s.member_user_id != e.user_id
+			AND NOT EXISTS (
+				SELECT 1 FROM calendar_events c
+				WHERE c.shared_from_event_id = e.id AND c.user_id = s.member_user_id
+			)
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find channel members: %w", err)
+	}
+	var memberIDs []int64
+	for rows.Next() {
+		var memberID int64
+		if err := rows.Scan(&memberID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan channel member: %w", err)
+		}
+		memberIDs = append(memberIDs, memberID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating channel members: %w", err)
+	}
+
+	var copyIDs []int64
+	for _, memberID := range memberIDs {
+		result, err := tx.Exec(`
+			INSERT INTO calendar_events (
+				user_id, channel_id, calendar_id, title, description,
+				start_time, end_time, location, status, action_type,
+				original_message_id, llm_reasoning, llm_confidence, quality_flags,
+				source, email_source_id, shared_from_event_id, recurrence
+			)
+			SELECT ?, channel_id,
+				COALESCE((SELECT NULLIF(selected_calendar_id, '') FROM gcal_settings WHERE user_id = ?), 'primary'),
+				title, description, start_time, end_time, location, 'pending', action_type,
+				original_message_id, llm_reasoning, llm_confidence, quality_flags,
+				source, email_source_id, id, recurrence
+			FROM calendar_events WHERE id = ?
+		`, memberID, memberID, eventID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to share event: %w", err)
+		}
+		copyID, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get shared event id: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO event_attendees (event_id, email, display_name, optional)
+			SELECT ?, email, display_name, optional FROM event_attendees WHERE event_id = ?
+		`, copyID, eventID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to share event attendees: %w", err)
+		}
+		copyIDs = append(copyIDs, copyID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit event share: %w", err)
+	}
+
+	copies := make([]*CalendarEvent, 0, len(copyIDs))
+	for _, id := range copyIDs {
+		copied, err := d.GetEventByID(id)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, copied)
+	}
+	return copies, nil
+}
+
+// UnshareEvent hides an owner's event from channel members, removing copies they haven't
+// acted on yet. Returns the number of copies removed.
+func (d *DB) UnshareEvent(eventID int64) (int, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin unshare transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE calendar_events SET shared = 0 WHERE id = ?`, eventID); err != nil {
+		return 0, fmt.Errorf("failed to mark event private: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM calendar_events WHERE shared_from_event_id = ? AND status = 'pending'`, eventID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove shared events: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count removed shared events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit event unshare: %w", err)
+	}
+	return int(removed), nil
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelShares(t *testing.T) {
+	db := NewTestDB(t)
+	owner := CreateTestUser(t, db)
+	member := CreateTestUserWithEmail(t, db, "partner@example.com")
+	outsider := CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(owner.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "family@s.whatsapp.net", "Family")
+	require.NoError(t, err)
+
+	share, err := db.CreateChannelShare(owner.ID, channel.ID, " Partner@Example.com ")
+	require.NoError(t, err)
+
+	t.Run("invitation is addressed to the normalized email", func(t *testing.T) {
+		assert.Equal(t, "partner@example.com", share.InviteeEmail)
+		assert.Equal(t, ChannelSharePending, share.Status)
+		assert.Equal(t, "Family", share.ChannelName)
+		assert.Nil(t, share.MemberUserID)
+
+		invitations, err := db.ListChannelShareInvitations("PARTNER@example.com")
+		require.NoError(t, err)
+		require.Len(t, invitations, 1)
+		assert.Equal(t, share.ID, invitations[0].ID)
+
+		hasAccess, err := db.HasChannelAccess(member.ID, channel.ID)
+		require.NoError(t, err)
+		assert.False(t, hasAccess, "pending invitations don't grant access")
+	})
+
+	t.Run("declined invitations can be re-sent", func(t *testing.T) {
+		responded, err := db.RespondToChannelShare(share.ID, member.ID, false)
+		require.NoError(t, err)
+		assert.True(t, responded)
+
+		reinvited, err := db.CreateChannelShare(owner.ID, channel.ID, "partner@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, share.ID, reinvited.ID)
+		assert.Equal(t, ChannelSharePending, reinvited.Status)
+		assert.Nil(t, reinvited.RespondedAt)
+	})
+
+	t.Run("accepting grants access", func(t *testing.T) {
+		responded, err := db.RespondToChannelShare(share.ID, member.ID, true)
+		require.NoError(t, err)
+		assert.True(t, responded)
+
+		responded, err = db.RespondToChannelShare(share.ID, member.ID, false)
+		require.NoError(t, err)
+		assert.False(t, responded, "only pending invitations can be answered")
+
+		for _, userID := range []int64{owner.ID, member.ID} {
+			hasAccess, err := db.HasChannelAccess(userID, channel.ID)
+			require.NoError(t, err)
+			assert.True(t, hasAccess)
+		}
+		hasAccess, err := db.HasChannelAccess(outsider.ID, channel.ID)
+		require.NoError(t, err)
+		assert.False(t, hasAccess)
+
+		shared, err := db.ListSharedChannels(member.ID)
+		require.NoError(t, err)
+		require.Len(t, shared, 1)
+		assert.Equal(t, channel.ID, shared[0].ChannelID)
+		assert.Equal(t, owner.Email, shared[0].OwnerEmail)
+	})
+
+	t.Run("events are copied to members once", func(t *testing.T) {
+		require.NoError(t, db.UpdateGCalSettings(member.ID, true, "partner-calendar", "Partner"))
+
+		event := createShareTestEvent(t, db, owner.ID, channel.ID)
+		require.NoError(t, db.SetEventAttendees(event.ID, []Attendee{{Email: "guest@example.com"}}))
+
+		copies, err := db.ShareEventWithMembers(event.ID)
+		require.NoError(t, err)
+		require.Len(t, copies, 1)
+
+		copied := copies[0]
+		assert.Equal(t, member.ID, copied.UserID)
+		assert.Equal(t, EventStatusPending, copied.Status)
+		assert.Equal(t, "partner-calendar", copied.CalendarID)
+		assert.Equal(t, event.Title, copied.Title)
+		require.NotNil(t, copied.SharedFromEventID)
+		assert.Equal(t, event.ID, *copied.SharedFromEventID)
+		require.Len(t, copied.Attendees, 1)
+
+		original, err := db.GetEventByID(event.ID)
+		require.NoError(t, err)
+		assert.True(t, original.Shared)
+
+		copies, err = db.ShareEventWithMembers(event.ID)
+		require.NoError(t, err)
+		assert.Empty(t, copies)
+	})
+
+	t.Run("unsharing removes only pending copies", func(t *testing.T) {
+		pending := createShareTestEvent(t, db, owner.ID, channel.ID)
+		confirmed := createShareTestEvent(t, db, owner.ID, channel.ID)
+		_, err := db.ShareEventWithMembers(pending.ID)
+		require.NoError(t, err)
+		confirmedCopies, err := db.ShareEventWithMembers(confirmed.ID)
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateEventStatus(confirmedCopies[0].ID, EventStatusConfirmed))
+
+		removed, err := db.UnshareEvent(pending.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+		removed, err = db.UnshareEvent(confirmed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, removed)
+
+		original, err := db.GetEventByID(pending.ID)
+		require.NoError(t, err)
+		assert.False(t, original.Shared)
+	})
+
+	t.Run("deleting a share removes the member's pending copies", func(t *testing.T) {
+		require.NoError(t, db.DeleteChannelShare(share.ID))
+
+		status := EventStatusPending
+		events, err := db.ListEvents(member.ID, &status, &channel.ID)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+
+		confirmedStatus := EventStatusConfirmed
+		events, err = db.ListEvents(member.ID, &confirmedStatus, &channel.ID)
+		require.NoError(t, err)
+		assert.Len(t, events, 1, "confirmed copies stay in the member's calendar")
+
+		hasAccess, err := db.HasChannelAccess(member.ID, channel.ID)
+		require.NoError(t, err)
+		assert.False(t, hasAccess)
+	})
+}
+
+func createShareTestEvent(t *testing.T, db *DB, userID, channelID int64) *CalendarEvent {
+	t.Helper()
+	event, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:     userID,
+		ChannelID:  channelID,
+		CalendarID: "primary",
+		Title:      "Parents' evening",
+		StartTime:  time.Now().Add(48 * time.Hour),
+		ActionType: EventActionCreate,
+	})
+	require.NoError(t, err)
+	return event
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// ChannelSuggestion is an untracked channel that recently had actionable-looking messages
+type ChannelSuggestion struct {
+	ChannelID        int64             `json:"channel_id"`
+	UserID           int64             `json:"-"`
+	SourceType       source.SourceType `json:"source_type"`
+	Identifier       string            `json:"identifier"`
+	Name             string            `json:"name"`
+	ActionableCount  int               `json:"actionable_messages"`
+	MessageCount     int               `json:"messages"`
+	LastActionableAt *time.Time        `json:"last_actionable_at,omitempty"`
+	ScoredAt         time.Time         `json:"scored_at"`
+}
+
+// ListSuggestionCandidates returns every user's untracked WhatsApp and Telegram channels
+// with messages at or after since
+func (d *DB) ListSuggestionCandidates(since time.Time) ([]*SourceChannel, error) {
+	rows, err := d.Query(`
+		SELECT c.id, c.user_id, COALESCE(c.source_type, 'whatsapp'), c.type, c.identifier, c.name, c.enabled,
+			c.analysis_mode, c.muted_until, c.created_at
+		FROM channels c
+		WHERE c.enabled = 0 AND COALESCE(c.source_type, 'whatsapp') IN (?, ?)
+			AND EXISTS (SELECT 1 FROM message_history m WHERE m.channel_id = c.id AND m.timestamp >= ?)
+		ORDER BY c.user_id, c.id
+	`, source.SourceTypeWhatsApp, source.SourceTypeTelegram, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suggestion candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var channels []*SourceChannel
+	for rows.Next() {
+		channel, err := scanSourceChannelRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, rows.Err()
+}
+
+// SaveChannelSuggestion records a channel's latest score
+func (d *DB) SaveChannelSuggestion(s ChannelSuggestion) error {
+	_, err := d.Exec(`
+		INSERT INTO channel_suggestions (channel_id, user_id, actionable_count, message_count, last_actionable_at, scored_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(channel_id) DO UPDATE SET
+			actionable_count = excluded.actionable_count,
+			message_count = excluded.message_count,
+			last_actionable_at = excluded.last_actionable_at,
+			scored_at = excluded.scored_at
+	`, s.ChannelID, s.UserID, s.ActionableCount, s.MessageCount, s.LastActionableAt, s.ScoredAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save channel suggestion: %w", err)
+	}
+	return nil
+}
+
+// DeleteStaleChannelSuggestions removes scores not refreshed since scoredBefore, i.e.
+// channels that were tracked or went quiet since the last run
+func (d *DB) DeleteStaleChannelSuggestions(scoredBefore time.Time) error {
+	_, err := d.Exec(`DELETE FROM channel_suggestions WHERE scored_at < ?`, scoredBefore.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to delete stale channel suggestions: %w", err)
+	}
+	return nil
+}
+
+// ListChannelSuggestions returns the user's untracked channels with the most
+// actionable-looking messages first
+func (d *DB) ListChannelSuggestions(userID int64, limit int) ([]ChannelSuggestion, error) {
+	rows, err := d.Query(`
+		SELECT s.channel_id, s.user_id, COALESCE(c.source_type, 'whatsapp'), c.identifier, c.name,
+			s.actionable_count, s.message_count, s.last_actionable_at, s.scored_at
+		FROM channel_suggestions s
+		JOIN channels c ON c.id = s.channel_id
+		WHERE s.user_id = ? AND c.enabled = 0 AND s.actionable_count > 0
+		ORDER BY s.actionable_count DESC, s.last_actionable_at DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channel suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []ChannelSuggestion{}
+	for rows.Next() {
+		var s ChannelSuggestion
+		var lastActionableAt sql.NullTime
+		if err := rows.Scan(&s.ChannelID, &s.UserID, &s.SourceType, &s.Identifier, &s.Name,
+			&s.ActionableCount, &s.MessageCount, &lastActionableAt, &s.ScoredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan channel suggestion: %w", err)
+		}
+		if lastActionableAt.Valid {
+			s.LastActionableAt = &lastActionableAt.Time
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating channel suggestions: %w", err)
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelSuggestions(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	now := time.Now().UTC()
+	since := now.Add(-7 * 24 * time.Hour)
+
+	create := func(identifier string, enabled bool, age time.Duration) *SourceChannel {
+		t.Helper()
+		channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, identifier, identifier)
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateSourceChannel(user.ID, channel.ID, channel.Name, enabled))
+		_, err = db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, identifier, identifier, "dinner tomorrow?", "", now.Add(-age))
+		require.NoError(t, err)
+		return channel
+	}
+	dana := create("dana", false, time.Hour)
+	quiet := create("quiet", false, 30*24*time.Hour)
+	tracked := create("tracked", true, time.Hour)
+	yossi := create("yossi", false, 2*time.Hour)
+
+	candidates, err := db.ListSuggestionCandidates(since)
+	require.NoError(t, err)
+	var ids []int64
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	assert.Equal(t, []int64{dana.ID, yossi.ID}, ids, "only untracked channels with recent messages")
+	assert.NotContains(t, ids, quiet.ID)
+	assert.NotContains(t, ids, tracked.ID)
+
+	lastAt := now.Add(-time.Hour)
+	require.NoError(t, db.SaveChannelSuggestion(ChannelSuggestion{ChannelID: dana.ID, UserID: user.ID, ActionableCount: 4, MessageCount: 6, LastActionableAt: &lastAt, ScoredAt: now}))
+	require.NoError(t, db.SaveChannelSuggestion(ChannelSuggestion{ChannelID: yossi.ID, UserID: user.ID, ActionableCount: 0, MessageCount: 3, ScoredAt: now}))
+
+	suggestions, err := db.ListChannelSuggestions(user.ID, 5)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1, "channels without actionable messages aren't suggested")
+	assert.Equal(t, dana.ID, suggestions[0].ChannelID)
+	assert.Equal(t, "dana", suggestions[0].Name)
+	assert.Equal(t, 4, suggestions[0].ActionableCount)
+	assert.Equal(t, 6, suggestions[0].MessageCount)
+	require.NotNil(t, suggestions[0].LastActionableAt)
+
+	t.Run("tracking a channel hides its suggestion", func(t *testing.T) {
+		require.NoError(t, db.UpdateSourceChannel(user.ID, dana.ID, dana.Name, true))
+		suggestions, err := db.ListChannelSuggestions(user.ID, 5)
+		require.NoError(t, err)
+		assert.Empty(t, suggestions)
+		require.NoError(t, db.UpdateSourceChannel(user.ID, dana.ID, dana.Name, false))
+	})
+
+	t.Run("stale scores are deleted", func(t *testing.T) {
+		later := now.Add(time.Hour)
+		require.NoError(t, db.SaveChannelSuggestion(ChannelSuggestion{ChannelID: yossi.ID, UserID: user.ID, ActionableCount: 2, MessageCount: 3, ScoredAt: later}))
+		require.NoError(t, db.DeleteStaleChannelSuggestions(later))
+
+		suggestions, err := db.ListChannelSuggestions(user.ID, 5)
+		require.NoError(t, err)
+		require.Len(t, suggestions, 1)
+		assert.Equal(t, yossi.ID, suggestions[0].ChannelID)
+	})
+}
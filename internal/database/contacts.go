@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// Contact is a person the user knows, merged across Gmail contacts and the
+// WhatsApp/Telegram chats Alfred has seen
+type Contact struct {
+	Name         string   `json:"name"`
+	Email        string   `json:"email,omitempty"`
+	Phone        string   `json:"phone,omitempty"`
+	Sources      []string `json:"sources"`
+	Interactions int      `json:"interactions"` // emails + chat messages exchanged
+}
+
+// SearchContacts finds a user's contacts whose name, email or chat identifier contains
+// query, merging entries with the same name from different sources. Results are ordered
+// by how much the user interacts with them.
+func (d *DB) SearchContacts(userID int64, query string, limit int) ([]Contact, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || limit <= 0 {
+		return []Contact{}, nil
+	}
+
+	merged := make(map[string]*Contact)
+	var order []string
+	add := func(key string, c Contact, src string) {
+		existing, ok := merged[key]
+		if !ok {
+			c.Sources = []string{src}
+			merged[key] = &c
+			order = append(order, key)
+			return
+		}
+		if existing.Email == "" {
+			existing.Email = c.Email
+		}
+		if existing.Phone == "" {
+			existing.Phone = c.Phone
+		}
+		existing.Interactions += c.Interactions
+		for _, s := range existing.Sources {
+			if s == src {
+				return
+			}
+		}
+		existing.Sources = append(existing.Sources, src)
+	}
+
+	googleContacts, err := d.SearchGoogleContacts(userID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, gc := range googleContacts {
+		name := strings.TrimSpace(gc.Name)
+		key := strings.ToLower(name)
+		if key == "" {
+			name, key = gc.Email, strings.ToLower(gc.Email)
+		}
+		add(key, Contact{Name: name, Email: gc.Email, Interactions: gc.EmailCount}, string(source.SourceTypeGmail))
+	}
+
+	pattern := "%" + query + "%"
+	rows, err := d.Query(`
+		SELECT source_type, identifier, name, total_message_count
+		FROM channels
+		WHERE user_id = ? AND type = ? AND source_type IN (?, ?)
+		  AND (LOWER(name) LIKE LOWER(?) OR LOWER(identifier) LIKE LOWER(?))
+		ORDER BY total_message_count DESC
+		LIMIT ?
+	`, userID, source.ChannelTypeSender, source.SourceTypeWhatsApp, source.SourceTypeTelegram, pattern, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search chat contacts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sourceType, identifier, name string
+		var messageCount int
+		if err := rows.Scan(&sourceType, &identifier, &name, &messageCount); err != nil {
+			return nil, fmt.Errorf("failed to scan chat contact: %w", err)
+		}
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if key == "" {
+			name, key = identifier, sourceType+":"+identifier
+		}
+		c := Contact{Name: name, Interactions: messageCount}
+		if source.SourceType(sourceType) == source.SourceTypeWhatsApp {
+			c.Phone = phoneFromWhatsAppJID(identifier)
+		}
+		add(key, c, sourceType)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chat contacts: %w", err)
+	}
+
+	contacts := make([]Contact, 0, len(order))
+	for _, key := range order {
+		contacts = append(contacts, *merged[key])
+	}
+	sort.SliceStable(contacts, func(i, j int) bool {
+		return contacts[i].Interactions > contacts[j].Interactions
+	})
+	if len(contacts) > limit {
+		contacts = contacts[:limit]
+	}
+	return contacts, nil
+}
+
+// phoneFromWhatsAppJID returns the phone number of a personal WhatsApp JID
+// ("972501234567@s.whatsapp.net" -> "+972501234567"), or "" for other identifiers
+func phoneFromWhatsAppJID(jid string) string {
+	number, ok := strings.CutSuffix(jid, "@s.whatsapp.net")
+	if !ok || number == "" {
+		return ""
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+	return "+" + number
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchContacts(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	require.NoError(t, db.ReplaceTopContacts(user.ID, []TopContact{
+		{Email: "yossi.cohen@example.com", Name: "Yossi Cohen", EmailCount: 12},
+		{Email: "yossi@other.com", Name: "Yossi Levi", EmailCount: 2},
+		{Email: "billing@example.com", EmailCount: 40},
+	}))
+
+	chat := func(sourceType source.SourceType, identifier, name string, messages int) {
+		channel, err := db.CreateSourceChannel(user.ID, sourceType, source.ChannelTypeSender, identifier, name)
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateChannelStats(channel.ID, messages, nil))
+	}
+	chat(source.SourceTypeWhatsApp, "972501234567@s.whatsapp.net", "Yossi Cohen", 300)
+	chat(source.SourceTypeTelegram, "5551234", "Grandma", 50)
+	chat(source.SourceTypeWhatsApp, "15550001111@s.whatsapp.net", "Yossi from work", 5)
+
+	t.Run("merges the same person across sources", func(t *testing.T) {
+		contacts, err := db.SearchContacts(user.ID, "yossi", 10)
+		require.NoError(t, err)
+		require.Len(t, contacts, 3)
+
+		assert.Equal(t, Contact{
+			Name:         "Yossi Cohen",
+			Email:        "yossi.cohen@example.com",
+			Phone:        "+972501234567",
+			Sources:      []string{"gmail", "whatsapp"},
+			Interactions: 312,
+		}, contacts[0])
+		assert.Equal(t, "Yossi from work", contacts[1].Name)
+		assert.Equal(t, "+15550001111", contacts[1].Phone)
+		assert.Equal(t, "Yossi Levi", contacts[2].Name)
+		assert.Empty(t, contacts[2].Phone)
+	})
+
+	t.Run("matches nicknames in chats", func(t *testing.T) {
+		contacts, err := db.SearchContacts(user.ID, "grandma", 10)
+		require.NoError(t, err)
+		require.Len(t, contacts, 1)
+		assert.Equal(t, []string{"telegram"}, contacts[0].Sources)
+		assert.Empty(t, contacts[0].Phone)
+	})
+
+	t.Run("falls back to email when a contact has no name", func(t *testing.T) {
+		contacts, err := db.SearchContacts(user.ID, "billing", 10)
+		require.NoError(t, err)
+		require.Len(t, contacts, 1)
+		assert.Equal(t, "billing@example.com", contacts[0].Name)
+	})
+
+	t.Run("respects limit", func(t *testing.T) {
+		contacts, err := db.SearchContacts(user.ID, "yossi", 1)
+		require.NoError(t, err)
+		assert.Len(t, contacts, 1)
+	})
+
+	t.Run("empty query and other users find nothing", func(t *testing.T) {
+		contacts, err := db.SearchContacts(user.ID, "  ", 10)
+		require.NoError(t, err)
+		assert.Empty(t, contacts)
+
+		other := CreateTestUser(t, db)
+		contacts, err = db.SearchContacts(other.ID, "yossi", 10)
+		require.NoError(t, err)
+		assert.Empty(t, contacts)
+	})
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrConversionNotPending is returned when the item to convert is no longer pending,
+// e.g. it was confirmed or converted in the meantime
+var ErrConversionNotPending = errors.New("item is no longer pending")
+
+// ConvertReminderToEvent turns a pending reminder into a pending event starting at
+// startTime, in one transaction: the reminder is rejected and the event keeps its
+// channel, calendar, text and trigger message
+func (d *DB) ConvertReminderToEvent(reminder *Reminder, startTime time.Time, endTime *time.Time) (*CalendarEvent, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin conversion: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE reminders SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ? AND status = ?
+	`, ReminderStatusRejected, reminder.ID, reminder.UserID, ReminderStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retire converted reminder: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, ErrConversionNotPending
+	}
+
+	result, err = tx.Exec(`
+		INSERT INTO calendar_events (
+			user_id, channel_id, calendar_id, title, description, start_time, end_time, location,
+			status, action_type, original_message_id, llm_reasoning, llm_confidence, quality_flags
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		reminder.UserID, reminder.ChannelID, reminder.CalendarID, reminder.Title, reminder.Description,
+		startTime, endTime, reminder.Location, EventStatusPending, EventActionCreate,
+		reminder.OriginalMsgID, reminder.LLMReasoning, reminder.LLMConfidence, encodeQualityFlags(reminder.QualityFlags),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event id: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit conversion: %w", err)
+	}
+	return d.GetEventByID(id)
+}
+
+// ConvertEventToReminder turns a pending event into a pending reminder, in one
+// transaction: the event is rejected and the reminder keeps its channel, calendar, text
+// and trigger message. dueDate may be nil for a reminder without a fixed time.
+func (d *DB) ConvertEventToReminder(event *CalendarEvent, dueDate *time.Time, priority ReminderPriority) (*Reminder, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin conversion: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE calendar_events SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ? AND status = ?
+	`, EventStatusRejected, event.ID, event.UserID, EventStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retire converted event: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, ErrConversionNotPending
+	}
+
+	result, err = tx.Exec(`
+		INSERT INTO reminders (
+			user_id, channel_id, calendar_id, title, description, location, due_date, priority,
+			status, action_type, original_message_id, llm_reasoning, llm_confidence, quality_flags
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		event.UserID, event.ChannelID, event.CalendarID, event.Title, event.Description, event.Location,
+		dueDate, priority, ReminderStatusPending, ReminderActionCreate,
+		event.OriginalMsgID, event.LLMReasoning, event.LLMConfidence, encodeQualityFlags(event.QualityFlags),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminder id: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit conversion: %w", err)
+	}
+	return d.GetReminderByID(id)
+}
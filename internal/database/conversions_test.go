@@ -0,0 +1,85 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertReminderToEvent(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	msg, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "sender", "Dana", "Team dinner Tuesday at 7", "", time.Now())
+	require.NoError(t, err)
+	reminder, err := db.CreatePendingReminder(&Reminder{
+		UserID:        user.ID,
+		ChannelID:     channel.ID,
+		CalendarID:    "primary",
+		Title:         "Team dinner",
+		Location:      "Cafe",
+		ActionType:    ReminderActionCreate,
+		Priority:      ReminderPriorityNormal,
+		OriginalMsgID: &msg.ID,
+		LLMReasoning:  "test",
+	})
+	require.NoError(t, err)
+
+	start := time.Date(2026, 11, 3, 19, 0, 0, 0, time.UTC)
+	event, err := db.ConvertReminderToEvent(reminder, start, nil)
+	require.NoError(t, err)
+	assert.Equal(t, EventStatusPending, event.Status)
+	assert.Equal(t, EventActionCreate, event.ActionType)
+	assert.Equal(t, "Team dinner", event.Title)
+	assert.Equal(t, "Cafe", event.Location)
+	assert.Equal(t, channel.ID, event.ChannelID)
+	assert.True(t, start.Equal(event.StartTime))
+	require.NotNil(t, event.OriginalMsgID)
+	assert.Equal(t, msg.ID, *event.OriginalMsgID)
+
+	converted, err := db.GetReminderByID(reminder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ReminderStatusRejected, converted.Status)
+
+	// A second conversion finds the reminder no longer pending and creates nothing
+	_, err = db.ConvertReminderToEvent(reminder, start, nil)
+	assert.ErrorIs(t, err, ErrConversionNotPending)
+	count, err := db.CountPendingEvents(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestConvertEventToReminder(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	event, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Pay rent",
+		StartTime:  time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC),
+		ActionType: EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	reminder, err := db.ConvertEventToReminder(event, nil, ReminderPriorityHigh)
+	require.NoError(t, err)
+	assert.Equal(t, ReminderStatusPending, reminder.Status)
+	assert.Equal(t, ReminderActionCreate, reminder.ActionType)
+	assert.Equal(t, ReminderPriorityHigh, reminder.Priority)
+	assert.Equal(t, "Pay rent", reminder.Title)
+	assert.Nil(t, reminder.DueDate)
+
+	converted, err := db.GetEventByID(event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, EventStatusRejected, converted.Status)
+
+	_, err = db.ConvertEventToReminder(event, nil, ReminderPriorityNormal)
+	assert.ErrorIs(t, err, ErrConversionNotPending)
+}
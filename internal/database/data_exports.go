@@ -0,0 +1,262 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// DataExportStatus represents where an account data export is in its lifecycle
+type DataExportStatus string
+
+const (
+	DataExportPending   DataExportStatus = "pending"
+	DataExportRunning   DataExportStatus = "running"
+	DataExportCompleted DataExportStatus = "completed"
+	DataExportFailed    DataExportStatus = "failed"
+)
+
+// dataExportStaleAfter is how long a pending/running export blocks a new one. Exports
+// interrupted by a restart never finish, so they stop counting as active after this.
+const dataExportStaleAfter = time.Hour
+
+// DataExport is a requested archive of everything stored for a user
+type DataExport struct {
+	ID          int64            `json:"id"`
+	UserID      int64            `json:"user_id"`
+	Status      DataExportStatus `json:"status"`
+	Progress    int              `json:"progress"`
+	Error       string           `json:"error,omitempty"`
+	SizeBytes   int64            `json:"size_bytes"`
+	CreatedAt   time.Time        `json:"created_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+}
+
+// UserDataSection is one part of a data export, written to the archive as <Name>.json
+type UserDataSection struct {
+	Name  string
+	query string
+	// encrypted lists message_text-style columns that are decrypted before export
+	encrypted []string
+}
+
+// UserDataSections lists what a data export contains, in archive order. Each query takes
+// the user ID once. Credentials (OAuth/session tokens, API key hashes, webhook secrets)
+// are left out.
+var UserDataSections = []UserDataSection{
+	{Name: "profile", query: `SELECT id, email, name, avatar_url, timezone, created_at, updated_at, last_login_at FROM users WHERE id = ?`},
+	{Name: "channels", query: `SELECT * FROM channels WHERE user_id = ? ORDER BY id`},
+	{Name: "channel_labels", query: `SELECT l.*, (SELECT GROUP_CONCAT(a.channel_id) FROM channel_label_assignments a WHERE a.label_id = l.id) AS channel_ids FROM channel_labels l WHERE l.user_id = ? ORDER BY l.id`},
+	{Name: "messages", query: `SELECT * FROM message_history WHERE user_id = ? ORDER BY channel_id, timestamp`, encrypted: []string{"message_text"}},
+	{Name: "events", query: `SELECT * FROM calendar_events WHERE user_id = ? ORDER BY id`},
+	{Name: "event_attendees", query: `SELECT a.* FROM event_attendees a JOIN calendar_events e ON e.id = a.event_id WHERE e.user_id = ? ORDER BY a.event_id, a.id`},
+	{Name: "event_corrections", query: `SELECT * FROM event_corrections WHERE user_id = ? ORDER BY id`},
+	{Name: "message_annotations", query: `SELECT * FROM message_annotations WHERE user_id = ? ORDER BY id`},
+	{Name: "reminders", query: `SELECT * FROM reminders WHERE user_id = ? ORDER BY id`},
+	{Name: "reminder_snoozes", query: `SELECT * FROM reminder_snoozes WHERE user_id = ? ORDER BY id`},
+	{Name: "email_sources", query: `SELECT * FROM email_sources WHERE user_id = ? ORDER BY id`},
+	{Name: "contacts", query: `SELECT * FROM google_contacts WHERE user_id = ? ORDER BY email`},
+	{Name: "unified_contacts", query: `SELECT * FROM contacts WHERE user_id = ? ORDER BY id`},
+	{Name: "contact_identities", query: `SELECT * FROM contact_identities WHERE user_id = ? ORDER BY contact_id, id`},
+	{Name: "channel_shares", query: `SELECT * FROM channel_shares WHERE owner_user_id = ?1 OR member_user_id = ?1 ORDER BY id`},
+	{Name: "notification_preferences", query: `SELECT * FROM user_notification_preferences WHERE user_id = ?`},
+	{Name: "feature_settings", query: `SELECT * FROM feature_settings WHERE user_id = ?`},
+	{Name: "feature_flags", query: `SELECT name, enabled, updated_at FROM feature_flags WHERE user_id = ? ORDER BY name`},
+	{Name: "gmail_settings", query: `SELECT * FROM gmail_settings WHERE user_id = ?`},
+	{Name: "gcal_settings", query: `SELECT * FROM gcal_settings WHERE user_id = ?`},
+	{Name: "gcal_channel_calendars", query: `SELECT * FROM gcal_channel_calendars WHERE user_id = ? ORDER BY channel_id`},
+	{Name: "devices", query: `SELECT id, platform, device_name, created_at, last_seen_at FROM devices WHERE user_id = ? ORDER BY id`},
+	{Name: "webhooks", query: `SELECT id, url, event_types, enabled, created_at, updated_at FROM webhooks WHERE user_id = ? ORDER BY id`},
+	{Name: "llm_usage", query: `SELECT * FROM llm_usage WHERE user_id = ? ORDER BY id`},
+	{Name: "api_keys", query: `SELECT id, name, key_prefix, scope, last_used_at, created_at FROM api_keys WHERE user_id = ? ORDER BY id`},
+}
+
+// ExportUserDataSection returns every row of a section as column → value maps
+func (d *DB) ExportUserDataSection(section UserDataSection, userID int64) ([]map[string]any, error) {
+	rows, err := d.Query(section.query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export %s: %w", section.Name, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %w", section.Name, err)
+	}
+
+	records := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", section.Name, err)
+		}
+
+		record := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				record[column] = string(b)
+			} else {
+				record[column] = values[i]
+			}
+		}
+		for _, column := range section.encrypted {
+			if text, ok := record[column].(string); ok {
+				if record[column], err = d.decryptMessageText(text); err != nil {
+					return nil, fmt.Errorf("failed to export %s: %w", section.Name, err)
+				}
+			}
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s: %w", section.Name, err)
+	}
+	return records, nil
+}
+
+const dataExportColumns = `id, user_id, status, progress, COALESCE(error, ''), size_bytes, created_at, completed_at, expires_at`
+
+func scanDataExport(scanner interface{ Scan(...any) error }) (*DataExport, error) {
+	var export DataExport
+	var completedAt, expiresAt sql.NullTime
+	if err := scanner.Scan(
+		&export.ID, &export.UserID, &export.Status, &export.Progress, &export.Error,
+		&export.SizeBytes, &export.CreatedAt, &completedAt, &expiresAt,
+	); err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		export.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		export.ExpiresAt = &expiresAt.Time
+	}
+	return &export, nil
+}
+
+// CreateDataExport queues a new export for a user
+func (d *DB) CreateDataExport(userID int64) (*DataExport, error) {
+	export, err := scanDataExport(d.QueryRow(`
+		INSERT INTO data_exports (user_id) VALUES (?)
+		RETURNING `+dataExportColumns, userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data export: %w", err)
+	}
+	return export, nil
+}
+
+// GetDataExportByID retrieves an export without its archive
+func (d *DB) GetDataExportByID(id int64) (*DataExport, error) {
+	export, err := scanDataExport(d.QueryRow(`
+		SELECT `+dataExportColumns+` FROM data_exports WHERE id = ?
+	`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	return export, nil
+}
+
+// GetActiveDataExport returns the user's pending or running export, or nil if none
+func (d *DB) GetActiveDataExport(userID int64) (*DataExport, error) {
+	export, err := scanDataExport(d.QueryRow(`
+		SELECT `+dataExportColumns+` FROM data_exports
+		WHERE user_id = ? AND status IN ('pending', 'running')
+		  AND created_at > datetime('now', ?)
+		ORDER BY id DESC LIMIT 1
+	`, userID, fmt.Sprintf("-%d seconds", int(dataExportStaleAfter.Seconds()))))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active data export: %w", err)
+	}
+	return export, nil
+}
+
+// ListDataExports returns a user's exports, newest first
+func (d *DB) ListDataExports(userID int64) ([]DataExport, error) {
+	rows, err := d.Query(`
+		SELECT `+dataExportColumns+` FROM data_exports
+		WHERE user_id = ?
+		ORDER BY id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []DataExport{}
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan data export: %w", err)
+		}
+		exports = append(exports, *export)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating data exports: %w", err)
+	}
+	return exports, nil
+}
+
+// UpdateDataExportProgress records a running export's progress (0-100)
+func (d *DB) UpdateDataExportProgress(id int64, progress int) error {
+	_, err := d.Exec(`
+		UPDATE data_exports SET status = 'running', progress = ? WHERE id = ?
+	`, progress, id)
+	if err != nil {
+		return fmt.Errorf("failed to update data export progress: %w", err)
+	}
+	return nil
+}
+
+// CompleteDataExport stores the finished archive, available until expiresAt
+func (d *DB) CompleteDataExport(id int64, archive []byte, expiresAt time.Time) error {
+	_, err := d.Exec(`
+		UPDATE data_exports
+		SET status = 'completed', progress = 100, archive = ?, size_bytes = ?,
+			completed_at = CURRENT_TIMESTAMP, expires_at = ?
+		WHERE id = ?
+	`, archive, len(archive), expiresAt.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to complete data export: %w", err)
+	}
+	return nil
+}
+
+// FailDataExport marks an export as failed with the reason
+func (d *DB) FailDataExport(id int64, reason string) error {
+	_, err := d.Exec(`
+		UPDATE data_exports
+		SET status = 'failed', error = ?, completed_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, reason, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark data export failed: %w", err)
+	}
+	return nil
+}
+
+// GetDataExportArchive returns the ZIP archive of a completed export
+func (d *DB) GetDataExportArchive(id int64) ([]byte, error) {
+	var archive []byte
+	err := d.QueryRow(`SELECT archive FROM data_exports WHERE id = ? AND status = 'completed'`, id).Scan(&archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export archive: %w", err)
+	}
+	return archive, nil
+}
+
+// DeleteExpiredDataExports drops exports whose archives have expired
+func (d *DB) DeleteExpiredDataExports(now time.Time) (int64, error) {
+	result, err := d.Exec(`DELETE FROM data_exports WHERE expires_at IS NOT NULL AND expires_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired data exports: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -11,9 +11,15 @@ import (
 
 type DB struct {
 	*sql.DB
+	// messageCipher encrypts message_history.message_text when set
+	messageCipher Cipher
+	// tokenKey encrypts OAuth tokens; nil = derived from the environment
+	tokenKey []byte
 }
 
-func New(dbPath string) (*DB, error) {
+// Open opens the database without applying migrations. Use New for normal startup;
+// Open is for tooling that inspects or changes the schema version itself.
+func Open(dbPath string) (*DB, error) {
 	// Enable WAL mode for better concurrency, busy timeout to wait instead of failing,
 	// and foreign keys for referential integrity
 	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000")
@@ -22,15 +28,34 @@ func New(dbPath string) (*DB, error) {
 	}
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	return &DB{DB: db}, nil
+}
+
+// New opens the database and brings its schema up to date
+func New(dbPath string) (*DB, error) {
+	d, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Run migrations
-	if err := migrations.RunMigrations(db); err != nil {
+	if err := migrations.RunMigrations(d.DB); err != nil {
+		d.Close()
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	return &DB{db}, nil
+	// The search index migration is a no-op on SQLite builds without FTS5; retry so the
+	// index appears once the binary is built with -tags sqlite_fts5.
+	if _, err := migrations.SetUpSearchIndex(d.DB); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to set up search index: %w", err)
+	}
+
+	return d, nil
 }
 
 func (d *DB) Close() error {
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Push services a device's token can belong to
+const (
+	PushProviderExpo = "expo"
+	PushProviderFCM  = "fcm"
+	PushProviderAPNs = "apns"
+)
+
+// IsValidPushProvider reports whether provider is a supported push service
+func IsValidPushProvider(provider string) bool {
+	switch provider {
+	case PushProviderExpo, PushProviderFCM, PushProviderAPNs:
+		return true
+	}
+	return false
+}
+
+// Device is a phone registered to receive push notifications for a user
+type Device struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	PushToken  string    `json:"push_token"`
+	Provider   string    `json:"provider"` // PushProviderExpo, PushProviderFCM or PushProviderAPNs
+	Platform   string    `json:"platform,omitempty"`
+	DeviceName string    `json:"device_name,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// RegisterDevice adds a push token for a user, or refreshes it if already registered.
+// A token moves to the new user when a phone is signed into a different account.
+// The token also becomes the user's push_token preference (latest device).
+// provider defaults to PushProviderExpo.
+func (d *DB) RegisterDevice(userID int64, pushToken, provider, platform, deviceName string) (*Device, error) {
+	if provider == "" {
+		provider = PushProviderExpo
+	}
+	if err := d.EnsureNotificationPrefs(userID); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin register device transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var device Device
+	err = tx.QueryRow(`
+		INSERT INTO devices (user_id, push_token, provider, platform, device_name)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(push_token) DO UPDATE SET
+			user_id = excluded.user_id,
+			provider = excluded.provider,
+			platform = CASE WHEN excluded.platform != '' THEN excluded.platform ELSE devices.platform END,
+			device_name = CASE WHEN excluded.device_name != '' THEN excluded.device_name ELSE devices.device_name END,
+			last_seen_at = CURRENT_TIMESTAMP
+		RETURNING id, user_id, push_token, provider, platform, device_name, created_at, last_seen_at
+	`, userID, pushToken, provider, platform, deviceName).Scan(
+		&device.ID, &device.UserID, &device.PushToken, &device.Provider, &device.Platform, &device.DeviceName,
+		&device.CreatedAt, &device.LastSeenAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_notification_preferences
+		SET push_token = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE push_token = ? AND user_id != ?
+	`, pushToken, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release push token from previous user: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE user_notification_preferences
+		SET push_token = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ?
+	`, pushToken, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update push token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit register device: %w", err)
+	}
+	return &device, nil
+}
+
+// ListDevices returns a user's registered devices, most recently seen first
+func (d *DB) ListDevices(userID int64) ([]Device, error) {
+	rows, err := d.Query(`
+		SELECT id, user_id, push_token, provider, platform, device_name, created_at, last_seen_at
+		FROM devices
+		WHERE user_id = ?
+		ORDER BY last_seen_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer rows.Close()
+
+	devices := []Device{}
+	for rows.Next() {
+		var device Device
+		if err := rows.Scan(
+			&device.ID, &device.UserID, &device.PushToken, &device.Provider, &device.Platform, &device.DeviceName,
+			&device.CreatedAt, &device.LastSeenAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating devices: %w", err)
+	}
+	return devices, nil
+}
+
+// GetPushTokens returns the push tokens of every device registered to a user
+func (d *DB) GetPushTokens(userID int64) ([]string, error) {
+	devices, err := d.ListDevices(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]string, 0, len(devices))
+	for _, device := range devices {
+		tokens = append(tokens, device.PushToken)
+	}
+	return tokens, nil
+}
+
+// DeleteDevice unregisters one of a user's devices. Returns false if the device
+// doesn't exist or belongs to another user.
+func (d *DB) DeleteDevice(userID, deviceID int64) (bool, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin delete device transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var pushToken string
+	err = tx.QueryRow(`
+		DELETE FROM devices WHERE id = ? AND user_id = ?
+		RETURNING push_token
+	`, deviceID, userID).Scan(&pushToken)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete device: %w", err)
+	}
+
+	if err := resetLatestPushToken(tx, pushToken); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit delete device: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteDevicesByPushToken removes devices whose tokens the push service reported as
+// no longer registered (e.g. the app was uninstalled)
+func (d *DB) DeleteDevicesByPushToken(pushTokens []string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin delete devices transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, pushToken := range pushTokens {
+		if _, err := tx.Exec(`DELETE FROM devices WHERE push_token = ?`, pushToken); err != nil {
+			return fmt.Errorf("failed to delete device: %w", err)
+		}
+		if err := resetLatestPushToken(tx, pushToken); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit delete devices: %w", err)
+	}
+	return nil
+}
+
+// resetLatestPushToken points any push_token preference still set to a removed token
+// at the user's most recently seen remaining device, or clears it
+func resetLatestPushToken(tx *sql.Tx, removedToken string) error {
+	_, err := tx.Exec(`
+		UPDATE user_notification_preferences
+		SET push_token = (
+			SELECT push_token FROM devices
+			WHERE devices.user_id = user_notification_preferences.user_id
+			ORDER BY last_seen_at DESC, id DESC
+			LIMIT 1
+		), updated_at = CURRENT_TIMESTAMP
+		WHERE push_token = ?
+	`, removedToken)
+	if err != nil {
+		return fmt.Errorf("failed to reset push token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevices(t *testing.T) {
+	t.Run("multiple devices per user", func(t *testing.T) {
+		db := NewTestDB(t)
+		user := CreateTestUser(t, db)
+
+		phone, err := db.RegisterDevice(user.ID, "ExponentPushToken[phone]", "", "ios", "iPhone")
+		require.NoError(t, err)
+		assert.Equal(t, "ios", phone.Platform)
+		assert.Equal(t, PushProviderExpo, phone.Provider)
+		require.NoError(t, db.UpdatePushToken(user.ID, "ExponentPushToken[tablet]"))
+
+		tokens, err := db.GetPushTokens(user.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"ExponentPushToken[phone]", "ExponentPushToken[tablet]"}, tokens)
+
+		prefs, err := db.GetUserNotificationPrefs(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "ExponentPushToken[tablet]", prefs.PushToken)
+
+		// Re-registering keeps one row and existing metadata
+		again, err := db.RegisterDevice(user.ID, "ExponentPushToken[phone]", "", "", "")
+		require.NoError(t, err)
+		assert.Equal(t, phone.ID, again.ID)
+		assert.Equal(t, "iPhone", again.DeviceName)
+
+		devices, err := db.ListDevices(user.ID)
+		require.NoError(t, err)
+		assert.Len(t, devices, 2)
+	})
+
+	t.Run("token moves to the account that registers it", func(t *testing.T) {
+		db := NewTestDB(t)
+		first := CreateTestUser(t, db)
+		second := CreateTestUser(t, db)
+
+		require.NoError(t, db.UpdatePushToken(first.ID, "ExponentPushToken[shared]"))
+		require.NoError(t, db.UpdatePushToken(second.ID, "ExponentPushToken[shared]"))
+
+		tokens, err := db.GetPushTokens(first.ID)
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+		prefs, err := db.GetUserNotificationPrefs(first.ID)
+		require.NoError(t, err)
+		assert.Empty(t, prefs.PushToken)
+
+		tokens, err = db.GetPushTokens(second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ExponentPushToken[shared]"}, tokens)
+	})
+
+	t.Run("delete is scoped to the user and resets the latest token", func(t *testing.T) {
+		db := NewTestDB(t)
+		user := CreateTestUser(t, db)
+		other := CreateTestUser(t, db)
+
+		older, err := db.RegisterDevice(user.ID, "ExponentPushToken[older]", "", "android", "")
+		require.NoError(t, err)
+		_, err = db.Exec(`UPDATE devices SET last_seen_at = datetime('now', '-1 day') WHERE id = ?`, older.ID)
+		require.NoError(t, err)
+		latest, err := db.RegisterDevice(user.ID, "ExponentPushToken[latest]", "", "ios", "")
+		require.NoError(t, err)
+
+		deleted, err := db.DeleteDevice(other.ID, latest.ID)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		deleted, err = db.DeleteDevice(user.ID, latest.ID)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		prefs, err := db.GetUserNotificationPrefs(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "ExponentPushToken[older]", prefs.PushToken)
+	})
+
+	t.Run("prune unregistered tokens", func(t *testing.T) {
+		db := NewTestDB(t)
+		user := CreateTestUser(t, db)
+
+		require.NoError(t, db.UpdatePushToken(user.ID, "ExponentPushToken[kept]"))
+		require.NoError(t, db.UpdatePushToken(user.ID, "ExponentPushToken[gone]"))
+
+		require.NoError(t, db.DeleteDevicesByPushToken([]string{"ExponentPushToken[gone]"}))
+
+		tokens, err := db.GetPushTokens(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ExponentPushToken[kept]"}, tokens)
+		prefs, err := db.GetUserNotificationPrefs(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "ExponentPushToken[kept]", prefs.PushToken)
+	})
+}
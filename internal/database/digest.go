@@ -0,0 +1,86 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultDigestTime is the local send time for users who haven't picked one
+const DefaultDigestTime = "08:00"
+
+// DigestSubscriber is a user with the morning digest enabled
+type DigestSubscriber struct {
+	UserID     int64
+	DigestTime string // "HH:MM" in Timezone
+	Timezone   string
+	LastSentOn string // local date (YYYY-MM-DD) of the last digest, "" if never sent
+}
+
+// ParseDigestTime parses an "HH:MM" digest send time
+func ParseDigestTime(value string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("time must be HH:MM (24-hour)")
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// UpdateDigestPrefs enables/disables the morning digest and sets its local send time
+func (d *DB) UpdateDigestPrefs(userID int64, enabled bool, digestTime string) error {
+	if err := d.EnsureNotificationPrefs(userID); err != nil {
+		return err
+	}
+
+	_, err := d.Exec(`
+		UPDATE user_notification_preferences
+		SET digest_enabled = ?, digest_time = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ?
+	`, enabled, digestTime, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update digest prefs: %w", err)
+	}
+	return nil
+}
+
+// ListDigestSubscribers returns every user with the morning digest enabled
+func (d *DB) ListDigestSubscribers() ([]DigestSubscriber, error) {
+	rows, err := d.Query(`
+		SELECT p.user_id, p.digest_time, COALESCE(u.timezone, ''), COALESCE(p.digest_last_sent_on, '')
+		FROM user_notification_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.digest_enabled = 1
+		ORDER BY p.user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list digest subscribers: %w", err)
+	}
+	defer rows.Close()
+
+	var subscribers []DigestSubscriber
+	for rows.Next() {
+		var s DigestSubscriber
+		if err := rows.Scan(&s.UserID, &s.DigestTime, &s.Timezone, &s.LastSentOn); err != nil {
+			return nil, fmt.Errorf("failed to scan digest subscriber: %w", err)
+		}
+		if s.Timezone == "" {
+			s.Timezone = "UTC"
+		}
+		subscribers = append(subscribers, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating digest subscribers: %w", err)
+	}
+	return subscribers, nil
+}
+
+// MarkDigestSent records that the user's digest for the local date day (YYYY-MM-DD)
+// was handled, so it isn't sent again that day
+func (d *DB) MarkDigestSent(userID int64, day string) error {
+	_, err := d.Exec(`
+		UPDATE user_notification_preferences SET digest_last_sent_on = ? WHERE user_id = ?
+	`, day, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest sent: %w", err)
+	}
+	return nil
+}
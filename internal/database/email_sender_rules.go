@@ -0,0 +1,175 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EmailSenderAction is what an email sender rule does with matching emails
+type EmailSenderAction string
+
+const (
+	// EmailSenderAllow always sends matching emails to analysis, overriding deny rules
+	EmailSenderAllow EmailSenderAction = "allow"
+	// EmailSenderDeny skips matching emails before they reach the LLM
+	EmailSenderDeny EmailSenderAction = "deny"
+)
+
+var (
+	ErrEmailSenderRuleNotFound = errors.New("email sender rule not found")
+	ErrInvalidSenderPattern    = errors.New("pattern must be an email address or domain")
+	ErrInvalidSenderAction     = errors.New("action must be allow or deny")
+)
+
+// EmailSenderRule allows or denies emails from an address or a domain
+type EmailSenderRule struct {
+	ID        int64             `json:"id"`
+	UserID    int64             `json:"user_id"`
+	Pattern   string            `json:"pattern"`
+	Action    EmailSenderAction `json:"action"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// IsAddress reports whether the rule matches a single address rather than a domain
+func (r *EmailSenderRule) IsAddress() bool {
+	return strings.Contains(r.Pattern, "@")
+}
+
+// matches reports whether sender (a lowercased address) is covered by the rule. Domain
+// rules also cover subdomains, so "shop.com" matches "news@mail.shop.com".
+func (r *EmailSenderRule) matches(sender string) bool {
+	if r.IsAddress() {
+		return sender == r.Pattern
+	}
+	at := strings.LastIndex(sender, "@")
+	if at < 0 {
+		return false
+	}
+	domain := sender[at+1:]
+	return domain == r.Pattern || strings.HasSuffix(domain, "."+r.Pattern)
+}
+
+// EmailSenderRules is a user's full rule set
+type EmailSenderRules []*EmailSenderRule
+
+// ActionFor returns the action for sender, or "" when no rule matches. Address rules
+// win over domain rules, and allow wins over deny at the same specificity, so
+// "teacher@school.org" can be allowed while the rest of "school.org" is denied.
+func (rules EmailSenderRules) ActionFor(sender string) EmailSenderAction {
+	sender = strings.ToLower(strings.TrimSpace(sender))
+	var domainAction, addressAction EmailSenderAction
+	for _, rule := range rules {
+		if !rule.matches(sender) {
+			continue
+		}
+		target := &domainAction
+		if rule.IsAddress() {
+			target = &addressAction
+		}
+		if *target != EmailSenderAllow {
+			*target = rule.Action
+		}
+	}
+	if addressAction != "" {
+		return addressAction
+	}
+	return domainAction
+}
+
+// Denies reports whether emails from sender should be skipped
+func (rules EmailSenderRules) Denies(sender string) bool {
+	return rules.ActionFor(sender) == EmailSenderDeny
+}
+
+// NormalizeSenderPattern lowercases pattern and checks that it's an address
+// ("news@shop.com") or a domain ("shop.com", "@shop.com")
+func NormalizeSenderPattern(pattern string) (string, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	pattern = strings.TrimPrefix(pattern, "@")
+	if pattern == "" || strings.ContainsAny(pattern, " \t<>,") {
+		return "", ErrInvalidSenderPattern
+	}
+
+	domain := pattern
+	if at := strings.Index(pattern, "@"); at >= 0 {
+		if at == 0 || strings.Count(pattern, "@") > 1 {
+			return "", ErrInvalidSenderPattern
+		}
+		domain = pattern[at+1:]
+	}
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", ErrInvalidSenderPattern
+	}
+	return pattern, nil
+}
+
+// SetEmailSenderRule creates a rule for pattern, or changes the action of the user's
+// existing rule for it
+func (d *DB) SetEmailSenderRule(userID int64, pattern string, action EmailSenderAction) (*EmailSenderRule, error) {
+	if action != EmailSenderAllow && action != EmailSenderDeny {
+		return nil, ErrInvalidSenderAction
+	}
+	pattern, err := NormalizeSenderPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = d.Exec(`
+		INSERT INTO email_sender_rules (user_id, pattern, action)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, pattern) DO UPDATE SET action = excluded.action
+	`, userID, pattern, action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save email sender rule: %w", err)
+	}
+
+	var rule EmailSenderRule
+	err = d.QueryRow(`
+		SELECT id, user_id, pattern, action, created_at
+		FROM email_sender_rules WHERE user_id = ? AND pattern = ?
+	`, userID, pattern).Scan(&rule.ID, &rule.UserID, &rule.Pattern, &rule.Action, &rule.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email sender rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// ListEmailSenderRules returns the user's sender rules, ordered by pattern
+func (d *DB) ListEmailSenderRules(userID int64) (EmailSenderRules, error) {
+	rows, err := d.Query(`
+		SELECT id, user_id, pattern, action, created_at
+		FROM email_sender_rules WHERE user_id = ? ORDER BY pattern
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email sender rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := EmailSenderRules{}
+	for rows.Next() {
+		var rule EmailSenderRule
+		if err := rows.Scan(&rule.ID, &rule.UserID, &rule.Pattern, &rule.Action, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan email sender rule: %w", err)
+		}
+		rules = append(rules, &rule)
+	}
+	return rules, rows.Err()
+}
+
+// DeleteEmailSenderRule deletes one of the user's sender rules
+func (d *DB) DeleteEmailSenderRule(userID, id int64) error {
+	result, err := d.Exec(`DELETE FROM email_sender_rules WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete email sender rule: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete email sender rule: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrEmailSenderRuleNotFound
+	}
+	return nil
+}
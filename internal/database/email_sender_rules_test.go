@@ -0,0 +1,90 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSenderPattern(t *testing.T) {
+	valid := map[string]string{
+		"News@Shop.com":   "news@shop.com",
+		" shop.com ":      "shop.com",
+		"@school.org":     "school.org",
+		"mail.school.org": "mail.school.org",
+	}
+	for input, want := range valid {
+		got, err := NormalizeSenderPattern(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got)
+	}
+
+	for _, input := range []string{"", "@", "localhost", "a@b@c.com", "news@", "Shop <news@shop.com>", ".com"} {
+		_, err := NormalizeSenderPattern(input)
+		assert.ErrorIs(t, err, ErrInvalidSenderPattern, input)
+	}
+}
+
+func TestEmailSenderRulesActionFor(t *testing.T) {
+	rules := EmailSenderRules{
+		{Pattern: "school.org", Action: EmailSenderDeny},
+		{Pattern: "teacher@school.org", Action: EmailSenderAllow},
+		{Pattern: "news@clinic.com", Action: EmailSenderDeny},
+		{Pattern: "clinic.com", Action: EmailSenderAllow},
+		{Pattern: "shop.com", Action: EmailSenderDeny},
+		{Pattern: "shop.com", Action: EmailSenderAllow},
+	}
+
+	assert.Equal(t, EmailSenderDeny, rules.ActionFor("office@school.org"))
+	assert.Equal(t, EmailSenderDeny, rules.ActionFor("office@mail.school.org"))
+	assert.Equal(t, EmailSenderAllow, rules.ActionFor("Teacher@School.org"))
+	assert.Equal(t, EmailSenderDeny, rules.ActionFor("news@clinic.com"))
+	assert.Equal(t, EmailSenderAllow, rules.ActionFor("doctor@clinic.com"))
+	assert.Equal(t, EmailSenderAllow, rules.ActionFor("orders@shop.com"))
+	assert.Equal(t, EmailSenderAction(""), rules.ActionFor("friend@notschool.org"))
+
+	assert.True(t, rules.Denies("office@school.org"))
+	assert.False(t, rules.Denies("friend@example.com"))
+	assert.False(t, EmailSenderRules(nil).Denies("office@school.org"))
+}
+
+func TestEmailSenderRulesCRUD(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+
+	deny, err := db.SetEmailSenderRule(user.ID, "@Newsletter.com", EmailSenderDeny)
+	require.NoError(t, err)
+	assert.Equal(t, "newsletter.com", deny.Pattern)
+	assert.Equal(t, EmailSenderDeny, deny.Action)
+
+	// Setting the same pattern again changes the existing rule's action
+	allow, err := db.SetEmailSenderRule(user.ID, "newsletter.com", EmailSenderAllow)
+	require.NoError(t, err)
+	assert.Equal(t, deny.ID, allow.ID)
+	assert.Equal(t, EmailSenderAllow, allow.Action)
+
+	_, err = db.SetEmailSenderRule(user.ID, "doctor@clinic.com", EmailSenderAllow)
+	require.NoError(t, err)
+	_, err = db.SetEmailSenderRule(user.ID, "shop.com", "ignore")
+	assert.ErrorIs(t, err, ErrInvalidSenderAction)
+	_, err = db.SetEmailSenderRule(other.ID, "newsletter.com", EmailSenderDeny)
+	require.NoError(t, err)
+
+	rules, err := db.ListEmailSenderRules(user.ID)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "doctor@clinic.com", rules[0].Pattern)
+	assert.Equal(t, "newsletter.com", rules[1].Pattern)
+
+	assert.ErrorIs(t, db.DeleteEmailSenderRule(other.ID, allow.ID), ErrEmailSenderRuleNotFound)
+	require.NoError(t, db.DeleteEmailSenderRule(user.ID, allow.ID))
+
+	rules, err = db.ListEmailSenderRules(user.ID)
+	require.NoError(t, err)
+	assert.Len(t, rules, 1)
+	otherRules, err := db.ListEmailSenderRules(other.ID)
+	require.NoError(t, err)
+	assert.Len(t, otherRules, 1)
+}
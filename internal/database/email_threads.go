@@ -0,0 +1,52 @@
+package database
+
+import "fmt"
+
+// SetEventEmailThread records the Gmail thread an event was detected in
+func (d *DB) SetEventEmailThread(eventID int64, threadID string) error {
+	_, err := d.Exec(`UPDATE calendar_events SET email_thread_id = ? WHERE id = ?`, threadID, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to set event email thread: %w", err)
+	}
+	return nil
+}
+
+// GetEventsForEmailThread returns the user's live (pending, confirmed or synced) events
+// detected in a Gmail thread, oldest first, so a reply can update them
+func (d *DB) GetEventsForEmailThread(userID int64, threadID string) ([]CalendarEvent, error) {
+	if threadID == "" {
+		return nil, nil
+	}
+
+	rows, err := d.Query(`
+		SELECT id FROM calendar_events
+		WHERE user_id = ? AND email_thread_id = ? AND status IN (?, ?, ?)
+		ORDER BY id ASC
+	`, userID, threadID, EventStatusPending, EventStatusConfirmed, EventStatusSynced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list thread events: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan thread event: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating thread events: %w", err)
+	}
+
+	events := make([]CalendarEvent, 0, len(ids))
+	for _, id := range ids {
+		event, err := d.GetEventByID(id)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEventsForEmailThread(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+	otherChannel := createTestChannel(t, db, other.ID)
+
+	create := func(userID, channelID int64, title, threadID string) *CalendarEvent {
+		t.Helper()
+		event, err := db.CreatePendingEvent(&CalendarEvent{
+			UserID:     userID,
+			ChannelID:  channelID,
+			CalendarID: "primary",
+			Title:      title,
+			StartTime:  time.Date(2030, 5, 16, 14, 0, 0, 0, time.UTC),
+			ActionType: EventActionCreate,
+		})
+		require.NoError(t, err)
+		if threadID != "" {
+			require.NoError(t, db.SetEventEmailThread(event.ID, threadID))
+		}
+		return event
+	}
+
+	review := create(user.ID, channel.ID, "Design review", "thread-1")
+	rejected := create(user.ID, channel.ID, "Old slot", "thread-1")
+	require.NoError(t, db.UpdateEventStatus(rejected.ID, EventStatusRejected))
+	create(user.ID, channel.ID, "Other thread", "thread-2")
+	create(user.ID, channel.ID, "No thread", "")
+	create(other.ID, otherChannel.ID, "Someone else's", "thread-1")
+
+	events, err := db.GetEventsForEmailThread(user.ID, "thread-1")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, review.ID, events[0].ID)
+
+	events, err = db.GetEventsForEmailThread(user.ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event correction kinds
+const (
+	EventCorrectionRejected = "rejected"
+	EventCorrectionEdited   = "edited"
+)
+
+// EventCorrection is a detected event the user rejected or changed, with the message it
+// was detected from, used as a few-shot example for the event agent
+type EventCorrection struct {
+	ID                 int64
+	EventID            int64
+	Kind               string
+	DetectedTitle      string
+	DetectedStartTime  time.Time
+	DetectedLocation   string
+	CorrectedTitle     string
+	CorrectedStartTime *time.Time
+	CorrectedLocation  string
+	// MessageText is the text of the event's original message ("" for emails or when pruned)
+	MessageText string
+	CreatedAt   time.Time
+}
+
+// IsMaterialEventEdit reports whether an edit changed what the agent got wrong: the
+// title or location beyond case and spacing, or the start time. Description-only edits
+// don't count.
+func IsMaterialEventEdit(before *CalendarEvent, title string, startTime time.Time, location string) bool {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), " "))
+	}
+	return normalize(before.Title) != normalize(title) ||
+		normalize(before.Location) != normalize(location) ||
+		!before.StartTime.Equal(startTime)
+}
+
+// RecordEventRejection stores a rejected detected event as a correction. If the user
+// edited it first, the originally detected values are kept.
+func (d *DB) RecordEventRejection(event *CalendarEvent) error {
+	_, err := d.Exec(`
+		INSERT INTO event_corrections (user_id, event_id, kind, detected_title, detected_start_time, detected_location)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(event_id) DO UPDATE SET
+			kind = excluded.kind,
+			corrected_title = NULL,
+			corrected_start_time = NULL,
+			corrected_location = NULL,
+			created_at = CURRENT_TIMESTAMP
+	`, event.UserID, event.ID, EventCorrectionRejected, event.Title, event.StartTime, event.Location)
+	if err != nil {
+		return fmt.Errorf("failed to record event rejection: %w", err)
+	}
+	return nil
+}
+
+// RecordEventEdit stores a user's change to a detected event's title, time or location.
+// before is the event prior to this edit; on later edits the values from the first
+// one, i.e. what the agent detected, are kept and only the corrected values change.
+func (d *DB) RecordEventEdit(before *CalendarEvent, title string, startTime time.Time, location string) error {
+	_, err := d.Exec(`
+		INSERT INTO event_corrections (user_id, event_id, kind, detected_title, detected_start_time, detected_location,
+			corrected_title, corrected_start_time, corrected_location)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(event_id) DO UPDATE SET
+			kind = excluded.kind,
+			corrected_title = excluded.corrected_title,
+			corrected_start_time = excluded.corrected_start_time,
+			corrected_location = excluded.corrected_location,
+			created_at = CURRENT_TIMESTAMP
+	`, before.UserID, before.ID, EventCorrectionEdited, before.Title, before.StartTime, before.Location,
+		title, startTime, location)
+	if err != nil {
+		return fmt.Errorf("failed to record event edit: %w", err)
+	}
+	return nil
+}
+
+// ListRecentEventCorrections returns the user's most recent corrections, newest first
+func (d *DB) ListRecentEventCorrections(userID int64, limit int) ([]EventCorrection, error) {
+	rows, err := d.Query(`
+		SELECT ec.id, ec.event_id, ec.kind, ec.detected_title, ec.detected_start_time,
+			COALESCE(ec.detected_location, ''), COALESCE(ec.corrected_title, ''), ec.corrected_start_time,
+			COALESCE(ec.corrected_location, ''), COALESCE(mh.message_text, ''), ec.created_at
+		FROM event_corrections ec
+		JOIN calendar_events e ON e.id = ec.event_id
+		LEFT JOIN message_history mh ON mh.id = e.original_message_id
+		WHERE ec.user_id = ?
+		ORDER BY ec.created_at DESC, ec.id DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event corrections: %w", err)
+	}
+	defer rows.Close()
+
+	var corrections []EventCorrection
+	for rows.Next() {
+		var c EventCorrection
+		var correctedStart sql.NullTime
+		if err := rows.Scan(&c.ID, &c.EventID, &c.Kind, &c.DetectedTitle, &c.DetectedStartTime,
+			&c.DetectedLocation, &c.CorrectedTitle, &correctedStart, &c.CorrectedLocation,
+			&c.MessageText, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event correction: %w", err)
+		}
+		if correctedStart.Valid {
+			c.CorrectedStartTime = &correctedStart.Time
+		}
+		if c.MessageText, err = d.decryptMessageText(c.MessageText); err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event corrections: %w", err)
+	}
+	return corrections, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsMaterialEventEdit(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	before := &CalendarEvent{Title: "Team  Sync", StartTime: start, Location: "Room A"}
+
+	assert.False(t, IsMaterialEventEdit(before, "team sync", start, " room a"))
+	assert.True(t, IsMaterialEventEdit(before, "Standup", start, "Room A"))
+	assert.True(t, IsMaterialEventEdit(before, "Team Sync", start.Add(30*time.Minute), "Room A"))
+	assert.True(t, IsMaterialEventEdit(before, "Team Sync", start, "Zoom"))
+}
+
+func TestEventCorrections(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	event, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Meeting",
+		StartTime:  start,
+		ActionType: EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.RecordEventEdit(event, "Sync", start, ""))
+	edited := *event
+	edited.Title = "Sync"
+	require.NoError(t, db.RecordEventEdit(&edited, "Standup", start.Add(time.Hour), "Zoom"))
+
+	corrections, err := db.ListRecentEventCorrections(user.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, corrections, 1, "one correction per event")
+	assert.Equal(t, EventCorrectionEdited, corrections[0].Kind)
+	assert.Equal(t, "Meeting", corrections[0].DetectedTitle, "keeps what the agent detected")
+	assert.Equal(t, "Standup", corrections[0].CorrectedTitle)
+	assert.Empty(t, corrections[0].MessageText, "no source message")
+
+	require.NoError(t, db.RecordEventRejection(&edited))
+	corrections, err = db.ListRecentEventCorrections(user.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, corrections, 1)
+	assert.Equal(t, EventCorrectionRejected, corrections[0].Kind)
+	assert.Equal(t, "Meeting", corrections[0].DetectedTitle)
+	assert.Empty(t, corrections[0].CorrectedTitle)
+	assert.Nil(t, corrections[0].CorrectedStartTime)
+
+	t.Run("scoped to the user", func(t *testing.T) {
+		other := CreateTestUser(t, db)
+		corrections, err := db.ListRecentEventCorrections(other.ID, 10)
+		require.NoError(t, err)
+		assert.Empty(t, corrections)
+	})
+
+	t.Run("opt-in defaults off", func(t *testing.T) {
+		enabled, err := db.IsCorrectionExamplesEnabled(user.ID)
+		require.NoError(t, err)
+		assert.False(t, enabled)
+
+		require.NoError(t, db.SetCorrectionExamplesEnabled(user.ID, true))
+		enabled, err = db.IsCorrectionExamplesEnabled(user.ID)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+	})
+}
@@ -0,0 +1,68 @@
+package database
+
+import "fmt"
+
+// EventToGeocode is an event whose location hasn't been looked up yet
+type EventToGeocode struct {
+	ID       int64
+	UserID   int64
+	Location string
+}
+
+// EventGeocode is where an event's location resolved to
+type EventGeocode struct {
+	Latitude  float64
+	Longitude float64
+	MapsURL   string
+}
+
+// ListEventsToGeocode returns up to limit pending, confirmed and synced events, newest
+// first, whose location has not been looked up since it was set
+func (d *DB) ListEventsToGeocode(limit int) ([]EventToGeocode, error) {
+	rows, err := d.Query(`
+		SELECT id, user_id, location
+		FROM calendar_events
+		WHERE geocoded_location IS NULL AND TRIM(COALESCE(location, '')) != ''
+		  AND status IN (?, ?, ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`, EventStatusPending, EventStatusConfirmed, EventStatusSynced, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events to geocode: %w", err)
+	}
+	defer rows.Close()
+
+	var events []EventToGeocode
+	for rows.Next() {
+		var e EventToGeocode
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Location); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+	return events, nil
+}
+
+// SaveEventGeocode records the lookup of an event's location. A nil geo means the
+// location matched no place. Nothing is saved if the location changed since it was
+// listed; the new one is looked up on the next run.
+func (d *DB) SaveEventGeocode(eventID int64, location string, geo *EventGeocode) error {
+	var latitude, longitude *float64
+	var mapsURL *string
+	if geo != nil {
+		latitude, longitude, mapsURL = &geo.Latitude, &geo.Longitude, &geo.MapsURL
+	}
+
+	_, err := d.Exec(`
+		UPDATE calendar_events
+		SET latitude = ?, longitude = ?, maps_url = ?, geocoded_location = ?
+		WHERE id = ? AND location = ?
+	`, latitude, longitude, mapsURL, location, eventID, location)
+	if err != nil {
+		return fmt.Errorf("failed to save event geocode: %w", err)
+	}
+	return nil
+}
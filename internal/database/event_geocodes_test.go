@@ -0,0 +1,103 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventGeocodes(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	create := func(title, location string) *CalendarEvent {
+		event, err := db.CreatePendingEvent(&CalendarEvent{
+			UserID:     user.ID,
+			ChannelID:  channel.ID,
+			CalendarID: "primary",
+			Title:      title,
+			StartTime:  time.Now().Add(24 * time.Hour),
+			Location:   location,
+			ActionType: EventActionCreate,
+		})
+		require.NoError(t, err)
+		return event
+	}
+
+	dinner := create("Dinner", "Luigi's, Dizengoff 99 Tel Aviv")
+	party := create("Party", "Dana's place")
+	create("Call", "")
+	create("Standup", "   ")
+	rejected := create("Rejected", "Somewhere")
+	require.NoError(t, db.UpdateEventStatus(rejected.ID, EventStatusRejected))
+
+	pending, err := db.ListEventsToGeocode(10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2, "events without a location and rejected events are skipped")
+	assert.Equal(t, party.ID, pending[0].ID, "newest first")
+	assert.Equal(t, user.ID, pending[0].UserID)
+	assert.Equal(t, "Luigi's, Dizengoff 99 Tel Aviv", pending[1].Location)
+
+	require.NoError(t, db.SaveEventGeocode(dinner.ID, dinner.Location, &EventGeocode{
+		Latitude:  32.0853,
+		Longitude: 34.7818,
+		MapsURL:   "https://www.google.com/maps/search/?api=1&query=32.0853,34.7818",
+	}))
+	require.NoError(t, db.SaveEventGeocode(party.ID, party.Location, nil))
+
+	t.Run("resolved events expose coordinates", func(t *testing.T) {
+		event, err := db.GetEventByID(dinner.ID)
+		require.NoError(t, err)
+		require.NotNil(t, event.Latitude)
+		require.NotNil(t, event.Longitude)
+		assert.Equal(t, 32.0853, *event.Latitude)
+		assert.Equal(t, 34.7818, *event.Longitude)
+		assert.Contains(t, event.MapsURL, "query=32.0853,34.7818")
+
+		events, _, err := db.ListEventsWithOptions(user.ID, nil, nil, ListOptions{})
+		require.NoError(t, err)
+		for _, e := range events {
+			if e.ID == dinner.ID {
+				assert.Equal(t, event.MapsURL, e.MapsURL)
+			}
+		}
+	})
+
+	t.Run("unmatched locations are not looked up again", func(t *testing.T) {
+		event, err := db.GetEventByID(party.ID)
+		require.NoError(t, err)
+		assert.Nil(t, event.Latitude)
+		assert.Empty(t, event.MapsURL)
+
+		pending, err := db.ListEventsToGeocode(10)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("editing the location clears the result", func(t *testing.T) {
+		require.NoError(t, db.UpdatePendingEvent(dinner.ID, "Dinner", "", dinner.StartTime, nil, "Luigi's, Dizengoff 99 Tel Aviv"))
+		event, err := db.GetEventByID(dinner.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, event.Latitude, "an unchanged location keeps its coordinates")
+
+		require.NoError(t, db.UpdatePendingEvent(dinner.ID, "Dinner", "", dinner.StartTime, nil, "Port Said, Tel Aviv"))
+		event, err = db.GetEventByID(dinner.ID)
+		require.NoError(t, err)
+		assert.Nil(t, event.Latitude)
+		assert.Empty(t, event.MapsURL)
+
+		pending, err := db.ListEventsToGeocode(10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, "Port Said, Tel Aviv", pending[0].Location)
+
+		// A lookup of the old location finishing late is dropped
+		require.NoError(t, db.SaveEventGeocode(dinner.ID, "Luigi's, Dizengoff 99 Tel Aviv", &EventGeocode{Latitude: 1, Longitude: 2}))
+		event, err = db.GetEventByID(dinner.ID)
+		require.NoError(t, err)
+		assert.Nil(t, event.Latitude)
+	})
+}
@@ -0,0 +1,238 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultEventNotifyOffsets is used for users without stored preferences
+	DefaultEventNotifyOffsets = "30"
+	// MaxEventNotifyOffsetMinutes bounds how far ahead of an event a notification can fire
+	MaxEventNotifyOffsetMinutes = 24 * 60
+	// eventStartNotificationGrace keeps just-started events in the window so an
+	// at-start (0 minute) notification still fires on the next poll
+	eventStartNotificationGrace = 5 * time.Minute
+)
+
+// UpcomingEventNotification is a confirmed/synced event starting within the notification
+// window, together with its effective pre-start offsets (in minutes)
+type UpcomingEventNotification struct {
+	Event   CalendarEvent
+	Offsets []int
+}
+
+// ParseNotifyOffsets parses a comma-separated list of minutes, ignoring invalid entries.
+// The result is deduplicated and sorted longest lead first.
+func ParseNotifyOffsets(raw string) []int {
+	var offsets []int
+	for _, part := range strings.Split(raw, ",") {
+		minutes, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		offsets = append(offsets, minutes)
+	}
+	return normalizeNotifyOffsets(offsets)
+}
+
+// FormatNotifyOffsets serializes offsets for storage
+func FormatNotifyOffsets(offsets []int) string {
+	offsets = normalizeNotifyOffsets(offsets)
+	parts := make([]string, len(offsets))
+	for i, minutes := range offsets {
+		parts[i] = strconv.Itoa(minutes)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ValidateNotifyOffsets checks offsets are within [0, MaxEventNotifyOffsetMinutes]
+func ValidateNotifyOffsets(offsets []int) error {
+	for _, minutes := range offsets {
+		if minutes < 0 || minutes > MaxEventNotifyOffsetMinutes {
+			return fmt.Errorf("offsets must be between 0 and %d minutes", MaxEventNotifyOffsetMinutes)
+		}
+	}
+	return nil
+}
+
+func normalizeNotifyOffsets(offsets []int) []int {
+	seen := make(map[int]bool)
+	result := []int{}
+	for _, minutes := range offsets {
+		if minutes < 0 || minutes > MaxEventNotifyOffsetMinutes || seen[minutes] {
+			continue
+		}
+		seen[minutes] = true
+		result = append(result, minutes)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(result)))
+	return result
+}
+
+// UpdateEventNotifyOffsets updates the user's default pre-start offsets for events
+func (d *DB) UpdateEventNotifyOffsets(userID int64, offsets []int) error {
+	if err := d.EnsureNotificationPrefs(userID); err != nil {
+		return err
+	}
+
+	_, err := d.Exec(`
+		UPDATE user_notification_preferences
+		SET event_notify_offsets = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ?
+	`, FormatNotifyOffsets(offsets), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update event notify offsets: %w", err)
+	}
+	return nil
+}
+
+// GetEventNotifyOffsetsOverride returns the per-event offsets override.
+// Returns nil when the event inherits the user's defaults.
+func (d *DB) GetEventNotifyOffsetsOverride(eventID int64) ([]int, error) {
+	var raw sql.NullString
+	err := d.QueryRow(`SELECT notify_offsets FROM calendar_events WHERE id = ?`, eventID).Scan(&raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event notify offsets: %w", err)
+	}
+	if !raw.Valid {
+		return nil, nil
+	}
+	return ParseNotifyOffsets(raw.String), nil
+}
+
+// SetEventNotifyOffsetsOverride stores per-event offsets. A nil slice clears the override;
+// an empty slice disables pre-start notifications for the event.
+func (d *DB) SetEventNotifyOffsetsOverride(eventID int64, offsets []int) error {
+	var value interface{}
+	if offsets != nil {
+		value = FormatNotifyOffsets(offsets)
+	}
+
+	_, err := d.Exec(`
+		UPDATE calendar_events SET notify_offsets = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, value, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to set event notify offsets: %w", err)
+	}
+	return nil
+}
+
+// GetUpcomingEventsForNotification retrieves up to limit confirmed/synced events with id >
+// afterID starting within MaxEventNotifyOffsetMinutes of now (or that just started), with
+// the effective offsets for each event. Events with notifications disabled are left out.
+// Results are ordered by id, so callers page through the window by passing the last id.
+func (d *DB) GetUpcomingEventsForNotification(now time.Time, afterID int64, limit int) ([]UpcomingEventNotification, error) {
+	query := `
+		SELECT e.id, e.user_id, e.channel_id, e.google_event_id, e.calendar_id, e.title,
+			e.description, e.start_time, e.end_time, e.location, e.status,
+			e.action_type, e.original_message_id, e.llm_reasoning, e.llm_confidence, e.quality_flags, e.created_at, e.updated_at,
+			COALESCE(c.name, 'Alfred') as channel_name,
+			COALESCE(e.notify_offsets, p.event_notify_offsets, ?) as offsets
+		FROM calendar_events e
+		LEFT JOIN channels c ON e.channel_id = c.id
+		LEFT JOIN user_notification_preferences p ON p.user_id = e.user_id
+		WHERE e.status IN (?, ?)
+		  AND e.start_time > ?
+		  AND e.start_time <= ?
+		  AND e.id > ?
+		  AND COALESCE(e.notify_offsets, p.event_notify_offsets, ?) != ''
+		ORDER BY e.id ASC
+		LIMIT ?
+	`
+
+	rows, err := d.Query(query, DefaultEventNotifyOffsets, EventStatusConfirmed, EventStatusSynced,
+		now.Add(-eventStartNotificationGrace), now.Add(MaxEventNotifyOffsetMinutes*time.Minute),
+		afterID, DefaultEventNotifyOffsets, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upcoming events for notification: %w", err)
+	}
+	defer rows.Close()
+
+	var upcoming []UpcomingEventNotification
+	for rows.Next() {
+		var event CalendarEvent
+		var googleEventID sql.NullString
+		var endTimeNull sql.NullTime
+		var origMsgIDNull sql.NullInt64
+		var qualityFlags sql.NullString
+		var offsets string
+
+		if err := rows.Scan(
+			&event.ID, &event.UserID, &event.ChannelID, &googleEventID, &event.CalendarID, &event.Title,
+			&event.Description, &event.StartTime, &endTimeNull, &event.Location, &event.Status,
+			&event.ActionType, &origMsgIDNull, &event.LLMReasoning, &event.LLMConfidence, &qualityFlags, &event.CreatedAt, &event.UpdatedAt,
+			&event.ChannelName, &offsets,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan upcoming event: %w", err)
+		}
+
+		if googleEventID.Valid {
+			event.GoogleEventID = &googleEventID.String
+		}
+		if endTimeNull.Valid {
+			event.EndTime = &endTimeNull.Time
+		}
+		if origMsgIDNull.Valid {
+			event.OriginalMsgID = &origMsgIDNull.Int64
+		}
+		event.QualityFlags = decodeQualityFlags(qualityFlags)
+
+		upcoming = append(upcoming, UpcomingEventNotification{Event: event, Offsets: ParseNotifyOffsets(offsets)})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating upcoming events: %w", err)
+	}
+
+	return upcoming, nil
+}
+
+// GetSentEventStartOffsets returns the offsets already notified for the event's current start time
+func (d *DB) GetSentEventStartOffsets(eventID int64, startTime time.Time) (map[int]bool, error) {
+	rows, err := d.Query(`
+		SELECT offset_minutes, start_time FROM event_start_notifications WHERE event_id = ?
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sent event notifications: %w", err)
+	}
+	defer rows.Close()
+
+	sent := make(map[int]bool)
+	for rows.Next() {
+		var minutes int
+		var sentFor time.Time
+		if err := rows.Scan(&minutes, &sentFor); err != nil {
+			return nil, fmt.Errorf("failed to scan sent event notification: %w", err)
+		}
+		if sentFor.Equal(startTime) {
+			sent[minutes] = true
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sent event notifications: %w", err)
+	}
+
+	return sent, nil
+}
+
+// MarkEventStartNotificationSent records that the offset notification was sent for the
+// given start time, replacing any record for an earlier schedule
+func (d *DB) MarkEventStartNotificationSent(eventID int64, offsetMinutes int, startTime, sentAt time.Time) error {
+	_, err := d.Exec(`
+		INSERT INTO event_start_notifications (event_id, offset_minutes, start_time, sent_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(event_id, offset_minutes) DO UPDATE SET
+			start_time = excluded.start_time,
+			sent_at = excluded.sent_at
+	`, eventID, offsetMinutes, startTime, sentAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark event notification sent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// EventSource is one channel message an event was detected from
+type EventSource struct {
+	SourceType  source.SourceType `json:"source_type"`
+	ChannelID   int64             `json:"channel_id"`
+	ChannelName string            `json:"channel_name"`
+	MessageID   *int64            `json:"message_id,omitempty"`
+	AddedAt     time.Time         `json:"added_at"`
+}
+
+// AddEventSource records another message describing an event detected elsewhere
+func (d *DB) AddEventSource(eventID int64, sourceType source.SourceType, channelID int64, messageID *int64) error {
+	_, err := d.Exec(`
+		INSERT INTO event_sources (event_id, source_type, channel_id, message_id)
+		VALUES (?, ?, ?, ?)
+	`, eventID, sourceType, channelID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to add event source: %w", err)
+	}
+	return nil
+}
+
+// GetEventSources returns every source of an event: its own channel and trigger message
+// first, then the sources merged into it, oldest first
+func (d *DB) GetEventSources(eventID int64) ([]EventSource, error) {
+	rows, err := d.Query(`
+		SELECT source_type, channel_id, channel_name, message_id, added_at FROM (
+			SELECT COALESCE(c.source_type, 'whatsapp') AS source_type, e.channel_id,
+				COALESCE(c.name, '') AS channel_name, e.original_message_id AS message_id,
+				e.created_at AS added_at, 0 AS position
+			FROM calendar_events e
+			LEFT JOIN channels c ON c.id = e.channel_id
+			WHERE e.id = ?
+			UNION ALL
+			SELECT s.source_type, s.channel_id, COALESCE(c.name, ''), s.message_id, s.created_at, s.id
+			FROM event_sources s
+			LEFT JOIN channels c ON c.id = s.channel_id
+			WHERE s.event_id = ?
+		)
+		ORDER BY position
+	`, eventID, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []EventSource
+	for rows.Next() {
+		var s EventSource
+		var messageID sql.NullInt64
+		if err := rows.Scan(&s.SourceType, &s.ChannelID, &s.ChannelName, &messageID, &s.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event source: %w", err)
+		}
+		if messageID.Valid {
+			s.MessageID = &messageID.Int64
+		}
+		sources = append(sources, s)
+	}
+	return sources, rows.Err()
+}
+
+// ListReconcileCandidates returns the user's live events (pending, confirmed or synced
+// creations, not shared copies) from channels other than excludeChannelID that start
+// within window of start. Another source's mention of the same event merges into one.
+// Start times keep the offset they were stored with, so they are compared as instants.
+func (d *DB) ListReconcileCandidates(userID, excludeChannelID int64, start time.Time, window time.Duration) ([]CalendarEvent, error) {
+	rows, err := d.Query(`
+		SELECT id, channel_id, title, start_time, status
+		FROM calendar_events
+		WHERE user_id = ? AND channel_id != ? AND action_type = ?
+		  AND status IN (?, ?, ?) AND shared_from_event_id IS NULL
+		  AND julianday(start_time) BETWEEN julianday(?) AND julianday(?)
+		ORDER BY created_at
+	`, userID, excludeChannelID, EventActionCreate,
+		EventStatusPending, EventStatusConfirmed, EventStatusSynced,
+		start.Add(-window), start.Add(window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconcile candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var events []CalendarEvent
+	for rows.Next() {
+		var e CalendarEvent
+		if err := rows.Scan(&e.ID, &e.ChannelID, &e.Title, &e.StartTime, &e.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan reconcile candidate: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSources(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	chat := createTestChannel(t, db, user.ID)
+	inbox, err := db.CreateSourceChannel(user.ID, source.SourceTypeGmail, source.ChannelTypeSender, "dana@example.com", "Dana (email)")
+	require.NoError(t, err)
+
+	// Stored in Israel time; candidates are looked up in UTC
+	start := time.Date(2026, 3, 13, 21, 0, 0, 0, time.FixedZone("IST", 2*60*60))
+	event, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  chat.ID,
+		CalendarID: "primary",
+		Title:      "Dinner",
+		StartTime:  start,
+		ActionType: EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	t.Run("candidates", func(t *testing.T) {
+		candidates, err := db.ListReconcileCandidates(user.ID, inbox.ID, time.Date(2026, 3, 13, 19, 20, 0, 0, time.UTC), 30*time.Minute)
+		require.NoError(t, err)
+		require.Len(t, candidates, 1)
+		assert.Equal(t, event.ID, candidates[0].ID)
+
+		candidates, err = db.ListReconcileCandidates(user.ID, chat.ID, start, 30*time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, candidates, "the event's own channel is excluded")
+
+		candidates, err = db.ListReconcileCandidates(user.ID, inbox.ID, start.Add(2*time.Hour), 30*time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, candidates)
+	})
+
+	t.Run("sources", func(t *testing.T) {
+		sources, err := db.GetEventSources(event.ID)
+		require.NoError(t, err)
+		require.Len(t, sources, 1)
+		assert.Equal(t, chat.ID, sources[0].ChannelID)
+
+		require.NoError(t, db.AddEventSource(event.ID, source.SourceTypeGmail, inbox.ID, nil))
+		got, err := db.GetEventByID(event.ID)
+		require.NoError(t, err)
+		require.Len(t, got.Sources, 2)
+		assert.Equal(t, source.SourceTypeGmail, got.Sources[1].SourceType)
+		assert.Equal(t, "Dana (email)", got.Sources[1].ChannelName)
+		assert.Nil(t, got.Sources[1].MessageID)
+	})
+}
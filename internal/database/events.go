@@ -51,6 +51,19 @@ type CalendarEvent struct {
 	// ChannelSourceType helps callers distinguish imported calendar events from Alfred-created ones.
 	ChannelSourceType string     `json:"channel_source_type,omitempty"` // Joined from channels.source_type
 	Attendees         []Attendee `json:"attendees,omitempty"`           // Participants for this event
+	// Shared marks an owner's event that is visible to members of its shared channel;
+	// SharedFromEventID links a member's copy back to the owner's event.
+	Shared            bool   `json:"shared"`
+	SharedFromEventID *int64 `json:"shared_from_event_id,omitempty"`
+	// Recurrence is an RRULE ("RRULE:FREQ=YEARLY") for repeating events, empty for one-off events
+	Recurrence string `json:"recurrence,omitempty"`
+	// Latitude, Longitude and MapsURL are set once the geocode worker resolved Location
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	MapsURL   string   `json:"maps_url,omitempty"`
+	// Sources lists the channel messages the event was detected from, its own first;
+	// set when the event is read by ID or listed
+	Sources []EventSource `json:"sources,omitempty"`
 }
 
 func decodeQualityFlags(raw sql.NullString) []string {
@@ -83,12 +96,13 @@ func (d *DB) CreatePendingEvent(event *CalendarEvent) (*CalendarEvent, error) {
 		INSERT INTO calendar_events (
 			user_id, channel_id, google_event_id, calendar_id, title, description,
 			start_time, end_time, location, status, action_type,
-			original_message_id, llm_reasoning, llm_confidence, quality_flags
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			original_message_id, llm_reasoning, llm_confidence, quality_flags, recurrence
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		event.UserID, event.ChannelID, event.GoogleEventID, event.CalendarID, event.Title, event.Description,
 		event.StartTime, event.EndTime, event.Location, EventStatusPending, event.ActionType,
 		event.OriginalMsgID, event.LLMReasoning, event.LLMConfidence, encodeQualityFlags(event.QualityFlags),
+		event.Recurrence,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create event: %w", err)
@@ -114,20 +128,24 @@ func (d *DB) GetEventByID(id int64) (*CalendarEvent, error) {
 	var endTimeNull sql.NullTime
 	var origMsgIDNull sql.NullInt64
 	var qualityFlags sql.NullString
+	var sharedFromNull sql.NullInt64
+	var latitude, longitude sql.NullFloat64
 
 	err := d.QueryRow(`
 		SELECT e.id, e.user_id, e.channel_id, e.google_event_id, e.calendar_id, e.title,
 			e.description, e.start_time, e.end_time, e.location, e.status,
-			e.action_type, e.original_message_id, e.llm_reasoning, e.llm_confidence, e.quality_flags, e.created_at, e.updated_at,
-			c.name as channel_name
+			e.action_type, e.original_message_id, e.llm_reasoning, e.llm_confidence, e.quality_flags,
+			e.shared, e.shared_from_event_id, COALESCE(e.recurrence, ''), e.created_at, e.updated_at,
+			e.latitude, e.longitude, COALESCE(e.maps_url, ''), c.name as channel_name
 		FROM calendar_events e
 		JOIN channels c ON e.channel_id = c.id
 		WHERE e.id = ?
 	`, id).Scan(
 		&event.ID, &event.UserID, &event.ChannelID, &googleEventID, &event.CalendarID, &event.Title,
 		&event.Description, &event.StartTime, &endTimeNull, &event.Location, &event.Status,
-		&event.ActionType, &origMsgIDNull, &event.LLMReasoning, &event.LLMConfidence, &qualityFlags, &event.CreatedAt, &event.UpdatedAt,
-		&event.ChannelName,
+		&event.ActionType, &origMsgIDNull, &event.LLMReasoning, &event.LLMConfidence, &qualityFlags,
+		&event.Shared, &sharedFromNull, &event.Recurrence, &event.CreatedAt, &event.UpdatedAt,
+		&latitude, &longitude, &event.MapsURL, &event.ChannelName,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get event: %w", err)
@@ -142,6 +160,12 @@ func (d *DB) GetEventByID(id int64) (*CalendarEvent, error) {
 	if origMsgIDNull.Valid {
 		event.OriginalMsgID = &origMsgIDNull.Int64
 	}
+	if sharedFromNull.Valid {
+		event.SharedFromEventID = &sharedFromNull.Int64
+	}
+	if latitude.Valid && longitude.Valid {
+		event.Latitude, event.Longitude = &latitude.Float64, &longitude.Float64
+	}
 	event.QualityFlags = decodeQualityFlags(qualityFlags)
 
 	// Fetch attendees for this event
@@ -151,6 +175,10 @@ func (d *DB) GetEventByID(id int64) (*CalendarEvent, error) {
 	}
 	event.Attendees = attendees
 
+	if event.Sources, err = d.GetEventSources(id); err != nil {
+		return nil, err
+	}
+
 	return &event, nil
 }
 
@@ -246,34 +274,59 @@ func (d *DB) GetEventByGoogleIDForUser(userID int64, googleEventID string) (*Cal
 	return &event, nil
 }
 
+// eventSortFields maps sortable event fields to their columns
+var eventSortFields = map[string]string{
+	"start_time": "e.start_time",
+	"created_at": "e.created_at",
+	"updated_at": "e.updated_at",
+	"title":      "e.title",
+}
+
 // ListEvents retrieves events for a user with optional filtering by status and channel
 func (d *DB) ListEvents(userID int64, status *EventStatus, channelID *int64) ([]CalendarEvent, error) {
-	query := `
-		SELECT e.id, e.user_id, e.channel_id, e.google_event_id, e.calendar_id, e.title,
-			e.description, e.start_time, e.end_time, e.location, e.status,
-			e.action_type, e.original_message_id, e.llm_reasoning, e.llm_confidence, e.quality_flags, e.created_at, e.updated_at,
-			c.name as channel_name
-		FROM calendar_events e
-		JOIN channels c ON e.channel_id = c.id
-		WHERE e.user_id = ?
-	`
+	events, _, err := d.ListEventsWithOptions(userID, status, channelID, ListOptions{})
+	return events, err
+}
+
+// ListEventsWithOptions retrieves a page of events for a user. From/To filter on start_time.
+// Returns the page and the total number of events matching the filters.
+func (d *DB) ListEventsWithOptions(userID int64, status *EventStatus, channelID *int64, opts ListOptions) ([]CalendarEvent, int, error) {
+	orderBy, err := opts.orderBy(eventSortFields, "e.created_at DESC")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where := " WHERE e.user_id = ?"
 	args := []any{userID}
 
 	if status != nil {
-		query += " AND e.status = ?"
+		where += " AND e.status = ?"
 		args = append(args, *status)
 	}
 
 	if channelID != nil {
-		query += " AND e.channel_id = ?"
+		where += " AND e.channel_id = ?"
 		args = append(args, *channelID)
 	}
 
-	query += " ORDER BY e.created_at DESC"
+	where, args = opts.dateRange("e.start_time", where, args)
+	where, args = opts.labelFilter("e.channel_id", where, args)
 
-	rows, err := d.Query(query, args...)
+	query := `
+		SELECT e.id, e.user_id, e.channel_id, e.google_event_id, e.calendar_id, e.title,
+			e.description, e.start_time, e.end_time, e.location, e.status,
+			e.action_type, e.original_message_id, e.llm_reasoning, e.llm_confidence, e.quality_flags,
+			e.shared, e.shared_from_event_id, COALESCE(e.recurrence, ''), e.created_at, e.updated_at,
+			e.latitude, e.longitude, COALESCE(e.maps_url, ''), c.name as channel_name
+		FROM calendar_events e
+		JOIN channels c ON e.channel_id = c.id
+	` + where + " ORDER BY " + orderBy + ", e.id DESC"
+
+	limit, limitArgs := opts.limitClause()
+
+	rows, err := d.Query(query+limit, append(append([]any{}, args...), limitArgs...)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list events: %w", err)
+		return nil, 0, fmt.Errorf("failed to list events: %w", err)
 	}
 	defer rows.Close()
 
@@ -284,14 +337,17 @@ func (d *DB) ListEvents(userID int64, status *EventStatus, channelID *int64) ([]
 		var endTimeNull sql.NullTime
 		var origMsgIDNull sql.NullInt64
 		var qualityFlags sql.NullString
+		var sharedFromNull sql.NullInt64
+		var latitude, longitude sql.NullFloat64
 
 		if err := rows.Scan(
 			&event.ID, &event.UserID, &event.ChannelID, &googleEventID, &event.CalendarID, &event.Title,
 			&event.Description, &event.StartTime, &endTimeNull, &event.Location, &event.Status,
-			&event.ActionType, &origMsgIDNull, &event.LLMReasoning, &event.LLMConfidence, &qualityFlags, &event.CreatedAt, &event.UpdatedAt,
-			&event.ChannelName,
+			&event.ActionType, &origMsgIDNull, &event.LLMReasoning, &event.LLMConfidence, &qualityFlags,
+			&event.Shared, &sharedFromNull, &event.Recurrence, &event.CreatedAt, &event.UpdatedAt,
+			&latitude, &longitude, &event.MapsURL, &event.ChannelName,
 		); err != nil {
-			return nil, fmt.Errorf("failed to scan event: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan event: %w", err)
 		}
 
 		if googleEventID.Valid {
@@ -303,25 +359,43 @@ func (d *DB) ListEvents(userID int64, status *EventStatus, channelID *int64) ([]
 		if origMsgIDNull.Valid {
 			event.OriginalMsgID = &origMsgIDNull.Int64
 		}
+		if sharedFromNull.Valid {
+			event.SharedFromEventID = &sharedFromNull.Int64
+		}
+		if latitude.Valid && longitude.Valid {
+			event.Latitude, event.Longitude = &latitude.Float64, &longitude.Float64
+		}
 		event.QualityFlags = decodeQualityFlags(qualityFlags)
 
 		events = append(events, event)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating events: %w", err)
+		return nil, 0, fmt.Errorf("error iterating events: %w", err)
 	}
 
-	// Fetch attendees for each event
+	// Fetch attendees and sources for each event
 	for i := range events {
 		attendees, err := d.GetEventAttendees(events[i].ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get attendees for event %d: %w", events[i].ID, err)
+			return nil, 0, fmt.Errorf("failed to get attendees for event %d: %w", events[i].ID, err)
 		}
 		events[i].Attendees = attendees
+
+		if events[i].Sources, err = d.GetEventSources(events[i].ID); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	return events, nil
+	total := len(events)
+	if opts.paged() {
+		countQuery := `SELECT COUNT(*) FROM calendar_events e JOIN channels c ON e.channel_id = c.id` + where
+		if err := d.QueryRow(countQuery, args...).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("failed to count events: %w", err)
+		}
+	}
+
+	return events, total, nil
 }
 
 // GetPendingEvents retrieves all pending events for a user, optionally filtered by channel
@@ -361,13 +435,15 @@ func (d *DB) UpdateSyncedEventFromGoogle(id int64, title, description string, st
 	return nil
 }
 
-// UpdateEventStatus updates the status of an event
+// UpdateEventStatus updates the status of an event. Leaving pending records reviewed_at.
 func (d *DB) UpdateEventStatus(id int64, status EventStatus) error {
 	_, err := d.Exec(`
 		UPDATE calendar_events
-		SET status = ?, updated_at = CURRENT_TIMESTAMP
+		SET status = ?,
+			reviewed_at = CASE WHEN status = 'pending' AND ? != 'pending' THEN CURRENT_TIMESTAMP ELSE reviewed_at END,
+			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, status, id)
+	`, status, status, id)
 	if err != nil {
 		return fmt.Errorf("failed to update event status: %w", err)
 	}
@@ -378,7 +454,9 @@ func (d *DB) UpdateEventStatus(id int64, status EventStatus) error {
 func (d *DB) UpdateEventGoogleID(id int64, googleEventID string) error {
 	_, err := d.Exec(`
 		UPDATE calendar_events
-		SET google_event_id = ?, status = ?, updated_at = CURRENT_TIMESTAMP
+		SET google_event_id = ?, status = ?,
+			reviewed_at = CASE WHEN status = 'pending' THEN CURRENT_TIMESTAMP ELSE reviewed_at END,
+			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`, googleEventID, EventStatusSynced, id)
 	if err != nil {
@@ -387,6 +465,17 @@ func (d *DB) UpdateEventGoogleID(id int64, googleEventID string) error {
 	return nil
 }
 
+// UpdateEventCalendarID moves an event to another calendar before it is synced
+func (d *DB) UpdateEventCalendarID(id int64, calendarID string) error {
+	_, err := d.Exec(`
+		UPDATE calendar_events SET calendar_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, calendarID, id)
+	if err != nil {
+		return fmt.Errorf("failed to update event calendar: %w", err)
+	}
+	return nil
+}
+
 // DeleteEvent removes an event from the database
 func (d *DB) DeleteEvent(id int64) error {
 	_, err := d.Exec(`DELETE FROM calendar_events WHERE id = ?`, id)
@@ -545,6 +634,68 @@ func (d *DB) GetTodayEvents(userID int64) ([]CalendarEvent, error) {
 	return events, nil
 }
 
+// ListEventsInRange retrieves a user's pending, confirmed and synced events across all
+// channels that overlap [start, end), ordered by start time
+func (d *DB) ListEventsInRange(userID int64, start, end time.Time, limit int) ([]CalendarEvent, error) {
+	query := `
+		SELECT e.id, e.user_id, e.channel_id, e.google_event_id, e.calendar_id, e.title,
+			e.description, e.start_time, e.end_time, e.location, e.status,
+			e.action_type, e.original_message_id, e.llm_reasoning, e.llm_confidence, e.quality_flags, e.created_at, e.updated_at,
+			COALESCE(c.name, 'Alfred') as channel_name,
+			COALESCE(c.source_type, 'whatsapp') as channel_source_type
+		FROM calendar_events e
+		LEFT JOIN channels c ON e.channel_id = c.id
+		WHERE e.user_id = ? AND e.status IN (?, ?, ?)
+		  AND e.start_time < ?
+		  AND COALESCE(e.end_time, e.start_time) >= ?
+		ORDER BY e.start_time ASC
+		LIMIT ?
+	`
+
+	rows, err := d.Query(query, userID, EventStatusPending, EventStatusConfirmed, EventStatusSynced, end, start, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events in range: %w", err)
+	}
+	defer rows.Close()
+
+	var events []CalendarEvent
+	for rows.Next() {
+		var event CalendarEvent
+		var googleEventID sql.NullString
+		var endTimeNull sql.NullTime
+		var origMsgIDNull sql.NullInt64
+		var qualityFlags sql.NullString
+
+		if err := rows.Scan(
+			&event.ID, &event.UserID, &event.ChannelID, &googleEventID, &event.CalendarID, &event.Title,
+			&event.Description, &event.StartTime, &endTimeNull, &event.Location, &event.Status,
+			&event.ActionType, &origMsgIDNull, &event.LLMReasoning, &event.LLMConfidence, &qualityFlags, &event.CreatedAt, &event.UpdatedAt,
+			&event.ChannelName, &event.ChannelSourceType,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		if googleEventID.Valid {
+			event.GoogleEventID = &googleEventID.String
+		}
+		if endTimeNull.Valid {
+			event.EndTime = &endTimeNull.Time
+		}
+		if origMsgIDNull.Valid {
+			event.OriginalMsgID = &origMsgIDNull.Int64
+		}
+		event.QualityFlags = decodeQualityFlags(qualityFlags)
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+
+	return events, nil
+}
+
 // ListSyncedEventsWithGoogleID returns events that are linked to Google Calendar for a user.
 func (d *DB) ListSyncedEventsWithGoogleID(userID int64) ([]CalendarEvent, error) {
 	query := `
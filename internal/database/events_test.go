@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -519,6 +520,60 @@ func TestGetActiveEventsForChannel(t *testing.T) {
 	})
 }
 
+func TestListEventsInRange(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+	otherChannel, err := db.CreateSourceChannel(user.ID, source.SourceTypeTelegram, source.ChannelTypeSender, "12345", "Other Chat")
+	require.NoError(t, err)
+
+	day := time.Date(2030, 5, 10, 0, 0, 0, 0, time.Local)
+	create := func(channelID int64, title string, start time.Time, end *time.Time, status EventStatus) {
+		created, err := db.CreatePendingEvent(&CalendarEvent{
+			UserID:     user.ID,
+			ChannelID:  channelID,
+			CalendarID: "primary",
+			Title:      title,
+			StartTime:  start,
+			EndTime:    end,
+			ActionType: EventActionCreate,
+		})
+		require.NoError(t, err)
+		if status != EventStatusPending {
+			require.NoError(t, db.UpdateEventStatus(created.ID, status))
+		}
+	}
+
+	overnightEnd := day.Add(2 * time.Hour)
+	create(channel.ID, "Overnight", day.Add(-2*time.Hour), &overnightEnd, EventStatusSynced)
+	create(otherChannel.ID, "Dinner", day.Add(20*time.Hour), nil, EventStatusPending)
+	create(channel.ID, "Rejected", day.Add(12*time.Hour), nil, EventStatusRejected)
+	create(channel.ID, "Next day", day.Add(30*time.Hour), nil, EventStatusConfirmed)
+
+	t.Run("returns active events overlapping the range across channels", func(t *testing.T) {
+		events, err := db.ListEventsInRange(user.ID, day, day.AddDate(0, 0, 1), 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "Overnight", events[0].Title)
+		assert.Equal(t, "Dinner", events[1].Title)
+		assert.Equal(t, "Other Chat", events[1].ChannelName)
+	})
+
+	t.Run("respects limit", func(t *testing.T) {
+		events, err := db.ListEventsInRange(user.ID, day, day.AddDate(0, 0, 2), 1)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "Overnight", events[0].Title)
+	})
+
+	t.Run("empty for different user", func(t *testing.T) {
+		user2 := CreateTestUser(t, db)
+		events, err := db.ListEventsInRange(user2.ID, day, day.AddDate(0, 0, 2), 10)
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+}
+
 func TestUpdateEventGoogleID(t *testing.T) {
 	db := NewTestDB(t)
 	user := CreateTestUser(t, db)
@@ -842,3 +897,185 @@ func TestListSyncedEventsWithGoogleID(t *testing.T) {
 		assert.Contains(t, []EventStatus{EventStatusSynced, EventStatusConfirmed}, event.Status)
 	}
 }
+
+func TestNotifyOffsetsParseAndFormat(t *testing.T) {
+	assert.Equal(t, []int{60, 30, 0}, ParseNotifyOffsets("30, 0,60,30,abc,-5"))
+	assert.Equal(t, []int{}, ParseNotifyOffsets(""))
+	assert.Equal(t, "120,15", FormatNotifyOffsets([]int{15, 120, 15}))
+	assert.Equal(t, "", FormatNotifyOffsets(nil))
+
+	assert.NoError(t, ValidateNotifyOffsets([]int{0, MaxEventNotifyOffsetMinutes}))
+	assert.Error(t, ValidateNotifyOffsets([]int{-1}))
+	assert.Error(t, ValidateNotifyOffsets([]int{MaxEventNotifyOffsetMinutes + 1}))
+}
+
+func TestGetUpcomingEventsForNotification(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	createEvent := func(title string, start time.Time, status EventStatus) *CalendarEvent {
+		event, err := db.CreatePendingEvent(&CalendarEvent{
+			UserID:     user.ID,
+			ChannelID:  channel.ID,
+			CalendarID: "primary",
+			Title:      title,
+			StartTime:  start,
+			ActionType: EventActionCreate,
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateEventStatus(event.ID, status))
+		return event
+	}
+
+	now := time.Now()
+	soon := createEvent("Dentist", now.Add(20*time.Minute), EventStatusConfirmed)
+	createEvent("Pending", now.Add(20*time.Minute), EventStatusPending)
+	createEvent("Next week", now.Add(7*24*time.Hour), EventStatusSynced)
+	overridden := createEvent("Flight", now.Add(2*time.Hour), EventStatusSynced)
+	require.NoError(t, db.SetEventNotifyOffsetsOverride(overridden.ID, []int{180, 60}))
+
+	upcoming, err := db.GetUpcomingEventsForNotification(now, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, upcoming, 2)
+	assert.Equal(t, soon.ID, upcoming[0].Event.ID)
+	assert.Equal(t, []int{30}, upcoming[0].Offsets)
+	assert.Equal(t, overridden.ID, upcoming[1].Event.ID)
+	assert.Equal(t, []int{180, 60}, upcoming[1].Offsets)
+
+	// User defaults apply to events without an override; an empty override disables.
+	require.NoError(t, db.UpdateEventNotifyOffsets(user.ID, []int{10, 45}))
+	require.NoError(t, db.SetEventNotifyOffsetsOverride(overridden.ID, []int{}))
+
+	upcoming, err = db.GetUpcomingEventsForNotification(now, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, upcoming, 1)
+	assert.Equal(t, []int{45, 10}, upcoming[0].Offsets)
+
+	// Clearing the override inherits the user defaults again.
+	require.NoError(t, db.SetEventNotifyOffsetsOverride(overridden.ID, nil))
+
+	// The window is read in batches, continuing after the last id
+	upcoming, err = db.GetUpcomingEventsForNotification(now, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, upcoming, 1)
+	assert.Equal(t, soon.ID, upcoming[0].Event.ID)
+	upcoming, err = db.GetUpcomingEventsForNotification(now, soon.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, upcoming, 1)
+	assert.Equal(t, overridden.ID, upcoming[0].Event.ID)
+	assert.Equal(t, []int{45, 10}, upcoming[0].Offsets)
+	upcoming, err = db.GetUpcomingEventsForNotification(now, overridden.ID, 1)
+	require.NoError(t, err)
+	assert.Empty(t, upcoming)
+	override, err := db.GetEventNotifyOffsetsOverride(overridden.ID)
+	require.NoError(t, err)
+	assert.Nil(t, override)
+}
+
+func TestMarkEventStartNotificationSent_TracksStartTime(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	start := time.Now().Add(time.Hour).Truncate(time.Second)
+	event, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Standup",
+		StartTime:  start,
+		ActionType: EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.MarkEventStartNotificationSent(event.ID, 30, start, time.Now()))
+
+	sent, err := db.GetSentEventStartOffsets(event.ID, start)
+	require.NoError(t, err)
+	assert.True(t, sent[30])
+	assert.False(t, sent[10])
+
+	// A rescheduled event is no longer considered notified.
+	sent, err = db.GetSentEventStartOffsets(event.ID, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, sent[30])
+
+	require.NoError(t, db.MarkEventStartNotificationSent(event.ID, 30, start.Add(time.Hour), time.Now()))
+	sent, err = db.GetSentEventStartOffsets(event.ID, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, sent[30])
+}
+
+func TestListEventsWithOptions(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		event, err := db.CreatePendingEvent(&CalendarEvent{
+			UserID:     user.ID,
+			ChannelID:  channel.ID,
+			CalendarID: "primary",
+			Title:      fmt.Sprintf("Event %d", i),
+			StartTime:  base.AddDate(0, 0, i),
+			ActionType: EventActionCreate,
+		})
+		require.NoError(t, err)
+		ids = append(ids, event.ID)
+	}
+
+	t.Run("pages in requested order", func(t *testing.T) {
+		opts := ListOptions{Limit: 2, Sort: "start_time"}
+		page, total, err := db.ListEventsWithOptions(user.ID, nil, nil, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 5, total)
+		require.Len(t, page, 2)
+		assert.Equal(t, ids[0], page[0].ID)
+		assert.Equal(t, ids[1], page[1].ID)
+
+		opts.Offset = 4
+		page, total, err = db.ListEventsWithOptions(user.ID, nil, nil, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 5, total)
+		require.Len(t, page, 1)
+		assert.Equal(t, ids[4], page[0].ID)
+	})
+
+	t.Run("offset without a limit counts the total", func(t *testing.T) {
+		page, total, err := db.ListEventsWithOptions(user.ID, nil, nil, ListOptions{Offset: 3})
+		require.NoError(t, err)
+		assert.Equal(t, 5, total)
+		assert.Len(t, page, 2)
+
+		page, total, err = db.ListEventsWithOptions(user.ID, nil, nil, ListOptions{Offset: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 5, total)
+		assert.Empty(t, page)
+	})
+
+	t.Run("descending sort", func(t *testing.T) {
+		page, _, err := db.ListEventsWithOptions(user.ID, nil, nil, ListOptions{Limit: 1, Sort: "-start_time"})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, ids[4], page[0].ID)
+	})
+
+	t.Run("date range on start time", func(t *testing.T) {
+		from := base.AddDate(0, 0, 1)
+		to := base.AddDate(0, 0, 3)
+		page, total, err := db.ListEventsWithOptions(user.ID, nil, nil, ListOptions{Limit: 10, Sort: "start_time", From: &from, To: &to})
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, page, 2)
+		assert.Equal(t, ids[1], page[0].ID)
+		assert.Equal(t, ids[2], page[1].ID)
+	})
+
+	t.Run("rejects unknown sort field", func(t *testing.T) {
+		_, _, err := db.ListEventsWithOptions(user.ID, nil, nil, ListOptions{Sort: "llm_reasoning"})
+		assert.ErrorIs(t, err, ErrInvalidSort)
+	})
+}
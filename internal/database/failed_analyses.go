@@ -0,0 +1,201 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FailedAnalysisStatus is where a failed analysis is in its retry lifecycle
+type FailedAnalysisStatus string
+
+const (
+	FailedAnalysisPending  FailedAnalysisStatus = "pending"  // waiting for its next retry
+	FailedAnalysisResolved FailedAnalysisStatus = "resolved" // a retry succeeded
+	FailedAnalysisDead     FailedAnalysisStatus = "dead"     // retries exhausted
+)
+
+// FailedAnalysis is an intent analysis whose agent call failed, kept with its input so
+// it can be retried
+type FailedAnalysis struct {
+	ID               int64                `json:"id"`
+	UserID           int64                `json:"user_id"`
+	ChannelID        int64                `json:"channel_id"`
+	SourceType       string               `json:"source_type"`
+	Intent           string               `json:"intent"`
+	TriggerMessageID *int64               `json:"trigger_message_id,omitempty"`
+	Input            string               `json:"-"` // intent input JSON
+	Status           FailedAnalysisStatus `json:"status"`
+	Attempts         int                  `json:"attempts"`
+	NextAttemptAt    *time.Time           `json:"next_attempt_at,omitempty"`
+	LastError        string               `json:"last_error,omitempty"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+// CreateFailedAnalysis records an analysis that failed its first attempt, to be retried
+// at nextAttemptAt. The input is encrypted like message_history.
+func (d *DB) CreateFailedAnalysis(f FailedAnalysis, nextAttemptAt time.Time) (int64, error) {
+	input, err := d.encryptMessageText(f.Input)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := d.Exec(`
+		INSERT INTO failed_analyses (user_id, channel_id, source_type, intent, trigger_message_id, input, status, attempts, next_attempt_at, last_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?, ?)
+	`, f.UserID, f.ChannelID, f.SourceType, f.Intent, f.TriggerMessageID, input, FailedAnalysisPending, nextAttemptAt.UTC(), f.LastError)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record failed analysis: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+const failedAnalysisColumns = `id, user_id, channel_id, source_type, intent, trigger_message_id, input, status, attempts,
+	next_attempt_at, last_error, created_at, updated_at`
+
+func (d *DB) scanFailedAnalysis(scanner interface{ Scan(...any) error }) (*FailedAnalysis, error) {
+	var f FailedAnalysis
+	var triggerMessageID sql.NullInt64
+	var nextAttemptAt sql.NullTime
+	var lastError sql.NullString
+	var input string
+	if err := scanner.Scan(
+		&f.ID, &f.UserID, &f.ChannelID, &f.SourceType, &f.Intent, &triggerMessageID, &input, &f.Status, &f.Attempts,
+		&nextAttemptAt, &lastError, &f.CreatedAt, &f.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if triggerMessageID.Valid {
+		f.TriggerMessageID = &triggerMessageID.Int64
+	}
+	if nextAttemptAt.Valid {
+		f.NextAttemptAt = &nextAttemptAt.Time
+	}
+	f.LastError = lastError.String
+
+	decrypted, err := d.decryptMessageText(input)
+	if err != nil {
+		return nil, err
+	}
+	f.Input = decrypted
+	return &f, nil
+}
+
+// GetDueFailedAnalyses returns pending failed analyses whose next retry is due, oldest first
+func (d *DB) GetDueFailedAnalyses(now time.Time, limit int) ([]FailedAnalysis, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	return d.queryFailedAnalyses(`
+		SELECT `+failedAnalysisColumns+`
+		FROM failed_analyses
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC, id ASC
+		LIMIT ?
+	`, FailedAnalysisPending, now.UTC(), limit)
+}
+
+// ListFailedAnalyses returns the most recent failed analyses across all users, optionally
+// only those with status
+func (d *DB) ListFailedAnalyses(status *FailedAnalysisStatus, limit int) ([]FailedAnalysis, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `SELECT ` + failedAnalysisColumns + ` FROM failed_analyses`
+	args := []any{}
+	if status != nil {
+		query += ` WHERE status = ?`
+		args = append(args, *status)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+	return d.queryFailedAnalyses(query, args...)
+}
+
+func (d *DB) queryFailedAnalyses(query string, args ...any) ([]FailedAnalysis, error) {
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed analyses: %w", err)
+	}
+	defer rows.Close()
+
+	analyses := []FailedAnalysis{}
+	for rows.Next() {
+		f, err := d.scanFailedAnalysis(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan failed analysis: %w", err)
+		}
+		analyses = append(analyses, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating failed analyses: %w", err)
+	}
+	return analyses, nil
+}
+
+// GetFailedAnalysis returns a failed analysis by ID
+func (d *DB) GetFailedAnalysis(id int64) (*FailedAnalysis, error) {
+	f, err := d.scanFailedAnalysis(d.QueryRow(`
+		SELECT `+failedAnalysisColumns+` FROM failed_analyses WHERE id = ?
+	`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed analysis: %w", err)
+	}
+	return f, nil
+}
+
+// RecordFailedAnalysisAttempt stores the outcome of a retry and moves the analysis to
+// status. nextAttemptAt schedules the next retry for pending analyses and is ignored
+// otherwise.
+func (d *DB) RecordFailedAnalysisAttempt(id int64, attempts int, status FailedAnalysisStatus, lastError string, nextAttemptAt *time.Time) error {
+	var errValue any
+	if lastError != "" {
+		errValue = lastError
+	}
+	var next any
+	if status == FailedAnalysisPending && nextAttemptAt != nil {
+		next = nextAttemptAt.UTC()
+	}
+
+	_, err := d.Exec(`
+		UPDATE failed_analyses
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = COALESCE(?, last_error), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, status, attempts, next, errValue, id)
+	if err != nil {
+		return fmt.Errorf("failed to record failed analysis attempt: %w", err)
+	}
+	return nil
+}
+
+// ScheduleFailedAnalysisRetry makes a failed analysis due for retry at now, reviving it
+// if its retries were exhausted. Returns sql.ErrNoRows if there is no such analysis and
+// false if it was already resolved.
+func (d *DB) ScheduleFailedAnalysisRetry(id int64, now time.Time) (bool, error) {
+	result, err := d.Exec(`
+		UPDATE failed_analyses
+		SET status = ?, next_attempt_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status != ?
+	`, FailedAnalysisPending, now.UTC(), id, FailedAnalysisResolved)
+	if err != nil {
+		return false, fmt.Errorf("failed to schedule failed analysis retry: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to schedule failed analysis retry: %w", err)
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	var exists bool
+	if err := d.QueryRow(`SELECT EXISTS(SELECT 1 FROM failed_analyses WHERE id = ?)`, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to schedule failed analysis retry: %w", err)
+	}
+	if !exists {
+		return false, sql.ErrNoRows
+	}
+	return false, nil
+}
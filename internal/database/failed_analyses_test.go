@@ -0,0 +1,76 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedAnalyses(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannelForMessages(t, db, user.ID)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	msgID := int64(42)
+	id, err := db.CreateFailedAnalysis(FailedAnalysis{
+		UserID:           user.ID,
+		ChannelID:        channel.ID,
+		SourceType:       "whatsapp",
+		Intent:           "event",
+		TriggerMessageID: &msgID,
+		Input:            `{"new_message":{"id":42}}`,
+		LastError:        "context deadline exceeded",
+	}, now.Add(time.Minute))
+	require.NoError(t, err)
+
+	f, err := db.GetFailedAnalysis(id)
+	require.NoError(t, err)
+	assert.Equal(t, FailedAnalysisPending, f.Status)
+	assert.Equal(t, 1, f.Attempts)
+	assert.Equal(t, `{"new_message":{"id":42}}`, f.Input)
+	require.NotNil(t, f.TriggerMessageID)
+	assert.Equal(t, msgID, *f.TriggerMessageID)
+
+	t.Run("due once its retry time passes", func(t *testing.T) {
+		due, err := db.GetDueFailedAnalyses(now, 10)
+		require.NoError(t, err)
+		assert.Empty(t, due)
+
+		due, err = db.GetDueFailedAnalyses(now.Add(time.Minute), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, id, due[0].ID)
+	})
+
+	t.Run("filters by status", func(t *testing.T) {
+		require.NoError(t, db.RecordFailedAnalysisAttempt(id, 2, FailedAnalysisDead, "overloaded", nil))
+		dead := FailedAnalysisDead
+		list, err := db.ListFailedAnalyses(&dead, 10)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, "overloaded", list[0].LastError)
+
+		pending := FailedAnalysisPending
+		list, err = db.ListFailedAnalyses(&pending, 10)
+		require.NoError(t, err)
+		assert.Empty(t, list)
+	})
+
+	t.Run("manual retry", func(t *testing.T) {
+		scheduled, err := db.ScheduleFailedAnalysisRetry(id, now)
+		require.NoError(t, err)
+		assert.True(t, scheduled)
+
+		require.NoError(t, db.RecordFailedAnalysisAttempt(id, 3, FailedAnalysisResolved, "", nil))
+		scheduled, err = db.ScheduleFailedAnalysisRetry(id, now)
+		require.NoError(t, err)
+		assert.False(t, scheduled, "resolved analyses are not retried")
+
+		_, err = db.ScheduleFailedAnalysisRetry(id+100, now)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
@@ -0,0 +1,196 @@
+package database
+
+import "fmt"
+
+// Feature flag names
+const (
+	FeatureTravelDetection    = "travel_detection"
+	FeatureBillDetection      = "bill_detection"
+	FeatureCorrectionExamples = "correction_examples"
+	FeatureAutoConfirm        = "auto_confirm"
+	FeatureDailyDigest        = "daily_digest"
+	FeatureGmailAnalysis      = "gmail_analysis"
+)
+
+// Where a user's feature flag value comes from
+const (
+	FeatureSourceUser     = "user"     // the user's own setting
+	FeatureSourceInstance = "instance" // the default an admin set for every user
+	FeatureSourceDefault  = "default"  // the flag's built-in default
+)
+
+// FeatureFlag is an optional capability that can be turned on or off per user without
+// a redeploy
+type FeatureFlag struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// FeatureFlags lists every known flag. Flags that were on for everyone before they
+// became flags default to on.
+var FeatureFlags = []FeatureFlag{
+	{FeatureTravelDetection, "Create events from flight, hotel and train bookings", true},
+	{FeatureBillDetection, "Create reminders for bills and payment due dates", false},
+	{FeatureCorrectionExamples, "Show the event agent your past corrections as examples", false},
+	{FeatureAutoConfirm, "Confirm detections at or above the auto-confirm confidence without review", true},
+	{FeatureDailyDigest, "Send the morning digest to users who turned it on in notification settings", true},
+	{FeatureGmailAnalysis, "Analyze emails from tracked Gmail sources", true},
+}
+
+// LookupFeatureFlag returns the flag with the given name
+func LookupFeatureFlag(name string) (FeatureFlag, bool) {
+	for _, flag := range FeatureFlags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return FeatureFlag{}, false
+}
+
+// FeatureFlagState is a flag's effective value for a user, or for the instance
+type FeatureFlagState struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"source"`
+}
+
+// GetFeatureFlags returns every flag's effective value for the user
+func (d *DB) GetFeatureFlags(userID int64) ([]FeatureFlagState, error) {
+	defaults, err := d.GetFeatureFlagDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.Query(`SELECT name, enabled FROM feature_flags WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var enabled bool
+		if err := rows.Scan(&name, &enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+		}
+		overrides[name] = enabled
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feature flags: %w", err)
+	}
+
+	for i, state := range defaults {
+		if enabled, ok := overrides[state.Name]; ok {
+			defaults[i].Enabled = enabled
+			defaults[i].Source = FeatureSourceUser
+		}
+	}
+	return defaults, nil
+}
+
+// IsFeatureEnabled returns the flag's effective value for the user: their own setting,
+// else the instance default, else the built-in default
+func (d *DB) IsFeatureEnabled(userID int64, name string) (bool, error) {
+	flag, ok := LookupFeatureFlag(name)
+	if !ok {
+		return false, fmt.Errorf("unknown feature flag %q", name)
+	}
+
+	var enabled bool
+	err := d.QueryRow(`
+		SELECT COALESCE(
+			(SELECT enabled FROM feature_flags WHERE user_id = ? AND name = ?),
+			(SELECT enabled FROM feature_flag_defaults WHERE name = ?),
+			?
+		)
+	`, userID, name, name, flag.Default).Scan(&enabled)
+	if err != nil {
+		return flag.Default, fmt.Errorf("failed to get feature flag %s: %w", name, err)
+	}
+	return enabled, nil
+}
+
+// SetFeatureFlag stores the user's own value for a flag. A nil value clears it, so the
+// instance or built-in default applies again.
+func (d *DB) SetFeatureFlag(userID int64, name string, enabled *bool) error {
+	if _, ok := LookupFeatureFlag(name); !ok {
+		return fmt.Errorf("unknown feature flag %q", name)
+	}
+
+	var err error
+	if enabled == nil {
+		_, err = d.Exec(`DELETE FROM feature_flags WHERE user_id = ? AND name = ?`, userID, name)
+	} else {
+		_, err = d.Exec(`
+			INSERT INTO feature_flags (user_id, name, enabled) VALUES (?, ?, ?)
+			ON CONFLICT(user_id, name) DO UPDATE SET enabled = excluded.enabled, updated_at = CURRENT_TIMESTAMP
+		`, userID, name, *enabled)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set feature flag %s: %w", name, err)
+	}
+	return nil
+}
+
+// GetFeatureFlagDefaults returns every flag's value for users without their own setting
+func (d *DB) GetFeatureFlagDefaults() ([]FeatureFlagState, error) {
+	rows, err := d.Query(`SELECT name, enabled FROM feature_flag_defaults`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flag defaults: %w", err)
+	}
+	defer rows.Close()
+
+	instance := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var enabled bool
+		if err := rows.Scan(&name, &enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag default: %w", err)
+		}
+		instance[name] = enabled
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feature flag defaults: %w", err)
+	}
+
+	states := make([]FeatureFlagState, 0, len(FeatureFlags))
+	for _, flag := range FeatureFlags {
+		state := FeatureFlagState{
+			Name:        flag.Name,
+			Description: flag.Description,
+			Enabled:     flag.Default,
+			Source:      FeatureSourceDefault,
+		}
+		if enabled, ok := instance[flag.Name]; ok {
+			state.Enabled = enabled
+			state.Source = FeatureSourceInstance
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// SetFeatureFlagDefault sets a flag for every user without their own setting. A nil
+// value reverts to the built-in default.
+func (d *DB) SetFeatureFlagDefault(name string, enabled *bool) error {
+	if _, ok := LookupFeatureFlag(name); !ok {
+		return fmt.Errorf("unknown feature flag %q", name)
+	}
+
+	var err error
+	if enabled == nil {
+		_, err = d.Exec(`DELETE FROM feature_flag_defaults WHERE name = ?`, name)
+	} else {
+		_, err = d.Exec(`
+			INSERT INTO feature_flag_defaults (name, enabled) VALUES (?, ?)
+			ON CONFLICT(name) DO UPDATE SET enabled = excluded.enabled, updated_at = CURRENT_TIMESTAMP
+		`, name, *enabled)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set feature flag default %s: %w", name, err)
+	}
+	return nil
+}
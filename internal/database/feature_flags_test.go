@@ -0,0 +1,63 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUserWithEmail(t, db, "other@example.com")
+	on, off := true, false
+
+	enabled, err := db.IsFeatureEnabled(user.ID, FeatureTravelDetection)
+	require.NoError(t, err)
+	assert.True(t, enabled, "built-in default")
+
+	t.Run("instance default applies to users without their own value", func(t *testing.T) {
+		require.NoError(t, db.SetFeatureFlagDefault(FeatureTravelDetection, &off))
+		require.NoError(t, db.SetFeatureFlag(user.ID, FeatureTravelDetection, &on))
+
+		enabled, err := db.IsFeatureEnabled(user.ID, FeatureTravelDetection)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+		enabled, err = db.IsFeatureEnabled(other.ID, FeatureTravelDetection)
+		require.NoError(t, err)
+		assert.False(t, enabled)
+
+		flags, err := db.GetFeatureFlags(other.ID)
+		require.NoError(t, err)
+		require.Len(t, flags, len(FeatureFlags))
+		assert.Equal(t, FeatureTravelDetection, flags[0].Name)
+		assert.Equal(t, FeatureSourceInstance, flags[0].Source)
+	})
+
+	t.Run("nil clears back to the defaults", func(t *testing.T) {
+		require.NoError(t, db.SetFeatureFlag(user.ID, FeatureTravelDetection, nil))
+		require.NoError(t, db.SetFeatureFlagDefault(FeatureTravelDetection, nil))
+
+		flags, err := db.GetFeatureFlags(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, FeatureSourceDefault, flags[0].Source)
+		assert.True(t, flags[0].Enabled)
+	})
+
+	t.Run("unknown flags are rejected", func(t *testing.T) {
+		_, err := db.IsFeatureEnabled(user.ID, "teleport")
+		assert.Error(t, err)
+		assert.Error(t, db.SetFeatureFlag(user.ID, "teleport", &on))
+		assert.Error(t, db.SetFeatureFlagDefault("teleport", &on))
+	})
+
+	t.Run("onboarding reset clears the user's flags", func(t *testing.T) {
+		require.NoError(t, db.SetFeatureFlag(user.ID, FeatureAutoConfirm, &off))
+		require.NoError(t, db.ResetOnboarding(user.ID))
+
+		enabled, err := db.IsFeatureEnabled(user.ID, FeatureAutoConfirm)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+	})
+}
@@ -25,6 +25,16 @@ type FeatureSettings struct {
 	GoogleCalendarEnabled  bool `json:"google_calendar_enabled"`
 	OutlookCalendarEnabled bool `json:"outlook_calendar_enabled"`
 
+	// Optional analyzers, from the bill_detection feature flag
+	BillDetectionEnabled bool `json:"bill_detection_enabled"`
+
+	// Learning from corrections, from the correction_examples feature flag
+	CorrectionExamplesEnabled bool `json:"correction_examples_enabled"`
+
+	// Confidence thresholds for detected events and reminders
+	MinConfidence         float64 `json:"min_confidence"`
+	AutoConfirmConfidence float64 `json:"auto_confirm_confidence"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -45,6 +55,8 @@ func (d *DB) GetFeatureSettings(userID int64) (*FeatureSettings, error) {
 			COALESCE(alfred_calendar_enabled, 1) as alfred_calendar_enabled,
 			google_calendar_enabled,
 			outlook_calendar_enabled,
+			COALESCE(min_confidence, 0.3) as min_confidence,
+			COALESCE(auto_confirm_confidence, 0) as auto_confirm_confidence,
 			created_at,
 			updated_at
 		FROM feature_settings WHERE user_id = ?
@@ -59,6 +71,8 @@ func (d *DB) GetFeatureSettings(userID int64) (*FeatureSettings, error) {
 		&settings.AlfredCalendarEnabled,
 		&settings.GoogleCalendarEnabled,
 		&settings.OutlookCalendarEnabled,
+		&settings.MinConfidence,
+		&settings.AutoConfirmConfidence,
 		&settings.CreatedAt,
 		&settings.UpdatedAt,
 	)
@@ -74,14 +88,123 @@ func (d *DB) GetFeatureSettings(userID int64) (*FeatureSettings, error) {
 			return nil, fmt.Errorf("failed to create feature settings: %w", insertErr)
 		}
 		// Return default settings
-		return &FeatureSettings{
+		settings = FeatureSettings{
 			UserID:                userID,
 			AlfredCalendarEnabled: true,
-		}, nil
+			MinConfidence:         DefaultMinConfidence,
+		}
+	}
+
+	if settings.BillDetectionEnabled, err = d.IsFeatureEnabled(userID, FeatureBillDetection); err != nil {
+		return nil, err
+	}
+	if settings.CorrectionExamplesEnabled, err = d.IsFeatureEnabled(userID, FeatureCorrectionExamples); err != nil {
+		return nil, err
 	}
 	return &settings, nil
 }
 
+// SetBillDetectionEnabled turns bill and payment-due detection on or off for a user
+func (d *DB) SetBillDetectionEnabled(userID int64, enabled bool) error {
+	return d.SetFeatureFlag(userID, FeatureBillDetection, &enabled)
+}
+
+// IsBillDetectionEnabled reports whether bill detection is on for the user
+func (d *DB) IsBillDetectionEnabled(userID int64) (bool, error) {
+	return d.IsFeatureEnabled(userID, FeatureBillDetection)
+}
+
+// SetCorrectionExamplesEnabled turns on or off feeding the user's past event corrections
+// to the event agent as examples
+func (d *DB) SetCorrectionExamplesEnabled(userID int64, enabled bool) error {
+	return d.SetFeatureFlag(userID, FeatureCorrectionExamples, &enabled)
+}
+
+// IsCorrectionExamplesEnabled reports whether correction examples are on for the user
+func (d *DB) IsCorrectionExamplesEnabled(userID int64) (bool, error) {
+	return d.IsFeatureEnabled(userID, FeatureCorrectionExamples)
+}
+
+// DefaultMinConfidence is the confidence below which detections are discarded for users
+// who haven't set their own threshold
+const DefaultMinConfidence = 0.30
+
+// ConfidenceThresholds decide what happens to an event or reminder the agents detect:
+// below MinConfidence it is discarded, from MinConfidence it is created pending review,
+// and from AutoConfirmConfidence it is confirmed (and synced) without review.
+// An AutoConfirmConfidence of 0 never auto-confirms.
+type ConfidenceThresholds struct {
+	MinConfidence         float64 `json:"min_confidence"`
+	AutoConfirmConfidence float64 `json:"auto_confirm_confidence"`
+}
+
+// DefaultConfidenceThresholds returns the thresholds of users who haven't set their own
+func DefaultConfidenceThresholds() ConfidenceThresholds {
+	return ConfidenceThresholds{MinConfidence: DefaultMinConfidence}
+}
+
+// Validate checks that both thresholds are between 0 and 1 and that auto-confirm, when
+// on, is not below the discard threshold
+func (t ConfidenceThresholds) Validate() error {
+	if t.MinConfidence < 0 || t.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence must be between 0 and 1")
+	}
+	if t.AutoConfirmConfidence < 0 || t.AutoConfirmConfidence > 1 {
+		return fmt.Errorf("auto_confirm_confidence must be between 0 and 1")
+	}
+	if t.AutoConfirmConfidence > 0 && t.AutoConfirmConfidence < t.MinConfidence {
+		return fmt.Errorf("auto_confirm_confidence must be at least min_confidence")
+	}
+	return nil
+}
+
+// ShouldDiscard reports whether a detection is below the discard threshold
+func (t ConfidenceThresholds) ShouldDiscard(confidence float64) bool {
+	return confidence < t.MinConfidence
+}
+
+// ShouldAutoConfirm reports whether a detection is confident enough to confirm without review
+func (t ConfidenceThresholds) ShouldAutoConfirm(confidence float64) bool {
+	return t.AutoConfirmConfidence > 0 && confidence >= t.AutoConfirmConfidence
+}
+
+// SetConfidenceThresholds stores the user's discard and auto-confirm thresholds
+func (d *DB) SetConfidenceThresholds(userID int64, thresholds ConfidenceThresholds) error {
+	if err := thresholds.Validate(); err != nil {
+		return err
+	}
+	// Ensure feature settings exist for this user
+	if _, err := d.GetFeatureSettings(userID); err != nil {
+		return err
+	}
+
+	_, err := d.Exec(`
+		UPDATE feature_settings SET min_confidence = ?, auto_confirm_confidence = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ?
+	`, thresholds.MinConfidence, thresholds.AutoConfirmConfidence, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update confidence thresholds: %w", err)
+	}
+	return nil
+}
+
+// GetConfidenceThresholds returns the user's discard and auto-confirm thresholds.
+// Users without feature settings get the defaults.
+func (d *DB) GetConfidenceThresholds(userID int64) (ConfidenceThresholds, error) {
+	var t ConfidenceThresholds
+	err := d.QueryRow(`
+		SELECT COALESCE(min_confidence, 0.3), COALESCE(auto_confirm_confidence, 0)
+		FROM feature_settings WHERE user_id = ?
+	`, userID).Scan(&t.MinConfidence, &t.AutoConfirmConfidence)
+	if err == sql.ErrNoRows {
+		return DefaultConfidenceThresholds(), nil
+	}
+	if err != nil {
+		return DefaultConfidenceThresholds(), fmt.Errorf("failed to get confidence thresholds: %w", err)
+	}
+	return t, nil
+}
+
 // ---- Simplified App Status API ----
 
 // AppStatus represents the simplified app status
@@ -160,45 +283,7 @@ func (d *DB) ResetOnboarding(userID int64) error {
 	}
 	defer tx.Rollback()
 
-	// Delete user-scoped data in dependency-safe order.
-	deleteSteps := []struct {
-		name  string
-		query string
-	}{
-		{
-			name:  "event attendees",
-			query: `DELETE FROM event_attendees WHERE event_id IN (SELECT id FROM calendar_events WHERE user_id = ?)`,
-		},
-		{name: "reminders", query: `DELETE FROM reminders WHERE user_id = ?`},
-		{name: "calendar events", query: `DELETE FROM calendar_events WHERE user_id = ?`},
-		{name: "message history", query: `DELETE FROM message_history WHERE user_id = ?`},
-		{
-			name:  "message history by channel ownership",
-			query: `DELETE FROM message_history WHERE channel_id IN (SELECT id FROM channels WHERE user_id = ?)`,
-		},
-		{name: "channels", query: `DELETE FROM channels WHERE user_id = ?`},
-		{name: "email sources", query: `DELETE FROM email_sources WHERE user_id = ?`},
-		{name: "processed emails", query: `DELETE FROM processed_emails WHERE user_id = ?`},
-		{name: "google tokens", query: `DELETE FROM google_tokens WHERE user_id = ?`},
-		{name: "whatsapp sessions", query: `DELETE FROM whatsapp_sessions WHERE user_id = ?`},
-		{name: "telegram sessions", query: `DELETE FROM telegram_sessions WHERE user_id = ?`},
-		{name: "gmail settings", query: `DELETE FROM gmail_settings WHERE user_id = ?`},
-		{name: "gcal settings", query: `DELETE FROM gcal_settings WHERE user_id = ?`},
-		{name: "notification preferences", query: `DELETE FROM user_notification_preferences WHERE user_id = ?`},
-		{name: "auth sessions", query: `DELETE FROM user_sessions WHERE user_id = ?`},
-	}
-
-	for _, step := range deleteSteps {
-		if _, err := tx.Exec(step.query, userID); err != nil {
-			return fmt.Errorf("failed to delete %s during onboarding reset: %w", step.name, err)
-		}
-	}
-
-	// Handle both possible contact cache table names for migration compatibility.
-	if err := deleteFromUserScopedTableIfExists(tx, "google_contacts", userID); err != nil {
-		return err
-	}
-	if err := deleteFromUserScopedTableIfExists(tx, "gmail_top_contacts", userID); err != nil {
+	if err := deleteUserData(tx, userID, "onboarding reset"); err != nil {
 		return err
 	}
 
@@ -215,6 +300,9 @@ func (d *DB) ResetOnboarding(userID int64) error {
 			alfred_calendar_enabled = 1,
 			google_calendar_enabled = 0,
 			outlook_calendar_enabled = 0,
+			bill_detection_enabled = 0,
+			min_confidence = 0.3,
+			auto_confirm_confidence = 0,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE user_id = ?
 	`, userID)
@@ -228,6 +316,92 @@ func (d *DB) ResetOnboarding(userID int64) error {
 	return nil
 }
 
+// userDataDeleteSteps deletes user-scoped data in dependency-safe order. Each query
+// receives the user ID exactly once.
+var userDataDeleteSteps = []struct {
+	name  string
+	query string
+}{
+	{
+		name:  "event attendees",
+		query: `DELETE FROM event_attendees WHERE event_id IN (SELECT id FROM calendar_events WHERE user_id = ?)`,
+	},
+	{
+		name:  "shared event copy attendees",
+		query: `DELETE FROM event_attendees WHERE event_id IN (SELECT id FROM calendar_events WHERE channel_id IN (SELECT id FROM channels WHERE user_id = ?))`,
+	},
+	{
+		name:  "event sources",
+		query: `DELETE FROM event_sources WHERE event_id IN (SELECT id FROM calendar_events WHERE user_id = ?)`,
+	},
+	{name: "feature flags", query: `DELETE FROM feature_flags WHERE user_id = ?`},
+	{name: "reminder snoozes", query: `DELETE FROM reminder_snoozes WHERE user_id = ?`},
+	{name: "reminders", query: `DELETE FROM reminders WHERE user_id = ?`},
+	{name: "event corrections", query: `DELETE FROM event_corrections WHERE user_id = ?`},
+	{name: "calendar events", query: `DELETE FROM calendar_events WHERE user_id = ?`},
+	{
+		// Members' copies of events from the user's shared channels reference its messages
+		name:  "shared event copies",
+		query: `DELETE FROM calendar_events WHERE channel_id IN (SELECT id FROM channels WHERE user_id = ?)`,
+	},
+	{name: "analysis log", query: `DELETE FROM analysis_log WHERE user_id = ?`},
+	{name: "message annotations", query: `DELETE FROM message_annotations WHERE user_id = ?`},
+	{name: "message embeddings", query: `DELETE FROM message_embeddings WHERE user_id = ?`},
+	{name: "message attachments", query: `DELETE FROM message_attachments WHERE user_id = ?`},
+	{name: "message history", query: `DELETE FROM message_history WHERE user_id = ?`},
+	{
+		name:  "message history by channel ownership",
+		query: `DELETE FROM message_history WHERE channel_id IN (SELECT id FROM channels WHERE user_id = ?)`,
+	},
+	{
+		name:  "channel label assignments",
+		query: `DELETE FROM channel_label_assignments WHERE label_id IN (SELECT id FROM channel_labels WHERE user_id = ?)`,
+	},
+	{name: "channel labels", query: `DELETE FROM channel_labels WHERE user_id = ?`},
+	{name: "channel suggestions", query: `DELETE FROM channel_suggestions WHERE user_id = ?`},
+	{name: "gcal channel calendars", query: `DELETE FROM gcal_channel_calendars WHERE user_id = ?`},
+	{name: "history sync checkpoints", query: `DELETE FROM history_sync_checkpoints WHERE user_id = ?`},
+	{name: "onboarding steps", query: `DELETE FROM onboarding_steps WHERE user_id = ?`},
+	{name: "channels", query: `DELETE FROM channels WHERE user_id = ?`},
+	{name: "email sources", query: `DELETE FROM email_sources WHERE user_id = ?`},
+	{name: "processed emails", query: `DELETE FROM processed_emails WHERE user_id = ?`},
+	{name: "email sender rules", query: `DELETE FROM email_sender_rules WHERE user_id = ?`},
+	{name: "contact identities", query: `DELETE FROM contact_identities WHERE user_id = ?`},
+	{name: "contacts", query: `DELETE FROM contacts WHERE user_id = ?`},
+	{name: "google tokens", query: `DELETE FROM google_tokens WHERE user_id = ?`},
+	{name: "whatsapp sessions", query: `DELETE FROM whatsapp_sessions WHERE user_id = ?`},
+	{name: "telegram sessions", query: `DELETE FROM telegram_sessions WHERE user_id = ?`},
+	{name: "gmail settings", query: `DELETE FROM gmail_settings WHERE user_id = ?`},
+	{name: "gcal settings", query: `DELETE FROM gcal_settings WHERE user_id = ?`},
+	{name: "data exports", query: `DELETE FROM data_exports WHERE user_id = ?`},
+	{name: "devices", query: `DELETE FROM devices WHERE user_id = ?`},
+	{name: "notification preferences", query: `DELETE FROM user_notification_preferences WHERE user_id = ?`},
+	{name: "webhooks", query: `DELETE FROM webhooks WHERE user_id = ?`},
+	{name: "shipments", query: `DELETE FROM shipments WHERE user_id = ?`},
+	{name: "occasions", query: `DELETE FROM occasions WHERE user_id = ?`},
+	{name: "auth sessions", query: `DELETE FROM user_sessions WHERE user_id = ?`},
+	{name: "api keys", query: `DELETE FROM api_keys WHERE user_id = ?`},
+	{name: "oauth states", query: `DELETE FROM oauth_states WHERE user_id = ?`},
+	{name: "owned channel shares", query: `DELETE FROM channel_shares WHERE owner_user_id = ?`},
+	{name: "channel share memberships", query: `DELETE FROM channel_shares WHERE member_user_id = ?`},
+}
+
+// deleteUserData runs userDataDeleteSteps and clears the contact caches in tx.
+// purpose names the operation in error messages.
+func deleteUserData(tx *sql.Tx, userID int64, purpose string) error {
+	for _, step := range userDataDeleteSteps {
+		if _, err := tx.Exec(step.query, userID); err != nil {
+			return fmt.Errorf("failed to delete %s during %s: %w", step.name, purpose, err)
+		}
+	}
+
+	// Handle both possible contact cache table names for migration compatibility.
+	if err := deleteFromUserScopedTableIfExists(tx, "google_contacts", userID); err != nil {
+		return err
+	}
+	return deleteFromUserScopedTableIfExists(tx, "gmail_top_contacts", userID)
+}
+
 func deleteFromUserScopedTableIfExists(tx *sql.Tx, table string, userID int64) error {
 	var exists int
 	if err := tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&exists); err != nil {
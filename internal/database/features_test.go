@@ -0,0 +1,52 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfidenceThresholds(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	t.Run("defaults without settings", func(t *testing.T) {
+		thresholds, err := db.GetConfidenceThresholds(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultConfidenceThresholds(), thresholds)
+		assert.True(t, thresholds.ShouldDiscard(0.29))
+		assert.False(t, thresholds.ShouldDiscard(0.3))
+		assert.False(t, thresholds.ShouldAutoConfirm(1))
+	})
+
+	t.Run("set and read back", func(t *testing.T) {
+		require.NoError(t, db.SetConfidenceThresholds(user.ID, ConfidenceThresholds{
+			MinConfidence:         0.5,
+			AutoConfirmConfidence: 0.9,
+		}))
+
+		thresholds, err := db.GetConfidenceThresholds(user.ID)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, thresholds.MinConfidence, 1e-9)
+		assert.InDelta(t, 0.9, thresholds.AutoConfirmConfidence, 1e-9)
+		assert.True(t, thresholds.ShouldAutoConfirm(0.9))
+		assert.False(t, thresholds.ShouldAutoConfirm(0.89))
+
+		settings, err := db.GetFeatureSettings(user.ID)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.5, settings.MinConfidence, 1e-9)
+		assert.InDelta(t, 0.9, settings.AutoConfirmConfidence, 1e-9)
+	})
+
+	t.Run("rejects invalid thresholds", func(t *testing.T) {
+		for _, invalid := range []ConfidenceThresholds{
+			{MinConfidence: -0.1},
+			{MinConfidence: 1.1},
+			{MinConfidence: 0.3, AutoConfirmConfidence: 1.5},
+			{MinConfidence: 0.6, AutoConfirmConfidence: 0.5},
+		} {
+			assert.Error(t, db.SetConfidenceThresholds(user.ID, invalid), "%+v", invalid)
+		}
+	})
+}
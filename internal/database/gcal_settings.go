@@ -2,19 +2,33 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrChannelCalendarChannel means a channel calendar mapping names a channel the user
+// doesn't have
+var ErrChannelCalendarChannel = errors.New("channel not found")
+
 // GCalSettings represents Google Calendar sync settings
 type GCalSettings struct {
-	ID                   int64     `json:"id"`
-	UserID               int64     `json:"user_id"`
-	SyncEnabled          bool      `json:"sync_enabled"`
-	SelectedCalendarID   string    `json:"selected_calendar_id"`
-	SelectedCalendarName string    `json:"selected_calendar_name"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	ID                   int64  `json:"id"`
+	UserID               int64  `json:"user_id"`
+	SyncEnabled          bool   `json:"sync_enabled"`
+	SelectedCalendarID   string `json:"selected_calendar_id"`
+	SelectedCalendarName string `json:"selected_calendar_name"`
+	// ChannelCalendars sends confirmed events from these channels to their own calendar
+	ChannelCalendars []ChannelCalendar `json:"channel_calendars"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// ChannelCalendar maps a channel to the Google calendar its events are created in
+type ChannelCalendar struct {
+	ChannelID    int64  `json:"channel_id"`
+	CalendarID   string `json:"calendar_id"`
+	CalendarName string `json:"calendar_name"`
 }
 
 // GetGCalSettings retrieves the Google Calendar settings for a user
@@ -48,11 +62,15 @@ func (d *DB) GetGCalSettings(userID int64) (*GCalSettings, error) {
 			SyncEnabled:          false,
 			SelectedCalendarID:   "primary",
 			SelectedCalendarName: "Primary",
+			ChannelCalendars:     []ChannelCalendar{},
 		}, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gcal settings: %w", err)
 	}
+	if settings.ChannelCalendars, err = d.ListChannelCalendars(userID); err != nil {
+		return nil, err
+	}
 	return &settings, nil
 }
 
@@ -86,3 +104,88 @@ func (d *DB) GetSelectedCalendarID(userID int64) (string, error) {
 	}
 	return settings.SelectedCalendarID, nil
 }
+
+// ListChannelCalendars returns the user's channel → calendar mappings by channel ID
+func (d *DB) ListChannelCalendars(userID int64) ([]ChannelCalendar, error) {
+	rows, err := d.Query(`
+		SELECT channel_id, calendar_id, calendar_name
+		FROM gcal_channel_calendars
+		WHERE user_id = ?
+		ORDER BY channel_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list channel calendars: %w", err)
+	}
+	defer rows.Close()
+
+	mappings := []ChannelCalendar{}
+	for rows.Next() {
+		var m ChannelCalendar
+		if err := rows.Scan(&m.ChannelID, &m.CalendarID, &m.CalendarName); err != nil {
+			return nil, fmt.Errorf("failed to scan channel calendar: %w", err)
+		}
+		mappings = append(mappings, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating channel calendars: %w", err)
+	}
+	return mappings, nil
+}
+
+// SetChannelCalendars replaces the user's channel → calendar mappings. Every channel
+// must belong to the user; a mapping with an empty calendar ID is dropped.
+func (d *DB) SetChannelCalendars(userID int64, mappings []ChannelCalendar) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin channel calendar transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM gcal_channel_calendars WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to clear channel calendars: %w", err)
+	}
+	for _, m := range mappings {
+		var owned bool
+		err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM channels WHERE id = ? AND user_id = ?)`, m.ChannelID, userID).Scan(&owned)
+		if err != nil {
+			return fmt.Errorf("failed to check channel: %w", err)
+		}
+		if !owned {
+			return ErrChannelCalendarChannel
+		}
+		if m.CalendarID == "" {
+			continue
+		}
+		_, err = tx.Exec(`
+			INSERT INTO gcal_channel_calendars (channel_id, user_id, calendar_id, calendar_name)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(channel_id) DO UPDATE SET
+				calendar_id = excluded.calendar_id,
+				calendar_name = excluded.calendar_name
+		`, m.ChannelID, userID, m.CalendarID, m.CalendarName)
+		if err != nil {
+			return fmt.Errorf("failed to set channel calendar: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit channel calendars: %w", err)
+	}
+	return nil
+}
+
+// GetChannelCalendarID returns the calendar mapped to one of the user's channels, or ""
+// when it has none
+func (d *DB) GetChannelCalendarID(userID, channelID int64) (string, error) {
+	var calendarID string
+	err := d.QueryRow(`
+		SELECT calendar_id FROM gcal_channel_calendars WHERE user_id = ? AND channel_id = ?
+	`, userID, channelID).Scan(&calendarID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel calendar: %w", err)
+	}
+	return calendarID, nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelCalendars(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	kids, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "kids@g.us", "Kids")
+	require.NoError(t, err)
+	boss, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "boss@s.whatsapp.net", "Boss")
+	require.NoError(t, err)
+
+	settings, err := db.GetGCalSettings(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, settings.ChannelCalendars)
+
+	require.NoError(t, db.SetChannelCalendars(user.ID, []ChannelCalendar{
+		{ChannelID: kids.ID, CalendarID: "family@group.calendar.google.com", CalendarName: "Family"},
+		{ChannelID: boss.ID, CalendarID: "work@group.calendar.google.com", CalendarName: "Work"},
+	}))
+	settings, err = db.GetGCalSettings(user.ID)
+	require.NoError(t, err)
+	require.Len(t, settings.ChannelCalendars, 2)
+	assert.Equal(t, "Family", settings.ChannelCalendars[0].CalendarName)
+
+	calendarID, err := db.GetChannelCalendarID(user.ID, boss.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "work@group.calendar.google.com", calendarID)
+
+	t.Run("replacing drops missing and empty mappings", func(t *testing.T) {
+		require.NoError(t, db.SetChannelCalendars(user.ID, []ChannelCalendar{
+			{ChannelID: kids.ID, CalendarID: "family@group.calendar.google.com", CalendarName: "Family"},
+			{ChannelID: boss.ID, CalendarID: ""},
+		}))
+		calendarID, err := db.GetChannelCalendarID(user.ID, boss.ID)
+		require.NoError(t, err)
+		assert.Empty(t, calendarID)
+		mappings, err := db.ListChannelCalendars(user.ID)
+		require.NoError(t, err)
+		require.Len(t, mappings, 1)
+		assert.Equal(t, kids.ID, mappings[0].ChannelID)
+	})
+
+	t.Run("another user's channel is rejected", func(t *testing.T) {
+		other := CreateTestUserWithEmail(t, db, "other@example.com")
+		err := db.SetChannelCalendars(other.ID, []ChannelCalendar{{ChannelID: kids.ID, CalendarID: "x"}})
+		assert.ErrorIs(t, err, ErrChannelCalendarChannel)
+		calendarID, err := db.GetChannelCalendarID(user.ID, kids.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "family@group.calendar.google.com", calendarID)
+	})
+}
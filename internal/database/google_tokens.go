@@ -16,26 +16,45 @@ import (
 	"golang.org/x/oauth2"
 )
 
-// getEncryptionKey derives a 32-byte key for AES-256 encryption
-func getEncryptionKey() ([]byte, error) {
-	// Try ALFRED_ENCRYPTION_KEY first
-	if envKey := os.Getenv("ALFRED_ENCRYPTION_KEY"); envKey != "" {
-		hash := sha256.Sum256([]byte(envKey))
+// tokenEncryptionKey derives the 32-byte AES-256 key OAuth tokens are encrypted with
+func tokenEncryptionKey(encryptionKey, anthropicAPIKey string) ([]byte, error) {
+	// Try the encryption key first
+	if encryptionKey != "" {
+		hash := sha256.Sum256([]byte(encryptionKey))
 		return hash[:], nil
 	}
 
-	// Fall back to deriving from ANTHROPIC_API_KEY
-	if apiKey := os.Getenv("ANTHROPIC_API_KEY"); apiKey != "" {
-		hash := sha256.Sum256([]byte("alfred-encryption-" + apiKey))
+	// Fall back to deriving from the Anthropic API key
+	if anthropicAPIKey != "" {
+		hash := sha256.Sum256([]byte("alfred-encryption-" + anthropicAPIKey))
 		return hash[:], nil
 	}
 
 	return nil, fmt.Errorf("no encryption key available: set ALFRED_ENCRYPTION_KEY or ANTHROPIC_API_KEY")
 }
 
+// SetTokenEncryptionKeys sets the configured ALFRED_ENCRYPTION_KEY and ANTHROPIC_API_KEY
+// values OAuth tokens are encrypted with. Without it they are read from the environment.
+func (d *DB) SetTokenEncryptionKeys(encryptionKey, anthropicAPIKey string) error {
+	key, err := tokenEncryptionKey(encryptionKey, anthropicAPIKey)
+	if err != nil {
+		return err
+	}
+	d.tokenKey = key
+	return nil
+}
+
+// getEncryptionKey returns the key OAuth tokens are encrypted with
+func (d *DB) getEncryptionKey() ([]byte, error) {
+	if d.tokenKey != nil {
+		return d.tokenKey, nil
+	}
+	return tokenEncryptionKey(os.Getenv("ALFRED_ENCRYPTION_KEY"), os.Getenv("ANTHROPIC_API_KEY"))
+}
+
 // encryptToken encrypts an OAuth token for storage
-func encryptToken(token string) ([]byte, error) {
-	key, err := getEncryptionKey()
+func (d *DB) encryptToken(token string) ([]byte, error) {
+	key, err := d.getEncryptionKey()
 	if err != nil {
 		return nil, err
 	}
@@ -60,8 +79,8 @@ func encryptToken(token string) ([]byte, error) {
 }
 
 // decryptToken decrypts an OAuth token from storage
-func decryptToken(ciphertext []byte) (string, error) {
-	key, err := getEncryptionKey()
+func (d *DB) decryptToken(ciphertext []byte) (string, error) {
+	key, err := d.getEncryptionKey()
 	if err != nil {
 		return "", err
 	}
@@ -110,12 +129,12 @@ func (d *DB) GetGoogleToken(userID int64) (*oauth2.Token, error) {
 	}
 
 	// Decrypt tokens
-	accessToken, err := decryptToken(accessTokenEnc)
+	accessToken, err := d.decryptToken(accessTokenEnc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt access token: %w", err)
 	}
 
-	refreshToken, err := decryptToken(refreshTokenEnc)
+	refreshToken, err := d.decryptToken(refreshTokenEnc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt refresh token: %w", err)
 	}
@@ -136,12 +155,12 @@ func (d *DB) GetGoogleToken(userID int64) (*oauth2.Token, error) {
 // SaveGoogleToken stores an OAuth2 token for a user (upsert)
 func (d *DB) SaveGoogleToken(userID int64, token *oauth2.Token, email string, scopes []string) error {
 	// Encrypt tokens
-	accessTokenEnc, err := encryptToken(token.AccessToken)
+	accessTokenEnc, err := d.encryptToken(token.AccessToken)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt access token: %w", err)
 	}
 
-	refreshTokenEnc, err := encryptToken(token.RefreshToken)
+	refreshTokenEnc, err := d.encryptToken(token.RefreshToken)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt refresh token: %w", err)
 	}
@@ -168,6 +187,7 @@ func (d *DB) SaveGoogleToken(userID int64, token *oauth2.Token, email string, sc
 			expiry = excluded.expiry,
 			scopes = excluded.scopes,
 			email = excluded.email,
+			reauth_required_at = NULL,
 			updated_at = CURRENT_TIMESTAMP
 	`, userID, accessTokenEnc, refreshTokenEnc, token.TokenType, expiry, scopesJSON, email)
 
@@ -270,7 +290,7 @@ func (d *DB) RemoveGoogleTokenScope(userID int64, scopeToRemove string) error {
 
 // UpdateGoogleToken updates just the access token and expiry (used after token refresh)
 func (d *DB) UpdateGoogleToken(userID int64, token *oauth2.Token) error {
-	accessTokenEnc, err := encryptToken(token.AccessToken)
+	accessTokenEnc, err := d.encryptToken(token.AccessToken)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt access token: %w", err)
 	}
@@ -281,7 +301,7 @@ func (d *DB) UpdateGoogleToken(userID int64, token *oauth2.Token) error {
 	}
 
 	// Also update refresh token if it changed
-	refreshTokenEnc, err := encryptToken(token.RefreshToken)
+	refreshTokenEnc, err := d.encryptToken(token.RefreshToken)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt refresh token: %w", err)
 	}
@@ -291,6 +311,7 @@ func (d *DB) UpdateGoogleToken(userID int64, token *oauth2.Token) error {
 			access_token_encrypted = ?,
 			refresh_token_encrypted = ?,
 			expiry = ?,
+			reauth_required_at = NULL,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE user_id = ?
 	`, accessTokenEnc, refreshTokenEnc, expiry, userID)
@@ -302,25 +323,28 @@ func (d *DB) UpdateGoogleToken(userID int64, token *oauth2.Token) error {
 	return nil
 }
 
-// GoogleTokenInfo represents token metadata without the actual token values
+// GoogleTokenInfo represents token metadata without the actual token values.
+// ReauthRequiredAt is set once Google rejected the refresh token; the user has to
+// reconnect before Calendar or Gmail work again.
 type GoogleTokenInfo struct {
-	UserID    int64
-	Email     string
-	HasToken  bool
-	ExpiresAt *time.Time
-	Scopes    []string
+	UserID           int64
+	Email            string
+	HasToken         bool
+	ExpiresAt        *time.Time
+	Scopes           []string
+	ReauthRequiredAt *time.Time
 }
 
 // GetGoogleTokenInfo retrieves token metadata without exposing the actual tokens
 func (d *DB) GetGoogleTokenInfo(userID int64) (*GoogleTokenInfo, error) {
 	var email sql.NullString
-	var expiry sql.NullTime
+	var expiry, reauthRequiredAt sql.NullTime
 	var scopes sql.NullString
 
 	err := d.QueryRow(`
-		SELECT email, expiry, scopes
+		SELECT email, expiry, scopes, reauth_required_at
 		FROM google_tokens WHERE user_id = ?
-	`, userID).Scan(&email, &expiry, &scopes)
+	`, userID).Scan(&email, &expiry, &scopes, &reauthRequiredAt)
 
 	if err == sql.ErrNoRows {
 		return &GoogleTokenInfo{UserID: userID, HasToken: false}, nil
@@ -338,6 +362,9 @@ func (d *DB) GetGoogleTokenInfo(userID int64) (*GoogleTokenInfo, error) {
 	if expiry.Valid {
 		info.ExpiresAt = &expiry.Time
 	}
+	if reauthRequiredAt.Valid {
+		info.ReauthRequiredAt = &reauthRequiredAt.Time
+	}
 
 	if scopes.Valid && scopes.String != "" {
 		info.Scopes = splitScopes(scopes.String)
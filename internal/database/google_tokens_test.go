@@ -104,6 +104,29 @@ func TestGoogleTokenInfoNoToken(t *testing.T) {
 	assert.False(t, info.HasToken)
 }
 
+func TestGoogleTokenExplicitKeys(t *testing.T) {
+	t.Setenv("ALFRED_ENCRYPTION_KEY", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	token := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}
+
+	assert.Error(t, db.SaveGoogleToken(user.ID, token, "test@example.com", nil), "no key configured")
+
+	require.NoError(t, db.SetTokenEncryptionKeys("", "sk-ant-configured"))
+	require.NoError(t, db.SaveGoogleToken(user.ID, token, "test@example.com", nil))
+	retrieved, err := db.GetGoogleToken(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh", retrieved.RefreshToken)
+
+	// Same derivation as the environment fallback, so existing tokens stay readable
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-configured")
+	fromEnv, err := (&DB{DB: db.DB}).GetGoogleToken(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "access", fromEnv.AccessToken)
+}
+
 // TestSplitScopes tests the critical scope parsing function
 // This function was the source of the OAuth bug where scopes were stored
 // as JSON but parsed as space-separated strings
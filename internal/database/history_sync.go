@@ -0,0 +1,143 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// HistorySyncStatus is where a conversation is in WhatsApp HistorySync
+type HistorySyncStatus string
+
+const (
+	HistorySyncStatusPending   HistorySyncStatus = "pending"
+	HistorySyncStatusCompleted HistorySyncStatus = "completed"
+)
+
+// HistorySyncCheckpoint records how far HistorySync has stored a channel's
+// conversation. A sync that is interrupted resumes after SyncedThrough.
+type HistorySyncCheckpoint struct {
+	ChannelID      int64             `json:"channel_id"`
+	Identifier     string            `json:"identifier"`
+	Name           string            `json:"name"`
+	Status         HistorySyncStatus `json:"status"`
+	MessagesTotal  int               `json:"messages_total"`  // Messages the current sync has to store
+	MessagesStored int               `json:"messages_stored"` // Messages stored so far
+	Percent        int               `json:"percent"`
+	SyncedThrough  *time.Time        `json:"synced_through,omitempty"` // Newest message stored
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// StartHistorySyncCheckpoint marks a channel's conversation pending with total messages
+// left to store, keeping how far earlier syncs got
+func (d *DB) StartHistorySyncCheckpoint(userID, channelID int64, total int) error {
+	_, err := d.Exec(`
+		INSERT INTO history_sync_checkpoints (channel_id, user_id, status, messages_total, messages_stored)
+		SELECT id, user_id, ?, ?, 0 FROM channels WHERE id = ? AND user_id = ?
+		ON CONFLICT(channel_id) DO UPDATE SET
+			status = excluded.status,
+			messages_total = excluded.messages_total,
+			messages_stored = 0,
+			updated_at = CURRENT_TIMESTAMP
+	`, HistorySyncStatusPending, total, channelID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to start history sync checkpoint: %w", err)
+	}
+	return nil
+}
+
+// AdvanceHistorySyncCheckpoint records that a channel's message sent at syncedThrough
+// was stored. Messages are stored oldest first, so the checkpoint only moves forward.
+func (d *DB) AdvanceHistorySyncCheckpoint(userID, channelID int64, syncedThrough time.Time) error {
+	_, err := d.Exec(`
+		UPDATE history_sync_checkpoints
+		SET messages_stored = MIN(messages_stored + 1, messages_total),
+			synced_through = CASE
+				WHEN synced_through IS NULL OR synced_through < ?1 THEN ?1
+				ELSE synced_through
+			END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE channel_id = ?2 AND user_id = ?3
+	`, syncedThrough.UTC(), channelID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to advance history sync checkpoint: %w", err)
+	}
+	return nil
+}
+
+// CompleteHistorySyncCheckpoint marks a channel's conversation fully stored
+func (d *DB) CompleteHistorySyncCheckpoint(userID, channelID int64) error {
+	_, err := d.Exec(`
+		UPDATE history_sync_checkpoints
+		SET status = ?, messages_stored = messages_total, updated_at = CURRENT_TIMESTAMP
+		WHERE channel_id = ? AND user_id = ?
+	`, HistorySyncStatusCompleted, channelID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to complete history sync checkpoint: %w", err)
+	}
+	return nil
+}
+
+const historySyncCheckpointColumns = `
+	k.channel_id, c.identifier, c.name, k.status, k.messages_total, k.messages_stored,
+	k.synced_through, k.updated_at`
+
+// GetHistorySyncCheckpoint returns a channel's checkpoint, or nil if HistorySync never
+// reached it
+func (d *DB) GetHistorySyncCheckpoint(userID, channelID int64) (*HistorySyncCheckpoint, error) {
+	row := d.QueryRow(`
+		SELECT `+historySyncCheckpointColumns+`
+		FROM history_sync_checkpoints k JOIN channels c ON c.id = k.channel_id
+		WHERE k.channel_id = ? AND k.user_id = ?
+	`, channelID, userID)
+	checkpoint, err := scanHistorySyncCheckpoint(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history sync checkpoint: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// ListHistorySyncCheckpoints returns the user's checkpoints, pending ones first
+func (d *DB) ListHistorySyncCheckpoints(userID int64) ([]HistorySyncCheckpoint, error) {
+	rows, err := d.Query(`
+		SELECT `+historySyncCheckpointColumns+`
+		FROM history_sync_checkpoints k JOIN channels c ON c.id = k.channel_id
+		WHERE k.user_id = ?
+		ORDER BY k.status = ? DESC, k.updated_at DESC, k.channel_id
+	`, userID, HistorySyncStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history sync checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	checkpoints := []HistorySyncCheckpoint{}
+	for rows.Next() {
+		checkpoint, err := scanHistorySyncCheckpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan history sync checkpoint: %w", err)
+		}
+		checkpoints = append(checkpoints, *checkpoint)
+	}
+	return checkpoints, rows.Err()
+}
+
+func scanHistorySyncCheckpoint(scanner interface{ Scan(...any) error }) (*HistorySyncCheckpoint, error) {
+	var checkpoint HistorySyncCheckpoint
+	var syncedThrough sql.NullTime
+	err := scanner.Scan(&checkpoint.ChannelID, &checkpoint.Identifier, &checkpoint.Name, &checkpoint.Status,
+		&checkpoint.MessagesTotal, &checkpoint.MessagesStored, &syncedThrough, &checkpoint.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if syncedThrough.Valid {
+		checkpoint.SyncedThrough = &syncedThrough.Time
+	}
+	checkpoint.Percent = ProgressPercent(checkpoint.MessagesStored, checkpoint.MessagesTotal)
+	if checkpoint.Status == HistorySyncStatusCompleted {
+		checkpoint.Percent = 100
+	}
+	return &checkpoint, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistorySyncCheckpoints(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+
+	alice, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "alice", "Alice")
+	require.NoError(t, err)
+	bob, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "bob", "Bob")
+	require.NoError(t, err)
+
+	checkpoint, err := db.GetHistorySyncCheckpoint(user.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	first := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, db.StartHistorySyncCheckpoint(user.ID, alice.ID, 3))
+	require.NoError(t, db.AdvanceHistorySyncCheckpoint(user.ID, alice.ID, first))
+	require.NoError(t, db.AdvanceHistorySyncCheckpoint(user.ID, alice.ID, first.Add(time.Minute)))
+
+	checkpoint, err = db.GetHistorySyncCheckpoint(user.ID, alice.ID)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, HistorySyncStatusPending, checkpoint.Status)
+	assert.Equal(t, "alice", checkpoint.Identifier)
+	assert.Equal(t, 3, checkpoint.MessagesTotal)
+	assert.Equal(t, 2, checkpoint.MessagesStored)
+	assert.Equal(t, 66, checkpoint.Percent)
+	require.NotNil(t, checkpoint.SyncedThrough)
+	assert.True(t, first.Add(time.Minute).Equal(*checkpoint.SyncedThrough))
+
+	t.Run("checkpoint never moves back", func(t *testing.T) {
+		require.NoError(t, db.AdvanceHistorySyncCheckpoint(user.ID, alice.ID, first))
+		checkpoint, err := db.GetHistorySyncCheckpoint(user.ID, alice.ID)
+		require.NoError(t, err)
+		assert.True(t, first.Add(time.Minute).Equal(*checkpoint.SyncedThrough))
+		assert.Equal(t, 3, checkpoint.MessagesStored)
+	})
+
+	t.Run("restart keeps the checkpoint", func(t *testing.T) {
+		require.NoError(t, db.StartHistorySyncCheckpoint(user.ID, alice.ID, 1))
+		checkpoint, err := db.GetHistorySyncCheckpoint(user.ID, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, checkpoint.MessagesTotal)
+		assert.Equal(t, 0, checkpoint.MessagesStored)
+		assert.True(t, first.Add(time.Minute).Equal(*checkpoint.SyncedThrough))
+	})
+
+	t.Run("list puts pending first", func(t *testing.T) {
+		require.NoError(t, db.StartHistorySyncCheckpoint(user.ID, bob.ID, 0))
+		require.NoError(t, db.CompleteHistorySyncCheckpoint(user.ID, bob.ID))
+
+		checkpoints, err := db.ListHistorySyncCheckpoints(user.ID)
+		require.NoError(t, err)
+		require.Len(t, checkpoints, 2)
+		assert.Equal(t, alice.ID, checkpoints[0].ChannelID)
+		assert.Equal(t, bob.ID, checkpoints[1].ChannelID)
+		assert.Equal(t, HistorySyncStatusCompleted, checkpoints[1].Status)
+		assert.Equal(t, 100, checkpoints[1].Percent)
+	})
+
+	t.Run("checkpoints are scoped to the channel owner", func(t *testing.T) {
+		require.NoError(t, db.StartHistorySyncCheckpoint(other.ID, alice.ID, 5))
+		checkpoint, err := db.GetHistorySyncCheckpoint(other.ID, alice.ID)
+		require.NoError(t, err)
+		assert.Nil(t, checkpoint)
+
+		checkpoints, err := db.ListHistorySyncCheckpoints(other.ID)
+		require.NoError(t, err)
+		assert.Empty(t, checkpoints)
+	})
+
+	t.Run("deleting the channel removes its checkpoint", func(t *testing.T) {
+		require.NoError(t, db.DeleteSourceChannel(user.ID, bob.ID))
+		checkpoint, err := db.GetHistorySyncCheckpoint(user.ID, bob.ID)
+		require.NoError(t, err)
+		assert.Nil(t, checkpoint)
+	})
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Insights summarizes how a user's detected events and reminders were reviewed since a
+// point in time, overall and per channel. Only detected items count: manual reminders,
+// imported Google Calendar events and copies shared from another user are left out.
+type Insights struct {
+	Since     time.Time         `json:"since"`
+	Events    InsightCounts     `json:"events"`
+	Reminders ReminderInsights  `json:"reminders"`
+	Channels  []ChannelInsights `json:"channels"`
+}
+
+// InsightCounts counts detected items by review outcome. Confirmed includes items
+// accepted and later synced, completed, dismissed or deleted. ConfirmRate is
+// confirmed / (confirmed + rejected), 0 when nothing was reviewed.
+// AvgConfirmMinutes is the mean time from detection to confirmation, nil when no
+// confirmation was timed.
+type InsightCounts struct {
+	Detected          int      `json:"detected"`
+	Pending           int      `json:"pending"`
+	Confirmed         int      `json:"confirmed"`
+	Rejected          int      `json:"rejected"`
+	ConfirmRate       float64  `json:"confirm_rate"`
+	AvgConfirmMinutes *float64 `json:"avg_confirm_minutes"`
+
+	confirmMinutes float64
+	timedConfirms  int
+}
+
+// ReminderInsights adds how confirmed reminders ended up. CompletionRate is
+// completed / confirmed, 0 when nothing was confirmed.
+type ReminderInsights struct {
+	InsightCounts
+	Completed      int     `json:"completed"`
+	Dismissed      int     `json:"dismissed"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+// ChannelInsights is one channel's share of the detected events and reminders
+type ChannelInsights struct {
+	ChannelID   int64            `json:"channel_id"`
+	ChannelName string           `json:"channel_name"`
+	SourceType  string           `json:"source_type"`
+	Events      InsightCounts    `json:"events"`
+	Reminders   ReminderInsights `json:"reminders"`
+}
+
+// GetInsights collects the user's review statistics for items detected since since.
+// Channels are ordered by the number of items detected, most first.
+func (d *DB) GetInsights(userID int64, since time.Time) (*Insights, error) {
+	from := since.UTC().Format(llmUsageTimeFormat)
+	insights := &Insights{Since: since, Channels: []ChannelInsights{}}
+	byChannel := make(map[int64]*ChannelInsights)
+	channel := func(id int64, name, sourceType string) *ChannelInsights {
+		c, ok := byChannel[id]
+		if !ok {
+			c = &ChannelInsights{ChannelID: id, ChannelName: name, SourceType: sourceType}
+			byChannel[id] = c
+		}
+		return c
+	}
+
+	rows, err := d.Query(`
+		SELECT e.channel_id, COALESCE(c.name, ''), COALESCE(c.source_type, ''), COUNT(*),
+			COALESCE(SUM(CASE WHEN e.status = 'pending' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN e.status IN ('confirmed', 'synced', 'deleted') THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN e.status = 'rejected' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN e.status IN ('confirmed', 'synced', 'deleted') AND e.reviewed_at IS NOT NULL
+				THEN (julianday(e.reviewed_at) - julianday(e.created_at)) * 1440 END), 0),
+			COALESCE(SUM(CASE WHEN e.status IN ('confirmed', 'synced', 'deleted') AND e.reviewed_at IS NOT NULL
+				THEN 1 ELSE 0 END), 0)
+		FROM calendar_events e
+		LEFT JOIN channels c ON c.id = e.channel_id
+		WHERE e.user_id = ? AND e.created_at >= ? AND e.shared_from_event_id IS NULL
+			AND COALESCE(c.source_type, '') NOT IN (?, ?)
+		GROUP BY e.channel_id
+	`, userID, from, manualReminderSourceType, googleCalendarImportSourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events per channel: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var name, sourceType string
+		var counts InsightCounts
+		if err := rows.Scan(&id, &name, &sourceType, &counts.Detected, &counts.Pending, &counts.Confirmed,
+			&counts.Rejected, &counts.confirmMinutes, &counts.timedConfirms); err != nil {
+			return nil, fmt.Errorf("failed to scan event insights: %w", err)
+		}
+		channel(id, name, sourceType).Events = counts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating event insights: %w", err)
+	}
+
+	rows, err = d.Query(`
+		SELECT r.channel_id, COALESCE(c.name, ''), COALESCE(c.source_type, ''), COUNT(*),
+			COALESCE(SUM(CASE WHEN r.status = 'pending' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN r.status IN ('confirmed', 'synced', 'completed', 'dismissed') THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN r.status = 'rejected' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN r.status IN ('confirmed', 'synced', 'completed', 'dismissed') AND r.reviewed_at IS NOT NULL
+				THEN (julianday(r.reviewed_at) - julianday(r.created_at)) * 1440 END), 0),
+			COALESCE(SUM(CASE WHEN r.status IN ('confirmed', 'synced', 'completed', 'dismissed') AND r.reviewed_at IS NOT NULL
+				THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN r.status = 'completed' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN r.status = 'dismissed' THEN 1 ELSE 0 END), 0)
+		FROM reminders r
+		LEFT JOIN channels c ON c.id = r.channel_id
+		WHERE r.user_id = ? AND r.created_at >= ?
+			AND COALESCE(c.source_type, '') NOT IN (?, ?)
+		GROUP BY r.channel_id
+	`, userID, from, manualReminderSourceType, googleCalendarImportSourceType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reminders per channel: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var name, sourceType string
+		var counts ReminderInsights
+		if err := rows.Scan(&id, &name, &sourceType, &counts.Detected, &counts.Pending, &counts.Confirmed,
+			&counts.Rejected, &counts.confirmMinutes, &counts.timedConfirms, &counts.Completed, &counts.Dismissed); err != nil {
+			return nil, fmt.Errorf("failed to scan reminder insights: %w", err)
+		}
+		channel(id, name, sourceType).Reminders = counts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reminder insights: %w", err)
+	}
+
+	for _, c := range byChannel {
+		insights.Events.add(c.Events)
+		insights.Reminders.add(c.Reminders)
+		c.Events.finish()
+		c.Reminders.finish()
+		insights.Channels = append(insights.Channels, *c)
+	}
+	insights.Events.finish()
+	insights.Reminders.finish()
+
+	sortChannelInsights(insights.Channels)
+	return insights, nil
+}
+
+func (c *InsightCounts) add(other InsightCounts) {
+	c.Detected += other.Detected
+	c.Pending += other.Pending
+	c.Confirmed += other.Confirmed
+	c.Rejected += other.Rejected
+	c.confirmMinutes += other.confirmMinutes
+	c.timedConfirms += other.timedConfirms
+}
+
+func (c *InsightCounts) finish() {
+	if reviewed := c.Confirmed + c.Rejected; reviewed > 0 {
+		c.ConfirmRate = float64(c.Confirmed) / float64(reviewed)
+	}
+	if c.timedConfirms > 0 {
+		avg := c.confirmMinutes / float64(c.timedConfirms)
+		c.AvgConfirmMinutes = &avg
+	}
+}
+
+func (r *ReminderInsights) add(other ReminderInsights) {
+	r.InsightCounts.add(other.InsightCounts)
+	r.Completed += other.Completed
+	r.Dismissed += other.Dismissed
+}
+
+func (r *ReminderInsights) finish() {
+	r.InsightCounts.finish()
+	if r.Confirmed > 0 {
+		r.CompletionRate = float64(r.Completed) / float64(r.Confirmed)
+	}
+}
+
+// sortChannelInsights orders channels by items detected, most first, then by ID
+func sortChannelInsights(channels []ChannelInsights) {
+	detected := func(c ChannelInsights) int { return c.Events.Detected + c.Reminders.Detected }
+	sort.Slice(channels, func(i, j int) bool {
+		if di, dj := detected(channels[i]), detected(channels[j]); di != dj {
+			return di > dj
+		}
+		return channels[i].ChannelID < channels[j].ChannelID
+	})
+}
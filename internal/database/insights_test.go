@@ -0,0 +1,91 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInsights(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	chat := createTestChannel(t, db, user.ID)
+	inbox, err := db.CreateSourceChannel(user.ID, source.SourceTypeGmail, source.ChannelTypeSender,
+		"school@example.com", "School")
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	createEvent := func(channelID int64, status EventStatus) *CalendarEvent {
+		event, err := db.CreatePendingEvent(&CalendarEvent{
+			UserID: user.ID, ChannelID: channelID, CalendarID: "primary", Title: "Event",
+			StartTime: now.Add(time.Hour), ActionType: EventActionCreate,
+		})
+		require.NoError(t, err)
+		// Detected an hour ago, so confirming it now took 60 minutes
+		_, err = db.Exec(`UPDATE calendar_events SET created_at = datetime('now', '-60 minutes') WHERE id = ?`, event.ID)
+		require.NoError(t, err)
+		if status != EventStatusPending {
+			require.NoError(t, db.UpdateEventStatus(event.ID, status))
+		}
+		return event
+	}
+	createEvent(chat.ID, EventStatusConfirmed)
+	createEvent(chat.ID, EventStatusRejected)
+	createEvent(chat.ID, EventStatusPending)
+	synced := createEvent(inbox.ID, EventStatusPending)
+	require.NoError(t, db.UpdateEventGoogleID(synced.ID, "g-1"))
+
+	for _, status := range []ReminderStatus{ReminderStatusConfirmed, ReminderStatusCompleted} {
+		reminder, err := db.CreatePendingReminder(&Reminder{
+			UserID: user.ID, ChannelID: inbox.ID, CalendarID: "primary", Title: "Reminder",
+			ActionType: ReminderActionCreate, Priority: ReminderPriorityNormal,
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateReminderStatus(reminder.ID, status))
+	}
+
+	// Manual reminders weren't detected
+	manual, err := db.EnsureManualReminderChannel(user.ID)
+	require.NoError(t, err)
+	_, err = db.CreatePendingReminder(&Reminder{
+		UserID: user.ID, ChannelID: manual.ID, CalendarID: "primary", Title: "Todo",
+		ActionType: ReminderActionCreate, Priority: ReminderPriorityNormal,
+	})
+	require.NoError(t, err)
+
+	insights, err := db.GetInsights(user.ID, now.AddDate(0, 0, -7))
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, insights.Events.Detected)
+	assert.Equal(t, 2, insights.Events.Confirmed)
+	assert.Equal(t, 1, insights.Events.Rejected)
+	assert.Equal(t, 1, insights.Events.Pending)
+	assert.InDelta(t, 2.0/3.0, insights.Events.ConfirmRate, 0.0001)
+	require.NotNil(t, insights.Events.AvgConfirmMinutes)
+	assert.InDelta(t, 60, *insights.Events.AvgConfirmMinutes, 1)
+
+	assert.Equal(t, 2, insights.Reminders.Detected)
+	assert.Equal(t, 2, insights.Reminders.Confirmed)
+	assert.Equal(t, 1, insights.Reminders.Completed)
+	assert.InDelta(t, 0.5, insights.Reminders.CompletionRate, 0.0001)
+
+	require.Len(t, insights.Channels, 2)
+	assert.Equal(t, chat.ID, insights.Channels[0].ChannelID)
+	assert.Equal(t, 3, insights.Channels[0].Events.Detected)
+	assert.InDelta(t, 0.5, insights.Channels[0].Events.ConfirmRate, 0.0001)
+	assert.Equal(t, inbox.ID, insights.Channels[1].ChannelID)
+	assert.Equal(t, "School", insights.Channels[1].ChannelName)
+	assert.Equal(t, 1, insights.Channels[1].Events.Confirmed)
+	assert.Equal(t, 2, insights.Channels[1].Reminders.Detected)
+
+	t.Run("items detected before the period are left out", func(t *testing.T) {
+		insights, err := db.GetInsights(user.ID, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, insights.Events.Detected)
+		assert.Nil(t, insights.Events.AvgConfirmMinutes)
+		assert.Empty(t, insights.Channels)
+	})
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// LLMSettings holds a user's overrides for the event/reminder agents.
+// Nil fields use the server defaults.
+type LLMSettings struct {
+	ModelTier   *string  `json:"model_tier"`
+	Temperature *float64 `json:"temperature"`
+}
+
+// GetLLMSettings returns the user's model tier and temperature overrides
+func (d *DB) GetLLMSettings(userID int64) (*LLMSettings, error) {
+	var tier sql.NullString
+	var temperature sql.NullFloat64
+	err := d.QueryRow(`SELECT llm_model_tier, llm_temperature FROM users WHERE id = ?`, userID).Scan(&tier, &temperature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get llm settings: %w", err)
+	}
+
+	settings := &LLMSettings{}
+	if tier.Valid {
+		settings.ModelTier = &tier.String
+	}
+	if temperature.Valid {
+		settings.Temperature = &temperature.Float64
+	}
+	return settings, nil
+}
+
+// SetLLMSettings replaces the user's model tier and temperature overrides
+func (d *DB) SetLLMSettings(userID int64, settings LLMSettings) error {
+	var tier sql.NullString
+	if settings.ModelTier != nil {
+		tier = sql.NullString{String: *settings.ModelTier, Valid: true}
+	}
+	var temperature sql.NullFloat64
+	if settings.Temperature != nil {
+		temperature = sql.NullFloat64{Float64: *settings.Temperature, Valid: true}
+	}
+
+	_, err := d.Exec(`
+		UPDATE users
+		SET llm_model_tier = ?, llm_temperature = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, tier, temperature, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update llm settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// llmUsageTimeFormat matches CURRENT_TIMESTAMP so range queries compare correctly
+const llmUsageTimeFormat = "2006-01-02 15:04:05"
+
+// LLMUsage is the token usage and estimated cost of one analysis
+type LLMUsage struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	Agent        string    `json:"agent"` // "event" or "reminder"
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	CostUSD      float64   `json:"cost_usd"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// LLMUsageTotals aggregates usage over a set of analyses
+type LLMUsageTotals struct {
+	Analyses     int     `json:"analyses"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	CostUSD      float64 `json:"cost_usd"`
+}
+
+// LLMModelUsage is the usage attributed to one model
+type LLMModelUsage struct {
+	Model string `json:"model"`
+	LLMUsageTotals
+}
+
+// LLMDailyUsage is the usage on one UTC day (YYYY-MM-DD)
+type LLMDailyUsage struct {
+	Date string `json:"date"`
+	LLMUsageTotals
+}
+
+// LLMUsageSummary summarizes a user's usage over [Since, Until)
+type LLMUsageSummary struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	LLMUsageTotals
+	ByModel []LLMModelUsage `json:"by_model"`
+	ByDay   []LLMDailyUsage `json:"by_day"`
+}
+
+// RecordLLMUsage stores the usage of one analysis. A zero CreatedAt means now.
+func (d *DB) RecordLLMUsage(usage LLMUsage) error {
+	createdAt := usage.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	_, err := d.Exec(`
+		INSERT INTO llm_usage (user_id, agent, model, input_tokens, output_tokens, cost_usd, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, usage.UserID, usage.Agent, usage.Model, usage.InputTokens, usage.OutputTokens, usage.CostUSD,
+		createdAt.UTC().Format(llmUsageTimeFormat))
+	if err != nil {
+		return fmt.Errorf("failed to record llm usage: %w", err)
+	}
+	return nil
+}
+
+// GetLLMSpend returns the user's estimated spend in USD since the given time
+func (d *DB) GetLLMSpend(userID int64, since time.Time) (float64, error) {
+	var spend float64
+	err := d.QueryRow(`
+		SELECT COALESCE(SUM(cost_usd), 0) FROM llm_usage WHERE user_id = ? AND created_at >= ?
+	`, userID, since.UTC().Format(llmUsageTimeFormat)).Scan(&spend)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get llm spend: %w", err)
+	}
+	return spend, nil
+}
+
+// GetLLMUsageSummary returns the user's usage in [since, until), in total, per model
+// and per day
+func (d *DB) GetLLMUsageSummary(userID int64, since, until time.Time) (*LLMUsageSummary, error) {
+	from := since.UTC().Format(llmUsageTimeFormat)
+	to := until.UTC().Format(llmUsageTimeFormat)
+	summary := &LLMUsageSummary{
+		Since:   since,
+		Until:   until,
+		ByModel: []LLMModelUsage{},
+		ByDay:   []LLMDailyUsage{},
+	}
+
+	const totals = `COUNT(*), COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0), COALESCE(SUM(cost_usd), 0)`
+	err := d.QueryRow(`
+		SELECT `+totals+` FROM llm_usage WHERE user_id = ? AND created_at >= ? AND created_at < ?
+	`, userID, from, to).Scan(
+		&summary.Analyses, &summary.InputTokens, &summary.OutputTokens, &summary.CostUSD,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get llm usage totals: %w", err)
+	}
+
+	rows, err := d.Query(`
+		SELECT model, `+totals+` FROM llm_usage
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY model ORDER BY SUM(cost_usd) DESC, model
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get llm usage by model: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m LLMModelUsage
+		if err := rows.Scan(&m.Model, &m.Analyses, &m.InputTokens, &m.OutputTokens, &m.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan llm usage by model: %w", err)
+		}
+		summary.ByModel = append(summary.ByModel, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating llm usage by model: %w", err)
+	}
+
+	dayRows, err := d.Query(`
+		SELECT date(created_at) AS day, `+totals+` FROM llm_usage
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY day ORDER BY day
+	`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get llm usage by day: %w", err)
+	}
+	defer dayRows.Close()
+	for dayRows.Next() {
+		var day LLMDailyUsage
+		if err := dayRows.Scan(&day.Date, &day.Analyses, &day.InputTokens, &day.OutputTokens, &day.CostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan llm usage by day: %w", err)
+		}
+		summary.ByDay = append(summary.ByDay, day)
+	}
+	if err := dayRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating llm usage by day: %w", err)
+	}
+
+	return summary, nil
+}
+
+// GetLLMMonthlyBudget returns the user's monthly budget override in USD (nil = server default)
+func (d *DB) GetLLMMonthlyBudget(userID int64) (*float64, error) {
+	var budget sql.NullFloat64
+	err := d.QueryRow(`SELECT llm_monthly_budget_usd FROM users WHERE id = ?`, userID).Scan(&budget)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get llm budget: %w", err)
+	}
+	if !budget.Valid {
+		return nil, nil
+	}
+	return &budget.Float64, nil
+}
+
+// SetLLMMonthlyBudget sets the user's monthly budget override; nil resets it to the
+// server default. Changing the budget re-arms the budget-exceeded notification.
+func (d *DB) SetLLMMonthlyBudget(userID int64, budget *float64) error {
+	var value sql.NullFloat64
+	if budget != nil {
+		value = sql.NullFloat64{Float64: *budget, Valid: true}
+	}
+	_, err := d.Exec(`
+		UPDATE users
+		SET llm_monthly_budget_usd = ?, llm_budget_notified_month = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, value, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update llm budget: %w", err)
+	}
+	return nil
+}
+
+// MarkLLMBudgetNotified records that the user was told their budget ran out in the
+// given month (YYYY-MM). It returns false if they were already notified that month.
+func (d *DB) MarkLLMBudgetNotified(userID int64, month string) (bool, error) {
+	result, err := d.Exec(`
+		UPDATE users SET llm_budget_notified_month = ?
+		WHERE id = ? AND (llm_budget_notified_month IS NULL OR llm_budget_notified_month != ?)
+	`, month, userID, month)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark llm budget notified: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark llm budget notified: %w", err)
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Message annotation kinds
+const (
+	MessageAnnotationMissedEvent    = "missed_event"    // should have created an event
+	MessageAnnotationMissedReminder = "missed_reminder" // should have created a reminder
+	MessageAnnotationFalseDetection = "false_detection" // an event or reminder was detected wrongly
+)
+
+// MaxMessageAnnotationNoteLength caps the free-text note on an annotation
+const MaxMessageAnnotationNoteLength = 1000
+
+// MessageAnnotation is a user's label on an analyzed message, kept as a labeled case
+// for evaluating the agents and for few-shot examples
+type MessageAnnotation struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	MessageID int64     `json:"message_id"`
+	ChannelID int64     `json:"channel_id"`
+	Kind      string    `json:"kind"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AnnotatedMessage is an annotation with the message it labels
+type AnnotatedMessage struct {
+	MessageAnnotation
+	Message SourceMessage `json:"message"`
+}
+
+// ValidateMessageAnnotation checks an annotation's kind and note
+func ValidateMessageAnnotation(kind, note string) error {
+	switch kind {
+	case MessageAnnotationMissedEvent, MessageAnnotationMissedReminder, MessageAnnotationFalseDetection:
+	default:
+		return fmt.Errorf("kind must be %s, %s or %s",
+			MessageAnnotationMissedEvent, MessageAnnotationMissedReminder, MessageAnnotationFalseDetection)
+	}
+	if len(note) > MaxMessageAnnotationNoteLength {
+		return fmt.Errorf("note must be at most %d characters", MaxMessageAnnotationNoteLength)
+	}
+	return nil
+}
+
+// AnnotateMessage labels one of the user's messages. Annotating a message again with
+// the same kind replaces the note.
+func (d *DB) AnnotateMessage(userID int64, msg *SourceMessage, kind, note string) (*MessageAnnotation, error) {
+	var a MessageAnnotation
+	err := d.QueryRow(`
+		INSERT INTO message_annotations (user_id, message_id, channel_id, kind, note)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(message_id, kind) DO UPDATE SET
+			note = excluded.note,
+			created_at = CURRENT_TIMESTAMP
+		RETURNING id, user_id, message_id, channel_id, kind, note, created_at
+	`, userID, msg.ID, msg.ChannelID, kind, note).Scan(
+		&a.ID, &a.UserID, &a.MessageID, &a.ChannelID, &a.Kind, &a.Note, &a.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to annotate message: %w", err)
+	}
+	return &a, nil
+}
+
+// ListAnnotatedMessages returns the user's annotations with the messages they label,
+// newest first, as labeled cases for the evaluation harness
+func (d *DB) ListAnnotatedMessages(userID int64, limit int) ([]AnnotatedMessage, error) {
+	rows, err := d.Query(`
+		SELECT a.id, a.user_id, a.message_id, a.channel_id, a.kind, a.note, a.created_at,
+			m.id, COALESCE(m.source_type, 'whatsapp'), m.channel_id, m.sender_jid, m.sender_name,
+			m.message_text, COALESCE(m.subject, ''), m.timestamp, m.created_at
+		FROM message_annotations a
+		JOIN message_history m ON m.id = a.message_id
+		WHERE a.user_id = ?
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list annotated messages: %w", err)
+	}
+	defer rows.Close()
+
+	result := []AnnotatedMessage{}
+	for rows.Next() {
+		var am AnnotatedMessage
+		a, m := &am.MessageAnnotation, &am.Message
+		if err := rows.Scan(&a.ID, &a.UserID, &a.MessageID, &a.ChannelID, &a.Kind, &a.Note, &a.CreatedAt,
+			&m.ID, &m.SourceType, &m.ChannelID, &m.SenderID, &m.SenderName,
+			&m.MessageText, &m.Subject, &m.Timestamp, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan annotated message: %w", err)
+		}
+		if m.MessageText, err = d.decryptMessageText(m.MessageText); err != nil {
+			return nil, err
+		}
+		result = append(result, am)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating annotated messages: %w", err)
+	}
+	return result, nil
+}
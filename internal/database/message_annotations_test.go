@@ -0,0 +1,57 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotateMessage(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannelForMessages(t, db, user.ID)
+	now := time.Now()
+	missed, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "sender", "Dana", "Dentist Tuesday 9am", "", now.Add(-time.Minute))
+	require.NoError(t, err)
+	wrong, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "sender", "Dana", "Remember that party last year?", "", now)
+	require.NoError(t, err)
+
+	a, err := db.AnnotateMessage(user.ID, missed, MessageAnnotationMissedEvent, "")
+	require.NoError(t, err)
+	assert.Equal(t, missed.ID, a.MessageID)
+	assert.Equal(t, channel.ID, a.ChannelID)
+	_, err = db.AnnotateMessage(user.ID, wrong, MessageAnnotationFalseDetection, "it was in the past")
+	require.NoError(t, err)
+
+	// Annotating again with the same kind replaces the note
+	again, err := db.AnnotateMessage(user.ID, missed, MessageAnnotationMissedEvent, "appointment at 9")
+	require.NoError(t, err)
+	assert.Equal(t, a.ID, again.ID)
+	assert.Equal(t, "appointment at 9", again.Note)
+
+	annotated, err := db.ListAnnotatedMessages(user.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, annotated, 2)
+	byKind := map[string]AnnotatedMessage{}
+	for _, am := range annotated {
+		byKind[am.Kind] = am
+	}
+	assert.Equal(t, "Dentist Tuesday 9am", byKind[MessageAnnotationMissedEvent].Message.MessageText)
+	assert.Equal(t, "appointment at 9", byKind[MessageAnnotationMissedEvent].Note)
+	assert.Equal(t, "Remember that party last year?", byKind[MessageAnnotationFalseDetection].Message.MessageText)
+
+	other := CreateTestUserWithEmail(t, db, "other@example.com")
+	annotated, err = db.ListAnnotatedMessages(other.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, annotated)
+}
+
+func TestValidateMessageAnnotation(t *testing.T) {
+	assert.NoError(t, ValidateMessageAnnotation(MessageAnnotationMissedReminder, ""))
+	assert.Error(t, ValidateMessageAnnotation("wrong", ""))
+	assert.Error(t, ValidateMessageAnnotation(MessageAnnotationMissedEvent, strings.Repeat("x", MaxMessageAnnotationNoteLength+1)))
+}
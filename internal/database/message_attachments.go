@@ -0,0 +1,124 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// MessageAttachment is a reference to media sent with a stored message
+type MessageAttachment struct {
+	ID        int64  `json:"id"`
+	MessageID int64  `json:"message_id"`
+	UserID    int64  `json:"-"`
+	Kind      string `json:"kind"` // "image", "video", "audio", "document"
+	MimeType  string `json:"mime_type,omitempty"`
+	FileName  string `json:"file_name,omitempty"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+	// SourceRef can carry media keys, so it stays server-side
+	SourceRef string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddMessageAttachments stores the attachment references of a message. A message whose
+// attachments are already stored (a replayed duplicate) is left as is.
+func (d *DB) AddMessageAttachments(messageID int64, attachments []source.Attachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM message_attachments WHERE message_id = ?`, messageID).Scan(&existing); err != nil {
+		return fmt.Errorf("failed to check message attachments: %w", err)
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	for _, a := range attachments {
+		ref, err := d.encryptMessageText(a.SourceRef)
+		if err != nil {
+			return err
+		}
+		// user_id is derived from the message's user_id via subquery
+		_, err = tx.Exec(`
+			INSERT INTO message_attachments (message_id, user_id, kind, mime_type, file_name, size_bytes, source_ref)
+			SELECT id, user_id, ?, ?, ?, ?, ?
+			FROM message_history
+			WHERE id = ?
+		`, a.Kind, a.MimeType, a.FileName, a.SizeBytes, ref, messageID)
+		if err != nil {
+			return fmt.Errorf("failed to add message attachment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit message attachments: %w", err)
+	}
+	return nil
+}
+
+// GetMessageAttachments returns the attachments of a message in the order they were sent
+func (d *DB) GetMessageAttachments(messageID int64) ([]MessageAttachment, error) {
+	rows, err := d.Query(`
+		SELECT id, message_id, user_id, kind, mime_type, file_name, size_bytes, source_ref, created_at
+		FROM message_attachments
+		WHERE message_id = ?
+		ORDER BY id ASC
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []MessageAttachment
+	for rows.Next() {
+		a, err := d.scanMessageAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// GetMessageAttachment returns an attachment by ID, or nil if it doesn't exist
+func (d *DB) GetMessageAttachment(id int64) (*MessageAttachment, error) {
+	row := d.QueryRow(`
+		SELECT id, message_id, user_id, kind, mime_type, file_name, size_bytes, source_ref, created_at
+		FROM message_attachments
+		WHERE id = ?
+	`, id)
+	a, err := d.scanMessageAttachment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+func (d *DB) scanMessageAttachment(scanner interface{ Scan(...any) error }) (*MessageAttachment, error) {
+	var a MessageAttachment
+	var ref string
+	err := scanner.Scan(&a.ID, &a.MessageID, &a.UserID, &a.Kind, &a.MimeType, &a.FileName, &a.SizeBytes, &ref, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan message attachment: %w", err)
+	}
+	if a.SourceRef, err = d.decryptMessageText(ref); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageAttachments(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	msg, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana",
+		"[Image] Party at ours!", "", time.Now())
+	require.NoError(t, err)
+
+	flyer := source.Attachment{
+		Kind:      source.AttachmentKindImage,
+		MimeType:  "image/jpeg",
+		SizeBytes: 52311,
+		SourceRef: "CgVpbWFnZQ==",
+	}
+	invite := source.Attachment{
+		Kind:      source.AttachmentKindDocument,
+		MimeType:  "application/pdf",
+		FileName:  "invite.pdf",
+		SourceRef: "Cghkb2N1bWVudA==",
+	}
+	require.NoError(t, db.AddMessageAttachments(msg.ID, []source.Attachment{flyer, invite}))
+
+	t.Run("lists a message's attachments in order", func(t *testing.T) {
+		attachments, err := db.GetMessageAttachments(msg.ID)
+		require.NoError(t, err)
+		require.Len(t, attachments, 2)
+		assert.Equal(t, source.AttachmentKindImage, attachments[0].Kind)
+		assert.Equal(t, int64(52311), attachments[0].SizeBytes)
+		assert.Equal(t, "CgVpbWFnZQ==", attachments[0].SourceRef)
+		assert.Equal(t, user.ID, attachments[0].UserID)
+		assert.Equal(t, "invite.pdf", attachments[1].FileName)
+
+		byID, err := db.GetMessageAttachment(attachments[1].ID)
+		require.NoError(t, err)
+		require.NotNil(t, byID)
+		assert.Equal(t, attachments[1], *byID)
+	})
+
+	t.Run("a replayed message keeps its attachments", func(t *testing.T) {
+		require.NoError(t, db.AddMessageAttachments(msg.ID, []source.Attachment{flyer}))
+		attachments, err := db.GetMessageAttachments(msg.ID)
+		require.NoError(t, err)
+		assert.Len(t, attachments, 2)
+	})
+
+	t.Run("missing attachment", func(t *testing.T) {
+		attachment, err := db.GetMessageAttachment(999999)
+		require.NoError(t, err)
+		assert.Nil(t, attachment)
+	})
+
+	t.Run("queued messages keep their attachments", func(t *testing.T) {
+		queued := source.Message{
+			UserID:      user.ID,
+			SourceType:  source.SourceTypeWhatsApp,
+			SourceID:    channel.ID,
+			Identifier:  "dana",
+			SenderID:    "dana@s.whatsapp.net",
+			Text:        "[Document] invite.pdf",
+			Timestamp:   time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+			Attachments: []source.Attachment{invite},
+		}
+		_, err := db.EnqueuePendingAnalysis(queued)
+		require.NoError(t, err)
+
+		claimed, err := db.ClaimNextPendingAnalysis()
+		require.NoError(t, err)
+		require.NotNil(t, claimed)
+		assert.Equal(t, queued, claimed.Message)
+	})
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxEmbeddingScan bounds how many of a channel's most recent embeddings a
+// similarity search compares against
+const maxEmbeddingScan = 5000
+
+// UnembeddedMessage is a stored message that has no embedding for the current model yet
+type UnembeddedMessage struct {
+	ID        int64
+	UserID    int64
+	ChannelID int64
+	Text      string // subject and decrypted message text
+}
+
+// MessageEmbedding is the embedding vector of a stored message
+type MessageEmbedding struct {
+	MessageID int64
+	Vector    []float32
+}
+
+// ListUnembeddedMessages returns up to limit messages, newest first, that have no
+// embedding from model
+func (d *DB) ListUnembeddedMessages(model string, limit int) ([]UnembeddedMessage, error) {
+	rows, err := d.Query(`
+		SELECT mh.id, mh.user_id, mh.channel_id, COALESCE(mh.subject, ''), mh.message_text
+		FROM message_history mh
+		WHERE NOT EXISTS (
+			SELECT 1 FROM message_embeddings me WHERE me.message_id = mh.id AND me.model = ?
+		)
+		ORDER BY mh.timestamp DESC, mh.id DESC
+		LIMIT ?
+	`, model, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unembedded messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []UnembeddedMessage
+	for rows.Next() {
+		var m UnembeddedMessage
+		var subject, text string
+		if err := rows.Scan(&m.ID, &m.UserID, &m.ChannelID, &subject, &text); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		if text, err = d.decryptMessageText(text); err != nil {
+			return nil, err
+		}
+		m.Text = text
+		if subject != "" {
+			m.Text = subject + "\n" + text
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+	return messages, nil
+}
+
+// SaveMessageEmbedding stores the embedding of a message, replacing any earlier one
+func (d *DB) SaveMessageEmbedding(message UnembeddedMessage, model string, vector []float32) error {
+	_, err := d.Exec(`
+		INSERT OR REPLACE INTO message_embeddings (message_id, user_id, channel_id, model, embedding)
+		VALUES (?, ?, ?, ?, ?)
+	`, message.ID, message.UserID, message.ChannelID, model, encodeVector(vector))
+	if err != nil {
+		return fmt.Errorf("failed to save message embedding: %w", err)
+	}
+	return nil
+}
+
+// ListChannelEmbeddings returns the model's embeddings of the channel's messages sent
+// before the given time, most recent first
+func (d *DB) ListChannelEmbeddings(userID, channelID int64, model string, before time.Time) ([]MessageEmbedding, error) {
+	rows, err := d.Query(`
+		SELECT me.message_id, me.embedding
+		FROM message_embeddings me
+		JOIN message_history mh ON mh.id = me.message_id
+		WHERE me.user_id = ? AND me.channel_id = ? AND me.model = ? AND mh.timestamp < ?
+		ORDER BY mh.timestamp DESC
+		LIMIT ?
+	`, userID, channelID, model, before, maxEmbeddingScan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	var embeddings []MessageEmbedding
+	for rows.Next() {
+		var e MessageEmbedding
+		var blob []byte
+		if err := rows.Scan(&e.MessageID, &blob); err != nil {
+			return nil, fmt.Errorf("failed to scan message embedding: %w", err)
+		}
+		e.Vector = decodeVector(blob)
+		embeddings = append(embeddings, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message embeddings: %w", err)
+	}
+	return embeddings, nil
+}
+
+// encodeVector packs a vector as little-endian float32s
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageEmbeddings(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	now := time.Now()
+	older, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana",
+		"The place is Herzl St 10", "", now.Add(-14*24*time.Hour))
+	require.NoError(t, err)
+	newer, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana",
+		"See you at the usual place", "", now)
+	require.NoError(t, err)
+
+	pending, err := db.ListUnembeddedMessages("model-a", 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, newer.ID, pending[0].ID, "newest first")
+	assert.Equal(t, "See you at the usual place", pending[0].Text, "text is decrypted")
+	assert.Equal(t, user.ID, pending[0].UserID)
+	assert.Equal(t, channel.ID, pending[0].ChannelID)
+
+	for _, m := range pending {
+		require.NoError(t, db.SaveMessageEmbedding(m, "model-a", []float32{0.5, -1.25, 3}))
+	}
+
+	pending, err = db.ListUnembeddedMessages("model-a", 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	pending, err = db.ListUnembeddedMessages("model-b", 10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2, "embeddings are tracked per model")
+
+	t.Run("before excludes the new message", func(t *testing.T) {
+		embeddings, err := db.ListChannelEmbeddings(user.ID, channel.ID, "model-a", now)
+		require.NoError(t, err)
+		require.Len(t, embeddings, 1)
+		assert.Equal(t, older.ID, embeddings[0].MessageID)
+		assert.Equal(t, []float32{0.5, -1.25, 3}, embeddings[0].Vector)
+	})
+
+	t.Run("scoped to user and model", func(t *testing.T) {
+		other := CreateTestUser(t, db)
+		embeddings, err := db.ListChannelEmbeddings(other.ID, channel.ID, "model-a", now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, embeddings)
+
+		embeddings, err = db.ListChannelEmbeddings(user.ID, channel.ID, "model-b", now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, embeddings)
+	})
+
+	t.Run("deleted with the message", func(t *testing.T) {
+		_, err := db.DeleteMessagesBefore(user.ID, now.Add(-time.Hour), 10)
+		require.NoError(t, err)
+
+		embeddings, err := db.ListChannelEmbeddings(user.ID, channel.ID, "model-a", now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, embeddings, 1)
+		assert.Equal(t, newer.ID, embeddings[0].MessageID)
+	})
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/database/migrations"
+)
+
+// encryptedMessagePrefix marks message_text values encrypted with the message cipher.
+// Rows written before encryption was enabled have no prefix and are read as-is.
+const encryptedMessagePrefix = migrations.EncryptedMessagePrefix
+
+// Cipher encrypts values stored at rest; auth.Encryptor implements it
+type Cipher interface {
+	EncryptString(plaintext string) (string, error)
+	DecryptString(encoded string) (string, error)
+}
+
+// SetMessageCipher enables encryption of message_history.message_text. New messages
+// are encrypted on write; call EncryptStoredMessages to encrypt existing rows.
+func (d *DB) SetMessageCipher(c Cipher) {
+	d.messageCipher = c
+}
+
+func (d *DB) encryptMessageText(text string) (string, error) {
+	if d.messageCipher == nil {
+		return text, nil
+	}
+	encrypted, err := d.messageCipher.EncryptString(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt message: %w", err)
+	}
+	return encryptedMessagePrefix + encrypted, nil
+}
+
+func (d *DB) decryptMessageText(stored string) (string, error) {
+	if !strings.HasPrefix(stored, encryptedMessagePrefix) {
+		return stored, nil
+	}
+	if d.messageCipher == nil {
+		return "", fmt.Errorf("message is encrypted but no encryption key is configured")
+	}
+	text, err := d.messageCipher.DecryptString(strings.TrimPrefix(stored, encryptedMessagePrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt message: %w", err)
+	}
+	return text, nil
+}
+
+// EncryptStoredMessages encrypts message_text on every row still stored in plaintext,
+// batchSize rows per transaction. It is a no-op without a message cipher and safe to
+// run on every startup. Returns the number of rows encrypted.
+func (d *DB) EncryptStoredMessages(batchSize int) (int, error) {
+	if d.messageCipher == nil {
+		return 0, nil
+	}
+
+	total := 0
+	var lastID int64
+	for {
+		n, last, err := d.encryptMessageBatch(lastID, batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if last == 0 {
+			break
+		}
+		lastID = last
+	}
+
+	// Deleted FTS rows linger in index segments until they are merged; optimize so the
+	// old plaintext is actually gone from disk.
+	if total > 0 {
+		var exists int
+		if err := d.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'search_index'`).Scan(&exists); err != nil {
+			return total, fmt.Errorf("failed to check search index: %w", err)
+		}
+		if exists > 0 {
+			if _, err := d.Exec(`INSERT INTO search_index(search_index) VALUES('optimize')`); err != nil {
+				return total, fmt.Errorf("failed to optimize search index: %w", err)
+			}
+		}
+	}
+	return total, nil
+}
+
+// encryptMessageBatch encrypts up to batchSize plaintext rows with id > afterID.
+// Returns the number encrypted and the last id seen (0 when there are no more rows).
+func (d *DB) encryptMessageBatch(afterID int64, batchSize int) (int, int64, error) {
+	rows, err := d.Query(`
+		SELECT id, message_text FROM message_history
+		WHERE id > ? AND message_text NOT LIKE ?
+		ORDER BY id
+		LIMIT ?
+	`, afterID, encryptedMessagePrefix+"%", batchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query plaintext messages: %w", err)
+	}
+
+	type plaintextRow struct {
+		id   int64
+		text string
+	}
+	var batch []plaintextRow
+	for rows.Next() {
+		var r plaintextRow
+		if err := rows.Scan(&r.id, &r.text); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan message: %w", err)
+		}
+		batch = append(batch, r)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error iterating plaintext messages: %w", err)
+	}
+	if len(batch) == 0 {
+		return 0, 0, nil
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, r := range batch {
+		encrypted, err := d.encryptMessageText(r.text)
+		if err != nil {
+			return 0, 0, err
+		}
+		if _, err := tx.Exec(`UPDATE message_history SET message_text = ? WHERE id = ?`, encrypted, r.id); err != nil {
+			return 0, 0, fmt.Errorf("failed to encrypt message %d: %w", r.id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(batch), batch[len(batch)-1].id, nil
+}
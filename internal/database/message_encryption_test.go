@@ -0,0 +1,174 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCipher(t *testing.T) Cipher {
+	t.Helper()
+	encryptor, err := auth.NewEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	return encryptor
+}
+
+func rawMessageText(t *testing.T, db *DB, id int64) string {
+	t.Helper()
+	var text string
+	require.NoError(t, db.QueryRow(`SELECT message_text FROM message_history WHERE id = ?`, id).Scan(&text))
+	return text
+}
+
+func TestMessageEncryption(t *testing.T) {
+	db := NewTestDB(t)
+	db.SetMessageCipher(newTestCipher(t))
+	user := CreateTestUser(t, db)
+	channel := createTestChannelForMessages(t, db, user.ID)
+	ts := time.Now().Add(-time.Hour)
+
+	msg, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana", "Dentist on Thursday at 4", "", ts)
+	require.NoError(t, err)
+	assert.Equal(t, "Dentist on Thursday at 4", msg.MessageText)
+
+	t.Run("text is stored encrypted", func(t *testing.T) {
+		raw := rawMessageText(t, db, msg.ID)
+		assert.True(t, strings.HasPrefix(raw, encryptedMessagePrefix))
+		assert.NotContains(t, raw, "Dentist")
+	})
+
+	t.Run("accessors decrypt", func(t *testing.T) {
+		history, err := db.GetSourceMessageHistory(user.ID, source.SourceTypeWhatsApp, channel.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "Dentist on Thursday at 4", history[0].MessageText)
+
+		byID, err := db.GetSourceMessageByID(user.ID, msg.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Dentist on Thursday at 4", byID.MessageText)
+
+		record, err := db.GetMessageByID(msg.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Dentist on Thursday at 4", record.MessageText)
+	})
+
+	t.Run("duplicates are still detected", func(t *testing.T) {
+		again, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana", "Dentist on Thursday at 4", "", ts)
+		require.NoError(t, err)
+		assert.Equal(t, msg.ID, again.ID)
+
+		other, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana", "Different text", "", ts)
+		require.NoError(t, err)
+		assert.NotEqual(t, msg.ID, other.ID)
+	})
+
+	t.Run("data export decrypts", func(t *testing.T) {
+		var section UserDataSection
+		for _, s := range UserDataSections {
+			if s.Name == "messages" {
+				section = s
+			}
+		}
+		records, err := db.ExportUserDataSection(section, user.ID)
+		require.NoError(t, err)
+		var texts []any
+		for _, r := range records {
+			texts = append(texts, r["message_text"])
+		}
+		assert.Contains(t, texts, "Dentist on Thursday at 4")
+	})
+
+	t.Run("encrypted text is searchable through decryption", func(t *testing.T) {
+		hits, err := db.Search(user.ID, "thursday dentist", nil, 10)
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, msg.ID, hits[0].ID)
+		assert.Equal(t, "Dentist on Thursday at 4", hits[0].Snippet)
+
+		hits, err = db.Search(user.ID, "dana", []SearchResultType{SearchResultMessage}, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, hits)
+		seen := make(map[int64]bool)
+		for _, hit := range hits {
+			assert.False(t, seen[hit.ID], "a match on the sender isn't repeated")
+			seen[hit.ID] = true
+			assert.NotContains(t, hit.Snippet, encryptedMessagePrefix)
+		}
+
+		hits, err = db.Search(user.ID, "thursday friday", nil, 10)
+		require.NoError(t, err)
+		assert.Empty(t, hits)
+	})
+
+	t.Run("reading without a key fails", func(t *testing.T) {
+		db.SetMessageCipher(nil)
+		defer db.SetMessageCipher(newTestCipher(t))
+
+		_, err := db.GetSourceMessageByID(user.ID, msg.ID)
+		assert.Error(t, err)
+	})
+}
+
+func TestEncryptStoredMessages(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannelForMessages(t, db, user.ID)
+
+	// Written before encryption was enabled
+	var ids []int64
+	for i, text := range []string{"first", "second", "third"} {
+		msg, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana", text, "", time.Now().Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+		ids = append(ids, msg.ID)
+	}
+	assert.Equal(t, "first", rawMessageText(t, db, ids[0]))
+
+	count, err := db.EncryptStoredMessages(2)
+	require.NoError(t, err)
+	assert.Zero(t, count, "no-op without a cipher")
+
+	db.SetMessageCipher(newTestCipher(t))
+
+	// Plaintext rows stay readable until they are encrypted
+	history, err := db.GetSourceMessageHistory(user.ID, source.SourceTypeWhatsApp, channel.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	hits, err := db.Search(user.ID, "second", []SearchResultType{SearchResultMessage}, 10)
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	count, err = db.EncryptStoredMessages(2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// Encrypted messages are still found by decrypting them
+	hits, err = db.Search(user.ID, "second", []SearchResultType{SearchResultMessage}, 10)
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	// Encrypting drops the plaintext from the search index
+	db.SetMessageCipher(nil)
+	hits, err = db.Search(user.ID, "second", []SearchResultType{SearchResultMessage}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, hits)
+	db.SetMessageCipher(newTestCipher(t))
+	for _, id := range ids {
+		assert.True(t, strings.HasPrefix(rawMessageText(t, db, id), encryptedMessagePrefix))
+	}
+
+	count, err = db.EncryptStoredMessages(2)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	history, err = db.GetSourceMessageHistory(user.ID, source.SourceTypeWhatsApp, channel.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "first", history[0].MessageText)
+	assert.Equal(t, "third", history[2].MessageText)
+}
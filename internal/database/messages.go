@@ -1,6 +1,7 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -53,6 +54,9 @@ func (d *DB) GetMessageHistory(channelID int64, limit int) ([]MessageRecord, err
 		if err := rows.Scan(&m.ID, &m.ChannelID, &m.SenderJID, &m.SenderName, &m.MessageText, &m.Timestamp, &m.CreatedAt, &m.SourceType, &m.Subject); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
+		if m.MessageText, err = d.decryptMessageText(m.MessageText); err != nil {
+			return nil, err
+		}
 
 		key := fmt.Sprintf("%s\x00%d\x00%s\x00%s\x00%s\x00%d",
 			m.SourceType,
@@ -101,6 +105,92 @@ func (d *DB) PruneMessages(channelID int64, keepCount int) error {
 	return nil
 }
 
+// MessageRetention is a user's message_history retention override. Days is nil when
+// the user uses the server default; 0 keeps messages forever.
+type MessageRetention struct {
+	UserID int64
+	Days   *int
+}
+
+// GetMessageRetentionDays returns the user's retention override (nil = server default)
+func (d *DB) GetMessageRetentionDays(userID int64) (*int, error) {
+	var days sql.NullInt64
+	err := d.QueryRow(`SELECT message_retention_days FROM users WHERE id = ?`, userID).Scan(&days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message retention: %w", err)
+	}
+	if !days.Valid {
+		return nil, nil
+	}
+	v := int(days.Int64)
+	return &v, nil
+}
+
+// SetMessageRetentionDays sets the user's retention override; nil resets it to the
+// server default
+func (d *DB) SetMessageRetentionDays(userID int64, days *int) error {
+	var value sql.NullInt64
+	if days != nil {
+		value = sql.NullInt64{Int64: int64(*days), Valid: true}
+	}
+	_, err := d.Exec(`
+		UPDATE users
+		SET message_retention_days = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, value, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update message retention: %w", err)
+	}
+	return nil
+}
+
+// ListMessageRetention returns every user's retention override
+func (d *DB) ListMessageRetention() ([]MessageRetention, error) {
+	rows, err := d.Query(`SELECT id, message_retention_days FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message retention: %w", err)
+	}
+	defer rows.Close()
+
+	var result []MessageRetention
+	for rows.Next() {
+		var r MessageRetention
+		var days sql.NullInt64
+		if err := rows.Scan(&r.UserID, &days); err != nil {
+			return nil, fmt.Errorf("failed to scan message retention: %w", err)
+		}
+		if days.Valid {
+			v := int(days.Int64)
+			r.Days = &v
+		}
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message retention: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteMessagesBefore deletes up to limit of the user's messages with a timestamp
+// before cutoff. Messages an event or reminder was detected from are kept so their
+// source stays viewable. Returns the number of messages deleted.
+func (d *DB) DeleteMessagesBefore(userID int64, cutoff time.Time, limit int) (int64, error) {
+	result, err := d.Exec(`
+		DELETE FROM message_history
+		WHERE id IN (
+			SELECT mh.id FROM message_history mh
+			WHERE mh.user_id = ? AND mh.timestamp < ?
+				AND NOT EXISTS (SELECT 1 FROM calendar_events e WHERE e.original_message_id = mh.id)
+				AND NOT EXISTS (SELECT 1 FROM reminders r WHERE r.original_message_id = mh.id)
+			LIMIT ?
+		)
+	`, userID, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old messages: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 // GetMessageByID retrieves a specific message by ID
 func (d *DB) GetMessageByID(id int64) (*MessageRecord, error) {
 	var m MessageRecord
@@ -113,6 +203,9 @@ func (d *DB) GetMessageByID(id int64) (*MessageRecord, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
+	if m.MessageText, err = d.decryptMessageText(m.MessageText); err != nil {
+		return nil, err
+	}
 	return &m, nil
 }
 
@@ -0,0 +1,42 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 17,
+		Name:    "reminder_snoozes",
+		Up:      reminderSnoozes,
+		Down:    reminderSnoozesDown,
+	})
+}
+
+func reminderSnoozes(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS reminder_snoozes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			reminder_id INTEGER NOT NULL,
+			previous_due_date DATETIME,
+			previous_reminder_time DATETIME,
+			snoozed_until DATETIME NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY(reminder_id) REFERENCES reminders(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_reminder_snoozes_reminder ON reminder_snoozes(reminder_id, created_at DESC)`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_reminder_snoozes_user ON reminder_snoozes(user_id)`)
+	return err
+}
+
+func reminderSnoozesDown(db *sql.DB) error {
+	return DropTables(db, "reminder_snoozes")
+}
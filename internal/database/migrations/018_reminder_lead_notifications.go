@@ -0,0 +1,32 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 18,
+		Name:    "reminder_lead_notifications",
+		Up:      reminderLeadNotifications,
+		Down:    reminderLeadNotificationsDown,
+	})
+}
+
+// reminderLeadNotifications tracks "due soon" notifications sent ahead of a reminder's
+// due date, one row per configured offset. The at-due notification keeps using
+// reminders.due_notification_sent_at.
+func reminderLeadNotifications(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS reminder_lead_notifications (
+			reminder_id INTEGER NOT NULL,
+			offset_minutes INTEGER NOT NULL,
+			sent_at DATETIME NOT NULL,
+			PRIMARY KEY (reminder_id, offset_minutes),
+			FOREIGN KEY(reminder_id) REFERENCES reminders(id) ON DELETE CASCADE
+		)
+	`)
+	return err
+}
+
+func reminderLeadNotificationsDown(db *sql.DB) error {
+	return DropTables(db, "reminder_lead_notifications")
+}
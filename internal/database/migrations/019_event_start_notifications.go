@@ -0,0 +1,56 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 19,
+		Name:    "event_start_notifications",
+		Up:      eventStartNotifications,
+		Down:    eventStartNotificationsDown,
+	})
+}
+
+// eventStartNotifications adds pre-start notification offsets for events.
+// Offsets are stored as comma-separated minutes: per user on the notification
+// preferences, optionally overridden per event (NULL = inherit).
+func eventStartNotifications(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "user_notification_preferences", "event_notify_offsets", "TEXT NOT NULL DEFAULT '30'"); err != nil {
+		return err
+	}
+	if err := AddColumnIfNotExists(db, "calendar_events", "notify_offsets", "TEXT"); err != nil {
+		return err
+	}
+
+	// start_time records which schedule a notification was sent for, so a
+	// rescheduled event is notified again.
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_start_notifications (
+			event_id INTEGER NOT NULL,
+			offset_minutes INTEGER NOT NULL,
+			start_time DATETIME NOT NULL,
+			sent_at DATETIME NOT NULL,
+			PRIMARY KEY (event_id, offset_minutes),
+			FOREIGN KEY(event_id) REFERENCES calendar_events(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_calendar_events_status_start ON calendar_events(status, start_time)`)
+	return err
+}
+
+func eventStartNotificationsDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_calendar_events_status_start`); err != nil {
+		return err
+	}
+	if err := DropTables(db, "event_start_notifications"); err != nil {
+		return err
+	}
+	if err := DropColumnIfExists(db, "calendar_events", "notify_offsets"); err != nil {
+		return err
+	}
+	return DropColumnIfExists(db, "user_notification_preferences", "event_notify_offsets")
+}
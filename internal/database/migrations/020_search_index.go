@@ -0,0 +1,137 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+func init() {
+	Register(Migration{
+		Version: 20,
+		Name:    "search_index",
+		Up:      searchIndex,
+		Down:    searchIndexDown,
+	})
+}
+
+func searchIndex(db *sql.DB) error {
+	available, err := EnsureSearchIndex(db)
+	if err != nil {
+		return err
+	}
+	if !available {
+		slog.Warn("SQLite built without FTS5 (build with -tags sqlite_fts5); search will use LIKE matching")
+	}
+	return nil
+}
+
+// searchIndexDown drops the sync triggers and the index. On builds without FTS5 none
+// of them exist and this is a no-op.
+func searchIndexDown(db *sql.DB) error {
+	for _, kind := range []string{"message", "event", "reminder"} {
+		for _, op := range []string{"insert", "delete", "update"} {
+			if _, err := db.Exec(fmt.Sprintf(`DROP TRIGGER IF EXISTS search_%s_%s`, kind, op)); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := db.Exec(`DROP TABLE IF EXISTS search_index`)
+	return err
+}
+
+// Search index rowids encode the source row: rowid = id*4 + kind.
+// This keeps trigger deletes a primary key lookup instead of a full index scan.
+const (
+	SearchKindMessage  = 1
+	SearchKindEvent    = 2
+	SearchKindReminder = 3
+)
+
+// EnsureSearchIndex creates the FTS5 search_index table, its sync triggers, and backfills
+// existing rows. It is idempotent and returns false when SQLite lacks the FTS5 module,
+// so a later startup with an FTS5-enabled build can still create the index.
+func EnsureSearchIndex(db *sql.DB) (bool, error) {
+	var exists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'search_index'`).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists > 0 {
+		return true, nil
+	}
+
+	_, err := db.Exec(`
+		CREATE VIRTUAL TABLE search_index USING fts5(
+			title,
+			body,
+			user_id UNINDEXED,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)
+	`)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module") {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	statements := []string{
+		// Messages: sender name as title, subject + text as body
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_insert AFTER INSERT ON message_history BEGIN
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, COALESCE(new.sender_name, ''), TRIM(COALESCE(new.subject, '') || ' ' || new.message_text), new.user_id);
+		END`, SearchKindMessage),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_delete AFTER DELETE ON message_history BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+		END`, SearchKindMessage),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_update AFTER UPDATE OF sender_name, subject, message_text ON message_history BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, COALESCE(new.sender_name, ''), TRIM(COALESCE(new.subject, '') || ' ' || new.message_text), new.user_id);
+		END`, SearchKindMessage),
+
+		// Events and reminders: title, description + location as body
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_event_insert AFTER INSERT ON calendar_events BEGIN
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, new.title, TRIM(COALESCE(new.description, '') || ' ' || COALESCE(new.location, '')), new.user_id);
+		END`, SearchKindEvent),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_event_delete AFTER DELETE ON calendar_events BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+		END`, SearchKindEvent),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_event_update AFTER UPDATE OF title, description, location ON calendar_events BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, new.title, TRIM(COALESCE(new.description, '') || ' ' || COALESCE(new.location, '')), new.user_id);
+		END`, SearchKindEvent),
+
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_reminder_insert AFTER INSERT ON reminders BEGIN
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, new.title, TRIM(COALESCE(new.description, '') || ' ' || COALESCE(new.location, '')), new.user_id);
+		END`, SearchKindReminder),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_reminder_delete AFTER DELETE ON reminders BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+		END`, SearchKindReminder),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_reminder_update AFTER UPDATE OF title, description, location ON reminders BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, new.title, TRIM(COALESCE(new.description, '') || ' ' || COALESCE(new.location, '')), new.user_id);
+		END`, SearchKindReminder),
+
+		// Backfill existing rows
+		fmt.Sprintf(`INSERT INTO search_index(rowid, title, body, user_id)
+			SELECT id*4+%[1]d, COALESCE(sender_name, ''), TRIM(COALESCE(subject, '') || ' ' || message_text), user_id FROM message_history`, SearchKindMessage),
+		fmt.Sprintf(`INSERT INTO search_index(rowid, title, body, user_id)
+			SELECT id*4+%[1]d, title, TRIM(COALESCE(description, '') || ' ' || COALESCE(location, '')), user_id FROM calendar_events`, SearchKindEvent),
+		fmt.Sprintf(`INSERT INTO search_index(rowid, title, body, user_id)
+			SELECT id*4+%[1]d, title, TRIM(COALESCE(description, '') || ' ' || COALESCE(location, '')), user_id FROM reminders`, SearchKindReminder),
+	}
+
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return false, fmt.Errorf("failed to set up search index: %w", err)
+		}
+	}
+
+	return true, nil
+}
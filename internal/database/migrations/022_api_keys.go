@@ -0,0 +1,39 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 22,
+		Name:    "api_keys",
+		Up:      apiKeys,
+		Down:    apiKeysDown,
+	})
+}
+
+// apiKeys stores hashed API keys for programmatic clients. Only the SHA-256 hash
+// is kept; key_prefix lets users tell keys apart in listings.
+func apiKeys(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			key_prefix TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			scope TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_used_at DATETIME,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id)`)
+	return err
+}
+
+func apiKeysDown(db *sql.DB) error {
+	return DropTables(db, "api_keys")
+}
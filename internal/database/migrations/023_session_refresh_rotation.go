@@ -0,0 +1,36 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 23,
+		Name:    "session_refresh_rotation",
+		Up:      sessionRefreshRotation,
+		Down:    sessionRefreshRotationDown,
+	})
+}
+
+// sessionRefreshRotation turns user_sessions into the refresh token store. token_hash
+// holds the current refresh token; previous_token_hash keeps the one it replaced so a
+// replayed refresh token can be detected and the session revoked.
+func sessionRefreshRotation(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "user_sessions", "previous_token_hash", "TEXT"); err != nil {
+		return err
+	}
+	if err := AddColumnIfNotExists(db, "user_sessions", "refreshed_at", "DATETIME"); err != nil {
+		return err
+	}
+	_, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_user_sessions_previous_token ON user_sessions(previous_token_hash)`)
+	return err
+}
+
+func sessionRefreshRotationDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_user_sessions_previous_token`); err != nil {
+		return err
+	}
+	if err := DropColumnIfExists(db, "user_sessions", "refreshed_at"); err != nil {
+		return err
+	}
+	return DropColumnIfExists(db, "user_sessions", "previous_token_hash")
+}
@@ -0,0 +1,71 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 24,
+		Name:    "household_sharing",
+		Up:      householdSharing,
+		Down:    householdSharingDown,
+	})
+}
+
+// householdSharing lets a channel owner share a channel with other users (e.g. a partner).
+// Invitations are addressed by email and bound to member_user_id on acceptance.
+// Events detected in a shared channel are copied to each member as pending events;
+// shared_from_event_id links a copy to the owner's event and shared marks owner
+// events that are visible to members.
+func householdSharing(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS channel_shares (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel_id INTEGER NOT NULL,
+			owner_user_id INTEGER NOT NULL,
+			invitee_email TEXT NOT NULL,
+			member_user_id INTEGER,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'accepted', 'declined')),
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			responded_at DATETIME,
+			UNIQUE(channel_id, invitee_email),
+			FOREIGN KEY(channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+			FOREIGN KEY(owner_user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY(member_user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	indexes := []string{
+		`CREATE INDEX IF NOT EXISTS idx_channel_shares_owner ON channel_shares(owner_user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_channel_shares_member ON channel_shares(member_user_id, status)`,
+		`CREATE INDEX IF NOT EXISTS idx_channel_shares_invitee ON channel_shares(invitee_email, status)`,
+	}
+	for _, stmt := range indexes {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	if err := AddColumnIfNotExists(db, "calendar_events", "shared", "BOOLEAN NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := AddColumnIfNotExists(db, "calendar_events", "shared_from_event_id", "INTEGER REFERENCES calendar_events(id) ON DELETE SET NULL"); err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_calendar_events_shared_from ON calendar_events(shared_from_event_id)`)
+	return err
+}
+
+// householdSharingDown keeps calendar_events.shared_from_event_id: SQLite cannot drop
+// a column with a foreign key, and the nullable column is ignored by older code.
+func householdSharingDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_calendar_events_shared_from`); err != nil {
+		return err
+	}
+	if err := DropColumnIfExists(db, "calendar_events", "shared"); err != nil {
+		return err
+	}
+	return DropTables(db, "channel_shares")
+}
@@ -0,0 +1,49 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 25,
+		Name:    "devices",
+		Up:      devices,
+		Down:    devicesDown,
+	})
+}
+
+// devices stores one row per registered phone so push notifications reach every
+// device a user is signed in on. user_notification_preferences.push_token is kept
+// as the most recently registered token for older clients.
+func devices(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS devices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			push_token TEXT NOT NULL UNIQUE,
+			platform TEXT NOT NULL DEFAULT '',
+			device_name TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_devices_user ON devices(user_id)`); err != nil {
+		return err
+	}
+
+	// Carry over the single token each user had registered before
+	_, err = db.Exec(`
+		INSERT OR IGNORE INTO devices (user_id, push_token)
+		SELECT user_id, push_token FROM user_notification_preferences
+		WHERE push_token IS NOT NULL AND push_token != ''
+	`)
+	return err
+}
+
+func devicesDown(db *sql.DB) error {
+	return DropTables(db, "devices")
+}
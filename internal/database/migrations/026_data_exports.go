@@ -0,0 +1,42 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 26,
+		Name:    "data_exports",
+		Up:      dataExports,
+		Down:    dataExportsDown,
+	})
+}
+
+// dataExports tracks asynchronous account data exports. The finished ZIP archive is
+// stored inline until it expires.
+func dataExports(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS data_exports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'running', 'completed', 'failed')),
+			progress INTEGER NOT NULL DEFAULT 0,
+			error TEXT,
+			archive BLOB,
+			size_bytes INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			completed_at DATETIME,
+			expires_at DATETIME,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_data_exports_user ON data_exports(user_id, created_at)`)
+	return err
+}
+
+func dataExportsDown(db *sql.DB) error {
+	return DropTables(db, "data_exports")
+}
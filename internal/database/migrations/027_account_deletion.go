@@ -0,0 +1,31 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 27,
+		Name:    "account_deletion",
+		Up:      accountDeletion,
+		Down:    accountDeletionDown,
+	})
+}
+
+// accountDeletion stores the short-lived confirmation token a user must echo back to
+// delete their account. Only the latest token per user is valid.
+func accountDeletion(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS account_deletion_tokens (
+			user_id INTEGER PRIMARY KEY,
+			token_hash TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	return err
+}
+
+func accountDeletionDown(db *sql.DB) error {
+	return DropTables(db, "account_deletion_tokens")
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 28,
+		Name:    "message_retention",
+		Up:      messageRetention,
+		Down:    messageRetentionDown,
+	})
+}
+
+// messageRetention adds a per-user retention window for message_history. NULL uses
+// the server default (ALFRED_MESSAGE_RETENTION_DAYS); 0 keeps messages forever.
+func messageRetention(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "users", "message_retention_days", "INTEGER"); err != nil {
+		return err
+	}
+	_, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_message_history_user_timestamp ON message_history(user_id, timestamp)`)
+	return err
+}
+
+func messageRetentionDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_message_history_user_timestamp`); err != nil {
+		return err
+	}
+	return DropColumnIfExists(db, "users", "message_retention_days")
+}
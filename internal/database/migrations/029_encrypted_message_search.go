@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+func init() {
+	Register(Migration{
+		Version: 29,
+		Name:    "encrypted_message_search",
+		Up:      encryptedMessageSearch,
+		Down:    encryptedMessageSearchDown,
+	})
+}
+
+// EncryptedMessagePrefix marks message_history.message_text values that are encrypted
+// at rest
+const EncryptedMessagePrefix = "enc:v1:"
+
+// SearchableMessageText returns a SQL expression for the searchable part of a message
+// text column: encrypted text is left out so ciphertext never reaches the index.
+func SearchableMessageText(column string) string {
+	return fmt.Sprintf(`(CASE WHEN %[1]s LIKE '%[2]s%%' THEN '' ELSE %[1]s END)`, column, EncryptedMessagePrefix)
+}
+
+// encryptedMessageSearch recreates the message search triggers so encrypted message
+// text is not copied into search_index, and re-indexes existing messages the same way.
+// Encrypted text is matched by decrypting at query time (DB.Search).
+func encryptedMessageSearch(db *sql.DB) error {
+	replaced, err := replaceMessageSearchTriggers(db, []string{
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_insert AFTER INSERT ON message_history BEGIN
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, COALESCE(new.sender_name, ''), TRIM(COALESCE(new.subject, '') || ' ' || %[2]s), new.user_id);
+		END`, SearchKindMessage, SearchableMessageText("new.message_text")),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_delete AFTER DELETE ON message_history BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+		END`, SearchKindMessage),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_update AFTER UPDATE OF sender_name, subject, message_text ON message_history BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, COALESCE(new.sender_name, ''), TRIM(COALESCE(new.subject, '') || ' ' || %[2]s), new.user_id);
+		END`, SearchKindMessage, SearchableMessageText("new.message_text")),
+	})
+	if err != nil || !replaced {
+		return err
+	}
+
+	// The search index migration backfills message text as stored, ciphertext included
+	for _, stmt := range []string{
+		fmt.Sprintf(`DELETE FROM search_index WHERE rowid IN (SELECT id*4+%[1]d FROM message_history)`, SearchKindMessage),
+		fmt.Sprintf(`INSERT INTO search_index(rowid, title, body, user_id)
+			SELECT id*4+%[1]d, COALESCE(sender_name, ''), TRIM(COALESCE(subject, '') || ' ' || %[2]s), user_id FROM message_history`,
+			SearchKindMessage, SearchableMessageText("message_text")),
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to re-index messages: %w", err)
+		}
+	}
+	return nil
+}
+
+// encryptedMessageSearchDown restores the original triggers. Messages that are already
+// encrypted stay encrypted.
+func encryptedMessageSearchDown(db *sql.DB) error {
+	_, err := replaceMessageSearchTriggers(db, []string{
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_insert AFTER INSERT ON message_history BEGIN
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, COALESCE(new.sender_name, ''), TRIM(COALESCE(new.subject, '') || ' ' || new.message_text), new.user_id);
+		END`, SearchKindMessage),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_delete AFTER DELETE ON message_history BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+		END`, SearchKindMessage),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS search_message_update AFTER UPDATE OF sender_name, subject, message_text ON message_history BEGIN
+			DELETE FROM search_index WHERE rowid = old.id*4+%[1]d;
+			INSERT INTO search_index(rowid, title, body, user_id)
+			VALUES (new.id*4+%[1]d, COALESCE(new.sender_name, ''), TRIM(COALESCE(new.subject, '') || ' ' || new.message_text), new.user_id);
+		END`, SearchKindMessage),
+	})
+	return err
+}
+
+// replaceMessageSearchTriggers swaps the message triggers of search_index, reporting
+// false when there is no index (SQLite without FTS5)
+func replaceMessageSearchTriggers(db *sql.DB, triggers []string) (bool, error) {
+	var exists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'search_index'`).Scan(&exists); err != nil {
+		return false, err
+	}
+	if exists == 0 {
+		// SetUpSearchIndex applies this migration once the index is created
+		return false, nil
+	}
+
+	for _, name := range []string{"search_message_insert", "search_message_delete", "search_message_update"} {
+		if _, err := db.Exec(fmt.Sprintf(`DROP TRIGGER IF EXISTS %s`, name)); err != nil {
+			return false, err
+		}
+	}
+	for _, stmt := range triggers {
+		if _, err := db.Exec(stmt); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// SetUpSearchIndex creates the search index if it is missing, e.g. on the first start
+// of a build with FTS5 after the migrations ran without it. The index is created as
+// migration 20 does, then brought up to date with the later search index migrations
+// that are already applied.
+func SetUpSearchIndex(db *sql.DB) (bool, error) {
+	var exists int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'search_index'`).Scan(&exists); err != nil {
+		return false, err
+	}
+	available, err := EnsureSearchIndex(db)
+	if err != nil || !available || exists > 0 {
+		return available, err
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := applied[29]; ok {
+		if err := encryptedMessageSearch(db); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
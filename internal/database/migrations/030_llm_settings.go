@@ -0,0 +1,28 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 30,
+		Name:    "llm_settings",
+		Up:      llmSettings,
+		Down:    llmSettingsDown,
+	})
+}
+
+// llmSettings stores each user's model tier and temperature for the event and
+// reminder agents. NULL uses the server defaults.
+func llmSettings(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "users", "llm_model_tier", "TEXT"); err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "users", "llm_temperature", "REAL")
+}
+
+func llmSettingsDown(db *sql.DB) error {
+	if err := DropColumnIfExists(db, "users", "llm_temperature"); err != nil {
+		return err
+	}
+	return DropColumnIfExists(db, "users", "llm_model_tier")
+}
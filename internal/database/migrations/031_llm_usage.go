@@ -0,0 +1,51 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 31,
+		Name:    "llm_usage",
+		Up:      llmUsage,
+		Down:    llmUsageDown,
+	})
+}
+
+// llmUsage records the tokens and estimated cost of every analysis, and adds a
+// per-user monthly budget (NULL = server default, 0 = unlimited). The notified
+// month keeps the budget-exceeded notification to once per month.
+func llmUsage(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS llm_usage (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			agent TEXT NOT NULL,
+			model TEXT NOT NULL,
+			input_tokens INTEGER NOT NULL DEFAULT 0,
+			output_tokens INTEGER NOT NULL DEFAULT 0,
+			cost_usd REAL NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_llm_usage_user_created ON llm_usage(user_id, created_at)`); err != nil {
+		return err
+	}
+	if err := AddColumnIfNotExists(db, "users", "llm_monthly_budget_usd", "REAL"); err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "users", "llm_budget_notified_month", "TEXT")
+}
+
+func llmUsageDown(db *sql.DB) error {
+	if err := DropColumnIfExists(db, "users", "llm_budget_notified_month"); err != nil {
+		return err
+	}
+	if err := DropColumnIfExists(db, "users", "llm_monthly_budget_usd"); err != nil {
+		return err
+	}
+	return DropTables(db, "llm_usage")
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 32,
+		Name:    "reminder_replaces",
+		Up:      reminderReplaces,
+		Down:    reminderReplacesDown,
+	})
+}
+
+// reminderReplaces links a pending update/delete reminder to the confirmed reminder it
+// changes, so confirming the change retires the original
+func reminderReplaces(db *sql.DB) error {
+	return AddColumnIfNotExists(db, "reminders", "replaces_reminder_id", "INTEGER")
+}
+
+func reminderReplacesDown(db *sql.DB) error {
+	return DropColumnIfExists(db, "reminders", "replaces_reminder_id")
+}
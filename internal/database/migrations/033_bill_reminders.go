@@ -0,0 +1,43 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 33,
+		Name:    "bill_reminders",
+		Up:      billReminders,
+		Down:    billRemindersDown,
+	})
+}
+
+// billReminders stores the payee and amount of reminders created by the bill agent,
+// and the per-user switch that enables bill detection
+func billReminders(db *sql.DB) error {
+	columns := []struct{ table, column, definition string }{
+		{"reminders", "payee", "TEXT"},
+		{"reminders", "amount", "REAL"},
+		{"reminders", "currency", "TEXT"},
+		{"feature_settings", "bill_detection_enabled", "BOOLEAN DEFAULT 0"},
+	}
+	for _, c := range columns {
+		if err := AddColumnIfNotExists(db, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func billRemindersDown(db *sql.DB) error {
+	for _, c := range []struct{ table, column string }{
+		{"feature_settings", "bill_detection_enabled"},
+		{"reminders", "currency"},
+		{"reminders", "amount"},
+		{"reminders", "payee"},
+	} {
+		if err := DropColumnIfExists(db, c.table, c.column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 34,
+		Name:    "shipments",
+		Up:      shipments,
+		Down:    shipmentsDown,
+	})
+}
+
+// shipments stores the packages the delivery agent tracks, one row per tracking number,
+// and tags the "package arriving" calendar events with the tracking number so later
+// carrier updates adjust the same entry
+func shipments(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS shipments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			tracking_number TEXT NOT NULL,
+			carrier TEXT,
+			merchant TEXT,
+			description TEXT,
+			status TEXT NOT NULL,
+			eta_start TEXT,
+			eta_end TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, tracking_number),
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if err := AddColumnIfNotExists(db, "calendar_events", "tracking_number", "TEXT"); err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_calendar_events_tracking ON calendar_events(user_id, tracking_number)`)
+	return err
+}
+
+func shipmentsDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_calendar_events_tracking`); err != nil {
+		return err
+	}
+	if err := DropColumnIfExists(db, "calendar_events", "tracking_number"); err != nil {
+		return err
+	}
+	return DropTables(db, "shipments")
+}
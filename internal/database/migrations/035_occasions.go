@@ -0,0 +1,44 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 35,
+		Name:    "occasions",
+		Up:      occasions,
+		Down:    occasionsDown,
+	})
+}
+
+// occasions records the birthdays and anniversaries the occasion agent has seen, one per
+// person and kind, so repeated mentions don't create duplicate yearly events. Calendar
+// events gain an RRULE recurrence for the yearly entries.
+func occasions(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS occasions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			person TEXT NOT NULL,
+			person_key TEXT NOT NULL,
+			month INTEGER NOT NULL,
+			day INTEGER NOT NULL,
+			year INTEGER,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(user_id, kind, person_key),
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "calendar_events", "recurrence", "TEXT")
+}
+
+func occasionsDown(db *sql.DB) error {
+	if err := DropColumnIfExists(db, "calendar_events", "recurrence"); err != nil {
+		return err
+	}
+	return DropTables(db, "occasions")
+}
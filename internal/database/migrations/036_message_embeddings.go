@@ -0,0 +1,42 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 36,
+		Name:    "message_embeddings",
+		Up:      messageEmbeddings,
+		Down:    messageEmbeddingsDown,
+	})
+}
+
+// messageEmbeddings stores one embedding vector per message_history row so the event
+// agent can retrieve semantically related earlier messages. Vectors are little-endian
+// float32 blobs; model records which embedding model produced them.
+func messageEmbeddings(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_embeddings (
+			message_id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			channel_id INTEGER NOT NULL,
+			model TEXT NOT NULL,
+			embedding BLOB NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(message_id) REFERENCES message_history(id) ON DELETE CASCADE,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_message_embeddings_channel ON message_embeddings(channel_id, model)`)
+	return err
+}
+
+func messageEmbeddingsDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_message_embeddings_channel`); err != nil {
+		return err
+	}
+	return DropTables(db, "message_embeddings")
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 37,
+		Name:    "language_settings",
+		Up:      languageSettings,
+		Down:    languageSettingsDown,
+	})
+}
+
+// languageSettings adds the user's preferred locale for agent output and notifications,
+// and the language last detected in each channel, used when a message is too short to
+// tell on its own
+func languageSettings(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "users", "locale", "TEXT"); err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "channels", "language", "TEXT")
+}
+
+func languageSettingsDown(db *sql.DB) error {
+	if err := DropColumnIfExists(db, "channels", "language"); err != nil {
+		return err
+	}
+	return DropColumnIfExists(db, "users", "locale")
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 38,
+		Name:    "event_corrections",
+		Up:      eventCorrections,
+		Down:    eventCorrectionsDown,
+	})
+}
+
+// eventCorrections records, once per event, what the event agent detected when the user
+// rejected a pending event or changed its title, time or location, and the switch that
+// feeds recent corrections back to the agent as examples. The source message is read
+// through the event's original_message_id, so its text stays encrypted in one place.
+func eventCorrections(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS event_corrections (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			event_id INTEGER NOT NULL UNIQUE,
+			kind TEXT NOT NULL,
+			detected_title TEXT NOT NULL,
+			detected_start_time DATETIME NOT NULL,
+			detected_location TEXT,
+			corrected_title TEXT,
+			corrected_start_time DATETIME,
+			corrected_location TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY(event_id) REFERENCES calendar_events(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_event_corrections_user ON event_corrections(user_id, created_at)`); err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "feature_settings", "correction_examples_enabled", "BOOLEAN DEFAULT 0")
+}
+
+func eventCorrectionsDown(db *sql.DB) error {
+	if err := DropColumnIfExists(db, "feature_settings", "correction_examples_enabled"); err != nil {
+		return err
+	}
+	return DropTables(db, "event_corrections")
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 39,
+		Name:    "shadow_analyses",
+		Up:      shadowAnalyses,
+		Down:    shadowAnalysesDown,
+	})
+}
+
+// shadowAnalyses stores the output of the shadow analyzer next to the live one for the
+// same message or email, so a new prompt or model can be compared offline before it
+// replaces the live configuration. Shadow outputs are never persisted as items.
+func shadowAnalyses(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS shadow_analyses (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			channel_id INTEGER NOT NULL DEFAULT 0,
+			source_type TEXT NOT NULL,
+			trigger_message_id INTEGER,
+			intent TEXT NOT NULL,
+			label TEXT NOT NULL,
+			primary_action TEXT,
+			primary_confidence REAL NOT NULL DEFAULT 0,
+			primary_output_json TEXT NOT NULL DEFAULT '{}',
+			shadow_model TEXT,
+			shadow_action TEXT,
+			shadow_confidence REAL NOT NULL DEFAULT 0,
+			shadow_output_json TEXT NOT NULL DEFAULT '{}',
+			shadow_error TEXT,
+			shadow_cost_usd REAL NOT NULL DEFAULT 0,
+			agreed BOOLEAN NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_shadow_analyses_user ON shadow_analyses(user_id)`); err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_shadow_analyses_label_created ON shadow_analyses(label, intent, created_at DESC)`)
+	return err
+}
+
+func shadowAnalysesDown(db *sql.DB) error {
+	return DropTables(db, "shadow_analyses")
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 40,
+		Name:    "daily_digest",
+		Up:      dailyDigest,
+		Down:    dailyDigestDown,
+	})
+}
+
+// dailyDigest adds the morning digest settings to notification preferences.
+// digest_time is the local "HH:MM" send time in the user's timezone, and
+// digest_last_sent_on the local date (YYYY-MM-DD) of the last digest.
+func dailyDigest(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "user_notification_preferences", "digest_enabled", "BOOLEAN NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := AddColumnIfNotExists(db, "user_notification_preferences", "digest_time", "TEXT NOT NULL DEFAULT '08:00'"); err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "user_notification_preferences", "digest_last_sent_on", "TEXT")
+}
+
+func dailyDigestDown(db *sql.DB) error {
+	for _, column := range []string{"digest_last_sent_on", "digest_time", "digest_enabled"} {
+		if err := DropColumnIfExists(db, "user_notification_preferences", column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 41,
+		Name:    "device_push_provider",
+		Up:      devicePushProvider,
+		Down:    devicePushProviderDown,
+	})
+}
+
+// devicePushProvider records which push service (expo, fcm, apns) each device's token
+// belongs to. Devices registered before this were all Expo.
+func devicePushProvider(db *sql.DB) error {
+	return AddColumnIfNotExists(db, "devices", "provider", "TEXT NOT NULL DEFAULT 'expo'")
+}
+
+func devicePushProviderDown(db *sql.DB) error {
+	return DropColumnIfExists(db, "devices", "provider")
+}
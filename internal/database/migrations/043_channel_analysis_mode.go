@@ -0,0 +1,22 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 43,
+		Name:    "channel_analysis_mode",
+		Up:      channelAnalysisMode,
+		Down:    channelAnalysisModeDown,
+	})
+}
+
+// channelAnalysisMode records which analyzers run on each channel's messages
+// (all, events, reminders, none). Existing channels keep running every analyzer.
+func channelAnalysisMode(db *sql.DB) error {
+	return AddColumnIfNotExists(db, "channels", "analysis_mode", "TEXT NOT NULL DEFAULT 'all'")
+}
+
+func channelAnalysisModeDown(db *sql.DB) error {
+	return DropColumnIfExists(db, "channels", "analysis_mode")
+}
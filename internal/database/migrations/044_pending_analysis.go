@@ -0,0 +1,44 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 44,
+		Name:    "pending_analysis",
+		Up:      pendingAnalysis,
+		Down:    pendingAnalysisDown,
+	})
+}
+
+// pendingAnalysis is the processor's durable inbox: every chat message is written here
+// as soon as it's received and deleted once analyzed, so messages still waiting when
+// the server stops or crashes are analyzed on the next start
+func pendingAnalysis(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS pending_analysis (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			source_type TEXT NOT NULL,
+			channel_id INTEGER NOT NULL,
+			identifier TEXT NOT NULL DEFAULT '',
+			sender_id TEXT NOT NULL DEFAULT '',
+			sender_name TEXT NOT NULL DEFAULT '',
+			message_text TEXT NOT NULL DEFAULT '',
+			subject TEXT NOT NULL DEFAULT '',
+			timestamp DATETIME NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_pending_analysis_user ON pending_analysis(user_id)`)
+	return err
+}
+
+func pendingAnalysisDown(db *sql.DB) error {
+	return DropTables(db, "pending_analysis")
+}
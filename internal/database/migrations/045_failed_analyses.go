@@ -0,0 +1,48 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 45,
+		Name:    "failed_analyses",
+		Up:      failedAnalyses,
+		Down:    failedAnalysesDown,
+	})
+}
+
+// failedAnalyses is the dead-letter queue for chat analyses whose agent call failed.
+// input holds the (encrypted) intent input so the analysis can be retried as it first
+// ran; rows are retried with backoff until they succeed or are marked dead.
+func failedAnalyses(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS failed_analyses (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			channel_id INTEGER NOT NULL,
+			source_type TEXT NOT NULL,
+			intent TEXT NOT NULL,
+			trigger_message_id INTEGER,
+			input TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 1,
+			next_attempt_at DATETIME,
+			last_error TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_failed_analyses_due ON failed_analyses(status, next_attempt_at)`); err != nil {
+		return err
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_failed_analyses_user ON failed_analyses(user_id)`)
+	return err
+}
+
+func failedAnalysesDown(db *sql.DB) error {
+	return DropTables(db, "failed_analyses")
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 46,
+		Name:    "pending_analysis_lanes",
+		Up:      pendingAnalysisLanes,
+		Down:    pendingAnalysisLanesDown,
+	})
+}
+
+// pendingAnalysisLanes splits the processor queue into priority lanes: live messages
+// (priority 0) are always claimed before backfilled ones (priority 1). claimed marks
+// rows a worker is analyzing, so workers can take rows out of arrival order.
+func pendingAnalysisLanes(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "pending_analysis", "priority", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := AddColumnIfNotExists(db, "pending_analysis", "claimed", "BOOLEAN NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	_, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_pending_analysis_next ON pending_analysis(claimed, priority, id)`)
+	return err
+}
+
+func pendingAnalysisLanesDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_pending_analysis_next`); err != nil {
+		return err
+	}
+	if err := DropColumnIfExists(db, "pending_analysis", "claimed"); err != nil {
+		return err
+	}
+	return DropColumnIfExists(db, "pending_analysis", "priority")
+}
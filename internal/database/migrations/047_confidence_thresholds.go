@@ -0,0 +1,36 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 47,
+		Name:    "confidence_thresholds",
+		Up:      confidenceThresholds,
+		Down:    confidenceThresholdsDown,
+	})
+}
+
+// confidenceThresholds stores the per-user confidence below which detections are
+// discarded and above which they are confirmed without review (0 turns that off)
+func confidenceThresholds(db *sql.DB) error {
+	columns := []struct{ table, column, definition string }{
+		{"feature_settings", "min_confidence", "REAL DEFAULT 0.3"},
+		{"feature_settings", "auto_confirm_confidence", "REAL DEFAULT 0"},
+	}
+	for _, c := range columns {
+		if err := AddColumnIfNotExists(db, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func confidenceThresholdsDown(db *sql.DB) error {
+	for _, column := range []string{"auto_confirm_confidence", "min_confidence"} {
+		if err := DropColumnIfExists(db, "feature_settings", column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
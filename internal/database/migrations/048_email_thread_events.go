@@ -0,0 +1,29 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 48,
+		Name:    "email_thread_events",
+		Up:      emailThreadEvents,
+		Down:    emailThreadEventsDown,
+	})
+}
+
+// emailThreadEvents records the Gmail thread an event was detected in, so replies in the
+// same thread can update it
+func emailThreadEvents(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "calendar_events", "email_thread_id", "TEXT"); err != nil {
+		return err
+	}
+	_, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_calendar_events_email_thread ON calendar_events(user_id, email_thread_id)`)
+	return err
+}
+
+func emailThreadEventsDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_calendar_events_email_thread`); err != nil {
+		return err
+	}
+	return DropColumnIfExists(db, "calendar_events", "email_thread_id")
+}
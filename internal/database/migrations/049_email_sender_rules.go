@@ -0,0 +1,33 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 49,
+		Name:    "email_sender_rules",
+		Up:      emailSenderRules,
+		Down:    emailSenderRulesDown,
+	})
+}
+
+// emailSenderRules holds each user's allow/deny rules for email senders. pattern is a
+// lowercased address ("news@shop.com") or domain ("shop.com").
+func emailSenderRules(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS email_sender_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			pattern TEXT NOT NULL,
+			action TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+			UNIQUE(user_id, pattern)
+		)
+	`)
+	return err
+}
+
+func emailSenderRulesDown(db *sql.DB) error {
+	return DropTables(db, "email_sender_rules")
+}
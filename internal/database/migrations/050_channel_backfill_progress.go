@@ -0,0 +1,33 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 50,
+		Name:    "channel_backfill_progress",
+		Up:      channelBackfillProgress,
+		Down:    channelBackfillProgressDown,
+	})
+}
+
+// channelBackfillProgress records how far back a channel's initial backfill looks and how
+// many of its messages have been analyzed, so the app can show a progress bar
+func channelBackfillProgress(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "channels", "initial_backfill_days", "INTEGER"); err != nil {
+		return err
+	}
+	if err := AddColumnIfNotExists(db, "channels", "initial_backfill_total", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "channels", "initial_backfill_processed", "INTEGER NOT NULL DEFAULT 0")
+}
+
+func channelBackfillProgressDown(db *sql.DB) error {
+	for _, column := range []string{"initial_backfill_processed", "initial_backfill_total", "initial_backfill_days"} {
+		if err := DropColumnIfExists(db, "channels", column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
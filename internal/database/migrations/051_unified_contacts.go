@@ -0,0 +1,54 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 51,
+		Name:    "unified_contacts",
+		Up:      unifiedContacts,
+		Down:    unifiedContactsDown,
+	})
+}
+
+// unifiedContacts maps each person the user talks to across WhatsApp, Telegram and
+// Gmail to one contact. contact_identities holds one row per platform identity
+// (WhatsApp phone number, Telegram user ID, email address); analysis_mode, when set,
+// is applied to the chat channels of all of a contact's identities.
+func unifiedContacts(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS contacts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			analysis_mode TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_contacts_user ON contacts(user_id)`,
+		`CREATE TABLE IF NOT EXISTS contact_identities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			contact_id INTEGER NOT NULL,
+			source_type TEXT NOT NULL,
+			identifier TEXT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY(contact_id) REFERENCES contacts(id) ON DELETE CASCADE,
+			UNIQUE(user_id, source_type, identifier)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_contact_identities_contact ON contact_identities(contact_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unifiedContactsDown(db *sql.DB) error {
+	return DropTables(db, "contact_identities", "contacts")
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 52,
+		Name:    "channel_labels",
+		Up:      channelLabels,
+		Down:    channelLabelsDown,
+	})
+}
+
+// channelLabels lets users tag channels ("Family", "Work"). A label with a calendar_id
+// sends events detected in its channels to that Google calendar.
+func channelLabels(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS channel_labels (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			calendar_id TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+			UNIQUE(user_id, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS channel_label_assignments (
+			label_id INTEGER NOT NULL,
+			channel_id INTEGER NOT NULL,
+			PRIMARY KEY(label_id, channel_id),
+			FOREIGN KEY(label_id) REFERENCES channel_labels(id) ON DELETE CASCADE,
+			FOREIGN KEY(channel_id) REFERENCES channels(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_channel_label_assignments_channel ON channel_label_assignments(channel_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func channelLabelsDown(db *sql.DB) error {
+	return DropTables(db, "channel_label_assignments", "channel_labels")
+}
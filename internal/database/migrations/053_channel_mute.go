@@ -0,0 +1,21 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 53,
+		Name:    "channel_mute",
+		Up:      channelMute,
+		Down:    channelMuteDown,
+	})
+}
+
+// channelMute lets users pause analysis of a channel until a given time
+func channelMute(db *sql.DB) error {
+	return AddColumnIfNotExists(db, "channels", "muted_until", "DATETIME")
+}
+
+func channelMuteDown(db *sql.DB) error {
+	return DropColumnIfExists(db, "channels", "muted_until")
+}
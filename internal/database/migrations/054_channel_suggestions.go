@@ -0,0 +1,40 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 54,
+		Name:    "channel_suggestions",
+		Up:      channelSuggestions,
+		Down:    channelSuggestionsDown,
+	})
+}
+
+// channelSuggestions stores how many actionable-looking messages each untracked
+// channel sent recently, so onboarding can suggest channels worth tracking
+func channelSuggestions(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS channel_suggestions (
+			channel_id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			actionable_count INTEGER NOT NULL DEFAULT 0,
+			message_count INTEGER NOT NULL DEFAULT 0,
+			last_actionable_at DATETIME,
+			scored_at DATETIME NOT NULL,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY(channel_id) REFERENCES channels(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_channel_suggestions_user ON channel_suggestions(user_id, actionable_count)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func channelSuggestionsDown(db *sql.DB) error {
+	return DropTables(db, "channel_suggestions")
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 55,
+		Name:    "feature_flags",
+		Up:      featureFlags,
+		Down:    featureFlagsDown,
+	})
+}
+
+// featureFlags stores per-user overrides and instance-wide defaults for optional
+// capabilities, carrying over users who had opted in to bill detection or correction
+// examples through their feature_settings columns
+func featureFlags(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS feature_flags (
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY(user_id, name),
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS feature_flag_defaults (
+			name TEXT PRIMARY KEY,
+			enabled BOOLEAN NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`INSERT OR IGNORE INTO feature_flags (user_id, name, enabled)
+			SELECT user_id, 'bill_detection', 1 FROM feature_settings WHERE bill_detection_enabled = 1`,
+		`INSERT OR IGNORE INTO feature_flags (user_id, name, enabled)
+			SELECT user_id, 'correction_examples', 1 FROM feature_settings WHERE correction_examples_enabled = 1`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// featureFlagsDown writes users' bill detection and correction example choices back to
+// feature_settings before dropping the tables
+func featureFlagsDown(db *sql.DB) error {
+	for column, flag := range map[string]string{
+		"bill_detection_enabled":      "bill_detection",
+		"correction_examples_enabled": "correction_examples",
+	} {
+		_, err := db.Exec(`
+			UPDATE feature_settings SET `+column+` = COALESCE(
+				(SELECT f.enabled FROM feature_flags f WHERE f.user_id = feature_settings.user_id AND f.name = ?),
+				`+column+`)
+		`, flag)
+		if err != nil {
+			return err
+		}
+	}
+	return DropTables(db, "feature_flags", "feature_flag_defaults")
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 56,
+		Name:    "message_attachments",
+		Up:      messageAttachments,
+		Down:    messageAttachmentsDown,
+	})
+}
+
+// messageAttachments stores references to media sent with a message_history row (flyer
+// images, PDF invites) so events detected from the message can link the original.
+// source_ref locates the content at the source and is encrypted like message text.
+// pending_analysis.attachments carries the references of queued messages as JSON.
+func messageAttachments(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			message_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			mime_type TEXT NOT NULL DEFAULT '',
+			file_name TEXT NOT NULL DEFAULT '',
+			size_bytes INTEGER NOT NULL DEFAULT 0,
+			source_ref TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(message_id) REFERENCES message_history(id) ON DELETE CASCADE,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_message_attachments_message ON message_attachments(message_id)`); err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "pending_analysis", "attachments", "TEXT NOT NULL DEFAULT ''")
+}
+
+func messageAttachmentsDown(db *sql.DB) error {
+	if err := DropColumnIfExists(db, "pending_analysis", "attachments"); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_message_attachments_message`); err != nil {
+		return err
+	}
+	return DropTables(db, "message_attachments")
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 57,
+		Name:    "event_geocodes",
+		Up:      eventGeocodes,
+		Down:    eventGeocodesDown,
+	})
+}
+
+// eventGeocodes stores the coordinates and map link the geocode worker resolved for an
+// event's location. geocoded_location is the location string that was looked up (NULL
+// until then); a trigger clears the result when the location changes so it is looked up
+// again.
+func eventGeocodes(db *sql.DB) error {
+	columns := []struct{ name, def string }{
+		{"latitude", "REAL"},
+		{"longitude", "REAL"},
+		{"maps_url", "TEXT"},
+		{"geocoded_location", "TEXT"},
+	}
+	for _, column := range columns {
+		if err := AddColumnIfNotExists(db, "calendar_events", column.name, column.def); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(`CREATE TRIGGER IF NOT EXISTS calendar_events_location_geocode AFTER UPDATE OF location ON calendar_events
+		WHEN new.location IS NOT old.location BEGIN
+			UPDATE calendar_events SET latitude = NULL, longitude = NULL, maps_url = NULL, geocoded_location = NULL
+			WHERE id = new.id;
+		END`)
+	return err
+}
+
+func eventGeocodesDown(db *sql.DB) error {
+	if _, err := db.Exec(`DROP TRIGGER IF EXISTS calendar_events_location_geocode`); err != nil {
+		return err
+	}
+	for _, column := range []string{"geocoded_location", "maps_url", "longitude", "latitude"} {
+		if err := DropColumnIfExists(db, "calendar_events", column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 58,
+		Name:    "calendar_feeds",
+		Up:      calendarFeeds,
+		Down:    calendarFeedsDown,
+	})
+}
+
+// calendarFeeds stores the hash of each user's secret iCalendar feed token. A user has
+// at most one feed; rotating the token replaces the row.
+func calendarFeeds(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS calendar_feeds (
+			user_id INTEGER PRIMARY KEY,
+			token_hash TEXT NOT NULL UNIQUE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_accessed_at DATETIME,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	return err
+}
+
+func calendarFeedsDown(db *sql.DB) error {
+	return DropTables(db, "calendar_feeds")
+}
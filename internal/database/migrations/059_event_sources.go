@@ -0,0 +1,42 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 59,
+		Name:    "event_sources",
+		Up:      eventSources,
+		Down:    eventSourcesDown,
+	})
+}
+
+// eventSources records the extra messages that describe an event already detected from
+// another channel, e.g. a calendar invite email for an event first mentioned on WhatsApp.
+// The event's own channel and original_message_id remain its first source.
+func eventSources(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS event_sources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id INTEGER NOT NULL,
+			source_type TEXT NOT NULL,
+			channel_id INTEGER NOT NULL,
+			message_id INTEGER,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(event_id) REFERENCES calendar_events(id) ON DELETE CASCADE,
+			FOREIGN KEY(channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+			FOREIGN KEY(message_id) REFERENCES message_history(id) ON DELETE SET NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_event_sources_event ON event_sources(event_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func eventSourcesDown(db *sql.DB) error {
+	return DropTables(db, "event_sources")
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 60,
+		Name:    "reminder_escalation",
+		Up:      reminderEscalation,
+		Down:    reminderEscalationDown,
+	})
+}
+
+// reminderEscalation adds the per-user escalation rules for overdue reminders and
+// tracks each reminder's last re-notification. Reminders at or above
+// escalation_min_priority re-notify every escalation_interval_hours while overdue.
+func reminderEscalation(db *sql.DB) error {
+	columns := []struct{ table, name, definition string }{
+		{"user_notification_preferences", "escalation_enabled", "BOOLEAN NOT NULL DEFAULT 1"},
+		{"user_notification_preferences", "escalation_min_priority", "TEXT NOT NULL DEFAULT 'high'"},
+		{"user_notification_preferences", "escalation_interval_hours", "INTEGER NOT NULL DEFAULT 24"},
+		{"reminders", "last_escalated_at", "DATETIME"},
+		{"reminders", "escalation_count", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, c := range columns {
+		if err := AddColumnIfNotExists(db, c.table, c.name, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reminderEscalationDown(db *sql.DB) error {
+	for _, column := range []string{"escalation_count", "last_escalated_at"} {
+		if err := DropColumnIfExists(db, "reminders", column); err != nil {
+			return err
+		}
+	}
+	for _, column := range []string{"escalation_interval_hours", "escalation_min_priority", "escalation_enabled"} {
+		if err := DropColumnIfExists(db, "user_notification_preferences", column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
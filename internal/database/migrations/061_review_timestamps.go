@@ -0,0 +1,33 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 61,
+		Name:    "review_timestamps",
+		Up:      reviewTimestamps,
+		Down:    reviewTimestampsDown,
+	})
+}
+
+// reviewTimestamps records when a pending event or reminder was confirmed or rejected,
+// so insights can report how long items wait for review. Items reviewed before this
+// migration keep a NULL reviewed_at.
+func reviewTimestamps(db *sql.DB) error {
+	for _, table := range []string{"calendar_events", "reminders"} {
+		if err := AddColumnIfNotExists(db, table, "reviewed_at", "DATETIME"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reviewTimestampsDown(db *sql.DB) error {
+	for _, table := range []string{"reminders", "calendar_events"} {
+		if err := DropColumnIfExists(db, table, "reviewed_at"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
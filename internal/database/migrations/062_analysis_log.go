@@ -0,0 +1,52 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 62,
+		Name:    "analysis_log",
+		Up:      analysisLog,
+		Down:    analysisLogDown,
+	})
+}
+
+// analysisLog keeps one row per agent run on a message: a hash of the agent's input,
+// the tools it called, the decision it reached and what the run cost, so the app can
+// explain why a message did or didn't become an event or reminder.
+func analysisLog(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS analysis_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			channel_id INTEGER NOT NULL,
+			intent TEXT NOT NULL,
+			agent TEXT NOT NULL,
+			model TEXT NOT NULL DEFAULT '',
+			input_hash TEXT NOT NULL,
+			tool_calls TEXT NOT NULL DEFAULT '[]',
+			action TEXT NOT NULL DEFAULT '',
+			confidence REAL NOT NULL DEFAULT 0,
+			reasoning TEXT NOT NULL DEFAULT '',
+			latency_ms INTEGER NOT NULL DEFAULT 0,
+			input_tokens INTEGER NOT NULL DEFAULT 0,
+			output_tokens INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(message_id) REFERENCES message_history(id) ON DELETE CASCADE,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_analysis_log_message ON analysis_log(message_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func analysisLogDown(db *sql.DB) error {
+	return DropTables(db, "analysis_log")
+}
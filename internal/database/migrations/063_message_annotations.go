@@ -0,0 +1,42 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 63,
+		Name:    "message_annotations",
+		Up:      messageAnnotations,
+		Down:    messageAnnotationsDown,
+	})
+}
+
+// messageAnnotations stores users' labels on analyzed messages: a detection the agents
+// missed or one they got wrong. One row per message and kind.
+func messageAnnotations(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS message_annotations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			message_id INTEGER NOT NULL,
+			channel_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(message_id, kind),
+			FOREIGN KEY(message_id) REFERENCES message_history(id) ON DELETE CASCADE,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_annotations_user ON message_annotations(user_id, created_at)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func messageAnnotationsDown(db *sql.DB) error {
+	return DropTables(db, "message_annotations")
+}
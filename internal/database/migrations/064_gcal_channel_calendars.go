@@ -0,0 +1,33 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 64,
+		Name:    "gcal_channel_calendars",
+		Up:      gcalChannelCalendars,
+		Down:    gcalChannelCalendarsDown,
+	})
+}
+
+// gcalChannelCalendars maps channels to the Google calendar their confirmed events go
+// to, overriding the selected calendar
+func gcalChannelCalendars(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS gcal_channel_calendars (
+			channel_id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			calendar_id TEXT NOT NULL,
+			calendar_name TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`)
+	return err
+}
+
+func gcalChannelCalendarsDown(db *sql.DB) error {
+	return DropTables(db, "gcal_channel_calendars")
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 65,
+		Name:    "oauth_states",
+		Up:      oauthStates,
+		Down:    oauthStatesDown,
+	})
+}
+
+// oauthStates stores the server-issued state of each pending Google authorization: the
+// flow it belongs to, who started it, and the PKCE verifier for its code exchange.
+// Only the state's hash is kept; rows are deleted when the state is used.
+func oauthStates(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS oauth_states (
+			state_hash TEXT PRIMARY KEY,
+			purpose TEXT NOT NULL,
+			user_id INTEGER,
+			redirect_uri TEXT NOT NULL,
+			scopes TEXT NOT NULL DEFAULT '[]',
+			code_verifier TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_oauth_states_expires ON oauth_states(expires_at)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func oauthStatesDown(db *sql.DB) error {
+	return DropTables(db, "oauth_states")
+}
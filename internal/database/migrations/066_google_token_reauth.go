@@ -0,0 +1,22 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 66,
+		Name:    "google_token_reauth",
+		Up:      googleTokenReauth,
+		Down:    googleTokenReauthDown,
+	})
+}
+
+// googleTokenReauth records when Google rejected a user's refresh token, so Calendar and
+// Gmail can report that the user has to reconnect. Storing a new token clears it.
+func googleTokenReauth(db *sql.DB) error {
+	return AddColumnIfNotExists(db, "google_tokens", "reauth_required_at", "DATETIME")
+}
+
+func googleTokenReauthDown(db *sql.DB) error {
+	return DropColumnIfExists(db, "google_tokens", "reauth_required_at")
+}
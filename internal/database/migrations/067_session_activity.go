@@ -0,0 +1,28 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 67,
+		Name:    "session_activity",
+		Up:      sessionActivity,
+		Down:    sessionActivityDown,
+	})
+}
+
+// sessionActivity records where each session was last used from and when, so users can
+// recognize their sessions in the session list before revoking one
+func sessionActivity(db *sql.DB) error {
+	if err := AddColumnIfNotExists(db, "user_sessions", "ip_address", "TEXT"); err != nil {
+		return err
+	}
+	return AddColumnIfNotExists(db, "user_sessions", "last_seen_at", "DATETIME")
+}
+
+func sessionActivityDown(db *sql.DB) error {
+	if err := DropColumnIfExists(db, "user_sessions", "last_seen_at"); err != nil {
+		return err
+	}
+	return DropColumnIfExists(db, "user_sessions", "ip_address")
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 68,
+		Name:    "history_sync_checkpoints",
+		Up:      historySyncCheckpoints,
+		Down:    historySyncCheckpointsDown,
+	})
+}
+
+// historySyncCheckpoints records how far WhatsApp HistorySync has stored each
+// conversation, so a sync interrupted on a large account resumes after the newest
+// message already stored instead of starting over
+func historySyncCheckpoints(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS history_sync_checkpoints (
+			channel_id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			messages_total INTEGER NOT NULL DEFAULT 0,
+			messages_stored INTEGER NOT NULL DEFAULT 0,
+			synced_through DATETIME,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(channel_id) REFERENCES channels(id) ON DELETE CASCADE,
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_history_sync_checkpoints_user ON history_sync_checkpoints(user_id)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func historySyncCheckpointsDown(db *sql.DB) error {
+	return DropTables(db, "history_sync_checkpoints")
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 69,
+		Name:    "onboarding_steps",
+		Up:      onboardingSteps,
+		Down:    onboardingStepsDown,
+	})
+}
+
+// onboardingSteps records each user's progress through onboarding, so the app can
+// resume where the user left off and a server restart doesn't lose it
+func onboardingSteps(db *sql.DB) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS onboarding_steps (
+			user_id INTEGER NOT NULL,
+			step TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			data TEXT,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY(user_id, step),
+			FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func onboardingStepsDown(db *sql.DB) error {
+	return DropTables(db, "onboarding_steps")
+}
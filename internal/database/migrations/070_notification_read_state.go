@@ -0,0 +1,22 @@
+package migrations
+
+import "database/sql"
+
+func init() {
+	Register(Migration{
+		Version: 70,
+		Name:    "notification_read_state",
+		Up:      notificationReadState,
+		Down:    notificationReadStateDown,
+	})
+}
+
+// notificationReadState records when the user read a delivered push, so unread ones can
+// be counted towards the app icon badge
+func notificationReadState(db *sql.DB) error {
+	return AddColumnIfNotExists(db, "notification_queue", "read_at", "DATETIME")
+}
+
+func notificationReadStateDown(db *sql.DB) error {
+	return DropColumnIfExists(db, "notification_queue", "read_at")
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+)
+
+// RunCommand runs a schema command against db, writing its report to out. It backs the
+// server's -migrate flag and alfredctl's migrate command.
+//
+//	up      apply all pending migrations
+//	status  list every migration and whether it is applied
+//	verify  fail unless the schema matches this build exactly
+//	down    revert migrations newer than target
+func RunCommand(db *sql.DB, cmd string, target int, out io.Writer) error {
+	switch cmd {
+	case "up":
+		if err := RunMigrations(db); err != nil {
+			return err
+		}
+		if _, err := SetUpSearchIndex(db); err != nil {
+			return fmt.Errorf("failed to set up search index: %w", err)
+		}
+		fmt.Fprintf(out, "Database is at version %d\n", LatestVersion())
+		return nil
+
+	case "status":
+		statuses, err := Statuses(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			switch {
+			case s.Unknown:
+				state = "unknown (applied " + s.AppliedAt + ")"
+			case s.Applied:
+				state = "applied " + s.AppliedAt
+			}
+			reversible := ""
+			if !s.Reversible && !s.Unknown {
+				reversible = " [irreversible]"
+			}
+			fmt.Fprintf(out, "%03d %-40s %s%s\n", s.Version, s.Name, state, reversible)
+		}
+		return nil
+
+	case "verify":
+		if err := Verify(db); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Database schema is up to date")
+		return nil
+
+	case "down":
+		if target < 0 {
+			return fmt.Errorf("down requires a target version")
+		}
+		if err := Rollback(db, target); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Database rolled back to version %d\n", target)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q (want up, status, verify or down)", cmd)
+	}
+}
@@ -3,28 +3,56 @@ package migrations
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
+	"strings"
 )
 
-// Migration represents a database migration
+// Migration represents a database migration. Down is optional; migrations without it
+// are irreversible and block rolling back past their version.
 type Migration struct {
-	Version     int
-	Name        string
-	Up          func(*sql.DB) error
+	Version int
+	Name    string
+	Up      func(*sql.DB) error
+	Down    func(*sql.DB) error
+}
+
+// MigrationStatus describes a registered or applied migration
+type MigrationStatus struct {
+	Version    int
+	Name       string
+	Applied    bool
+	AppliedAt  string
+	Reversible bool
+	// Unknown is set for versions recorded in schema_migrations that this binary
+	// does not know about (e.g. the database was migrated by a newer build).
+	Unknown bool
 }
 
 // registry holds all registered migrations
 var registry []Migration
 
-// Register adds a migration to the registry
+// Register adds a migration to the registry. Registering the same version twice is a
+// programming error and panics at startup.
 func Register(m Migration) {
+	for _, existing := range registry {
+		if existing.Version == m.Version {
+			panic(fmt.Sprintf("migration version %d registered twice (%s, %s)", m.Version, existing.Name, m.Name))
+		}
+	}
 	registry = append(registry, m)
 }
 
-// RunMigrations executes all pending migrations in order
-func RunMigrations(db *sql.DB) error {
-	// Create schema_migrations table if it doesn't exist
+// sortedRegistry returns registered migrations in ascending version order
+func sortedRegistry() []Migration {
+	sort.Slice(registry, func(i, j int) bool {
+		return registry[i].Version < registry[j].Version
+	})
+	return registry
+}
+
+// ensureSchemaTable creates the schema_migrations table if it doesn't exist
+func ensureSchemaTable(db *sql.DB) error {
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS schema_migrations (
 			version INTEGER PRIMARY KEY,
@@ -35,35 +63,65 @@ func RunMigrations(db *sql.DB) error {
 	if err != nil {
 		return fmt.Errorf("failed to create schema_migrations table: %w", err)
 	}
+	return nil
+}
 
-	// Get applied migrations
-	applied := make(map[int]bool)
-	rows, err := db.Query("SELECT version FROM schema_migrations")
+type appliedMigration struct {
+	name      string
+	appliedAt string
+}
+
+// appliedMigrations returns the rows recorded in schema_migrations keyed by version
+func appliedMigrations(db *sql.DB) (map[int]appliedMigration, error) {
+	rows, err := db.Query("SELECT version, name, COALESCE(applied_at, '') FROM schema_migrations")
 	if err != nil {
-		return fmt.Errorf("failed to query schema_migrations: %w", err)
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
 	}
 	defer rows.Close()
 
+	applied := make(map[int]appliedMigration)
 	for rows.Next() {
 		var version int
-		if err := rows.Scan(&version); err != nil {
-			return fmt.Errorf("failed to scan version: %w", err)
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
 		}
-		applied[version] = true
+		applied[version] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema_migrations: %w", err)
 	}
+	return applied, nil
+}
 
-	// Sort migrations by version
-	sort.Slice(registry, func(i, j int) bool {
-		return registry[i].Version < registry[j].Version
-	})
+// RunMigrations executes all pending migrations in order
+func RunMigrations(db *sql.DB) error {
+	if err := ensureSchemaTable(db); err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[int]bool)
+	for _, m := range sortedRegistry() {
+		known[m.Version] = true
+	}
+	for version, a := range applied {
+		if !known[version] {
+			slog.Warn("Database has a migration applied that this build does not know about", "version", version, "name", a.name)
+		}
+	}
 
 	// Run pending migrations
 	for _, m := range registry {
-		if applied[m.Version] {
+		if _, ok := applied[m.Version]; ok {
 			continue
 		}
 
-		log.Printf("Running migration %d: %s", m.Version, m.Name)
+		slog.Info("Running migration", "version", m.Version, "name", m.Name)
 
 		if err := m.Up(db); err != nil {
 			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
@@ -78,12 +136,176 @@ func RunMigrations(db *sql.DB) error {
 			return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
 		}
 
-		log.Printf("Migration %d completed successfully", m.Version)
+		slog.Info("Migration completed", "version", m.Version)
+	}
+
+	return nil
+}
+
+// Rollback reverts applied migrations newer than target, newest first. Every migration
+// that needs reverting must have a Down function; this is checked before anything runs
+// so a rollback never stops halfway on an irreversible migration.
+func Rollback(db *sql.DB, target int) error {
+	if target < 0 {
+		return fmt.Errorf("invalid rollback target %d", target)
+	}
+	if err := ensureSchemaTable(db); err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	migrations := sortedRegistry()
+	var toRevert []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= target {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return fmt.Errorf("migration %d (%s) is irreversible", m.Version, m.Name)
+		}
+		toRevert = append(toRevert, m)
+	}
+	for version, a := range applied {
+		if version > target && !hasVersion(migrations, version) {
+			return fmt.Errorf("migration %d (%s) is not known to this build and cannot be rolled back", version, a.name)
+		}
+	}
+
+	for _, m := range toRevert {
+		slog.Info("Reverting migration", "version", m.Version, "name", m.Name)
+
+		if err := m.Down(db); err != nil {
+			return fmt.Errorf("rollback of migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := db.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+			return fmt.Errorf("failed to unrecord migration %d: %w", m.Version, err)
+		}
+
+		slog.Info("Migration reverted", "version", m.Version)
 	}
 
 	return nil
 }
 
+// Statuses reports every registered migration along with whether it has been applied,
+// plus any applied versions this build does not know about.
+func Statuses(db *sql.DB) ([]MigrationStatus, error) {
+	if err := ensureSchemaTable(db); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := sortedRegistry()
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		a, ok := applied[m.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:    m.Version,
+			Name:       m.Name,
+			Applied:    ok,
+			AppliedAt:  a.appliedAt,
+			Reversible: m.Down != nil,
+		})
+	}
+	for version, a := range applied {
+		if !hasVersion(migrations, version) {
+			statuses = append(statuses, MigrationStatus{
+				Version:   version,
+				Name:      a.name,
+				Applied:   true,
+				AppliedAt: a.appliedAt,
+				Unknown:   true,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Verify checks that the database schema matches this build exactly: every registered
+// migration is applied and no unknown migrations are recorded.
+func Verify(db *sql.DB) error {
+	statuses, err := Statuses(db)
+	if err != nil {
+		return err
+	}
+
+	var pending, unknown []string
+	for _, s := range statuses {
+		switch {
+		case s.Unknown:
+			unknown = append(unknown, fmt.Sprintf("%d_%s", s.Version, s.Name))
+		case !s.Applied:
+			pending = append(pending, fmt.Sprintf("%d_%s", s.Version, s.Name))
+		}
+	}
+
+	var problems []string
+	if len(pending) > 0 {
+		problems = append(problems, "pending: "+strings.Join(pending, ", "))
+	}
+	if len(unknown) > 0 {
+		problems = append(problems, "unknown: "+strings.Join(unknown, ", "))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("database schema is out of date (%s)", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// LatestVersion returns the highest registered migration version
+func LatestVersion() int {
+	migrations := sortedRegistry()
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+func hasVersion(migrations []Migration, version int) bool {
+	for _, m := range migrations {
+		if m.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// DropTables drops the given tables (and with them their indexes) in order. Intended
+// for Down functions of migrations that only create tables.
+func DropTables(db *sql.DB, tables ...string) error {
+	for _, table := range tables {
+		if _, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", table)); err != nil {
+			return fmt.Errorf("failed to drop table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// DropColumnIfExists drops a column from a table if it exists
+func DropColumnIfExists(db *sql.DB, table, column string) error {
+	exists, err := ColumnExists(db, table, column)
+	if err != nil || !exists {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column))
+	return err
+}
+
 // addColumnIfNotExists adds a column to a table if it doesn't already exist
 func AddColumnIfNotExists(db *sql.DB, table, column, columnDef string) error {
 	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "alfred.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count)
+	require.NoError(t, err)
+	return count > 0
+}
+
+func TestMigrations(t *testing.T) {
+	t.Run("verify reports pending migrations on a fresh database", func(t *testing.T) {
+		db := openTestDB(t)
+
+		err := Verify(db)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pending")
+	})
+
+	t.Run("run applies every migration and verify passes", func(t *testing.T) {
+		db := openTestDB(t)
+		require.NoError(t, RunMigrations(db))
+		require.NoError(t, Verify(db))
+
+		statuses, err := Statuses(db)
+		require.NoError(t, err)
+		require.Len(t, statuses, len(registry))
+		for _, s := range statuses {
+			assert.True(t, s.Applied, "migration %d should be applied", s.Version)
+			assert.NotEmpty(t, s.AppliedAt)
+		}
+		assert.Equal(t, statuses[len(statuses)-1].Version, LatestVersion())
+
+		// Running again is a no-op
+		require.NoError(t, RunMigrations(db))
+	})
+
+	t.Run("rollback reverts and re-applies reversible migrations", func(t *testing.T) {
+		db := openTestDB(t)
+		require.NoError(t, RunMigrations(db))
+
+		require.NoError(t, Rollback(db, 22))
+		assert.False(t, tableExists(t, db, "account_deletion_tokens"))
+		assert.False(t, tableExists(t, db, "devices"))
+		assert.False(t, tableExists(t, db, "channel_shares"))
+		assert.True(t, tableExists(t, db, "api_keys"))
+		exists, err := ColumnExists(db, "user_sessions", "previous_token_hash")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		err = Verify(db)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "23_session_refresh_rotation")
+
+		require.NoError(t, RunMigrations(db))
+		require.NoError(t, Verify(db))
+		assert.True(t, tableExists(t, db, "account_deletion_tokens"))
+	})
+
+	t.Run("search index rolls back and re-applies", func(t *testing.T) {
+		db := openTestDB(t)
+		require.NoError(t, RunMigrations(db))
+
+		require.NoError(t, Rollback(db, 19))
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name LIKE 'search\_%' ESCAPE '\'`).Scan(&count)
+		require.NoError(t, err)
+		assert.Zero(t, count, "search_index and its triggers are dropped")
+
+		require.NoError(t, RunMigrations(db))
+		require.NoError(t, Verify(db))
+		available, err := SetUpSearchIndex(db)
+		require.NoError(t, err)
+		assert.Equal(t, available, tableExists(t, db, "search_index"))
+	})
+
+	t.Run("rollback refuses to cross an irreversible migration", func(t *testing.T) {
+		db := openTestDB(t)
+		require.NoError(t, RunMigrations(db))
+
+		err := Rollback(db, 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "irreversible")
+
+		// Nothing was reverted
+		require.NoError(t, Verify(db))
+	})
+
+	t.Run("unknown applied migrations fail verification", func(t *testing.T) {
+		db := openTestDB(t)
+		require.NoError(t, RunMigrations(db))
+
+		_, err := db.Exec(`INSERT INTO schema_migrations (version, name) VALUES (9999, 'from_the_future')`)
+		require.NoError(t, err)
+
+		err = Verify(db)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown: 9999_from_the_future")
+
+		statuses, err := Statuses(db)
+		require.NoError(t, err)
+		last := statuses[len(statuses)-1]
+		assert.Equal(t, 9999, last.Version)
+		assert.True(t, last.Unknown)
+
+		err = Rollback(db, 25)
+		require.Error(t, err)
+		assert.True(t, tableExists(t, db, "data_exports"))
+	})
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NotificationStatus represents where a queued notification is in its retry lifecycle
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationFailed  NotificationStatus = "failed"
+)
+
+// Channels a queued notification is delivered over
+const (
+	NotificationChannelPush  = "push"
+	NotificationChannelEmail = "email"
+)
+
+// Kinds of pushes about a pending event or reminder. The item itself counts towards
+// PendingCounts, so these pushes don't count as unread notifications as well.
+const (
+	NotificationKindEventPending    = "event_pending"
+	NotificationKindReminderPending = "reminder_pending"
+)
+
+// QueuedNotification is one push or email waiting for, or done with, delivery.
+// Pushes go to every device the user has registered when the notification is delivered.
+type QueuedNotification struct {
+	ID            int64              `json:"id"`
+	UserID        int64              `json:"user_id"`
+	Channel       string             `json:"channel"`
+	Kind          string             `json:"kind"`
+	Title         string             `json:"title"`
+	Body          string             `json:"body"`
+	Recipient     string             `json:"recipient,omitempty"` // email address
+	Payload       string             `json:"-"`                   // push data JSON or email HTML
+	Status        NotificationStatus `json:"status"`
+	Attempts      int                `json:"attempts"`
+	NextAttemptAt *time.Time         `json:"next_attempt_at,omitempty"`
+	LastError     string             `json:"last_error,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	SentAt        *time.Time         `json:"sent_at,omitempty"`
+	ReadAt        *time.Time         `json:"read_at,omitempty"` // When the user opened or dismissed a delivered push
+}
+
+// EnqueueNotification queues n for delivery at now and returns its ID
+func (d *DB) EnqueueNotification(n QueuedNotification, now time.Time) (int64, error) {
+	var recipient any
+	if n.Recipient != "" {
+		recipient = n.Recipient
+	}
+
+	result, err := d.Exec(`
+		INSERT INTO notification_queue (user_id, channel, kind, title, body, recipient, payload, status, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, n.UserID, n.Channel, n.Kind, n.Title, n.Body, recipient, n.Payload, NotificationPending, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+const queuedNotificationColumns = `id, user_id, channel, kind, title, body, recipient, payload, status, attempts,
+	next_attempt_at, last_error, created_at, sent_at, read_at`
+
+func scanQueuedNotification(scanner interface{ Scan(...any) error }) (*QueuedNotification, error) {
+	var n QueuedNotification
+	var recipient, lastError sql.NullString
+	var nextAttemptAt, sentAt, readAt sql.NullTime
+	if err := scanner.Scan(
+		&n.ID, &n.UserID, &n.Channel, &n.Kind, &n.Title, &n.Body, &recipient, &n.Payload, &n.Status, &n.Attempts,
+		&nextAttemptAt, &lastError, &n.CreatedAt, &sentAt, &readAt,
+	); err != nil {
+		return nil, err
+	}
+	n.Recipient = recipient.String
+	n.LastError = lastError.String
+	if nextAttemptAt.Valid {
+		n.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if sentAt.Valid {
+		n.SentAt = &sentAt.Time
+	}
+	if readAt.Valid {
+		n.ReadAt = &readAt.Time
+	}
+	return &n, nil
+}
+
+// GetDueNotifications returns pending notifications whose next attempt is due, oldest first
+func (d *DB) GetDueNotifications(now time.Time, limit int) ([]QueuedNotification, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := d.Query(`
+		SELECT `+queuedNotificationColumns+`
+		FROM notification_queue
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC, id ASC
+		LIMIT ?
+	`, NotificationPending, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []QueuedNotification
+	for rows.Next() {
+		n, err := scanQueuedNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan queued notification: %w", err)
+		}
+		notifications = append(notifications, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating queued notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// RecordNotificationAttempt stores the outcome of a delivery attempt and moves the
+// notification to status. nextAttemptAt schedules the retry for pending notifications
+// and is ignored otherwise.
+func (d *DB) RecordNotificationAttempt(id int64, attempts int, status NotificationStatus, lastError string, nextAttemptAt *time.Time) error {
+	var errValue any
+	if lastError != "" {
+		errValue = lastError
+	}
+	var next any
+	if status == NotificationPending && nextAttemptAt != nil {
+		next = nextAttemptAt.UTC()
+	}
+	var sentAt any
+	if status == NotificationSent {
+		sentAt = time.Now().UTC()
+	}
+
+	_, err := d.Exec(`
+		UPDATE notification_queue
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, sent_at = ?
+		WHERE id = ?
+	`, status, attempts, next, errValue, sentAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to record notification attempt: %w", err)
+	}
+	return nil
+}
+
+// GetQueuedNotification returns a queued notification by ID
+func (d *DB) GetQueuedNotification(id int64) (*QueuedNotification, error) {
+	n, err := scanQueuedNotification(d.QueryRow(`
+		SELECT `+queuedNotificationColumns+` FROM notification_queue WHERE id = ?
+	`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued notification: %w", err)
+	}
+	return n, nil
+}
+
+// ListQueuedNotifications returns a user's most recent notifications, optionally only
+// those with status
+func (d *DB) ListQueuedNotifications(userID int64, status *NotificationStatus, limit int) ([]QueuedNotification, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `SELECT ` + queuedNotificationColumns + ` FROM notification_queue WHERE user_id = ?`
+	args := []any{userID}
+	if status != nil {
+		query += ` AND status = ?`
+		args = append(args, *status)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []QueuedNotification{}
+	for rows.Next() {
+		n, err := scanQueuedNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan queued notification: %w", err)
+		}
+		notifications = append(notifications, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating queued notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// PruneNotificationQueue deletes sent and failed notifications created before cutoff
+func (d *DB) PruneNotificationQueue(cutoff time.Time) (int64, error) {
+	result, err := d.Exec(`
+		DELETE FROM notification_queue WHERE status != ? AND created_at < ?
+	`, NotificationPending, cutoff.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune notification queue: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// unreadNotificationsCondition selects a user's delivered pushes that haven't been read,
+// leaving out those about pending items (see NotificationKindEventPending)
+const unreadNotificationsCondition = `user_id = ? AND channel = '` + NotificationChannelPush + `'
+	AND status = '` + string(NotificationSent) + `' AND read_at IS NULL
+	AND kind NOT IN ('` + NotificationKindEventPending + `', '` + NotificationKindReminderPending + `')`
+
+// MarkNotificationsRead marks a user's delivered pushes as read: those with the given
+// IDs, or all of them when ids is empty. It returns how many were newly marked.
+func (d *DB) MarkNotificationsRead(userID int64, ids []int64, now time.Time) (int64, error) {
+	query := `UPDATE notification_queue SET read_at = ? WHERE user_id = ? AND channel = ? AND status = ? AND read_at IS NULL`
+	args := []any{now.UTC(), userID, NotificationChannelPush, NotificationSent}
+	if len(ids) > 0 {
+		query += ` AND id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	result, err := d.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -23,6 +23,19 @@ type UserNotificationPrefs struct {
 	WebhookEnabled bool   `json:"webhook_enabled"`
 	WebhookURL     string `json:"webhook_url,omitempty"`
 
+	// Minutes before confirmed/synced events to send a push (empty = disabled)
+	EventNotifyOffsets []int `json:"event_notify_offsets"`
+
+	// Morning digest, sent at DigestTime ("HH:MM") in the user's timezone
+	DigestEnabled bool   `json:"digest_enabled"`
+	DigestTime    string `json:"digest_time"`
+
+	// Overdue reminders at or above EscalationMinPriority re-notify every
+	// EscalationIntervalHours until completed or dismissed
+	EscalationEnabled       bool             `json:"escalation_enabled"`
+	EscalationMinPriority   ReminderPriority `json:"escalation_min_priority"`
+	EscalationIntervalHours int              `json:"escalation_interval_hours"`
+
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
@@ -47,12 +60,16 @@ func (d *DB) GetUserNotificationPrefs(userID int64) (*UserNotificationPrefs, err
 	}
 
 	var prefs UserNotificationPrefs
+	var eventNotifyOffsets string
 	err := d.QueryRow(`
 		SELECT
 			email_enabled, COALESCE(email_address, ''),
 			push_enabled, COALESCE(push_token, ''),
 			sms_enabled, COALESCE(sms_phone, ''),
 			webhook_enabled, COALESCE(webhook_url, ''),
+			event_notify_offsets,
+			digest_enabled, digest_time,
+			escalation_enabled, escalation_min_priority, escalation_interval_hours,
 			updated_at
 		FROM user_notification_preferences
 		WHERE user_id = ?
@@ -61,11 +78,15 @@ func (d *DB) GetUserNotificationPrefs(userID int64) (*UserNotificationPrefs, err
 		&prefs.PushEnabled, &prefs.PushToken,
 		&prefs.SMSEnabled, &prefs.SMSPhone,
 		&prefs.WebhookEnabled, &prefs.WebhookURL,
+		&eventNotifyOffsets,
+		&prefs.DigestEnabled, &prefs.DigestTime,
+		&prefs.EscalationEnabled, &prefs.EscalationMinPriority, &prefs.EscalationIntervalHours,
 		&prefs.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get notification prefs: %w", err)
 	}
+	prefs.EventNotifyOffsets = ParseNotifyOffsets(eventNotifyOffsets)
 	return &prefs, nil
 }
 
@@ -105,20 +126,8 @@ func (d *DB) UpdatePushPrefs(userID int64, enabled bool) error {
 	return nil
 }
 
-// UpdatePushToken stores the Expo push token for a user
+// UpdatePushToken registers an Expo push token as one of the user's devices
 func (d *DB) UpdatePushToken(userID int64, token string) error {
-	// First ensure the row exists
-	if err := d.EnsureNotificationPrefs(userID); err != nil {
-		return err
-	}
-
-	_, err := d.Exec(`
-		UPDATE user_notification_preferences
-		SET push_token = ?, updated_at = CURRENT_TIMESTAMP
-		WHERE user_id = ?
-	`, token, userID)
-	if err != nil {
-		return fmt.Errorf("failed to update push token: %w", err)
-	}
-	return nil
+	_, err := d.RegisterDevice(userID, token, PushProviderExpo, "", "")
+	return err
 }
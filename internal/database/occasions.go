@@ -0,0 +1,79 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OccasionKind is the kind of yearly personal date
+type OccasionKind string
+
+const (
+	OccasionBirthday    OccasionKind = "birthday"
+	OccasionAnniversary OccasionKind = "anniversary"
+)
+
+// Occasion is a birthday or anniversary the occasion agent has recorded for a user.
+// There is at most one per person and kind.
+type Occasion struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	Kind      OccasionKind `json:"kind"`
+	Person    string       `json:"person"`
+	Month     int          `json:"month"`
+	Day       int          `json:"day"`
+	Year      *int         `json:"year,omitempty"` // Birth or wedding year, when mentioned
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// occasionPersonKey normalizes a person's name so "Mom" and " mom " are the same occasion
+func occasionPersonKey(person string) string {
+	return strings.ToLower(strings.Join(strings.Fields(person), " "))
+}
+
+// RecordOccasion stores an occasion unless the user already has one for the same person
+// and kind. Returns false when it was already recorded.
+func (d *DB) RecordOccasion(occasion *Occasion) (bool, error) {
+	result, err := d.Exec(`
+		INSERT OR IGNORE INTO occasions (user_id, kind, person, person_key, month, day, year)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, occasion.UserID, occasion.Kind, strings.TrimSpace(occasion.Person), occasionPersonKey(occasion.Person),
+		occasion.Month, occasion.Day, occasion.Year)
+	if err != nil {
+		return false, fmt.Errorf("failed to record occasion: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record occasion: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// ListOccasions returns a user's occasions in calendar order
+func (d *DB) ListOccasions(userID int64) ([]Occasion, error) {
+	rows, err := d.Query(`
+		SELECT id, user_id, kind, person, month, day, year, created_at
+		FROM occasions
+		WHERE user_id = ?
+		ORDER BY month, day, id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list occasions: %w", err)
+	}
+	defer rows.Close()
+
+	occasions := []Occasion{}
+	for rows.Next() {
+		var occasion Occasion
+		if err := rows.Scan(
+			&occasion.ID, &occasion.UserID, &occasion.Kind, &occasion.Person,
+			&occasion.Month, &occasion.Day, &occasion.Year, &occasion.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan occasion: %w", err)
+		}
+		occasions = append(occasions, occasion)
+	}
+	return occasions, rows.Err()
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordOccasionDedupsByPersonAndKind(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	year := 1960
+
+	isNew, err := db.RecordOccasion(&Occasion{UserID: user.ID, Kind: OccasionBirthday, Person: "Mom", Month: 3, Day: 3, Year: &year})
+	require.NoError(t, err)
+	assert.True(t, isNew)
+
+	// Same person with different casing and spacing is the same occasion
+	isNew, err = db.RecordOccasion(&Occasion{UserID: user.ID, Kind: OccasionBirthday, Person: "  mom ", Month: 3, Day: 3})
+	require.NoError(t, err)
+	assert.False(t, isNew)
+
+	// A different kind for the same person is tracked separately
+	isNew, err = db.RecordOccasion(&Occasion{UserID: user.ID, Kind: OccasionAnniversary, Person: "Mom", Month: 8, Day: 20})
+	require.NoError(t, err)
+	assert.True(t, isNew)
+
+	occasions, err := db.ListOccasions(user.ID)
+	require.NoError(t, err)
+	require.Len(t, occasions, 2)
+
+	other := CreateTestUser(t, db)
+	isNew, err = db.RecordOccasion(&Occasion{UserID: other.ID, Kind: OccasionBirthday, Person: "Mom", Month: 5, Day: 1})
+	require.NoError(t, err)
+	assert.True(t, isNew, "occasions are scoped per user")
+}
+
+func TestEventRecurrenceRoundTrip(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	created, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Mom's birthday",
+		StartTime:  time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC),
+		ActionType: EventActionCreate,
+		Recurrence: "RRULE:FREQ=YEARLY",
+	})
+	require.NoError(t, err)
+
+	retrieved, err := db.GetEventByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "RRULE:FREQ=YEARLY", retrieved.Recurrence)
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OnboardingStepName identifies one screen or connection in the onboarding flow
+type OnboardingStepName string
+
+const (
+	OnboardingStepWelcome             OnboardingStepName = "welcome"
+	OnboardingStepInputSelection      OnboardingStepName = "input_selection"
+	OnboardingStepWhatsApp            OnboardingStepName = "whatsapp"
+	OnboardingStepTelegram            OnboardingStepName = "telegram"
+	OnboardingStepGmail               OnboardingStepName = "gmail"
+	OnboardingStepGoogleCalendar      OnboardingStepName = "google_calendar"
+	OnboardingStepSourceConfiguration OnboardingStepName = "source_configuration"
+)
+
+// OnboardingSteps lists the steps in the order the app walks through them
+var OnboardingSteps = []OnboardingStepName{
+	OnboardingStepWelcome,
+	OnboardingStepInputSelection,
+	OnboardingStepWhatsApp,
+	OnboardingStepTelegram,
+	OnboardingStepGmail,
+	OnboardingStepGoogleCalendar,
+	OnboardingStepSourceConfiguration,
+}
+
+// OnboardingStepStatus is how far the user got with a step
+type OnboardingStepStatus string
+
+const (
+	OnboardingStepPending    OnboardingStepStatus = "pending"
+	OnboardingStepInProgress OnboardingStepStatus = "in_progress"
+	OnboardingStepCompleted  OnboardingStepStatus = "completed"
+	OnboardingStepSkipped    OnboardingStepStatus = "skipped" // Not wanted, e.g. an input the user didn't select
+)
+
+// Done reports whether the step no longer needs the user's attention
+func (s OnboardingStepStatus) Done() bool {
+	return s == OnboardingStepCompleted || s == OnboardingStepSkipped
+}
+
+// OnboardingStep is a user's progress through one onboarding step
+type OnboardingStep struct {
+	Step      OnboardingStepName   `json:"step"`
+	Status    OnboardingStepStatus `json:"status"`
+	Data      json.RawMessage      `json:"data,omitempty"` // Whatever the app needs to resume the step, e.g. selected inputs
+	UpdatedAt *time.Time           `json:"updated_at,omitempty"`
+}
+
+// ValidOnboardingStep reports whether step is one of OnboardingSteps
+func ValidOnboardingStep(step OnboardingStepName) bool {
+	for _, known := range OnboardingSteps {
+		if step == known {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOnboardingSteps returns every onboarding step for a user in flow order. Steps the
+// user hasn't reached yet are pending.
+func (d *DB) GetOnboardingSteps(userID int64) ([]OnboardingStep, error) {
+	rows, err := d.Query(`
+		SELECT step, status, data, updated_at FROM onboarding_steps WHERE user_id = ?
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query onboarding steps: %w", err)
+	}
+	defer rows.Close()
+
+	recorded := make(map[OnboardingStepName]OnboardingStep)
+	for rows.Next() {
+		var step OnboardingStep
+		var data sql.NullString
+		var updatedAt time.Time
+		if err := rows.Scan(&step.Step, &step.Status, &data, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan onboarding step: %w", err)
+		}
+		if data.Valid && data.String != "" {
+			step.Data = json.RawMessage(data.String)
+		}
+		step.UpdatedAt = &updatedAt
+		recorded[step.Step] = step
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read onboarding steps: %w", err)
+	}
+
+	steps := make([]OnboardingStep, len(OnboardingSteps))
+	for i, name := range OnboardingSteps {
+		if step, ok := recorded[name]; ok {
+			steps[i] = step
+		} else {
+			steps[i] = OnboardingStep{Step: name, Status: OnboardingStepPending}
+		}
+	}
+	return steps, nil
+}
+
+// SetOnboardingStep records a user's progress on a step. Nil data keeps what was stored.
+func (d *DB) SetOnboardingStep(userID int64, step OnboardingStepName, status OnboardingStepStatus, data json.RawMessage) error {
+	if !ValidOnboardingStep(step) {
+		return fmt.Errorf("unknown onboarding step %q", step)
+	}
+
+	var dataArg any
+	if data != nil {
+		dataArg = string(data)
+	}
+	_, err := d.Exec(`
+		INSERT INTO onboarding_steps (user_id, step, status, data)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id, step) DO UPDATE SET
+			status = excluded.status,
+			data = COALESCE(excluded.data, onboarding_steps.data),
+			updated_at = CURRENT_TIMESTAMP
+	`, userID, step, status, dataArg)
+	if err != nil {
+		return fmt.Errorf("failed to save onboarding step: %w", err)
+	}
+	return nil
+}
+
+// CurrentOnboardingStep is the first step that is neither completed nor skipped, or ""
+// when every step is done
+func CurrentOnboardingStep(steps []OnboardingStep) OnboardingStepName {
+	for _, step := range steps {
+		if !step.Status.Done() {
+			return step.Step
+		}
+	}
+	return ""
+}
+
+// GetUsersResumingOnboarding returns the users who have recorded onboarding progress but
+// haven't completed onboarding
+func (d *DB) GetUsersResumingOnboarding() ([]int64, error) {
+	rows, err := d.Query(`
+		SELECT DISTINCT os.user_id FROM onboarding_steps os
+		LEFT JOIN feature_settings fs ON fs.user_id = os.user_id
+		WHERE COALESCE(fs.onboarding_complete, 0) = 0
+		ORDER BY os.user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users resuming onboarding: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read users resuming onboarding: %w", err)
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnboardingSteps(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+
+	steps, err := db.GetOnboardingSteps(user.ID)
+	require.NoError(t, err)
+	require.Len(t, steps, len(OnboardingSteps))
+	for i, step := range steps {
+		assert.Equal(t, OnboardingSteps[i], step.Step)
+		assert.Equal(t, OnboardingStepPending, step.Status)
+		assert.Nil(t, step.UpdatedAt)
+	}
+	assert.Equal(t, OnboardingStepWelcome, CurrentOnboardingStep(steps))
+
+	inputs := json.RawMessage(`{"whatsapp":true,"telegram":false}`)
+	require.NoError(t, db.SetOnboardingStep(user.ID, OnboardingStepWelcome, OnboardingStepCompleted, nil))
+	require.NoError(t, db.SetOnboardingStep(user.ID, OnboardingStepInputSelection, OnboardingStepCompleted, inputs))
+	require.NoError(t, db.SetOnboardingStep(user.ID, OnboardingStepWhatsApp, OnboardingStepInProgress, nil))
+	require.NoError(t, db.SetOnboardingStep(user.ID, OnboardingStepTelegram, OnboardingStepSkipped, nil))
+	assert.Error(t, db.SetOnboardingStep(user.ID, "sms", OnboardingStepCompleted, nil))
+
+	steps, err = db.GetOnboardingSteps(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, OnboardingStepWhatsApp, CurrentOnboardingStep(steps))
+	assert.JSONEq(t, string(inputs), string(steps[1].Data))
+	assert.NotNil(t, steps[1].UpdatedAt)
+	assert.Equal(t, OnboardingStepSkipped, steps[3].Status)
+
+	// Nil data keeps what was stored
+	require.NoError(t, db.SetOnboardingStep(user.ID, OnboardingStepInputSelection, OnboardingStepInProgress, nil))
+	steps, err = db.GetOnboardingSteps(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, OnboardingStepInProgress, steps[1].Status)
+	assert.JSONEq(t, string(inputs), string(steps[1].Data))
+	assert.Equal(t, OnboardingStepInputSelection, CurrentOnboardingStep(steps))
+
+	// Other users start from scratch
+	steps, err = db.GetOnboardingSteps(other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, OnboardingStepWelcome, CurrentOnboardingStep(steps))
+
+	resuming, err := db.GetUsersResumingOnboarding()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{user.ID}, resuming)
+
+	require.NoError(t, db.CompleteOnboarding(user.ID, true, false, false))
+	resuming, err = db.GetUsersResumingOnboarding()
+	require.NoError(t, err)
+	assert.Empty(t, resuming)
+
+	// Resetting onboarding forgets the recorded steps
+	require.NoError(t, db.ResetOnboarding(user.ID))
+	steps, err = db.GetOnboardingSteps(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, OnboardingStepWelcome, CurrentOnboardingStep(steps))
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSort is returned when a list is requested with an unsupported sort field
+var ErrInvalidSort = errors.New("invalid sort field")
+
+// ListOptions controls paging, ordering, and date-range filtering for list queries.
+// The zero value returns every row in the endpoint's default order.
+type ListOptions struct {
+	Limit  int        // 0 = no limit
+	Offset int        // rows to skip
+	Sort   string     // field name, prefixed with "-" for descending; "" = default order
+	From   *time.Time // inclusive lower bound on the list's date field
+	To     *time.Time // exclusive upper bound on the list's date field
+	Label  int64      // only items from channels with this label; 0 = any
+}
+
+// orderBy resolves opts.Sort against the allowed fields (field name -> column expression).
+// Descending sorts put NULLs last to match the ascending behavior of the defaults.
+func (o ListOptions) orderBy(fields map[string]string, defaultOrder string) (string, error) {
+	if o.Sort == "" {
+		return defaultOrder, nil
+	}
+
+	field := strings.TrimPrefix(o.Sort, "-")
+	column, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidSort, field)
+	}
+
+	direction := "ASC"
+	if strings.HasPrefix(o.Sort, "-") {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("(%s IS NULL) ASC, %s %s", column, column, direction), nil
+}
+
+// dateRange appends From/To bounds on column to the WHERE clause
+func (o ListOptions) dateRange(column string, where string, args []any) (string, []any) {
+	if o.From != nil {
+		where += fmt.Sprintf(" AND %s >= ?", column)
+		args = append(args, *o.From)
+	}
+	if o.To != nil {
+		where += fmt.Sprintf(" AND %s < ?", column)
+		args = append(args, *o.To)
+	}
+	return where, args
+}
+
+// labelFilter restricts the WHERE clause to items whose channelColumn has opts.Label
+func (o ListOptions) labelFilter(channelColumn string, where string, args []any) (string, []any) {
+	if o.Label != 0 {
+		where += fmt.Sprintf(" AND %s IN (SELECT channel_id FROM channel_label_assignments WHERE label_id = ?)", channelColumn)
+		args = append(args, o.Label)
+	}
+	return where, args
+}
+
+// paged reports whether rows may be skipped or cut off, so the page alone doesn't give
+// the total and it has to be counted
+func (o ListOptions) paged() bool {
+	return o.Limit > 0 || o.Offset > 0
+}
+
+// limitClause returns the LIMIT/OFFSET suffix for the query
+func (o ListOptions) limitClause() (string, []any) {
+	if o.Limit <= 0 {
+		if o.Offset > 0 {
+			return " LIMIT -1 OFFSET ?", []any{o.Offset}
+		}
+		return "", nil
+	}
+	return " LIMIT ? OFFSET ?", []any{o.Limit, o.Offset}
+}
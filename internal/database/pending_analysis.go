@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// PendingAnalysis is a received chat message that hasn't been analyzed yet
+type PendingAnalysis struct {
+	ID        int64
+	Message   source.Message
+	Attempts  int // times claimed, including the current claim
+	CreatedAt time.Time
+}
+
+// Priority lanes of the pending_analysis queue
+const (
+	pendingPriorityLive     = 0
+	pendingPriorityBackfill = 1
+)
+
+// EnqueuePendingAnalysis durably records a received message before it is analyzed and
+// returns the row's ID. Backfilled messages go to the low-priority lane. The text and
+// attachment references are encrypted like message_history.
+func (d *DB) EnqueuePendingAnalysis(msg source.Message) (int64, error) {
+	text, err := d.encryptMessageText(msg.Text)
+	if err != nil {
+		return 0, err
+	}
+	attachments, err := d.encodePendingAttachments(msg.Attachments)
+	if err != nil {
+		return 0, err
+	}
+
+	priority := pendingPriorityLive
+	if msg.Backfill {
+		priority = pendingPriorityBackfill
+	}
+
+	result, err := d.Exec(`
+		INSERT INTO pending_analysis (user_id, source_type, channel_id, identifier, sender_id, sender_name, message_text, subject, timestamp, priority, attachments)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, msg.UserID, msg.SourceType, msg.SourceID, msg.Identifier, msg.SenderID, msg.SenderName, text, msg.Subject, msg.Timestamp, priority, attachments)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue message for analysis: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// ClaimNextPendingAnalysis marks the next unclaimed message as being analyzed and
+// returns it, counting the attempt. Live messages are claimed before backfilled ones,
+// each lane oldest first. Returns nil when no message is waiting.
+func (d *DB) ClaimNextPendingAnalysis() (*PendingAnalysis, error) {
+	for {
+		var p PendingAnalysis
+		var text, attachments string
+		var priority int
+		err := d.QueryRow(`
+			SELECT id, user_id, source_type, channel_id, identifier, sender_id, sender_name, message_text, subject, timestamp,
+				priority, attempts, created_at, attachments
+			FROM pending_analysis
+			WHERE claimed = 0
+			ORDER BY priority ASC, id ASC
+			LIMIT 1
+		`).Scan(
+			&p.ID, &p.Message.UserID, &p.Message.SourceType, &p.Message.SourceID, &p.Message.Identifier,
+			&p.Message.SenderID, &p.Message.SenderName, &text, &p.Message.Subject, &p.Message.Timestamp,
+			&priority, &p.Attempts, &p.CreatedAt, &attachments,
+		)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get next pending analysis: %w", err)
+		}
+
+		// Another worker may have claimed the row since it was read
+		result, err := d.Exec(`
+			UPDATE pending_analysis SET claimed = 1, attempts = attempts + 1 WHERE id = ? AND claimed = 0
+		`, p.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim pending analysis: %w", err)
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to claim pending analysis: %w", err)
+		} else if n == 0 {
+			continue
+		}
+
+		if p.Message.Text, err = d.decryptMessageText(text); err != nil {
+			return nil, err
+		}
+		if p.Message.Attachments, err = d.decodePendingAttachments(attachments); err != nil {
+			return nil, err
+		}
+		p.Message.Backfill = priority > pendingPriorityLive
+		p.Attempts++
+		return &p, nil
+	}
+}
+
+// encodePendingAttachments stores attachment references as encrypted JSON; no
+// attachments is an empty string
+func (d *DB) encodePendingAttachments(attachments []source.Attachment) (string, error) {
+	if len(attachments) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(attachments)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode attachments: %w", err)
+	}
+	return d.encryptMessageText(string(data))
+}
+
+func (d *DB) decodePendingAttachments(stored string) ([]source.Attachment, error) {
+	if stored == "" {
+		return nil, nil
+	}
+	data, err := d.decryptMessageText(stored)
+	if err != nil {
+		return nil, err
+	}
+	var attachments []source.Attachment
+	if err := json.Unmarshal([]byte(data), &attachments); err != nil {
+		return nil, fmt.Errorf("failed to decode attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// ReleasePendingAnalysisClaims returns every claimed message to its queue. Call it at
+// startup: claims left by a previous run belong to analyses that never finished.
+func (d *DB) ReleasePendingAnalysisClaims() error {
+	if _, err := d.Exec(`UPDATE pending_analysis SET claimed = 0 WHERE claimed = 1`); err != nil {
+		return fmt.Errorf("failed to release pending analysis claims: %w", err)
+	}
+	return nil
+}
+
+// DeletePendingAnalyses removes messages whose analysis has finished
+func (d *DB) DeletePendingAnalyses(ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	_, err := d.Exec(`DELETE FROM pending_analysis WHERE id IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete pending analyses: %w", err)
+	}
+	return nil
+}
+
+// CountPendingAnalyses returns how many received messages are waiting for analysis
+func (d *DB) CountPendingAnalyses() (int, error) {
+	var count int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM pending_analysis`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count pending analyses: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingAnalysisQueue(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel := createTestChannelForMessages(t, db, user.ID)
+
+	msg := func(text string, backfill bool) source.Message {
+		return source.Message{
+			UserID:     user.ID,
+			SourceType: source.SourceTypeWhatsApp,
+			SourceID:   channel.ID,
+			Identifier: "msg-test@s.whatsapp.net",
+			SenderID:   "msg-test@s.whatsapp.net",
+			SenderName: "Message Test Contact",
+			Text:       text,
+			Timestamp:  time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+			Backfill:   backfill,
+		}
+	}
+
+	backfillID, err := db.EnqueuePendingAnalysis(msg("from last week", true))
+	require.NoError(t, err)
+	firstID, err := db.EnqueuePendingAnalysis(msg("dinner friday?", false))
+	require.NoError(t, err)
+	secondID, err := db.EnqueuePendingAnalysis(msg("8pm works", false))
+	require.NoError(t, err)
+
+	t.Run("claims live messages first, oldest first", func(t *testing.T) {
+		first, err := db.ClaimNextPendingAnalysis()
+		require.NoError(t, err)
+		require.NotNil(t, first)
+		assert.Equal(t, firstID, first.ID)
+		assert.Equal(t, msg("dinner friday?", false), first.Message)
+		assert.Equal(t, 1, first.Attempts)
+
+		second, err := db.ClaimNextPendingAnalysis()
+		require.NoError(t, err)
+		require.NotNil(t, second)
+		assert.Equal(t, secondID, second.ID)
+
+		backfill, err := db.ClaimNextPendingAnalysis()
+		require.NoError(t, err)
+		require.NotNil(t, backfill)
+		assert.Equal(t, backfillID, backfill.ID)
+		assert.True(t, backfill.Message.Backfill)
+
+		none, err := db.ClaimNextPendingAnalysis()
+		require.NoError(t, err)
+		assert.Nil(t, none)
+	})
+
+	t.Run("released claims are claimed again", func(t *testing.T) {
+		require.NoError(t, db.ReleasePendingAnalysisClaims())
+		again, err := db.ClaimNextPendingAnalysis()
+		require.NoError(t, err)
+		require.NotNil(t, again)
+		assert.Equal(t, firstID, again.ID)
+		assert.Equal(t, 2, again.Attempts)
+	})
+
+	t.Run("deletes analyzed messages", func(t *testing.T) {
+		require.NoError(t, db.DeletePendingAnalyses(backfillID, firstID, secondID))
+		count, err := db.CountPendingAnalyses()
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
@@ -0,0 +1,31 @@
+package database
+
+import "fmt"
+
+// PendingCounts is what is waiting for a user's attention, for the app icon badge and
+// home-screen indicators
+type PendingCounts struct {
+	PendingEvents       int `json:"pending_events"`
+	PendingReminders    int `json:"pending_reminders"`
+	UnreadNotifications int `json:"unread_notifications"` // Delivered pushes not yet read, other than those about pending items
+	Total               int `json:"total"`
+}
+
+// GetPendingCounts counts a user's pending events, pending reminders and unread
+// notifications in one query
+func (d *DB) GetPendingCounts(userID int64) (*PendingCounts, error) {
+	var counts PendingCounts
+	err := d.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM calendar_events WHERE user_id = ? AND status = ?),
+			(SELECT COUNT(*) FROM reminders WHERE user_id = ? AND status = ?),
+			(SELECT COUNT(*) FROM notification_queue WHERE `+unreadNotificationsCondition+`)
+	`, userID, EventStatusPending, userID, ReminderStatusPending, userID).Scan(
+		&counts.PendingEvents, &counts.PendingReminders, &counts.UnreadNotifications,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count pending items: %w", err)
+	}
+	counts.Total = counts.PendingEvents + counts.PendingReminders + counts.UnreadNotifications
+	return &counts, nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingCounts(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+	now := time.Now()
+
+	counts, err := db.GetPendingCounts(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, PendingCounts{}, *counts)
+
+	for i := 0; i < 2; i++ {
+		_, err := db.CreatePendingEvent(&CalendarEvent{
+			UserID:     user.ID,
+			ChannelID:  channel.ID,
+			CalendarID: "primary",
+			Title:      "Pending Event",
+			StartTime:  now,
+			ActionType: EventActionCreate,
+		})
+		require.NoError(t, err)
+	}
+	_, err = db.CreatePendingReminder(&Reminder{
+		UserID:       user.ID,
+		ChannelID:    channel.ID,
+		CalendarID:   "primary",
+		Title:        "Pending reminder",
+		ActionType:   ReminderActionCreate,
+		Priority:     ReminderPriorityNormal,
+		LLMReasoning: "test",
+	})
+	require.NoError(t, err)
+
+	// Delivered pushes count until read, except those about the pending items themselves
+	push := func(userID int64, kind string, status NotificationStatus) int64 {
+		id, err := db.EnqueueNotification(QueuedNotification{
+			UserID:  userID,
+			Channel: NotificationChannelPush,
+			Kind:    kind,
+			Title:   "Alfred",
+		}, now)
+		require.NoError(t, err)
+		require.NoError(t, db.RecordNotificationAttempt(id, 1, status, "", nil))
+		return id
+	}
+	dueID := push(user.ID, "reminder_due", NotificationSent)
+	push(user.ID, "digest", NotificationSent)
+	push(user.ID, "digest", NotificationFailed)
+	push(user.ID, NotificationKindEventPending, NotificationSent)
+	otherID := push(other.ID, "digest", NotificationSent)
+
+	counts, err = db.GetPendingCounts(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, PendingCounts{PendingEvents: 2, PendingReminders: 1, UnreadNotifications: 2, Total: 5}, *counts)
+
+	// Marking is limited to the user's own notifications
+	marked, err := db.MarkNotificationsRead(user.ID, []int64{dueID, otherID}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+
+	n, err := db.GetQueuedNotification(dueID)
+	require.NoError(t, err)
+	assert.NotNil(t, n.ReadAt)
+
+	counts, err = db.GetPendingCounts(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counts.UnreadNotifications)
+	assert.Equal(t, 4, counts.Total)
+
+	marked, err = db.MarkNotificationsRead(user.ID, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), marked) // The digest and the event push
+
+	counts, err = db.GetPendingCounts(other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, PendingCounts{UnreadNotifications: 1, Total: 1}, *counts)
+}
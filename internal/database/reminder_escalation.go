@@ -0,0 +1,152 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Escalation interval bounds, in hours
+const (
+	DefaultEscalationIntervalHours = 24
+	MinEscalationIntervalHours     = 1
+	MaxEscalationIntervalHours     = 7 * 24
+)
+
+// ValidateEscalationPrefs checks the escalation rules a user can set
+func ValidateEscalationPrefs(minPriority ReminderPriority, intervalHours int) error {
+	switch minPriority {
+	case ReminderPriorityLow, ReminderPriorityNormal, ReminderPriorityHigh:
+	default:
+		return fmt.Errorf("min_priority must be one of low, normal, high")
+	}
+	if intervalHours < MinEscalationIntervalHours || intervalHours > MaxEscalationIntervalHours {
+		return fmt.Errorf("interval_hours must be between %d and %d", MinEscalationIntervalHours, MaxEscalationIntervalHours)
+	}
+	return nil
+}
+
+// UpdateEscalationPrefs sets the user's escalation rules for overdue reminders
+func (d *DB) UpdateEscalationPrefs(userID int64, enabled bool, minPriority ReminderPriority, intervalHours int) error {
+	if err := d.EnsureNotificationPrefs(userID); err != nil {
+		return err
+	}
+
+	_, err := d.Exec(`
+		UPDATE user_notification_preferences
+		SET escalation_enabled = ?, escalation_min_priority = ?, escalation_interval_hours = ?,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ?
+	`, enabled, minPriority, intervalHours, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update escalation prefs: %w", err)
+	}
+	return nil
+}
+
+// ListOverdueReminders retrieves a user's confirmed and synced reminders whose due date
+// has passed, oldest due first
+func (d *DB) ListOverdueReminders(userID int64, now time.Time) ([]Reminder, error) {
+	query := `
+		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
+			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
+			r.created_at, r.updated_at,
+			COALESCE(c.name, 'Alfred') as channel_name
+		FROM reminders r
+		LEFT JOIN channels c ON r.channel_id = c.id
+		WHERE r.user_id = ? AND r.status IN (?, ?)
+		  AND r.due_date IS NOT NULL
+		  AND r.due_date < ?
+		ORDER BY r.due_date ASC, r.id ASC
+	`
+
+	rows, err := d.Query(query, userID, ReminderStatusConfirmed, ReminderStatusSynced, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, *reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating overdue reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// GetRemindersForEscalation retrieves overdue reminders due another notification under
+// their owner's escalation rules: escalation is enabled, the reminder's priority is at
+// least the user's minimum, and the interval has passed since the at-due notification
+// or the last escalation. Users without preferences get the defaults (high, daily).
+func (d *DB) GetRemindersForEscalation(now time.Time, limit int) ([]Reminder, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
+			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
+			r.created_at, r.updated_at,
+			COALESCE(c.name, 'Alfred') as channel_name
+		FROM reminders r
+		LEFT JOIN channels c ON r.channel_id = c.id
+		LEFT JOIN user_notification_preferences p ON p.user_id = r.user_id
+		WHERE r.status IN (?, ?)
+		  AND r.due_date IS NOT NULL
+		  AND r.due_date < ?
+		  AND r.due_notification_sent_at IS NOT NULL
+		  AND COALESCE(p.escalation_enabled, 1) = 1
+		  AND (CASE r.priority WHEN 'high' THEN 3 WHEN 'normal' THEN 2 ELSE 1 END) >=
+			(CASE COALESCE(p.escalation_min_priority, 'high') WHEN 'high' THEN 3 WHEN 'normal' THEN 2 ELSE 1 END)
+		  AND julianday(COALESCE(r.last_escalated_at, r.due_notification_sent_at)) <=
+			julianday(?) - COALESCE(p.escalation_interval_hours, ?) / 24.0
+		ORDER BY r.due_date ASC
+		LIMIT ?
+	`
+
+	rows, err := d.Query(query, ReminderStatusConfirmed, ReminderStatusSynced, now, now,
+		DefaultEscalationIntervalHours, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders for escalation: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, *reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reminders for escalation: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// MarkReminderEscalated records an escalation notification for a reminder
+func (d *DB) MarkReminderEscalated(id int64, sentAt time.Time) error {
+	_, err := d.Exec(`
+		UPDATE reminders
+		SET last_escalated_at = ?, escalation_count = escalation_count + 1
+		WHERE id = ?
+	`, sentAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark reminder escalated: %w", err)
+	}
+	return nil
+}
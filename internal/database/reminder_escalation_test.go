@@ -0,0 +1,120 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createConfirmedReminder(t *testing.T, db *DB, userID, channelID int64, title string, due time.Time, priority ReminderPriority) *Reminder {
+	t.Helper()
+	reminder, err := db.CreatePendingReminder(&Reminder{
+		UserID:       userID,
+		ChannelID:    channelID,
+		CalendarID:   "primary",
+		Title:        title,
+		DueDate:      &due,
+		ActionType:   ReminderActionCreate,
+		Priority:     priority,
+		LLMReasoning: "test",
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateReminderStatus(reminder.ID, ReminderStatusConfirmed))
+	return reminder
+}
+
+func TestListOverdueReminders(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender,
+		"overdue-test@s.whatsapp.net", "Overdue Test")
+	require.NoError(t, err)
+
+	now := time.Now()
+	older := createConfirmedReminder(t, db, user.ID, channel.ID, "Older", now.Add(-48*time.Hour), ReminderPriorityLow)
+	newer := createConfirmedReminder(t, db, user.ID, channel.ID, "Newer", now.Add(-time.Hour), ReminderPriorityHigh)
+	createConfirmedReminder(t, db, user.ID, channel.ID, "Future", now.Add(time.Hour), ReminderPriorityHigh)
+	done := createConfirmedReminder(t, db, user.ID, channel.ID, "Done", now.Add(-2*time.Hour), ReminderPriorityHigh)
+	require.NoError(t, db.UpdateReminderStatus(done.ID, ReminderStatusCompleted))
+
+	overdue, err := db.ListOverdueReminders(user.ID, now)
+	require.NoError(t, err)
+	require.Len(t, overdue, 2)
+	assert.Equal(t, older.ID, overdue[0].ID)
+	assert.Equal(t, newer.ID, overdue[1].ID)
+}
+
+func TestGetRemindersForEscalation(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender,
+		"escalation-test@s.whatsapp.net", "Escalation Test")
+	require.NoError(t, err)
+
+	now := time.Now()
+	high := createConfirmedReminder(t, db, user.ID, channel.ID, "Pay rent", now.Add(-26*time.Hour), ReminderPriorityHigh)
+	normal := createConfirmedReminder(t, db, user.ID, channel.ID, "Water plants", now.Add(-26*time.Hour), ReminderPriorityNormal)
+	recent := createConfirmedReminder(t, db, user.ID, channel.ID, "Call bank", now.Add(-2*time.Hour), ReminderPriorityHigh)
+	for _, r := range []*Reminder{high, normal} {
+		_, err := db.MarkReminderDueNotificationSent(r.ID, now.Add(-25*time.Hour))
+		require.NoError(t, err)
+	}
+	_, err = db.MarkReminderDueNotificationSent(recent.ID, now.Add(-2*time.Hour))
+	require.NoError(t, err)
+
+	t.Run("defaults escalate high priority daily", func(t *testing.T) {
+		reminders, err := db.GetRemindersForEscalation(now, 10)
+		require.NoError(t, err)
+		require.Len(t, reminders, 1)
+		assert.Equal(t, high.ID, reminders[0].ID)
+	})
+
+	t.Run("escalation waits an interval after the last one", func(t *testing.T) {
+		require.NoError(t, db.MarkReminderEscalated(high.ID, now.Add(-time.Hour)))
+		reminders, err := db.GetRemindersForEscalation(now, 10)
+		require.NoError(t, err)
+		assert.Empty(t, reminders)
+
+		reminders, err = db.GetRemindersForEscalation(now.Add(24*time.Hour), 10)
+		require.NoError(t, err)
+		require.Len(t, reminders, 2)
+		assert.Equal(t, high.ID, reminders[0].ID)
+		assert.Equal(t, recent.ID, reminders[1].ID)
+	})
+
+	t.Run("user rules lower the priority and shorten the interval", func(t *testing.T) {
+		require.NoError(t, db.UpdateEscalationPrefs(user.ID, true, ReminderPriorityNormal, 1))
+		reminders, err := db.GetRemindersForEscalation(now, 10)
+		require.NoError(t, err)
+		ids := []int64{}
+		for _, r := range reminders {
+			ids = append(ids, r.ID)
+		}
+		assert.ElementsMatch(t, []int64{high.ID, normal.ID, recent.ID}, ids)
+	})
+
+	t.Run("disabled escalation and dismissed reminders are skipped", func(t *testing.T) {
+		require.NoError(t, db.UpdateEscalationPrefs(user.ID, true, ReminderPriorityLow, 1))
+		require.NoError(t, db.UpdateReminderStatus(normal.ID, ReminderStatusDismissed))
+		reminders, err := db.GetRemindersForEscalation(now, 10)
+		require.NoError(t, err)
+		require.Len(t, reminders, 2)
+		assert.Equal(t, high.ID, reminders[0].ID)
+		assert.Equal(t, recent.ID, reminders[1].ID)
+
+		require.NoError(t, db.UpdateEscalationPrefs(user.ID, false, ReminderPriorityLow, 1))
+		reminders, err = db.GetRemindersForEscalation(now, 10)
+		require.NoError(t, err)
+		assert.Empty(t, reminders)
+	})
+}
+
+func TestValidateEscalationPrefs(t *testing.T) {
+	assert.NoError(t, ValidateEscalationPrefs(ReminderPriorityHigh, DefaultEscalationIntervalHours))
+	assert.Error(t, ValidateEscalationPrefs("urgent", 24))
+	assert.Error(t, ValidateEscalationPrefs(ReminderPriorityNormal, 0))
+	assert.Error(t, ValidateEscalationPrefs(ReminderPriorityNormal, MaxEscalationIntervalHours+1))
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ReminderSnooze records a single snooze of a reminder
+type ReminderSnooze struct {
+	ID                   int64      `json:"id"`
+	ReminderID           int64      `json:"reminder_id"`
+	PreviousDueDate      *time.Time `json:"previous_due_date,omitempty"`
+	PreviousReminderTime *time.Time `json:"previous_reminder_time,omitempty"`
+	SnoozedUntil         time.Time  `json:"snoozed_until"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// SnoozeReminder moves a reminder's due date to until, clears any earlier reminder_time,
+// and re-arms the due and lead notifications. The previous schedule is recorded in reminder_snoozes.
+func (d *DB) SnoozeReminder(reminder *Reminder, until time.Time) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin snooze transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO reminder_snoozes (user_id, reminder_id, previous_due_date, previous_reminder_time, snoozed_until)
+		VALUES (?, ?, ?, ?, ?)
+	`, reminder.UserID, reminder.ID, reminder.DueDate, reminder.ReminderTime, until)
+	if err != nil {
+		return fmt.Errorf("failed to record reminder snooze: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE reminders
+		SET due_date = ?, reminder_time = NULL, due_notification_sent_at = NULL, last_escalated_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, until, reminder.ID)
+	if err != nil {
+		return fmt.Errorf("failed to snooze reminder: %w", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM reminder_lead_notifications WHERE reminder_id = ?`, reminder.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reset reminder lead notifications: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reminder snooze: %w", err)
+	}
+	return nil
+}
+
+// ListReminderSnoozes returns the snooze history for a reminder, most recent first
+func (d *DB) ListReminderSnoozes(reminderID int64) ([]ReminderSnooze, error) {
+	rows, err := d.Query(`
+		SELECT id, reminder_id, previous_due_date, previous_reminder_time, snoozed_until, created_at
+		FROM reminder_snoozes
+		WHERE reminder_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, reminderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminder snoozes: %w", err)
+	}
+	defer rows.Close()
+
+	snoozes := []ReminderSnooze{}
+	for rows.Next() {
+		var snooze ReminderSnooze
+		var prevDue, prevReminderTime sql.NullTime
+		if err := rows.Scan(
+			&snooze.ID, &snooze.ReminderID, &prevDue, &prevReminderTime, &snooze.SnoozedUntil, &snooze.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan reminder snooze: %w", err)
+		}
+		if prevDue.Valid {
+			snooze.PreviousDueDate = &prevDue.Time
+		}
+		if prevReminderTime.Valid {
+			snooze.PreviousReminderTime = &prevReminderTime.Time
+		}
+		snoozes = append(snoozes, snooze)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reminder snoozes: %w", err)
+	}
+
+	return snoozes, nil
+}
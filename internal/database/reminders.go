@@ -57,6 +57,10 @@ type Reminder struct {
 	QualityFlags  []string           `json:"quality_flags,omitempty"`
 	Source        string             `json:"source,omitempty"`
 	EmailSourceID *int64             `json:"email_source_id,omitempty"`
+	ReplacesID    *int64             `json:"replaces_reminder_id,omitempty"` // Confirmed reminder an update/delete action changes
+	Payee         string             `json:"payee,omitempty"`                // Bill reminders: who is owed
+	Amount        *float64           `json:"amount,omitempty"`               // Bill reminders: amount due
+	Currency      string             `json:"currency,omitempty"`             // Bill reminders: ISO 4217 code
 	CreatedAt     time.Time          `json:"created_at"`
 	UpdatedAt     time.Time          `json:"updated_at"`
 	ChannelName   string             `json:"channel_name,omitempty"` // Joined from channels table
@@ -69,12 +73,14 @@ func (d *DB) CreatePendingReminder(reminder *Reminder) (*Reminder, error) {
 		INSERT INTO reminders (
 			user_id, channel_id, google_event_id, calendar_id, title, description,
 			location, due_date, reminder_time, priority, status, action_type,
-			original_message_id, llm_reasoning, llm_confidence, quality_flags, source, email_source_id
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			original_message_id, llm_reasoning, llm_confidence, quality_flags, source, email_source_id,
+			replaces_reminder_id, payee, amount, currency
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		reminder.UserID, reminder.ChannelID, reminder.GoogleEventID, reminder.CalendarID, reminder.Title, reminder.Description,
 		reminder.Location, reminder.DueDate, reminder.ReminderTime, reminder.Priority, ReminderStatusPending, reminder.ActionType,
 		reminder.OriginalMsgID, reminder.LLMReasoning, reminder.LLMConfidence, encodeQualityFlags(reminder.QualityFlags), reminder.Source, reminder.EmailSourceID,
+		reminder.ReplacesID, reminder.Payee, reminder.Amount, reminder.Currency,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create reminder: %w", err)
@@ -106,13 +112,18 @@ func scanReminder(scanner reminderScanner) (*Reminder, error) {
 	var reminderTimeNull sql.NullTime
 	var origMsgIDNull sql.NullInt64
 	var emailSourceIDNull sql.NullInt64
+	var replacesIDNull sql.NullInt64
+	var payeeNull sql.NullString
+	var amountNull sql.NullFloat64
+	var currencyNull sql.NullString
 	var sourceNull sql.NullString
 	var qualityFlagsNull sql.NullString
 
 	err := scanner.Scan(
 		&reminder.ID, &reminder.UserID, &reminder.ChannelID, &googleEventID, &reminder.CalendarID, &reminder.Title,
 		&descriptionNull, &locationNull, &dueDateNull, &reminderTimeNull, &reminder.Priority, &reminder.Status,
-		&reminder.ActionType, &origMsgIDNull, &reminder.LLMReasoning, &reminder.LLMConfidence, &qualityFlagsNull, &sourceNull, &emailSourceIDNull,
+		&reminder.ActionType, &origMsgIDNull, &reminder.LLMReasoning, &reminder.LLMConfidence, &qualityFlagsNull, &sourceNull, &emailSourceIDNull, &replacesIDNull,
+		&payeeNull, &amountNull, &currencyNull,
 		&reminder.CreatedAt, &reminder.UpdatedAt, &reminder.ChannelName,
 	)
 	if err != nil {
@@ -141,6 +152,14 @@ func scanReminder(scanner reminderScanner) (*Reminder, error) {
 	if emailSourceIDNull.Valid {
 		reminder.EmailSourceID = &emailSourceIDNull.Int64
 	}
+	if replacesIDNull.Valid {
+		reminder.ReplacesID = &replacesIDNull.Int64
+	}
+	reminder.Payee = payeeNull.String
+	if amountNull.Valid {
+		reminder.Amount = &amountNull.Float64
+	}
+	reminder.Currency = currencyNull.String
 	if sourceNull.Valid {
 		reminder.Source = sourceNull.String
 	}
@@ -154,7 +173,8 @@ func (d *DB) GetReminderByID(id int64) (*Reminder, error) {
 	reminder, err := scanReminder(d.QueryRow(`
 		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
 			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
-			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
 			r.created_at, r.updated_at,
 			c.name as channel_name
 		FROM reminders r
@@ -168,35 +188,61 @@ func (d *DB) GetReminderByID(id int64) (*Reminder, error) {
 	return reminder, nil
 }
 
+// reminderSortFields maps sortable reminder fields to their columns
+var reminderSortFields = map[string]string{
+	"due_date":   "r.due_date",
+	"created_at": "r.created_at",
+	"updated_at": "r.updated_at",
+	"title":      "r.title",
+}
+
 // ListReminders retrieves reminders with optional filtering by status and channel
 func (d *DB) ListReminders(userID int64, status *ReminderStatus, channelID *int64) ([]Reminder, error) {
-	query := `
-		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
-			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
-			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id,
-			r.created_at, r.updated_at,
-			c.name as channel_name
-		FROM reminders r
-		JOIN channels c ON r.channel_id = c.id
-		WHERE r.user_id = ?
-	`
+	reminders, _, err := d.ListRemindersWithOptions(userID, status, channelID, ListOptions{})
+	return reminders, err
+}
+
+// ListRemindersWithOptions retrieves a page of reminders for a user. From/To filter on due_date,
+// so reminders without a due date are excluded when either bound is set.
+// Returns the page and the total number of reminders matching the filters.
+func (d *DB) ListRemindersWithOptions(userID int64, status *ReminderStatus, channelID *int64, opts ListOptions) ([]Reminder, int, error) {
+	orderBy, err := opts.orderBy(reminderSortFields, "(r.due_date IS NULL) ASC, r.due_date ASC, r.created_at DESC")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	where := " WHERE r.user_id = ?"
 	args := []any{userID}
 
 	if status != nil {
-		query += " AND r.status = ?"
+		where += " AND r.status = ?"
 		args = append(args, *status)
 	}
 
 	if channelID != nil {
-		query += " AND r.channel_id = ?"
+		where += " AND r.channel_id = ?"
 		args = append(args, *channelID)
 	}
 
-	query += " ORDER BY (r.due_date IS NULL) ASC, r.due_date ASC, r.created_at DESC"
+	where, args = opts.dateRange("r.due_date", where, args)
+	where, args = opts.labelFilter("r.channel_id", where, args)
 
-	rows, err := d.Query(query, args...)
+	query := `
+		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
+			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
+			r.created_at, r.updated_at,
+			c.name as channel_name
+		FROM reminders r
+		JOIN channels c ON r.channel_id = c.id
+	` + where + " ORDER BY " + orderBy + ", r.id DESC"
+
+	limit, limitArgs := opts.limitClause()
+
+	rows, err := d.Query(query+limit, append(append([]any{}, args...), limitArgs...)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list reminders: %w", err)
+		return nil, 0, fmt.Errorf("failed to list reminders: %w", err)
 	}
 	defer rows.Close()
 
@@ -204,16 +250,24 @@ func (d *DB) ListReminders(userID int64, status *ReminderStatus, channelID *int6
 	for rows.Next() {
 		reminder, err := scanReminder(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+			return nil, 0, fmt.Errorf("failed to scan reminder: %w", err)
 		}
 		reminders = append(reminders, *reminder)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating reminders: %w", err)
+		return nil, 0, fmt.Errorf("error iterating reminders: %w", err)
 	}
 
-	return reminders, nil
+	total := len(reminders)
+	if opts.paged() {
+		countQuery := `SELECT COUNT(*) FROM reminders r JOIN channels c ON r.channel_id = c.id` + where
+		if err := d.QueryRow(countQuery, args...).Scan(&total); err != nil {
+			return nil, 0, fmt.Errorf("failed to count reminders: %w", err)
+		}
+	}
+
+	return reminders, total, nil
 }
 
 // GetPendingReminders retrieves all pending reminders, optionally filtered by channel
@@ -228,7 +282,8 @@ func (d *DB) GetActiveRemindersForChannel(channelID int64) ([]Reminder, error) {
 	query := `
 		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
 			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
-			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
 			r.created_at, r.updated_at,
 			c.name as channel_name
 		FROM reminders r
@@ -278,24 +333,55 @@ func (d *DB) UpdatePendingReminder(
 	return nil
 }
 
-// UpdateReminderStatus updates the status of a reminder
+// UpdateReminderStatus updates the status of a reminder. Leaving pending records reviewed_at.
 func (d *DB) UpdateReminderStatus(id int64, status ReminderStatus) error {
 	_, err := d.Exec(`
 		UPDATE reminders
-		SET status = ?, updated_at = CURRENT_TIMESTAMP
+		SET status = ?,
+			reviewed_at = CASE WHEN status = 'pending' AND ? != 'pending' THEN CURRENT_TIMESTAMP ELSE reviewed_at END,
+			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
-	`, status, id)
+	`, status, status, id)
 	if err != nil {
 		return fmt.Errorf("failed to update reminder status: %w", err)
 	}
 	return nil
 }
 
+// RetireReplacedReminder dismisses the confirmed or synced reminder that a confirmed
+// update/delete reminder replaces, so only the change stays active. It returns the
+// retired reminder's ID, or 0 if there was none.
+func (d *DB) RetireReplacedReminder(changeID int64) (int64, error) {
+	var originalID sql.NullInt64
+	err := d.QueryRow(`SELECT replaces_reminder_id FROM reminders WHERE id = ?`, changeID).Scan(&originalID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get replaced reminder: %w", err)
+	}
+	if !originalID.Valid {
+		return 0, nil
+	}
+
+	result, err := d.Exec(`
+		UPDATE reminders
+		SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN (?, ?)
+	`, ReminderStatusDismissed, originalID.Int64, ReminderStatusConfirmed, ReminderStatusSynced)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retire replaced reminder: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return 0, nil
+	}
+	return originalID.Int64, nil
+}
+
 // UpdateReminderGoogleID sets the Google Calendar event ID after syncing
 func (d *DB) UpdateReminderGoogleID(id int64, googleEventID string) error {
 	_, err := d.Exec(`
 		UPDATE reminders
-		SET google_event_id = ?, status = ?, updated_at = CURRENT_TIMESTAMP
+		SET google_event_id = ?, status = ?,
+			reviewed_at = CASE WHEN status = 'pending' THEN CURRENT_TIMESTAMP ELSE reviewed_at END,
+			updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`, googleEventID, ReminderStatusSynced, id)
 	if err != nil {
@@ -323,6 +409,16 @@ func (d *DB) CountPendingReminders() (int, error) {
 	return count, nil
 }
 
+// CountPendingRemindersForUser returns the number of pending reminders for a user
+func (d *DB) CountPendingRemindersForUser(userID int64) (int, error) {
+	var count int
+	err := d.QueryRow(`SELECT COUNT(*) FROM reminders WHERE user_id = ? AND status = ?`, userID, ReminderStatusPending).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending reminders: %w", err)
+	}
+	return count, nil
+}
+
 // GetUpcomingReminders retrieves confirmed/synced reminders due within a time window
 func (d *DB) GetUpcomingReminders(window time.Duration) ([]Reminder, error) {
 	now := time.Now()
@@ -331,7 +427,8 @@ func (d *DB) GetUpcomingReminders(window time.Duration) ([]Reminder, error) {
 	query := `
 		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
 			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
-			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
 			r.created_at, r.updated_at,
 			COALESCE(c.name, 'Alfred') as channel_name
 		FROM reminders r
@@ -365,6 +462,48 @@ func (d *DB) GetUpcomingReminders(window time.Duration) ([]Reminder, error) {
 	return reminders, nil
 }
 
+// ListRemindersDueInRange retrieves a user's confirmed and synced reminders due in
+// [start, end), ordered by due date
+func (d *DB) ListRemindersDueInRange(userID int64, start, end time.Time, limit int) ([]Reminder, error) {
+	query := `
+		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
+			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
+			r.created_at, r.updated_at,
+			COALESCE(c.name, 'Alfred') as channel_name
+		FROM reminders r
+		LEFT JOIN channels c ON r.channel_id = c.id
+		WHERE r.user_id = ? AND r.status IN (?, ?)
+		  AND r.due_date IS NOT NULL
+		  AND r.due_date >= ?
+		  AND r.due_date < ?
+		ORDER BY r.due_date ASC, r.id ASC
+		LIMIT ?
+	`
+
+	rows, err := d.Query(query, userID, ReminderStatusConfirmed, ReminderStatusSynced, start, end, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders in range: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, *reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
 // GetDueRemindersForNotification retrieves active reminders that reached their scheduled notification time.
 // Uses reminder_time when present, otherwise falls back to due_date.
 func (d *DB) GetDueRemindersForNotification(now time.Time, limit int) ([]Reminder, error) {
@@ -375,7 +514,8 @@ func (d *DB) GetDueRemindersForNotification(now time.Time, limit int) ([]Reminde
 	query := `
 		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
 			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
-			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
 			r.created_at, r.updated_at,
 			COALESCE(c.name, 'Alfred') as channel_name
 		FROM reminders r
@@ -410,6 +550,77 @@ func (d *DB) GetDueRemindersForNotification(now time.Time, limit int) ([]Reminde
 	return reminders, nil
 }
 
+// GetRemindersForLeadNotification retrieves active reminders whose due date falls within
+// the next lead window and that haven't had a notification for that lead yet.
+// Reminders already past their due date are left to the at-due notification.
+func (d *DB) GetRemindersForLeadNotification(now time.Time, lead time.Duration, limit int) ([]Reminder, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT r.id, r.user_id, r.channel_id, r.google_event_id, r.calendar_id, r.title,
+			r.description, r.location, r.due_date, r.reminder_time, r.priority, r.status,
+			r.action_type, r.original_message_id, r.llm_reasoning, r.llm_confidence, r.quality_flags, r.source, r.email_source_id, r.replaces_reminder_id,
+			r.payee, r.amount, r.currency,
+			r.created_at, r.updated_at,
+			COALESCE(c.name, 'Alfred') as channel_name
+		FROM reminders r
+		LEFT JOIN channels c ON r.channel_id = c.id
+		WHERE r.status IN (?, ?)
+		  AND r.due_date IS NOT NULL
+		  AND r.due_date > ?
+		  AND r.due_date <= ?
+		  AND NOT EXISTS (
+			SELECT 1 FROM reminder_lead_notifications ln
+			WHERE ln.reminder_id = r.id AND ln.offset_minutes = ?
+		  )
+		ORDER BY r.due_date ASC
+		LIMIT ?
+	`
+
+	rows, err := d.Query(query, ReminderStatusConfirmed, ReminderStatusSynced,
+		now, now.Add(lead), int(lead/time.Minute), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reminders for lead notification: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, *reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lead reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// MarkReminderLeadNotificationSent records that the lead notification for the given
+// offset was sent. Returns true only when this call inserted the record.
+func (d *DB) MarkReminderLeadNotificationSent(id int64, lead time.Duration, sentAt time.Time) (bool, error) {
+	result, err := d.Exec(`
+		INSERT OR IGNORE INTO reminder_lead_notifications (reminder_id, offset_minutes, sent_at)
+		VALUES (?, ?, ?)
+	`, id, int(lead/time.Minute), sentAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark reminder lead notification sent: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to read rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
 // MarkReminderDueNotificationSent marks a reminder as already notified.
 // Returns true only when this call changed the row.
 func (d *DB) MarkReminderDueNotificationSent(id int64, sentAt time.Time) (bool, error) {
@@ -132,3 +132,374 @@ func TestMarkReminderDueNotificationSent_Idempotent(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, second)
 }
+
+func TestGetRemindersForLeadNotification(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeWhatsApp,
+		source.ChannelTypeSender,
+		"lead-reminder-test@s.whatsapp.net",
+		"Lead Reminder Test",
+	)
+	require.NoError(t, err)
+
+	createConfirmed := func(title string, due time.Time) *Reminder {
+		reminder, err := db.CreatePendingReminder(&Reminder{
+			UserID:       user.ID,
+			ChannelID:    channel.ID,
+			CalendarID:   "primary",
+			Title:        title,
+			DueDate:      &due,
+			ActionType:   ReminderActionCreate,
+			Priority:     ReminderPriorityNormal,
+			LLMReasoning: "test",
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateReminderStatus(reminder.ID, ReminderStatusConfirmed))
+		return reminder
+	}
+
+	now := time.Now()
+	soon := createConfirmed("Due soon", now.Add(30*time.Minute))
+	createConfirmed("Due later", now.Add(3*time.Hour))
+	createConfirmed("Already due", now.Add(-time.Minute))
+
+	reminders, err := db.GetRemindersForLeadNotification(now, time.Hour, 10)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, soon.ID, reminders[0].ID)
+
+	first, err := db.MarkReminderLeadNotificationSent(soon.ID, time.Hour, now)
+	require.NoError(t, err)
+	assert.True(t, first)
+
+	second, err := db.MarkReminderLeadNotificationSent(soon.ID, time.Hour, now)
+	require.NoError(t, err)
+	assert.False(t, second)
+
+	reminders, err = db.GetRemindersForLeadNotification(now, time.Hour, 10)
+	require.NoError(t, err)
+	assert.Empty(t, reminders)
+
+	// Other offsets are tracked independently.
+	reminders, err = db.GetRemindersForLeadNotification(now, 45*time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, soon.ID, reminders[0].ID)
+
+	// Snoozing re-arms lead notifications.
+	require.NoError(t, db.SnoozeReminder(soon, now.Add(50*time.Minute)))
+	reminders, err = db.GetRemindersForLeadNotification(now, time.Hour, 10)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, soon.ID, reminders[0].ID)
+}
+
+func TestSnoozeReminder_ReschedulesAndRecordsHistory(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeWhatsApp,
+		source.ChannelTypeSender,
+		"snooze-reminder-test@s.whatsapp.net",
+		"Snooze Reminder Test",
+	)
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	reminderAt := time.Now().Add(-2 * time.Hour)
+	reminder, err := db.CreatePendingReminder(&Reminder{
+		UserID:       user.ID,
+		ChannelID:    channel.ID,
+		CalendarID:   "primary",
+		Title:        "Snooze me",
+		DueDate:      &past,
+		ReminderTime: &reminderAt,
+		ActionType:   ReminderActionCreate,
+		Priority:     ReminderPriorityNormal,
+		LLMReasoning: "test",
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateReminderStatus(reminder.ID, ReminderStatusConfirmed))
+
+	notified, err := db.MarkReminderDueNotificationSent(reminder.ID, time.Now())
+	require.NoError(t, err)
+	require.True(t, notified)
+
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	require.NoError(t, db.SnoozeReminder(reminder, until))
+
+	snoozed, err := db.GetReminderByID(reminder.ID)
+	require.NoError(t, err)
+	require.NotNil(t, snoozed.DueDate)
+	assert.WithinDuration(t, until, *snoozed.DueDate, time.Second)
+	assert.Nil(t, snoozed.ReminderTime)
+
+	// The due notification is re-armed for the new due date.
+	due, err := db.GetDueRemindersForNotification(until.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, reminder.ID, due[0].ID)
+
+	history, err := db.ListReminderSnoozes(reminder.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.NotNil(t, history[0].PreviousDueDate)
+	assert.WithinDuration(t, past, *history[0].PreviousDueDate, time.Second)
+	require.NotNil(t, history[0].PreviousReminderTime)
+	assert.WithinDuration(t, until, history[0].SnoozedUntil, time.Second)
+}
+
+func TestListRemindersWithOptions(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeWhatsApp,
+		source.ChannelTypeSender,
+		"paged-reminders@s.whatsapp.net",
+		"Paged Reminders",
+	)
+	require.NoError(t, err)
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	create := func(title string, due *time.Time) int64 {
+		reminder, err := db.CreatePendingReminder(&Reminder{
+			UserID:       user.ID,
+			ChannelID:    channel.ID,
+			CalendarID:   "primary",
+			Title:        title,
+			DueDate:      due,
+			ActionType:   ReminderActionCreate,
+			Priority:     ReminderPriorityNormal,
+			LLMReasoning: "test",
+		})
+		require.NoError(t, err)
+		return reminder.ID
+	}
+
+	first, second, third := base, base.AddDate(0, 0, 1), base.AddDate(0, 0, 2)
+	firstID := create("First", &first)
+	secondID := create("Second", &second)
+	thirdID := create("Third", &third)
+	undatedID := create("Undated", nil)
+
+	page, total, err := db.ListRemindersWithOptions(user.ID, nil, nil, ListOptions{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	require.Len(t, page, 3)
+	assert.Equal(t, firstID, page[0].ID)
+
+	// An offset without a limit, even one past the end, still reports the real total
+	page, total, err = db.ListRemindersWithOptions(user.ID, nil, nil, ListOptions{Offset: 100})
+	require.NoError(t, err)
+	assert.Empty(t, page)
+	assert.Equal(t, 4, total)
+
+	// Undated reminders sort last in both directions.
+	page, _, err = db.ListRemindersWithOptions(user.ID, nil, nil, ListOptions{Sort: "-due_date"})
+	require.NoError(t, err)
+	require.Len(t, page, 4)
+	assert.Equal(t, thirdID, page[0].ID)
+	assert.Equal(t, undatedID, page[3].ID)
+
+	from := second
+	page, total, err = db.ListRemindersWithOptions(user.ID, nil, nil, ListOptions{Limit: 10, From: &from})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, page, 2)
+	assert.Equal(t, secondID, page[0].ID)
+	assert.Equal(t, thirdID, page[1].ID)
+}
+
+func TestRetireReplacedReminder(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeWhatsApp,
+		source.ChannelTypeSender,
+		"retire-reminder-test@s.whatsapp.net",
+		"Retire Reminder Test",
+	)
+	require.NoError(t, err)
+
+	due := time.Now().Add(24 * time.Hour)
+	original, err := db.CreatePendingReminder(&Reminder{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Pay rent",
+		DueDate:    &due,
+		ActionType: ReminderActionCreate,
+		Priority:   ReminderPriorityNormal,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateReminderGoogleID(original.ID, "gcal-rent"))
+
+	change, err := db.CreatePendingReminder(&Reminder{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Pay rent",
+		DueDate:    &due,
+		ActionType: ReminderActionUpdate,
+		Priority:   ReminderPriorityHigh,
+		ReplacesID: &original.ID,
+	})
+	require.NoError(t, err)
+
+	fetched, err := db.GetReminderByID(change.ID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.ReplacesID)
+	assert.Equal(t, original.ID, *fetched.ReplacesID)
+
+	retiredID, err := db.RetireReplacedReminder(change.ID)
+	require.NoError(t, err)
+	assert.Equal(t, original.ID, retiredID)
+
+	retired, err := db.GetReminderByID(original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ReminderStatusDismissed, retired.Status)
+
+	// Already retired and reminders without a replacement are no-ops
+	retiredID, err = db.RetireReplacedReminder(change.ID)
+	require.NoError(t, err)
+	assert.Zero(t, retiredID)
+
+	retiredID, err = db.RetireReplacedReminder(original.ID)
+	require.NoError(t, err)
+	assert.Zero(t, retiredID)
+}
+
+func TestReminderBillMetadataRoundTrip(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeWhatsApp,
+		source.ChannelTypeSender,
+		"bill-reminder-test@s.whatsapp.net",
+		"Bill Reminder Test",
+	)
+	require.NoError(t, err)
+
+	due := time.Now().Add(72 * time.Hour)
+	amount := 412.5
+	created, err := db.CreatePendingReminder(&Reminder{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Pay Israel Electric Corp",
+		DueDate:    &due,
+		ActionType: ReminderActionCreate,
+		Priority:   ReminderPriorityHigh,
+		Payee:      "Israel Electric Corp",
+		Amount:     &amount,
+		Currency:   "ILS",
+	})
+	require.NoError(t, err)
+
+	got, err := db.GetReminderByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Israel Electric Corp", got.Payee)
+	require.NotNil(t, got.Amount)
+	assert.Equal(t, 412.5, *got.Amount)
+	assert.Equal(t, "ILS", got.Currency)
+
+	plain, err := db.CreatePendingReminder(&Reminder{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Call mom",
+		DueDate:    &due,
+		ActionType: ReminderActionCreate,
+		Priority:   ReminderPriorityNormal,
+	})
+	require.NoError(t, err)
+	got, err = db.GetReminderByID(plain.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.Amount)
+	assert.Empty(t, got.Payee)
+}
+
+func TestBillDetectionSetting(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	enabled, err := db.IsBillDetectionEnabled(user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled, "bill detection is opt-in")
+
+	require.NoError(t, db.SetBillDetectionEnabled(user.ID, true))
+	enabled, err = db.IsBillDetectionEnabled(user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	settings, err := db.GetFeatureSettings(user.ID)
+	require.NoError(t, err)
+	assert.True(t, settings.BillDetectionEnabled)
+
+	require.NoError(t, db.SetBillDetectionEnabled(user.ID, false))
+	enabled, err = db.IsBillDetectionEnabled(user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+}
+
+func TestListRemindersDueInRange(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	create := func(userID int64, title string, due *time.Time, status ReminderStatus) *Reminder {
+		channel, err := db.CreateSourceChannel(userID, source.SourceTypeWhatsApp, source.ChannelTypeSender, title+"@s.whatsapp.net", title)
+		require.NoError(t, err)
+		reminder, err := db.CreatePendingReminder(&Reminder{
+			UserID:     userID,
+			ChannelID:  channel.ID,
+			CalendarID: "primary",
+			Title:      title,
+			DueDate:    due,
+			ActionType: ReminderActionCreate,
+			Priority:   ReminderPriorityNormal,
+		})
+		require.NoError(t, err)
+		if status != ReminderStatusPending {
+			require.NoError(t, db.UpdateReminderStatus(reminder.ID, status))
+		}
+		return reminder
+	}
+	at := func(hours int) *time.Time {
+		due := day.Add(time.Duration(hours) * time.Hour)
+		return &due
+	}
+
+	evening := create(user.ID, "evening", at(18), ReminderStatusSynced)
+	morning := create(user.ID, "morning", at(9), ReminderStatusConfirmed)
+	create(user.ID, "pending", at(10), ReminderStatusPending)
+	create(user.ID, "done", at(11), ReminderStatusCompleted)
+	create(user.ID, "undated", nil, ReminderStatusConfirmed)
+	create(user.ID, "tomorrow", at(24), ReminderStatusConfirmed)
+	create(other.ID, "other user", at(12), ReminderStatusConfirmed)
+
+	reminders, err := db.ListRemindersDueInRange(user.ID, day, day.AddDate(0, 0, 1), 10)
+	require.NoError(t, err)
+	require.Len(t, reminders, 2, "only active reminders due in the range, end exclusive")
+	assert.Equal(t, morning.ID, reminders[0].ID)
+	assert.Equal(t, evening.ID, reminders[1].ID)
+	assert.Equal(t, "morning", reminders[0].ChannelName)
+
+	reminders, err = db.ListRemindersDueInRange(user.ID, day, day.AddDate(0, 0, 2), 1)
+	require.NoError(t, err)
+	require.Len(t, reminders, 1)
+	assert.Equal(t, morning.ID, reminders[0].ID)
+}
@@ -0,0 +1,378 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/omriShneor/project_alfred/internal/database/migrations"
+)
+
+// SearchResultType identifies what a search hit points at
+type SearchResultType string
+
+const (
+	SearchResultMessage  SearchResultType = "message"
+	SearchResultEvent    SearchResultType = "event"
+	SearchResultReminder SearchResultType = "reminder"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	snippetRadius      = 60
+
+	// maxDecryptedSearchMessages bounds how many of a user's encrypted messages one
+	// search decrypts, most recent first
+	maxDecryptedSearchMessages = 5000
+)
+
+var searchKinds = map[SearchResultType]int{
+	SearchResultMessage:  migrations.SearchKindMessage,
+	SearchResultEvent:    migrations.SearchKindEvent,
+	SearchResultReminder: migrations.SearchKindReminder,
+}
+
+// SearchHit is a single search result across messages, events, and reminders
+type SearchHit struct {
+	Type        SearchResultType `json:"type"`
+	ID          int64            `json:"id"`
+	Title       string           `json:"title"`
+	Snippet     string           `json:"snippet"`
+	ChannelID   int64            `json:"channel_id"`
+	ChannelName string           `json:"channel_name,omitempty"`
+	Timestamp   *time.Time       `json:"timestamp,omitempty"` // message time, event start, or reminder due date
+	Status      string           `json:"status,omitempty"`    // event/reminder status
+}
+
+// Search finds the user's messages, events, and reminders matching every term in query.
+// Uses the FTS5 index (ranked by relevance) when available, otherwise LIKE matching
+// ordered by recency. Encrypted message text is never indexed, so it is matched by
+// decrypting the user's messages; those hits follow the ranked ones. An empty types
+// slice searches all types.
+func (d *DB) Search(userID int64, query string, types []SearchResultType, limit int) ([]SearchHit, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return []SearchHit{}, nil
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+	if len(types) == 0 {
+		types = []SearchResultType{SearchResultMessage, SearchResultEvent, SearchResultReminder}
+	}
+
+	var exists int
+	if err := d.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'search_index'`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check search index: %w", err)
+	}
+	ranked := exists > 0
+
+	var hits []SearchHit
+	var err error
+	if ranked {
+		hits, err = d.searchFTS(userID, terms, types, limit)
+	} else {
+		hits, err = d.searchLike(userID, terms, types, limit)
+	}
+	if err != nil || d.messageCipher == nil || !slices.Contains(types, SearchResultMessage) {
+		return hits, err
+	}
+
+	encrypted, err := d.searchEncryptedMessages(userID, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int64]bool)
+	for _, hit := range hits {
+		if hit.Type == SearchResultMessage {
+			found[hit.ID] = true
+		}
+	}
+	for _, hit := range encrypted {
+		if !found[hit.ID] {
+			hits = append(hits, hit)
+		}
+	}
+	if !ranked {
+		sortByRecency(hits)
+	}
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// searchTerms splits a free-text query into words, dropping punctuation so user
+// input can never be interpreted as FTS5 query syntax.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '-'
+	})
+}
+
+func (d *DB) searchFTS(userID int64, terms []string, types []SearchResultType, limit int) ([]SearchHit, error) {
+	// Every term must match; each is a quoted prefix query so "dent" finds "dentist".
+	matches := make([]string, len(terms))
+	for i, term := range terms {
+		matches[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+
+	kinds := make([]string, 0, len(types))
+	for _, t := range types {
+		kind, ok := searchKinds[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown search type: %s", t)
+		}
+		kinds = append(kinds, fmt.Sprint(kind))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT s.rowid %% 4, s.rowid / 4, s.title,
+			snippet(search_index, -1, '', '', '…', 16),
+			COALESCE(m.channel_id, e.channel_id, r.channel_id, 0),
+			COALESCE(c.name, ''),
+			m.timestamp, e.start_time, r.due_date,
+			COALESCE(e.status, r.status, '')
+		FROM search_index s
+		LEFT JOIN message_history m ON s.rowid %% 4 = %[1]d AND m.id = s.rowid / 4
+		LEFT JOIN calendar_events e ON s.rowid %% 4 = %[2]d AND e.id = s.rowid / 4
+		LEFT JOIN reminders r ON s.rowid %% 4 = %[3]d AND r.id = s.rowid / 4
+		LEFT JOIN channels c ON c.id = COALESCE(m.channel_id, e.channel_id, r.channel_id)
+		WHERE search_index MATCH ? AND s.user_id = ? AND s.rowid %% 4 IN (%[4]s)
+		ORDER BY bm25(search_index, 2.0, 1.0)
+		LIMIT ?
+	`, migrations.SearchKindMessage, migrations.SearchKindEvent, migrations.SearchKindReminder, strings.Join(kinds, ","))
+
+	rows, err := d.Query(query, strings.Join(matches, " "), userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []SearchHit{}
+	for rows.Next() {
+		var hit SearchHit
+		var kind int
+		var messageTime, eventStart, reminderDue sql.NullTime
+		if err := rows.Scan(
+			&kind, &hit.ID, &hit.Title, &hit.Snippet, &hit.ChannelID, &hit.ChannelName,
+			&messageTime, &eventStart, &reminderDue, &hit.Status,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+
+		switch kind {
+		case migrations.SearchKindMessage:
+			hit.Type = SearchResultMessage
+			hit.Timestamp = nullTimePtr(messageTime)
+		case migrations.SearchKindEvent:
+			hit.Type = SearchResultEvent
+			hit.Timestamp = nullTimePtr(eventStart)
+		case migrations.SearchKindReminder:
+			hit.Type = SearchResultReminder
+			hit.Timestamp = nullTimePtr(reminderDue)
+		}
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search hits: %w", err)
+	}
+
+	return hits, nil
+}
+
+// searchLike is the fallback for SQLite builds without FTS5
+func (d *DB) searchLike(userID int64, terms []string, types []SearchResultType, limit int) ([]SearchHit, error) {
+	// Encrypted message text is matched after decryption (searchEncryptedMessages)
+	messageText := migrations.SearchableMessageText("m.message_text")
+	tables := map[SearchResultType]struct {
+		query    string
+		textExpr string
+	}{
+		SearchResultMessage: {
+			query: `SELECT m.id, COALESCE(m.sender_name, ''), TRIM(COALESCE(m.subject, '') || ' ' || ` + messageText + `),
+				m.channel_id, COALESCE(c.name, ''), m.timestamp, ''
+				FROM message_history m LEFT JOIN channels c ON c.id = m.channel_id
+				WHERE m.user_id = ?`,
+			textExpr: `(COALESCE(m.sender_name, '') || ' ' || COALESCE(m.subject, '') || ' ' || ` + messageText + `)`,
+		},
+		SearchResultEvent: {
+			query: `SELECT e.id, e.title, TRIM(COALESCE(e.description, '') || ' ' || COALESCE(e.location, '')),
+				e.channel_id, COALESCE(c.name, ''), e.start_time, e.status
+				FROM calendar_events e LEFT JOIN channels c ON c.id = e.channel_id
+				WHERE e.user_id = ?`,
+			textExpr: `(e.title || ' ' || COALESCE(e.description, '') || ' ' || COALESCE(e.location, ''))`,
+		},
+		SearchResultReminder: {
+			query: `SELECT r.id, r.title, TRIM(COALESCE(r.description, '') || ' ' || COALESCE(r.location, '')),
+				r.channel_id, COALESCE(c.name, ''), r.due_date, r.status
+				FROM reminders r LEFT JOIN channels c ON c.id = r.channel_id
+				WHERE r.user_id = ?`,
+			textExpr: `(r.title || ' ' || COALESCE(r.description, '') || ' ' || COALESCE(r.location, ''))`,
+		},
+	}
+
+	hits := []SearchHit{}
+	for _, t := range types {
+		table, ok := tables[t]
+		if !ok {
+			return nil, fmt.Errorf("unknown search type: %s", t)
+		}
+
+		query := table.query
+		args := []any{userID}
+		for _, term := range terms {
+			query += fmt.Sprintf(` AND %s LIKE ? ESCAPE '\'`, table.textExpr)
+			args = append(args, "%"+escapeLike(term)+"%")
+		}
+		query += " LIMIT ?"
+		args = append(args, limit)
+
+		rows, err := d.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %ss: %w", t, err)
+		}
+
+		for rows.Next() {
+			hit := SearchHit{Type: t}
+			var body string
+			var ts sql.NullTime
+			if err := rows.Scan(&hit.ID, &hit.Title, &body, &hit.ChannelID, &hit.ChannelName, &ts, &hit.Status); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan search hit: %w", err)
+			}
+			hit.Timestamp = nullTimePtr(ts)
+			hit.Snippet = likeSnippet(body, terms)
+			hits = append(hits, hit)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating search hits: %w", err)
+		}
+	}
+
+	// Without relevance ranking, most recent first
+	sortByRecency(hits)
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// searchEncryptedMessages matches terms against the user's encrypted messages by
+// decrypting them, most recent first. Like the LIKE fallback, a term matches anywhere
+// in the sender name, subject, or text.
+func (d *DB) searchEncryptedMessages(userID int64, terms []string, limit int) ([]SearchHit, error) {
+	rows, err := d.Query(`
+		SELECT m.id, COALESCE(m.sender_name, ''), COALESCE(m.subject, ''), m.message_text,
+			m.channel_id, COALESCE(c.name, ''), m.timestamp
+		FROM message_history m LEFT JOIN channels c ON c.id = m.channel_id
+		WHERE m.user_id = ? AND m.message_text LIKE ?
+		ORDER BY m.timestamp DESC, m.id DESC
+		LIMIT ?
+	`, userID, encryptedMessagePrefix+"%", maxDecryptedSearchMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search encrypted messages: %w", err)
+	}
+	defer rows.Close()
+
+	lowerTerms := make([]string, len(terms))
+	for i, term := range terms {
+		lowerTerms[i] = strings.ToLower(term)
+	}
+
+	hits := []SearchHit{}
+	for rows.Next() && len(hits) < limit {
+		hit := SearchHit{Type: SearchResultMessage}
+		var subject, text string
+		var ts sql.NullTime
+		if err := rows.Scan(&hit.ID, &hit.Title, &subject, &text, &hit.ChannelID, &hit.ChannelName, &ts); err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+		if text, err = d.decryptMessageText(text); err != nil {
+			return nil, err
+		}
+
+		body := strings.TrimSpace(subject + " " + text)
+		haystack := strings.ToLower(hit.Title + " " + body)
+		matched := true
+		for _, term := range lowerTerms {
+			if !strings.Contains(haystack, term) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		hit.Timestamp = nullTimePtr(ts)
+		hit.Snippet = likeSnippet(body, terms)
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search hits: %w", err)
+	}
+	return hits, nil
+}
+
+// sortByRecency orders hits most recent first, undated ones last
+func sortByRecency(hits []SearchHit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Timestamp == nil || hits[j].Timestamp == nil {
+			return hits[i].Timestamp != nil
+		}
+		return hits[i].Timestamp.After(*hits[j].Timestamp)
+	})
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// likeSnippet returns the text surrounding the first matching term
+func likeSnippet(body string, terms []string) string {
+	lower := strings.ToLower(body)
+	idx := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, strings.ToLower(term)); i >= 0 {
+			idx = i
+			break
+		}
+	}
+	// Lowercasing can change byte lengths for some scripts; fall back to the start
+	if idx < 0 || idx > len(body) {
+		idx = 0
+	}
+
+	runes := []rune(body)
+	runeIdx := utf8.RuneCountInString(body[:idx])
+	start := max(runeIdx-snippetRadius, 0)
+	end := min(runeIdx+snippetRadius, len(runes))
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
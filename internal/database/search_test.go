@@ -0,0 +1,131 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedSearchData(t *testing.T, db *DB) (userID int64, ids map[string]int64) {
+	t.Helper()
+	user := CreateTestUser(t, db)
+	channel := createTestChannel(t, db, user.ID)
+
+	msg, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana",
+		"Don't forget the dentist appointment on Thursday", "", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana",
+		"Lunch tomorrow?", "", time.Now())
+	require.NoError(t, err)
+
+	event, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:      user.ID,
+		ChannelID:   channel.ID,
+		CalendarID:  "primary",
+		Title:       "Dentist",
+		Description: "Cleaning",
+		Location:    "Herzl St 10",
+		StartTime:   time.Now().Add(48 * time.Hour),
+		ActionType:  EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	reminder, err := db.CreatePendingReminder(&Reminder{
+		UserID:       user.ID,
+		ChannelID:    channel.ID,
+		CalendarID:   "primary",
+		Title:        "Pay electricity bill",
+		ActionType:   ReminderActionCreate,
+		Priority:     ReminderPriorityNormal,
+		LLMReasoning: "test",
+	})
+	require.NoError(t, err)
+
+	// Another user's data must never match.
+	other := CreateTestUser(t, db)
+	otherChannel, err := db.CreateSourceChannel(other.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "other@s.whatsapp.net", "Other")
+	require.NoError(t, err)
+	_, err = db.StoreSourceMessage(source.SourceTypeWhatsApp, otherChannel.ID, "other@s.whatsapp.net", "Other",
+		"My dentist moved", "", time.Now())
+	require.NoError(t, err)
+
+	return user.ID, map[string]int64{"message": msg.ID, "event": event.ID, "reminder": reminder.ID}
+}
+
+func TestSearch(t *testing.T) {
+	db := NewTestDB(t)
+	userID, ids := seedSearchData(t, db)
+
+	t.Run("matches messages and events by prefix", func(t *testing.T) {
+		hits, err := db.Search(userID, "dent", nil, 10)
+		require.NoError(t, err)
+		require.Len(t, hits, 2)
+
+		byType := map[SearchResultType]SearchHit{}
+		for _, hit := range hits {
+			byType[hit.Type] = hit
+		}
+		assert.Equal(t, ids["message"], byType[SearchResultMessage].ID)
+		assert.Equal(t, "Dana", byType[SearchResultMessage].Title)
+		assert.Contains(t, byType[SearchResultMessage].Snippet, "dentist")
+		assert.NotNil(t, byType[SearchResultMessage].Timestamp)
+		assert.Equal(t, ids["event"], byType[SearchResultEvent].ID)
+		assert.Equal(t, string(EventStatusPending), byType[SearchResultEvent].Status)
+	})
+
+	t.Run("requires every term", func(t *testing.T) {
+		hits, err := db.Search(userID, "dentist thursday", nil, 10)
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, SearchResultMessage, hits[0].Type)
+	})
+
+	t.Run("filters by type", func(t *testing.T) {
+		hits, err := db.Search(userID, "bill", []SearchResultType{SearchResultReminder}, 10)
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, ids["reminder"], hits[0].ID)
+
+		hits, err = db.Search(userID, "dentist", []SearchResultType{SearchResultReminder}, 10)
+		require.NoError(t, err)
+		assert.Empty(t, hits)
+	})
+
+	t.Run("ignores query syntax characters", func(t *testing.T) {
+		hits, err := db.Search(userID, `"dentist*" ( ^`, nil, 10)
+		require.NoError(t, err)
+		assert.Len(t, hits, 2)
+
+		hits, err = db.Search(userID, "***", nil, 10)
+		require.NoError(t, err)
+		assert.Empty(t, hits)
+	})
+
+	t.Run("tracks updates and deletes", func(t *testing.T) {
+		require.NoError(t, db.UpdatePendingEvent(ids["event"], "Orthodontist", "", time.Now().Add(48*time.Hour), nil, ""))
+		hits, err := db.Search(userID, "orthodontist", nil, 10)
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, SearchResultEvent, hits[0].Type)
+
+		require.NoError(t, db.DeleteEvent(ids["event"]))
+		hits, err = db.Search(userID, "orthodontist", nil, 10)
+		require.NoError(t, err)
+		assert.Empty(t, hits)
+	})
+}
+
+func TestSearchLikeFallback(t *testing.T) {
+	db := NewTestDB(t)
+	userID, ids := seedSearchData(t, db)
+
+	hits, err := db.searchLike(userID, searchTerms("dentist"), []SearchResultType{SearchResultMessage, SearchResultEvent}, 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	// Most recent first: the event is in the future, the message in the past.
+	assert.Equal(t, ids["event"], hits[0].ID)
+	assert.Equal(t, ids["message"], hits[1].ID)
+}
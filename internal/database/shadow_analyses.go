@@ -0,0 +1,170 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ShadowAnalysis pairs the live analyzer's output for a message or email with the
+// shadow analyzer's output for the same input
+type ShadowAnalysis struct {
+	ID                int64     `json:"id"`
+	UserID            int64     `json:"user_id"`
+	ChannelID         int64     `json:"channel_id"`
+	SourceType        string    `json:"source_type"`
+	TriggerMessageID  *int64    `json:"trigger_message_id,omitempty"`
+	Intent            string    `json:"intent"`
+	Label             string    `json:"label"`
+	PrimaryAction     string    `json:"primary_action"`
+	PrimaryConfidence float64   `json:"primary_confidence"`
+	PrimaryOutput     string    `json:"primary_output"` // JSON
+	ShadowModel       string    `json:"shadow_model,omitempty"`
+	ShadowAction      string    `json:"shadow_action"`
+	ShadowConfidence  float64   `json:"shadow_confidence"`
+	ShadowOutput      string    `json:"shadow_output"` // JSON
+	ShadowError       string    `json:"shadow_error,omitempty"`
+	ShadowCostUSD     float64   `json:"shadow_cost_usd"`
+	Agreed            bool      `json:"agreed"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ShadowAnalysisFilter narrows ListShadowAnalyses. Zero values match everything.
+type ShadowAnalysisFilter struct {
+	Label            string
+	Intent           string
+	DisagreementOnly bool
+	Limit            int
+}
+
+// ShadowAnalysisSummary counts how often a shadow configuration agreed with the live one
+type ShadowAnalysisSummary struct {
+	Label         string  `json:"label"`
+	Intent        string  `json:"intent"`
+	Total         int     `json:"total"`
+	Agreed        int     `json:"agreed"`
+	Errors        int     `json:"errors"`
+	ShadowCostUSD float64 `json:"shadow_cost_usd"`
+}
+
+// CreateShadowAnalysis stores one live/shadow comparison
+func (d *DB) CreateShadowAnalysis(a ShadowAnalysis) error {
+	primaryOutput := a.PrimaryOutput
+	if primaryOutput == "" {
+		primaryOutput = "{}"
+	}
+	shadowOutput := a.ShadowOutput
+	if shadowOutput == "" {
+		shadowOutput = "{}"
+	}
+
+	_, err := d.Exec(`
+		INSERT INTO shadow_analyses (
+			user_id, channel_id, source_type, trigger_message_id, intent, label,
+			primary_action, primary_confidence, primary_output_json,
+			shadow_model, shadow_action, shadow_confidence, shadow_output_json, shadow_error,
+			shadow_cost_usd, agreed
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		a.UserID, a.ChannelID, a.SourceType, a.TriggerMessageID, a.Intent, a.Label,
+		a.PrimaryAction, a.PrimaryConfidence, primaryOutput,
+		a.ShadowModel, a.ShadowAction, a.ShadowConfidence, shadowOutput, a.ShadowError,
+		a.ShadowCostUSD, a.Agreed,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create shadow analysis: %w", err)
+	}
+	return nil
+}
+
+// ListShadowAnalyses returns comparisons across all users, newest first
+func (d *DB) ListShadowAnalyses(filter ShadowAnalysisFilter) ([]ShadowAnalysis, error) {
+	var where []string
+	var args []any
+	if filter.Label != "" {
+		where = append(where, "label = ?")
+		args = append(args, filter.Label)
+	}
+	if filter.Intent != "" {
+		where = append(where, "intent = ?")
+		args = append(args, filter.Intent)
+	}
+	if filter.DisagreementOnly {
+		where = append(where, "agreed = 0")
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, user_id, channel_id, source_type, trigger_message_id, intent, label,
+			COALESCE(primary_action, ''), primary_confidence, primary_output_json,
+			COALESCE(shadow_model, ''), COALESCE(shadow_action, ''), shadow_confidence, shadow_output_json,
+			COALESCE(shadow_error, ''), shadow_cost_usd, agreed, created_at
+		FROM shadow_analyses`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shadow analyses: %w", err)
+	}
+	defer rows.Close()
+
+	var analyses []ShadowAnalysis
+	for rows.Next() {
+		var a ShadowAnalysis
+		var triggerID sql.NullInt64
+		if err := rows.Scan(
+			&a.ID, &a.UserID, &a.ChannelID, &a.SourceType, &triggerID, &a.Intent, &a.Label,
+			&a.PrimaryAction, &a.PrimaryConfidence, &a.PrimaryOutput,
+			&a.ShadowModel, &a.ShadowAction, &a.ShadowConfidence, &a.ShadowOutput,
+			&a.ShadowError, &a.ShadowCostUSD, &a.Agreed, &a.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan shadow analysis: %w", err)
+		}
+		if triggerID.Valid {
+			a.TriggerMessageID = &triggerID.Int64
+		}
+		analyses = append(analyses, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shadow analyses: %w", err)
+	}
+	return analyses, nil
+}
+
+// SummarizeShadowAnalyses returns agreement counts per shadow label and intent
+func (d *DB) SummarizeShadowAnalyses() ([]ShadowAnalysisSummary, error) {
+	rows, err := d.Query(`
+		SELECT label, intent, COUNT(*),
+			COALESCE(SUM(CASE WHEN agreed THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN COALESCE(shadow_error, '') != '' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(shadow_cost_usd), 0)
+		FROM shadow_analyses
+		GROUP BY label, intent
+		ORDER BY label, intent
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize shadow analyses: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []ShadowAnalysisSummary{}
+	for rows.Next() {
+		var s ShadowAnalysisSummary
+		if err := rows.Scan(&s.Label, &s.Intent, &s.Total, &s.Agreed, &s.Errors, &s.ShadowCostUSD); err != nil {
+			return nil, fmt.Errorf("failed to scan shadow analysis summary: %w", err)
+		}
+		summaries = append(summaries, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shadow analysis summary: %w", err)
+	}
+	return summaries, nil
+}
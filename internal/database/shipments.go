@@ -0,0 +1,138 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ShipmentStatus is the last delivery status a carrier reported for a package
+type ShipmentStatus string
+
+const (
+	ShipmentStatusShipped        ShipmentStatus = "shipped"
+	ShipmentStatusOutForDelivery ShipmentStatus = "out_for_delivery"
+	ShipmentStatusDelayed        ShipmentStatus = "delayed"
+	ShipmentStatusDelivered      ShipmentStatus = "delivered"
+	ShipmentStatusCancelled      ShipmentStatus = "cancelled"
+)
+
+// Shipment is a package tracked by the delivery agent, keyed by tracking number.
+// The estimated delivery window is kept as reported (ISO 8601).
+type Shipment struct {
+	ID             int64          `json:"id"`
+	UserID         int64          `json:"user_id"`
+	TrackingNumber string         `json:"tracking_number"`
+	Carrier        string         `json:"carrier,omitempty"`
+	Merchant       string         `json:"merchant,omitempty"`
+	Description    string         `json:"description,omitempty"`
+	Status         ShipmentStatus `json:"status"`
+	ETAStart       string         `json:"eta_start,omitempty"`
+	ETAEnd         string         `json:"eta_end,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+const shipmentColumns = `id, user_id, tracking_number, COALESCE(carrier, ''), COALESCE(merchant, ''),
+	COALESCE(description, ''), status, COALESCE(eta_start, ''), COALESCE(eta_end, ''), created_at, updated_at`
+
+func scanShipment(scanner interface{ Scan(...any) error }) (*Shipment, error) {
+	var shipment Shipment
+	if err := scanner.Scan(
+		&shipment.ID, &shipment.UserID, &shipment.TrackingNumber, &shipment.Carrier, &shipment.Merchant,
+		&shipment.Description, &shipment.Status, &shipment.ETAStart, &shipment.ETAEnd,
+		&shipment.CreatedAt, &shipment.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+// UpsertShipment records a carrier update for a tracking number. Fields the update
+// leaves empty keep their previous values.
+func (d *DB) UpsertShipment(shipment *Shipment) (*Shipment, error) {
+	_, err := d.Exec(`
+		INSERT INTO shipments (user_id, tracking_number, carrier, merchant, description, status, eta_start, eta_end)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, tracking_number) DO UPDATE SET
+			carrier = COALESCE(NULLIF(excluded.carrier, ''), shipments.carrier),
+			merchant = COALESCE(NULLIF(excluded.merchant, ''), shipments.merchant),
+			description = COALESCE(NULLIF(excluded.description, ''), shipments.description),
+			status = excluded.status,
+			eta_start = COALESCE(NULLIF(excluded.eta_start, ''), shipments.eta_start),
+			eta_end = COALESCE(NULLIF(excluded.eta_end, ''), shipments.eta_end),
+			updated_at = CURRENT_TIMESTAMP
+	`, shipment.UserID, shipment.TrackingNumber, shipment.Carrier, shipment.Merchant,
+		shipment.Description, shipment.Status, shipment.ETAStart, shipment.ETAEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save shipment: %w", err)
+	}
+	return d.GetShipment(shipment.UserID, shipment.TrackingNumber)
+}
+
+// GetShipment retrieves a user's shipment by tracking number. Returns nil if not tracked.
+func (d *DB) GetShipment(userID int64, trackingNumber string) (*Shipment, error) {
+	row := d.QueryRow(`SELECT `+shipmentColumns+`
+		FROM shipments
+		WHERE user_id = ? AND tracking_number = ?
+	`, userID, trackingNumber)
+	shipment, err := scanShipment(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+	return shipment, nil
+}
+
+// ListShipments returns a user's shipments, most recently updated first
+func (d *DB) ListShipments(userID int64) ([]Shipment, error) {
+	rows, err := d.Query(`SELECT `+shipmentColumns+`
+		FROM shipments
+		WHERE user_id = ?
+		ORDER BY updated_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shipments: %w", err)
+	}
+	defer rows.Close()
+
+	shipments := []Shipment{}
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shipment: %w", err)
+		}
+		shipments = append(shipments, *shipment)
+	}
+	return shipments, rows.Err()
+}
+
+// SetEventTrackingNumber tags a calendar event with the shipment it announces
+func (d *DB) SetEventTrackingNumber(eventID int64, trackingNumber string) error {
+	_, err := d.Exec(`UPDATE calendar_events SET tracking_number = ? WHERE id = ?`, trackingNumber, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to set event tracking number: %w", err)
+	}
+	return nil
+}
+
+// GetTrackedEventID returns the newest live (pending, confirmed or synced) calendar event
+// announcing a shipment, or 0 if there is none
+func (d *DB) GetTrackedEventID(userID int64, trackingNumber string) (int64, error) {
+	var id int64
+	err := d.QueryRow(`
+		SELECT id FROM calendar_events
+		WHERE user_id = ? AND tracking_number = ? AND status IN (?, ?, ?)
+		ORDER BY id DESC
+		LIMIT 1
+	`, userID, trackingNumber, EventStatusPending, EventStatusConfirmed, EventStatusSynced).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tracked event: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertShipmentKeepsKnownFields(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	created, err := db.UpsertShipment(&Shipment{
+		UserID:         user.ID,
+		TrackingNumber: "1Z999AA10123456784",
+		Carrier:        "UPS",
+		Merchant:       "Amazon",
+		Description:    "Running shoes",
+		Status:         ShipmentStatusShipped,
+		ETAStart:       "2030-05-12T09:00:00",
+		ETAEnd:         "2030-05-12T18:00:00",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "UPS", created.Carrier)
+
+	// A carrier update that only moves the window keeps the order details
+	updated, err := db.UpsertShipment(&Shipment{
+		UserID:         user.ID,
+		TrackingNumber: "1Z999AA10123456784",
+		Status:         ShipmentStatusDelayed,
+		ETAStart:       "2030-05-14T10:00:00",
+		ETAEnd:         "2030-05-14T14:00:00",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, ShipmentStatusDelayed, updated.Status)
+	assert.Equal(t, "2030-05-14T10:00:00", updated.ETAStart)
+	assert.Equal(t, "Amazon", updated.Merchant)
+	assert.Equal(t, "Running shoes", updated.Description)
+
+	other := CreateTestUser(t, db)
+	missing, err := db.GetShipment(other.ID, "1Z999AA10123456784")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	shipments, err := db.ListShipments(user.ID)
+	require.NoError(t, err)
+	require.Len(t, shipments, 1)
+}
+
+func TestGetTrackedEventID(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeGmail,
+		source.ChannelTypeSender,
+		"email:sender:ship@ups.com",
+		"Email: UPS",
+	)
+	require.NoError(t, err)
+
+	id, err := db.GetTrackedEventID(user.ID, "1Z999AA10123456784")
+	require.NoError(t, err)
+	assert.Zero(t, id)
+
+	event, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Package arriving: Running shoes",
+		StartTime:  time.Date(2030, 5, 12, 9, 0, 0, 0, time.UTC),
+		ActionType: EventActionCreate,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.SetEventTrackingNumber(event.ID, "1Z999AA10123456784"))
+
+	id, err = db.GetTrackedEventID(user.ID, "1Z999AA10123456784")
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, id)
+
+	// Rejected entries no longer announce the package
+	require.NoError(t, db.UpdateEventStatus(event.ID, EventStatusRejected))
+	id, err = db.GetTrackedEventID(user.ID, "1Z999AA10123456784")
+	require.NoError(t, err)
+	assert.Zero(t, id)
+}
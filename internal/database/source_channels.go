@@ -17,8 +17,10 @@ type SourceChannel struct {
 	Identifier        string             `json:"identifier"`
 	Name              string             `json:"name"`
 	Enabled           bool               `json:"enabled"`
-	TotalMessageCount int                `json:"total_message_count"` // Actual message count from HistorySync
-	LastMessageAt     *time.Time         `json:"last_message_at"`     // Timestamp of most recent message
+	AnalysisMode      AnalysisMode       `json:"analysis_mode"`
+	MutedUntil        *time.Time         `json:"muted_until,omitempty"` // analysis is paused until then
+	TotalMessageCount int                `json:"total_message_count"`   // Actual message count from HistorySync
+	LastMessageAt     *time.Time         `json:"last_message_at"`       // Timestamp of most recent message
 	CreatedAt         time.Time          `json:"created_at"`
 }
 
@@ -32,6 +34,40 @@ const (
 	googleCalendarImportChannelName                   = "Google Calendar"
 )
 
+// AnalysisMode controls which analyzers run on a channel's messages
+type AnalysisMode string
+
+const (
+	AnalysisModeAll       AnalysisMode = "all"       // every analyzer
+	AnalysisModeEvents    AnalysisMode = "events"    // only analyzers that detect events
+	AnalysisModeReminders AnalysisMode = "reminders" // only analyzers that detect reminders
+	AnalysisModeNone      AnalysisMode = "none"      // messages are kept as history but not analyzed
+)
+
+// IsValidAnalysisMode reports whether mode is a known analysis mode
+func IsValidAnalysisMode(mode AnalysisMode) bool {
+	switch mode {
+	case AnalysisModeAll, AnalysisModeEvents, AnalysisModeReminders, AnalysisModeNone:
+		return true
+	}
+	return false
+}
+
+// AllowsEvents reports whether event detection runs in this mode
+func (m AnalysisMode) AllowsEvents() bool {
+	return m != AnalysisModeReminders && m != AnalysisModeNone
+}
+
+// AllowsReminders reports whether reminder detection runs in this mode
+func (m AnalysisMode) AllowsReminders() bool {
+	return m != AnalysisModeEvents && m != AnalysisModeNone
+}
+
+// IsMuted reports whether analysis of the channel is paused at now
+func (sc *SourceChannel) IsMuted(now time.Time) bool {
+	return sc.MutedUntil != nil && sc.MutedUntil.After(now)
+}
+
 // ToSourceChannel converts a SourceChannel to source.Channel
 func (sc *SourceChannel) ToSourceChannel() source.Channel {
 	return source.Channel{
@@ -61,7 +97,13 @@ func (d *DB) CreateSourceChannel(userID int64, sourceType source.SourceType, cha
 		return nil, fmt.Errorf("failed to get last insert id: %w", err)
 	}
 
-	return d.GetSourceChannelByID(userID, id)
+	channel, err := d.GetSourceChannelByID(userID, id)
+	if err != nil || channel == nil {
+		return channel, err
+	}
+	// Best effort: SyncContactsFromHistory links any channel missed here
+	_ = d.linkChannelContact(channel)
+	return channel, nil
 }
 
 // EnsureManualReminderChannel returns a stable per-user channel for manually created reminders.
@@ -127,7 +169,7 @@ func (d *DB) EnsureGoogleCalendarImportChannel(userID int64) (*SourceChannel, er
 // GetSourceChannelByID retrieves a channel by ID for a specific user
 func (d *DB) GetSourceChannelByID(userID int64, id int64) (*SourceChannel, error) {
 	row := d.QueryRow(
-		`SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled, created_at
+		`SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled, analysis_mode, muted_until, created_at
 		 FROM channels WHERE id = ? AND user_id = ?`,
 		id, userID,
 	)
@@ -137,7 +179,7 @@ func (d *DB) GetSourceChannelByID(userID int64, id int64) (*SourceChannel, error
 // GetSourceChannelByIdentifier retrieves a channel by source type and identifier for a specific user
 func (d *DB) GetSourceChannelByIdentifier(userID int64, sourceType source.SourceType, identifier string) (*SourceChannel, error) {
 	row := d.QueryRow(
-		`SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled, created_at
+		`SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled, analysis_mode, muted_until, created_at
 		 FROM channels WHERE user_id = ? AND source_type = ? AND identifier = ?`,
 		userID, sourceType, identifier,
 	)
@@ -147,7 +189,7 @@ func (d *DB) GetSourceChannelByIdentifier(userID int64, sourceType source.Source
 // ListSourceChannels lists all channels for a given source type for a specific user
 func (d *DB) ListSourceChannels(userID int64, sourceType source.SourceType) ([]*SourceChannel, error) {
 	rows, err := d.Query(
-		`SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled, created_at
+		`SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled, analysis_mode, muted_until, created_at
 		 FROM channels WHERE user_id = ? AND source_type = ? ORDER BY created_at DESC`,
 		userID, sourceType,
 	)
@@ -187,6 +229,91 @@ func (d *DB) UpdateSourceChannel(userID int64, id int64, name string, enabled bo
 	return nil
 }
 
+// UpdateChannelAnalysisMode sets which analyzers run on a channel's messages
+func (d *DB) UpdateChannelAnalysisMode(userID int64, id int64, mode AnalysisMode) error {
+	result, err := d.Exec(
+		`UPDATE channels SET analysis_mode = ? WHERE id = ? AND user_id = ?`,
+		mode, id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update channel analysis mode: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update channel analysis mode: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("channel not found")
+	}
+	return nil
+}
+
+// SetChannelMutedUntil pauses analysis of a channel until the given time; nil unmutes it
+func (d *DB) SetChannelMutedUntil(userID int64, id int64, until *time.Time) error {
+	var value any
+	if until != nil {
+		value = until.UTC()
+	}
+	result, err := d.Exec(
+		`UPDATE channels SET muted_until = ? WHERE id = ? AND user_id = ?`,
+		value, id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update channel mute: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update channel mute: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("channel not found")
+	}
+	return nil
+}
+
+// UnmuteExpiredChannels clears mutes that ended at or before now and returns the
+// channels that were unmuted
+func (d *DB) UnmuteExpiredChannels(now time.Time) ([]*SourceChannel, error) {
+	rows, err := d.Query(
+		`SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled, analysis_mode, muted_until, created_at
+		 FROM channels WHERE muted_until IS NOT NULL AND muted_until <= ?`,
+		now.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired channel mutes: %w", err)
+	}
+	var channels []*SourceChannel
+	for rows.Next() {
+		channel, err := scanSourceChannelRows(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired channel mutes: %w", err)
+	}
+
+	unmuted := channels[:0]
+	for _, channel := range channels {
+		// The guard keeps a mute extended since the SELECT in place
+		result, err := d.Exec(
+			`UPDATE channels SET muted_until = NULL WHERE id = ? AND muted_until IS NOT NULL AND muted_until <= ?`,
+			channel.ID, now.UTC(),
+		)
+		if err != nil {
+			return unmuted, fmt.Errorf("failed to unmute channel %d: %w", channel.ID, err)
+		}
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			channel.MutedUntil = nil
+			unmuted = append(unmuted, channel)
+		}
+	}
+	return unmuted, nil
+}
+
 // DeleteSourceChannel deletes a channel by ID for a specific user
 func (d *DB) DeleteSourceChannel(userID int64, id int64) error {
 	result, err := d.Exec(`DELETE FROM channels WHERE id = ? AND user_id = ?`, id, userID)
@@ -270,11 +397,31 @@ func (d *DB) UpdateChannelStats(id int64, totalMessageCount int, lastMessageAt *
 	return nil
 }
 
+// GetChannelLanguage returns the language code last detected in a channel's messages,
+// or "" when none has been detected yet
+func (d *DB) GetChannelLanguage(id int64) (string, error) {
+	var language sql.NullString
+	err := d.QueryRow(`SELECT language FROM channels WHERE id = ?`, id).Scan(&language)
+	if err != nil {
+		return "", fmt.Errorf("failed to get channel language: %w", err)
+	}
+	return language.String, nil
+}
+
+// SetChannelLanguage records the language code detected in a channel's messages
+func (d *DB) SetChannelLanguage(id int64, language string) error {
+	_, err := d.Exec(`UPDATE channels SET language = ? WHERE id = ?`, language, id)
+	if err != nil {
+		return fmt.Errorf("failed to update channel language: %w", err)
+	}
+	return nil
+}
+
 // GetTopChannelsByMessageCount returns top channels by actual message count for a specific user
 // This uses total_message_count which is populated during HistorySync with accurate counts
 func (d *DB) GetTopChannelsByMessageCount(userID int64, sourceType source.SourceType, limit int) ([]*SourceChannel, error) {
 	rows, err := d.Query(`
-		SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled,
+		SELECT id, user_id, COALESCE(source_type, 'whatsapp'), type, identifier, name, enabled, analysis_mode, muted_until,
 		       total_message_count, last_message_at, created_at
 		FROM channels
 		WHERE user_id = ? AND source_type = ? AND total_message_count > 0
@@ -289,14 +436,17 @@ func (d *DB) GetTopChannelsByMessageCount(userID int64, sourceType source.Source
 	var channels []*SourceChannel
 	for rows.Next() {
 		var c SourceChannel
-		var lastMsgAt sql.NullTime
+		var lastMsgAt, mutedUntil sql.NullTime
 		if err := rows.Scan(&c.ID, &c.UserID, &c.SourceType, &c.Type, &c.Identifier, &c.Name,
-			&c.Enabled, &c.TotalMessageCount, &lastMsgAt, &c.CreatedAt); err != nil {
+			&c.Enabled, &c.AnalysisMode, &mutedUntil, &c.TotalMessageCount, &lastMsgAt, &c.CreatedAt); err != nil {
 			continue
 		}
 		if lastMsgAt.Valid {
 			c.LastMessageAt = &lastMsgAt.Time
 		}
+		if mutedUntil.Valid {
+			c.MutedUntil = &mutedUntil.Time
+		}
 		channels = append(channels, &c)
 	}
 
@@ -305,21 +455,29 @@ func (d *DB) GetTopChannelsByMessageCount(userID int64, sourceType source.Source
 
 func scanSourceChannel(row *sql.Row) (*SourceChannel, error) {
 	var c SourceChannel
-	err := row.Scan(&c.ID, &c.UserID, &c.SourceType, &c.Type, &c.Identifier, &c.Name, &c.Enabled, &c.CreatedAt)
+	var mutedUntil sql.NullTime
+	err := row.Scan(&c.ID, &c.UserID, &c.SourceType, &c.Type, &c.Identifier, &c.Name, &c.Enabled, &c.AnalysisMode, &mutedUntil, &c.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan source channel: %w", err)
 	}
+	if mutedUntil.Valid {
+		c.MutedUntil = &mutedUntil.Time
+	}
 	return &c, nil
 }
 
 func scanSourceChannelRows(rows *sql.Rows) (*SourceChannel, error) {
 	var c SourceChannel
-	err := rows.Scan(&c.ID, &c.UserID, &c.SourceType, &c.Type, &c.Identifier, &c.Name, &c.Enabled, &c.CreatedAt)
+	var mutedUntil sql.NullTime
+	err := rows.Scan(&c.ID, &c.UserID, &c.SourceType, &c.Type, &c.Identifier, &c.Name, &c.Enabled, &c.AnalysisMode, &mutedUntil, &c.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan source channel: %w", err)
 	}
+	if mutedUntil.Valid {
+		c.MutedUntil = &mutedUntil.Time
+	}
 	return &c, nil
 }
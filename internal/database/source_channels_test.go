@@ -326,6 +326,25 @@ func TestListSourceChannels(t *testing.T) {
 	})
 }
 
+func TestUpdateChannelAnalysisMode(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "mode@s.whatsapp.net", "Mode")
+	require.NoError(t, err)
+	assert.Equal(t, AnalysisModeAll, channel.AnalysisMode)
+
+	require.NoError(t, db.UpdateChannelAnalysisMode(user.ID, channel.ID, AnalysisModeEvents))
+	updated, err := db.GetSourceChannelByID(user.ID, channel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, AnalysisModeEvents, updated.AnalysisMode)
+	assert.True(t, updated.AnalysisMode.AllowsEvents())
+	assert.False(t, updated.AnalysisMode.AllowsReminders())
+
+	assert.Error(t, db.UpdateChannelAnalysisMode(other.ID, channel.ID, AnalysisModeNone), "only the owner can change the mode")
+}
+
 func TestUpdateSourceChannel(t *testing.T) {
 	db := NewTestDB(t)
 	user := CreateTestUser(t, db)
@@ -41,34 +41,18 @@ func (d *DB) StoreSourceMessage(sourceType source.SourceType, channelID int64, s
 	//
 	// This protects prompt/context quality (no duplicated messages) and keeps the
 	// "View Context" UI clean.
-	var existing SourceMessage
-	err := d.QueryRow(`
-		SELECT id, COALESCE(source_type, 'whatsapp'), channel_id, sender_jid, sender_name, message_text, COALESCE(subject, ''), timestamp, created_at
-		FROM message_history
-		WHERE channel_id = ?
-			AND COALESCE(source_type, 'whatsapp') = ?
-			AND sender_jid = ?
-			AND message_text = ?
-			AND timestamp = ?
-			AND COALESCE(subject, '') = COALESCE(?, '')
-		ORDER BY id DESC
-		LIMIT 1
-	`, channelID, sourceType, senderID, text, timestamp, subject).Scan(
-		&existing.ID,
-		&existing.SourceType,
-		&existing.ChannelID,
-		&existing.SenderID,
-		&existing.SenderName,
-		&existing.MessageText,
-		&existing.Subject,
-		&existing.Timestamp,
-		&existing.CreatedAt,
-	)
-	if err == nil {
-		return &existing, nil
+	// The text is compared after decryption since encrypted values differ on every write.
+	existing, err := d.findDuplicateSourceMessage(sourceType, channelID, senderID, text, subject, timestamp)
+	if err != nil {
+		return nil, err
 	}
-	if err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to check existing message: %w", err)
+	if existing != nil {
+		return existing, nil
+	}
+
+	storedText, err := d.encryptMessageText(text)
+	if err != nil {
+		return nil, err
 	}
 
 	// user_id is derived from the channel's user_id via subquery
@@ -77,7 +61,7 @@ func (d *DB) StoreSourceMessage(sourceType source.SourceType, channelID int64, s
 		SELECT user_id, ?, ?, ?, ?, ?, ?, ?
 		FROM channels
 		WHERE id = ?
-	`, sourceType, channelID, senderID, senderName, text, subject, timestamp, channelID)
+	`, sourceType, channelID, senderID, senderName, storedText, subject, timestamp, channelID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to store source message: %w", err)
 	}
@@ -109,6 +93,54 @@ func (d *DB) StoreSourceMessage(sourceType source.SourceType, channelID int64, s
 	}, nil
 }
 
+// findDuplicateSourceMessage returns the newest stored message with the same source,
+// channel, sender, subject, timestamp and text, or nil if there is none
+func (d *DB) findDuplicateSourceMessage(sourceType source.SourceType, channelID int64, senderID, text, subject string, timestamp time.Time) (*SourceMessage, error) {
+	rows, err := d.Query(`
+		SELECT id, COALESCE(source_type, 'whatsapp'), channel_id, sender_jid, sender_name, message_text, COALESCE(subject, ''), timestamp, created_at
+		FROM message_history
+		WHERE channel_id = ?
+			AND COALESCE(source_type, 'whatsapp') = ?
+			AND sender_jid = ?
+			AND timestamp = ?
+			AND COALESCE(subject, '') = COALESCE(?, '')
+		ORDER BY id DESC
+	`, channelID, sourceType, senderID, timestamp, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing message: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := d.scanSourceMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing message: %w", err)
+		}
+		if m.MessageText == text {
+			return m, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating existing messages: %w", err)
+	}
+	return nil, nil
+}
+
+// scanSourceMessage scans a message_history row selected with the standard column list
+// and decrypts its text
+func (d *DB) scanSourceMessage(scanner interface{ Scan(...any) error }) (*SourceMessage, error) {
+	var m SourceMessage
+	if err := scanner.Scan(&m.ID, &m.SourceType, &m.ChannelID, &m.SenderID, &m.SenderName, &m.MessageText, &m.Subject, &m.Timestamp, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	text, err := d.decryptMessageText(m.MessageText)
+	if err != nil {
+		return nil, err
+	}
+	m.MessageText = text
+	return &m, nil
+}
+
 // GetSourceMessageHistory retrieves the last N messages for a source type and channel, ordered chronologically
 func (d *DB) GetSourceMessageHistory(userID int64, sourceType source.SourceType, channelID int64, limit int) ([]SourceMessage, error) {
 	fetchLimit := limit * 5
@@ -134,8 +166,8 @@ func (d *DB) GetSourceMessageHistory(userID int64, sourceType source.SourceType,
 	var messages []SourceMessage
 	seen := make(map[string]struct{}, limit)
 	for rows.Next() {
-		var m SourceMessage
-		if err := rows.Scan(&m.ID, &m.SourceType, &m.ChannelID, &m.SenderID, &m.SenderName, &m.MessageText, &m.Subject, &m.Timestamp, &m.CreatedAt); err != nil {
+		m, err := d.scanSourceMessage(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan source message: %w", err)
 		}
 
@@ -151,7 +183,7 @@ func (d *DB) GetSourceMessageHistory(userID int64, sourceType source.SourceType,
 			continue
 		}
 		seen[key] = struct{}{}
-		messages = append(messages, m)
+		messages = append(messages, *m)
 		if len(messages) >= limit {
 			break
 		}
@@ -188,19 +220,18 @@ func (d *DB) PruneSourceMessages(userID int64, sourceType source.SourceType, cha
 
 // GetSourceMessageByID retrieves a specific message by ID with source type validation
 func (d *DB) GetSourceMessageByID(userID int64, id int64) (*SourceMessage, error) {
-	var m SourceMessage
-	err := d.QueryRow(`
+	m, err := d.scanSourceMessage(d.QueryRow(`
 		SELECT id, COALESCE(source_type, 'whatsapp'), channel_id, sender_jid, sender_name, message_text, COALESCE(subject, ''), timestamp, created_at
 		FROM message_history
 		WHERE user_id = ? AND id = ?
-	`, userID, id).Scan(&m.ID, &m.SourceType, &m.ChannelID, &m.SenderID, &m.SenderName, &m.MessageText, &m.Subject, &m.Timestamp, &m.CreatedAt)
+	`, userID, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get source message: %w", err)
 	}
-	return &m, nil
+	return m, nil
 }
 
 // CountSourceMessages returns the number of messages stored for a source type and channel
@@ -232,11 +263,11 @@ func (d *DB) GetAllSourceMessages(userID int64, sourceType source.SourceType, li
 
 	var messages []SourceMessage
 	for rows.Next() {
-		var m SourceMessage
-		if err := rows.Scan(&m.ID, &m.SourceType, &m.ChannelID, &m.SenderID, &m.SenderName, &m.MessageText, &m.Subject, &m.Timestamp, &m.CreatedAt); err != nil {
+		m, err := d.scanSourceMessage(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan source message: %w", err)
 		}
-		messages = append(messages, m)
+		messages = append(messages, *m)
 	}
 
 	return messages, rows.Err()
@@ -258,8 +289,8 @@ func (d *DB) GetSourceMessagesSince(userID int64, sourceType source.SourceType,
 	var messages []SourceMessage
 	seen := make(map[string]struct{})
 	for rows.Next() {
-		var m SourceMessage
-		if err := rows.Scan(&m.ID, &m.SourceType, &m.ChannelID, &m.SenderID, &m.SenderName, &m.MessageText, &m.Subject, &m.Timestamp, &m.CreatedAt); err != nil {
+		m, err := d.scanSourceMessage(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan source message: %w", err)
 		}
 		key := fmt.Sprintf("%s\x00%d\x00%s\x00%s\x00%s\x00%d",
@@ -274,7 +305,7 @@ func (d *DB) GetSourceMessagesSince(userID int64, sourceType source.SourceType,
 			continue
 		}
 		seen[key] = struct{}{}
-		messages = append(messages, m)
+		messages = append(messages, *m)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -569,3 +569,77 @@ func TestGetAllSourceMessages(t *testing.T) {
 		}
 	})
 }
+
+func TestDeleteMessagesBefore(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUserWithEmail(t, db, "other@example.com")
+	channel := createTestChannelForMessages(t, db, user.ID)
+	otherChannel := createTestChannelForMessages(t, db, other.ID)
+
+	now := time.Now().UTC()
+	store := func(channelID int64, text string, age time.Duration) *SourceMessage {
+		msg, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channelID, "sender@s.whatsapp.net", "Sender", text, "", now.Add(-age))
+		require.NoError(t, err)
+		return msg
+	}
+
+	store(channel.ID, "expired", 100*24*time.Hour)
+	eventSource := store(channel.ID, "expired but has an event", 100*24*time.Hour)
+	store(channel.ID, "recent", time.Hour)
+	store(otherChannel.ID, "other user's old message", 100*24*time.Hour)
+
+	_, err := db.CreatePendingEvent(&CalendarEvent{
+		UserID:        user.ID,
+		ChannelID:     channel.ID,
+		CalendarID:    "primary",
+		Title:         "Dinner",
+		StartTime:     now,
+		ActionType:    EventActionCreate,
+		OriginalMsgID: &eventSource.ID,
+	})
+	require.NoError(t, err)
+
+	deleted, err := db.DeleteMessagesBefore(user.ID, now.AddDate(0, 0, -90), 100)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	messages, err := db.GetSourceMessageHistory(user.ID, source.SourceTypeWhatsApp, channel.ID, 10)
+	require.NoError(t, err)
+	var texts []string
+	for _, m := range messages {
+		texts = append(texts, m.MessageText)
+	}
+	assert.ElementsMatch(t, []string{"expired but has an event", "recent"}, texts)
+
+	count, err := db.CountSourceMessages(other.ID, source.SourceTypeWhatsApp, otherChannel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestMessageRetentionDays(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+
+	days, err := db.GetMessageRetentionDays(user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, days)
+
+	thirty := 30
+	require.NoError(t, db.SetMessageRetentionDays(user.ID, &thirty))
+	days, err = db.GetMessageRetentionDays(user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, days)
+	assert.Equal(t, 30, *days)
+
+	all, err := db.ListMessageRetention()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, user.ID, all[0].UserID)
+	assert.Equal(t, 30, *all[0].Days)
+
+	require.NoError(t, db.SetMessageRetentionDays(user.ID, nil))
+	days, err = db.GetMessageRetentionDays(user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, days)
+}
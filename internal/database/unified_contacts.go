@@ -0,0 +1,454 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+var (
+	ErrUnifiedContactNotFound  = errors.New("contact not found")
+	ErrContactIdentityNotFound = errors.New("contact identity not found")
+)
+
+// ContactIdentity is one platform identity of a contact: a WhatsApp phone number, a
+// Telegram user ID or an email address
+type ContactIdentity struct {
+	ID         int64             `json:"id"`
+	ContactID  int64             `json:"contact_id"`
+	SourceType source.SourceType `json:"source_type"`
+	Identifier string            `json:"identifier"`
+	Name       string            `json:"name"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// UnifiedContact is one person across WhatsApp, Telegram and Gmail. AnalysisMode, when
+// set, applies to the chat channels of every identity.
+type UnifiedContact struct {
+	ID           int64             `json:"id"`
+	UserID       int64             `json:"user_id"`
+	Name         string            `json:"name"`
+	AnalysisMode AnalysisMode      `json:"analysis_mode,omitempty"`
+	Identities   []ContactIdentity `json:"identities"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// NormalizeContactIdentifier returns the form identities are stored in: bare phone
+// numbers for WhatsApp JIDs and lowercased addresses for email
+func NormalizeContactIdentifier(sourceType source.SourceType, identifier string) string {
+	identifier = strings.TrimSpace(identifier)
+	switch sourceType {
+	case source.SourceTypeWhatsApp:
+		identifier, _ = strings.CutSuffix(identifier, "@s.whatsapp.net")
+	case source.SourceTypeGmail:
+		identifier = strings.ToLower(identifier)
+	}
+	return identifier
+}
+
+// applyContactAnalysisModeQuery sets the analysis mode of the user's chat channels that
+// belong to a contact's identities. WhatsApp channels may use bare numbers or full JIDs.
+const applyContactAnalysisModeQuery = `
+	UPDATE channels SET analysis_mode = ?
+	WHERE user_id = ? AND type = 'sender' AND EXISTS (
+		SELECT 1 FROM contact_identities ci
+		WHERE ci.contact_id = ? AND ci.source_type = channels.source_type
+		  AND (ci.identifier = channels.identifier OR ci.identifier || '@s.whatsapp.net' = channels.identifier)
+	)
+`
+
+// UpsertContactIdentity records a platform identity and returns the ID of the contact it
+// belongs to. A new identity joins the user's contact with the same name if that
+// contact has no identity on the same platform yet; otherwise it gets a new contact.
+// Known identities keep their contact, so manual merges and splits stick.
+func (d *DB) UpsertContactIdentity(userID int64, sourceType source.SourceType, identifier, name string) (int64, error) {
+	identifier = NormalizeContactIdentifier(sourceType, identifier)
+	name = strings.TrimSpace(name)
+	if identifier == "" {
+		return 0, fmt.Errorf("identifier is required")
+	}
+	// Sources fall back to the identifier when they have no display name
+	if strings.EqualFold(NormalizeContactIdentifier(sourceType, name), identifier) {
+		name = ""
+	}
+
+	var contactID int64
+	err := d.QueryRow(`
+		SELECT contact_id FROM contact_identities
+		WHERE user_id = ? AND source_type = ? AND identifier = ?
+	`, userID, sourceType, identifier).Scan(&contactID)
+	if err == nil {
+		if name != "" {
+			_, err = d.Exec(`
+				UPDATE contact_identities SET name = ?
+				WHERE user_id = ? AND source_type = ? AND identifier = ? AND name = ''
+			`, name, userID, sourceType, identifier)
+			if err != nil {
+				return 0, fmt.Errorf("failed to update contact identity: %w", err)
+			}
+		}
+		return contactID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get contact identity: %w", err)
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin contact transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var mode sql.NullString
+	if name != "" {
+		err = tx.QueryRow(`
+			SELECT c.id, c.analysis_mode FROM contacts c
+			WHERE c.user_id = ? AND LOWER(c.name) = LOWER(?)
+			  AND NOT EXISTS (SELECT 1 FROM contact_identities ci WHERE ci.contact_id = c.id AND ci.source_type = ?)
+			ORDER BY c.id LIMIT 1
+		`, userID, name, sourceType).Scan(&contactID, &mode)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, fmt.Errorf("failed to find contact by name: %w", err)
+		}
+	}
+	if contactID == 0 {
+		displayName := name
+		if displayName == "" {
+			displayName = identifier
+		}
+		result, err := tx.Exec(`INSERT INTO contacts (user_id, name) VALUES (?, ?)`, userID, displayName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create contact: %w", err)
+		}
+		if contactID, err = result.LastInsertId(); err != nil {
+			return 0, fmt.Errorf("failed to get contact id: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO contact_identities (user_id, contact_id, source_type, identifier, name)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, contactID, sourceType, identifier, name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create contact identity: %w", err)
+	}
+	if mode.Valid && mode.String != "" {
+		if _, err := tx.Exec(applyContactAnalysisModeQuery, mode.String, userID, contactID); err != nil {
+			return 0, fmt.Errorf("failed to apply contact analysis mode: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit contact identity: %w", err)
+	}
+	return contactID, nil
+}
+
+// linkChannelContact records the identity of a new WhatsApp/Telegram contact channel
+// and gives the channel its contact's analysis mode, if one is set
+func (d *DB) linkChannelContact(channel *SourceChannel) error {
+	if channel.Type != source.ChannelTypeSender ||
+		(channel.SourceType != source.SourceTypeWhatsApp && channel.SourceType != source.SourceTypeTelegram) {
+		return nil
+	}
+	contactID, err := d.UpsertContactIdentity(channel.UserID, channel.SourceType, channel.Identifier, channel.Name)
+	if err != nil {
+		return err
+	}
+
+	var mode sql.NullString
+	if err := d.QueryRow(`SELECT analysis_mode FROM contacts WHERE id = ?`, contactID).Scan(&mode); err != nil {
+		return fmt.Errorf("failed to get contact: %w", err)
+	}
+	if !mode.Valid || mode.String == "" {
+		return nil
+	}
+	if err := d.UpdateChannelAnalysisMode(channel.UserID, channel.ID, AnalysisMode(mode.String)); err != nil {
+		return err
+	}
+	channel.AnalysisMode = AnalysisMode(mode.String)
+	return nil
+}
+
+// SyncContactsFromHistory records an identity for every WhatsApp/Telegram contact
+// channel, tracked Gmail sender and cached Gmail contact of the user
+func (d *DB) SyncContactsFromHistory(userID int64) error {
+	type identity struct {
+		sourceType source.SourceType
+		identifier string
+		name       string
+	}
+	var identities []identity
+
+	collect := func(query string, args ...any) error {
+		rows, err := d.Query(query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to list contact identities: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id identity
+			var name sql.NullString
+			if err := rows.Scan(&id.sourceType, &id.identifier, &name); err != nil {
+				return fmt.Errorf("failed to scan contact identity: %w", err)
+			}
+			id.name = name.String
+			identities = append(identities, id)
+		}
+		return rows.Err()
+	}
+
+	if err := collect(`
+		SELECT source_type, identifier, name FROM channels
+		WHERE user_id = ? AND type = ? AND source_type IN (?, ?)
+		ORDER BY id
+	`, userID, source.ChannelTypeSender, source.SourceTypeWhatsApp, source.SourceTypeTelegram); err != nil {
+		return err
+	}
+	if err := collect(`
+		SELECT ?, identifier, name FROM email_sources
+		WHERE user_id = ? AND type = ?
+		ORDER BY id
+	`, source.SourceTypeGmail, userID, EmailSourceTypeSender); err != nil {
+		return err
+	}
+	if err := collect(`
+		SELECT ?, email, name FROM google_contacts
+		WHERE user_id = ?
+		ORDER BY email_count DESC
+	`, source.SourceTypeGmail, userID); err != nil {
+		return err
+	}
+
+	for _, id := range identities {
+		if _, err := d.UpsertContactIdentity(userID, id.sourceType, id.identifier, id.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUnifiedContact returns one of the user's contacts with its identities
+func (d *DB) GetUnifiedContact(userID, id int64) (*UnifiedContact, error) {
+	contacts, err := d.queryUnifiedContacts(`WHERE c.user_id = ? AND c.id = ?`, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(contacts) == 0 {
+		return nil, ErrUnifiedContactNotFound
+	}
+	return contacts[0], nil
+}
+
+// ListUnifiedContacts returns the user's contacts whose name or any identity contains
+// query (all contacts when query is empty), ordered by name
+func (d *DB) ListUnifiedContacts(userID int64, query string) ([]*UnifiedContact, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return d.queryUnifiedContacts(`WHERE c.user_id = ?`, userID)
+	}
+	pattern := "%" + query + "%"
+	return d.queryUnifiedContacts(`
+		WHERE c.user_id = ? AND (LOWER(c.name) LIKE LOWER(?) OR EXISTS (
+			SELECT 1 FROM contact_identities ci
+			WHERE ci.contact_id = c.id AND (LOWER(ci.name) LIKE LOWER(?) OR LOWER(ci.identifier) LIKE LOWER(?))
+		))
+	`, userID, pattern, pattern, pattern)
+}
+
+// queryUnifiedContacts loads the contacts matching where (over contacts c) and their
+// identities
+func (d *DB) queryUnifiedContacts(where string, args ...any) ([]*UnifiedContact, error) {
+	rows, err := d.Query(`
+		SELECT c.id, c.user_id, c.name, COALESCE(c.analysis_mode, ''), c.created_at, c.updated_at
+		FROM contacts c `+where+`
+		ORDER BY LOWER(c.name), c.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	defer rows.Close()
+
+	contacts := []*UnifiedContact{}
+	byID := make(map[int64]*UnifiedContact)
+	for rows.Next() {
+		c := &UnifiedContact{Identities: []ContactIdentity{}}
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.AnalysisMode, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan contact: %w", err)
+		}
+		contacts = append(contacts, c)
+		byID[c.ID] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating contacts: %w", err)
+	}
+	if len(contacts) == 0 {
+		return contacts, nil
+	}
+
+	identityRows, err := d.Query(`
+		SELECT ci.id, ci.contact_id, ci.source_type, ci.identifier, ci.name, ci.created_at
+		FROM contact_identities ci
+		WHERE ci.contact_id IN (SELECT c.id FROM contacts c `+where+`)
+		ORDER BY ci.source_type, ci.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contact identities: %w", err)
+	}
+	defer identityRows.Close()
+	for identityRows.Next() {
+		var identity ContactIdentity
+		if err := identityRows.Scan(&identity.ID, &identity.ContactID, &identity.SourceType, &identity.Identifier, &identity.Name, &identity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan contact identity: %w", err)
+		}
+		if c := byID[identity.ContactID]; c != nil {
+			c.Identities = append(c.Identities, identity)
+		}
+	}
+	if err := identityRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating contact identities: %w", err)
+	}
+	return contacts, nil
+}
+
+// UpdateUnifiedContact renames a contact (unless name is empty) and sets its analysis
+// mode, applying the mode to its identities' chat channels. An empty mode leaves each
+// channel's own mode in place.
+func (d *DB) UpdateUnifiedContact(userID, id int64, name string, mode AnalysisMode) (*UnifiedContact, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin contact transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE contacts
+		SET name = COALESCE(NULLIF(?, ''), name), analysis_mode = NULLIF(?, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ?
+	`, strings.TrimSpace(name), mode, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update contact: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to update contact: %w", err)
+	} else if rowsAffected == 0 {
+		return nil, ErrUnifiedContactNotFound
+	}
+	if mode != "" {
+		if _, err := tx.Exec(applyContactAnalysisModeQuery, mode, userID, id); err != nil {
+			return nil, fmt.Errorf("failed to apply contact analysis mode: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit contact update: %w", err)
+	}
+	return d.GetUnifiedContact(userID, id)
+}
+
+// MergeUnifiedContacts moves every identity of contact fromID into contact intoID and
+// deletes fromID. The merged contact keeps intoID's name, and its analysis mode (if
+// set) is applied to the channels that joined it.
+func (d *DB) MergeUnifiedContacts(userID, intoID, fromID int64) (*UnifiedContact, error) {
+	if intoID == fromID {
+		return nil, fmt.Errorf("cannot merge a contact into itself")
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin contact transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var mode sql.NullString
+	err = tx.QueryRow(`SELECT analysis_mode FROM contacts WHERE id = ? AND user_id = ?`, intoID, userID).Scan(&mode)
+	if err == sql.ErrNoRows {
+		return nil, ErrUnifiedContactNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact: %w", err)
+	}
+
+	// Identities move before the contact is deleted so the foreign key cascade doesn't
+	// take them with it
+	if _, err := tx.Exec(`UPDATE contact_identities SET contact_id = ? WHERE contact_id = ? AND user_id = ?`, intoID, fromID, userID); err != nil {
+		return nil, fmt.Errorf("failed to move contact identities: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM contacts WHERE id = ? AND user_id = ?`, fromID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete merged contact: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to delete merged contact: %w", err)
+	} else if rowsAffected == 0 {
+		return nil, ErrUnifiedContactNotFound
+	}
+	if _, err := tx.Exec(`UPDATE contacts SET updated_at = CURRENT_TIMESTAMP WHERE id = ?`, intoID); err != nil {
+		return nil, fmt.Errorf("failed to update contact: %w", err)
+	}
+	if mode.Valid && mode.String != "" {
+		if _, err := tx.Exec(applyContactAnalysisModeQuery, mode.String, userID, intoID); err != nil {
+			return nil, fmt.Errorf("failed to apply contact analysis mode: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit contact merge: %w", err)
+	}
+	return d.GetUnifiedContact(userID, intoID)
+}
+
+// SplitContactIdentity moves an identity out of its contact into a new contact of its
+// own, named after the identity. Splitting a contact's only identity returns it as is.
+func (d *DB) SplitContactIdentity(userID, identityID int64) (*UnifiedContact, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin contact transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var contactID int64
+	var identifier, name string
+	var siblings int
+	err = tx.QueryRow(`
+		SELECT ci.contact_id, ci.identifier, ci.name,
+			(SELECT COUNT(*) FROM contact_identities o WHERE o.contact_id = ci.contact_id) - 1
+		FROM contact_identities ci
+		WHERE ci.id = ? AND ci.user_id = ?
+	`, identityID, userID).Scan(&contactID, &identifier, &name, &siblings)
+	if err == sql.ErrNoRows {
+		return nil, ErrContactIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact identity: %w", err)
+	}
+	if siblings == 0 {
+		tx.Rollback()
+		return d.GetUnifiedContact(userID, contactID)
+	}
+
+	if name == "" {
+		name = identifier
+	}
+	result, err := tx.Exec(`INSERT INTO contacts (user_id, name) VALUES (?, ?)`, userID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create contact: %w", err)
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact id: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE contact_identities SET contact_id = ? WHERE id = ?`, newID, identityID); err != nil {
+		return nil, fmt.Errorf("failed to move contact identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit contact split: %w", err)
+	}
+	return d.GetUnifiedContact(userID, newID)
+}
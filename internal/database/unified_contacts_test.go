@@ -0,0 +1,123 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedContacts_MergeAcrossSources(t *testing.T) {
+	db := NewTestDB(t)
+	user := CreateTestUser(t, db)
+	other := CreateTestUser(t, db)
+
+	// Creating contact channels records their identities
+	whatsapp, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "972501234567@s.whatsapp.net", "Yossi Cohen")
+	require.NoError(t, err)
+	telegram, err := db.CreateSourceChannel(user.ID, source.SourceTypeTelegram, source.ChannelTypeSender, "5551234", "yossi cohen")
+	require.NoError(t, err)
+	_, err = db.CreateSourceChannel(other.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "972501234567@s.whatsapp.net", "Yossi Cohen")
+	require.NoError(t, err)
+
+	require.NoError(t, db.ReplaceTopContacts(user.ID, []TopContact{
+		{Email: "Yossi.Cohen@example.com", Name: "Yossi Cohen", EmailCount: 12},
+		{Email: "billing@example.com", EmailCount: 40},
+	}))
+	require.NoError(t, db.SyncContactsFromHistory(user.ID))
+	// Syncing again doesn't duplicate anything
+	require.NoError(t, db.SyncContactsFromHistory(user.ID))
+
+	contacts, err := db.ListUnifiedContacts(user.ID, "")
+	require.NoError(t, err)
+	require.Len(t, contacts, 2)
+
+	assert.Equal(t, "billing@example.com", contacts[0].Name)
+	require.Len(t, contacts[0].Identities, 1)
+
+	yossi := contacts[1]
+	assert.Equal(t, "Yossi Cohen", yossi.Name)
+	require.Len(t, yossi.Identities, 3)
+	assert.Equal(t, source.SourceTypeGmail, yossi.Identities[0].SourceType)
+	assert.Equal(t, "yossi.cohen@example.com", yossi.Identities[0].Identifier)
+	assert.Equal(t, source.SourceTypeTelegram, yossi.Identities[1].SourceType)
+	assert.Equal(t, source.SourceTypeWhatsApp, yossi.Identities[2].SourceType)
+	assert.Equal(t, "972501234567", yossi.Identities[2].Identifier)
+
+	found, err := db.ListUnifiedContacts(user.ID, "5551234")
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, yossi.ID, found[0].ID)
+
+	otherContacts, err := db.ListUnifiedContacts(other.ID, "")
+	require.NoError(t, err)
+	require.Len(t, otherContacts, 1)
+	assert.NotEqual(t, yossi.ID, otherContacts[0].ID)
+
+	t.Run("analysis mode applies to every chat channel", func(t *testing.T) {
+		updated, err := db.UpdateUnifiedContact(user.ID, yossi.ID, "", AnalysisModeReminders)
+		require.NoError(t, err)
+		assert.Equal(t, "Yossi Cohen", updated.Name)
+		assert.Equal(t, AnalysisModeReminders, updated.AnalysisMode)
+
+		for _, id := range []int64{whatsapp.ID, telegram.ID} {
+			channel, err := db.GetSourceChannelByID(user.ID, id)
+			require.NoError(t, err)
+			assert.Equal(t, AnalysisModeReminders, channel.AnalysisMode)
+		}
+		otherChannels, err := db.ListSourceChannels(other.ID, source.SourceTypeWhatsApp)
+		require.NoError(t, err)
+		require.Len(t, otherChannels, 1)
+		assert.Equal(t, AnalysisModeAll, otherChannels[0].AnalysisMode)
+	})
+
+	t.Run("split and merge", func(t *testing.T) {
+		var telegramIdentity ContactIdentity
+		for _, identity := range yossi.Identities {
+			if identity.SourceType == source.SourceTypeTelegram {
+				telegramIdentity = identity
+			}
+		}
+
+		split, err := db.SplitContactIdentity(user.ID, telegramIdentity.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, yossi.ID, split.ID)
+		assert.Equal(t, "yossi cohen", split.Name)
+		require.Len(t, split.Identities, 1)
+
+		// A re-sync keeps the split
+		require.NoError(t, db.SyncContactsFromHistory(user.ID))
+		remaining, err := db.GetUnifiedContact(user.ID, yossi.ID)
+		require.NoError(t, err)
+		assert.Len(t, remaining.Identities, 2)
+
+		// Splitting a contact's only identity is a no-op
+		same, err := db.SplitContactIdentity(user.ID, telegramIdentity.ID)
+		require.NoError(t, err)
+		assert.Equal(t, split.ID, same.ID)
+
+		_, err = db.MergeUnifiedContacts(other.ID, yossi.ID, split.ID)
+		assert.ErrorIs(t, err, ErrUnifiedContactNotFound)
+
+		merged, err := db.MergeUnifiedContacts(user.ID, yossi.ID, split.ID)
+		require.NoError(t, err)
+		assert.Len(t, merged.Identities, 3)
+		_, err = db.GetUnifiedContact(user.ID, split.ID)
+		assert.ErrorIs(t, err, ErrUnifiedContactNotFound)
+	})
+
+	t.Run("new channels get their contact's analysis mode", func(t *testing.T) {
+		_, err := db.UpsertContactIdentity(user.ID, source.SourceTypeWhatsApp, "15550001111", "Grandma")
+		require.NoError(t, err)
+		grandma, err := db.ListUnifiedContacts(user.ID, "grandma")
+		require.NoError(t, err)
+		require.Len(t, grandma, 1)
+		_, err = db.UpdateUnifiedContact(user.ID, grandma[0].ID, "", AnalysisModeNone)
+		require.NoError(t, err)
+
+		channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "15550001111@s.whatsapp.net", "Grandma")
+		require.NoError(t, err)
+		assert.Equal(t, AnalysisModeNone, channel.AnalysisMode)
+	})
+}
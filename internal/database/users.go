@@ -53,6 +53,15 @@ func (d *DB) GetAllUsers() ([]User, error) {
 	return users, rows.Err()
 }
 
+// GetUserEmail returns a user's email address
+func (d *DB) GetUserEmail(userID int64) (string, error) {
+	var email string
+	if err := d.QueryRow(`SELECT email FROM users WHERE id = ?`, userID).Scan(&email); err != nil {
+		return "", fmt.Errorf("failed to get user email: %w", err)
+	}
+	return email, nil
+}
+
 // GetUserTimezone returns a user's preferred timezone.
 func (d *DB) GetUserTimezone(userID int64) (string, error) {
 	var tz sql.NullString
@@ -78,3 +87,31 @@ func (d *DB) UpdateUserTimezone(userID int64, timezone string) error {
 	}
 	return nil
 }
+
+// GetUserLocale returns the user's preferred language code, or "" when agents should
+// answer in the language of each conversation
+func (d *DB) GetUserLocale(userID int64) (string, error) {
+	var locale sql.NullString
+	err := d.QueryRow(`SELECT locale FROM users WHERE id = ?`, userID).Scan(&locale)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user locale: %w", err)
+	}
+	return locale.String, nil
+}
+
+// SetUserLocale sets the user's preferred language code; "" clears it
+func (d *DB) SetUserLocale(userID int64, locale string) error {
+	var value sql.NullString
+	if locale != "" {
+		value = sql.NullString{String: locale, Valid: true}
+	}
+	_, err := d.Exec(`
+		UPDATE users
+		SET locale = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, value, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user locale: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,381 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WebhookDeliveryStatus represents where a delivery is in its retry lifecycle
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// Webhook is a user-registered endpoint that receives signed event payloads.
+// The secret is only returned to the user when the webhook is created.
+type Webhook struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	EventTypes []string  `json:"event_types"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is a single event queued for a webhook
+type WebhookDelivery struct {
+	ID             int64                    `json:"id"`
+	WebhookID      int64                    `json:"webhook_id"`
+	EventType      string                   `json:"event_type"`
+	Payload        string                   `json:"payload"`
+	Status         WebhookDeliveryStatus    `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	NextAttemptAt  *time.Time               `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int                     `json:"last_status_code,omitempty"`
+	LastError      string                   `json:"last_error,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	DeliveredAt    *time.Time               `json:"delivered_at,omitempty"`
+	AttemptLog     []WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+
+	// Populated by GetDueWebhookDeliveries for the delivery worker
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookDeliveryAttempt records the outcome of one HTTP delivery attempt
+type WebhookDeliveryAttempt struct {
+	ID         int64     `json:"id"`
+	DeliveryID int64     `json:"delivery_id"`
+	Attempt    int       `json:"attempt"`
+	StatusCode *int      `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func formatEventTypes(eventTypes []string) string {
+	return strings.Join(eventTypes, ",")
+}
+
+func parseEventTypes(raw string) []string {
+	eventTypes := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			eventTypes = append(eventTypes, part)
+		}
+	}
+	return eventTypes
+}
+
+func scanWebhook(scanner interface{ Scan(...any) error }) (*Webhook, error) {
+	var webhook Webhook
+	var eventTypes string
+	if err := scanner.Scan(
+		&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &eventTypes,
+		&webhook.Enabled, &webhook.CreatedAt, &webhook.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	webhook.EventTypes = parseEventTypes(eventTypes)
+	return &webhook, nil
+}
+
+// CreateWebhook registers a new webhook endpoint for a user
+func (d *DB) CreateWebhook(userID int64, url, secret string, eventTypes []string) (*Webhook, error) {
+	result, err := d.Exec(`
+		INSERT INTO webhooks (user_id, url, secret, event_types)
+		VALUES (?, ?, ?, ?)
+	`, userID, url, secret, formatEventTypes(eventTypes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook id: %w", err)
+	}
+	return d.GetWebhookByID(id)
+}
+
+// GetWebhookByID retrieves a webhook by ID
+func (d *DB) GetWebhookByID(id int64) (*Webhook, error) {
+	row := d.QueryRow(`
+		SELECT id, user_id, url, secret, event_types, enabled, created_at, updated_at
+		FROM webhooks
+		WHERE id = ?
+	`, id)
+	webhook, err := scanWebhook(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks returns a user's webhooks, oldest first
+func (d *DB) ListWebhooks(userID int64) ([]Webhook, error) {
+	rows, err := d.Query(`
+		SELECT id, user_id, url, secret, event_types, enabled, created_at, updated_at
+		FROM webhooks
+		WHERE user_id = ?
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// UpdateWebhook replaces a webhook's URL, event types, and enabled flag
+func (d *DB) UpdateWebhook(id int64, url string, eventTypes []string, enabled bool) error {
+	_, err := d.Exec(`
+		UPDATE webhooks
+		SET url = ?, event_types = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, url, formatEventTypes(eventTypes), enabled, id)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteWebhook removes a webhook along with its queued deliveries and attempt log
+func (d *DB) DeleteWebhook(id int64) error {
+	_, err := d.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// EnqueueWebhookDeliveries queues payload for every enabled webhook of the user
+// subscribed to eventType. Returns the number of deliveries queued.
+func (d *DB) EnqueueWebhookDeliveries(userID int64, eventType, payload string, now time.Time) (int, error) {
+	result, err := d.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload, status, next_attempt_at)
+		SELECT id, ?, ?, ?, ?
+		FROM webhooks
+		WHERE user_id = ?
+		  AND enabled = 1
+		  AND (',' || event_types || ',') LIKE ('%,' || ? || ',%')
+	`, eventType, payload, WebhookDeliveryPending, now.UTC(), userID, eventType)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count enqueued webhook deliveries: %w", err)
+	}
+	return int(count), nil
+}
+
+const webhookDeliveryColumns = `d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+// scanWebhookDelivery scans webhookDeliveryColumns into delivery, followed by any extra destinations
+func scanWebhookDelivery(scanner interface{ Scan(...any) error }, delivery *WebhookDelivery, extra ...any) error {
+	var nextAttemptAt, deliveredAt sql.NullTime
+	var lastStatusCode sql.NullInt64
+	var lastError sql.NullString
+	dest := append([]any{
+		&delivery.ID, &delivery.WebhookID, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+		&nextAttemptAt, &lastStatusCode, &lastError, &delivery.CreatedAt, &deliveredAt,
+	}, extra...)
+	if err := scanner.Scan(dest...); err != nil {
+		return err
+	}
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastStatusCode.Valid {
+		code := int(lastStatusCode.Int64)
+		delivery.LastStatusCode = &code
+	}
+	delivery.LastError = lastError.String
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return nil
+}
+
+// GetDueWebhookDeliveries returns pending deliveries whose next attempt is due,
+// joined with their webhook's URL and secret. Deliveries for disabled webhooks are skipped.
+func (d *DB) GetDueWebhookDeliveries(now time.Time, limit int) ([]WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := d.Query(`
+		SELECT `+webhookDeliveryColumns+`, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = ?
+		  AND w.enabled = 1
+		  AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at ASC, d.id ASC
+		LIMIT ?
+	`, WebhookDeliveryPending, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var delivery WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery, &delivery.URL, &delivery.Secret); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// RecordWebhookDeliveryAttempt logs an attempt and moves the delivery to status.
+// nextAttemptAt schedules the retry for pending deliveries and is ignored otherwise.
+func (d *DB) RecordWebhookDeliveryAttempt(deliveryID int64, attempt WebhookDeliveryAttempt, status WebhookDeliveryStatus, nextAttemptAt *time.Time) error {
+	var attemptErr any
+	if attempt.Error != "" {
+		attemptErr = attempt.Error
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin webhook attempt transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?)
+	`, deliveryID, attempt.Attempt, attempt.StatusCode, attemptErr, attempt.DurationMs)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	var next any
+	if status == WebhookDeliveryPending && nextAttemptAt != nil {
+		next = nextAttemptAt.UTC()
+	}
+	var deliveredAt any
+	if status == WebhookDeliverySucceeded {
+		deliveredAt = time.Now().UTC()
+	}
+
+	_, err = tx.Exec(`
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_status_code = ?, last_error = ?, delivered_at = ?
+		WHERE id = ?
+	`, status, attempt.Attempt, next, attempt.StatusCode, attemptErr, deliveredAt, deliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns a webhook's most recent deliveries with their attempt log
+func (d *DB) ListWebhookDeliveries(webhookID int64, limit int) ([]WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := d.Query(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = ?
+		ORDER BY d.created_at DESC, d.id DESC
+		LIMIT ?
+	`, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		var delivery WebhookDelivery
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		index[delivery.ID] = len(deliveries)
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+	rows.Close()
+
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	attemptRows, err := d.Query(`
+		SELECT a.id, a.delivery_id, a.attempt, a.status_code, a.error, a.duration_ms, a.created_at
+		FROM webhook_delivery_attempts a
+		JOIN webhook_deliveries d ON d.id = a.delivery_id
+		WHERE d.webhook_id = ?
+		ORDER BY a.delivery_id, a.attempt
+	`, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook delivery attempts: %w", err)
+	}
+	defer attemptRows.Close()
+
+	for attemptRows.Next() {
+		var attempt WebhookDeliveryAttempt
+		var statusCode sql.NullInt64
+		var attemptErr sql.NullString
+		if err := attemptRows.Scan(
+			&attempt.ID, &attempt.DeliveryID, &attempt.Attempt, &statusCode, &attemptErr, &attempt.DurationMs, &attempt.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery attempt: %w", err)
+		}
+		if statusCode.Valid {
+			code := int(statusCode.Int64)
+			attempt.StatusCode = &code
+		}
+		attempt.Error = attemptErr.String
+
+		if i, ok := index[attempt.DeliveryID]; ok {
+			deliveries[i].AttemptLog = append(deliveries[i].AttemptLog, attempt)
+		}
+	}
+
+	if err := attemptRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook delivery attempts: %w", err)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	t.Run("create, list, update, delete", func(t *testing.T) {
+		db := NewTestDB(t)
+		user := CreateTestUser(t, db)
+
+		hook, err := db.CreateWebhook(user.ID, "https://example.com/hook", "whsec_test", []string{"event.created", "reminder.due"})
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, hook.UserID)
+		assert.Equal(t, "whsec_test", hook.Secret)
+		assert.Equal(t, []string{"event.created", "reminder.due"}, hook.EventTypes)
+		assert.True(t, hook.Enabled)
+
+		hooks, err := db.ListWebhooks(user.ID)
+		require.NoError(t, err)
+		require.Len(t, hooks, 1)
+
+		require.NoError(t, db.UpdateWebhook(hook.ID, "https://example.com/v2", []string{"event.confirmed"}, false))
+		updated, err := db.GetWebhookByID(hook.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/v2", updated.URL)
+		assert.Equal(t, []string{"event.confirmed"}, updated.EventTypes)
+		assert.False(t, updated.Enabled)
+
+		require.NoError(t, db.DeleteWebhook(hook.ID))
+		hooks, err = db.ListWebhooks(user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, hooks)
+	})
+
+	t.Run("enqueue only matches enabled subscribed webhooks of the user", func(t *testing.T) {
+		db := NewTestDB(t)
+		user := CreateTestUser(t, db)
+		other := CreateTestUser(t, db)
+		now := time.Now()
+
+		subscribed, err := db.CreateWebhook(user.ID, "https://example.com/a", "s", []string{"event.created", "event.confirmed"})
+		require.NoError(t, err)
+		_, err = db.CreateWebhook(user.ID, "https://example.com/b", "s", []string{"reminder.due"})
+		require.NoError(t, err)
+		disabled, err := db.CreateWebhook(user.ID, "https://example.com/c", "s", []string{"event.created"})
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateWebhook(disabled.ID, disabled.URL, disabled.EventTypes, false))
+		_, err = db.CreateWebhook(other.ID, "https://example.com/d", "s", []string{"event.created"})
+		require.NoError(t, err)
+
+		count, err := db.EnqueueWebhookDeliveries(user.ID, "event.created", `{"type":"event.created"}`, now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		// "event" must not match "event.created" as a substring
+		count, err = db.EnqueueWebhookDeliveries(user.ID, "event", `{}`, now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		due, err := db.GetDueWebhookDeliveries(now.Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, subscribed.ID, due[0].WebhookID)
+		assert.Equal(t, "https://example.com/a", due[0].URL)
+		assert.Equal(t, "s", due[0].Secret)
+		assert.Equal(t, WebhookDeliveryPending, due[0].Status)
+	})
+
+	t.Run("attempts reschedule and complete deliveries", func(t *testing.T) {
+		db := NewTestDB(t)
+		user := CreateTestUser(t, db)
+		now := time.Now()
+
+		hook, err := db.CreateWebhook(user.ID, "https://example.com/a", "s", []string{"reminder.due"})
+		require.NoError(t, err)
+		_, err = db.EnqueueWebhookDeliveries(user.ID, "reminder.due", `{}`, now)
+		require.NoError(t, err)
+
+		due, err := db.GetDueWebhookDeliveries(now.Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		deliveryID := due[0].ID
+
+		code := 500
+		retryAt := now.Add(time.Minute)
+		require.NoError(t, db.RecordWebhookDeliveryAttempt(deliveryID, WebhookDeliveryAttempt{
+			Attempt: 1, StatusCode: &code, Error: "endpoint returned status 500", DurationMs: 12,
+		}, WebhookDeliveryPending, &retryAt))
+
+		due, err = db.GetDueWebhookDeliveries(now.Add(time.Second), 10)
+		require.NoError(t, err)
+		assert.Empty(t, due, "retry is not due yet")
+
+		due, err = db.GetDueWebhookDeliveries(retryAt.Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, 1, due[0].Attempts)
+
+		okCode := 204
+		require.NoError(t, db.RecordWebhookDeliveryAttempt(deliveryID, WebhookDeliveryAttempt{
+			Attempt: 2, StatusCode: &okCode,
+		}, WebhookDeliverySucceeded, nil))
+
+		deliveries, err := db.ListWebhookDeliveries(hook.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		delivery := deliveries[0]
+		assert.Equal(t, WebhookDeliverySucceeded, delivery.Status)
+		assert.Equal(t, 2, delivery.Attempts)
+		assert.Nil(t, delivery.NextAttemptAt)
+		assert.NotNil(t, delivery.DeliveredAt)
+		require.Len(t, delivery.AttemptLog, 2)
+		assert.Equal(t, "endpoint returned status 500", delivery.AttemptLog[0].Error)
+		assert.Equal(t, 500, *delivery.AttemptLog[0].StatusCode)
+		assert.Equal(t, 204, *delivery.AttemptLog[1].StatusCode)
+	})
+}
@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountDeletion(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().WithUserID(ts.TestUser.ID).WhatsApp().MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).WithUserID(ts.TestUser.ID).Pending().MustBuild(ts.DB)
+
+	deleteAccount := func(t *testing.T, body any) *http.Response {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req, err := http.NewRequest("DELETE", ts.BaseURL()+"/api/account", bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	requestToken := func(t *testing.T) string {
+		resp, err := http.Post(ts.BaseURL()+"/api/account/deletion-token", "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var result struct {
+			ConfirmationToken string `json:"confirmation_token"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.NotEmpty(t, result.ConfirmationToken)
+		return result.ConfirmationToken
+	}
+
+	t.Run("requires a confirmation token", func(t *testing.T) {
+		resp := deleteAccount(t, map[string]string{})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = deleteAccount(t, map[string]string{"confirmation_token": "guess"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("only the latest token is accepted", func(t *testing.T) {
+		stale := requestToken(t)
+		requestToken(t)
+
+		resp := deleteAccount(t, map[string]string{"confirmation_token": stale})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		email, err := ts.DB.GetUserEmail(ts.TestUser.ID)
+		require.NoError(t, err)
+		assert.Equal(t, ts.TestUser.Email, email)
+	})
+
+	t.Run("deletes the account and its data", func(t *testing.T) {
+		token := requestToken(t)
+
+		resp := deleteAccount(t, map[string]string{"confirmation_token": token})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var count int
+		require.NoError(t, ts.DB.QueryRow(`SELECT COUNT(*) FROM users WHERE id = ?`, ts.TestUser.ID).Scan(&count))
+		assert.Zero(t, count)
+		require.NoError(t, ts.DB.QueryRow(`SELECT COUNT(*) FROM calendar_events WHERE user_id = ?`, ts.TestUser.ID).Scan(&count))
+		assert.Zero(t, count)
+		require.NoError(t, ts.DB.QueryRow(`SELECT COUNT(*) FROM channels WHERE user_id = ?`, ts.TestUser.ID).Scan(&count))
+		assert.Zero(t, count)
+	})
+}
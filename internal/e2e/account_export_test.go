@@ -0,0 +1,123 @@
+package e2e
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountDataExport(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().WithUserID(ts.TestUser.ID).WhatsApp().WithName("Family").MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).WithUserID(ts.TestUser.ID).WithTitle("Grandma's birthday").Pending().MustBuild(ts.DB)
+
+	getExport := func(t *testing.T, id int64) database.DataExport {
+		resp, err := http.Get(fmt.Sprintf("%s/api/account/exports/%d", ts.BaseURL(), id))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var export database.DataExport
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&export))
+		return export
+	}
+
+	resp, err := http.Post(ts.BaseURL()+"/api/account/export", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var started database.DataExport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+
+	t.Run("export completes in the background", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return getExport(t, started.ID).Status == database.DataExportCompleted
+		}, 5*time.Second, 20*time.Millisecond)
+
+		export := getExport(t, started.ID)
+		assert.Equal(t, 100, export.Progress)
+		assert.NotNil(t, export.ExpiresAt)
+	})
+
+	t.Run("download contains the user's data", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/account/exports/%d/download", ts.BaseURL(), started.ID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment")
+
+		archive, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		require.NoError(t, err)
+
+		var events []map[string]any
+		for _, f := range zr.File {
+			if f.Name != "events.json" {
+				continue
+			}
+			rc, err := f.Open()
+			require.NoError(t, err)
+			require.NoError(t, json.NewDecoder(rc).Decode(&events))
+			rc.Close()
+		}
+		require.Len(t, events, 1)
+		assert.Equal(t, "Grandma's birthday", events[0]["title"])
+	})
+
+	t.Run("list exports", func(t *testing.T) {
+		resp, err := http.Get(ts.BaseURL() + "/api/account/exports")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var exports []database.DataExport
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&exports))
+		require.Len(t, exports, 1)
+		assert.Equal(t, started.ID, exports[0].ID)
+	})
+
+	t.Run("other users' exports are not visible", func(t *testing.T) {
+		other := database.CreateTestUser(t, ts.DB)
+		otherExport, err := ts.DB.CreateDataExport(other.ID)
+		require.NoError(t, err)
+
+		resp, err := http.Get(fmt.Sprintf("%s/api/account/exports/%d", ts.BaseURL(), otherExport.ID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, err = http.Get(fmt.Sprintf("%s/api/account/exports/%d/download", ts.BaseURL(), otherExport.ID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("only one export runs at a time", func(t *testing.T) {
+		pending, err := ts.DB.CreateDataExport(ts.TestUser.ID)
+		require.NoError(t, err)
+
+		resp, err := http.Post(ts.BaseURL()+"/api/account/export", "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp, err = http.Get(fmt.Sprintf("%s/api/account/exports/%d/download", ts.BaseURL(), pending.ID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+}
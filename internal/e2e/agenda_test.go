@@ -0,0 +1,131 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/server"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgenda(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WithName("Dana").
+		MustBuild(ts.DB)
+
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return day.Add(time.Duration(hours) * time.Hour) }
+
+	standup := testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Standup").
+		WithStartTime(at(10)).
+		WithEndTime(at(10).Add(15 * time.Minute)).
+		Confirmed().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Dinner").
+		WithStartTime(at(19)).
+		WithEndTime(at(21)).
+		Synced().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Awaiting review").
+		WithStartTime(at(12)).
+		Pending().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Tomorrow").
+		WithStartTime(at(34)).
+		WithEndTime(at(35)).
+		Confirmed().
+		MustBuild(ts.DB)
+	rent := testutil.NewReminderBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Pay rent").
+		WithDueDate(at(9)).
+		HighPriority().
+		Confirmed().
+		MustBuild(ts.DB)
+	testutil.NewReminderBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Pending reminder").
+		WithDueDate(at(11)).
+		Pending().
+		MustBuild(ts.DB)
+
+	getAgenda := func(t *testing.T, query string) (int, server.AgendaResponse) {
+		t.Helper()
+		resp, err := http.Get(ts.BaseURL() + "/api/agenda" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var agenda server.AgendaResponse
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&agenda))
+		}
+		return resp.StatusCode, agenda
+	}
+
+	t.Run("merges events and reminders in time order", func(t *testing.T) {
+		status, agenda := getAgenda(t, "?from=2026-03-10&to=2026-03-10")
+		require.Equal(t, http.StatusOK, status)
+
+		assert.Equal(t, "2026-03-10T00:00:00Z", agenda.From)
+		assert.Equal(t, "2026-03-11T00:00:00Z", agenda.To, "a date for to includes that day")
+		assert.Equal(t, "not_connected", agenda.GoogleCalendar)
+
+		require.Len(t, agenda.Items, 3, "pending items and the next day are left out")
+		titles := []string{agenda.Items[0].Title, agenda.Items[1].Title, agenda.Items[2].Title}
+		assert.Equal(t, []string{"Pay rent", "Standup", "Dinner"}, titles)
+
+		reminder := agenda.Items[0]
+		assert.Equal(t, server.AgendaItemReminder, reminder.Type)
+		require.NotNil(t, reminder.ReminderID)
+		assert.Equal(t, rent.ID, *reminder.ReminderID)
+		assert.Equal(t, "high", reminder.Priority)
+		assert.Empty(t, reminder.EndTime)
+
+		event := agenda.Items[1]
+		assert.Equal(t, server.AgendaItemEvent, event.Type)
+		assert.Equal(t, "alfred", event.Source)
+		require.NotNil(t, event.EventID)
+		assert.Equal(t, standup.ID, *event.EventID)
+		assert.Equal(t, "2026-03-10T10:15:00Z", event.EndTime)
+	})
+
+	t.Run("time bounds", func(t *testing.T) {
+		status, agenda := getAgenda(t, "?from=2026-03-10T09:30:00Z&to=2026-03-10T18:00:00Z")
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, agenda.Items, 1)
+		assert.Equal(t, "Standup", agenda.Items[0].Title)
+
+		status, agenda = getAgenda(t, "?from=2026-03-10T20:00:00Z")
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, agenda.Items, 1, "an event still running at from is included")
+		assert.Equal(t, "Dinner", agenda.Items[0].Title)
+		assert.Equal(t, "2026-03-11T00:00:00Z", agenda.To, "to defaults to the end of from's day")
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		for _, query := range []string{
+			"?from=yesterday",
+			"?from=2026-03-10&to=soon",
+			"?from=2026-03-10T12:00:00Z&to=2026-03-10T08:00:00Z",
+			"?from=2026-01-01&to=2026-12-31",
+		} {
+			status, _ := getAgenda(t, query)
+			assert.Equal(t, http.StatusBadRequest, status, query)
+		}
+	})
+}
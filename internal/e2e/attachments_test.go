@@ -0,0 +1,113 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventAttachments(t *testing.T) {
+	ts := testutil.NewTestServer(t, testutil.WithSimulatedWhatsApp())
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WhatsApp().
+		WithName("Dana").
+		MustBuild(ts.DB)
+
+	msg, err := ts.DB.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "dana@s.whatsapp.net", "Dana",
+		"[Image] Birthday party Saturday 4pm!", "", time.Now())
+	require.NoError(t, err)
+	require.NoError(t, ts.DB.AddMessageAttachments(msg.ID, []source.Attachment{{
+		Kind:      source.AttachmentKindImage,
+		MimeType:  "image/jpeg",
+		SizeBytes: 4,
+		SourceRef: "flyer-ref",
+	}}))
+
+	event := testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Birthday party").
+		WithOriginalMessage(msg.ID).
+		Pending().
+		MustBuild(ts.DB)
+	plain := testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("No attachment").
+		Pending().
+		MustBuild(ts.DB)
+
+	getEvent := func(t *testing.T, id int64) map[string]any {
+		t.Helper()
+		resp, err := http.Get(fmt.Sprintf("%s/api/events/%d", ts.BaseURL(), id))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	var contentURL string
+	t.Run("event detail lists the trigger message's attachments", func(t *testing.T) {
+		body := getEvent(t, event.ID)
+		attachments, ok := body["attachments"].([]any)
+		require.True(t, ok)
+		require.Len(t, attachments, 1)
+
+		attachment := attachments[0].(map[string]any)
+		assert.Equal(t, "image", attachment["kind"])
+		assert.Equal(t, "image/jpeg", attachment["mime_type"])
+		assert.NotContains(t, attachment, "source_ref", "media keys stay server-side")
+		contentURL, _ = attachment["content_url"].(string)
+		assert.Contains(t, contentURL, fmt.Sprintf("/api/events/%d/attachments/", event.ID))
+
+		assert.NotContains(t, getEvent(t, plain.ID), "attachments")
+	})
+
+	t.Run("content needs the WhatsApp account connected", func(t *testing.T) {
+		resp, err := http.Get(ts.BaseURL() + contentURL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	})
+
+	t.Run("content is downloaded from WhatsApp", func(t *testing.T) {
+		pair, _ := json.Marshal(map[string]string{"phone_number": "+15551234567"})
+		resp, err := http.Post(ts.BaseURL()+"/api/whatsapp/pair", "application/json", bytes.NewReader(pair))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		fake := ts.WhatsAppFake()
+		require.NotNil(t, fake)
+		fake.CompletePairing()
+		fake.AddMedia("flyer-ref", []byte{0xff, 0xd8, 0xff, 0xe0})
+
+		resp, err = http.Get(ts.BaseURL() + contentURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xff, 0xd8, 0xff, 0xe0}, data)
+	})
+
+	t.Run("attachments of other messages are not served", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("%s/api/events/%d/attachments/1", ts.BaseURL(), plain.ID))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,135 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WithName("Dana").
+		MustBuild(ts.DB)
+
+	tomorrow := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	dinner := testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Friday dinner").
+		WithStartTime(tomorrow).
+		WithLocation("Luigi's, Tel Aviv").
+		Synced().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Standup").
+		WithStartTime(tomorrow.Add(2 * time.Hour)).
+		Confirmed().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Awaiting review").
+		WithStartTime(tomorrow).
+		Pending().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Last year").
+		WithStartTime(time.Now().AddDate(-1, 0, 0)).
+		Confirmed().
+		MustBuild(ts.DB)
+
+	get := func(t *testing.T, url string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	createFeed := func(t *testing.T) map[string]string {
+		t.Helper()
+		resp, err := http.Post(ts.BaseURL()+"/api/calendar-feed", "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		return created
+	}
+
+	t.Run("single event download", func(t *testing.T) {
+		resp, body := get(t, ts.BaseURL()+"/api/events/"+fmt.Sprint(dinner.ID)+".ics")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "event-"+fmt.Sprint(dinner.ID)+".ics")
+		assert.Contains(t, body, "SUMMARY:Friday dinner")
+		assert.Contains(t, body, `LOCATION:Luigi's\, Tel Aviv`)
+
+		resp, _ = get(t, ts.BaseURL()+"/api/events/999999.ics")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("feed is off until created", func(t *testing.T) {
+		resp, body := get(t, ts.BaseURL()+"/api/calendar-feed")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"enabled": false}`, body)
+	})
+
+	t.Run("feed serves confirmed events", func(t *testing.T) {
+		created := createFeed(t)
+		require.True(t, strings.HasPrefix(created["url"], ts.BaseURL()+"/feeds/"))
+		assert.True(t, strings.HasPrefix(created["webcal_url"], "webcal://"))
+
+		resp, body := get(t, created["url"])
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, "X-WR-CALNAME:Alfred")
+		assert.Contains(t, body, "SUMMARY:Friday dinner")
+		assert.Contains(t, body, "SUMMARY:Standup")
+		assert.NotContains(t, body, "Awaiting review", "pending events are not in the feed")
+		assert.NotContains(t, body, "Last year", "old events are not in the feed")
+
+		var status map[string]any
+		_, raw := get(t, ts.BaseURL()+"/api/calendar-feed")
+		require.NoError(t, json.Unmarshal([]byte(raw), &status))
+		assert.Equal(t, true, status["enabled"])
+		assert.NotNil(t, status["last_accessed_at"])
+	})
+
+	t.Run("rotating the token retires the old URL", func(t *testing.T) {
+		old := createFeed(t)
+		rotated := createFeed(t)
+		assert.NotEqual(t, old["url"], rotated["url"])
+
+		resp, _ := get(t, old["url"])
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp, _ = get(t, rotated["url"])
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("turning the feed off", func(t *testing.T) {
+		created := createFeed(t)
+
+		req, err := http.NewRequest(http.MethodDelete, ts.BaseURL()+"/api/calendar-feed", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, _ = get(t, created["url"])
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,105 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/server"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarRange(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WithName("Dana").
+		MustBuild(ts.DB)
+
+	monday := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	at := func(days, hours int) time.Time {
+		return monday.AddDate(0, 0, days).Add(time.Duration(hours) * time.Hour)
+	}
+
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Friday dinner").
+		WithStartTime(at(4, 19)).
+		WithEndTime(at(4, 21)).
+		Synced().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Monday standup").
+		WithStartTime(at(0, 9)).
+		Confirmed().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Awaiting review").
+		WithStartTime(at(2, 12)).
+		Pending().
+		MustBuild(ts.DB)
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Next week").
+		WithStartTime(at(7, 10)).
+		Confirmed().
+		MustBuild(ts.DB)
+
+	getCalendar := func(t *testing.T, query string) (int, []server.TodayEventResponse) {
+		t.Helper()
+		resp, err := http.Get(ts.BaseURL() + "/api/calendar" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var events []server.TodayEventResponse
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&events))
+		}
+		return resp.StatusCode, events
+	}
+
+	t.Run("week view", func(t *testing.T) {
+		status, events := getCalendar(t, "?start=2026-03-09&end=2026-03-15")
+		require.Equal(t, http.StatusOK, status)
+
+		require.Len(t, events, 2, "pending events and the next week are left out")
+		assert.Equal(t, "Monday standup", events[0].Summary)
+		assert.Equal(t, "2026-03-09T10:00:00Z", events[0].EndTime, "end defaults to an hour after start")
+		assert.Equal(t, "Friday dinner", events[1].Summary)
+		assert.Equal(t, "alfred", events[1].Source)
+		assert.Equal(t, "alfred", events[1].CalendarID)
+	})
+
+	t.Run("month view", func(t *testing.T) {
+		status, events := getCalendar(t, "?start=2026-03-01&end=2026-03-31")
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, events, 3)
+		assert.Equal(t, "Next week", events[2].Summary)
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		status, events := getCalendar(t, "?start=2026-04-01T00:00:00Z&end=2026-04-02T00:00:00Z")
+		require.Equal(t, http.StatusOK, status)
+		assert.NotNil(t, events, "an empty range is an empty array")
+		assert.Empty(t, events)
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		for _, query := range []string{
+			"",
+			"?start=2026-03-09",
+			"?start=monday&end=2026-03-15",
+			"?start=2026-03-15&end=2026-03-09",
+			"?start=2026-01-01&end=2026-12-31",
+		} {
+			status, _ := getCalendar(t, query)
+			assert.Equal(t, http.StatusBadRequest, status, query)
+		}
+	})
+}
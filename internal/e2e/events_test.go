@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -134,6 +135,31 @@ func TestEventConfirmation(t *testing.T) {
 		// Should return error since event is already confirmed
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	})
+
+	t.Run("sending invites requires email", func(t *testing.T) {
+		dinner := testutil.NewEventBuilder(channel.ID).
+			WithUserID(ts.TestUser.ID).
+			WithTitle("Dinner").
+			Pending().
+			MustBuild(ts.DB)
+		_, err := ts.DB.AddEventAttendee(dinner.ID, "alice@example.com", "Alice", false)
+		require.NoError(t, err)
+
+		url := ts.BaseURL() + fmt.Sprintf("/api/events/%d/confirm", dinner.ID)
+		resp, err := ts.Client().Post(url, "application/json", strings.NewReader(`{"send_invites": true}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		pending, err := ts.DB.GetEventByID(dinner.ID)
+		require.NoError(t, err)
+		assert.Equal(t, database.EventStatusPending, pending.Status)
+
+		resp, err = ts.Client().Post(url, "application/json", strings.NewReader(`{"send_invites": false}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
 }
 
 func TestEventRejection(t *testing.T) {
@@ -269,6 +295,76 @@ func TestEventFiltering(t *testing.T) {
 	})
 }
 
+func TestEventPagination(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WhatsApp().
+		WithName("Paging Channel").
+		MustBuild(ts.DB)
+
+	base := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	for i := 0; i < 5; i++ {
+		testutil.NewEventBuilder(channel.ID).
+			WithUserID(ts.TestUser.ID).
+			WithTitle(fmt.Sprintf("Event %d", i)).
+			WithStartTime(base.Add(time.Duration(i) * time.Hour)).
+			WithEndTime(base.Add(time.Duration(i)*time.Hour + 30*time.Minute)).
+			MustBuild(ts.DB)
+	}
+
+	t.Run("follows next cursor through all pages", func(t *testing.T) {
+		var titles []string
+		url := ts.BaseURL() + "/api/events?limit=2&sort=start_time"
+		for pages := 0; url != ""; pages++ {
+			require.Less(t, pages, 5, "pagination did not terminate")
+
+			resp, err := http.Get(url)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "5", resp.Header.Get("X-Total-Count"))
+
+			var events []database.CalendarEvent
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&events))
+			resp.Body.Close()
+			for _, e := range events {
+				titles = append(titles, e.Title)
+			}
+
+			url = ""
+			if cursor := resp.Header.Get("X-Next-Cursor"); cursor != "" {
+				url = ts.BaseURL() + "/api/events?limit=2&sort=start_time&cursor=" + cursor
+			}
+		}
+
+		assert.Equal(t, []string{"Event 0", "Event 1", "Event 2", "Event 3", "Event 4"}, titles)
+	})
+
+	t.Run("filters by start time range", func(t *testing.T) {
+		from := base.Add(time.Hour).UTC().Format(time.RFC3339)
+		to := base.Add(3 * time.Hour).UTC().Format(time.RFC3339)
+		resp, err := http.Get(ts.BaseURL() + "/api/events?sort=start_time&from=" + from + "&to=" + to)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var events []database.CalendarEvent
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&events))
+		require.Len(t, events, 2)
+		assert.Equal(t, "Event 1", events[0].Title)
+		assert.Equal(t, "Event 2", events[1].Title)
+	})
+
+	t.Run("rejects unknown sort field", func(t *testing.T) {
+		resp, err := http.Get(ts.BaseURL() + "/api/events?sort=password")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
 func TestTodayEvents(t *testing.T) {
 	ts := testutil.NewTestServer(t)
 
@@ -0,0 +1,75 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageSettings(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	type languageSettings struct {
+		Locale           *string  `json:"locale"`
+		RTL              bool     `json:"rtl"`
+		SupportedLocales []string `json:"supported_locales"`
+	}
+
+	get := func(t *testing.T) languageSettings {
+		resp, err := http.Get(ts.BaseURL() + "/api/settings/language")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result languageSettings
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	put := func(t *testing.T, body string) *http.Response {
+		req, err := http.NewRequest("PUT", ts.BaseURL()+"/api/settings/language", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("defaults to the conversation language", func(t *testing.T) {
+		result := get(t)
+		assert.Nil(t, result.Locale)
+		assert.False(t, result.RTL)
+		assert.Contains(t, result.SupportedLocales, "he")
+	})
+
+	t.Run("choose Hebrew", func(t *testing.T) {
+		resp := put(t, `{"locale": "HE"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		result := get(t)
+		require.NotNil(t, result.Locale)
+		assert.Equal(t, "he", *result.Locale)
+		assert.True(t, result.RTL)
+
+		locale, err := ts.DB.GetUserLocale(ts.TestUser.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "he", locale)
+	})
+
+	t.Run("rejects unsupported locales", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, put(t, `{"locale": "klingon"}`).StatusCode)
+		assert.Equal(t, http.StatusBadRequest, put(t, `not json`).StatusCode)
+	})
+
+	t.Run("null resets to the conversation language", func(t *testing.T) {
+		resp := put(t, `{"locale": null}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Nil(t, get(t).Locale)
+	})
+}
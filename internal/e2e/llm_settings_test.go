@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLLMSettings(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	type llmSettings struct {
+		ModelTier      string   `json:"model_tier"`
+		Temperature    float64  `json:"temperature"`
+		IsDefault      bool     `json:"is_default"`
+		AvailableTiers []string `json:"available_tiers"`
+	}
+
+	get := func(t *testing.T) llmSettings {
+		resp, err := http.Get(ts.BaseURL() + "/api/settings/llm")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result llmSettings
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	put := func(t *testing.T, body string) *http.Response {
+		req, err := http.NewRequest("PUT", ts.BaseURL()+"/api/settings/llm", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("defaults to the accurate tier", func(t *testing.T) {
+		result := get(t)
+		assert.True(t, result.IsDefault)
+		assert.Equal(t, "accurate", result.ModelTier)
+		assert.ElementsMatch(t, []string{"fast", "accurate"}, result.AvailableTiers)
+	})
+
+	t.Run("choose fast tier and temperature", func(t *testing.T) {
+		resp := put(t, `{"model_tier": "fast", "temperature": 0.5}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		result := get(t)
+		assert.False(t, result.IsDefault)
+		assert.Equal(t, "fast", result.ModelTier)
+		assert.Equal(t, 0.5, result.Temperature)
+
+		settings, err := ts.DB.GetLLMSettings(ts.TestUser.ID)
+		require.NoError(t, err)
+		require.NotNil(t, settings.ModelTier)
+		assert.Equal(t, "fast", *settings.ModelTier)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, put(t, `{"model_tier": "turbo"}`).StatusCode)
+		assert.Equal(t, http.StatusBadRequest, put(t, `{"temperature": 1.5}`).StatusCode)
+		assert.Equal(t, http.StatusBadRequest, put(t, `not json`).StatusCode)
+	})
+
+	t.Run("null resets to defaults", func(t *testing.T) {
+		resp := put(t, `{"model_tier": null, "temperature": null}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		result := get(t)
+		assert.True(t, result.IsDefault)
+		assert.Equal(t, "accurate", result.ModelTier)
+	})
+}
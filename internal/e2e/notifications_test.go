@@ -3,9 +3,11 @@ package e2e
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"testing"
 
+	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -179,4 +181,166 @@ func TestPushNotificationPreferences(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	})
+
+	t.Run("list and delete devices", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{
+			"token":       "ExponentPushToken[second-phone]",
+			"platform":    "android",
+			"device_name": "Pixel",
+		})
+		resp, err := http.Post(ts.BaseURL()+"/api/notifications/push/register", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		listResp, err := http.Get(ts.BaseURL() + "/api/devices")
+		require.NoError(t, err)
+		defer listResp.Body.Close()
+		require.Equal(t, http.StatusOK, listResp.StatusCode)
+
+		var devices []database.Device
+		require.NoError(t, json.NewDecoder(listResp.Body).Decode(&devices))
+		require.Len(t, devices, 2)
+
+		var pixel *database.Device
+		for i := range devices {
+			if devices[i].PushToken == "ExponentPushToken[second-phone]" {
+				pixel = &devices[i]
+			}
+		}
+		require.NotNil(t, pixel)
+		assert.Equal(t, "android", pixel.Platform)
+		assert.Equal(t, "Pixel", pixel.DeviceName)
+
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/devices/%d", ts.BaseURL(), pixel.ID), nil)
+		require.NoError(t, err)
+		deleteResp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		deleteResp.Body.Close()
+		assert.Equal(t, http.StatusOK, deleteResp.StatusCode)
+
+		deleteResp, err = ts.Client().Do(req)
+		require.NoError(t, err)
+		deleteResp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, deleteResp.StatusCode)
+
+		tokens, err := ts.DB.GetPushTokens(ts.TestUser.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ExponentPushToken[xxxxxxxxxxxxxxxxxxxxxx]"}, tokens)
+	})
+}
+
+func TestEventNotificationOffsets(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WhatsApp().
+		WithName("Clinic").
+		MustBuild(ts.DB)
+
+	event := testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Dentist").
+		Confirmed().
+		MustBuild(ts.DB)
+
+	put := func(t *testing.T, path string, payload interface{}) *http.Response {
+		body, _ := json.Marshal(payload)
+		req, err := http.NewRequest("PUT", ts.BaseURL()+path, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	getEventOffsets := func(t *testing.T) map[string]interface{} {
+		resp, err := http.Get(fmt.Sprintf("%s/api/events/%d/notifications", ts.BaseURL(), event.ID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	t.Run("defaults to 30 minutes", func(t *testing.T) {
+		result := getEventOffsets(t)
+		assert.Equal(t, []interface{}{float64(30)}, result["offsets_minutes"])
+		assert.Equal(t, false, result["is_override"])
+	})
+
+	t.Run("update user default offsets", func(t *testing.T) {
+		resp := put(t, "/api/notifications/events", map[string]interface{}{"offsets_minutes": []int{15, 60}})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var prefs map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&prefs))
+		assert.Equal(t, []interface{}{float64(60), float64(15)}, prefs["event_notify_offsets"])
+
+		result := getEventOffsets(t)
+		assert.Equal(t, []interface{}{float64(60), float64(15)}, result["offsets_minutes"])
+	})
+
+	t.Run("rejects out of range offsets", func(t *testing.T) {
+		resp := put(t, "/api/notifications/events", map[string]interface{}{"offsets_minutes": []int{-5}})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("missing offsets leave the defaults unchanged", func(t *testing.T) {
+		resp := put(t, "/api/notifications/events", map[string]interface{}{})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var prefs map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&prefs))
+		assert.Equal(t, []interface{}{float64(60), float64(15)}, prefs["event_notify_offsets"])
+	})
+
+	t.Run("rejects empty offsets unless disabling", func(t *testing.T) {
+		resp := put(t, "/api/notifications/events", map[string]interface{}{"offsets_minutes": []int{}})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		disabled := put(t, "/api/notifications/events", map[string]interface{}{"disabled": true})
+		defer disabled.Body.Close()
+		require.Equal(t, http.StatusOK, disabled.StatusCode)
+		var prefs map[string]interface{}
+		require.NoError(t, json.NewDecoder(disabled.Body).Decode(&prefs))
+		assert.Empty(t, prefs["event_notify_offsets"])
+
+		restored := put(t, "/api/notifications/events", map[string]interface{}{"offsets_minutes": []int{15, 60}})
+		defer restored.Body.Close()
+		require.Equal(t, http.StatusOK, restored.StatusCode)
+	})
+
+	t.Run("override per event", func(t *testing.T) {
+		resp := put(t, fmt.Sprintf("/api/events/%d/notifications", event.ID), map[string]interface{}{"offsets_minutes": []int{120}})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		result := getEventOffsets(t)
+		assert.Equal(t, []interface{}{float64(120)}, result["offsets_minutes"])
+		assert.Equal(t, true, result["is_override"])
+	})
+
+	t.Run("reset override to default", func(t *testing.T) {
+		resp := put(t, fmt.Sprintf("/api/events/%d/notifications", event.ID), map[string]interface{}{"use_default": true})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		result := getEventOffsets(t)
+		assert.Equal(t, []interface{}{float64(60), float64(15)}, result["offsets_minutes"])
+		assert.Equal(t, false, result["is_override"])
+	})
+
+	t.Run("requires offsets or use_default", func(t *testing.T) {
+		resp := put(t, fmt.Sprintf("/api/events/%d/notifications", event.ID), map[string]interface{}{})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
 }
@@ -0,0 +1,73 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent/mockagent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/server"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReminder(t *testing.T) {
+	ts := testutil.NewTestServer(t,
+		testutil.WithAnalyzers(nil, mockagent.NewReminderAnalyzer(mockagent.DefaultRules())),
+	)
+
+	parse := func(t *testing.T, base, text string) (int, server.ParsedReminder) {
+		t.Helper()
+		body, _ := json.Marshal(map[string]string{"text": text})
+		resp, err := http.Post(base+"/api/reminders/parse", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var parsed server.ParsedReminder
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&parsed))
+		}
+		return resp.StatusCode, parsed
+	}
+
+	t.Run("returns a structured reminder without saving it", func(t *testing.T) {
+		before := time.Now()
+		status, parsed := parse(t, ts.BaseURL(), "remind me to renew the passport")
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "Reminder", parsed.Title)
+		assert.Equal(t, database.ReminderPriorityNormal, parsed.Priority)
+		require.NotNil(t, parsed.DueDate)
+		assert.WithinDuration(t, before.Add(24*time.Hour), *parsed.DueDate, time.Minute)
+
+		reminders, err := ts.DB.ListReminders(ts.TestUser.ID, nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, reminders)
+	})
+
+	t.Run("keeps the agent's priority", func(t *testing.T) {
+		status, parsed := parse(t, ts.BaseURL(), "pay the electricity bill")
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "Pay bill", parsed.Title)
+		assert.Equal(t, database.ReminderPriorityHigh, parsed.Priority)
+	})
+
+	t.Run("text without a reminder", func(t *testing.T) {
+		status, _ := parse(t, ts.BaseURL(), "nice weather today")
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+	})
+
+	t.Run("text is required", func(t *testing.T) {
+		status, _ := parse(t, ts.BaseURL(), "   ")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("no reminder analyzer", func(t *testing.T) {
+		bare := testutil.NewTestServer(t)
+		status, _ := parse(t, bare.BaseURL(), "remind me to call mom")
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+	})
+}
@@ -986,3 +986,101 @@ func TestReminderChannelName(t *testing.T) {
 		}
 	})
 }
+
+func TestReminderSnooze(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WhatsApp().
+		WithName("Snoozer").
+		MustBuild(ts.DB)
+
+	reminder := testutil.NewReminderBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Water the plants").
+		Confirmed().
+		MustBuild(ts.DB)
+
+	snooze := func(t *testing.T, id int64, payload map[string]interface{}) *http.Response {
+		body, _ := json.Marshal(payload)
+		req, err := http.NewRequest("POST", ts.BaseURL()+fmt.Sprintf("/api/reminders/%d/snooze", id), bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("snooze by duration", func(t *testing.T) {
+		before := time.Now()
+		resp := snooze(t, reminder.ID, map[string]interface{}{"duration_minutes": 90})
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var updated database.Reminder
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+		require.NotNil(t, updated.DueDate)
+		assert.WithinDuration(t, before.Add(90*time.Minute), *updated.DueDate, 5*time.Second)
+		assert.Equal(t, database.ReminderStatusConfirmed, updated.Status)
+	})
+
+	t.Run("snooze to explicit due date", func(t *testing.T) {
+		until := time.Now().Add(72 * time.Hour).Truncate(time.Second)
+		resp := snooze(t, reminder.ID, map[string]interface{}{"due_date": until.Format(time.RFC3339)})
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		updated, err := ts.DB.GetReminderByID(reminder.ID)
+		require.NoError(t, err)
+		require.NotNil(t, updated.DueDate)
+		assert.WithinDuration(t, until, *updated.DueDate, time.Second)
+	})
+
+	t.Run("history is returned with the reminder", func(t *testing.T) {
+		resp, err := http.Get(ts.BaseURL() + fmt.Sprintf("/api/reminders/%d", reminder.ID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result struct {
+			SnoozeHistory []database.ReminderSnooze `json:"snooze_history"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Len(t, result.SnoozeHistory, 2)
+	})
+
+	t.Run("requires exactly one of duration or due date", func(t *testing.T) {
+		resp := snooze(t, reminder.ID, map[string]interface{}{})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp2 := snooze(t, reminder.ID, map[string]interface{}{
+			"duration_minutes": 10,
+			"due_date":         time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+		defer resp2.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp2.StatusCode)
+	})
+
+	t.Run("rejects due date in the past", func(t *testing.T) {
+		resp := snooze(t, reminder.ID, map[string]interface{}{
+			"due_date": time.Now().Add(-time.Hour).Format(time.RFC3339),
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("cannot snooze completed reminder", func(t *testing.T) {
+		completed := testutil.NewReminderBuilder(channel.ID).
+			WithUserID(ts.TestUser.ID).
+			Completed().
+			MustBuild(ts.DB)
+
+		resp := snooze(t, completed.ID, map[string]interface{}{"duration_minutes": 10})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
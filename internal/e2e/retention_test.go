@@ -0,0 +1,75 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageRetentionSettings(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	type retention struct {
+		MessageRetentionDays int  `json:"message_retention_days"`
+		IsDefault            bool `json:"is_default"`
+		DefaultDays          int  `json:"default_days"`
+	}
+
+	get := func(t *testing.T) retention {
+		resp, err := http.Get(ts.BaseURL() + "/api/account/retention")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result retention
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	put := func(t *testing.T, body string) *http.Response {
+		req, err := http.NewRequest("PUT", ts.BaseURL()+"/api/account/retention", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("defaults to the server setting", func(t *testing.T) {
+		result := get(t)
+		assert.True(t, result.IsDefault)
+		assert.Equal(t, result.DefaultDays, result.MessageRetentionDays)
+	})
+
+	t.Run("set a custom window", func(t *testing.T) {
+		resp := put(t, `{"message_retention_days": 30}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		result := get(t)
+		assert.False(t, result.IsDefault)
+		assert.Equal(t, 30, result.MessageRetentionDays)
+
+		days, err := ts.DB.GetMessageRetentionDays(ts.TestUser.ID)
+		require.NoError(t, err)
+		require.NotNil(t, days)
+		assert.Equal(t, 30, *days)
+	})
+
+	t.Run("rejects out of range values", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, put(t, `{"message_retention_days": -1}`).StatusCode)
+		assert.Equal(t, http.StatusBadRequest, put(t, `{"message_retention_days": 100000}`).StatusCode)
+		assert.Equal(t, 30, get(t).MessageRetentionDays)
+	})
+
+	t.Run("null resets to the default", func(t *testing.T) {
+		resp := put(t, `{"message_retention_days": null}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, get(t).IsDefault)
+	})
+}
@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WhatsApp().
+		WithName("Dana").
+		MustBuild(ts.DB)
+
+	message := testutil.NewMessageBuilder(channel.ID).
+		WithSenderName("Dana").
+		WithText("Reminder: dentist on Thursday at 4").
+		MustBuild(ts.DB)
+
+	event := testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Dentist").
+		Confirmed().
+		MustBuild(ts.DB)
+
+	search := func(t *testing.T, query string) (int, []database.SearchHit) {
+		resp, err := http.Get(ts.BaseURL() + "/api/search?" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result struct {
+			Results []database.SearchHit `json:"results"`
+		}
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		}
+		return resp.StatusCode, result.Results
+	}
+
+	t.Run("returns typed hits", func(t *testing.T) {
+		status, hits := search(t, "q="+url.QueryEscape("dentist"))
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, hits, 2)
+
+		found := map[database.SearchResultType]int64{}
+		for _, hit := range hits {
+			found[hit.Type] = hit.ID
+			assert.Equal(t, channel.ID, hit.ChannelID)
+		}
+		assert.Equal(t, message.ID, found[database.SearchResultMessage])
+		assert.Equal(t, event.ID, found[database.SearchResultEvent])
+	})
+
+	t.Run("filters by type", func(t *testing.T) {
+		status, hits := search(t, "q=dentist&types=event")
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, hits, 1)
+		assert.Equal(t, database.SearchResultEvent, hits[0].Type)
+	})
+
+	t.Run("requires a query", func(t *testing.T) {
+		status, _ := search(t, "q=")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("rejects unknown types", func(t *testing.T) {
+		status, _ := search(t, "q=dentist&types=contact")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
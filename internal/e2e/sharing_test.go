@@ -0,0 +1,160 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelSharing(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	send := func(t *testing.T, method, path string, body any) *http.Response {
+		var data []byte
+		if body != nil {
+			var err error
+			data, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		req, err := http.NewRequest(method, ts.BaseURL()+path, bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	// The test user is invited to a partner's channel
+	partner := database.CreateTestUserWithEmail(t, ts.DB, "partner-sharing@example.com")
+	partnerChannel := testutil.NewChannelBuilder().WithUserID(partner.ID).WhatsApp().WithName("Kids School").MustBuild(ts.DB)
+	invitation, err := ts.DB.CreateChannelShare(partner.ID, partnerChannel.ID, ts.TestUser.Email)
+	require.NoError(t, err)
+
+	t.Run("invitee sees and accepts the invitation", func(t *testing.T) {
+		resp := send(t, "GET", "/api/shares/invitations", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var invitations []database.ChannelShare
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&invitations))
+		require.Len(t, invitations, 1)
+		assert.Equal(t, "Kids School", invitations[0].ChannelName)
+		assert.Equal(t, "partner-sharing@example.com", invitations[0].OwnerEmail)
+
+		resp = send(t, "POST", fmt.Sprintf("/api/shares/%d/accept", invitation.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(t, "POST", fmt.Sprintf("/api/shares/%d/decline", invitation.ID), nil)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = send(t, "GET", "/api/shares", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var shared []database.ChannelShare
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&shared))
+		require.Len(t, shared, 1)
+		assert.Equal(t, partnerChannel.ID, shared[0].ChannelID)
+	})
+
+	t.Run("member receives a copy they can confirm", func(t *testing.T) {
+		event := testutil.NewEventBuilder(partnerChannel.ID).WithUserID(partner.ID).WithTitle("School play").Pending().MustBuild(ts.DB)
+		copies, err := ts.DB.ShareEventWithMembers(event.ID)
+		require.NoError(t, err)
+		require.Len(t, copies, 1)
+
+		resp := send(t, "GET", fmt.Sprintf("/api/events?channel_id=%d", partnerChannel.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var events []database.CalendarEvent
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&events))
+		require.Len(t, events, 1)
+		assert.Equal(t, "School play", events[0].Title)
+		require.NotNil(t, events[0].SharedFromEventID)
+		assert.Equal(t, event.ID, *events[0].SharedFromEventID)
+
+		resp = send(t, "POST", fmt.Sprintf("/api/events/%d/confirm", copies[0].ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// The owner's event is untouched and still not the member's to act on
+		original, err := ts.DB.GetEventByID(event.ID)
+		require.NoError(t, err)
+		assert.Equal(t, database.EventStatusPending, original.Status)
+		resp = send(t, "POST", fmt.Sprintf("/api/events/%d/confirm", event.ID), nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("member can read channel history but not manage the channel", func(t *testing.T) {
+		resp := send(t, "GET", fmt.Sprintf("/api/events/channel/%d/history", partnerChannel.ID), nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(t, "GET", fmt.Sprintf("/api/channels/%d/shares", partnerChannel.ID), nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = send(t, "POST", fmt.Sprintf("/api/channels/%d/shares", partnerChannel.ID), map[string]string{"email": "someone@example.com"})
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("member can leave the channel", func(t *testing.T) {
+		resp := send(t, "DELETE", fmt.Sprintf("/api/shares/%d", invitation.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(t, "GET", fmt.Sprintf("/api/events/channel/%d/history", partnerChannel.ID), nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("owner invites and controls event sharing", func(t *testing.T) {
+		channel := testutil.NewChannelBuilder().WithUserID(ts.TestUser.ID).Telegram().MustBuild(ts.DB)
+
+		resp := send(t, "POST", fmt.Sprintf("/api/channels/%d/shares", channel.ID), map[string]string{"email": "not-an-email"})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = send(t, "POST", fmt.Sprintf("/api/channels/%d/shares", channel.ID), map[string]string{"email": ts.TestUser.Email})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, "POST", fmt.Sprintf("/api/channels/%d/shares", channel.ID), map[string]string{"email": partner.Email})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var share database.ChannelShare
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&share))
+		assert.Equal(t, database.ChannelSharePending, share.Status)
+
+		// The invitee can't be the one answering through another account
+		resp = send(t, "POST", fmt.Sprintf("/api/shares/%d/accept", share.ID), nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		responded, err := ts.DB.RespondToChannelShare(share.ID, partner.ID, true)
+		require.NoError(t, err)
+		require.True(t, responded)
+
+		event := testutil.NewEventBuilder(channel.ID).WithUserID(ts.TestUser.ID).Pending().CreateAction().MustBuild(ts.DB)
+		resp = send(t, "PUT", fmt.Sprintf("/api/events/%d/sharing", event.ID), map[string]bool{"shared": true})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var updated database.CalendarEvent
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+		assert.True(t, updated.Shared)
+
+		status := database.EventStatusPending
+		partnerEvents, err := ts.DB.ListEvents(partner.ID, &status, &channel.ID)
+		require.NoError(t, err)
+		require.Len(t, partnerEvents, 1)
+
+		// Members can't change sharing on their copy
+		resp = send(t, "PUT", fmt.Sprintf("/api/events/%d/sharing", partnerEvents[0].ID), map[string]bool{"shared": false})
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = send(t, "PUT", fmt.Sprintf("/api/events/%d/sharing", event.ID), map[string]bool{"shared": false})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		partnerEvents, err = ts.DB.ListEvents(partner.ID, &status, &channel.ID)
+		require.NoError(t, err)
+		assert.Empty(t, partnerEvents)
+	})
+
+	t.Run("manual task channels cannot be shared", func(t *testing.T) {
+		channel, err := ts.DB.EnsureManualReminderChannel(ts.TestUser.ID)
+		require.NoError(t, err)
+
+		resp := send(t, "POST", fmt.Sprintf("/api/channels/%d/shares", channel.ID), map[string]string{"email": partner.Email})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
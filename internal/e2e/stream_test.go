@@ -0,0 +1,196 @@
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/sse"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// openStream connects to an SSE endpoint and returns a channel of parsed events.
+// A non-empty lastEventID is sent as Last-Event-ID to resume the stream.
+func openStream(t *testing.T, ts *testutil.TestServer, path, lastEventID string) <-chan streamEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.BaseURL()+path, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan streamEvent, 10)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var current streamEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				current.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				current.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				current.Data = strings.TrimPrefix(line, "data: ")
+			case line == "" && current.Type != "":
+				events <- current
+				current = streamEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func nextStreamEvent(t *testing.T, events <-chan streamEvent) streamEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "stream closed")
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for stream event")
+		return streamEvent{}
+	}
+}
+
+func TestUserStream(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WhatsApp().
+		MustBuild(ts.DB)
+
+	testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Existing").
+		Pending().
+		MustBuild(ts.DB)
+
+	events := openStream(t, ts, "/api/stream", "")
+
+	ready := nextStreamEvent(t, events)
+	require.Equal(t, "ready", ready.Type)
+	assert.JSONEq(t, `{"pending_events":1,"pending_reminders":0}`, ready.Data)
+
+	t.Run("pushes pending events", func(t *testing.T) {
+		event := testutil.NewEventBuilder(channel.ID).
+			WithUserID(ts.TestUser.ID).
+			WithTitle("Team lunch").
+			Pending().
+			MustBuild(ts.DB)
+
+		ts.NotifyService.NotifyPendingEvent(context.Background(), event)
+
+		got := nextStreamEvent(t, events)
+		require.Equal(t, sse.UpdateEventPending, got.Type)
+		var payload struct {
+			ID    int64  `json:"id"`
+			Title string `json:"title"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(got.Data), &payload))
+		assert.Equal(t, event.ID, payload.ID)
+		assert.Equal(t, "Team lunch", payload.Title)
+	})
+
+	t.Run("pushes pending reminders", func(t *testing.T) {
+		reminder := testutil.NewReminderBuilder(channel.ID).
+			WithUserID(ts.TestUser.ID).
+			WithTitle("Pay rent").
+			MustBuild(ts.DB)
+
+		ts.NotifyService.NotifyPendingReminder(context.Background(), reminder)
+
+		got := nextStreamEvent(t, events)
+		require.Equal(t, sse.UpdateReminderPending, got.Type)
+		assert.Contains(t, got.Data, `"title":"Pay rent"`)
+	})
+
+	t.Run("does not leak other users' updates", func(t *testing.T) {
+		require.NoError(t, ts.Server.Streams().Publish(ts.TestUser.ID+1, sse.UpdateSyncComplete, map[string]string{"source": "gcal"}))
+		require.NoError(t, ts.Server.Streams().Publish(ts.TestUser.ID, sse.UpdateSyncComplete, map[string]string{"source": "gmail"}))
+
+		got := nextStreamEvent(t, events)
+		require.Equal(t, sse.UpdateSyncComplete, got.Type)
+		assert.JSONEq(t, `{"source":"gmail"}`, got.Data)
+	})
+}
+
+func TestStreamResume(t *testing.T) {
+	t.Run("onboarding stream replays missed updates", func(t *testing.T) {
+		ts := testutil.NewTestServer(t)
+
+		events := openStream(t, ts, "/api/onboarding/stream", "")
+		status := nextStreamEvent(t, events)
+		require.Equal(t, "status", status.Type)
+
+		ts.State.SetWhatsAppStatus("waiting")
+		first := nextStreamEvent(t, events)
+		require.Equal(t, "whatsapp_status", first.Type)
+		require.NotEmpty(t, first.ID)
+
+		// Updates published while the client is disconnected
+		ts.State.SetQR("data:image/png;base64,abc")
+		ts.State.SetGCalStatus("needs_auth")
+
+		resumed := openStream(t, ts, "/api/onboarding/stream", first.ID)
+		qr := nextStreamEvent(t, resumed)
+		assert.Equal(t, "qr", qr.Type)
+		assert.Equal(t, "data:image/png;base64,abc", qr.Data)
+		gcal := nextStreamEvent(t, resumed)
+		assert.Equal(t, "gcal_status", gcal.Type)
+		assert.Equal(t, "needs_auth", gcal.Data)
+	})
+
+	t.Run("unknown id falls back to full status", func(t *testing.T) {
+		ts := testutil.NewTestServer(t)
+		ts.State.SetWhatsAppStatus("waiting")
+
+		events := openStream(t, ts, "/api/onboarding/stream", "999")
+		status := nextStreamEvent(t, events)
+		assert.Equal(t, "status", status.Type)
+		assert.Equal(t, "1", status.ID)
+	})
+
+	t.Run("user stream replays missed updates", func(t *testing.T) {
+		ts := testutil.NewTestServer(t)
+
+		events := openStream(t, ts, "/api/stream", "")
+		ready := nextStreamEvent(t, events)
+		require.Equal(t, "ready", ready.Type)
+
+		streams := ts.Server.Streams()
+		require.NoError(t, streams.Publish(ts.TestUser.ID, sse.UpdateSyncComplete, map[string]string{"source": "gcal"}))
+		first := nextStreamEvent(t, events)
+
+		require.NoError(t, streams.Publish(ts.TestUser.ID, sse.UpdateSyncComplete, map[string]string{"source": "gmail"}))
+
+		resumed := openStream(t, ts, "/api/stream", first.ID)
+		missed := nextStreamEvent(t, resumed)
+		assert.Equal(t, sse.UpdateSyncComplete, missed.Type)
+		assert.JSONEq(t, `{"source":"gmail"}`, missed.Data)
+	})
+}
@@ -0,0 +1,113 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLLMUsageAndBudget(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	type budget struct {
+		MonthlyBudgetUSD float64  `json:"monthly_budget_usd"`
+		IsDefault        bool     `json:"is_default"`
+		SpentUSD         float64  `json:"spent_usd"`
+		RemainingUSD     *float64 `json:"remaining_usd"`
+		Paused           bool     `json:"paused"`
+	}
+	type usageResponse struct {
+		Month  string                   `json:"month"`
+		Usage  database.LLMUsageSummary `json:"usage"`
+		Budget budget                   `json:"budget"`
+	}
+
+	getUsage := func(t *testing.T, query string) (int, usageResponse) {
+		resp, err := http.Get(ts.BaseURL() + "/api/usage" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result usageResponse
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		}
+		return resp.StatusCode, result
+	}
+
+	putBudget := func(t *testing.T, body string) *http.Response {
+		req, err := http.NewRequest("PUT", ts.BaseURL()+"/api/usage/budget", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	now := time.Now().UTC()
+	require.NoError(t, ts.DB.RecordLLMUsage(database.LLMUsage{
+		UserID: ts.TestUser.ID, Agent: "event", Model: "claude-sonnet-4-20250514",
+		InputTokens: 1200, OutputTokens: 80, CostUSD: 0.0048, CreatedAt: now,
+	}))
+	require.NoError(t, ts.DB.RecordLLMUsage(database.LLMUsage{
+		UserID: ts.TestUser.ID, Agent: "reminder", Model: "claude-3-5-haiku-20241022",
+		InputTokens: 900, OutputTokens: 40, CostUSD: 0.00088, CreatedAt: now,
+	}))
+
+	t.Run("summarizes the current month", func(t *testing.T) {
+		status, result := getUsage(t, "")
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, now.Format("2006-01"), result.Month)
+		assert.Equal(t, 2, result.Usage.Analyses)
+		assert.Equal(t, 2100, result.Usage.InputTokens)
+		assert.Len(t, result.Usage.ByModel, 2)
+		require.Len(t, result.Usage.ByDay, 1)
+		assert.Equal(t, now.Format("2006-01-02"), result.Usage.ByDay[0].Date)
+
+		assert.True(t, result.Budget.IsDefault)
+		assert.Equal(t, 0.0, result.Budget.MonthlyBudgetUSD)
+		assert.Nil(t, result.Budget.RemainingUSD)
+		assert.False(t, result.Budget.Paused)
+	})
+
+	t.Run("other months are empty", func(t *testing.T) {
+		status, result := getUsage(t, "?month=2020-01")
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, 0, result.Usage.Analyses)
+		assert.Empty(t, result.Usage.ByModel)
+
+		status, _ = getUsage(t, "?month=January")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("budget below spend pauses analysis", func(t *testing.T) {
+		resp := putBudget(t, `{"monthly_budget_usd": 0.005}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result budget
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.IsDefault)
+		assert.True(t, result.Paused)
+		require.NotNil(t, result.RemainingUSD)
+		assert.Equal(t, 0.0, *result.RemainingUSD)
+	})
+
+	t.Run("rejects invalid budgets", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, putBudget(t, `{"monthly_budget_usd": -1}`).StatusCode)
+		assert.Equal(t, http.StatusBadRequest, putBudget(t, `nope`).StatusCode)
+	})
+
+	t.Run("null resets to the default", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, putBudget(t, `{"monthly_budget_usd": null}`).StatusCode)
+		_, result := getUsage(t, "")
+		assert.True(t, result.Budget.IsDefault)
+		assert.False(t, result.Budget.Paused)
+	})
+}
@@ -0,0 +1,129 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooksAPI(t *testing.T) {
+	ts := testutil.NewTestServer(t)
+
+	send := func(t *testing.T, method, path string, body any) *http.Response {
+		var reader *bytes.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			reader = bytes.NewReader(data)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req, err := http.NewRequest(method, ts.BaseURL()+path, reader)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	var created struct {
+		ID         int64    `json:"id"`
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+		Enabled    bool     `json:"enabled"`
+		Secret     string   `json:"secret"`
+	}
+
+	t.Run("create returns the generated secret once", func(t *testing.T) {
+		resp := send(t, "POST", "/api/webhooks", map[string]any{
+			"url":         "https://example.com/alfred",
+			"event_types": []string{"event.created", "event.confirmed"},
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.True(t, strings.HasPrefix(created.Secret, "whsec_"))
+		assert.True(t, created.Enabled)
+
+		resp = send(t, "GET", "/api/webhooks", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var listed []map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+		require.Len(t, listed, 1)
+		assert.NotContains(t, listed[0], "secret")
+	})
+
+	t.Run("create validates url and event types", func(t *testing.T) {
+		resp := send(t, "POST", "/api/webhooks", map[string]any{
+			"url": "ftp://example.com", "event_types": []string{"event.created"},
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, "POST", "/api/webhooks", map[string]any{
+			"url": "https://example.com", "event_types": []string{"event.deleted"},
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = send(t, "POST", "/api/webhooks", map[string]any{"url": "https://example.com"})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("confirming an event queues event.confirmed", func(t *testing.T) {
+		channel := testutil.NewChannelBuilder().WithUserID(ts.TestUser.ID).WhatsApp().MustBuild(ts.DB)
+		event := testutil.NewEventBuilder(channel.ID).WithUserID(ts.TestUser.ID).Pending().MustBuild(ts.DB)
+
+		resp := send(t, "POST", fmt.Sprintf("/api/events/%d/confirm", event.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = send(t, "GET", fmt.Sprintf("/api/webhooks/%d/deliveries", created.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var deliveries []database.WebhookDelivery
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&deliveries))
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, "event.confirmed", deliveries[0].EventType)
+		assert.Equal(t, database.WebhookDeliveryPending, deliveries[0].Status)
+		assert.Contains(t, deliveries[0].Payload, fmt.Sprintf(`"id":%d`, event.ID))
+	})
+
+	t.Run("update disables and changes event types", func(t *testing.T) {
+		resp := send(t, "PUT", fmt.Sprintf("/api/webhooks/%d", created.ID), map[string]any{
+			"enabled":     false,
+			"event_types": []string{"reminder.due"},
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		hook, err := ts.DB.GetWebhookByID(created.ID)
+		require.NoError(t, err)
+		assert.False(t, hook.Enabled)
+		assert.Equal(t, []string{"reminder.due"}, hook.EventTypes)
+		assert.Equal(t, "https://example.com/alfred", hook.URL)
+	})
+
+	t.Run("other users' webhooks are not found", func(t *testing.T) {
+		other := database.CreateTestUserWithEmail(t, ts.DB, "other-webhooks@example.com")
+		hook, err := ts.DB.CreateWebhook(other.ID, "https://example.com/other", "s", []string{"event.created"})
+		require.NoError(t, err)
+
+		resp := send(t, "DELETE", fmt.Sprintf("/api/webhooks/%d", hook.ID), nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = send(t, "GET", fmt.Sprintf("/api/webhooks/%d/deliveries", hook.ID), nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp := send(t, "DELETE", fmt.Sprintf("/api/webhooks/%d", created.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		hooks, err := ts.DB.ListWebhooks(ts.TestUser.ID)
+		require.NoError(t, err)
+		assert.Empty(t, hooks)
+	})
+}
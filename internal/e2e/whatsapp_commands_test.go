@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/omriShneor/project_alfred/internal/whatsapp/mockwhatsapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhatsAppSelfChatCommands(t *testing.T) {
+	ts := testutil.NewTestServer(t, testutil.WithSimulatedWhatsApp())
+
+	body, _ := json.Marshal(map[string]string{"phone_number": "+15551234567"})
+	resp, err := http.Post(ts.BaseURL()+"/api/whatsapp/pair", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	fake := ts.WhatsAppFake()
+	require.NotNil(t, fake)
+	fake.CompletePairing()
+
+	channel := testutil.NewChannelBuilder().
+		WithUserID(ts.TestUser.ID).
+		WhatsApp().
+		WithName("Dana").
+		MustBuild(ts.DB)
+	tomorrow := time.Now().Add(24 * time.Hour)
+	dinner := testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Dinner").
+		WithStartTime(tomorrow).
+		Pending().
+		MustBuild(ts.DB)
+	standup := testutil.NewEventBuilder(channel.ID).
+		WithUserID(ts.TestUser.ID).
+		WithTitle("Standup").
+		WithStartTime(tomorrow).
+		Pending().
+		MustBuild(ts.DB)
+	// The pending list is newest first, so Standup is 1 and Dinner is 2
+	_, err = ts.DB.Exec(`UPDATE calendar_events SET created_at = ? WHERE id = ?`, time.Now().Add(-time.Hour), dinner.ID)
+	require.NoError(t, err)
+
+	// send posts a self-chat message and waits for Alfred's reply
+	send := func(t *testing.T, text string) string {
+		t.Helper()
+		before := len(fake.SentMessages())
+		require.NoError(t, fake.SendToSelf(text))
+		var reply mockwhatsapp.SentMessage
+		require.Eventually(t, func() bool {
+			sent := fake.SentMessages()
+			if len(sent) <= before {
+				return false
+			}
+			reply = sent[before]
+			return true
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, "15551234567", reply.Phone, "replies go to the self-chat")
+		return reply.Text
+	}
+
+	status := func(t *testing.T, id int64) database.EventStatus {
+		t.Helper()
+		event, err := ts.DB.GetEventByID(id)
+		require.NoError(t, err)
+		return event.Status
+	}
+
+	t.Run("pending lists numbered events", func(t *testing.T) {
+		reply := send(t, "pending")
+		assert.Contains(t, reply, "1. Standup")
+		assert.Contains(t, reply, "2. Dinner")
+	})
+
+	t.Run("confirm by number", func(t *testing.T) {
+		reply := send(t, "confirm 2")
+		assert.Contains(t, reply, "confirmed Dinner")
+		assert.Equal(t, database.EventStatusConfirmed, status(t, dinner.ID))
+		assert.Equal(t, database.EventStatusPending, status(t, standup.ID))
+	})
+
+	t.Run("reject last", func(t *testing.T) {
+		reply := send(t, "Reject last")
+		assert.Contains(t, reply, "rejected Standup")
+		assert.Equal(t, database.EventStatusRejected, status(t, standup.ID))
+	})
+
+	t.Run("nothing left", func(t *testing.T) {
+		assert.Contains(t, send(t, "confirm 1"), "nothing is waiting")
+	})
+
+	t.Run("notes to self are ignored", func(t *testing.T) {
+		require.NoError(t, fake.SendToSelf("buy milk"))
+		time.Sleep(100 * time.Millisecond)
+		assert.Len(t, fake.SentMessages(), 4)
+	})
+}
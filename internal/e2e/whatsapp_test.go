@@ -0,0 +1,136 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent/mockagent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/testutil"
+	"github.com/omriShneor/project_alfred/internal/whatsapp/mockwhatsapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhatsAppOnboardingAndBackfill(t *testing.T) {
+	ts := testutil.NewTestServer(t,
+		testutil.WithSimulatedWhatsApp(),
+		testutil.WithAnalyzers(mockagent.NewEventAnalyzer(mockagent.DefaultRules()), nil),
+	)
+
+	getJSON := func(t *testing.T, path string, out any) {
+		t.Helper()
+		resp, err := http.Get(ts.BaseURL() + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+
+	const danaPhone = "15559876543"
+
+	t.Run("pair with phone", func(t *testing.T) {
+		var status map[string]any
+		getJSON(t, "/api/whatsapp/status", &status)
+		assert.Equal(t, false, status["connected"])
+
+		body, _ := json.Marshal(map[string]string{"phone_number": "+15551234567"})
+		resp, err := http.Post(ts.BaseURL()+"/api/whatsapp/pair", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var pair map[string]string
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&pair))
+		assert.Equal(t, mockwhatsapp.PairingCode, pair["code"])
+
+		fake := ts.WhatsAppFake()
+		require.NotNil(t, fake, "pairing creates the user's client")
+		assert.False(t, fake.IsLoggedIn(), "pairing waits for the phone")
+
+		fake.CompletePairing()
+		getJSON(t, "/api/whatsapp/status", &status)
+		assert.Equal(t, true, status["connected"])
+
+		session, err := ts.DB.GetWhatsAppSession(ts.TestUser.ID)
+		require.NoError(t, err)
+		require.NotNil(t, session)
+		assert.True(t, session.Connected)
+	})
+
+	t.Run("history sync discovers contacts", func(t *testing.T) {
+		fake := ts.WhatsAppFake()
+		fake.AddContact(danaPhone, "Dana Cohen", "Dana")
+		fake.AddContact("15550001111", "", "Avi")
+
+		sentAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+		require.NoError(t, fake.EmitHistorySync(
+			mockwhatsapp.Chat{Phone: danaPhone, Messages: []mockwhatsapp.Message{
+				{Text: "How was the trip?", Timestamp: sentAt.Add(-time.Hour)},
+				{Text: "Dinner tomorrow at Luigi's?", Timestamp: sentAt},
+			}},
+			mockwhatsapp.Chat{Phone: "15550001111", Messages: []mockwhatsapp.Message{
+				{Text: "ok", Timestamp: sentAt},
+			}},
+		))
+
+		var top struct {
+			Contacts []map[string]any `json:"contacts"`
+		}
+		getJSON(t, "/api/whatsapp/top-contacts", &top)
+		require.Len(t, top.Contacts, 2)
+		assert.Equal(t, "Dana Cohen", top.Contacts[0]["name"], "contacts are ranked by message count and named from the contact store")
+		assert.Equal(t, float64(2), top.Contacts[0]["message_count"])
+		assert.Equal(t, false, top.Contacts[0]["is_tracked"])
+
+		var search struct {
+			Contacts []map[string]any `json:"contacts"`
+		}
+		getJSON(t, "/api/whatsapp/contacts/search?query=avi", &search)
+		require.Len(t, search.Contacts, 1)
+		assert.Equal(t, "15550001111", search.Contacts[0]["identifier"])
+	})
+
+	t.Run("tracking a contact backfills its history", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]string{"type": "sender", "identifier": danaPhone, "name": "Dana"})
+		resp, err := http.Post(ts.BaseURL()+"/api/whatsapp/channel", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, "history sync already created the channel")
+
+		var channel database.SourceChannel
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&channel))
+		assert.True(t, channel.Enabled)
+
+		require.Eventually(t, func() bool {
+			progress, err := ts.DB.GetChannelBackfillProgress(ts.TestUser.ID, channel.ID)
+			return err == nil && progress != nil && progress.Status == database.BackfillStatusCompleted
+		}, 5*time.Second, 20*time.Millisecond)
+
+		events, err := ts.DB.ListEventsByChannel(ts.TestUser.ID, channel.ID)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "Dinner", events[0].Title)
+		assert.Equal(t, database.EventStatusPending, events[0].Status)
+
+		avi, err := ts.DB.GetSourceChannelByIdentifier(ts.TestUser.ID, source.SourceTypeWhatsApp, "15550001111")
+		require.NoError(t, err)
+		require.NotNil(t, avi)
+		assert.False(t, avi.Enabled, "untracked contacts aren't analyzed")
+	})
+
+	t.Run("disconnect logs out", func(t *testing.T) {
+		resp, err := http.Post(ts.BaseURL()+"/api/whatsapp/disconnect", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var status map[string]any
+		getJSON(t, "/api/whatsapp/status", &status)
+		assert.Equal(t, false, status["connected"])
+	})
+}
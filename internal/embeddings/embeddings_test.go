@@ -0,0 +1,165 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbedder maps known texts to fixed vectors
+type fakeEmbedder struct {
+	vectors map[string][]float32
+	calls   int
+}
+
+func (f *fakeEmbedder) Model() string { return "fake" }
+
+func (f *fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	f.calls++
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		v, ok := f.vectors[text]
+		if !ok {
+			return nil, fmt.Errorf("unexpected text %q", text)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+type fakeStore struct {
+	unembedded []database.UnembeddedMessage
+	saved      map[int64][]float32
+	embeddings []database.MessageEmbedding
+	messages   map[int64]database.MessageRecord
+}
+
+func (s *fakeStore) ListUnembeddedMessages(_ string, limit int) ([]database.UnembeddedMessage, error) {
+	var out []database.UnembeddedMessage
+	for _, m := range s.unembedded {
+		if _, done := s.saved[m.ID]; !done && len(out) < limit {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) SaveMessageEmbedding(m database.UnembeddedMessage, _ string, vector []float32) error {
+	s.saved[m.ID] = vector
+	return nil
+}
+
+func (s *fakeStore) ListChannelEmbeddings(_, _ int64, _ string, _ time.Time) ([]database.MessageEmbedding, error) {
+	return s.embeddings, nil
+}
+
+func (s *fakeStore) GetMessageByID(id int64) (*database.MessageRecord, error) {
+	m, ok := s.messages[id]
+	if !ok {
+		return nil, fmt.Errorf("message not found")
+	}
+	return &m, nil
+}
+
+func TestIndexOnce(t *testing.T) {
+	store := &fakeStore{
+		unembedded: []database.UnembeddedMessage{
+			{ID: 1, Text: "address"},
+			{ID: 2, Text: "  "},
+			{ID: 3, Text: "lunch"},
+		},
+		saved: map[int64][]float32{},
+	}
+	embedder := &fakeEmbedder{vectors: map[string][]float32{"address": {1, 0}, "lunch": {0, 1}}}
+
+	indexed, err := NewIndexer(store, embedder).IndexOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, indexed)
+	assert.Equal(t, 1, embedder.calls, "one request per batch")
+	assert.Equal(t, []float32{1, 0}, store.saved[1])
+	assert.Contains(t, store.saved, int64(2), "empty messages are marked so they are not listed again")
+	assert.Nil(t, store.saved[2])
+
+	indexed, err = NewIndexer(store, embedder).IndexOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, indexed)
+}
+
+func TestRelated(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeStore{
+		embeddings: []database.MessageEmbedding{
+			{MessageID: 4, Vector: []float32{0.9, 0.1}},
+			{MessageID: 3, Vector: []float32{0, 1}}, // unrelated
+			{MessageID: 2, Vector: []float32{1, 0}},
+			{MessageID: 1, Vector: []float32{1, 0.05}}, // already in history
+		},
+		messages: map[int64]database.MessageRecord{
+			1: {ID: 1, MessageText: "Herzl 10", Timestamp: base},
+			2: {ID: 2, MessageText: "The usual place is Herzl 10", Timestamp: base.Add(time.Hour)},
+			3: {ID: 3, MessageText: "lunch?", Timestamp: base.Add(2 * time.Hour)},
+			4: {ID: 4, MessageText: "Ring the bell at Herzl 10", Timestamp: base.Add(3 * time.Hour)},
+		},
+	}
+	embedder := &fakeEmbedder{vectors: map[string][]float32{"See you at the usual place": {1, 0}}}
+	retriever := NewRetriever(store, embedder, 5)
+
+	related, err := retriever.Related(context.Background(), 1, 1,
+		database.MessageRecord{ID: 10, MessageText: "See you at the usual place", Timestamp: base.Add(14 * 24 * time.Hour)},
+		map[int64]bool{1: true})
+	require.NoError(t, err)
+	require.Len(t, related, 2)
+	assert.Equal(t, int64(2), related[0].ID, "oldest first")
+	assert.Equal(t, int64(4), related[1].ID)
+
+	t.Run("limit keeps the closest matches", func(t *testing.T) {
+		related, err := NewRetriever(store, embedder, 1).Related(context.Background(), 1, 1,
+			database.MessageRecord{ID: 10, MessageText: "See you at the usual place"}, nil)
+		require.NoError(t, err)
+		require.Len(t, related, 1)
+		assert.Equal(t, int64(2), related[0].ID)
+	})
+
+	t.Run("empty message skips the embedding call", func(t *testing.T) {
+		calls := embedder.calls
+		related, err := retriever.Related(context.Background(), 1, 1, database.MessageRecord{ID: 11}, nil)
+		require.NoError(t, err)
+		assert.Empty(t, related)
+		assert.Equal(t, calls, embedder.calls)
+	})
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1.0, cosineSimilarity([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0.0, cosineSimilarity([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.Zero(t, cosineSimilarity([]float32{1, 0}, []float32{1, 0, 0}))
+	assert.Zero(t, cosineSimilarity(nil, nil))
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	var got openAIEmbeddingRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		// Out of order on purpose; vectors are placed by index
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	embedder := NewOpenAIEmbedder("test-key", "")
+	embedder.apiURL = server.URL
+
+	vectors, err := embedder.Embed(context.Background(), []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultModel, got.Model)
+	assert.Equal(t, []string{"first", "second"}, got.Input)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, vectors)
+}
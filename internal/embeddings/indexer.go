@@ -0,0 +1,122 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	defaultIndexInterval = time.Minute
+	// indexBatchSize is how many messages are embedded per API request
+	indexBatchSize = 64
+	// maxBatchesPerRun bounds one run so a large backlog is worked off across ticks
+	maxBatchesPerRun = 20
+)
+
+// IndexStore is the storage used by Indexer. Implemented by *database.DB.
+type IndexStore interface {
+	ListUnembeddedMessages(model string, limit int) ([]database.UnembeddedMessage, error)
+	SaveMessageEmbedding(message database.UnembeddedMessage, model string, vector []float32) error
+}
+
+// Indexer embeds stored messages in the background, newest first
+type Indexer struct {
+	store    IndexStore
+	embedder Embedder
+}
+
+// NewIndexer returns an Indexer that embeds messages with embedder
+func NewIndexer(store IndexStore, embedder Embedder) *Indexer {
+	return &Indexer{store: store, embedder: embedder}
+}
+
+// Start indexes immediately and then every interval until ctx is cancelled
+func (ix *Indexer) Start(ctx context.Context, interval time.Duration) {
+	if ix == nil || ix.store == nil || ix.embedder == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultIndexInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		ix.index(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ix.index(ctx)
+			}
+		}
+	}()
+}
+
+func (ix *Indexer) index(ctx context.Context) {
+	indexed, err := ix.IndexOnce(ctx)
+	if err != nil {
+		slog.Error("Embeddings: Indexing failed", "error", err)
+	}
+	if indexed > 0 {
+		slog.Info("Embeddings: Indexed messages", "indexed", indexed)
+	}
+}
+
+// IndexOnce embeds up to maxBatchesPerRun batches of unembedded messages and returns
+// how many were stored
+func (ix *Indexer) IndexOnce(ctx context.Context) (int, error) {
+	model := ix.embedder.Model()
+	indexed := 0
+	for batch := 0; batch < maxBatchesPerRun; batch++ {
+		if ctx.Err() != nil {
+			return indexed, ctx.Err()
+		}
+
+		messages, err := ix.store.ListUnembeddedMessages(model, indexBatchSize)
+		if err != nil {
+			return indexed, err
+		}
+		if len(messages) == 0 {
+			return indexed, nil
+		}
+
+		// Messages without text (media, reactions) get an empty vector so they are not
+		// listed again; it never matches a search
+		var texts []string
+		var toEmbed []database.UnembeddedMessage
+		for _, m := range messages {
+			if strings.TrimSpace(m.Text) == "" {
+				if err := ix.store.SaveMessageEmbedding(m, model, nil); err != nil {
+					return indexed, err
+				}
+				continue
+			}
+			texts = append(texts, m.Text)
+			toEmbed = append(toEmbed, m)
+		}
+		if len(toEmbed) == 0 {
+			continue
+		}
+
+		vectors, err := ix.embedder.Embed(ctx, texts)
+		if err != nil {
+			return indexed, fmt.Errorf("failed to embed messages: %w", err)
+		}
+		for i, m := range toEmbed {
+			if err := ix.store.SaveMessageEmbedding(m, model, vectors[i]); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+	}
+	return indexed, nil
+}
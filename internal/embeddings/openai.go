@@ -0,0 +1,126 @@
+// Package embeddings indexes message_history with embedding vectors and retrieves
+// earlier messages that are semantically related to a new one.
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultOpenAIEmbeddingsURL = "https://api.openai.com/v1/embeddings"
+	// DefaultModel is the embedding model used when none is configured
+	DefaultModel = "text-embedding-3-small"
+	// maxInputChars keeps a single long email well under the model's token limit
+	maxInputChars = 8000
+)
+
+// Embedder turns texts into embedding vectors
+type Embedder interface {
+	// Embed returns one vector per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model names the embedding model, so vectors from different models are never compared
+	Model() string
+}
+
+// OpenAIEmbedder calls the OpenAI embeddings API
+type OpenAIEmbedder struct {
+	apiKey     string
+	model      string
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewOpenAIEmbedder creates an embedder for the given model (DefaultModel when empty)
+func NewOpenAIEmbedder(apiKey, model string) *OpenAIEmbedder {
+	if model == "" {
+		model = DefaultModel
+	}
+	return &OpenAIEmbedder{
+		apiKey:     apiKey,
+		model:      model,
+		apiURL:     defaultOpenAIEmbeddingsURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Model returns the embedding model name
+func (e *OpenAIEmbedder) Model() string {
+	return e.model
+}
+
+type openAIEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed embeds texts in a single request
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	input := make([]string, len(texts))
+	for i, text := range texts {
+		if len(text) > maxInputChars {
+			text = text[:maxInputChars]
+		}
+		input[i] = text
+	}
+
+	reqBody, err := json.Marshal(openAIEmbeddingRequest{Model: e.model, Input: input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", e.apiURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+e.apiKey)
+
+	resp, err := e.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var parsed openAIEmbeddingResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range parsed.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("embeddings API returned unexpected index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("embeddings API returned no vector for input %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,117 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	defaultRelatedLimit = 5
+	// defaultMinSimilarity drops weak matches; text-embedding-3 cosine scores for
+	// unrelated chat messages typically sit well below it
+	defaultMinSimilarity = 0.35
+)
+
+// RetrieverStore is the storage used by Retriever. Implemented by *database.DB.
+type RetrieverStore interface {
+	ListChannelEmbeddings(userID, channelID int64, model string, before time.Time) ([]database.MessageEmbedding, error)
+	GetMessageByID(id int64) (*database.MessageRecord, error)
+}
+
+// Retriever finds earlier messages in a channel that are semantically related to a
+// new one, beyond the recent history window the agents already see
+type Retriever struct {
+	store         RetrieverStore
+	embedder      Embedder
+	limit         int
+	minSimilarity float64
+}
+
+// NewRetriever returns a Retriever that returns at most limit messages
+// (defaultRelatedLimit when limit <= 0)
+func NewRetriever(store RetrieverStore, embedder Embedder, limit int) *Retriever {
+	if limit <= 0 {
+		limit = defaultRelatedLimit
+	}
+	return &Retriever{store: store, embedder: embedder, limit: limit, minSimilarity: defaultMinSimilarity}
+}
+
+// Related returns the channel's earlier messages most similar to message, oldest first.
+// Messages in exclude (typically the history already in the prompt) are skipped.
+func (r *Retriever) Related(ctx context.Context, userID, channelID int64, message database.MessageRecord, exclude map[int64]bool) ([]database.MessageRecord, error) {
+	text := strings.TrimSpace(message.MessageText)
+	if message.Subject != "" {
+		text = message.Subject + "\n" + text
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+
+	candidates, err := r.store.ListChannelEmbeddings(userID, channelID, r.embedder.Model(), message.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	vectors, err := r.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed message: %w", err)
+	}
+	query := vectors[0]
+
+	type scored struct {
+		id    int64
+		score float64
+	}
+	var matches []scored
+	for _, c := range candidates {
+		if c.MessageID == message.ID || exclude[c.MessageID] {
+			continue
+		}
+		if score := cosineSimilarity(query, c.Vector); score >= r.minSimilarity {
+			matches = append(matches, scored{id: c.MessageID, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > r.limit {
+		matches = matches[:r.limit]
+	}
+
+	related := make([]database.MessageRecord, 0, len(matches))
+	for _, m := range matches {
+		record, err := r.store.GetMessageByID(m.id)
+		if err != nil {
+			// Pruned since it was listed
+			continue
+		}
+		related = append(related, *record)
+	}
+	sort.SliceStable(related, func(i, j int) bool { return related[i].Timestamp.Before(related[j].Timestamp) })
+	return related, nil
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 when they
+// differ in length or either is empty
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/notify"
+)
+
+// ArchiveTTL is how long a finished export can be downloaded
+const ArchiveTTL = 7 * 24 * time.Hour
+
+// manifest is written to the archive as manifest.json
+type manifest struct {
+	UserID     int64          `json:"user_id"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Sections   map[string]int `json:"sections"` // section name → record count
+}
+
+// Exporter builds account data exports in the background
+type Exporter struct {
+	db     *database.DB
+	notify *notify.Service
+}
+
+// NewExporter creates an exporter. notifyService may be nil, in which case progress
+// is only recorded in the database.
+func NewExporter(db *database.DB, notifyService *notify.Service) *Exporter {
+	return &Exporter{db: db, notify: notifyService}
+}
+
+// Start queues an export for the user and builds it asynchronously
+func (e *Exporter) Start(userID int64) (*database.DataExport, error) {
+	if _, err := e.db.DeleteExpiredDataExports(time.Now()); err != nil {
+		slog.Error("Export: Failed to delete expired exports", "error", err)
+	}
+
+	export, err := e.db.CreateDataExport(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	go e.run(context.Background(), export.ID, userID)
+	return export, nil
+}
+
+func (e *Exporter) run(ctx context.Context, exportID, userID int64) {
+	archive, err := e.Build(userID, func(progress int) {
+		if err := e.db.UpdateDataExportProgress(exportID, progress); err != nil {
+			slog.Error("Export: Failed to record progress for export", "export_id", exportID, "error", err)
+			return
+		}
+		e.report(ctx, exportID)
+	})
+
+	if err != nil {
+		slog.Error("Export: Export failed", "export_id", exportID, "user_id", userID, "error", err)
+		if err := e.db.FailDataExport(exportID, err.Error()); err != nil {
+			slog.Error("Export: Failed to mark export failed", "export_id", exportID, "error", err)
+		}
+	} else if err := e.db.CompleteDataExport(exportID, archive, time.Now().Add(ArchiveTTL)); err != nil {
+		slog.Error("Export: Failed to store export", "export_id", exportID, "error", err)
+		if err := e.db.FailDataExport(exportID, "failed to store archive"); err != nil {
+			slog.Error("Export: Failed to mark export failed", "export_id", exportID, "error", err)
+		}
+	}
+	e.report(ctx, exportID)
+}
+
+// report sends the export's current state through the notification service
+func (e *Exporter) report(ctx context.Context, exportID int64) {
+	if e.notify == nil {
+		return
+	}
+	export, err := e.db.GetDataExportByID(exportID)
+	if err != nil {
+		slog.Error("Export: Failed to load export", "export_id", exportID, "error", err)
+		return
+	}
+	e.notify.NotifyDataExport(ctx, export)
+}
+
+// Build collects every section of the user's data into a ZIP archive with one JSON file
+// per section plus a manifest. onProgress is called with 0-99 after each section.
+func (e *Exporter) Build(userID int64, onProgress func(progress int)) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	meta := manifest{
+		UserID:     userID,
+		ExportedAt: time.Now().UTC(),
+		Sections:   make(map[string]int, len(database.UserDataSections)),
+	}
+
+	for i, section := range database.UserDataSections {
+		records, err := e.db.ExportUserDataSection(section, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeJSON(zw, section.Name+".json", records); err != nil {
+			return nil, err
+		}
+		meta.Sections[section.Name] = len(records)
+
+		if onProgress != nil {
+			onProgress((i + 1) * 99 / len(database.UserDataSections))
+		}
+	}
+
+	if err := writeJSON(zw, "manifest.json", meta); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
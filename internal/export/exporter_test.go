@@ -0,0 +1,106 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readArchive(t *testing.T, archive []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = data
+	}
+	return files
+}
+
+func TestBuild(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	other := database.CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "123@s.whatsapp.net", "Mom")
+	require.NoError(t, err)
+	_, err = db.CreateSourceChannel(other.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "456@s.whatsapp.net", "Not mine")
+	require.NoError(t, err)
+	_, err = db.CreateWebhook(user.ID, "https://example.com/hook", "whsec_secret", []string{"event.created"})
+	require.NoError(t, err)
+
+	var progress []int
+	archive, err := NewExporter(db, nil).Build(user.ID, func(p int) { progress = append(progress, p) })
+	require.NoError(t, err)
+
+	files := readArchive(t, archive)
+	for _, section := range database.UserDataSections {
+		assert.Contains(t, files, section.Name+".json")
+	}
+	require.Contains(t, files, "manifest.json")
+	assert.Len(t, progress, len(database.UserDataSections))
+	assert.Equal(t, 99, progress[len(progress)-1])
+
+	var channels []map[string]any
+	require.NoError(t, json.Unmarshal(files["channels.json"], &channels))
+	require.Len(t, channels, 1)
+	assert.Equal(t, float64(channel.ID), channels[0]["id"])
+	assert.Equal(t, "Mom", channels[0]["name"])
+
+	var profile []map[string]any
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	require.Len(t, profile, 1)
+	assert.Equal(t, user.Email, profile[0]["email"])
+
+	// Credentials never leave the server
+	assert.NotContains(t, string(files["webhooks.json"]), "whsec_secret")
+	assert.Contains(t, string(files["webhooks.json"]), "https://example.com/hook")
+
+	var meta manifest
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &meta))
+	assert.Equal(t, user.ID, meta.UserID)
+	assert.Equal(t, 1, meta.Sections["channels"])
+}
+
+func TestStart(t *testing.T) {
+	// The export runs in a goroutine while the test polls, so both need a connection at
+	// once. Each connection to ":memory:" is a separate empty database; use a file.
+	db, err := database.New(filepath.Join(t.TempDir(), "alfred.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	user := database.CreateTestUser(t, db)
+
+	export, err := NewExporter(db, nil).Start(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.DataExportPending, export.Status)
+
+	require.Eventually(t, func() bool {
+		current, err := db.GetDataExportByID(export.ID)
+		return err == nil && current.Status == database.DataExportCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+
+	completed, err := db.GetDataExportByID(export.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 100, completed.Progress)
+	require.NotNil(t, completed.ExpiresAt)
+	assert.True(t, completed.ExpiresAt.After(time.Now().Add(ArchiveTTL-time.Hour)))
+
+	archive, err := db.GetDataExportArchive(export.ID)
+	require.NoError(t, err)
+	assert.Equal(t, completed.SizeBytes, int64(len(archive)))
+	assert.Contains(t, readArchive(t, archive), "manifest.json")
+}
@@ -3,6 +3,7 @@ package gcal
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/omriShneor/project_alfred/internal/database"
 	"golang.org/x/oauth2"
@@ -38,7 +39,7 @@ func (c *Client) tryInitService() error {
 		c.token = newToken
 		// Save refreshed token (database for multi-user, file for single-user)
 		if err := c.saveToken(newToken); err != nil {
-			fmt.Printf("Warning: could not save refreshed token: %v\n", err)
+			slog.Warn("could not save refreshed token", "error", err)
 		}
 	}
 
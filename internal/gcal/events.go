@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 
 	"google.golang.org/api/calendar/v3"
@@ -12,6 +14,16 @@ import (
 
 var ErrEventNotFound = errors.New("google calendar event not found")
 
+const (
+	// maxEventsPerPage is the largest page the Calendar API returns, so most ranges
+	// need a single request
+	maxEventsPerPage = 2500
+	// rangeFetchWindow and rangeFetchParallel split long ranges in
+	// ListEventsInRangeBatched
+	rangeFetchWindow   = 14 * 24 * time.Hour
+	rangeFetchParallel = 4
+)
+
 // IsEventNotFound returns true when a Google Calendar event no longer exists.
 func IsEventNotFound(err error) bool {
 	return errors.Is(err, ErrEventNotFound)
@@ -25,6 +37,18 @@ type EventInput struct {
 	StartTime   time.Time
 	EndTime     time.Time
 	Attendees   []string // Email addresses of attendees
+	Recurrence  []string // RRULE lines for repeating events (e.g., "RRULE:FREQ=YEARLY")
+	TimeZone    string   // IANA zone the recurrence expands in; required by Google for repeating events
+	// SkipAttendeeEmails stops Google emailing attendees, for when Alfred sends its own invites
+	SkipAttendeeEmails bool
+}
+
+// sendUpdates is the Google sendUpdates value for input's attendee notifications
+func (input EventInput) sendUpdates() string {
+	if input.SkipAttendeeEmails {
+		return "none"
+	}
+	return "all"
 }
 
 // EventDetails represents a single Google Calendar event.
@@ -81,6 +105,21 @@ func parseGoogleEventTimes(item *calendar.Event, loc *time.Location) (time.Time,
 	return startTime, endTime, false, nil
 }
 
+// applyRecurrence makes the event repeat. Google expands recurring events in the
+// start/end time zone, which must then be named explicitly (UTC if unknown).
+func applyRecurrence(event *calendar.Event, input EventInput) {
+	if len(input.Recurrence) == 0 {
+		return
+	}
+	timeZone := input.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	event.Recurrence = input.Recurrence
+	event.Start.TimeZone = timeZone
+	event.End.TimeZone = timeZone
+}
+
 // CreateEvent creates a new event in Google Calendar and returns the event ID
 func (c *Client) CreateEvent(calendarID string, input EventInput) (string, error) {
 	if c.service == nil {
@@ -104,6 +143,8 @@ func (c *Client) CreateEvent(calendarID string, input EventInput) (string, error
 		},
 	}
 
+	applyRecurrence(event, input)
+
 	// Add attendees if provided
 	if len(input.Attendees) > 0 {
 		attendees := make([]*calendar.EventAttendee, len(input.Attendees))
@@ -114,7 +155,7 @@ func (c *Client) CreateEvent(calendarID string, input EventInput) (string, error
 	}
 
 	// SendUpdates sends notifications to attendees
-	created, err := c.service.Events.Insert(calendarID, event).SendUpdates("all").Do()
+	created, err := c.service.Events.Insert(calendarID, event).SendUpdates(input.sendUpdates()).Do()
 	if err != nil {
 		return "", fmt.Errorf("failed to create event: %w", err)
 	}
@@ -144,6 +185,8 @@ func (c *Client) UpdateEvent(calendarID, eventID string, input EventInput) error
 		},
 	}
 
+	applyRecurrence(event, input)
+
 	// Add attendees if provided
 	if len(input.Attendees) > 0 {
 		attendees := make([]*calendar.EventAttendee, len(input.Attendees))
@@ -153,7 +196,7 @@ func (c *Client) UpdateEvent(calendarID, eventID string, input EventInput) error
 		event.Attendees = attendees
 	}
 
-	_, err := c.service.Events.Update(calendarID, eventID, event).SendUpdates("all").Do()
+	_, err := c.service.Events.Update(calendarID, eventID, event).SendUpdates(input.sendUpdates()).Do()
 	if err != nil {
 		return fmt.Errorf("failed to update event: %w", err)
 	}
@@ -257,7 +300,8 @@ func (c *Client) ListEventsInRange(calendarID string, timeMin, timeMax time.Time
 			TimeMax(timeMax.Format(time.RFC3339)).
 			SingleEvents(true).
 			ShowDeleted(false).
-			OrderBy("startTime")
+			OrderBy("startTime").
+			MaxResults(maxEventsPerPage)
 		if pageToken != "" {
 			call = call.PageToken(pageToken)
 		}
@@ -311,6 +355,60 @@ func (c *Client) ListEventsInRange(calendarID string, timeMin, timeMax time.Time
 	return result, nil
 }
 
+// ListEventsInRangeBatched lists events like ListEventsInRange, fetching ranges longer
+// than two weeks as two-week windows fetched in parallel, so month views take about as
+// long as a week.
+// Events spanning a window boundary are returned once; the result is ordered by start.
+func (c *Client) ListEventsInRangeBatched(calendarID string, timeMin, timeMax time.Time) ([]EventDetails, error) {
+	if timeMax.Sub(timeMin) <= rangeFetchWindow {
+		return c.ListEventsInRange(calendarID, timeMin, timeMax)
+	}
+
+	type window struct{ start, end time.Time }
+	var windows []window
+	for start := timeMin; start.Before(timeMax); start = start.Add(rangeFetchWindow) {
+		end := start.Add(rangeFetchWindow)
+		if end.After(timeMax) {
+			end = timeMax
+		}
+		windows = append(windows, window{start, end})
+	}
+
+	results := make([][]EventDetails, len(windows))
+	errs := make([]error, len(windows))
+	sem := make(chan struct{}, rangeFetchParallel)
+	var wg sync.WaitGroup
+	for i, w := range windows {
+		wg.Add(1)
+		go func(i int, w window) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = c.ListEventsInRange(calendarID, w.start, w.end)
+		}(i, w)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	var merged []EventDetails
+	for i := range windows {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		for _, event := range results[i] {
+			if seen[event.ID] {
+				continue
+			}
+			seen[event.ID] = true
+			merged = append(merged, event)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].StartTime.Before(merged[j].StartTime)
+	})
+	return merged, nil
+}
+
 // TodayEvent represents a calendar event for today's schedule display
 type TodayEvent struct {
 	ID          string    `json:"id"`
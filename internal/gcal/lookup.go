@@ -0,0 +1,43 @@
+package gcal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// EventLookup lists users' Google Calendar events for read-only lookups.
+// Each call builds a client from the user's stored token.
+type EventLookup struct {
+	credentialsFile string
+	db              *database.DB
+}
+
+// NewEventLookup creates an EventLookup using the OAuth credentials in credentialsFile
+func NewEventLookup(credentialsFile string, db *database.DB) *EventLookup {
+	return &EventLookup{credentialsFile: credentialsFile, db: db}
+}
+
+// ListUserEvents returns the user's events between start and end on their selected
+// calendar, falling back to primary if it is no longer accessible
+func (l *EventLookup) ListUserEvents(userID int64, start, end time.Time) ([]EventDetails, error) {
+	client, err := NewClientForUser(userID, l.credentialsFile, l.db)
+	if err != nil {
+		return nil, err
+	}
+	if !client.IsAuthenticated() {
+		return nil, fmt.Errorf("google calendar not connected")
+	}
+
+	calendarID := "primary"
+	if settings, err := l.db.GetGCalSettings(userID); err == nil && settings.SelectedCalendarID != "" {
+		calendarID = settings.SelectedCalendarID
+	}
+
+	events, err := client.ListEventsInRange(calendarID, start, end)
+	if err != nil && calendarID != "primary" {
+		events, err = client.ListEventsInRange("primary", start, end)
+	}
+	return events, err
+}
@@ -3,6 +3,7 @@ package gcal
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"slices"
 	"strings"
 	"sync"
@@ -35,6 +36,8 @@ type Worker struct {
 	db           SyncDBInterface
 	userID       int64
 	pollInterval time.Duration
+	onSync       func(userID int64)
+	onChange     func(event *database.CalendarEvent, deleted bool)
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -46,6 +49,11 @@ type Worker struct {
 type WorkerConfig struct {
 	UserID              int64
 	PollIntervalMinutes int
+	// OnSyncComplete is called after each sync cycle that reached Google Calendar.
+	OnSyncComplete func(userID int64)
+	// OnEventChanged is called after a synced event Alfred detected was edited or deleted
+	// in Google Calendar and its row updated. Imported Google events don't report changes.
+	OnEventChanged func(event *database.CalendarEvent, deleted bool)
 }
 
 // NewWorker creates a new Google Calendar sync worker.
@@ -62,6 +70,8 @@ func NewWorker(client *Client, db SyncDBInterface, config WorkerConfig) *Worker
 		db:           db,
 		userID:       config.UserID,
 		pollInterval: pollInterval,
+		onSync:       config.OnSyncComplete,
+		onChange:     config.OnEventChanged,
 		ctx:          ctx,
 		cancel:       cancel,
 	}
@@ -70,10 +80,10 @@ func NewWorker(client *Client, db SyncDBInterface, config WorkerConfig) *Worker
 // Start begins the periodic Google Calendar sync loop.
 func (w *Worker) Start() error {
 	if w.client == nil || !w.client.IsAuthenticated() {
-		fmt.Println("Google Calendar worker: client not authenticated, will poll when authenticated")
+		slog.Info("Google Calendar worker: client not authenticated, will poll when authenticated")
 	}
 
-	fmt.Printf("Google Calendar worker: starting with %v poll interval\n", w.pollInterval)
+	slog.Info("Google Calendar worker: starting poll interval", "poll_interval", w.pollInterval)
 
 	w.wg.Add(1)
 	go w.pollLoop()
@@ -83,7 +93,7 @@ func (w *Worker) Start() error {
 
 // Stop gracefully shuts down the worker.
 func (w *Worker) Stop() {
-	fmt.Println("Google Calendar worker: stopping...")
+	slog.Info("Google Calendar worker: stopping...")
 	w.cancel()
 
 	done := make(chan struct{})
@@ -94,9 +104,9 @@ func (w *Worker) Stop() {
 
 	select {
 	case <-done:
-		fmt.Println("Google Calendar worker: stopped")
+		slog.Info("Google Calendar worker: stopped")
 	case <-time.After(stopWaitTimeout):
-		fmt.Printf("Google Calendar worker: stop timed out after %v; continuing shutdown\n", stopWaitTimeout)
+		slog.Error("Google Calendar worker: stop timed out, continuing shutdown", "timeout", stopWaitTimeout)
 	}
 }
 
@@ -152,7 +162,7 @@ func (w *Worker) poll() {
 
 	settings, err := w.db.GetGCalSettings(w.userID)
 	if err != nil {
-		fmt.Printf("Google Calendar worker: failed to get settings: %v\n", err)
+		slog.Error("Google Calendar worker: failed to get settings", "error", err)
 		return
 	}
 	if settings == nil || !settings.SyncEnabled {
@@ -161,13 +171,24 @@ func (w *Worker) poll() {
 
 	events, err := w.db.ListSyncedEventsWithGoogleID(w.userID)
 	if err != nil {
-		fmt.Printf("Google Calendar worker: failed to list synced events: %v\n", err)
+		slog.Error("Google Calendar worker: failed to list synced events", "error", err)
 		return
 	}
 	if len(events) == 0 {
 		events = []database.CalendarEvent{}
 	}
 
+	// Changes to imported Google events are the user's own; only detected events report them
+	var importChannelID int64
+	if importChannel, err := w.db.EnsureGoogleCalendarImportChannel(w.userID); err == nil && importChannel != nil {
+		importChannelID = importChannel.ID
+	}
+	reportChange := func(event database.CalendarEvent, deleted bool) {
+		if w.onChange != nil && event.ChannelID != importChannelID {
+			w.onChange(&event, deleted)
+		}
+	}
+
 	linkedGoogleIDs := make(map[string]struct{}, len(events))
 	for _, event := range events {
 		if event.GoogleEventID != nil && *event.GoogleEventID != "" {
@@ -213,13 +234,16 @@ func (w *Worker) poll() {
 			if IsEventNotFound(err) {
 				if event.Status != database.EventStatusDeleted {
 					if updateErr := w.db.UpdateEventStatus(event.ID, database.EventStatusDeleted); updateErr != nil {
-						fmt.Printf("Google Calendar worker: failed to mark event %d as deleted: %v\n", event.ID, updateErr)
+						slog.Error("Google Calendar worker: failed to mark event as deleted", "event_id", event.ID, "error", updateErr)
+					} else {
+						event.Status = database.EventStatusDeleted
+						reportChange(event, true)
 					}
 				}
 				continue
 			}
 
-			fmt.Printf("Google Calendar worker: failed to fetch google event %s: %v\n", *event.GoogleEventID, err)
+			slog.Error("Google Calendar worker: failed to fetch google event", "google_event_id", *event.GoogleEventID, "error", err)
 			continue
 		}
 
@@ -232,9 +256,15 @@ func (w *Worker) poll() {
 				googleEvent.EndTime,
 				googleEvent.Location,
 			); updateErr != nil {
-				fmt.Printf("Google Calendar worker: failed to update event %d from Google: %v\n", event.ID, updateErr)
+				slog.Error("Google Calendar worker: failed to update event from Google", "event_id", event.ID, "error", updateErr)
 				continue
 			}
+			event.Title = googleEvent.Summary
+			event.Description = googleEvent.Description
+			event.StartTime = googleEvent.StartTime
+			event.EndTime = googleEvent.EndTime
+			event.Location = googleEvent.Location
+			reportChange(event, false)
 		}
 
 		googleAttendees := make([]database.Attendee, 0, len(googleEvent.Attendees))
@@ -253,12 +283,16 @@ func (w *Worker) poll() {
 
 		if attendeesChanged(event.Attendees, googleAttendees) {
 			if attendeeErr := w.db.SetEventAttendees(event.ID, googleAttendees); attendeeErr != nil {
-				fmt.Printf("Google Calendar worker: failed to sync attendees for event %d: %v\n", event.ID, attendeeErr)
+				slog.Error("Google Calendar worker: failed to sync attendees", "event_id", event.ID, "error", attendeeErr)
 			}
 		}
 	}
 
 	w.importMissingGoogleEvents(client, settings, linkedGoogleIDs)
+
+	if w.onSync != nil {
+		w.onSync(w.userID)
+	}
 }
 
 func (w *Worker) importMissingGoogleEvents(client *Client, settings *database.GCalSettings, linkedGoogleIDs map[string]struct{}) {
@@ -268,7 +302,7 @@ func (w *Worker) importMissingGoogleEvents(client *Client, settings *database.GC
 
 	importChannel, err := w.db.EnsureGoogleCalendarImportChannel(w.userID)
 	if err != nil || importChannel == nil {
-		fmt.Printf("Google Calendar worker: failed to ensure import channel: %v\n", err)
+		slog.Error("Google Calendar worker: failed to ensure import channel", "error", err)
 		return
 	}
 
@@ -287,7 +321,7 @@ func (w *Worker) importMissingGoogleEvents(client *Client, settings *database.GC
 		targetCalendarID = "primary"
 	}
 	if err != nil {
-		fmt.Printf("Google Calendar worker: failed to list remote events for import: %v\n", err)
+		slog.Error("Google Calendar worker: failed to list remote events for import", "error", err)
 		return
 	}
 
@@ -301,7 +335,7 @@ func (w *Worker) importMissingGoogleEvents(client *Client, settings *database.GC
 
 		existing, err := w.db.GetEventByGoogleIDForUser(w.userID, remoteEvent.ID)
 		if err != nil {
-			fmt.Printf("Google Calendar worker: failed to lookup event by google id %s: %v\n", remoteEvent.ID, err)
+			slog.Error("Google Calendar worker: failed to lookup event by google", "remote_event_id", remoteEvent.ID, "error", err)
 			continue
 		}
 		if existing != nil {
@@ -336,12 +370,12 @@ func (w *Worker) importMissingGoogleEvents(client *Client, settings *database.GC
 			ActionType:    database.EventActionCreate,
 		})
 		if createErr != nil {
-			fmt.Printf("Google Calendar worker: failed to import new event %s: %v\n", remoteEvent.ID, createErr)
+			slog.Error("Google Calendar worker: failed to import new event", "remote_event_id", remoteEvent.ID, "error", createErr)
 			continue
 		}
 
 		if statusErr := w.db.UpdateEventStatus(importedEvent.ID, database.EventStatusSynced); statusErr != nil {
-			fmt.Printf("Google Calendar worker: failed to mark imported event %d as synced: %v\n", importedEvent.ID, statusErr)
+			slog.Error("Google Calendar worker: failed to mark imported event as synced", "imported_event_id", importedEvent.ID, "error", statusErr)
 			continue
 		}
 
@@ -361,7 +395,7 @@ func (w *Worker) importMissingGoogleEvents(client *Client, settings *database.GC
 
 		if len(attendees) > 0 {
 			if attendeeErr := w.db.SetEventAttendees(importedEvent.ID, attendees); attendeeErr != nil {
-				fmt.Printf("Google Calendar worker: failed to set attendees for imported event %d: %v\n", importedEvent.ID, attendeeErr)
+				slog.Error("Google Calendar worker: failed to set attendees for imported event", "imported_event_id", importedEvent.ID, "error", attendeeErr)
 			}
 		}
 
@@ -0,0 +1,53 @@
+// Package geocode resolves event location strings to coordinates and map links, so the
+// app can offer a "navigate" button and later estimate travel time.
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Result is the best match for a location
+type Result struct {
+	Latitude  float64
+	Longitude float64
+	// PlaceID is the provider's place identifier, when it has one (Google)
+	PlaceID string
+	// DisplayName is the provider's full name for the place
+	DisplayName string
+}
+
+// Geocoder resolves a free-form location ("Luigi's, Dizengoff 99 Tel Aviv")
+type Geocoder interface {
+	// Geocode returns the best match, or nil when the location matches no place
+	Geocode(ctx context.Context, location string) (*Result, error)
+}
+
+// MapsURL returns a Google Maps link to the result. It opens the maps app on phones, and
+// the place ID, when known, selects the business rather than just the coordinates.
+func (r *Result) MapsURL() string {
+	query := url.Values{}
+	query.Set("api", "1")
+	query.Set("query", strconv.FormatFloat(r.Latitude, 'f', -1, 64)+","+strconv.FormatFloat(r.Longitude, 'f', -1, 64))
+	if r.PlaceID != "" {
+		query.Set("query_place_id", r.PlaceID)
+	}
+	return "https://www.google.com/maps/search/?" + query.Encode()
+}
+
+// New returns the geocoder for provider ("nominatim" or "google"). baseURL overrides the
+// provider's API (e.g. a self-hosted Nominatim); apiKey is required for Google.
+func New(provider, baseURL, apiKey string) (Geocoder, error) {
+	switch provider {
+	case "nominatim":
+		return NewNominatim(baseURL), nil
+	case "google":
+		if apiKey == "" {
+			return nil, fmt.Errorf("google geocoding needs an API key")
+		}
+		return NewGoogle(apiKey, baseURL), nil
+	}
+	return nil, fmt.Errorf("unknown geocoder %q", provider)
+}
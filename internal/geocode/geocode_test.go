@@ -0,0 +1,145 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNominatim(t *testing.T) {
+	var userAgent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		assert.Equal(t, "/search", r.URL.Path)
+		assert.Equal(t, "jsonv2", r.URL.Query().Get("format"))
+		if r.URL.Query().Get("q") == "nowhere" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"lat":"32.0853","lon":"34.7818","display_name":"Dizengoff 99, Tel Aviv"}]`))
+	}))
+	defer srv.Close()
+
+	n := NewNominatim(srv.URL)
+	n.interval = 0
+	result, err := n.Geocode(context.Background(), "Dizengoff 99 Tel Aviv")
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, 32.0853, result.Latitude)
+	assert.Equal(t, 34.7818, result.Longitude)
+	assert.Equal(t, "Dizengoff 99, Tel Aviv", result.DisplayName)
+	assert.Contains(t, userAgent, "Alfred", "the usage policy requires an identifying user agent")
+
+	result, err = n.Geocode(context.Background(), "nowhere")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestGoogle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.URL.Query().Get("key"))
+		switch r.URL.Query().Get("address") {
+		case "nowhere":
+			w.Write([]byte(`{"status":"ZERO_RESULTS","results":[]}`))
+		case "denied":
+			w.Write([]byte(`{"status":"REQUEST_DENIED","error_message":"API key invalid"}`))
+		default:
+			w.Write([]byte(`{"status":"OK","results":[{"formatted_address":"Dizengoff St 99, Tel Aviv","place_id":"ChIJ123",
+				"geometry":{"location":{"lat":32.0853,"lng":34.7818}}}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	g := NewGoogle("test-key", srv.URL)
+	result, err := g.Geocode(context.Background(), "Dizengoff 99 Tel Aviv")
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, "ChIJ123", result.PlaceID)
+	assert.Equal(t, 34.7818, result.Longitude)
+	assert.Equal(t, "https://www.google.com/maps/search/?api=1&query=32.0853%2C34.7818&query_place_id=ChIJ123", result.MapsURL())
+
+	result, err = g.Geocode(context.Background(), "nowhere")
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	_, err = g.Geocode(context.Background(), "denied")
+	assert.ErrorContains(t, err, "API key invalid")
+}
+
+func TestNew(t *testing.T) {
+	g, err := New("nominatim", "", "")
+	require.NoError(t, err)
+	assert.IsType(t, &Nominatim{}, g)
+
+	_, err = New("google", "", "")
+	assert.Error(t, err, "google needs an API key")
+
+	_, err = New("bing", "", "")
+	assert.Error(t, err)
+}
+
+type fakeGeocoder struct {
+	results map[string]*Result
+	calls   map[string]int
+	err     error
+}
+
+func (f *fakeGeocoder) Geocode(_ context.Context, location string) (*Result, error) {
+	f.calls[location]++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.results[location], nil
+}
+
+type fakeStore struct {
+	events []database.EventToGeocode
+	saved  map[int64]*database.EventGeocode
+}
+
+func (f *fakeStore) ListEventsToGeocode(limit int) ([]database.EventToGeocode, error) {
+	return f.events, nil
+}
+
+func (f *fakeStore) SaveEventGeocode(eventID int64, _ string, geo *database.EventGeocode) error {
+	f.saved[eventID] = geo
+	return nil
+}
+
+func TestWorkerGeocodeOnce(t *testing.T) {
+	store := &fakeStore{
+		events: []database.EventToGeocode{
+			{ID: 3, Location: "the office"},
+			{ID: 2, Location: "Dana's place"},
+			{ID: 1, Location: "the office"},
+		},
+		saved: make(map[int64]*database.EventGeocode),
+	}
+	geocoder := &fakeGeocoder{
+		results: map[string]*Result{"the office": {Latitude: 32.1, Longitude: 34.8}},
+		calls:   make(map[string]int),
+	}
+
+	geocoded, err := NewWorker(store, geocoder).GeocodeOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, geocoded)
+	assert.Equal(t, 1, geocoder.calls["the office"], "a shared location is looked up once")
+
+	require.NotNil(t, store.saved[1])
+	assert.Equal(t, 32.1, store.saved[1].Latitude)
+	assert.Contains(t, store.saved[3].MapsURL, "query=32.1%2C34.8")
+	assert.Contains(t, store.saved, int64(2))
+	assert.Nil(t, store.saved[2], "an unmatched location is saved without coordinates")
+
+	failing := &fakeStore{events: store.events, saved: make(map[int64]*database.EventGeocode)}
+	geocoded, err = NewWorker(failing, &fakeGeocoder{err: errors.New("rate limited"), calls: make(map[string]int)}).GeocodeOnce(context.Background())
+	assert.Error(t, err)
+	assert.Zero(t, geocoded)
+	assert.Empty(t, failing.saved, "failed lookups are retried next run")
+}
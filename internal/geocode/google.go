@@ -0,0 +1,95 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const defaultGoogleGeocodeURL = "https://maps.googleapis.com/maps/api/geocode/json"
+
+// Google geocodes with the Google Maps Geocoding API
+type Google struct {
+	apiKey     string
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewGoogle creates a Google geocoder (the public API when apiURL is empty)
+func NewGoogle(apiKey, apiURL string) *Google {
+	if apiURL == "" {
+		apiURL = defaultGoogleGeocodeURL
+	}
+	return &Google{
+		apiKey:     apiKey,
+		apiURL:     apiURL,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+type googleGeocodeResponse struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+	Results      []struct {
+		FormattedAddress string `json:"formatted_address"`
+		PlaceID          string `json:"place_id"`
+		Geometry         struct {
+			Location struct {
+				Lat float64 `json:"lat"`
+				Lng float64 `json:"lng"`
+			} `json:"location"`
+		} `json:"geometry"`
+	} `json:"results"`
+}
+
+// Geocode returns the best match for location, or nil when there is none
+func (g *Google) Geocode(ctx context.Context, location string) (*Result, error) {
+	query := url.Values{}
+	query.Set("address", location)
+	query.Set("key", g.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", g.apiURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocoding API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var parsed googleGeocodeResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	switch parsed.Status {
+	case "OK":
+	case "ZERO_RESULTS":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("geocoding API error: %s %s", parsed.Status, parsed.ErrorMessage)
+	}
+	if len(parsed.Results) == 0 {
+		return nil, nil
+	}
+
+	best := parsed.Results[0]
+	return &Result{
+		Latitude:    best.Geometry.Location.Lat,
+		Longitude:   best.Geometry.Location.Lng,
+		PlaceID:     best.PlaceID,
+		DisplayName: best.FormattedAddress,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNominatimURL = "https://nominatim.openstreetmap.org"
+	// nominatimUserAgent identifies the app, as the Nominatim usage policy requires
+	nominatimUserAgent = "Alfred/1.0 (https://github.com/omriShneor/project_alfred)"
+	// nominatimInterval keeps to the public server's limit of one request per second
+	nominatimInterval = time.Second
+)
+
+// Nominatim geocodes with OpenStreetMap's Nominatim API. Requests are spaced
+// nominatimInterval apart.
+type Nominatim struct {
+	baseURL    string
+	httpClient *http.Client
+	interval   time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewNominatim creates a Nominatim geocoder (the public server when baseURL is empty)
+func NewNominatim(baseURL string) *Nominatim {
+	if baseURL == "" {
+		baseURL = defaultNominatimURL
+	}
+	return &Nominatim{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		interval:   nominatimInterval,
+	}
+}
+
+type nominatimPlace struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	DisplayName string `json:"display_name"`
+}
+
+// Geocode returns the best match for location, or nil when there is none
+func (n *Nominatim) Geocode(ctx context.Context, location string) (*Result, error) {
+	if err := n.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("q", location)
+	query.Set("format", "jsonv2")
+	query.Set("limit", "1")
+	req, err := http.NewRequestWithContext(ctx, "GET", n.baseURL+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", nominatimUserAgent)
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var places []nominatimPlace
+	if err := json.Unmarshal(body, &places); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(places) == 0 {
+		return nil, nil
+	}
+
+	lat, err := strconv.ParseFloat(places[0].Lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("nominatim returned invalid latitude %q", places[0].Lat)
+	}
+	lng, err := strconv.ParseFloat(places[0].Lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("nominatim returned invalid longitude %q", places[0].Lon)
+	}
+	return &Result{Latitude: lat, Longitude: lng, DisplayName: places[0].DisplayName}, nil
+}
+
+// wait blocks until the interval has passed since the previous request
+func (n *Nominatim) wait(ctx context.Context) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if delay := time.Until(n.last.Add(n.interval)); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	n.last = time.Now()
+	return nil
+}
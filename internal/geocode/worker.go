@@ -0,0 +1,111 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	defaultGeocodeInterval = time.Minute
+	// maxEventsPerRun bounds one run; at Nominatim's one request per second a backlog
+	// is worked off across ticks
+	maxEventsPerRun = 30
+)
+
+// Store is the storage used by Worker. Implemented by *database.DB.
+type Store interface {
+	ListEventsToGeocode(limit int) ([]database.EventToGeocode, error)
+	SaveEventGeocode(eventID int64, location string, geo *database.EventGeocode) error
+}
+
+// Worker geocodes the locations of new and edited events in the background
+type Worker struct {
+	store    Store
+	geocoder Geocoder
+}
+
+// NewWorker returns a Worker that resolves locations with geocoder
+func NewWorker(store Store, geocoder Geocoder) *Worker {
+	return &Worker{store: store, geocoder: geocoder}
+}
+
+// Start geocodes immediately and then every interval until ctx is cancelled
+func (w *Worker) Start(ctx context.Context, interval time.Duration) {
+	if w == nil || w.store == nil || w.geocoder == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultGeocodeInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		w.run(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.run(ctx)
+			}
+		}
+	}()
+}
+
+func (w *Worker) run(ctx context.Context) {
+	geocoded, err := w.GeocodeOnce(ctx)
+	if err != nil {
+		slog.Error("Geocode: run failed", "error", err)
+	}
+	if geocoded > 0 {
+		slog.Info("Geocode: Geocoded event locations", "events", geocoded)
+	}
+}
+
+// GeocodeOnce resolves up to maxEventsPerRun event locations and returns how many were
+// stored. Locations that match no place are stored without coordinates so they are not
+// looked up again. A lookup error ends the run; the event is retried next run.
+func (w *Worker) GeocodeOnce(ctx context.Context) (int, error) {
+	events, err := w.store.ListEventsToGeocode(maxEventsPerRun)
+	if err != nil {
+		return 0, err
+	}
+
+	// Events often share a location ("the office"); look each one up once per run
+	resolved := make(map[string]*database.EventGeocode)
+	geocoded := 0
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return geocoded, ctx.Err()
+		}
+
+		geo, ok := resolved[event.Location]
+		if !ok {
+			result, err := w.geocoder.Geocode(ctx, event.Location)
+			if err != nil {
+				return geocoded, fmt.Errorf("failed to geocode event %d: %w", event.ID, err)
+			}
+			if result != nil {
+				geo = &database.EventGeocode{
+					Latitude:  result.Latitude,
+					Longitude: result.Longitude,
+					MapsURL:   result.MapsURL(),
+				}
+			}
+			resolved[event.Location] = geo
+		}
+
+		if err := w.store.SaveEventGeocode(event.ID, event.Location, geo); err != nil {
+			return geocoded, err
+		}
+		geocoded++
+	}
+	return geocoded, nil
+}
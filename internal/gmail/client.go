@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
+	"github.com/omriShneor/project_alfred/internal/source"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/gmail/v1"
 	"google.golang.org/api/option"
@@ -32,6 +34,9 @@ type Email struct {
 	Snippet    string
 	Labels     []string
 	MessageID  string // RFC 2822 Message-ID header
+	// Attachments references the files attached to the email; fetch their content
+	// with GetAttachment
+	Attachments []source.Attachment
 }
 
 // NewClient creates a new Gmail client using an existing OAuth2 config and token
@@ -168,7 +173,7 @@ func (c *Client) GetMessagesSince(query string, maxResults int64) ([]*Email, err
 		email, err := c.GetMessage(msg.Id)
 		if err != nil {
 			// Log but continue with other messages
-			fmt.Printf("Warning: failed to get message %s: %v\n", msg.Id, err)
+			slog.Warn("failed to get message", "message_id", msg.Id, "error", err)
 			continue
 		}
 		emails = append(emails, email)
@@ -209,10 +214,54 @@ func (c *Client) parseMessage(msg *gmail.Message) *Email {
 
 	// Extract body
 	email.Body = c.extractBody(msg.Payload)
+	email.Attachments = extractAttachments(msg.Id, msg.Payload)
 
 	return email
 }
 
+// extractAttachments lists the attachment parts of a message payload
+func extractAttachments(messageID string, payload *gmail.MessagePart) []source.Attachment {
+	if payload == nil {
+		return nil
+	}
+	var attachments []source.Attachment
+	if payload.Filename != "" && payload.Body != nil && payload.Body.AttachmentId != "" {
+		attachments = append(attachments, source.Attachment{
+			Kind:      source.AttachmentKindForMimeType(payload.MimeType),
+			MimeType:  payload.MimeType,
+			FileName:  payload.Filename,
+			SizeBytes: payload.Body.Size,
+			SourceRef: messageID + "/" + payload.Body.AttachmentId,
+		})
+	}
+	for _, part := range payload.Parts {
+		attachments = append(attachments, extractAttachments(messageID, part)...)
+	}
+	return attachments
+}
+
+// GetAttachment downloads an attachment by the SourceRef of a parsed email attachment
+func (c *Client) GetAttachment(sourceRef string) ([]byte, error) {
+	if c.service == nil {
+		return nil, fmt.Errorf("Gmail service not initialized")
+	}
+
+	messageID, attachmentID, ok := strings.Cut(sourceRef, "/")
+	if !ok || messageID == "" || attachmentID == "" {
+		return nil, fmt.Errorf("invalid attachment reference")
+	}
+
+	body, err := c.service.Users.Messages.Attachments.Get("me", messageID, attachmentID).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	data, err := base64.URLEncoding.DecodeString(body.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode attachment: %w", err)
+	}
+	return data, nil
+}
+
 // extractBody extracts plain text body from message payload
 func (c *Client) extractBody(payload *gmail.MessagePart) string {
 	// First try to find plain text part
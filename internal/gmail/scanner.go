@@ -2,6 +2,7 @@ package gmail
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 )
@@ -113,7 +114,7 @@ func (s *Scanner) ScanForEmails(sources []*EmailSource, sinceTime *time.Time, ma
 	for _, msg := range messages {
 		email, err := s.client.GetMessage(msg.Id)
 		if err != nil {
-			fmt.Printf("Warning: failed to get message %s: %v\n", msg.Id, err)
+			slog.Warn("failed to get message", "message_id", msg.Id, "error", err)
 			continue
 		}
 		if !passesSinceTimeFilter(email, sinceTime) {
@@ -159,7 +160,7 @@ func (s *Scanner) ScanSourceEmails(source *EmailSource, sinceTime *time.Time, ma
 	for _, msg := range messages {
 		email, err := s.client.GetMessage(msg.Id)
 		if err != nil {
-			fmt.Printf("Warning: failed to get message %s: %v\n", msg.Id, err)
+			slog.Warn("failed to get message", "message_id", msg.Id, "error", err)
 			continue
 		}
 		if !passesSinceTimeFilter(email, sinceTime) {
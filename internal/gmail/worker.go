@@ -3,6 +3,7 @@ package gmail
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -18,6 +19,8 @@ type DBInterface interface {
 	GetGmailSettings(userID int64) (*database.GmailSettings, error)
 	UpdateGmailLastPoll(userID int64) error
 	ListEnabledEmailSources(userID int64) ([]*database.EmailSource, error)
+	ListEmailSenderRules(userID int64) (database.EmailSenderRules, error)
+	IsFeatureEnabled(userID int64, name string) (bool, error)
 	// Top contacts caching
 	GetTopContacts(userID int64, limit int) ([]database.TopContact, error)
 	ReplaceTopContacts(userID int64, contacts []database.TopContact) error
@@ -39,6 +42,7 @@ type Worker struct {
 	userID       int64 // User this worker is processing for
 	pollInterval time.Duration
 	maxEmails    int64
+	onSync       func(userID int64)
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -51,6 +55,8 @@ type WorkerConfig struct {
 	UserID              int64
 	PollIntervalMinutes int
 	MaxEmailsPerPoll    int
+	// OnSyncComplete is called after each poll that scanned the mailbox.
+	OnSyncComplete func(userID int64)
 }
 
 // NewWorker creates a new Gmail worker for a specific user
@@ -75,6 +81,7 @@ func NewWorker(client *Client, db DBInterface, processor EmailProcessor, config
 		userID:       config.UserID,
 		pollInterval: pollInterval,
 		maxEmails:    maxEmails,
+		onSync:       config.OnSyncComplete,
 		ctx:          ctx,
 		cancel:       cancel,
 	}
@@ -84,10 +91,10 @@ func NewWorker(client *Client, db DBInterface, processor EmailProcessor, config
 // The worker always starts, but only polls when Gmail is enabled in database settings
 func (w *Worker) Start() error {
 	if w.client == nil || !w.client.IsAuthenticated() {
-		fmt.Println("Gmail worker: client not authenticated, will poll when authenticated")
+		slog.Info("Gmail worker: client not authenticated, will poll when authenticated")
 	}
 
-	fmt.Printf("Gmail worker: starting with %v poll interval (enable/disable via settings)\n", w.pollInterval)
+	slog.Info("Gmail worker: starting poll interval (enable/disable via settings)", "poll_interval", w.pollInterval)
 
 	w.wg.Add(1)
 	go w.pollLoop()
@@ -97,7 +104,7 @@ func (w *Worker) Start() error {
 
 // Stop gracefully shuts down the worker
 func (w *Worker) Stop() {
-	fmt.Println("Gmail worker: stopping...")
+	slog.Info("Gmail worker: stopping...")
 	w.cancel()
 
 	done := make(chan struct{})
@@ -108,9 +115,9 @@ func (w *Worker) Stop() {
 
 	select {
 	case <-done:
-		fmt.Println("Gmail worker: stopped")
+		slog.Info("Gmail worker: stopped")
 	case <-time.After(workerStopWaitTimeout):
-		fmt.Printf("Gmail worker: stop timed out after %v; continuing shutdown\n", workerStopWaitTimeout)
+		slog.Error("Gmail worker: stop timed out, continuing shutdown", "timeout", workerStopWaitTimeout)
 	}
 }
 
@@ -170,17 +177,25 @@ func (w *Worker) poll() {
 	// Check if Gmail is enabled in settings
 	settings, err := w.db.GetGmailSettings(w.userID)
 	if err != nil {
-		fmt.Printf("Gmail worker: failed to get settings: %v\n", err)
+		slog.Error("Gmail worker: failed to get settings", "error", err)
 		return
 	}
 	if settings == nil || !settings.Enabled {
 		return
 	}
+	enabled, err := w.db.IsFeatureEnabled(w.userID, database.FeatureGmailAnalysis)
+	if err != nil {
+		slog.Error("Gmail worker: failed to get feature flag", "error", err)
+		return
+	}
+	if !enabled {
+		return
+	}
 
 	// Get enabled sources from database
 	dbSources, err := w.db.ListEnabledEmailSources(w.userID)
 	if err != nil {
-		fmt.Printf("Gmail worker: failed to get sources: %v\n", err)
+		slog.Error("Gmail worker: failed to get sources", "error", err)
 		return
 	}
 	if len(dbSources) == 0 {
@@ -216,28 +231,29 @@ func (w *Worker) poll() {
 	// Scan for emails
 	results, err := scanner.ScanForEmails(sources, sinceTime, w.maxEmails)
 	if err != nil {
-		fmt.Printf("Gmail worker: failed to scan emails: %v\n", err)
+		slog.Error("Gmail worker: failed to scan emails", "error", err)
 		return
 	}
 
 	if len(results) == 0 {
-		if err := w.db.UpdateGmailLastPoll(w.userID); err != nil {
-			fmt.Printf("Gmail worker: failed to update last poll: %v\n", err)
-		}
+		w.finishPoll()
 		return
 	}
 
-	fmt.Printf("Gmail worker: found %d emails matching tracked sources\n", len(results))
+	slog.Info("Gmail worker: found emails matching tracked sources", "count", len(results))
+
+	rules := w.senderRules()
 
 	// Process each email
 	processedCount := 0
 	alreadyProcessedCount := 0
+	deniedCount := 0
 	processedCheckErrorCount := 0
 	for _, result := range results {
 		// Check if already processed
 		processed, err := w.db.IsEmailProcessed(w.userID, result.Email.ID)
 		if err != nil {
-			fmt.Printf("Gmail worker: failed to check processed status: %v\n", err)
+			slog.Error("Gmail worker: failed to check processed status", "error", err)
 			processedCheckErrorCount++
 			continue
 		}
@@ -246,12 +262,21 @@ func (w *Worker) poll() {
 			continue
 		}
 
+		// Denied senders never reach the LLM; mark them so they aren't checked again
+		if rules.Denies(ExtractSenderEmail(result.Email.From)) {
+			if err := w.db.MarkEmailProcessed(w.userID, result.Email.ID); err != nil {
+				slog.Error("Gmail worker: failed to mark email processed", "error", err)
+			}
+			deniedCount++
+			continue
+		}
+
 		// Fetch thread context if available
 		var thread *Thread
 		if result.Email.ThreadID != "" {
 			thread, err = client.GetThread(result.Email.ThreadID, 10)
 			if err != nil {
-				fmt.Printf("Gmail worker: warning - failed to get thread %s: %v\n", result.Email.ThreadID, err)
+				slog.Warn("Gmail worker: failed to get thread", "thread_id", result.Email.ThreadID, "error", err)
 				// Continue without thread context (graceful degradation)
 			}
 		}
@@ -259,36 +284,57 @@ func (w *Worker) poll() {
 		// Process the email with thread context
 		if w.processor != nil && result.Source != nil {
 			if err := w.processor.ProcessEmail(w.ctx, result.Email, result.Source, thread); err != nil {
-				fmt.Printf("Gmail worker: failed to process email %s: %v\n", result.Email.ID, err)
+				slog.Error("Gmail worker: failed to process email", "email_id", result.Email.ID, "error", err)
 				// Continue with other emails
 			}
 		}
 
 		// Mark as processed
 		if err := w.db.MarkEmailProcessed(w.userID, result.Email.ID); err != nil {
-			fmt.Printf("Gmail worker: failed to mark email processed: %v\n", err)
+			slog.Error("Gmail worker: failed to mark email processed", "error", err)
 		}
 		processedCount++
 	}
 
-	fmt.Printf(
-		"Gmail worker: processed %d new emails, skipped %d already processed\n",
-		processedCount,
-		alreadyProcessedCount,
-	)
+	slog.Info("Gmail worker: processed new emails, skipped already processed", "processed", processedCount, "skipped", alreadyProcessedCount)
+	if deniedCount > 0 {
+		slog.Info("Gmail worker: skipped emails from denied senders", "count", deniedCount)
+	}
 	if processedCheckErrorCount > 0 {
-		fmt.Printf(
-			"Gmail worker: skipped %d emails due to processed-status check errors\n",
-			processedCheckErrorCount,
-		)
+		slog.Warn("Gmail worker: skipped emails due to processed-status check errors", "count", processedCheckErrorCount)
 	}
 
-	// Update last poll time
+	w.finishPoll()
+}
+
+// finishPoll records the poll time and reports the completed sync
+func (w *Worker) finishPoll() {
 	if err := w.db.UpdateGmailLastPoll(w.userID); err != nil {
-		fmt.Printf("Gmail worker: failed to update last poll: %v\n", err)
+		slog.Error("Gmail worker: failed to update last poll", "error", err)
+	}
+	if w.onSync != nil {
+		w.onSync(w.userID)
 	}
 }
 
+// senderRules loads the user's sender allow/deny rules. If they can't be loaded, no
+// emails are skipped.
+func (w *Worker) senderRules() database.EmailSenderRules {
+	rules, err := w.db.ListEmailSenderRules(w.userID)
+	if err != nil {
+		slog.Error("Gmail worker: failed to get sender rules", "error", err)
+		return nil
+	}
+	return rules
+}
+
+// IsAuthenticated returns whether the worker has a Gmail client it can poll with
+func (w *Worker) IsAuthenticated() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.client != nil && w.client.IsAuthenticated()
+}
+
 // PollNow triggers an immediate poll (for testing or manual trigger)
 func (w *Worker) PollNow() {
 	go w.poll()
@@ -325,33 +371,41 @@ func (w *Worker) BackfillSource(ctx context.Context, source *EmailSource, since
 		return 0, err
 	}
 
+	rules := w.senderRules()
+
 	processedCount := 0
 	for _, result := range results {
 		processed, err := w.db.IsEmailProcessed(w.userID, result.Email.ID)
 		if err != nil {
-			fmt.Printf("Gmail backfill: failed to check processed status: %v\n", err)
+			slog.Error("Gmail backfill: failed to check processed status", "error", err)
 			continue
 		}
 		if processed {
 			continue
 		}
+		if rules.Denies(ExtractSenderEmail(result.Email.From)) {
+			if err := w.db.MarkEmailProcessed(w.userID, result.Email.ID); err != nil {
+				slog.Error("Gmail backfill: failed to mark email processed", "error", err)
+			}
+			continue
+		}
 
 		var thread *Thread
 		if result.Email.ThreadID != "" {
 			thread, err = client.GetThread(result.Email.ThreadID, 10)
 			if err != nil {
-				fmt.Printf("Gmail backfill: warning - failed to get thread %s: %v\n", result.Email.ThreadID, err)
+				slog.Warn("Gmail backfill: failed to get thread", "thread_id", result.Email.ThreadID, "error", err)
 			}
 		}
 
 		if w.processor != nil && result.Source != nil {
 			if err := w.processor.ProcessEmail(ctx, result.Email, result.Source, thread); err != nil {
-				fmt.Printf("Gmail backfill: failed to process email %s: %v\n", result.Email.ID, err)
+				slog.Error("Gmail backfill: failed to process email", "email_id", result.Email.ID, "error", err)
 			}
 		}
 
 		if err := w.db.MarkEmailProcessed(w.userID, result.Email.ID); err != nil {
-			fmt.Printf("Gmail backfill: failed to mark email processed: %v\n", err)
+			slog.Error("Gmail backfill: failed to mark email processed", "error", err)
 		}
 		processedCount++
 	}
@@ -363,7 +417,7 @@ func (w *Worker) BackfillSource(ctx context.Context, source *EmailSource, since
 func (w *Worker) RefreshContactsIfNeeded() {
 	lastComputed, err := w.db.GetTopContactsComputedAt(w.userID)
 	if err != nil {
-		fmt.Printf("Gmail worker: failed to get contacts computed at: %v\n", err)
+		slog.Error("Gmail worker: failed to get contacts computed at", "error", err)
 		return
 	}
 
@@ -382,19 +436,19 @@ func (w *Worker) RefreshContacts() {
 	w.mu.Unlock()
 
 	if client == nil {
-		fmt.Println("Gmail worker: cannot refresh contacts - client is nil")
+		slog.Error("Gmail worker: cannot refresh contacts - client is nil")
 		return
 	}
 	if !client.IsAuthenticated() {
-		fmt.Println("Gmail worker: cannot refresh contacts - client not authenticated")
+		slog.Error("Gmail worker: cannot refresh contacts - client not authenticated")
 		return
 	}
 
-	fmt.Println("Gmail worker: refreshing contacts...")
+	slog.Info("Gmail worker: refreshing contacts...")
 
 	contacts, err := client.DiscoverContacts()
 	if err != nil {
-		fmt.Printf("Gmail worker: failed to discover contacts: %v\n", err)
+		slog.Error("Gmail worker: failed to discover contacts", "error", err)
 		return
 	}
 
@@ -409,14 +463,14 @@ func (w *Worker) RefreshContacts() {
 	}
 
 	if err := w.db.ReplaceTopContacts(w.userID, dbContacts); err != nil {
-		fmt.Printf("Gmail worker: failed to replace contacts: %v\n", err)
+		slog.Error("Gmail worker: failed to replace contacts", "error", err)
 		return
 	}
 
 	if err := w.db.SetTopContactsComputedAt(w.userID, time.Now()); err != nil {
-		fmt.Printf("Gmail worker: failed to set contacts computed at: %v\n", err)
+		slog.Error("Gmail worker: failed to set contacts computed at", "error", err)
 		return
 	}
 
-	fmt.Printf("Gmail worker: cached %d contacts\n", len(dbContacts))
+	slog.Info("Gmail worker: cached contacts", "count", len(dbContacts))
 }
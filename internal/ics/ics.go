@@ -0,0 +1,175 @@
+// Package ics renders Alfred events as iCalendar (RFC 5545) data, for single-event
+// downloads and the subscribable per-user feed.
+package ics
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	// ContentType is the media type of the rendered calendars
+	ContentType = "text/calendar; charset=utf-8"
+	// InviteContentType is the media type of an invite from Invite, which mail clients
+	// show with accept/decline buttons
+	InviteContentType = "text/calendar; charset=utf-8; method=REQUEST"
+
+	prodID = "-//Alfred//Alfred Calendar//EN"
+	// defaultDuration is the length of events without an end time
+	defaultDuration = time.Hour
+	// maxLineOctets is the longest content line before folding
+	maxLineOctets = 75
+	timeLayout    = "20060102T150405Z"
+)
+
+// Person is an invite's organizer or attendee
+type Person struct {
+	Name  string
+	Email string
+}
+
+// Calendar renders events as a VCALENDAR. name, when set, is the display name calendar
+// apps give a subscribed feed. stamp is the DTSTAMP of every event.
+func Calendar(name string, events []database.CalendarEvent, stamp time.Time) []byte {
+	var buf bytes.Buffer
+	writeHeader(&buf, "PUBLISH")
+	if name != "" {
+		writeLine(&buf, "X-WR-CALNAME:"+escapeText(name))
+	}
+	for i := range events {
+		writeEvent(&buf, &events[i], stamp, nil)
+	}
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// Invite renders a METHOD:REQUEST invitation to event from organizer, which attendees'
+// mail clients offer to add to their calendar and answer to the organizer
+func Invite(event *database.CalendarEvent, organizer Person, attendees []Person, stamp time.Time) []byte {
+	var buf bytes.Buffer
+	writeHeader(&buf, "REQUEST")
+	writeEvent(&buf, event, stamp, func(buf *bytes.Buffer) {
+		writeLine(buf, "ORGANIZER"+commonName(organizer.Name)+":mailto:"+organizer.Email)
+		for _, attendee := range attendees {
+			writeLine(buf, "ATTENDEE"+commonName(attendee.Name)+
+				";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:"+attendee.Email)
+		}
+		writeLine(buf, "SEQUENCE:0")
+	})
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func writeHeader(buf *bytes.Buffer, method string) {
+	writeLine(buf, "BEGIN:VCALENDAR")
+	writeLine(buf, "VERSION:2.0")
+	writeLine(buf, "PRODID:"+prodID)
+	writeLine(buf, "CALSCALE:GREGORIAN")
+	writeLine(buf, "METHOD:"+method)
+}
+
+// commonName is the CN parameter for a name, quoted as a parameter value
+// (RFC 5545 section 3.2), or "" when there is no name
+func commonName(name string) string {
+	name = strings.NewReplacer(`"`, "'", "\r", "", "\n", " ").Replace(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	return `;CN="` + name + `"`
+}
+
+// UID is the stable iCalendar identifier of an event, so calendar apps update rather
+// than duplicate it when a feed is refreshed
+func UID(eventID int64) string {
+	return fmt.Sprintf("event-%d@alfred", eventID)
+}
+
+// writeEvent writes a VEVENT; people, when set, adds the organizer and attendees
+func writeEvent(buf *bytes.Buffer, event *database.CalendarEvent, stamp time.Time, people func(*bytes.Buffer)) {
+	end := event.StartTime.Add(defaultDuration)
+	if event.EndTime != nil && event.EndTime.After(event.StartTime) {
+		end = *event.EndTime
+	}
+
+	writeLine(buf, "BEGIN:VEVENT")
+	writeLine(buf, "UID:"+UID(event.ID))
+	writeLine(buf, "DTSTAMP:"+formatTime(stamp))
+	writeLine(buf, "DTSTART:"+formatTime(event.StartTime))
+	writeLine(buf, "DTEND:"+formatTime(end))
+	if !event.UpdatedAt.IsZero() {
+		writeLine(buf, "LAST-MODIFIED:"+formatTime(event.UpdatedAt))
+	}
+	if strings.HasPrefix(event.Recurrence, "RRULE:") {
+		writeLine(buf, event.Recurrence)
+	}
+	writeLine(buf, "SUMMARY:"+escapeText(event.Title))
+	if event.Description != "" {
+		writeLine(buf, "DESCRIPTION:"+escapeText(event.Description))
+	}
+	if event.Location != "" {
+		writeLine(buf, "LOCATION:"+escapeText(event.Location))
+	}
+	if event.Latitude != nil && event.Longitude != nil {
+		writeLine(buf, fmt.Sprintf("GEO:%.6f;%.6f", *event.Latitude, *event.Longitude))
+	}
+	if event.MapsURL != "" {
+		writeLine(buf, "URL:"+event.MapsURL)
+	}
+	if people != nil {
+		people(buf)
+	}
+	writeLine(buf, "STATUS:"+eventStatus(event.Status))
+	writeLine(buf, "END:VEVENT")
+}
+
+// eventStatus maps an event's review status to the iCalendar STATUS property
+func eventStatus(status database.EventStatus) string {
+	switch status {
+	case database.EventStatusConfirmed, database.EventStatusSynced:
+		return "CONFIRMED"
+	case database.EventStatusRejected, database.EventStatusDeleted:
+		return "CANCELLED"
+	default:
+		return "TENTATIVE"
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// escapeText escapes a TEXT property value (RFC 5545 section 3.3.11)
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// writeLine writes a content line, folded onto continuation lines after 75 octets
+// without splitting a UTF-8 character (RFC 5545 section 3.1)
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with the folding space
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,104 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar(t *testing.T) {
+	start := time.Date(2026, 3, 13, 19, 0, 0, 0, time.FixedZone("IST", 2*60*60))
+	end := start.Add(2 * time.Hour)
+	lat, lng := 32.0853, 34.7818
+	stamp := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	out := string(Calendar("Alfred", []database.CalendarEvent{
+		{
+			ID:          7,
+			Title:       "Dinner, drinks; fun",
+			Description: "Bring wine\nand dessert",
+			StartTime:   start,
+			EndTime:     &end,
+			Location:    "Luigi's",
+			Latitude:    &lat,
+			Longitude:   &lng,
+			MapsURL:     "https://www.google.com/maps/search/?api=1&query=32.0853%2C34.7818",
+			Status:      database.EventStatusSynced,
+			UpdatedAt:   stamp,
+		},
+		{
+			ID:         8,
+			Title:      "Dana's birthday",
+			StartTime:  start,
+			Recurrence: "RRULE:FREQ=YEARLY",
+			Status:     database.EventStatusPending,
+		},
+	}, stamp))
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "X-WR-CALNAME:Alfred\r\n")
+	assert.Equal(t, 2, strings.Count(out, "BEGIN:VEVENT"))
+
+	for _, want := range []string{
+		"UID:event-7@alfred\r\n",
+		"DTSTAMP:20260301T080000Z\r\n",
+		"DTSTART:20260313T170000Z\r\n",
+		"DTEND:20260313T190000Z\r\n",
+		`SUMMARY:Dinner\, drinks\; fun` + "\r\n",
+		`DESCRIPTION:Bring wine\nand dessert` + "\r\n",
+		"GEO:32.085300;34.781800\r\n",
+		"STATUS:CONFIRMED\r\n",
+	} {
+		assert.Contains(t, out, want)
+	}
+
+	// Events without an end time last an hour; recurrence rules are passed through
+	assert.Contains(t, out, "UID:event-8@alfred\r\nDTSTAMP:20260301T080000Z\r\nDTSTART:20260313T170000Z\r\nDTEND:20260313T180000Z\r\nRRULE:FREQ=YEARLY\r\n")
+	assert.Contains(t, out, "STATUS:TENTATIVE\r\n")
+}
+
+func TestWriteLineFolds(t *testing.T) {
+	out := string(Calendar("", []database.CalendarEvent{{
+		ID:        1,
+		Title:     strings.Repeat("שלום ", 40),
+		StartTime: time.Date(2026, 3, 13, 19, 0, 0, 0, time.UTC),
+	}}, time.Now()))
+
+	var unfolded strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), 75, "line %q", line)
+		assert.True(t, strings.ToValidUTF8(line, "") == line, "folding split a character")
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	assert.Contains(t, unfolded.String(), "SUMMARY:"+strings.Repeat("שלום ", 40))
+}
+
+func TestInvite(t *testing.T) {
+	start := time.Date(2026, 3, 13, 19, 0, 0, 0, time.UTC)
+	invite := Invite(&database.CalendarEvent{
+		ID:        7,
+		Title:     "Dinner",
+		StartTime: start,
+		Status:    database.EventStatusConfirmed,
+	}, Person{Name: "Omri", Email: "omri@example.com"}, []Person{
+		{Name: `Dana "D" Levi`, Email: "dana@example.com"},
+		{Email: "noam@example.com"},
+	}, start)
+	// Long attendee lines are folded; compare the unfolded content
+	out := strings.ReplaceAll(string(invite), "\r\n ", "")
+
+	assert.Contains(t, out, "METHOD:REQUEST\r\n")
+	assert.Contains(t, out, "ORGANIZER;CN=\"Omri\":mailto:omri@example.com\r\n")
+	assert.Contains(t, out, "ATTENDEE;CN=\"Dana 'D' Levi\";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:dana@example.com\r\n")
+	assert.Contains(t, out, "ATTENDEE;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:noam@example.com\r\n")
+	assert.Contains(t, out, "SEQUENCE:0\r\n")
+}
@@ -0,0 +1,409 @@
+// Package loadgen drives the message processor with synthetic traffic and measures
+// how it copes. Messages go through the real intake, durable queue, workers and
+// persistence on a SQLite file; only the analyzers are replaced by the deterministic
+// mockagent ones, with a configurable delay standing in for the LLM call.
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/mockagent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/processor"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// probeInterval is how often the write probe measures how long a small write waits
+// for the database while the processor is busy
+const probeInterval = 20 * time.Millisecond
+
+// messageTexts are the synthetic messages, cycled in order. Each routes to exactly one
+// intent and matches a mockagent default rule, so every message reaches an analyzer.
+var messageTexts = []string{
+	"Dinner tomorrow at 8?",
+	"Lunch on Thursday at noon works for me",
+	"The team meeting moved to 3pm",
+	"Remind me about the dentist next week",
+	"Don't forget to pay the electricity bill",
+}
+
+var tagPattern = regexp.MustCompile(`\[lg(\d+)\]`)
+
+// Config describes one load run
+type Config struct {
+	Users           int           // users to spread the traffic over
+	ChannelsPerUser int           // tracked contacts per user
+	Messages        int           // messages to inject
+	Workers         int           // processor workers (ALFRED_PROCESSOR_WORKERS)
+	Rate            int           // messages per second to inject; 0 sends as fast as possible
+	AnalyzerDelay   time.Duration // simulated LLM latency per analyzer call
+	BatchWindow     time.Duration // burst window (ALFRED_BATCH_WINDOW_SECONDS); 0 analyzes each message
+	Timeout         time.Duration // gives up waiting for the queue to drain; 0 means 5 minutes
+}
+
+// DefaultConfig returns a run of 2000 messages over 10 users with production defaults
+// for workers and a 200ms analyzer delay
+func DefaultConfig() Config {
+	return Config{
+		Users:           10,
+		ChannelsPerUser: 5,
+		Messages:        2000,
+		Workers:         2,
+		AnalyzerDelay:   200 * time.Millisecond,
+	}
+}
+
+// Latency summarizes a set of durations
+type Latency struct {
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Config   Config        `json:"config"`
+	Injected int           `json:"injected"`
+	Analyzed int           `json:"analyzed"`
+	Duration time.Duration `json:"duration"`
+	// Throughput is analyzed messages per second, from the first injection until the
+	// durable queue is empty
+	Throughput float64 `json:"throughput"`
+	// QueueLatency is the time from injecting a message until an analyzer starts on it
+	QueueLatency Latency `json:"queue_latency"`
+	// DBWriteLatency is how long a one-row write took while the processor ran, i.e.
+	// what an API request writing to the database would have waited
+	DBWriteLatency Latency `json:"db_write_latency"`
+	// DBPoolWaits and DBPoolWaitTime count waits for a free connection in the pool
+	DBPoolWaits    int64         `json:"db_pool_waits"`
+	DBPoolWaitTime time.Duration `json:"db_pool_wait_time"`
+	// TimedOut is set when the queue didn't drain within the timeout
+	TimedOut bool `json:"timed_out"`
+}
+
+// WriteText prints the report for humans
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "messages:        %d injected, %d analyzed in %s", r.Injected, r.Analyzed, r.Duration.Round(time.Millisecond))
+	if r.TimedOut {
+		fmt.Fprint(w, " (timed out)")
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "throughput:      %.1f msg/s (%d workers, %s analyzer delay)\n", r.Throughput, r.Config.Workers, r.Config.AnalyzerDelay)
+	fmt.Fprintf(w, "queue latency:   %s\n", r.QueueLatency)
+	fmt.Fprintf(w, "db write probe:  %s\n", r.DBWriteLatency)
+	fmt.Fprintf(w, "db pool waits:   %d (%s)\n", r.DBPoolWaits, r.DBPoolWaitTime.Round(time.Millisecond))
+}
+
+func (l Latency) String() string {
+	return fmt.Sprintf("p50 %s  p95 %s  p99 %s  max %s",
+		l.P50.Round(time.Millisecond), l.P95.Round(time.Millisecond),
+		l.P99.Round(time.Millisecond), l.Max.Round(time.Millisecond))
+}
+
+// Run seeds users and channels into db, then injects cfg.Messages messages through a
+// processor and waits until all of them are analyzed and the durable queue is empty.
+// db should be a SQLite file: an in-memory database gives every connection its own
+// empty copy, so concurrent workers wouldn't see the seeded data.
+func Run(ctx context.Context, db *database.DB, cfg Config) (*Report, error) {
+	if cfg.Users <= 0 || cfg.ChannelsPerUser <= 0 || cfg.Messages <= 0 {
+		return nil, fmt.Errorf("users, channels per user and messages must be positive")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	channels, err := seed(db, cfg.Users, cfg.ChannelsPerUser)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := newTracker(cfg.Messages)
+	rules := mockagent.DefaultRules()
+	eventAnalyzer := &delayedEventAnalyzer{mockagent.NewEventAnalyzer(rules), cfg.AnalyzerDelay, tracker}
+	reminderAnalyzer := &delayedReminderAnalyzer{mockagent.NewReminderAnalyzer(rules), cfg.AnalyzerDelay, tracker}
+
+	msgChan := make(chan source.Message, 100)
+	proc := processor.New(db, eventAnalyzer, reminderAnalyzer, msgChan, 0, nil)
+	proc.SetWorkerCount(cfg.Workers)
+	if cfg.BatchWindow > 0 {
+		proc.SetBatchWindow(cfg.BatchWindow, 0, 0)
+	}
+	if err := proc.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start processor: %w", err)
+	}
+	defer proc.Stop()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	probeCtx, stopProbe := context.WithCancel(ctx)
+	probe := startWriteProbe(probeCtx, db, channels[0])
+	poolBefore := db.Stats()
+	start := time.Now()
+
+	injected := inject(ctx, msgChan, channels, cfg, tracker)
+	drained := waitForDrain(ctx, db, tracker, injected)
+
+	report := &Report{
+		Config:   cfg,
+		Injected: injected,
+		Analyzed: tracker.analyzed(),
+		Duration: time.Since(start),
+		TimedOut: !drained,
+	}
+	stopProbe()
+	poolAfter := db.Stats()
+
+	if report.Duration > 0 {
+		report.Throughput = float64(report.Analyzed) / report.Duration.Seconds()
+	}
+	report.QueueLatency = summarize(tracker.latencies())
+	report.DBWriteLatency = summarize(probe.wait())
+	report.DBPoolWaits = poolAfter.WaitCount - poolBefore.WaitCount
+	report.DBPoolWaitTime = poolAfter.WaitDuration - poolBefore.WaitDuration
+	return report, nil
+}
+
+// seed creates the users and their tracked WhatsApp contacts
+func seed(db *database.DB, users, channelsPerUser int) ([]*database.SourceChannel, error) {
+	runID := time.Now().UnixNano()
+	channels := make([]*database.SourceChannel, 0, users*channelsPerUser)
+	for u := 0; u < users; u++ {
+		result, err := db.Exec(`INSERT INTO users (google_id, email, name) VALUES (?, ?, ?)`,
+			fmt.Sprintf("loadgen-%d-%d", runID, u),
+			fmt.Sprintf("loadgen-%d-%d@example.com", runID, u),
+			fmt.Sprintf("Load User %d", u))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		userID, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+
+		for c := 0; c < channelsPerUser; c++ {
+			identifier := fmt.Sprintf("1555%07d@s.whatsapp.net", u*channelsPerUser+c)
+			channel, err := db.CreateSourceChannel(userID, source.SourceTypeWhatsApp, source.ChannelTypeSender, identifier, fmt.Sprintf("Contact %d", c))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create channel: %w", err)
+			}
+			channels = append(channels, channel)
+		}
+	}
+	return channels, nil
+}
+
+// inject sends the messages round-robin over the channels, paced by cfg.Rate, and
+// returns how many were sent before ctx ended
+func inject(ctx context.Context, msgChan chan<- source.Message, channels []*database.SourceChannel, cfg Config, tracker *tracker) int {
+	var tick <-chan time.Time
+	if cfg.Rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(cfg.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for i := 0; i < cfg.Messages; i++ {
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				return i
+			case <-tick:
+			}
+		}
+
+		channel := channels[i%len(channels)]
+		msg := source.Message{
+			UserID:     channel.UserID,
+			SourceType: source.SourceTypeWhatsApp,
+			SourceID:   channel.ID,
+			Identifier: channel.Identifier,
+			SenderID:   channel.Identifier,
+			SenderName: channel.Name,
+			Text:       fmt.Sprintf("%s [lg%d]", messageTexts[i%len(messageTexts)], i),
+			Timestamp:  time.Now(),
+		}
+		tracker.sent(i)
+		select {
+		case <-ctx.Done():
+			return i
+		case msgChan <- msg:
+		}
+	}
+	return cfg.Messages
+}
+
+// waitForDrain waits until every injected message was analyzed and acknowledged
+func waitForDrain(ctx context.Context, db *database.DB, tracker *tracker, injected int) bool {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if tracker.analyzed() >= injected {
+			if count, err := db.CountPendingAnalyses(); err == nil && count == 0 {
+				return true
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// tracker records when each message was injected and when an analyzer first saw it
+type tracker struct {
+	mu       sync.Mutex
+	sentAt   []time.Time
+	waited   []time.Duration
+	analyzes map[int]bool
+}
+
+func newTracker(messages int) *tracker {
+	return &tracker{sentAt: make([]time.Time, messages), analyzes: make(map[int]bool, messages)}
+}
+
+func (t *tracker) sent(i int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sentAt[i] = time.Now()
+}
+
+// started records the tagged messages in text, which holds several when a burst was
+// merged into one analyzer call
+func (t *tracker) started(text string) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, match := range tagPattern.FindAllStringSubmatch(text, -1) {
+		i, err := strconv.Atoi(match[1])
+		if err != nil || i >= len(t.sentAt) || t.analyzes[i] {
+			continue
+		}
+		t.analyzes[i] = true
+		t.waited = append(t.waited, now.Sub(t.sentAt[i]))
+	}
+}
+
+func (t *tracker) analyzed() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.analyzes)
+}
+
+func (t *tracker) latencies() []time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]time.Duration(nil), t.waited...)
+}
+
+// writeProbe periodically times a one-row write
+type writeProbe struct {
+	done    chan struct{}
+	samples []time.Duration
+}
+
+func startWriteProbe(ctx context.Context, db *database.DB, channel *database.SourceChannel) *writeProbe {
+	p := &writeProbe{done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(probeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			start := time.Now()
+			if err := db.UpdateSourceChannel(channel.UserID, channel.ID, channel.Name, true); err == nil {
+				p.samples = append(p.samples, time.Since(start))
+			}
+		}
+	}()
+	return p
+}
+
+// wait waits for the probe to stop and returns its samples
+func (p *writeProbe) wait() []time.Duration {
+	<-p.done
+	return p.samples
+}
+
+func summarize(durations []time.Duration) Latency {
+	if len(durations) == 0 {
+		return Latency{}
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	at := func(q float64) time.Duration {
+		return durations[int(q*float64(len(durations)-1))]
+	}
+	return Latency{P50: at(0.50), P95: at(0.95), P99: at(0.99), Max: durations[len(durations)-1]}
+}
+
+// delayedEventAnalyzer records when analysis starts and sleeps like an LLM call
+// before answering with the mock analyzer
+type delayedEventAnalyzer struct {
+	*mockagent.EventAnalyzer
+	delay   time.Duration
+	tracker *tracker
+}
+
+func (a *delayedEventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	a.tracker.started(newMessage.MessageText)
+	if err := sleep(ctx, a.delay); err != nil {
+		return nil, err
+	}
+	return a.EventAnalyzer.AnalyzeMessages(ctx, history, newMessage, existingEvents)
+}
+
+// delayedReminderAnalyzer is delayedEventAnalyzer for reminders
+type delayedReminderAnalyzer struct {
+	*mockagent.ReminderAnalyzer
+	delay   time.Duration
+	tracker *tracker
+}
+
+func (a *delayedReminderAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingReminders []database.Reminder,
+) (*agent.ReminderAnalysis, error) {
+	a.tracker.started(newMessage.MessageText)
+	if err := sleep(ctx, a.delay); err != nil {
+		return nil, err
+	}
+	return a.ReminderAnalyzer.AnalyzeMessages(ctx, history, newMessage, existingReminders)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFileDB(tb testing.TB) *database.DB {
+	tb.Helper()
+	db, err := database.New(filepath.Join(tb.TempDir(), "loadgen.db"))
+	require.NoError(tb, err)
+	tb.Cleanup(func() { db.Close() })
+	return db
+}
+
+func quietLogs(tb testing.TB) {
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tb.Cleanup(func() { slog.SetDefault(previous) })
+}
+
+func TestRun(t *testing.T) {
+	quietLogs(t)
+	db := newFileDB(t)
+
+	report, err := Run(context.Background(), db, Config{
+		Users:           2,
+		ChannelsPerUser: 3,
+		Messages:        40,
+		Workers:         4,
+		AnalyzerDelay:   time.Millisecond,
+		Timeout:         30 * time.Second,
+	})
+	require.NoError(t, err)
+
+	assert.False(t, report.TimedOut)
+	assert.Equal(t, 40, report.Injected)
+	assert.Equal(t, 40, report.Analyzed, "every synthetic message reaches an analyzer")
+	assert.Greater(t, report.Throughput, 0.0)
+	assert.Greater(t, report.QueueLatency.Max, time.Duration(0))
+	assert.LessOrEqual(t, report.QueueLatency.P50, report.QueueLatency.P95)
+
+	var events, reminders int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM calendar_events`).Scan(&events))
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM reminders`).Scan(&reminders))
+	assert.Positive(t, events, "detections are persisted like in production")
+	assert.Positive(t, reminders)
+
+	_, err = Run(context.Background(), db, Config{Users: 1, ChannelsPerUser: 1})
+	assert.Error(t, err)
+}
+
+func TestRunBatched(t *testing.T) {
+	quietLogs(t)
+
+	report, err := Run(context.Background(), newFileDB(t), Config{
+		Users:           1,
+		ChannelsPerUser: 2,
+		Messages:        20,
+		Workers:         2,
+		BatchWindow:     50 * time.Millisecond,
+		Timeout:         30 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.False(t, report.TimedOut)
+	assert.Equal(t, 20, report.Analyzed, "merged bursts count every message in them")
+}
+
+func TestSummarize(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	l := summarize(durations)
+	assert.Equal(t, 50*time.Millisecond, l.P50)
+	assert.Equal(t, 95*time.Millisecond, l.P95)
+	assert.Equal(t, 99*time.Millisecond, l.P99)
+	assert.Equal(t, 100*time.Millisecond, l.Max)
+	assert.Equal(t, Latency{}, summarize(nil))
+}
+
+// BenchmarkProcessor measures the processor at different worker counts with a short
+// simulated LLM call. Run it with -benchtime=2000x to push a fixed number of messages:
+//
+//	go test ./internal/loadgen -run '^$' -bench Processor -benchtime=2000x
+func BenchmarkProcessor(b *testing.B) {
+	quietLogs(b)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			db := newFileDB(b)
+			b.ResetTimer()
+
+			report, err := Run(context.Background(), db, Config{
+				Users:           10,
+				ChannelsPerUser: 5,
+				Messages:        b.N,
+				Workers:         workers,
+				AnalyzerDelay:   5 * time.Millisecond,
+			})
+			require.NoError(b, err)
+			require.False(b, report.TimedOut)
+
+			b.ReportMetric(report.Throughput, "msgs/s")
+			b.ReportMetric(float64(report.QueueLatency.P95.Microseconds())/1000, "p95-queue-ms")
+			b.ReportMetric(float64(report.DBWriteLatency.P95.Microseconds())/1000, "p95-dbwrite-ms")
+		})
+	}
+}
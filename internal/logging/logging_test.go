@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn", FormatJSON)
+	require.NoError(t, err)
+
+	ctx := With(context.Background(), slog.String("request_id", "abc"))
+	ctx = With(ctx, slog.Int64("user_id", 7))
+
+	logger.InfoContext(ctx, "dropped")
+	logger.WarnContext(ctx, "kept", "channel_id", 3)
+	require.NotContains(t, buf.String(), "dropped")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "kept", record["msg"])
+	assert.Equal(t, "abc", record["request_id"])
+	assert.Equal(t, float64(7), record["user_id"])
+	assert.Equal(t, float64(3), record["channel_id"])
+}
+
+func TestNewRejectsUnknownSettings(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "verbose", FormatText)
+	assert.Error(t, err)
+	_, err = New(&bytes.Buffer{}, "info", "xml")
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	assert.Error(t, Setup(&buf, "verbose", "xml"))
+	slog.Info("still logs")
+	assert.Contains(t, buf.String(), "msg=\"still logs\"")
+	slog.SetDefault(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+}
@@ -0,0 +1,79 @@
+// Package mute lifts channel mutes once they expire.
+package mute
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/sse"
+)
+
+const defaultPollInterval = time.Minute
+
+// Publisher sends live updates to a user's stream
+type Publisher interface {
+	Publish(userID int64, updateType string, payload any) error
+}
+
+// Scheduler clears expired channel mutes. Muted channels are already analyzed again once
+// muted_until passes; clearing it tells the app the channel is back on.
+type Scheduler struct {
+	db      *database.DB
+	streams Publisher
+	now     func() time.Time
+}
+
+// NewScheduler returns a Scheduler. streams may be nil.
+func NewScheduler(db *database.DB, streams Publisher) *Scheduler {
+	return &Scheduler{db: db, streams: streams, now: time.Now}
+}
+
+// Start unmutes immediately and then every pollInterval until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context, pollInterval time.Duration) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		s.unmute()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.unmute()
+			}
+		}
+	}()
+}
+
+func (s *Scheduler) unmute() {
+	unmuted, err := s.UnmuteOnce()
+	if err != nil {
+		slog.Error("Mute: Unmute failed", "error", err)
+	}
+	if len(unmuted) > 0 {
+		slog.Info("Mute: Unmuted channels", "count", len(unmuted))
+	}
+}
+
+// UnmuteOnce clears every expired mute, publishes a channel_unmuted update for each
+// channel, and returns the unmuted channels
+func (s *Scheduler) UnmuteOnce() ([]*database.SourceChannel, error) {
+	unmuted, err := s.db.UnmuteExpiredChannels(s.now())
+	if s.streams != nil {
+		for _, channel := range unmuted {
+			_ = s.streams.Publish(channel.UserID, sse.UpdateChannelUnmuted, channel)
+		}
+	}
+	return unmuted, err
+}
@@ -0,0 +1,73 @@
+package mute
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/sse"
+)
+
+type recordedUpdate struct {
+	userID     int64
+	updateType string
+	channelID  int64
+}
+
+type stubPublisher struct {
+	updates []recordedUpdate
+}
+
+func (p *stubPublisher) Publish(userID int64, updateType string, payload any) error {
+	p.updates = append(p.updates, recordedUpdate{userID, updateType, payload.(*database.SourceChannel).ID})
+	return nil
+}
+
+func TestUnmuteOnce(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	now := time.Now().UTC()
+
+	create := func(identifier string, mutedUntil *time.Time) *database.SourceChannel {
+		t.Helper()
+		channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, identifier, identifier)
+		require.NoError(t, err)
+		if mutedUntil != nil {
+			require.NoError(t, db.SetChannelMutedUntil(user.ID, channel.ID, mutedUntil))
+		}
+		return channel
+	}
+	expired, active := now.Add(-time.Minute), now.Add(time.Hour)
+	expiredChannel := create("expired@s.whatsapp.net", &expired)
+	activeChannel := create("active@s.whatsapp.net", &active)
+	create("unmuted@s.whatsapp.net", nil)
+
+	publisher := &stubPublisher{}
+	scheduler := NewScheduler(db, publisher)
+	scheduler.now = func() time.Time { return now }
+
+	unmuted, err := scheduler.UnmuteOnce()
+	require.NoError(t, err)
+	require.Len(t, unmuted, 1)
+	assert.Equal(t, expiredChannel.ID, unmuted[0].ID)
+	assert.Equal(t, []recordedUpdate{{user.ID, sse.UpdateChannelUnmuted, expiredChannel.ID}}, publisher.updates)
+
+	channel, err := db.GetSourceChannelByID(user.ID, expiredChannel.ID)
+	require.NoError(t, err)
+	assert.Nil(t, channel.MutedUntil)
+	assert.False(t, channel.IsMuted(now))
+
+	channel, err = db.GetSourceChannelByID(user.ID, activeChannel.ID)
+	require.NoError(t, err)
+	require.NotNil(t, channel.MutedUntil)
+	assert.True(t, channel.IsMuted(now))
+
+	// Nothing left to unmute
+	unmuted, err = scheduler.UnmuteOnce()
+	require.NoError(t, err)
+	assert.Empty(t, unmuted)
+}
@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+
+	// apnsTokenLifetime is how long a provider token is reused; Apple rejects tokens
+	// older than an hour and throttles ones refreshed more often than every 20 minutes
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNsPushNotifier sends push notifications directly through Apple Push Notification
+// service, authenticating with a .p8 signing key (token-based auth)
+type APNsPushNotifier struct {
+	httpClient *http.Client
+	url        string
+	keyID      string
+	teamID     string
+	topic      string // the app's bundle ID
+	key        *ecdsa.PrivateKey
+
+	mu          sync.Mutex
+	token       string
+	tokenIssued time.Time
+}
+
+// NewAPNsPushNotifier creates an APNs notifier from a .p8 key file. sandbox selects
+// Apple's development environment, for debug builds of the app.
+func NewAPNsPushNotifier(keyFile, keyID, teamID, topic string, sandbox bool) (*APNsPushNotifier, error) {
+	if keyID == "" || teamID == "" || topic == "" {
+		return nil, fmt.Errorf("APNs key ID, team ID and topic are required")
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read APNs key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("APNs key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse APNs key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("APNs key is not an ECDSA key")
+	}
+
+	url := apnsProductionURL
+	if sandbox {
+		url = apnsSandboxURL
+	}
+	return &APNsPushNotifier{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		url:        url,
+		keyID:      keyID,
+		teamID:     teamID,
+		topic:      topic,
+		key:        key,
+	}, nil
+}
+
+// Name returns the notifier name
+func (a *APNsPushNotifier) Name() string {
+	return "apns_push"
+}
+
+// IsConfigured returns true once a signing key has been loaded
+func (a *APNsPushNotifier) IsConfigured() bool {
+	return a != nil && a.key != nil
+}
+
+// SendPush sends msg to each APNs device token, one request per token
+func (a *APNsPushNotifier) SendPush(ctx context.Context, tokens []string, msg PushMessage) error {
+	// Custom data sits next to "aps" at the top level of the payload
+	payload := make(map[string]interface{}, len(msg.Data)+1)
+	for key, value := range msg.Data {
+		payload[key] = value
+	}
+	aps := map[string]interface{}{
+		"alert": map[string]string{"title": msg.Title, "body": msg.Body},
+		"sound": "default",
+	}
+	if msg.Badge != nil {
+		aps["badge"] = *msg.Badge
+	}
+	payload["aps"] = aps
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal APNs payload: %w", err)
+	}
+
+	return sendEach(tokens, func(token string) (bool, error) {
+		return a.post(ctx, token, body)
+	})
+}
+
+// post sends one notification, reporting whether APNs rejected the token as unregistered
+func (a *APNsPushNotifier) post(ctx context.Context, deviceToken string, body []byte) (bool, error) {
+	authToken, err := a.providerToken()
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.url+"/3/device/"+deviceToken, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", a.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to send APNs push: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode == http.StatusGone || result.Reason == "BadDeviceToken" || result.Reason == "Unregistered" {
+		return true, nil
+	}
+	return false, fmt.Errorf("APNs returned status %d: %s", resp.StatusCode, result.Reason)
+}
+
+// providerToken returns the ES256 JWT APNs authenticates requests with, signing a new
+// one when the current one is older than apnsTokenLifetime
+func (a *APNsPushNotifier) providerToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Since(a.tokenIssued) < apnsTokenLifetime {
+		return a.token, nil
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": a.keyID})
+	claims, _ := json.Marshal(map[string]interface{}{"iss": a.teamID, "iat": now.Unix()})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, a.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign APNs token: %w", err)
+	}
+	// JWS ES256 signatures are r and s as fixed-width 32-byte big-endian integers
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	a.token = unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	a.tokenIssued = now
+	return a.token, nil
+}
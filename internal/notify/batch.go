@@ -0,0 +1,131 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// maxBatchTitles bounds the event titles listed in a batched push
+const maxBatchTitles = 3
+
+// pushBatch collects one user's pending events until its window closes
+type pushBatch struct {
+	events []*database.CalendarEvent
+}
+
+// pushBatcher coalesces the pending event pushes a user gets within a window, e.g. while
+// a Gmail backfill detects many events at once
+type pushBatcher struct {
+	mu      sync.Mutex
+	window  time.Duration
+	batches map[int64]*pushBatch
+}
+
+// SetPushBatchWindow coalesces pending event pushes: the first event for a user opens a
+// window, and every event detected before it closes goes out as one push ("5 new events
+// need review"). A window of 0 (the default) pushes every event as it's detected.
+// Emails are not batched since each carries the event's confirm/reject links.
+func (s *Service) SetPushBatchWindow(window time.Duration) {
+	if window <= 0 {
+		s.batcher = nil
+		return
+	}
+	s.batcher = &pushBatcher{window: window, batches: make(map[int64]*pushBatch)}
+}
+
+// batchEventPush adds the event to its user's open batch, opening one if needed.
+// An event already in the batch (e.g. notified twice) is only counted once.
+func (s *Service) batchEventPush(event *database.CalendarEvent) {
+	b := s.batcher
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batch, ok := b.batches[event.UserID]
+	if !ok {
+		batch = &pushBatch{}
+		b.batches[event.UserID] = batch
+		time.AfterFunc(b.window, func() { s.flushEventPushes(context.Background(), event.UserID) })
+	}
+	for _, queued := range batch.events {
+		if queued.ID == event.ID {
+			return
+		}
+	}
+	batch.events = append(batch.events, event)
+}
+
+// flushEventPushes closes the user's batch and sends it: a lone event gets its usual
+// push, several get one summary push
+func (s *Service) flushEventPushes(ctx context.Context, userID int64) {
+	b := s.batcher
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	batch := b.batches[userID]
+	delete(b.batches, userID)
+	b.mu.Unlock()
+	if batch == nil || len(batch.events) == 0 {
+		return
+	}
+
+	// Preferences and devices may have changed while the batch was open
+	prefs, err := s.db.GetUserNotificationPrefs(userID)
+	if err != nil {
+		slog.Error("Notification: Failed to get prefs", "error", err)
+		return
+	}
+	devices := s.pushDevices(userID)
+	if !prefs.PushEnabled || len(devices) == 0 {
+		return
+	}
+
+	locale := s.userLocale(userID)
+	if len(batch.events) == 1 {
+		if err := s.sendEventPush(ctx, batch.events[0], devices, locale); err != nil {
+			slog.Error("Notification: Push failed", "error", err)
+		}
+		return
+	}
+
+	msg := batchPushMessage(batch.events, locale)
+	if s.queueDeliveries {
+		err = s.queuePush(userID, kindEventPending, msg)
+	} else {
+		err = s.sendPush(ctx, devices, msg)
+	}
+	if err != nil {
+		slog.Error("Notification: Batched push failed", "error", err)
+		return
+	}
+	slog.Info("Notification: Batched push sent", "events", len(batch.events), "user_id", userID)
+}
+
+// batchPushMessage summarizes several pending events in one push
+func batchPushMessage(events []*database.CalendarEvent, locale string) PushMessage {
+	msgs := messagesFor(locale)
+
+	titles := make([]string, 0, maxBatchTitles)
+	for _, event := range events {
+		if len(titles) == maxBatchTitles {
+			titles = append(titles, "…")
+			break
+		}
+		titles = append(titles, event.Title)
+	}
+
+	return PushMessage{
+		Title: fmt.Sprintf(msgs.eventsPending, len(events)),
+		Body:  strings.Join(titles, ", "),
+		Data: map[string]interface{}{
+			"count":  len(events),
+			"screen": "Events",
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPushProvider records every message it's asked to send
+type recordingPushProvider struct {
+	mu   sync.Mutex
+	sent []PushMessage
+}
+
+func (r *recordingPushProvider) Name() string       { return "recording_push" }
+func (r *recordingPushProvider) IsConfigured() bool { return true }
+
+func (r *recordingPushProvider) SendPush(ctx context.Context, tokens []string, msg PushMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func (r *recordingPushProvider) messages() []PushMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PushMessage(nil), r.sent...)
+}
+
+func TestPendingEventPushesAreBatched(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	require.NoError(t, db.UpdatePushPrefs(user.ID, true))
+	_, err := db.RegisterDevice(user.ID, "fcm-token", database.PushProviderFCM, "android", "")
+	require.NoError(t, err)
+
+	provider := &recordingPushProvider{}
+	service := NewService(db, nil, nil)
+	service.SetPushProvider(database.PushProviderFCM, provider)
+	service.SetPushBatchWindow(50 * time.Millisecond)
+
+	titles := []string{"Dentist", "Dinner", "Flight"}
+	for i, title := range titles {
+		service.NotifyPendingEvent(ctx, &database.CalendarEvent{
+			ID:         int64(i + 1),
+			UserID:     user.ID,
+			Title:      title,
+			StartTime:  time.Now().Add(time.Hour),
+			ActionType: database.EventActionCreate,
+		})
+	}
+	// The same event notified again within the window is only counted once
+	service.NotifyPendingEvent(ctx, &database.CalendarEvent{ID: 1, UserID: user.ID, Title: "Dentist", ActionType: database.EventActionCreate})
+	assert.Empty(t, provider.messages(), "pushes wait for the window to close")
+
+	require.Eventually(t, func() bool { return len(provider.messages()) > 0 }, time.Second, 10*time.Millisecond)
+	sent := provider.messages()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "3 new events need review", sent[0].Title)
+	assert.Equal(t, "Dentist, Dinner, Flight", sent[0].Body)
+	assert.Equal(t, 3, sent[0].Data["count"])
+}
+
+func TestSingleBatchedEventGetsItsOwnPush(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	require.NoError(t, db.UpdatePushPrefs(user.ID, true))
+	_, err := db.RegisterDevice(user.ID, "fcm-token", database.PushProviderFCM, "android", "")
+	require.NoError(t, err)
+
+	provider := &recordingPushProvider{}
+	service := NewService(db, nil, nil)
+	service.SetPushProvider(database.PushProviderFCM, provider)
+	service.SetPushBatchWindow(time.Hour)
+
+	event := &database.CalendarEvent{ID: 7, UserID: user.ID, Title: "Dentist", ActionType: database.EventActionCreate}
+	service.NotifyPendingEvent(ctx, event)
+	service.flushEventPushes(ctx, user.ID)
+
+	sent := provider.messages()
+	require.Len(t, sent, 1)
+	expected := eventPushMessage(event, "")
+	badge := 0 // The event was never stored, so nothing is pending
+	expected.Badge = &badge
+	assert.Equal(t, expected, sent[0])
+}
@@ -0,0 +1,300 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	// digestSendWindow is how long after a user's digest time a missed digest (e.g. the
+	// server was down) is still sent; later than that, the day is skipped
+	digestSendWindow = 2 * time.Hour
+	// maxDigestEvents bounds the events listed in one digest
+	maxDigestEvents = 50
+)
+
+// DigestCalendarEvent is an event on the user's Google Calendar
+type DigestCalendarEvent struct {
+	GoogleEventID string
+	Title         string
+	StartTime     time.Time
+	AllDay        bool
+	Location      string
+}
+
+// DigestCalendar lists a user's Google Calendar events so the digest can merge them
+// with Alfred's own events
+type DigestCalendar interface {
+	ListDigestEvents(userID int64, start, end time.Time) ([]DigestCalendarEvent, error)
+}
+
+// SetDigestCalendar sets the Google Calendar merged into digests. Without one, digests
+// list only Alfred's events.
+func (s *Service) SetDigestCalendar(calendar DigestCalendar) {
+	s.digestCalendar = calendar
+}
+
+// digestItem is one line of a digest
+type digestItem struct {
+	at       time.Time
+	allDay   bool
+	title    string
+	location string
+}
+
+// dailyDigest is a user's morning summary
+type dailyDigest struct {
+	events    []digestItem
+	reminders []digestItem
+	pending   int
+}
+
+func (d *dailyDigest) empty() bool {
+	return len(d.events) == 0 && len(d.reminders) == 0 && d.pending == 0
+}
+
+// StartDigestWorker polls for users whose morning digest time has come and sends each
+// of them one digest per local day. Users whose daily_digest feature flag is off are skipped.
+func (s *Service) StartDigestWorker(ctx context.Context, pollInterval time.Duration) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultDueReminderPollInterval
+	}
+
+	go runPolling(ctx, pollInterval, s.processDigests)
+}
+
+func (s *Service) processDigests(ctx context.Context) {
+	subscribers, err := s.db.ListDigestSubscribers()
+	if err != nil {
+		slog.Error("Notification: Failed to fetch digest subscribers", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for _, sub := range subscribers {
+		loc, err := time.LoadLocation(sub.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		local := now.In(loc)
+		today := local.Format("2006-01-02")
+		if sub.LastSentOn == today {
+			continue
+		}
+
+		hour, minute, err := database.ParseDigestTime(sub.DigestTime)
+		if err != nil {
+			hour, minute, _ = database.ParseDigestTime(database.DefaultDigestTime)
+		}
+		sendAt := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+		if local.Before(sendAt) {
+			continue
+		}
+
+		enabled, err := s.db.IsFeatureEnabled(sub.UserID, database.FeatureDailyDigest)
+		if err != nil {
+			slog.Error("Notification: Failed to read digest feature flag", "user_id", sub.UserID, "error", err)
+			continue
+		}
+		if !enabled {
+			continue
+		}
+
+		if local.Sub(sendAt) <= digestSendWindow {
+			processed, err := s.sendDigest(ctx, sub.UserID, local)
+			if err != nil {
+				slog.Error("Notification: Failed sending digest", "user_id", sub.UserID, "error", err)
+				continue
+			}
+			if !processed {
+				continue
+			}
+		}
+
+		if err := s.db.MarkDigestSent(sub.UserID, today); err != nil {
+			slog.Error("Notification: Failed to mark digest sent", "user_id", sub.UserID, "error", err)
+		}
+	}
+}
+
+// buildDigest collects the user's events and due reminders for the local day containing
+// now, plus the number of items awaiting confirmation
+func (s *Service) buildDigest(userID int64, now time.Time) (*dailyDigest, error) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 0, 1)
+	digest := &dailyDigest{}
+
+	events, err := s.db.ListEventsInRange(userID, start, end, maxDigestEvents)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if event.Status != database.EventStatusConfirmed && event.Status != database.EventStatusSynced {
+			continue
+		}
+		if event.GoogleEventID != nil {
+			seen[*event.GoogleEventID] = true
+		}
+		digest.events = append(digest.events, digestItem{at: event.StartTime, title: event.Title, location: event.Location})
+	}
+
+	if s.digestCalendar != nil {
+		calendarEvents, err := s.digestCalendar.ListDigestEvents(userID, start, end)
+		if err != nil {
+			// Google Calendar being unreachable shouldn't hold back the rest of the digest
+			slog.Error("Notification: Digest calendar lookup failed", "user_id", userID, "error", err)
+		}
+		for _, event := range calendarEvents {
+			if event.GoogleEventID != "" && seen[event.GoogleEventID] {
+				continue
+			}
+			digest.events = append(digest.events, digestItem{
+				at:       event.StartTime,
+				allDay:   event.AllDay,
+				title:    event.Title,
+				location: event.Location,
+			})
+		}
+	}
+	sort.SliceStable(digest.events, func(i, j int) bool {
+		if digest.events[i].allDay != digest.events[j].allDay {
+			return digest.events[i].allDay
+		}
+		return digest.events[i].at.Before(digest.events[j].at)
+	})
+
+	for _, status := range []database.ReminderStatus{database.ReminderStatusConfirmed, database.ReminderStatusSynced} {
+		reminders, _, err := s.db.ListRemindersWithOptions(userID, &status, nil, database.ListOptions{From: &start, To: &end})
+		if err != nil {
+			return nil, err
+		}
+		for _, reminder := range reminders {
+			digest.reminders = append(digest.reminders, digestItem{at: *reminder.DueDate, title: reminder.Title, location: reminder.Location})
+		}
+	}
+	sort.SliceStable(digest.reminders, func(i, j int) bool {
+		return digest.reminders[i].at.Before(digest.reminders[j].at)
+	})
+
+	pendingEvents, err := s.db.CountPendingEvents(userID)
+	if err != nil {
+		return nil, err
+	}
+	pendingReminders, err := s.db.CountPendingRemindersForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	digest.pending = pendingEvents + pendingReminders
+
+	return digest, nil
+}
+
+// sendDigest delivers the user's digest for the local day containing now by push and
+// email. Like sendReminderNotification it reports processed=false only when every
+// attempted channel failed. Days with nothing to report are skipped without sending.
+func (s *Service) sendDigest(ctx context.Context, userID int64, now time.Time) (bool, error) {
+	prefs, err := s.db.GetUserNotificationPrefs(userID)
+	if err != nil {
+		return false, fmt.Errorf("load notification prefs: %w", err)
+	}
+
+	devices := s.pushDevices(userID)
+	pushEnabled := prefs.PushEnabled && len(devices) > 0
+	emailEnabled := prefs.EmailEnabled && prefs.EmailAddress != ""
+	if !pushEnabled && !emailEnabled {
+		return true, nil
+	}
+
+	digest, err := s.buildDigest(userID, now)
+	if err != nil {
+		return false, fmt.Errorf("build digest: %w", err)
+	}
+	if digest.empty() {
+		return true, nil
+	}
+
+	msgs := messagesFor(s.userLocale(userID))
+	attempted, delivered := 0, 0
+	var lastErr error
+
+	if pushEnabled {
+		if s.canPush(devices) {
+			attempted++
+			body := fmt.Sprintf(msgs.digestSummary, len(digest.events), len(digest.reminders), digest.pending)
+			if err := s.sendSimplePush(ctx, userID, kindDigest, devices, msgs.digestTitle, body, "Home"); err != nil {
+				lastErr = err
+			} else {
+				delivered++
+			}
+		} else {
+			slog.Info("Notification: Digest push skipped - notifier not configured")
+		}
+	}
+
+	if emailEnabled {
+		if email := s.emailSender(); email != nil {
+			attempted++
+			if err := s.sendSimpleEmail(ctx, email, userID, kindDigest, prefs.EmailAddress, msgs.digestTitle, digest.text(msgs, now.Location())); err != nil {
+				lastErr = err
+			} else {
+				delivered++
+			}
+		} else {
+			slog.Info("Notification: Digest email skipped - notifier not configured")
+		}
+	}
+
+	if attempted > 0 && delivered == 0 {
+		return false, lastErr
+	}
+
+	if delivered > 0 {
+		slog.Info("Notification: Digest sent", "user_id", userID)
+	}
+	return true, nil
+}
+
+// text renders the full digest, with times in loc
+func (d *dailyDigest) text(msgs messages, loc *time.Location) string {
+	var b strings.Builder
+	line := func(item digestItem) {
+		when := msgs.allDay
+		if !item.allDay {
+			when = item.at.In(loc).Format(msgs.timeLayout)
+		}
+		fmt.Fprintf(&b, "• %s  %s", when, item.title)
+		if item.location != "" {
+			fmt.Fprintf(&b, " (%s)", item.location)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(d.events) > 0 {
+		b.WriteString(msgs.digestEvents + "\n")
+		for _, item := range d.events {
+			line(item)
+		}
+		b.WriteString("\n")
+	}
+	if len(d.reminders) > 0 {
+		b.WriteString(msgs.digestReminders + "\n")
+		for _, item := range d.reminders {
+			line(item)
+		}
+		b.WriteString("\n")
+	}
+	if d.pending > 0 {
+		b.WriteString(fmt.Sprintf(msgs.digestPending, d.pending) + "\n")
+	}
+	return strings.TrimSpace(b.String())
+}
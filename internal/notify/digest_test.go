@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDigestCalendar struct {
+	events []DigestCalendarEvent
+}
+
+func (f *fakeDigestCalendar) ListDigestEvents(userID int64, start, end time.Time) ([]DigestCalendarEvent, error) {
+	return f.events, nil
+}
+
+func TestBuildDigest_MergesCalendarEvents(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "digest@s.whatsapp.net", "Digest")
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	synced, err := db.CreatePendingEvent(&database.CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Standup",
+		StartTime:  today.Add(9 * time.Hour),
+		ActionType: database.EventActionCreate,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateEventGoogleID(synced.ID, "g-standup"))
+	require.NoError(t, db.UpdateEventStatus(synced.ID, database.EventStatusSynced))
+
+	_, err = db.CreatePendingEvent(&database.CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Maybe lunch",
+		StartTime:  today.Add(12 * time.Hour),
+		ActionType: database.EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	service := NewService(db, nil, nil)
+	service.SetDigestCalendar(&fakeDigestCalendar{events: []DigestCalendarEvent{
+		{GoogleEventID: "g-standup", Title: "Standup", StartTime: today.Add(9 * time.Hour)},
+		{GoogleEventID: "g-gym", Title: "Gym", StartTime: today.Add(7 * time.Hour)},
+		{GoogleEventID: "g-holiday", Title: "Holiday", StartTime: today, AllDay: true},
+	}})
+
+	digest, err := service.buildDigest(user.ID, now)
+	require.NoError(t, err)
+
+	// The synced event appears once, all-day events come first, and the pending event is
+	// only counted as awaiting confirmation.
+	require.Len(t, digest.events, 3)
+	assert.Equal(t, "Holiday", digest.events[0].title)
+	assert.Equal(t, "Gym", digest.events[1].title)
+	assert.Equal(t, "Standup", digest.events[2].title)
+	assert.Equal(t, 1, digest.pending)
+
+	text := digest.text(englishMessages, time.UTC)
+	assert.Contains(t, text, "Today's events:")
+	assert.Contains(t, text, "• All day  Holiday")
+	assert.Contains(t, text, "• 7:00 AM  Gym")
+	assert.Contains(t, text, "Awaiting your confirmation: 1")
+}
+
+func TestProcessDigests_MarksDueUsersOncePerDay(t *testing.T) {
+	db := database.NewTestDB(t)
+	due := database.CreateTestUser(t, db)
+	later := database.CreateTestUser(t, db)
+
+	require.NoError(t, db.UpdateDigestPrefs(due.ID, true, "00:00"))
+	require.NoError(t, db.UpdateDigestPrefs(later.ID, true, "23:59"))
+
+	service := NewService(db, nil, nil)
+	service.processDigests(context.Background())
+
+	subscribers, err := db.ListDigestSubscribers()
+	require.NoError(t, err)
+	require.Len(t, subscribers, 2)
+
+	today := time.Now().UTC().Format("2006-01-02")
+	for _, sub := range subscribers {
+		switch sub.UserID {
+		case due.ID:
+			assert.Equal(t, today, sub.LastSentOn)
+		case later.ID:
+			assert.Empty(t, sub.LastSentOn)
+		}
+	}
+}
+
+func TestParseDigestTime(t *testing.T) {
+	hour, minute, err := database.ParseDigestTime("07:30")
+	require.NoError(t, err)
+	assert.Equal(t, 7, hour)
+	assert.Equal(t, 30, minute)
+
+	_, _, err = database.ParseDigestTime("25:00")
+	assert.Error(t, err)
+	_, _, err = database.ParseDigestTime("morning")
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailActionSigner(t *testing.T) {
+	signer := NewEmailActionSigner([]byte("test-key"))
+	now := time.Now()
+	token := signer.Sign(7, 42, EmailActionConfirm, now.Add(time.Hour))
+
+	claims, err := signer.Verify(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), claims.UserID)
+	assert.Equal(t, int64(42), claims.EventID)
+	assert.Equal(t, EmailActionConfirm, claims.Action)
+
+	_, err = signer.Verify(token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrEmailActionExpired)
+
+	_, err = NewEmailActionSigner([]byte("other-key")).Verify(token, now)
+	assert.ErrorIs(t, err, ErrInvalidEmailAction)
+
+	// Swapping in claims for another event invalidates the signature
+	other := signer.Sign(7, 43, EmailActionReject, now.Add(time.Hour))
+	payload, _, _ := strings.Cut(other, ".")
+	_, sig, _ := strings.Cut(token, ".")
+	_, err = signer.Verify(payload+"."+sig, now)
+	assert.ErrorIs(t, err, ErrInvalidEmailAction)
+}
+
+func TestEventEmailIncludesActionLinks(t *testing.T) {
+	notifier := NewResendNotifier("key", "Alfred <alfred@example.com>", "https://alfred.example.com")
+	event := &database.CalendarEvent{ID: 42, UserID: 7, Title: "Dinner", StartTime: time.Now(), ActionType: database.EventActionCreate}
+
+	assert.NotContains(t, notifier.formatEmailHTML(event), "/api/email-actions/")
+
+	notifier.SetActionSigner(NewEmailActionSigner([]byte("test-key")))
+	body := notifier.formatEmailHTML(event)
+	assert.Equal(t, 2, strings.Count(body, "https://alfred.example.com/api/email-actions/"))
+}
@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// emailLayout renders Alfred's notification emails; each email notifier embeds one
+type emailLayout struct {
+	appURL  string
+	actions *EmailActionSigner
+}
+
+// SetActionSigner enables one-click confirm/reject links in pending event emails
+func (l *emailLayout) SetActionSigner(signer *EmailActionSigner) {
+	l.actions = signer
+}
+
+// formatEmailHTML creates the HTML email body
+func (l *emailLayout) formatEmailHTML(event *database.CalendarEvent) string {
+	// Format the start time
+	startTimeStr := event.StartTime.Format("Monday, January 2, 2006 at 3:04 PM")
+
+	// Format end time if available
+	endTimeStr := ""
+	if event.EndTime != nil {
+		// If same day, just show the time
+		if event.StartTime.Format("2006-01-02") == event.EndTime.Format("2006-01-02") {
+			endTimeStr = fmt.Sprintf(" - %s", event.EndTime.Format("3:04 PM"))
+		} else {
+			endTimeStr = fmt.Sprintf(" - %s", event.EndTime.Format("Monday, January 2, 2006 at 3:04 PM"))
+		}
+	}
+
+	// Build location section
+	locationHTML := ""
+	if event.Location != "" {
+		locationHTML = fmt.Sprintf(`<p style="margin: 8px 0;"><strong>Location:</strong> %s</p>`, event.Location)
+	}
+
+	// Build description section
+	descriptionHTML := ""
+	if event.Description != "" {
+		descriptionHTML = fmt.Sprintf(`<p style="margin: 16px 0;">%s</p>`, event.Description)
+	}
+
+	// Build reasoning section
+	reasoningHTML := ""
+	if event.LLMReasoning != "" {
+		reasoningHTML = fmt.Sprintf(`<p style="margin: 16px 0; color: #666; font-style: italic;">Claude's reasoning: %s</p>`, event.LLMReasoning)
+	}
+
+	// Build channel source
+	channelSource := event.ChannelName
+	if channelSource == "" {
+		channelSource = "Unknown channel"
+	}
+
+	actionsHTML := l.formatActionLinksHTML(event)
+
+	// Action type badge
+	actionBadge := "New Event"
+	actionColor := "#28a745"
+	switch event.ActionType {
+	case database.EventActionUpdate:
+		actionBadge = "Update Event"
+		actionColor = "#ffc107"
+	case database.EventActionDelete:
+		actionBadge = "Delete Event"
+		actionColor = "#dc3545"
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px; background-color: #f5f5f5;">
+  <div style="background-color: white; border-radius: 8px; padding: 24px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+    <div style="margin-bottom: 16px;">
+      <span style="background-color: %s; color: white; padding: 4px 12px; border-radius: 4px; font-size: 12px; font-weight: 600;">%s</span>
+    </div>
+
+    <h2 style="margin: 0 0 16px 0; color: #333;">%s</h2>
+
+    <div style="background: #f8f9fa; padding: 16px; border-radius: 8px; margin: 16px 0; border-left: 4px solid #007bff;">
+      <p style="margin: 8px 0;"><strong>Date:</strong> %s%s</p>
+      %s
+      <p style="margin: 8px 0;"><strong>Source:</strong> %s</p>
+    </div>
+
+    %s
+    %s
+    %s
+
+    <a href="%s/events" style="display: inline-block; background: #007bff; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin-top: 16px; font-weight: 500;">
+      Review Event
+    </a>
+
+    <hr style="margin-top: 32px; border: none; border-top: 1px solid #eee;">
+    <p style="color: #999; font-size: 12px; margin-top: 16px;">
+      Alfred - Virtual Personal Assistant<br>
+      <span style="color: #ccc;">Sent at %s</span>
+    </p>
+  </div>
+</body>
+</html>`,
+		actionColor,
+		actionBadge,
+		event.Title,
+		startTimeStr,
+		endTimeStr,
+		locationHTML,
+		channelSource,
+		descriptionHTML,
+		reasoningHTML,
+		actionsHTML,
+		l.appURL,
+		time.Now().Format("Jan 2, 2006 3:04 PM"),
+	)
+}
+
+// formatActionLinksHTML renders signed one-click confirm/reject buttons for the event, or
+// nothing when no signer is set
+func (l *emailLayout) formatActionLinksHTML(event *database.CalendarEvent) string {
+	if l.actions == nil || event.ID == 0 {
+		return ""
+	}
+
+	expiresAt := time.Now().Add(EmailActionLinkLifetime)
+	link := func(action EmailAction) string {
+		return html.EscapeString(l.appURL + "/api/email-actions/" + l.actions.Sign(event.UserID, event.ID, action, expiresAt))
+	}
+
+	return fmt.Sprintf(`
+    <div style="margin: 24px 0 8px 0;">
+      <a href="%s" style="display: inline-block; background: #28a745; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; margin-right: 8px; font-weight: 500;">Confirm</a>
+      <a href="%s" style="display: inline-block; background: #dc3545; color: white; padding: 12px 24px; text-decoration: none; border-radius: 6px; font-weight: 500;">Reject</a>
+    </div>
+    <p style="color: #999; font-size: 12px; margin: 0;">These links expire in %d days.</p>`,
+		link(EmailActionConfirm),
+		link(EmailActionReject),
+		int(EmailActionLinkLifetime.Hours()/24),
+	)
+}
+
+// formatSimpleEmailHTML wraps a plain-text body in the standard Alfred email layout
+func (l *emailLayout) formatSimpleEmailHTML(subject, body string) string {
+	paragraphs := ""
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		paragraphs += fmt.Sprintf(`<p style="margin: 8px 0;">%s</p>`, html.EscapeString(line))
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+</head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px; background-color: #f5f5f5;">
+  <div style="background-color: white; border-radius: 8px; padding: 24px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+    <h2 style="margin: 0 0 16px 0; color: #333;">%s</h2>
+
+    %s
+
+    <hr style="margin-top: 32px; border: none; border-top: 1px solid #eee;">
+    <p style="color: #999; font-size: 12px; margin-top: 16px;">
+      Alfred - Virtual Personal Assistant<br>
+      <span style="color: #ccc;">Sent at %s</span>
+    </p>
+  </div>
+</body>
+</html>`,
+		html.EscapeString(subject),
+		paragraphs,
+		time.Now().Format("Jan 2, 2006 3:04 PM"),
+	)
+}
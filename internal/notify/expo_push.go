@@ -5,17 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/omriShneor/project_alfred/internal/database"
 )
 
-const expoPushURL = "https://exp.host/--/api/v2/push/send"
+const (
+	expoPushURL = "https://exp.host/--/api/v2/push/send"
+
+	// expoPushBatchSize is the most messages Expo accepts in one request
+	expoPushBatchSize = 100
+)
 
 // ExpoPushNotifier sends push notifications via Expo Push Notification Service
 type ExpoPushNotifier struct {
 	httpClient *http.Client
+	url        string
 }
 
 // NewExpoPushNotifier creates a new Expo push notifier
@@ -24,6 +31,7 @@ func NewExpoPushNotifier() *ExpoPushNotifier {
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		url: expoPushURL,
 	}
 }
 
@@ -44,113 +52,162 @@ type expoPushMessage struct {
 	Body     string                 `json:"body"`
 	Data     map[string]interface{} `json:"data,omitempty"`
 	Sound    string                 `json:"sound,omitempty"`
-	Badge    int                    `json:"badge,omitempty"`
+	Badge    *int                   `json:"badge,omitempty"`
 	Priority string                 `json:"priority,omitempty"`
 }
 
+// expoPushTicket is Expo's per-message result, in the same order as the request
+type expoPushTicket struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Details struct {
+		Error string `json:"error"`
+	} `json:"details"`
+}
+
+// UnregisteredDevicesError reports push tokens Expo rejected as DeviceNotRegistered
+// (app uninstalled or token rotated), so the caller can stop sending to them
+type UnregisteredDevicesError struct {
+	Tokens []string
+}
+
+func (e *UnregisteredDevicesError) Error() string {
+	return fmt.Sprintf("%d push token(s) no longer registered", len(e.Tokens))
+}
+
 // Send sends a push notification for a pending event
 func (e *ExpoPushNotifier) Send(ctx context.Context, event *database.CalendarEvent, recipient string) error {
 	if recipient == "" {
 		return fmt.Errorf("no push token specified")
 	}
+	return e.SendToDevices(ctx, event, []string{recipient}, "")
+}
 
-	// Determine title based on action type
-	title := "New Event Detected"
-	switch event.ActionType {
-	case database.EventActionUpdate:
-		title = "Event Update Detected"
-	case database.EventActionDelete:
-		title = "Event Deletion Detected"
-	}
-
-	// Format the body with event title and date
-	body := event.Title
-	if !event.StartTime.IsZero() {
-		body = fmt.Sprintf("%s - %s", event.Title, event.StartTime.Format("Mon, Jan 2 at 3:04 PM"))
+// SendToDevices sends a push notification for a pending event to each of a user's
+// devices, in the user's locale ("" = English)
+func (e *ExpoPushNotifier) SendToDevices(ctx context.Context, event *database.CalendarEvent, tokens []string, locale string) error {
+	if err := e.SendPush(ctx, tokens, eventPushMessage(event, locale)); err != nil {
+		return err
 	}
 
-	message := expoPushMessage{
-		To:    recipient,
-		Title: title,
-		Body:  body,
-		Sound: "default",
-		Data: map[string]interface{}{
-			"eventId":    event.ID,
-			"actionType": string(event.ActionType),
-			"screen":     "Events",
-		},
-		Priority: "high",
-	}
+	slog.Info("Push notification sent for event", "devices", len(tokens), "title", event.Title)
+	return nil
+}
 
-	jsonData, err := json.Marshal(message)
-	if err != nil {
-		return fmt.Errorf("failed to marshal push message: %w", err)
+// SendSimple sends a simple push notification (not tied to a CalendarEvent)
+func (e *ExpoPushNotifier) SendSimple(ctx context.Context, token, title, body, screen string) error {
+	if token == "" {
+		return fmt.Errorf("no push token specified")
 	}
+	return e.SendSimpleToDevices(ctx, []string{token}, title, body, screen)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", expoPushURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+// SendSimpleToDevices sends a simple push notification to each of a user's devices
+func (e *ExpoPushNotifier) SendSimpleToDevices(ctx context.Context, tokens []string, title, body, screen string) error {
+	if err := e.SendPush(ctx, tokens, simplePushMessage(title, body, screen)); err != nil {
+		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Accept-Encoding", "gzip, deflate")
-
-	resp, err := e.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send push notification: %w", err)
-	}
-	defer resp.Body.Close()
+	slog.Info("Push notification sent", "devices", len(tokens), "title", title)
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("expo push API returned status %d", resp.StatusCode)
+// SendPush sends msg to each Expo push token, batching the devices into as few requests
+// as possible
+func (e *ExpoPushNotifier) SendPush(ctx context.Context, tokens []string, msg PushMessage) error {
+	messages := make([]expoPushMessage, 0, len(tokens))
+	for _, token := range tokens {
+		messages = append(messages, expoPushMessage{
+			To:       token,
+			Title:    msg.Title,
+			Body:     msg.Body,
+			Sound:    "default",
+			Priority: "high",
+			Data:     msg.Data,
+			Badge:    msg.Badge,
+		})
 	}
-
-	fmt.Printf("Push notification sent to %s for event: %s\n", recipient, event.Title)
-	return nil
+	return e.sendBatch(ctx, messages)
 }
 
-// SendSimple sends a simple push notification (not tied to a CalendarEvent)
-func (e *ExpoPushNotifier) SendSimple(ctx context.Context, token, title, body, screen string) error {
-	if token == "" {
+// sendBatch posts messages to Expo in chunks of expoPushBatchSize. It returns an
+// *UnregisteredDevicesError if every other message was accepted but some tokens
+// are no longer registered.
+func (e *ExpoPushNotifier) sendBatch(ctx context.Context, messages []expoPushMessage) error {
+	if len(messages) == 0 {
 		return fmt.Errorf("no push token specified")
 	}
 
-	message := expoPushMessage{
-		To:       token,
-		Title:    title,
-		Body:     body,
-		Sound:    "default",
-		Priority: "high",
-		Data: map[string]interface{}{
-			"screen": screen,
-		},
+	var unregistered []string
+	var lastErr error
+	delivered := 0
+	for start := 0; start < len(messages); start += expoPushBatchSize {
+		end := min(start+expoPushBatchSize, len(messages))
+		chunk := messages[start:end]
+
+		tickets, err := e.post(ctx, chunk)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for i, ticket := range tickets {
+			if i >= len(chunk) {
+				break
+			}
+			switch {
+			case ticket.Status == "ok":
+				delivered++
+			case ticket.Details.Error == "DeviceNotRegistered":
+				unregistered = append(unregistered, chunk[i].To)
+			default:
+				lastErr = fmt.Errorf("expo push rejected message: %s", ticket.Message)
+			}
+		}
 	}
 
-	jsonData, err := json.Marshal(message)
+	if delivered == 0 && lastErr != nil {
+		return lastErr
+	}
+	if len(unregistered) > 0 {
+		return &UnregisteredDevicesError{Tokens: unregistered}
+	}
+	if delivered == 0 {
+		return fmt.Errorf("expo push delivered no messages")
+	}
+	return nil
+}
+
+// post sends one batch request to Expo and returns its tickets
+func (e *ExpoPushNotifier) post(ctx context.Context, messages []expoPushMessage) ([]expoPushTicket, error) {
+	jsonData, err := json.Marshal(messages)
 	if err != nil {
-		return fmt.Errorf("failed to marshal push message: %w", err)
+		return nil, fmt.Errorf("failed to marshal push message: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", expoPushURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", e.url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Accept-Encoding", "gzip, deflate")
 
 	resp, err := e.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send push notification: %w", err)
+		return nil, fmt.Errorf("failed to send push notification: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("expo push API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("expo push API returned status %d", resp.StatusCode)
 	}
 
-	fmt.Printf("Push notification sent to %s: %s\n", token[:20]+"...", title)
-	return nil
+	var result struct {
+		Data []expoPushTicket `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode expo push response: %w", err)
+	}
+	return result.Data, nil
 }
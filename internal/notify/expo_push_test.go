@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestExpoServer answers push requests with an ok ticket per message, or
+// DeviceNotRegistered for tokens in unregistered. It records each batch size.
+func newTestExpoServer(t *testing.T, unregistered map[string]bool) (*ExpoPushNotifier, *[]int) {
+	var mu sync.Mutex
+	var batches []int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var messages []expoPushMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&messages))
+
+		mu.Lock()
+		batches = append(batches, len(messages))
+		mu.Unlock()
+
+		tickets := make([]map[string]any, 0, len(messages))
+		for _, message := range messages {
+			if unregistered[message.To] {
+				tickets = append(tickets, map[string]any{
+					"status":  "error",
+					"message": "not registered",
+					"details": map[string]string{"error": "DeviceNotRegistered"},
+				})
+				continue
+			}
+			tickets = append(tickets, map[string]any{"status": "ok", "id": "ticket"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": tickets})
+	}))
+	t.Cleanup(server.Close)
+
+	notifier := NewExpoPushNotifier()
+	notifier.url = server.URL
+	return notifier, &batches
+}
+
+func TestExpoPushFanOut(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("batches devices into requests of at most 100", func(t *testing.T) {
+		notifier, batches := newTestExpoServer(t, nil)
+
+		tokens := make([]string, 0, 150)
+		for i := 0; i < 150; i++ {
+			tokens = append(tokens, "ExponentPushToken[device]")
+		}
+		require.NoError(t, notifier.SendSimpleToDevices(ctx, tokens, "Title", "Body", "Home"))
+		assert.Equal(t, []int{100, 50}, *batches)
+	})
+
+	t.Run("reports unregistered devices", func(t *testing.T) {
+		notifier, _ := newTestExpoServer(t, map[string]bool{"ExponentPushToken[gone]": true})
+
+		event := &database.CalendarEvent{ID: 1, Title: "Dinner", ActionType: database.EventActionCreate}
+		err := notifier.SendToDevices(ctx, event, []string{"ExponentPushToken[kept]", "ExponentPushToken[gone]"}, "")
+
+		var unregistered *UnregisteredDevicesError
+		require.True(t, errors.As(err, &unregistered))
+		assert.Equal(t, []string{"ExponentPushToken[gone]"}, unregistered.Tokens)
+	})
+
+	t.Run("service prunes unregistered devices", func(t *testing.T) {
+		db := database.NewTestDB(t)
+		user := database.CreateTestUser(t, db)
+		require.NoError(t, db.UpdatePushPrefs(user.ID, true))
+		require.NoError(t, db.UpdatePushToken(user.ID, "ExponentPushToken[kept]"))
+		require.NoError(t, db.UpdatePushToken(user.ID, "ExponentPushToken[gone]"))
+
+		notifier, batches := newTestExpoServer(t, map[string]bool{"ExponentPushToken[gone]": true})
+		service := NewService(db, nil, notifier)
+
+		event := &database.CalendarEvent{ID: 1, UserID: user.ID, Title: "Dinner", ActionType: database.EventActionCreate}
+		service.NotifyPendingEvent(ctx, event)
+		assert.Equal(t, []int{2}, *batches)
+
+		tokens, err := db.GetPushTokens(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ExponentPushToken[kept]"}, tokens)
+	})
+}
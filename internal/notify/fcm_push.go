@@ -0,0 +1,153 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	fcmSendURL = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	fcmScope   = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// FCMPushNotifier sends push notifications directly through Firebase Cloud Messaging
+// (HTTP v1 API), for app builds that register native FCM tokens instead of Expo tokens
+type FCMPushNotifier struct {
+	httpClient *http.Client
+	url        string
+}
+
+// NewFCMPushNotifier creates an FCM notifier from a Firebase service account key file
+func NewFCMPushNotifier(credentialsFile string) (*FCMPushNotifier, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+	}
+
+	ctx := context.Background()
+	creds, err := google.CredentialsFromJSON(ctx, data, fcmScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM credentials: %w", err)
+	}
+	if creds.ProjectID == "" {
+		return nil, fmt.Errorf("FCM credentials have no project_id")
+	}
+
+	httpClient := oauth2.NewClient(ctx, creds.TokenSource)
+	httpClient.Timeout = 10 * time.Second
+	return &FCMPushNotifier{
+		httpClient: httpClient,
+		url:        fmt.Sprintf(fcmSendURL, creds.ProjectID),
+	}, nil
+}
+
+// Name returns the notifier name
+func (f *FCMPushNotifier) Name() string {
+	return "fcm_push"
+}
+
+// IsConfigured returns true once credentials have been loaded
+func (f *FCMPushNotifier) IsConfigured() bool {
+	return f != nil && f.url != ""
+}
+
+// fcmMessage is the request body of the FCM v1 send API. Data values must be strings.
+type fcmMessage struct {
+	Message struct {
+		Token        string            `json:"token"`
+		Notification fcmNotification   `json:"notification"`
+		Data         map[string]string `json:"data,omitempty"`
+		Android      struct {
+			Priority     string                  `json:"priority"`
+			Notification *fcmAndroidNotification `json:"notification,omitempty"`
+		} `json:"android"`
+	} `json:"message"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroidNotification struct {
+	NotificationCount int `json:"notification_count"` // Launcher badge count
+}
+
+// fcmError is the error body FCM returns for rejected messages
+type fcmError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// SendPush sends msg to each FCM registration token, one request per token as the v1
+// API requires
+func (f *FCMPushNotifier) SendPush(ctx context.Context, tokens []string, msg PushMessage) error {
+	var data map[string]string
+	if len(msg.Data) > 0 {
+		data = make(map[string]string, len(msg.Data))
+		for key, value := range msg.Data {
+			data[key] = fmt.Sprint(value)
+		}
+	}
+
+	return sendEach(tokens, func(token string) (bool, error) {
+		var body fcmMessage
+		body.Message.Token = token
+		body.Message.Notification = fcmNotification{Title: msg.Title, Body: msg.Body}
+		body.Message.Data = data
+		body.Message.Android.Priority = "high"
+		if msg.Badge != nil {
+			body.Message.Android.Notification = &fcmAndroidNotification{NotificationCount: *msg.Badge}
+		}
+		return f.post(ctx, body)
+	})
+}
+
+// post sends one message, reporting whether FCM rejected the token as unregistered
+func (f *FCMPushNotifier) post(ctx context.Context, message fcmMessage) (bool, error) {
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal FCM message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", f.url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to send FCM push: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+
+	var result fcmError
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	for _, detail := range result.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return true, nil
+		}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return true, nil
+	}
+	return false, fmt.Errorf("FCM API returned status %d: %s", resp.StatusCode, result.Error.Message)
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/ics"
+)
+
+// invitePayload is the queued payload of an event invite email
+type invitePayload struct {
+	HTML   string `json:"html"`
+	Invite string `json:"invite"`
+}
+
+// SendEventInvites emails each attendee an iCalendar invite to event from organizer, so
+// their mail client offers to add it and replies go to the organizer. Times in the
+// email body are shown in loc. It returns the last error if any attendee wasn't sent.
+func (s *Service) SendEventInvites(ctx context.Context, event *database.CalendarEvent, organizer ics.Person, attendees []ics.Person, loc *time.Location) error {
+	email := s.emailSender()
+	if email == nil {
+		return errors.New("email not configured")
+	}
+	if len(attendees) == 0 {
+		return nil
+	}
+
+	invite := ics.Invite(event, organizer, attendees, time.Now())
+	subject := "Invitation: " + event.Title
+	body := inviteEmailBody(event, organizer, loc)
+	html := email.formatSimpleEmailHTML(subject, body)
+
+	var lastErr error
+	for _, attendee := range attendees {
+		var err error
+		if s.queueDeliveries {
+			err = s.queueInvite(event.UserID, attendee.Email, subject, body, html, invite)
+		} else {
+			err = email.SendInvite(ctx, attendee.Email, subject, html, invite)
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("failed to send invite to %s: %w", attendee.Email, err)
+		}
+	}
+	return lastErr
+}
+
+func (s *Service) queueInvite(userID int64, recipient, subject, body, html string, invite []byte) error {
+	payload, err := json.Marshal(invitePayload{HTML: html, Invite: string(invite)})
+	if err != nil {
+		return err
+	}
+	return s.queueEmail(userID, kindEventInvite, recipient, subject, body, string(payload))
+}
+
+// deliverInvite sends a queued event invite
+func deliverInvite(ctx context.Context, email EmailNotifier, n *database.QueuedNotification) error {
+	var payload invitePayload
+	if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+		return &permanentDeliveryError{fmt.Errorf("invalid invite payload: %w", err)}
+	}
+	return email.SendInvite(ctx, n.Recipient, n.Title, payload.HTML, []byte(payload.Invite))
+}
+
+func inviteEmailBody(event *database.CalendarEvent, organizer ics.Person, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	from := organizer.Name
+	if from == "" {
+		from = organizer.Email
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s invited you to %s.\n", from, event.Title)
+	fmt.Fprintf(&b, "When: %s\n", event.StartTime.In(loc).Format("Monday, January 2, 2006 at 3:04 PM MST"))
+	if event.Location != "" {
+		fmt.Fprintf(&b, "Where: %s\n", event.Location)
+	}
+	if event.Description != "" {
+		b.WriteString(event.Description + "\n")
+	}
+	return b.String()
+}
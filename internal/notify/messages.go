@@ -0,0 +1,456 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// messages holds the notification text for one locale. Format strings take the
+// event/reminder title first where they take one.
+type messages struct {
+	newEvent      string
+	eventUpdate   string
+	eventDeletion string
+	eventsPending string // %d events, for a batch of pending events
+
+	newReminder  string // %s title
+	noDueDate    string
+	dueOn        string // %s date
+	reminder     string // %s title
+	reminderNow  string
+	scheduledFor string // %s date
+	dueIn        string // %s lead, %s title
+	dueAt        string // %s date
+	overdue      string // %s title
+	wasDue       string // %s date
+
+	eventStartingNow string // %s title
+	eventIn          string // %s title, %s lead
+	startsAt         string // %s time
+
+	changedInGoogle   string // %s title
+	deletedInGoogle   string // %s title
+	nowOn             string // %s date
+	removedFromEvents string
+
+	digestTitle     string
+	digestSummary   string // %d events, %d reminders due, %d awaiting confirmation
+	digestEvents    string
+	digestReminders string
+	digestPending   string // %d items
+	allDay          string
+
+	minute, minutes string
+	hour, hours     string
+	day, days       string
+
+	// Go time layouts
+	dateTimeLayout      string
+	eventDateTimeLayout string
+	timeLayout          string
+}
+
+var englishMessages = messages{
+	newEvent:      "New Event Detected",
+	eventUpdate:   "Event Update Detected",
+	eventDeletion: "Event Deletion Detected",
+	eventsPending: "%d new events need review",
+
+	newReminder:  "📌 New Reminder: %s",
+	noDueDate:    "No due date",
+	dueOn:        "Due: %s",
+	reminder:     "⏰ Reminder: %s",
+	reminderNow:  "It's time for this reminder.",
+	scheduledFor: "Scheduled for %s",
+	dueIn:        "⏳ Due in %s: %s",
+	dueAt:        "Due %s",
+	overdue:      "🔴 Overdue: %s",
+	wasDue:       "Was due %s",
+
+	eventStartingNow: "📅 %s is starting now",
+	eventIn:          "📅 %s in %s",
+	startsAt:         "Starts at %s",
+
+	changedInGoogle:   "✏️ %s was changed in Google Calendar",
+	deletedInGoogle:   "🗑️ %s was deleted in Google Calendar",
+	nowOn:             "Now %s",
+	removedFromEvents: "Alfred removed it from your events.",
+
+	digestTitle:     "☀️ Your day at a glance",
+	digestSummary:   "Events: %d · Due: %d · To confirm: %d",
+	digestEvents:    "Today's events:",
+	digestReminders: "Due today:",
+	digestPending:   "Awaiting your confirmation: %d",
+	allDay:          "All day",
+
+	minute: "minute", minutes: "minutes",
+	hour: "hour", hours: "hours",
+	day: "day", days: "days",
+
+	dateTimeLayout:      "Jan 2 at 3:04 PM",
+	eventDateTimeLayout: "Mon, Jan 2 at 3:04 PM",
+	timeLayout:          "3:04 PM",
+}
+
+// localizedMessages covers langpolicy.SupportedLocales; any other locale gets English
+var localizedMessages = map[string]messages{
+	"en": englishMessages,
+	"he": {
+		newEvent:      "זוהה אירוע חדש",
+		eventUpdate:   "זוהה עדכון לאירוע",
+		eventDeletion: "זוהתה מחיקת אירוע",
+		eventsPending: "%d אירועים חדשים ממתינים לבדיקה",
+
+		newReminder:  "📌 תזכורת חדשה: %s",
+		noDueDate:    "ללא תאריך יעד",
+		dueOn:        "מועד: %s",
+		reminder:     "⏰ תזכורת: %s",
+		reminderNow:  "הגיע הזמן לתזכורת הזו.",
+		scheduledFor: "מתוזמן ל-%s",
+		dueIn:        "⏳ בעוד %s: %s",
+		dueAt:        "מועד: %s",
+		overdue:      "🔴 באיחור: %s",
+		wasDue:       "היה אמור להתבצע ב-%s",
+
+		eventStartingNow: "📅 %s מתחיל עכשיו",
+		eventIn:          "📅 %s בעוד %s",
+		startsAt:         "מתחיל ב-%s",
+
+		changedInGoogle:   "✏️ %s שונה ב-Google Calendar",
+		deletedInGoogle:   "🗑️ %s נמחק ב-Google Calendar",
+		nowOn:             "כעת ב-%s",
+		removedFromEvents: "Alfred הסיר אותו מהאירועים שלך.",
+
+		digestTitle:     "☀️ היום שלך במבט אחד",
+		digestSummary:   "אירועים: %d · לביצוע: %d · לאישור: %d",
+		digestEvents:    "האירועים של היום:",
+		digestReminders: "לביצוע היום:",
+		digestPending:   "ממתינים לאישורך: %d",
+		allDay:          "כל היום",
+
+		minute: "דקה", minutes: "דקות",
+		hour: "שעה", hours: "שעות",
+		day: "יום", days: "ימים",
+
+		dateTimeLayout:      "2.1 15:04",
+		eventDateTimeLayout: "2.1 15:04",
+		timeLayout:          "15:04",
+	},
+	"ar": {
+		newEvent:      "تم اكتشاف حدث جديد",
+		eventUpdate:   "تم اكتشاف تحديث لحدث",
+		eventDeletion: "تم اكتشاف حذف حدث",
+		eventsPending: "%d أحداث جديدة بحاجة إلى مراجعة",
+
+		newReminder:  "📌 تذكير جديد: %s",
+		noDueDate:    "بدون تاريخ استحقاق",
+		dueOn:        "الموعد: %s",
+		reminder:     "⏰ تذكير: %s",
+		reminderNow:  "حان وقت هذا التذكير.",
+		scheduledFor: "مجدول في %s",
+		dueIn:        "⏳ بعد %s: %s",
+		dueAt:        "الموعد: %s",
+		overdue:      "🔴 متأخر: %s",
+		wasDue:       "كان موعده %s",
+
+		eventStartingNow: "📅 %s يبدأ الآن",
+		eventIn:          "📅 %s بعد %s",
+		startsAt:         "يبدأ في %s",
+
+		changedInGoogle:   "✏️ تم تغيير %s في تقويم Google",
+		deletedInGoogle:   "🗑️ تم حذف %s في تقويم Google",
+		nowOn:             "الآن في %s",
+		removedFromEvents: "أزاله Alfred من أحداثك.",
+
+		digestTitle:     "☀️ يومك في لمحة",
+		digestSummary:   "الأحداث: %d · المستحق: %d · للتأكيد: %d",
+		digestEvents:    "أحداث اليوم:",
+		digestReminders: "مستحق اليوم:",
+		digestPending:   "بانتظار تأكيدك: %d",
+		allDay:          "طوال اليوم",
+
+		minute: "دقيقة", minutes: "دقائق",
+		hour: "ساعة", hours: "ساعات",
+		day: "يوم", days: "أيام",
+
+		dateTimeLayout:      "2/1 15:04",
+		eventDateTimeLayout: "2/1 15:04",
+		timeLayout:          "15:04",
+	},
+	"ru": {
+		newEvent:      "Обнаружено новое событие",
+		eventUpdate:   "Обнаружено изменение события",
+		eventDeletion: "Обнаружено удаление события",
+		eventsPending: "Новых событий на проверку: %d",
+
+		newReminder:  "📌 Новое напоминание: %s",
+		noDueDate:    "Без срока",
+		dueOn:        "Срок: %s",
+		reminder:     "⏰ Напоминание: %s",
+		reminderNow:  "Время для этого напоминания.",
+		scheduledFor: "Запланировано на %s",
+		dueIn:        "⏳ Через %s: %s",
+		dueAt:        "Срок: %s",
+		overdue:      "🔴 Просрочено: %s",
+		wasDue:       "Срок был %s",
+
+		eventStartingNow: "📅 %s начинается сейчас",
+		eventIn:          "📅 %s через %s",
+		startsAt:         "Начало в %s",
+
+		changedInGoogle:   "✏️ %s изменено в Google Календаре",
+		deletedInGoogle:   "🗑️ %s удалено в Google Календаре",
+		nowOn:             "Теперь %s",
+		removedFromEvents: "Alfred убрал его из ваших событий.",
+
+		digestTitle:     "☀️ Ваш день вкратце",
+		digestSummary:   "События: %d · Сроки: %d · На подтверждение: %d",
+		digestEvents:    "События сегодня:",
+		digestReminders: "Срок сегодня:",
+		digestPending:   "Ожидают подтверждения: %d",
+		allDay:          "Весь день",
+
+		// Abbreviations avoid Russian's three plural forms
+		minute: "мин", minutes: "мин",
+		hour: "ч", hours: "ч",
+		day: "дн.", days: "дн.",
+
+		dateTimeLayout:      "02.01 15:04",
+		eventDateTimeLayout: "02.01 15:04",
+		timeLayout:          "15:04",
+	},
+	"es": {
+		newEvent:      "Nuevo evento detectado",
+		eventUpdate:   "Actualización de evento detectada",
+		eventDeletion: "Eliminación de evento detectada",
+		eventsPending: "%d eventos nuevos por revisar",
+
+		newReminder:  "📌 Nuevo recordatorio: %s",
+		noDueDate:    "Sin fecha límite",
+		dueOn:        "Vence: %s",
+		reminder:     "⏰ Recordatorio: %s",
+		reminderNow:  "Es la hora de este recordatorio.",
+		scheduledFor: "Programado para %s",
+		dueIn:        "⏳ Vence en %s: %s",
+		dueAt:        "Vence: %s",
+		overdue:      "🔴 Vencido: %s",
+		wasDue:       "Venció: %s",
+
+		eventStartingNow: "📅 %s empieza ahora",
+		eventIn:          "📅 %s en %s",
+		startsAt:         "Empieza a las %s",
+
+		changedInGoogle:   "✏️ %s se modificó en Google Calendar",
+		deletedInGoogle:   "🗑️ %s se eliminó en Google Calendar",
+		nowOn:             "Ahora: %s",
+		removedFromEvents: "Alfred lo quitó de tus eventos.",
+
+		digestTitle:     "☀️ Tu día de un vistazo",
+		digestSummary:   "Eventos: %d · Vencen: %d · Por confirmar: %d",
+		digestEvents:    "Eventos de hoy:",
+		digestReminders: "Vence hoy:",
+		digestPending:   "Esperando tu confirmación: %d",
+		allDay:          "Todo el día",
+
+		minute: "minuto", minutes: "minutos",
+		hour: "hora", hours: "horas",
+		day: "día", days: "días",
+
+		dateTimeLayout:      "02/01 15:04",
+		eventDateTimeLayout: "02/01 15:04",
+		timeLayout:          "15:04",
+	},
+	"fr": {
+		newEvent:      "Nouvel événement détecté",
+		eventUpdate:   "Mise à jour d'événement détectée",
+		eventDeletion: "Suppression d'événement détectée",
+		eventsPending: "%d nouveaux événements à vérifier",
+
+		newReminder:  "📌 Nouveau rappel : %s",
+		noDueDate:    "Sans échéance",
+		dueOn:        "Échéance : %s",
+		reminder:     "⏰ Rappel : %s",
+		reminderNow:  "C'est l'heure de ce rappel.",
+		scheduledFor: "Prévu le %s",
+		dueIn:        "⏳ Échéance dans %s : %s",
+		dueAt:        "Échéance : %s",
+		overdue:      "🔴 En retard : %s",
+		wasDue:       "Échéance dépassée : %s",
+
+		eventStartingNow: "📅 %s commence maintenant",
+		eventIn:          "📅 %s dans %s",
+		startsAt:         "Commence à %s",
+
+		changedInGoogle:   "✏️ %s a été modifié dans Google Agenda",
+		deletedInGoogle:   "🗑️ %s a été supprimé dans Google Agenda",
+		nowOn:             "Désormais : %s",
+		removedFromEvents: "Alfred l'a retiré de vos événements.",
+
+		digestTitle:     "☀️ Votre journée en un coup d'œil",
+		digestSummary:   "Événements : %d · Échéances : %d · À confirmer : %d",
+		digestEvents:    "Événements du jour :",
+		digestReminders: "À faire aujourd'hui :",
+		digestPending:   "En attente de votre confirmation : %d",
+		allDay:          "Toute la journée",
+
+		minute: "minute", minutes: "minutes",
+		hour: "heure", hours: "heures",
+		day: "jour", days: "jours",
+
+		dateTimeLayout:      "02/01 15:04",
+		eventDateTimeLayout: "02/01 15:04",
+		timeLayout:          "15:04",
+	},
+	"pt": {
+		newEvent:      "Novo evento detectado",
+		eventUpdate:   "Atualização de evento detectada",
+		eventDeletion: "Exclusão de evento detectada",
+		eventsPending: "%d novos eventos para revisar",
+
+		newReminder:  "📌 Novo lembrete: %s",
+		noDueDate:    "Sem data de vencimento",
+		dueOn:        "Vence: %s",
+		reminder:     "⏰ Lembrete: %s",
+		reminderNow:  "Chegou a hora deste lembrete.",
+		scheduledFor: "Agendado para %s",
+		dueIn:        "⏳ Vence em %s: %s",
+		dueAt:        "Vence: %s",
+		overdue:      "🔴 Atrasado: %s",
+		wasDue:       "Venceu: %s",
+
+		eventStartingNow: "📅 %s começa agora",
+		eventIn:          "📅 %s em %s",
+		startsAt:         "Começa às %s",
+
+		changedInGoogle:   "✏️ %s foi alterado no Google Agenda",
+		deletedInGoogle:   "🗑️ %s foi excluído no Google Agenda",
+		nowOn:             "Agora: %s",
+		removedFromEvents: "O Alfred removeu-o dos seus eventos.",
+
+		digestTitle:     "☀️ Seu dia num relance",
+		digestSummary:   "Eventos: %d · Vencem: %d · A confirmar: %d",
+		digestEvents:    "Eventos de hoje:",
+		digestReminders: "Vence hoje:",
+		digestPending:   "Aguardando sua confirmação: %d",
+		allDay:          "Dia inteiro",
+
+		minute: "minuto", minutes: "minutos",
+		hour: "hora", hours: "horas",
+		day: "dia", days: "dias",
+
+		dateTimeLayout:      "02/01 15:04",
+		eventDateTimeLayout: "02/01 15:04",
+		timeLayout:          "15:04",
+	},
+	"de": {
+		newEvent:      "Neuer Termin erkannt",
+		eventUpdate:   "Terminänderung erkannt",
+		eventDeletion: "Terminlöschung erkannt",
+		eventsPending: "%d neue Termine zur Überprüfung",
+
+		newReminder:  "📌 Neue Erinnerung: %s",
+		noDueDate:    "Kein Fälligkeitsdatum",
+		dueOn:        "Fällig: %s",
+		reminder:     "⏰ Erinnerung: %s",
+		reminderNow:  "Zeit für diese Erinnerung.",
+		scheduledFor: "Geplant für %s",
+		dueIn:        "⏳ Fällig in %s: %s",
+		dueAt:        "Fällig: %s",
+		overdue:      "🔴 Überfällig: %s",
+		wasDue:       "War fällig: %s",
+
+		eventStartingNow: "📅 %s beginnt jetzt",
+		eventIn:          "📅 %s in %s",
+		startsAt:         "Beginnt um %s",
+
+		changedInGoogle:   "✏️ %s wurde in Google Kalender geändert",
+		deletedInGoogle:   "🗑️ %s wurde in Google Kalender gelöscht",
+		nowOn:             "Jetzt: %s",
+		removedFromEvents: "Alfred hat es aus deinen Terminen entfernt.",
+
+		digestTitle:     "☀️ Ihr Tag auf einen Blick",
+		digestSummary:   "Termine: %d · Fällig: %d · Zu bestätigen: %d",
+		digestEvents:    "Heutige Termine:",
+		digestReminders: "Heute fällig:",
+		digestPending:   "Warten auf Ihre Bestätigung: %d",
+		allDay:          "Ganztägig",
+
+		// Dative plural, as used after "in"
+		minute: "Minute", minutes: "Minuten",
+		hour: "Stunde", hours: "Stunden",
+		day: "Tag", days: "Tagen",
+
+		dateTimeLayout:      "02.01. 15:04",
+		eventDateTimeLayout: "02.01. 15:04",
+		timeLayout:          "15:04",
+	},
+	"it": {
+		newEvent:      "Nuovo evento rilevato",
+		eventUpdate:   "Aggiornamento evento rilevato",
+		eventDeletion: "Eliminazione evento rilevata",
+		eventsPending: "%d nuovi eventi da rivedere",
+
+		newReminder:  "📌 Nuovo promemoria: %s",
+		noDueDate:    "Nessuna scadenza",
+		dueOn:        "Scadenza: %s",
+		reminder:     "⏰ Promemoria: %s",
+		reminderNow:  "È ora di questo promemoria.",
+		scheduledFor: "Programmato per %s",
+		dueIn:        "⏳ Scade tra %s: %s",
+		dueAt:        "Scadenza: %s",
+		overdue:      "🔴 In ritardo: %s",
+		wasDue:       "Era in scadenza: %s",
+
+		eventStartingNow: "📅 %s inizia ora",
+		eventIn:          "📅 %s tra %s",
+		startsAt:         "Inizia alle %s",
+
+		changedInGoogle:   "✏️ %s è stato modificato in Google Calendar",
+		deletedInGoogle:   "🗑️ %s è stato eliminato in Google Calendar",
+		nowOn:             "Ora: %s",
+		removedFromEvents: "Alfred l'ha rimosso dai tuoi eventi.",
+
+		digestTitle:     "☀️ La tua giornata in breve",
+		digestSummary:   "Eventi: %d · In scadenza: %d · Da confermare: %d",
+		digestEvents:    "Eventi di oggi:",
+		digestReminders: "In scadenza oggi:",
+		digestPending:   "In attesa di conferma: %d",
+		allDay:          "Tutto il giorno",
+
+		minute: "minuto", minutes: "minuti",
+		hour: "ora", hours: "ore",
+		day: "giorno", days: "giorni",
+
+		dateTimeLayout:      "02/01 15:04",
+		eventDateTimeLayout: "02/01 15:04",
+		timeLayout:          "15:04",
+	},
+}
+
+// messagesFor returns the notification text for a locale, defaulting to English
+func messagesFor(locale string) messages {
+	if m, ok := localizedMessages[locale]; ok {
+		return m
+	}
+	return englishMessages
+}
+
+// formatLead renders a lead time as "1 hour", "90 minutes", "2 days".
+func (m messages) formatLead(lead time.Duration) string {
+	minutes := int(lead / time.Minute)
+	plural := func(n int, one, other string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", one)
+		}
+		return fmt.Sprintf("%d %s", n, other)
+	}
+
+	switch {
+	case minutes >= 24*60 && minutes%(24*60) == 0:
+		return plural(minutes/(24*60), m.day, m.days)
+	case minutes >= 60 && minutes%60 == 0:
+		return plural(minutes/60, m.hour, m.hours)
+	default:
+		return plural(minutes, m.minute, m.minutes)
+	}
+}
@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent/langpolicy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessagesFor(t *testing.T) {
+	assert.Equal(t, englishMessages, messagesFor(""))
+	assert.Equal(t, englishMessages, messagesFor("xx"))
+	assert.Equal(t, "📌 תזכורת חדשה: %s", messagesFor("he").newReminder)
+
+	for _, locale := range langpolicy.SupportedLocales() {
+		_, ok := localizedMessages[locale]
+		assert.True(t, ok, "missing notification messages for %s", locale)
+	}
+}
+
+func TestLocalizedFormatLead(t *testing.T) {
+	he := messagesFor("he")
+	assert.Equal(t, "1 שעה", he.formatLead(time.Hour))
+	assert.Equal(t, "15 דקות", he.formatLead(15*time.Minute))
+
+	de := messagesFor("de")
+	assert.Equal(t, "2 Tagen", de.formatLead(48*time.Hour))
+}
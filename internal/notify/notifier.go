@@ -15,3 +15,21 @@ type Notifier interface {
 	// IsConfigured returns true if the notifier has server-side config
 	IsConfigured() bool
 }
+
+// EmailNotifier is a Notifier that delivers by email (Resend or SMTP) and can also send
+// the plain notifications (reminders, digests, exports) that aren't about an event
+type EmailNotifier interface {
+	Notifier
+	// SendSimple sends a plain-text body in the standard Alfred email layout
+	SendSimple(ctx context.Context, recipient, subject, body string) error
+	// SendHTML sends an email whose HTML body has already been rendered
+	SendHTML(ctx context.Context, recipient, subject, htmlBody string) error
+	// SendInvite sends an email carrying invite, an iCalendar METHOD:REQUEST, so the
+	// recipient's mail client offers to add the event and reply to the organizer
+	SendInvite(ctx context.Context, recipient, subject, htmlBody string, invite []byte) error
+	// SetActionSigner enables one-click confirm/reject links in pending event emails
+	SetActionSigner(signer *EmailActionSigner)
+
+	formatEmailHTML(event *database.CalendarEvent) string
+	formatSimpleEmailHTML(subject, body string) string
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// PushMessage is a push notification independent of the service delivering it
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]interface{}
+	Badge *int // App icon badge count; nil leaves the badge as it is
+}
+
+// PushProvider delivers push notifications to device tokens issued by one push service
+// (Expo, FCM or APNs). Tokens the service reports as no longer registered are returned
+// in an *UnregisteredDevicesError when every other token was accepted.
+type PushProvider interface {
+	Name() string
+	IsConfigured() bool
+	SendPush(ctx context.Context, tokens []string, msg PushMessage) error
+}
+
+// simplePushMessage is a push that opens screen in the app
+func simplePushMessage(title, body, screen string) PushMessage {
+	return PushMessage{
+		Title: title,
+		Body:  body,
+		Data:  map[string]interface{}{"screen": screen},
+	}
+}
+
+// eventPushMessage is the push for a new pending event, in the user's locale ("" = English)
+func eventPushMessage(event *database.CalendarEvent, locale string) PushMessage {
+	msgs := messagesFor(locale)
+
+	// Determine title based on action type
+	title := msgs.newEvent
+	switch event.ActionType {
+	case database.EventActionUpdate:
+		title = msgs.eventUpdate
+	case database.EventActionDelete:
+		title = msgs.eventDeletion
+	}
+
+	// Format the body with event title and date
+	body := event.Title
+	if !event.StartTime.IsZero() {
+		body = fmt.Sprintf("%s - %s", event.Title, event.StartTime.Format(msgs.eventDateTimeLayout))
+	}
+
+	return PushMessage{
+		Title: title,
+		Body:  body,
+		Data: map[string]interface{}{
+			"eventId":    event.ID,
+			"actionType": string(event.ActionType),
+			"screen":     "Events",
+		},
+	}
+}
+
+// sendEach sends to services that take one token per request. send reports whether the
+// service rejected the token as no longer registered. Errors follow the same rules as
+// ExpoPushNotifier.sendBatch.
+func sendEach(tokens []string, send func(token string) (unregistered bool, err error)) error {
+	if len(tokens) == 0 {
+		return fmt.Errorf("no push token specified")
+	}
+
+	var unregistered []string
+	var lastErr error
+	delivered := 0
+	for _, token := range tokens {
+		gone, err := send(token)
+		switch {
+		case gone:
+			unregistered = append(unregistered, token)
+		case err != nil:
+			lastErr = err
+		default:
+			delivered++
+		}
+	}
+
+	if delivered == 0 && lastErr != nil {
+		return lastErr
+	}
+	if len(unregistered) > 0 {
+		return &UnregisteredDevicesError{Tokens: unregistered}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePushProvider records pushes and reports tokens in unregistered as gone
+type fakePushProvider struct {
+	unregistered map[string]bool
+	sent         []string
+}
+
+func (f *fakePushProvider) Name() string       { return "fake_push" }
+func (f *fakePushProvider) IsConfigured() bool { return true }
+
+func (f *fakePushProvider) SendPush(ctx context.Context, tokens []string, msg PushMessage) error {
+	return sendEach(tokens, func(token string) (bool, error) {
+		if f.unregistered[token] {
+			return true, nil
+		}
+		f.sent = append(f.sent, token)
+		return false, nil
+	})
+}
+
+func TestSendPushRoutesByProvider(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	require.NoError(t, db.UpdatePushPrefs(user.ID, true))
+
+	_, err := db.RegisterDevice(user.ID, "ExponentPushToken[expo]", database.PushProviderExpo, "ios", "")
+	require.NoError(t, err)
+	_, err = db.RegisterDevice(user.ID, "fcm-token", database.PushProviderFCM, "android", "")
+	require.NoError(t, err)
+	_, err = db.RegisterDevice(user.ID, "fcm-gone", database.PushProviderFCM, "android", "")
+	require.NoError(t, err)
+	_, err = db.RegisterDevice(user.ID, "apns-token", database.PushProviderAPNs, "ios", "")
+	require.NoError(t, err)
+
+	expo, batches := newTestExpoServer(t, nil)
+	fcm := &fakePushProvider{unregistered: map[string]bool{"fcm-gone": true}}
+	service := NewService(db, nil, expo)
+	service.SetPushProvider(database.PushProviderFCM, fcm)
+
+	// APNs isn't configured, so that device is skipped while the others still get the push
+	devices := service.pushDevices(user.ID)
+	require.Len(t, devices, 4)
+	require.NoError(t, service.sendPush(ctx, devices, simplePushMessage("Title", "Body", "Home")))
+
+	assert.Equal(t, []int{1}, *batches)
+	assert.Equal(t, []string{"fcm-token"}, fcm.sent)
+
+	tokens, err := db.GetPushTokens(user.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ExponentPushToken[expo]", "fcm-token", "apns-token"}, tokens)
+}
+
+func TestSendPushFailsWithoutConfiguredProvider(t *testing.T) {
+	service := NewService(database.NewTestDB(t), nil, nil)
+	devices := []database.Device{{PushToken: "apns-token", Provider: database.PushProviderAPNs}}
+
+	assert.False(t, service.canPush(devices))
+	assert.Error(t, service.sendPush(context.Background(), devices, simplePushMessage("Title", "Body", "Home")))
+}
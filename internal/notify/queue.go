@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// Kinds of notification recorded in the delivery queue
+const (
+	kindEventPending       = database.NotificationKindEventPending
+	kindEventStart         = "event_start"
+	kindEventChanged       = "event_changed"
+	kindReminderPending    = database.NotificationKindReminderPending
+	kindReminderDue        = "reminder_due"
+	kindReminderEscalation = "reminder_escalation"
+	kindWhatsAppConnected  = "whatsapp_connected"
+	kindDataExport         = "data_export"
+	kindBudgetExceeded     = "llm_budget_exceeded"
+	kindDigest             = "digest"
+	kindEventInvite        = "event_invite"
+	kindGoogleReauth       = "google_reauth_required"
+)
+
+const (
+	defaultDeliveryPollInterval = 10 * time.Second
+	deliveryBatchSize           = 50
+	// notificationQueueRetention is how long sent and failed notifications are kept
+	notificationQueueRetention = 30 * 24 * time.Hour
+)
+
+// deliveryBackoff is the delay before each retry; a notification is marked failed once
+// it has been attempted len(deliveryBackoff)+1 times.
+var deliveryBackoff = []time.Duration{
+	30 * time.Second,
+	2 * time.Minute,
+	10 * time.Minute,
+	time.Hour,
+}
+
+// maxDeliveryAttempts is the number of delivery attempts before a notification is marked failed
+var maxDeliveryAttempts = len(deliveryBackoff) + 1
+
+// permanentDeliveryError marks a failure retrying can't fix, such as a user with no
+// registered devices or a channel that isn't configured on this server
+type permanentDeliveryError struct {
+	err error
+}
+
+func (e *permanentDeliveryError) Error() string {
+	return e.err.Error()
+}
+
+// StartDeliveryWorker switches the service from sending notifications inline to queueing
+// them in notification_queue, and polls the queue to deliver them with retries.
+// Call it before the service is used.
+func (s *Service) StartDeliveryWorker(ctx context.Context, pollInterval time.Duration) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultDeliveryPollInterval
+	}
+
+	s.queueDeliveries = true
+	go runPolling(ctx, pollInterval, s.processDeliveries)
+}
+
+// queuePush queues msg for every device of the user
+func (s *Service) queuePush(userID int64, kind string, msg PushMessage) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal push data: %w", err)
+	}
+	_, err = s.db.EnqueueNotification(database.QueuedNotification{
+		UserID:  userID,
+		Channel: database.NotificationChannelPush,
+		Kind:    kind,
+		Title:   msg.Title,
+		Body:    msg.Body,
+		Payload: string(data),
+	}, time.Now())
+	return err
+}
+
+// queueEmail queues an email; body is the plain-text summary shown in the delivery API
+func (s *Service) queueEmail(userID int64, kind, recipient, subject, body, html string) error {
+	_, err := s.db.EnqueueNotification(database.QueuedNotification{
+		UserID:    userID,
+		Channel:   database.NotificationChannelEmail,
+		Kind:      kind,
+		Title:     subject,
+		Body:      body,
+		Recipient: recipient,
+		Payload:   html,
+	}, time.Now())
+	return err
+}
+
+func (s *Service) processDeliveries(ctx context.Context) {
+	notifications, err := s.db.GetDueNotifications(time.Now(), deliveryBatchSize)
+	if err != nil {
+		slog.Error("Notification: Failed to fetch queued notifications", "error", err)
+		return
+	}
+
+	for i := range notifications {
+		if ctx.Err() != nil {
+			return
+		}
+		s.deliver(ctx, &notifications[i])
+	}
+
+	if _, err := s.db.PruneNotificationQueue(time.Now().Add(-notificationQueueRetention)); err != nil {
+		slog.Error("Notification: Failed to prune notification queue", "error", err)
+	}
+}
+
+// deliver makes one attempt at a queued notification and records the outcome
+func (s *Service) deliver(ctx context.Context, n *database.QueuedNotification) {
+	attempts := n.Attempts + 1
+
+	var err error
+	switch n.Channel {
+	case database.NotificationChannelPush:
+		err = s.deliverPush(ctx, n)
+	case database.NotificationChannelEmail:
+		err = s.deliverEmail(ctx, n)
+	default:
+		err = &permanentDeliveryError{fmt.Errorf("unknown channel %q", n.Channel)}
+	}
+
+	status := database.NotificationSent
+	var nextAttemptAt *time.Time
+	lastError := ""
+	if err != nil {
+		lastError = err.Error()
+		var permanent *permanentDeliveryError
+		if errors.As(err, &permanent) || attempts >= maxDeliveryAttempts {
+			status = database.NotificationFailed
+			slog.Error("Notification: Delivery failed", "channel", n.Channel, "notification_id", n.ID, "attempts", attempts, "error", err)
+		} else {
+			status = database.NotificationPending
+			next := time.Now().Add(deliveryBackoff[attempts-1])
+			nextAttemptAt = &next
+		}
+	}
+
+	if err := s.db.RecordNotificationAttempt(n.ID, attempts, status, lastError, nextAttemptAt); err != nil {
+		slog.Error("Notification: Failed to record attempt", "notification_id", n.ID, "error", err)
+	}
+}
+
+func (s *Service) deliverPush(ctx context.Context, n *database.QueuedNotification) error {
+	devices := s.pushDevices(n.UserID)
+	if len(devices) == 0 {
+		return &permanentDeliveryError{errors.New("no devices registered")}
+	}
+	if !s.canPush(devices) {
+		return &permanentDeliveryError{errors.New("push not configured for the user's devices")}
+	}
+
+	msg := PushMessage{Title: n.Title, Body: n.Body}
+	if n.Payload != "" {
+		if err := json.Unmarshal([]byte(n.Payload), &msg.Data); err != nil {
+			return &permanentDeliveryError{fmt.Errorf("invalid push data: %w", err)}
+		}
+	}
+	// Once delivered this push is itself unread, unless it is about a pending item
+	unread := 1
+	if n.Kind == kindEventPending || n.Kind == kindReminderPending {
+		unread = 0
+	}
+	msg.Badge = s.badgeCount(n.UserID, unread)
+	return s.sendPush(ctx, devices, msg)
+}
+
+func (s *Service) deliverEmail(ctx context.Context, n *database.QueuedNotification) error {
+	email := s.emailSender()
+	if email == nil {
+		return &permanentDeliveryError{errors.New("email not configured")}
+	}
+	if n.Kind == kindEventInvite {
+		return deliverInvite(ctx, email, n)
+	}
+	return email.SendHTML(ctx, n.Recipient, n.Title, n.Payload)
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPushProvider rejects every push with a retryable error
+type failingPushProvider struct{}
+
+func (failingPushProvider) Name() string       { return "failing_push" }
+func (failingPushProvider) IsConfigured() bool { return true }
+
+func (failingPushProvider) SendPush(ctx context.Context, tokens []string, msg PushMessage) error {
+	return errors.New("provider unavailable")
+}
+
+func TestQueuedPushIsDeliveredByWorker(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	_, err := db.RegisterDevice(user.ID, "ExponentPushToken[expo]", database.PushProviderExpo, "ios", "")
+	require.NoError(t, err)
+
+	expo, batches := newTestExpoServer(t, nil)
+	service := NewService(db, nil, expo)
+	service.queueDeliveries = true
+
+	devices := service.pushDevices(user.ID)
+	require.NoError(t, service.sendSimplePush(ctx, user.ID, kindDigest, devices, "Title", "Body", "Home"))
+	assert.Empty(t, *batches, "queued pushes are not sent inline")
+
+	service.processDeliveries(ctx)
+	assert.Equal(t, []int{1}, *batches)
+
+	queued, err := db.ListQueuedNotifications(user.ID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, database.NotificationSent, queued[0].Status)
+	assert.Equal(t, 1, queued[0].Attempts)
+	assert.Equal(t, kindDigest, queued[0].Kind)
+	assert.NotNil(t, queued[0].SentAt)
+}
+
+func TestDeliverRetriesThenFails(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	_, err := db.RegisterDevice(user.ID, "fcm-token", database.PushProviderFCM, "android", "")
+	require.NoError(t, err)
+
+	service := NewService(db, nil, nil)
+	service.SetPushProvider(database.PushProviderFCM, failingPushProvider{})
+	service.queueDeliveries = true
+	require.NoError(t, service.queuePush(user.ID, kindReminderDue, simplePushMessage("Title", "Body", "Home")))
+
+	queued, err := db.ListQueuedNotifications(user.ID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+
+	service.deliver(ctx, &queued[0])
+	n, err := db.GetQueuedNotification(queued[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.NotificationPending, n.Status)
+	assert.Equal(t, 1, n.Attempts)
+	assert.Equal(t, "provider unavailable", n.LastError)
+	require.NotNil(t, n.NextAttemptAt)
+
+	// The last allowed attempt marks the notification failed instead of rescheduling it
+	n.Attempts = maxDeliveryAttempts - 1
+	service.deliver(ctx, n)
+	n, err = db.GetQueuedNotification(n.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.NotificationFailed, n.Status)
+	assert.Equal(t, maxDeliveryAttempts, n.Attempts)
+	assert.Nil(t, n.NextAttemptAt)
+}
+
+func TestDeliverFailsPermanentlyWithoutDevices(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	service := NewService(db, nil, nil)
+	require.NoError(t, service.queuePush(user.ID, kindEventStart, simplePushMessage("Title", "Body", "Home")))
+
+	service.processDeliveries(context.Background())
+
+	failed := database.NotificationFailed
+	queued, err := db.ListQueuedNotifications(user.ID, &failed, 10)
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, 1, queued[0].Attempts)
+	assert.Equal(t, "no devices registered", queued[0].LastError)
+}
+
+func TestQueuedPushCarriesBadgeCount(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	_, err := db.RegisterDevice(user.ID, "fcm-token", database.PushProviderFCM, "android", "")
+	require.NoError(t, err)
+
+	provider := &recordingPushProvider{}
+	service := NewService(db, nil, nil)
+	service.SetPushProvider(database.PushProviderFCM, provider)
+	service.queueDeliveries = true
+
+	// An earlier push the user hasn't read yet
+	require.NoError(t, service.queuePush(user.ID, kindDigest, simplePushMessage("Digest", "Body", "Home")))
+	service.processDeliveries(ctx)
+
+	// The new push counts itself, a push about a pending item doesn't
+	require.NoError(t, service.queuePush(user.ID, kindReminderDue, simplePushMessage("Due", "Body", "Reminders")))
+	require.NoError(t, service.queuePush(user.ID, kindReminderPending, simplePushMessage("New", "Body", "Reminders")))
+	service.processDeliveries(ctx)
+
+	sent := provider.messages()
+	require.Len(t, sent, 3)
+	badges := make([]int, len(sent))
+	for i, msg := range sent {
+		require.NotNil(t, msg.Badge)
+		badges[i] = *msg.Badge
+	}
+	assert.Equal(t, []int{1, 2, 2}, badges)
+}
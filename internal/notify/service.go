@@ -2,77 +2,127 @@ package notify
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
 	"time"
 
 	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/sse"
+	"github.com/omriShneor/project_alfred/internal/webhook"
 )
 
 const (
 	defaultDueReminderPollInterval = time.Minute
 	dueReminderBatchSize           = 50
+	upcomingEventBatchSize         = 100
 )
 
 // Service orchestrates notifications based on user preferences
 type Service struct {
-	db            *database.DB
-	emailNotifier Notifier
-	pushNotifier  Notifier
+	db                 *database.DB
+	emailNotifier      Notifier
+	pushNotifier       Notifier
+	dueReminderOffsets []time.Duration
+	streams            *sse.StateManager
+	webhooks           *webhook.Dispatcher
+	digestCalendar     DigestCalendar
+	pushProviders      map[string]PushProvider // non-Expo push services by provider name
+	queueDeliveries    bool                    // set by StartDeliveryWorker
+	batcher            *pushBatcher            // nil unless SetPushBatchWindow enabled batching
 }
 
 // NewService creates a notification service
 func NewService(db *database.DB, emailNotifier Notifier, pushNotifier Notifier) *Service {
 	return &Service{
-		db:            db,
-		emailNotifier: emailNotifier,
-		pushNotifier:  pushNotifier,
+		db:                 db,
+		emailNotifier:      emailNotifier,
+		pushNotifier:       pushNotifier,
+		dueReminderOffsets: []time.Duration{0},
+	}
+}
+
+// SetPushProvider routes pushes for devices registered with provider (database.PushProviderFCM
+// or database.PushProviderAPNs) through sender. Expo devices use the service's push notifier.
+func (s *Service) SetPushProvider(provider string, sender PushProvider) {
+	if s.pushProviders == nil {
+		s.pushProviders = make(map[string]PushProvider)
+	}
+	s.pushProviders[provider] = sender
+}
+
+// SetStreams enables publishing pending items to users' /api/stream subscribers
+func (s *Service) SetStreams(streams *sse.StateManager) {
+	s.streams = streams
+}
+
+// SetWebhooks enables queuing outbound webhook deliveries for events and reminders
+func (s *Service) SetWebhooks(webhooks *webhook.Dispatcher) {
+	s.webhooks = webhooks
+}
+
+// publish sends an update to a user's open streams. Streams are not gated by
+// notification preferences since they only reach an app that is already open.
+func (s *Service) publish(userID int64, updateType string, payload any) {
+	if s.streams == nil {
+		return
+	}
+	if err := s.streams.Publish(userID, updateType, payload); err != nil {
+		slog.Error("Notification: Stream publish failed", "error", err)
 	}
 }
 
 // NotifyPendingEvent sends notifications for a new pending event
 // based on user preferences. Errors are logged but don't fail the operation.
 func (s *Service) NotifyPendingEvent(ctx context.Context, event *database.CalendarEvent) {
-	fmt.Printf("Notification: Processing event %d (%s) for user %d\n", event.ID, event.Title, event.UserID)
+	slog.Info("Notification: Processing event", "event_id", event.ID, "title", event.Title, "user_id", event.UserID)
+
+	s.publish(event.UserID, sse.UpdateEventPending, event)
+	s.webhooks.Enqueue(event.UserID, webhook.EventCreated, event)
 
 	prefs, err := s.db.GetUserNotificationPrefs(event.UserID)
 	if err != nil {
-		fmt.Printf("Notification: Failed to get prefs: %v\n", err)
+		slog.Error("Notification: Failed to get prefs", "error", err)
 		return
 	}
 
-	fmt.Printf("Notification: Prefs loaded - email_enabled=%v, email_address=%q\n",
-		prefs.EmailEnabled, prefs.EmailAddress)
+	slog.Info("Notification: Prefs loaded", "email_enabled", prefs.EmailEnabled, "email_address", prefs.EmailAddress)
 
 	// Email notification
 	if prefs.EmailEnabled && prefs.EmailAddress != "" {
 		if s.emailNotifier != nil && s.emailNotifier.IsConfigured() {
-			fmt.Printf("Notification: Sending email to %s\n", prefs.EmailAddress)
-			if err := s.emailNotifier.Send(ctx, event, prefs.EmailAddress); err != nil {
-				fmt.Printf("Notification: Email failed: %v\n", err)
+			slog.Info("Notification: Sending email", "email_address", prefs.EmailAddress)
+			if err := s.sendEventEmail(ctx, event, prefs.EmailAddress); err != nil {
+				slog.Error("Notification: Email failed", "error", err)
 			} else {
-				fmt.Printf("Notification: Email sent successfully\n")
+				slog.Info("Notification: Email sent successfully")
 			}
 		} else {
-			fmt.Println("Notification: Email enabled but server not configured (no API key)")
+			slog.Info("Notification: Email enabled but server not configured (no API key)")
 		}
 	} else {
-		fmt.Println("Notification: Email not enabled or no address configured")
+		slog.Info("Notification: Email not enabled or no address configured")
 	}
 
 	// Push notification
-	if prefs.PushEnabled && prefs.PushToken != "" {
-		if s.pushNotifier != nil && s.pushNotifier.IsConfigured() {
-			fmt.Printf("Notification: Sending push to token %s...\n", prefs.PushToken[:20]+"...")
-			if err := s.pushNotifier.Send(ctx, event, prefs.PushToken); err != nil {
-				fmt.Printf("Notification: Push failed: %v\n", err)
+	devices := s.pushDevices(event.UserID)
+	if prefs.PushEnabled && len(devices) > 0 {
+		if s.IsPushAvailable() && s.batcher != nil {
+			s.batchEventPush(event)
+			slog.Info("Notification: Push batched")
+		} else if s.IsPushAvailable() {
+			slog.Info("Notification: Sending push", "devices", len(devices))
+			if err := s.sendEventPush(ctx, event, devices, s.userLocale(event.UserID)); err != nil {
+				slog.Error("Notification: Push failed", "error", err)
 			} else {
-				fmt.Printf("Notification: Push sent successfully\n")
+				slog.Info("Notification: Push sent successfully")
 			}
 		} else {
-			fmt.Println("Notification: Push enabled but notifier not configured")
+			slog.Info("Notification: Push enabled but notifier not configured")
 		}
 	} else {
-		fmt.Println("Notification: Push not enabled or no token registered")
+		slog.Info("Notification: Push not enabled or no token registered")
 	}
 
 	// Future: SMS notification
@@ -82,6 +132,45 @@ func (s *Service) NotifyPendingEvent(ctx context.Context, event *database.Calend
 	// if prefs.WebhookEnabled && prefs.WebhookURL != "" && s.webhookNotifier != nil { ... }
 }
 
+// NotifyEventConfirmed queues event.confirmed webhooks for an event the user confirmed
+func (s *Service) NotifyEventConfirmed(event *database.CalendarEvent) {
+	s.webhooks.Enqueue(event.UserID, webhook.EventConfirmed, event)
+}
+
+// NotifyEventChangedInGoogle tells the user that a synced event was edited or deleted
+// directly in Google Calendar and Alfred followed, on their stream and by push
+func (s *Service) NotifyEventChangedInGoogle(ctx context.Context, event *database.CalendarEvent, deleted bool) {
+	change := "edited"
+	if deleted {
+		change = "deleted"
+	}
+	s.publish(event.UserID, sse.UpdateEventChanged, map[string]any{
+		"event":  event,
+		"change": change,
+	})
+
+	prefs, err := s.db.GetUserNotificationPrefs(event.UserID)
+	if err != nil {
+		slog.Error("Notification: Failed to get prefs", "error", err)
+		return
+	}
+	devices := s.pushDevices(event.UserID)
+	if !prefs.PushEnabled || len(devices) == 0 || !s.canPush(devices) {
+		return
+	}
+
+	msgs := messagesFor(s.userLocale(event.UserID))
+	title := fmt.Sprintf(msgs.changedInGoogle, event.Title)
+	body := fmt.Sprintf(msgs.nowOn, event.StartTime.Local().Format(msgs.eventDateTimeLayout))
+	if deleted {
+		title = fmt.Sprintf(msgs.deletedInGoogle, event.Title)
+		body = msgs.removedFromEvents
+	}
+	if err := s.sendSimplePush(ctx, event.UserID, kindEventChanged, devices, title, body, "Home"); err != nil {
+		slog.Error("Notification: Event change push failed", "event_id", event.ID, "error", err)
+	}
+}
+
 // IsEmailAvailable returns true if email notifications can be used
 func (s *Service) IsEmailAvailable() bool {
 	return s.emailNotifier != nil && s.emailNotifier.IsConfigured()
@@ -89,48 +178,256 @@ func (s *Service) IsEmailAvailable() bool {
 
 // IsPushAvailable returns true if push notifications can be used
 func (s *Service) IsPushAvailable() bool {
-	return s.pushNotifier != nil && s.pushNotifier.IsConfigured()
+	if s.pushNotifier != nil && s.pushNotifier.IsConfigured() {
+		return true
+	}
+	for _, provider := range s.pushProviders {
+		if provider.IsConfigured() {
+			return true
+		}
+	}
+	return false
+}
+
+// userLocale returns the user's preferred language for notification text ("" = English)
+func (s *Service) userLocale(userID int64) string {
+	locale, err := s.db.GetUserLocale(userID)
+	if err != nil {
+		slog.Error("Notification: Failed to load locale", "error", err)
+		return ""
+	}
+	return locale
+}
+
+// pushDevices returns every device the user has registered for push
+func (s *Service) pushDevices(userID int64) []database.Device {
+	devices, err := s.db.ListDevices(userID)
+	if err != nil {
+		slog.Error("Notification: Failed to load devices", "error", err)
+		return nil
+	}
+	return devices
+}
+
+// pushProvider returns the configured sender for a device's push provider, or nil
+func (s *Service) pushProvider(provider string) PushProvider {
+	var sender PushProvider
+	if provider == "" || provider == database.PushProviderExpo {
+		sender, _ = s.pushNotifier.(PushProvider)
+	} else {
+		sender = s.pushProviders[provider]
+	}
+	if sender == nil || !sender.IsConfigured() {
+		return nil
+	}
+	return sender
+}
+
+// canPush reports whether at least one of the devices can be reached
+func (s *Service) canPush(devices []database.Device) bool {
+	for _, device := range devices {
+		if s.pushProvider(device.Provider) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// sendEventEmail emails a pending event, or queues the email when the delivery worker is running
+func (s *Service) sendEventEmail(ctx context.Context, event *database.CalendarEvent, recipient string) error {
+	if email, ok := s.emailNotifier.(EmailNotifier); ok && s.queueDeliveries {
+		return s.queueEmail(event.UserID, kindEventPending, recipient, eventEmailSubject(event), event.Title, email.formatEmailHTML(event))
+	}
+	return s.emailNotifier.Send(ctx, event, recipient)
+}
+
+// emailSender returns the email notifier when one is configured, or nil
+func (s *Service) emailSender() EmailNotifier {
+	email, ok := s.emailNotifier.(EmailNotifier)
+	if !ok || !email.IsConfigured() {
+		return nil
+	}
+	return email
+}
+
+// sendEventPush sends a pending event push to each device, or queues it when the delivery
+// worker is running. A push notifier that isn't a PushProvider is called once per device.
+func (s *Service) sendEventPush(ctx context.Context, event *database.CalendarEvent, devices []database.Device, locale string) error {
+	if _, ok := s.pushNotifier.(PushProvider); ok || s.pushNotifier == nil {
+		msg := eventPushMessage(event, locale)
+		if s.queueDeliveries {
+			return s.queuePush(event.UserID, kindEventPending, msg)
+		}
+		return s.sendPush(ctx, devices, msg)
+	}
+
+	var lastErr error
+	delivered := 0
+	for _, device := range devices {
+		if err := s.pushNotifier.Send(ctx, event, device.PushToken); err != nil {
+			lastErr = err
+		} else {
+			delivered++
+		}
+	}
+	if delivered == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// sendSimplePush sends a simple push to each of the user's devices, or queues it when the
+// delivery worker is running
+func (s *Service) sendSimplePush(ctx context.Context, userID int64, kind string, devices []database.Device, title, body, screen string) error {
+	msg := simplePushMessage(title, body, screen)
+	if s.queueDeliveries {
+		return s.queuePush(userID, kind, msg)
+	}
+	return s.sendPush(ctx, devices, msg)
+}
+
+// sendSimpleEmail sends a plain email, or queues it when the delivery worker is running
+func (s *Service) sendSimpleEmail(ctx context.Context, email EmailNotifier, userID int64, kind, recipient, subject, body string) error {
+	if s.queueDeliveries {
+		return s.queueEmail(userID, kind, recipient, subject, body, email.formatSimpleEmailHTML(subject, body))
+	}
+	return email.SendSimple(ctx, recipient, subject, body)
+}
+
+// sendPush delivers msg to the devices, grouped by push provider so each service gets
+// one call. It fails only if no provider accepted the push.
+func (s *Service) sendPush(ctx context.Context, devices []database.Device, msg PushMessage) error {
+	if msg.Badge == nil && len(devices) > 0 {
+		msg.Badge = s.badgeCount(devices[0].UserID, 0)
+	}
+
+	tokensByProvider := make(map[string][]string)
+	var providers []string
+	for _, device := range devices {
+		provider := device.Provider
+		if provider == "" {
+			provider = database.PushProviderExpo
+		}
+		if _, ok := tokensByProvider[provider]; !ok {
+			providers = append(providers, provider)
+		}
+		tokensByProvider[provider] = append(tokensByProvider[provider], device.PushToken)
+	}
+
+	var lastErr error
+	delivered := 0
+	for _, provider := range providers {
+		sender := s.pushProvider(provider)
+		if sender == nil {
+			lastErr = fmt.Errorf("%s push not configured", provider)
+			continue
+		}
+		if err := s.pruneUnregisteredDevices(sender.SendPush(ctx, tokensByProvider[provider], msg)); err != nil {
+			lastErr = err
+			continue
+		}
+		delivered++
+	}
+	if delivered == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// badgeCount is the app icon badge for a push to the user: their pending counts plus
+// extra. It is nil, leaving the badge alone, when the counts can't be read.
+func (s *Service) badgeCount(userID int64, extra int) *int {
+	if s.db == nil || userID == 0 {
+		return nil
+	}
+	counts, err := s.db.GetPendingCounts(userID)
+	if err != nil {
+		slog.Warn("Notification: Failed to count pending items for badge", "user_id", userID, "error", err)
+		return nil
+	}
+	badge := counts.Total + extra
+	return &badge
+}
+
+// pruneUnregisteredDevices removes devices the push service no longer recognizes. Since every other
+// device received the push, an *UnregisteredDevicesError is not treated as a failure.
+func (s *Service) pruneUnregisteredDevices(err error) error {
+	var unregistered *UnregisteredDevicesError
+	if !errors.As(err, &unregistered) {
+		return err
+	}
+	if err := s.db.DeleteDevicesByPushToken(unregistered.Tokens); err != nil {
+		slog.Error("Notification: Failed to remove unregistered devices", "error", err)
+	} else {
+		slog.Info("Notification: Removed unregistered devices", "devices", len(unregistered.Tokens))
+	}
+	return nil
 }
 
 // NotifyPendingReminder sends notifications for a new pending reminder
 // based on user preferences. Errors are logged but don't fail the operation.
 func (s *Service) NotifyPendingReminder(ctx context.Context, reminder *database.Reminder) {
-	fmt.Printf("Notification: Processing reminder %d (%s) for user %d\n", reminder.ID, reminder.Title, reminder.UserID)
+	slog.Info("Notification: Processing reminder", "reminder_id", reminder.ID, "title", reminder.Title, "user_id", reminder.UserID)
+
+	s.publish(reminder.UserID, sse.UpdateReminderPending, reminder)
 
 	prefs, err := s.db.GetUserNotificationPrefs(reminder.UserID)
 	if err != nil {
-		fmt.Printf("Notification: Failed to get prefs: %v\n", err)
+		slog.Error("Notification: Failed to get prefs", "error", err)
 		return
 	}
 
 	// Push notification
-	if prefs.PushEnabled && prefs.PushToken != "" {
-		expoPush, ok := s.pushNotifier.(*ExpoPushNotifier)
-		if ok && expoPush.IsConfigured() {
-			body := "No due date"
+	devices := s.pushDevices(reminder.UserID)
+	if prefs.PushEnabled && len(devices) > 0 {
+		if s.canPush(devices) {
+			msgs := messagesFor(s.userLocale(reminder.UserID))
+			body := msgs.noDueDate
 			if reminder.DueDate != nil {
-				body = fmt.Sprintf("Due: %s", reminder.DueDate.Format("Jan 2 at 3:04 PM"))
+				body = fmt.Sprintf(msgs.dueOn, reminder.DueDate.Format(msgs.dateTimeLayout))
 			}
 			if reminder.Description != "" {
 				body = reminder.Description + "\n" + body
 			}
-			err = expoPush.SendSimple(
+			err = s.sendSimplePush(
 				ctx,
-				prefs.PushToken,
-				"📌 New Reminder: "+reminder.Title,
+				reminder.UserID,
+				kindReminderPending,
+				devices,
+				fmt.Sprintf(msgs.newReminder, reminder.Title),
 				body,
 				"Reminders",
 			)
 			if err != nil {
-				fmt.Printf("Notification: Push failed: %v\n", err)
+				slog.Error("Notification: Push failed", "error", err)
 			} else {
-				fmt.Printf("Notification: Push sent successfully for reminder\n")
+				slog.Info("Notification: Push sent successfully for reminder")
 			}
 		}
 	}
 }
 
-// StartDueReminderWorker polls for due reminders and sends one-time push notifications.
+// SetDueReminderOffsets configures when due-date notifications fire, as durations before
+// the due date. A zero offset is the at-due notification (honoring reminder_time).
+// Offsets are deduplicated, rounded to whole minutes, and processed longest lead first.
+func (s *Service) SetDueReminderOffsets(offsets []time.Duration) {
+	seen := make(map[time.Duration]bool)
+	normalized := make([]time.Duration, 0, len(offsets))
+	for _, offset := range offsets {
+		offset = offset.Truncate(time.Minute)
+		if offset < 0 || seen[offset] {
+			continue
+		}
+		seen[offset] = true
+		normalized = append(normalized, offset)
+	}
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i] > normalized[j] })
+	s.dueReminderOffsets = normalized
+}
+
+// StartDueReminderWorker polls for reminders approaching or reaching their due date
+// and sends one-time push/email notifications at each configured offset. Overdue
+// reminders are then re-notified under each user's escalation rules.
 func (s *Service) StartDueReminderWorker(ctx context.Context, pollInterval time.Duration) {
 	if s == nil || s.db == nil {
 		return
@@ -139,126 +436,453 @@ func (s *Service) StartDueReminderWorker(ctx context.Context, pollInterval time.
 		pollInterval = defaultDueReminderPollInterval
 	}
 
-	go func() {
-		ticker := time.NewTicker(pollInterval)
-		defer ticker.Stop()
+	go runPolling(ctx, pollInterval, s.processDueReminders)
+}
 
-		s.processDueReminders(ctx)
+// StartEventNotificationWorker polls for confirmed/synced events about to start and sends
+// one-time push notifications at each of the event's effective offsets.
+func (s *Service) StartEventNotificationWorker(ctx context.Context, pollInterval time.Duration) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultDueReminderPollInterval
+	}
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				s.processDueReminders(ctx)
-			}
+	go runPolling(ctx, pollInterval, s.processEventStartNotifications)
+}
+
+// runPolling runs fn immediately and then on every tick until ctx is cancelled
+func runPolling(ctx context.Context, pollInterval time.Duration, fn func(context.Context)) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	fn(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
 		}
-	}()
+	}
 }
 
 func (s *Service) processDueReminders(ctx context.Context) {
-	reminders, err := s.db.GetDueRemindersForNotification(time.Now(), dueReminderBatchSize)
+	now := time.Now()
+	for _, offset := range s.dueReminderOffsets {
+		if offset == 0 {
+			s.processAtDueReminders(ctx, now)
+		} else {
+			s.processLeadReminders(ctx, now, offset)
+		}
+	}
+	s.processReminderEscalations(ctx, now)
+}
+
+func (s *Service) processAtDueReminders(ctx context.Context, now time.Time) {
+	reminders, err := s.db.GetDueRemindersForNotification(now, dueReminderBatchSize)
 	if err != nil {
-		fmt.Printf("Notification: Failed to fetch due reminders: %v\n", err)
+		slog.Error("Notification: Failed to fetch due reminders", "error", err)
 		return
 	}
-	if len(reminders) == 0 {
+
+	for i := range reminders {
+		reminder := &reminders[i]
+
+		scheduledAt := reminder.DueDate
+		if reminder.ReminderTime != nil {
+			scheduledAt = reminder.ReminderTime
+		}
+
+		msgs := messagesFor(s.userLocale(reminder.UserID))
+		body := msgs.reminderNow
+		if scheduledAt != nil {
+			body = fmt.Sprintf(msgs.scheduledFor, scheduledAt.Local().Format(msgs.dateTimeLayout))
+		}
+		if reminder.Description != "" {
+			body = reminder.Description + "\n" + body
+		}
+
+		processed, err := s.sendReminderNotification(ctx, reminder, kindReminderDue, fmt.Sprintf(msgs.reminder, reminder.Title), body)
+		if err != nil {
+			slog.Error("Notification: Failed sending due reminder", "reminder_id", reminder.ID, "error", err)
+			continue
+		}
+		if !processed {
+			continue
+		}
+
+		marked, err := s.db.MarkReminderDueNotificationSent(reminder.ID, time.Now())
+		if err != nil {
+			slog.Error("Notification: Failed to mark reminder as notified", "reminder_id", reminder.ID, "error", err)
+			continue
+		}
+		if marked {
+			s.webhooks.Enqueue(reminder.UserID, webhook.ReminderDue, reminder)
+		}
+	}
+}
+
+func (s *Service) processLeadReminders(ctx context.Context, now time.Time, lead time.Duration) {
+	reminders, err := s.db.GetRemindersForLeadNotification(now, lead, dueReminderBatchSize)
+	if err != nil {
+		slog.Error("Notification: Failed to fetch reminders due within", "lead", lead, "error", err)
 		return
 	}
 
 	for i := range reminders {
 		reminder := &reminders[i]
 
-		processed, err := s.sendDueReminderNotification(ctx, reminder)
+		msgs := messagesFor(s.userLocale(reminder.UserID))
+		body := fmt.Sprintf(msgs.dueAt, reminder.DueDate.Local().Format(msgs.dateTimeLayout))
+		if reminder.Description != "" {
+			body = reminder.Description + "\n" + body
+		}
+
+		processed, err := s.sendReminderNotification(ctx, reminder, kindReminderDue, fmt.Sprintf(msgs.dueIn, msgs.formatLead(lead), reminder.Title), body)
 		if err != nil {
-			fmt.Printf("Notification: Failed sending due reminder %d: %v\n", reminder.ID, err)
+			slog.Error("Notification: Failed sending lead reminder", "reminder_id", reminder.ID, "error", err)
 			continue
 		}
 		if !processed {
 			continue
 		}
 
-		if _, err := s.db.MarkReminderDueNotificationSent(reminder.ID, time.Now()); err != nil {
-			fmt.Printf("Notification: Failed to mark reminder %d as notified: %v\n", reminder.ID, err)
+		if _, err := s.db.MarkReminderLeadNotificationSent(reminder.ID, lead, time.Now()); err != nil {
+			slog.Error("Notification: Failed to mark reminder lead notification", "reminder_id", reminder.ID, "error", err)
 		}
 	}
 }
 
-func (s *Service) sendDueReminderNotification(ctx context.Context, reminder *database.Reminder) (bool, error) {
-	prefs, err := s.db.GetUserNotificationPrefs(reminder.UserID)
+// processReminderEscalations re-notifies overdue reminders under each user's escalation
+// rules until they are completed, dismissed or snoozed
+func (s *Service) processReminderEscalations(ctx context.Context, now time.Time) {
+	reminders, err := s.db.GetRemindersForEscalation(now, dueReminderBatchSize)
+	if err != nil {
+		slog.Error("Notification: Failed to fetch reminders for escalation", "error", err)
+		return
+	}
+
+	for i := range reminders {
+		reminder := &reminders[i]
+
+		msgs := messagesFor(s.userLocale(reminder.UserID))
+		body := fmt.Sprintf(msgs.wasDue, reminder.DueDate.Local().Format(msgs.dateTimeLayout))
+		if reminder.Description != "" {
+			body = reminder.Description + "\n" + body
+		}
+
+		processed, err := s.sendReminderNotification(ctx, reminder, kindReminderEscalation, fmt.Sprintf(msgs.overdue, reminder.Title), body)
+		if err != nil {
+			slog.Error("Notification: Failed sending reminder escalation", "reminder_id", reminder.ID, "error", err)
+			continue
+		}
+		if !processed {
+			continue
+		}
+
+		if err := s.db.MarkReminderEscalated(reminder.ID, time.Now()); err != nil {
+			slog.Error("Notification: Failed to mark reminder escalated", "reminder_id", reminder.ID, "error", err)
+		}
+	}
+}
+
+// processEventStartNotifications pages through the events in the notification window.
+// Events stay in the window after they are notified, so every poll reads it to the end
+// rather than only its first batch.
+func (s *Service) processEventStartNotifications(ctx context.Context) {
+	now := time.Now()
+	var lastID int64
+	for ctx.Err() == nil {
+		upcoming, err := s.db.GetUpcomingEventsForNotification(now, lastID, upcomingEventBatchSize)
+		if err != nil {
+			slog.Error("Notification: Failed to fetch upcoming events", "error", err)
+			return
+		}
+		s.notifyUpcomingEvents(ctx, now, upcoming)
+		if len(upcoming) < upcomingEventBatchSize {
+			return
+		}
+		lastID = upcoming[len(upcoming)-1].Event.ID
+	}
+}
+
+// notifyUpcomingEvents sends one start notification per event with offsets that have come due
+func (s *Service) notifyUpcomingEvents(ctx context.Context, now time.Time, upcoming []database.UpcomingEventNotification) {
+	for i := range upcoming {
+		event := &upcoming[i].Event
+
+		sent, err := s.db.GetSentEventStartOffsets(event.ID, event.StartTime)
+		if err != nil {
+			slog.Error("Notification: Failed to load sent notifications", "event_id", event.ID, "error", err)
+			continue
+		}
+
+		// Collapse every offset that has come due into a single notification, so an event
+		// created shortly before it starts doesn't trigger a burst.
+		var due []int
+		for _, minutes := range upcoming[i].Offsets {
+			if sent[minutes] {
+				continue
+			}
+			if !event.StartTime.Add(-time.Duration(minutes) * time.Minute).After(now) {
+				due = append(due, minutes)
+			}
+		}
+		if len(due) == 0 {
+			continue
+		}
+
+		processed, err := s.sendEventStartNotification(ctx, event, event.StartTime.Sub(now))
+		if err != nil {
+			slog.Error("Notification: Failed sending start notification", "event_id", event.ID, "error", err)
+			continue
+		}
+		if !processed {
+			continue
+		}
+
+		for _, minutes := range due {
+			if err := s.db.MarkEventStartNotificationSent(event.ID, minutes, event.StartTime, time.Now()); err != nil {
+				slog.Error("Notification: Failed to mark event as notified", "event_id", event.ID, "error", err)
+			}
+		}
+	}
+}
+
+func (s *Service) sendEventStartNotification(ctx context.Context, event *database.CalendarEvent, remaining time.Duration) (bool, error) {
+	prefs, err := s.db.GetUserNotificationPrefs(event.UserID)
 	if err != nil {
 		return false, fmt.Errorf("load notification prefs: %w", err)
 	}
 
 	// If push isn't enabled for this user, mark as processed to avoid reprocessing forever.
-	if !prefs.PushEnabled || prefs.PushToken == "" {
+	devices := s.pushDevices(event.UserID)
+	if !prefs.PushEnabled || len(devices) == 0 {
 		return true, nil
 	}
 
-	expoPush, ok := s.pushNotifier.(*ExpoPushNotifier)
-	if !ok || expoPush == nil || !expoPush.IsConfigured() {
-		fmt.Println("Notification: Due reminder push skipped - notifier not configured")
+	if !s.canPush(devices) {
+		slog.Info("Notification: Event start push skipped - notifier not configured")
 		return true, nil
 	}
 
-	scheduledAt := reminder.DueDate
-	if reminder.ReminderTime != nil {
-		scheduledAt = reminder.ReminderTime
+	msgs := messagesFor(s.userLocale(event.UserID))
+	title := fmt.Sprintf(msgs.eventStartingNow, event.Title)
+	if remaining = remaining.Round(time.Minute); remaining >= time.Minute {
+		title = fmt.Sprintf(msgs.eventIn, event.Title, msgs.formatLead(remaining))
 	}
 
-	body := "It's time for this reminder."
-	if scheduledAt != nil {
-		body = fmt.Sprintf("Scheduled for %s", scheduledAt.Local().Format("Jan 2 at 3:04 PM"))
-	}
-	if reminder.Description != "" {
-		body = reminder.Description + "\n" + body
+	body := fmt.Sprintf(msgs.startsAt, event.StartTime.Local().Format(msgs.timeLayout))
+	if event.Location != "" {
+		body += "\n" + event.Location
 	}
 
-	if err := expoPush.SendSimple(
-		ctx,
-		prefs.PushToken,
-		"⏰ Reminder: "+reminder.Title,
-		body,
-		"Home",
-	); err != nil {
+	if err := s.sendSimplePush(ctx, event.UserID, kindEventStart, devices, title, body, "Home"); err != nil {
 		return false, err
 	}
 
-	fmt.Printf("Notification: Due reminder push sent for reminder %d\n", reminder.ID)
+	slog.Info("Notification: Event start push sent", "event_id", event.ID)
 	return true, nil
 }
 
+// sendReminderNotification delivers a reminder notification over every channel the user
+// has enabled. It reports processed=false only when every attempted channel failed, so
+// the worker retries without duplicating deliveries that already succeeded.
+func (s *Service) sendReminderNotification(ctx context.Context, reminder *database.Reminder, kind, title, body string) (bool, error) {
+	prefs, err := s.db.GetUserNotificationPrefs(reminder.UserID)
+	if err != nil {
+		return false, fmt.Errorf("load notification prefs: %w", err)
+	}
+
+	attempted, delivered := 0, 0
+	var lastErr error
+
+	if devices := s.pushDevices(reminder.UserID); prefs.PushEnabled && len(devices) > 0 {
+		if s.canPush(devices) {
+			attempted++
+			if err := s.sendSimplePush(ctx, reminder.UserID, kind, devices, title, body, "Home"); err != nil {
+				lastErr = err
+			} else {
+				delivered++
+			}
+		} else {
+			slog.Info("Notification: Reminder push skipped - notifier not configured")
+		}
+	}
+
+	if prefs.EmailEnabled && prefs.EmailAddress != "" {
+		if email := s.emailSender(); email != nil {
+			attempted++
+			if err := s.sendSimpleEmail(ctx, email, reminder.UserID, kind, prefs.EmailAddress, title, body); err != nil {
+				lastErr = err
+			} else {
+				delivered++
+			}
+		} else {
+			slog.Info("Notification: Reminder email skipped - notifier not configured")
+		}
+	}
+
+	// Nothing enabled or configured for this user: mark as processed to avoid reprocessing forever.
+	if attempted > 0 && delivered == 0 {
+		return false, lastErr
+	}
+
+	if delivered > 0 {
+		slog.Info("Notification: Reminder notification sent", "reminder_id", reminder.ID)
+	}
+	return true, nil
+}
+
+// formatLead renders a lead time in English as "1 hour", "90 minutes", "2 days".
+func formatLead(lead time.Duration) string {
+	return englishMessages.formatLead(lead)
+}
+
 func (s *Service) NotifyWhatsAppConnected(ctx context.Context, userID int64) {
-	fmt.Printf("Notification: WhatsApp connected for user %d, checking push preferences\n", userID)
+	slog.Info("Notification: WhatsApp connected, checking push preferences", "user_id", userID)
 
 	prefs, err := s.db.GetUserNotificationPrefs(userID)
 	if err != nil {
-		fmt.Printf("Notification: Failed to get prefs: %v\n", err)
+		slog.Error("Notification: Failed to get prefs", "error", err)
 		return
 	}
 
-	if !prefs.PushEnabled || prefs.PushToken == "" {
-		fmt.Println("Notification: Push not enabled or no token registered")
+	devices := s.pushDevices(userID)
+	if !prefs.PushEnabled || len(devices) == 0 {
+		slog.Info("Notification: Push not enabled or no token registered")
 		return
 	}
 
-	// Type assert to get ExpoPushNotifier for SendSimple method
-	expoPush, ok := s.pushNotifier.(*ExpoPushNotifier)
-	if !ok {
-		fmt.Println("Notification: Push notifier is not ExpoPushNotifier")
+	if !s.canPush(devices) {
+		slog.Info("Notification: Push notifier not configured for the user's devices")
 		return
 	}
 
-	err = expoPush.SendSimple(
+	err = s.sendSimplePush(
 		ctx,
-		prefs.PushToken,
+		userID,
+		kindWhatsAppConnected,
+		devices,
 		"WhatsApp Connected",
 		"Your WhatsApp account is now linked. Tap to continue setup.",
 		"Permissions",
 	)
 	if err != nil {
-		fmt.Printf("Notification: Failed to send WhatsApp connected push: %v\n", err)
+		slog.Error("Notification: Failed to send WhatsApp connected push", "error", err)
 	} else {
-		fmt.Println("Notification: WhatsApp connected push sent successfully")
+		slog.Info("Notification: WhatsApp connected push sent successfully")
+	}
+}
+
+// NotifyDataExport reports an account data export's progress on the user's stream. Once
+// the export finishes, the user is also told by push and email that it can be downloaded.
+func (s *Service) NotifyDataExport(ctx context.Context, export *database.DataExport) {
+	s.publish(export.UserID, sse.UpdateExportProgress, export)
+
+	var title, body string
+	switch export.Status {
+	case database.DataExportCompleted:
+		title = "Your data export is ready"
+		body = "Download it from Settings before it expires."
+		if export.ExpiresAt != nil {
+			body = fmt.Sprintf("Download it from Settings before %s.", export.ExpiresAt.Local().Format("Jan 2"))
+		}
+	case database.DataExportFailed:
+		title = "Your data export failed"
+		body = "Something went wrong while preparing your data. Please try again."
+	default:
+		return
+	}
+
+	prefs, err := s.db.GetUserNotificationPrefs(export.UserID)
+	if err != nil {
+		slog.Error("Notification: Failed to get prefs", "error", err)
+		return
+	}
+
+	if devices := s.pushDevices(export.UserID); prefs.PushEnabled && len(devices) > 0 {
+		if s.canPush(devices) {
+			if err := s.sendSimplePush(ctx, export.UserID, kindDataExport, devices, title, body, "Settings"); err != nil {
+				slog.Error("Notification: Data export push failed", "error", err)
+			}
+		}
+	}
+
+	if prefs.EmailEnabled && prefs.EmailAddress != "" {
+		if email := s.emailSender(); email != nil {
+			if err := s.sendSimpleEmail(ctx, email, export.UserID, kindDataExport, prefs.EmailAddress, title, body); err != nil {
+				slog.Error("Notification: Data export email failed", "error", err)
+			}
+		}
+	}
+}
+
+// NotifyLLMBudgetExceeded tells the user that analysis is paused because they have
+// spent their monthly LLM budget, on their stream and by push and email.
+func (s *Service) NotifyLLMBudgetExceeded(ctx context.Context, userID int64, spentUSD, budgetUSD float64) {
+	s.publish(userID, sse.UpdateBudgetExceeded, map[string]any{
+		"spent_usd":  spentUSD,
+		"budget_usd": budgetUSD,
+	})
+
+	title := "Message analysis paused"
+	body := fmt.Sprintf("You've used $%.2f of your $%.2f monthly AI budget. Raise it in Settings to resume detection.", spentUSD, budgetUSD)
+
+	prefs, err := s.db.GetUserNotificationPrefs(userID)
+	if err != nil {
+		slog.Error("Notification: Failed to get prefs", "error", err)
+		return
+	}
+
+	if devices := s.pushDevices(userID); prefs.PushEnabled && len(devices) > 0 {
+		if s.canPush(devices) {
+			if err := s.sendSimplePush(ctx, userID, kindBudgetExceeded, devices, title, body, "Settings"); err != nil {
+				slog.Error("Notification: Budget exceeded push failed", "error", err)
+			}
+		}
+	}
+
+	if prefs.EmailEnabled && prefs.EmailAddress != "" {
+		if email := s.emailSender(); email != nil {
+			if err := s.sendSimpleEmail(ctx, email, userID, kindBudgetExceeded, prefs.EmailAddress, title, body); err != nil {
+				slog.Error("Notification: Budget exceeded email failed", "error", err)
+			}
+		}
+	}
+}
+
+// NotifyGoogleReauthRequired tells the user that Google rejected Alfred's access, so
+// Calendar sync and Gmail scanning are stopped until they reconnect, on their stream
+// and by push and email
+func (s *Service) NotifyGoogleReauthRequired(ctx context.Context, userID int64) {
+	s.publish(userID, sse.UpdateGoogleReauth, map[string]any{"reauth_required": true})
+
+	title := "Reconnect your Google account"
+	body := "Google no longer accepts Alfred's access, so Calendar sync and Gmail scanning are paused. Reconnect Google in Settings to resume."
+
+	prefs, err := s.db.GetUserNotificationPrefs(userID)
+	if err != nil {
+		slog.Error("Notification: Failed to get prefs", "error", err)
+		return
+	}
+
+	if devices := s.pushDevices(userID); prefs.PushEnabled && len(devices) > 0 {
+		if s.canPush(devices) {
+			if err := s.sendSimplePush(ctx, userID, kindGoogleReauth, devices, title, body, "Settings"); err != nil {
+				slog.Error("Notification: Google reauth push failed", "error", err)
+			}
+		}
+	}
+
+	if prefs.EmailEnabled && prefs.EmailAddress != "" {
+		if email := s.emailSender(); email != nil {
+			if err := s.sendSimpleEmail(ctx, email, userID, kindGoogleReauth, prefs.EmailAddress, title, body); err != nil {
+				slog.Error("Notification: Google reauth email failed", "error", err)
+			}
+		}
 	}
 }
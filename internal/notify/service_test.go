@@ -7,6 +7,7 @@ import (
 
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -341,3 +342,207 @@ func TestNotifyPendingEvent_WithRealEvent(t *testing.T) {
 		return e.Title == "Real Test Event"
 	}), "real@test.com")
 }
+
+func TestSetDueReminderOffsets(t *testing.T) {
+	service := NewService(database.NewTestDB(t), nil, nil)
+	assert.Equal(t, []time.Duration{0}, service.dueReminderOffsets)
+
+	service.SetDueReminderOffsets([]time.Duration{0, time.Hour, -time.Minute, 90*time.Second + time.Hour, time.Hour})
+
+	assert.Equal(t, []time.Duration{time.Hour + time.Minute, time.Hour, 0}, service.dueReminderOffsets)
+}
+
+func TestFormatLead(t *testing.T) {
+	assert.Equal(t, "1 minute", formatLead(time.Minute))
+	assert.Equal(t, "15 minutes", formatLead(15*time.Minute))
+	assert.Equal(t, "1 hour", formatLead(time.Hour))
+	assert.Equal(t, "90 minutes", formatLead(90*time.Minute))
+	assert.Equal(t, "2 hours", formatLead(2*time.Hour))
+	assert.Equal(t, "1 day", formatLead(24*time.Hour))
+}
+
+func TestProcessDueReminders_MarksLeadAndDueWithoutEnabledChannels(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "due-worker@s.whatsapp.net", "Due Worker")
+	require.NoError(t, err)
+
+	createConfirmed := func(title string, due time.Time) *database.Reminder {
+		reminder, err := db.CreatePendingReminder(&database.Reminder{
+			UserID:       user.ID,
+			ChannelID:    channel.ID,
+			CalendarID:   "primary",
+			Title:        title,
+			DueDate:      &due,
+			ActionType:   database.ReminderActionCreate,
+			Priority:     database.ReminderPriorityNormal,
+			LLMReasoning: "test",
+		})
+		require.NoError(t, err)
+		require.NoError(t, db.UpdateReminderStatus(reminder.ID, database.ReminderStatusConfirmed))
+		return reminder
+	}
+
+	upcoming := createConfirmed("Upcoming", time.Now().Add(30*time.Minute))
+	overdue := createConfirmed("Overdue", time.Now().Add(-time.Minute))
+
+	service := NewService(db, nil, nil)
+	service.SetDueReminderOffsets([]time.Duration{time.Hour, 0})
+	service.processDueReminders(context.Background())
+
+	// Users without push/email enabled are marked processed so the worker doesn't spin on them.
+	leads, err := db.GetRemindersForLeadNotification(time.Now(), time.Hour, 10)
+	require.NoError(t, err)
+	assert.Empty(t, leads)
+
+	due, err := db.GetDueRemindersForNotification(time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	// The upcoming reminder still gets its at-due notification later.
+	due, err = db.GetDueRemindersForNotification(upcoming.DueDate.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, upcoming.ID, due[0].ID)
+	assert.NotEqual(t, overdue.ID, due[0].ID)
+}
+
+func TestProcessDueReminders_QueuesReminderDueWebhookOnce(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "due-webhook@s.whatsapp.net", "Due Webhook")
+	require.NoError(t, err)
+
+	due := time.Now().Add(-time.Minute)
+	reminder, err := db.CreatePendingReminder(&database.Reminder{
+		UserID:       user.ID,
+		ChannelID:    channel.ID,
+		CalendarID:   "primary",
+		Title:        "Pay rent",
+		DueDate:      &due,
+		ActionType:   database.ReminderActionCreate,
+		Priority:     database.ReminderPriorityNormal,
+		LLMReasoning: "test",
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateReminderStatus(reminder.ID, database.ReminderStatusConfirmed))
+
+	hook, err := db.CreateWebhook(user.ID, "https://example.com/hook", "s", []string{webhook.ReminderDue})
+	require.NoError(t, err)
+
+	service := NewService(db, nil, nil)
+	service.SetWebhooks(webhook.NewDispatcher(db))
+	service.processDueReminders(context.Background())
+	service.processDueReminders(context.Background())
+
+	deliveries, err := db.ListWebhookDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhook.ReminderDue, deliveries[0].EventType)
+	assert.Contains(t, deliveries[0].Payload, `"title":"Pay rent"`)
+}
+
+func TestProcessReminderEscalations_RenotifiesOverdueHighPriority(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	require.NoError(t, db.UpdatePushPrefs(user.ID, true))
+	_, err := db.RegisterDevice(user.ID, "ExponentPushToken[expo]", database.PushProviderExpo, "ios", "")
+	require.NoError(t, err)
+
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "escalation@s.whatsapp.net", "Escalation")
+	require.NoError(t, err)
+
+	due := time.Now().Add(-26 * time.Hour)
+	reminder, err := db.CreatePendingReminder(&database.Reminder{
+		UserID:       user.ID,
+		ChannelID:    channel.ID,
+		CalendarID:   "primary",
+		Title:        "Pay rent",
+		DueDate:      &due,
+		ActionType:   database.ReminderActionCreate,
+		Priority:     database.ReminderPriorityHigh,
+		LLMReasoning: "test",
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateReminderStatus(reminder.ID, database.ReminderStatusConfirmed))
+	_, err = db.MarkReminderDueNotificationSent(reminder.ID, time.Now().Add(-25*time.Hour))
+	require.NoError(t, err)
+
+	expo, _ := newTestExpoServer(t, nil)
+	service := NewService(db, nil, expo)
+	service.queueDeliveries = true
+	service.processReminderEscalations(ctx, time.Now())
+	// The next escalation waits another day
+	service.processReminderEscalations(ctx, time.Now())
+
+	queued, err := db.ListQueuedNotifications(user.ID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, kindReminderEscalation, queued[0].Kind)
+	assert.Equal(t, "🔴 Overdue: Pay rent", queued[0].Title)
+
+	escalations, err := db.GetRemindersForEscalation(time.Now().Add(24*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, escalations, 1)
+	assert.Equal(t, reminder.ID, escalations[0].ID)
+}
+
+func TestProcessEventStartNotifications_MarksDueOffsets(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "event-start@s.whatsapp.net", "Event Start")
+	require.NoError(t, err)
+
+	event, err := db.CreatePendingEvent(&database.CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Dentist",
+		StartTime:  time.Now().Add(20 * time.Minute),
+		ActionType: database.EventActionCreate,
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateEventStatus(event.ID, database.EventStatusConfirmed))
+	require.NoError(t, db.SetEventNotifyOffsetsOverride(event.ID, []int{60, 30, 10}))
+
+	service := NewService(db, nil, nil)
+	service.processEventStartNotifications(context.Background())
+
+	// Both passed offsets are collapsed into one notification; the 10 minute one is still pending.
+	sent, err := db.GetSentEventStartOffsets(event.ID, event.StartTime)
+	require.NoError(t, err)
+	assert.True(t, sent[60])
+	assert.True(t, sent[30])
+	assert.False(t, sent[10])
+}
+
+func TestNotifyEventChangedInGoogle(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	require.NoError(t, db.UpdatePushPrefs(user.ID, true))
+	_, err := db.RegisterDevice(user.ID, "ExponentPushToken[expo]", database.PushProviderExpo, "ios", "")
+	require.NoError(t, err)
+
+	expo, _ := newTestExpoServer(t, nil)
+	service := NewService(db, nil, expo)
+	service.queueDeliveries = true
+
+	event := &database.CalendarEvent{ID: 7, UserID: user.ID, Title: "Dinner", StartTime: time.Now()}
+	service.NotifyEventChangedInGoogle(ctx, event, false)
+	service.NotifyEventChangedInGoogle(ctx, event, true)
+
+	queued, err := db.ListQueuedNotifications(user.ID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, queued, 2)
+	titles := []string{queued[0].Title, queued[1].Title}
+	assert.ElementsMatch(t, []string{
+		"✏️ Dinner was changed in Google Calendar",
+		"🗑️ Dinner was deleted in Google Calendar",
+	}, titles)
+	assert.Equal(t, kindEventChanged, queued[0].Kind)
+}
@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/ics"
+)
+
+// SMTP connection security modes
+const (
+	SMTPSecurityStartTLS = "starttls" // plain connection upgraded with STARTTLS (usually port 587)
+	SMTPSecurityTLS      = "tls"      // implicit TLS from the first byte (usually port 465)
+	SMTPSecurityNone     = "none"     // no encryption, for local relays only
+)
+
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig is the mail server an SMTPNotifier sends through
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Security string // SMTPSecurityStartTLS (default), SMTPSecurityTLS or SMTPSecurityNone
+	Username string // empty skips authentication
+	Password string
+	From     string // "Name <address>" or a bare address
+}
+
+// SMTPNotifier sends email notifications through any SMTP server, for self-hosted
+// installs without a Resend account
+type SMTPNotifier struct {
+	emailLayout
+	cfg      SMTPConfig
+	envelope string // bare sender address for MAIL FROM
+}
+
+// NewSMTPNotifier creates an SMTP email notifier
+func NewSMTPNotifier(cfg SMTPConfig, appURL string) (*SMTPNotifier, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	switch cfg.Security {
+	case "":
+		cfg.Security = SMTPSecurityStartTLS
+	case SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security %q (use starttls, tls or none)", cfg.Security)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+	}
+
+	return &SMTPNotifier{
+		emailLayout: emailLayout{appURL: appURL},
+		cfg:         cfg,
+		envelope:    from.Address,
+	}, nil
+}
+
+// Name returns the notifier name
+func (n *SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+// IsConfigured returns true once a server and sender are set
+func (n *SMTPNotifier) IsConfigured() bool {
+	return n != nil && n.cfg.Host != "" && n.envelope != ""
+}
+
+// Send sends an email notification for a pending event to the specified recipient
+func (n *SMTPNotifier) Send(ctx context.Context, event *database.CalendarEvent, recipient string) error {
+	return n.SendHTML(ctx, recipient, eventEmailSubject(event), n.formatEmailHTML(event))
+}
+
+// SendSimple sends a plain email (not tied to a CalendarEvent)
+func (n *SMTPNotifier) SendSimple(ctx context.Context, recipient, subject, body string) error {
+	return n.SendHTML(ctx, recipient, subject, n.formatSimpleEmailHTML(subject, body))
+}
+
+// SendHTML sends an email whose HTML body has already been rendered
+func (n *SMTPNotifier) SendHTML(ctx context.Context, recipient, subject, htmlBody string) error {
+	return n.send(ctx, recipient, subject, htmlBody, nil)
+}
+
+// SendInvite sends an email with a calendar invitation as a text/calendar alternative
+// to the HTML body, which mail clients show with accept/decline buttons
+func (n *SMTPNotifier) SendInvite(ctx context.Context, recipient, subject, htmlBody string, invite []byte) error {
+	return n.send(ctx, recipient, subject, htmlBody, invite)
+}
+
+func (n *SMTPNotifier) send(ctx context.Context, recipient, subject, htmlBody string, invite []byte) error {
+	if recipient == "" {
+		return fmt.Errorf("no recipient specified")
+	}
+	to, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", recipient, err)
+	}
+
+	msg, err := n.buildMessage(to.Address, subject, htmlBody, invite)
+	if err != nil {
+		return err
+	}
+	if err := n.deliver(ctx, to.Address, msg); err != nil {
+		return fmt.Errorf("smtp send failed: %w", err)
+	}
+
+	slog.Info("Email notification sent", "recipient", recipient, "subject", subject)
+	return nil
+}
+
+// buildMessage renders an RFC 5322 message with a quoted-printable HTML body. An invite
+// makes it multipart/alternative with the invite as the text/calendar part.
+func (n *SMTPNotifier) buildMessage(to, subject, htmlBody string, invite []byte) ([]byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message ID: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), n.cfg.Host)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	if invite == nil {
+		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+		msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&msg, []byte(htmlBody)); err != nil {
+			return nil, err
+		}
+		return msg.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+
+	htmlPart, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+	if err := writeQuotedPrintable(htmlPart, []byte(htmlBody)); err != nil {
+		return nil, err
+	}
+
+	invitePart, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {ics.InviteContentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode invite: %w", err)
+	}
+	if err := writeQuotedPrintable(invitePart, invite); err != nil {
+		return nil, err
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode email body: %w", err)
+	}
+	return msg.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, content []byte) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write(content); err != nil {
+		return fmt.Errorf("failed to encode email body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("failed to encode email body: %w", err)
+	}
+	return nil
+}
+
+// deliver opens a connection, authenticates and sends one message
+func (n *SMTPNotifier) deliver(ctx context.Context, to string, msg []byte) error {
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: n.cfg.Host}
+
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+
+	var conn net.Conn
+	var err error
+	if n.cfg.Security == SMTPSecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if n.cfg.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if n.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.envelope); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,205 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/ics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSession is what the fake server received in one session
+type smtpSession struct {
+	from, to string
+	data     string
+}
+
+// newTestSMTPServer accepts one unencrypted session, answering just enough of the
+// protocol for net/smtp, and reports what it received
+func newTestSMTPServer(t *testing.T) (string, int, <-chan smtpSession) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+		var session smtpSession
+
+		reply("220 test ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 test")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				session.from = strings.Trim(strings.TrimPrefix(cmd, "MAIL FROM:"), "<>")
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				session.to = strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>")
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				session.data = data.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				sessions <- session
+				return
+			default:
+				reply("502 unsupported")
+			}
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return host, portNum, sessions
+}
+
+func TestSMTPNotifierSendSimple(t *testing.T) {
+	host, port, sessions := newTestSMTPServer(t)
+	notifier, err := NewSMTPNotifier(SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Security: SMTPSecurityNone,
+		From:     "Alfred <alfred@example.com>",
+	}, "http://localhost:8080")
+	require.NoError(t, err)
+	require.True(t, notifier.IsConfigured())
+
+	require.NoError(t, notifier.SendSimple(context.Background(), "user@example.com", "⏰ Reminder: Dentist", "It's time for this reminder."))
+
+	var session smtpSession
+	select {
+	case session = <-sessions:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP server received no session")
+	}
+	assert.Equal(t, "alfred@example.com", session.from)
+	assert.Equal(t, "user@example.com", session.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	require.NoError(t, err)
+	assert.Equal(t, "Alfred <alfred@example.com>", msg.Header.Get("From"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "⏰ Reminder: Dentist", subject)
+	assert.Equal(t, "text/html; charset=UTF-8", msg.Header.Get("Content-Type"))
+	body, err := io.ReadAll(msg.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "time for this reminder")
+}
+
+func TestNewSMTPNotifierValidatesConfig(t *testing.T) {
+	_, err := NewSMTPNotifier(SMTPConfig{From: "alfred@example.com"}, "")
+	assert.Error(t, err, "host is required")
+
+	_, err = NewSMTPNotifier(SMTPConfig{Host: "mail.example.com", Security: "ssl", From: "alfred@example.com"}, "")
+	assert.Error(t, err, "unknown security mode")
+
+	_, err = NewSMTPNotifier(SMTPConfig{Host: "mail.example.com", From: "not an address"}, "")
+	assert.Error(t, err, "invalid sender")
+
+	notifier, err := NewSMTPNotifier(SMTPConfig{Host: "mail.example.com", From: "alfred@example.com"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 587, notifier.cfg.Port)
+	assert.Equal(t, SMTPSecurityStartTLS, notifier.cfg.Security)
+}
+
+func TestQueuedEventInviteIsDeliveredOverSMTP(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+
+	host, port, sessions := newTestSMTPServer(t)
+	notifier, err := NewSMTPNotifier(SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Security: SMTPSecurityNone,
+		From:     "alfred@example.com",
+	}, "")
+	require.NoError(t, err)
+	service := NewService(db, notifier, nil)
+	service.queueDeliveries = true
+
+	start := time.Date(2026, 3, 13, 17, 0, 0, 0, time.UTC)
+	event := &database.CalendarEvent{ID: 7, UserID: user.ID, Title: "Dinner", StartTime: start, Location: "Luigi's"}
+	require.NoError(t, service.SendEventInvites(ctx, event,
+		ics.Person{Name: "Omri", Email: "omri@example.com"},
+		[]ics.Person{{Name: "Dana", Email: "dana@example.com"}},
+		time.FixedZone("IST", 2*60*60)))
+
+	service.processDeliveries(ctx)
+	var session smtpSession
+	select {
+	case session = <-sessions:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP server received no session")
+	}
+	assert.Equal(t, "dana@example.com", session.to)
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	require.NoError(t, err)
+	assert.Equal(t, "Invitation: Dinner", msg.Header.Get("Subject"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	htmlPart, err := parts.NextPart()
+	require.NoError(t, err)
+	html, err := io.ReadAll(htmlPart)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Omri invited you to Dinner.")
+	assert.Contains(t, string(html), "7:00 PM IST")
+
+	invitePart, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, ics.InviteContentType, invitePart.Header.Get("Content-Type"))
+	invite, err := io.ReadAll(invitePart)
+	require.NoError(t, err)
+	assert.Contains(t, string(invite), "METHOD:REQUEST\r\n")
+	assert.Contains(t, string(invite), "ATTENDEE;CN=\"Dana\"")
+
+	queued, err := db.ListQueuedNotifications(user.ID, nil, 10)
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, kindEventInvite, queued[0].Kind)
+	assert.Equal(t, database.NotificationSent, queued[0].Status)
+}
@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/intents"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// maxLoggedToolOutput caps how much of each tool result is kept in the analysis log
+const maxLoggedToolOutput = 2000
+
+// analysisRecorder collects the agent runs of one intent module analysis so they can
+// be stored in the analysis log once the module's decision is known
+type analysisRecorder struct {
+	mu   sync.Mutex
+	runs []agent.RunLog
+}
+
+// context returns ctx with the recorder receiving its agent runs
+func (r *analysisRecorder) context(ctx context.Context) context.Context {
+	return agent.WithRunLogger(ctx, func(run agent.RunLog) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.runs = append(r.runs, run)
+	})
+}
+
+// save stores one analysis log entry per recorded agent run on the message, filled in
+// from base and the module's output. Failures are logged, not returned.
+func (r *analysisRecorder) save(db *database.DB, base database.AnalysisLogEntry, output *intents.ModuleOutput) {
+	if base.UserID == 0 || base.MessageID == 0 {
+		return
+	}
+	r.mu.Lock()
+	runs := append([]agent.RunLog(nil), r.runs...)
+	r.mu.Unlock()
+
+	for _, run := range runs {
+		entry := base
+		entry.Agent = run.Agent
+		entry.Model = run.Model
+		entry.InputHash = run.InputHash
+		entry.LatencyMS = run.Latency.Milliseconds()
+		entry.InputTokens = run.Usage.InputTokens
+		entry.OutputTokens = run.Usage.OutputTokens
+		if output != nil {
+			entry.Action = output.Action
+			entry.Confidence = output.Confidence
+			entry.Reasoning = output.Reasoning
+		}
+		if run.Err != nil {
+			entry.Error = run.Err.Error()
+		}
+		for _, call := range run.ToolCalls {
+			logged := database.AnalysisToolCall{
+				Name:   call.Name,
+				Input:  call.Input,
+				Output: truncate(call.Output, maxLoggedToolOutput),
+			}
+			if call.Error != nil {
+				logged.Error = call.Error.Error()
+			}
+			entry.ToolCalls = append(entry.ToolCalls, logged)
+		}
+		if err := db.CreateAnalysisLogEntry(entry); err != nil {
+			slog.Warn("Processor: failed to store analysis log", "message_id", base.MessageID, "intent", base.Intent, "error", err)
+		}
+	}
+}
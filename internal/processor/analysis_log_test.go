@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cannedTransport string
+
+func (c cannedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(c))),
+		Request:    r,
+	}, nil
+}
+
+// agentEventAnalyzer runs a real agent against a canned LLM response
+type agentEventAnalyzer struct {
+	agent *agent.Agent
+}
+
+func (a *agentEventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	_, err := a.agent.Execute(ctx, agent.AgentInput{Messages: []agent.Message{{
+		Role:    "user",
+		Content: []agent.ContentBlock{agent.TextBlock{Type: "text", Text: newMessage.MessageText}},
+	}}})
+	if err != nil {
+		return nil, err
+	}
+	return &agent.EventAnalysis{Action: "none", Confidence: 0.9, Reasoning: "just chatting"}, nil
+}
+
+func (a *agentEventAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.EventAnalysis, error) {
+	return &agent.EventAnalysis{Action: "none", Confidence: 0.9}, nil
+}
+
+func (a *agentEventAnalyzer) IsConfigured() bool { return true }
+
+func TestProcessMessageStoresAnalysisLog(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "contact@s.whatsapp.net", "Contact")
+	require.NoError(t, err)
+
+	analyzer := &agentEventAnalyzer{agent: agent.NewAgent(agent.AgentConfig{
+		Name:      "event-scheduler",
+		Provider:  agent.ProviderOpenAI,
+		APIKey:    "key",
+		Model:     "gpt-4o",
+		Transport: cannedTransport(`{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"{}"}}],"usage":{"prompt_tokens":300,"completion_tokens":20}}`),
+	})}
+	p := New(db, analyzer, nil, make(chan source.Message), 25, nil)
+	defer p.Stop()
+
+	require.NoError(t, p.processMessage(source.Message{
+		UserID:     user.ID,
+		SourceType: source.SourceTypeWhatsApp,
+		SourceID:   channel.ID,
+		SenderID:   "contact@s.whatsapp.net",
+		SenderName: "Contact",
+		Text:       "How was the trip?",
+		Timestamp:  time.Now(),
+	}, 0))
+
+	messages, err := db.GetSourceMessageHistory(user.ID, source.SourceTypeWhatsApp, channel.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	entries, err := db.ListAnalysisLog(user.ID, messages[0].ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "event", entries[0].Intent)
+	assert.Equal(t, "event-scheduler", entries[0].Agent)
+	assert.Equal(t, "gpt-4o", entries[0].Model)
+	assert.Len(t, entries[0].InputHash, 64)
+	assert.Equal(t, "none", entries[0].Action)
+	assert.Equal(t, 0.9, entries[0].Confidence)
+	assert.Equal(t, "just chatting", entries[0].Reasoning)
+	assert.Equal(t, 300, entries[0].InputTokens)
+	assert.Equal(t, 20, entries[0].OutputTokens)
+	assert.Empty(t, entries[0].Error)
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// Which kinds of item each built-in intent module creates, for a channel's analysis mode.
+// Intents not listed (modules registered by plugins) run unless analysis is off.
+var (
+	eventIntents    = map[string]bool{"event": true, "travel": true, "delivery": true, "occasion": true}
+	reminderIntents = map[string]bool{"reminder": true, "bill": true, "occasion": true}
+)
+
+// filterIntentsForMode drops the modules a channel's analysis mode doesn't run.
+// Channels stored before analysis modes existed have an empty mode and run everything.
+func filterIntentsForMode(order []string, mode database.AnalysisMode) []string {
+	if mode == "" || mode == database.AnalysisModeAll {
+		return order
+	}
+	if mode == database.AnalysisModeNone {
+		return nil
+	}
+
+	filtered := make([]string, 0, len(order))
+	for _, intent := range order {
+		createsEvents, createsReminders := eventIntents[intent], reminderIntents[intent]
+		if !createsEvents && !createsReminders {
+			filtered = append(filtered, intent)
+			continue
+		}
+		if (createsEvents && mode.AllowsEvents()) || (createsReminders && mode.AllowsReminders()) {
+			filtered = append(filtered, intent)
+		}
+	}
+	return filtered
+}
+
+// channelMode returns the analysis mode of a channel, treating a missing channel as "all"
+// and a muted one as "none"
+func channelMode(channel *database.SourceChannel) database.AnalysisMode {
+	if channel != nil && channel.IsMuted(time.Now()) {
+		return database.AnalysisModeNone
+	}
+	if channel == nil || channel.AnalysisMode == "" {
+		return database.AnalysisModeAll
+	}
+	return channel.AnalysisMode
+}
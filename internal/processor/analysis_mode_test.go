@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterIntentsForMode(t *testing.T) {
+	order := []string{"reminder", "event", "travel", "bill", "occasion", "custom"}
+
+	tests := []struct {
+		mode     database.AnalysisMode
+		expected []string
+	}{
+		{"", order},
+		{database.AnalysisModeAll, order},
+		{database.AnalysisModeEvents, []string{"event", "travel", "occasion", "custom"}},
+		{database.AnalysisModeReminders, []string{"reminder", "bill", "occasion", "custom"}},
+		{database.AnalysisModeNone, nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			assert.Equal(t, tt.expected, filterIntentsForMode(order, tt.mode))
+		})
+	}
+}
+
+func TestIntentPersisterHonorsAnalysisMode(t *testing.T) {
+	channel := &database.SourceChannel{AnalysisMode: database.AnalysisModeEvents}
+	persister := &messageIntentPersister{channel: channel}
+
+	// Reminders from modules that create both (occasion) are dropped without touching the processor
+	assert.NoError(t, persister.PersistReminder(context.Background(), nil))
+}
+
+func TestChannelModeMuted(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
+	assert.Equal(t, database.AnalysisModeAll, channelMode(nil))
+	assert.Equal(t, database.AnalysisModeEvents, channelMode(&database.SourceChannel{AnalysisMode: database.AnalysisModeEvents, MutedUntil: &past}))
+	assert.Equal(t, database.AnalysisModeNone, channelMode(&database.SourceChannel{AnalysisMode: database.AnalysisModeEvents, MutedUntil: &future}))
+}
@@ -2,7 +2,9 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/agent/intents"
@@ -21,6 +23,7 @@ type BackfillProcessor struct {
 	reminderCreator  *ReminderCreator
 	intentRegistry   *intents.Registry
 	intentRouter     intents.Router
+	onProgress       func(processed, total int)
 }
 
 // NewBackfillProcessor creates a new backfill processor.
@@ -45,6 +48,22 @@ func NewBackfillProcessor(db *database.DB, eventAnalyzer agent.EventAnalyzer, re
 	}
 }
 
+// RegisterIntentModule adds an analyzer beyond the built-in event and reminder modules
+func (p *BackfillProcessor) RegisterIntentModule(module intents.IntentModule) error {
+	return p.intentRegistry.Register(module)
+}
+
+// SetAutoConfirmer confirms detections above the user's auto-confirm threshold without review
+func (p *BackfillProcessor) SetAutoConfirmer(c AutoConfirmer) {
+	p.eventCreator.autoConfirmer = c
+	p.reminderCreator.autoConfirmer = c
+}
+
+// SetProgressFunc sets a callback run after each message ProcessChannelMessages analyzes
+func (p *BackfillProcessor) SetProgressFunc(fn func(processed, total int)) {
+	p.onProgress = fn
+}
+
 // ProcessChannelMessages analyzes existing messages from history for events and reminders.
 func (p *BackfillProcessor) ProcessChannelMessages(ctx context.Context, userID int64, channelID int64, sourceType source.SourceType, messages []database.SourceMessage) error {
 	if len(messages) == 0 {
@@ -58,7 +77,7 @@ func (p *BackfillProcessor) ProcessChannelMessages(ctx context.Context, userID i
 	if channel == nil {
 		return fmt.Errorf("channel not found: %d", channelID)
 	}
-	if !channel.Enabled {
+	if !channel.Enabled || channelMode(channel) == database.AnalysisModeNone {
 		return nil
 	}
 
@@ -75,13 +94,13 @@ func (p *BackfillProcessor) ProcessChannelMessages(ctx context.Context, userID i
 
 		existingEvents, err := p.db.GetActiveEventsForChannel(userID, channelID)
 		if err != nil {
-			fmt.Printf("Backfill: warning - failed to get existing events: %v\n", err)
+			slog.Warn("Backfill: failed to get existing events", "error", err)
 			existingEvents = []database.CalendarEvent{}
 		}
 
 		existingReminders, err := p.db.GetActiveRemindersForChannel(channelID)
 		if err != nil {
-			fmt.Printf("Backfill: warning - failed to get existing reminders: %v\n", err)
+			slog.Warn("Backfill: failed to get existing reminders", "error", err)
 			existingReminders = []database.Reminder{}
 		}
 
@@ -97,7 +116,10 @@ func (p *BackfillProcessor) ProcessChannelMessages(ctx context.Context, userID i
 				ExistingReminders: existingReminders,
 			},
 		); err != nil {
-			fmt.Printf("Backfill intent orchestration error: %v\n", err)
+			slog.Error("Backfill intent orchestration error", "error", err)
+		}
+		if p.onProgress != nil {
+			p.onProgress(i+1, len(messages))
 		}
 	}
 
@@ -159,10 +181,16 @@ type backfillIntentPersister struct {
 }
 
 func (bp *backfillIntentPersister) PersistEvent(ctx context.Context, analysis *agent.EventAnalysis) error {
+	if !channelMode(bp.channel).AllowsEvents() {
+		return nil
+	}
 	return bp.p.createPendingEvent(bp.channel, bp.messageID, analysis, bp.source)
 }
 
 func (bp *backfillIntentPersister) PersistReminder(ctx context.Context, analysis *agent.ReminderAnalysis) error {
+	if !channelMode(bp.channel).AllowsReminders() {
+		return nil
+	}
 	return bp.p.createPendingReminder(bp.channel, bp.messageID, analysis, bp.source)
 }
 
@@ -179,6 +207,7 @@ func (p *BackfillProcessor) routeAnalyzeAndPersistBackfill(
 
 	route := p.intentRouter.RouteMessages(ctx, input)
 	intentOrder, unknownRoutedIntent := resolveIntentExecutionOrder(p.intentRegistry, route)
+	intentOrder = filterIntentsForMode(intentOrder, channelMode(channel))
 	if len(intentOrder) == 0 {
 		return nil
 	}
@@ -186,7 +215,7 @@ func (p *BackfillProcessor) routeAnalyzeAndPersistBackfill(
 	var firstErr error
 	for _, intentName := range intentOrder {
 		if err := p.runBackfillIntentModule(ctx, intentName, channel, messageID, sourceType, input); err != nil {
-			fmt.Printf("Backfill intent module %s error: %v\n", intentName, err)
+			slog.Error("Backfill intent module error", "intent_name", intentName, "error", err)
 			if firstErr == nil {
 				firstErr = err
 			}
@@ -208,7 +237,7 @@ func (p *BackfillProcessor) runBackfillIntentModule(
 ) error {
 	module, ok := p.intentRegistry.Get(intentName)
 	if !ok {
-		fmt.Printf("Unknown backfill intent '%s' -> no_action\n", intentName)
+		slog.Info("Unknown backfill intent -> no_action", "intent_name", intentName)
 		msgID := messageID
 		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 			UserID:           channel.UserID,
@@ -221,7 +250,27 @@ func (p *BackfillProcessor) runBackfillIntentModule(
 		return nil
 	}
 
-	output, err := module.AnalyzeMessages(ctx, input)
+	analysisCtx := agent.WithMessageTime(AnalysisContext(ctx, p.db, channel.UserID), input.NewMessage.Timestamp)
+	recorder := &analysisRecorder{}
+	output, err := module.AnalyzeMessages(recorder.context(analysisCtx), input)
+	recorder.save(p.db, database.AnalysisLogEntry{
+		UserID:    channel.UserID,
+		MessageID: messageID,
+		ChannelID: channel.ID,
+		Intent:    intentName,
+	}, output)
+	if errors.Is(err, agent.ErrBudgetExceeded) {
+		msgID := messageID
+		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
+			UserID:           channel.UserID,
+			ChannelID:        channel.ID,
+			SourceType:       string(sourceType),
+			TriggerMessageID: &msgID,
+			Intent:           intentName,
+			Status:           "budget_exceeded",
+		})
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -229,7 +278,7 @@ func (p *BackfillProcessor) runBackfillIntentModule(
 		return nil
 	}
 	if err := module.Validate(ctx, output); err != nil {
-		fmt.Printf("Backfill intent validation failed intent=%s: %v\n", intentName, err)
+		slog.Error("Backfill intent validation failed", "intent", intentName, "error", err)
 		msgID := messageID
 		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 			UserID:           channel.UserID,
@@ -244,8 +293,8 @@ func (p *BackfillProcessor) runBackfillIntentModule(
 		})
 		return nil
 	}
-	if output.Confidence < minPersistConfidence {
-		fmt.Printf("Backfill: skipping low-confidence intent=%s confidence=%.2f\n", intentName, output.Confidence)
+	if confidenceThresholds(p.db, channel.UserID).ShouldDiscard(output.Confidence) {
+		slog.Info("Backfill: skipping low-confidence", "intent", intentName, "confidence", output.Confidence)
 		msgID := messageID
 		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 			UserID:           channel.UserID,
@@ -260,7 +309,7 @@ func (p *BackfillProcessor) runBackfillIntentModule(
 		})
 		return nil
 	}
-	err = module.Persist(ctx, output, &backfillIntentPersister{
+	err = module.Persist(analysisCtx, output, &backfillIntentPersister{
 		p:         p,
 		channel:   channel,
 		messageID: messageID,
@@ -0,0 +1,171 @@
+package processor
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+const (
+	defaultBatchMaxWait     = 30 * time.Second
+	defaultBatchMaxMessages = 10
+)
+
+// batchKey identifies the channel a burst of messages belongs to
+type batchKey struct {
+	userID     int64
+	sourceType source.SourceType
+	channelID  int64
+}
+
+// messageBatch collects rapid-fire messages from one channel until the channel
+// goes quiet for the batch window
+type messageBatch struct {
+	channel    *database.SourceChannel
+	messages   []*database.SourceMessage
+	pendingIDs []int64 // durable queue rows, removed once the burst is analyzed
+	started    time.Time
+	timer      *time.Timer
+}
+
+// SetBatchWindow enables conversation-level batching: messages from the same channel
+// that arrive within window of each other are analyzed together in one agent call.
+// A burst is flushed early once it has waited maxWait or holds maxMessages messages.
+// A zero window analyzes every message as it arrives.
+func (p *Processor) SetBatchWindow(window, maxWait time.Duration, maxMessages int) {
+	if maxWait <= 0 {
+		maxWait = defaultBatchMaxWait
+	}
+	if maxMessages <= 0 {
+		maxMessages = defaultBatchMaxMessages
+	}
+
+	p.batchMu.Lock()
+	defer p.batchMu.Unlock()
+	p.batchWindow = window
+	p.batchMaxWait = maxWait
+	p.batchMaxMessages = maxMessages
+}
+
+// enqueueMessage adds a stored message to its channel's pending burst. The burst is
+// analyzed once the channel has been quiet for the batch window, or immediately in
+// the caller's goroutine when it reaches the wait or size limit.
+func (p *Processor) enqueueMessage(channel *database.SourceChannel, sourceType source.SourceType, stored *database.SourceMessage, pendingID int64) bool {
+	p.batchMu.Lock()
+	if p.batchWindow <= 0 || p.batchesStopped {
+		p.batchMu.Unlock()
+		return false
+	}
+
+	key := batchKey{userID: channel.UserID, sourceType: sourceType, channelID: channel.ID}
+	batch, ok := p.batches[key]
+	if !ok {
+		batch = &messageBatch{started: time.Now()}
+		p.batches[key] = batch
+	}
+	batch.channel = channel
+	batch.messages = append(batch.messages, stored)
+	if pendingID != 0 {
+		batch.pendingIDs = append(batch.pendingIDs, pendingID)
+	}
+
+	if len(batch.messages) >= p.batchMaxMessages || time.Since(batch.started) >= p.batchMaxWait {
+		if batch.timer != nil {
+			batch.timer.Stop()
+		}
+		delete(p.batches, key)
+		p.batchMu.Unlock()
+		p.analyzeBatch(batch.channel, sourceType, batch.messages, batch.pendingIDs)
+		return true
+	}
+
+	if batch.timer == nil {
+		batch.timer = time.AfterFunc(p.batchWindow, func() { p.flushBatch(key, batch) })
+	} else {
+		batch.timer.Reset(p.batchWindow)
+	}
+	p.batchMu.Unlock()
+	return true
+}
+
+// flushBatch analyzes a burst whose debounce timer fired. A timer that fires after
+// its burst was already flushed or the processor stopped does nothing.
+func (p *Processor) flushBatch(key batchKey, batch *messageBatch) {
+	p.batchMu.Lock()
+	if p.batchesStopped || p.batches[key] != batch {
+		p.batchMu.Unlock()
+		return
+	}
+	delete(p.batches, key)
+	p.wg.Add(1)
+	p.batchMu.Unlock()
+	defer p.wg.Done()
+
+	p.analyzeBatch(batch.channel, key.sourceType, batch.messages, batch.pendingIDs)
+}
+
+// stopBatches cancels pending debounce timers. Their messages stay in the durable
+// queue and are analyzed again on the next start.
+func (p *Processor) stopBatches() {
+	p.batchMu.Lock()
+	defer p.batchMu.Unlock()
+
+	p.batchesStopped = true
+	for key, batch := range p.batches {
+		batch.timer.Stop()
+		slog.Info("Event processor: deferring pending batch to next start", "messages", len(batch.messages), "channel_id", key.channelID)
+	}
+	p.batches = make(map[batchKey]*messageBatch)
+}
+
+// analyzeBatch runs analysis once for a burst of messages, logging instead of
+// returning errors since it may run on a timer goroutine
+func (p *Processor) analyzeBatch(channel *database.SourceChannel, sourceType source.SourceType, messages []*database.SourceMessage, pendingIDs []int64) {
+	defer p.ackPending(pendingIDs...)
+
+	ctx := p.logContext(channel, sourceType, messages[len(messages)-1].ID)
+	if len(messages) > 1 {
+		slog.InfoContext(ctx, "Analyzing batch", "messages", len(messages))
+	}
+	if err := p.analyzeMessages(channel, sourceType, messages); err != nil {
+		slog.ErrorContext(ctx, "Event processor: error analyzing messages", "error", err)
+	}
+}
+
+// mergeBurst folds a burst of messages into one record so the agent sees the whole
+// exchange as the new message. The record keeps the last message's ID and timestamp,
+// which makes it the trigger for any event or reminder created. Lines are prefixed
+// with the sender when more than one person took part.
+func mergeBurst(messages []*database.SourceMessage) database.MessageRecord {
+	last := messages[len(messages)-1]
+	record := convertSourceMessageToRecord(last)
+	if len(messages) == 1 {
+		return record
+	}
+
+	multipleSenders := false
+	for _, m := range messages {
+		if m.SenderID != last.SenderID {
+			multipleSenders = true
+			break
+		}
+	}
+
+	lines := make([]string, len(messages))
+	for i, m := range messages {
+		if multipleSenders {
+			lines[i] = fmt.Sprintf("%s: %s", m.SenderName, m.MessageText)
+		} else {
+			lines[i] = m.MessageText
+		}
+	}
+	record.MessageText = strings.Join(lines, "\n")
+	if multipleSenders {
+		record.SenderName = "Multiple senders"
+	}
+	return record
+}
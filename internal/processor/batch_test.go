@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingEventAnalyzer struct {
+	mu          sync.Mutex
+	newMessages []database.MessageRecord
+	histories   [][]database.MessageRecord
+}
+
+func (a *recordingEventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.newMessages = append(a.newMessages, newMessage)
+	a.histories = append(a.histories, history)
+	return &agent.EventAnalysis{Action: "none", Confidence: 0.9}, nil
+}
+
+func (a *recordingEventAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.EventAnalysis, error) {
+	return &agent.EventAnalysis{Action: "none", Confidence: 0.9}, nil
+}
+
+func (a *recordingEventAnalyzer) IsConfigured() bool { return true }
+
+func (a *recordingEventAnalyzer) calls() []database.MessageRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]database.MessageRecord{}, a.newMessages...)
+}
+
+func TestMergeBurst(t *testing.T) {
+	now := time.Now()
+	dinner := &database.SourceMessage{ID: 1, SenderID: "dana", SenderName: "Dana", MessageText: "dinner?", Timestamp: now}
+	friday := &database.SourceMessage{ID: 2, SenderID: "dana", SenderName: "Dana", MessageText: "friday", Timestamp: now.Add(time.Second)}
+	place := &database.SourceMessage{ID: 3, SenderID: "omer", SenderName: "Omer", MessageText: "8pm at Luigi's", Timestamp: now.Add(2 * time.Second)}
+
+	single := mergeBurst([]*database.SourceMessage{dinner})
+	assert.Equal(t, "dinner?", single.MessageText)
+	assert.Equal(t, "Dana", single.SenderName)
+
+	sameSender := mergeBurst([]*database.SourceMessage{dinner, friday})
+	assert.Equal(t, int64(2), sameSender.ID)
+	assert.Equal(t, "dinner?\nfriday", sameSender.MessageText)
+	assert.Equal(t, "Dana", sameSender.SenderName)
+	assert.Equal(t, friday.Timestamp, sameSender.Timestamp)
+
+	mixed := mergeBurst([]*database.SourceMessage{dinner, friday, place})
+	assert.Equal(t, int64(3), mixed.ID)
+	assert.Equal(t, "Dana: dinner?\nDana: friday\nOmer: 8pm at Luigi's", mixed.MessageText)
+	assert.Equal(t, "Multiple senders", mixed.SenderName)
+}
+
+func TestProcessMessageBatchesBurst(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "contact@s.whatsapp.net", "Contact")
+	require.NoError(t, err)
+
+	analyzer := &recordingEventAnalyzer{}
+	p := New(db, analyzer, nil, make(chan source.Message), 25, nil)
+	defer p.Stop()
+
+	send := func(text string) {
+		require.NoError(t, p.processMessage(source.Message{
+			UserID:     user.ID,
+			SourceType: source.SourceTypeWhatsApp,
+			SourceID:   channel.ID,
+			Identifier: "contact@s.whatsapp.net",
+			SenderID:   "contact@s.whatsapp.net",
+			SenderName: "Contact",
+			Text:       text,
+			Timestamp:  time.Now(),
+		}, 0))
+	}
+
+	// Without a window every message is analyzed on its own
+	send("Meeting on Sunday?")
+	require.Len(t, analyzer.calls(), 1)
+
+	t.Run("burst is analyzed once after the channel goes quiet", func(t *testing.T) {
+		p.SetBatchWindow(100*time.Millisecond, time.Minute, 10)
+		send("dinner?")
+		send("friday")
+		send("8pm at Luigi's")
+		assert.Len(t, analyzer.calls(), 1)
+
+		require.Eventually(t, func() bool { return len(analyzer.calls()) == 2 }, 2*time.Second, 10*time.Millisecond)
+		calls := analyzer.calls()
+		assert.Equal(t, "dinner?\nfriday\n8pm at Luigi's", calls[1].MessageText)
+
+		analyzer.mu.Lock()
+		history := analyzer.histories[1]
+		analyzer.mu.Unlock()
+		require.Len(t, history, 1)
+		assert.Equal(t, "Meeting on Sunday?", history[0].MessageText)
+	})
+
+	t.Run("full burst is analyzed immediately", func(t *testing.T) {
+		p.SetBatchWindow(time.Minute, time.Minute, 2)
+		send("lunch tomorrow?")
+		assert.Len(t, analyzer.calls(), 2)
+		send("at 1pm")
+		calls := analyzer.calls()
+		require.Len(t, calls, 3)
+		assert.Equal(t, "lunch tomorrow?\nat 1pm", calls[2].MessageText)
+	})
+}
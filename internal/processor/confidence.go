@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// AutoConfirmer confirms pending events and reminders on the user's behalf, syncing them
+// to their calendar the same way a manual confirm does
+type AutoConfirmer interface {
+	ConfirmEvent(ctx context.Context, event *database.CalendarEvent) error
+	ConfirmReminder(ctx context.Context, reminder *database.Reminder) error
+}
+
+// confidenceThresholds returns the user's discard and auto-confirm thresholds, falling
+// back to the defaults if they can't be read
+func confidenceThresholds(db *database.DB, userID int64) database.ConfidenceThresholds {
+	thresholds, err := db.GetConfidenceThresholds(userID)
+	if err != nil {
+		slog.Warn("Using default confidence thresholds", "error", err)
+		return database.DefaultConfidenceThresholds()
+	}
+	return thresholds
+}
+
+// autoConfirmEnabled reports whether the user's auto_confirm feature flag is on. A flag
+// that can't be read counts as off, so nothing is confirmed without review by mistake.
+func autoConfirmEnabled(ctx context.Context, db *database.DB, userID int64) bool {
+	enabled, err := db.IsFeatureEnabled(userID, database.FeatureAutoConfirm)
+	if err != nil {
+		slog.WarnContext(ctx, "Skipping auto-confirm, feature flag unavailable", "error", err)
+		return false
+	}
+	return enabled
+}
@@ -2,7 +2,9 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/agent/intents"
@@ -22,6 +24,7 @@ type EmailProcessor struct {
 	reminderCreator  *ReminderCreator
 	intentRegistry   *intents.Registry
 	intentRouter     intents.Router
+	shadow           *ShadowRunner
 }
 
 // NewEmailProcessor creates a new email processor
@@ -46,13 +49,29 @@ func NewEmailProcessor(db *database.DB, eventAnalyzer agent.EventAnalyzer, remin
 	}
 }
 
+// RegisterIntentModule adds an analyzer beyond the built-in event and reminder modules
+func (p *EmailProcessor) RegisterIntentModule(module intents.IntentModule) error {
+	return p.intentRegistry.Register(module)
+}
+
+// SetShadow runs the shadow analyzers alongside the live ones for comparison; nil disables it
+func (p *EmailProcessor) SetShadow(shadow *ShadowRunner) {
+	p.shadow = shadow
+}
+
+// SetAutoConfirmer confirms detections above the user's auto-confirm threshold without review
+func (p *EmailProcessor) SetAutoConfirmer(c AutoConfirmer) {
+	p.eventCreator.autoConfirmer = c
+	p.reminderCreator.autoConfirmer = c
+}
+
 // ProcessEmail processes a single email for event and reminder detection
 func (p *EmailProcessor) ProcessEmail(ctx context.Context, email *gmail.Email, emailSource *gmail.EmailSource, thread *gmail.Thread) error {
 	threadLen := 0
 	if thread != nil {
 		threadLen = len(thread.Messages)
 	}
-	fmt.Printf("Processing email: %s (from: %s, thread: %d messages)\n", truncate(email.Subject, 50), email.From, threadLen)
+	slog.Info("Processing email", "subject", truncate(email.Subject, 50), "from", email.From, "thread_messages", threadLen)
 
 	// Clean the email body
 	body := gmail.CleanEmailBody(email.Body)
@@ -76,51 +95,98 @@ func (p *EmailProcessor) ProcessEmail(ctx context.Context, email *gmail.Email, e
 			email.ReceivedAt,
 		)
 		if err != nil {
-			fmt.Printf("Email: failed to store message context: %v\n", err)
+			slog.Error("Email: failed to store message context", "error", err)
 		} else {
 			triggerMsgID = &stored.ID
+			if err := p.db.AddMessageAttachments(stored.ID, email.Attachments); err != nil {
+				slog.Warn("Email: failed to store attachments", "message_id", stored.ID, "error", err)
+			}
 		}
 	}
 
 	// Build email content with thread context (shared between analyzers)
 	emailContent := agent.EmailContent{
-		Subject: email.Subject,
-		From:    email.From,
-		To:      email.To,
-		Date:    email.Date,
-		Body:    body,
-	}
-
-	// Add thread history (excluding the latest message which is the email being analyzed)
-	if thread != nil && len(thread.Messages) > 1 {
-		for _, msg := range thread.Messages[:len(thread.Messages)-1] {
-			emailContent.ThreadHistory = append(emailContent.ThreadHistory, agent.EmailThreadMessage{
-				From:    msg.From,
-				Date:    msg.Date,
-				Subject: msg.Subject,
-				Body:    msg.Body,
-			})
+		Subject:       email.Subject,
+		From:          email.From,
+		To:            email.To,
+		Date:          email.Date,
+		Body:          body,
+		ThreadID:      email.ThreadID,
+		ThreadHistory: threadHistory(thread, email.ID),
+	}
+	if emailContent.ThreadID == "" && thread != nil {
+		emailContent.ThreadID = thread.ID
+	}
+
+	// Events detected earlier in the thread, so a reply updates them
+	if userID != 0 && emailContent.ThreadID != "" {
+		threadEvents, err := p.db.GetEventsForEmailThread(userID, emailContent.ThreadID)
+		if err != nil {
+			slog.Error("Email: failed to load thread events", "error", err)
 		}
+		emailContent.ThreadEvents = threadEvents
 	}
 
+	// The user turned analysis off for this sender; the email is still kept as context
+	if channelMode(emailChannel) == database.AnalysisModeNone {
+		return nil
+	}
+
+	// Resolve relative dates against when the email arrived, not when it is analyzed
+	ctx = agent.WithMessageTime(ctx, email.ReceivedAt)
 	if err := p.routeAnalyzeAndPersistEmail(ctx, emailSource, userID, emailChannel, triggerMsgID, intents.EmailInput{Email: emailContent}); err != nil {
-		fmt.Printf("Email intent orchestration error: %v\n", err)
+		slog.Error("Email intent orchestration error", "error", err)
 	}
 
 	return nil
 }
 
+// threadHistory returns the thread's messages before the email being analyzed. If the
+// email isn't in the thread, every message but the latest is history.
+func threadHistory(thread *gmail.Thread, emailID string) []agent.EmailThreadMessage {
+	if thread == nil || len(thread.Messages) == 0 {
+		return nil
+	}
+
+	earlier := thread.Messages[:len(thread.Messages)-1]
+	for i, msg := range thread.Messages {
+		if emailID != "" && msg.ID == emailID {
+			earlier = thread.Messages[:i]
+			break
+		}
+	}
+
+	history := make([]agent.EmailThreadMessage, 0, len(earlier))
+	for _, msg := range earlier {
+		history = append(history, agent.EmailThreadMessage{
+			From:    msg.From,
+			Date:    msg.Date,
+			Subject: msg.Subject,
+			Body:    msg.Body,
+		})
+	}
+	return history
+}
+
 type emailIntentPersister struct {
 	p           *EmailProcessor
 	emailSource *gmail.EmailSource
+	channel     *database.SourceChannel
 	messageID   *int64
+	threadID    string
 }
 
 func (ep *emailIntentPersister) PersistEvent(ctx context.Context, analysis *agent.EventAnalysis) error {
-	return ep.p.createPendingEventFromEmail(ep.emailSource, ep.messageID, analysis)
+	if !channelMode(ep.channel).AllowsEvents() {
+		return nil
+	}
+	return ep.p.createPendingEventFromEmail(ep.emailSource, ep.messageID, ep.threadID, analysis)
 }
 
 func (ep *emailIntentPersister) PersistReminder(ctx context.Context, analysis *agent.ReminderAnalysis) error {
+	if !channelMode(ep.channel).AllowsReminders() {
+		return nil
+	}
 	return ep.p.createPendingReminderFromEmail(ep.emailSource, ep.messageID, analysis)
 }
 
@@ -137,7 +203,7 @@ func (p *EmailProcessor) routeAnalyzeAndPersistEmail(
 	}
 
 	route := p.intentRouter.RouteEmail(ctx, input)
-	fmt.Printf("Email intent route: intent=%s confidence=%.2f reason=%s\n", route.Intent, route.Confidence, truncate(route.Reasoning, 80))
+	slog.Info("Email intent route", "intent", route.Intent, "confidence", route.Confidence, "reason", truncate(route.Reasoning, 80))
 
 	if emailChannel != nil && userID != 0 {
 		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
@@ -159,11 +225,13 @@ func (p *EmailProcessor) routeAnalyzeAndPersistEmail(
 				"email_body_len":       len(input.Email.Body),
 				"email_body_excerpt":   truncate(input.Email.Body, 800),
 				"thread_history_count": len(input.Email.ThreadHistory),
+				"thread_event_count":   len(input.Email.ThreadEvents),
 			},
 		})
 	}
 
 	intentOrder, unknownRoutedIntent := resolveIntentExecutionOrder(p.intentRegistry, route)
+	intentOrder = filterIntentsForMode(intentOrder, channelMode(emailChannel))
 	if len(intentOrder) == 0 {
 		return nil
 	}
@@ -171,7 +239,7 @@ func (p *EmailProcessor) routeAnalyzeAndPersistEmail(
 	var firstErr error
 	for _, intentName := range intentOrder {
 		if err := p.runEmailIntentModule(ctx, intentName, emailSource, userID, emailChannel, triggerMsgID, input); err != nil {
-			fmt.Printf("Email intent module %s error: %v\n", intentName, err)
+			slog.Error("Email intent module error", "intent_name", intentName, "error", err)
 			if firstErr == nil {
 				firstErr = err
 			}
@@ -199,7 +267,7 @@ func (p *EmailProcessor) runEmailIntentModule(
 
 	module, ok := p.intentRegistry.Get(intentName)
 	if !ok {
-		fmt.Printf("Unknown email intent '%s' -> no_action\n", intentName)
+		slog.Info("Unknown email intent -> no_action", "intent_name", intentName)
 		if emailChannel != nil && userID != 0 && channelID != 0 {
 			_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 				UserID:     userID,
@@ -220,7 +288,41 @@ func (p *EmailProcessor) runEmailIntentModule(
 		return nil
 	}
 
-	output, err := module.AnalyzeEmail(ctx, input)
+	// Persist runs with the same user context so modules can look up the user's data
+	ctx = AnalysisContext(ctx, p.db, userID)
+	recorder := &analysisRecorder{}
+	output, err := module.AnalyzeEmail(recorder.context(ctx), input)
+	if triggerMsgID != nil {
+		recorder.save(p.db, database.AnalysisLogEntry{
+			UserID:    userID,
+			MessageID: *triggerMsgID,
+			ChannelID: channelID,
+			Intent:    intentName,
+		}, output)
+	}
+	if errors.Is(err, agent.ErrBudgetExceeded) {
+		if emailChannel != nil && userID != 0 && channelID != 0 {
+			_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
+				UserID:           userID,
+				ChannelID:        channelID,
+				SourceType:       string(source.SourceTypeGmail),
+				TriggerMessageID: triggerMsgID,
+				Intent:           intentName,
+				Status:           "budget_exceeded",
+			})
+		}
+		return nil
+	}
+	if userID != 0 {
+		p.shadow.compare(ctx, intentName, database.ShadowAnalysis{
+			UserID:           userID,
+			ChannelID:        channelID,
+			SourceType:       string(source.SourceTypeGmail),
+			TriggerMessageID: triggerMsgID,
+		}, output, func(ctx context.Context, shadow intents.IntentModule) (*intents.ModuleOutput, error) {
+			return shadow.AnalyzeEmail(ctx, input)
+		})
+	}
 	if err != nil {
 		return err
 	}
@@ -229,7 +331,7 @@ func (p *EmailProcessor) runEmailIntentModule(
 	}
 
 	if err := module.Validate(ctx, output); err != nil {
-		fmt.Printf("Email intent validation failed intent=%s: %v\n", intentName, err)
+		slog.Error("Email intent validation failed", "intent", intentName, "error", err)
 		if emailChannel != nil && userID != 0 && channelID != 0 {
 			_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 				UserID:     userID,
@@ -253,8 +355,8 @@ func (p *EmailProcessor) runEmailIntentModule(
 		}
 		return nil
 	}
-	if output.Confidence < minPersistConfidence {
-		fmt.Printf("Skipping low-confidence email intent=%s confidence=%.2f\n", intentName, output.Confidence)
+	if confidenceThresholds(p.db, userID).ShouldDiscard(output.Confidence) {
+		slog.Info("Skipping low-confidence email", "intent", intentName, "confidence", output.Confidence)
 		if emailChannel != nil && userID != 0 && channelID != 0 {
 			_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 				UserID:     userID,
@@ -277,7 +379,7 @@ func (p *EmailProcessor) runEmailIntentModule(
 		return nil
 	}
 
-	err = module.Persist(ctx, output, &emailIntentPersister{p: p, emailSource: emailSource, messageID: triggerMsgID})
+	err = module.Persist(ctx, output, &emailIntentPersister{p: p, emailSource: emailSource, channel: emailChannel, messageID: triggerMsgID, threadID: input.Email.ThreadID})
 	status := "persisted"
 	if err != nil {
 		status = "persist_error"
@@ -305,7 +407,7 @@ func (p *EmailProcessor) runEmailIntentModule(
 }
 
 // createPendingEventFromEmail creates a pending event from email analysis
-func (p *EmailProcessor) createPendingEventFromEmail(emailSource *gmail.EmailSource, messageID *int64, analysis *agent.EventAnalysis) error {
+func (p *EmailProcessor) createPendingEventFromEmail(emailSource *gmail.EmailSource, messageID *int64, threadID string, analysis *agent.EventAnalysis) error {
 	// Get or create a placeholder channel for email sources
 	emailChannel, userID, err := p.getOrCreateEmailChannel(emailSource)
 	if err != nil {
@@ -318,10 +420,19 @@ func (p *EmailProcessor) createPendingEventFromEmail(emailSource *gmail.EmailSou
 		ChannelID:     emailChannel.ID,
 		SourceType:    source.SourceTypeGmail,
 		EmailSourceID: &emailSourceID,
+		EmailThreadID: threadID,
 		MessageID:     messageID,
 		Analysis:      analysis,
 	}
 
+	// Check if we should update an existing pending event
+	if analysis.Event != nil && analysis.Event.AlfredEventRef != 0 {
+		existing, err := p.db.GetEventByID(analysis.Event.AlfredEventRef)
+		if err == nil && existing.UserID == userID && existing.Status == database.EventStatusPending {
+			params.ExistingEvent = existing
+		}
+	}
+
 	_, err = p.eventCreator.CreateEventFromAnalysis(context.Background(), params)
 	return err
 }
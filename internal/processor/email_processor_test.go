@@ -0,0 +1,32 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/testutil/fixtures"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreadHistory(t *testing.T) {
+	thread := fixtures.NewEmailBuilder().
+		InReplyTo("Dana <dana@example.com>", "Design review Thursday at 2pm").
+		InReplyTo("me@example.com", "ok, moved to 4pm").
+		WithBody("see you there").
+		Thread()
+	first, second := thread.Messages[0].ID, thread.Messages[1].ID
+
+	bodies := func(emailID string) []string {
+		var out []string
+		for _, msg := range threadHistory(thread, emailID) {
+			out = append(out, msg.Body)
+		}
+		return out
+	}
+
+	// A reply only sees what came before it, even when later replies exist
+	assert.Equal(t, []string{"Design review Thursday at 2pm"}, bodies(second))
+	assert.Empty(t, bodies(first))
+	// Unknown emails fall back to everything but the latest message
+	assert.Equal(t, []string{"Design review Thursday at 2pm", "ok, moved to 4pm"}, bodies("unknown"))
+	assert.Nil(t, threadHistory(nil, first))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -26,6 +27,7 @@ type EventCreationParams struct {
 	// Source tracking
 	SourceType    source.SourceType
 	EmailSourceID *int64 // Only for gmail sources
+	EmailThreadID string // Only for gmail sources, so replies in the thread can update the event
 	MessageID     *int64 // Reference to triggering message
 
 	// From event analysis
@@ -39,6 +41,7 @@ type EventCreationParams struct {
 type EventCreator struct {
 	db            *database.DB
 	notifyService *notify.Service
+	autoConfirmer AutoConfirmer
 }
 
 // NewEventCreator creates a new EventCreator
@@ -88,8 +91,11 @@ func (ec *EventCreator) CreateEventFromAnalysis(ctx context.Context, params Even
 		googleEventID = existingRefEvent.GoogleEventID
 	}
 
-	// Get calendar ID if not provided
+	// Get calendar ID if not provided: the channel's label calendar, else the selected one
 	calendarID := params.CalendarID
+	if calendarID == "" {
+		calendarID, _ = ec.db.GetChannelLabelCalendarID(params.UserID, params.ChannelID)
+	}
 	if calendarID == "" {
 		calendarID, _ = ec.db.GetSelectedCalendarID(params.UserID)
 	}
@@ -103,6 +109,7 @@ func (ec *EventCreator) CreateEventFromAnalysis(ctx context.Context, params Even
 	title := strings.TrimSpace(params.Analysis.Event.Title)
 	description := strings.TrimSpace(params.Analysis.Event.Description)
 	location := strings.TrimSpace(params.Analysis.Event.Location)
+	recurrence := strings.TrimSpace(params.Analysis.Event.Recurrence)
 	if existingRefEvent != nil {
 		if title == "" {
 			title = existingRefEvent.Title
@@ -113,11 +120,26 @@ func (ec *EventCreator) CreateEventFromAnalysis(ctx context.Context, params Even
 		if location == "" {
 			location = existingRefEvent.Location
 		}
+		if recurrence == "" {
+			recurrence = existingRefEvent.Recurrence
+		}
 	}
 	if title == "" {
 		title = "Untitled event"
 	}
 
+	// Another source may already have described this event, e.g. a calendar invite email
+	// for a dinner first mentioned on WhatsApp
+	if canReconcile(params.Analysis, actionType, existingRefEvent) {
+		same, err := ec.findSameEvent(params, title, startTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look for the same event: %w", err)
+		}
+		if same != nil {
+			return ec.mergeEventSource(ctx, same, params, endTime)
+		}
+	}
+
 	qualityFlags := buildQualityFlags(params.Analysis.Confidence, timezoneFallback)
 
 	event := &database.CalendarEvent{
@@ -135,6 +157,7 @@ func (ec *EventCreator) CreateEventFromAnalysis(ctx context.Context, params Even
 		LLMReasoning:  params.Analysis.Reasoning,
 		LLMConfidence: params.Analysis.Confidence,
 		QualityFlags:  qualityFlags,
+		Recurrence:    recurrence,
 	}
 
 	created, err := ec.db.CreatePendingEvent(event)
@@ -151,12 +174,31 @@ func (ec *EventCreator) CreateEventFromAnalysis(ctx context.Context, params Even
 			params.SourceType, created.ID)
 	}
 
+	if params.EmailThreadID != "" {
+		if err := ec.db.SetEventEmailThread(created.ID, params.EmailThreadID); err != nil {
+			return nil, err
+		}
+	}
+
+	if trackingNumber := strings.TrimSpace(params.Analysis.Event.TrackingNumber); trackingNumber != "" {
+		if err := ec.db.SetEventTrackingNumber(created.ID, trackingNumber); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := ec.persistEventAttendees(created.ID, params.Analysis.Event); err != nil {
 		return nil, fmt.Errorf("failed to persist event attendees: %w", err)
 	}
 
-	fmt.Printf("Created pending event: %s (ID: %d, Action: %s, Source: %s)\n",
-		created.Title, created.ID, created.ActionType, params.SourceType)
+	slog.InfoContext(ctx, "Created pending event", "title", created.Title, "event_id", created.ID, "action_type", created.ActionType, "source_type", params.SourceType)
+
+	if created.ActionType == database.EventActionCreate {
+		ec.shareWithChannelMembers(created)
+	}
+
+	if confirmed := ec.autoConfirm(ctx, created, params.Analysis.Confidence); confirmed != nil {
+		return confirmed, nil
+	}
 
 	// Send notification (non-blocking, don't fail event creation)
 	if ec.notifyService != nil {
@@ -166,6 +208,54 @@ func (ec *EventCreator) CreateEventFromAnalysis(ctx context.Context, params Even
 	return created, nil
 }
 
+// autoConfirm confirms a new pending event when its confidence reaches the user's
+// auto-confirm threshold and the auto_confirm feature flag is on. It returns the
+// confirmed event, or nil to leave it pending.
+func (ec *EventCreator) autoConfirm(ctx context.Context, event *database.CalendarEvent, confidence float64) *database.CalendarEvent {
+	if ec.autoConfirmer == nil || !confidenceThresholds(ec.db, event.UserID).ShouldAutoConfirm(confidence) ||
+		!autoConfirmEnabled(ctx, ec.db, event.UserID) {
+		return nil
+	}
+	if err := ec.autoConfirmer.ConfirmEvent(ctx, event); err != nil {
+		slog.WarnContext(ctx, "failed to auto-confirm event, leaving it pending", "event_id", event.ID, "error", err)
+		return nil
+	}
+	slog.InfoContext(ctx, "Auto-confirmed event", "title", event.Title, "event_id", event.ID, "confidence", confidence)
+
+	confirmed, err := ec.db.GetEventByID(event.ID)
+	if err != nil {
+		return event
+	}
+	return confirmed
+}
+
+// shareWithChannelMembers copies a new event to the members of its channel if the channel
+// is shared, so it lands in their calendars once they confirm it. Failures are logged.
+func (ec *EventCreator) shareWithChannelMembers(event *database.CalendarEvent) {
+	shared, err := ec.db.IsChannelShared(event.ChannelID)
+	if err != nil {
+		slog.Warn("failed to check shares", "channel_id", event.ChannelID, "error", err)
+		return
+	}
+	if !shared {
+		return
+	}
+
+	copies, err := ec.db.ShareEventWithMembers(event.ID)
+	if err != nil {
+		slog.Warn("failed to share event", "event_id", event.ID, "error", err)
+		return
+	}
+	event.Shared = true
+
+	for _, copied := range copies {
+		slog.Info("Shared event", "event_id", event.ID, "shared_user_id", copied.UserID, "shared_event_id", copied.ID)
+		if ec.notifyService != nil {
+			go ec.notifyService.NotifyPendingEvent(context.Background(), copied)
+		}
+	}
+}
+
 // handleExistingPendingEvent handles update/delete of an existing pending event
 func (ec *EventCreator) handleExistingPendingEvent(
 	existing *database.CalendarEvent,
@@ -177,8 +267,7 @@ func (ec *EventCreator) handleExistingPendingEvent(
 		if err := ec.db.UpdateEventStatus(existing.ID, database.EventStatusRejected); err != nil {
 			return nil, fmt.Errorf("failed to reject pending event: %w", err)
 		}
-		fmt.Printf("Rejected pending event: %s (ID: %d) - user cancelled\n",
-			existing.Title, existing.ID)
+		slog.Info("Rejected pending event, user cancelled", "title", existing.Title, "event_id", existing.ID)
 		return existing, nil
 	}
 
@@ -227,8 +316,7 @@ func (ec *EventCreator) handleExistingPendingEvent(
 		return nil, fmt.Errorf("failed to update event attendees: %w", err)
 	}
 
-	fmt.Printf("Updated pending event: %s (ID: %d)\n",
-		title, existing.ID)
+	slog.Info("Updated pending event", "title", title, "event_id", existing.ID)
 
 	// Return the updated event
 	updated, _ := ec.db.GetEventByID(existing.ID)
@@ -536,3 +536,122 @@ func TestEventCreationParams(t *testing.T) {
 	assert.NotNil(t, params.Analysis)
 	assert.NotNil(t, params.ExistingEvent)
 }
+
+func TestCreateEventFromAnalysis_TagsTrackingNumber(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeGmail,
+		source.ChannelTypeSender,
+		"email:sender:ship@ups.com",
+		"Email: UPS",
+	)
+	require.NoError(t, err)
+
+	creator := NewEventCreator(db, nil)
+
+	created, err := creator.CreateEventFromAnalysis(context.Background(), EventCreationParams{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		SourceType: source.SourceTypeGmail,
+		Analysis: &agent.EventAnalysis{
+			HasEvent:   true,
+			Action:     "create",
+			Confidence: 0.9,
+			Event: &agent.EventData{
+				Title:          "Package arriving: Amazon",
+				StartTime:      "2030-05-12T09:00:00Z",
+				EndTime:        "2030-05-12T18:00:00Z",
+				TrackingNumber: "1Z999AA10123456784",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	eventID, err := db.GetTrackedEventID(user.ID, "1Z999AA10123456784")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, eventID)
+}
+
+// stubAutoConfirmer confirms locally and records what it was asked to confirm
+type stubAutoConfirmer struct {
+	db        *database.DB
+	err       error
+	events    []int64
+	reminders []int64
+}
+
+func (s *stubAutoConfirmer) ConfirmEvent(_ context.Context, event *database.CalendarEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.events = append(s.events, event.ID)
+	return s.db.UpdateEventStatus(event.ID, database.EventStatusConfirmed)
+}
+
+func (s *stubAutoConfirmer) ConfirmReminder(_ context.Context, reminder *database.Reminder) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.reminders = append(s.reminders, reminder.ID)
+	return s.db.UpdateReminderStatus(reminder.ID, database.ReminderStatusConfirmed)
+}
+
+func TestCreateEventFromAnalysis_AutoConfirm(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "auto@s.whatsapp.net", "Auto")
+	require.NoError(t, err)
+
+	confirmer := &stubAutoConfirmer{db: db}
+	creator := NewEventCreator(db, nil)
+	creator.autoConfirmer = confirmer
+
+	create := func(confidence float64) *database.CalendarEvent {
+		t.Helper()
+		created, err := creator.CreateEventFromAnalysis(context.Background(), EventCreationParams{
+			UserID:     user.ID,
+			ChannelID:  channel.ID,
+			SourceType: source.SourceTypeWhatsApp,
+			Analysis: &agent.EventAnalysis{
+				HasEvent:   true,
+				Action:     "create",
+				Confidence: confidence,
+				Event:      &agent.EventData{Title: "Dinner", StartTime: "2030-01-15T19:00:00Z"},
+			},
+		})
+		require.NoError(t, err)
+		return created
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		created := create(0.99)
+		assert.Equal(t, database.EventStatusPending, created.Status)
+		assert.Empty(t, confirmer.events)
+	})
+
+	require.NoError(t, db.SetConfidenceThresholds(user.ID, database.ConfidenceThresholds{
+		MinConfidence:         0.4,
+		AutoConfirmConfidence: 0.9,
+	}))
+
+	t.Run("below threshold stays pending", func(t *testing.T) {
+		created := create(0.85)
+		assert.Equal(t, database.EventStatusPending, created.Status)
+		assert.Empty(t, confirmer.events)
+	})
+
+	t.Run("at threshold is confirmed", func(t *testing.T) {
+		created := create(0.9)
+		assert.Equal(t, database.EventStatusConfirmed, created.Status)
+		assert.Equal(t, []int64{created.ID}, confirmer.events)
+	})
+
+	t.Run("failed confirm leaves it pending", func(t *testing.T) {
+		confirmer.err = assert.AnError
+		created := create(0.95)
+		assert.Equal(t, database.EventStatusPending, created.Status)
+	})
+}
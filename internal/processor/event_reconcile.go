@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// reconcileWindow is how far apart two sources' start times can be and still describe
+// the same event
+const reconcileWindow = 30 * time.Minute
+
+// minTitleOverlap is the share of the shorter title's words the other title must contain
+const minTitleOverlap = 0.5
+
+// titleStopWords are left out when comparing titles
+var titleStopWords = map[string]bool{
+	"the": true, "and": true, "with": true, "for": true, "at": true, "on": true, "in": true,
+	"of": true, "to": true, "a": true, "an": true, "invitation": true, "invite": true,
+	"updated": true, "event": true,
+}
+
+// findSameEvent returns the user's event from another channel that describes the same
+// event as a new detection, or nil. A match starts within reconcileWindow and shares
+// most of the words of its title.
+func (ec *EventCreator) findSameEvent(params EventCreationParams, title string, start time.Time) (*database.CalendarEvent, error) {
+	candidates, err := ec.db.ListReconcileCandidates(params.UserID, params.ChannelID, start, reconcileWindow)
+	if err != nil {
+		return nil, err
+	}
+	for i := range candidates {
+		if sameEventTitle(candidates[i].Title, title) {
+			return ec.db.GetEventByID(candidates[i].ID)
+		}
+	}
+	return nil, nil
+}
+
+// mergeEventSource records a new detection as another source of an existing event and
+// fills in details the event is missing while it is still pending
+func (ec *EventCreator) mergeEventSource(ctx context.Context, existing *database.CalendarEvent, params EventCreationParams, endTime *time.Time) (*database.CalendarEvent, error) {
+	if err := ec.db.AddEventSource(existing.ID, params.SourceType, params.ChannelID, params.MessageID); err != nil {
+		return nil, err
+	}
+
+	if existing.Status == database.EventStatusPending {
+		data := params.Analysis.Event
+		description, location, end := existing.Description, existing.Location, existing.EndTime
+		if description == "" {
+			description = strings.TrimSpace(data.Description)
+		}
+		if location == "" {
+			location = strings.TrimSpace(data.Location)
+		}
+		if end == nil {
+			end = endTime
+		}
+		if err := ec.db.UpdatePendingEvent(existing.ID, existing.Title, description, existing.StartTime, end, location); err != nil {
+			return nil, err
+		}
+		if len(existing.Attendees) == 0 {
+			if err := ec.persistEventAttendees(existing.ID, data); err != nil {
+				return nil, fmt.Errorf("failed to persist event attendees: %w", err)
+			}
+		}
+	}
+
+	slog.InfoContext(ctx, "Merged event source", "event_id", existing.ID, "title", existing.Title,
+		"source_type", params.SourceType, "channel_id", params.ChannelID)
+
+	merged, err := ec.db.GetEventByID(existing.ID)
+	if err != nil {
+		return existing, nil
+	}
+	return merged, nil
+}
+
+// canReconcile reports whether a detection may merge into an event from another source:
+// only new events, not edits of an event the analysis already referenced
+func canReconcile(analysis *agent.EventAnalysis, actionType database.EventActionType, ref *database.CalendarEvent) bool {
+	return actionType == database.EventActionCreate && ref == nil && analysis.Event.UpdateRef == ""
+}
+
+// sameEventTitle reports whether two titles name the same event: the longer one holds
+// at least half the significant words of the shorter one
+func sameEventTitle(a, b string) bool {
+	wordsA, wordsB := titleWords(a), titleWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return false
+	}
+	if len(wordsA) > len(wordsB) {
+		wordsA, wordsB = wordsB, wordsA
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return shared > 0 && float64(shared)/float64(len(wordsA)) >= minTitleOverlap
+}
+
+func titleWords(title string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) < 2 || titleStopWords[word] {
+			continue
+		}
+		words[word] = true
+	}
+	return words
+}
@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSameEventTitle(t *testing.T) {
+	assert.True(t, sameEventTitle("Dinner with Dana", "Invitation: Dinner at Luigi's"))
+	assert.True(t, sameEventTitle("Dana's birthday party", "Birthday party"))
+	assert.True(t, sameEventTitle("ארוחת ערב עם דנה", "ארוחת ערב"))
+	assert.False(t, sameEventTitle("Dentist", "Team standup"))
+	assert.False(t, sameEventTitle("Dinner with Dana and Avi", "Office party dinner"), "one shared word is not half of three")
+	assert.False(t, sameEventTitle("The event", "An invitation"))
+}
+
+func TestCreateEventFromAnalysis_MergesSameEventFromAnotherSource(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	chat, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "dana@s.whatsapp.net", "Dana")
+	require.NoError(t, err)
+	inbox, err := db.CreateSourceChannel(user.ID, source.SourceTypeGmail, source.ChannelTypeSender, "dana@example.com", "dana@example.com")
+	require.NoError(t, err)
+
+	creator := NewEventCreator(db, nil)
+	create := func(channel *database.SourceChannel, sourceType source.SourceType, event *agent.EventData) *database.CalendarEvent {
+		t.Helper()
+		created, err := creator.CreateEventFromAnalysis(context.Background(), EventCreationParams{
+			UserID:     user.ID,
+			ChannelID:  channel.ID,
+			SourceType: sourceType,
+			Analysis:   &agent.EventAnalysis{HasEvent: true, Action: "create", Confidence: 0.9, Event: event},
+		})
+		require.NoError(t, err)
+		return created
+	}
+
+	mention := create(chat, source.SourceTypeWhatsApp, &agent.EventData{
+		Title:     "Dinner with Dana",
+		StartTime: "2026-03-13T19:00:00Z",
+	})
+	invite := create(inbox, source.SourceTypeGmail, &agent.EventData{
+		Title:     "Invitation: Dinner at Luigi's",
+		StartTime: "2026-03-13T19:15:00Z",
+		EndTime:   "2026-03-13T21:00:00Z",
+		Location:  "Luigi's, Tel Aviv",
+		Attendees: []agent.EventAttendeeData{{Email: "dana@example.com", Name: "Dana"}},
+	})
+
+	assert.Equal(t, mention.ID, invite.ID, "the invite merges into the WhatsApp event")
+	assert.Equal(t, "Dinner with Dana", invite.Title)
+	assert.Equal(t, "Luigi's, Tel Aviv", invite.Location, "missing details are filled in")
+	require.NotNil(t, invite.EndTime)
+	assert.Len(t, invite.Attendees, 1)
+	require.Len(t, invite.Sources, 2)
+	assert.Equal(t, source.SourceTypeWhatsApp, invite.Sources[0].SourceType)
+	assert.Equal(t, source.SourceTypeGmail, invite.Sources[1].SourceType)
+	assert.Equal(t, inbox.ID, invite.Sources[1].ChannelID)
+
+	pending, err := db.GetPendingEvents(user.ID, nil)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	other := create(inbox, source.SourceTypeGmail, &agent.EventData{
+		Title:     "Dinner with Dana",
+		StartTime: "2026-03-14T19:00:00Z",
+	})
+	assert.NotEqual(t, mention.ID, other.ID, "a different day is a different event")
+}
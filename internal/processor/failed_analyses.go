@@ -0,0 +1,203 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent/intents"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// failedAnalysisBackoff is the wait before each retry of a failed analysis; after the
+// last one the analysis is marked dead
+var failedAnalysisBackoff = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+}
+
+const (
+	failedAnalysisRetryInterval = 30 * time.Second
+	failedAnalysisBatchSize     = 20
+)
+
+// analysisError marks an error from an intent module's agent call (API error, timeout),
+// as opposed to validation or persistence errors. Only these are retried.
+type analysisError struct {
+	err error
+}
+
+func (e *analysisError) Error() string { return e.err.Error() }
+func (e *analysisError) Unwrap() error { return e.err }
+
+// failedAnalysisInput is the part of a message's intent input stored with a failed
+// analysis. Existing events and reminders are reloaded when it is retried, so the agent
+// sees what other analyses created in the meantime.
+type failedAnalysisInput struct {
+	History         []database.MessageRecord `json:"history"`
+	NewMessage      database.MessageRecord   `json:"new_message"`
+	Related         []database.MessageRecord `json:"related,omitempty"`
+	ChannelLanguage string                   `json:"channel_language,omitempty"`
+}
+
+// recordFailedAnalysis adds an analysis whose agent call failed to the dead-letter queue.
+// Failures during shutdown are left to the durable message queue instead.
+func (p *Processor) recordFailedAnalysis(
+	intentName string,
+	channel *database.SourceChannel,
+	sourceType source.SourceType,
+	messageID int64,
+	input intents.MessageInput,
+	cause error,
+) {
+	if p.ctx.Err() != nil {
+		return
+	}
+
+	raw, err := json.Marshal(failedAnalysisInput{
+		History:         input.History,
+		NewMessage:      input.NewMessage,
+		Related:         input.Related,
+		ChannelLanguage: input.ChannelLanguage,
+	})
+	if err != nil {
+		slog.Warn("failed to encode failed analysis input", "error", err)
+		return
+	}
+
+	msgID := messageID
+	id, err := p.db.CreateFailedAnalysis(database.FailedAnalysis{
+		UserID:           channel.UserID,
+		ChannelID:        channel.ID,
+		SourceType:       string(sourceType),
+		Intent:           intentName,
+		TriggerMessageID: &msgID,
+		Input:            string(raw),
+		LastError:        cause.Error(),
+	}, time.Now().Add(failedAnalysisBackoff[0]))
+	if err != nil {
+		slog.Warn("Event processor: failed to queue failed analysis", "intent_name", intentName, "error", err)
+		return
+	}
+	slog.Info("Event processor: queued failed analysis for retry", "intent_name", intentName, "failed_analysis_id", id)
+}
+
+// retryLoop periodically retries failed analyses that are due
+func (p *Processor) retryLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(failedAnalysisRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.retryDueAnalyses(time.Now())
+		}
+	}
+}
+
+// retryDueAnalyses retries every failed analysis whose backoff has elapsed. Nothing is
+// retried while the LLM API is down, so outages don't use up the retries.
+func (p *Processor) retryDueAnalyses(now time.Time) {
+	if p.llmDown() {
+		return
+	}
+
+	due, err := p.db.GetDueFailedAnalyses(now, failedAnalysisBatchSize)
+	if err != nil {
+		slog.Error("Event processor: failed to get due failed analyses", "error", err)
+		return
+	}
+
+	for _, f := range due {
+		if p.ctx.Err() != nil {
+			return
+		}
+		p.retryFailedAnalysis(f, now)
+	}
+}
+
+// retryFailedAnalysis runs a failed analysis's intent again and records the outcome:
+// resolved on success, rescheduled with backoff on failure, dead once retries run out
+func (p *Processor) retryFailedAnalysis(f database.FailedAnalysis, now time.Time) {
+	err := p.rerunFailedAnalysis(f)
+	if err != nil && p.ctx.Err() != nil {
+		// Interrupted by shutdown; the attempt doesn't count
+		return
+	}
+
+	attempts := f.Attempts + 1
+	if err == nil {
+		slog.Info("Event processor: failed analysis resolved", "failed_analysis_id", f.ID, "attempts", attempts)
+		if recordErr := p.db.RecordFailedAnalysisAttempt(f.ID, attempts, database.FailedAnalysisResolved, "", nil); recordErr != nil {
+			slog.Warn("Event processor: failed to record failed analysis attempt", "failed_analysis_id", f.ID, "error", recordErr)
+		}
+		return
+	}
+
+	status := database.FailedAnalysisDead
+	var next *time.Time
+	if f.Attempts < len(failedAnalysisBackoff) {
+		status = database.FailedAnalysisPending
+		retryAt := now.Add(failedAnalysisBackoff[f.Attempts])
+		next = &retryAt
+	}
+	slog.Error("Event processor: retry of failed analysis failed", "failed_analysis_id", f.ID, "attempts", attempts, "status", status, "error", err)
+	if recordErr := p.db.RecordFailedAnalysisAttempt(f.ID, attempts, status, err.Error(), next); recordErr != nil {
+		slog.Warn("Event processor: failed to record failed analysis attempt", "failed_analysis_id", f.ID, "error", recordErr)
+	}
+}
+
+// rerunFailedAnalysis runs the failed intent module again with the stored input. An
+// analysis whose channel was removed, disabled or no longer runs the intent succeeds
+// without doing anything.
+func (p *Processor) rerunFailedAnalysis(f database.FailedAnalysis) error {
+	channel, err := p.db.GetSourceChannelByID(f.UserID, f.ChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to get channel: %w", err)
+	}
+	if channel == nil || !channel.Enabled || len(filterIntentsForMode([]string{f.Intent}, channelMode(channel))) == 0 {
+		return nil
+	}
+
+	var stored failedAnalysisInput
+	if err := json.Unmarshal([]byte(f.Input), &stored); err != nil {
+		return fmt.Errorf("failed to decode input: %w", err)
+	}
+
+	existingEvents, err := p.db.GetActiveEventsForChannel(channel.UserID, channel.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing events: %w", err)
+	}
+	existingReminders, err := p.db.GetActiveRemindersForChannel(channel.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing reminders: %w", err)
+	}
+
+	messageID := stored.NewMessage.ID
+	if f.TriggerMessageID != nil {
+		messageID = *f.TriggerMessageID
+	}
+	return p.runMessageIntentModule(f.Intent, channel, source.SourceType(f.SourceType), messageID, intents.MessageInput{
+		History:           stored.History,
+		NewMessage:        stored.NewMessage,
+		ExistingEvents:    existingEvents,
+		ExistingReminders: existingReminders,
+		Related:           stored.Related,
+		ChannelLanguage:   stored.ChannelLanguage,
+	})
+}
+
+// isAnalysisError reports whether err came from an intent module's agent call
+func isAnalysisError(err error) bool {
+	var failure *analysisError
+	return errors.As(err, &failure)
+}
@@ -0,0 +1,195 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyEventAnalyzer fails until it is told to recover
+type flakyEventAnalyzer struct {
+	recordingEventAnalyzer
+	failMu  sync.Mutex
+	failing bool
+}
+
+func (a *flakyEventAnalyzer) setFailing(failing bool) {
+	a.failMu.Lock()
+	defer a.failMu.Unlock()
+	a.failing = failing
+}
+
+func (a *flakyEventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	a.failMu.Lock()
+	failing := a.failing
+	a.failMu.Unlock()
+	if failing {
+		return nil, errors.New("anthropic: 529 overloaded")
+	}
+	return a.recordingEventAnalyzer.AnalyzeMessages(ctx, history, newMessage, existingEvents)
+}
+
+func TestFailedAnalysisRetries(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "contact@s.whatsapp.net", "Contact")
+	require.NoError(t, err)
+
+	analyzer := &flakyEventAnalyzer{failing: true}
+	p := New(db, analyzer, nil, make(chan source.Message), 25, nil)
+	defer p.Stop()
+
+	require.NoError(t, p.processMessage(source.Message{
+		UserID:     user.ID,
+		SourceType: source.SourceTypeWhatsApp,
+		SourceID:   channel.ID,
+		SenderID:   "contact@s.whatsapp.net",
+		SenderName: "Contact",
+		Text:       "Meeting on Sunday at 10?",
+		Timestamp:  time.Now(),
+	}, 0))
+
+	failed, err := db.ListFailedAnalyses(nil, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "event", failed[0].Intent)
+	assert.Equal(t, database.FailedAnalysisPending, failed[0].Status)
+	assert.Equal(t, 1, failed[0].Attempts)
+	assert.Contains(t, failed[0].LastError, "overloaded")
+	id := failed[0].ID
+
+	t.Run("not retried before its backoff", func(t *testing.T) {
+		p.retryDueAnalyses(time.Now())
+		f, err := db.GetFailedAnalysis(id)
+		require.NoError(t, err)
+		assert.Equal(t, 1, f.Attempts)
+	})
+
+	t.Run("failed retry backs off", func(t *testing.T) {
+		now := time.Now().Add(failedAnalysisBackoff[0])
+		p.retryDueAnalyses(now)
+		f, err := db.GetFailedAnalysis(id)
+		require.NoError(t, err)
+		assert.Equal(t, 2, f.Attempts)
+		assert.Equal(t, database.FailedAnalysisPending, f.Status)
+		require.NotNil(t, f.NextAttemptAt)
+		assert.WithinDuration(t, now.Add(failedAnalysisBackoff[1]), *f.NextAttemptAt, time.Second)
+	})
+
+	t.Run("successful retry resolves it with the original input", func(t *testing.T) {
+		analyzer.setFailing(false)
+		p.retryDueAnalyses(time.Now().Add(time.Hour))
+		f, err := db.GetFailedAnalysis(id)
+		require.NoError(t, err)
+		assert.Equal(t, database.FailedAnalysisResolved, f.Status)
+
+		calls := analyzer.calls()
+		require.Len(t, calls, 1)
+		assert.Equal(t, "Meeting on Sunday at 10?", calls[0].MessageText)
+	})
+}
+
+func TestFailedAnalysisDiesAfterLastRetry(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "contact@s.whatsapp.net", "Contact")
+	require.NoError(t, err)
+
+	p := New(db, &flakyEventAnalyzer{failing: true}, nil, make(chan source.Message), 25, nil)
+	defer p.Stop()
+
+	id, err := db.CreateFailedAnalysis(database.FailedAnalysis{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		SourceType: string(source.SourceTypeWhatsApp),
+		Intent:     "event",
+		Input:      `{"new_message":{"id":1,"message_text":"Meeting on Sunday at 10?"}}`,
+		LastError:  "timeout",
+	}, time.Now())
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i := 0; i < len(failedAnalysisBackoff); i++ {
+		now = now.Add(3 * time.Hour)
+		p.retryDueAnalyses(now)
+	}
+
+	f, err := db.GetFailedAnalysis(id)
+	require.NoError(t, err)
+	assert.Equal(t, database.FailedAnalysisDead, f.Status)
+	assert.Equal(t, len(failedAnalysisBackoff)+1, f.Attempts)
+	assert.Nil(t, f.NextAttemptAt)
+
+	// A manual retry revives it
+	scheduled, err := db.ScheduleFailedAnalysisRetry(id, now)
+	require.NoError(t, err)
+	assert.True(t, scheduled)
+	due, err := db.GetDueFailedAnalyses(now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, id, due[0].ID)
+}
+
+func TestAnalysisWhileLLMUnavailable(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "contact@s.whatsapp.net", "Contact")
+	require.NoError(t, err)
+
+	analyzer := &flakyEventAnalyzer{failing: true}
+	p := New(db, analyzer, nil, make(chan source.Message), 25, nil)
+	defer p.Stop()
+	var available atomic.Bool
+	p.SetLLMAvailability(available.Load)
+
+	send := func(text string) {
+		require.NoError(t, p.processMessage(source.Message{
+			UserID:     user.ID,
+			SourceType: source.SourceTypeWhatsApp,
+			SourceID:   channel.ID,
+			SenderID:   "contact@s.whatsapp.net",
+			SenderName: "Contact",
+			Text:       text,
+			Timestamp:  time.Now(),
+		}, 0))
+	}
+
+	// Chatter the prefilter alone would analyze is skipped while the API is down
+	send("see you there then")
+	var skipped int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM analysis_traces WHERE user_id = ? AND reasoning = 'llm_unavailable'`, user.ID).Scan(&skipped))
+	assert.Equal(t, 1, skipped)
+
+	// Actionable messages still fail into the retry queue
+	send("Meeting on Sunday at 10?")
+	failed, err := db.ListFailedAnalyses(nil, 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	id := failed[0].ID
+
+	p.retryDueAnalyses(time.Now().Add(time.Hour))
+	f, err := db.GetFailedAnalysis(id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.Attempts, "retries wait for the API")
+
+	available.Store(true)
+	analyzer.setFailing(false)
+	p.retryDueAnalyses(time.Now().Add(time.Hour))
+	f, err = db.GetFailedAnalysis(id)
+	require.NoError(t, err)
+	assert.Equal(t, database.FailedAnalysisResolved, f.Status)
+}
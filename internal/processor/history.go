@@ -2,12 +2,14 @@ package processor
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/source"
 )
 
-// storeSourceMessage saves a message to the message history with source type
+// storeSourceMessage saves a message to the message history with source type, along
+// with references to its attachments
 func (p *Processor) storeSourceMessage(msg source.Message) (*database.SourceMessage, error) {
 	record, err := p.db.StoreSourceMessage(
 		msg.SourceType,
@@ -21,5 +23,9 @@ func (p *Processor) storeSourceMessage(msg source.Message) (*database.SourceMess
 	if err != nil {
 		return nil, fmt.Errorf("failed to store message: %w", err)
 	}
+	if err := p.db.AddMessageAttachments(record.ID, msg.Attachments); err != nil {
+		// The message is still analyzed; only the link to the original media is lost
+		slog.Warn("failed to store message attachments", "message_id", record.ID, "error", err)
+	}
 	return record, nil
 }
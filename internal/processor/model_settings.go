@@ -0,0 +1,64 @@
+package processor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// maxCorrectionExamples caps how many past corrections are shown to the event agent
+const maxCorrectionExamples = 5
+
+// AnalysisContext identifies the user an analysis runs for (for usage metering) and
+// attaches their timezone, locale, model tier and temperature, plus their recent event
+// corrections when they opted in, so the agents analyze their messages with them.
+// Lookup failures fall back to defaults. Also used for assistant chats.
+func AnalysisContext(ctx context.Context, db *database.DB, userID int64) context.Context {
+	if userID == 0 {
+		return ctx
+	}
+	ctx = agent.WithUserID(ctx, userID)
+
+	if tz, err := db.GetUserTimezone(userID); err != nil {
+		slog.Warn("Processor: using server timezone", "user_id", userID, "error", err)
+	} else if loc, err := time.LoadLocation(tz); err != nil {
+		slog.Warn("Processor: using server timezone", "user_id", userID, "error", err)
+	} else {
+		ctx = agent.WithTimezone(ctx, loc)
+	}
+
+	if locale, err := db.GetUserLocale(userID); err != nil {
+		slog.Warn("Processor: using conversation language", "user_id", userID, "error", err)
+	} else {
+		ctx = agent.WithLocale(ctx, locale)
+	}
+
+	if enabled, err := db.IsCorrectionExamplesEnabled(userID); err != nil {
+		slog.Warn("Processor: skipping correction examples", "user_id", userID, "error", err)
+	} else if enabled {
+		corrections, err := db.ListRecentEventCorrections(userID, maxCorrectionExamples)
+		if err != nil {
+			slog.Warn("Processor: skipping correction examples", "user_id", userID, "error", err)
+		} else {
+			ctx = agent.WithEventCorrections(ctx, corrections)
+		}
+	}
+
+	settings, err := db.GetLLMSettings(userID)
+	if err != nil {
+		slog.Warn("Processor: using default model settings", "user_id", userID, "error", err)
+		return ctx
+	}
+	if settings.ModelTier == nil && settings.Temperature == nil {
+		return ctx
+	}
+
+	modelSettings := agent.ModelSettings{Temperature: settings.Temperature}
+	if settings.ModelTier != nil {
+		modelSettings.Tier = *settings.ModelTier
+	}
+	return agent.WithModelSettings(ctx, modelSettings)
+}
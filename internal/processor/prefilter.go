@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// defaultSkipPhrases are whole-message acknowledgements and reactions that never
+// contain an event or reminder. Matching ignores case, punctuation and emoji. Bare
+// "yes"/"no" are left out since they can confirm or cancel a proposed event.
+var defaultSkipPhrases = []string{
+	"ok", "okay", "okey", "k", "kk", "lol", "lmao", "rofl", "haha", "hehe",
+	"thanks", "thank you", "thanks a lot", "thx", "ty", "tnx", "np", "no problem",
+	"cool", "nice", "great", "awesome", "perfect", "got it", "sounds good", "same",
+	"wow", "omg", "good", "fine",
+	"good morning", "good night", "gm", "gn", "bye", "cya", "see ya", "welcome",
+	"you're welcome", "youre welcome", "love you", "miss you", "congrats", "mazal tov",
+}
+
+// defaultSkipPatterns catch elongated laughter and acknowledgements ("hahahaha", "okkk")
+var defaultSkipPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(ha|he|hi|ho|ja|xa|ah)+h?$`),
+	regexp.MustCompile(`^l+o+l+$`),
+	regexp.MustCompile(`^o+k+a*y*$`),
+	regexp.MustCompile(`^k+$`),
+}
+
+// schedulingCue matches words that suggest a plan, appointment or task. It only ranks
+// untracked channels for suggestions; analysis never depends on it.
+var schedulingCue = regexp.MustCompile(`\b(today|tonight|tomorrow|tmrw|monday|tuesday|wednesday|thursday|friday|saturday|sunday|weekend|next week|morning|afternoon|evening|noon|meet|meeting|appointment|dinner|lunch|breakfast|party|pick ?up|drop ?off|remind|deadline|due|schedule|reschedule|call|pay|bill|rsvp)\b`)
+
+// PrefilterConfig configures the rules that skip obviously non-actionable messages
+// before they reach the LLM
+type PrefilterConfig struct {
+	Enabled bool
+	// MinChars skips messages with fewer letters and digits than this
+	MinChars int
+	// SkipPhrases are added to the built-in list of whole-message phrases to skip
+	SkipPhrases []string
+	// SkipPattern is an optional extra regex; matching messages are skipped
+	SkipPattern string
+	// KeepPattern is an optional regex; matching messages are always analyzed
+	KeepPattern string
+}
+
+// DefaultPrefilterConfig returns the built-in rules
+func DefaultPrefilterConfig() PrefilterConfig {
+	return PrefilterConfig{Enabled: true, MinChars: 2}
+}
+
+// Prefilter decides which messages are worth an LLM call. It errs on the side of
+// analysis: anything containing a digit (times, dates, amounts) is always kept.
+type Prefilter struct {
+	enabled     bool
+	minChars    int
+	skipPhrases map[string]bool
+	skipPattern *regexp.Regexp
+	keepPattern *regexp.Regexp
+}
+
+// NewPrefilter compiles a prefilter from cfg
+func NewPrefilter(cfg PrefilterConfig) (*Prefilter, error) {
+	f := &Prefilter{
+		enabled:     cfg.Enabled,
+		minChars:    cfg.MinChars,
+		skipPhrases: make(map[string]bool),
+	}
+	for _, phrase := range append(append([]string{}, defaultSkipPhrases...), cfg.SkipPhrases...) {
+		if normalized := normalizeForPrefilter(phrase); normalized != "" {
+			f.skipPhrases[normalized] = true
+		}
+	}
+
+	var err error
+	if cfg.SkipPattern != "" {
+		if f.skipPattern, err = regexp.Compile(cfg.SkipPattern); err != nil {
+			return nil, fmt.Errorf("invalid prefilter skip pattern: %w", err)
+		}
+	}
+	if cfg.KeepPattern != "" {
+		if f.keepPattern, err = regexp.Compile(cfg.KeepPattern); err != nil {
+			return nil, fmt.Errorf("invalid prefilter keep pattern: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// Skip reports whether text should not be sent to the LLM, and which rule matched
+func (f *Prefilter) Skip(text string) (bool, string) {
+	if f == nil || !f.enabled {
+		return false, ""
+	}
+
+	trimmed := strings.TrimSpace(text)
+	if f.keepPattern != nil && f.keepPattern.MatchString(trimmed) {
+		return false, ""
+	}
+	if strings.IndexFunc(trimmed, unicode.IsDigit) >= 0 {
+		return false, ""
+	}
+	if f.skipPattern != nil && f.skipPattern.MatchString(trimmed) {
+		return true, "skip_pattern"
+	}
+
+	normalized := normalizeForPrefilter(trimmed)
+	if normalized == "" {
+		return true, "no_text"
+	}
+	if f.skipPhrases[normalized] {
+		return true, "phrase"
+	}
+	for _, pattern := range defaultSkipPatterns {
+		if pattern.MatchString(strings.ReplaceAll(normalized, " ", "")) {
+			return true, "phrase"
+		}
+	}
+	if f.minChars > 0 && countAlphanumeric(normalized) < f.minChars {
+		return true, "too_short"
+	}
+	return false, ""
+}
+
+// LooksActionable reports whether text passes the prefilter and mentions a time, date or
+// scheduling word. It's a cheap stand-in for the LLM when scoring untracked channels.
+func (f *Prefilter) LooksActionable(text string) bool {
+	if skip, _ := f.Skip(text); skip {
+		return false
+	}
+	if strings.IndexFunc(text, unicode.IsDigit) >= 0 {
+		return true
+	}
+	return schedulingCue.MatchString(normalizeForPrefilter(text))
+}
+
+// normalizeForPrefilter lowercases text, drops everything but letters, digits and
+// apostrophes, and collapses whitespace, so "Ok!! 👍" becomes "ok"
+func normalizeForPrefilter(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// countAlphanumeric counts letters and digits. CJK characters are whole words, so
+// each counts as several to keep short messages like "明天" above the length floor.
+func countAlphanumeric(text string) int {
+	count := 0
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			count += 3
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,155 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+func TestPrefilterSkip(t *testing.T) {
+	f, err := NewPrefilter(DefaultPrefilterConfig())
+	require.NoError(t, err)
+
+	tests := []struct {
+		text string
+		skip bool
+		rule string
+	}{
+		{text: "ok", skip: true, rule: "phrase"},
+		{text: "Okkk!!", skip: true, rule: "phrase"},
+		{text: "lol 😂", skip: true, rule: "phrase"},
+		{text: "hahahaha", skip: true, rule: "phrase"},
+		{text: "Thanks!", skip: true, rule: "phrase"},
+		{text: "👍👍", skip: true, rule: "no_text"},
+		{text: "   ", skip: true, rule: "no_text"},
+		{text: "?", skip: true, rule: "no_text"},
+		{text: "x", skip: true, rule: "too_short"},
+		{text: "yes", skip: false},
+		{text: "no", skip: false},
+		{text: "5", skip: false},
+		{text: "ok see you at 8", skip: false},
+		{text: "Dinner tomorrow?", skip: false},
+		{text: "明天", skip: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			skip, rule := f.Skip(tt.text)
+			assert.Equal(t, tt.skip, skip)
+			assert.Equal(t, tt.rule, rule)
+		})
+	}
+}
+
+func TestPrefilterLooksActionable(t *testing.T) {
+	f, err := NewPrefilter(DefaultPrefilterConfig())
+	require.NoError(t, err)
+
+	for text, expected := range map[string]bool{
+		"Dinner tomorrow?":          true,
+		"pickup at 4:30":            true,
+		"Can you call the plumber?": true,
+		"ok":                        false,
+		"hahaha":                    false,
+		"how are you":               false,
+		"Due date":                  true,
+	} {
+		assert.Equal(t, expected, f.LooksActionable(text), text)
+	}
+}
+
+func TestPrefilterConfig(t *testing.T) {
+	f, err := NewPrefilter(PrefilterConfig{
+		Enabled:     true,
+		SkipPhrases: []string{"on my way"},
+		SkipPattern: `(?i)^sent from my`,
+		KeepPattern: `(?i)^ok(ay)?,? (remind|schedule)`,
+	})
+	require.NoError(t, err)
+
+	skip, _ := f.Skip("On my way!")
+	assert.True(t, skip)
+	skip, rule := f.Skip("Sent from my iPhone")
+	assert.True(t, skip)
+	assert.Equal(t, "skip_pattern", rule)
+	skip, _ = f.Skip("ok remind")
+	assert.False(t, skip)
+
+	disabled, err := NewPrefilter(PrefilterConfig{Enabled: false})
+	require.NoError(t, err)
+	skip, _ = disabled.Skip("ok")
+	assert.False(t, skip)
+
+	_, err = NewPrefilter(PrefilterConfig{Enabled: true, SkipPattern: "("})
+	assert.Error(t, err)
+}
+
+type countingEventAnalyzer struct {
+	calls int
+}
+
+func (a *countingEventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	a.calls++
+	return &agent.EventAnalysis{Action: "none", Confidence: 0.9}, nil
+}
+
+func (a *countingEventAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.EventAnalysis, error) {
+	a.calls++
+	return &agent.EventAnalysis{Action: "none", Confidence: 0.9}, nil
+}
+
+func (a *countingEventAnalyzer) IsConfigured() bool { return true }
+
+func TestProcessMessageSkipsPrefilteredMessages(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "contact@s.whatsapp.net", "Contact")
+	require.NoError(t, err)
+
+	analyzer := &countingEventAnalyzer{}
+	p := New(db, analyzer, nil, make(chan source.Message), 25, nil)
+	defer p.Stop()
+
+	send := func(text string) {
+		require.NoError(t, p.processMessage(source.Message{
+			UserID:     user.ID,
+			SourceType: source.SourceTypeWhatsApp,
+			SourceID:   channel.ID,
+			Identifier: "contact@s.whatsapp.net",
+			SenderID:   "contact@s.whatsapp.net",
+			SenderName: "Contact",
+			Text:       text,
+			Timestamp:  time.Now(),
+		}, 0))
+	}
+
+	send("lol")
+	assert.Equal(t, 0, analyzer.calls)
+
+	var prefiltered int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM analysis_traces WHERE user_id = ? AND status = 'prefiltered'`, user.ID).Scan(&prefiltered))
+	assert.Equal(t, 1, prefiltered)
+
+	// The skipped message is still kept as history for later analyses
+	history, err := db.GetSourceMessageHistory(user.ID, source.SourceTypeWhatsApp, channel.ID, 25)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	send("Dinner at Gino's on Friday?")
+	assert.Equal(t, 1, analyzer.calls)
+
+	p.SetPrefilter(nil)
+	send("ok")
+	assert.Equal(t, 2, analyzer.calls)
+}
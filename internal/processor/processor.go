@@ -2,21 +2,25 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/agent/intents"
+	"github.com/omriShneor/project_alfred/internal/agent/langpolicy"
 	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/logging"
 	"github.com/omriShneor/project_alfred/internal/notify"
 	"github.com/omriShneor/project_alfred/internal/source"
 )
 
 const (
-	defaultHistorySize   = 25
-	defaultWorkerCount   = 2
-	minPersistConfidence = 0.30
+	defaultHistorySize = 25
+	defaultWorkerCount = 2
 )
 
 // Processor handles incoming messages from any source and detects calendar events and reminders
@@ -32,6 +36,19 @@ type Processor struct {
 	eventCreator     *EventCreator
 	reminderCreator  *ReminderCreator
 	workerCount      int
+	prefilter        *Prefilter
+	llmAvailable     func() bool // nil = always available
+	related          RelatedMessageFinder
+	shadow           *ShadowRunner
+
+	batchMu          sync.Mutex
+	batchWindow      time.Duration
+	batchMaxWait     time.Duration
+	batchMaxMessages int
+	batches          map[batchKey]*messageBatch
+	batchesStopped   bool
+
+	queued chan struct{} // wakes an idle worker when a message is queued
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -54,6 +71,7 @@ func New(
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	prefilter, _ := NewPrefilter(DefaultPrefilterConfig())
 	registry := intents.NewRegistry()
 	if eventAnalyzer != nil && eventAnalyzer.IsConfigured() {
 		_ = registry.Register(&intents.EventModule{Analyzer: eventAnalyzer})
@@ -74,15 +92,88 @@ func New(
 		eventCreator:     NewEventCreator(db, notifyService),
 		reminderCreator:  NewReminderCreator(db, notifyService),
 		workerCount:      defaultWorkerCount,
+		prefilter:        prefilter,
+		batchMaxWait:     defaultBatchMaxWait,
+		batchMaxMessages: defaultBatchMaxMessages,
+		batches:          make(map[batchKey]*messageBatch),
+		queued:           make(chan struct{}, defaultWorkerCount),
 		ctx:              ctx,
 		cancel:           cancel,
 	}
 }
 
-// Start begins processing messages from the channel
+// SetWorkerCount sets how many messages are analyzed concurrently. Call it before Start.
+func (p *Processor) SetWorkerCount(n int) {
+	if n <= 0 {
+		n = defaultWorkerCount
+	}
+	p.workerCount = n
+	p.queued = make(chan struct{}, n)
+}
+
+// SetPrefilter replaces the rules that skip non-actionable messages; nil disables filtering
+func (p *Processor) SetPrefilter(f *Prefilter) {
+	p.prefilter = f
+}
+
+// SetLLMAvailability tells the processor whether the LLM API is up, e.g. from its
+// circuit breaker. While it is down only messages the prefilter finds actionable are
+// analyzed, and failed analyses wait for it instead of using up their retries.
+func (p *Processor) SetLLMAvailability(available func() bool) {
+	p.llmAvailable = available
+}
+
+// llmDown reports whether the LLM API is known to be unavailable
+func (p *Processor) llmDown() bool {
+	return p.llmAvailable != nil && !p.llmAvailable()
+}
+
+// RelatedMessageFinder retrieves a channel's earlier messages that are semantically
+// related to a new one. Implemented by *embeddings.Retriever.
+type RelatedMessageFinder interface {
+	Related(ctx context.Context, userID, channelID int64, message database.MessageRecord, exclude map[int64]bool) ([]database.MessageRecord, error)
+}
+
+// SetAutoConfirmer confirms detections above the user's auto-confirm threshold without
+// review; without one everything is left pending
+func (p *Processor) SetAutoConfirmer(c AutoConfirmer) {
+	p.eventCreator.autoConfirmer = c
+	p.reminderCreator.autoConfirmer = c
+}
+
+// SetRelatedMessageFinder enables retrieval of related earlier messages for analysis context
+func (p *Processor) SetRelatedMessageFinder(f RelatedMessageFinder) {
+	p.related = f
+}
+
+// SetShadow runs the shadow analyzers alongside the live ones for comparison; nil disables it
+func (p *Processor) SetShadow(shadow *ShadowRunner) {
+	p.shadow = shadow
+}
+
+// RegisterIntentModule adds an analyzer beyond the built-in event and reminder modules.
+// Call it before Start.
+func (p *Processor) RegisterIntentModule(module intents.IntentModule) error {
+	return p.intentRegistry.Register(module)
+}
+
+// Start begins processing messages from the channel. Messages left in the durable
+// queue by the previous run are picked up with the new ones, live messages before
+// backfilled ones, and failed analyses are retried in the background.
 func (p *Processor) Start() error {
-	fmt.Println("Event processor started")
+	slog.Info("Event processor started")
+	if err := p.db.ReleasePendingAnalysisClaims(); err != nil {
+		slog.Warn("Event processor: failed to release analysis claims", "error", err)
+	}
+	if count, err := p.db.CountPendingAnalyses(); err != nil {
+		slog.Warn("failed to count pending analyses", "error", err)
+	} else if count > 0 {
+		slog.Info("Event processor: recovering messages received before restart", "count", count)
+	}
 
+	p.wg.Add(2)
+	go p.intakeLoop()
+	go p.retryLoop()
 	for i := 0; i < p.workerCount; i++ {
 		p.wg.Add(1)
 		go p.processLoop()
@@ -93,35 +184,32 @@ func (p *Processor) Start() error {
 
 // Stop gracefully shuts down the processor
 func (p *Processor) Stop() {
-	fmt.Println("Stopping event processor...")
+	slog.Info("Stopping event processor...")
+	p.stopBatches()
 	p.cancel()
 	p.wg.Wait()
-	fmt.Println("Event processor stopped")
+	if p.shadow != nil {
+		p.shadow.Wait()
+	}
+	slog.Info("Event processor stopped")
 }
 
-// processLoop continuously reads messages from the channel and processes them
-func (p *Processor) processLoop() {
-	defer p.wg.Done()
-
-	for {
-		select {
-		case <-p.ctx.Done():
-			return
-		case msg, ok := <-p.msgChan:
-			if !ok {
-				fmt.Println("Event processor: message channel closed")
-				return
-			}
-			if err := p.processMessage(msg); err != nil {
-				fmt.Printf("Event processor: error processing message: %v\n", err)
-			}
+// processMessage handles a single incoming message from any source. pendingID is the
+// message's durable queue row (0 for none), removed once the message is analyzed.
+func (p *Processor) processMessage(msg source.Message, pendingID int64) error {
+	handedOff := false
+	defer func() {
+		if !handedOff {
+			p.ackPending(pendingID)
 		}
-	}
-}
+	}()
 
-// processMessage handles a single incoming message from any source
-func (p *Processor) processMessage(msg source.Message) error {
-	fmt.Printf("Processing %s message from channel %d: %s\n", msg.SourceType, msg.SourceID, truncate(msg.Text, 50))
+	ctx := logging.With(p.ctx,
+		slog.Int64("user_id", msg.UserID),
+		slog.Int64("channel_id", msg.SourceID),
+		slog.String("source_type", string(msg.SourceType)),
+	)
+	slog.DebugContext(ctx, "Processing message", "text", truncate(msg.Text, 50))
 
 	// Get the channel to find its calendar_id
 	channel, err := p.db.GetSourceChannelByID(msg.UserID, msg.SourceID)
@@ -143,51 +231,168 @@ func (p *Processor) processMessage(msg source.Message) error {
 		return fmt.Errorf("failed to store message: %w", err)
 	}
 
+	ctx = logging.With(ctx, slog.Int64("message_id", storedMsg.ID))
+
 	// Prune old messages to keep only the last N
 	if err := p.db.PruneSourceMessages(msg.UserID, msg.SourceType, msg.SourceID, p.historySize); err != nil {
-		fmt.Printf("Warning: failed to prune messages: %v\n", err)
+		slog.WarnContext(ctx, "failed to prune messages", "error", err)
+	}
+
+	// The user turned analysis off for this channel; the message still counts as history
+	if channelMode(channel) == database.AnalysisModeNone {
+		return nil
+	}
+
+	// Skip the LLM for obvious chatter; the message still counts as history context
+	if skip, rule := p.prefilter.Skip(msg.Text); skip {
+		slog.InfoContext(ctx, "Prefilter: skipping message", "rule", rule)
+		msgID := storedMsg.ID
+		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
+			UserID:           channel.UserID,
+			ChannelID:        channel.ID,
+			SourceType:       string(msg.SourceType),
+			TriggerMessageID: &msgID,
+			Intent:           "none",
+			Status:           "prefiltered",
+			Reasoning:        rule,
+		})
+		return nil
+	}
+
+	// While the LLM API is down, degrade to the prefilter's stricter actionable check. What
+	// passes fails fast and is queued as a failed analysis, retried once the API is back.
+	if p.llmDown() && !p.prefilter.LooksActionable(msg.Text) {
+		slog.InfoContext(ctx, "LLM unavailable: skipping non-actionable message")
+		msgID := storedMsg.ID
+		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
+			UserID:           channel.UserID,
+			ChannelID:        channel.ID,
+			SourceType:       string(msg.SourceType),
+			TriggerMessageID: &msgID,
+			Intent:           "none",
+			Status:           "prefiltered",
+			Reasoning:        "llm_unavailable",
+		})
+		return nil
+	}
+
+	// Wait for the rest of a rapid-fire burst before analyzing
+	if p.enqueueMessage(channel, msg.SourceType, storedMsg, pendingID) {
+		handedOff = true
+		return nil
 	}
+	return p.analyzeMessages(channel, msg.SourceType, []*database.SourceMessage{storedMsg})
+}
+
+// analyzeMessages routes and analyzes one message, or a burst of consecutive messages
+// from the same channel, with the channel's history as context
+func (p *Processor) analyzeMessages(
+	channel *database.SourceChannel,
+	sourceType source.SourceType,
+	messages []*database.SourceMessage,
+) error {
+	ctx := p.logContext(channel, sourceType, messages[len(messages)-1].ID)
 
 	// Get message history for context (shared between analyzers)
-	history, err := p.db.GetSourceMessageHistory(msg.UserID, msg.SourceType, msg.SourceID, p.historySize)
+	history, err := p.db.GetSourceMessageHistory(channel.UserID, sourceType, channel.ID, p.historySize)
 	if err != nil {
 		return fmt.Errorf("failed to get message history: %w", err)
 	}
 
 	// Get existing active events (pending + synced) for this channel
-	existingEvents, err := p.db.GetActiveEventsForChannel(msg.UserID, msg.SourceID)
+	existingEvents, err := p.db.GetActiveEventsForChannel(channel.UserID, channel.ID)
 	if err != nil {
-		fmt.Printf("Warning: failed to get existing events: %v\n", err)
+		slog.WarnContext(ctx, "failed to get existing events", "error", err)
 		existingEvents = []database.CalendarEvent{}
 	}
 
 	// Get existing active reminders for this channel
-	existingReminders, err := p.db.GetActiveRemindersForChannel(msg.SourceID)
+	existingReminders, err := p.db.GetActiveRemindersForChannel(channel.ID)
 	if err != nil {
-		fmt.Printf("Warning: failed to get existing reminders: %v\n", err)
+		slog.WarnContext(ctx, "failed to get existing reminders", "error", err)
 		existingReminders = []database.Reminder{}
 	}
 
+	// The burst becomes the new message, so leave its messages out of the history
+	inBurst := make(map[int64]bool, len(messages))
+	for _, m := range messages {
+		inBurst[m.ID] = true
+	}
+	priorHistory := make([]database.SourceMessage, 0, len(history))
+	for _, m := range history {
+		if !inBurst[m.ID] {
+			priorHistory = append(priorHistory, m)
+		}
+	}
+
 	// Convert to database types for analysis (shared context)
-	historyRecords := convertToMessageRecords(history)
-	newMessageRecord := convertSourceMessageToRecord(storedMsg)
+	historyRecords := convertToMessageRecords(priorHistory)
+	newMessageRecord := mergeBurst(messages)
+	related := p.findRelatedMessages(channel, newMessageRecord, historyRecords, inBurst)
+	channelLanguage := p.updateChannelLanguage(channel, newMessageRecord)
 	if err := p.routeAnalyzeAndPersistMessage(
 		channel,
-		msg.SourceType,
-		storedMsg.ID,
+		sourceType,
+		newMessageRecord.ID,
 		intents.MessageInput{
 			History:           historyRecords,
 			NewMessage:        newMessageRecord,
 			ExistingEvents:    existingEvents,
 			ExistingReminders: existingReminders,
+			Related:           related,
+			ChannelLanguage:   channelLanguage,
 		},
 	); err != nil {
-		fmt.Printf("Intent orchestration error: %v\n", err)
+		slog.ErrorContext(ctx, "Intent orchestration error", "error", err)
 	}
 
 	return nil
 }
 
+// findRelatedMessages looks up earlier messages related to the new message that are
+// not already in the history or the burst. Failures only cost the extra context.
+func (p *Processor) findRelatedMessages(
+	channel *database.SourceChannel,
+	newMessage database.MessageRecord,
+	history []database.MessageRecord,
+	inBurst map[int64]bool,
+) []database.MessageRecord {
+	if p.related == nil {
+		return nil
+	}
+	exclude := make(map[int64]bool, len(history)+len(inBurst))
+	for id := range inBurst {
+		exclude[id] = true
+	}
+	for _, m := range history {
+		exclude[m.ID] = true
+	}
+	related, err := p.related.Related(p.ctx, channel.UserID, channel.ID, newMessage, exclude)
+	if err != nil {
+		slog.Warn("failed to retrieve related messages", "error", err)
+		return nil
+	}
+	return related
+}
+
+// updateChannelLanguage records the language of the new message when it can be detected
+// and returns the channel's language, so short follow-ups ("ok 8pm?") in a Hebrew chat
+// still get Hebrew titles
+func (p *Processor) updateChannelLanguage(channel *database.SourceChannel, newMessage database.MessageRecord) string {
+	stored, err := p.db.GetChannelLanguage(channel.ID)
+	if err != nil {
+		slog.Warn("failed to get channel language", "error", err)
+	}
+	detected := langpolicy.DetectTargetLanguage(newMessage.MessageText)
+	if !detected.Reliable || detected.Code == "" || detected.Code == stored {
+		return stored
+	}
+	if err := p.db.SetChannelLanguage(channel.ID, detected.Code); err != nil {
+		slog.Warn("failed to update channel language", "error", err)
+	}
+	return detected.Code
+}
+
 // convertToMessageRecords converts SourceMessage slice to MessageRecord slice for Claude
 func convertToMessageRecords(messages []database.SourceMessage) []database.MessageRecord {
 	records := make([]database.MessageRecord, len(messages))
@@ -230,11 +435,18 @@ type messageIntentPersister struct {
 }
 
 func (mp *messageIntentPersister) PersistEvent(ctx context.Context, analysis *agent.EventAnalysis) error {
-	return mp.p.createPendingEvent(mp.channel, mp.messageID, analysis, mp.source)
+	// Modules that create both kinds (occasion) still run in events/reminders mode
+	if !channelMode(mp.channel).AllowsEvents() {
+		return nil
+	}
+	return mp.p.createPendingEvent(ctx, mp.channel, mp.messageID, analysis, mp.source)
 }
 
 func (mp *messageIntentPersister) PersistReminder(ctx context.Context, analysis *agent.ReminderAnalysis) error {
-	return mp.p.createPendingReminder(mp.channel, mp.messageID, analysis, mp.source)
+	if !channelMode(mp.channel).AllowsReminders() {
+		return nil
+	}
+	return mp.p.createPendingReminder(ctx, mp.channel, mp.messageID, analysis, mp.source)
 }
 
 func (p *Processor) routeAnalyzeAndPersistMessage(
@@ -247,8 +459,9 @@ func (p *Processor) routeAnalyzeAndPersistMessage(
 		return nil
 	}
 
-	route := p.intentRouter.RouteMessages(p.ctx, input)
-	fmt.Printf("Intent route: intent=%s confidence=%.2f reason=%s\n", route.Intent, route.Confidence, truncate(route.Reasoning, 80))
+	ctx := p.logContext(channel, sourceType, messageID)
+	route := p.intentRouter.RouteMessages(ctx, input)
+	slog.InfoContext(ctx, "Intent route", "intent", route.Intent, "confidence", route.Confidence, "reason", truncate(route.Reasoning, 80))
 	msgID := messageID
 	_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 		UserID:           channel.UserID,
@@ -264,6 +477,7 @@ func (p *Processor) routeAnalyzeAndPersistMessage(
 	})
 
 	intentOrder, unknownRoutedIntent := resolveIntentExecutionOrder(p.intentRegistry, route)
+	intentOrder = filterIntentsForMode(intentOrder, channelMode(channel))
 	if len(intentOrder) == 0 {
 		return nil
 	}
@@ -271,7 +485,10 @@ func (p *Processor) routeAnalyzeAndPersistMessage(
 	var firstErr error
 	for _, intentName := range intentOrder {
 		if err := p.runMessageIntentModule(intentName, channel, sourceType, messageID, input); err != nil {
-			fmt.Printf("Intent module %s error: %v\n", intentName, err)
+			slog.ErrorContext(ctx, "Intent module error", "intent_name", intentName, "error", err)
+			if isAnalysisError(err) {
+				p.recordFailedAnalysis(intentName, channel, sourceType, messageID, input, err)
+			}
 			if firstErr == nil {
 				firstErr = err
 			}
@@ -290,10 +507,11 @@ func (p *Processor) runMessageIntentModule(
 	messageID int64,
 	input intents.MessageInput,
 ) error {
+	ctx := p.logContext(channel, sourceType, messageID)
 	module, ok := p.intentRegistry.Get(intentName)
 	if !ok {
 		count := p.unknownIntentCount.Add(1)
-		fmt.Printf("Unknown intent '%s' -> no_action (count=%d)\n", intentName, count)
+		slog.InfoContext(ctx, "Unknown intent -> no_action", "intent_name", intentName, "count", count)
 		msgID := messageID
 		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 			UserID:           channel.UserID,
@@ -307,16 +525,47 @@ func (p *Processor) runMessageIntentModule(
 		return nil
 	}
 
-	output, err := module.AnalyzeMessages(p.ctx, input)
+	analysisCtx := agent.WithMessageTime(AnalysisContext(ctx, p.db, channel.UserID), input.NewMessage.Timestamp)
+	analysisCtx = agent.WithRelatedMessages(analysisCtx, input.Related)
+	analysisCtx = agent.WithChannelLanguage(analysisCtx, input.ChannelLanguage)
+	recorder := &analysisRecorder{}
+	output, err := module.AnalyzeMessages(recorder.context(analysisCtx), input)
+	recorder.save(p.db, database.AnalysisLogEntry{
+		UserID:    channel.UserID,
+		MessageID: messageID,
+		ChannelID: channel.ID,
+		Intent:    intentName,
+	}, output)
+	if errors.Is(err, agent.ErrBudgetExceeded) {
+		msgID := messageID
+		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
+			UserID:           channel.UserID,
+			ChannelID:        channel.ID,
+			SourceType:       string(sourceType),
+			TriggerMessageID: &msgID,
+			Intent:           intentName,
+			Status:           "budget_exceeded",
+		})
+		return nil
+	}
+	msgID := messageID
+	p.shadow.compare(analysisCtx, intentName, database.ShadowAnalysis{
+		UserID:           channel.UserID,
+		ChannelID:        channel.ID,
+		SourceType:       string(sourceType),
+		TriggerMessageID: &msgID,
+	}, output, func(ctx context.Context, shadow intents.IntentModule) (*intents.ModuleOutput, error) {
+		return shadow.AnalyzeMessages(ctx, input)
+	})
 	if err != nil {
-		return err
+		return &analysisError{err: err}
 	}
 	if output == nil {
 		return nil
 	}
 
-	if err := module.Validate(p.ctx, output); err != nil {
-		fmt.Printf("Intent validation failed intent=%s: %v\n", intentName, err)
+	if err := module.Validate(ctx, output); err != nil {
+		slog.ErrorContext(ctx, "Intent validation failed", "intent", intentName, "error", err)
 		msgID := messageID
 		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 			UserID:           channel.UserID,
@@ -334,8 +583,8 @@ func (p *Processor) runMessageIntentModule(
 		})
 		return nil
 	}
-	if output.Confidence < minPersistConfidence {
-		fmt.Printf("Skipping low-confidence intent=%s confidence=%.2f\n", intentName, output.Confidence)
+	if confidenceThresholds(p.db, channel.UserID).ShouldDiscard(output.Confidence) {
+		slog.InfoContext(ctx, "Skipping low-confidence", "intent", intentName, "confidence", output.Confidence)
 		msgID := messageID
 		_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 			UserID:           channel.UserID,
@@ -357,12 +606,11 @@ func (p *Processor) runMessageIntentModule(
 		source:    sourceType,
 		messageID: messageID,
 	}
-	err = module.Persist(p.ctx, output, persister)
+	err = module.Persist(analysisCtx, output, persister)
 	status := "persisted"
 	if err != nil {
 		status = "persist_error"
 	}
-	msgID := messageID
 	_ = p.db.CreateAnalysisTrace(database.AnalysisTrace{
 		UserID:           channel.UserID,
 		ChannelID:        channel.ID,
@@ -379,6 +627,7 @@ func (p *Processor) runMessageIntentModule(
 
 // createPendingEvent creates or updates a pending event from the analysis
 func (p *Processor) createPendingEvent(
+	ctx context.Context,
 	channel *database.SourceChannel,
 	messageID int64,
 	analysis *agent.EventAnalysis,
@@ -400,12 +649,13 @@ func (p *Processor) createPendingEvent(
 		}
 	}
 
-	_, err := p.eventCreator.CreateEventFromAnalysis(p.ctx, params)
+	_, err := p.eventCreator.CreateEventFromAnalysis(ctx, params)
 	return err
 }
 
 // createPendingReminder creates or updates a pending reminder from the analysis
 func (p *Processor) createPendingReminder(
+	ctx context.Context,
 	channel *database.SourceChannel,
 	messageID int64,
 	analysis *agent.ReminderAnalysis,
@@ -419,10 +669,21 @@ func (p *Processor) createPendingReminder(
 		Analysis:   analysis,
 	}
 
-	_, err := p.reminderCreator.CreateReminderFromAnalysis(p.ctx, params)
+	_, err := p.reminderCreator.CreateReminderFromAnalysis(ctx, params)
 	return err
 }
 
+// logContext returns the processor's context carrying the user, channel and message IDs
+// that correlate log records for one analysis
+func (p *Processor) logContext(channel *database.SourceChannel, sourceType source.SourceType, messageID int64) context.Context {
+	return logging.With(p.ctx,
+		slog.Int64("user_id", channel.UserID),
+		slog.Int64("channel_id", channel.ID),
+		slog.String("source_type", string(sourceType)),
+		slog.Int64("message_id", messageID),
+	)
+}
+
 // truncate shortens a string to maxLen characters
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
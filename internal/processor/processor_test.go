@@ -7,6 +7,7 @@ import (
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/source"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNew(t *testing.T) {
@@ -164,3 +165,21 @@ func TestConvertToMessageRecords_Empty(t *testing.T) {
 	records = convertToMessageRecords([]database.SourceMessage{})
 	assert.Len(t, records, 0)
 }
+
+func TestUpdateChannelLanguage(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "dana@s.whatsapp.net", "Dana")
+	require.NoError(t, err)
+	p := New(db, nil, nil, make(chan source.Message), 25, nil)
+
+	assert.Equal(t, "", p.updateChannelLanguage(channel, database.MessageRecord{MessageText: "ok"}))
+	assert.Equal(t, "he", p.updateChannelLanguage(channel, database.MessageRecord{MessageText: "נפגש מחר בשעה חמש"}))
+
+	// Too short to detect: the channel keeps its language
+	assert.Equal(t, "he", p.updateChannelLanguage(channel, database.MessageRecord{MessageText: "8pm?"}))
+
+	stored, err := db.GetChannelLanguage(channel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "he", stored)
+}
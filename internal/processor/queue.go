@@ -0,0 +1,143 @@
+package processor
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+const (
+	// maxPendingAttempts gives up on a queued message that was claimed this many times
+	// without finishing, e.g. because analyzing it crashes the server
+	maxPendingAttempts = 3
+	// pendingPollInterval rechecks the queue in case a wakeup was missed or a read failed
+	pendingPollInterval = 5 * time.Second
+	// liveMessageMaxAge is how old a message can be when it arrives and still count as
+	// live; older ones are catch-up after a reconnect and go to the backfill lane
+	liveMessageMaxAge = 5 * time.Minute
+)
+
+// intakeLoop moves received messages from the in-memory channel into the durable
+// pending_analysis queue as soon as they arrive, so a crash or restart only loses
+// what the channel buffered in the last moments
+func (p *Processor) intakeLoop() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			p.drainIntake()
+			return
+		case msg, ok := <-p.msgChan:
+			if !ok {
+				slog.Info("Event processor: message channel closed")
+				return
+			}
+			p.persistIncoming(msg)
+		}
+	}
+}
+
+// drainIntake persists messages still buffered in the channel at shutdown
+func (p *Processor) drainIntake() {
+	drained := 0
+	for {
+		select {
+		case msg, ok := <-p.msgChan:
+			if !ok {
+				return
+			}
+			if _, err := p.db.EnqueuePendingAnalysis(classifyLane(msg, time.Now())); err != nil {
+				slog.Error("Event processor: failed to persist message at shutdown", "error", err)
+				continue
+			}
+			drained++
+		default:
+			if drained > 0 {
+				slog.Info("Event processor: persisted buffered messages for next start", "drained", drained)
+			}
+			return
+		}
+	}
+}
+
+// persistIncoming queues msg in its lane and wakes a worker. If the queue can't be
+// written the message is processed right away rather than dropped.
+func (p *Processor) persistIncoming(msg source.Message) {
+	msg = classifyLane(msg, time.Now())
+	if _, err := p.db.EnqueuePendingAnalysis(msg); err != nil {
+		slog.Error("Event processor: failed to persist message, processing without recovery", "error", err)
+		if err := p.processMessage(msg, 0); err != nil {
+			slog.Error("Event processor: error processing message", "user_id", msg.UserID, "channel_id", msg.SourceID, "error", err)
+		}
+		return
+	}
+
+	select {
+	case p.queued <- struct{}{}:
+	default:
+	}
+}
+
+// classifyLane moves messages that were already old when they arrived (delivered
+// after a reconnect or replayed from history) to the backfill lane
+func classifyLane(msg source.Message, now time.Time) source.Message {
+	if !msg.Timestamp.IsZero() && now.Sub(msg.Timestamp) > liveMessageMaxAge {
+		msg.Backfill = true
+	}
+	return msg
+}
+
+// processLoop claims queued messages, live ones first, and processes them until the
+// processor stops
+func (p *Processor) processLoop() {
+	defer p.wg.Done()
+
+	for {
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		pending, err := p.db.ClaimNextPendingAnalysis()
+		if err != nil {
+			slog.Error("Event processor: failed to claim queued message", "error", err)
+		}
+		if pending == nil {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-p.queued:
+			case <-time.After(pendingPollInterval):
+			}
+			continue
+		}
+
+		if pending.Attempts > maxPendingAttempts {
+			slog.Warn("Event processor: giving up on message", "pending_id", pending.ID, "user_id", pending.Message.UserID, "channel_id", pending.Message.SourceID, "attempts", pending.Attempts-1)
+			p.ackPending(pending.ID)
+			continue
+		}
+		if err := p.processMessage(pending.Message, pending.ID); err != nil {
+			slog.Error("Event processor: error processing message", "user_id", pending.Message.UserID, "channel_id", pending.Message.SourceID, "error", err)
+		}
+	}
+}
+
+// ackPending removes analyzed messages from the durable queue. Messages finishing
+// during shutdown are kept, since their analysis may have been cut short by the
+// canceled context; they are analyzed again on the next start.
+func (p *Processor) ackPending(ids ...int64) {
+	if p.ctx.Err() != nil {
+		return
+	}
+	acked := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 {
+			acked = append(acked, id)
+		}
+	}
+	if err := p.db.DeletePendingAnalyses(acked...); err != nil {
+		slog.Warn("Event processor: failed to delete acknowledged analyses", "error", err)
+	}
+}
@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/testutil/fixtures"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorQueue(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel := fixtures.NewChannelBuilder().
+		WithUserID(user.ID).
+		WithIdentifier("contact@s.whatsapp.net").
+		WithName("Contact").
+		MustBuild(db)
+
+	msg := func(text string) source.Message {
+		return fixtures.NewMessageBuilder(channel.ID).
+			WithUserID(user.ID).
+			WithSenderID("contact@s.whatsapp.net").
+			WithSenderName("Contact").
+			WithText(text).
+			WithTimestamp(time.Now()).
+			SourceMessage()
+	}
+	pendingCount := func() int {
+		count, err := db.CountPendingAnalyses()
+		require.NoError(t, err)
+		return count
+	}
+
+	// Left over by a previous run: one message that kept failing, one never picked up
+	poisonID, err := db.EnqueuePendingAnalysis(msg("Dinner at 8 tomorrow?"))
+	require.NoError(t, err)
+	for i := 0; i < maxPendingAttempts; i++ {
+		claimed, err := db.ClaimNextPendingAnalysis()
+		require.NoError(t, err)
+		require.NotNil(t, claimed)
+		require.Equal(t, poisonID, claimed.ID)
+		require.NoError(t, db.ReleasePendingAnalysisClaims())
+	}
+	_, err = db.EnqueuePendingAnalysis(msg("Meeting on Sunday?"))
+	require.NoError(t, err)
+
+	msgChan := make(chan source.Message, 10)
+	analyzer := &recordingEventAnalyzer{}
+	p := New(db, analyzer, nil, msgChan, 25, nil)
+	require.NoError(t, p.Start())
+	defer p.Stop()
+
+	t.Run("recovers messages from the previous run", func(t *testing.T) {
+		require.Eventually(t, func() bool { return len(analyzer.calls()) == 1 }, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, "Meeting on Sunday?", analyzer.calls()[0].MessageText)
+		require.Eventually(t, func() bool { return pendingCount() == 0 }, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("analyzes and removes new messages", func(t *testing.T) {
+		msgChan <- msg("Lunch on Monday at noon?")
+		require.Eventually(t, func() bool { return len(analyzer.calls()) == 2 }, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, "Lunch on Monday at noon?", analyzer.calls()[1].MessageText)
+		require.Eventually(t, func() bool { return pendingCount() == 0 }, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestProcessorQueueKeepsBurstsAcrossRestart(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel := fixtures.NewChannelBuilder().
+		WithUserID(user.ID).
+		WithIdentifier("contact@s.whatsapp.net").
+		WithName("Contact").
+		MustBuild(db)
+
+	msgChan := make(chan source.Message, 10)
+	p := New(db, &recordingEventAnalyzer{}, nil, msgChan, 25, nil)
+	p.SetBatchWindow(time.Minute, time.Minute, 10)
+	require.NoError(t, p.Start())
+
+	msgChan <- fixtures.NewMessageBuilder(channel.ID).
+		WithUserID(user.ID).
+		WithSenderID("contact@s.whatsapp.net").
+		WithSenderName("Contact").
+		WithText("Meeting on Sunday?").
+		WithTimestamp(time.Now()).
+		SourceMessage()
+	require.Eventually(t, func() bool {
+		p.batchMu.Lock()
+		defer p.batchMu.Unlock()
+		return len(p.batches) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	p.Stop()
+
+	require.NoError(t, db.ReleasePendingAnalysisClaims())
+	pending, err := db.ClaimNextPendingAnalysis()
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, "Meeting on Sunday?", pending.Message.Text)
+	require.NoError(t, db.ReleasePendingAnalysisClaims())
+
+	// The next run analyzes the burst without storing the message twice
+	analyzer := &recordingEventAnalyzer{}
+	restarted := New(db, analyzer, nil, make(chan source.Message), 25, nil)
+	require.NoError(t, restarted.Start())
+	defer restarted.Stop()
+
+	require.Eventually(t, func() bool { return len(analyzer.calls()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	count, err := db.CountSourceMessages(user.ID, source.SourceTypeWhatsApp, channel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestClassifyLane(t *testing.T) {
+	now := time.Now()
+
+	live := classifyLane(source.Message{Timestamp: now.Add(-time.Minute)}, now)
+	assert.False(t, live.Backfill)
+
+	late := classifyLane(source.Message{Timestamp: now.Add(-time.Hour)}, now)
+	assert.True(t, late.Backfill, "messages delivered long after they were sent are catch-up")
+
+	flagged := classifyLane(source.Message{Timestamp: now, Backfill: true}, now)
+	assert.True(t, flagged.Backfill)
+}
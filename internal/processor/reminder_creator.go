@@ -3,6 +3,7 @@ package processor
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -35,6 +36,7 @@ type ReminderCreationParams struct {
 type ReminderCreator struct {
 	db            *database.DB
 	notifyService *notify.Service
+	autoConfirmer AutoConfirmer
 }
 
 // NewReminderCreator creates a new ReminderCreator
@@ -67,7 +69,7 @@ func (rc *ReminderCreator) CreateReminderFromAnalysis(ctx context.Context, param
 }
 
 // createReminder creates a new pending reminder
-func (rc *ReminderCreator) createReminder(_ context.Context, params ReminderCreationParams) (*database.Reminder, error) {
+func (rc *ReminderCreator) createReminder(ctx context.Context, params ReminderCreationParams) (*database.Reminder, error) {
 	if params.Analysis.Reminder == nil {
 		return nil, fmt.Errorf("analysis has no reminder data")
 	}
@@ -125,6 +127,9 @@ func (rc *ReminderCreator) createReminder(_ context.Context, params ReminderCrea
 		LLMConfidence: params.Analysis.Confidence,
 		QualityFlags:  buildQualityFlags(params.Analysis.Confidence, timezoneFallback),
 		Source:        string(params.SourceType),
+		Payee:         strings.TrimSpace(reminderData.Payee),
+		Amount:        reminderData.Amount,
+		Currency:      strings.ToUpper(strings.TrimSpace(reminderData.Currency)),
 	}
 
 	created, err := rc.db.CreatePendingReminder(reminder)
@@ -142,8 +147,11 @@ func (rc *ReminderCreator) createReminder(_ context.Context, params ReminderCrea
 	if created.DueDate != nil {
 		dueLabel = created.DueDate.Format("2006-01-02 15:04")
 	}
-	fmt.Printf("Created pending reminder: %s (ID: %d, Due: %s, Priority: %s, Source: %s)\n",
-		created.Title, created.ID, dueLabel, created.Priority, params.SourceType)
+	slog.InfoContext(ctx, "Created pending reminder", "title", created.Title, "reminder_id", created.ID, "due_label", dueLabel, "priority", created.Priority, "source_type", params.SourceType)
+
+	if confirmed := rc.autoConfirm(ctx, created, params.Analysis.Confidence); confirmed != nil {
+		return confirmed, nil
+	}
 
 	// Send notification (non-blocking, don't fail reminder creation)
 	if rc.notifyService != nil {
@@ -154,7 +162,7 @@ func (rc *ReminderCreator) createReminder(_ context.Context, params ReminderCrea
 }
 
 // updateReminder updates an existing pending reminder
-func (rc *ReminderCreator) updateReminder(_ context.Context, params ReminderCreationParams) (*database.Reminder, error) {
+func (rc *ReminderCreator) updateReminder(ctx context.Context, params ReminderCreationParams) (*database.Reminder, error) {
 	if params.Analysis.Reminder == nil {
 		return nil, fmt.Errorf("analysis has no reminder data for update")
 	}
@@ -171,13 +179,17 @@ func (rc *ReminderCreator) updateReminder(_ context.Context, params ReminderCrea
 		return nil, fmt.Errorf("update action requires alfred_reminder_id")
 	}
 
-	existing, err := rc.db.GetReminderByID(reminderData.AlfredReminderRef)
+	existing, err := rc.findExistingReminder(params.UserID, reminderData.AlfredReminderRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find existing reminder %d: %w", reminderData.AlfredReminderRef, err)
+		return nil, err
 	}
 
-	// Only update pending reminders
-	if existing.Status != database.ReminderStatusPending {
+	// Confirmed reminders are changed through a new pending reminder the user reviews
+	switch existing.Status {
+	case database.ReminderStatusPending:
+	case database.ReminderStatusConfirmed, database.ReminderStatusSynced:
+		return rc.createReminderChange(ctx, params, existing, database.ReminderActionUpdate)
+	default:
 		return nil, fmt.Errorf("cannot update reminder with status %s", existing.Status)
 	}
 
@@ -239,7 +251,7 @@ func (rc *ReminderCreator) updateReminder(_ context.Context, params ReminderCrea
 		WHERE id = ?
 	`, params.Analysis.Reasoning, params.Analysis.Confidence, qualityFlagsJSON(buildQualityFlags(params.Analysis.Confidence, timezoneFallback)), existing.ID)
 
-	fmt.Printf("Updated pending reminder: %s (ID: %d)\n", title, existing.ID)
+	slog.InfoContext(ctx, "Updated pending reminder", "title", title, "reminder_id", existing.ID)
 
 	// Return the updated reminder
 	updated, _ := rc.db.GetReminderByID(existing.ID)
@@ -250,7 +262,7 @@ func (rc *ReminderCreator) updateReminder(_ context.Context, params ReminderCrea
 }
 
 // deleteReminder cancels/rejects an existing pending reminder
-func (rc *ReminderCreator) deleteReminder(_ context.Context, params ReminderCreationParams) (*database.Reminder, error) {
+func (rc *ReminderCreator) deleteReminder(ctx context.Context, params ReminderCreationParams) (*database.Reminder, error) {
 	if params.Analysis.Reminder == nil {
 		return nil, fmt.Errorf("analysis has no reminder data for delete")
 	}
@@ -262,13 +274,17 @@ func (rc *ReminderCreator) deleteReminder(_ context.Context, params ReminderCrea
 		return nil, fmt.Errorf("delete action requires alfred_reminder_id")
 	}
 
-	existing, err := rc.db.GetReminderByID(reminderData.AlfredReminderRef)
+	existing, err := rc.findExistingReminder(params.UserID, reminderData.AlfredReminderRef)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find existing reminder %d: %w", reminderData.AlfredReminderRef, err)
+		return nil, err
 	}
 
-	// Only delete/reject pending reminders
-	if existing.Status != database.ReminderStatusPending {
+	// Pending reminders are rejected outright; confirmed ones need the user's review
+	switch existing.Status {
+	case database.ReminderStatusPending:
+	case database.ReminderStatusConfirmed, database.ReminderStatusSynced:
+		return rc.createReminderChange(ctx, params, existing, database.ReminderActionDelete)
+	default:
 		return nil, fmt.Errorf("cannot delete reminder with status %s", existing.Status)
 	}
 
@@ -277,12 +293,132 @@ func (rc *ReminderCreator) deleteReminder(_ context.Context, params ReminderCrea
 		return nil, fmt.Errorf("failed to reject pending reminder: %w", err)
 	}
 
-	fmt.Printf("Rejected pending reminder: %s (ID: %d) - user cancelled\n",
-		existing.Title, existing.ID)
+	slog.InfoContext(ctx, "Rejected pending reminder, user cancelled", "title", existing.Title, "reminder_id", existing.ID)
+
+	return existing, nil
+}
 
+// findExistingReminder loads a reminder the agent referenced, making sure it belongs to the user
+func (rc *ReminderCreator) findExistingReminder(userID, reminderID int64) (*database.Reminder, error) {
+	existing, err := rc.db.GetReminderByID(reminderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find existing reminder %d: %w", reminderID, err)
+	}
+	if existing.UserID != userID {
+		return nil, fmt.Errorf("failed to find existing reminder %d: reminder belongs to another user", reminderID)
+	}
 	return existing, nil
 }
 
+// createReminderChange creates a pending update or delete for a confirmed reminder,
+// like an event change for a synced event. It keeps the original's Google event so
+// confirming the change updates or removes it there, and the original is retired
+// once the change is confirmed.
+func (rc *ReminderCreator) createReminderChange(
+	ctx context.Context,
+	params ReminderCreationParams,
+	existing *database.Reminder,
+	actionType database.ReminderActionType,
+) (*database.Reminder, error) {
+	userTimezone, _ := rc.db.GetUserTimezone(params.UserID)
+	if userTimezone == "" {
+		userTimezone = "UTC"
+	}
+	timezoneFallback := false
+
+	change := &database.Reminder{
+		UserID:        params.UserID,
+		ChannelID:     existing.ChannelID,
+		GoogleEventID: existing.GoogleEventID,
+		CalendarID:    existing.CalendarID,
+		Title:         existing.Title,
+		Description:   existing.Description,
+		Location:      existing.Location,
+		DueDate:       existing.DueDate,
+		ReminderTime:  existing.ReminderTime,
+		Priority:      existing.Priority,
+		ActionType:    actionType,
+		OriginalMsgID: params.MessageID,
+		LLMReasoning:  params.Analysis.Reasoning,
+		LLMConfidence: params.Analysis.Confidence,
+		Source:        string(params.SourceType),
+		ReplacesID:    &existing.ID,
+		Payee:         existing.Payee,
+		Amount:        existing.Amount,
+		Currency:      existing.Currency,
+	}
+
+	if actionType == database.ReminderActionUpdate {
+		data := params.Analysis.Reminder
+		if title := strings.TrimSpace(data.Title); title != "" {
+			change.Title = title
+		}
+		if description := strings.TrimSpace(data.Description); description != "" {
+			change.Description = description
+		}
+		if data.DueDate != "" {
+			parsed, fallback, err := parseReminderTime(data.DueDate, userTimezone)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse due date: %w", err)
+			}
+			change.DueDate = &parsed
+			timezoneFallback = timezoneFallback || fallback
+		}
+		if data.ReminderTime != "" {
+			if rt, fallback, err := parseReminderTime(data.ReminderTime, userTimezone); err == nil {
+				change.ReminderTime = &rt
+				timezoneFallback = timezoneFallback || fallback
+			}
+		}
+		if data.Priority != "" {
+			change.Priority = mapReminderPriority(data.Priority)
+		}
+	}
+	change.QualityFlags = buildQualityFlags(params.Analysis.Confidence, timezoneFallback)
+
+	created, err := rc.db.CreatePendingReminder(change)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save reminder change: %w", err)
+	}
+	if params.EmailSourceID != nil {
+		_, _ = rc.db.Exec(`UPDATE reminders SET email_source_id = ? WHERE id = ?`,
+			*params.EmailSourceID, created.ID)
+	}
+
+	slog.InfoContext(ctx, "Created pending reminder", "action_type", actionType, "title", created.Title, "reminder_id", created.ID, "replaces_id", existing.ID, "source_type", params.SourceType)
+
+	if confirmed := rc.autoConfirm(ctx, created, params.Analysis.Confidence); confirmed != nil {
+		return confirmed, nil
+	}
+
+	if rc.notifyService != nil {
+		go rc.notifyService.NotifyPendingReminder(context.Background(), created)
+	}
+
+	return created, nil
+}
+
+// autoConfirm confirms a new pending reminder when its confidence reaches the user's
+// auto-confirm threshold and the auto_confirm feature flag is on. It returns the
+// confirmed reminder, or nil to leave it pending.
+func (rc *ReminderCreator) autoConfirm(ctx context.Context, reminder *database.Reminder, confidence float64) *database.Reminder {
+	if rc.autoConfirmer == nil || !confidenceThresholds(rc.db, reminder.UserID).ShouldAutoConfirm(confidence) ||
+		!autoConfirmEnabled(ctx, rc.db, reminder.UserID) {
+		return nil
+	}
+	if err := rc.autoConfirmer.ConfirmReminder(ctx, reminder); err != nil {
+		slog.WarnContext(ctx, "failed to auto-confirm reminder, leaving it pending", "reminder_id", reminder.ID, "error", err)
+		return nil
+	}
+	slog.InfoContext(ctx, "Auto-confirmed reminder", "title", reminder.Title, "reminder_id", reminder.ID, "confidence", confidence)
+
+	confirmed, err := rc.db.GetReminderByID(reminder.ID)
+	if err != nil {
+		return reminder
+	}
+	return confirmed
+}
+
 // parseReminderTime parses a time string in various formats
 func parseReminderTime(timeStr, timezone string) (time.Time, bool, error) {
 	if t, fallback, err := timeutil.ParseDateTime(timeStr, timezone); err == nil {
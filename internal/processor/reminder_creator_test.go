@@ -0,0 +1,178 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReminderCreatorFixture(t *testing.T) (*database.DB, *database.TestUser, *database.SourceChannel) {
+	t.Helper()
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+
+	channel, err := db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeWhatsApp,
+		source.ChannelTypeSender,
+		"test@s.whatsapp.net",
+		"Test Contact",
+	)
+	require.NoError(t, err)
+	return db, user, channel
+}
+
+func createTestReminder(t *testing.T, db *database.DB, userID, channelID int64, status database.ReminderStatus) *database.Reminder {
+	t.Helper()
+	due := time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)
+	reminder, err := db.CreatePendingReminder(&database.Reminder{
+		UserID:     userID,
+		ChannelID:  channelID,
+		CalendarID: "primary",
+		Title:      "Pay rent",
+		DueDate:    &due,
+		Priority:   database.ReminderPriorityNormal,
+		ActionType: database.ReminderActionCreate,
+	})
+	require.NoError(t, err)
+	if status == database.ReminderStatusSynced {
+		require.NoError(t, db.UpdateReminderGoogleID(reminder.ID, "gcal-rent"))
+	} else if status != database.ReminderStatusPending {
+		require.NoError(t, db.UpdateReminderStatus(reminder.ID, status))
+	}
+	reminder, err = db.GetReminderByID(reminder.ID)
+	require.NoError(t, err)
+	return reminder
+}
+
+func TestUpdateReminder_PendingUpdatedInPlace(t *testing.T) {
+	db, user, channel := newReminderCreatorFixture(t)
+	existing := createTestReminder(t, db, user.ID, channel.ID, database.ReminderStatusPending)
+
+	updated, err := NewReminderCreator(db, nil).CreateReminderFromAnalysis(context.Background(), ReminderCreationParams{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		SourceType: source.SourceTypeWhatsApp,
+		Analysis: &agent.ReminderAnalysis{
+			HasReminder: true,
+			Action:      "update",
+			Confidence:  0.9,
+			Reminder:    &agent.ReminderData{Title: "Pay rent today", AlfredReminderRef: existing.ID},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, updated.ID)
+	assert.Equal(t, "Pay rent today", updated.Title)
+	assert.Nil(t, updated.ReplacesID)
+}
+
+func TestUpdateReminder_SyncedCreatesPendingChange(t *testing.T) {
+	db, user, channel := newReminderCreatorFixture(t)
+	existing := createTestReminder(t, db, user.ID, channel.ID, database.ReminderStatusSynced)
+
+	change, err := NewReminderCreator(db, nil).CreateReminderFromAnalysis(context.Background(), ReminderCreationParams{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		SourceType: source.SourceTypeWhatsApp,
+		Analysis: &agent.ReminderAnalysis{
+			HasReminder: true,
+			Action:      "update",
+			Reasoning:   "Rent moved to the 5th",
+			Confidence:  0.9,
+			Reminder: &agent.ReminderData{
+				DueDate:           "2030-03-05T09:00:00Z",
+				AlfredReminderRef: existing.ID,
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, existing.ID, change.ID)
+	assert.Equal(t, database.ReminderStatusPending, change.Status)
+	assert.Equal(t, database.ReminderActionUpdate, change.ActionType)
+	assert.Equal(t, "Pay rent", change.Title)
+	require.NotNil(t, change.DueDate)
+	assert.Equal(t, 5, change.DueDate.Day())
+	require.NotNil(t, change.GoogleEventID)
+	assert.Equal(t, "gcal-rent", *change.GoogleEventID)
+	require.NotNil(t, change.ReplacesID)
+	assert.Equal(t, existing.ID, *change.ReplacesID)
+
+	// The original stays active until the change is confirmed
+	original, err := db.GetReminderByID(existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.ReminderStatusSynced, original.Status)
+}
+
+func TestDeleteReminder_ConfirmedCreatesPendingChange(t *testing.T) {
+	db, user, channel := newReminderCreatorFixture(t)
+	existing := createTestReminder(t, db, user.ID, channel.ID, database.ReminderStatusConfirmed)
+
+	change, err := NewReminderCreator(db, nil).CreateReminderFromAnalysis(context.Background(), ReminderCreationParams{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		SourceType: source.SourceTypeWhatsApp,
+		Analysis: &agent.ReminderAnalysis{
+			HasReminder: true,
+			Action:      "delete",
+			Confidence:  0.9,
+			Reminder:    &agent.ReminderData{AlfredReminderRef: existing.ID},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, database.ReminderStatusPending, change.Status)
+	assert.Equal(t, database.ReminderActionDelete, change.ActionType)
+	require.NotNil(t, change.ReplacesID)
+	assert.Equal(t, existing.ID, *change.ReplacesID)
+}
+
+func TestUpdateReminder_RejectsOtherUsersReminder(t *testing.T) {
+	db, user, channel := newReminderCreatorFixture(t)
+	existing := createTestReminder(t, db, user.ID, channel.ID, database.ReminderStatusConfirmed)
+	other := database.CreateTestUser(t, db)
+
+	_, err := NewReminderCreator(db, nil).CreateReminderFromAnalysis(context.Background(), ReminderCreationParams{
+		UserID:     other.ID,
+		ChannelID:  channel.ID,
+		SourceType: source.SourceTypeWhatsApp,
+		Analysis: &agent.ReminderAnalysis{
+			HasReminder: true,
+			Action:      "delete",
+			Confidence:  0.9,
+			Reminder:    &agent.ReminderData{AlfredReminderRef: existing.ID},
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestCreateReminder_AutoConfirm(t *testing.T) {
+	db, user, channel := newReminderCreatorFixture(t)
+	require.NoError(t, db.SetConfidenceThresholds(user.ID, database.ConfidenceThresholds{
+		MinConfidence:         0.3,
+		AutoConfirmConfidence: 0.8,
+	}))
+
+	confirmer := &stubAutoConfirmer{db: db}
+	creator := NewReminderCreator(db, nil)
+	creator.autoConfirmer = confirmer
+
+	created, err := creator.CreateReminderFromAnalysis(context.Background(), ReminderCreationParams{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		SourceType: source.SourceTypeWhatsApp,
+		Analysis: &agent.ReminderAnalysis{
+			HasReminder: true,
+			Action:      "create",
+			Confidence:  0.85,
+			Reminder:    &agent.ReminderData{Title: "Call mom", DueDate: "2030-03-01T18:00:00Z"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, database.ReminderStatusConfirmed, created.Status)
+	assert.Equal(t, []int64{created.ID}, confirmer.reminders)
+}
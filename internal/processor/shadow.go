@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/intents"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	// shadowTimeout bounds one shadow analysis, which runs after the live one returns
+	shadowTimeout = 2 * time.Minute
+	// maxConcurrentShadows caps in-flight shadow analyses; extra ones are skipped
+	maxConcurrentShadows = 4
+)
+
+// ShadowRunner runs an alternative analyzer configuration (a new prompt or model) on
+// the same input as the live analyzers and records both outputs in shadow_analyses.
+// Shadow outputs are never validated or persisted, so users see no difference, and
+// shadow analyses don't count against users' LLM budgets.
+type ShadowRunner struct {
+	db         *database.DB
+	label      string
+	sampleRate float64
+	sample     func() float64
+	modules    map[string]intents.IntentModule
+	slots      chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewShadowRunner creates a runner that records comparisons under label for a
+// sampleRate fraction (0-1] of analyses
+func NewShadowRunner(db *database.DB, label string, sampleRate float64) *ShadowRunner {
+	if sampleRate <= 0 || sampleRate > 1 {
+		sampleRate = 1
+	}
+	return &ShadowRunner{
+		db:         db,
+		label:      label,
+		sampleRate: sampleRate,
+		sample:     rand.Float64,
+		modules:    make(map[string]intents.IntentModule),
+		slots:      make(chan struct{}, maxConcurrentShadows),
+	}
+}
+
+// Register adds the shadow counterpart of the live module with the same intent name
+func (s *ShadowRunner) Register(module intents.IntentModule) {
+	s.modules[module.IntentName()] = module
+}
+
+// Label returns the name comparisons are recorded under
+func (s *ShadowRunner) Label() string {
+	return s.label
+}
+
+// Wait blocks until in-flight shadow analyses have been recorded
+func (s *ShadowRunner) Wait() {
+	s.wg.Wait()
+}
+
+// shadowAnalyzeFunc runs a shadow module on the input the live module analyzed
+type shadowAnalyzeFunc func(ctx context.Context, module intents.IntentModule) (*intents.ModuleOutput, error)
+
+// compare runs the shadow module for intentName in the background and records its
+// output next to primary. record carries the user, channel and trigger message.
+func (s *ShadowRunner) compare(
+	ctx context.Context,
+	intentName string,
+	record database.ShadowAnalysis,
+	primary *intents.ModuleOutput,
+	analyze shadowAnalyzeFunc,
+) {
+	if s == nil {
+		return
+	}
+	module, ok := s.modules[intentName]
+	if !ok || s.sample() >= s.sampleRate {
+		return
+	}
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		slog.Warn("Shadow: skipping analysis, too many in flight", "label", s.label, "intent_name", intentName)
+		return
+	}
+
+	// The live configuration's model settings don't apply to the shadow, and its usage
+	// is captured here instead of by the budget tracker or the analysis log. Shadow
+	// requests yield to live ones under the provider rate limit.
+	var usage agent.UsageRecord
+	var usageMu sync.Mutex
+	shadowCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowTimeout)
+	shadowCtx = agent.WithModelSettings(shadowCtx, agent.ModelSettings{})
+	shadowCtx = agent.WithBackgroundPriority(shadowCtx)
+	shadowCtx = agent.WithRunLogger(shadowCtx, nil)
+	shadowCtx = agent.WithUsageRecorder(shadowCtx, func(rec agent.UsageRecord) {
+		usageMu.Lock()
+		defer usageMu.Unlock()
+		usage.Model = rec.Model
+		usage.Usage.Add(rec.Usage)
+	})
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() { <-s.slots }()
+		defer cancel()
+
+		output, err := analyze(shadowCtx, module)
+
+		record.Intent = intentName
+		record.Label = s.label
+		if primary != nil {
+			record.PrimaryAction = primary.Action
+			record.PrimaryConfidence = primary.Confidence
+			record.PrimaryOutput = shadowOutputJSON(primary)
+		}
+		if err != nil {
+			record.ShadowError = err.Error()
+		}
+		if output != nil {
+			record.ShadowAction = output.Action
+			record.ShadowConfidence = output.Confidence
+			record.ShadowOutput = shadowOutputJSON(output)
+		}
+		usageMu.Lock()
+		record.ShadowModel = usage.Model
+		record.ShadowCostUSD = agent.EstimateCost(usage.Model, usage.Usage)
+		usageMu.Unlock()
+		record.Agreed = err == nil && output != nil && primary != nil && output.Action == primary.Action
+
+		if err := s.db.CreateShadowAnalysis(record); err != nil {
+			slog.Error("Shadow: analysis failed", "label", s.label, "error", err)
+		}
+	}()
+}
+
+// shadowOutputJSON serializes the analysis a module produced for offline comparison
+func shadowOutputJSON(out *intents.ModuleOutput) string {
+	var payload any = out
+	switch {
+	case out.EventAnalysis != nil:
+		payload = out.EventAnalysis
+	case out.ReminderAnalysis != nil:
+		payload = out.ReminderAnalysis
+	case out.TravelAnalysis != nil:
+		payload = out.TravelAnalysis
+	case out.BillAnalysis != nil:
+		payload = out.BillAnalysis
+	case out.DeliveryAnalysis != nil:
+		payload = out.DeliveryAnalysis
+	case out.OccasionAnalysis != nil:
+		payload = out.OccasionAnalysis
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
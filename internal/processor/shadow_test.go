@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/intents"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedEventAnalyzer struct {
+	analysis agent.EventAnalysis
+}
+
+func (a *fixedEventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	analysis := a.analysis
+	return &analysis, nil
+}
+
+func (a *fixedEventAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.EventAnalysis, error) {
+	analysis := a.analysis
+	return &analysis, nil
+}
+
+func (a *fixedEventAnalyzer) IsConfigured() bool { return true }
+
+func TestShadowRunnerRecordsWithoutPersisting(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "dana@s.whatsapp.net", "Dana")
+	require.NoError(t, err)
+
+	live := &fixedEventAnalyzer{analysis: agent.EventAnalysis{Action: "none", Confidence: 0.9}}
+	shadow := NewShadowRunner(db, "candidate", 1)
+	shadow.Register(&intents.EventModule{Analyzer: &fixedEventAnalyzer{analysis: agent.EventAnalysis{
+		HasEvent:   true,
+		Action:     "create",
+		Confidence: 0.8,
+		Event:      &agent.EventData{Title: "Dinner", StartTime: "2026-03-06T20:00:00Z"},
+	}}})
+
+	p := New(db, live, nil, make(chan source.Message), 25, nil)
+	p.SetShadow(shadow)
+	require.NoError(t, p.processMessage(source.Message{
+		UserID:     user.ID,
+		SourceType: source.SourceTypeWhatsApp,
+		SourceID:   channel.ID,
+		Identifier: "dana@s.whatsapp.net",
+		SenderID:   "dana@s.whatsapp.net",
+		SenderName: "Dana",
+		Text:       "Dinner on Friday at 8?",
+		Timestamp:  time.Now(),
+	}, 0))
+	p.Stop()
+
+	events, err := db.GetActiveEventsForChannel(user.ID, channel.ID)
+	require.NoError(t, err)
+	assert.Empty(t, events, "shadow output is never persisted")
+
+	analyses, err := db.ListShadowAnalyses(database.ShadowAnalysisFilter{Label: "candidate"})
+	require.NoError(t, err)
+	require.Len(t, analyses, 1)
+	assert.Equal(t, "event", analyses[0].Intent)
+	assert.Equal(t, "none", analyses[0].PrimaryAction)
+	assert.Equal(t, "create", analyses[0].ShadowAction)
+	assert.Contains(t, analyses[0].ShadowOutput, "Dinner")
+	assert.False(t, analyses[0].Agreed)
+	require.NotNil(t, analyses[0].TriggerMessageID)
+
+	summary, err := db.SummarizeShadowAnalyses()
+	require.NoError(t, err)
+	require.Len(t, summary, 1)
+	assert.Equal(t, 1, summary[0].Total)
+	assert.Equal(t, 0, summary[0].Agreed)
+
+	t.Run("sampled out", func(t *testing.T) {
+		sampled := NewShadowRunner(db, "sampled", 0.1)
+		sampled.sample = func() float64 { return 0.5 }
+		sampled.Register(&intents.EventModule{Analyzer: live})
+		sampled.compare(context.Background(), "event", database.ShadowAnalysis{UserID: user.ID}, nil,
+			func(ctx context.Context, m intents.IntentModule) (*intents.ModuleOutput, error) {
+				return m.AnalyzeMessages(ctx, intents.MessageInput{})
+			})
+		sampled.Wait()
+
+		analyses, err := db.ListShadowAnalyses(database.ShadowAnalysisFilter{Label: "sampled"})
+		require.NoError(t, err)
+		assert.Empty(t, analyses)
+	})
+}
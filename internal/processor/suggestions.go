@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	// suggestionWindow is how far back untracked channels are scored
+	suggestionWindow = 7 * 24 * time.Hour
+	// defaultSuggestionInterval is how often channels are rescored
+	defaultSuggestionInterval = time.Hour
+)
+
+// ChannelSuggester scores untracked channels by how many actionable-looking messages
+// they had in the last week, using the prefilter rather than the LLM
+type ChannelSuggester struct {
+	db        *database.DB
+	prefilter *Prefilter
+	now       func() time.Time
+}
+
+// NewChannelSuggester returns a ChannelSuggester. A nil prefilter uses the default rules.
+func NewChannelSuggester(db *database.DB, prefilter *Prefilter) *ChannelSuggester {
+	if prefilter == nil {
+		prefilter, _ = NewPrefilter(DefaultPrefilterConfig())
+	}
+	return &ChannelSuggester{db: db, prefilter: prefilter, now: time.Now}
+}
+
+// Start scores immediately and then every interval until ctx is cancelled
+func (s *ChannelSuggester) Start(ctx context.Context, interval time.Duration) {
+	if s == nil || s.db == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultSuggestionInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		s.score(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.score(ctx)
+			}
+		}
+	}()
+}
+
+func (s *ChannelSuggester) score(ctx context.Context) {
+	if _, err := s.ScoreOnce(ctx); err != nil {
+		slog.Error("Suggestions: Scoring failed", "error", err)
+	}
+}
+
+// ScoreOnce rescores every untracked channel with recent messages and drops the scores of
+// channels that were tracked or went quiet. Returns the number of channels scored.
+func (s *ChannelSuggester) ScoreOnce(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+	since := now.Add(-suggestionWindow)
+
+	channels, err := s.db.ListSuggestionCandidates(since)
+	if err != nil {
+		return 0, err
+	}
+
+	scored := 0
+	for _, channel := range channels {
+		if err := ctx.Err(); err != nil {
+			return scored, err
+		}
+		messages, err := s.db.GetSourceMessagesSince(channel.UserID, channel.SourceType, channel.ID, since)
+		if err != nil {
+			return scored, fmt.Errorf("channel %d: %w", channel.ID, err)
+		}
+
+		suggestion := database.ChannelSuggestion{
+			ChannelID:    channel.ID,
+			UserID:       channel.UserID,
+			MessageCount: len(messages),
+			ScoredAt:     now,
+		}
+		for _, msg := range messages {
+			if !s.prefilter.LooksActionable(msg.MessageText) {
+				continue
+			}
+			suggestion.ActionableCount++
+			timestamp := msg.Timestamp
+			suggestion.LastActionableAt = &timestamp
+		}
+		if err := s.db.SaveChannelSuggestion(suggestion); err != nil {
+			return scored, err
+		}
+		scored++
+	}
+
+	if err := s.db.DeleteStaleChannelSuggestions(now); err != nil {
+		return scored, err
+	}
+	return scored, nil
+}
@@ -0,0 +1,62 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+func TestChannelSuggesterScoreOnce(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	now := time.Now().UTC()
+
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "972500000001", "Dana")
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateSourceChannel(user.ID, channel.ID, channel.Name, false))
+
+	for i, msg := range []struct {
+		text string
+		age  time.Duration
+	}{
+		{"Can we meet tomorrow?", time.Hour},
+		{"pickup at 4:30", 2 * time.Hour},
+		{"haha", 3 * time.Hour},
+		{"how are you", 4 * time.Hour},
+		{"dentist appointment on Monday", 10 * 24 * time.Hour}, // outside the window
+	} {
+		_, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "972500000001@s.whatsapp.net", "Dana",
+			msg.text, "", now.Add(-msg.age-time.Duration(i)*time.Second))
+		require.NoError(t, err)
+	}
+
+	suggester := NewChannelSuggester(db, nil)
+	suggester.now = func() time.Time { return now }
+
+	scored, err := suggester.ScoreOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, scored)
+
+	suggestions, err := db.ListChannelSuggestions(user.ID, 5)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, channel.ID, suggestions[0].ChannelID)
+	assert.Equal(t, 2, suggestions[0].ActionableCount)
+	assert.Equal(t, 4, suggestions[0].MessageCount)
+
+	// Once the channel is tracked it's no longer scored or suggested
+	require.NoError(t, db.UpdateSourceChannel(user.ID, channel.ID, channel.Name, true))
+	suggester.now = func() time.Time { return now.Add(time.Minute) }
+	scored, err = suggester.ScoreOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, scored)
+	suggestions, err = db.ListChannelSuggestions(user.ID, 5)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
@@ -0,0 +1,114 @@
+// Package retention enforces the message_history retention window.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	defaultPollInterval = time.Hour
+	// deleteBatchSize bounds each DELETE so pruning never holds the write lock for long
+	deleteBatchSize = 500
+)
+
+// Pruner deletes messages older than each user's retention window
+type Pruner struct {
+	db          *database.DB
+	defaultDays int
+	now         func() time.Time
+}
+
+// NewPruner returns a Pruner. defaultDays applies to users without their own
+// setting; 0 keeps messages forever.
+func NewPruner(db *database.DB, defaultDays int) *Pruner {
+	if defaultDays < 0 {
+		defaultDays = 0
+	}
+	return &Pruner{db: db, defaultDays: defaultDays, now: time.Now}
+}
+
+// DefaultDays returns the retention window used for users without their own setting
+func (p *Pruner) DefaultDays() int {
+	return p.defaultDays
+}
+
+// EffectiveDays resolves a user's retention override against the default
+func (p *Pruner) EffectiveDays(days *int) int {
+	if days == nil {
+		return p.defaultDays
+	}
+	return *days
+}
+
+// Start prunes immediately and then every pollInterval until ctx is cancelled
+func (p *Pruner) Start(ctx context.Context, pollInterval time.Duration) {
+	if p == nil || p.db == nil {
+		return
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		p.prune(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.prune(ctx)
+			}
+		}
+	}()
+}
+
+func (p *Pruner) prune(ctx context.Context) {
+	deleted, err := p.PruneOnce(ctx)
+	if err != nil {
+		slog.Error("Retention: Prune failed", "error", err)
+	}
+	if deleted > 0 {
+		slog.Info("Retention: Deleted expired messages", "deleted", deleted)
+	}
+}
+
+// PruneOnce deletes every user's expired messages and returns how many were deleted
+func (p *Pruner) PruneOnce(ctx context.Context) (int64, error) {
+	users, err := p.db.ListMessageRetention()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, u := range users {
+		days := p.EffectiveDays(u.Days)
+		if days <= 0 {
+			continue
+		}
+		cutoff := p.now().UTC().AddDate(0, 0, -days)
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return total, err
+			}
+			deleted, err := p.db.DeleteMessagesBefore(u.UserID, cutoff, deleteBatchSize)
+			if err != nil {
+				return total, fmt.Errorf("user %d: %w", u.UserID, err)
+			}
+			total += deleted
+			if deleted < deleteBatchSize {
+				break
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,66 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+func TestPruneOnce(t *testing.T) {
+	db := database.NewTestDB(t)
+	now := time.Now().UTC()
+
+	// One user on the default window, one with a shorter window, one keeping everything
+	defaultUser := database.CreateTestUserWithEmail(t, db, "default@example.com")
+	shortUser := database.CreateTestUserWithEmail(t, db, "short@example.com")
+	foreverUser := database.CreateTestUserWithEmail(t, db, "forever@example.com")
+	seven, zero := 7, 0
+	require.NoError(t, db.SetMessageRetentionDays(shortUser.ID, &seven))
+	require.NoError(t, db.SetMessageRetentionDays(foreverUser.ID, &zero))
+
+	channels := make(map[int64]int64)
+	for _, user := range []*database.TestUser{defaultUser, shortUser, foreverUser} {
+		channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "contact@s.whatsapp.net", "Contact")
+		require.NoError(t, err)
+		channels[user.ID] = channel.ID
+
+		for i, age := range []time.Duration{time.Hour, 30 * 24 * time.Hour, 120 * 24 * time.Hour} {
+			_, err := db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "contact@s.whatsapp.net", "Contact",
+				string(rune('A'+i)), "", now.Add(-age))
+			require.NoError(t, err)
+		}
+	}
+
+	pruner := NewPruner(db, 90)
+	deleted, err := pruner.PruneOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	remaining := func(user *database.TestUser) int {
+		count, err := db.CountSourceMessages(user.ID, source.SourceTypeWhatsApp, channels[user.ID])
+		require.NoError(t, err)
+		return count
+	}
+	assert.Equal(t, 2, remaining(defaultUser))
+	assert.Equal(t, 1, remaining(shortUser))
+	assert.Equal(t, 3, remaining(foreverUser))
+}
+
+func TestPruneOnceDisabledByDefault(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	channel, err := db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "contact@s.whatsapp.net", "Contact")
+	require.NoError(t, err)
+	_, err = db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "contact@s.whatsapp.net", "Contact", "old", "", time.Now().AddDate(-2, 0, 0))
+	require.NoError(t, err)
+
+	deleted, err := NewPruner(db, 0).PruneOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const awsJSONContentType = "application/x-amz-json-1.1"
+
+// awsSecretValue reads a secret from Secrets Manager
+func (r *Resolver) awsSecretValue(ctx context.Context, secretID string) (string, error) {
+	if secretID == "" {
+		return "", errors.New("aws-sm reference has no secret id")
+	}
+	var out struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+	}
+	err := r.awsCall(ctx, "secretsmanager", "secretsmanager.GetSecretValue",
+		map[string]string{"SecretId": secretID}, &out)
+	if err != nil {
+		return "", err
+	}
+	if out.SecretString != "" || out.SecretBinary == "" {
+		return out.SecretString, nil
+	}
+	value, err := base64.StdEncoding.DecodeString(out.SecretBinary)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret binary: %w", err)
+	}
+	return string(value), nil
+}
+
+// kmsDecrypt decrypts a base64 ciphertext blob with KMS. The blob names its key, so
+// only the ciphertext is needed.
+func (r *Resolver) kmsDecrypt(ctx context.Context, ciphertext string) (string, error) {
+	if _, err := base64.StdEncoding.DecodeString(ciphertext); err != nil {
+		return "", fmt.Errorf("aws-kms reference is not base64 ciphertext: %w", err)
+	}
+	var out struct {
+		Plaintext string `json:"Plaintext"`
+	}
+	err := r.awsCall(ctx, "kms", "TrentService.Decrypt", map[string]string{"CiphertextBlob": ciphertext}, &out)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(out.Plaintext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode kms plaintext: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// awsCall sends a signed AWS JSON 1.1 request and decodes the response into out
+func (r *Resolver) awsCall(ctx context.Context, service, target string, in, out any) error {
+	creds, err := r.awsCredentials(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", service, err)
+	}
+
+	endpoint := r.aws.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", service, r.aws.Region)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/",
+		bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", service, err)
+	}
+	req.Header.Set("Content-Type", awsJSONContentType)
+	req.Header.Set("X-Amz-Target", target)
+	r.signAWS(req, service, body, creds)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return fmt.Errorf("%s returned %d: %s", service, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", service, err)
+	}
+	return nil
+}
+
+// signAWS adds AWS Signature Version 4 headers to a POST to the service root
+func (r *Resolver) signAWS(req *http.Request, service string, body []byte, creds awsCredentials) {
+	now := r.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	bodyHash := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(bodyHash[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         req.URL.Host,
+		"x-amz-date":   amzDate,
+		"x-amz-target": req.Header.Get("X-Amz-Target"),
+	}
+	if creds.SessionToken != "" {
+		headers["x-amz-security-token"] = creds.SessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{day, r.aws.Region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	key = hmacSHA256(key, r.aws.Region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
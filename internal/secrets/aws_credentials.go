@@ -0,0 +1,221 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultECSEndpoint  = "http://169.254.170.2"
+	defaultIMDSEndpoint = "http://169.254.169.254"
+
+	// imdsTimeout keeps startup fast on hosts without instance metadata
+	imdsTimeout  = 2 * time.Second
+	imdsTokenTTL = "21600"
+
+	// Temporary credentials are refreshed this long before they expire
+	credentialsExpiryWindow = 5 * time.Minute
+)
+
+// awsCredentials are the keys AWS requests are signed with
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time // zero for static keys
+}
+
+// awsCredentials returns the credentials for the next AWS request, looked up like the
+// AWS SDKs' default chain: static keys (AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY, with
+// AWS_SESSION_TOKEN), then the ECS or EKS container credentials endpoint, then the EC2
+// instance profile through IMDSv2. Temporary credentials are cached until shortly
+// before they expire.
+func (r *Resolver) awsCredentials(ctx context.Context) (awsCredentials, error) {
+	if r.aws.AccessKeyID != "" && r.aws.SecretAccessKey != "" {
+		return awsCredentials{
+			AccessKeyID:     r.aws.AccessKeyID,
+			SecretAccessKey: r.aws.SecretAccessKey,
+			SessionToken:    r.aws.SessionToken,
+		}, nil
+	}
+
+	r.credsMu.Lock()
+	defer r.credsMu.Unlock()
+	if r.creds != nil && r.now().Before(r.creds.Expires.Add(-credentialsExpiryWindow)) {
+		return *r.creds, nil
+	}
+
+	var creds awsCredentials
+	var err error
+	switch {
+	case r.aws.ContainerCredentialsRelativeURI != "":
+		creds, err = r.containerCredentials(ctx, strings.TrimSuffix(r.ecsEndpoint, "/")+r.aws.ContainerCredentialsRelativeURI)
+	case r.aws.ContainerCredentialsFullURI != "":
+		creds, err = r.containerCredentials(ctx, r.aws.ContainerCredentialsFullURI)
+	case !r.aws.DisableIMDS:
+		creds, err = r.instanceCredentials(ctx)
+		if err != nil {
+			err = fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set and no instance profile is available: %w", err)
+		}
+	default:
+		err = errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or a container or instance role, are required for aws secret references")
+	}
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	r.creds = &creds
+	return creds, nil
+}
+
+// containerCredentials fetches task or pod role credentials from the container
+// credentials endpoint
+func (r *Resolver) containerCredentials(ctx context.Context, endpoint string) (awsCredentials, error) {
+	if err := checkContainerEndpoint(endpoint); err != nil {
+		return awsCredentials{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to create container credentials request: %w", err)
+	}
+
+	token := r.aws.ContainerAuthorizationToken
+	if r.aws.ContainerAuthorizationTokenFile != "" {
+		data, err := os.ReadFile(r.aws.ContainerAuthorizationTokenFile)
+		if err != nil {
+			return awsCredentials{}, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	creds, err := r.fetchCredentials(req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("container credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// checkContainerEndpoint accepts https endpoints and plain http only to this host or
+// the link-local container credential agents, as the AWS SDKs do
+func checkContainerEndpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid container credentials URI: %w", err)
+	}
+	if parsed.Scheme == "https" {
+		return nil
+	}
+	if parsed.Scheme == "http" {
+		host := parsed.Hostname()
+		if host == "localhost" {
+			return nil
+		}
+		if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsLinkLocalUnicast()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("container credentials URI %q must use https or a loopback or link-local host", endpoint)
+}
+
+// instanceCredentials fetches the EC2 instance profile's credentials through IMDSv2
+func (r *Resolver) instanceCredentials(ctx context.Context) (awsCredentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, imdsTimeout)
+	defer cancel()
+	base := strings.TrimSuffix(r.imdsEndpoint, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, base+"/latest/api/token", nil)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to create metadata token request: %w", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", imdsTokenTTL)
+	token, err := r.metadataText(req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("metadata token: %w", err)
+	}
+
+	rolesURL := base + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, rolesURL, nil)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to create instance role request: %w", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	roles, err := r.metadataText(req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("instance role: %w", err)
+	}
+	role, _, _ := strings.Cut(roles, "\n")
+	if role == "" {
+		return awsCredentials{}, errors.New("instance has no IAM role")
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, rolesURL+url.PathEscape(role), nil)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to create instance credentials request: %w", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	creds, err := r.fetchCredentials(req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("instance credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// metadataText sends a metadata request and returns its trimmed text response
+func (r *Resolver) metadataText(req *http.Request) (string, error) {
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata service returned %d", resp.StatusCode)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// fetchCredentials sends a request to a credentials endpoint, which answers with the
+// JSON document the ECS agent and EC2 instance metadata share
+func (r *Resolver) fetchCredentials(req *http.Request) (awsCredentials, error) {
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return awsCredentials{}, fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+
+	var out struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+	if out.AccessKeyID == "" || out.SecretAccessKey == "" {
+		return awsCredentials{}, errors.New("endpoint returned no credentials")
+	}
+	return awsCredentials{
+		AccessKeyID:     out.AccessKeyID,
+		SecretAccessKey: out.SecretAccessKey,
+		SessionToken:    out.Token,
+		Expires:         out.Expiration,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// gcpSecretValue reads a secret version from GCP Secret Manager. name is
+// projects/<project>/secrets/<secret>, optionally followed by /versions/<version>;
+// the latest version is read when none is given.
+func (r *Resolver) gcpSecretValue(ctx context.Context, name string) (string, error) {
+	parts := strings.Split(name, "/")
+	switch {
+	case len(parts) == 4 && parts[0] == "projects" && parts[2] == "secrets":
+		name += "/versions/latest"
+	case len(parts) == 6 && parts[0] == "projects" && parts[2] == "secrets" && parts[4] == "versions":
+	default:
+		return "", fmt.Errorf("gcp-sm reference %q should be projects/<project>/secrets/<name>[/versions/<version>]", name)
+	}
+
+	tokenSource := r.gcpTokenSource
+	if tokenSource == nil {
+		var err error
+		tokenSource, err = google.DefaultTokenSource(ctx, gcpCloudPlatformScope)
+		if err != nil {
+			return "", fmt.Errorf("failed to find google application default credentials: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(r.gcpEndpoint, "/")+"/v1/"+name+":access", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create secret manager request: %w", err)
+	}
+	client := oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, r.httpClient), tokenSource)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secret manager request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return "", fmt.Errorf("secret manager returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode secret manager response: %w", err)
+	}
+	if out.Payload.Data == "" {
+		return "", errors.New("secret manager returned an empty payload")
+	}
+	data, err := base64.StdEncoding.DecodeString(out.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
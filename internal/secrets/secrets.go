@@ -0,0 +1,131 @@
+// Package secrets fetches configuration secrets from AWS Secrets Manager, AWS KMS and
+// GCP Secret Manager, so keys don't have to be stored in the environment.
+//
+// A setting holds a reference instead of the secret itself:
+//
+//	aws-sm://<secret id or ARN>[#json-field]
+//	aws-kms://<base64 ciphertext>
+//	gcp-sm://projects/<project>/secrets/<name>[/versions/<version>][#json-field]
+//
+// A #json-field suffix reads one field of a secret stored as a JSON object.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Reference schemes
+const (
+	SchemeAWSSecretsManager = "aws-sm://"
+	SchemeAWSKMS            = "aws-kms://"
+	SchemeGCPSecretManager  = "gcp-sm://"
+)
+
+const (
+	requestTimeout    = 15 * time.Second
+	maxErrorBodyBytes = 512
+)
+
+// IsReference reports whether value names a secret to fetch rather than holding one
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, SchemeAWSSecretsManager) ||
+		strings.HasPrefix(value, SchemeAWSKMS) ||
+		strings.HasPrefix(value, SchemeGCPSecretManager)
+}
+
+// AWSConfig holds the credentials used for Secrets Manager and KMS requests. Without
+// static keys, the container credentials endpoint and then the EC2 instance profile are
+// used, like the AWS SDKs' default credential chain.
+type AWSConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Optional, for temporary credentials
+	Endpoint        string // Overrides https://<service>.<region>.amazonaws.com, e.g. for VPC endpoints
+
+	ContainerCredentialsRelativeURI string // AWS_CONTAINER_CREDENTIALS_RELATIVE_URI (ECS task role)
+	ContainerCredentialsFullURI     string // AWS_CONTAINER_CREDENTIALS_FULL_URI
+	ContainerAuthorizationToken     string // AWS_CONTAINER_AUTHORIZATION_TOKEN
+	ContainerAuthorizationTokenFile string // AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE (EKS Pod Identity)
+	DisableIMDS                     bool   // AWS_EC2_METADATA_DISABLED
+}
+
+// Resolver fetches the secrets references point to
+type Resolver struct {
+	aws            AWSConfig
+	ecsEndpoint    string // base of AWS_CONTAINER_CREDENTIALS_RELATIVE_URI
+	imdsEndpoint   string
+	gcpEndpoint    string
+	gcpTokenSource oauth2.TokenSource // Application Default Credentials when nil
+	httpClient     *http.Client
+	now            func() time.Time
+
+	credsMu sync.Mutex
+	creds   *awsCredentials // temporary AWS credentials from a role
+}
+
+// NewResolver returns a Resolver that signs AWS requests with aws. GCP requests use
+// Application Default Credentials (GOOGLE_APPLICATION_CREDENTIALS or the metadata server).
+func NewResolver(aws AWSConfig) *Resolver {
+	if aws.Region == "" {
+		aws.Region = "us-east-1"
+	}
+	return &Resolver{
+		aws:          aws,
+		ecsEndpoint:  defaultECSEndpoint,
+		imdsEndpoint: defaultIMDSEndpoint,
+		gcpEndpoint:  "https://secretmanager.googleapis.com",
+		httpClient:   &http.Client{Timeout: requestTimeout},
+		now:          time.Now,
+	}
+}
+
+// Resolve fetches the secret ref points to
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	if ciphertext, ok := strings.CutPrefix(ref, SchemeAWSKMS); ok {
+		return r.kmsDecrypt(ctx, ciphertext)
+	}
+
+	ref, field, _ := strings.Cut(ref, "#")
+	var value string
+	var err error
+	switch {
+	case strings.HasPrefix(ref, SchemeAWSSecretsManager):
+		value, err = r.awsSecretValue(ctx, strings.TrimPrefix(ref, SchemeAWSSecretsManager))
+	case strings.HasPrefix(ref, SchemeGCPSecretManager):
+		value, err = r.gcpSecretValue(ctx, strings.TrimPrefix(ref, SchemeGCPSecretManager))
+	default:
+		return "", fmt.Errorf("unsupported secret reference %q", ref)
+	}
+	if err != nil || field == "" {
+		return value, err
+	}
+	return jsonField(value, field)
+}
+
+// jsonField reads one field of a secret stored as a JSON object
+func jsonField(value, field string) (string, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, so field %q can't be read", field)
+	}
+	v, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", field)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
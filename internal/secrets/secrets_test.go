@@ -0,0 +1,248 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestIsReference(t *testing.T) {
+	assert.True(t, IsReference("aws-sm://prod/alfred"))
+	assert.True(t, IsReference("aws-kms://AQICAHh="))
+	assert.True(t, IsReference("gcp-sm://projects/p/secrets/s"))
+	assert.False(t, IsReference("sk-ant-plain-key"))
+	assert.False(t, IsReference(""))
+}
+
+func newAWSTestResolver(t *testing.T, handler func(target string, body map[string]string) (int, any)) *Resolver {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, awsJSONContentType, r.Header.Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260102/eu-west-1/"))
+		assert.Equal(t, "session-token", r.Header.Get("X-Amz-Security-Token"))
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		status, out := handler(r.Header.Get("X-Amz-Target"), body)
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	t.Cleanup(server.Close)
+
+	resolver := NewResolver(AWSConfig{
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session-token",
+		Endpoint:        server.URL,
+	})
+	resolver.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return resolver
+}
+
+func TestResolveAWS(t *testing.T) {
+	resolver := newAWSTestResolver(t, func(target string, body map[string]string) (int, any) {
+		switch {
+		case target == "secretsmanager.GetSecretValue" && body["SecretId"] == "prod/anthropic":
+			return http.StatusOK, map[string]string{"SecretString": "sk-ant-from-aws"}
+		case target == "secretsmanager.GetSecretValue" && body["SecretId"] == "prod/telegram":
+			return http.StatusOK, map[string]string{"SecretString": `{"api_id":12345,"api_hash":"abc"}`}
+		case target == "secretsmanager.GetSecretValue" && body["SecretId"] == "prod/binary":
+			return http.StatusOK, map[string]string{"SecretBinary": base64.StdEncoding.EncodeToString([]byte("bin"))}
+		case target == "TrentService.Decrypt":
+			assert.Equal(t, "Y2lwaGVydGV4dA==", body["CiphertextBlob"])
+			return http.StatusOK, map[string]string{"Plaintext": base64.StdEncoding.EncodeToString([]byte("kms-key"))}
+		}
+		return http.StatusBadRequest, map[string]string{"__type": "ResourceNotFoundException"}
+	})
+	ctx := context.Background()
+
+	t.Run("secrets manager string", func(t *testing.T) {
+		value, err := resolver.Resolve(ctx, "aws-sm://prod/anthropic")
+		require.NoError(t, err)
+		assert.Equal(t, "sk-ant-from-aws", value)
+	})
+
+	t.Run("json fields", func(t *testing.T) {
+		value, err := resolver.Resolve(ctx, "aws-sm://prod/telegram#api_hash")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", value)
+
+		value, err = resolver.Resolve(ctx, "aws-sm://prod/telegram#api_id")
+		require.NoError(t, err)
+		assert.Equal(t, "12345", value)
+
+		_, err = resolver.Resolve(ctx, "aws-sm://prod/telegram#missing")
+		assert.ErrorContains(t, err, `no field "missing"`)
+	})
+
+	t.Run("secrets manager binary", func(t *testing.T) {
+		value, err := resolver.Resolve(ctx, "aws-sm://prod/binary")
+		require.NoError(t, err)
+		assert.Equal(t, "bin", value)
+	})
+
+	t.Run("kms ciphertext", func(t *testing.T) {
+		value, err := resolver.Resolve(ctx, "aws-kms://Y2lwaGVydGV4dA==")
+		require.NoError(t, err)
+		assert.Equal(t, "kms-key", value)
+	})
+
+	t.Run("errors are reported", func(t *testing.T) {
+		_, err := resolver.Resolve(ctx, "aws-sm://prod/unknown")
+		assert.ErrorContains(t, err, "ResourceNotFoundException")
+
+		_, err = NewResolver(AWSConfig{DisableIMDS: true}).Resolve(ctx, "aws-sm://prod/anthropic")
+		assert.ErrorContains(t, err, "AWS_ACCESS_KEY_ID")
+	})
+}
+
+// newRoleCredentialsResolver returns a Resolver without static keys and the credentials
+// it should find, for a Secrets Manager stub that checks requests are signed with them
+func newRoleCredentialsResolver(t *testing.T, aws AWSConfig) *Resolver {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ASIAROLE/"))
+		assert.Equal(t, "role-session-token", r.Header.Get("X-Amz-Security-Token"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": "from-role"})
+	}))
+	t.Cleanup(server.Close)
+
+	aws.Endpoint = server.URL
+	resolver := NewResolver(aws)
+	resolver.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return resolver
+}
+
+// roleCredentials is the document ECS and EC2 instance metadata answer with
+var roleCredentials = map[string]string{
+	"AccessKeyId":     "ASIAROLE",
+	"SecretAccessKey": "role-secret",
+	"Token":           "role-session-token",
+	"Expiration":      "2026-01-02T05:00:00Z",
+}
+
+func TestAWSCredentialChain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ecs task role", func(t *testing.T) {
+		var calls int
+		ecs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			assert.Equal(t, "/v2/credentials/task", r.URL.Path)
+			_ = json.NewEncoder(w).Encode(roleCredentials)
+		}))
+		defer ecs.Close()
+
+		resolver := newRoleCredentialsResolver(t, AWSConfig{ContainerCredentialsRelativeURI: "/v2/credentials/task"})
+		resolver.ecsEndpoint = ecs.URL
+		for range 2 {
+			value, err := resolver.Resolve(ctx, "aws-sm://prod/anthropic")
+			require.NoError(t, err)
+			assert.Equal(t, "from-role", value)
+		}
+		assert.Equal(t, 1, calls, "credentials are cached until they expire")
+
+		resolver.now = func() time.Time { return time.Date(2026, 1, 2, 4, 56, 0, 0, time.UTC) }
+		_, err := resolver.Resolve(ctx, "aws-sm://prod/anthropic")
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls, "refreshed shortly before expiry")
+	})
+
+	t.Run("container full uri with token file", func(t *testing.T) {
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(tokenFile, []byte("pod-token\n"), 0o600))
+		agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "pod-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(roleCredentials)
+		}))
+		defer agent.Close()
+
+		resolver := newRoleCredentialsResolver(t, AWSConfig{
+			ContainerCredentialsFullURI:     agent.URL + "/v1/credentials",
+			ContainerAuthorizationTokenFile: tokenFile,
+		})
+		value, err := resolver.Resolve(ctx, "aws-sm://prod/anthropic")
+		require.NoError(t, err)
+		assert.Equal(t, "from-role", value)
+
+		_, err = NewResolver(AWSConfig{ContainerCredentialsFullURI: "http://credentials.example.com/v1"}).
+			Resolve(ctx, "aws-sm://prod/anthropic")
+		assert.ErrorContains(t, err, "must use https")
+	})
+
+	t.Run("ec2 instance profile through imdsv2", func(t *testing.T) {
+		imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/latest/api/token" {
+				assert.Equal(t, http.MethodPut, r.Method)
+				assert.NotEmpty(t, r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+				_, _ = w.Write([]byte("imds-token"))
+				return
+			}
+			if r.Header.Get("X-aws-ec2-metadata-token") != "imds-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Path {
+			case "/latest/meta-data/iam/security-credentials/":
+				_, _ = w.Write([]byte("alfred-instance-role\n"))
+			case "/latest/meta-data/iam/security-credentials/alfred-instance-role":
+				_ = json.NewEncoder(w).Encode(roleCredentials)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer imds.Close()
+
+		resolver := newRoleCredentialsResolver(t, AWSConfig{})
+		resolver.imdsEndpoint = imds.URL
+		value, err := resolver.Resolve(ctx, "aws-sm://prod/anthropic")
+		require.NoError(t, err)
+		assert.Equal(t, "from-role", value)
+	})
+}
+
+func TestResolveGCP(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gcp-token", r.Header.Get("Authorization"))
+		paths = append(paths, r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte("gcp-secret"))},
+		})
+	}))
+	defer server.Close()
+
+	resolver := NewResolver(AWSConfig{})
+	resolver.gcpEndpoint = server.URL
+	resolver.gcpTokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "gcp-token"})
+
+	value, err := resolver.Resolve(context.Background(), "gcp-sm://projects/alfred/secrets/encryption-key")
+	require.NoError(t, err)
+	assert.Equal(t, "gcp-secret", value)
+
+	_, err = resolver.Resolve(context.Background(), "gcp-sm://projects/alfred/secrets/encryption-key/versions/3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/v1/projects/alfred/secrets/encryption-key/versions/latest:access",
+		"/v1/projects/alfred/secrets/encryption-key/versions/3:access",
+	}, paths)
+
+	_, err = resolver.Resolve(context.Background(), "gcp-sm://encryption-key")
+	assert.ErrorContains(t, err, "projects/<project>/secrets/<name>")
+}
@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// accountDeletionTokenTTL is how long a user has to confirm account deletion
+const accountDeletionTokenTTL = 10 * time.Minute
+
+// getDataExportForUser loads the export in the {id} path value, writing an error
+// response and returning nil unless it belongs to userID
+func (s *Server) getDataExportForUser(w http.ResponseWriter, r *http.Request, userID int64) *database.DataExport {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return nil
+	}
+
+	export, err := s.db.GetDataExportByID(id)
+	if err != nil || export.UserID != userID {
+		respondError(w, http.StatusNotFound, "export not found")
+		return nil
+	}
+	return export
+}
+
+// handleCreateDataExport starts building an archive of all the user's data.
+// Progress is published on /api/stream and the user is notified when it's ready.
+func (s *Server) handleCreateDataExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if s.exporter == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "data export not available")
+		return
+	}
+
+	active, err := s.db.GetActiveDataExport(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if active != nil {
+		respondErrorDetails(w, http.StatusConflict, CodeExportInProgress, "an export is already in progress", map[string]any{
+			"export": active,
+		})
+		return
+	}
+
+	export, err := s.exporter.Start(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusAccepted, export)
+}
+
+// handleListDataExports returns the user's exports, newest first
+func (s *Server) handleListDataExports(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	exports, err := s.db.ListDataExports(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, exports)
+}
+
+// handleGetDataExport returns an export's status and progress
+func (s *Server) handleGetDataExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	export := s.getDataExportForUser(w, r, userID)
+	if export == nil {
+		return
+	}
+	respondJSON(w, http.StatusOK, export)
+}
+
+// handleDownloadDataExport streams a completed export's ZIP archive
+func (s *Server) handleDownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	export := s.getDataExportForUser(w, r, userID)
+	if export == nil {
+		return
+	}
+	if export.Status != database.DataExportCompleted {
+		respondError(w, http.StatusConflict, fmt.Sprintf("export is %s", export.Status))
+		return
+	}
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		respondErrorCode(w, http.StatusGone, CodeExportExpired, "export has expired")
+		return
+	}
+
+	archive, err := s.db.GetDataExportArchive(export.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("alfred-export-%s.zip", export.CreatedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
+
+func hashAccountDeletionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleCreateAccountDeletionToken issues the confirmation token DELETE /api/account requires.
+// Requesting a new token invalidates the previous one.
+func (s *Server) handleCreateAccountDeletionToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if auth.GetAPIKeyScope(r.Context()) != "" {
+		respondErrorCode(w, http.StatusForbidden, CodeAPIKeyNotAllowed, "api keys cannot delete accounts")
+		return
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to generate confirmation token")
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	expiresAt := time.Now().Add(accountDeletionTokenTTL)
+
+	if err := s.db.SetAccountDeletionToken(userID, hashAccountDeletionToken(token), expiresAt); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]any{
+		"confirmation_token": token,
+		"expires_at":         expiresAt,
+	})
+}
+
+// handleDeleteAccount permanently deletes the user's account: it logs out WhatsApp and
+// Telegram (removing their session files), revokes Google access, and deletes every
+// user-scoped row. Body: { "confirmation_token": "..." }
+func (s *Server) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if auth.GetAPIKeyScope(r.Context()) != "" {
+		respondErrorCode(w, http.StatusForbidden, CodeAPIKeyNotAllowed, "api keys cannot delete accounts")
+		return
+	}
+
+	var req struct {
+		ConfirmationToken string `json:"confirmation_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+	if req.ConfirmationToken == "" {
+		respondError(w, http.StatusBadRequest, "confirmation_token is required")
+		return
+	}
+
+	confirmed, err := s.db.ConsumeAccountDeletionToken(userID, hashAccountDeletionToken(req.ConfirmationToken), time.Now())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !confirmed {
+		respondError(w, http.StatusForbidden, "invalid or expired confirmation token")
+		return
+	}
+
+	// Stop background work before removing the data it reads
+	if s.userServiceManager != nil {
+		s.userServiceManager.StopServicesForUser(userID)
+	}
+
+	// Full WhatsApp/Telegram logout, deleting session files
+	if s.clientManager != nil {
+		if err := s.clientManager.ResetUserSessions(userID); err != nil {
+			slog.WarnContext(r.Context(), "Failed to reset sessions", "error", err)
+		}
+	}
+
+	// Revoke Alfred's access to the user's Google account
+	if token, err := s.db.GetGoogleToken(userID); err != nil {
+		slog.WarnContext(r.Context(), "Failed to load Google token", "error", err)
+	} else if token != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		if err := auth.RevokeGoogleToken(ctx, token); err != nil {
+			slog.WarnContext(r.Context(), "Failed to revoke Google token", "error", err)
+		}
+		cancel()
+	}
+
+	if err := s.db.DeleteUserAccount(userID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Account deleted")
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// maxMessageRetentionDays caps the retention window users can choose (10 years)
+const maxMessageRetentionDays = 3650
+
+// messageRetentionResponse reports the user's effective retention window
+func (s *Server) messageRetentionResponse(days *int) map[string]any {
+	effective := s.messageRetentionDays
+	if days != nil {
+		effective = *days
+	}
+	return map[string]any{
+		"message_retention_days": effective,
+		"is_default":             days == nil,
+		"default_days":           s.messageRetentionDays,
+	}
+}
+
+// handleGetMessageRetention returns how many days of messages are kept for the user.
+// 0 means messages are kept forever.
+func (s *Server) handleGetMessageRetention(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	days, err := s.db.GetMessageRetentionDays(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, s.messageRetentionResponse(days))
+}
+
+// handleUpdateMessageRetention sets the user's retention window. A null value resets it
+// to the server default. Messages an event or reminder was detected from are never pruned.
+func (s *Server) handleUpdateMessageRetention(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		MessageRetentionDays *int `json:"message_retention_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+	if days := req.MessageRetentionDays; days != nil && (*days < 0 || *days > maxMessageRetentionDays) {
+		respondInvalidParameter(w, "message_retention_days", 0, maxMessageRetentionDays, fmt.Sprintf("message_retention_days must be between 0 and %d", maxMessageRetentionDays))
+		return
+	}
+
+	if err := s.db.SetMessageRetentionDays(userID, req.MessageRetentionDays); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, s.messageRetentionResponse(req.MessageRetentionDays))
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/omriShneor/project_alfred/internal/backup"
+)
+
+// handleListBackups returns the stored database backups, newest first
+func (s *Server) handleListBackups(w http.ResponseWriter, r *http.Request) {
+	if s.backups == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "backups not configured")
+		return
+	}
+
+	backups, err := s.backups.List(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if backups == nil {
+		backups = []backup.Info{}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"store":   s.backups.Store().String(),
+		"backups": backups,
+	})
+}
+
+// handleCreateBackup snapshots the database now, outside the regular schedule
+func (s *Server) handleCreateBackup(w http.ResponseWriter, r *http.Request) {
+	if s.backups == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "backups not configured")
+		return
+	}
+
+	info, err := s.backups.Backup(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, info)
+}
+
+// handleRestoreBackup replaces the live database with the named backup. The current
+// database is backed up first; its name is returned so the restore can be undone.
+func (s *Server) handleRestoreBackup(w http.ResponseWriter, r *http.Request) {
+	if s.backups == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "backups not configured")
+		return
+	}
+
+	name := r.PathValue("name")
+	preRestore, err := s.backups.Restore(r.Context(), name)
+	switch {
+	case errors.Is(err, backup.ErrInvalidName):
+		respondError(w, http.StatusBadRequest, "invalid backup name")
+		return
+	case errors.Is(err, backup.ErrNotFound):
+		respondError(w, http.StatusNotFound, "backup not found")
+		return
+	case err != nil:
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status":             "restored",
+		"restored":           name,
+		"pre_restore_backup": preRestore,
+	})
+}
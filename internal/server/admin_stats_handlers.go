@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+const (
+	defaultAdminStatsDays = 30
+	maxAdminStatsDays     = 90
+)
+
+// AdminStatsResponse is the response of GET /api/admin/stats: the stored statistics plus
+// the LLM client's retries and circuit breaker since startup
+type AdminStatsResponse struct {
+	*database.AdminStats
+	LLMClient agent.ProviderStats `json:"llm_client"`
+}
+
+// handleAdminStats returns instance-wide usage, review, agent and queue statistics.
+// Optional days (1-90, default 30) sets the window, counted in whole UTC days ending today.
+func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	days := defaultAdminStatsDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxAdminStatsDays {
+			respondInvalidParameter(w, "days", 1, 90, "days must be between 1 and 90")
+			return
+		}
+		days = parsed
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	stats, err := s.db.GetAdminStats(today.AddDate(0, 0, -(days-1)), now)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, AdminStatsResponse{AdminStats: stats, LLMClient: agent.GetProviderStats(s.llmProvider)})
+}
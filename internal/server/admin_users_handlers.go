@@ -0,0 +1,161 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// AdminBackfillRequest is the optional body of POST /api/admin/users/{id}/backfill
+type AdminBackfillRequest struct {
+	Days int `json:"days"`
+}
+
+// adminUserID parses the {id} path value and checks the user exists, responding with
+// an error and returning false otherwise
+func (s *Server) adminUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid user ID")
+		return 0, false
+	}
+	if _, err := s.db.GetUserEmail(userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "user not found")
+			return 0, false
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return 0, false
+	}
+	return userID, true
+}
+
+// handleAdminListUsers returns every account with its onboarding state, active
+// sessions, API keys and tracked channels
+func (s *Server) handleAdminListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.db.ListAdminUsers(time.Now())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, users)
+}
+
+// handleAdminResetOnboarding resets another user's onboarding the same way the user's
+// own reset does: their clients are logged out and their data purged
+func (s *Server) handleAdminResetOnboarding(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.adminUserID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.resetUserOnboarding(r.Context(), userID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	slog.InfoContext(r.Context(), "Admin reset user onboarding", "target_user_id", userID)
+	respondJSON(w, http.StatusOK, map[string]any{"user_id": userID, "onboarding_complete": false})
+}
+
+// handleAdminRevokeTokens deletes every session and API key of a user
+func (s *Server) handleAdminRevokeTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.adminUserID(w, r)
+	if !ok {
+		return
+	}
+	sessions, apiKeys, err := s.db.RevokeUserTokens(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	slog.InfoContext(r.Context(), "Admin revoked user tokens",
+		"target_user_id", userID, "sessions", sessions, "api_keys", apiKeys)
+	respondJSON(w, http.StatusOK, map[string]any{"user_id": userID, "sessions": sessions, "api_keys": apiKeys})
+}
+
+// handleAdminBackfill starts a backfill of every enabled WhatsApp/Telegram channel over
+// the last days days (the default window if omitted) and of every enabled email source
+func (s *Server) handleAdminBackfill(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.adminUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req AdminBackfillRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+			return
+		}
+	}
+	if !validBackfillDays(req.Days) {
+		respondInvalidParameter(w, "days", 0, maxBackfillDays, fmt.Sprintf("days must be between 0 and %d", maxBackfillDays))
+		return
+	}
+
+	channels := 0
+	for _, sourceType := range []source.SourceType{source.SourceTypeWhatsApp, source.SourceTypeTelegram} {
+		list, err := s.db.ListSourceChannels(userID, sourceType)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, channel := range list {
+			if !channel.Enabled {
+				continue
+			}
+			s.startChannelBackfill(userID, channel, req.Days)
+			channels++
+		}
+	}
+
+	emailSources, err := s.db.ListEnabledEmailSources(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, emailSource := range emailSources {
+		s.startEmailSourceBackfill(userID, emailSource)
+	}
+
+	slog.InfoContext(r.Context(), "Admin started user backfill",
+		"target_user_id", userID, "channels", channels, "email_sources", len(emailSources))
+	respondJSON(w, http.StatusAccepted, map[string]any{
+		"user_id":       userID,
+		"channels":      channels,
+		"email_sources": len(emailSources),
+	})
+}
+
+// handleAdminExportUser builds a user's data export and returns the ZIP archive
+// directly, without storing it
+func (s *Server) handleAdminExportUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := s.adminUserID(w, r)
+	if !ok {
+		return
+	}
+	if s.exporter == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "data export not available")
+		return
+	}
+
+	archive, err := s.exporter.Build(userID, nil)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	slog.InfoContext(r.Context(), "Admin exported user data", "target_user_id", userID)
+
+	filename := fmt.Sprintf("alfred-export-user-%d-%s.zip", userID, time.Now().UTC().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleAdminListUsers(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUserWithEmail(t, s.db, "someone@example.com")
+
+	w := httptest.NewRecorder()
+	s.handleAdminListUsers(w, httptest.NewRequest(http.MethodGet, "/api/admin/users", nil))
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var users []database.AdminUser
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &users))
+	require.Len(t, users, 1)
+	assert.Equal(t, user.ID, users[0].ID)
+	assert.Equal(t, "someone@example.com", users[0].Email)
+}
+
+func TestHandleAdminRevokeTokens(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+	_, err := s.db.Exec(`INSERT INTO user_sessions (user_id, token_hash, expires_at) VALUES (?, 'hash', ?)`,
+		user.ID, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	revoke := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/users/"+id+"/revoke-tokens", nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		s.handleAdminRevokeTokens(w, req)
+		return w
+	}
+
+	w := revoke(strconv.FormatInt(user.ID, 10))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response map[string]int64
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response["sessions"])
+	assert.Equal(t, int64(0), response["api_keys"])
+
+	assert.Equal(t, http.StatusNotFound, revoke("99999").Code)
+	assert.Equal(t, http.StatusBadRequest, revoke("abc").Code)
+}
+
+func TestHandleAdminBackfillValidatesDays(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+	id := strconv.FormatInt(user.ID, 10)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/users/"+id+"/backfill", strings.NewReader(`{"days": 365}`))
+	req.SetPathValue("id", id)
+	w := httptest.NewRecorder()
+	s.handleAdminBackfill(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/users/"+id+"/backfill", nil)
+	req.SetPathValue("id", id)
+	w = httptest.NewRecorder()
+	s.handleAdminBackfill(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"user_id": `+id+`, "channels": 0, "email_sources": 0}`, w.Body.String())
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/timeutil"
+)
+
+const (
+	// maxAgendaRange bounds how far apart from and to may be
+	maxAgendaRange = 62 * 24 * time.Hour
+	// maxAgendaItems caps each source of the agenda
+	maxAgendaItems = 500
+)
+
+// Agenda item types
+const (
+	AgendaItemEvent    = "event"
+	AgendaItemReminder = "reminder"
+)
+
+// AgendaItem is one entry of the agenda feed. Type tells events from reminders;
+// reminders are placed at their due date and have no end time.
+type AgendaItem struct {
+	Type        string `json:"type"`   // "event" or "reminder"
+	ID          string `json:"id"`     // "alfred-12", "reminder-7", or the Google event ID
+	Source      string `json:"source"` // "alfred", "google", "outlook"
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Location    string `json:"location,omitempty"`
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time,omitempty"`
+	AllDay      bool   `json:"all_day"`
+	CalendarID  string `json:"calendar_id,omitempty"`
+	// EventID and ReminderID are the Alfred IDs for the event and reminder endpoints
+	EventID    *int64 `json:"event_id,omitempty"`
+	ReminderID *int64 `json:"reminder_id,omitempty"`
+	Priority   string `json:"priority,omitempty"`
+	Status     string `json:"status,omitempty"`
+
+	start time.Time
+}
+
+// AgendaResponse is the body of GET /api/agenda
+type AgendaResponse struct {
+	From  string       `json:"from"`
+	To    string       `json:"to"`
+	Items []AgendaItem `json:"items"`
+	// GoogleCalendar is "ok", "not_connected" or "unavailable" (the fetch failed and
+	// only Alfred items are listed)
+	GoogleCalendar string `json:"google_calendar"`
+}
+
+// handleGetAgenda merges confirmed Alfred events, Google Calendar events and active
+// reminders due in a time range into one feed ordered by start time.
+// Query: from, to (RFC 3339 or YYYY-MM-DD in the user's timezone; a date for to
+// includes that day). Defaults to today. calendar_id overrides the Google calendar.
+func (s *Server) handleGetAgenda(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	from, to, err := parseAgendaRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"), s.getUserTimezone(userID), time.Now())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items := []AgendaItem{}
+	seenGoogleIDs := make(map[string]bool)
+
+	events, err := s.db.ListEventsInRange(userID, from, to, maxAgendaItems)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, e := range events {
+		if e.Status == database.EventStatusPending {
+			// Awaiting review; the agenda only shows what the user accepted
+			continue
+		}
+		items = append(items, agendaEventItem(e))
+		if e.GoogleEventID != nil {
+			seenGoogleIDs[*e.GoogleEventID] = true
+		}
+	}
+
+	reminders, err := s.db.ListRemindersDueInRange(userID, from, to, maxAgendaItems)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, rem := range reminders {
+		items = append(items, agendaReminderItem(rem))
+	}
+
+	googleStatus := "not_connected"
+	if client := s.getGCalClientForUser(userID); client != nil && client.IsAuthenticated() {
+		calendarID := s.requestedCalendarID(r, userID)
+		gcalEvents, err := client.ListEventsInRange(calendarID, from, to)
+		if err != nil && calendarID != "primary" {
+			gcalEvents, err = client.ListEventsInRange("primary", from, to)
+		}
+		if err != nil {
+			slog.Warn("agenda: failed to list Google Calendar events", "user_id", userID, "error", err)
+			googleStatus = "unavailable"
+		} else {
+			googleStatus = "ok"
+			for _, ge := range gcalEvents {
+				if seenGoogleIDs[ge.ID] {
+					continue
+				}
+				item := AgendaItem{
+					Type:        AgendaItemEvent,
+					ID:          ge.ID,
+					Source:      "google",
+					Title:       ge.Summary,
+					Description: ge.Description,
+					Location:    ge.Location,
+					StartTime:   ge.StartTime.Format(time.RFC3339),
+					AllDay:      ge.AllDay,
+					CalendarID:  ge.CalendarID,
+					start:       ge.StartTime,
+				}
+				if ge.EndTime != nil {
+					item.EndTime = ge.EndTime.Format(time.RFC3339)
+				}
+				items = append(items, item)
+			}
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].start.Before(items[j].start)
+	})
+
+	respondJSON(w, http.StatusOK, AgendaResponse{
+		From:           from.Format(time.RFC3339),
+		To:             to.Format(time.RFC3339),
+		Items:          items,
+		GoogleCalendar: googleStatus,
+	})
+}
+
+func agendaEventItem(e database.CalendarEvent) AgendaItem {
+	endTime := e.StartTime.Add(time.Hour) // Default 1 hour duration
+	if e.EndTime != nil {
+		endTime = *e.EndTime
+	}
+	id := e.ID
+	return AgendaItem{
+		Type:        AgendaItemEvent,
+		ID:          fmt.Sprintf("alfred-%d", e.ID),
+		Source:      alfredEventSource(e),
+		Title:       e.Title,
+		Description: e.Description,
+		Location:    e.Location,
+		StartTime:   e.StartTime.Format(time.RFC3339),
+		EndTime:     endTime.Format(time.RFC3339),
+		CalendarID:  "alfred",
+		EventID:     &id,
+		Status:      string(e.Status),
+		start:       e.StartTime,
+	}
+}
+
+func agendaReminderItem(rem database.Reminder) AgendaItem {
+	id := rem.ID
+	return AgendaItem{
+		Type:        AgendaItemReminder,
+		ID:          fmt.Sprintf("reminder-%d", rem.ID),
+		Source:      "alfred",
+		Title:       rem.Title,
+		Description: rem.Description,
+		Location:    rem.Location,
+		StartTime:   rem.DueDate.Format(time.RFC3339),
+		ReminderID:  &id,
+		Priority:    string(rem.Priority),
+		Status:      string(rem.Status),
+		start:       *rem.DueDate,
+	}
+}
+
+// parseAgendaRange resolves the from/to query params in timezone. from defaults to the
+// start of today and to to the end of from's day; a date for to includes that day.
+func parseAgendaRange(rawFrom, rawTo, timezone string, now time.Time) (time.Time, time.Time, error) {
+	loc, _ := timeutil.ResolveLocation(timezone)
+	startOfDay := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+
+	from := startOfDay(now)
+	if rawFrom != "" {
+		t, _, err := parseDateOrTime(rawFrom, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+		from = t
+	}
+
+	to := startOfDay(from).AddDate(0, 0, 1)
+	if rawTo != "" {
+		t, isDate, err := parseDateOrTime(rawTo, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+		if isDate {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must be after from")
+	}
+	if to.Sub(from) > maxAgendaRange {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %d days", int(maxAgendaRange.Hours()/24))
+	}
+	return from, to, nil
+}
+
+// parseDateOrTime parses an RFC 3339 time or a YYYY-MM-DD date (midnight in loc),
+// reporting which it was
+func parseDateOrTime(raw string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// handleListMessageAnalyses returns every agent run on one of the user's messages,
+// oldest first: the intent module it ran for, the tools the model called, the module's
+// decision and the run's latency and tokens. Powers the "why did Alfred think this?" view.
+func (s *Server) handleListMessageAnalyses(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	msg, err := s.db.GetSourceMessageByID(userID, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg == nil {
+		respondError(w, http.StatusNotFound, "message not found")
+		return
+	}
+
+	entries, err := s.db.ListAnalysisLog(userID, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"analyses": entries})
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/auth"
+)
+
+const maxAPIKeyNameLength = 100
+
+// apiKeyCreatedResponse includes the plaintext key, which is only returned on creation
+type apiKeyCreatedResponse struct {
+	*auth.APIKey
+	Key string `json:"key"`
+}
+
+// requireSessionForAPIKeys rejects API key management from API key authenticated requests,
+// so a leaked key cannot mint further keys. Returns false after writing the error response.
+func (s *Server) requireSessionForAPIKeys(w http.ResponseWriter, r *http.Request) bool {
+	if s.authService == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
+		return false
+	}
+	if auth.GetAPIKeyScope(r.Context()) != "" {
+		respondErrorCode(w, http.StatusForbidden, CodeAPIKeyNotAllowed, "api keys cannot manage api keys")
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if !s.requireSessionForAPIKeys(w, r) {
+		return
+	}
+
+	keys, err := s.authService.ListAPIKeys(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, keys)
+}
+
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if !s.requireSessionForAPIKeys(w, r) {
+		return
+	}
+
+	var req struct {
+		Name  string           `json:"name"`
+		Scope auth.APIKeyScope `json:"scope"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLength {
+		respondError(w, http.StatusBadRequest, "name is required (max 100 characters)")
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = auth.APIKeyScopeRead
+	}
+	if !req.Scope.IsValid() {
+		respondError(w, http.StatusBadRequest, "scope must be read or read_write")
+		return
+	}
+
+	key, apiKey, err := s.authService.CreateAPIKey(userID, req.Name, req.Scope)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, apiKeyCreatedResponse{APIKey: apiKey, Key: key})
+}
+
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if !s.requireSessionForAPIKeys(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	revoked, err := s.authService.RevokeAPIKey(userID, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !revoked {
+		respondError(w, http.StatusNotFound, "api key not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
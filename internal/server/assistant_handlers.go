@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/processor"
+)
+
+const (
+	maxAssistantMessageLength = 2000
+	maxAssistantHistoryTurns  = 20
+)
+
+// AssistantChatRequest is a question for the assistant, with the earlier turns of the
+// conversation (oldest first). The server keeps no chat state; clients resend history.
+type AssistantChatRequest struct {
+	Message string           `json:"message"`
+	History []agent.ChatTurn `json:"history,omitempty"`
+}
+
+// handleAssistantChat answers a natural-language question about the user's schedule,
+// reminders and conversations ("what's on Thursday?") using the assistant's read-only tools
+func (s *Server) handleAssistantChat(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if s.assistant == nil || !s.assistant.IsConfigured() {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "assistant not configured")
+		return
+	}
+
+	var req AssistantChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		respondError(w, http.StatusBadRequest, "message is required")
+		return
+	}
+	if len(req.Message) > maxAssistantMessageLength {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("message must be at most %d characters", maxAssistantMessageLength))
+		return
+	}
+	if len(req.History) > maxAssistantHistoryTurns {
+		// Keep the most recent turns
+		req.History = req.History[len(req.History)-maxAssistantHistoryTurns:]
+	}
+	for _, turn := range req.History {
+		if turn.Role != "user" && turn.Role != "assistant" {
+			respondError(w, http.StatusBadRequest, "history role must be user or assistant")
+			return
+		}
+	}
+
+	ctx := processor.AnalysisContext(r.Context(), s.db, userID)
+	reply, err := s.assistant.Ask(ctx, req.Message, req.History)
+	if errors.Is(err, agent.ErrBudgetExceeded) {
+		respondErrorCode(w, http.StatusTooManyRequests, CodeLLMBudgetExceeded, "monthly LLM budget exceeded")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Assistant: failed to answer", "error", err)
+		respondError(w, http.StatusBadGateway, "assistant failed to answer")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, reply)
+}
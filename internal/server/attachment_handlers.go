@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/gmail"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// errAttachmentSourceUnavailable means the account holding an attachment isn't connected
+var errAttachmentSourceUnavailable = errors.New("attachment source not connected")
+
+// eventAttachment is an attachment of the message an event was detected from, as listed
+// in the event detail response
+type eventAttachment struct {
+	database.MessageAttachment
+	// ContentURL serves the file, fetched from the source on request
+	ContentURL string `json:"content_url"`
+}
+
+// eventAttachments lists the attachments of an event's trigger message
+func (s *Server) eventAttachments(event *database.CalendarEvent) []eventAttachment {
+	attachments, err := s.db.GetMessageAttachments(*event.OriginalMsgID)
+	if err != nil {
+		slog.Warn("failed to list event attachments", "event_id", event.ID, "error", err)
+		return nil
+	}
+
+	result := make([]eventAttachment, len(attachments))
+	for i, a := range attachments {
+		result[i] = eventAttachment{
+			MessageAttachment: a,
+			ContentURL:        fmt.Sprintf("/api/events/%d/attachments/%d", event.ID, a.ID),
+		}
+	}
+	return result
+}
+
+// handleGetEventAttachment serves an attachment of the message an event was detected
+// from. The file is downloaded from the account that received the message, so it is
+// only available while that account is connected and the source still keeps it.
+func (s *Server) handleGetEventAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+	attachmentID, err := strconv.ParseInt(r.PathValue("attachment_id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid attachment id")
+		return
+	}
+
+	event, err := s.db.GetEventByID(id)
+	if err != nil || event.UserID != userID {
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
+		return
+	}
+	if canView, _ := s.db.HasChannelAccess(userID, event.ChannelID); !canView || event.OriginalMsgID == nil {
+		respondError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+
+	attachment, err := s.db.GetMessageAttachment(attachmentID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if attachment == nil || attachment.MessageID != *event.OriginalMsgID {
+		respondError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	msg, err := s.db.GetMessageByID(attachment.MessageID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	data, err := s.downloadAttachment(r.Context(), msg.SourceType, attachment)
+	if errors.Is(err, errAttachmentSourceUnavailable) {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeSourceNotConnected, err.Error())
+		return
+	}
+	if err != nil {
+		slog.Warn("failed to download attachment", "attachment_id", attachment.ID, "error", err)
+		respondError(w, http.StatusBadGateway, "attachment is no longer available from the source")
+		return
+	}
+
+	contentType := attachment.MimeType
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	w.Header().Set("Content-Type", contentType)
+	if attachment.FileName != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename=%q`, attachment.FileName))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// downloadAttachment fetches an attachment's file through the account of the user who
+// received the message
+func (s *Server) downloadAttachment(ctx context.Context, sourceType source.SourceType, attachment *database.MessageAttachment) ([]byte, error) {
+	switch sourceType {
+	case source.SourceTypeWhatsApp:
+		if s.clientManager == nil {
+			return nil, errAttachmentSourceUnavailable
+		}
+		client, ok := s.clientManager.PeekWhatsAppClient(attachment.UserID)
+		if !ok || !client.IsLoggedIn() {
+			return nil, errAttachmentSourceUnavailable
+		}
+		return client.DownloadMedia(ctx, attachment.Kind, attachment.SourceRef)
+	case source.SourceTypeGmail:
+		client := s.getGmailClientForUser(attachment.UserID)
+		if client == nil {
+			return nil, errAttachmentSourceUnavailable
+		}
+		return client.GetAttachment(attachment.SourceRef)
+	}
+	return nil, fmt.Errorf("attachments from %s are not supported", sourceType)
+}
+
+// getGmailClientForUser creates a Gmail client from the user's Google credentials.
+// Returns nil if Google isn't connected.
+func (s *Server) getGmailClientForUser(userID int64) *gmail.Client {
+	gcalClient := s.getGCalClientForUser(userID)
+	if gcalClient == nil || !gcalClient.IsAuthenticated() {
+		return nil
+	}
+	client, err := gmail.NewClient(gcalClient.GetOAuthConfig(), gcalClient.GetToken())
+	if err != nil || !client.IsAuthenticated() {
+		return nil
+	}
+	return client
+}
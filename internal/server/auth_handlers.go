@@ -2,7 +2,9 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -64,13 +66,18 @@ func (s *Server) initAuth(cfg AuthConfig) error {
 	}
 
 	// Create auth service
-	authService, err := auth.NewService(s.db.DB, oauthConfig)
-	if err != nil {
+	var authService *auth.Service
+	if s.encryptor != nil {
+		authService = auth.NewServiceWithEncryptor(s.db.DB, oauthConfig, s.encryptor)
+	} else if authService, err = auth.NewService(s.db.DB, oauthConfig); err != nil {
 		return fmt.Errorf("failed to create auth service: %w", err)
 	}
 
 	s.authService = authService
 	s.authMiddleware = auth.NewMiddleware(authService)
+	s.authMiddleware.SetErrorResponder(func(w http.ResponseWriter, status int, code, message string) {
+		respondErrorCode(w, status, ErrorCode(code), message)
+	})
 
 	return nil
 }
@@ -80,7 +87,7 @@ func (s *Server) initAuth(cfg AuthConfig) error {
 // Body: { "redirect_uri": "..." } (optional)
 func (s *Server) handleAuthGoogleLogin(w http.ResponseWriter, r *http.Request) {
 	if s.authService == nil {
-		respondError(w, http.StatusServiceUnavailable, "authentication not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
 		return
 	}
 
@@ -89,42 +96,36 @@ func (s *Server) handleAuthGoogleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 	_ = json.NewDecoder(r.Body).Decode(&req) // Optional body
 
-	// Create OAuth config with profile scopes only
-	config := s.authService.GetOAuthConfig()
-
-	redirectURI := req.RedirectURI
-	if redirectURI == "" {
-		redirectURI = config.RedirectURL
-	}
-
-	modifiedConfig := &oauth2.Config{
-		ClientID:     config.ClientID,
-		ClientSecret: config.ClientSecret,
-		Endpoint:     config.Endpoint,
-		RedirectURL:  redirectURI,
-		Scopes:       auth.ProfileScopes, // Only profile scopes for login
+	// Only profile scopes for login
+	authURL, err := s.authService.StartOAuthFlow(auth.OAuthFlow{
+		Purpose:     auth.OAuthPurposeLogin,
+		RedirectURI: req.RedirectURI,
+	}, auth.ProfileScopes)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to start authorization")
+		return
 	}
 
-	authURL := modifiedConfig.AuthCodeURL("state", oauth2.AccessTypeOffline, oauth2.ApprovalForce)
-
 	respondJSON(w, http.StatusOK, map[string]string{"auth_url": authURL})
 }
 
-// handleAuthGoogleCallback handles the OAuth callback and creates a session
+// handleAuthGoogleCallback handles the OAuth callback and creates a session.
+// Returns a short-lived access token and a refresh token for POST /api/auth/refresh.
+// The state must be one issued by handleAuthGoogleLogin; each state is accepted once.
 // POST /api/auth/google/callback
-// Body: { "code": "...", "redirect_uri": "..." }
+// Body: { "code": "...", "state": "..." }
 func (s *Server) handleAuthGoogleCallback(w http.ResponseWriter, r *http.Request) {
 	if s.authService == nil {
-		respondError(w, http.StatusServiceUnavailable, "authentication not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
 		return
 	}
 
 	var req struct {
-		Code        string `json:"code"`
-		RedirectURI string `json:"redirect_uri"`
+		Code  string `json:"code"`
+		State string `json:"state"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
 		return
 	}
 
@@ -133,27 +134,29 @@ func (s *Server) handleAuthGoogleCallback(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	flow, err := s.authService.ConsumeOAuthFlow(req.State, auth.OAuthPurposeLogin, 0)
+	if err != nil {
+		respondOAuthStateError(w, err)
+		return
+	}
+
 	// Get device info from headers
 	deviceInfo := r.Header.Get("X-Device-Info")
 	if deviceInfo == "" {
 		deviceInfo = r.Header.Get("User-Agent")
 	}
 
-	// If a custom redirect URI was used, we need to create a temporary config
-	var user *auth.User
-	var sessionToken string
-	var err error
-
-	// Pass the redirect URI so the token exchange uses the same URI as the auth URL
-	user, sessionToken, err = s.authService.ExchangeCodeAndLogin(r.Context(), req.Code, deviceInfo, req.RedirectURI)
-
+	// The exchange uses the redirect URI and PKCE verifier stored with the state
+	client := auth.SessionClient{DeviceInfo: deviceInfo, IPAddress: clientIP(r)}
+	user, tokens, err := s.authService.ExchangeCodeAndLogin(r.Context(), req.Code, client, flow.RedirectURI,
+		flow.ExchangeOptions()...)
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "authentication failed: "+err.Error())
 		return
 	}
 
 	// Log user info for debugging
-	fmt.Printf("✅ User logged in - ID: %d, Email: %s, Google ID: %s\n", user.ID, user.Email, user.GoogleID)
+	slog.InfoContext(r.Context(), "User logged in", "user_id", user.ID, "email", user.Email, "google_id", user.GoogleID)
 
 	// Check if returning user has sources configured - start services immediately
 	if s.userServiceManager != nil {
@@ -164,7 +167,11 @@ func (s *Server) handleAuthGoogleCallback(w http.ResponseWriter, r *http.Request
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"session_token": sessionToken,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"expires_at":    tokens.ExpiresAt,
 		"user": map[string]interface{}{
 			"id":         user.ID,
 			"email":      user.Email,
@@ -175,44 +182,91 @@ func (s *Server) handleAuthGoogleCallback(w http.ResponseWriter, r *http.Request
 	})
 }
 
-// handleAuthLogout invalidates the current session
-// POST /api/auth/google/logout
-func (s *Server) handleAuthLogout(w http.ResponseWriter, r *http.Request) {
-	userID, err := getUserID(r)
-	if err != nil {
-		respondError(w, http.StatusUnauthorized, "authentication required")
+// handleAuthRefresh exchanges a refresh token for a new access and refresh token.
+// The presented refresh token stops working; reusing it revokes the session.
+// POST /api/auth/refresh
+// Body: { "refresh_token": "..." }
+func (s *Server) handleAuthRefresh(w http.ResponseWriter, r *http.Request) {
+	if s.authService == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
 		return
 	}
 
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		respondError(w, http.StatusBadRequest, "missing refresh token")
+		return
+	}
+
+	tokens, err := s.authService.RefreshSession(req.RefreshToken, clientIP(r))
+	if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrRefreshTokenReused) {
+		respondError(w, http.StatusUnauthorized, err.Error())
+		return
+	} else if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to refresh session")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+// handleAuthLogout revokes the current session. Accepts the access token as the
+// bearer token (expired tokens are fine) or the refresh token in the body.
+// POST /api/auth/google/logout
+// Body (optional): { "refresh_token": "..." }
+func (s *Server) handleAuthLogout(w http.ResponseWriter, r *http.Request) {
 	if s.authService == nil {
-		respondError(w, http.StatusServiceUnavailable, "authentication not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
 		return
 	}
 
-	// Get token from Authorization header
-	token := extractBearerToken(r)
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+			return
+		}
+	}
+
+	token := req.RefreshToken
+	if token == "" {
+		token = extractBearerToken(r)
+	}
 	if token == "" {
 		respondError(w, http.StatusBadRequest, "missing authorization token")
 		return
 	}
 
+	userID, err := s.authService.Logout(token)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to logout")
+		return
+	}
+	if userID == 0 {
+		respondError(w, http.StatusUnauthorized, "invalid or expired token")
+		return
+	}
+
 	// Cleanup all WhatsApp/Telegram clients for this user
-	if s.clientManager != nil && userID > 0 {
+	if s.clientManager != nil {
 		if err := s.clientManager.CleanupUser(userID); err != nil {
-			fmt.Printf("Warning: Failed to cleanup clients for user %d: %v\n", userID, err)
+			slog.WarnContext(r.Context(), "Failed to cleanup clients", "user_id", userID, "error", err)
 		}
 	}
 
 	// Stop user services
-	if s.userServiceManager != nil && userID > 0 {
+	if s.userServiceManager != nil {
 		s.userServiceManager.StopServicesForUser(userID)
 	}
 
-	if err := s.authService.Logout(token); err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to logout")
-		return
-	}
-
 	respondJSON(w, http.StatusOK, map[string]string{"status": "logged_out"})
 }
 
@@ -244,7 +298,7 @@ func (s *Server) handleUpdateAuthMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if s.authService == nil {
-		respondError(w, http.StatusServiceUnavailable, "authentication not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
 		return
 	}
 
@@ -252,7 +306,7 @@ func (s *Server) handleUpdateAuthMe(w http.ResponseWriter, r *http.Request) {
 		Timezone string `json:"timezone"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
 		return
 	}
 
@@ -287,11 +341,12 @@ func (s *Server) handleUpdateAuthMe(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleAuthOAuthCallback handles the OAuth callback from Google (browser redirect)
-// Google redirects here with ?code=..., then we redirect to the mobile app's deep link
+// Google redirects here with ?code=...&state=..., then we redirect to the mobile app's deep link
 // This allows using a standard http(s) URL as the Google OAuth redirect URI
-// GET /api/auth/callback?code=...
+// GET /api/auth/callback?code=...&state=...
 func (s *Server) handleAuthOAuthCallback(w http.ResponseWriter, r *http.Request) {
 	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
 	errorParam := r.URL.Query().Get("error")
 
 	// Deep link URL for the mobile app
@@ -312,8 +367,8 @@ func (s *Server) handleAuthOAuthCallback(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Redirect to mobile app with the auth code
-	redirectURL := fmt.Sprintf("%s?code=%s", deepLinkBase, url.QueryEscape(code))
+	// Redirect to mobile app with the auth code and the state it must be redeemed with
+	redirectURL := fmt.Sprintf("%s?code=%s&state=%s", deepLinkBase, url.QueryEscape(code), url.QueryEscape(state))
 
 	// Simple HTTP redirect - no HTML page needed
 	http.Redirect(w, r, redirectURL, http.StatusFound)
@@ -324,7 +379,13 @@ func (s *Server) handleAuthOAuthCallback(w http.ResponseWriter, r *http.Request)
 // Body: { "scopes": ["gmail"] } or { "scopes": ["calendar"] }, "redirect_uri": "..." }
 func (s *Server) handleRequestAdditionalScopes(w http.ResponseWriter, r *http.Request) {
 	if s.authService == nil {
-		respondError(w, http.StatusServiceUnavailable, "authentication not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
 
@@ -333,7 +394,7 @@ func (s *Server) handleRequestAdditionalScopes(w http.ResponseWriter, r *http.Re
 		RedirectURI string   `json:"redirect_uri"` // Optional custom redirect
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
 		return
 	}
 
@@ -356,38 +417,29 @@ func (s *Server) handleRequestAdditionalScopes(w http.ResponseWriter, r *http.Re
 		}
 	}
 
-	// Use custom redirect URI if provided, otherwise use default
-	redirectURI := req.RedirectURI
-	if redirectURI == "" {
-		redirectURI = s.authService.GetOAuthConfig().RedirectURL
-	}
-
-	// Create OAuth config with custom redirect and incremental scopes
-	config := s.authService.GetOAuthConfig()
-	modifiedConfig := &oauth2.Config{
-		ClientID:     config.ClientID,
-		ClientSecret: config.ClientSecret,
-		Endpoint:     config.Endpoint,
-		RedirectURL:  redirectURI,
-		Scopes:       requestedScopes,
-	}
-
 	// Generate incremental auth URL with include_granted_scopes=true
-	authURL := modifiedConfig.AuthCodeURL("state",
-		oauth2.AccessTypeOffline,
-		oauth2.ApprovalForce,
-		oauth2.SetAuthURLParam("include_granted_scopes", "true"),
-	)
+	authURL, err := s.authService.StartOAuthFlow(auth.OAuthFlow{
+		Purpose:     auth.OAuthPurposeAddScopes,
+		UserID:      userID,
+		RedirectURI: req.RedirectURI,
+		ScopeNames:  req.Scopes,
+	}, requestedScopes, oauth2.SetAuthURLParam("include_granted_scopes", "true"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to start authorization")
+		return
+	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"auth_url": authURL})
 }
 
-// handleAddScopesCallback handles the OAuth callback for incremental authorization
+// handleAddScopesCallback handles the OAuth callback for incremental authorization.
+// The state must be one the same user got from handleRequestAdditionalScopes; the scopes
+// granted are the ones requested there.
 // POST /api/auth/google/add-scopes/callback
-// Body: { "code": "...", "redirect_uri": "...", "scopes": ["gmail"] }
+// Body: { "code": "...", "state": "..." }
 func (s *Server) handleAddScopesCallback(w http.ResponseWriter, r *http.Request) {
 	if s.authService == nil {
-		respondError(w, http.StatusServiceUnavailable, "authentication not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
 		return
 	}
 
@@ -402,12 +454,11 @@ func (s *Server) handleAddScopesCallback(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req struct {
-		Code        string   `json:"code"`
-		RedirectURI string   `json:"redirect_uri"`
-		Scopes      []string `json:"scopes"` // "gmail" or "calendar"
+		Code  string `json:"code"`
+		State string `json:"state"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
 		return
 	}
 
@@ -416,9 +467,15 @@ func (s *Server) handleAddScopesCallback(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	flow, err := s.authService.ConsumeOAuthFlow(req.State, auth.OAuthPurposeAddScopes, userID)
+	if err != nil {
+		respondOAuthStateError(w, err)
+		return
+	}
+
 	// Map scope names to actual OAuth scopes
 	var newScopes []string
-	for _, scope := range req.Scopes {
+	for _, scope := range flow.ScopeNames {
 		switch scope {
 		case "gmail":
 			newScopes = append(newScopes, auth.GmailScopes...)
@@ -428,12 +485,12 @@ func (s *Server) handleAddScopesCallback(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Exchange code and add scopes
-	if err := s.authService.ExchangeCodeAndAddScopes(r.Context(), userID, req.Code, newScopes); err != nil {
+	if err := s.authService.ExchangeCodeAndAddScopes(r.Context(), userID, req.Code, newScopes, flow.ExchangeOptions()...); err != nil {
 		respondError(w, http.StatusBadRequest, "failed to add scopes: "+err.Error())
 		return
 	}
 
-	for _, scope := range req.Scopes {
+	for _, scope := range flow.ScopeNames {
 		if scope == "gmail" {
 			_ = s.db.SetGmailEnabled(userID, true)
 			break
@@ -443,7 +500,7 @@ func (s *Server) handleAddScopesCallback(w http.ResponseWriter, r *http.Request)
 	// Start services so Gmail worker can refresh top contacts immediately after auth
 	if s.userServiceManager != nil {
 		needsBackgroundServices := false
-		for _, scope := range req.Scopes {
+		for _, scope := range flow.ScopeNames {
 			if scope == "gmail" || scope == "calendar" {
 				needsBackgroundServices = true
 				break
@@ -457,6 +514,15 @@ func (s *Server) handleAddScopesCallback(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, map[string]string{"status": "scopes_added"})
 }
 
+// respondOAuthStateError reports a callback whose state could not be redeemed
+func respondOAuthStateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, auth.ErrInvalidOAuthState) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondError(w, http.StatusInternalServerError, "failed to verify authorization state")
+}
+
 // extractBearerToken extracts the token from the Authorization header
 func extractBearerToken(r *http.Request) string {
 	authHeader := r.Header.Get("Authorization")
@@ -470,3 +536,10 @@ func extractBearerToken(r *http.Request) string {
 	}
 	return ""
 }
+
+// googleReauthRequired reports whether the background token refresh found the user's
+// Google access revoked or expired
+func (s *Server) googleReauthRequired(userID int64) bool {
+	info, err := s.db.GetGoogleTokenInfo(userID)
+	return err == nil && info.ReauthRequiredAt != nil
+}
@@ -470,6 +470,90 @@ func TestHandleAddScopesCallback(t *testing.T) {
 	})
 }
 
+func TestHandleAuthRefresh(t *testing.T) {
+	s := createTestServerWithAuth(t)
+	user := database.CreateTestUser(t, s.db)
+
+	tokens, err := s.authService.CreateSession(user.ID, auth.SessionClient{DeviceInfo: "test"})
+	require.NoError(t, err)
+
+	refresh := func(refreshToken string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+		req := httptest.NewRequest("POST", "/api/auth/refresh", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		s.handleAuthRefresh(w, req)
+		return w
+	}
+
+	t.Run("returns a rotated token pair", func(t *testing.T) {
+		w := refresh(tokens.RefreshToken)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response auth.TokenPair
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.AccessToken)
+		assert.NotEqual(t, tokens.RefreshToken, response.RefreshToken)
+		assert.Equal(t, "Bearer", response.TokenType)
+
+		validated, err := s.authService.ValidateAccessToken(response.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, validated.ID)
+	})
+
+	t.Run("rejects a reused refresh token", func(t *testing.T) {
+		w := refresh(tokens.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("rejects a missing refresh token", func(t *testing.T) {
+		w := refresh("")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestHandleAuthLogout(t *testing.T) {
+	s := createTestServerWithAuth(t)
+	user := database.CreateTestUser(t, s.db)
+
+	t.Run("revokes the session of the bearer access token", func(t *testing.T) {
+		tokens, err := s.authService.CreateSession(user.ID, auth.SessionClient{DeviceInfo: "test"})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/auth/google/logout", nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		w := httptest.NewRecorder()
+		s.handleAuthLogout(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		_, err = s.authService.ValidateAccessToken(tokens.AccessToken)
+		assert.Error(t, err)
+		_, err = s.authService.RefreshSession(tokens.RefreshToken, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("revokes the session of a refresh token in the body", func(t *testing.T) {
+		tokens, err := s.authService.CreateSession(user.ID, auth.SessionClient{DeviceInfo: "test"})
+		require.NoError(t, err)
+
+		body, _ := json.Marshal(map[string]string{"refresh_token": tokens.RefreshToken})
+		req := httptest.NewRequest("POST", "/api/auth/google/logout", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		s.handleAuthLogout(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		_, err = s.authService.ValidateAccessToken(tokens.AccessToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unknown tokens", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/auth/google/logout", nil)
+		req.Header.Set("Authorization", "Bearer unknown-token")
+		w := httptest.NewRecorder()
+		s.handleAuthLogout(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
 func TestExtractBearerToken(t *testing.T) {
 	t.Run("extracts valid bearer token", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/", nil)
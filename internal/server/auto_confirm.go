@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// autoConfirmer lets the processors confirm detections above the user's auto-confirm
+// threshold through the same path as the confirm endpoints, including calendar sync
+type autoConfirmer struct {
+	s *Server
+}
+
+// ConfirmEvent confirms a pending event, reloading it so its attendees are synced too
+func (a autoConfirmer) ConfirmEvent(_ context.Context, event *database.CalendarEvent) error {
+	current, err := a.s.db.GetEventByID(event.ID)
+	if err != nil {
+		return err
+	}
+	_, err = a.s.confirmPendingEvent(current.UserID, current, false)
+	return err
+}
+
+// ConfirmReminder confirms a pending reminder
+func (a autoConfirmer) ConfirmReminder(_ context.Context, reminder *database.Reminder) error {
+	current, err := a.s.db.GetReminderByID(reminder.ID)
+	if err != nil {
+		return err
+	}
+	_, err = a.s.confirmPendingReminder(current.UserID, current)
+	return err
+}
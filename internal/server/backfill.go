@@ -2,51 +2,162 @@ package server
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/gmail"
 	"github.com/omriShneor/project_alfred/internal/processor"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/sse"
 )
 
 const (
 	backfillWindowDays = 10
+	maxBackfillDays    = 90
 	backfillMaxEmails  = 200
 )
 
-func (s *Server) startChannelBackfill(userID int64, channel *database.SourceChannel) {
+// hasMessageAnalyzers reports whether any analyzer is configured for chat messages
+func (s *Server) hasMessageAnalyzers() bool {
+	return s.eventAnalyzer != nil || s.reminderAnalyzer != nil || s.travelAnalyzer != nil || s.billAnalyzer != nil || s.occasionAnalyzer != nil
+}
+
+// newBackfillProcessor builds a processor that analyzes stored chat history with the
+// same intent modules as the live processor
+func (s *Server) newBackfillProcessor() *processor.BackfillProcessor {
+	backfillProc := processor.NewBackfillProcessor(s.db, s.eventAnalyzer, s.reminderAnalyzer, s.notifyService)
+	registerTravelIntent(s.travelAnalyzer, s.db, backfillProc.RegisterIntentModule)
+	registerBillIntent(s.billAnalyzer, s.db, backfillProc.RegisterIntentModule)
+	registerOccasionIntent(s.occasionAnalyzer, s.db, backfillProc.RegisterIntentModule)
+	backfillProc.SetAutoConfirmer(autoConfirmer{s: s})
+	return backfillProc
+}
+
+// validBackfillDays reports whether days is an allowed lookback for a channel's initial
+// backfill; 0 means the default window
+func validBackfillDays(days int) bool {
+	return days >= 0 && days <= maxBackfillDays
+}
+
+// startChannelBackfill analyzes the channel's stored messages from the last days days
+// (the default window if 0) in the background, recording progress on the channel and
+// publishing it on the user's stream
+func (s *Server) startChannelBackfill(userID int64, channel *database.SourceChannel, days int) {
 	if s == nil || s.db == nil || channel == nil {
 		return
 	}
-
-	if s.eventAnalyzer == nil && s.reminderAnalyzer == nil {
-		_ = s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusSkipped)
+	if !s.hasMessageAnalyzers() {
+		s.runChannelBackfill(userID, channel, days)
 		return
 	}
+	go s.runChannelBackfill(userID, channel, days)
+}
 
+// startChannelBackfills backfills several channels one after another in the background,
+// so a bulk import doesn't analyze every channel's history at once
+func (s *Server) startChannelBackfills(userID int64, channels []*database.SourceChannel, days int) {
+	if s == nil || s.db == nil || len(channels) == 0 {
+		return
+	}
+	if !s.hasMessageAnalyzers() {
+		for _, channel := range channels {
+			s.runChannelBackfill(userID, channel, days)
+		}
+		return
+	}
 	go func() {
-		if err := s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusInProgress); err != nil {
-			fmt.Printf("Backfill: failed to mark channel in progress: %v\n", err)
+		for _, channel := range channels {
+			s.runChannelBackfill(userID, channel, days)
 		}
+	}()
+}
 
-		since := time.Now().Add(-backfillWindowDays * 24 * time.Hour)
-		messages, err := s.db.GetSourceMessagesSince(userID, channel.SourceType, channel.ID, since)
-		if err != nil {
-			fmt.Printf("Backfill: failed to load message history for channel %d: %v\n", channel.ID, err)
-			_ = s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusFailed)
-			return
-		}
+// runChannelBackfill is the body of startChannelBackfill
+func (s *Server) runChannelBackfill(userID int64, channel *database.SourceChannel, days int) {
+	if days <= 0 || days > maxBackfillDays {
+		days = backfillWindowDays
+	}
 
-		backfillProc := processor.NewBackfillProcessor(s.db, s.eventAnalyzer, s.reminderAnalyzer, s.notifyService)
-		if err := backfillProc.ProcessChannelMessages(context.Background(), userID, channel.ID, channel.SourceType, messages); err != nil {
-			fmt.Printf("Backfill: failed to process history for channel %d: %v\n", channel.ID, err)
-			_ = s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusFailed)
-			return
+	if !s.hasMessageAnalyzers() {
+		_ = s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusSkipped)
+		s.publishChannelBackfillProgress(userID, channel)
+		return
+	}
+
+	if err := s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusInProgress); err != nil {
+		slog.Error("Backfill: failed to mark channel in progress", "error", err)
+	}
+
+	since := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	messages, err := s.db.GetSourceMessagesSince(userID, channel.SourceType, channel.ID, since)
+	if err != nil {
+		slog.Error("Backfill: failed to load message history", "channel_id", channel.ID, "error", err)
+		_ = s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusFailed)
+		s.publishChannelBackfillProgress(userID, channel)
+		return
+	}
+	if err := s.db.StartChannelBackfillProgress(userID, channel.ID, days, len(messages)); err != nil {
+		slog.Error("Backfill: failed to record progress", "channel_id", channel.ID, "error", err)
+	}
+	s.publishChannelBackfillProgress(userID, channel)
+
+	backfillProc := s.newBackfillProcessor()
+	lastPercent := 0
+	backfillProc.SetProgressFunc(func(processed, total int) {
+		if err := s.db.UpdateChannelBackfillProgress(userID, channel.ID, processed); err != nil {
+			slog.Error("Backfill: failed to record progress", "channel_id", channel.ID, "error", err)
 		}
+		// The last message is reported with the completed status below
+		if percent := database.ProgressPercent(processed, total); percent != lastPercent && processed < total {
+			lastPercent = percent
+			s.publishChannelBackfillProgress(userID, channel)
+		}
+	})
+	if err := backfillProc.ProcessChannelMessages(agent.WithBackgroundPriority(context.Background()), userID, channel.ID, channel.SourceType, messages); err != nil {
+		slog.Error("Backfill: failed to process history", "channel_id", channel.ID, "error", err)
+		_ = s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusFailed)
+		s.publishChannelBackfillProgress(userID, channel)
+		return
+	}
 
-		_ = s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusCompleted)
-	}()
+	_ = s.db.UpdateChannelInitialBackfillStatus(userID, channel.ID, database.BackfillStatusCompleted)
+	s.publishChannelBackfillProgress(userID, channel)
+}
+
+// channelBackfillUpdate is the payload of backfill_progress stream updates
+type channelBackfillUpdate struct {
+	ChannelID  int64             `json:"channel_id"`
+	SourceType source.SourceType `json:"source_type"`
+	*database.BackfillProgress
+}
+
+// publishChannelBackfillProgress sends the channel's current backfill progress to the
+// user's stream
+func (s *Server) publishChannelBackfillProgress(userID int64, channel *database.SourceChannel) {
+	if s.streams == nil {
+		return
+	}
+	progress, err := s.db.GetChannelBackfillProgress(userID, channel.ID)
+	if err != nil || progress == nil {
+		return
+	}
+	update := channelBackfillUpdate{ChannelID: channel.ID, SourceType: channel.SourceType, BackfillProgress: progress}
+	if err := s.streams.Publish(userID, sse.UpdateBackfillProgress, update); err != nil {
+		slog.Error("Backfill: failed to publish progress", "error", err)
+	}
+}
+
+// publishHistorySyncProgress sends a conversation's HistorySync checkpoint to the
+// user's stream
+func (s *Server) publishHistorySyncProgress(userID int64, checkpoint *database.HistorySyncCheckpoint) {
+	if s.streams == nil {
+		return
+	}
+	if err := s.streams.Publish(userID, sse.UpdateHistorySyncProgress, checkpoint); err != nil {
+		slog.Error("HistorySync: failed to publish progress", "error", err)
+	}
 }
 
 func (s *Server) startEmailSourceBackfill(userID int64, source *database.EmailSource) {
@@ -61,11 +172,11 @@ func (s *Server) startEmailSourceBackfill(userID int64, source *database.EmailSo
 
 	go func() {
 		if err := s.db.UpdateEmailSourceInitialBackfillStatus(userID, source.ID, database.BackfillStatusInProgress); err != nil {
-			fmt.Printf("Backfill: failed to mark email source in progress: %v\n", err)
+			slog.Error("Backfill: failed to mark email source in progress", "error", err)
 		}
 
 		if err := s.userServiceManager.StartServicesForUser(userID); err != nil {
-			fmt.Printf("Backfill: failed to start services for user %d: %v\n", userID, err)
+			slog.Error("Backfill: failed to start services", "user_id", userID, "error", err)
 		}
 
 		worker := s.userServiceManager.GetGmailWorkerForUser(userID)
@@ -85,8 +196,8 @@ func (s *Server) startEmailSourceBackfill(userID int64, source *database.EmailSo
 		}
 
 		since := time.Now().Add(-backfillWindowDays * 24 * time.Hour)
-		if _, err := worker.BackfillSource(context.Background(), gmailSource, since, backfillMaxEmails); err != nil {
-			fmt.Printf("Backfill: failed to backfill email source %d: %v\n", source.ID, err)
+		if _, err := worker.BackfillSource(agent.WithBackgroundPriority(context.Background()), gmailSource, since, backfillMaxEmails); err != nil {
+			slog.Error("Backfill: failed to backfill email source", "source_id", source.ID, "error", err)
 			_ = s.db.UpdateEmailSourceInitialBackfillStatus(userID, source.ID, database.BackfillStatusFailed)
 			return
 		}
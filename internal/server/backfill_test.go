@@ -14,6 +14,7 @@ import (
 	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/sse"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -84,7 +85,7 @@ func TestStartChannelBackfill_SkippedWithoutAnalyzers(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	s.startChannelBackfill(user.ID, channel)
+	s.startChannelBackfill(user.ID, channel, 0)
 
 	status, at := getChannelInitialBackfillStatus(t, s.db, channel.ID)
 	require.True(t, status.Valid)
@@ -119,12 +120,101 @@ func TestStartChannelBackfill_CompletesAndAnalyzesMessages(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	s.startChannelBackfill(user.ID, channel)
+	s.startChannelBackfill(user.ID, channel, 0)
 	waitForChannelBackfillStatus(t, s.db, channel.ID, database.BackfillStatusCompleted)
 
 	assert.Equal(t, int64(1), analyzer.calls.Load())
 }
 
+func TestStartChannelBackfill_LookbackAndProgress(t *testing.T) {
+	s := createTestServer(t)
+	s.db.SetMaxOpenConns(1)
+	analyzer := &countingBackfillEventAnalyzer{}
+	s.eventAnalyzer = analyzer
+
+	user := database.CreateTestUser(t, s.db)
+	channel, err := s.db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeWhatsApp,
+		source.ChannelTypeSender,
+		"lookback-backfill@s.whatsapp.net",
+		"Lookback Backfill",
+	)
+	require.NoError(t, err)
+
+	// Only the first two are inside a 30 day lookback; the default window would see one
+	for _, age := range []time.Duration{2 * time.Hour, 20 * 24 * time.Hour, 45 * 24 * time.Hour} {
+		_, err = s.db.StoreSourceMessage(
+			source.SourceTypeWhatsApp,
+			channel.ID,
+			"sender@s.whatsapp.net",
+			"Sender",
+			"hello from history",
+			"",
+			time.Now().Add(-age),
+		)
+		require.NoError(t, err)
+	}
+
+	updates := s.streams.Subscribe(user.ID)
+	defer s.streams.Unsubscribe(user.ID, updates)
+
+	s.startChannelBackfill(user.ID, channel, 30)
+	waitForChannelBackfillStatus(t, s.db, channel.ID, database.BackfillStatusCompleted)
+	assert.Equal(t, int64(2), analyzer.calls.Load())
+
+	var percents []int
+	for len(percents) < 3 {
+		select {
+		case update := <-updates:
+			require.Equal(t, sse.UpdateBackfillProgress, update.Type)
+			var payload channelBackfillUpdate
+			require.NoError(t, json.Unmarshal([]byte(update.Data), &payload))
+			assert.Equal(t, channel.ID, payload.ChannelID)
+			assert.Equal(t, 30, payload.Days)
+			assert.Equal(t, 2, payload.Total)
+			percents = append(percents, payload.Percent)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected 3 progress updates, got %v", percents)
+		}
+	}
+	assert.Equal(t, []int{0, 50, 100}, percents)
+
+	req := httptest.NewRequest("GET", "/api/channels/"+strconv.FormatInt(channel.ID, 10)+"/backfill", nil)
+	req.SetPathValue("id", strconv.FormatInt(channel.ID, 10))
+	req = withAuthContext(req, user)
+	w := httptest.NewRecorder()
+	s.handleGetChannelBackfill(w, req)
+	require.Equal(t, 200, w.Code)
+
+	var progress channelBackfillUpdate
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &progress))
+	assert.Equal(t, database.BackfillStatusCompleted, progress.Status)
+	assert.Equal(t, 2, progress.Processed)
+	assert.Equal(t, 100, progress.Percent)
+	assert.NotNil(t, progress.CompletedAt)
+}
+
+func TestHandleCreateWhatsappChannel_RejectsInvalidBackfillDays(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	body, err := json.Marshal(map[string]any{
+		"type":          "sender",
+		"identifier":    "too-far@s.whatsapp.net",
+		"name":          "Too Far",
+		"backfill_days": maxBackfillDays + 1,
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/whatsapp/channel", bytes.NewReader(body))
+	req = withAuthContext(req, user)
+	w := httptest.NewRecorder()
+	s.handleCreateWhatsappChannel(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
+
 func TestHandleCreateWhatsappChannel_DisableAndReEnableTriggersBackfillWithPreservedHistory(t *testing.T) {
 	s := createTestServer(t)
 	s.db.SetMaxOpenConns(1)
@@ -225,3 +315,68 @@ func TestHandleWhatsAppCustomSource_StartsBackfill(t *testing.T) {
 	waitForChannelBackfillStatus(t, s.db, channel.ID, database.BackfillStatusCompleted)
 	assert.Equal(t, int64(0), analyzer.calls.Load(), "no historical messages means no analyzer calls")
 }
+
+func TestHandleReprocessChannel(t *testing.T) {
+	s := createTestServer(t)
+	s.db.SetMaxOpenConns(1)
+	analyzer := &countingBackfillEventAnalyzer{}
+	s.eventAnalyzer = analyzer
+
+	user := database.CreateTestUser(t, s.db)
+	channel, err := s.db.CreateSourceChannel(
+		user.ID,
+		source.SourceTypeWhatsApp,
+		source.ChannelTypeSender,
+		"reprocess@s.whatsapp.net",
+		"Reprocess Contact",
+	)
+	require.NoError(t, err)
+	for _, age := range []time.Duration{72 * time.Hour, 2 * time.Hour} {
+		_, err = s.db.StoreSourceMessage(
+			source.SourceTypeWhatsApp,
+			channel.ID,
+			"sender@s.whatsapp.net",
+			"Sender",
+			"message from "+age.String()+" ago",
+			"",
+			time.Now().Add(-age),
+		)
+		require.NoError(t, err)
+	}
+
+	reprocess := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/channels/"+strconv.FormatInt(channel.ID, 10)+"/reprocess"+query, nil)
+		req.SetPathValue("id", strconv.FormatInt(channel.ID, 10))
+		req = withAuthContext(req, user)
+		w := httptest.NewRecorder()
+		s.handleReprocessChannel(w, req)
+		return w
+	}
+
+	t.Run("re-runs analysis on messages since the given time", func(t *testing.T) {
+		since := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+		w := reprocess("?since=" + since)
+		require.Equal(t, 202, w.Code)
+
+		var resp struct {
+			Messages int `json:"messages"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Messages)
+		require.Eventually(t, func() bool {
+			_, running := s.reprocessing.Load(channel.ID)
+			return !running
+		}, 2*time.Second, 20*time.Millisecond)
+		assert.Equal(t, int64(1), analyzer.calls.Load())
+	})
+
+	t.Run("rejects an invalid since", func(t *testing.T) {
+		assert.Equal(t, 400, reprocess("?since=yesterday").Code)
+		assert.Equal(t, 400, reprocess("?since="+time.Now().Add(time.Hour).UTC().Format(time.RFC3339)).Code)
+	})
+
+	t.Run("refuses channels with analysis off", func(t *testing.T) {
+		require.NoError(t, s.db.UpdateChannelAnalysisMode(user.ID, channel.ID, database.AnalysisModeNone))
+		assert.Equal(t, 409, reprocess("").Code)
+	})
+}
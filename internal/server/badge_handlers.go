@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// MarkNotificationsReadRequest is the body of POST /api/notifications/read. Without IDs
+// every delivered push is marked read.
+type MarkNotificationsReadRequest struct {
+	IDs []int64 `json:"ids" validate:"max=500"`
+}
+
+// handleGetPendingCount returns the counts behind the app icon badge: pending events,
+// pending reminders and unread notifications
+func (s *Server) handleGetPendingCount(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	counts, err := s.db.GetPendingCounts(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, counts)
+}
+
+// handleMarkNotificationsRead marks delivered pushes read, typically when the user opens
+// one or the app, and returns the updated pending counts
+func (s *Server) handleMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req MarkNotificationsReadRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if _, err := s.db.MarkNotificationsRead(userID, req.IDs, time.Now()); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	counts, err := s.db.GetPendingCounts(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, counts)
+}
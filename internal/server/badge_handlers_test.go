@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingCount(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	channel, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "alice", "Alice")
+	require.NoError(t, err)
+	_, err = s.db.CreatePendingEvent(&database.CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Dentist",
+		StartTime:  time.Now(),
+		ActionType: database.EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	var pushIDs []int64
+	for _, kind := range []string{"digest", "reminder_due"} {
+		id, err := s.db.EnqueueNotification(database.QueuedNotification{
+			UserID:  user.ID,
+			Channel: database.NotificationChannelPush,
+			Kind:    kind,
+			Title:   "Alfred",
+		}, time.Now())
+		require.NoError(t, err)
+		require.NoError(t, s.db.RecordNotificationAttempt(id, 1, database.NotificationSent, "", nil))
+		pushIDs = append(pushIDs, id)
+	}
+
+	decode := func(w *httptest.ResponseRecorder) database.PendingCounts {
+		var counts database.PendingCounts
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &counts))
+		return counts
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/pending-count", nil)
+	w := httptest.NewRecorder()
+	s.handleGetPendingCount(w, withAuthContext(req, user))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, database.PendingCounts{PendingEvents: 1, UnreadNotifications: 2, Total: 3}, decode(w))
+
+	markRead := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/notifications/read", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.handleMarkNotificationsRead(w, withAuthContext(req, user))
+		return w
+	}
+
+	w = markRead(`{"ids": [` + strconv.FormatInt(pushIDs[0], 10) + `]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 1, decode(w).UnreadNotifications)
+
+	w = markRead(``)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, database.PendingCounts{PendingEvents: 1, Total: 1}, decode(w))
+}
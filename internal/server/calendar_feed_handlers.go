@@ -0,0 +1,174 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/ics"
+)
+
+const (
+	// calendarFeedHistory is how far back ended events stay in the feed
+	calendarFeedHistory = 30 * 24 * time.Hour
+	// maxCalendarFeedEvents caps the events in one feed response
+	maxCalendarFeedEvents = 1000
+	calendarFeedName      = "Alfred"
+)
+
+func hashCalendarFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// externalBaseURL is the base URL clients outside the server reach it at
+func (s *Server) externalBaseURL(r *http.Request) string {
+	if s.publicURL != "" {
+		return s.publicURL
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// handleGetCalendarFeed reports whether the user's calendar feed is on. The feed URL
+// is only returned when the token is created.
+func (s *Server) handleGetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	feed, err := s.db.GetCalendarFeed(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if feed == nil {
+		respondJSON(w, http.StatusOK, map[string]any{"enabled": false})
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"enabled":          true,
+		"created_at":       feed.CreatedAt,
+		"last_accessed_at": feed.LastAccessedAt,
+	})
+}
+
+// handleCreateCalendarFeed turns on the user's calendar feed with a new secret URL.
+// Calling it again rotates the token, so the previous URL stops working.
+func (s *Server) handleCreateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if auth.GetAPIKeyScope(r.Context()) != "" {
+		respondErrorCode(w, http.StatusForbidden, CodeAPIKeyNotAllowed, "api keys cannot manage calendar feeds")
+		return
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		respondError(w, http.StatusInternalServerError, "failed to generate feed token")
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	if err := s.db.SetCalendarFeedToken(userID, hashCalendarFeedToken(token)); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	feedURL := fmt.Sprintf("%s/feeds/%s/alfred.ics", s.externalBaseURL(r), token)
+	_, hostAndPath, _ := strings.Cut(feedURL, "://")
+	respondJSON(w, http.StatusCreated, map[string]any{
+		"url":        feedURL,
+		"webcal_url": "webcal://" + hostAndPath,
+	})
+}
+
+// handleDeleteCalendarFeed turns off the user's calendar feed
+func (s *Server) handleDeleteCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if auth.GetAPIKeyScope(r.Context()) != "" {
+		respondErrorCode(w, http.StatusForbidden, CodeAPIKeyNotAllowed, "api keys cannot manage calendar feeds")
+		return
+	}
+
+	deleted, err := s.db.DeleteCalendarFeed(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "calendar feed not enabled")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCalendarFeed serves the user's confirmed events as an iCalendar feed that
+// calendar apps subscribe to. The secret token in the URL authenticates.
+func (s *Server) handleCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := s.db.UseCalendarFeedToken(hashCalendarFeedToken(r.PathValue("token")))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Calendar feed: token lookup failed", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if userID == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	now := time.Now()
+	events, err := s.db.ListFeedEvents(userID, now.Add(-calendarFeedHistory), maxCalendarFeedEvents)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Calendar feed: failed to list events", "user_id", userID, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ics.ContentType)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(ics.Calendar(calendarFeedName, events, now))
+}
+
+// handleGetEventICS downloads one of the user's events as an .ics file
+// (GET /api/events/{id}.ics, dispatched from handleGetEvent)
+func (s *Server) handleGetEventICS(w http.ResponseWriter, userID int64, rawID string) {
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	event, err := s.db.GetEventByID(id)
+	if err != nil || event.UserID != userID {
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", ics.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="event-%d.ics"`, event.ID))
+	w.WriteHeader(http.StatusOK)
+	w.Write(ics.Calendar("", []database.CalendarEvent{*event}, time.Now()))
+}
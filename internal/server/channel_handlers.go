@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// handleUpdateChannelAnalysisMode sets which analyzers run on a channel the user owns
+// Body: { "mode": "all" | "events" | "reminders" | "none" }
+func (s *Server) handleUpdateChannelAnalysisMode(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	channel := s.getOwnedChannel(w, r, userID)
+	if channel == nil {
+		return
+	}
+
+	var req struct {
+		Mode database.AnalysisMode `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+	if !database.IsValidAnalysisMode(req.Mode) {
+		respondError(w, http.StatusBadRequest, "mode must be all, events, reminders or none")
+		return
+	}
+
+	if err := s.db.UpdateChannelAnalysisMode(userID, channel.ID, req.Mode); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	channel.AnalysisMode = req.Mode
+	respondJSON(w, http.StatusOK, channel)
+}
+
+const (
+	defaultChannelSuggestions = 5
+	maxChannelSuggestions     = 20
+)
+
+// handleListChannelSuggestions lists untracked channels worth tracking, ranked by how
+// many actionable-looking messages they had in the last week. Query: ?limit= (max 20)
+func (s *Server) handleListChannelSuggestions(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	limit := defaultChannelSuggestions
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxChannelSuggestions {
+			respondInvalidParameter(w, "limit", 1, maxChannelSuggestions, fmt.Sprintf("limit must be between 1 and %d", maxChannelSuggestions))
+			return
+		}
+		limit = parsed
+	}
+
+	suggestions, err := s.db.ListChannelSuggestions(userID, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"suggestions": suggestions})
+}
+
+// maxChannelMute bounds how long a channel can be muted
+const maxChannelMute = 365 * 24 * time.Hour
+
+// handleMuteChannel pauses analysis of a channel the user owns until a time; the mute
+// scheduler lifts it afterwards. Messages are still stored as history meanwhile.
+// Body: { "duration_minutes": 20160 } or { "until": "2025-07-01" }
+func (s *Server) handleMuteChannel(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	channel := s.getOwnedChannel(w, r, userID)
+	if channel == nil {
+		return
+	}
+
+	var req struct {
+		DurationMinutes *int    `json:"duration_minutes"`
+		Until           *string `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	hasUntil := req.Until != nil && strings.TrimSpace(*req.Until) != ""
+	if (req.DurationMinutes == nil) == !hasUntil {
+		respondError(w, http.StatusBadRequest, "exactly one of duration_minutes or until is required")
+		return
+	}
+
+	now := time.Now()
+	var until time.Time
+	if req.DurationMinutes != nil {
+		if *req.DurationMinutes <= 0 {
+			respondError(w, http.StatusBadRequest, "duration_minutes must be positive")
+			return
+		}
+		until = now.Add(time.Duration(*req.DurationMinutes) * time.Minute)
+	} else {
+		parsed, err := parseReminderDateTime(strings.TrimSpace(*req.Until), s.getUserTimezone(userID))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid until format")
+			return
+		}
+		if !parsed.After(now) {
+			respondError(w, http.StatusBadRequest, "until must be in the future")
+			return
+		}
+		until = parsed
+	}
+	if until.Sub(now) > maxChannelMute {
+		respondError(w, http.StatusBadRequest, "a channel can be muted for at most a year")
+		return
+	}
+
+	if err := s.db.SetChannelMutedUntil(userID, channel.ID, &until); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	until = until.UTC()
+	channel.MutedUntil = &until
+	respondJSON(w, http.StatusOK, channel)
+}
+
+// handleUnmuteChannel resumes analysis of a muted channel the user owns
+func (s *Server) handleUnmuteChannel(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	channel := s.getOwnedChannel(w, r, userID)
+	if channel == nil {
+		return
+	}
+
+	if err := s.db.SetChannelMutedUntil(userID, channel.ID, nil); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	channel.MutedUntil = nil
+	respondJSON(w, http.StatusOK, channel)
+}
+
+// parseReprocessSince reads the optional since query parameter (RFC 3339 or YYYY-MM-DD),
+// defaulting to the initial backfill window
+func parseReprocessSince(raw string, now time.Time) (time.Time, error) {
+	if raw == "" {
+		return now.Add(-backfillWindowDays * 24 * time.Hour), nil
+	}
+	since, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		if since, err = time.Parse("2006-01-02", raw); err != nil {
+			return time.Time{}, fmt.Errorf("since must be an RFC 3339 time or YYYY-MM-DD date")
+		}
+	}
+	if since.After(now) {
+		return time.Time{}, fmt.Errorf("since must not be in the future")
+	}
+	return since, nil
+}
+
+// handleReprocessChannel re-runs the analyzers over a channel's stored messages since a
+// time, e.g. after enabling an analyzer or changing a prompt. Runs in the background and
+// returns 202 with the number of messages queued.
+// Query: ?since=2026-03-01 or RFC 3339 (default: the last 10 days)
+func (s *Server) handleReprocessChannel(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	channel := s.getOwnedChannel(w, r, userID)
+	if channel == nil {
+		return
+	}
+
+	since, err := parseReprocessSince(r.URL.Query().Get("since"), time.Now())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !s.hasMessageAnalyzers() {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "no analyzers are configured")
+		return
+	}
+	if !channel.Enabled || channel.AnalysisMode == database.AnalysisModeNone {
+		respondError(w, http.StatusConflict, "analysis is off for this channel")
+		return
+	}
+	if channel.IsMuted(time.Now()) {
+		respondErrorCode(w, http.StatusConflict, CodeChannelMuted, "channel is muted")
+		return
+	}
+
+	messages, err := s.db.GetSourceMessagesSince(userID, channel.SourceType, channel.ID, since)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response := map[string]any{
+		"channel_id": channel.ID,
+		"since":      since,
+		"messages":   len(messages),
+	}
+	if len(messages) == 0 {
+		respondJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if _, running := s.reprocessing.LoadOrStore(channel.ID, true); running {
+		respondError(w, http.StatusConflict, "channel is already being reprocessed")
+		return
+	}
+	go func() {
+		defer s.reprocessing.Delete(channel.ID)
+		ctx := agent.WithBackgroundPriority(context.Background())
+		if err := s.newBackfillProcessor().ProcessChannelMessages(ctx, userID, channel.ID, channel.SourceType, messages); err != nil {
+			slog.ErrorContext(r.Context(), "Reprocess: failed", "channel_id", channel.ID, "error", err)
+			return
+		}
+		slog.InfoContext(r.Context(), "Reprocess: analyzed messages", "messages", len(messages), "channel_id", channel.ID)
+	}()
+
+	respondJSON(w, http.StatusAccepted, response)
+}
+
+// handleGetChannelBackfill returns the progress of a channel's initial backfill. Live
+// progress is also published on /api/stream as backfill_progress updates.
+func (s *Server) handleGetChannelBackfill(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	channel := s.getOwnedChannel(w, r, userID)
+	if channel == nil {
+		return
+	}
+
+	progress, err := s.db.GetChannelBackfillProgress(userID, channel.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if progress == nil {
+		respondError(w, http.StatusNotFound, "channel has not been backfilled")
+		return
+	}
+	respondJSON(w, http.StatusOK, channelBackfillUpdate{
+		ChannelID:        channel.ID,
+		SourceType:       channel.SourceType,
+		BackfillProgress: progress,
+	})
+}
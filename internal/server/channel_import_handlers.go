@@ -0,0 +1,266 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/validate"
+)
+
+// maxChannelImportContacts caps one import; larger lists can be sent in batches
+const maxChannelImportContacts = 500
+
+// ChannelImportRequest is the body of POST /api/channels/import
+type ChannelImportRequest struct {
+	Contacts     []ChannelImportContact `json:"contacts" validate:"required,max=500"`
+	BackfillDays int                    `json:"backfill_days"` // Initial backfill lookback for new and re-enabled channels (default 10)
+}
+
+// ChannelImportContact is one contact to track. Source may be left out: a phone number
+// means WhatsApp and a username means Telegram.
+type ChannelImportContact struct {
+	Source   source.SourceType `json:"source" validate:"omitempty,oneof=whatsapp telegram"`
+	Name     string            `json:"name"`
+	Phone    string            `json:"phone" validate:"required_without=Username,required_if=Source whatsapp"`
+	Username string            `json:"username" validate:"required_if=Source telegram"`
+}
+
+// ChannelImportResponse reports what the import did, with one result per contact in order
+type ChannelImportResponse struct {
+	Created   int                            `json:"created"`
+	Enabled   int                            `json:"enabled"`
+	Unchanged int                            `json:"unchanged"`
+	Channels  []database.ChannelImportResult `json:"channels"`
+}
+
+// handleImportChannels creates or enables WhatsApp and Telegram contact channels from a
+// JSON body or a CSV file (Content-Type: text/csv, header row with name, phone, username
+// and source columns). Every contact is checked first; if any is invalid nothing is
+// imported and the response lists the failing fields. Channels are written in one
+// transaction, then new and re-enabled channels are backfilled one at a time.
+func (s *Server) handleImportChannels(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req ChannelImportRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		req.Contacts, err = parseChannelImportCSV(r.Body)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if days := r.URL.Query().Get("backfill_days"); days != "" {
+			if req.BackfillDays, err = strconv.Atoi(days); err != nil {
+				req.BackfillDays = -1
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	for i := range req.Contacts {
+		contact := &req.Contacts[i]
+		contact.Source = source.SourceType(strings.ToLower(strings.TrimSpace(string(contact.Source))))
+		if contact.Source == "" {
+			if strings.TrimSpace(contact.Phone) != "" {
+				contact.Source = source.SourceTypeWhatsApp
+			} else if strings.TrimSpace(contact.Username) != "" {
+				contact.Source = source.SourceTypeTelegram
+			}
+		}
+	}
+	if errs := validate.Struct(req); errs != nil {
+		respondValidationErrors(w, errs)
+		return
+	}
+	if !validBackfillDays(req.BackfillDays) {
+		respondInvalidParameter(w, "backfill_days", 1, maxBackfillDays, fmt.Sprintf("backfill_days must be between 1 and %d", maxBackfillDays))
+		return
+	}
+
+	imports, errs, ok := s.resolveChannelImports(w, r, userID, req.Contacts)
+	if !ok {
+		return
+	}
+	if errs != nil {
+		respondValidationErrors(w, errs)
+		return
+	}
+
+	results, err := s.db.ImportSourceChannels(userID, imports)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to import channels: %v", err))
+		return
+	}
+
+	response := ChannelImportResponse{Channels: results}
+	var backfill []*database.SourceChannel
+	for _, result := range results {
+		switch result.Status {
+		case database.ChannelImportCreated:
+			response.Created++
+			backfill = append(backfill, result.Channel)
+		case database.ChannelImportEnabled:
+			response.Enabled++
+			backfill = append(backfill, result.Channel)
+		default:
+			response.Unchanged++
+		}
+	}
+	s.startChannelBackfills(userID, backfill, req.BackfillDays)
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// resolveChannelImports turns contacts into channel identifiers: WhatsApp numbers are
+// normalized and Telegram usernames resolved to user IDs. Contacts that can't be are
+// returned as field errors. ok is false when a response was already sent.
+func (s *Server) resolveChannelImports(w http.ResponseWriter, r *http.Request, userID int64, contacts []ChannelImportContact) (imports []database.ChannelImport, errs validate.Errors, ok bool) {
+	fieldError := func(i int, field, rule, message string) {
+		name := fmt.Sprintf("contacts[%d].%s", i, field)
+		errs = append(errs, validate.FieldError{Field: name, Rule: rule, Message: name + ": " + message})
+	}
+
+	var resolveTelegram func(username string) (int64, string, string, error)
+	for _, contact := range contacts {
+		if contact.Source != source.SourceTypeTelegram {
+			continue
+		}
+		if s.clientManager == nil {
+			respondErrorCode(w, http.StatusBadRequest, CodeTelegramNotReady, "Telegram not connected")
+			return nil, nil, false
+		}
+		tgClient, err := s.clientManager.GetTelegramClient(userID)
+		if err != nil || !tgClient.IsConnected() {
+			respondErrorCode(w, http.StatusBadRequest, CodeTelegramNotReady, "Telegram not connected")
+			return nil, nil, false
+		}
+		resolveTelegram = func(username string) (int64, string, string, error) {
+			return tgClient.ResolveUsername(r.Context(), username)
+		}
+		break
+	}
+
+	for i, contact := range contacts {
+		name := strings.TrimSpace(contact.Name)
+		switch contact.Source {
+		case source.SourceTypeWhatsApp:
+			phone, err := normalizePhoneNumber(contact.Phone)
+			if err != nil {
+				fieldError(i, "phone", "phone", err.Error())
+				continue
+			}
+			if name == "" {
+				name = strings.TrimSpace(contact.Phone)
+			}
+			imports = append(imports, database.ChannelImport{
+				SourceType:     source.SourceTypeWhatsApp,
+				Identifier:     phone,
+				AltIdentifiers: []string{phone + "@s.whatsapp.net"},
+				Name:           name,
+			})
+
+		case source.SourceTypeTelegram:
+			username, err := normalizeTelegramUsername(contact.Username)
+			if err != nil {
+				fieldError(i, "username", "username", err.Error())
+				continue
+			}
+			resolvedID, displayName, resolvedUsername, err := resolveTelegram(username)
+			if err != nil {
+				fieldError(i, "username", "resolve", err.Error())
+				continue
+			}
+			if name == "" {
+				name = displayName
+			}
+			if name == "" {
+				name = "@" + resolvedUsername
+			}
+			imports = append(imports, database.ChannelImport{
+				SourceType: source.SourceTypeTelegram,
+				Identifier: strconv.FormatInt(resolvedID, 10),
+				Name:       name,
+			})
+		}
+	}
+	return imports, errs, true
+}
+
+// parseChannelImportCSV reads contacts from a CSV file whose header row names the
+// columns. Column names are case-insensitive; phone_number is accepted for phone.
+func parseChannelImportCSV(body io.Reader) ([]ChannelImportContact, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if column == "phone_number" {
+			column = "phone"
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["phone"]; !ok {
+		if _, ok := columns["username"]; !ok {
+			return nil, errors.New("CSV header must include a phone or username column")
+		}
+	}
+
+	var contacts []ChannelImportContact
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		if len(contacts) == maxChannelImportContacts {
+			// One over the limit is enough for validation to reject the import
+			contacts = append(contacts, ChannelImportContact{})
+			break
+		}
+
+		field := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		contact := ChannelImportContact{
+			Source:   source.SourceType(field("source")),
+			Name:     field("name"),
+			Phone:    field("phone"),
+			Username: field("username"),
+		}
+		if contact == (ChannelImportContact{}) {
+			continue // Blank line
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts, nil
+}
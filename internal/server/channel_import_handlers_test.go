@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleImportChannels(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	importChannels := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/channels/import", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		req = withAuthContext(req, user)
+		w := httptest.NewRecorder()
+		s.handleImportChannels(w, req)
+		return w
+	}
+
+	disabled, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "15550002222@s.whatsapp.net", "Bob")
+	require.NoError(t, err)
+	require.NoError(t, s.db.UpdateSourceChannel(user.ID, disabled.ID, disabled.Name, false))
+
+	t.Run("JSON", func(t *testing.T) {
+		w := importChannels("application/json", `{"contacts": [
+			{"name": "Alice", "phone": "+1 (555) 000-1111"},
+			{"source": "whatsapp", "phone": "15550002222"}
+		]}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response ChannelImportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Created)
+		assert.Equal(t, 1, response.Enabled)
+		require.Len(t, response.Channels, 2)
+		assert.Equal(t, "15550001111", response.Channels[0].Channel.Identifier)
+		assert.Equal(t, "Alice", response.Channels[0].Channel.Name)
+		assert.Equal(t, disabled.ID, response.Channels[1].Channel.ID)
+		assert.True(t, response.Channels[1].Channel.Enabled)
+
+		// Without analyzers the backfill is recorded as skipped
+		status, _ := getChannelInitialBackfillStatus(t, s.db, response.Channels[0].Channel.ID)
+		assert.Equal(t, string(database.BackfillStatusSkipped), status.String)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		w := importChannels("text/csv; charset=utf-8", "Name,Phone_Number\nAlice,15550001111\n\nDana,+44 20 7946 0000\n")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response ChannelImportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Created)
+		assert.Equal(t, 1, response.Unchanged)
+		assert.Equal(t, "442079460000", response.Channels[1].Channel.Identifier)
+	})
+
+	t.Run("invalid contacts import nothing", func(t *testing.T) {
+		w := importChannels("application/json", `{"contacts": [{"phone": "15550003333"}, {"phone": "12"}, {"name": "Nobody"}]}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "validation_failed", response["code"])
+		fields := response["fields"].([]any)
+		require.Len(t, fields, 1)
+		assert.Equal(t, "contacts[2].phone", fields[0].(map[string]any)["field"])
+
+		w = importChannels("application/json", `{"contacts": [{"phone": "15550003333"}, {"phone": "12"}]}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "contacts[1].phone: Invalid phone number format")
+
+		channel, err := s.db.GetSourceChannelByIdentifier(user.ID, source.SourceTypeWhatsApp, "15550003333")
+		require.NoError(t, err)
+		assert.Nil(t, channel)
+	})
+
+	t.Run("Telegram needs a connected client", func(t *testing.T) {
+		w := importChannels("application/json", `{"contacts": [{"username": "@carol_smith"}]}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"telegram_not_connected"`)
+	})
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// maxChatCommandListed caps the pending events listed in a reply
+const maxChatCommandListed = 10
+
+// Actions of a chat command
+const (
+	chatCommandPending = "pending"
+	chatCommandConfirm = "confirm"
+	chatCommandReject  = "reject"
+)
+
+// chatCommand is an approval command from the user's WhatsApp self-chat
+type chatCommand struct {
+	action string
+	// position is the 1-based place of the event in the pending list, newest first;
+	// "last" is 1
+	position int
+}
+
+var (
+	chatCommandActionPattern  = regexp.MustCompile(`^(confirm|approve|reject|decline)\s+(\d+|last)$`)
+	chatCommandPendingPattern = regexp.MustCompile(`^(pending|list)$`)
+)
+
+// parseChatCommand parses "pending", "confirm <n>" or "reject <n>", where <n> is a
+// position in the pending list or "last". Anything else is not a command.
+func parseChatCommand(text string) (chatCommand, bool) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	text = strings.TrimRight(text, ".!")
+
+	if chatCommandPendingPattern.MatchString(text) {
+		return chatCommand{action: chatCommandPending}, true
+	}
+
+	m := chatCommandActionPattern.FindStringSubmatch(text)
+	if m == nil {
+		return chatCommand{}, false
+	}
+	cmd := chatCommand{action: chatCommandConfirm, position: 1}
+	if m[1] == "reject" || m[1] == "decline" {
+		cmd.action = chatCommandReject
+	}
+	if m[2] != "last" {
+		n, err := strconv.Atoi(m[2])
+		if err != nil || n < 1 {
+			return chatCommand{}, false
+		}
+		cmd.position = n
+	}
+	return cmd, true
+}
+
+// runChatCommand runs an approval command the user sent to their WhatsApp self-chat
+// and returns the reply. It is the clients.ClientManager self-chat hook.
+func (s *Server) runChatCommand(_ context.Context, userID int64, text string) (string, bool) {
+	cmd, ok := parseChatCommand(text)
+	if !ok {
+		return "", false
+	}
+
+	pending, err := s.db.GetPendingEvents(userID, nil)
+	if err != nil {
+		slog.Error("Chat command: failed to list pending events", "user_id", userID, "error", err)
+		return "Alfred: something went wrong, try again later.", true
+	}
+
+	if cmd.action == chatCommandPending {
+		return s.formatPendingList(userID, pending), true
+	}
+
+	if len(pending) == 0 {
+		return "Alfred: nothing is waiting for approval.", true
+	}
+	if cmd.position > len(pending) {
+		return fmt.Sprintf("Alfred: there are only %d pending events. Send \"pending\" to list them.", len(pending)), true
+	}
+	event := &pending[cmd.position-1]
+
+	if cmd.action == chatCommandReject {
+		if err := s.rejectPendingEvent(event); err != nil {
+			slog.Error("Chat command: failed to reject event", "event_id", event.ID, "error", err)
+			return "Alfred: couldn't reject " + event.Title + ".", true
+		}
+		return "Alfred: rejected " + event.Title + ".", true
+	}
+
+	if _, err := s.confirmPendingEvent(userID, event, false); err != nil {
+		slog.Error("Chat command: failed to confirm event", "event_id", event.ID, "error", err)
+		return "Alfred: couldn't confirm " + event.Title + ".", true
+	}
+	return fmt.Sprintf("Alfred: confirmed %s (%s).", event.Title, s.chatCommandWhen(userID, event)), true
+}
+
+// formatPendingList numbers the pending events the way confirm and reject refer to them
+func (s *Server) formatPendingList(userID int64, pending []database.CalendarEvent) string {
+	if len(pending) == 0 {
+		return "Alfred: nothing is waiting for approval."
+	}
+
+	var b strings.Builder
+	b.WriteString("Alfred: pending events\n")
+	for i, event := range pending {
+		if i == maxChatCommandListed {
+			fmt.Fprintf(&b, "…and %d more in the app\n", len(pending)-i)
+			break
+		}
+		fmt.Fprintf(&b, "%d. %s (%s)\n", i+1, event.Title, s.chatCommandWhen(userID, &event))
+	}
+	b.WriteString(`Reply "confirm 1" or "reject 1".`)
+	return b.String()
+}
+
+func (s *Server) chatCommandWhen(userID int64, event *database.CalendarEvent) string {
+	loc, err := time.LoadLocation(s.getUserTimezone(userID))
+	if err != nil {
+		loc = time.UTC
+	}
+	return event.StartTime.In(loc).Format("Mon Jan 2, 15:04")
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChatCommand(t *testing.T) {
+	tests := []struct {
+		text string
+		want chatCommand
+		ok   bool
+	}{
+		{"pending", chatCommand{action: chatCommandPending}, true},
+		{"List", chatCommand{action: chatCommandPending}, true},
+		{"confirm 3", chatCommand{action: chatCommandConfirm, position: 3}, true},
+		{"  Approve   2 ", chatCommand{action: chatCommandConfirm, position: 2}, true},
+		{"reject last", chatCommand{action: chatCommandReject, position: 1}, true},
+		{"Decline 1.", chatCommand{action: chatCommandReject, position: 1}, true},
+		{"confirm 0", chatCommand{}, false},
+		{"confirm", chatCommand{}, false},
+		{"confirm dinner with Dana", chatCommand{}, false},
+		{"buy milk", chatCommand{}, false},
+		{"Alfred: confirmed Dinner (Fri Mar 13, 19:00).", chatCommand{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := parseChatCommand(tt.text)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	large := map[string]string{"text": strings.Repeat("alfred ", 500)}
+	handler := gzipMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			respondJSON(w, http.StatusOK, large)
+		case "/small":
+			respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		case "/zip":
+			w.Header().Set("Content-Type", "application/zip")
+			_, _ = w.Write([]byte(strings.Repeat("z", 4096)))
+		case "/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {}\n\n"))
+			w.(http.Flusher).Flush()
+		}
+	}))
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("compresses large JSON", func(t *testing.T) {
+		w := get("/large", "gzip, deflate")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+		reader, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `"text":"alfred alfred`)
+	})
+
+	t.Run("leaves the rest alone", func(t *testing.T) {
+		for _, tc := range []struct{ path, acceptEncoding string }{
+			{"/large", ""},
+			{"/large", "gzip;q=0"},
+			{"/small", "gzip"},
+			{"/zip", "gzip"},
+			{"/stream", "gzip"},
+		} {
+			w := get(tc.path, tc.acceptEncoding)
+			assert.Empty(t, w.Header().Get("Content-Encoding"), tc.path+" "+tc.acceptEncoding)
+			assert.NotEmpty(t, w.Body.String())
+		}
+	})
+}
+
+func TestWithETag(t *testing.T) {
+	events := []string{"standup", "dentist"}
+	handler := gzipMiddleware(withETag(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			respondError(w, http.StatusBadRequest, "bad")
+			return
+		}
+		respondJSON(w, http.StatusOK, events)
+	}))
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("/api/events", "")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.True(t, strings.HasPrefix(etag, `W/"`), etag)
+	assert.Equal(t, "private, no-cache", first.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `["standup","dentist"]`, first.Body.String())
+
+	notModified := get("/api/events", `"other", `+etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	events = append(events, "flight")
+	changed := get("/api/events", etag)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+
+	failed := get("/api/events?fail=1", etag)
+	assert.Equal(t, http.StatusBadRequest, failed.Code)
+	assert.Empty(t, failed.Header().Get("ETag"))
+}
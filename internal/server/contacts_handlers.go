@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// Unified Contacts API - one contact per person across WhatsApp, Telegram and Gmail
+
+func (s *Server) handleListContacts(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	contacts, err := s.db.ListUnifiedContacts(userID, r.URL.Query().Get("q"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"contacts": contacts})
+}
+
+// handleSyncContacts pulls contacts from the connected WhatsApp and Telegram accounts,
+// plus the user's chat channels and Gmail senders, into the unified contacts
+func (s *Server) handleSyncContacts(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	s.syncClientContacts(r.Context(), userID)
+	if err := s.db.SyncContactsFromHistory(userID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	contacts, err := s.db.ListUnifiedContacts(userID, "")
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"contacts": contacts})
+}
+
+// syncClientContacts records the contacts of the user's connected WhatsApp and Telegram
+// clients. Clients that aren't connected are skipped.
+func (s *Server) syncClientContacts(ctx context.Context, userID int64) {
+	if s.clientManager == nil {
+		return
+	}
+
+	if waClient, err := s.clientManager.GetWhatsAppClient(userID); err == nil {
+		allContacts, err := waClient.GetAllContacts(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Contacts: failed to read WhatsApp contacts", "user_id", userID, "error", err)
+		}
+		for jid, contact := range allContacts {
+			if jid.Server != "s.whatsapp.net" {
+				continue
+			}
+			name := strings.TrimSpace(contact.FullName)
+			if name == "" {
+				name = strings.TrimSpace(contact.PushName)
+			}
+			if _, err := s.db.UpsertContactIdentity(userID, source.SourceTypeWhatsApp, jid.User, name); err != nil {
+				slog.ErrorContext(ctx, "Contacts: failed to record WhatsApp contact", "error", err)
+			}
+		}
+	}
+
+	if tgClient, err := s.clientManager.GetTelegramClient(userID); err == nil && tgClient.IsConnected() {
+		channels, err := tgClient.GetDiscoverableChannels(ctx, userID, s.db)
+		if err != nil {
+			slog.ErrorContext(ctx, "Contacts: failed to read Telegram contacts", "user_id", userID, "error", err)
+		}
+		for _, ch := range channels {
+			if ch.Type != "contact" {
+				continue
+			}
+			if _, err := s.db.UpsertContactIdentity(userID, source.SourceTypeTelegram, ch.Identifier, ch.Name); err != nil {
+				slog.ErrorContext(ctx, "Contacts: failed to record Telegram contact", "error", err)
+			}
+		}
+	}
+}
+
+// handleUpdateContact renames a contact and/or sets the analysis mode for all of its chat
+// channels. Body: { "name": "...", "analysis_mode": "all" | "events" | "reminders" | "none" | "" }
+func (s *Server) handleUpdateContact(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	var req struct {
+		Name         string                `json:"name"`
+		AnalysisMode database.AnalysisMode `json:"analysis_mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+	if req.AnalysisMode != "" && !database.IsValidAnalysisMode(req.AnalysisMode) {
+		respondError(w, http.StatusBadRequest, "analysis_mode must be all, events, reminders or none")
+		return
+	}
+
+	contact, err := s.db.UpdateUnifiedContact(userID, id, req.Name, req.AnalysisMode)
+	if err != nil {
+		s.respondContactError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, contact)
+}
+
+// handleMergeContacts merges another contact into the one in the path.
+// Body: { "contact_id": 12 }
+func (s *Server) handleMergeContacts(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	var req struct {
+		ContactID int64 `json:"contact_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+	if req.ContactID == 0 || req.ContactID == id {
+		respondError(w, http.StatusBadRequest, "contact_id must be another contact")
+		return
+	}
+
+	contact, err := s.db.MergeUnifiedContacts(userID, id, req.ContactID)
+	if err != nil {
+		s.respondContactError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, contact)
+}
+
+// handleSplitContactIdentity moves an identity out of its contact into a new contact
+func (s *Server) handleSplitContactIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	contact, err := s.db.SplitContactIdentity(userID, id)
+	if err != nil {
+		s.respondContactError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, contact)
+}
+
+// respondContactError maps unified contact errors to responses
+func (s *Server) respondContactError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrUnifiedContactNotFound):
+		respondError(w, http.StatusNotFound, "contact not found")
+	case errors.Is(err, database.ErrContactIdentityNotFound):
+		respondError(w, http.StatusNotFound, "contact identity not found")
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
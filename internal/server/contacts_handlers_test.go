@@ -0,0 +1,80 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleContactsMergeAndSplit(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+	otherUser := database.CreateTestUser(t, s.db)
+
+	_, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "972501234567", "Dana")
+	require.NoError(t, err)
+	telegram, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeTelegram, source.ChannelTypeSender, "5551234", "Dana L")
+	require.NoError(t, err)
+
+	list := func() []database.UnifiedContact {
+		req := withAuthContext(httptest.NewRequest("GET", "/api/contacts?q=dana", nil), user)
+		w := httptest.NewRecorder()
+		s.handleListContacts(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Contacts []database.UnifiedContact `json:"contacts"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Contacts
+	}
+	post := func(path, id string, body any, handler http.HandlerFunc, as *database.TestUser) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", path, bytes.NewReader(data))
+		req.SetPathValue("id", id)
+		req = withAuthContext(req, as)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	contacts := list()
+	require.Len(t, contacts, 2, "different names stay separate until merged")
+	into, from := strconv.FormatInt(contacts[0].ID, 10), contacts[1].ID
+
+	w := post("/api/contacts/"+into+"/merge", into, map[string]int64{"contact_id": from}, s.handleMergeContacts, otherUser)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = post("/api/contacts/"+into+"/merge", into, map[string]int64{"contact_id": from}, s.handleMergeContacts, user)
+	require.Equal(t, http.StatusOK, w.Code)
+	var merged database.UnifiedContact
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &merged))
+	require.Len(t, merged.Identities, 2)
+	require.Len(t, list(), 1)
+
+	// One analysis mode for both platforms
+	body, err := json.Marshal(map[string]string{"analysis_mode": "events"})
+	require.NoError(t, err)
+	req := httptest.NewRequest("PUT", "/api/contacts/"+into, bytes.NewReader(body))
+	req.SetPathValue("id", into)
+	req = withAuthContext(req, user)
+	w = httptest.NewRecorder()
+	s.handleUpdateContact(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	channel, err := s.db.GetSourceChannelByID(user.ID, telegram.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.AnalysisModeEvents, channel.AnalysisMode)
+
+	identityID := strconv.FormatInt(merged.Identities[0].ID, 10)
+	w = post("/api/contacts/identities/"+identityID+"/split", identityID, nil, s.handleSplitContactIdentity, user)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, list(), 2)
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// ConvertToEventRequest is the optional body of POST /api/reminders/{id}/convert-to-event
+type ConvertToEventRequest struct {
+	StartTime string  `json:"start_time"` // Defaults to the reminder's due date
+	EndTime   *string `json:"end_time"`   // Defaults to an hour after the start
+}
+
+// ConvertToReminderRequest is the optional body of POST /api/events/{id}/convert-to-reminder
+type ConvertToReminderRequest struct {
+	DueDate  *string `json:"due_date"` // Left out for a reminder without a fixed time
+	Priority string  `json:"priority" validate:"omitempty,oneof=low normal high"`
+}
+
+// handleConvertReminderToEvent turns a pending reminder the agent should have classified
+// as an event into a confirmed event, syncing it to Google Calendar when sync is enabled
+func (s *Server) handleConvertReminderToEvent(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	reminder, err := s.db.GetReminderByID(id)
+	if err != nil || reminder.UserID != userID {
+		respondErrorCode(w, http.StatusNotFound, CodeReminderNotFound, "reminder not found")
+		return
+	}
+
+	if reminder.Status != database.ReminderStatusPending {
+		respondErrorCode(w, http.StatusBadRequest, CodeReminderNotPending, "reminder is not pending")
+		return
+	}
+	if reminder.ActionType != database.ReminderActionCreate {
+		respondError(w, http.StatusBadRequest, "only new reminders can be converted")
+		return
+	}
+
+	// The body is optional
+	var req ConvertToEventRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	timezone := s.getUserTimezone(userID)
+	var startTime time.Time
+	switch {
+	case req.StartTime != "":
+		startTime, _, err = parseEventTime(req.StartTime, timezone)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid start_time format: %v", err))
+			return
+		}
+	case reminder.DueDate != nil:
+		startTime = *reminder.DueDate
+	default:
+		respondError(w, http.StatusBadRequest, "start_time is required for a reminder without a due date")
+		return
+	}
+
+	var endTime *time.Time
+	if req.EndTime != nil && *req.EndTime != "" {
+		et, _, err := parseEventTime(*req.EndTime, timezone)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("invalid end_time format: %v", err))
+			return
+		}
+		if !et.After(startTime) {
+			respondError(w, http.StatusBadRequest, "end_time must be after start_time")
+			return
+		}
+		endTime = &et
+	}
+
+	event, err := s.db.ConvertReminderToEvent(reminder, startTime, endTime)
+	if errors.Is(err, database.ErrConversionNotPending) {
+		respondErrorCode(w, http.StatusBadRequest, CodeReminderNotPending, "reminder is not pending")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	confirmedEvent, err := s.confirmPendingEvent(userID, event, false)
+	if err != nil {
+		// The conversion stands; the event stays pending for the user to confirm again
+		slog.WarnContext(r.Context(), "failed to confirm converted event", "reminder_id", id, "event_id", event.ID, "error", err)
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, confirmedEvent)
+}
+
+// handleConvertEventToReminder turns a pending event the agent should have classified as
+// a reminder into a confirmed reminder, with no fixed time unless a due date is given.
+// The event is recorded as a rejection so the event agent learns from the mistake.
+func (s *Server) handleConvertEventToReminder(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	event, err := s.db.GetEventByID(id)
+	if err != nil || event.UserID != userID {
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
+		return
+	}
+
+	if event.Status != database.EventStatusPending {
+		respondErrorCode(w, http.StatusBadRequest, CodeEventNotPending, "event is not pending")
+		return
+	}
+	if event.ActionType != database.EventActionCreate {
+		respondError(w, http.StatusBadRequest, "only new events can be converted")
+		return
+	}
+
+	// The body is optional
+	var req ConvertToReminderRequest
+	if r.ContentLength != 0 && !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var dueDate *time.Time
+	if req.DueDate != nil && *req.DueDate != "" {
+		parsed, err := parseReminderDateTime(*req.DueDate, s.getUserTimezone(userID))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid due_date format")
+			return
+		}
+		dueDate = &parsed
+	}
+	priority, err := parseReminderPriority(req.Priority)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reminder, err := s.db.ConvertEventToReminder(event, dueDate, priority)
+	if errors.Is(err, database.ErrConversionNotPending) {
+		respondErrorCode(w, http.StatusBadRequest, CodeEventNotPending, "event is not pending")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := s.db.RecordEventRejection(event); err != nil {
+		slog.WarnContext(r.Context(), "failed to record correction", "event_id", id, "error", err)
+	}
+
+	confirmedReminder, err := s.confirmPendingReminder(userID, reminder)
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to confirm converted reminder", "event_id", id, "reminder_id", reminder.ID, "error", err)
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, confirmedReminder)
+}
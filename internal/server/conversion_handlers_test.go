@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertReminderToEvent(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	channel, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "alice", "Alice")
+	require.NoError(t, err)
+	reminder, err := s.db.CreatePendingReminder(&database.Reminder{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Team dinner",
+		ActionType: database.ReminderActionCreate,
+		Priority:   database.ReminderPriorityNormal,
+	})
+	require.NoError(t, err)
+
+	convert := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/reminders/"+strconv.FormatInt(reminder.ID, 10)+"/convert-to-event", strings.NewReader(body))
+		req.SetPathValue("id", strconv.FormatInt(reminder.ID, 10))
+		w := httptest.NewRecorder()
+		s.handleConvertReminderToEvent(w, withAuthContext(req, user))
+		return w
+	}
+
+	// The reminder has no due date, so the event needs a start time
+	w := convert(``)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = convert(`{"start_time": "2026-11-03T19:00:00Z"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var event database.CalendarEvent
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &event))
+	assert.Equal(t, "Team dinner", event.Title)
+	assert.Equal(t, database.EventStatusConfirmed, event.Status)
+	assert.True(t, time.Date(2026, 11, 3, 19, 0, 0, 0, time.UTC).Equal(event.StartTime))
+
+	converted, err := s.db.GetReminderByID(reminder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.ReminderStatusRejected, converted.Status)
+
+	w = convert(`{"start_time": "2026-11-03T19:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(CodeReminderNotPending))
+}
+
+func TestConvertEventToReminder(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	channel, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "alice", "Alice")
+	require.NoError(t, err)
+	event, err := s.db.CreatePendingEvent(&database.CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Renew passport",
+		StartTime:  time.Now().Add(24 * time.Hour),
+		ActionType: database.EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	convert := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/events/"+strconv.FormatInt(event.ID, 10)+"/convert-to-reminder", strings.NewReader(body))
+		req.SetPathValue("id", strconv.FormatInt(event.ID, 10))
+		w := httptest.NewRecorder()
+		s.handleConvertEventToReminder(w, withAuthContext(req, user))
+		return w
+	}
+
+	w := convert(`{"priority": "urgent"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = convert(`{"priority": "high"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var reminder database.Reminder
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reminder))
+	assert.Equal(t, "Renew passport", reminder.Title)
+	assert.Equal(t, database.ReminderStatusConfirmed, reminder.Status)
+	assert.Equal(t, database.ReminderPriorityHigh, reminder.Priority)
+	assert.Nil(t, reminder.DueDate)
+
+	converted, err := s.db.GetEventByID(event.ID)
+	require.NoError(t, err)
+	assert.Equal(t, database.EventStatusRejected, converted.Status)
+}
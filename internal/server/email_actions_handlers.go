@@ -0,0 +1,167 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/notify"
+)
+
+// emailActionPage is the page a one-click email link opens. Links only show the event and
+// a button; the action runs on the button's POST, so mail scanners that prefetch links
+// can't confirm or reject events.
+var emailActionPage = template.Must(template.New("email-action").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>Alfred</title>
+</head>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; max-width: 600px; margin: 0 auto; padding: 20px; background-color: #f5f5f5;">
+  <div style="background-color: white; border-radius: 8px; padding: 24px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+    <h2 style="margin: 0 0 16px 0; color: #333;">{{.Heading}}</h2>
+    {{if .Event}}
+    <div style="background: #f8f9fa; padding: 16px; border-radius: 8px; margin: 16px 0; border-left: 4px solid #007bff;">
+      <p style="margin: 8px 0;"><strong>{{.Event.Title}}</strong></p>
+      <p style="margin: 8px 0;">{{.When}}</p>
+      {{if .Event.Location}}<p style="margin: 8px 0;">{{.Event.Location}}</p>{{end}}
+    </div>
+    {{end}}
+    {{if .Message}}<p style="margin: 16px 0;">{{.Message}}</p>{{end}}
+    {{if .Button}}
+    <form method="POST">
+      <button type="submit" style="background: {{.ButtonColor}}; color: white; padding: 12px 24px; border: none; border-radius: 6px; font-size: 16px; font-weight: 500; cursor: pointer;">{{.Button}}</button>
+    </form>
+    {{end}}
+  </div>
+</body>
+</html>`))
+
+type emailActionView struct {
+	Heading     string
+	Event       *database.CalendarEvent
+	When        string
+	Message     string
+	Button      string
+	ButtonColor template.CSS
+}
+
+func respondEmailActionPage(w http.ResponseWriter, status int, view emailActionView) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := emailActionPage.Execute(w, view); err != nil {
+		slog.Warn("failed to render email action page", "error", err)
+	}
+}
+
+// loadEmailAction verifies the link's token and loads its event, rendering an error page
+// and returning nil when the link can't be used
+func (s *Server) loadEmailAction(w http.ResponseWriter, r *http.Request) (*notify.EmailActionClaims, *database.CalendarEvent) {
+	if s.emailActions == nil {
+		respondEmailActionPage(w, http.StatusNotFound, emailActionView{Heading: "Link not available"})
+		return nil, nil
+	}
+
+	claims, err := s.emailActions.Verify(r.PathValue("token"), time.Now())
+	if errors.Is(err, notify.ErrEmailActionExpired) {
+		respondEmailActionPage(w, http.StatusGone, emailActionView{
+			Heading: "This link has expired",
+			Message: "Open Alfred to review the event.",
+		})
+		return nil, nil
+	}
+	if err != nil {
+		respondEmailActionPage(w, http.StatusBadRequest, emailActionView{Heading: "Invalid link"})
+		return nil, nil
+	}
+
+	event, err := s.db.GetEventByID(claims.EventID)
+	if err != nil || event.UserID != claims.UserID {
+		respondEmailActionPage(w, http.StatusNotFound, emailActionView{Heading: "Event not found"})
+		return nil, nil
+	}
+
+	if event.Status != database.EventStatusPending {
+		respondEmailActionPage(w, http.StatusOK, emailActionView{
+			Heading: "Already handled",
+			Event:   event,
+			When:    s.emailActionWhen(event),
+			Message: fmt.Sprintf("This event is already %s.", event.Status),
+		})
+		return nil, nil
+	}
+	return claims, event
+}
+
+// emailActionWhen formats the event's start in the owner's timezone
+func (s *Server) emailActionWhen(event *database.CalendarEvent) string {
+	loc, err := time.LoadLocation(s.getUserTimezone(event.UserID))
+	if err != nil {
+		loc = time.UTC
+	}
+	return event.StartTime.In(loc).Format("Monday, January 2, 2006 at 3:04 PM")
+}
+
+// handleEmailActionPage shows the event a one-click email link is for, with a button that
+// confirms or rejects it
+func (s *Server) handleEmailActionPage(w http.ResponseWriter, r *http.Request) {
+	claims, event := s.loadEmailAction(w, r)
+	if event == nil {
+		return
+	}
+
+	view := emailActionView{
+		Heading:     "Confirm this event?",
+		Event:       event,
+		When:        s.emailActionWhen(event),
+		Button:      "Confirm",
+		ButtonColor: "#28a745",
+	}
+	if claims.Action == notify.EmailActionReject {
+		view.Heading = "Reject this event?"
+		view.Button = "Reject"
+		view.ButtonColor = "#dc3545"
+	}
+	respondEmailActionPage(w, http.StatusOK, view)
+}
+
+// handleEmailAction confirms or rejects the pending event a signed email link is for
+func (s *Server) handleEmailAction(w http.ResponseWriter, r *http.Request) {
+	claims, event := s.loadEmailAction(w, r)
+	if event == nil {
+		return
+	}
+
+	view := emailActionView{Event: event, When: s.emailActionWhen(event)}
+	switch claims.Action {
+	case notify.EmailActionConfirm:
+		updated, err := s.confirmPendingEvent(claims.UserID, event, false)
+		if err != nil {
+			respondEmailActionPage(w, http.StatusInternalServerError, emailActionView{
+				Heading: "Something went wrong",
+				Message: err.Error(),
+			})
+			return
+		}
+		view.Heading = "Event confirmed"
+		if updated != nil {
+			view.Event = updated
+		}
+	case notify.EmailActionReject:
+		if err := s.rejectPendingEvent(event); err != nil {
+			respondEmailActionPage(w, http.StatusInternalServerError, emailActionView{
+				Heading: "Something went wrong",
+				Message: err.Error(),
+			})
+			return
+		}
+		view.Heading = "Event rejected"
+	}
+	respondEmailActionPage(w, http.StatusOK, view)
+}
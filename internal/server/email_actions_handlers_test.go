@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/notify"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleEmailAction(t *testing.T) {
+	s := createTestServer(t)
+	s.emailActions = notify.NewEmailActionSigner([]byte("test-key"))
+	user := database.CreateTestUser(t, s.db)
+
+	channel, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, "email-action@s.whatsapp.net", "Email Action")
+	require.NoError(t, err)
+	created, err := s.db.CreatePendingEvent(&database.CalendarEvent{
+		UserID:     user.ID,
+		ChannelID:  channel.ID,
+		CalendarID: "primary",
+		Title:      "Dentist",
+		StartTime:  time.Now().Add(24 * time.Hour),
+		ActionType: database.EventActionCreate,
+	})
+	require.NoError(t, err)
+
+	call := func(handler http.HandlerFunc, method, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/email-actions/"+token, nil)
+		req.SetPathValue("token", token)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+	token := s.emailActions.Sign(user.ID, created.ID, notify.EmailActionConfirm, time.Now().Add(time.Hour))
+
+	t.Run("GET only shows the event", func(t *testing.T) {
+		w := call(s.handleEmailActionPage, "GET", token)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Dentist")
+
+		event, err := s.db.GetEventByID(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, database.EventStatusPending, event.Status)
+	})
+
+	t.Run("POST confirms the event", func(t *testing.T) {
+		w := call(s.handleEmailAction, "POST", token)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		event, err := s.db.GetEventByID(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, database.EventStatusConfirmed, event.Status)
+	})
+
+	t.Run("a used link reports the event as handled", func(t *testing.T) {
+		reject := s.emailActions.Sign(user.ID, created.ID, notify.EmailActionReject, time.Now().Add(time.Hour))
+		w := call(s.handleEmailAction, "POST", reject)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Already handled")
+
+		event, err := s.db.GetEventByID(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, database.EventStatusConfirmed, event.Status)
+	})
+
+	t.Run("expired and forged links are refused", func(t *testing.T) {
+		expired := s.emailActions.Sign(user.ID, created.ID, notify.EmailActionConfirm, time.Now().Add(-time.Minute))
+		assert.Equal(t, http.StatusGone, call(s.handleEmailAction, "POST", expired).Code)
+
+		forged := notify.NewEmailActionSigner([]byte("other-key")).Sign(user.ID, created.ID, notify.EmailActionConfirm, time.Now().Add(time.Hour))
+		assert.Equal(t, http.StatusBadRequest, call(s.handleEmailAction, "POST", forged).Code)
+	})
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// headerErrorFormat lets a client opt in to the error envelope. Clients that don't send
+// it get the legacy shape, so app versions already installed keep working.
+const (
+	headerErrorFormat   = "X-Error-Format"
+	errorFormatEnvelope = "envelope"
+)
+
+// ErrorCode is a stable, machine-readable error identifier. Clients branch on codes;
+// messages are for people and may change.
+type ErrorCode string
+
+// Codes derived from the HTTP status when a handler doesn't pick a specific one
+const (
+	CodeInvalidRequest     ErrorCode = "invalid_request"
+	CodeAuthRequired       ErrorCode = "authentication_required"
+	CodeForbidden          ErrorCode = "forbidden"
+	CodeNotFound           ErrorCode = "not_found"
+	CodeConflict           ErrorCode = "conflict"
+	CodeGone               ErrorCode = "gone"
+	CodePayloadTooLarge    ErrorCode = "payload_too_large"
+	CodeUnprocessable      ErrorCode = "unprocessable"
+	CodeRateLimited        ErrorCode = "rate_limited"
+	CodeInternal           ErrorCode = "internal_error"
+	CodeUpstreamFailed     ErrorCode = "upstream_failed"
+	CodeServiceUnavailable ErrorCode = "service_unavailable"
+)
+
+// Codes for specific failures
+const (
+	CodeInvalidJSON        ErrorCode = "invalid_json"
+	CodeValidationFailed   ErrorCode = "validation_failed" // details: "fields", one entry per failed field
+	CodeInvalidID          ErrorCode = "invalid_id"
+	CodeInvalidParameter   ErrorCode = "invalid_parameter" // details: "param", and "min"/"max" for ranges
+	CodeInvalidToken       ErrorCode = "invalid_token"
+	CodeInvalidAPIKey      ErrorCode = "invalid_api_key"
+	CodeAPIKeyReadOnly     ErrorCode = "api_key_read_only"
+	CodeAPIKeyNotAllowed   ErrorCode = "api_key_not_allowed"
+	CodeAdminRequired      ErrorCode = "admin_required"
+	CodeEventNotFound      ErrorCode = "event_not_found"
+	CodeEventNotPending    ErrorCode = "event_not_pending"
+	CodeReminderNotFound   ErrorCode = "reminder_not_found"
+	CodeReminderNotPending ErrorCode = "reminder_not_pending"
+	CodeReminderFinal      ErrorCode = "reminder_final"
+	CodeChannelNotFound    ErrorCode = "channel_not_found"
+	CodeSourceNotFound     ErrorCode = "source_not_found"
+	CodeSourceExists       ErrorCode = "source_exists"
+	CodeSourceNotConnected ErrorCode = "source_not_connected"
+	CodeChannelMuted       ErrorCode = "channel_muted"
+	CodeGCalNotConnected   ErrorCode = "gcal_not_connected"
+	CodeGCalNotConfigured  ErrorCode = "gcal_not_configured"
+	CodeGmailNotAuthorized ErrorCode = "gmail_not_authorized"
+	CodeWhatsAppNotReady   ErrorCode = "whatsapp_not_connected"
+	CodeTelegramNotReady   ErrorCode = "telegram_not_connected"
+	CodeAlreadyConnected   ErrorCode = "already_connected"
+	CodeLLMBudgetExceeded  ErrorCode = "llm_budget_exceeded"
+	CodeExportInProgress   ErrorCode = "export_in_progress"
+	CodeExportExpired      ErrorCode = "export_expired"
+	CodeNotConfigured      ErrorCode = "not_configured"
+)
+
+// errorBody is the error envelope: {"error": {"code", "message", "details"}}
+type errorBody struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	Code    ErrorCode      `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// respondError responds with an error whose code follows from status
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondErrorDetails(w, status, statusErrorCode(status), message, nil)
+}
+
+// respondErrorCode responds with an error with a specific code
+func respondErrorCode(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	respondErrorDetails(w, status, code, message, nil)
+}
+
+// respondErrorDetails responds with an error carrying details. Clients that asked for
+// the envelope get it; others get the legacy {"error": message} with the code and
+// details alongside.
+func respondErrorDetails(w http.ResponseWriter, status int, code ErrorCode, message string, details map[string]any) {
+	if wantsErrorEnvelope(w) {
+		respondJSON(w, status, errorBody{Error: apiError{Code: code, Message: message, Details: details}})
+		return
+	}
+
+	legacy := make(map[string]any, len(details)+2)
+	for key, value := range details {
+		legacy[key] = value
+	}
+	legacy["error"] = message
+	legacy["code"] = code
+	respondJSON(w, status, legacy)
+}
+
+// respondInvalidParameter responds 400 for a query or body parameter outside [min, max]
+func respondInvalidParameter(w http.ResponseWriter, param string, min, max int, message string) {
+	respondErrorDetails(w, http.StatusBadRequest, CodeInvalidParameter, message, map[string]any{
+		"param": param,
+		"min":   min,
+		"max":   max,
+	})
+}
+
+// statusErrorCode is the generic code for an HTTP error status
+func statusErrorCode(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeAuthRequired
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeUpstreamFailed
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	default:
+		return CodeInternal
+	}
+}
+
+// errorFormatMiddleware remembers whether the request opted in to the error envelope.
+// respondError only sees the ResponseWriter, so the choice travels with it.
+func errorFormatMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get(headerErrorFormat), errorFormatEnvelope) {
+			w = &envelopeErrorWriter{ResponseWriter: w}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wantsErrorEnvelope reports whether w belongs to a request that opted in to the envelope
+func wantsErrorEnvelope(w http.ResponseWriter) bool {
+	for w != nil {
+		if _, ok := w.(*envelopeErrorWriter); ok {
+			return true
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = unwrapper.Unwrap()
+	}
+	return false
+}
+
+// envelopeErrorWriter marks a response whose errors use the envelope, passing through
+// the optional interfaces streaming and websocket handlers rely on
+type envelopeErrorWriter struct {
+	http.ResponseWriter
+}
+
+func (w *envelopeErrorWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *envelopeErrorWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response does not support hijacking")
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *envelopeErrorWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
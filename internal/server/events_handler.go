@@ -2,13 +2,19 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/omriShneor/project_alfred/internal/auth"
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/gcal"
+	"github.com/omriShneor/project_alfred/internal/ics"
 )
 
 func (s *Server) handleListEvents(w http.ResponseWriter, r *http.Request) {
@@ -34,12 +40,27 @@ func (s *Server) handleListEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	events, err := s.db.ListEvents(userID, status, channelID)
+	opts, err := parseListOptions(r, s.getUserTimezone(userID))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := parseLabelFilter(r, &opts); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, total, err := s.db.ListEventsWithOptions(userID, status, channelID, opts)
+	if errors.Is(err, database.ErrInvalidSort) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	setPaginationHeaders(w, opts, len(events), total)
 	respondJSON(w, http.StatusOK, events)
 }
 
@@ -50,15 +71,21 @@ func (s *Server) handleGetEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A route wildcard must be a whole segment, so /api/events/{id}.ics lands here
+	if rawID, ok := strings.CutSuffix(r.PathValue("id"), ".ics"); ok {
+		s.handleGetEventICS(w, userID, rawID)
+		return
+	}
+
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	event, err := s.db.GetEventByID(id)
 	if err != nil || event.UserID != userID {
-		respondError(w, http.StatusNotFound, "event not found")
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
 		return
 	}
 
@@ -67,16 +94,31 @@ func (s *Server) handleGetEvent(w http.ResponseWriter, r *http.Request) {
 		"event": event,
 	}
 
+	// Shared copies point at a message in the owner's channel; only show it while the
+	// user still has access to that channel
 	if event.OriginalMsgID != nil {
-		msg, err := s.db.GetMessageByID(*event.OriginalMsgID)
-		if err == nil {
-			response["trigger_message"] = msg
+		if canView, _ := s.db.HasChannelAccess(userID, event.ChannelID); canView {
+			msg, err := s.db.GetMessageByID(*event.OriginalMsgID)
+			if err == nil {
+				response["trigger_message"] = msg
+				if attachments := s.eventAttachments(event); len(attachments) > 0 {
+					response["attachments"] = attachments
+				}
+			}
 		}
 	}
 
 	respondJSON(w, http.StatusOK, response)
 }
 
+// eventRecurrence returns the RRULE lines to send to Google Calendar for a repeating event
+func eventRecurrence(event *database.CalendarEvent) []string {
+	if event.Recurrence == "" {
+		return nil
+	}
+	return []string{event.Recurrence}
+}
+
 func (s *Server) handleConfirmEvent(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
 	if err != nil {
@@ -86,21 +128,103 @@ func (s *Server) handleConfirmEvent(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	event, err := s.db.GetEventByID(id)
 	if err != nil || event.UserID != userID {
-		respondError(w, http.StatusNotFound, "event not found")
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
 		return
 	}
 
 	if event.Status != database.EventStatusPending {
-		respondError(w, http.StatusBadRequest, "event is not pending")
+		respondErrorCode(w, http.StatusBadRequest, CodeEventNotPending, "event is not pending")
 		return
 	}
 
+	// The body is optional
+	var req ConfirmEventRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+			return
+		}
+	}
+	sendInvites := req.SendInvites && event.ActionType != database.EventActionDelete && len(event.Attendees) > 0
+	if sendInvites && (s.notifyService == nil || !s.notifyService.IsEmailAvailable()) {
+		respondError(w, http.StatusBadRequest, "email is not configured on this server")
+		return
+	}
+
+	updatedEvent, err := s.confirmPendingEvent(userID, event, sendInvites)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if sendInvites {
+		s.sendEventInvites(r, userID, updatedEvent)
+	}
+	respondJSON(w, http.StatusOK, updatedEvent)
+}
+
+// ConfirmEventRequest is the optional body of POST /api/events/{id}/confirm
+type ConfirmEventRequest struct {
+	// SendInvites emails attendees an iCal invite from Alfred instead of Google
+	SendInvites bool `json:"send_invites"`
+}
+
+// sendEventInvites emails the event's attendees an invite from the user. Failures are
+// logged; the event is already confirmed.
+func (s *Server) sendEventInvites(r *http.Request, userID int64, event *database.CalendarEvent) {
+	organizer := ics.Person{}
+	if user := auth.GetUserFromContext(r.Context()); user != nil {
+		organizer = ics.Person{Name: user.Name, Email: user.Email}
+	}
+	if organizer.Email == "" {
+		email, err := s.db.GetUserEmail(userID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to look up invite organizer", "user_id", userID, "error", err)
+			return
+		}
+		organizer.Email = email
+	}
+
+	attendees := make([]ics.Person, len(event.Attendees))
+	for i, a := range event.Attendees {
+		attendees[i] = ics.Person{Name: a.DisplayName, Email: a.Email}
+	}
+
+	loc, err := time.LoadLocation(s.getUserTimezone(userID))
+	if err != nil {
+		loc = time.UTC
+	}
+	if err := s.notifyService.SendEventInvites(r.Context(), event, organizer, attendees, loc); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to send event invites", "event_id", event.ID, "error", err)
+	}
+}
+
+// confirmPendingEvent applies a pending event's action, syncing it to Google Calendar when
+// sync is enabled, and returns the updated event. New events from a channel mapped to a
+// calendar in the gcal settings go to that calendar. With ownInvites, Google is told not
+// to email attendees because Alfred sends the invites.
+func (s *Server) confirmPendingEvent(userID int64, event *database.CalendarEvent, ownInvites bool) (*database.CalendarEvent, error) {
+	id := event.ID
+	var err error
+
+	calendarID := event.CalendarID
+	if event.ActionType == database.EventActionCreate {
+		channelCalendarID, err := s.db.GetChannelCalendarID(userID, event.ChannelID)
+		if err != nil {
+			slog.Warn("Failed to look up channel calendar", "event_id", id, "error", err)
+		} else if channelCalendarID != "" && channelCalendarID != calendarID {
+			if err := s.db.UpdateEventCalendarID(id, channelCalendarID); err != nil {
+				return nil, fmt.Errorf("failed to route event to channel calendar: %w", err)
+			}
+			calendarID = channelCalendarID
+		}
+	}
+
 	// Check if sync is enabled and Google Calendar is connected
 	gcalSettings, _ := s.db.GetGCalSettings(userID)
 	userGCalClient := s.getGCalClientForUser(userID)
@@ -116,13 +240,12 @@ func (s *Server) handleConfirmEvent(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err := s.db.UpdateEventStatus(id, newStatus); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to confirm event: %v", err))
-			return
+			return nil, fmt.Errorf("failed to confirm event: %w", err)
 		}
 
 		updatedEvent, _ := s.db.GetEventByID(id)
-		respondJSON(w, http.StatusOK, updatedEvent)
-		return
+		s.notifyEventConfirmed(updatedEvent)
+		return updatedEvent, nil
 	}
 
 	// Sync to Google Calendar
@@ -141,31 +264,32 @@ func (s *Server) handleConfirmEvent(w http.ResponseWriter, r *http.Request) {
 			attendeeEmails[i] = a.Email
 		}
 
-		googleEventID, err = userGCalClient.CreateEvent(event.CalendarID, gcal.EventInput{
+		googleEventID, err = userGCalClient.CreateEvent(calendarID, gcal.EventInput{
 			Summary:     event.Title,
 			Description: event.Description,
 			Location:    event.Location,
 			StartTime:   event.StartTime,
 			EndTime:     endTime,
 			Attendees:   attendeeEmails,
+			Recurrence:  eventRecurrence(event),
+			TimeZone:    s.getUserTimezone(userID),
+
+			SkipAttendeeEmails: ownInvites,
 		})
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create calendar event: %v", err))
-			return
+			return nil, fmt.Errorf("failed to create calendar event: %w", err)
 		}
 
 		// Update database with Google event ID
 		if err := s.db.UpdateEventGoogleID(id, googleEventID); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update event: %v", err))
-			return
+			return nil, fmt.Errorf("failed to update event: %w", err)
 		}
 
 	case database.EventActionUpdate:
 		// If no Google event ID, just confirm locally (event was created before sync was enabled)
 		if event.GoogleEventID == nil {
 			if err := s.db.UpdateEventStatus(id, database.EventStatusConfirmed); err != nil {
-				respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to confirm event: %v", err))
-				return
+				return nil, fmt.Errorf("failed to confirm event: %w", err)
 			}
 			break
 		}
@@ -188,42 +312,53 @@ func (s *Server) handleConfirmEvent(w http.ResponseWriter, r *http.Request) {
 			StartTime:   event.StartTime,
 			EndTime:     endTime,
 			Attendees:   updateAttendeeEmails,
+			Recurrence:  eventRecurrence(event),
+			TimeZone:    s.getUserTimezone(userID),
+
+			SkipAttendeeEmails: ownInvites,
 		})
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update calendar event: %v", err))
-			return
+			return nil, fmt.Errorf("failed to update calendar event: %w", err)
 		}
 
 		if err := s.db.UpdateEventStatus(id, database.EventStatusSynced); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update event status: %v", err))
-			return
+			return nil, fmt.Errorf("failed to update event status: %w", err)
 		}
 
 	case database.EventActionDelete:
 		// If no Google event ID, just mark as deleted locally
 		if event.GoogleEventID == nil {
 			if err := s.db.UpdateEventStatus(id, database.EventStatusDeleted); err != nil {
-				respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete event: %v", err))
-				return
+				return nil, fmt.Errorf("failed to delete event: %w", err)
 			}
 			break
 		}
 
 		err = userGCalClient.DeleteEvent(event.CalendarID, *event.GoogleEventID)
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to delete calendar event: %v", err))
-			return
+			return nil, fmt.Errorf("failed to delete calendar event: %w", err)
 		}
 
 		if err := s.db.UpdateEventStatus(id, database.EventStatusDeleted); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update event status: %v", err))
-			return
+			return nil, fmt.Errorf("failed to update event status: %w", err)
 		}
 	}
 
 	// Get updated event
 	updatedEvent, _ := s.db.GetEventByID(id)
-	respondJSON(w, http.StatusOK, updatedEvent)
+	s.notifyEventConfirmed(updatedEvent)
+	return updatedEvent, nil
+}
+
+// notifyEventConfirmed fans out event.confirmed for events that were confirmed or synced
+// (confirming a delete action is not a confirmation of the event itself)
+func (s *Server) notifyEventConfirmed(event *database.CalendarEvent) {
+	if s.notifyService == nil || event == nil {
+		return
+	}
+	if event.Status == database.EventStatusConfirmed || event.Status == database.EventStatusSynced {
+		s.notifyService.NotifyEventConfirmed(event)
+	}
 }
 
 func (s *Server) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
@@ -235,13 +370,13 @@ func (s *Server) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	event, err := s.db.GetEventByID(id)
 	if err != nil || event.UserID != userID {
-		respondError(w, http.StatusNotFound, "event not found")
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
 		return
 	}
 
@@ -263,7 +398,7 @@ func (s *Server) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
 		return
 	}
 
@@ -302,6 +437,13 @@ func (s *Server) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Remember what the agent got wrong; failing to record it shouldn't fail the edit
+	if database.IsMaterialEventEdit(event, req.Title, startTime, req.Location) {
+		if err := s.db.RecordEventEdit(event, req.Title, startTime, req.Location); err != nil {
+			slog.WarnContext(r.Context(), "failed to record correction", "event_id", id, "error", err)
+		}
+	}
+
 	// Update attendees
 	attendees := make([]database.Attendee, len(req.Attendees))
 	for i, a := range req.Attendees {
@@ -328,22 +470,22 @@ func (s *Server) handleRejectEvent(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	event, err := s.db.GetEventByID(id)
 	if err != nil || event.UserID != userID {
-		respondError(w, http.StatusNotFound, "event not found")
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
 		return
 	}
 
 	if event.Status != database.EventStatusPending {
-		respondError(w, http.StatusBadRequest, "event is not pending")
+		respondErrorCode(w, http.StatusBadRequest, CodeEventNotPending, "event is not pending")
 		return
 	}
 
-	if err := s.db.UpdateEventStatus(id, database.EventStatusRejected); err != nil {
+	if err := s.rejectPendingEvent(event); err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -351,6 +493,116 @@ func (s *Server) handleRejectEvent(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "rejected"})
 }
 
+// rejectPendingEvent marks a pending event rejected and records the rejection as a
+// correction for the event agent
+func (s *Server) rejectPendingEvent(event *database.CalendarEvent) error {
+	if err := s.db.UpdateEventStatus(event.ID, database.EventStatusRejected); err != nil {
+		return err
+	}
+	if err := s.db.RecordEventRejection(event); err != nil {
+		slog.Warn("failed to record correction", "event_id", event.ID, "error", err)
+	}
+	return nil
+}
+
+// handleGetEventNotifications returns the effective pre-start notification offsets for an event
+func (s *Server) handleGetEventNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	event, err := s.db.GetEventByID(id)
+	if err != nil || event.UserID != userID {
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
+		return
+	}
+
+	s.respondEventNotifications(w, event)
+}
+
+// handleUpdateEventNotifications overrides (or resets to the user default) an event's
+// pre-start notification offsets
+func (s *Server) handleUpdateEventNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	event, err := s.db.GetEventByID(id)
+	if err != nil || event.UserID != userID {
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
+		return
+	}
+
+	var req struct {
+		OffsetsMinutes *[]int `json:"offsets_minutes"`
+		UseDefault     bool   `json:"use_default"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+
+	var offsets []int
+	if !req.UseDefault {
+		if req.OffsetsMinutes == nil {
+			respondError(w, http.StatusBadRequest, "offsets_minutes or use_default is required")
+			return
+		}
+		if err := database.ValidateNotifyOffsets(*req.OffsetsMinutes); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		offsets = append([]int{}, *req.OffsetsMinutes...)
+	}
+
+	if err := s.db.SetEventNotifyOffsetsOverride(event.ID, offsets); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.respondEventNotifications(w, event)
+}
+
+func (s *Server) respondEventNotifications(w http.ResponseWriter, event *database.CalendarEvent) {
+	override, err := s.db.GetEventNotifyOffsetsOverride(event.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	offsets := override
+	if override == nil {
+		prefs, err := s.db.GetUserNotificationPrefs(event.UserID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		offsets = prefs.EventNotifyOffsets
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"event_id":        event.ID,
+		"offsets_minutes": offsets,
+		"is_override":     override != nil,
+	})
+}
+
 func (s *Server) handleGetChannelHistory(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
 	if err != nil {
@@ -360,17 +612,18 @@ func (s *Server) handleGetChannelHistory(w http.ResponseWriter, r *http.Request)
 
 	channelID, err := strconv.ParseInt(r.PathValue("channelId"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid channel id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid channel id")
 		return
 	}
 
-	channel, err := s.db.GetSourceChannelByID(userID, channelID)
+	// Owners and accepted members of a shared channel can read its history
+	canView, err := s.db.HasChannelAccess(userID, channelID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if channel == nil {
-		respondError(w, http.StatusNotFound, "channel not found")
+	if !canView {
+		respondErrorCode(w, http.StatusNotFound, CodeChannelNotFound, "channel not found")
 		return
 	}
 
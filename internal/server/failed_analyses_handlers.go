@@ -0,0 +1,75 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// handleListFailedAnalyses returns failed analyses across all users, newest first.
+// Optional filters: status (pending, resolved or dead), limit (1-500).
+func (s *Server) handleListFailedAnalyses(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var status *database.FailedAnalysisStatus
+	if raw := query.Get("status"); raw != "" {
+		st := database.FailedAnalysisStatus(raw)
+		if st != database.FailedAnalysisPending && st != database.FailedAnalysisResolved && st != database.FailedAnalysisDead {
+			respondError(w, http.StatusBadRequest, "status must be pending, resolved or dead")
+			return
+		}
+		status = &st
+	}
+
+	limit := 100
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > 500 {
+			respondInvalidParameter(w, "limit", 1, 500, "limit must be between 1 and 500")
+			return
+		}
+		limit = parsed
+	}
+
+	analyses, err := s.db.ListFailedAnalyses(status, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, analyses)
+}
+
+// handleRetryFailedAnalysis makes a failed analysis due for retry now, including one whose
+// retries were exhausted. The processor picks it up on its next retry pass.
+func (s *Server) handleRetryFailedAnalysis(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid failed analysis ID")
+		return
+	}
+
+	scheduled, err := s.db.ScheduleFailedAnalysisRetry(id, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "failed analysis not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !scheduled {
+		respondError(w, http.StatusConflict, "failed analysis is already resolved")
+		return
+	}
+
+	analysis, err := s.db.GetFailedAnalysis(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusAccepted, analysis)
+}
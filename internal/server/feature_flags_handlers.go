@@ -0,0 +1,73 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// SetFeatureFlagRequest is the body of PUT /api/admin/feature-flags/{name}. Without
+// UserIDs it sets the instance default; with them it sets each user's own value. A null
+// Enabled clears the value instead.
+type SetFeatureFlagRequest struct {
+	Enabled *bool   `json:"enabled"`
+	UserIDs []int64 `json:"user_ids"`
+}
+
+// handleListFeatureFlagDefaults returns every feature flag's value for users without
+// their own setting
+func (s *Server) handleListFeatureFlagDefaults(w http.ResponseWriter, r *http.Request) {
+	flags, err := s.db.GetFeatureFlagDefaults()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, flags)
+}
+
+// handleSetFeatureFlag rolls a feature flag out to the whole instance or to specific users
+func (s *Server) handleSetFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, ok := database.LookupFeatureFlag(name); !ok {
+		respondError(w, http.StatusNotFound, "unknown feature flag")
+		return
+	}
+
+	var req SetFeatureFlagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	if len(req.UserIDs) == 0 {
+		if err := s.db.SetFeatureFlagDefault(name, req.Enabled); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.handleListFeatureFlagDefaults(w, r)
+		return
+	}
+
+	// Check every user before changing anything
+	for _, userID := range req.UserIDs {
+		if _, err := s.db.GetUserEmail(userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				respondError(w, http.StatusNotFound, fmt.Sprintf("user %d not found", userID))
+				return
+			}
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	for _, userID := range req.UserIDs {
+		if err := s.db.SetFeatureFlag(userID, name, req.Enabled); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"name": name, "enabled": req.Enabled, "user_ids": req.UserIDs})
+}
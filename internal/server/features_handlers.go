@@ -1,9 +1,13 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+
+	"github.com/omriShneor/project_alfred/internal/database"
 )
 
 // ---- Simplified App Status API (new navigation flow) ----
@@ -144,7 +148,7 @@ func (s *Server) handleCompleteOnboarding(w http.ResponseWriter, r *http.Request
 
 	var req CompleteOnboardingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
 		return
 	}
 
@@ -171,18 +175,135 @@ func (s *Server) handleCompleteOnboarding(w http.ResponseWriter, r *http.Request
 	s.handleGetAppStatus(w, r)
 }
 
-// handleResetOnboarding resets the onboarding status (for testing)
-func (s *Server) handleResetOnboarding(w http.ResponseWriter, r *http.Request) {
+// ---- Optional Analyzers API ----
+
+// UpdateFeatureSettingsRequest is the body of PUT /api/settings/features.
+// Omitted fields are left unchanged. In Flags, null clears the user's own value so the
+// instance default applies again.
+type UpdateFeatureSettingsRequest struct {
+	BillDetectionEnabled      *bool            `json:"bill_detection_enabled"`
+	CorrectionExamplesEnabled *bool            `json:"correction_examples_enabled"`
+	MinConfidence             *float64         `json:"min_confidence"`
+	AutoConfirmConfidence     *float64         `json:"auto_confirm_confidence"`
+	Flags                     map[string]*bool `json:"flags"`
+}
+
+func featureSettingsResponse(settings *database.FeatureSettings, flags []database.FeatureFlagState) map[string]any {
+	return map[string]any{
+		"bill_detection_enabled":      settings.BillDetectionEnabled,
+		"correction_examples_enabled": settings.CorrectionExamplesEnabled,
+		"min_confidence":              settings.MinConfidence,
+		"auto_confirm_confidence":     settings.AutoConfirmConfidence,
+		"flags":                       flags,
+	}
+}
+
+// unknownFeatureFlag returns the first name in flags that isn't a known feature flag
+func unknownFeatureFlag(flags map[string]*bool) string {
+	for name := range flags {
+		if _, ok := database.LookupFeatureFlag(name); !ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// handleGetFeatureSettings returns the user's optional analyzers, confidence thresholds
+// and feature flags
+func (s *Server) handleGetFeatureSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	settings, err := s.db.GetFeatureSettings(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	flags, err := s.db.GetFeatureFlags(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, featureSettingsResponse(settings, flags))
+}
+
+// handleUpdateFeatureSettings turns optional analyzers and feature flags on or off for the user
+func (s *Server) handleUpdateFeatureSettings(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
 	if err != nil {
 		respondError(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
 
+	var req UpdateFeatureSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	// Validate the thresholds together before changing anything
+	var thresholds *database.ConfidenceThresholds
+	if req.MinConfidence != nil || req.AutoConfirmConfidence != nil {
+		current, err := s.db.GetConfidenceThresholds(userID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if req.MinConfidence != nil {
+			current.MinConfidence = *req.MinConfidence
+		}
+		if req.AutoConfirmConfidence != nil {
+			current.AutoConfirmConfidence = *req.AutoConfirmConfidence
+		}
+		if err := current.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		thresholds = &current
+	}
+	if name := unknownFeatureFlag(req.Flags); name != "" {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown feature flag %q", name))
+		return
+	}
+
+	if req.BillDetectionEnabled != nil {
+		if err := s.db.SetBillDetectionEnabled(userID, *req.BillDetectionEnabled); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if req.CorrectionExamplesEnabled != nil {
+		if err := s.db.SetCorrectionExamplesEnabled(userID, *req.CorrectionExamplesEnabled); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if thresholds != nil {
+		if err := s.db.SetConfidenceThresholds(userID, *thresholds); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	for name, enabled := range req.Flags {
+		if err := s.db.SetFeatureFlag(userID, name, enabled); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	s.handleGetFeatureSettings(w, r)
+}
+
+// resetUserOnboarding logs the user's clients out, stops their services, purges their
+// data and signs them out everywhere
+func (s *Server) resetUserOnboarding(ctx context.Context, userID int64) error {
 	// Reset all client sessions for this user (full logout with session deletion)
 	if s.clientManager != nil {
 		if err := s.clientManager.ResetUserSessions(userID); err != nil {
-			fmt.Printf("Warning: Failed to reset user sessions: %v\n", err)
+			slog.WarnContext(ctx, "Failed to reset user sessions", "error", err)
 		}
 	}
 
@@ -193,16 +314,30 @@ func (s *Server) handleResetOnboarding(w http.ResponseWriter, r *http.Request) {
 
 	// Reset database state (purges all user-scoped data and resets feature flags)
 	if err := s.db.ResetOnboarding(userID); err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
-		return
+		return err
 	}
 
 	// Delete all user sessions (forces re-login)
 	if s.authService != nil {
 		if err := s.authService.DeleteAllUserSessions(userID); err != nil {
-			fmt.Printf("Warning: Failed to delete user sessions: %v\n", err)
+			slog.WarnContext(ctx, "Failed to delete user sessions", "error", err)
 		}
 	}
+	return nil
+}
+
+// handleResetOnboarding resets the onboarding status (for testing)
+func (s *Server) handleResetOnboarding(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if err := s.resetUserOnboarding(r.Context(), userID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	// Reset SSE state
 	if s.state != nil {
@@ -1,9 +1,12 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -78,3 +81,188 @@ func TestHandleGetAppStatusStartsServicesWhenGmailScopeCached(t *testing.T) {
 		return userServiceManager.IsRunningForUser(testUser.ID)
 	}, 2*time.Second, 20*time.Millisecond)
 }
+
+func TestHandleUpdateFeatureSettingsConfidenceThresholds(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/settings/features", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.handleUpdateFeatureSettings(w, withAuthContext(req, user))
+		return w
+	}
+
+	w := update(`{"min_confidence": 0.5, "auto_confirm_confidence": 0.9}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.InDelta(t, 0.5, resp["min_confidence"], 1e-9)
+	assert.InDelta(t, 0.9, resp["auto_confirm_confidence"], 1e-9)
+
+	// A single threshold is validated against the stored other one
+	w = update(`{"auto_confirm_confidence": 0.4}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = update(`{"auto_confirm_confidence": 0}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	thresholds, err := s.db.GetConfidenceThresholds(user.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, thresholds.MinConfidence, 1e-9)
+	assert.Zero(t, thresholds.AutoConfirmConfidence)
+}
+
+func TestHandleUpdateFeatureSettingsFlags(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/settings/features", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.handleUpdateFeatureSettings(w, withAuthContext(req, user))
+		return w
+	}
+
+	w := update(`{"flags": {"travel_detection": false, "bill_detection": true}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		BillDetectionEnabled bool                        `json:"bill_detection_enabled"`
+		Flags                []database.FeatureFlagState `json:"flags"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.BillDetectionEnabled, "the legacy field follows the flag")
+	flags := make(map[string]database.FeatureFlagState)
+	for _, flag := range resp.Flags {
+		flags[flag.Name] = flag
+	}
+	assert.False(t, flags[database.FeatureTravelDetection].Enabled)
+	assert.Equal(t, database.FeatureSourceUser, flags[database.FeatureTravelDetection].Source)
+	assert.Equal(t, database.FeatureSourceDefault, flags[database.FeatureGmailAnalysis].Source)
+
+	// null clears the user's own value
+	w = update(`{"flags": {"travel_detection": null}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	enabled, err := s.db.IsFeatureEnabled(user.ID, database.FeatureTravelDetection)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	// Unknown flags are rejected before anything changes
+	w = update(`{"bill_detection_enabled": false, "flags": {"teleport": true}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	enabled, err = s.db.IsBillDetectionEnabled(user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+}
+
+func TestHandleSetFeatureFlag(t *testing.T) {
+	s := createTestServer(t)
+	early := database.CreateTestUserWithEmail(t, s.db, "early@example.com")
+	other := database.CreateTestUserWithEmail(t, s.db, "other@example.com")
+
+	set := func(name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/feature-flags/"+name, strings.NewReader(body))
+		req.SetPathValue("name", name)
+		w := httptest.NewRecorder()
+		s.handleSetFeatureFlag(w, req)
+		return w
+	}
+
+	w := set(database.FeatureBillDetection, `{"enabled": true, "user_ids": [`+strconv.FormatInt(early.ID, 10)+`]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	enabled, err := s.db.IsFeatureEnabled(early.ID, database.FeatureBillDetection)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+	enabled, err = s.db.IsFeatureEnabled(other.ID, database.FeatureBillDetection)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	w = set(database.FeatureGmailAnalysis, `{"enabled": false}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	enabled, err = s.db.IsFeatureEnabled(other.ID, database.FeatureGmailAnalysis)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	assert.Equal(t, http.StatusNotFound, set("teleport", `{"enabled": true}`).Code)
+	assert.Equal(t, http.StatusNotFound, set(database.FeatureBillDetection, `{"enabled": true, "user_ids": [99999]}`).Code)
+}
+
+func TestOnboardingSteps(t *testing.T) {
+	s := createTestServer(t)
+	s.streams = sse.NewStateManager()
+	user := database.CreateTestUser(t, s.db)
+
+	decode := func(w *httptest.ResponseRecorder) OnboardingStepsResponse {
+		var resp OnboardingStepsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	get := func() OnboardingStepsResponse {
+		req := httptest.NewRequest(http.MethodGet, "/api/onboarding/steps", nil)
+		w := httptest.NewRecorder()
+		s.handleGetOnboardingSteps(w, withAuthContext(req, user))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return decode(w)
+	}
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/onboarding/steps", strings.NewReader(body))
+		w := &envelopeErrorWriter{ResponseWriter: httptest.NewRecorder()}
+		s.handleUpdateOnboardingSteps(w, withAuthContext(req, user))
+		return w.ResponseWriter.(*httptest.ResponseRecorder)
+	}
+
+	resp := get()
+	assert.Equal(t, database.OnboardingStepWelcome, resp.CurrentStep)
+	assert.False(t, resp.Complete)
+	assert.Len(t, resp.Steps, len(database.OnboardingSteps))
+
+	updates := s.streams.Subscribe(user.ID)
+	defer s.streams.Unsubscribe(user.ID, updates)
+
+	w := put(`{"steps": [
+		{"step": "welcome", "status": "completed"},
+		{"step": "input_selection", "status": "completed", "data": {"whatsapp": true}},
+		{"step": "telegram", "status": "skipped"},
+		{"step": "gmail", "status": "skipped"}
+	]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp = decode(w)
+	assert.Equal(t, database.OnboardingStepWhatsApp, resp.CurrentStep)
+	assert.JSONEq(t, `{"whatsapp": true}`, string(resp.Steps[1].Data))
+
+	select {
+	case update := <-updates:
+		assert.Equal(t, sse.UpdateOnboardingSteps, update.Type)
+	case <-time.After(time.Second):
+		t.Fatal("no onboarding_steps update published")
+	}
+
+	// A connection made since is picked up, and the user resumes after it
+	require.NoError(t, s.db.SaveWhatsAppSession(user.ID, "+15551234567", "15551234567@s.whatsapp.net", true))
+	resp = get()
+	assert.Equal(t, database.OnboardingStepCompleted, resp.Steps[2].Status)
+	assert.Equal(t, database.OnboardingStepGoogleCalendar, resp.CurrentStep)
+
+	w = put(`{"steps": [{"step": "sms", "status": "done"}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"validation_failed"`)
+	assert.Contains(t, w.Body.String(), `"steps[0].step"`)
+
+	require.NoError(t, s.db.CompleteOnboarding(user.ID, true, false, false))
+	resp = get()
+	assert.True(t, resp.Complete)
+	assert.Empty(t, resp.CurrentStep)
+}
+
+func TestRestoreOnboardingState(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+	require.NoError(t, s.db.SetOnboardingStep(user.ID, database.OnboardingStepWhatsApp, database.OnboardingStepCompleted, nil))
+	require.NoError(t, s.db.SetOnboardingStep(user.ID, database.OnboardingStepTelegram, database.OnboardingStepInProgress, nil))
+
+	s.RestoreOnboardingState()
+
+	status := s.onboardingState.GetStatus()
+	assert.Equal(t, "connected", status.WhatsApp.Status)
+	assert.Equal(t, "pending", status.Telegram.Status)
+	assert.False(t, status.Complete)
+}
@@ -2,10 +2,23 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"sort"
 	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/timeutil"
+)
+
+const (
+	// maxCalendarRange bounds GET /api/calendar; a month view with leading and trailing
+	// weeks fits comfortably
+	maxCalendarRange = 92 * 24 * time.Hour
+	// maxCalendarEvents caps the Alfred events listed per range
+	maxCalendarEvents = 2000
 )
 
 // Google Calendar API
@@ -17,9 +30,10 @@ func (s *Server) handleGCalStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	status := map[string]interface{}{
-		"connected":  false,
-		"message":    "Not configured",
-		"has_scopes": false,
+		"connected":       false,
+		"message":         "Not configured",
+		"has_scopes":      false,
+		"reauth_required": false,
 	}
 
 	if s.credentialsFile == "" {
@@ -34,6 +48,13 @@ func (s *Server) handleGCalStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.googleReauthRequired(userID) {
+		status["reauth_required"] = true
+		status["message"] = "Google access expired or was revoked. Please reconnect Google Calendar."
+		respondJSON(w, http.StatusOK, status)
+		return
+	}
+
 	// Get per-user gcal client
 	userGCalClient := s.getGCalClientForUser(userID)
 
@@ -107,7 +128,7 @@ func (s *Server) handleListTodayEvents(w http.ResponseWriter, r *http.Request) {
 	userGCalClient := s.getGCalClientForUser(userID)
 
 	if userGCalClient == nil || !userGCalClient.IsAuthenticated() {
-		respondError(w, http.StatusServiceUnavailable, "Google Calendar not connected")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeGCalNotConnected, "Google Calendar not connected")
 		return
 	}
 
@@ -137,6 +158,8 @@ type TodayEventResponse struct {
 	AllDay      bool   `json:"all_day"`
 	CalendarID  string `json:"calendar_id"`
 	Source      string `json:"source"` // "alfred", "google", "outlook"
+
+	start time.Time
 }
 
 // handleListMergedTodayEvents returns merged events from Alfred Calendar + external calendars
@@ -147,33 +170,61 @@ func (s *Server) handleListMergedTodayEvents(w http.ResponseWriter, r *http.Requ
 		respondError(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
-	var events []TodayEventResponse
+
+	// Today in server local time, matching the Google today view
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	respondJSON(w, http.StatusOK, s.mergedCalendarEvents(r, userID, startOfDay, startOfDay.Add(24*time.Hour)))
+}
+
+// handleListCalendar returns merged Alfred and Google Calendar events overlapping a range,
+// for the app's week and month views.
+// Query: start, end (required; RFC 3339 or YYYY-MM-DD in the user's timezone, a date for
+// end includes that day). calendar_id overrides the Google calendar.
+func (s *Server) handleListCalendar(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	start, end, err := parseCalendarRange(r.URL.Query().Get("start"), r.URL.Query().Get("end"), s.getUserTimezone(userID))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, s.mergedCalendarEvents(r, userID, start, end))
+}
+
+// mergedCalendarEvents lists confirmed Alfred events and Google Calendar events
+// overlapping [start, end), ordered by start time. Alfred events synced to Google are
+// listed once. Both sources are best-effort: a failing source is logged and skipped.
+func (s *Server) mergedCalendarEvents(r *http.Request, userID int64, start, end time.Time) []TodayEventResponse {
+	events := []TodayEventResponse{}
 
 	// Track Google Event IDs to avoid duplicates
 	seenGoogleIDs := make(map[string]bool)
 
 	// 1. Always get Alfred Calendar events (local database)
-	alfredEvents, err := s.db.GetTodayEvents(userID)
+	alfredEvents, err := s.db.ListEventsInRange(userID, start, end, maxCalendarEvents)
 	if err != nil {
-		// Ignore error - Alfred events are best-effort
-		alfredEvents = nil
+		slog.Warn("calendar: failed to list Alfred events", "user_id", userID, "error", err)
 	}
 
 	for _, e := range alfredEvents {
+		if e.Status == database.EventStatusPending {
+			// Awaiting review; not on the calendar yet
+			continue
+		}
+
 		endTime := e.StartTime.Add(1 * time.Hour) // Default 1 hour duration
 		if e.EndTime != nil {
 			endTime = *e.EndTime
 		}
 
-		eventSource := "alfred"
-		switch e.ChannelSourceType {
-		case "google_calendar":
-			eventSource = "google"
-		case "outlook_calendar":
-			eventSource = "outlook"
-		}
-
-		event := TodayEventResponse{
+		events = append(events, TodayEventResponse{
 			ID:          fmt.Sprintf("alfred-%d", e.ID),
 			Summary:     e.Title,
 			Description: e.Description,
@@ -182,9 +233,9 @@ func (s *Server) handleListMergedTodayEvents(w http.ResponseWriter, r *http.Requ
 			EndTime:     endTime.Format(time.RFC3339),
 			AllDay:      false,
 			CalendarID:  "alfred",
-			Source:      eventSource,
-		}
-		events = append(events, event)
+			Source:      alfredEventSource(e),
+			start:       e.StartTime,
+		})
 
 		// Track if this event is synced to Google
 		if e.GoogleEventID != nil {
@@ -193,54 +244,102 @@ func (s *Server) handleListMergedTodayEvents(w http.ResponseWriter, r *http.Requ
 	}
 
 	// 2. Get Google Calendar events when the account is connected.
-	// Prefer query param, otherwise user-selected calendar, then primary.
-	selectedCalendarID := r.URL.Query().Get("calendar_id")
-	if selectedCalendarID == "" {
-		gcalSettings, err := s.db.GetGCalSettings(userID)
-		if err == nil && gcalSettings.SelectedCalendarID != "" {
-			selectedCalendarID = gcalSettings.SelectedCalendarID
-		}
-	}
-	if selectedCalendarID == "" {
-		selectedCalendarID = "primary"
-	}
-
 	userGCalClient := s.getGCalClientForUser(userID)
 	if userGCalClient != nil && userGCalClient.IsAuthenticated() {
-		gcalEvents, err := userGCalClient.ListTodayEvents(selectedCalendarID)
+		selectedCalendarID := s.requestedCalendarID(r, userID)
+		gcalEvents, err := userGCalClient.ListEventsInRangeBatched(selectedCalendarID, start, end)
 		if err != nil && selectedCalendarID != "primary" {
 			// Fall back to primary if selected calendar is no longer accessible.
-			gcalEvents, err = userGCalClient.ListTodayEvents("primary")
+			gcalEvents, err = userGCalClient.ListEventsInRangeBatched("primary", start, end)
 		}
-		if err == nil {
-			for _, ge := range gcalEvents {
-				// Skip if already seen (synced from Alfred)
-				if seenGoogleIDs[ge.ID] {
-					continue
-				}
+		if err != nil {
+			slog.Warn("calendar: failed to list Google Calendar events", "user_id", userID, "error", err)
+		}
+		for _, ge := range gcalEvents {
+			// Skip if already seen (synced from Alfred)
+			if seenGoogleIDs[ge.ID] {
+				continue
+			}
 
-				event := TodayEventResponse{
-					ID:          ge.ID,
-					Summary:     ge.Summary,
-					Description: ge.Description,
-					Location:    ge.Location,
-					StartTime:   ge.StartTime.Format(time.RFC3339),
-					EndTime:     ge.EndTime.Format(time.RFC3339),
-					AllDay:      ge.AllDay,
-					CalendarID:  ge.CalendarID,
-					Source:      "google",
-				}
-				events = append(events, event)
+			endTime := ge.StartTime.Add(1 * time.Hour)
+			if ge.EndTime != nil {
+				endTime = *ge.EndTime
 			}
+
+			events = append(events, TodayEventResponse{
+				ID:          ge.ID,
+				Summary:     ge.Summary,
+				Description: ge.Description,
+				Location:    ge.Location,
+				StartTime:   ge.StartTime.Format(time.RFC3339),
+				EndTime:     endTime.Format(time.RFC3339),
+				AllDay:      ge.AllDay,
+				CalendarID:  ge.CalendarID,
+				Source:      "google",
+				start:       ge.StartTime,
+			})
 		}
 	}
 
-	// 3. Sort events by start time using standard library
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].StartTime < events[j].StartTime
+	// 3. Sort events by start time; comparing the formatted strings breaks across offsets
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].start.Before(events[j].start)
 	})
 
-	respondJSON(w, http.StatusOK, events)
+	return events
+}
+
+// parseCalendarRange resolves the required start/end query params in timezone; a date
+// for end includes that day
+func parseCalendarRange(rawStart, rawEnd, timezone string) (time.Time, time.Time, error) {
+	if rawStart == "" || rawEnd == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("start and end are required")
+	}
+	loc, _ := timeutil.ResolveLocation(timezone)
+
+	start, _, err := parseDateOrTime(rawStart, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start must be an RFC 3339 time or YYYY-MM-DD date")
+	}
+	end, isDate, err := parseDateOrTime(rawEnd, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("end must be an RFC 3339 time or YYYY-MM-DD date")
+	}
+	if isDate {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end must be after start")
+	}
+	if end.Sub(start) > maxCalendarRange {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed %d days", int(maxCalendarRange.Hours()/24))
+	}
+	return start, end, nil
+}
+
+// alfredEventSource reports where an Alfred event came from: events imported from an
+// external calendar keep that calendar as their source
+func alfredEventSource(e database.CalendarEvent) string {
+	switch e.ChannelSourceType {
+	case "google_calendar":
+		return "google"
+	case "outlook_calendar":
+		return "outlook"
+	}
+	return "alfred"
+}
+
+// requestedCalendarID returns the Google calendar to read: the calendar_id query param,
+// otherwise the user's selected calendar, then primary
+func (s *Server) requestedCalendarID(r *http.Request, userID int64) string {
+	if calendarID := r.URL.Query().Get("calendar_id"); calendarID != "" {
+		return calendarID
+	}
+	if settings, err := s.db.GetGCalSettings(userID); err == nil && settings.SelectedCalendarID != "" {
+		return settings.SelectedCalendarID
+	}
+	return "primary"
 }
 
 // handleGCalDisconnect disconnects Google services (Calendar, Gmail, or both)
@@ -270,7 +369,7 @@ func (s *Server) handleGCalDisconnect(w http.ResponseWriter, r *http.Request) {
 	if req.Scope == "" {
 		userGCalClient := s.getGCalClientForUser(userID)
 		if userGCalClient == nil {
-			respondError(w, http.StatusServiceUnavailable, "Google Calendar not configured")
+			respondErrorCode(w, http.StatusServiceUnavailable, CodeGCalNotConfigured, "Google Calendar not configured")
 			return
 		}
 
@@ -343,6 +442,7 @@ func (s *Server) handleGetGCalSettings(w http.ResponseWriter, r *http.Request) {
 			"sync_enabled":           false,
 			"selected_calendar_id":   "",
 			"selected_calendar_name": "",
+			"channel_calendars":      []database.ChannelCalendar{},
 		})
 		return
 	}
@@ -361,13 +461,27 @@ func (s *Server) handleUpdateGCalSettings(w http.ResponseWriter, r *http.Request
 		SyncEnabled          bool   `json:"sync_enabled"`
 		SelectedCalendarID   string `json:"selected_calendar_id"`
 		SelectedCalendarName string `json:"selected_calendar_name"`
+		// ChannelCalendars replaces the channel mappings when present
+		ChannelCalendars *[]database.ChannelCalendar `json:"channel_calendars"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
 		return
 	}
 
+	if req.ChannelCalendars != nil {
+		err := s.db.SetChannelCalendars(userID, *req.ChannelCalendars)
+		if errors.Is(err, database.ErrChannelCalendarChannel) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update settings: %v", err))
+			return
+		}
+	}
+
 	if err := s.db.UpdateGCalSettings(userID, req.SyncEnabled, req.SelectedCalendarID, req.SelectedCalendarName); err != nil {
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update settings: %v", err))
 		return
@@ -21,10 +21,11 @@ func (s *Server) handleGmailStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	status := map[string]interface{}{
-		"connected":  false,
-		"enabled":    false,
-		"message":    "Gmail not configured",
-		"has_scopes": false,
+		"connected":       false,
+		"enabled":         false,
+		"message":         "Gmail not configured",
+		"has_scopes":      false,
+		"reauth_required": false,
 	}
 
 	// Check if user has Gmail scope granted
@@ -38,6 +39,10 @@ func (s *Server) handleGmailStatus(w http.ResponseWriter, r *http.Request) {
 	if !hasGmailScope {
 		// User hasn't granted Gmail access yet
 		status["message"] = "Gmail access not authorized. Please connect Gmail to scan emails."
+	} else if s.googleReauthRequired(userID) {
+		// The scope was granted, but Google rejected the refresh token since
+		status["reauth_required"] = true
+		status["message"] = "Google access expired or was revoked. Please reconnect Gmail."
 	} else {
 		// User has scope - in multi-user mode, we consider this "connected"
 		// Per-user Gmail clients are created on-demand by the worker
@@ -105,12 +110,12 @@ func (s *Server) handleCreateEmailSource(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if s.authService == nil {
-		respondError(w, http.StatusForbidden, "gmail access not authorized")
+		respondErrorCode(w, http.StatusForbidden, CodeGmailNotAuthorized, "gmail access not authorized")
 		return
 	}
 	hasGmailScope, _ := s.authService.HasGmailScope(userID)
 	if !hasGmailScope {
-		respondError(w, http.StatusForbidden, "gmail access not authorized")
+		respondErrorCode(w, http.StatusForbidden, CodeGmailNotAuthorized, "gmail access not authorized")
 		return
 	}
 
@@ -121,7 +126,7 @@ func (s *Server) handleCreateEmailSource(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
 		return
 	}
 
@@ -156,7 +161,7 @@ func (s *Server) handleCreateEmailSource(w http.ResponseWriter, r *http.Request)
 	// Check if already exists
 	existing, _ := s.db.GetEmailSourceByIdentifier(userID, sourceType, identifier)
 	if existing != nil {
-		respondError(w, http.StatusConflict, "email source already exists")
+		respondErrorCode(w, http.StatusConflict, CodeSourceExists, "email source already exists")
 		return
 	}
 
@@ -180,7 +185,7 @@ func (s *Server) handleUpdateEmailSource(w http.ResponseWriter, r *http.Request)
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
@@ -190,13 +195,13 @@ func (s *Server) handleUpdateEmailSource(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
 		return
 	}
 
 	if err := s.db.UpdateEmailSourceForUser(userID, id, req.Name, req.Enabled); err != nil {
 		if errors.Is(err, database.ErrEmailSourceNotFound) {
-			respondError(w, http.StatusNotFound, "source not found")
+			respondErrorCode(w, http.StatusNotFound, CodeSourceNotFound, "source not found")
 			return
 		}
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -209,7 +214,7 @@ func (s *Server) handleUpdateEmailSource(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if source == nil {
-		respondError(w, http.StatusNotFound, "source not found")
+		respondErrorCode(w, http.StatusNotFound, CodeSourceNotFound, "source not found")
 		return
 	}
 
@@ -225,13 +230,85 @@ func (s *Server) handleDeleteEmailSource(w http.ResponseWriter, r *http.Request)
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	if err := s.db.DeleteEmailSourceForUser(userID, id); err != nil {
 		if errors.Is(err, database.ErrEmailSourceNotFound) {
-			respondError(w, http.StatusNotFound, "source not found")
+			respondErrorCode(w, http.StatusNotFound, CodeSourceNotFound, "source not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// Sender Rules API - allow/deny rules checked before emails are analyzed
+
+func (s *Server) handleListEmailSenderRules(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	rules, err := s.db.ListEmailSenderRules(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{"rules": rules})
+}
+
+func (s *Server) handleSetEmailSenderRule(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Pattern string `json:"pattern"` // e.g., "news@shop.com" or "shop.com"
+		Action  string `json:"action"`  // "allow" or "deny"
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	rule, err := s.db.SetEmailSenderRule(userID, req.Pattern, database.EmailSenderAction(req.Action))
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidSenderPattern) || errors.Is(err, database.ErrInvalidSenderAction) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, rule)
+}
+
+func (s *Server) handleDeleteEmailSenderRule(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	if err := s.db.DeleteEmailSenderRule(userID, id); err != nil {
+		if errors.Is(err, database.ErrEmailSenderRuleNotFound) {
+			respondError(w, http.StatusNotFound, "rule not found")
 			return
 		}
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -356,12 +433,12 @@ func (s *Server) handleAddCustomSource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if s.authService == nil {
-		respondError(w, http.StatusForbidden, "gmail access not authorized")
+		respondErrorCode(w, http.StatusForbidden, CodeGmailNotAuthorized, "gmail access not authorized")
 		return
 	}
 	hasGmailScope, _ := s.authService.HasGmailScope(userID)
 	if !hasGmailScope {
-		respondError(w, http.StatusForbidden, "gmail access not authorized")
+		respondErrorCode(w, http.StatusForbidden, CodeGmailNotAuthorized, "gmail access not authorized")
 		return
 	}
 
@@ -370,7 +447,7 @@ func (s *Server) handleAddCustomSource(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
 		return
 	}
 
@@ -401,7 +478,7 @@ func (s *Server) handleAddCustomSource(w http.ResponseWriter, r *http.Request) {
 	// Check if already exists
 	existing, _ := s.db.GetEmailSourceByIdentifier(userID, sourceType, identifier)
 	if existing != nil {
-		respondError(w, http.StatusConflict, "Already tracking this source")
+		respondErrorCode(w, http.StatusConflict, CodeSourceExists, "Already tracking this source")
 		return
 	}
 
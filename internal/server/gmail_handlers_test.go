@@ -177,3 +177,59 @@ func TestHandleDeleteEmailSource_UserScoped(t *testing.T) {
 		assert.Nil(t, deleted)
 	})
 }
+
+func TestHandleEmailSenderRules(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+	otherUser := database.CreateTestUser(t, s.db)
+
+	set := func(pattern, action string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(map[string]string{"pattern": pattern, "action": action})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/api/gmail/sender-rules", bytes.NewReader(body))
+		req = withAuthContext(req, user)
+		w := httptest.NewRecorder()
+		s.handleSetEmailSenderRule(w, req)
+		return w
+	}
+
+	w := set("@Newsletter.com", "deny")
+	require.Equal(t, http.StatusOK, w.Code)
+	var rule database.EmailSenderRule
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rule))
+	assert.Equal(t, "newsletter.com", rule.Pattern)
+	assert.Equal(t, database.EmailSenderDeny, rule.Action)
+
+	assert.Equal(t, http.StatusBadRequest, set("not an address", "deny").Code)
+	assert.Equal(t, http.StatusBadRequest, set("shop.com", "maybe").Code)
+
+	req := httptest.NewRequest("GET", "/api/gmail/sender-rules", nil)
+	req = withAuthContext(req, user)
+	w = httptest.NewRecorder()
+	s.handleListEmailSenderRules(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var listed struct {
+		Rules []database.EmailSenderRule `json:"rules"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Rules, 1)
+
+	id := strconv.FormatInt(rule.ID, 10)
+	req = httptest.NewRequest("DELETE", "/api/gmail/sender-rules/"+id, nil)
+	req.SetPathValue("id", id)
+	req = withAuthContext(req, otherUser)
+	w = httptest.NewRecorder()
+	s.handleDeleteEmailSenderRule(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest("DELETE", "/api/gmail/sender-rules/"+id, nil)
+	req.SetPathValue("id", id)
+	req = withAuthContext(req, user)
+	w = httptest.NewRecorder()
+	s.handleDeleteEmailSenderRule(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	rules, err := s.db.ListEmailSenderRules(user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
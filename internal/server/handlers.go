@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/sse"
 	"github.com/omriShneor/project_alfred/internal/timeutil"
 )
 
@@ -63,10 +66,6 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	_ = json.NewEncoder(w).Encode(data)
 }
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
-}
-
 // parseEventTime parses a time string with user timezone fallback.
 func parseEventTime(s, timezone string) (time.Time, bool, error) {
 	return timeutil.ParseDateTime(s, timezone)
@@ -96,7 +95,7 @@ func (s *Server) handleOnboardingStatus(w http.ResponseWriter, r *http.Request)
 
 func (s *Server) handleOnboardingSSE(w http.ResponseWriter, r *http.Request) {
 	if s.onboardingState == nil {
-		respondError(w, http.StatusServiceUnavailable, "Onboarding not initialized")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Onboarding not initialized")
 		return
 	}
 
@@ -112,13 +111,19 @@ func (s *Server) handleOnboardingSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Subscribe to updates
-	updates := s.onboardingState.Subscribe()
+	// Subscribe to updates, resuming from Last-Event-ID when the buffer still covers it
+	updates, missed, resumed := s.onboardingState.SubscribeFrom(lastEventID(r))
 	defer s.onboardingState.Unsubscribe(updates)
 
-	// Send initial status
-	statusJSON := s.onboardingState.GetStatusJSON()
-	fmt.Fprintf(w, "event: status\ndata: %s\n\n", statusJSON)
+	if resumed {
+		for _, update := range missed {
+			writeSSEUpdate(w, update)
+		}
+	} else {
+		// Send initial status
+		statusJSON := s.onboardingState.GetStatusJSON()
+		writeSSEUpdate(w, sse.Update{ID: s.onboardingState.LastEventID(), Type: "status", Data: statusJSON})
+	}
 	flusher.Flush()
 
 	// Stream updates
@@ -128,7 +133,7 @@ func (s *Server) handleOnboardingSSE(w http.ResponseWriter, r *http.Request) {
 			if !ok {
 				return
 			}
-			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Type, update.Data)
+			writeSSEUpdate(w, update)
 			flusher.Flush()
 		case <-r.Context().Done():
 			return
@@ -149,9 +154,10 @@ func (s *Server) handleGetNotificationPrefs(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Determine push availability
-	pushAvailable := false
+	// Determine email (Resend or SMTP) and push availability
+	emailAvailable, pushAvailable := false, false
 	if s.notifyService != nil {
+		emailAvailable = s.notifyService.IsEmailAvailable()
 		pushAvailable = s.notifyService.IsPushAvailable()
 	}
 
@@ -159,7 +165,7 @@ func (s *Server) handleGetNotificationPrefs(w http.ResponseWriter, r *http.Reque
 	response := map[string]interface{}{
 		"preferences": prefs,
 		"available": map[string]bool{
-			"email":   s.resendAPIKey != "",
+			"email":   emailAvailable,
 			"push":    pushAvailable,
 			"sms":     false,
 			"webhook": false,
@@ -176,20 +182,69 @@ func (s *Server) handleUpdateEmailPrefs(w http.ResponseWriter, r *http.Request)
 	}
 	var req struct {
 		Enabled bool   `json:"enabled"`
-		Address string `json:"address"`
+		Address string `json:"address" validate:"required_if=Enabled true"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+	if err := s.db.UpdateEmailPrefs(userID, req.Enabled, req.Address); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if req.Enabled && req.Address == "" {
-		respondError(w, http.StatusBadRequest, "email address required when enabling notifications")
+	prefs, _ := s.db.GetUserNotificationPrefs(userID)
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// handleUpdateEventNotificationPrefs sets the default minutes-before-start offsets for event pushes.
+// Body: { "offsets_minutes": [30, 10] } or { "disabled": true }. A missing offsets_minutes
+// leaves the offsets unchanged; an empty list is rejected so a partial body can't turn
+// notifications off by accident.
+func (s *Server) handleUpdateEventNotificationPrefs(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
+	var req struct {
+		OffsetsMinutes *[]int `json:"offsets_minutes"`
+		Disabled       bool   `json:"disabled"`
+	}
 
-	if err := s.db.UpdateEmailPrefs(userID, req.Enabled, req.Address); err != nil {
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var offsets []int
+	switch {
+	case req.Disabled:
+		if req.OffsetsMinutes != nil && len(*req.OffsetsMinutes) > 0 {
+			respondError(w, http.StatusBadRequest, "offsets_minutes must be empty when disabled is set")
+			return
+		}
+		offsets = []int{}
+	case req.OffsetsMinutes == nil:
+		prefs, err := s.db.GetUserNotificationPrefs(userID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusOK, prefs)
+		return
+	case len(*req.OffsetsMinutes) == 0:
+		respondError(w, http.StatusBadRequest, `offsets_minutes must not be empty; send "disabled": true to turn event notifications off`)
+		return
+	default:
+		offsets = *req.OffsetsMinutes
+	}
+
+	if err := database.ValidateNotifyOffsets(offsets); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.db.UpdateEventNotifyOffsets(userID, offsets); err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -198,28 +253,107 @@ func (s *Server) handleUpdateEmailPrefs(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, prefs)
 }
 
-// handleRegisterPushToken stores the Expo push token from mobile app
-func (s *Server) handleRegisterPushToken(w http.ResponseWriter, r *http.Request) {
+// handleUpdateDigestPrefs enables/disables the morning digest and sets its local send time
+// Body: { "enabled": true, "time": "08:00" }
+func (s *Server) handleUpdateDigestPrefs(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
 	if err != nil {
 		respondError(w, http.StatusUnauthorized, "authentication required")
 		return
 	}
 	var req struct {
-		Token string `json:"token"`
+		Enabled bool   `json:"enabled"`
+		Time    string `json:"time"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	if req.Token == "" {
-		respondError(w, http.StatusBadRequest, "token is required")
+	if req.Time == "" {
+		req.Time = database.DefaultDigestTime
+	}
+	hour, minute, err := database.ParseDigestTime(req.Time)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := s.db.UpdatePushToken(userID, req.Token); err != nil {
+	if err := s.db.UpdateDigestPrefs(userID, req.Enabled, fmt.Sprintf("%02d:%02d", hour, minute)); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	prefs, _ := s.db.GetUserNotificationPrefs(userID)
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// handleUpdateEscalationPrefs sets the escalation rules for overdue reminders
+// Body: { "enabled": true, "min_priority": "high", "interval_hours": 24 }
+func (s *Server) handleUpdateEscalationPrefs(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	var req struct {
+		Enabled       bool   `json:"enabled"`
+		MinPriority   string `json:"min_priority"`
+		IntervalHours int    `json:"interval_hours"`
+	}
+
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	minPriority := database.ReminderPriority(req.MinPriority)
+	if minPriority == "" {
+		minPriority = database.ReminderPriorityHigh
+	}
+	if req.IntervalHours == 0 {
+		req.IntervalHours = database.DefaultEscalationIntervalHours
+	}
+	if err := database.ValidateEscalationPrefs(minPriority, req.IntervalHours); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.db.UpdateEscalationPrefs(userID, req.Enabled, minPriority, req.IntervalHours); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	prefs, _ := s.db.GetUserNotificationPrefs(userID)
+	respondJSON(w, http.StatusOK, prefs)
+}
+
+// handleRegisterPushToken registers the app's push token as one of the user's devices
+// Body: { "token": "ExponentPushToken[...]", "provider": "expo", "platform": "ios", "device_name": "..." }
+func (s *Server) handleRegisterPushToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	var req struct {
+		Token      string `json:"token" validate:"required"`
+		Provider   string `json:"provider"`
+		Platform   string `json:"platform"`
+		DeviceName string `json:"device_name"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.Provider == "" {
+		req.Provider = database.PushProviderExpo
+	}
+	if !database.IsValidPushProvider(req.Provider) {
+		respondError(w, http.StatusBadRequest, "provider must be expo, fcm or apns")
+		return
+	}
+
+	if _, err := s.db.RegisterDevice(userID, req.Token, req.Provider, req.Platform, req.DeviceName); err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -227,6 +361,48 @@ func (s *Server) handleRegisterPushToken(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, http.StatusOK, map[string]string{"status": "registered"})
 }
 
+// handleListDevices returns the devices registered to receive the user's push notifications
+func (s *Server) handleListDevices(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	devices, err := s.db.ListDevices(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, devices)
+}
+
+// handleDeleteDevice stops push notifications to one of the user's devices
+func (s *Server) handleDeleteDevice(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	deleted, err := s.db.DeleteDevice(userID, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "device not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
 // handleUpdatePushPrefs enables/disables push notifications
 func (s *Server) handleUpdatePushPrefs(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
@@ -238,8 +414,7 @@ func (s *Server) handleUpdatePushPrefs(w http.ResponseWriter, r *http.Request) {
 		Enabled bool `json:"enabled"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -515,6 +515,80 @@ func TestHandleWhatsAppCustomSource(t *testing.T) {
 	})
 }
 
+func TestHandleWhatsAppQRImage(t *testing.T) {
+	s := createTestServer(t)
+
+	req := httptest.NewRequest("GET", "/api/whatsapp/qr.png", nil)
+	w := httptest.NewRecorder()
+	s.handleWhatsAppQRImage(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	s.onboardingState.SetQR("data:image/png;base64,iVBORw0K")
+	w = httptest.NewRecorder()
+	s.handleWhatsAppQRImage(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, []byte("\x89PNG\r\n"), w.Body.Bytes())
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// Unchanged code
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	s.handleWhatsAppQRImage(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// Rotated code
+	s.onboardingState.SetQR("data:image/png;base64,iVBORw0KGgo=")
+	w = httptest.NewRecorder()
+	s.handleWhatsAppQRImage(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestHandleWhatsAppHistorySync(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	get := func() HistorySyncProgressResponse {
+		req := httptest.NewRequest("GET", "/api/whatsapp/history-sync", nil)
+		req = withAuthContext(req, user)
+		w := httptest.NewRecorder()
+		s.handleWhatsAppHistorySync(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response HistorySyncProgressResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := get()
+	assert.Equal(t, "not_started", response.Status)
+	assert.Empty(t, response.Channels)
+
+	var channels []*database.SourceChannel
+	for _, identifier := range []string{"15551230001", "15551230002"} {
+		channel, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender, identifier, identifier)
+		require.NoError(t, err)
+		require.NoError(t, s.db.StartHistorySyncCheckpoint(user.ID, channel.ID, 4))
+		channels = append(channels, channel)
+	}
+	require.NoError(t, s.db.CompleteHistorySyncCheckpoint(user.ID, channels[0].ID))
+
+	response = get()
+	assert.Equal(t, "pending", response.Status)
+	assert.Equal(t, 1, response.CompletedChannels)
+	assert.Equal(t, 2, response.TotalChannels)
+	assert.Equal(t, 50, response.Percent)
+	require.Len(t, response.Channels, 2)
+	assert.Equal(t, channels[1].ID, response.Channels[0].ChannelID, "pending channels come first")
+
+	require.NoError(t, s.db.CompleteHistorySyncCheckpoint(user.ID, channels[1].ID))
+	response = get()
+	assert.Equal(t, "completed", response.Status)
+	assert.Equal(t, 100, response.Percent)
+}
+
 func TestHandleUpdateWhatsappChannel(t *testing.T) {
 	s := createTestServer(t)
 	user := database.CreateTestUser(t, s.db)
@@ -1142,4 +1216,172 @@ func TestRespondError(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 	assert.Equal(t, "test error message", response["error"])
+	assert.Equal(t, "invalid_request", response["code"])
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	handler := errorFormatMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondErrorDetails(w, http.StatusConflict, CodeExportInProgress, "an export is already in progress", map[string]any{"export": "e1"})
+	}))
+
+	t.Run("envelope on request", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/account/export", nil)
+		req.Header.Set(headerErrorFormat, errorFormatEnvelope)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusConflict, w.Code)
+
+		var response errorBody
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CodeExportInProgress, response.Error.Code)
+		assert.Equal(t, "an export is already in progress", response.Error.Message)
+		assert.Equal(t, "e1", response.Error.Details["export"])
+	})
+
+	t.Run("legacy shape by default", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/account/export", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "an export is already in progress", response["error"])
+		assert.Equal(t, "export_in_progress", response["code"])
+		assert.Equal(t, "e1", response["export"])
+	})
+
+	t.Run("handler codes", func(t *testing.T) {
+		s := createTestServer(t)
+		user := database.CreateTestUser(t, s.db)
+
+		req := httptest.NewRequest("POST", "/api/events/999/confirm", nil)
+		req.SetPathValue("id", "999")
+		req = withAuthContext(req, user)
+		w := &envelopeErrorWriter{ResponseWriter: httptest.NewRecorder()}
+		s.handleConfirmEvent(w, req)
+
+		recorder := w.ResponseWriter.(*httptest.ResponseRecorder)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+		var response errorBody
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, CodeEventNotFound, response.Error.Code)
+	})
+}
+
+func TestRequestValidation(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	t.Run("field errors", func(t *testing.T) {
+		body := `{"type": "group", "identifier": " "}`
+		req := httptest.NewRequest("POST", "/api/whatsapp/channel", bytes.NewBufferString(body))
+		req = withAuthContext(req, user)
+		w := &envelopeErrorWriter{ResponseWriter: httptest.NewRecorder()}
+		s.handleCreateWhatsappChannel(w, req)
+
+		recorder := w.ResponseWriter.(*httptest.ResponseRecorder)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		var response errorBody
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Equal(t, CodeValidationFailed, response.Error.Code)
+		assert.Equal(t, "type must be one of: sender", response.Error.Message)
+
+		fields, ok := response.Error.Details["fields"].([]any)
+		require.True(t, ok)
+		require.Len(t, fields, 3)
+		assert.Equal(t, "identifier", fields[1].(map[string]any)["field"])
+		assert.Equal(t, "required", fields[2].(map[string]any)["rule"])
+	})
+
+	t.Run("conditional field", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/api/notifications/email", bytes.NewBufferString(`{"enabled": true}`))
+		req = withAuthContext(req, user)
+		w := httptest.NewRecorder()
+		s.handleUpdateEmailPrefs(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "address is required", response["error"])
+		assert.Equal(t, "validation_failed", response["code"])
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/notifications/push/register", bytes.NewBufferString(`{`))
+		req = withAuthContext(req, user)
+		w := httptest.NewRecorder()
+		s.handleRegisterPushToken(w, req)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"invalid_json"`)
+	})
+}
+
+func TestEventCorrectionsRecorded(t *testing.T) {
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+
+	channel, err := s.db.CreateSourceChannel(user.ID, source.SourceTypeWhatsApp, source.ChannelTypeSender,
+		"corrections@s.whatsapp.net", "Corrections")
+	require.NoError(t, err)
+	msg, err := s.db.StoreSourceMessage(source.SourceTypeWhatsApp, channel.ID, "corrections@s.whatsapp.net",
+		"Dana", "Standup moved to 9:30 on Zoom", "", time.Now())
+	require.NoError(t, err)
+
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Minute)
+	createEvent := func(title string) *database.CalendarEvent {
+		created, err := s.db.CreatePendingEvent(&database.CalendarEvent{
+			UserID:        user.ID,
+			ChannelID:     channel.ID,
+			CalendarID:    "primary",
+			Title:         title,
+			StartTime:     start,
+			ActionType:    database.EventActionCreate,
+			OriginalMsgID: &msg.ID,
+		})
+		require.NoError(t, err)
+		return created
+	}
+	update := func(event *database.CalendarEvent, body map[string]any) {
+		jsonBody, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest("PUT", "/api/events/"+strconv.FormatInt(event.ID, 10), bytes.NewReader(jsonBody))
+		req.SetPathValue("id", strconv.FormatInt(event.ID, 10))
+		req = withAuthContext(req, user)
+		w := httptest.NewRecorder()
+		s.handleUpdateEvent(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	edited := createEvent("Meeting")
+	update(edited, map[string]any{"title": "Meeting", "description": "notes", "start_time": start.Format(time.RFC3339)})
+	corrections, err := s.db.ListRecentEventCorrections(user.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, corrections, "description edits are not corrections")
+
+	update(edited, map[string]any{"title": "Sync", "start_time": start.Format(time.RFC3339)})
+	update(edited, map[string]any{"title": "Standup", "start_time": start.Add(30 * time.Minute).Format(time.RFC3339), "location": "Zoom"})
+
+	rejected := createEvent("Lunch")
+	req := httptest.NewRequest("POST", "/api/events/"+strconv.FormatInt(rejected.ID, 10)+"/reject", nil)
+	req.SetPathValue("id", strconv.FormatInt(rejected.ID, 10))
+	req = withAuthContext(req, user)
+	w := httptest.NewRecorder()
+	s.handleRejectEvent(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	corrections, err = s.db.ListRecentEventCorrections(user.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, corrections, 2)
+
+	assert.Equal(t, database.EventCorrectionRejected, corrections[0].Kind)
+	assert.Equal(t, "Lunch", corrections[0].DetectedTitle)
+
+	assert.Equal(t, database.EventCorrectionEdited, corrections[1].Kind)
+	assert.Equal(t, "Meeting", corrections[1].DetectedTitle)
+	assert.Equal(t, "Standup", corrections[1].CorrectedTitle)
+	assert.Equal(t, "Zoom", corrections[1].CorrectedLocation)
+	require.NotNil(t, corrections[1].CorrectedStartTime)
+	assert.True(t, corrections[1].CorrectedStartTime.Equal(start.Add(30*time.Minute)))
+	assert.Equal(t, "Standup moved to 9:30 on Zoom", corrections[1].MessageText)
 }
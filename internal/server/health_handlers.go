@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/clients"
+)
+
+// Subsystem statuses reported by /api/admin/health
+const (
+	checkOK       = "ok"
+	checkDegraded = "degraded"
+	checkDown     = "down"
+	checkDisabled = "disabled"
+)
+
+const (
+	readinessTimeout  = 3 * time.Second
+	llmProbeCacheTime = 30 * time.Second
+)
+
+// healthCheck is one subsystem's entry in the /api/admin/health response
+type healthCheck struct {
+	Status    string         `json:"status"`
+	Message   string         `json:"message,omitempty"`
+	LatencyMS *int64         `json:"latency_ms,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// handleLiveness reports that the process is up and serving requests. It checks no
+// dependencies, so orchestrators only restart the server when it is truly stuck.
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]any{
+		"status":         checkOK,
+		"uptime_seconds": int64(time.Since(s.startedAt).Seconds()),
+	})
+}
+
+// handleReadiness reports only the overall status, since it is unauthenticated; the
+// per-subsystem checks are served to admins by handleAdminHealth
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	status, code, _ := s.runHealthChecks(r.Context())
+	respondJSON(w, code, map[string]any{"status": status})
+}
+
+// handleAdminHealth reports each subsystem's status with its details: client, worker
+// and retry counts and the LLM circuit breaker
+func (s *Server) handleAdminHealth(w http.ResponseWriter, r *http.Request) {
+	status, code, checks := s.runHealthChecks(r.Context())
+	respondJSON(w, code, map[string]any{
+		"status":         status,
+		"uptime_seconds": int64(time.Since(s.startedAt).Seconds()),
+		"checks":         checks,
+	})
+}
+
+// runHealthChecks checks every subsystem. Only the database gates readiness (503 when it
+// is down); other failures mark the server degraded but still ready, since they affect
+// some users or features rather than every request.
+func (s *Server) runHealthChecks(ctx context.Context) (string, int, map[string]healthCheck) {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	checks := map[string]healthCheck{
+		"database":      s.checkDatabase(),
+		"processor":     s.checkProcessor(),
+		"whatsapp":      s.checkWhatsApp(),
+		"telegram":      s.checkTelegram(),
+		"gmail":         s.checkGmailWorkers(),
+		"llm":           s.checkLLM(ctx),
+		"notifications": s.checkNotifications(),
+	}
+
+	status := checkOK
+	for _, check := range checks {
+		if check.Status == checkDegraded || check.Status == checkDown {
+			status = checkDegraded
+		}
+	}
+	if checks["database"].Status != checkOK {
+		return "unavailable", http.StatusServiceUnavailable, checks
+	}
+	return status, http.StatusOK, checks
+}
+
+func (s *Server) checkDatabase() healthCheck {
+	start := time.Now()
+	if err := s.db.Ping(); err != nil {
+		return healthCheck{Status: checkDown, Message: "database unavailable"}
+	}
+	return healthCheck{Status: checkOK, LatencyMS: millisSince(start)}
+}
+
+func (s *Server) checkProcessor() healthCheck {
+	if s.userServiceManager == nil {
+		return healthCheck{Status: checkDisabled}
+	}
+	if !s.userServiceManager.GlobalProcessorRunning() {
+		return healthCheck{Status: checkDown, Message: "message processor is not running"}
+	}
+	return healthCheck{Status: checkOK}
+}
+
+func (s *Server) checkWhatsApp() healthCheck {
+	if s.clientManager == nil {
+		return healthCheck{Status: checkDisabled}
+	}
+	summary := s.clientManager.WhatsAppSummary()
+	return clientMonitorCheck(summary)
+}
+
+func (s *Server) checkTelegram() healthCheck {
+	if s.clientManager == nil {
+		return healthCheck{Status: checkDisabled}
+	}
+	summary := s.clientManager.TelegramSummary()
+	return clientMonitorCheck(summary)
+}
+
+// clientMonitorCheck adds the client monitor's reconnecting and evicted counts to the
+// client summary check
+func clientMonitorCheck(summary clients.ClientSummary) healthCheck {
+	check := clientSummaryCheck(summary.Clients, summary.Connected)
+	check.Details["reconnecting"] = summary.Reconnecting
+	check.Details["evicted"] = summary.Evicted
+	return check
+}
+
+// clientSummaryCheck reports per-user client counts. Disconnected users degrade the
+// check, since their phones may simply be offline.
+func clientSummaryCheck(clients, connected int) healthCheck {
+	check := healthCheck{
+		Status:  checkOK,
+		Details: map[string]any{"clients": clients, "connected": connected},
+	}
+	if connected < clients {
+		check.Status = checkDegraded
+		check.Message = fmt.Sprintf("%d of %d clients disconnected", clients-connected, clients)
+	}
+	return check
+}
+
+func (s *Server) checkGmailWorkers() healthCheck {
+	if s.userServiceManager == nil {
+		return healthCheck{Status: checkDisabled}
+	}
+	workers, authenticated := s.userServiceManager.GmailWorkerSummary()
+	check := healthCheck{
+		Status:  checkOK,
+		Details: map[string]any{"workers": workers, "authenticated": authenticated},
+	}
+	if authenticated < workers {
+		check.Status = checkDegraded
+		check.Message = fmt.Sprintf("%d of %d workers without a Gmail client", workers-authenticated, workers)
+	}
+	return check
+}
+
+func (s *Server) checkLLM(ctx context.Context) healthCheck {
+	if s.eventAnalyzer == nil || s.llmProbe == nil {
+		return healthCheck{Status: checkDisabled}
+	}
+	check := s.llmProbe.check(ctx)
+	stats := agent.GetProviderStats(s.llmProvider)
+	check.Details = map[string]any{"provider": s.llmProvider, "retries": stats.Retries, "failures": stats.Failures}
+	if stats.Circuit != nil {
+		check.Details["circuit"] = stats.Circuit
+		if stats.Circuit.State != agent.CircuitClosed && check.Status == checkOK {
+			check.Status = checkDegraded
+			check.Message = "LLM API failing; analysis limited to messages the pre-filter finds actionable"
+		}
+	}
+	return check
+}
+
+func (s *Server) checkNotifications() healthCheck {
+	if s.notifyService == nil {
+		return healthCheck{Status: checkDisabled}
+	}
+	email := s.notifyService.IsEmailAvailable()
+	push := s.notifyService.IsPushAvailable()
+	check := healthCheck{
+		Status:  checkOK,
+		Details: map[string]any{"email": email, "push": push},
+	}
+	if !email && !push {
+		check.Status = checkDisabled
+	}
+	return check
+}
+
+// reachabilityProbe checks that an HTTP endpoint answers, caching the result so
+// frequent readiness polls don't hammer the provider
+type reachabilityProbe struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	last      healthCheck
+	inflight  chan struct{} // closed when the running probe finishes; nil while idle
+}
+
+func newReachabilityProbe(url string) *reachabilityProbe {
+	return &reachabilityProbe{
+		url:    url,
+		client: &http.Client{Timeout: readinessTimeout},
+		ttl:    llmProbeCacheTime,
+	}
+}
+
+// check reports whether the endpoint answered. The request is made without holding the
+// lock, and callers arriving while it runs wait for its result instead of probing again.
+func (p *reachabilityProbe) check(ctx context.Context) healthCheck {
+	p.mu.Lock()
+	if !p.checkedAt.IsZero() && time.Since(p.checkedAt) < p.ttl {
+		last := p.last
+		p.mu.Unlock()
+		return last
+	}
+	if wait := p.inflight; wait != nil {
+		p.mu.Unlock()
+		select {
+		case <-wait:
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			return p.last
+		case <-ctx.Done():
+			return healthCheck{Status: checkDown, Message: "provider check timed out"}
+		}
+	}
+	done := make(chan struct{})
+	p.inflight = done
+	p.mu.Unlock()
+
+	// The result is shared and cached, so one caller going away mustn't cut it short;
+	// the client timeout still bounds it
+	result := p.probe(context.WithoutCancel(ctx))
+
+	p.mu.Lock()
+	p.checkedAt = time.Now()
+	p.last = result
+	p.inflight = nil
+	p.mu.Unlock()
+	close(done)
+	return result
+}
+
+// probe sends one request. Any HTTP response counts, since an unauthenticated request
+// is expected to be rejected.
+func (p *reachabilityProbe) probe(ctx context.Context) healthCheck {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err == nil {
+		var resp *http.Response
+		resp, err = p.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		return healthCheck{Status: checkDown, Message: "provider unreachable"}
+	}
+	return healthCheck{Status: checkOK, LatencyMS: millisSince(start)}
+}
+
+func millisSince(start time.Time) *int64 {
+	ms := time.Since(start).Milliseconds()
+	return &ms
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/clients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleLiveness(t *testing.T) {
+	s := createTestServer(t)
+
+	w := httptest.NewRecorder()
+	s.handleLiveness(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "ok", response["status"])
+}
+
+func TestHandleReadiness(t *testing.T) {
+	t.Run("reports only the status", func(t *testing.T) {
+		s := createTestServer(t)
+
+		w := httptest.NewRecorder()
+		s.handleReadiness(w, httptest.NewRequest("GET", "/readyz", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, map[string]any{"status": "ok"}, response)
+	})
+
+	t.Run("unavailable when the database is down", func(t *testing.T) {
+		s := createTestServer(t)
+		require.NoError(t, s.db.Close())
+
+		w := httptest.NewRecorder()
+		s.handleReadiness(w, httptest.NewRequest("GET", "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, map[string]any{"status": "unavailable"}, response)
+	})
+}
+
+func TestHandleAdminHealth(t *testing.T) {
+	type health struct {
+		Status string                 `json:"status"`
+		Checks map[string]healthCheck `json:"checks"`
+	}
+
+	t.Run("ready with database only", func(t *testing.T) {
+		s := createTestServer(t)
+
+		w := httptest.NewRecorder()
+		s.handleAdminHealth(w, httptest.NewRequest("GET", "/api/admin/health", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response health
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "ok", response.Status)
+		assert.Equal(t, checkOK, response.Checks["database"].Status)
+		assert.NotNil(t, response.Checks["database"].LatencyMS)
+		for _, name := range []string{"processor", "whatsapp", "telegram", "gmail", "llm", "notifications"} {
+			assert.Equal(t, checkDisabled, response.Checks[name].Status, name)
+		}
+	})
+
+	t.Run("unavailable when the database is down", func(t *testing.T) {
+		s := createTestServer(t)
+		require.NoError(t, s.db.Close())
+
+		w := httptest.NewRecorder()
+		s.handleAdminHealth(w, httptest.NewRequest("GET", "/api/admin/health", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response health
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "unavailable", response.Status)
+		assert.Equal(t, checkDown, response.Checks["database"].Status)
+	})
+}
+
+func TestClientSummaryCheck(t *testing.T) {
+	assert.Equal(t, checkOK, clientSummaryCheck(0, 0).Status)
+	assert.Equal(t, checkOK, clientSummaryCheck(2, 2).Status)
+
+	check := clientSummaryCheck(3, 1)
+	assert.Equal(t, checkDegraded, check.Status)
+	assert.Equal(t, "2 of 3 clients disconnected", check.Message)
+
+	check = clientMonitorCheck(clients.ClientSummary{Clients: 2, Connected: 1, Reconnecting: 1, Evicted: 3})
+	assert.Equal(t, checkDegraded, check.Status)
+	assert.Equal(t, 1, check.Details["reconnecting"])
+	assert.Equal(t, 3, check.Details["evicted"])
+}
+
+func TestReachabilityProbe(t *testing.T) {
+	calls := 0
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+
+	probe := newReachabilityProbe(provider.URL)
+	check := probe.check(context.Background())
+	assert.Equal(t, checkOK, check.Status, "an auth error still means the provider answered")
+	assert.NotNil(t, check.LatencyMS)
+
+	probe.check(context.Background())
+	assert.Equal(t, 1, calls, "results are cached")
+
+	provider.Close()
+	down := newReachabilityProbe(provider.URL)
+	assert.Equal(t, checkDown, down.check(context.Background()).Status)
+}
+
+func TestReachabilityProbeSharesInflightCheck(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}))
+	defer provider.Close()
+
+	probe := newReachabilityProbe(provider.URL)
+	results := make(chan healthCheck, 3)
+	for range 3 {
+		go func() { results <- probe.check(context.Background()) }()
+	}
+
+	// A caller that gives up returns while the request is still running
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, checkDown, probe.check(ctx).Status)
+
+	close(release)
+	for range 3 {
+		assert.Equal(t, checkOK, (<-results).Status)
+	}
+	assert.Equal(t, int32(1), calls.Load(), "concurrent callers share one request")
+}
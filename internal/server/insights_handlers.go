@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultInsightsDays = 30
+	maxInsightsDays     = 365
+)
+
+// handleGetInsights returns how the user's detected events and reminders were reviewed,
+// overall and per channel. Optional days (1-365, default 30) sets the period, counted in
+// whole days of the user's timezone ending today.
+func (s *Server) handleGetInsights(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	days := defaultInsightsDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxInsightsDays {
+			respondInvalidParameter(w, "days", 1, 365, "days must be between 1 and 365")
+			return
+		}
+		days = parsed
+	}
+
+	loc, err := time.LoadLocation(s.getUserTimezone(userID))
+	if err != nil {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	insights, err := s.db.GetInsights(userID, today.AddDate(0, 0, -(days-1)))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, insights)
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// Channel Labels API - user tags for grouping channels
+
+type channelLabelRequest struct {
+	Name       string `json:"name"`
+	CalendarID string `json:"calendar_id"` // Google calendar for the label's events ("" = selected calendar)
+}
+
+func (s *Server) handleListChannelLabels(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	labels, err := s.db.ListChannelLabels(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"labels": labels})
+}
+
+func (s *Server) handleCreateChannelLabel(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req channelLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	label, err := s.db.CreateChannelLabel(userID, req.Name, req.CalendarID)
+	if err != nil {
+		respondChannelLabelError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, label)
+}
+
+func (s *Server) handleUpdateChannelLabel(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	var req channelLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	label, err := s.db.UpdateChannelLabel(userID, id, req.Name, req.CalendarID)
+	if err != nil {
+		respondChannelLabelError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, label)
+}
+
+func (s *Server) handleDeleteChannelLabel(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	if err := s.db.DeleteChannelLabel(userID, id); err != nil {
+		respondChannelLabelError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handleSetChannelLabels replaces the labels on a channel the user owns
+// Body: { "label_ids": [1, 2] }
+func (s *Server) handleSetChannelLabels(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	channel := s.getOwnedChannel(w, r, userID)
+	if channel == nil {
+		return
+	}
+
+	var req struct {
+		LabelIDs []int64 `json:"label_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	if err := s.db.SetChannelLabels(userID, channel.ID, req.LabelIDs); err != nil {
+		respondChannelLabelError(w, err)
+		return
+	}
+	labels, err := s.db.ListLabelsForChannel(userID, channel.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"labels": labels})
+}
+
+// parseLabelFilter reads the optional label query parameter (a label ID) of the event
+// and reminder lists into opts
+func parseLabelFilter(r *http.Request, opts *database.ListOptions) error {
+	raw := r.URL.Query().Get("label")
+	if raw == "" {
+		return nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return errors.New("label must be a label id")
+	}
+	opts.Label = id
+	return nil
+}
+
+// respondChannelLabelError maps label errors to responses
+func respondChannelLabelError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrChannelLabelNotFound):
+		respondError(w, http.StatusNotFound, "label not found")
+	case errors.Is(err, database.ErrChannelLabelExists):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
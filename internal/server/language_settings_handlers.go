@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/agent/langpolicy"
+)
+
+// languageSettingsResponse reports the user's locale; nil means agents answer in the
+// language of each conversation and notifications are in English
+func languageSettingsResponse(locale string) map[string]any {
+	var value any
+	if locale != "" {
+		value = locale
+	}
+	return map[string]any{
+		"locale":            value,
+		"rtl":               langpolicy.IsRTL(locale),
+		"supported_locales": langpolicy.SupportedLocales(),
+	}
+}
+
+// handleGetLanguageSettings returns the language used for detected event/reminder text
+// and notifications
+func (s *Server) handleGetLanguageSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	locale, err := s.db.GetUserLocale(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, languageSettingsResponse(locale))
+}
+
+// handleUpdateLanguageSettings sets the user's locale (a code from supported_locales).
+// A null locale goes back to matching each conversation's language.
+func (s *Server) handleUpdateLanguageSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Locale *string `json:"locale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+	locale := ""
+	if req.Locale != nil {
+		locale = strings.ToLower(strings.TrimSpace(*req.Locale))
+		if !langpolicy.IsSupportedLocale(locale) {
+			respondError(w, http.StatusBadRequest, "locale must be one of "+strings.Join(langpolicy.SupportedLocales(), ", "))
+			return
+		}
+	}
+
+	if err := s.db.SetUserLocale(userID, locale); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, languageSettingsResponse(locale))
+}
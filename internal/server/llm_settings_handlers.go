@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// llmSettingsResponse reports the user's effective model tier and temperature
+func (s *Server) llmSettingsResponse(settings *database.LLMSettings) map[string]any {
+	tier := agent.ModelTierAccurate
+	if settings.ModelTier != nil {
+		tier = *settings.ModelTier
+	}
+	temperature := s.llmTemperature
+	if settings.Temperature != nil {
+		temperature = *settings.Temperature
+	}
+	return map[string]any{
+		"model_tier":          tier,
+		"temperature":         temperature,
+		"is_default":          settings.ModelTier == nil && settings.Temperature == nil,
+		"default_temperature": s.llmTemperature,
+		"available_tiers":     []string{agent.ModelTierFast, agent.ModelTierAccurate},
+	}
+}
+
+// handleGetLLMSettings returns the model tier and temperature used to analyze the user's messages
+func (s *Server) handleGetLLMSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	settings, err := s.db.GetLLMSettings(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, s.llmSettingsResponse(settings))
+}
+
+// handleUpdateLLMSettings sets the user's model tier ("fast" or "accurate") and
+// temperature (0-1). Null values reset them to the server defaults.
+func (s *Server) handleUpdateLLMSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req database.LLMSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+	if req.ModelTier != nil && !agent.IsValidModelTier(*req.ModelTier) {
+		respondError(w, http.StatusBadRequest, "model_tier must be \"fast\" or \"accurate\"")
+		return
+	}
+	if t := req.Temperature; t != nil && (*t < 0 || *t > 1) {
+		respondInvalidParameter(w, "temperature", 0, 1, "temperature must be between 0 and 1")
+		return
+	}
+
+	if err := s.db.SetLLMSettings(userID, req); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, s.llmSettingsResponse(&req))
+}
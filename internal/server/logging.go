@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/logging"
+)
+
+// headerRequestID carries the request ID in both directions; a client-supplied ID is kept
+// so logs can be correlated across services
+const headerRequestID = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestLog collects what inner handlers learn about a request for its access log line
+type requestLog struct {
+	userID int64
+}
+
+type requestLogKey struct{}
+
+// loggingMiddleware assigns every request an ID, adds it to the request context's log
+// attributes, and logs one access line once the request completes
+func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(headerRequestID)
+		if !validRequestID.MatchString(requestID) {
+			requestID = logging.NewRequestID()
+		}
+		w.Header().Set(headerRequestID, requestID)
+
+		info := &requestLog{}
+		ctx := logging.With(r.Context(), slog.String("request_id", requestID))
+		ctx = context.WithValue(ctx, requestLogKey{}, info)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		attrs := []any{
+			"method", r.Method,
+			"path", loggedPath(r.URL.Path),
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		}
+		if info.userID != 0 {
+			attrs = append(attrs, "user_id", info.userID)
+		}
+		level := slog.LevelInfo
+		if r.Method == http.MethodOptions || isHealthPath(r.URL.Path) {
+			level = slog.LevelDebug
+		}
+		slog.Log(ctx, level, "HTTP request", attrs...)
+	})
+}
+
+// loggedPath hides the secret token in calendar feed URLs, which authenticates the feed
+func loggedPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/feeds/"); ok {
+		if _, file, found := strings.Cut(rest, "/"); found {
+			return "/feeds/REDACTED/" + file
+		}
+	}
+	return path
+}
+
+// isHealthPath reports probe endpoints polled often enough to flood the access log
+func isHealthPath(path string) bool {
+	return path == "/health" || path == "/healthz" || path == "/readyz"
+}
+
+// withUserLogging adds the authenticated user's ID to the request's log attributes and
+// access log line. It wraps handlers after authentication has populated the context.
+func withUserLogging(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, err := getUserID(r)
+		if err != nil {
+			handler(w, r)
+			return
+		}
+		if info, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+			info.userID = userID
+		}
+		ctx := logging.With(r.Context(), slog.Int64("user_id", userID))
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// statusRecorder remembers the response status while passing through the optional
+// interfaces streaming and websocket handlers rely on
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := r.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response does not support hijacking")
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
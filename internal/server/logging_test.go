@@ -0,0 +1,94 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", logging.FormatJSON)
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	s := createTestServer(t)
+	user := database.CreateTestUser(t, s.db)
+	handler := s.loggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		withUserLogging(func(w http.ResponseWriter, r *http.Request) {
+			slog.InfoContext(r.Context(), "inside handler")
+			w.WriteHeader(http.StatusTeapot)
+		})(w, withAuthContext(r, user))
+	}))
+
+	decode := func() []map[string]any {
+		var records []map[string]any
+		for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(line, &record))
+			records = append(records, record)
+		}
+		buf.Reset()
+		return records
+	}
+
+	t.Run("generates a request ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/events", nil))
+
+		requestID := w.Header().Get(headerRequestID)
+		assert.Len(t, requestID, 16)
+
+		records := decode()
+		require.Len(t, records, 2)
+		assert.Equal(t, "inside handler", records[0]["msg"])
+		assert.Equal(t, requestID, records[0]["request_id"])
+		assert.EqualValues(t, user.ID, records[0]["user_id"])
+
+		assert.Equal(t, "HTTP request", records[1]["msg"])
+		assert.Equal(t, requestID, records[1]["request_id"])
+		assert.EqualValues(t, user.ID, records[1]["user_id"])
+		assert.EqualValues(t, http.StatusTeapot, records[1]["status"])
+		assert.Equal(t, "/api/events", records[1]["path"])
+	})
+
+	t.Run("keeps a valid client request ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/events", nil)
+		req.Header.Set(headerRequestID, "client-abc.123")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, "client-abc.123", w.Header().Get(headerRequestID))
+		records := decode()
+		require.Len(t, records, 2)
+		assert.Equal(t, "client-abc.123", records[1]["request_id"])
+	})
+
+	t.Run("replaces an invalid client request ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/events", nil)
+		req.Header.Set(headerRequestID, "bad id\nwith newline")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Len(t, w.Header().Get(headerRequestID), 16)
+		decode()
+	})
+
+	t.Run("hides calendar feed tokens", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/feeds/s3cret/alfred.ics", nil))
+
+		records := decode()
+		require.Len(t, records, 2)
+		assert.Equal(t, "/feeds/REDACTED/alfred.ics", records[1]["path"])
+	})
+}
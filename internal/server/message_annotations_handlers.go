@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// handleAnnotateMessage lets the user flag one of their messages as a missed event or
+// reminder, or as wrongly detected. Annotations are labeled cases for evaluating the
+// agents; annotating again with the same kind replaces the note.
+func (s *Server) handleAnnotateMessage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	var req struct {
+		Kind string `json:"kind"`
+		Note string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+	note := strings.TrimSpace(req.Note)
+	if err := database.ValidateMessageAnnotation(req.Kind, note); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	msg, err := s.db.GetSourceMessageByID(userID, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg == nil {
+		respondError(w, http.StatusNotFound, "message not found")
+		return
+	}
+
+	annotation, err := s.db.AnnotateMessage(userID, msg, req.Kind, note)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, annotation)
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// handleListNotificationDeliveries returns the user's recently queued notifications with
+// their delivery status. Query: status (pending, sent, failed), limit (default 50, max 200).
+func (s *Server) handleListNotificationDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var status *database.NotificationStatus
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		st := database.NotificationStatus(raw)
+		switch st {
+		case database.NotificationPending, database.NotificationSent, database.NotificationFailed:
+			status = &st
+		default:
+			respondError(w, http.StatusBadRequest, "status must be pending, sent or failed")
+			return
+		}
+	}
+
+	limit := 50
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > 200 {
+			respondInvalidParameter(w, "limit", 1, 200, "limit must be between 1 and 200")
+			return
+		}
+	}
+
+	deliveries, err := s.db.ListQueuedNotifications(userID, status, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, deliveries)
+}
+
+// handleGetNotificationDelivery returns one of the user's queued notifications
+func (s *Server) handleGetNotificationDelivery(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	delivery, err := s.db.GetQueuedNotification(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && delivery.UserID != userID) {
+		respondError(w, http.StatusNotFound, "notification not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, delivery)
+}
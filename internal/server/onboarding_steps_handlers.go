@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/sse"
+)
+
+// OnboardingStepsResponse is the response of GET and PUT /api/onboarding/steps
+type OnboardingStepsResponse struct {
+	CurrentStep database.OnboardingStepName `json:"current_step,omitempty"` // First step still to do; empty once onboarding is complete
+	Complete    bool                        `json:"complete"`
+	Steps       []database.OnboardingStep   `json:"steps"`
+}
+
+// UpdateOnboardingStepsRequest is the body of PUT /api/onboarding/steps
+type UpdateOnboardingStepsRequest struct {
+	Steps []UpdateOnboardingStep `json:"steps" validate:"required"`
+}
+
+// UpdateOnboardingStep is one step's new status. Data is kept when left out.
+type UpdateOnboardingStep struct {
+	Step   database.OnboardingStepName   `json:"step" validate:"required,oneof=welcome input_selection whatsapp telegram gmail google_calendar source_configuration"`
+	Status database.OnboardingStepStatus `json:"status" validate:"required,oneof=pending in_progress completed skipped"`
+	Data   json.RawMessage               `json:"data"`
+}
+
+// handleGetOnboardingSteps returns the user's onboarding progress so the app can resume
+// where they left off. Connection steps the user has since connected are marked completed.
+func (s *Server) handleGetOnboardingSteps(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	response, err := s.onboardingSteps(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// handleUpdateOnboardingSteps records progress on one or more onboarding steps and
+// returns the updated progress. Other devices of the user hear about it on /api/stream.
+func (s *Server) handleUpdateOnboardingSteps(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req UpdateOnboardingStepsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	for _, step := range req.Steps {
+		if string(step.Data) == "null" {
+			step.Data = nil
+		}
+		if err := s.db.SetOnboardingStep(userID, step.Step, step.Status, step.Data); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	response, err := s.onboardingSteps(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if s.streams != nil {
+		if err := s.streams.Publish(userID, sse.UpdateOnboardingSteps, response); err != nil {
+			slog.Warn("Failed to publish onboarding steps", "user_id", userID, "error", err)
+		}
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// onboardingSteps loads a user's onboarding progress, first recording as completed any
+// connection step whose account is now connected
+func (s *Server) onboardingSteps(userID int64) (*OnboardingStepsResponse, error) {
+	steps, err := s.db.GetOnboardingSteps(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, step := range steps {
+		if step.Status.Done() || !s.onboardingStepConnected(userID, step.Step) {
+			continue
+		}
+		if err := s.db.SetOnboardingStep(userID, step.Step, database.OnboardingStepCompleted, nil); err != nil {
+			return nil, err
+		}
+		steps[i].Status = database.OnboardingStepCompleted
+	}
+
+	status, err := s.db.GetAppStatus(userID)
+	if err != nil {
+		return nil, err
+	}
+	response := &OnboardingStepsResponse{Complete: status.OnboardingComplete, Steps: steps}
+	if !response.Complete {
+		response.CurrentStep = database.CurrentOnboardingStep(steps)
+	}
+	return response, nil
+}
+
+// onboardingStepConnected reports whether the account a connection step sets up is
+// connected. Steps that don't connect anything are never connected.
+func (s *Server) onboardingStepConnected(userID int64, step database.OnboardingStepName) bool {
+	switch step {
+	case database.OnboardingStepWhatsApp:
+		if s.clientManager != nil {
+			if waClient, ok := s.clientManager.PeekWhatsAppClient(userID); ok && waClient.IsLoggedIn() {
+				return true
+			}
+		}
+		session, err := s.db.GetWhatsAppSession(userID)
+		return err == nil && session != nil && session.Connected
+	case database.OnboardingStepTelegram:
+		session, err := s.db.GetTelegramSession(userID)
+		return err == nil && session != nil && session.Connected
+	case database.OnboardingStepGmail:
+		if s.authService == nil {
+			return false
+		}
+		hasScope, _ := s.authService.HasGmailScope(userID)
+		return hasScope
+	case database.OnboardingStepGoogleCalendar:
+		gcalClient := s.getGCalClientForUser(userID)
+		return gcalClient != nil && gcalClient.IsAuthenticated()
+	}
+	return false
+}
+
+// RestoreOnboardingState rehydrates the onboarding SSE state from the database after a
+// restart. That state is shared by the whole server, so it is only restored when exactly
+// one user is part way through onboarding, as on a single-user install.
+func (s *Server) RestoreOnboardingState() {
+	if s.onboardingState == nil {
+		return
+	}
+
+	userIDs, err := s.db.GetUsersResumingOnboarding()
+	if err != nil {
+		slog.Warn("Failed to restore onboarding state", "error", err)
+		return
+	}
+	if len(userIDs) != 1 {
+		return
+	}
+	userID := userIDs[0]
+
+	steps, err := s.db.GetOnboardingSteps(userID)
+	if err != nil {
+		slog.Warn("Failed to restore onboarding state", "user_id", userID, "error", err)
+		return
+	}
+
+	var status sse.StatusResponse
+	for _, step := range steps {
+		if step.Status != database.OnboardingStepCompleted {
+			continue
+		}
+		switch step.Step {
+		case database.OnboardingStepWhatsApp:
+			status.WhatsApp.Status = "connected"
+		case database.OnboardingStepTelegram:
+			status.Telegram.Status = "connected"
+		case database.OnboardingStepGoogleCalendar:
+			status.GCal.Status = "connected"
+		}
+	}
+	s.onboardingState.Restore(status)
+	slog.Info("Restored onboarding state", "user_id", userID, "current_step", database.CurrentOnboardingStep(steps))
+}
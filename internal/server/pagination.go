@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/timeutil"
+)
+
+const (
+	maxListLimit = 200
+
+	headerTotalCount = "X-Total-Count"
+	headerNextCursor = "X-Next-Cursor"
+)
+
+// parseListOptions reads the limit, cursor/offset, sort, from, and to query parameters.
+// Date-only from/to values are interpreted in the user's timezone; a date-only "to"
+// includes that whole day.
+func parseListOptions(r *http.Request, timezone string) (database.ListOptions, error) {
+	q := r.URL.Query()
+	var opts database.ListOptions
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return opts, fmt.Errorf("limit must be a positive integer")
+		}
+		opts.Limit = min(limit, maxListLimit)
+	}
+
+	if raw := q.Get("cursor"); raw != "" {
+		offset, err := decodeCursor(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid cursor")
+		}
+		opts.Offset = offset
+	} else if raw := q.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return opts, fmt.Errorf("offset must be a non-negative integer")
+		}
+		opts.Offset = offset
+	}
+
+	opts.Sort = strings.TrimSpace(q.Get("sort"))
+
+	if raw := q.Get("from"); raw != "" {
+		from, err := parseListBound(raw, timezone, false)
+		if err != nil {
+			return opts, fmt.Errorf("invalid from: %s", raw)
+		}
+		opts.From = &from
+	}
+
+	if raw := q.Get("to"); raw != "" {
+		to, err := parseListBound(raw, timezone, true)
+		if err != nil {
+			return opts, fmt.Errorf("invalid to: %s", raw)
+		}
+		opts.To = &to
+	}
+
+	if opts.From != nil && opts.To != nil && !opts.To.After(*opts.From) {
+		return opts, fmt.Errorf("to must be after from")
+	}
+
+	return opts, nil
+}
+
+func parseListBound(raw, timezone string, endOfDay bool) (time.Time, error) {
+	if t, _, err := parseEventTime(raw, timezone); err == nil {
+		return t, nil
+	}
+
+	day, _, err := timeutil.ParseDateWithDefaultTime(raw, timezone, 0, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// setPaginationHeaders reports the total match count and, when more rows remain, an opaque
+// cursor for the next page. Bodies stay plain arrays so existing clients are unaffected.
+func setPaginationHeaders(w http.ResponseWriter, opts database.ListOptions, returned, total int) {
+	w.Header().Set(headerTotalCount, strconv.Itoa(total))
+	if opts.Limit > 0 && opts.Offset+returned < total {
+		w.Header().Set(headerNextCursor, encodeCursor(opts.Offset+returned))
+	}
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	value, ok := strings.CutPrefix(string(raw), "o:")
+	if !ok {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("malformed cursor")
+	}
+	return offset, nil
+}
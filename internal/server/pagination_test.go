@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListOptions(t *testing.T) {
+	t.Run("defaults to unpaged", func(t *testing.T) {
+		opts, err := parseListOptions(httptest.NewRequest("GET", "/api/events", nil), "UTC")
+		require.NoError(t, err)
+		assert.Zero(t, opts.Limit)
+		assert.Zero(t, opts.Offset)
+		assert.Nil(t, opts.From)
+		assert.Nil(t, opts.To)
+	})
+
+	t.Run("caps limit and reads offset", func(t *testing.T) {
+		opts, err := parseListOptions(httptest.NewRequest("GET", "/api/events?limit=5000&offset=40&sort=-start_time", nil), "UTC")
+		require.NoError(t, err)
+		assert.Equal(t, maxListLimit, opts.Limit)
+		assert.Equal(t, 40, opts.Offset)
+		assert.Equal(t, "-start_time", opts.Sort)
+	})
+
+	t.Run("cursor takes precedence over offset", func(t *testing.T) {
+		opts, err := parseListOptions(httptest.NewRequest("GET", "/api/events?offset=3&cursor="+encodeCursor(20), nil), "UTC")
+		require.NoError(t, err)
+		assert.Equal(t, 20, opts.Offset)
+	})
+
+	t.Run("date-only to includes the whole day", func(t *testing.T) {
+		opts, err := parseListOptions(httptest.NewRequest("GET", "/api/events?from=2026-03-01&to=2026-03-01", nil), "Asia/Jerusalem")
+		require.NoError(t, err)
+		require.NotNil(t, opts.From)
+		require.NotNil(t, opts.To)
+		assert.Equal(t, 24*time.Hour, opts.To.Sub(*opts.From))
+		assert.Equal(t, 0, opts.From.Hour())
+	})
+
+	for _, query := range []string{"limit=0", "limit=abc", "offset=-1", "cursor=bogus", "from=yesterday", "from=2026-03-02&to=2026-03-01T00:00:00Z"} {
+		t.Run("rejects "+query, func(t *testing.T) {
+			_, err := parseListOptions(httptest.NewRequest("GET", "/api/events?"+query, nil), "UTC")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSetPaginationHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	opts, err := parseListOptions(httptest.NewRequest("GET", "/api/events?limit=2", nil), "UTC")
+	require.NoError(t, err)
+
+	setPaginationHeaders(w, opts, 2, 5)
+	assert.Equal(t, "5", w.Header().Get(headerTotalCount))
+
+	offset, err := decodeCursor(w.Header().Get(headerNextCursor))
+	require.NoError(t, err)
+	assert.Equal(t, 2, offset)
+
+	w = httptest.NewRecorder()
+	opts.Offset = 4
+	setPaginationHeaders(w, opts, 1, 5)
+	assert.Empty(t, w.Header().Get(headerNextCursor))
+}
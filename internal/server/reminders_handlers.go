@@ -2,14 +2,18 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/gcal"
+	"github.com/omriShneor/project_alfred/internal/processor"
 	"github.com/omriShneor/project_alfred/internal/timeutil"
 )
 
@@ -37,12 +41,47 @@ func (s *Server) handleListReminders(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	reminders, err := s.db.ListReminders(userID, status, channelID)
+	opts, err := parseListOptions(r, s.getUserTimezone(userID))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := parseLabelFilter(r, &opts); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reminders, total, err := s.db.ListRemindersWithOptions(userID, status, channelID, opts)
+	if errors.Is(err, database.ErrInvalidSort) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	setPaginationHeaders(w, opts, len(reminders), total)
+	respondJSON(w, http.StatusOK, reminders)
+}
+
+// handleListOverdueReminders returns the user's confirmed and synced reminders whose due
+// date has passed, oldest due first
+func (s *Server) handleListOverdueReminders(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	reminders, err := s.db.ListOverdueReminders(userID, time.Now())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if reminders == nil {
+		reminders = []database.Reminder{}
+	}
 	respondJSON(w, http.StatusOK, reminders)
 }
 
@@ -64,7 +103,7 @@ func (s *Server) handleCreateReminder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
 		return
 	}
 
@@ -138,6 +177,105 @@ func (s *Server) handleCreateReminder(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusCreated, created)
 }
 
+// maxReminderParseLength caps the text accepted by handleParseReminder
+const maxReminderParseLength = 500
+
+// ParsedReminder is a reminder read from free text by handleParseReminder. It is not
+// saved; clients show it for review and create it with POST /api/reminders.
+type ParsedReminder struct {
+	Title        string                    `json:"title"`
+	Description  string                    `json:"description,omitempty"`
+	DueDate      *time.Time                `json:"due_date,omitempty"`
+	ReminderTime *time.Time                `json:"reminder_time,omitempty"`
+	Priority     database.ReminderPriority `json:"priority"`
+	Confidence   float64                   `json:"confidence"`
+	Reasoning    string                    `json:"reasoning,omitempty"`
+}
+
+// handleParseReminder turns free text ("remind me to renew the passport two weeks
+// before August 1st") into a structured reminder with a computed due date and priority,
+// using the reminder agent with the user's timezone and model settings
+func (s *Server) handleParseReminder(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if s.reminderAnalyzer == nil || !s.reminderAnalyzer.IsConfigured() {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "reminder analyzer not configured")
+		return
+	}
+
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		respondError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+	if len(text) > maxReminderParseLength {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("text must be at most %d characters", maxReminderParseLength))
+		return
+	}
+
+	// The text is analyzed as a message the user just sent themselves
+	now := time.Now()
+	ctx := agent.WithMessageTime(processor.AnalysisContext(r.Context(), s.db, userID), now)
+	analysis, err := s.reminderAnalyzer.AnalyzeMessages(ctx, nil, database.MessageRecord{
+		SenderName:  "Me",
+		MessageText: text,
+		Timestamp:   now,
+	}, nil)
+	if errors.Is(err, agent.ErrBudgetExceeded) {
+		respondErrorCode(w, http.StatusTooManyRequests, CodeLLMBudgetExceeded, "monthly LLM budget exceeded")
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Reminders: failed to parse text", "error", err)
+		respondError(w, http.StatusBadGateway, "failed to parse reminder")
+		return
+	}
+	if analysis == nil || analysis.Action != "create" || analysis.Reminder == nil || strings.TrimSpace(analysis.Reminder.Title) == "" {
+		respondError(w, http.StatusUnprocessableEntity, "no reminder found in text")
+		return
+	}
+
+	data := analysis.Reminder
+	timezone := s.getUserTimezone(userID)
+	parsed := ParsedReminder{
+		Title:       strings.TrimSpace(data.Title),
+		Description: strings.TrimSpace(data.Description),
+		Priority:    database.ReminderPriorityNormal,
+		Confidence:  analysis.Confidence,
+		Reasoning:   analysis.Reasoning,
+	}
+	if priority, err := parseReminderPriority(data.Priority); err == nil {
+		parsed.Priority = priority
+	}
+	if data.DueDate != "" {
+		dueDate, err := parseReminderDateTime(data.DueDate, timezone)
+		if err != nil {
+			slog.WarnContext(r.Context(), "Reminders: unparseable due date from agent", "due_date", data.DueDate)
+			respondError(w, http.StatusUnprocessableEntity, "could not determine the due date")
+			return
+		}
+		parsed.DueDate = &dueDate
+	}
+	if data.ReminderTime != "" {
+		if reminderTime, err := parseReminderDateTime(data.ReminderTime, timezone); err == nil {
+			parsed.ReminderTime = &reminderTime
+		}
+	}
+
+	respondJSON(w, http.StatusOK, parsed)
+}
+
 // handleGetReminder returns a single reminder by ID
 func (s *Server) handleGetReminder(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
@@ -148,13 +286,13 @@ func (s *Server) handleGetReminder(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	reminder, err := s.db.GetReminderByID(id)
 	if err != nil || reminder.UserID != userID {
-		respondError(w, http.StatusNotFound, "reminder not found")
+		respondErrorCode(w, http.StatusNotFound, CodeReminderNotFound, "reminder not found")
 		return
 	}
 
@@ -170,6 +308,10 @@ func (s *Server) handleGetReminder(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if snoozes, err := s.db.ListReminderSnoozes(reminder.ID); err == nil && len(snoozes) > 0 {
+		response["snooze_history"] = snoozes
+	}
+
 	respondJSON(w, http.StatusOK, response)
 }
 
@@ -183,13 +325,13 @@ func (s *Server) handleUpdateReminder(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	reminder, err := s.db.GetReminderByID(id)
 	if err != nil || reminder.UserID != userID {
-		respondError(w, http.StatusNotFound, "reminder not found")
+		respondErrorCode(w, http.StatusNotFound, CodeReminderNotFound, "reminder not found")
 		return
 	}
 
@@ -208,7 +350,7 @@ func (s *Server) handleUpdateReminder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
 		return
 	}
 
@@ -292,21 +434,35 @@ func (s *Server) handleConfirmReminder(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	reminder, err := s.db.GetReminderByID(id)
 	if err != nil || reminder.UserID != userID {
-		respondError(w, http.StatusNotFound, "reminder not found")
+		respondErrorCode(w, http.StatusNotFound, CodeReminderNotFound, "reminder not found")
 		return
 	}
 
 	if reminder.Status != database.ReminderStatusPending {
-		respondError(w, http.StatusBadRequest, "reminder is not pending")
+		respondErrorCode(w, http.StatusBadRequest, CodeReminderNotPending, "reminder is not pending")
 		return
 	}
 
+	updatedReminder, err := s.confirmPendingReminder(userID, reminder)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, updatedReminder)
+}
+
+// confirmPendingReminder applies a pending reminder's action, syncing it to Google Calendar
+// when sync is enabled, and returns the updated reminder
+func (s *Server) confirmPendingReminder(userID int64, reminder *database.Reminder) (*database.Reminder, error) {
+	id := reminder.ID
+	var err error
+
 	// Check if sync is enabled and Google Calendar is connected
 	gcalSettings, _ := s.db.GetGCalSettings(userID)
 	userGCalClient := s.getGCalClientForUser(userID)
@@ -322,13 +478,12 @@ func (s *Server) handleConfirmReminder(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err := s.db.UpdateReminderStatus(id, newStatus); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to confirm reminder: %v", err))
-			return
+			return nil, fmt.Errorf("failed to confirm reminder: %w", err)
 		}
 
+		s.retireReplacedReminder(reminder)
 		updatedReminder, _ := s.db.GetReminderByID(id)
-		respondJSON(w, http.StatusOK, updatedReminder)
-		return
+		return updatedReminder, nil
 	}
 
 	// Sync to Google Calendar as a reminder event
@@ -337,8 +492,7 @@ func (s *Server) handleConfirmReminder(w http.ResponseWriter, r *http.Request) {
 		if reminder.DueDate == nil {
 			// Cannot sync without a timestamp; keep reminder locally.
 			if err := s.db.UpdateReminderStatus(id, database.ReminderStatusConfirmed); err != nil {
-				respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to confirm reminder: %v", err))
-				return
+				return nil, fmt.Errorf("failed to confirm reminder: %w", err)
 			}
 			break
 		}
@@ -352,22 +506,19 @@ func (s *Server) handleConfirmReminder(w http.ResponseWriter, r *http.Request) {
 			EndTime:     endTime,
 		})
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create calendar reminder: %v", err))
-			return
+			return nil, fmt.Errorf("failed to create calendar reminder: %w", err)
 		}
 
 		// Update database with Google event ID
 		if err := s.db.UpdateReminderGoogleID(id, googleEventID); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update reminder: %v", err))
-			return
+			return nil, fmt.Errorf("failed to update reminder: %w", err)
 		}
 
 	case database.ReminderActionUpdate:
 		// If no Google event ID or due date, just confirm locally
 		if reminder.GoogleEventID == nil || reminder.DueDate == nil {
 			if err := s.db.UpdateReminderStatus(id, database.ReminderStatusConfirmed); err != nil {
-				respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to confirm reminder: %v", err))
-				return
+				return nil, fmt.Errorf("failed to confirm reminder: %w", err)
 			}
 			break
 		}
@@ -381,29 +532,38 @@ func (s *Server) handleConfirmReminder(w http.ResponseWriter, r *http.Request) {
 			EndTime:     endTime,
 		})
 		if err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update calendar reminder: %v", err))
-			return
+			return nil, fmt.Errorf("failed to update calendar reminder: %w", err)
 		}
 		if err := s.db.UpdateReminderStatus(id, database.ReminderStatusSynced); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to update reminder status: %v", err))
-			return
+			return nil, fmt.Errorf("failed to update reminder status: %w", err)
 		}
 
 	case database.ReminderActionDelete:
 		// If we have a Google event ID, delete from calendar
 		if reminder.GoogleEventID != nil {
 			if err := userGCalClient.DeleteEvent(reminder.CalendarID, *reminder.GoogleEventID); err != nil {
-				fmt.Printf("Warning: failed to delete calendar reminder: %v\n", err)
+				slog.Warn("failed to delete calendar reminder", "error", err)
 			}
 		}
 		if err := s.db.UpdateReminderStatus(id, database.ReminderStatusDismissed); err != nil {
-			respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to dismiss reminder: %v", err))
-			return
+			return nil, fmt.Errorf("failed to dismiss reminder: %w", err)
 		}
 	}
 
+	s.retireReplacedReminder(reminder)
 	updatedReminder, _ := s.db.GetReminderByID(id)
-	respondJSON(w, http.StatusOK, updatedReminder)
+	return updatedReminder, nil
+}
+
+// retireReplacedReminder dismisses the confirmed reminder a just-confirmed
+// update/delete replaces, so the user doesn't see both
+func (s *Server) retireReplacedReminder(reminder *database.Reminder) {
+	if reminder.ReplacesID == nil {
+		return
+	}
+	if _, err := s.db.RetireReplacedReminder(reminder.ID); err != nil {
+		slog.Warn("failed to retire reminder replaced", "replaces_id", *reminder.ReplacesID, "reminder_id", reminder.ID, "error", err)
+	}
 }
 
 // handleRejectReminder rejects a pending reminder
@@ -416,18 +576,18 @@ func (s *Server) handleRejectReminder(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	reminder, err := s.db.GetReminderByID(id)
 	if err != nil || reminder.UserID != userID {
-		respondError(w, http.StatusNotFound, "reminder not found")
+		respondErrorCode(w, http.StatusNotFound, CodeReminderNotFound, "reminder not found")
 		return
 	}
 
 	if reminder.Status != database.ReminderStatusPending {
-		respondError(w, http.StatusBadRequest, "reminder is not pending")
+		respondErrorCode(w, http.StatusBadRequest, CodeReminderNotPending, "reminder is not pending")
 		return
 	}
 
@@ -450,13 +610,13 @@ func (s *Server) handleCompleteReminder(w http.ResponseWriter, r *http.Request)
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	reminder, err := s.db.GetReminderByID(id)
 	if err != nil || reminder.UserID != userID {
-		respondError(w, http.StatusNotFound, "reminder not found")
+		respondErrorCode(w, http.StatusNotFound, CodeReminderNotFound, "reminder not found")
 		return
 	}
 
@@ -485,19 +645,19 @@ func (s *Server) handleDismissReminder(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	reminder, err := s.db.GetReminderByID(id)
 	if err != nil || reminder.UserID != userID {
-		respondError(w, http.StatusNotFound, "reminder not found")
+		respondErrorCode(w, http.StatusNotFound, CodeReminderNotFound, "reminder not found")
 		return
 	}
 
 	// Can dismiss pending, confirmed, or synced reminders
 	if reminder.Status == database.ReminderStatusCompleted || reminder.Status == database.ReminderStatusRejected || reminder.Status == database.ReminderStatusDismissed {
-		respondError(w, http.StatusBadRequest, "reminder is already in a final state")
+		respondErrorCode(w, http.StatusBadRequest, CodeReminderFinal, "reminder is already in a final state")
 		return
 	}
 
@@ -505,7 +665,7 @@ func (s *Server) handleDismissReminder(w http.ResponseWriter, r *http.Request) {
 	userGCalClient := s.getGCalClientForUser(userID)
 	if reminder.GoogleEventID != nil && userGCalClient != nil && userGCalClient.IsAuthenticated() {
 		if err := userGCalClient.DeleteEvent(reminder.CalendarID, *reminder.GoogleEventID); err != nil {
-			fmt.Printf("Warning: failed to delete calendar reminder: %v\n", err)
+			slog.WarnContext(r.Context(), "failed to delete calendar reminder", "error", err)
 		}
 	}
 
@@ -518,6 +678,93 @@ func (s *Server) handleDismissReminder(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, updatedReminder)
 }
 
+// handleSnoozeReminder defers an active reminder by a duration or to an explicit new due date
+func (s *Server) handleSnoozeReminder(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	reminder, err := s.db.GetReminderByID(id)
+	if err != nil || reminder.UserID != userID {
+		respondErrorCode(w, http.StatusNotFound, CodeReminderNotFound, "reminder not found")
+		return
+	}
+
+	if reminder.Status == database.ReminderStatusCompleted || reminder.Status == database.ReminderStatusRejected || reminder.Status == database.ReminderStatusDismissed {
+		respondErrorCode(w, http.StatusBadRequest, CodeReminderFinal, "reminder is already in a final state")
+		return
+	}
+
+	var req struct {
+		DurationMinutes *int    `json:"duration_minutes"`
+		DueDate         *string `json:"due_date"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+
+	hasDueDate := req.DueDate != nil && strings.TrimSpace(*req.DueDate) != ""
+	if (req.DurationMinutes == nil) == !hasDueDate {
+		respondError(w, http.StatusBadRequest, "exactly one of duration_minutes or due_date is required")
+		return
+	}
+
+	var until time.Time
+	if req.DurationMinutes != nil {
+		if *req.DurationMinutes <= 0 {
+			respondError(w, http.StatusBadRequest, "duration_minutes must be positive")
+			return
+		}
+		until = time.Now().Add(time.Duration(*req.DurationMinutes) * time.Minute)
+	} else {
+		parsed, err := parseReminderDateTime(strings.TrimSpace(*req.DueDate), s.getUserTimezone(userID))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid due_date format")
+			return
+		}
+		if !parsed.After(time.Now()) {
+			respondError(w, http.StatusBadRequest, "due_date must be in the future")
+			return
+		}
+		until = parsed
+	}
+
+	if err := s.db.SnoozeReminder(reminder, until); err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("failed to snooze reminder: %v", err))
+		return
+	}
+
+	// Keep the Google Calendar copy aligned with the new due date.
+	if reminder.GoogleEventID != nil {
+		userGCalClient := s.getGCalClientForUser(userID)
+		if userGCalClient != nil && userGCalClient.IsAuthenticated() {
+			err := userGCalClient.UpdateEvent(reminder.CalendarID, *reminder.GoogleEventID, gcal.EventInput{
+				Summary:     "[Reminder] " + reminder.Title,
+				Description: reminder.Description,
+				Location:    reminder.Location,
+				StartTime:   until,
+				EndTime:     until.Add(30 * time.Minute),
+			})
+			if err != nil {
+				slog.WarnContext(r.Context(), "failed to update snoozed calendar reminder", "error", err)
+			}
+		}
+	}
+
+	updatedReminder, _ := s.db.GetReminderByID(id)
+	respondJSON(w, http.StatusOK, updatedReminder)
+}
+
 func parseReminderDateTime(s, timezone string) (time.Time, error) {
 	if t, _, err := parseEventTime(s, timezone); err == nil {
 		return t, nil
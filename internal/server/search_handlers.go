@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// handleSearch runs a full-text search over the user's messages, events, and reminders.
+// Query: q (required), types (comma-separated: message,event,reminder), limit.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	var types []database.SearchResultType
+	if raw := r.URL.Query().Get("types"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			t := database.SearchResultType(strings.TrimSpace(part))
+			switch t {
+			case database.SearchResultMessage, database.SearchResultEvent, database.SearchResultReminder:
+				types = append(types, t)
+			default:
+				respondError(w, http.StatusBadRequest, "invalid type: must be one of message, event, reminder")
+				return
+			}
+		}
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if l, err := strconv.Atoi(raw); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	hits, err := s.db.Search(userID, query, types, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"query":   query,
+		"results": hits,
+	})
+}
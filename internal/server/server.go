@@ -3,13 +3,18 @@ package server
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/omriShneor/project_alfred/internal/backup"
 	"github.com/omriShneor/project_alfred/internal/clients"
 	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/export"
 	"github.com/omriShneor/project_alfred/internal/gcal"
 	"github.com/omriShneor/project_alfred/internal/gmail"
 	"github.com/omriShneor/project_alfred/internal/notify"
@@ -22,32 +27,73 @@ type Server struct {
 	gmailClient      *gmail.Client
 	gmailWorker      *gmail.Worker
 	onboardingState  *sse.State
-	state            *sse.State // Alias for onboardingState (for consistency)
+	state            *sse.State        // Alias for onboardingState (for consistency)
+	streams          *sse.StateManager // Per-user event bus for /api/stream
 	notifyService    *notify.Service
 	eventAnalyzer    agent.EventAnalyzer
 	reminderAnalyzer agent.ReminderAnalyzer
+	travelAnalyzer   agent.TravelAnalyzer
+	billAnalyzer     agent.BillAnalyzer
+	occasionAnalyzer agent.OccasionAnalyzer
+	assistant        agent.Assistant
 	httpSrv          *http.Server
 	port             int
-	resendAPIKey     string // For checking email availability
 	credentialsFile  string // Path to Google OAuth credentials file (for per-user gcal clients)
 	devMode          bool   // Enable development features
 	// Authentication
 	authService    *auth.Service
 	authMiddleware *auth.Middleware
+	encryptor      *auth.Encryptor // nil = keyed from the environment
 	// Per-user service management
 	userServiceManager *UserServiceManager
+	// Account data exports
+	exporter *export.Exporter
+	// Database backups (nil when not configured)
+	backups *backup.Manager
+	// Verifies one-click confirm/reject links in emails (nil disables them)
+	emailActions *notify.EmailActionSigner
+	// Channels with a reprocess run in progress (channel ID -> true)
+	reprocessing sync.Map
+	// Lowercased emails of users allowed to call /api/admin endpoints
+	adminEmails map[string]bool
+	// Message retention for users without their own setting (0 = forever)
+	messageRetentionDays int
+	// Default agent temperature, reported by /api/settings/llm
+	llmTemperature float64
+	// Monthly LLM budget in USD for users without their own (0 = unlimited)
+	llmMonthlyBudget float64
+	// LLM provider and its reachability check for the health checks (nil when unknown)
+	llmProvider string
+	llmProbe    *reachabilityProbe
+	startedAt   time.Time
+	// Externally reachable base URL for links such as calendar feeds ("" = from the request)
+	publicURL string
 }
 
 // ServerConfig holds configuration for initial server creation (onboarding-capable)
 type ServerConfig struct {
 	DB              *database.DB
 	OnboardingState *sse.State
+	Streams         *sse.StateManager // Per-user stream bus (created if nil)
 	Port            int
-	ResendAPIKey    string
 	DevMode         bool // Enable development features (e.g., unauthenticated reset)
 	// Auth configuration (optional - auth disabled if not provided)
 	CredentialsFile string // Path to Google OAuth credentials file
 	CredentialsJSON string // Google OAuth credentials as JSON string
+	// Encrypts OAuth tokens and keys access tokens (nil = key read from the environment)
+	Encryptor *auth.Encryptor
+	// Emails of users allowed to call /api/admin endpoints
+	AdminEmails []string
+	// Default message retention in days, reported by /api/account/retention
+	MessageRetentionDays int
+	// Default agent temperature, reported by /api/settings/llm
+	LLMTemperature float64
+	// Default monthly LLM budget in USD, reported by /api/usage
+	LLMMonthlyBudget float64
+	// LLM provider whose API the health checks probe for reachability ("" = Anthropic)
+	LLMProvider string
+	// Externally reachable base URL, e.g. https://alfred.example.com ("" = from the request)
+	PublicURL string
 }
 
 // ClientsConfig holds configuration for completing initialization after onboarding
@@ -57,21 +103,46 @@ type ClientsConfig struct {
 	NotifyService    *notify.Service
 	EventAnalyzer    agent.EventAnalyzer
 	ReminderAnalyzer agent.ReminderAnalyzer
+	TravelAnalyzer   agent.TravelAnalyzer
+	BillAnalyzer     agent.BillAnalyzer
+	OccasionAnalyzer agent.OccasionAnalyzer
+	Assistant        agent.Assistant
 }
 
 func New(cfg ServerConfig) *Server {
 	s := &Server{
-		db:              cfg.DB,
-		onboardingState: cfg.OnboardingState,
-		state:           cfg.OnboardingState, // Alias for consistency
-		port:            cfg.Port,
-		resendAPIKey:    cfg.ResendAPIKey,
-		credentialsFile: cfg.CredentialsFile,
-		devMode:         cfg.DevMode,
+		db:                   cfg.DB,
+		onboardingState:      cfg.OnboardingState,
+		state:                cfg.OnboardingState, // Alias for consistency
+		streams:              cfg.Streams,
+		port:                 cfg.Port,
+		credentialsFile:      cfg.CredentialsFile,
+		encryptor:            cfg.Encryptor,
+		devMode:              cfg.DevMode,
+		adminEmails:          make(map[string]bool),
+		messageRetentionDays: cfg.MessageRetentionDays,
+		llmTemperature:       cfg.LLMTemperature,
+		llmMonthlyBudget:     cfg.LLMMonthlyBudget,
+		llmProvider:          cfg.LLMProvider,
+		startedAt:            time.Now(),
+		publicURL:            strings.TrimSuffix(cfg.PublicURL, "/"),
+	}
+	if s.llmProvider == "" {
+		s.llmProvider = agent.ProviderAnthropic
+	}
+	if url, err := agent.ProviderURL(s.llmProvider); err == nil {
+		s.llmProbe = newReachabilityProbe(url)
+	}
+	for _, email := range cfg.AdminEmails {
+		s.adminEmails[strings.ToLower(email)] = true
+	}
+
+	if s.streams == nil {
+		s.streams = sse.NewStateManager()
 	}
 
 	if cfg.DevMode {
-		fmt.Println("Development mode enabled - some endpoints will bypass authentication")
+		slog.Info("Development mode enabled - some endpoints will bypass authentication")
 	}
 
 	// Initialize authentication if credentials are available
@@ -81,7 +152,7 @@ func New(cfg ServerConfig) *Server {
 	}
 	if err := s.initAuth(authCfg); err != nil {
 		// Auth initialization is optional - log warning but continue
-		fmt.Printf("Warning: authentication not configured: %v\n", err)
+		slog.Warn("authentication not configured", "error", err)
 	}
 
 	mux := http.NewServeMux()
@@ -89,7 +160,7 @@ func New(cfg ServerConfig) *Server {
 
 	s.httpSrv = &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      s.corsMiddleware(mux),
+		Handler:      s.loggingMiddleware(s.corsMiddleware(gzipMiddleware(errorFormatMiddleware(mux)))),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -105,6 +176,16 @@ func (s *Server) InitializeClients(cfg ClientsConfig) {
 	s.notifyService = cfg.NotifyService
 	s.eventAnalyzer = cfg.EventAnalyzer
 	s.reminderAnalyzer = cfg.ReminderAnalyzer
+	s.travelAnalyzer = cfg.TravelAnalyzer
+	s.billAnalyzer = cfg.BillAnalyzer
+	s.occasionAnalyzer = cfg.OccasionAnalyzer
+	s.assistant = cfg.Assistant
+	s.exporter = export.NewExporter(s.db, cfg.NotifyService)
+}
+
+// Streams returns the per-user event bus backing /api/stream
+func (s *Server) Streams() *sse.StateManager {
+	return s.streams
 }
 
 // SetClientManager sets the ClientManager for per-user WhatsApp/Telegram clients
@@ -112,8 +193,10 @@ func (s *Server) SetClientManager(mgr *clients.ClientManager) {
 	s.clientManager = mgr
 	if mgr != nil {
 		mgr.SetWhatsAppHistorySyncBackfillHook(func(userID int64, channel *database.SourceChannel) {
-			s.startChannelBackfill(userID, channel)
+			s.startChannelBackfill(userID, channel, 0)
 		})
+		mgr.SetWhatsAppHistorySyncProgressHook(s.publishHistorySyncProgress)
+		mgr.SetWhatsAppSelfChatCommandHook(s.runChatCommand)
 	}
 }
 
@@ -130,7 +213,7 @@ func (s *Server) getGCalClientForUser(userID int64) *gcal.Client {
 	}
 	client, err := gcal.NewClientForUser(userID, s.credentialsFile, s.db)
 	if err != nil {
-		fmt.Printf("Warning: failed to create gcal client for user %d: %v\n", userID, err)
+		slog.Warn("failed to create gcal client", "user_id", userID, "error", err)
 		return nil
 	}
 	return client
@@ -139,6 +222,19 @@ func (s *Server) getGCalClientForUser(userID int64) *gcal.Client {
 // SetUserServiceManager sets the user service manager
 func (s *Server) SetUserServiceManager(mgr *UserServiceManager) {
 	s.userServiceManager = mgr
+	if mgr != nil {
+		mgr.autoConfirmer = autoConfirmer{s: s}
+	}
+}
+
+// SetBackupManager enables the /api/admin/backups endpoints
+func (s *Server) SetBackupManager(mgr *backup.Manager) {
+	s.backups = mgr
+}
+
+// SetEmailActionSigner enables the public /api/email-actions endpoints
+func (s *Server) SetEmailActionSigner(signer *notify.EmailActionSigner) {
+	s.emailActions = signer
 }
 
 // GetUserServiceManager returns the user service manager
@@ -146,6 +242,20 @@ func (s *Server) GetUserServiceManager() *UserServiceManager {
 	return s.userServiceManager
 }
 
+// StartGoogleTokenRefresher refreshes users' Google tokens before they expire until ctx
+// is cancelled, and notifies users whose Google access was revoked. It does nothing
+// when authentication isn't configured.
+func (s *Server) StartGoogleTokenRefresher(ctx context.Context, pollInterval time.Duration) {
+	if s.authService == nil {
+		return
+	}
+	auth.NewGoogleTokenRefresher(s.authService, func(ctx context.Context, userID int64) {
+		if s.notifyService != nil {
+			s.notifyService.NotifyGoogleReauthRequired(ctx, userID)
+		}
+	}).Start(ctx, pollInterval)
+}
+
 // requireAuth wraps a handler to require authentication
 func (s *Server) requireAuth(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -154,16 +264,33 @@ func (s *Server) requireAuth(handler http.HandlerFunc) http.HandlerFunc {
 			handler(w, r)
 			return
 		}
-		s.authMiddleware.RequireAuth(http.HandlerFunc(handler)).ServeHTTP(w, r)
+		s.authMiddleware.RequireAuth(withUserLogging(handler)).ServeHTTP(w, r)
 	}
 }
 
+// requireAdmin wraps a handler to require a signed-in user listed in ALFRED_ADMIN_EMAILS.
+// API keys never grant admin access.
+func (s *Server) requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		user := auth.GetUserFromContext(r.Context())
+		if user == nil {
+			respondError(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		if auth.GetAPIKeyScope(r.Context()) != "" || !s.adminEmails[strings.ToLower(user.Email)] {
+			respondErrorCode(w, http.StatusForbidden, CodeAdminRequired, "admin access required")
+			return
+		}
+		handler(w, r)
+	})
+}
+
 // requireAuthUnlessDevMode wraps a handler to require auth in production but allow in dev mode
 func (s *Server) requireAuthUnlessDevMode(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// In dev mode, bypass auth and inject the dev user
 		if s.devMode {
-			fmt.Printf("Dev mode enabled - bypassing auth for %s\n", r.URL.Path)
+			slog.Debug("Dev mode enabled - bypassing auth", "path", r.URL.Path)
 			// Inject dev user (ID 1) - matches the user created in main.go
 			user := &auth.User{
 				ID:       1,
@@ -172,10 +299,10 @@ func (s *Server) requireAuthUnlessDevMode(handler http.HandlerFunc) http.Handler
 				Name:     "Omri Shneor",
 			}
 			ctx := auth.SetUserInContext(r.Context(), user)
-			handler(w, r.WithContext(ctx))
+			withUserLogging(handler)(w, r.WithContext(ctx))
 			return
 		}
-		fmt.Printf("Dev mode disabled - requiring auth for %s\n", r.URL.Path)
+		slog.Debug("Dev mode disabled - requiring auth", "path", r.URL.Path)
 		// Otherwise use normal auth
 		s.requireAuth(handler)(w, r)
 	}
@@ -189,7 +316,7 @@ func (s *Server) optionalAuth(handler http.HandlerFunc) http.HandlerFunc {
 			handler(w, r)
 			return
 		}
-		s.authMiddleware.OptionalAuth(http.HandlerFunc(handler)).ServeHTTP(w, r)
+		s.authMiddleware.OptionalAuth(withUserLogging(handler)).ServeHTTP(w, r)
 	}
 }
 
@@ -200,13 +327,18 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 
 	// Health check
 	mux.HandleFunc("GET /health", s.handleHealthCheck)
+	mux.HandleFunc("GET /healthz", s.handleLiveness)
+	mux.HandleFunc("GET /readyz", s.handleReadiness)
 
 	// Authentication API (must be public for login flow)
 	mux.HandleFunc("POST /api/auth/google/login", s.handleAuthGoogleLogin)
 	mux.HandleFunc("POST /api/auth/google/callback", s.handleAuthGoogleCallback)
+	mux.HandleFunc("POST /api/auth/refresh", s.handleAuthRefresh)
 	mux.HandleFunc("POST /api/auth/google/logout", s.handleAuthLogout)
 	mux.HandleFunc("GET /api/auth/me", s.requireAuth(s.handleAuthMe))
 	mux.HandleFunc("PUT /api/auth/me", s.requireAuth(s.handleUpdateAuthMe))
+	mux.HandleFunc("GET /api/auth/sessions", s.requireAuth(s.handleListSessions))
+	mux.HandleFunc("DELETE /api/auth/sessions/{id}", s.requireAuth(s.handleRevokeSession))
 
 	// Incremental authorization (requires auth - user must be logged in to add scopes)
 	mux.HandleFunc("POST /api/auth/google/add-scopes", s.requireAuth(s.handleRequestAdditionalScopes))
@@ -215,10 +347,18 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// Onboarding API (public for initial app load)
 	mux.HandleFunc("GET /api/onboarding/status", s.handleOnboardingStatus)
 	mux.HandleFunc("GET /api/onboarding/stream", s.handleOnboardingSSE)
+	mux.HandleFunc("GET /api/whatsapp/qr.png", s.handleWhatsAppQRImage)
 
 	// OAuth callback for auth flow (browser redirect from Google, redirects to mobile deep link)
 	mux.HandleFunc("GET /api/auth/callback", s.handleAuthOAuthCallback)
 
+	// One-click confirm/reject links from notification emails (the signed token authenticates)
+	mux.HandleFunc("GET /api/email-actions/{token}", s.handleEmailActionPage)
+	mux.HandleFunc("POST /api/email-actions/{token}", s.handleEmailAction)
+
+	// Subscribable iCalendar feed (the secret token in the URL authenticates)
+	mux.HandleFunc("GET /feeds/{token}/alfred.ics", s.handleCalendarFeed)
+
 	// ============================================
 	// OPTIONAL AUTH ROUTES (work for both authenticated and anonymous)
 	// ============================================
@@ -235,27 +375,30 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /api/whatsapp/pair", s.requireAuth(s.handleWhatsAppPair))
 	mux.HandleFunc("POST /api/whatsapp/reconnect", s.requireAuth(s.handleWhatsAppReconnect))
 	mux.HandleFunc("POST /api/whatsapp/disconnect", s.requireAuth(s.handleWhatsAppDisconnect))
-	mux.HandleFunc("GET /api/whatsapp/top-contacts", s.requireAuth(s.handleWhatsAppTopContacts))
-	mux.HandleFunc("GET /api/whatsapp/contacts/search", s.requireAuth(s.handleWhatsAppContactSearch))
+	mux.HandleFunc("GET /api/whatsapp/top-contacts", s.requireAuth(withETag(s.handleWhatsAppTopContacts)))
+	mux.HandleFunc("GET /api/whatsapp/history-sync", s.requireAuth(s.handleWhatsAppHistorySync))
+	mux.HandleFunc("GET /api/whatsapp/contacts/search", s.requireAuth(withETag(s.handleWhatsAppContactSearch)))
 	mux.HandleFunc("POST /api/whatsapp/sources/custom", s.requireAuth(s.handleWhatsAppCustomSource))
 
 	// Telegram API
 	mux.HandleFunc("GET /api/telegram/status", s.requireAuth(s.handleTelegramStatus))
 	mux.HandleFunc("POST /api/telegram/send-code", s.requireAuth(s.handleTelegramSendCode))
 	mux.HandleFunc("POST /api/telegram/verify-code", s.requireAuth(s.handleTelegramVerifyCode))
+	mux.HandleFunc("POST /api/telegram/password", s.requireAuth(s.handleTelegramVerifyPassword))
+	mux.HandleFunc("POST /api/telegram/qr-login", s.requireAuth(s.handleTelegramQRLogin))
 	mux.HandleFunc("POST /api/telegram/disconnect", s.requireAuth(s.handleTelegramDisconnect))
 	mux.HandleFunc("POST /api/telegram/reconnect", s.requireAuth(s.handleTelegramReconnect))
 	mux.HandleFunc("GET /api/telegram/discovery/channels", s.requireAuth(s.handleDiscoverTelegramChannels))
-	mux.HandleFunc("GET /api/telegram/channel", s.requireAuth(s.handleListTelegramChannels))
+	mux.HandleFunc("GET /api/telegram/channel", s.requireAuth(withETag(s.handleListTelegramChannels)))
 	mux.HandleFunc("POST /api/telegram/channel", s.requireAuth(s.handleCreateTelegramChannel))
 	mux.HandleFunc("PUT /api/telegram/channel/{id}", s.requireAuth(s.handleUpdateTelegramChannel))
 	mux.HandleFunc("DELETE /api/telegram/channel/{id}", s.requireAuth(s.handleDeleteTelegramChannel))
-	mux.HandleFunc("GET /api/telegram/top-contacts", s.requireAuth(s.handleTelegramTopContacts))
-	mux.HandleFunc("GET /api/telegram/contacts/search", s.requireAuth(s.handleTelegramContactSearch))
+	mux.HandleFunc("GET /api/telegram/top-contacts", s.requireAuth(withETag(s.handleTelegramTopContacts)))
+	mux.HandleFunc("GET /api/telegram/contacts/search", s.requireAuth(withETag(s.handleTelegramContactSearch)))
 	mux.HandleFunc("POST /api/telegram/sources/custom", s.requireAuth(s.handleTelegramCustomSource))
 
 	// WhatsApp Channel Registry API
-	mux.HandleFunc("GET /api/whatsapp/channel", s.requireAuth(s.handleListWhatsappChannels))
+	mux.HandleFunc("GET /api/whatsapp/channel", s.requireAuth(withETag(s.handleListWhatsappChannels)))
 	mux.HandleFunc("POST /api/whatsapp/channel", s.requireAuth(s.handleCreateWhatsappChannel))
 	mux.HandleFunc("PUT /api/whatsapp/channel/{id}", s.requireAuth(s.handleUpdateWhatsappChannel))
 	mux.HandleFunc("DELETE /api/whatsapp/channel/{id}", s.requireAuth(s.handleDeleteWhatsappChannel))
@@ -265,37 +408,184 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/gcal/calendars", s.requireAuth(s.handleGCalListCalendars))
 	mux.HandleFunc("GET /api/gcal/settings", s.requireAuth(s.handleGetGCalSettings))
 	mux.HandleFunc("PUT /api/gcal/settings", s.requireAuth(s.handleUpdateGCalSettings))
-	mux.HandleFunc("GET /api/gcal/events/today", s.requireAuth(s.handleListTodayEvents))
+	mux.HandleFunc("GET /api/gcal/events/today", s.requireAuth(withETag(s.handleListTodayEvents)))
 	mux.HandleFunc("POST /api/gcal/disconnect", s.requireAuth(s.handleGCalDisconnect))
 
 	// Events API
-	mux.HandleFunc("GET /api/events", s.requireAuth(s.handleListEvents))
-	mux.HandleFunc("GET /api/events/today", s.requireAuth(s.handleListMergedTodayEvents))
+	mux.HandleFunc("GET /api/events", s.requireAuth(withETag(s.handleListEvents)))
+	mux.HandleFunc("GET /api/events/today", s.requireAuth(withETag(s.handleListMergedTodayEvents)))
+	mux.HandleFunc("GET /api/calendar", s.requireAuth(s.handleListCalendar))
+	mux.HandleFunc("GET /api/calendar-feed", s.requireAuth(s.handleGetCalendarFeed))
+	mux.HandleFunc("POST /api/calendar-feed", s.requireAuth(s.handleCreateCalendarFeed))
+	mux.HandleFunc("DELETE /api/calendar-feed", s.requireAuth(s.handleDeleteCalendarFeed))
+	mux.HandleFunc("GET /api/agenda", s.requireAuth(s.handleGetAgenda))
 	mux.HandleFunc("GET /api/events/{id}", s.requireAuth(s.handleGetEvent))
+	mux.HandleFunc("GET /api/events/{id}/attachments/{attachment_id}", s.requireAuth(s.handleGetEventAttachment))
 	mux.HandleFunc("PUT /api/events/{id}", s.requireAuth(s.handleUpdateEvent))
 	mux.HandleFunc("POST /api/events/{id}/confirm", s.requireAuth(s.handleConfirmEvent))
 	mux.HandleFunc("POST /api/events/{id}/reject", s.requireAuth(s.handleRejectEvent))
-	mux.HandleFunc("GET /api/events/channel/{channelId}/history", s.requireAuth(s.handleGetChannelHistory))
+	mux.HandleFunc("POST /api/events/{id}/convert-to-reminder", s.requireAuth(s.handleConvertEventToReminder))
+	mux.HandleFunc("GET /api/events/{id}/notifications", s.requireAuth(s.handleGetEventNotifications))
+	mux.HandleFunc("PUT /api/events/{id}/notifications", s.requireAuth(s.handleUpdateEventNotifications))
+	mux.HandleFunc("GET /api/events/channel/{channelId}/history", s.requireAuth(withETag(s.handleGetChannelHistory)))
+	mux.HandleFunc("PUT /api/events/{id}/sharing", s.requireAuth(s.handleUpdateEventSharing))
+
+	// Which analyzers run on a channel's messages
+	mux.HandleFunc("POST /api/channels/import", s.requireAuth(s.handleImportChannels))
+	mux.HandleFunc("GET /api/channels/suggestions", s.requireAuth(withETag(s.handleListChannelSuggestions)))
+	mux.HandleFunc("PUT /api/channels/{id}/analysis-mode", s.requireAuth(s.handleUpdateChannelAnalysisMode))
+	mux.HandleFunc("PUT /api/channels/{id}/mute", s.requireAuth(s.handleMuteChannel))
+	mux.HandleFunc("DELETE /api/channels/{id}/mute", s.requireAuth(s.handleUnmuteChannel))
+	mux.HandleFunc("POST /api/channels/{id}/reprocess", s.requireAuth(s.handleReprocessChannel))
+	mux.HandleFunc("GET /api/channels/{id}/backfill", s.requireAuth(s.handleGetChannelBackfill))
+
+	// Channel labels: tags that group channels and can route their events to a calendar
+	mux.HandleFunc("GET /api/labels", s.requireAuth(withETag(s.handleListChannelLabels)))
+	mux.HandleFunc("POST /api/labels", s.requireAuth(s.handleCreateChannelLabel))
+	mux.HandleFunc("PUT /api/labels/{id}", s.requireAuth(s.handleUpdateChannelLabel))
+	mux.HandleFunc("DELETE /api/labels/{id}", s.requireAuth(s.handleDeleteChannelLabel))
+	mux.HandleFunc("PUT /api/channels/{id}/labels", s.requireAuth(s.handleSetChannelLabels))
+
+	// Unified contacts: one person across WhatsApp, Telegram and Gmail
+	mux.HandleFunc("GET /api/contacts", s.requireAuth(withETag(s.handleListContacts)))
+	mux.HandleFunc("POST /api/contacts/sync", s.requireAuth(s.handleSyncContacts))
+	mux.HandleFunc("PUT /api/contacts/{id}", s.requireAuth(s.handleUpdateContact))
+	mux.HandleFunc("POST /api/contacts/{id}/merge", s.requireAuth(s.handleMergeContacts))
+	mux.HandleFunc("POST /api/contacts/identities/{id}/split", s.requireAuth(s.handleSplitContactIdentity))
+
+	// Household sharing: channel owners invite members by email; members receive copies
+	// of events detected in the channel
+	mux.HandleFunc("GET /api/channels/{id}/shares", s.requireAuth(s.handleListChannelShares))
+	mux.HandleFunc("POST /api/channels/{id}/shares", s.requireAuth(s.handleCreateChannelShare))
+	mux.HandleFunc("GET /api/shares", s.requireAuth(withETag(s.handleListSharedChannels)))
+	mux.HandleFunc("GET /api/shares/invitations", s.requireAuth(s.handleListShareInvitations))
+	mux.HandleFunc("POST /api/shares/{id}/accept", s.requireAuth(s.handleAcceptChannelShare))
+	mux.HandleFunc("POST /api/shares/{id}/decline", s.requireAuth(s.handleDeclineChannelShare))
+	mux.HandleFunc("DELETE /api/shares/{id}", s.requireAuth(s.handleDeleteChannelShare))
 
 	// Reminders API
-	mux.HandleFunc("GET /api/reminders", s.requireAuth(s.handleListReminders))
+	mux.HandleFunc("GET /api/reminders", s.requireAuth(withETag(s.handleListReminders)))
 	mux.HandleFunc("POST /api/reminders", s.requireAuth(s.handleCreateReminder))
+	mux.HandleFunc("POST /api/reminders/parse", s.requireAuth(s.handleParseReminder))
+	mux.HandleFunc("GET /api/reminders/overdue", s.requireAuth(withETag(s.handleListOverdueReminders)))
 	mux.HandleFunc("GET /api/reminders/{id}", s.requireAuth(s.handleGetReminder))
 	mux.HandleFunc("PUT /api/reminders/{id}", s.requireAuth(s.handleUpdateReminder))
 	mux.HandleFunc("POST /api/reminders/{id}/confirm", s.requireAuth(s.handleConfirmReminder))
 	mux.HandleFunc("POST /api/reminders/{id}/reject", s.requireAuth(s.handleRejectReminder))
+	mux.HandleFunc("POST /api/reminders/{id}/convert-to-event", s.requireAuth(s.handleConvertReminderToEvent))
 	mux.HandleFunc("POST /api/reminders/{id}/complete", s.requireAuth(s.handleCompleteReminder))
 	mux.HandleFunc("POST /api/reminders/{id}/dismiss", s.requireAuth(s.handleDismissReminder))
+	mux.HandleFunc("POST /api/reminders/{id}/snooze", s.requireAuth(s.handleSnoozeReminder))
+
+	// Search API
+	mux.HandleFunc("GET /api/search", s.requireAuth(withETag(s.handleSearch)))
+
+	// Insights API
+	mux.HandleFunc("GET /api/insights", s.requireAuth(s.handleGetInsights))
+
+	// Message analysis log and annotations API
+	mux.HandleFunc("GET /api/messages/{id}/analyses", s.requireAuth(s.handleListMessageAnalyses))
+	mux.HandleFunc("POST /api/messages/{id}/annotate", s.requireAuth(s.handleAnnotateMessage))
+
+	// API keys for programmatic clients (session auth only)
+	mux.HandleFunc("GET /api/apikeys", s.requireAuth(s.handleListAPIKeys))
+	mux.HandleFunc("POST /api/apikeys", s.requireAuth(s.handleCreateAPIKey))
+	mux.HandleFunc("DELETE /api/apikeys/{id}", s.requireAuth(s.handleRevokeAPIKey))
+
+	// Webhooks API
+	mux.HandleFunc("GET /api/webhooks", s.requireAuth(s.handleListWebhooks))
+	mux.HandleFunc("POST /api/webhooks", s.requireAuth(s.handleCreateWebhook))
+	mux.HandleFunc("PUT /api/webhooks/{id}", s.requireAuth(s.handleUpdateWebhook))
+	mux.HandleFunc("DELETE /api/webhooks/{id}", s.requireAuth(s.handleDeleteWebhook))
+	mux.HandleFunc("GET /api/webhooks/{id}/deliveries", s.requireAuth(s.handleListWebhookDeliveries))
+
+	// Per-user live updates (pending items, sync completion)
+	mux.HandleFunc("GET /api/stream", s.requireAuth(s.handleUserStream))
 
 	// Notification Preferences API
 	mux.HandleFunc("GET /api/notifications/preferences", s.requireAuth(s.handleGetNotificationPrefs))
 	mux.HandleFunc("PUT /api/notifications/email", s.requireAuth(s.handleUpdateEmailPrefs))
 	mux.HandleFunc("POST /api/notifications/push/register", s.requireAuth(s.handleRegisterPushToken))
 	mux.HandleFunc("PUT /api/notifications/push", s.requireAuth(s.handleUpdatePushPrefs))
+	mux.HandleFunc("PUT /api/notifications/events", s.requireAuth(s.handleUpdateEventNotificationPrefs))
+	mux.HandleFunc("PUT /api/notifications/digest", s.requireAuth(s.handleUpdateDigestPrefs))
+	mux.HandleFunc("PUT /api/notifications/escalation", s.requireAuth(s.handleUpdateEscalationPrefs))
+	mux.HandleFunc("GET /api/notifications/deliveries", s.requireAuth(s.handleListNotificationDeliveries))
+	mux.HandleFunc("GET /api/notifications/deliveries/{id}", s.requireAuth(s.handleGetNotificationDelivery))
+	mux.HandleFunc("POST /api/notifications/read", s.requireAuth(s.handleMarkNotificationsRead))
+	mux.HandleFunc("GET /api/pending-count", s.requireAuth(withETag(s.handleGetPendingCount)))
+
+	// Account deletion (confirmation token first, then DELETE with it)
+	mux.HandleFunc("POST /api/account/deletion-token", s.requireAuth(s.handleCreateAccountDeletionToken))
+	mux.HandleFunc("DELETE /api/account", s.requireAuth(s.handleDeleteAccount))
+
+	// Message retention
+	mux.HandleFunc("GET /api/account/retention", s.requireAuth(s.handleGetMessageRetention))
+	mux.HandleFunc("PUT /api/account/retention", s.requireAuth(s.handleUpdateMessageRetention))
+
+	// LLM settings API
+	mux.HandleFunc("GET /api/settings/llm", s.requireAuth(s.handleGetLLMSettings))
+	mux.HandleFunc("PUT /api/settings/llm", s.requireAuth(s.handleUpdateLLMSettings))
+	mux.HandleFunc("GET /api/settings/language", s.requireAuth(s.handleGetLanguageSettings))
+	mux.HandleFunc("PUT /api/settings/language", s.requireAuth(s.handleUpdateLanguageSettings))
+
+	// Optional analyzers (bill detection)
+	mux.HandleFunc("GET /api/settings/features", s.requireAuth(s.handleGetFeatureSettings))
+	mux.HandleFunc("PUT /api/settings/features", s.requireAuth(s.handleUpdateFeatureSettings))
+
+	// Package tracking
+	mux.HandleFunc("GET /api/shipments", s.requireAuth(s.handleListShipments))
+
+	// Ask Alfred
+	mux.HandleFunc("POST /api/assistant/chat", s.requireAuth(s.handleAssistantChat))
+
+	// LLM usage and budget API
+	mux.HandleFunc("GET /api/usage", s.requireAuth(s.handleGetUsage))
+	mux.HandleFunc("PUT /api/usage/budget", s.requireAuth(s.handleUpdateUsageBudget))
+
+	// Account data export
+	mux.HandleFunc("POST /api/account/export", s.requireAuth(s.handleCreateDataExport))
+	mux.HandleFunc("GET /api/account/exports", s.requireAuth(s.handleListDataExports))
+	mux.HandleFunc("GET /api/account/exports/{id}", s.requireAuth(s.handleGetDataExport))
+	mux.HandleFunc("GET /api/account/exports/{id}/download", s.requireAuth(s.handleDownloadDataExport))
+
+	// Admin: database backups
+	mux.HandleFunc("GET /api/admin/backups", s.requireAdmin(s.handleListBackups))
+	mux.HandleFunc("POST /api/admin/backups", s.requireAdmin(s.handleCreateBackup))
+	mux.HandleFunc("POST /api/admin/backups/{name}/restore", s.requireAdmin(s.handleRestoreBackup))
+
+	// Admin: shadow mode comparisons
+	mux.HandleFunc("GET /api/admin/shadow-analyses", s.requireAdmin(s.handleListShadowAnalyses))
+	mux.HandleFunc("GET /api/admin/shadow-analyses/summary", s.requireAdmin(s.handleShadowAnalysisSummary))
+
+	// Admin: dead-letter queue of failed analyses
+	mux.HandleFunc("GET /api/admin/failed-analyses", s.requireAdmin(s.handleListFailedAnalyses))
+	mux.HandleFunc("POST /api/admin/failed-analyses/{id}/retry", s.requireAdmin(s.handleRetryFailedAnalysis))
+
+	// Admin: instance statistics
+	mux.HandleFunc("GET /api/admin/stats", s.requireAdmin(s.handleAdminStats))
+
+	// Admin: subsystem health checks (/readyz only reports the overall status)
+	mux.HandleFunc("GET /api/admin/health", s.requireAdmin(s.handleAdminHealth))
+
+	// Admin: feature flag rollout
+	mux.HandleFunc("GET /api/admin/feature-flags", s.requireAdmin(s.handleListFeatureFlagDefaults))
+	mux.HandleFunc("PUT /api/admin/feature-flags/{name}", s.requireAdmin(s.handleSetFeatureFlag))
+
+	// Admin: user management
+	mux.HandleFunc("GET /api/admin/users", s.requireAdmin(s.handleAdminListUsers))
+	mux.HandleFunc("POST /api/admin/users/{id}/reset-onboarding", s.requireAdmin(s.handleAdminResetOnboarding))
+	mux.HandleFunc("POST /api/admin/users/{id}/revoke-tokens", s.requireAdmin(s.handleAdminRevokeTokens))
+	mux.HandleFunc("POST /api/admin/users/{id}/backfill", s.requireAdmin(s.handleAdminBackfill))
+	mux.HandleFunc("GET /api/admin/users/{id}/export", s.requireAdmin(s.handleAdminExportUser))
+
+	// Push devices
+	mux.HandleFunc("GET /api/devices", s.requireAuth(s.handleListDevices))
+	mux.HandleFunc("DELETE /api/devices/{id}", s.requireAuth(s.handleDeleteDevice))
 
 	// Gmail Top Contacts API
-	mux.HandleFunc("GET /api/gmail/top-contacts", s.requireAuth(s.handleGetTopContacts))
-	mux.HandleFunc("GET /api/gmail/contacts/search", s.requireAuth(s.handleGmailContactSearch))
+	mux.HandleFunc("GET /api/gmail/top-contacts", s.requireAuth(withETag(s.handleGetTopContacts)))
+	mux.HandleFunc("GET /api/gmail/contacts/search", s.requireAuth(withETag(s.handleGmailContactSearch)))
 	mux.HandleFunc("POST /api/gmail/sources/custom", s.requireAuth(s.handleAddCustomSource))
 
 	// Gmail Sources API
@@ -304,15 +594,20 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /api/gmail/sources", s.requireAuth(s.handleCreateEmailSource))
 	mux.HandleFunc("PUT /api/gmail/sources/{id}", s.requireAuth(s.handleUpdateEmailSource))
 	mux.HandleFunc("DELETE /api/gmail/sources/{id}", s.requireAuth(s.handleDeleteEmailSource))
+	mux.HandleFunc("GET /api/gmail/sender-rules", s.requireAuth(s.handleListEmailSenderRules))
+	mux.HandleFunc("POST /api/gmail/sender-rules", s.requireAuth(s.handleSetEmailSenderRule))
+	mux.HandleFunc("DELETE /api/gmail/sender-rules/{id}", s.requireAuth(s.handleDeleteEmailSenderRule))
 
 	// Onboarding completion (requires auth - user must be logged in)
 	mux.HandleFunc("POST /api/onboarding/complete", s.requireAuth(s.handleCompleteOnboarding))
+	mux.HandleFunc("GET /api/onboarding/steps", s.requireAuth(s.handleGetOnboardingSteps))
+	mux.HandleFunc("PUT /api/onboarding/steps", s.requireAuth(s.handleUpdateOnboardingSteps))
 	// Reset endpoint - requires auth in production, but allows unauthenticated access in dev mode
 	mux.HandleFunc("POST /api/onboarding/reset", s.requireAuthUnlessDevMode(s.handleResetOnboarding))
 }
 
 func (s *Server) Start() error {
-	fmt.Printf("Starting HTTP server on http://localhost:%d\n", s.port)
+	slog.Info("Starting HTTP server", "addr", fmt.Sprintf("http://localhost:%d", s.port))
 	return s.httpSrv.ListenAndServe()
 }
 
@@ -330,7 +625,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID, If-None-Match, "+headerRequestID+", "+headerErrorFormat)
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, "+headerTotalCount+", "+headerNextCursor+", "+headerRequestID)
 
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
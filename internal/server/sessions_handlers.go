@@ -0,0 +1,92 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/auth"
+)
+
+// requireSessionForSessions rejects API keys, which aren't sessions and must not be able
+// to sign a user's devices out
+func (s *Server) requireSessionForSessions(w http.ResponseWriter, r *http.Request) bool {
+	if s.authService == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "authentication not configured")
+		return false
+	}
+	if auth.GetAPIKeyScope(r.Context()) != "" {
+		respondErrorCode(w, http.StatusForbidden, CodeAPIKeyNotAllowed, "api keys cannot manage sessions")
+		return false
+	}
+	return true
+}
+
+// handleListSessions returns the user's active sessions with device, IP address, and
+// created and last-seen times; the caller's own session has "current": true
+// GET /api/auth/sessions
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if !s.requireSessionForSessions(w, r) {
+		return
+	}
+
+	sessions, err := s.authService.ListSessions(userID, auth.GetSessionID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{"sessions": sessions})
+}
+
+// handleRevokeSession signs a session out, e.g. on a lost phone. Revoking the current
+// session is the same as logging out.
+// DELETE /api/auth/sessions/{id}
+func (s *Server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if !s.requireSessionForSessions(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	if err := s.authService.RevokeSession(userID, id); errors.Is(err, auth.ErrSessionNotFound) {
+		respondError(w, http.StatusNotFound, "session not found")
+		return
+	} else if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// clientIP returns the address a request came from: the first X-Forwarded-For entry when
+// behind a proxy, otherwise the connection's remote address. It is shown to users to help
+// them recognize sessions, not used for access control.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		first, _, _ := strings.Cut(forwarded, ",")
+		if ip := strings.TrimSpace(first); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// handleListShadowAnalyses returns live/shadow comparisons across all users, newest
+// first. Optional filters: label, intent, disagreements=true, limit (1-500).
+func (s *Server) handleListShadowAnalyses(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.ShadowAnalysisFilter{
+		Label:            query.Get("label"),
+		Intent:           query.Get("intent"),
+		DisagreementOnly: query.Get("disagreements") == "true",
+		Limit:            100,
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > 500 {
+			respondInvalidParameter(w, "limit", 1, 500, "limit must be between 1 and 500")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	analyses, err := s.db.ListShadowAnalyses(filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if analyses == nil {
+		analyses = []database.ShadowAnalysis{}
+	}
+	respondJSON(w, http.StatusOK, analyses)
+}
+
+// handleShadowAnalysisSummary returns how often each shadow configuration agreed with
+// the live one, per intent
+func (s *Server) handleShadowAnalysisSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := s.db.SummarizeShadowAnalyses()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// shareableSourceTypes are the channel sources whose detected events can be shared.
+// Manual task lists and imported Google Calendar events are personal.
+var shareableSourceTypes = map[source.SourceType]bool{
+	source.SourceTypeWhatsApp: true,
+	source.SourceTypeTelegram: true,
+	source.SourceTypeGmail:    true,
+}
+
+// getOwnedChannel loads the channel in the {id} path value, writing an error response and
+// returning nil unless it belongs to userID
+func (s *Server) getOwnedChannel(w http.ResponseWriter, r *http.Request, userID int64) *database.SourceChannel {
+	channelID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid channel id")
+		return nil
+	}
+
+	channel, err := s.db.GetSourceChannelByID(userID, channelID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	if channel == nil {
+		respondErrorCode(w, http.StatusNotFound, CodeChannelNotFound, "channel not found")
+		return nil
+	}
+	return channel
+}
+
+// getShareForUser loads the share in the {id} path value, writing an error response and
+// returning nil unless userID is its owner, member, or (while pending) its invitee
+func (s *Server) getShareForUser(w http.ResponseWriter, r *http.Request, userID int64) (*database.ChannelShare, string) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return nil, ""
+	}
+
+	email, err := s.db.GetUserEmail(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return nil, ""
+	}
+
+	share, err := s.db.GetChannelShareByID(id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "share not found")
+		return nil, ""
+	}
+
+	isOwner := share.OwnerUserID == userID
+	isMember := share.MemberUserID != nil && *share.MemberUserID == userID
+	isInvitee := share.Status == database.ChannelSharePending && strings.EqualFold(share.InviteeEmail, email)
+	if !isOwner && !isMember && !isInvitee {
+		respondError(w, http.StatusNotFound, "share not found")
+		return nil, ""
+	}
+	return share, email
+}
+
+// handleListChannelShares returns every invitation and member of a channel the user owns
+func (s *Server) handleListChannelShares(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	channel := s.getOwnedChannel(w, r, userID)
+	if channel == nil {
+		return
+	}
+
+	shares, err := s.db.ListChannelShares(channel.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, shares)
+}
+
+// handleCreateChannelShare invites another user, by email, to a channel the user owns
+// Body: { "email": "partner@example.com" }
+func (s *Server) handleCreateChannelShare(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	channel := s.getOwnedChannel(w, r, userID)
+	if channel == nil {
+		return
+	}
+	if !shareableSourceTypes[channel.SourceType] {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("%s channels cannot be shared", channel.SourceType))
+		return
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil || addr.Address != strings.TrimSpace(req.Email) {
+		respondError(w, http.StatusBadRequest, "a valid email is required")
+		return
+	}
+
+	ownerEmail, err := s.db.GetUserEmail(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if strings.EqualFold(ownerEmail, addr.Address) {
+		respondError(w, http.StatusBadRequest, "cannot share a channel with yourself")
+		return
+	}
+
+	share, err := s.db.CreateChannelShare(userID, channel.ID, addr.Address)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, share)
+}
+
+// handleListShareInvitations returns pending invitations addressed to the user's email
+func (s *Server) handleListShareInvitations(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	email, err := s.db.GetUserEmail(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	invitations, err := s.db.ListChannelShareInvitations(email)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, invitations)
+}
+
+// handleListSharedChannels returns the channels other users have shared with the user
+func (s *Server) handleListSharedChannels(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	shares, err := s.db.ListSharedChannels(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, shares)
+}
+
+func (s *Server) handleAcceptChannelShare(w http.ResponseWriter, r *http.Request) {
+	s.respondToChannelShare(w, r, true)
+}
+
+func (s *Server) handleDeclineChannelShare(w http.ResponseWriter, r *http.Request) {
+	s.respondToChannelShare(w, r, false)
+}
+
+// respondToChannelShare accepts or declines an invitation addressed to the user
+func (s *Server) respondToChannelShare(w http.ResponseWriter, r *http.Request, accept bool) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	share, email := s.getShareForUser(w, r, userID)
+	if share == nil {
+		return
+	}
+	if !strings.EqualFold(share.InviteeEmail, email) {
+		respondError(w, http.StatusForbidden, "only the invitee can respond to an invitation")
+		return
+	}
+
+	responded, err := s.db.RespondToChannelShare(share.ID, userID, accept)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !responded {
+		respondError(w, http.StatusConflict, "invitation is no longer pending")
+		return
+	}
+
+	updated, err := s.db.GetChannelShareByID(share.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
+
+// handleDeleteChannelShare revokes a share (owner), leaves a shared channel (member),
+// or withdraws a pending invitation (either side)
+func (s *Server) handleDeleteChannelShare(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	share, _ := s.getShareForUser(w, r, userID)
+	if share == nil {
+		return
+	}
+
+	if err := s.db.DeleteChannelShare(share.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handleUpdateEventSharing shares an event with the members of its channel or makes it
+// private again. Only the channel owner can change sharing, and only on their own event.
+// Body: { "shared": true }
+func (s *Server) handleUpdateEventSharing(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return
+	}
+
+	event, err := s.db.GetEventByID(id)
+	if err != nil || event.UserID != userID {
+		respondErrorCode(w, http.StatusNotFound, CodeEventNotFound, "event not found")
+		return
+	}
+
+	channel, err := s.db.GetSourceChannelByID(userID, event.ChannelID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if channel == nil || event.SharedFromEventID != nil {
+		respondError(w, http.StatusForbidden, "only the channel owner can change sharing")
+		return
+	}
+
+	var req struct {
+		Shared *bool `json:"shared"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Shared == nil {
+		respondError(w, http.StatusBadRequest, "shared is required")
+		return
+	}
+
+	if *req.Shared {
+		if event.ActionType != database.EventActionCreate {
+			respondError(w, http.StatusBadRequest, "only new events can be shared")
+			return
+		}
+		copies, err := s.db.ShareEventWithMembers(event.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if s.notifyService != nil {
+			for _, copied := range copies {
+				go s.notifyService.NotifyPendingEvent(context.Background(), copied)
+			}
+		}
+	} else if _, err := s.db.UnshareEvent(event.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	updated, err := s.db.GetEventByID(event.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
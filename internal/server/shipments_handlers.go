@@ -0,0 +1,20 @@
+package server
+
+import "net/http"
+
+// handleListShipments returns the packages the delivery agent is tracking for the user,
+// most recently updated first
+func (s *Server) handleListShipments(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	shipments, err := s.db.ListShipments(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, shipments)
+}
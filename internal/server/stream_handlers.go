@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/sse"
+)
+
+// streamKeepAliveInterval keeps idle connections open through proxies
+const streamKeepAliveInterval = 25 * time.Second
+
+// streamReady is the first event on /api/stream so clients can resync badge counts
+type streamReady struct {
+	PendingEvents    int `json:"pending_events"`
+	PendingReminders int `json:"pending_reminders"`
+}
+
+// handleUserStream streams the authenticated user's live updates over SSE:
+// event_pending, reminder_pending, and sync_complete.
+func (s *Server) handleUserStream(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	var ready streamReady
+	if ready.PendingEvents, err = s.db.CountPendingEvents(userID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if ready.PendingReminders, err = s.db.CountPendingRemindersForUser(userID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The server-wide WriteTimeout would otherwise cut the stream off
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	updates, missed, resumed := s.streams.SubscribeFrom(userID, lastEventID(r))
+	defer s.streams.Unsubscribe(userID, updates)
+
+	if resumed {
+		for _, update := range missed {
+			writeSSEUpdate(w, update)
+		}
+	} else {
+		readyJSON, _ := json.Marshal(ready)
+		writeSSEUpdate(w, sse.Update{ID: s.streams.LastEventID(userID), Type: "ready", Data: string(readyJSON)})
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			writeSSEUpdate(w, update)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// lastEventID returns the ID a reconnecting SSE client last saw, from the
+// Last-Event-ID header or, for clients that cannot set headers, ?last_event_id=.
+func lastEventID(r *http.Request) int64 {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// writeSSEUpdate writes an update as an SSE message, including its id when set
+func writeSSEUpdate(w http.ResponseWriter, update sse.Update) {
+	if update.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", update.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Type, update.Data)
+}
@@ -1,19 +1,27 @@
 package server
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/omriShneor/project_alfred/internal/clients"
 	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/omriShneor/project_alfred/internal/telegram"
 )
 
 // TelegramStatusResponse represents the Telegram connection status
 type TelegramStatusResponse struct {
-	Connected bool   `json:"connected"`
-	Message   string `json:"message,omitempty"`
+	Connected        bool   `json:"connected"`
+	PasswordRequired bool   `json:"password_required,omitempty"` // send the 2FA password to /api/telegram/password
+	Message          string `json:"message,omitempty"`
+	// Connection is the client manager's view of the client (reconnect backoff, eviction)
+	Connection *clients.ConnectionState `json:"connection,omitempty"`
 }
 
 // handleTelegramStatus returns the current Telegram connection status
@@ -31,11 +39,14 @@ func (s *Server) handleTelegramStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	connection := s.clientManager.TelegramConnectionState(userID)
+
 	// Read-only status path: do not create clients.
 	if tgClient, ok := s.clientManager.PeekTelegramClient(userID); ok {
 		respondJSON(w, http.StatusOK, TelegramStatusResponse{
-			Connected: tgClient.IsConnected(),
-			Message:   "",
+			Connected:  tgClient.IsConnected(),
+			Message:    "",
+			Connection: &connection,
 		})
 		return
 	}
@@ -43,21 +54,23 @@ func (s *Server) handleTelegramStatus(w http.ResponseWriter, r *http.Request) {
 	// Fall back to stored session metadata when client is not in memory.
 	if tgSession, err := s.db.GetTelegramSession(userID); err == nil && tgSession != nil && tgSession.Connected {
 		respondJSON(w, http.StatusOK, TelegramStatusResponse{
-			Connected: true,
-			Message:   "",
+			Connected:  true,
+			Message:    "",
+			Connection: &connection,
 		})
 		return
 	}
 
 	respondJSON(w, http.StatusOK, TelegramStatusResponse{
-		Connected: false,
-		Message:   "Not connected",
+		Connected:  false,
+		Message:    "Not connected",
+		Connection: &connection,
 	})
 }
 
 // TelegramSendCodeRequest represents a request to send verification code
 type TelegramSendCodeRequest struct {
-	PhoneNumber string `json:"phone_number"`
+	PhoneNumber string `json:"phone_number" validate:"required"`
 }
 
 // handleTelegramSendCode sends a verification code to the given phone number
@@ -68,18 +81,12 @@ func (s *Server) handleTelegramSendCode(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if s.clientManager == nil {
-		respondError(w, http.StatusServiceUnavailable, "Client manager not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not configured")
 		return
 	}
 
 	var req TelegramSendCodeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
-		return
-	}
-
-	if req.PhoneNumber == "" {
-		respondError(w, http.StatusBadRequest, "Phone number is required")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -106,7 +113,7 @@ func (s *Server) handleTelegramSendCode(w http.ResponseWriter, r *http.Request)
 // TelegramVerifyCodeRequest represents a request to verify the code
 type TelegramVerifyCodeRequest struct {
 	PhoneNumber string `json:"phone_number"`
-	Code        string `json:"code"`
+	Code        string `json:"code" validate:"required"`
 }
 
 // handleTelegramVerifyCode verifies the code and completes authentication
@@ -117,18 +124,127 @@ func (s *Server) handleTelegramVerifyCode(w http.ResponseWriter, r *http.Request
 		return
 	}
 	if s.clientManager == nil {
-		respondError(w, http.StatusServiceUnavailable, "Client manager not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not configured")
 		return
 	}
 
 	var req TelegramVerifyCodeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	// Get per-user Telegram client
+	tgClient, err := s.clientManager.GetTelegramClient(userID)
+	if err != nil {
+		s.state.SetTelegramError(fmt.Sprintf("Failed to get Telegram client: %v", err))
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get Telegram client: %v", err))
+		return
+	}
+
+	if err := tgClient.VerifyCode(r.Context(), req.Code); errors.Is(err, telegram.ErrPasswordRequired) {
+		s.state.SetTelegramStatus("password_required")
+		respondJSON(w, http.StatusOK, TelegramStatusResponse{
+			Connected:        false,
+			PasswordRequired: true,
+			Message:          "Two-step verification is enabled - enter your Telegram password",
+		})
+		return
+	} else if err != nil {
+		s.state.SetTelegramError(err.Error())
+		respondError(w, http.StatusUnauthorized, fmt.Sprintf("Failed to verify code: %v", err))
+		return
+	}
+
+	s.state.SetTelegramStatus("connected")
+	respondJSON(w, http.StatusOK, TelegramStatusResponse{
+		Connected: true,
+		Message:   "Successfully authenticated",
+	})
+}
+
+// handleTelegramQRLogin starts signing in by QR code, for users who don't receive the SMS
+// code. QR codes are published on the onboarding SSE stream as "telegram_qr" updates (and
+// in /api/onboarding/status) until one is scanned, which sets telegram_status to
+// "connected", or "password_required" for accounts with two-step verification.
+func (s *Server) handleTelegramQRLogin(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if s.clientManager == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not configured")
+		return
+	}
+
+	// Get per-user Telegram client
+	tgClient, err := s.clientManager.GetTelegramClient(userID)
+	if err != nil {
+		s.state.SetTelegramError(fmt.Sprintf("Failed to get Telegram client: %v", err))
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get Telegram client: %v", err))
+		return
+	}
+	if err := tgClient.Connect(); err != nil {
+		s.state.SetTelegramError(err.Error())
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to connect: %v", err))
+		return
+	}
+	if tgClient.IsConnected() {
+		respondErrorCode(w, http.StatusConflict, CodeAlreadyConnected, "already authenticated - disconnect first to re-authenticate")
+		return
+	}
+
+	// Use a background context since the request ends before the code is scanned
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), telegram.QRLoginTimeout)
+		defer cancel()
+
+		err := tgClient.LoginWithQR(ctx, func(loginURL, qrDataURL string, expires time.Time) error {
+			s.state.SetTelegramQR(qrDataURL, loginURL)
+			return nil
+		})
+		switch {
+		case err == nil:
+			s.state.SetTelegramStatus("connected")
+		case errors.Is(err, telegram.ErrPasswordRequired):
+			s.state.SetTelegramStatus("password_required")
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			s.state.SetTelegramError("QR code expired. Start the QR login again to get a new code.")
+		case errors.Is(err, context.Canceled):
+			// Replaced by another login attempt, which reports its own status
+		default:
+			slog.Warn("Telegram QR login failed", "user_id", userID, "error", err)
+			s.state.SetTelegramError(err.Error())
+		}
+	}()
+
+	respondJSON(w, http.StatusAccepted, map[string]string{
+		"status":  "waiting",
+		"message": "QR login started, the code will be sent on the onboarding stream",
+	})
+}
+
+// TelegramPasswordRequest represents a request to finish a login with the account's
+// two-step verification (cloud) password
+type TelegramPasswordRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// handleTelegramVerifyPassword completes authentication for accounts with two-step
+// verification after verify-code answered password_required. A wrong password can be retried.
+func (s *Server) handleTelegramVerifyPassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if s.clientManager == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not configured")
 		return
 	}
 
-	if req.Code == "" {
-		respondError(w, http.StatusBadRequest, "Verification code is required")
+	var req TelegramPasswordRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -140,9 +256,13 @@ func (s *Server) handleTelegramVerifyCode(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := tgClient.VerifyCode(r.Context(), req.Code); err != nil {
+	if err := tgClient.VerifyPassword(r.Context(), req.Password); errors.Is(err, telegram.ErrPasswordInvalid) {
+		// The login is still waiting for the password, so the status stays password_required
+		respondError(w, http.StatusUnauthorized, err.Error())
+		return
+	} else if err != nil {
 		s.state.SetTelegramError(err.Error())
-		respondError(w, http.StatusUnauthorized, fmt.Sprintf("Failed to verify code: %v", err))
+		respondError(w, http.StatusUnauthorized, fmt.Sprintf("Failed to verify password: %v", err))
 		return
 	}
 
@@ -161,7 +281,7 @@ func (s *Server) handleTelegramDisconnect(w http.ResponseWriter, r *http.Request
 		return
 	}
 	if s.clientManager == nil {
-		respondError(w, http.StatusServiceUnavailable, "Client manager not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not configured")
 		return
 	}
 
@@ -186,7 +306,7 @@ func (s *Server) handleTelegramReconnect(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if s.clientManager == nil {
-		respondError(w, http.StatusServiceUnavailable, "Client manager not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not configured")
 		return
 	}
 
@@ -228,7 +348,7 @@ func (s *Server) handleDiscoverTelegramChannels(w http.ResponseWriter, r *http.R
 	}
 
 	if s.clientManager == nil {
-		respondError(w, http.StatusServiceUnavailable, "Client manager not configured")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not configured")
 		return
 	}
 
@@ -240,7 +360,7 @@ func (s *Server) handleDiscoverTelegramChannels(w http.ResponseWriter, r *http.R
 	}
 
 	if !tgClient.IsConnected() {
-		respondError(w, http.StatusServiceUnavailable, "Telegram not connected")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeTelegramNotReady, "Telegram not connected")
 		return
 	}
 
@@ -271,9 +391,10 @@ func (s *Server) handleListTelegramChannels(w http.ResponseWriter, r *http.Reque
 
 // TelegramCreateChannelRequest represents a request to create a Telegram channel
 type TelegramCreateChannelRequest struct {
-	Type       string `json:"type"` // "contact", "group", "channel"
-	Identifier string `json:"identifier"`
-	Name       string `json:"name"`
+	Type         string `json:"type" validate:"omitempty,oneof=contact sender"` // Contacts only; empty means contact
+	Identifier   string `json:"identifier" validate:"required"`
+	Name         string `json:"name" validate:"required"`
+	BackfillDays int    `json:"backfill_days"` // Initial backfill lookback (default 10)
 }
 
 // handleCreateTelegramChannel adds a Telegram channel to track
@@ -284,19 +405,12 @@ func (s *Server) handleCreateTelegramChannel(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	var req TelegramCreateChannelRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
-		return
-	}
-
-	if req.Identifier == "" || req.Name == "" {
-		respondError(w, http.StatusBadRequest, "Identifier and name are required")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	// Only contacts (sender type) are supported
-	if req.Type != "" && req.Type != "contact" && req.Type != "sender" {
-		respondError(w, http.StatusBadRequest, "Only contacts are supported (type must be 'contact' or 'sender')")
+	if !validBackfillDays(req.BackfillDays) {
+		respondInvalidParameter(w, "backfill_days", 1, maxBackfillDays, fmt.Sprintf("backfill_days must be between 1 and %d", maxBackfillDays))
 		return
 	}
 	channelType := source.ChannelTypeSender
@@ -311,7 +425,7 @@ func (s *Server) handleCreateTelegramChannel(w http.ResponseWriter, r *http.Requ
 		existingChannel.Name = req.Name
 		existingChannel.Enabled = true
 		if wasDisabled {
-			s.startChannelBackfill(userID, existingChannel)
+			s.startChannelBackfill(userID, existingChannel, req.BackfillDays)
 		}
 		respondJSON(w, http.StatusOK, existingChannel)
 		return
@@ -323,7 +437,7 @@ func (s *Server) handleCreateTelegramChannel(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	s.startChannelBackfill(userID, channel)
+	s.startChannelBackfill(userID, channel, req.BackfillDays)
 
 	respondJSON(w, http.StatusCreated, channel)
 }
@@ -344,19 +458,18 @@ func (s *Server) handleUpdateTelegramChannel(w http.ResponseWriter, r *http.Requ
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid channel ID")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "Invalid channel ID")
 		return
 	}
 
 	var req TelegramUpdateChannelRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	if err := s.db.UpdateSourceChannel(userID, id, req.Name, req.Enabled); err != nil {
 		if err.Error() == "channel not found" {
-			respondError(w, http.StatusNotFound, "channel not found")
+			respondErrorCode(w, http.StatusNotFound, CodeChannelNotFound, "channel not found")
 			return
 		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update channel: %v", err))
@@ -377,19 +490,19 @@ func (s *Server) handleDeleteTelegramChannel(w http.ResponseWriter, r *http.Requ
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid channel ID")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "Invalid channel ID")
 		return
 	}
 
 	channel, err := s.db.GetSourceChannelByID(userID, id)
 	if err != nil || channel == nil {
-		respondError(w, http.StatusNotFound, "channel not found")
+		respondErrorCode(w, http.StatusNotFound, CodeChannelNotFound, "channel not found")
 		return
 	}
 
 	if err := s.db.UpdateSourceChannel(userID, id, channel.Name, false); err != nil {
 		if err.Error() == "channel not found" {
-			respondError(w, http.StatusNotFound, "channel not found")
+			respondErrorCode(w, http.StatusNotFound, CodeChannelNotFound, "channel not found")
 			return
 		}
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to disable channel: %v", err))
@@ -558,7 +671,7 @@ func (s *Server) handleTelegramContactSearch(w http.ResponseWriter, r *http.Requ
 
 // TelegramCustomSourceRequest represents a request to add a custom Telegram source
 type TelegramCustomSourceRequest struct {
-	Username string `json:"username"`
+	Username string `json:"username" validate:"required"`
 }
 
 // handleTelegramCustomSource creates a Telegram channel from a username
@@ -570,29 +683,13 @@ func (s *Server) handleTelegramCustomSource(w http.ResponseWriter, r *http.Reque
 	}
 
 	var req TelegramCustomSourceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
-		return
-	}
-
-	if req.Username == "" {
-		respondError(w, http.StatusBadRequest, "username is required")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	// Normalize username - remove @ prefix if present
-	username := req.Username
-	if len(username) > 0 && username[0] == '@' {
-		username = username[1:]
-	}
-
-	// Basic validation: 5-32 characters, alphanumeric and underscore, starts with letter
-	if len(username) < 5 || len(username) > 32 {
-		respondError(w, http.StatusBadRequest, "Username must be 5-32 characters")
-		return
-	}
-	if username[0] < 'a' || (username[0] > 'z' && username[0] < 'A') || username[0] > 'Z' {
-		respondError(w, http.StatusBadRequest, "Username must start with a letter")
+	username, err := normalizeTelegramUsername(req.Username)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -602,7 +699,7 @@ func (s *Server) handleTelegramCustomSource(w http.ResponseWriter, r *http.Reque
 	}
 	tgClient, err := s.clientManager.GetTelegramClient(userID)
 	if err != nil || !tgClient.IsConnected() {
-		respondError(w, http.StatusBadRequest, "Telegram not connected")
+		respondErrorCode(w, http.StatusBadRequest, CodeTelegramNotReady, "Telegram not connected")
 		return
 	}
 
@@ -621,7 +718,7 @@ func (s *Server) handleTelegramCustomSource(w http.ResponseWriter, r *http.Reque
 	// Check if already tracked
 	existing, err := s.db.GetSourceChannelByIdentifier(userID, source.SourceTypeTelegram, identifier)
 	if err == nil && existing != nil {
-		respondError(w, http.StatusConflict, "This username is already being tracked")
+		respondErrorCode(w, http.StatusConflict, CodeSourceExists, "This username is already being tracked")
 		return
 	}
 
@@ -632,7 +729,21 @@ func (s *Server) handleTelegramCustomSource(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	s.startChannelBackfill(userID, channel)
+	s.startChannelBackfill(userID, channel, 0)
 
 	respondJSON(w, http.StatusCreated, channel)
 }
+
+// normalizeTelegramUsername removes the @ prefix from a username and checks its shape
+func normalizeTelegramUsername(input string) (string, error) {
+	username := strings.TrimPrefix(strings.TrimSpace(input), "@")
+
+	// Basic validation: 5-32 characters, alphanumeric and underscore, starts with letter
+	if len(username) < 5 || len(username) > 32 {
+		return "", errors.New("Username must be 5-32 characters")
+	}
+	if username[0] < 'a' || (username[0] > 'z' && username[0] < 'A') || username[0] > 'Z' {
+		return "", errors.New("Username must start with a letter")
+	}
+	return username, nil
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/usage"
+)
+
+// maxLLMMonthlyBudget caps the monthly budget users can set, in USD
+const maxLLMMonthlyBudget = 10000
+
+// llmBudgetResponse reports the user's monthly budget and this month's spend
+func (s *Server) llmBudgetResponse(userID int64, now time.Time) (map[string]any, error) {
+	override, err := s.db.GetLLMMonthlyBudget(userID)
+	if err != nil {
+		return nil, err
+	}
+	budget := s.llmMonthlyBudget
+	if override != nil {
+		budget = *override
+	}
+	spent, err := s.db.GetLLMSpend(userID, usage.MonthStart(now))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := map[string]any{
+		"monthly_budget_usd": budget,
+		"is_default":         override == nil,
+		"default_budget_usd": s.llmMonthlyBudget,
+		"spent_usd":          spent,
+		"paused":             budget > 0 && spent >= budget,
+	}
+	if budget > 0 {
+		resp["remaining_usd"] = max(budget-spent, 0)
+	}
+	return resp, nil
+}
+
+// handleGetUsage returns the user's LLM token usage and estimated cost for a month
+// (?month=YYYY-MM, default current), in total, per model and per day, with their budget.
+func (s *Server) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	now := time.Now()
+	since := usage.MonthStart(now)
+	if month := r.URL.Query().Get("month"); month != "" {
+		parsed, err := time.Parse("2006-01", month)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "month must be in YYYY-MM format")
+			return
+		}
+		since = parsed
+	}
+	until := since.AddDate(0, 1, 0)
+
+	summary, err := s.db.GetLLMUsageSummary(userID, since, until)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	budget, err := s.llmBudgetResponse(userID, now)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"month":  since.Format("2006-01"),
+		"usage":  summary,
+		"budget": budget,
+	})
+}
+
+// handleUpdateUsageBudget sets the user's monthly LLM budget in USD. 0 means unlimited
+// and null resets it to the server default. Analysis pauses once the budget is spent.
+func (s *Server) handleUpdateUsageBudget(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		MonthlyBudgetUSD *float64 `json:"monthly_budget_usd"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+	if b := req.MonthlyBudgetUSD; b != nil && (*b < 0 || *b > maxLLMMonthlyBudget) {
+		respondInvalidParameter(w, "monthly_budget_usd", 0, maxLLMMonthlyBudget, fmt.Sprintf("monthly_budget_usd must be between 0 and %d", maxLLMMonthlyBudget))
+		return
+	}
+
+	if err := s.db.SetLLMMonthlyBudget(userID, req.MonthlyBudgetUSD); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	budget, err := s.llmBudgetResponse(userID, time.Now())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, budget)
+}
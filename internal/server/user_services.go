@@ -1,18 +1,24 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/intents"
 	"github.com/omriShneor/project_alfred/internal/auth"
 	"github.com/omriShneor/project_alfred/internal/clients"
 	"github.com/omriShneor/project_alfred/internal/config"
 	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/embeddings"
 	"github.com/omriShneor/project_alfred/internal/gcal"
 	"github.com/omriShneor/project_alfred/internal/gmail"
 	"github.com/omriShneor/project_alfred/internal/notify"
 	"github.com/omriShneor/project_alfred/internal/processor"
+	"github.com/omriShneor/project_alfred/internal/sse"
 )
 
 // UserServices holds the active services for a single user
@@ -31,6 +37,14 @@ type UserServiceManager struct {
 	notifyService    *notify.Service
 	eventAnalyzer    agent.EventAnalyzer
 	reminderAnalyzer agent.ReminderAnalyzer
+	travelAnalyzer   agent.TravelAnalyzer
+	billAnalyzer     agent.BillAnalyzer
+	deliveryAnalyzer agent.DeliveryAnalyzer
+	occasionAnalyzer agent.OccasionAnalyzer
+	relatedMessages  *embeddings.Retriever
+	shadow           *processor.ShadowRunner
+	streams          *sse.StateManager       // Per-user /api/stream bus (optional)
+	autoConfirmer    processor.AutoConfirmer // Set by Server.SetUserServiceManager
 
 	// ClientManager for per-user WhatsApp/Telegram clients
 	clientManager *clients.ClientManager
@@ -51,7 +65,14 @@ type UserServiceManagerConfig struct {
 	NotifyService    *notify.Service
 	EventAnalyzer    agent.EventAnalyzer
 	ReminderAnalyzer agent.ReminderAnalyzer
+	TravelAnalyzer   agent.TravelAnalyzer
+	BillAnalyzer     agent.BillAnalyzer
+	DeliveryAnalyzer agent.DeliveryAnalyzer
+	OccasionAnalyzer agent.OccasionAnalyzer
+	RelatedMessages  *embeddings.Retriever   // Optional: related-message retrieval for chat analysis
+	Shadow           *processor.ShadowRunner // Optional: shadow analyzers compared against the live ones
 	ClientManager    *clients.ClientManager
+	Streams          *sse.StateManager
 }
 
 // NewUserServiceManager creates a new UserServiceManager
@@ -63,7 +84,14 @@ func NewUserServiceManager(cfg UserServiceManagerConfig) *UserServiceManager {
 		notifyService:    cfg.NotifyService,
 		eventAnalyzer:    cfg.EventAnalyzer,
 		reminderAnalyzer: cfg.ReminderAnalyzer,
+		travelAnalyzer:   cfg.TravelAnalyzer,
+		billAnalyzer:     cfg.BillAnalyzer,
+		deliveryAnalyzer: cfg.DeliveryAnalyzer,
+		occasionAnalyzer: cfg.OccasionAnalyzer,
+		relatedMessages:  cfg.RelatedMessages,
+		shadow:           cfg.Shadow,
 		clientManager:    cfg.ClientManager,
+		streams:          cfg.Streams,
 		userServices:     make(map[int64]*UserServices),
 	}
 }
@@ -82,7 +110,7 @@ func (m *UserServiceManager) StartGlobalProcessor() error {
 		return nil
 	}
 
-	if m.eventAnalyzer == nil && m.reminderAnalyzer == nil {
+	if m.eventAnalyzer == nil && m.reminderAnalyzer == nil && m.travelAnalyzer == nil && m.billAnalyzer == nil && m.occasionAnalyzer == nil {
 		return nil
 	}
 
@@ -99,12 +127,41 @@ func (m *UserServiceManager) StartGlobalProcessor() error {
 		historySize,
 		m.notifyService,
 	)
+	registerTravelIntent(m.travelAnalyzer, m.db, proc.RegisterIntentModule)
+	registerBillIntent(m.billAnalyzer, m.db, proc.RegisterIntentModule)
+	registerOccasionIntent(m.occasionAnalyzer, m.db, proc.RegisterIntentModule)
+	if m.relatedMessages != nil {
+		proc.SetRelatedMessageFinder(m.relatedMessages)
+	}
+	proc.SetShadow(m.shadow)
+	proc.SetAutoConfirmer(m.autoConfirmer)
+	if m.cfg != nil {
+		prefilter, err := processor.NewPrefilter(processor.PrefilterConfig{
+			Enabled:     m.cfg.PrefilterEnabled,
+			MinChars:    m.cfg.PrefilterMinChars,
+			SkipPhrases: m.cfg.PrefilterSkipPhrases,
+			SkipPattern: m.cfg.PrefilterSkipPattern,
+			KeepPattern: m.cfg.PrefilterKeepPattern,
+		})
+		if err != nil {
+			slog.Warn("Using default prefilter rules", "error", err)
+		} else {
+			proc.SetPrefilter(prefilter)
+		}
+		proc.SetBatchWindow(
+			time.Duration(m.cfg.BatchWindowSeconds)*time.Second,
+			time.Duration(m.cfg.BatchMaxWaitSeconds)*time.Second,
+			m.cfg.BatchMaxMessages,
+		)
+		proc.SetWorkerCount(m.cfg.ProcessorWorkers)
+		proc.SetLLMAvailability(func() bool { return agent.ProviderAvailable(m.cfg.LLMProvider) })
+	}
 	if err := proc.Start(); err != nil {
 		return err
 	}
 
 	m.globalProcessor = proc
-	fmt.Println("Global processor started")
+	slog.Info("Global processor started")
 	return nil
 }
 
@@ -137,10 +194,10 @@ func (m *UserServiceManager) StartServicesForUser(userID int64) error {
 		if existing.GCalWorker == nil {
 			gcalWorker, err := m.createGCalWorker(userID)
 			if err != nil {
-				fmt.Printf("  - Google Calendar worker failed to start: %v\n", err)
+				slog.Error("Google Calendar worker failed to start", "error", err)
 			} else if gcalWorker != nil {
 				existing.GCalWorker = gcalWorker
-				fmt.Printf("  - Google Calendar worker started\n")
+				slog.Info("Google Calendar worker started")
 			}
 		}
 
@@ -148,17 +205,17 @@ func (m *UserServiceManager) StartServicesForUser(userID int64) error {
 		if existing.GmailWorker == nil {
 			gmailWorker, err := m.createGmailWorker(userID)
 			if err != nil {
-				fmt.Printf("  - Gmail worker failed to start: %v\n", err)
+				slog.Error("Gmail worker failed to start", "error", err)
 			} else if gmailWorker != nil {
 				existing.GmailWorker = gmailWorker
-				fmt.Printf("  - Gmail worker started\n")
+				slog.Info("Gmail worker started")
 			}
 		}
-		fmt.Printf("Services already running for user %d\n", userID)
+		slog.Info("Services already running", "user_id", userID)
 		return nil
 	}
 
-	fmt.Printf("Starting services for user %d\n", userID)
+	slog.Info("Starting services", "user_id", userID)
 
 	services := &UserServices{
 		UserID: userID,
@@ -171,25 +228,25 @@ func (m *UserServiceManager) StartServicesForUser(userID int64) error {
 	// Start Google Calendar sync worker if user has authenticated Calendar scope
 	gcalWorker, err := m.createGCalWorker(userID)
 	if err != nil {
-		fmt.Printf("  - Google Calendar worker failed to start: %v\n", err)
+		slog.Error("Google Calendar worker failed to start", "error", err)
 	} else if gcalWorker != nil {
 		services.GCalWorker = gcalWorker
-		fmt.Printf("  - Google Calendar worker started\n")
+		slog.Info("Google Calendar worker started")
 	}
 
 	// Start Gmail worker if user has authenticated Google Calendar (for Gmail access)
 	gmailWorker, err := m.createGmailWorker(userID)
 	if err != nil {
-		fmt.Printf("  - Gmail worker failed to start: %v\n", err)
+		slog.Error("Gmail worker failed to start", "error", err)
 	} else if gmailWorker != nil {
 		services.GmailWorker = gmailWorker
-		fmt.Printf("  - Gmail worker started\n")
+		slog.Info("Gmail worker started")
 	}
 
 	services.running = true
 	m.userServices[userID] = services
 
-	fmt.Printf("Services started for user %d\n", userID)
+	slog.Info("Services started", "user_id", userID)
 	return nil
 }
 
@@ -203,7 +260,7 @@ func (m *UserServiceManager) StopServicesForUser(userID int64) {
 		return
 	}
 
-	fmt.Printf("Stopping services for user %d\n", userID)
+	slog.Info("Stopping services", "user_id", userID)
 
 	if services.GCalWorker != nil {
 		services.GCalWorker.Stop()
@@ -216,9 +273,9 @@ func (m *UserServiceManager) StopServicesForUser(userID int64) {
 	// Cleanup WhatsApp/Telegram clients for this user
 	if m.clientManager != nil {
 		if err := m.clientManager.CleanupUser(userID); err != nil {
-			fmt.Printf("  - Warning: Failed to cleanup clients for user %d: %v\n", userID, err)
+			slog.Warn("Failed to cleanup clients", "user_id", userID, "error", err)
 		} else {
-			fmt.Printf("  - Clients cleaned up for user %d\n", userID)
+			slog.Info("Clients cleaned up", "user_id", userID)
 		}
 	}
 
@@ -251,6 +308,23 @@ func (m *UserServiceManager) IsRunningForUser(userID int64) bool {
 	return ok && services.running
 }
 
+// GmailWorkerSummary counts running Gmail workers and how many have an authenticated client
+func (m *UserServiceManager) GmailWorkerSummary() (workers, authenticated int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, services := range m.userServices {
+		if !services.running || services.GmailWorker == nil {
+			continue
+		}
+		workers++
+		if services.GmailWorker.IsAuthenticated() {
+			authenticated++
+		}
+	}
+	return workers, authenticated
+}
+
 // GetGmailWorkerForUser retrieves the Gmail worker for a specific user
 func (m *UserServiceManager) GetGmailWorkerForUser(userID int64) *gmail.Worker {
 	m.mu.RLock()
@@ -345,7 +419,7 @@ func (m *UserServiceManager) StartServicesForEligibleUsers() {
 
 	for userID := range userIDs {
 		if err := m.StartServicesForUser(userID); err != nil {
-			fmt.Printf("Warning: failed to start services for user %d: %v\n", userID, err)
+			slog.Warn("failed to start services", "user_id", userID, "error", err)
 		}
 	}
 }
@@ -398,6 +472,12 @@ func (m *UserServiceManager) createGmailWorker(userID int64) (*gmail.Worker, err
 	}
 
 	emailProc := processor.NewEmailProcessor(m.db, m.eventAnalyzer, m.reminderAnalyzer, m.notifyService)
+	registerTravelIntent(m.travelAnalyzer, m.db, emailProc.RegisterIntentModule)
+	registerBillIntent(m.billAnalyzer, m.db, emailProc.RegisterIntentModule)
+	registerDeliveryIntent(m.deliveryAnalyzer, m.db, emailProc.RegisterIntentModule)
+	registerOccasionIntent(m.occasionAnalyzer, m.db, emailProc.RegisterIntentModule)
+	emailProc.SetShadow(m.shadow)
+	emailProc.SetAutoConfirmer(m.autoConfirmer)
 
 	pollInterval := 1 // Default 1 minute
 	maxEmails := 10   // Default 10
@@ -414,6 +494,7 @@ func (m *UserServiceManager) createGmailWorker(userID int64) (*gmail.Worker, err
 		UserID:              userID,
 		PollIntervalMinutes: pollInterval,
 		MaxEmailsPerPoll:    maxEmails,
+		OnSyncComplete:      m.syncCompleteHook("gmail"),
 	})
 
 	if err := worker.Start(); err != nil {
@@ -452,6 +533,8 @@ func (m *UserServiceManager) createGCalWorker(userID int64) (*gcal.Worker, error
 	worker := gcal.NewWorker(userGCalClient, m.db, gcal.WorkerConfig{
 		UserID:              userID,
 		PollIntervalMinutes: pollInterval,
+		OnSyncComplete:      m.syncCompleteHook("gcal"),
+		OnEventChanged:      m.eventChangedHook(),
 	})
 
 	if err := worker.Start(); err != nil {
@@ -463,3 +546,77 @@ func (m *UserServiceManager) createGCalWorker(userID int64) (*gcal.Worker, error
 
 	return worker, nil
 }
+
+// eventChangedHook returns a Google Calendar worker callback that tells the user about
+// events changed on the Google side, or nil when notifications are not configured
+func (m *UserServiceManager) eventChangedHook() func(event *database.CalendarEvent, deleted bool) {
+	if m.notifyService == nil {
+		return nil
+	}
+	return func(event *database.CalendarEvent, deleted bool) {
+		m.notifyService.NotifyEventChangedInGoogle(context.Background(), event, deleted)
+	}
+}
+
+// syncCompleteHook returns a worker callback that publishes sync_complete to the
+// user's stream, or nil when streaming is not configured.
+func (m *UserServiceManager) syncCompleteHook(source string) func(userID int64) {
+	if m.streams == nil {
+		return nil
+	}
+	return func(userID int64) {
+		payload := map[string]any{"source": source, "completed_at": time.Now()}
+		if err := m.streams.Publish(userID, sse.UpdateSyncComplete, payload); err != nil {
+			slog.Warn("failed to publish sync_complete", "user_id", userID, "error", err)
+		}
+	}
+}
+
+// registerTravelIntent adds the travel itinerary module to a processor when the travel agent is configured.
+// The module skips users whose travel_detection feature flag is off.
+func registerTravelIntent(analyzer agent.TravelAnalyzer, db *database.DB, register func(intents.IntentModule) error) {
+	if analyzer == nil || !analyzer.IsConfigured() {
+		return
+	}
+	if err := register(&intents.TravelModule{Analyzer: analyzer, Enabled: featureCheck(db, database.FeatureTravelDetection)}); err != nil {
+		slog.Warn("failed to register travel analyzer", "error", err)
+	}
+}
+
+// registerBillIntent adds the bill detection module to a processor when the bill agent is configured.
+// The module only calls the agent for users whose bill_detection feature flag is on.
+func registerBillIntent(analyzer agent.BillAnalyzer, db *database.DB, register func(intents.IntentModule) error) {
+	if analyzer == nil || !analyzer.IsConfigured() {
+		return
+	}
+	if err := register(&intents.BillModule{Analyzer: analyzer, Enabled: db.IsBillDetectionEnabled}); err != nil {
+		slog.Warn("failed to register bill analyzer", "error", err)
+	}
+}
+
+// featureCheck returns a per-user switch for an intent module backed by a feature flag
+func featureCheck(db *database.DB, name string) func(userID int64) (bool, error) {
+	return func(userID int64) (bool, error) {
+		return db.IsFeatureEnabled(userID, name)
+	}
+}
+
+// registerDeliveryIntent adds the package tracking module to an email processor when the delivery agent is configured
+func registerDeliveryIntent(analyzer agent.DeliveryAnalyzer, db *database.DB, register func(intents.IntentModule) error) {
+	if analyzer == nil || !analyzer.IsConfigured() {
+		return
+	}
+	if err := register(&intents.DeliveryModule{Analyzer: analyzer, Shipments: db}); err != nil {
+		slog.Warn("failed to register delivery analyzer", "error", err)
+	}
+}
+
+// registerOccasionIntent adds the birthday/anniversary module to a processor when the occasion agent is configured
+func registerOccasionIntent(analyzer agent.OccasionAnalyzer, db *database.DB, register func(intents.IntentModule) error) {
+	if analyzer == nil || !analyzer.IsConfigured() {
+		return
+	}
+	if err := register(&intents.OccasionModule{Analyzer: analyzer, Occasions: db}); err != nil {
+		slog.Warn("failed to register occasion analyzer", "error", err)
+	}
+}
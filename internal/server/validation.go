@@ -0,0 +1,30 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/omriShneor/project_alfred/internal/validate"
+)
+
+// decodeRequest decodes a JSON body into dst and checks its validate tags, responding
+// 400 and returning false when either fails
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+		return false
+	}
+	if errs := validate.Struct(dst); errs != nil {
+		respondValidationErrors(w, errs)
+		return false
+	}
+	return true
+}
+
+// respondValidationErrors responds 400 with every failed field. The message is the first
+// field's, which reads well on its own for clients that only show the message.
+func respondValidationErrors(w http.ResponseWriter, errs validate.Errors) {
+	respondErrorDetails(w, http.StatusBadRequest, CodeValidationFailed, errs[0].Message, map[string]any{
+		"fields": errs,
+	})
+}
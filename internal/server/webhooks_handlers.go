@@ -0,0 +1,218 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/webhook"
+)
+
+// webhookCreatedResponse includes the signing secret, which is only returned on creation
+type webhookCreatedResponse struct {
+	*database.Webhook
+	Secret string `json:"secret"`
+}
+
+// validateWebhookEventTypes requires at least one supported event type and deduplicates
+func validateWebhookEventTypes(eventTypes []string) ([]string, error) {
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("event_types must include at least one of: %s", strings.Join(webhook.EventTypes, ", "))
+	}
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !webhook.IsValidEventType(eventType) {
+			return nil, fmt.Errorf("unsupported event type %q (supported: %s)", eventType, strings.Join(webhook.EventTypes, ", "))
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			result = append(result, eventType)
+		}
+	}
+	return result, nil
+}
+
+// getWebhookForUser loads the webhook in the {id} path value, writing an error response
+// and returning nil when it is missing or belongs to another user
+func (s *Server) getWebhookForUser(w http.ResponseWriter, r *http.Request, userID int64) *database.Webhook {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
+		return nil
+	}
+
+	hook, err := s.db.GetWebhookByID(id)
+	if err != nil || hook.UserID != userID {
+		respondError(w, http.StatusNotFound, "webhook not found")
+		return nil
+	}
+	return hook
+}
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	hooks, err := s.db.ListWebhooks(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, hooks)
+}
+
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+		Secret     string   `json:"secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+
+	hookURL, err := webhook.ValidateURL(r.Context(), req.URL)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	eventTypes, err := validateWebhookEventTypes(req.EventTypes)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = webhook.GenerateSecret(); err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	hook, err := s.db.CreateWebhook(userID, hookURL, secret, eventTypes)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, webhookCreatedResponse{Webhook: hook, Secret: hook.Secret})
+}
+
+func (s *Server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	hook := s.getWebhookForUser(w, r, userID)
+	if hook == nil {
+		return
+	}
+
+	var req struct {
+		URL        *string  `json:"url"`
+		EventTypes []string `json:"event_types"`
+		Enabled    *bool    `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid request body")
+		return
+	}
+
+	hookURL := hook.URL
+	if req.URL != nil {
+		if hookURL, err = webhook.ValidateURL(r.Context(), *req.URL); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	eventTypes := hook.EventTypes
+	if req.EventTypes != nil {
+		if eventTypes, err = validateWebhookEventTypes(req.EventTypes); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	enabled := hook.Enabled
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	if err := s.db.UpdateWebhook(hook.ID, hookURL, eventTypes, enabled); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	updated, err := s.db.GetWebhookByID(hook.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	hook := s.getWebhookForUser(w, r, userID)
+	if hook == nil {
+		return
+	}
+
+	if err := s.db.DeleteWebhook(hook.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handleListWebhookDeliveries returns recent deliveries for a webhook with their attempt log.
+// Query: limit (default 50, max 200).
+func (s *Server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	hook := s.getWebhookForUser(w, r, userID)
+	if hook == nil {
+		return
+	}
+
+	limit := 50
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > 200 {
+			respondInvalidParameter(w, "limit", 1, 200, "limit must be between 1 and 200")
+			return
+		}
+	}
+
+	deliveries, err := s.db.ListWebhookDeliveries(hook.ID, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, deliveries)
+}
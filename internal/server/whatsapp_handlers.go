@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -95,7 +97,7 @@ func (s *Server) handleWhatsAppTopContacts(w http.ResponseWriter, r *http.Reques
 	// If no stats available yet, wait briefly for HistorySync to complete before falling back
 	if s.clientManager != nil {
 		waClient, err := s.clientManager.GetWhatsAppClient(userID)
-		if err == nil && waClient.WAClient != nil && waClient.IsLoggedIn() {
+		if err == nil && waClient.IsLoggedIn() {
 			timeout := time.After(6 * time.Second)
 			ticker := time.NewTicker(500 * time.Millisecond)
 			defer ticker.Stop()
@@ -156,14 +158,14 @@ func (s *Server) handleWhatsAppContactSearch(w http.ResponseWriter, r *http.Requ
 	}
 
 	waClient, err := s.clientManager.GetWhatsAppClient(userID)
-	if err != nil || waClient == nil || waClient.WAClient == nil || waClient.WAClient.Store == nil || waClient.WAClient.Store.Contacts == nil {
+	if err != nil {
 		respondJSON(w, http.StatusOK, map[string]interface{}{
 			"contacts": []TopContactResponse{},
 		})
 		return
 	}
 
-	allContacts, err := waClient.WAClient.Store.Contacts.GetAllContacts(r.Context())
+	allContacts, err := waClient.GetAllContacts(r.Context())
 	if err != nil {
 		respondJSON(w, http.StatusOK, map[string]interface{}{
 			"contacts": []TopContactResponse{},
@@ -244,37 +246,31 @@ func (s *Server) handleWhatsAppCustomSource(w http.ResponseWriter, r *http.Reque
 	}
 
 	var req struct {
-		Name        string `json:"name"`
+		Name        string `json:"name" validate:"required_without=PhoneNumber"`
 		PhoneNumber string `json:"phone_number"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	nameInput := strings.TrimSpace(req.Name)
 	phoneInput := strings.TrimSpace(req.PhoneNumber)
 
-	if nameInput == "" && phoneInput == "" {
-		respondError(w, http.StatusBadRequest, "name or phone_number is required")
-		return
-	}
-
 	// Name-based contact resolution (preferred)
 	if nameInput != "" {
 		if s.clientManager == nil {
-			respondError(w, http.StatusBadRequest, "WhatsApp contacts not available")
+			respondErrorCode(w, http.StatusBadRequest, CodeWhatsAppNotReady, "WhatsApp contacts not available")
 			return
 		}
 
 		waClient, err := s.clientManager.GetWhatsAppClient(userID)
-		if err != nil || waClient == nil || waClient.WAClient == nil || waClient.WAClient.Store == nil || waClient.WAClient.Store.Contacts == nil {
-			respondError(w, http.StatusBadRequest, "WhatsApp contacts not available")
+		if err != nil {
+			respondErrorCode(w, http.StatusBadRequest, CodeWhatsAppNotReady, "WhatsApp contacts not available")
 			return
 		}
 
-		allContacts, err := waClient.WAClient.Store.Contacts.GetAllContacts(r.Context())
+		allContacts, err := waClient.GetAllContacts(r.Context())
 		if err != nil {
 			respondError(w, http.StatusBadRequest, "Failed to load WhatsApp contacts")
 			return
@@ -334,7 +330,7 @@ func (s *Server) handleWhatsAppCustomSource(w http.ResponseWriter, r *http.Reque
 			existing, err = s.db.GetSourceChannelByIdentifier(userID, source.SourceTypeWhatsApp, legacyIdentifier)
 		}
 		if err == nil && existing != nil {
-			respondError(w, http.StatusConflict, "This contact is already being tracked")
+			respondErrorCode(w, http.StatusConflict, CodeSourceExists, "This contact is already being tracked")
 			return
 		}
 
@@ -350,29 +346,18 @@ func (s *Server) handleWhatsAppCustomSource(w http.ResponseWriter, r *http.Reque
 			return
 		}
 
-		s.startChannelBackfill(userID, channel)
+		s.startChannelBackfill(userID, channel, 0)
 
 		respondJSON(w, http.StatusCreated, channel)
 		return
 	}
 
 	// Phone-number-based fallback (legacy)
-	phone := phoneInput
-	for _, char := range []string{" ", "-", "(", ")", "+"} {
-		phone = replaceAll(phone, char, "")
-	}
-
-	// Basic validation: should be 7-15 digits
-	if len(phone) < 7 || len(phone) > 15 {
-		respondError(w, http.StatusBadRequest, "Invalid phone number format")
+	phone, err := normalizePhoneNumber(phoneInput)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	for _, c := range phone {
-		if c < '0' || c > '9' {
-			respondError(w, http.StatusBadRequest, "Phone number must contain only digits")
-			return
-		}
-	}
 
 	// Canonical identifier (digits only)
 	identifier := phone
@@ -384,16 +369,15 @@ func (s *Server) handleWhatsAppCustomSource(w http.ResponseWriter, r *http.Reque
 		existing, err = s.db.GetSourceChannelByIdentifier(userID, source.SourceTypeWhatsApp, legacyIdentifier)
 	}
 	if err == nil && existing != nil {
-		respondError(w, http.StatusConflict, "This phone number is already being tracked")
+		respondErrorCode(w, http.StatusConflict, CodeSourceExists, "This phone number is already being tracked")
 		return
 	}
 
 	name := req.PhoneNumber
 	if s.clientManager != nil {
-		if waClient, err := s.clientManager.GetWhatsAppClient(userID); err == nil &&
-			waClient != nil && waClient.WAClient != nil && waClient.WAClient.Store != nil && waClient.WAClient.Store.Contacts != nil {
+		if waClient, err := s.clientManager.GetWhatsAppClient(userID); err == nil {
 			if jid, err := types.ParseJID(legacyIdentifier); err == nil {
-				if contact, err := waClient.WAClient.Store.Contacts.GetContact(r.Context(), jid); err == nil {
+				if contact, err := waClient.GetContact(r.Context(), jid); err == nil {
 					if contact.FullName != "" {
 						name = contact.FullName
 					} else if contact.PushName != "" {
@@ -417,11 +401,31 @@ func (s *Server) handleWhatsAppCustomSource(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	s.startChannelBackfill(userID, channel)
+	s.startChannelBackfill(userID, channel, 0)
 
 	respondJSON(w, http.StatusCreated, channel)
 }
 
+// normalizePhoneNumber strips formatting from a phone number, leaving the 7-15 digits
+// WhatsApp channels are identified by
+func normalizePhoneNumber(input string) (string, error) {
+	phone := strings.TrimSpace(input)
+	for _, char := range []string{" ", "-", "(", ")", "+"} {
+		phone = replaceAll(phone, char, "")
+	}
+
+	// Basic validation: should be 7-15 digits
+	if len(phone) < 7 || len(phone) > 15 {
+		return "", errors.New("Invalid phone number format")
+	}
+	for _, c := range phone {
+		if c < '0' || c > '9' {
+			return "", errors.New("Phone number must contain only digits")
+		}
+	}
+	return phone, nil
+}
+
 func (s *Server) handleListWhatsappChannels(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
 	if err != nil {
@@ -438,9 +442,8 @@ func (s *Server) handleListWhatsappChannels(w http.ResponseWriter, r *http.Reque
 
 	pushNameByIdentifier := map[string]string{}
 	if s.clientManager != nil {
-		if waClient, err := s.clientManager.GetWhatsAppClient(userID); err == nil &&
-			waClient != nil && waClient.WAClient != nil && waClient.WAClient.Store != nil && waClient.WAClient.Store.Contacts != nil {
-			if allContacts, err := waClient.WAClient.Store.Contacts.GetAllContacts(r.Context()); err == nil {
+		if waClient, err := s.clientManager.GetWhatsAppClient(userID); err == nil {
+			if allContacts, err := waClient.GetAllContacts(r.Context()); err == nil {
 				for jid, contact := range allContacts {
 					if jid.Server != "s.whatsapp.net" {
 						continue
@@ -481,23 +484,18 @@ func (s *Server) handleCreateWhatsappChannel(w http.ResponseWriter, r *http.Requ
 	}
 
 	var req struct {
-		Type       string `json:"type"`
-		Identifier string `json:"identifier"`
-		Name       string `json:"name"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
-		return
+		Type         string `json:"type" validate:"required,oneof=sender"` // Contacts only
+		Identifier   string `json:"identifier" validate:"required"`
+		Name         string `json:"name" validate:"required"`
+		BackfillDays int    `json:"backfill_days"`
 	}
 
-	if req.Type == "" || req.Identifier == "" || req.Name == "" {
-		respondError(w, http.StatusBadRequest, "type, identifier and name are required")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	if req.Type != "sender" {
-		respondError(w, http.StatusBadRequest, "type must be 'sender' (contacts only)")
+	if !validBackfillDays(req.BackfillDays) {
+		respondInvalidParameter(w, "backfill_days", 1, maxBackfillDays, fmt.Sprintf("backfill_days must be between 1 and %d", maxBackfillDays))
 		return
 	}
 
@@ -514,7 +512,7 @@ func (s *Server) handleCreateWhatsappChannel(w http.ResponseWriter, r *http.Requ
 		existingChannel.Name = req.Name
 		existingChannel.Enabled = true
 		if wasDisabled {
-			s.startChannelBackfill(userID, existingChannel)
+			s.startChannelBackfill(userID, existingChannel, req.BackfillDays)
 		}
 		respondJSON(w, http.StatusOK, existingChannel)
 		return
@@ -533,7 +531,7 @@ func (s *Server) handleCreateWhatsappChannel(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	s.startChannelBackfill(userID, channel)
+	s.startChannelBackfill(userID, channel, req.BackfillDays)
 
 	respondJSON(w, http.StatusCreated, channel)
 }
@@ -547,7 +545,7 @@ func (s *Server) handleUpdateWhatsappChannel(w http.ResponseWriter, r *http.Requ
 
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
@@ -556,14 +554,13 @@ func (s *Server) handleUpdateWhatsappChannel(w http.ResponseWriter, r *http.Requ
 		Enabled bool   `json:"enabled"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	if err := s.db.UpdateSourceChannel(userID, id, req.Name, req.Enabled); err != nil {
 		if err.Error() == "channel not found" {
-			respondError(w, http.StatusNotFound, "channel not found")
+			respondErrorCode(w, http.StatusNotFound, CodeChannelNotFound, "channel not found")
 			return
 		}
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -582,19 +579,19 @@ func (s *Server) handleDeleteWhatsappChannel(w http.ResponseWriter, r *http.Requ
 	}
 	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "invalid id")
+		respondErrorCode(w, http.StatusBadRequest, CodeInvalidID, "invalid id")
 		return
 	}
 
 	channel, err := s.db.GetSourceChannelByID(userID, id)
 	if err != nil || channel == nil {
-		respondError(w, http.StatusNotFound, "channel not found")
+		respondErrorCode(w, http.StatusNotFound, CodeChannelNotFound, "channel not found")
 		return
 	}
 
 	if err := s.db.UpdateSourceChannel(userID, id, channel.Name, false); err != nil {
 		if err.Error() == "channel not found" {
-			respondError(w, http.StatusNotFound, "channel not found")
+			respondErrorCode(w, http.StatusNotFound, CodeChannelNotFound, "channel not found")
 			return
 		}
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -627,12 +624,12 @@ func (s *Server) handleWhatsAppReconnect(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	if s.clientManager == nil {
-		respondError(w, http.StatusServiceUnavailable, "Client manager not initialized")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not initialized")
 		return
 	}
 
 	if s.onboardingState == nil {
-		respondError(w, http.StatusServiceUnavailable, "Onboarding state not initialized")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Onboarding state not initialized")
 		return
 	}
 
@@ -670,6 +667,8 @@ func (s *Server) handleWhatsAppStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	status["connection"] = s.clientManager.WhatsAppConnectionState(userID)
+
 	// Read-only status path: do not create clients.
 	if waClient, ok := s.clientManager.PeekWhatsAppClient(userID); ok && waClient.IsLoggedIn() {
 		status["connected"] = true
@@ -689,6 +688,83 @@ func (s *Server) handleWhatsAppStatus(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, status)
 }
 
+// handleWhatsAppQRImage serves the current WhatsApp pairing QR code as a PNG, so web
+// clients can use it as an image source instead of rendering the payload themselves.
+// Codes rotate while waiting for a scan; the ETag changes with each one, so clients
+// poll with If-None-Match and get 304 until a new code is shown.
+func (s *Server) handleWhatsAppQRImage(w http.ResponseWriter, r *http.Request) {
+	if s.onboardingState == nil {
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Onboarding not initialized")
+		return
+	}
+
+	png, ok := s.onboardingState.QRImage()
+	if !ok {
+		respondError(w, http.StatusNotFound, "No QR code available")
+		return
+	}
+
+	sum := sha256.Sum256(png)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(png)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+// HistorySyncProgressResponse is how far WhatsApp HistorySync has stored the user's
+// conversations
+type HistorySyncProgressResponse struct {
+	Status            string                           `json:"status"` // "not_started", "pending" or "completed"
+	CompletedChannels int                              `json:"completed_channels"`
+	TotalChannels     int                              `json:"total_channels"`
+	Percent           int                              `json:"percent"`
+	Channels          []database.HistorySyncCheckpoint `json:"channels"` // Pending first
+}
+
+// handleWhatsAppHistorySync reports HistorySync progress per channel. The same
+// checkpoints are published as history_sync_progress updates on /api/stream.
+func (s *Server) handleWhatsAppHistorySync(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		respondError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	checkpoints, err := s.db.ListHistorySyncCheckpoints(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := HistorySyncProgressResponse{
+		Status:        "not_started",
+		TotalChannels: len(checkpoints),
+		Channels:      checkpoints,
+	}
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Status == database.HistorySyncStatusCompleted {
+			response.CompletedChannels++
+		}
+	}
+	switch {
+	case response.TotalChannels == 0:
+	case response.CompletedChannels < response.TotalChannels:
+		response.Status = string(database.HistorySyncStatusPending)
+	default:
+		response.Status = string(database.HistorySyncStatusCompleted)
+	}
+	response.Percent = database.ProgressPercent(response.CompletedChannels, response.TotalChannels)
+	respondJSON(w, http.StatusOK, response)
+}
+
 // handleWhatsAppPair generates a pairing code for phone-number-based WhatsApp linking
 func (s *Server) handleWhatsAppPair(w http.ResponseWriter, r *http.Request) {
 	userID, err := getUserID(r)
@@ -697,21 +773,15 @@ func (s *Server) handleWhatsAppPair(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if s.clientManager == nil {
-		respondError(w, http.StatusServiceUnavailable, "Client manager not initialized")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not initialized")
 		return
 	}
 
 	var req struct {
-		PhoneNumber string `json:"phone_number"` // e.g., "+1234567890" or "1234567890"
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid JSON")
-		return
+		PhoneNumber string `json:"phone_number" validate:"required"` // e.g., "+1234567890" or "1234567890"
 	}
 
-	if req.PhoneNumber == "" {
-		respondError(w, http.StatusBadRequest, "phone_number is required")
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
@@ -763,7 +833,7 @@ func (s *Server) handleWhatsAppDisconnect(w http.ResponseWriter, r *http.Request
 		return
 	}
 	if s.clientManager == nil {
-		respondError(w, http.StatusServiceUnavailable, "Client manager not initialized")
+		respondErrorCode(w, http.StatusServiceUnavailable, CodeNotConfigured, "Client manager not initialized")
 		return
 	}
 
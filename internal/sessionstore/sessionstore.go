@@ -0,0 +1,77 @@
+// Package sessionstore encrypts the WhatsApp and Telegram session stores at rest. They
+// hold long-lived account credentials, so they are sealed with the same key as OAuth
+// tokens, and plaintext stores written by older versions are encrypted when first read.
+package sessionstore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// encryptedHeader starts every encrypted session file, so plaintext files written
+// before encryption was enabled can be told apart and migrated
+var encryptedHeader = []byte("ALFRED-SESSION-ENC1\n")
+
+// Cipher encrypts session data; auth.Encryptor implements it
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// Seal encrypts data for writing to a session file
+func Seal(c Cipher, data []byte) ([]byte, error) {
+	ciphertext, err := c.Encrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt session: %w", err)
+	}
+	return append(append([]byte{}, encryptedHeader...), ciphertext...), nil
+}
+
+// Open decrypts the contents of a session file. Files without the encrypted header are
+// plaintext and are returned as-is with encrypted false, for the caller to re-seal.
+func Open(c Cipher, stored []byte) (data []byte, encrypted bool, err error) {
+	ciphertext, ok := bytes.CutPrefix(stored, encryptedHeader)
+	if !ok {
+		return stored, false, nil
+	}
+	if c == nil {
+		return nil, true, fmt.Errorf("session is encrypted but no encryption key is configured")
+	}
+	data, err = c.Decrypt(ciphertext)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to decrypt session: %w", err)
+	}
+	return data, true, nil
+}
+
+// WriteFile replaces path with data through a temporary file and rename, so a crash
+// mid-write never leaves a truncated session behind
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set session file permissions: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace session file: %w", err)
+	}
+	return nil
+}
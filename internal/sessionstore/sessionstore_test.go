@@ -0,0 +1,162 @@
+package sessionstore
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCipher(t *testing.T) *auth.Encryptor {
+	t.Helper()
+	key, err := auth.GenerateKey()
+	require.NoError(t, err)
+	encryptor, err := auth.NewEncryptor(key)
+	require.NoError(t, err)
+	return encryptor
+}
+
+func TestSealOpen(t *testing.T) {
+	c := newCipher(t)
+
+	sealed, err := Seal(c, []byte("session"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "session")
+
+	data, encrypted, err := Open(c, sealed)
+	require.NoError(t, err)
+	assert.True(t, encrypted)
+	assert.Equal(t, []byte("session"), data)
+
+	data, encrypted, err = Open(c, []byte("plaintext session"))
+	require.NoError(t, err)
+	assert.False(t, encrypted)
+	assert.Equal(t, []byte("plaintext session"), data)
+
+	_, _, err = Open(newCipher(t), sealed)
+	assert.Error(t, err)
+	_, _, err = Open(nil, sealed)
+	assert.Error(t, err)
+}
+
+func TestSQLite(t *testing.T) {
+	t.Run("persists encrypted across reopen", func(t *testing.T) {
+		c := newCipher(t)
+		path := filepath.Join(t.TempDir(), "whatsapp.db")
+
+		store, err := OpenSQLite(path, c)
+		require.NoError(t, err)
+		_, err = store.DB().Exec(`CREATE TABLE keys (id INTEGER PRIMARY KEY, secret TEXT)`)
+		require.NoError(t, err)
+		_, err = store.DB().Exec(`INSERT INTO keys (secret) VALUES ('identity-key')`)
+		require.NoError(t, err)
+		require.NoError(t, store.Close())
+
+		stored, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(stored), "identity-key")
+
+		store, err = OpenSQLite(path, c)
+		require.NoError(t, err)
+		defer store.Close()
+		var secret string
+		require.NoError(t, store.DB().QueryRow(`SELECT secret FROM keys`).Scan(&secret))
+		assert.Equal(t, "identity-key", secret)
+	})
+
+	t.Run("encrypts a plaintext database", func(t *testing.T) {
+		c := newCipher(t)
+		path := filepath.Join(t.TempDir(), "whatsapp.db")
+
+		plain, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL")
+		require.NoError(t, err)
+		_, err = plain.Exec(`CREATE TABLE keys (secret TEXT)`)
+		require.NoError(t, err)
+		_, err = plain.Exec(`INSERT INTO keys VALUES ('noise-key')`)
+		require.NoError(t, err)
+		require.NoError(t, plain.Close())
+
+		store, err := OpenSQLite(path, c)
+		require.NoError(t, err)
+		defer store.Close()
+
+		stored, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(stored), "noise-key")
+		assert.NoFileExists(t, path+"-wal")
+
+		var secret string
+		require.NoError(t, store.DB().QueryRow(`SELECT secret FROM keys`).Scan(&secret))
+		assert.Equal(t, "noise-key", secret)
+	})
+
+	t.Run("flush skips unchanged database", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "whatsapp.db")
+		store, err := OpenSQLite(path, newCipher(t))
+		require.NoError(t, err)
+		defer store.Close()
+
+		_, err = store.DB().Exec(`CREATE TABLE keys (secret TEXT)`)
+		require.NoError(t, err)
+		require.NoError(t, store.Flush(context.Background()))
+		first, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		require.NoError(t, store.Flush(context.Background()))
+		second, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("flushes commits to sync tables right away", func(t *testing.T) {
+		c := newCipher(t)
+		path := filepath.Join(t.TempDir(), "whatsapp.db")
+		store, err := OpenSQLite(path, c)
+		require.NoError(t, err)
+		defer store.Close()
+		store.FlushOnCommit("keys")
+
+		// Written at the next interval, which the test doesn't wait for
+		_, err = store.DB().Exec(`CREATE TABLE contacts (name TEXT)`)
+		require.NoError(t, err)
+		_, err = store.DB().Exec(`CREATE TABLE keys (secret TEXT)`)
+		require.NoError(t, err)
+		_, err = store.DB().Exec(`INSERT INTO contacts VALUES ('dana')`)
+		require.NoError(t, err)
+		assert.NoFileExists(t, path)
+
+		tx, err := store.DB().Begin()
+		require.NoError(t, err)
+		_, err = tx.Exec(`INSERT INTO keys VALUES ('session-key')`)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		require.Eventually(t, func() bool {
+			stored, err := os.ReadFile(path)
+			if err != nil {
+				return false
+			}
+			image, _, err := Open(c, stored)
+			return err == nil && strings.Contains(string(image), "session-key")
+		}, FlushInterval/2, 10*time.Millisecond)
+	})
+
+	t.Run("wrong key fails to open", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "whatsapp.db")
+		store, err := OpenSQLite(path, newCipher(t))
+		require.NoError(t, err)
+		_, err = store.DB().Exec(`CREATE TABLE keys (secret TEXT)`)
+		require.NoError(t, err)
+		require.NoError(t, store.Close())
+
+		_, err = OpenSQLite(path, newCipher(t))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,310 @@
+package sessionstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// FlushInterval is how often an encrypted SQLite store writes its changes to disk.
+// Changes made since the last flush are lost if the process crashes, except in the
+// tables passed to FlushOnCommit.
+const FlushInterval = 5 * time.Second
+
+// sqliteHeader starts every plaintext SQLite database file
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// SQLite is a SQLite database that lives in memory and is persisted as an encrypted
+// image of the whole database file, for stores like whatsmeow's that can't encrypt
+// their own columns. Changes are flushed every FlushInterval, right after commits that
+// touch the FlushOnCommit tables, and on Close.
+type SQLite struct {
+	path   string
+	cipher Cipher
+	db     *sql.DB
+	driver *sqlite3.SQLiteDriver
+
+	mu         sync.Mutex
+	snapshot   []byte          // Image last written to disk, loaded into new connections
+	syncTables map[string]bool // Tables whose commits are flushed right away
+	syncDirty  bool            // The open transaction changed a sync table
+
+	flushMu     sync.Mutex
+	flushedConn *sqlite3.SQLiteConn // Connection lastChanges was counted on
+	lastChanges int64
+
+	flushNow  chan struct{} // Requests a flush ahead of the next tick
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// OpenSQLite opens the encrypted SQLite store at path, creating it if missing. A
+// plaintext SQLite file from before encryption was enabled is encrypted in place.
+func OpenSQLite(path string, c Cipher) (*SQLite, error) {
+	image, plaintext, err := readSQLiteImage(path, c)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SQLite{
+		path:     path,
+		cipher:   c,
+		driver:   &sqlite3.SQLiteDriver{},
+		snapshot: image,
+		flushNow: make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	// One connection, since every in-memory connection is its own database
+	s.db = sql.OpenDB(snapshotConnector{s})
+	s.db.SetMaxOpenConns(1)
+	s.db.SetMaxIdleConns(1)
+	if err := s.db.Ping(); err != nil {
+		s.db.Close()
+		return nil, fmt.Errorf("failed to load session database: %w", err)
+	}
+
+	if plaintext {
+		if err := s.Flush(context.Background()); err != nil {
+			s.db.Close()
+			return nil, fmt.Errorf("failed to encrypt session database: %w", err)
+		}
+		// The journal files of the plaintext database hold plaintext pages too
+		for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+			if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+				slog.Warn("failed to remove plaintext session journal", "path", path+suffix, "error", err)
+			}
+		}
+		slog.Info("Encrypted plaintext session database", "path", path)
+	}
+
+	go s.flushLoop()
+	return s, nil
+}
+
+// DB returns the database handle
+func (s *SQLite) DB() *sql.DB {
+	return s.db
+}
+
+// FlushOnCommit flushes the store as soon as a transaction that writes one of tables
+// commits, rather than at the next FlushInterval, so losing those rows in a crash can't
+// leave the file behind the other side of a protocol (e.g. Signal identity keys and
+// sessions). Each such flush rewrites the whole image, so busy tables cost disk writes;
+// leave them out to keep interval-only flushing. Calling it again replaces the tables.
+func (s *SQLite) FlushOnCommit(tables ...string) {
+	syncTables := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		syncTables[table] = true
+	}
+	s.mu.Lock()
+	s.syncTables = syncTables
+	s.mu.Unlock()
+}
+
+// Flush writes the database to its encrypted file if it changed since the last flush
+func (s *SQLite) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get session database connection: %w", err)
+	}
+	defer conn.Close()
+
+	var changes int64
+	if err := conn.QueryRowContext(ctx, "SELECT total_changes()").Scan(&changes); err != nil {
+		return fmt.Errorf("failed to check session database changes: %w", err)
+	}
+
+	var image []byte
+	var sqliteConn *sqlite3.SQLiteConn
+	err = conn.Raw(func(driverConn any) error {
+		sqliteConn = driverConn.(*sqlite3.SQLiteConn)
+		if sqliteConn == s.flushedConn && changes == s.lastChanges {
+			return nil
+		}
+		image, err = sqliteConn.Serialize("main")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize session database: %w", err)
+	}
+	if image == nil {
+		return nil
+	}
+
+	sealed, err := Seal(s.cipher, image)
+	if err != nil {
+		return err
+	}
+	if err := WriteFile(s.path, sealed); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.snapshot = image
+	s.mu.Unlock()
+	s.flushedConn = sqliteConn
+	s.lastChanges = changes
+	return nil
+}
+
+// Close flushes pending changes and closes the database. It is safe to call twice.
+func (s *SQLite) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		err = s.Flush(context.Background())
+		if closeErr := s.db.Close(); err == nil {
+			err = closeErr
+		}
+	})
+	return err
+}
+
+func (s *SQLite) flushLoop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-s.flushNow:
+		}
+		if err := s.Flush(context.Background()); err != nil {
+			slog.Warn("failed to flush session database", "path", s.path, "error", err)
+		}
+	}
+}
+
+// registerHooks makes commits that changed a FlushOnCommit table wake the flush loop.
+// The flush can't run in the hook, which is called mid-commit on the only connection.
+func (s *SQLite) registerHooks(conn *sqlite3.SQLiteConn) {
+	conn.RegisterUpdateHook(func(_ int, _ string, table string, _ int64) {
+		s.mu.Lock()
+		if s.syncTables[table] {
+			s.syncDirty = true
+		}
+		s.mu.Unlock()
+	})
+	conn.RegisterCommitHook(func() int {
+		s.mu.Lock()
+		dirty := s.syncDirty
+		s.syncDirty = false
+		s.mu.Unlock()
+		if dirty {
+			select {
+			case s.flushNow <- struct{}{}:
+			default: // A flush is already pending and will include this commit
+			}
+		}
+		return 0
+	})
+	conn.RegisterRollbackHook(func() {
+		s.mu.Lock()
+		s.syncDirty = false
+		s.mu.Unlock()
+	})
+}
+
+// readSQLiteImage reads the database image stored at path. plaintext is true for an
+// unencrypted SQLite file that still has to be encrypted.
+func readSQLiteImage(path string, c Cipher) (image []byte, plaintext bool, err error) {
+	stored, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read session database: %w", err)
+	}
+
+	image, encrypted, err := Open(c, stored)
+	if err != nil || encrypted {
+		return image, false, err
+	}
+	if len(stored) == 0 {
+		return nil, false, nil
+	}
+	if !bytes.HasPrefix(stored, sqliteHeader) {
+		return nil, false, fmt.Errorf("session database %s is neither encrypted nor SQLite", path)
+	}
+
+	image, err = serializeFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read plaintext session database: %w", err)
+	}
+	return image, true, nil
+}
+
+// serializeFile returns the image of the SQLite database file at path, including
+// anything still in its write-ahead log
+func serializeFile(path string) ([]byte, error) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Checkpoint the log into the file; an image in WAL mode can't be loaded in memory
+	if _, err := conn.ExecContext(ctx, "PRAGMA journal_mode=DELETE"); err != nil {
+		return nil, err
+	}
+	var image []byte
+	err = conn.Raw(func(driverConn any) error {
+		image, err = driverConn.(*sqlite3.SQLiteConn).Serialize("main")
+		return err
+	})
+	return image, err
+}
+
+// snapshotConnector opens in-memory connections loaded with the last flushed image,
+// so a connection the pool replaces comes back with the data
+type snapshotConnector struct {
+	s *SQLite
+}
+
+func (c snapshotConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.s.driver.Open("file::memory:?_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+	c.s.registerHooks(conn.(*sqlite3.SQLiteConn))
+
+	c.s.mu.Lock()
+	image := c.s.snapshot
+	c.s.mu.Unlock()
+	if len(image) > 0 {
+		if err := conn.(*sqlite3.SQLiteConn).Deserialize(image, "main"); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to load session database image: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (c snapshotConnector) Driver() driver.Driver {
+	return c.s.driver
+}
@@ -1,6 +1,9 @@
 package source
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // SourceType identifies the message source
 type SourceType string
@@ -25,7 +28,7 @@ const (
 
 // Message represents a message from any source (WhatsApp, Telegram, Gmail)
 type Message struct {
-	UserID     int64      // User who owns this channel
+	UserID     int64 // User who owns this channel
 	SourceType SourceType
 	SourceID   int64  // Channel/source database ID
 	Identifier string // WhatsApp phone number / Telegram user ID / email address
@@ -34,6 +37,43 @@ type Message struct {
 	Text       string
 	Subject    string // For emails
 	Timestamp  time.Time
+	Backfill   bool // replayed history rather than a live message; analyzed after live ones
+	// Attachments are media sent with the message (flyer image, PDF invite). Only a
+	// reference is kept; the content is fetched from the source when viewed.
+	Attachments []Attachment
+}
+
+// Attachment kinds
+const (
+	AttachmentKindImage    = "image"
+	AttachmentKindVideo    = "video"
+	AttachmentKindAudio    = "audio"
+	AttachmentKindDocument = "document"
+)
+
+// AttachmentKindForMimeType maps a MIME type to an attachment kind; anything that isn't
+// an image, video or audio file is a document
+func AttachmentKindForMimeType(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return AttachmentKindImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return AttachmentKindVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return AttachmentKindAudio
+	}
+	return AttachmentKindDocument
+}
+
+// Attachment references a media file sent with a message
+type Attachment struct {
+	Kind      string `json:"kind"` // "image", "video", "audio", "document"
+	MimeType  string `json:"mime_type,omitempty"`
+	FileName  string `json:"file_name,omitempty"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+	// SourceRef locates the content at the source: the encoded media message for
+	// WhatsApp, "<message id>/<attachment id>" for Gmail
+	SourceRef string `json:"source_ref"`
 }
 
 // Channel represents a tracked source (contact, group, email sender)
@@ -202,3 +202,11 @@ func TestChannelType_StringComparison(t *testing.T) {
 	ct = ChannelType("category")
 	assert.Equal(t, ChannelTypeCategory, ct)
 }
+
+func TestAttachmentKindForMimeType(t *testing.T) {
+	assert.Equal(t, AttachmentKindImage, AttachmentKindForMimeType("image/jpeg"))
+	assert.Equal(t, AttachmentKindVideo, AttachmentKindForMimeType("video/mp4"))
+	assert.Equal(t, AttachmentKindAudio, AttachmentKindForMimeType("audio/ogg; codecs=opus"))
+	assert.Equal(t, AttachmentKindDocument, AttachmentKindForMimeType("application/pdf"))
+	assert.Equal(t, AttachmentKindDocument, AttachmentKindForMimeType(""))
+}
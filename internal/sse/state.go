@@ -2,10 +2,19 @@ package sse
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"strings"
 	"sync"
 )
 
+// ReplayBufferSize is the number of recent updates kept per State so clients
+// reconnecting with Last-Event-ID can catch up without a full reset.
+const ReplayBufferSize = 100
+
+// qrDataURLPrefix prefixes the PNG data URLs QR codes are stored as
+const qrDataURLPrefix = "data:image/png;base64,"
+
 // State manages the onboarding state for SSE streaming
 type State struct {
 	mu sync.RWMutex
@@ -14,8 +23,10 @@ type State struct {
 	CurrentQR      string // Base64 data URL
 	WhatsAppError  string
 
-	TelegramStatus string // "checking", "pending", "code_sent", "waiting", "connected", "error"
-	TelegramError  string
+	TelegramStatus   string // "checking", "pending", "code_sent", "password_required", "waiting", "connected", "error"
+	TelegramQR       string // Base64 data URL of the QR login code
+	TelegramLoginURL string // tg://login URL the QR code encodes, for opening Telegram on the same device
+	TelegramError    string
 
 	GCalStatus     string // "not_configured", "needs_auth", "waiting", "connected", "error"
 	GCalConfigured bool
@@ -25,11 +36,15 @@ type State struct {
 
 	subscribers map[chan Update]struct{}
 	completeCh  chan struct{}
+
+	lastID  int64    // ID of the most recent broadcast update
+	history []Update // Most recent updates, oldest first, at most ReplayBufferSize
 }
 
 // Update represents an SSE update event
 type Update struct {
-	Type string `json:"type"` // "whatsapp_status", "telegram_status", "qr", "gcal_status", "complete"
+	ID   int64  `json:"id"`   // Sequential per State, sent as the SSE id field
+	Type string `json:"type"` // "whatsapp_status", "telegram_status", "qr", "telegram_qr", "gcal_status", "complete"
 	Data string `json:"data"`
 }
 
@@ -50,8 +65,10 @@ type WhatsAppStatusResponse struct {
 
 // TelegramStatusResponse contains Telegram status details
 type TelegramStatusResponse struct {
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
+	Status   string `json:"status"`
+	QRCode   string `json:"qr_code,omitempty"`
+	LoginURL string `json:"login_url,omitempty"`
+	Error    string `json:"error,omitempty"`
 }
 
 // GCalStatusResponse contains Google Calendar status details
@@ -82,19 +99,66 @@ func (s *State) Subscribe() chan Update {
 	return ch
 }
 
-// Unsubscribe removes a subscriber channel
+// SubscribeFrom creates a subscriber channel and returns the buffered updates
+// after lastID. resumed is false when lastID is unknown or has already been
+// evicted from the replay buffer, in which case the caller should send full state.
+func (s *State) SubscribeFrom(lastID int64) (ch chan Update, missed []Update, resumed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch = make(chan Update, 10)
+	s.subscribers[ch] = struct{}{}
+
+	if lastID <= 0 || lastID > s.lastID {
+		return ch, nil, false
+	}
+	if lastID == s.lastID {
+		return ch, nil, true
+	}
+	if len(s.history) == 0 || s.history[0].ID > lastID+1 {
+		return ch, nil, false
+	}
+
+	for _, update := range s.history {
+		if update.ID > lastID {
+			missed = append(missed, update)
+		}
+	}
+	return ch, missed, true
+}
+
+// LastEventID returns the ID of the most recent update, for tagging full-state snapshots
+func (s *State) LastEventID() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastID
+}
+
+// Unsubscribe removes a subscriber channel. It is a no-op if the channel
+// was already closed by StateManager.RemoveState.
 func (s *State) Unsubscribe(ch chan Update) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, ok := s.subscribers[ch]; !ok {
+		return
+	}
 	delete(s.subscribers, ch)
 	close(ch)
 }
 
-// broadcast sends an update to all subscribers
+// broadcast assigns the next ID to an update, records it for replay,
+// and sends it to all subscribers
 func (s *State) broadcast(update Update) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	update.ID = s.lastID
+	s.history = append(s.history, update)
+	if len(s.history) > ReplayBufferSize {
+		s.history = s.history[len(s.history)-ReplayBufferSize:]
+	}
 
 	for ch := range s.subscribers {
 		select {
@@ -138,6 +202,24 @@ func (s *State) SetQR(dataURL string) {
 	s.broadcast(Update{Type: "qr", Data: dataURL})
 }
 
+// QRImage returns the current WhatsApp pairing QR code as PNG bytes, or false when no
+// code is being shown (not waiting for a scan, or already connected)
+func (s *State) QRImage() ([]byte, bool) {
+	s.mu.RLock()
+	dataURL, status := s.CurrentQR, s.WhatsAppStatus
+	s.mu.RUnlock()
+
+	encoded, ok := strings.CutPrefix(dataURL, qrDataURLPrefix)
+	if !ok || status != "waiting" {
+		return nil, false
+	}
+	png, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+	return png, true
+}
+
 // SetGCalStatus updates the Google Calendar status and broadcasts
 func (s *State) SetGCalStatus(status string) {
 	s.mu.Lock()
@@ -178,17 +260,33 @@ func (s *State) SetTelegramStatus(status string) {
 	if status != "error" {
 		s.TelegramError = "" // Clear error when status changes to non-error
 	}
+	if status != "waiting" {
+		s.TelegramQR, s.TelegramLoginURL = "", "" // The QR code is only valid while waiting for a scan
+	}
 	s.mu.Unlock()
 
 	s.broadcast(Update{Type: "telegram_status", Data: status})
 	s.checkComplete()
 }
 
+// SetTelegramQR updates the Telegram QR login code and broadcasts it
+func (s *State) SetTelegramQR(dataURL, loginURL string) {
+	s.mu.Lock()
+	s.TelegramQR = dataURL
+	s.TelegramLoginURL = loginURL
+	s.TelegramStatus = "waiting"
+	s.TelegramError = ""
+	s.mu.Unlock()
+
+	s.broadcast(Update{Type: "telegram_qr", Data: dataURL})
+}
+
 // SetTelegramError sets an error for Telegram
 func (s *State) SetTelegramError(err string) {
 	s.mu.Lock()
 	s.TelegramStatus = "error"
 	s.TelegramError = err
+	s.TelegramQR, s.TelegramLoginURL = "", ""
 	s.mu.Unlock()
 
 	s.broadcast(Update{Type: "telegram_status", Data: "error"})
@@ -223,6 +321,28 @@ func (s *State) MarkComplete() {
 	close(s.completeCh)
 }
 
+// Restore sets the connection statuses and completion saved from an earlier run, without
+// broadcasting: it is for startup, before anyone subscribes. QR codes and errors aren't
+// restored since they don't outlive the process that showed them.
+func (s *State) Restore(status StatusResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status.WhatsApp.Status != "" {
+		s.WhatsAppStatus = status.WhatsApp.Status
+	}
+	if status.Telegram.Status != "" {
+		s.TelegramStatus = status.Telegram.Status
+	}
+	if status.GCal.Status != "" && s.GCalStatus != "not_configured" {
+		s.GCalStatus = status.GCal.Status
+	}
+	if status.Complete && !s.Complete {
+		s.Complete = true
+		close(s.completeCh)
+	}
+}
+
 // IsComplete returns whether onboarding is complete
 func (s *State) IsComplete() bool {
 	s.mu.RLock()
@@ -252,8 +372,10 @@ func (s *State) GetStatus() StatusResponse {
 			Error:  s.WhatsAppError,
 		},
 		Telegram: TelegramStatusResponse{
-			Status: s.TelegramStatus,
-			Error:  s.TelegramError,
+			Status:   s.TelegramStatus,
+			QRCode:   s.TelegramQR,
+			LoginURL: s.TelegramLoginURL,
+			Error:    s.TelegramError,
 		},
 		GCal: GCalStatusResponse{
 			Status:     s.GCalStatus,
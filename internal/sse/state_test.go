@@ -1,6 +1,7 @@
 package sse
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -47,6 +48,45 @@ func TestState(t *testing.T) {
 		assert.Equal(t, "waiting", state.WhatsAppStatus)
 	})
 
+	t.Run("qr image", func(t *testing.T) {
+		state := NewState()
+
+		_, ok := state.QRImage()
+		assert.False(t, ok)
+
+		state.SetQR("data:image/png;base64,iVBORw0K")
+		png, ok := state.QRImage()
+		require.True(t, ok)
+		assert.Equal(t, []byte("\x89PNG\r\n"), png)
+
+		// No image once the scan completed
+		state.SetWhatsAppStatus("connected")
+		_, ok = state.QRImage()
+		assert.False(t, ok)
+	})
+
+	t.Run("set telegram qr code", func(t *testing.T) {
+		state := NewState()
+		ch := state.Subscribe()
+		defer state.Unsubscribe(ch)
+
+		state.SetTelegramQR("data:image/png;base64,tg", "tg://login?token=abc")
+		update := <-ch
+		assert.Equal(t, "telegram_qr", update.Type)
+		assert.Equal(t, "data:image/png;base64,tg", update.Data)
+
+		status := state.GetStatus().Telegram
+		assert.Equal(t, "waiting", status.Status)
+		assert.Equal(t, "data:image/png;base64,tg", status.QRCode)
+		assert.Equal(t, "tg://login?token=abc", status.LoginURL)
+
+		// The code is dropped once the login moves on
+		state.SetTelegramStatus("connected")
+		status = state.GetStatus().Telegram
+		assert.Empty(t, status.QRCode)
+		assert.Empty(t, status.LoginURL)
+	})
+
 	t.Run("set errors", func(t *testing.T) {
 		state := NewState()
 
@@ -138,6 +178,108 @@ func TestState(t *testing.T) {
 		time.Sleep(20 * time.Millisecond)
 		state.Unsubscribe(ch)
 	})
+
+	t.Run("restore", func(t *testing.T) {
+		state := NewState()
+		state.SetGCalConfigured(false)
+		ch := state.Subscribe()
+
+		state.Restore(StatusResponse{
+			WhatsApp: WhatsAppStatusResponse{Status: "connected"},
+			GCal:     GCalStatusResponse{Status: "connected"},
+			Complete: true,
+		})
+
+		assert.Equal(t, "connected", state.WhatsAppStatus)
+		assert.Equal(t, "pending", state.TelegramStatus)
+		assert.Equal(t, "not_configured", state.GCalStatus)
+		assert.True(t, state.IsComplete())
+		assert.Zero(t, state.LastEventID())
+		require.NoError(t, state.WaitForCompletion(context.Background()))
+		select {
+		case update := <-ch:
+			t.Fatalf("unexpected broadcast %q", update.Type)
+		default:
+		}
+
+		// Completing again must not close the channel twice
+		state.MarkComplete()
+		state.Unsubscribe(ch)
+	})
+}
+
+func TestStateReplay(t *testing.T) {
+	t.Run("updates get sequential ids", func(t *testing.T) {
+		state := NewState()
+		ch := state.Subscribe()
+		defer state.Unsubscribe(ch)
+
+		state.SetWhatsAppStatus("waiting")
+		state.SetGCalStatus("needs_auth")
+
+		assert.Equal(t, int64(1), (<-ch).ID)
+		assert.Equal(t, int64(2), (<-ch).ID)
+		assert.Equal(t, int64(2), state.LastEventID())
+	})
+
+	t.Run("resume returns missed updates", func(t *testing.T) {
+		state := NewState()
+		state.SetWhatsAppStatus("waiting")
+		state.SetQR("data:image/png;base64,abc")
+		state.SetTelegramStatus("code_sent")
+
+		ch, missed, resumed := state.SubscribeFrom(1)
+		defer state.Unsubscribe(ch)
+
+		require.True(t, resumed)
+		require.Len(t, missed, 2)
+		assert.Equal(t, "qr", missed[0].Type)
+		assert.Equal(t, int64(2), missed[0].ID)
+		assert.Equal(t, "telegram_status", missed[1].Type)
+
+		state.SetGCalStatus("connected")
+		update := <-ch
+		assert.Equal(t, int64(4), update.ID)
+	})
+
+	t.Run("resume at latest id has nothing missed", func(t *testing.T) {
+		state := NewState()
+		state.SetWhatsAppStatus("waiting")
+
+		ch, missed, resumed := state.SubscribeFrom(1)
+		defer state.Unsubscribe(ch)
+
+		assert.True(t, resumed)
+		assert.Empty(t, missed)
+	})
+
+	t.Run("no id or unknown id requires full state", func(t *testing.T) {
+		state := NewState()
+		state.SetWhatsAppStatus("waiting")
+
+		for _, lastID := range []int64{0, 5} {
+			ch, missed, resumed := state.SubscribeFrom(lastID)
+			assert.False(t, resumed)
+			assert.Empty(t, missed)
+			state.Unsubscribe(ch)
+		}
+	})
+
+	t.Run("evicted ids require full state", func(t *testing.T) {
+		state := NewState()
+		for i := 0; i < ReplayBufferSize+5; i++ {
+			state.SetWhatsAppStatus("waiting")
+		}
+
+		ch, _, resumed := state.SubscribeFrom(2)
+		state.Unsubscribe(ch)
+		assert.False(t, resumed)
+
+		ch, missed, resumed := state.SubscribeFrom(5)
+		defer state.Unsubscribe(ch)
+		assert.True(t, resumed)
+		assert.Len(t, missed, ReplayBufferSize)
+	})
 }
 
 func TestStateManager(t *testing.T) {
@@ -213,6 +355,42 @@ func TestStateManager(t *testing.T) {
 		state2.Unsubscribe(ch2)
 	})
 
+	t.Run("publish marshals payload for user stream", func(t *testing.T) {
+		manager := NewStateManager()
+
+		ch := manager.Subscribe(1)
+		defer manager.Unsubscribe(1, ch)
+
+		err := manager.Publish(1, UpdateEventPending, map[string]any{"id": 42, "title": "Dinner"})
+		require.NoError(t, err)
+
+		select {
+		case update := <-ch:
+			assert.Equal(t, UpdateEventPending, update.Type)
+			assert.JSONEq(t, `{"id":42,"title":"Dinner"}`, update.Data)
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("subscriber didn't receive published update")
+		}
+	})
+
+	t.Run("publish without subscribers is a no-op", func(t *testing.T) {
+		manager := NewStateManager()
+
+		require.NoError(t, manager.Publish(7, UpdateSyncComplete, map[string]string{"source": "gcal"}))
+		assert.Empty(t, manager.GetAllStates())
+	})
+
+	t.Run("unsubscribe after remove state does not panic", func(t *testing.T) {
+		manager := NewStateManager()
+
+		ch := manager.Subscribe(1)
+		manager.RemoveState(1)
+
+		_, open := <-ch
+		assert.False(t, open)
+		assert.NotPanics(t, func() { manager.Unsubscribe(1, ch) })
+	})
+
 	t.Run("get all states", func(t *testing.T) {
 		manager := NewStateManager()
 
@@ -0,0 +1,59 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Update types published on the per-user /api/stream
+const (
+	UpdateEventPending        = "event_pending"
+	UpdateReminderPending     = "reminder_pending"
+	UpdateSyncComplete        = "sync_complete"
+	UpdateExportProgress      = "export_progress"
+	UpdateBudgetExceeded      = "llm_budget_exceeded"
+	UpdateBackfillProgress    = "backfill_progress"
+	UpdateHistorySyncProgress = "history_sync_progress"
+	UpdateChannelUnmuted      = "channel_unmuted"
+	UpdateEventChanged        = "event_changed"
+	UpdateGoogleReauth        = "google_reauth_required"
+	UpdateOnboardingSteps     = "onboarding_steps"
+)
+
+// Subscribe creates a new update channel for a user's stream
+func (m *StateManager) Subscribe(userID int64) chan Update {
+	return m.GetState(userID).Subscribe()
+}
+
+// SubscribeFrom subscribes to a user's stream, returning updates missed since lastID.
+// See State.SubscribeFrom.
+func (m *StateManager) SubscribeFrom(userID int64, lastID int64) (chan Update, []Update, bool) {
+	return m.GetState(userID).SubscribeFrom(lastID)
+}
+
+// LastEventID returns the ID of the most recent update on a user's stream
+func (m *StateManager) LastEventID(userID int64) int64 {
+	return m.GetState(userID).LastEventID()
+}
+
+// Unsubscribe removes a subscriber channel from a user's stream
+func (m *StateManager) Unsubscribe(userID int64, ch chan Update) {
+	m.mu.RLock()
+	state, exists := m.states[userID]
+	m.mu.RUnlock()
+
+	if exists {
+		state.Unsubscribe(ch)
+	}
+}
+
+// Publish marshals payload as JSON and broadcasts it to a user's subscribers.
+// Users without an open stream are skipped.
+func (m *StateManager) Publish(userID int64, updateType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s update: %w", updateType, err)
+	}
+	m.BroadcastToUser(userID, Update{Type: updateType, Data: string(data)})
+	return nil
+}
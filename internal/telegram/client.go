@@ -2,7 +2,9 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
@@ -11,24 +13,38 @@ import (
 	"github.com/gotd/td/telegram"
 	"github.com/gotd/td/telegram/auth"
 	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	"github.com/omriShneor/project_alfred/internal/sessionstore"
 )
 
+// ErrPasswordRequired is returned by VerifyCode for accounts with two-step verification;
+// the login is finished by VerifyPassword
+var ErrPasswordRequired = errors.New("two-step verification password required")
+
+// ErrPasswordInvalid is returned by VerifyPassword for a wrong password. The login stays
+// pending, so the password can be tried again.
+var ErrPasswordInvalid = errors.New("incorrect two-step verification password")
+
 // Client manages the Telegram connection
 type Client struct {
-	apiID        int
-	apiHash      string
-	sessionPath  string
-	client       *telegram.Client
-	api          *tg.Client
-	handler      *Handler
-	connected    bool
-	phoneNumber  string
-	codeHash     string // Stored during code verification flow
-	mu           sync.RWMutex
-	ctx          context.Context
-	cancel       context.CancelFunc
-	updatesChan  chan tg.UpdatesClass
-	runDone      chan struct{} // Signals when client.Run() goroutine finishes
+	apiID         int
+	apiHash       string
+	sessionPath   string
+	sessionCipher sessionstore.Cipher
+	client        *telegram.Client
+	api           *tg.Client
+	handler       *Handler
+	connected     bool
+	phoneNumber   string
+	codeHash      string // Stored during code verification flow
+	needsPassword bool   // Code or QR login accepted, waiting for the two-step verification password
+	mu            sync.RWMutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+	updatesChan   chan tg.UpdatesClass
+	runDone       chan struct{}      // Signals when client.Run() goroutine finishes
+	loginTokens   chan struct{}      // Signals that a QR login token was accepted
+	cancelQRLogin context.CancelFunc // Stops the QR login in progress, if any
 }
 
 // ClientConfig holds configuration for the Telegram client
@@ -37,6 +53,8 @@ type ClientConfig struct {
 	APIHash     string
 	SessionPath string
 	Handler     *Handler
+	// SessionCipher encrypts the session file; nil stores it in plaintext
+	SessionCipher sessionstore.Cipher
 }
 
 // NewClient creates a new Telegram client
@@ -48,14 +66,16 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	c := &Client{
-		apiID:       cfg.APIID,
-		apiHash:     cfg.APIHash,
-		sessionPath: cfg.SessionPath,
-		handler:     cfg.Handler,
-		ctx:         ctx,
-		cancel:      cancel,
-		updatesChan: make(chan tg.UpdatesClass, 100),
-		runDone:     make(chan struct{}),
+		apiID:         cfg.APIID,
+		apiHash:       cfg.APIHash,
+		sessionPath:   cfg.SessionPath,
+		sessionCipher: cfg.SessionCipher,
+		handler:       cfg.Handler,
+		ctx:           ctx,
+		cancel:        cancel,
+		updatesChan:   make(chan tg.UpdatesClass, 100),
+		runDone:       make(chan struct{}),
+		loginTokens:   make(chan struct{}, 1),
 	}
 
 	return c, nil
@@ -86,7 +106,7 @@ func (c *Client) Connect() error {
 	}
 
 	// Create storage for session persistence
-	sessionStorage := &FileSessionStorage{Path: c.sessionPath}
+	sessionStorage := &FileSessionStorage{Path: c.sessionPath, Cipher: c.sessionCipher}
 
 	// Create the Telegram client
 	client := telegram.NewClient(c.apiID, c.apiHash, telegram.Options{
@@ -121,16 +141,24 @@ func (c *Client) Connect() error {
 			c.mu.Unlock()
 
 			if status.Authorized {
-				fmt.Println("Telegram: Already authorized")
+				slog.Info("Telegram: Already authorized")
 			} else {
-				fmt.Println("Telegram: Not authorized, waiting for authentication")
+				slog.Info("Telegram: Not authorized, waiting for authentication")
 			}
 
 			// Block until context is cancelled
 			<-ctx.Done()
 			return ctx.Err()
 		}); err != nil && err != context.Canceled {
-			fmt.Printf("Telegram client error: %v\n", err)
+			slog.Error("Telegram client error", "error", err)
+			// Let Connect start the client again
+			c.mu.Lock()
+			if c.client == client {
+				c.connected = false
+				c.api = nil
+				c.client = nil
+			}
+			c.mu.Unlock()
 		}
 	}()
 
@@ -148,7 +176,7 @@ func (c *Client) Connect() error {
 			apiReady := c.api != nil
 			c.mu.RUnlock()
 			if apiReady {
-				fmt.Println("Telegram: Client connected and ready")
+				slog.Info("Telegram: Client connected and ready")
 				return nil
 			}
 		}
@@ -236,7 +264,7 @@ func (c *Client) Disconnect() {
 		case <-runDone:
 			// Goroutine finished
 		case <-time.After(5 * time.Second):
-			fmt.Println("Telegram: Timeout waiting for client to disconnect")
+			slog.Error("Telegram: Timeout waiting for client to disconnect")
 		}
 	}
 
@@ -245,6 +273,11 @@ func (c *Client) Disconnect() {
 	defer c.mu.Unlock()
 
 	c.connected = false
+	c.needsPassword = false
+	if c.cancelQRLogin != nil {
+		c.cancelQRLogin()
+		c.cancelQRLogin = nil
+	}
 	c.api = nil
 	c.client = nil
 
@@ -256,6 +289,13 @@ func (c *Client) Disconnect() {
 	c.updatesChan = make(chan tg.UpdatesClass, 100)
 }
 
+// IsStarted returns whether Connect started the client, authenticated or not
+func (c *Client) IsStarted() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.api != nil
+}
+
 // IsConnected returns whether the client is connected and authenticated
 func (c *Client) IsConnected() bool {
 	c.mu.RLock()
@@ -273,7 +313,7 @@ func (c *Client) DeleteSession() error {
 	// Check if file exists
 	if _, err := os.Stat(sessionPath); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("Telegram: Session file already deleted: %s\n", sessionPath)
+			slog.Info("Telegram: Session file already deleted", "session_path", sessionPath)
 			return nil
 		}
 		return fmt.Errorf("failed to check session file: %w", err)
@@ -284,7 +324,7 @@ func (c *Client) DeleteSession() error {
 		return fmt.Errorf("failed to delete session file: %w", err)
 	}
 
-	fmt.Printf("Telegram: Deleted session file: %s\n", sessionPath)
+	slog.Info("Telegram: Deleted session file", "session_path", sessionPath)
 	return nil
 }
 
@@ -300,7 +340,7 @@ func (c *Client) SendCode(ctx context.Context, phoneNumber string) error {
 	c.mu.RUnlock()
 
 	if needsConnect {
-		fmt.Println("Telegram: Auto-connecting before sending code...")
+		slog.Info("Telegram: Auto-connecting before sending code...")
 		if err := c.Connect(); err != nil {
 			return fmt.Errorf("failed to connect: %w", err)
 		}
@@ -329,8 +369,13 @@ func (c *Client) SendCode(ctx context.Context, phoneNumber string) error {
 		return fmt.Errorf("failed to send code: %w", err)
 	}
 
-	// Store phone number and code hash for verification
+	// Store phone number and code hash for verification, replacing a QR login in progress
 	c.phoneNumber = phoneNumber
+	c.needsPassword = false
+	if c.cancelQRLogin != nil {
+		c.cancelQRLogin()
+		c.cancelQRLogin = nil
+	}
 	switch v := sentCode.(type) {
 	case *tg.AuthSentCode:
 		c.codeHash = v.PhoneCodeHash
@@ -338,7 +383,7 @@ func (c *Client) SendCode(ctx context.Context, phoneNumber string) error {
 		return fmt.Errorf("unexpected sent code type: %T", sentCode)
 	}
 
-	fmt.Printf("Telegram: Verification code sent to %s\n", phoneNumber)
+	slog.Info("Telegram: Verification code sent", "phone_number", phoneNumber)
 	return nil
 }
 
@@ -362,7 +407,13 @@ func (c *Client) VerifyCode(ctx context.Context, code string) error {
 		PhoneCode:     code,
 	})
 	if err != nil {
-		// Check if 2FA is required
+		// Accounts with two-step verification need their cloud password next
+		if tgerr.Is(err, "SESSION_PASSWORD_NEEDED") {
+			c.needsPassword = true
+			c.codeHash = ""
+			slog.Info("Telegram: Two-step verification password required", "phone_number", c.phoneNumber)
+			return ErrPasswordRequired
+		}
 		if auth.IsKeyUnregistered(err) {
 			return fmt.Errorf("phone number not registered on Telegram")
 		}
@@ -372,7 +423,7 @@ func (c *Client) VerifyCode(ctx context.Context, code string) error {
 	switch v := authResult.(type) {
 	case *tg.AuthAuthorization:
 		c.connected = true
-		fmt.Printf("Telegram: Successfully authenticated as %v\n", v.User)
+		slog.Info("Telegram: Successfully authenticated", "user", v.User)
 	case *tg.AuthAuthorizationSignUpRequired:
 		return fmt.Errorf("account registration required - please sign up on Telegram first")
 	default:
@@ -386,8 +437,47 @@ func (c *Client) VerifyCode(ctx context.Context, code string) error {
 	return nil
 }
 
+// VerifyPassword completes a login that VerifyCode answered with ErrPasswordRequired. The
+// password is checked with Telegram's SRP protocol, so it is never sent to Telegram
+// and isn't stored.
+func (c *Client) VerifyPassword(ctx context.Context, password string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.api == nil || c.client == nil {
+		return fmt.Errorf("client not connected")
+	}
+	if !c.needsPassword {
+		return fmt.Errorf("no pending password verification - verify the code first")
+	}
+
+	authorization, err := c.client.Auth().Password(ctx, password)
+	if errors.Is(err, auth.ErrPasswordInvalid) {
+		return ErrPasswordInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check password: %w", err)
+	}
+
+	c.connected = true
+	c.needsPassword = false
+	c.phoneNumber = ""
+	slog.Info("Telegram: Successfully authenticated with two-step verification", "user", authorization.User)
+	return nil
+}
+
+// PasswordRequired reports whether the login is waiting for the two-step verification
+// password
+func (c *Client) PasswordRequired() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.needsPassword
+}
+
 // Handle implements telegram.UpdateHandler
 func (c *Client) Handle(ctx context.Context, u tg.UpdatesClass) error {
+	c.notifyLoginToken(u)
+
 	if c.handler == nil {
 		return nil
 	}
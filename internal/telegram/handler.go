@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/gotd/td/tg"
@@ -128,7 +129,7 @@ func (h *Handler) handleNewMessage(msg tg.MessageClass) {
 		var err error
 		tracked, sourceID, channelType, err = h.db.IsSourceChannelTracked(h.UserID, source.SourceTypeTelegram, chatIdentifier)
 		if err != nil {
-			fmt.Printf("Telegram: Error checking channel: %v\n", err)
+			slog.Error("Telegram: Error checking channel", "error", err)
 			return
 		}
 		_ = channelType
@@ -138,8 +139,8 @@ func (h *Handler) handleNewMessage(msg tg.MessageClass) {
 		return
 	}
 
-	// Log message
-	fmt.Printf("[Telegram DM: %s] %s\n", senderName, truncateText(text, 100))
+	// Message text is only logged at debug level
+	slog.Debug("Telegram DM", "user_id", h.UserID, "sender", senderName, "text", truncateText(text, 100))
 
 	// Send to processor (blocking for reliability).
 	h.messageChan <- source.Message{
@@ -178,7 +179,7 @@ func (h *Handler) handleShortMessage(msg *tg.UpdateShortMessage) {
 		var err error
 		tracked, sourceID, _, err = h.db.IsSourceChannelTracked(h.UserID, source.SourceTypeTelegram, chatIdentifier)
 		if err != nil {
-			fmt.Printf("Telegram: Error checking channel: %v\n", err)
+			slog.Error("Telegram: Error checking channel", "error", err)
 			return
 		}
 	}
@@ -187,7 +188,7 @@ func (h *Handler) handleShortMessage(msg *tg.UpdateShortMessage) {
 		return
 	}
 
-	fmt.Printf("[Telegram DM: %s] %s\n", senderName, truncateText(msg.Message, 100))
+	slog.Debug("Telegram DM", "user_id", h.UserID, "sender", senderName, "text", truncateText(msg.Message, 100))
 
 	h.messageChan <- source.Message{
 		UserID:     h.UserID,
@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gotd/td/telegram/auth/qrlogin"
+	"github.com/gotd/td/tg"
+	"github.com/gotd/td/tgerr"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QRLoginTimeout is how long LoginWithQR waits for the QR code to be scanned. Telegram
+// expires each login token after about 30 seconds; a new QR code is shown for each.
+const QRLoginTimeout = 3 * time.Minute
+
+// LoginWithQR signs in by QR code instead of a code sent by SMS: show is called with
+// each login token's tg://login URL and a PNG data URL of its QR code, until the code
+// is scanned in a logged-in Telegram app (Settings > Devices > Link Desktop Device) or
+// ctx ends. Accounts with two-step verification get ErrPasswordRequired and finish
+// with VerifyPassword. Starting a new QR login cancels one still waiting.
+func (c *Client) LoginWithQR(ctx context.Context, show func(loginURL, qrDataURL string, expires time.Time) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.mu.Lock()
+	if c.connected {
+		c.mu.Unlock()
+		return fmt.Errorf("already authenticated - disconnect first to re-authenticate")
+	}
+	if c.api == nil || c.client == nil {
+		c.mu.Unlock()
+		return fmt.Errorf("client not connected")
+	}
+	client := c.client
+	if c.cancelQRLogin != nil {
+		c.cancelQRLogin()
+	}
+	c.cancelQRLogin = cancel
+	c.phoneNumber = ""
+	c.codeHash = ""
+	c.needsPassword = false
+	c.mu.Unlock()
+
+	// Drop a signal left over from an earlier attempt
+	select {
+	case <-c.loginTokens:
+	default:
+	}
+
+	// The lock isn't held while waiting, since the scan can take minutes
+	authorization, err := client.QR().Auth(ctx, qrlogin.LoggedIn(c.loginTokens),
+		func(ctx context.Context, token qrlogin.Token) error {
+			dataURL, err := qrDataURL(token.URL())
+			if err != nil {
+				return err
+			}
+			return show(token.URL(), dataURL, token.Expires())
+		})
+	if tgerr.Is(err, "SESSION_PASSWORD_NEEDED") {
+		c.mu.Lock()
+		c.needsPassword = true
+		c.mu.Unlock()
+		slog.Info("Telegram: Two-step verification password required after QR login")
+		return ErrPasswordRequired
+	}
+	if err != nil {
+		return fmt.Errorf("qr login failed: %w", err)
+	}
+
+	c.mu.Lock()
+	c.connected = true
+	c.mu.Unlock()
+	slog.Info("Telegram: Successfully authenticated by QR code", "user", authorization.User)
+	return nil
+}
+
+// notifyLoginToken wakes LoginWithQR when Telegram reports the QR code was scanned
+func (c *Client) notifyLoginToken(u tg.UpdatesClass) {
+	short, ok := u.(*tg.UpdateShort)
+	if !ok {
+		return
+	}
+	if _, ok := short.Update.(*tg.UpdateLoginToken); !ok {
+		return
+	}
+	select {
+	case c.loginTokens <- struct{}{}:
+	default:
+	}
+}
+
+// qrDataURL renders a login URL as a QR code PNG data URL, like the WhatsApp QR flow
+func qrDataURL(loginURL string) (string, error) {
+	png, err := qrcode.Encode(loginURL, qrcode.Medium, 256)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png), nil
+}
@@ -3,31 +3,54 @@ package telegram
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/gotd/td/session"
+	"github.com/omriShneor/project_alfred/internal/sessionstore"
 )
 
-// FileSessionStorage implements session.Storage for file-based persistence
+// FileSessionStorage implements session.Storage for file-based persistence. With a
+// Cipher the file is encrypted, and a plaintext file is encrypted when first loaded.
 type FileSessionStorage struct {
-	Path string
+	Path   string
+	Cipher sessionstore.Cipher
 }
 
 // LoadSession loads the session from file
 func (s *FileSessionStorage) LoadSession(ctx context.Context) ([]byte, error) {
-	data, err := os.ReadFile(s.Path)
+	stored, err := os.ReadFile(s.Path)
 	if os.IsNotExist(err) {
 		return nil, session.ErrNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
+
+	data, encrypted, err := sessionstore.Open(s.Cipher, stored)
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted && s.Cipher != nil && len(data) > 0 {
+		if err := s.StoreSession(ctx, data); err != nil {
+			return nil, fmt.Errorf("failed to encrypt plaintext session: %w", err)
+		}
+		slog.Info("Telegram: Encrypted plaintext session file", "path", s.Path)
+	}
 	return data, nil
 }
 
 // StoreSession saves the session to file
 func (s *FileSessionStorage) StoreSession(ctx context.Context, data []byte) error {
-	return os.WriteFile(s.Path, data, 0600)
+	if s.Cipher == nil {
+		return os.WriteFile(s.Path, data, 0600)
+	}
+	sealed, err := sessionstore.Seal(s.Cipher, data)
+	if err != nil {
+		return err
+	}
+	return sessionstore.WriteFile(s.Path, sealed)
 }
 
 // JSONSessionStorage wraps session data for JSON storage
@@ -0,0 +1,198 @@
+package fixtures
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/gmail"
+	"github.com/omriShneor/project_alfred/internal/source"
+)
+
+// EmailBuilder builds test emails along with the thread and source the email
+// processor receives them with
+type EmailBuilder struct {
+	id         string
+	threadID   string
+	subject    string
+	from       string
+	to         string
+	body       string
+	receivedAt time.Time
+	labels     []string
+	earlier    []gmail.ThreadMessage
+}
+
+// NewEmailBuilder creates a new email builder with defaults
+func NewEmailBuilder() *EmailBuilder {
+	return &EmailBuilder{
+		id:         "msg-1",
+		threadID:   "thread-1",
+		subject:    "Team sync",
+		from:       "Test Sender <sender@example.com>",
+		to:         "me@example.com",
+		body:       "Let's meet tomorrow at 2pm in the main conference room",
+		receivedAt: time.Now().Truncate(time.Second),
+		labels:     []string{"INBOX", "CATEGORY_PRIMARY"},
+	}
+}
+
+// WithID sets the Gmail message ID
+func (b *EmailBuilder) WithID(id string) *EmailBuilder {
+	b.id = id
+	return b
+}
+
+// WithThreadID sets the Gmail thread ID
+func (b *EmailBuilder) WithThreadID(id string) *EmailBuilder {
+	b.threadID = id
+	return b
+}
+
+// WithSubject sets the subject
+func (b *EmailBuilder) WithSubject(subject string) *EmailBuilder {
+	b.subject = subject
+	return b
+}
+
+// WithFrom sets the From header, e.g. "Dana <dana@example.com>"
+func (b *EmailBuilder) WithFrom(from string) *EmailBuilder {
+	b.from = from
+	return b
+}
+
+// WithTo sets the To header
+func (b *EmailBuilder) WithTo(to string) *EmailBuilder {
+	b.to = to
+	return b
+}
+
+// WithBody sets the plain text body
+func (b *EmailBuilder) WithBody(body string) *EmailBuilder {
+	b.body = body
+	return b
+}
+
+// WithReceivedAt sets when the email arrived
+func (b *EmailBuilder) WithReceivedAt(t time.Time) *EmailBuilder {
+	b.receivedAt = t
+	return b
+}
+
+// WithLabels sets the Gmail labels
+func (b *EmailBuilder) WithLabels(labels ...string) *EmailBuilder {
+	b.labels = labels
+	return b
+}
+
+// InReplyTo adds an earlier message to the thread, oldest first
+func (b *EmailBuilder) InReplyTo(from, body string) *EmailBuilder {
+	b.earlier = append(b.earlier, gmail.ThreadMessage{From: from, Body: body})
+	return b
+}
+
+// earlierMessages fills in the InReplyTo messages, an hour apart before the email
+func (b *EmailBuilder) earlierMessages() []gmail.ThreadMessage {
+	subject := strings.TrimPrefix(b.subject, "Re: ")
+	messages := make([]gmail.ThreadMessage, len(b.earlier))
+	for i, msg := range b.earlier {
+		msg.ID = fmt.Sprintf("%s-earlier-%d", b.id, i+1)
+		msg.To = b.to
+		msg.Date = b.receivedAt.Add(-time.Duration(len(b.earlier)-i) * time.Hour).Format(time.RFC1123Z)
+		msg.Subject = subject
+		if i > 0 {
+			msg.Subject = "Re: " + subject
+		}
+		messages[i] = msg
+	}
+	return messages
+}
+
+// Email returns the email as the Gmail client fetches it
+func (b *EmailBuilder) Email() *gmail.Email {
+	return &gmail.Email{
+		ID:         b.id,
+		ThreadID:   b.threadID,
+		Subject:    b.subject,
+		From:       b.from,
+		To:         b.to,
+		Date:       b.receivedAt.Format(time.RFC1123Z),
+		ReceivedAt: b.receivedAt,
+		Body:       b.body,
+		Snippet:    gmail.TruncateText(b.body, 100),
+		Labels:     b.labels,
+		MessageID:  fmt.Sprintf("<%s@mail.example.com>", b.id),
+	}
+}
+
+// Thread returns the email's thread: the InReplyTo messages followed by the email
+func (b *EmailBuilder) Thread() *gmail.Thread {
+	messages := make([]gmail.ThreadMessage, 0, len(b.earlier)+1)
+	messages = append(messages, b.earlierMessages()...)
+	messages = append(messages, gmail.ThreadMessage{
+		ID:       b.id,
+		From:     b.from,
+		To:       b.to,
+		Date:     b.receivedAt.Format(time.RFC1123Z),
+		Subject:  b.subject,
+		Body:     b.body,
+		IsLatest: true,
+	})
+	return &gmail.Thread{ID: b.threadID, Messages: messages}
+}
+
+// Source returns a tracked sender source matching the email's From address
+func (b *EmailBuilder) Source() *gmail.EmailSource {
+	return &gmail.EmailSource{
+		Type:       gmail.SourceTypeSender,
+		Identifier: gmail.ExtractSenderEmail(b.from),
+		Name:       gmail.ExtractSenderName(b.from),
+		Enabled:    true,
+	}
+}
+
+// Content returns the email as the analyzers receive it
+func (b *EmailBuilder) Content() agent.EmailContent {
+	history := make([]agent.EmailThreadMessage, 0, len(b.earlier))
+	for _, msg := range b.earlierMessages() {
+		history = append(history, agent.EmailThreadMessage{
+			From:    msg.From,
+			Date:    msg.Date,
+			Subject: msg.Subject,
+			Body:    msg.Body,
+		})
+	}
+	return agent.EmailContent{
+		Subject:       b.subject,
+		From:          b.from,
+		To:            b.to,
+		Date:          b.receivedAt.Format(time.RFC1123Z),
+		Body:          b.body,
+		ThreadID:      b.threadID,
+		ThreadHistory: history,
+	}
+}
+
+// Build stores the email in the channel's message history
+func (b *EmailBuilder) Build(db *database.DB, channelID int64) (*database.SourceMessage, error) {
+	return db.StoreSourceMessage(
+		source.SourceTypeGmail,
+		channelID,
+		gmail.ExtractSenderEmail(b.from),
+		gmail.ExtractSenderName(b.from),
+		b.body,
+		b.subject,
+		b.receivedAt,
+	)
+}
+
+// MustBuild stores the email or panics
+func (b *EmailBuilder) MustBuild(db *database.DB, channelID int64) *database.SourceMessage {
+	msg, err := b.Build(db, channelID)
+	if err != nil {
+		panic(fmt.Sprintf("failed to build email: %v", err))
+	}
+	return msg
+}
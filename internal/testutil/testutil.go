@@ -3,13 +3,18 @@ package testutil
 import (
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
+	"github.com/omriShneor/project_alfred/internal/agent"
 	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/omriShneor/project_alfred/internal/clients"
 	"github.com/omriShneor/project_alfred/internal/database"
 	"github.com/omriShneor/project_alfred/internal/notify"
 	"github.com/omriShneor/project_alfred/internal/server"
 	"github.com/omriShneor/project_alfred/internal/sse"
+	"github.com/omriShneor/project_alfred/internal/webhook"
+	"github.com/omriShneor/project_alfred/internal/whatsapp/mockwhatsapp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,11 +27,20 @@ type TestServer struct {
 	TestUser   *database.TestUser
 	t          *testing.T
 
+	// NotifyService is wired to the server's per-user stream bus
+	NotifyService *notify.Service
+
 	// Mock clients
 	GCalMock      *MockGCalClient
 	GmailMock     *MockGmailClient
 	ClientManager *MockClientManager
 
+	// WhatsApp creates the simulated WhatsApp clients of WithSimulatedWhatsApp
+	WhatsApp *mockwhatsapp.Factory
+
+	eventAnalyzer    agent.EventAnalyzer
+	reminderAnalyzer agent.ReminderAnalyzer
+
 	// Deprecated: Use ClientManager.GetWhatsAppClient(userID) instead
 	WhatsAppMock *MockWhatsAppClient
 	// Deprecated: Use ClientManager.GetTelegramClient(userID) instead
@@ -73,10 +87,24 @@ func NewTestServer(t *testing.T, opts ...TestServerOption) *TestServer {
 	// Ensure notification service exists so push availability is true in tests.
 	// Expo push does not require credentials, so we can wire it unconditionally.
 	notifyService := notify.NewService(db, nil, notify.NewExpoPushNotifier())
+	notifyService.SetStreams(ts.Server.Streams())
+	notifyService.SetWebhooks(webhook.NewDispatcher(db))
+	ts.NotifyService = notifyService
 	ts.Server.InitializeClients(server.ClientsConfig{
-		NotifyService: notifyService,
+		NotifyService:    notifyService,
+		EventAnalyzer:    ts.eventAnalyzer,
+		ReminderAnalyzer: ts.reminderAnalyzer,
 	})
 
+	if ts.WhatsApp != nil {
+		dir := t.TempDir()
+		ts.Server.SetClientManager(clients.NewClientManager(db, &clients.ManagerConfig{
+			WhatsAppDBBasePath: filepath.Join(dir, "whatsapp.db"),
+			TelegramDBBasePath: filepath.Join(dir, "telegram.db"),
+			NewWhatsAppClient:  ts.WhatsApp.New,
+		}, notifyService, state))
+	}
+
 	// Always create MockClientManager for multi-user support
 	// Tests can use GetWhatsAppClient(userID) or GetTelegramClient(userID) on the manager
 	ts.ClientManager = NewMockClientManager(100)
@@ -134,6 +162,33 @@ func (ts *TestServer) GetTestUserTelegramClient() (*MockTelegramClient, error) {
 	return ts.GetTelegramClient(ts.TestUser.ID)
 }
 
+// WhatsAppFake returns the test user's simulated WhatsApp client, or nil before the
+// server has created it (it does on the first WhatsApp request)
+func (ts *TestServer) WhatsAppFake() *mockwhatsapp.Client {
+	if ts.WhatsApp == nil {
+		return nil
+	}
+	return ts.WhatsApp.Client(ts.TestUser.ID)
+}
+
+// WithSimulatedWhatsApp gives the server a ClientManager whose WhatsApp clients are
+// in-process mockwhatsapp fakes, so WhatsApp handlers, pairing and HistorySync run
+// without a phone
+func WithSimulatedWhatsApp() TestServerOption {
+	return func(ts *TestServer) {
+		ts.WhatsApp = mockwhatsapp.NewFactory()
+	}
+}
+
+// WithAnalyzers sets the event and reminder analyzers used for backfill, e.g.
+// mockagent analyzers. Either may be nil.
+func WithAnalyzers(event agent.EventAnalyzer, reminder agent.ReminderAnalyzer) TestServerOption {
+	return func(ts *TestServer) {
+		ts.eventAnalyzer = event
+		ts.reminderAnalyzer = reminder
+	}
+}
+
 // WithMockGCal enables Google Calendar mocking
 func WithMockGCal() TestServerOption {
 	return func(ts *TestServer) {
@@ -231,10 +286,24 @@ func NewTestServerWithUser(t *testing.T, email string) *TestServer {
 	// Ensure notification service exists so push availability is true in tests.
 	// Expo push does not require credentials, so we can wire it unconditionally.
 	notifyService := notify.NewService(db, nil, notify.NewExpoPushNotifier())
+	notifyService.SetStreams(ts.Server.Streams())
+	notifyService.SetWebhooks(webhook.NewDispatcher(db))
+	ts.NotifyService = notifyService
 	ts.Server.InitializeClients(server.ClientsConfig{
-		NotifyService: notifyService,
+		NotifyService:    notifyService,
+		EventAnalyzer:    ts.eventAnalyzer,
+		ReminderAnalyzer: ts.reminderAnalyzer,
 	})
 
+	if ts.WhatsApp != nil {
+		dir := t.TempDir()
+		ts.Server.SetClientManager(clients.NewClientManager(db, &clients.ManagerConfig{
+			WhatsAppDBBasePath: filepath.Join(dir, "whatsapp.db"),
+			TelegramDBBasePath: filepath.Join(dir, "telegram.db"),
+			NewWhatsAppClient:  ts.WhatsApp.New,
+		}, notifyService, state))
+	}
+
 	// Always create MockClientManager for multi-user support
 	ts.ClientManager = NewMockClientManager(100)
 
@@ -0,0 +1,390 @@
+// Package usage meters LLM analysis per user: it records the tokens and estimated
+// cost of every analysis and pauses analysis once a user's monthly budget is spent.
+package usage
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// BudgetNotifier is told the first time in a month that a user's budget runs out
+type BudgetNotifier interface {
+	NotifyLLMBudgetExceeded(ctx context.Context, userID int64, spentUSD, budgetUSD float64)
+}
+
+// Tracker wraps analyzers to meter their usage. Analyses whose context carries no
+// user (agent.WithUserID) run unmetered.
+type Tracker struct {
+	db            *database.DB
+	notifier      BudgetNotifier
+	defaultBudget float64 // USD per month for users without their own budget; 0 = unlimited
+	now           func() time.Time
+}
+
+// NewTracker creates a usage tracker. notifier may be nil.
+func NewTracker(db *database.DB, notifier BudgetNotifier, defaultBudget float64) *Tracker {
+	return &Tracker{
+		db:            db,
+		notifier:      notifier,
+		defaultBudget: defaultBudget,
+		now:           time.Now,
+	}
+}
+
+// DefaultBudget returns the monthly budget for users without their own setting
+func (t *Tracker) DefaultBudget() float64 {
+	return t.defaultBudget
+}
+
+// MonthStart returns the start of the UTC calendar month containing ts
+func MonthStart(ts time.Time) time.Time {
+	ts = ts.UTC()
+	return time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// effectiveBudget returns the user's monthly budget in USD (0 = unlimited)
+func (t *Tracker) effectiveBudget(userID int64) (float64, error) {
+	budget, err := t.db.GetLLMMonthlyBudget(userID)
+	if err != nil {
+		return 0, err
+	}
+	if budget == nil {
+		return t.defaultBudget, nil
+	}
+	return *budget, nil
+}
+
+// begin checks the user's budget and returns a context that collects the usage of
+// the analysis, plus a func that stores it. It returns agent.ErrBudgetExceeded when
+// the budget is spent.
+func (t *Tracker) begin(ctx context.Context, kind string) (context.Context, func(), error) {
+	userID, ok := agent.UserIDFromContext(ctx)
+	if !ok {
+		return ctx, func() {}, nil
+	}
+
+	if err := t.checkBudget(ctx, userID); err != nil {
+		return ctx, func() {}, err
+	}
+
+	var mu sync.Mutex
+	var model string
+	var total agent.UsageStats
+	ctx = agent.WithUsageRecorder(ctx, func(rec agent.UsageRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		model = rec.Model
+		total.Add(rec.Usage)
+	})
+
+	finish := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if total.TotalTokens == 0 {
+			return
+		}
+		err := t.db.RecordLLMUsage(database.LLMUsage{
+			UserID:       userID,
+			Agent:        kind,
+			Model:        model,
+			InputTokens:  total.InputTokens,
+			OutputTokens: total.OutputTokens,
+			CostUSD:      agent.EstimateCost(model, total),
+			CreatedAt:    t.now(),
+		})
+		if err != nil {
+			slog.Error("Usage: failed to record analysis", "kind", kind, "user_id", userID, "error", err)
+		}
+	}
+	return ctx, finish, nil
+}
+
+func (t *Tracker) checkBudget(ctx context.Context, userID int64) error {
+	budget, err := t.effectiveBudget(userID)
+	if err != nil {
+		// Don't block analysis on a lookup failure
+		slog.Error("Usage: failed to get budget", "user_id", userID, "error", err)
+		return nil
+	}
+	if budget <= 0 {
+		return nil
+	}
+
+	now := t.now()
+	spent, err := t.db.GetLLMSpend(userID, MonthStart(now))
+	if err != nil {
+		slog.Error("Usage: failed to get spend", "user_id", userID, "error", err)
+		return nil
+	}
+	if spent < budget {
+		return nil
+	}
+
+	first, err := t.db.MarkLLMBudgetNotified(userID, now.UTC().Format("2006-01"))
+	if err != nil {
+		slog.Error("Usage: failed to mark budget notification", "user_id", userID, "error", err)
+	}
+	if first {
+		slog.Info("Usage: user reached monthly budget, pausing analysis", "user_id", userID, "spent_usd", spent, "budget_usd", budget)
+		if t.notifier != nil {
+			t.notifier.NotifyLLMBudgetExceeded(ctx, userID, spent, budget)
+		}
+	}
+	return agent.ErrBudgetExceeded
+}
+
+// EventAnalyzer wraps an event analyzer so its analyses are metered
+func (t *Tracker) EventAnalyzer(inner agent.EventAnalyzer) agent.EventAnalyzer {
+	if inner == nil {
+		return nil
+	}
+	return &eventAnalyzer{tracker: t, inner: inner}
+}
+
+// ReminderAnalyzer wraps a reminder analyzer so its analyses are metered
+func (t *Tracker) ReminderAnalyzer(inner agent.ReminderAnalyzer) agent.ReminderAnalyzer {
+	if inner == nil {
+		return nil
+	}
+	return &reminderAnalyzer{tracker: t, inner: inner}
+}
+
+// TravelAnalyzer wraps a travel analyzer so its analyses are metered
+func (t *Tracker) TravelAnalyzer(inner agent.TravelAnalyzer) agent.TravelAnalyzer {
+	if inner == nil {
+		return nil
+	}
+	return &travelAnalyzer{tracker: t, inner: inner}
+}
+
+// BillAnalyzer wraps a bill analyzer so its analyses are metered
+func (t *Tracker) BillAnalyzer(inner agent.BillAnalyzer) agent.BillAnalyzer {
+	if inner == nil {
+		return nil
+	}
+	return &billAnalyzer{tracker: t, inner: inner}
+}
+
+// DeliveryAnalyzer wraps a delivery analyzer so its analyses are metered
+func (t *Tracker) DeliveryAnalyzer(inner agent.DeliveryAnalyzer) agent.DeliveryAnalyzer {
+	if inner == nil {
+		return nil
+	}
+	return &deliveryAnalyzer{tracker: t, inner: inner}
+}
+
+// OccasionAnalyzer wraps an occasion analyzer so its analyses are metered
+func (t *Tracker) OccasionAnalyzer(inner agent.OccasionAnalyzer) agent.OccasionAnalyzer {
+	if inner == nil {
+		return nil
+	}
+	return &occasionAnalyzer{tracker: t, inner: inner}
+}
+
+// Assistant wraps the assistant so its answers are metered
+func (t *Tracker) Assistant(inner agent.Assistant) agent.Assistant {
+	if inner == nil {
+		return nil
+	}
+	return &assistant{tracker: t, inner: inner}
+}
+
+type eventAnalyzer struct {
+	tracker *Tracker
+	inner   agent.EventAnalyzer
+}
+
+func (a *eventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "event")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeMessages(ctx, history, newMessage, existingEvents)
+}
+
+func (a *eventAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.EventAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "event")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeEmail(ctx, email)
+}
+
+func (a *eventAnalyzer) IsConfigured() bool {
+	return a.inner.IsConfigured()
+}
+
+type reminderAnalyzer struct {
+	tracker *Tracker
+	inner   agent.ReminderAnalyzer
+}
+
+func (a *reminderAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingReminders []database.Reminder,
+) (*agent.ReminderAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "reminder")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeMessages(ctx, history, newMessage, existingReminders)
+}
+
+func (a *reminderAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.ReminderAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "reminder")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeEmail(ctx, email)
+}
+
+func (a *reminderAnalyzer) IsConfigured() bool {
+	return a.inner.IsConfigured()
+}
+
+type travelAnalyzer struct {
+	tracker *Tracker
+	inner   agent.TravelAnalyzer
+}
+
+func (a *travelAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.TravelAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "travel")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeMessages(ctx, history, newMessage, existingEvents)
+}
+
+func (a *travelAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.TravelAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "travel")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeEmail(ctx, email)
+}
+
+func (a *travelAnalyzer) IsConfigured() bool {
+	return a.inner.IsConfigured()
+}
+
+type billAnalyzer struct {
+	tracker *Tracker
+	inner   agent.BillAnalyzer
+}
+
+func (a *billAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingReminders []database.Reminder,
+) (*agent.BillAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "bill")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeMessages(ctx, history, newMessage, existingReminders)
+}
+
+func (a *billAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.BillAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "bill")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeEmail(ctx, email)
+}
+
+func (a *billAnalyzer) IsConfigured() bool {
+	return a.inner.IsConfigured()
+}
+
+type deliveryAnalyzer struct {
+	tracker *Tracker
+	inner   agent.DeliveryAnalyzer
+}
+
+func (a *deliveryAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.DeliveryAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "delivery")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeEmail(ctx, email)
+}
+
+func (a *deliveryAnalyzer) IsConfigured() bool {
+	return a.inner.IsConfigured()
+}
+
+type occasionAnalyzer struct {
+	tracker *Tracker
+	inner   agent.OccasionAnalyzer
+}
+
+func (a *occasionAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+) (*agent.OccasionAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "occasion")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeMessages(ctx, history, newMessage)
+}
+
+func (a *occasionAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.OccasionAnalysis, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "occasion")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.AnalyzeEmail(ctx, email)
+}
+
+func (a *occasionAnalyzer) IsConfigured() bool {
+	return a.inner.IsConfigured()
+}
+
+type assistant struct {
+	tracker *Tracker
+	inner   agent.Assistant
+}
+
+func (a *assistant) Ask(ctx context.Context, question string, history []agent.ChatTurn) (*agent.AssistantReply, error) {
+	ctx, finish, err := a.tracker.begin(ctx, "assistant")
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+	return a.inner.Ask(ctx, question, history)
+}
+
+func (a *assistant) IsConfigured() bool {
+	return a.inner.IsConfigured()
+}
@@ -0,0 +1,116 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// fakeEventAnalyzer reports fixed usage for every analysis, like a real agent would
+type fakeEventAnalyzer struct {
+	calls int
+	usage agent.UsageStats
+}
+
+func (f *fakeEventAnalyzer) AnalyzeMessages(
+	ctx context.Context,
+	history []database.MessageRecord,
+	newMessage database.MessageRecord,
+	existingEvents []database.CalendarEvent,
+) (*agent.EventAnalysis, error) {
+	f.calls++
+	// The language policy can re-run the agent; both runs count toward one analysis
+	agent.ReportUsage(ctx, agent.UsageRecord{Agent: "event-scheduler", Model: "claude-sonnet-4-20250514", Usage: f.usage})
+	agent.ReportUsage(ctx, agent.UsageRecord{Agent: "event-scheduler", Model: "claude-sonnet-4-20250514", Usage: f.usage})
+	return &agent.EventAnalysis{Action: "none"}, nil
+}
+
+func (f *fakeEventAnalyzer) AnalyzeEmail(ctx context.Context, email agent.EmailContent) (*agent.EventAnalysis, error) {
+	return f.AnalyzeMessages(ctx, nil, database.MessageRecord{}, nil)
+}
+
+func (f *fakeEventAnalyzer) IsConfigured() bool { return true }
+
+type recordingNotifier struct {
+	calls []float64
+}
+
+func (n *recordingNotifier) NotifyLLMBudgetExceeded(ctx context.Context, userID int64, spentUSD, budgetUSD float64) {
+	n.calls = append(n.calls, spentUSD)
+}
+
+func TestTrackerRecordsUsagePerAnalysis(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+
+	inner := &fakeEventAnalyzer{usage: agent.UsageStats{InputTokens: 1000, OutputTokens: 100, TotalTokens: 1100}}
+	tracker := NewTracker(db, nil, 0)
+	analyzer := tracker.EventAnalyzer(inner)
+
+	ctx := agent.WithUserID(context.Background(), user.ID)
+	_, err := analyzer.AnalyzeMessages(ctx, nil, database.MessageRecord{}, nil)
+	require.NoError(t, err)
+
+	// Without a user the analysis runs but is not recorded
+	_, err = analyzer.AnalyzeMessages(context.Background(), nil, database.MessageRecord{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.calls)
+
+	now := time.Now()
+	summary, err := db.GetLLMUsageSummary(user.ID, MonthStart(now), MonthStart(now).AddDate(0, 1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Analyses)
+	assert.Equal(t, 2000, summary.InputTokens)
+	assert.Equal(t, 200, summary.OutputTokens)
+	// 2000 input at $3/M + 200 output at $15/M
+	assert.InDelta(t, 0.009, summary.CostUSD, 1e-9)
+	require.Len(t, summary.ByModel, 1)
+	assert.Equal(t, "claude-sonnet-4-20250514", summary.ByModel[0].Model)
+}
+
+func TestTrackerPausesAnalysisOverBudget(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	other := database.CreateTestUser(t, db)
+
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, db.RecordLLMUsage(database.LLMUsage{UserID: user.ID, Agent: "event", Model: "gpt-4o", CostUSD: 4, CreatedAt: now.AddDate(0, 0, -2)}))
+	// Last month's spend doesn't count toward this month's budget
+	require.NoError(t, db.RecordLLMUsage(database.LLMUsage{UserID: user.ID, Agent: "event", Model: "gpt-4o", CostUSD: 50, CreatedAt: now.AddDate(0, -1, 0)}))
+
+	inner := &fakeEventAnalyzer{}
+	notifier := &recordingNotifier{}
+	tracker := NewTracker(db, notifier, 5)
+	tracker.now = func() time.Time { return now }
+	analyzer := tracker.EventAnalyzer(inner)
+
+	// $4 of the $5 default spent: still allowed
+	_, err := analyzer.AnalyzeMessages(agent.WithUserID(context.Background(), user.ID), nil, database.MessageRecord{}, nil)
+	require.NoError(t, err)
+
+	budget := 3.0
+	require.NoError(t, db.SetLLMMonthlyBudget(user.ID, &budget))
+	for i := 0; i < 2; i++ {
+		_, err = analyzer.AnalyzeMessages(agent.WithUserID(context.Background(), user.ID), nil, database.MessageRecord{}, nil)
+		assert.True(t, errors.Is(err, agent.ErrBudgetExceeded))
+	}
+	assert.Equal(t, 1, inner.calls)
+	assert.Equal(t, []float64{4}, notifier.calls, "notified once per month")
+
+	// Other users are unaffected
+	_, err = analyzer.AnalyzeMessages(agent.WithUserID(context.Background(), other.ID), nil, database.MessageRecord{}, nil)
+	require.NoError(t, err)
+
+	// Unlimited budget resumes analysis
+	unlimited := 0.0
+	require.NoError(t, db.SetLLMMonthlyBudget(user.ID, &unlimited))
+	_, err = analyzer.AnalyzeMessages(agent.WithUserID(context.Background(), user.ID), nil, database.MessageRecord{}, nil)
+	require.NoError(t, err)
+}
@@ -0,0 +1,307 @@
+// Package validate checks request structs against their `validate` struct tags, so
+// handlers declare what a body needs instead of checking fields one by one:
+//
+//	var req struct {
+//		Type string `json:"type" validate:"required,oneof=sender"`
+//		Name string `json:"name" validate:"required,max=200"`
+//	}
+//	if errs := validate.Struct(req); errs != nil { ... }
+//
+// Rules, comma-separated and checked in order:
+//
+//	required            not the zero value; strings must have non-space characters
+//	required_if=F v     required when field F (Go name) formats as v
+//	required_without=F  required when field F (Go name) is the zero value ("a or b is required")
+//	omitempty           skip the remaining rules when the value is the zero value
+//	min=n, max=n        length for strings (in characters), slices and maps; value for numbers
+//	oneof=a b c         one of the space-separated values
+//
+// Pointers are checked through: nil fails required and skips the other rules. Nested
+// structs and slices of structs are checked too, with their errors' fields prefixed.
+// An unknown rule is a programming error and panics.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError is one field that failed a rule
+type FieldError struct {
+	// Field is the field's JSON name, dotted for nested fields ("items[0].name")
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Errors lists every failed field of a struct
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+type rule struct {
+	name  string
+	param string
+}
+
+type field struct {
+	index   int
+	name    string // JSON name
+	rules   []rule
+	nested  bool // Struct, pointer to struct or slice of structs to check recursively
+	isSlice bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+// Struct validates v, a struct or pointer to one, returning nil when every rule passes
+func Struct(v any) Errors {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: Struct called with %s", value.Kind()))
+	}
+
+	var errs Errors
+	validateStruct(value, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateStruct(value reflect.Value, prefix string, errs *Errors) {
+	for _, f := range fieldsOf(value.Type()) {
+		fieldValue := value.Field(f.index)
+		name := prefix + f.name
+		if !checkRules(value, fieldValue, f, name, errs) {
+			continue
+		}
+		if !f.nested {
+			continue
+		}
+
+		if f.isSlice {
+			for i := 0; i < fieldValue.Len(); i++ {
+				if elem, ok := structValue(fieldValue.Index(i)); ok {
+					validateStruct(elem, fmt.Sprintf("%s[%d].", name, i), errs)
+				}
+			}
+		} else if elem, ok := structValue(fieldValue); ok {
+			validateStruct(elem, name+".", errs)
+		}
+	}
+}
+
+// checkRules applies a field's rules, reporting the first failure. It returns false when
+// the field failed or omitempty skipped it.
+func checkRules(parent, value reflect.Value, f field, name string, errs *Errors) bool {
+	for _, r := range f.rules {
+		switch r.name {
+		case "required":
+			if isMissing(value) {
+				*errs = append(*errs, FieldError{Field: name, Rule: r.name, Message: name + " is required"})
+				return false
+			}
+		case "required_if":
+			other, want, _ := strings.Cut(r.param, " ")
+			if fmt.Sprint(indirect(parent.FieldByName(other)).Interface()) == want && isMissing(value) {
+				*errs = append(*errs, FieldError{Field: name, Rule: r.name, Param: r.param, Message: name + " is required"})
+				return false
+			}
+		case "required_without":
+			if isMissing(parent.FieldByName(r.param)) && isMissing(value) {
+				other, _ := parent.Type().FieldByName(r.param)
+				message := fmt.Sprintf("%s or %s is required", name, jsonName(other))
+				*errs = append(*errs, FieldError{Field: name, Rule: r.name, Param: r.param, Message: message})
+				return false
+			}
+		case "omitempty":
+			if isMissing(value) {
+				return false
+			}
+		default:
+			elem := indirect(value)
+			if !elem.IsValid() {
+				return false
+			}
+			if message, ok := checkValue(elem, r, name); !ok {
+				*errs = append(*errs, FieldError{Field: name, Rule: r.name, Param: r.param, Message: message})
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkValue applies a rule that inspects the value itself
+func checkValue(value reflect.Value, r rule, name string) (string, bool) {
+	switch r.name {
+	case "min", "max":
+		limit, _ := strconv.ParseFloat(r.param, 64)
+		size, unit := measure(value)
+		if (r.name == "min" && size < limit) || (r.name == "max" && size > limit) {
+			word := "at least"
+			if r.name == "max" {
+				word = "at most"
+			}
+			if unit == "" {
+				return fmt.Sprintf("%s must be %s %s", name, word, r.param), false
+			}
+			return fmt.Sprintf("%s must be %s %s %s", name, word, r.param, unit), false
+		}
+	case "oneof":
+		options := strings.Fields(r.param)
+		actual := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if actual == option {
+				return "", true
+			}
+		}
+		return fmt.Sprintf("%s must be one of: %s", name, strings.Join(options, ", ")), false
+	}
+	return "", true
+}
+
+// measure returns what min and max compare: a length with its unit, or a number
+func measure(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), "items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), ""
+	}
+	panic(fmt.Sprintf("validate: min/max on %s", value.Kind()))
+}
+
+// isMissing reports whether a value counts as absent for required
+func isMissing(value reflect.Value) bool {
+	if !value.IsValid() {
+		return true
+	}
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return value.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+func structValue(value reflect.Value) (reflect.Value, bool) {
+	value = indirect(value)
+	return value, value.IsValid() && value.Kind() == reflect.Struct
+}
+
+// fieldsOf parses the validate tags of a struct type once
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		f := field{index: i, name: jsonName(sf), rules: parseRules(t, sf)}
+
+		elem := sf.Type
+		if elem.Kind() == reflect.Slice {
+			f.isSlice = true
+			elem = elem.Elem()
+		}
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		f.nested = elem.Kind() == reflect.Struct && hasRules(elem)
+
+		if len(f.rules) > 0 || f.nested {
+			fields = append(fields, f)
+		}
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// hasRules reports whether a struct type or any struct it nests has validate tags
+func hasRules(t reflect.Type) bool {
+	return len(fieldsOf(t)) > 0
+}
+
+func parseRules(t reflect.Type, sf reflect.StructField) []rule {
+	tag := sf.Tag.Get("validate")
+	if tag == "" || tag == "-" {
+		return nil
+	}
+
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "required", "omitempty":
+		case "required_if", "required_without":
+			other, _, _ := strings.Cut(param, " ")
+			if _, ok := t.FieldByName(other); !ok {
+				panic(fmt.Sprintf("validate: %s.%s: %s refers to unknown field %q", t, sf.Name, name, other))
+			}
+		case "min", "max":
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				panic(fmt.Sprintf("validate: %s.%s: invalid %s %q", t, sf.Name, name, param))
+			}
+		case "oneof":
+			if strings.TrimSpace(param) == "" {
+				panic(fmt.Sprintf("validate: %s.%s: oneof needs values", t, sf.Name))
+			}
+		default:
+			panic(fmt.Sprintf("validate: %s.%s: unknown rule %q", t, sf.Name, name))
+		}
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules
+}
+
+// jsonName is the field's name in request bodies
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
@@ -0,0 +1,88 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type request struct {
+	Type     string   `json:"type" validate:"required,oneof=contact sender"`
+	Name     string   `json:"name" validate:"required,max=5"`
+	Phone    string   `json:"phone_number" validate:"required_without=Username"`
+	Username string   `json:"username"`
+	Enabled  bool     `json:"enabled"`
+	Address  string   `json:"address" validate:"required_if=Enabled true"`
+	Priority string   `json:"priority" validate:"omitempty,oneof=low high"`
+	Limit    *int     `json:"limit" validate:"omitempty,min=1,max=100"`
+	Items    []item   `json:"items" validate:"max=2"`
+	Nested   *item    `json:"nested"`
+	Tags     []string `json:"tags"`
+}
+
+func TestStructValid(t *testing.T) {
+	limit := 10
+	req := request{Type: "sender", Name: "Alice", Phone: "+1555", Limit: &limit, Items: []item{{Name: "a"}}}
+	assert.Nil(t, Struct(req))
+	assert.Nil(t, Struct(&req))
+	assert.Nil(t, Struct((*request)(nil)))
+}
+
+func TestStructErrors(t *testing.T) {
+	limit := 0
+	errs := Struct(request{
+		Type:     "group",
+		Name:     "  ",
+		Enabled:  true,
+		Priority: "urgent",
+		Limit:    &limit,
+		Items:    []item{{Name: "a"}, {}},
+		Nested:   &item{},
+	})
+	require.NotNil(t, errs)
+
+	fields := make(map[string]FieldError)
+	for _, fieldErr := range errs {
+		fields[fieldErr.Field] = fieldErr
+	}
+	assert.Len(t, fields, 8)
+	assert.Equal(t, "type must be one of: contact, sender", fields["type"].Message)
+	assert.Equal(t, "oneof", fields["type"].Rule)
+	assert.Equal(t, "name is required", fields["name"].Message)
+	assert.Equal(t, "phone_number or username is required", fields["phone_number"].Message)
+	assert.Equal(t, "address is required", fields["address"].Message)
+	assert.Equal(t, "priority must be one of: low, high", fields["priority"].Message)
+	assert.Equal(t, "limit must be at least 1", fields["limit"].Message)
+	assert.Equal(t, "items[1].name is required", fields["items[1].name"].Message)
+	assert.Equal(t, "nested.name is required", fields["nested.name"].Message)
+	assert.Contains(t, errs.Error(), "name is required; ")
+}
+
+func TestStructLengths(t *testing.T) {
+	errs := Struct(request{Type: "contact", Name: "Zoë Smith", Username: "zoe", Items: make([]item, 3)})
+	require.Len(t, errs, 2)
+	assert.Equal(t, FieldError{Field: "name", Rule: "max", Param: "5", Message: "name must be at most 5 characters"}, errs[0])
+	assert.Equal(t, "items must be at most 2 items", errs[1].Message)
+
+	// Multi-byte characters count once
+	assert.Nil(t, Struct(request{Type: "contact", Name: "Zoëëë", Username: "zoe"}))
+}
+
+func TestStructInvalidTags(t *testing.T) {
+	assert.Panics(t, func() {
+		Struct(struct {
+			Name string `validate:"requird"`
+		}{})
+	})
+	assert.Panics(t, func() {
+		Struct(struct {
+			Name string `validate:"required_without=Missing"`
+		}{})
+	})
+	assert.Panics(t, func() { Struct("not a struct") })
+}
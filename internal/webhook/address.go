@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrDisallowedAddress is returned for webhook URLs that point at this host or its
+// private network. Webhook URLs are user-supplied, so they must not reach internal services.
+var ErrDisallowedAddress = errors.New("webhook URL must not point at a loopback, private, link-local or unspecified address")
+
+// IsDisallowedIP reports whether ip is loopback, private, link-local or unspecified
+func IsDisallowedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// ValidateURL requires an absolute http(s) URL whose host resolves only to allowed
+// addresses, returning the trimmed URL. Deliveries check the address again when they
+// connect, since DNS can change after the webhook is saved.
+func ValidateURL(ctx context.Context, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", fmt.Errorf("url must be an absolute http or https URL")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return "", fmt.Errorf("url host %q could not be resolved", parsed.Hostname())
+	}
+	for _, addr := range addrs {
+		if IsDisallowedIP(addr.IP) {
+			return "", ErrDisallowedAddress
+		}
+	}
+	return raw, nil
+}
+
+// checkDialAddress is a net.Dialer Control function that refuses connections to
+// disallowed addresses, after DNS resolution
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsDisallowedIP(ip) {
+		return ErrDisallowedAddress
+	}
+	return nil
+}
+
+// newHTTPClient returns the client deliveries are sent with. Redirects aren't followed
+// (a 3xx response is a failed delivery) and no proxy is used, so every connection goes
+// through control; nil control allows any address (tests).
+func newHTTPClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: deliveryTimeout,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: deliveryTimeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+)
+
+// Event types users can subscribe a webhook to
+const (
+	EventCreated   = "event.created"
+	EventConfirmed = "event.confirmed"
+	ReminderDue    = "reminder.due"
+)
+
+// EventTypes lists every supported event type
+var EventTypes = []string{EventCreated, EventConfirmed, ReminderDue}
+
+// Request headers sent with every delivery
+const (
+	HeaderEvent     = "X-Alfred-Event"
+	HeaderDelivery  = "X-Alfred-Delivery"
+	HeaderSignature = "X-Alfred-Signature"
+)
+
+const (
+	defaultPollInterval = 30 * time.Second
+	deliveryBatchSize   = 20
+	deliveryTimeout     = 10 * time.Second
+)
+
+// retryBackoff is the delay before each retry; a delivery is marked failed
+// once it has been attempted len(retryBackoff)+1 times.
+var retryBackoff = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+// maxAttempts is the number of delivery attempts before a delivery is marked failed
+var maxAttempts = len(retryBackoff) + 1
+
+// IsValidEventType reports whether eventType is a supported webhook event type
+func IsValidEventType(eventType string) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateSecret returns a new random signing secret
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign computes the X-Alfred-Signature header value for a payload:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed by the secret>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// envelope is the JSON body POSTed to webhook URLs
+type envelope struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Dispatcher queues webhook deliveries and sends them from a background worker
+type Dispatcher struct {
+	db         *database.DB
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewDispatcher creates a webhook dispatcher
+func NewDispatcher(db *database.DB) *Dispatcher {
+	return &Dispatcher{
+		db:         db,
+		httpClient: newHTTPClient(checkDialAddress),
+		now:        time.Now,
+	}
+}
+
+// Enqueue queues data for every enabled webhook of the user subscribed to eventType.
+// Errors are logged but don't fail the caller.
+func (d *Dispatcher) Enqueue(userID int64, eventType string, data any) {
+	if d == nil {
+		return
+	}
+
+	now := d.now()
+	payload, err := json.Marshal(envelope{Type: eventType, CreatedAt: now.UTC(), Data: data})
+	if err != nil {
+		slog.Error("Webhooks: Failed to marshal payload", "event_type", eventType, "error", err)
+		return
+	}
+
+	if _, err := d.db.EnqueueWebhookDeliveries(userID, eventType, string(payload), now); err != nil {
+		slog.Error("Webhooks: Failed to enqueue", "event_type", eventType, "user_id", userID, "error", err)
+	}
+}
+
+// Start polls for due deliveries until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context, pollInterval time.Duration) {
+	if d == nil || d.db == nil {
+		return
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		d.processDueDeliveries(ctx)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.processDueDeliveries(ctx)
+			}
+		}
+	}()
+}
+
+func (d *Dispatcher) processDueDeliveries(ctx context.Context) {
+	deliveries, err := d.db.GetDueWebhookDeliveries(d.now(), deliveryBatchSize)
+	if err != nil {
+		slog.Error("Webhooks: Failed to fetch due deliveries", "error", err)
+		return
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		d.deliver(ctx, &deliveries[i])
+	}
+}
+
+// deliver makes one attempt at a delivery and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery *database.WebhookDelivery) {
+	attempt := database.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts + 1,
+	}
+
+	started := d.now()
+	statusCode, err := d.post(ctx, delivery, started)
+	attempt.DurationMs = d.now().Sub(started).Milliseconds()
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+
+	status := database.WebhookDeliverySucceeded
+	var nextAttemptAt *time.Time
+	if err != nil {
+		attempt.Error = err.Error()
+		if attempt.Attempt >= maxAttempts {
+			status = database.WebhookDeliveryFailed
+			slog.Error("Webhooks: Delivery failed", "delivery_id", delivery.ID, "attempt", attempt.Attempt, "error", err)
+		} else {
+			status = database.WebhookDeliveryPending
+			next := d.now().Add(retryBackoff[attempt.Attempt-1])
+			nextAttemptAt = &next
+		}
+	}
+
+	if err := d.db.RecordWebhookDeliveryAttempt(delivery.ID, attempt, status, nextAttemptAt); err != nil {
+		slog.Error("Webhooks: Failed to record attempt", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// post sends the signed payload, returning the response status code (0 if no response)
+// and an error for transport failures or non-2xx responses. The response body is never
+// recorded, so a webhook can't be used to read what an endpoint returns.
+func (d *Dispatcher) post(ctx context.Context, delivery *database.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Alfred-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, now, body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
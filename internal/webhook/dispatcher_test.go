@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts an endpoint that answers with the given status codes in order,
+// repeating the last one, and records every request
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var received []receivedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, receivedRequest{header: r.Header.Clone(), body: body})
+		status := statuses[min(len(received), len(statuses))-1]
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedRequest(nil), received...)
+	}
+}
+
+// newTestDispatcher returns a dispatcher that may deliver to the loopback test receivers
+func newTestDispatcher(db *database.DB) *Dispatcher {
+	d := NewDispatcher(db)
+	d.httpClient = newHTTPClient(nil)
+	return d
+}
+
+func TestSign(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	sig := Sign("whsec_test", ts, []byte(`{"a":1}`))
+
+	assert.Equal(t, "t=1700000000,v1=", sig[:16])
+	assert.Equal(t, sig, Sign("whsec_test", ts, []byte(`{"a":1}`)))
+	assert.NotEqual(t, sig, Sign("whsec_other", ts, []byte(`{"a":1}`)))
+	assert.NotEqual(t, sig, Sign("whsec_test", ts, []byte(`{"a":2}`)))
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	receiver, received := newReceiver(t, http.StatusOK)
+
+	hook, err := db.CreateWebhook(user.ID, receiver.URL, "whsec_test", []string{EventCreated})
+	require.NoError(t, err)
+
+	d := newTestDispatcher(db)
+	d.Enqueue(user.ID, EventCreated, map[string]any{"id": 7, "title": "Dinner"})
+	d.Enqueue(user.ID, ReminderDue, map[string]any{"id": 8})
+	d.processDueDeliveries(context.Background())
+
+	requests := received()
+	require.Len(t, requests, 1)
+	req := requests[0]
+
+	assert.Equal(t, EventCreated, req.header.Get(HeaderEvent))
+	assert.NotEmpty(t, req.header.Get(HeaderDelivery))
+
+	var body struct {
+		Type string         `json:"type"`
+		Data map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(req.body, &body))
+	assert.Equal(t, EventCreated, body.Type)
+	assert.Equal(t, "Dinner", body.Data["title"])
+
+	// Receivers verify the signature by recomputing it over the raw body
+	signature := req.header.Get(HeaderSignature)
+	var unix int64
+	_, err = fmt.Sscanf(signature, "t=%d,", &unix)
+	require.NoError(t, err)
+	assert.Equal(t, Sign("whsec_test", time.Unix(unix, 0), req.body), signature)
+
+	deliveries, err := db.ListWebhookDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, database.WebhookDeliverySucceeded, deliveries[0].Status)
+	require.Len(t, deliveries[0].AttemptLog, 1)
+	assert.Equal(t, http.StatusOK, *deliveries[0].AttemptLog[0].StatusCode)
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	receiver, received := newReceiver(t, http.StatusInternalServerError, http.StatusOK)
+
+	hook, err := db.CreateWebhook(user.ID, receiver.URL, "whsec_test", []string{ReminderDue})
+	require.NoError(t, err)
+
+	now := time.Now()
+	d := newTestDispatcher(db)
+	d.now = func() time.Time { return now }
+
+	d.Enqueue(user.ID, ReminderDue, map[string]any{"id": 1})
+	d.processDueDeliveries(context.Background())
+
+	deliveries, err := db.ListWebhookDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, database.WebhookDeliveryPending, deliveries[0].Status)
+	require.NotNil(t, deliveries[0].NextAttemptAt)
+	assert.WithinDuration(t, now.Add(retryBackoff[0]), *deliveries[0].NextAttemptAt, time.Second)
+	assert.Equal(t, "endpoint returned status 500", deliveries[0].LastError)
+
+	// Not due yet
+	d.processDueDeliveries(context.Background())
+	assert.Len(t, received(), 1)
+
+	now = now.Add(retryBackoff[0] + time.Second)
+	d.processDueDeliveries(context.Background())
+	assert.Len(t, received(), 2)
+
+	deliveries, err = db.ListWebhookDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, database.WebhookDeliverySucceeded, deliveries[0].Status)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.Len(t, deliveries[0].AttemptLog, 2)
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	receiver, received := newReceiver(t, http.StatusBadGateway)
+
+	hook, err := db.CreateWebhook(user.ID, receiver.URL, "whsec_test", []string{EventConfirmed})
+	require.NoError(t, err)
+
+	now := time.Now()
+	d := newTestDispatcher(db)
+	d.now = func() time.Time { return now }
+	d.Enqueue(user.ID, EventConfirmed, map[string]any{"id": 1})
+
+	for i := 0; i < maxAttempts+2; i++ {
+		d.processDueDeliveries(context.Background())
+		now = now.Add(24 * time.Hour)
+	}
+
+	assert.Len(t, received(), maxAttempts)
+
+	deliveries, err := db.ListWebhookDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, database.WebhookDeliveryFailed, deliveries[0].Status)
+	assert.Equal(t, maxAttempts, deliveries[0].Attempts)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+}
+
+func TestDispatcherRefusesInternalAddresses(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	receiver, received := newReceiver(t, http.StatusOK)
+
+	hook, err := db.CreateWebhook(user.ID, receiver.URL, "whsec_test", []string{EventCreated})
+	require.NoError(t, err)
+
+	d := NewDispatcher(db)
+	d.Enqueue(user.ID, EventCreated, map[string]any{"id": 1})
+	d.processDueDeliveries(context.Background())
+
+	assert.Empty(t, received())
+	deliveries, err := db.ListWebhookDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, database.WebhookDeliveryPending, deliveries[0].Status)
+	assert.Contains(t, deliveries[0].LastError, ErrDisallowedAddress.Error())
+}
+
+func TestDispatcherDoesNotFollowRedirects(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	target, received := newReceiver(t, http.StatusOK)
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", target.URL)
+		w.WriteHeader(http.StatusTemporaryRedirect)
+		_, _ = w.Write([]byte("internal details"))
+	}))
+	t.Cleanup(redirect.Close)
+
+	hook, err := db.CreateWebhook(user.ID, redirect.URL, "whsec_test", []string{EventCreated})
+	require.NoError(t, err)
+
+	d := newTestDispatcher(db)
+	d.Enqueue(user.ID, EventCreated, map[string]any{"id": 1})
+	d.processDueDeliveries(context.Background())
+
+	assert.Empty(t, received())
+	deliveries, err := db.ListWebhookDeliveries(hook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "endpoint returned status 307", deliveries[0].LastError)
+	require.Len(t, deliveries[0].AttemptLog, 1)
+	assert.Equal(t, http.StatusTemporaryRedirect, *deliveries[0].AttemptLog[0].StatusCode)
+	assert.Equal(t, "endpoint returned status 307", deliveries[0].AttemptLog[0].Error)
+}
+
+func TestValidateURL(t *testing.T) {
+	ctx := context.Background()
+
+	for _, raw := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://10.1.2.3/hook",
+		"http://192.168.0.10/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://0.0.0.0/hook",
+	} {
+		_, err := ValidateURL(ctx, raw)
+		assert.ErrorIs(t, err, ErrDisallowedAddress, raw)
+	}
+
+	for _, raw := range []string{"ftp://example.com/hook", "/relative", "https://"} {
+		_, err := ValidateURL(ctx, raw)
+		assert.Error(t, err, raw)
+	}
+
+	hookURL, err := ValidateURL(ctx, "  https://93.184.215.14/hook ")
+	require.NoError(t, err)
+	assert.Equal(t, "https://93.184.215.14/hook", hookURL)
+}
+
+func TestIsDisallowedIP(t *testing.T) {
+	for _, raw := range []string{"127.0.0.1", "::1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "169.254.169.254", "fe80::1", "fd00::1", "0.0.0.0", "::", "::ffff:127.0.0.1"} {
+		assert.True(t, IsDisallowedIP(net.ParseIP(raw)), raw)
+	}
+	for _, raw := range []string{"93.184.215.14", "2606:2800:220:1::1"} {
+		assert.False(t, IsDisallowedIP(net.ParseIP(raw)), raw)
+	}
+}
@@ -0,0 +1,44 @@
+package whatsapp
+
+import (
+	"context"
+
+	"github.com/omriShneor/project_alfred/internal/sse"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// Account is the per-user WhatsApp client surface used by the ClientManager and the
+// server. *Client implements it with whatsmeow; mockwhatsapp provides an in-process
+// fake for integration tests.
+type Account interface {
+	IsLoggedIn() bool
+	IsConnected() bool
+	// Connect opens the connection of an already paired account
+	Connect() error
+	// DeviceJID is the linked device's JID, or "" before pairing
+	DeviceJID() string
+	PairWithPhone(ctx context.Context, phone string, state *sse.State) (string, error)
+	Reconnect(ctx context.Context, state *sse.State)
+	Disconnect()
+	Logout() error
+	// Close releases the session store once the client is no longer used
+	Close() error
+
+	GetDiscoverableChannels() ([]DiscoverableChannel, error)
+	ContactLister
+	// DownloadMedia fetches the file of a message attachment by its kind and SourceRef
+	DownloadMedia(ctx context.Context, kind, sourceRef string) ([]byte, error)
+
+	SetUserID(userID int64)
+	SetHistorySyncBackfillHook(hook HistorySyncBackfillHook)
+	SetHistorySyncProgressHook(hook HistorySyncProgressHook)
+	SetSelfChatCommandHook(hook SelfChatCommandHook)
+}
+
+// ContactLister reads the account's synced contacts
+type ContactLister interface {
+	GetAllContacts(ctx context.Context) (map[types.JID]types.ContactInfo, error)
+	GetContact(ctx context.Context, jid types.JID) (types.ContactInfo, error)
+}
+
+var _ Account = (*Client)(nil)
@@ -2,10 +2,13 @@ package whatsapp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/omriShneor/project_alfred/internal/notify"
+	"github.com/omriShneor/project_alfred/internal/sessionstore"
 	"github.com/omriShneor/project_alfred/internal/sse"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store"
@@ -14,15 +17,31 @@ import (
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
+// sessionKeyTables are whatsmeow's identity, pre-key and Signal session tables. Losing
+// their latest rows in a crash leaves the device out of step with its contacts, so an
+// encrypted store can flush them on commit.
+var sessionKeyTables = []string{
+	"whatsmeow_device",
+	"whatsmeow_identity_keys",
+	"whatsmeow_pre_keys",
+	"whatsmeow_sessions",
+	"whatsmeow_sender_keys",
+}
+
 type Client struct {
 	UserID        int64 // User who owns this client (for multi-user support)
 	WAClient      *whatsmeow.Client
 	handler       *Handler
 	container     *sqlstore.Container
+	sessionDB     *sessionstore.SQLite // Encrypted session store, nil when stored in plaintext
 	notifyService *notify.Service
 }
 
-func NewClient(handler *Handler, dbPath string, preferredDeviceJID string, notifyService *notify.Service) (*Client, error) {
+// NewClient opens the whatsmeow session store at dbPath. With a sessionCipher the store
+// is kept in memory and persisted encrypted; a plaintext store is encrypted on open.
+// flushOnCommit persists the encrypted store's key and session changes as they commit
+// rather than every sessionstore.FlushInterval.
+func NewClient(handler *Handler, dbPath string, preferredDeviceJID string, sessionCipher sessionstore.Cipher, flushOnCommit bool, notifyService *notify.Service) (*Client, error) {
 	dbLog := waLog.Stdout("Database", "DEBUG", true)
 	clientLog := waLog.Stdout("Client", "DEBUG", true)
 
@@ -32,13 +51,16 @@ func NewClient(handler *Handler, dbPath string, preferredDeviceJID string, notif
 	store.DeviceProps.RequireFullSync = boolPtr(false)
 
 	ctx := context.Background()
-	container, err := sqlstore.New(ctx, "sqlite3", "file:"+dbPath+"?_foreign_keys=on", dbLog)
+	container, sessionDB, err := openContainer(ctx, dbPath, sessionCipher, flushOnCommit, dbLog)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create database: %w", err)
+		return nil, err
 	}
 
 	deviceStore, err := getDeviceStore(ctx, container, preferredDeviceJID)
 	if err != nil {
+		if sessionDB != nil {
+			sessionDB.Close()
+		}
 		return nil, fmt.Errorf("failed to get device store: %w", err)
 	}
 
@@ -48,6 +70,7 @@ func NewClient(handler *Handler, dbPath string, preferredDeviceJID string, notif
 		WAClient:      waClient,
 		handler:       handler,
 		container:     container,
+		sessionDB:     sessionDB,
 		notifyService: notifyService,
 	}
 
@@ -59,6 +82,30 @@ func NewClient(handler *Handler, dbPath string, preferredDeviceJID string, notif
 	return c, nil
 }
 
+func openContainer(ctx context.Context, dbPath string, sessionCipher sessionstore.Cipher, flushOnCommit bool, dbLog waLog.Logger) (*sqlstore.Container, *sessionstore.SQLite, error) {
+	if sessionCipher == nil {
+		container, err := sqlstore.New(ctx, "sqlite3", "file:"+dbPath+"?_foreign_keys=on", dbLog)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create database: %w", err)
+		}
+		return container, nil, nil
+	}
+
+	sessionDB, err := sessionstore.OpenSQLite(dbPath, sessionCipher)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open encrypted database: %w", err)
+	}
+	if flushOnCommit {
+		sessionDB.FlushOnCommit(sessionKeyTables...)
+	}
+	container := sqlstore.NewWithDB(sessionDB.DB(), "sqlite3", dbLog)
+	if err := container.Upgrade(ctx); err != nil {
+		sessionDB.Close()
+		return nil, nil, fmt.Errorf("failed to upgrade database: %w", err)
+	}
+	return container, sessionDB, nil
+}
+
 func getDeviceStore(ctx context.Context, container *sqlstore.Container, preferredDeviceJID string) (*store.Device, error) {
 	if preferredDeviceJID != "" {
 		jid, err := types.ParseJID(preferredDeviceJID)
@@ -80,7 +127,15 @@ func getDeviceStore(ctx context.Context, container *sqlstore.Container, preferre
 // The session is preserved so the user remains authenticated on restart.
 func (c *Client) Disconnect() {
 	c.WAClient.Disconnect()
-	fmt.Println("WhatsApp disconnected (session preserved)")
+	slog.Info("WhatsApp disconnected (session preserved)")
+}
+
+// Close writes the session store to disk and closes it. The client can't be used after.
+func (c *Client) Close() error {
+	if c.sessionDB == nil {
+		return nil
+	}
+	return c.sessionDB.Close()
 }
 
 // Logout explicitly logs out from WhatsApp and clears the session.
@@ -101,7 +156,7 @@ func (c *Client) Logout() error {
 		return fmt.Errorf("logout failed: %w", err)
 	}
 
-	fmt.Println("WhatsApp logged out successfully (session cleared)")
+	slog.Info("WhatsApp logged out successfully (session cleared)")
 	return nil
 }
 
@@ -110,11 +165,11 @@ func (c *Client) ReinitializeDevice() error {
 
 	devices, err := c.container.GetAllDevices(ctx)
 	if err != nil {
-		fmt.Printf("Warning: could not get existing devices: %v\n", err)
+		slog.Warn("could not get existing devices", "error", err)
 	} else {
 		for _, dev := range devices {
 			if err := c.container.DeleteDevice(ctx, dev); err != nil {
-				fmt.Printf("Warning: failed to delete device %v: %v\n", dev.ID, err)
+				slog.Warn("failed to delete device", "device_id", dev.ID, "error", err)
 			}
 		}
 	}
@@ -171,7 +226,7 @@ func (c *Client) PairWithPhone(ctx context.Context, phone string, state *sse.Sta
 		for evt := range qrChan {
 			switch evt.Event {
 			case "success":
-				fmt.Println("WhatsApp paired successfully via QR channel!")
+				slog.Info("WhatsApp paired successfully via QR channel!")
 				if state != nil {
 					state.SetWhatsAppStatus("connected")
 				}
@@ -180,7 +235,7 @@ func (c *Client) PairWithPhone(ctx context.Context, phone string, state *sse.Sta
 				}
 				return
 			case "timeout":
-				fmt.Println("WhatsApp pairing timed out")
+				slog.Error("WhatsApp pairing timed out")
 				if state != nil {
 					state.SetWhatsAppError("Pairing timed out. Please try again.")
 				}
@@ -196,6 +251,42 @@ func (c *Client) IsLoggedIn() bool {
 	return c.WAClient.Store.ID != nil
 }
 
+// Connect opens the WhatsApp connection using the stored session
+func (c *Client) Connect() error {
+	return c.WAClient.Connect()
+}
+
+// DeviceJID returns the linked device's JID, or "" before pairing
+func (c *Client) DeviceJID() string {
+	if c.WAClient == nil || c.WAClient.Store == nil || c.WAClient.Store.ID == nil {
+		return ""
+	}
+	return c.WAClient.Store.ID.String()
+}
+
+var errContactsUnavailable = errors.New("WhatsApp contacts not available")
+
+// GetAllContacts returns every contact synced from the phone
+func (c *Client) GetAllContacts(ctx context.Context) (map[types.JID]types.ContactInfo, error) {
+	if c.WAClient == nil || c.WAClient.Store == nil || c.WAClient.Store.Contacts == nil {
+		return nil, errContactsUnavailable
+	}
+	return c.WAClient.Store.Contacts.GetAllContacts(ctx)
+}
+
+// GetContact returns one synced contact
+func (c *Client) GetContact(ctx context.Context, jid types.JID) (types.ContactInfo, error) {
+	if c.WAClient == nil || c.WAClient.Store == nil || c.WAClient.Store.Contacts == nil {
+		return types.ContactInfo{}, errContactsUnavailable
+	}
+	return c.WAClient.Store.Contacts.GetContact(ctx, jid)
+}
+
+// IsConnected returns whether the client currently has a live connection to WhatsApp
+func (c *Client) IsConnected() bool {
+	return c.WAClient != nil && c.WAClient.IsConnected()
+}
+
 // SetHistorySyncBackfillHook configures a callback invoked after HistorySync stores messages.
 func (c *Client) SetHistorySyncBackfillHook(hook HistorySyncBackfillHook) {
 	if c.handler != nil {
@@ -203,6 +294,20 @@ func (c *Client) SetHistorySyncBackfillHook(hook HistorySyncBackfillHook) {
 	}
 }
 
+// SetHistorySyncProgressHook configures a callback invoked as HistorySync checkpoints conversations.
+func (c *Client) SetHistorySyncProgressHook(hook HistorySyncProgressHook) {
+	if c.handler != nil {
+		c.handler.SetHistorySyncProgressHook(hook)
+	}
+}
+
+// SetSelfChatCommandHook configures the callback that runs commands from the self-chat
+func (c *Client) SetSelfChatCommandHook(hook SelfChatCommandHook) {
+	if c.handler != nil {
+		c.handler.SetSelfChatCommandHook(hook)
+	}
+}
+
 // SetUserID sets the user ID on both the client and its handler
 func (c *Client) SetUserID(userID int64) {
 	c.UserID = userID
@@ -245,7 +350,7 @@ func (c *Client) Reconnect(ctx context.Context, state *sse.State) {
 			state.SetQR(dataURL)
 		case "success":
 			state.SetWhatsAppStatus("connected")
-			fmt.Println("WhatsApp reconnected successfully!")
+			slog.Info("WhatsApp reconnected successfully!")
 			return
 		case "timeout":
 			state.SetWhatsAppError("QR code expired. Click retry to try again.")
@@ -18,15 +18,21 @@ type DiscoverableChannel struct {
 
 // GetDiscoverableChannels returns all contacts as discoverable channels (no groups)
 func (c *Client) GetDiscoverableChannels() ([]DiscoverableChannel, error) {
+	return ListDiscoverableChannels(context.Background(), c)
+}
+
+// ListDiscoverableChannels returns the individual contacts in contacts as discoverable
+// channels, sorted by name
+func ListDiscoverableChannels(ctx context.Context, contacts ContactLister) ([]DiscoverableChannel, error) {
 	var channels []DiscoverableChannel
 
 	// Get all contacts (groups not supported)
-	contacts, err := c.WAClient.Store.Contacts.GetAllContacts(context.Background())
+	all, err := contacts.GetAllContacts(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get contacts: %w", err)
 	}
 
-	for jid, contact := range contacts {
+	for jid, contact := range all {
 		// Only return individual contacts (not groups)
 		if jid.Server != "s.whatsapp.net" {
 			continue
@@ -2,7 +2,7 @@ package whatsapp
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -23,6 +23,9 @@ type FilteredMessage = source.Message
 // HistorySyncBackfillHook is invoked after HistorySync has stored messages for enabled channels.
 type HistorySyncBackfillHook func(userID int64, channel *database.SourceChannel)
 
+// HistorySyncProgressHook is invoked when HistorySync starts or finishes storing a conversation.
+type HistorySyncProgressHook func(userID int64, checkpoint *database.HistorySyncCheckpoint)
+
 type Handler struct {
 	UserID           int64 // User who owns this handler (for multi-user support)
 	db               *database.DB
@@ -30,11 +33,17 @@ type Handler struct {
 	messageChan      chan source.Message
 	state            *sse.State
 	wClient          *whatsmeow.Client // For ParseWebMessage in history sync
+	contacts         ContactLister     // Overrides wClient's contact store when set
+	textSender       TextSender        // Overrides wClient for replies when set
 
 	historySyncMu               sync.Mutex
 	historySyncBackfillHook     HistorySyncBackfillHook
+	historySyncProgressHook     HistorySyncProgressHook
 	historySyncPendingBackfill  map[int64]*database.SourceChannel
 	historySyncBackfillDebounce *time.Timer
+
+	selfChatMu   sync.Mutex
+	selfChatHook SelfChatCommandHook
 }
 
 func NewHandler(userID int64, db *database.DB, debugAllMessages bool, state *sse.State) *Handler {
@@ -54,6 +63,23 @@ func (h *Handler) SetClient(client *whatsmeow.Client) {
 	h.wClient = client
 }
 
+// SetContacts makes the handler read contact names from contacts instead of the
+// WhatsApp client's store
+func (h *Handler) SetContacts(contacts ContactLister) {
+	h.contacts = contacts
+}
+
+// contactLister returns the contact source for channel names, or nil if there is none
+func (h *Handler) contactLister() ContactLister {
+	if h.contacts != nil {
+		return h.contacts
+	}
+	if h.wClient != nil && h.wClient.Store != nil && h.wClient.Store.Contacts != nil {
+		return h.wClient.Store.Contacts
+	}
+	return nil
+}
+
 // SetMessageChannel allows ClientManager to override the message channel
 // with a shared channel for multi-user support
 func (h *Handler) SetMessageChannel(ch chan source.Message) {
@@ -68,6 +94,14 @@ func (h *Handler) SetHistorySyncBackfillHook(hook HistorySyncBackfillHook) {
 	h.historySyncBackfillHook = hook
 }
 
+// SetHistorySyncProgressHook configures an optional callback that reports each
+// conversation's HistorySync checkpoint as it is stored.
+func (h *Handler) SetHistorySyncProgressHook(hook HistorySyncProgressHook) {
+	h.historySyncMu.Lock()
+	defer h.historySyncMu.Unlock()
+	h.historySyncProgressHook = hook
+}
+
 func (h *Handler) MessageChan() <-chan source.Message {
 	return h.messageChan
 }
@@ -100,7 +134,7 @@ func (h *Handler) handleConnected(_ *events.Connected) {
 	}
 
 	if err := h.db.SaveWhatsAppSession(h.UserID, "", deviceJID, true); err != nil {
-		fmt.Printf("WhatsApp: failed to save connected session for user %d: %v\n", h.UserID, err)
+		slog.Error("WhatsApp: failed to save connected session", "user_id", h.UserID, "error", err)
 	}
 }
 
@@ -110,7 +144,7 @@ func (h *Handler) handleDisconnected(_ *events.Disconnected) {
 	}
 
 	if err := h.db.UpdateWhatsAppConnected(h.UserID, false); err != nil {
-		fmt.Printf("WhatsApp: failed to mark disconnected session for user %d: %v\n", h.UserID, err)
+		slog.Error("WhatsApp: failed to mark disconnected session", "user_id", h.UserID, "error", err)
 	}
 }
 
@@ -120,7 +154,7 @@ func (h *Handler) handleLoggedOut(_ *events.LoggedOut) {
 	}
 
 	if err := h.db.UpdateWhatsAppConnected(h.UserID, false); err != nil {
-		fmt.Printf("WhatsApp: failed to mark logged-out session for user %d: %v\n", h.UserID, err)
+		slog.Error("WhatsApp: failed to mark logged-out session", "user_id", h.UserID, "error", err)
 	}
 }
 
@@ -131,28 +165,28 @@ func (h *Handler) handleLoggedOut(_ *events.LoggedOut) {
 func (h *Handler) handleAppStateSyncComplete(evt *events.AppStateSyncComplete) {
 	switch evt.Name {
 	case appstate.WAPatchCriticalBlock:
-		fmt.Println("AppStateSyncComplete: (critical_block)")
+		slog.Info("AppStateSyncComplete", "name", "critical_block")
 		go h.refreshTopContactNames()
 
 	case appstate.WAPatchCriticalUnblockLow:
-		fmt.Println("AppStateSyncComplete: (critical_unblock_low)")
+		slog.Info("AppStateSyncComplete", "name", "critical_unblock_low")
 		go h.refreshAllContactNames()
 	}
 }
 
 func (h *Handler) forceSyncContactsAndRefresh() {
 	if h.wClient == nil {
-		fmt.Println("ForceSyncContacts: WhatsApp client not available")
+		slog.Info("ForceSyncContacts: WhatsApp client not available")
 		return
 	}
 
-	fmt.Println("ForceSyncContacts: Attempting to sync contact list via FetchAppState")
+	slog.Info("ForceSyncContacts: Attempting to sync contact list via FetchAppState")
 
 	err := h.wClient.FetchAppState(context.Background(), appstate.WAPatchCriticalUnblockLow, true, false)
 	if err != nil {
-		fmt.Printf("ForceSyncContacts: FetchAppState failed: %v (will rely on events)\n", err)
+		slog.Warn("ForceSyncContacts: FetchAppState failed, will rely on events", "error", err)
 	} else {
-		fmt.Println("ForceSyncContacts: FetchAppState succeeded")
+		slog.Info("ForceSyncContacts: FetchAppState succeeded")
 	}
 
 	// Small delay to let the store populate
@@ -176,6 +210,14 @@ func (h *Handler) handleMessage(msg *events.Message) {
 		return
 	}
 
+	// The user's own chat takes approval commands; it is never analyzed
+	if isSelfChat(msg.Info) {
+		if hook := h.selfChatCommandHook(); hook != nil {
+			go h.runSelfChatCommand(hook, msg.Info.Chat, text)
+		}
+		return
+	}
+
 	sender := msg.Info.Sender
 	identifier := sender.User
 
@@ -190,7 +232,7 @@ func (h *Handler) handleMessage(msg *events.Message) {
 		var channelType source.ChannelType
 		tracked, sourceID, channelType, err = h.db.IsSourceChannelTracked(h.UserID, source.SourceTypeWhatsApp, identifier)
 		if err != nil {
-			fmt.Printf("Error checking channel: %v\n", err)
+			slog.Error("WhatsApp: Error checking channel", "error", err)
 			return
 		}
 		_ = channelType // channelType used for logging if needed
@@ -200,19 +242,20 @@ func (h *Handler) handleMessage(msg *events.Message) {
 		return
 	}
 
-	// Log to stdout
-	fmt.Printf("[WhatsApp DM: %s] %s\n", sender.User, text)
+	// Message text is only logged at debug level
+	slog.Debug("WhatsApp DM", "user_id", h.UserID, "sender", sender.User, "text", text)
 
 	// Send to channel for assistant processing (blocking for reliability).
 	h.messageChan <- source.Message{
-		UserID:     h.UserID,
-		SourceType: source.SourceTypeWhatsApp,
-		SourceID:   sourceID,
-		Identifier: identifier,
-		SenderID:   sender.String(),
-		SenderName: sender.User,
-		Text:       text,
-		Timestamp:  msg.Info.Timestamp,
+		UserID:      h.UserID,
+		SourceType:  source.SourceTypeWhatsApp,
+		SourceID:    sourceID,
+		Identifier:  identifier,
+		SenderID:    sender.String(),
+		SenderName:  sender.User,
+		Text:        text,
+		Timestamp:   msg.Info.Timestamp,
+		Attachments: extractAttachments(msg),
 	}
 }
 
@@ -248,6 +291,24 @@ const maxHistoryMessagesPerContact = 25
 
 var historySyncBackfillDebounce = 2 * time.Second
 
+// HistoryConversation is one direct chat from a HistorySync
+type HistoryConversation struct {
+	ChatJID types.JID
+	// MessageCount counts every message in the chat, including ones without text
+	MessageCount  int
+	LastMessageAt *time.Time
+	// Messages are the chat's text messages, newest first
+	Messages []HistoryMessage
+}
+
+// HistoryMessage is a text message from a HistorySync
+type HistoryMessage struct {
+	SenderID   string
+	SenderName string
+	Text       string
+	Timestamp  time.Time
+}
+
 // senderInfo tracks accurate message stats for a sender during HistorySync
 type senderInfo struct {
 	identifier    string
@@ -259,31 +320,26 @@ type senderInfo struct {
 type historyConversationWork struct {
 	identifier string
 	chatJID    types.JID
-	messages   []*waProto.HistorySyncMsg
+	channel    *database.SourceChannel
+	messages   []HistoryMessage
 }
 
 // handleHistorySync processes the HistorySync event from WhatsApp
 // This runs in a goroutine to not block the event handler
 func (h *Handler) handleHistorySync(evt *events.HistorySync) {
 	if h.wClient == nil {
-		fmt.Println("HistorySync: WhatsApp client not set, skipping")
+		slog.Info("HistorySync: WhatsApp client not set, skipping")
 		return
 	}
 
 	conversations := evt.Data.GetConversations()
-	fmt.Printf("HistorySync: Processing %d conversations\n", len(conversations))
-
-	// Track ACCURATE message counts per sender (not limited to 25)
-	senderStats := make(map[string]*senderInfo)
-	workItems := make([]historyConversationWork, 0, len(conversations))
+	slog.Info("HistorySync: Processing conversations", "conversations", len(conversations))
 
-	// Phase 1: fast metadata pass.
-	// Build sender stats across all conversations so top contacts can be shown
-	// while message history is still being processed.
+	parsed := make([]HistoryConversation, 0, len(conversations))
 	for _, conv := range conversations {
 		chatJID, err := types.ParseJID(conv.GetID())
 		if err != nil {
-			fmt.Printf("HistorySync: Failed to parse JID %s: %v\n", conv.GetID(), err)
+			slog.Error("HistorySync: Failed to parse JID", "conversation_id", conv.GetID(), "error", err)
 			continue
 		}
 
@@ -296,37 +352,95 @@ func (h *Handler) handleHistorySync(evt *events.HistorySync) {
 		if len(messages) == 0 {
 			continue
 		}
+		parsed = append(parsed, h.parseHistoryConversation(chatJID, messages))
+	}
+
+	h.ProcessHistorySync(parsed)
+
+	// Force sync contacts and refresh names immediately
+	// This uses FetchAppState to get contacts ASAP, with AppStateSyncComplete as fallback
+	go h.forceSyncContactsAndRefresh()
+}
+
+// parseHistoryConversation keeps up to maxHistoryMessagesPerContact text messages
+func (h *Handler) parseHistoryConversation(chatJID types.JID, messages []*waProto.HistorySyncMsg) HistoryConversation {
+	conv := HistoryConversation{ChatJID: chatJID, MessageCount: len(messages)}
+
+	if parsedEvt, err := h.wClient.ParseWebMessage(chatJID, messages[0].GetMessage()); err == nil {
+		ts := parsedEvt.Info.Timestamp
+		conv.LastMessageAt = &ts
+	}
+
+	for _, historyMsg := range messages {
+		if len(conv.Messages) >= maxHistoryMessagesPerContact {
+			break
+		}
+
+		evt, err := h.wClient.ParseWebMessage(chatJID, historyMsg.GetMessage())
+		if err != nil {
+			continue // Skip messages that can't be parsed
+		}
+
+		text := extractText(evt)
+		if text == "" {
+			continue
+		}
+
+		senderName := evt.Info.Sender.User
+		if evt.Info.PushName != "" {
+			senderName = evt.Info.PushName
+		}
+		conv.Messages = append(conv.Messages, HistoryMessage{
+			SenderID:   evt.Info.Sender.String(),
+			SenderName: senderName,
+			Text:       text,
+			Timestamp:  evt.Info.Timestamp,
+		})
+	}
+
+	return conv
+}
+
+// ProcessHistorySync stores parsed HistorySync conversations: it creates (disabled)
+// channels for new contacts, records their message stats, stores their recent messages
+// and queues backfill for enabled channels. Each conversation is checkpointed as its
+// messages are stored, so when WhatsApp delivers a conversation again after an
+// interrupted sync only the messages after the checkpoint are stored.
+func (h *Handler) ProcessHistorySync(conversations []HistoryConversation) {
+	// Track ACCURATE message counts per sender (not limited to 25)
+	senderStats := make(map[string]*senderInfo)
+	workItems := make([]historyConversationWork, 0, len(conversations))
 
-		identifier := chatJID.User
+	// Phase 1: fast metadata pass.
+	// Build sender stats across all conversations so top contacts can be shown
+	// while message history is still being processed.
+	for _, conv := range conversations {
+		identifier := conv.ChatJID.User
 
 		if _, exists := senderStats[identifier]; !exists {
 			senderStats[identifier] = &senderInfo{
 				identifier:   identifier,
-				jid:          chatJID,
+				jid:          conv.ChatJID,
 				messageCount: 0,
 			}
 		}
-		senderStats[identifier].messageCount += len(messages)
+		senderStats[identifier].messageCount += conv.MessageCount
 
-		if len(messages) > 0 {
-			lastMsg := messages[0]
-			if parsedEvt, err := h.wClient.ParseWebMessage(chatJID, lastMsg.GetMessage()); err == nil {
-				ts := parsedEvt.Info.Timestamp
-				// Only update if this is more recent
-				if senderStats[identifier].lastMessageAt == nil || ts.After(*senderStats[identifier].lastMessageAt) {
-					senderStats[identifier].lastMessageAt = &ts
-				}
+		// Only update if this is more recent
+		if ts := conv.LastMessageAt; ts != nil {
+			if senderStats[identifier].lastMessageAt == nil || ts.After(*senderStats[identifier].lastMessageAt) {
+				senderStats[identifier].lastMessageAt = ts
 			}
 		}
 
 		workItems = append(workItems, historyConversationWork{
 			identifier: identifier,
-			chatJID:    chatJID,
-			messages:   messages,
+			chatJID:    conv.ChatJID,
+			messages:   conv.Messages,
 		})
 	}
 
-	fmt.Printf("HistorySync: Found %d unique senders\n", len(senderStats))
+	slog.Info("HistorySync: Found unique senders", "count", len(senderStats))
 
 	// Prime channels + top-contact stats from metadata before message processing.
 	// This is intentionally best-effort/inaccurate while sync is still running.
@@ -335,39 +449,68 @@ func (h *Handler) handleHistorySync(evt *events.HistorySync) {
 	for identifier, info := range senderStats {
 		channel, err := h.getOrCreateHistoryChannel(identifier, info.jid)
 		if err != nil {
-			fmt.Printf("HistorySync: Failed to get/create channel for %s: %v\n", identifier, err)
+			slog.Error("HistorySync: Failed to get/create channel", "identifier", identifier, "error", err)
 			continue
 		}
 		channelsByIdentifier[identifier] = channel
 
 		if err := h.db.UpdateChannelStats(channel.ID, info.messageCount, info.lastMessageAt); err != nil {
-			fmt.Printf("HistorySync: Failed to update in-progress stats for %s: %v\n", identifier, err)
+			slog.Error("HistorySync: Failed to update in-progress stats", "identifier", identifier, "error", err)
 			continue
 		}
 		statsUpdated++
 	}
 
-	fmt.Printf("HistorySync: Primed top-contact stats for %d senders\n", statsUpdated)
+	slog.Info("HistorySync: Primed top-contact stats", "senders", statsUpdated)
 
-	// Phase 2: store message history.
-	processedContacts := 0
-	processedChannels := make(map[int64]*database.SourceChannel)
+	// Checkpoint every conversation before storing messages, so sync progress covers
+	// the whole batch from the start. Conversations an earlier sync completed are
+	// skipped unless they have newer messages.
+	pendingItems := make([]historyConversationWork, 0, len(workItems))
+	resumedContacts := 0
 	for _, item := range workItems {
 		channel := channelsByIdentifier[item.identifier]
 		if channel == nil {
 			fallbackChannel, err := h.getOrCreateHistoryChannel(item.identifier, item.chatJID)
 			if err != nil {
-				fmt.Printf("HistorySync: Failed to resolve channel during message processing for %s: %v\n", item.identifier, err)
+				slog.Error("HistorySync: Failed to resolve channel during message processing", "identifier", item.identifier, "error", err)
 				continue
 			}
 			channel = fallbackChannel
 			channelsByIdentifier[item.identifier] = channel
 		}
 
-		if h.processConversationHistory(channel, item.chatJID, item.messages) {
+		checkpoint, messages := h.pendingHistoryMessages(channel.ID, item.messages)
+		if checkpoint != nil {
+			resumedContacts++
+			if checkpoint.Status == database.HistorySyncStatusCompleted && len(messages) == 0 {
+				continue
+			}
+		}
+		if err := h.db.StartHistorySyncCheckpoint(h.UserID, channel.ID, len(messages)); err != nil {
+			slog.Error("HistorySync: Failed to start checkpoint", "identifier", item.identifier, "error", err)
+		}
+		h.publishHistorySyncProgress(channel.ID)
+
+		item.channel = channel
+		item.messages = messages
+		pendingItems = append(pendingItems, item)
+	}
+
+	slog.Info("HistorySync: Checkpointed conversations", "pending", len(pendingItems), "resumed", resumedContacts)
+
+	// Phase 2: store message history.
+	processedContacts := 0
+	processedChannels := make(map[int64]*database.SourceChannel)
+	for _, item := range pendingItems {
+		if h.processConversationHistory(item.channel, item.chatJID, item.messages) {
 			processedContacts++
-			processedChannels[channel.ID] = channel
+			processedChannels[item.channel.ID] = item.channel
+		}
+		if err := h.db.CompleteHistorySyncCheckpoint(h.UserID, item.channel.ID); err != nil {
+			slog.Error("HistorySync: Failed to complete checkpoint", "identifier", item.identifier, "error", err)
 		}
+		h.publishHistorySyncProgress(item.channel.ID)
 	}
 
 	// Finalize top-contact stats after phase 2 so ranking reflects the full HistorySync snapshot.
@@ -385,24 +528,15 @@ func (h *Handler) handleHistorySync(evt *events.HistorySync) {
 		}
 
 		if err := h.db.UpdateChannelStats(channel.ID, info.messageCount, info.lastMessageAt); err != nil {
-			fmt.Printf("HistorySync: Failed to finalize stats for %s: %v\n", identifier, err)
+			slog.Error("HistorySync: Failed to finalize stats", "identifier", identifier, "error", err)
 			continue
 		}
 		finalizedStats++
 	}
 
-	fmt.Printf(
-		"HistorySync: Completed - processed %d contacts, top stats primed for %d, finalized for %d\n",
-		processedContacts,
-		statsUpdated,
-		finalizedStats,
-	)
+	slog.Info("HistorySync: Completed", "processed_contacts", processedContacts, "stats_primed", statsUpdated, "stats_finalized", finalizedStats)
 
 	h.queueHistorySyncBackfill(processedChannels)
-
-	// Force sync contacts and refresh names immediately
-	// This uses FetchAppState to get contacts ASAP, with AppStateSyncComplete as fallback
-	go h.forceSyncContactsAndRefresh()
 }
 
 func (h *Handler) queueHistorySyncBackfill(processedChannels map[int64]*database.SourceChannel) {
@@ -464,52 +598,87 @@ func (h *Handler) flushHistorySyncBackfill() {
 	}
 
 	if triggered > 0 {
-		fmt.Printf("HistorySync: Triggered post-sync backfill for %d enabled channels\n", triggered)
+		slog.Info("HistorySync: Triggered post-sync backfill for enabled channels", "triggered", triggered)
 	}
 }
 
-// processConversationHistory stores messages from a single conversation
-func (h *Handler) processConversationHistory(channel *database.SourceChannel, chatJID types.JID, messages []*waProto.HistorySyncMsg) bool {
-	identifier := chatJID.User
+// pendingHistoryMessages returns a conversation's checkpoint (nil if it was never
+// synced) and the messages not yet stored, newest first: up to
+// maxHistoryMessagesPerContact, none before the checkpoint. An interrupted sync may
+// have stored only some of the messages sent in the checkpoint's second, so those are
+// kept unless the conversation completed; StoreSourceMessage skips ones already stored.
+func (h *Handler) pendingHistoryMessages(channelID int64, messages []HistoryMessage) (*database.HistorySyncCheckpoint, []HistoryMessage) {
+	if len(messages) > maxHistoryMessagesPerContact {
+		messages = messages[:maxHistoryMessagesPerContact]
+	}
 
-	// Process messages (limit to maxHistoryMessagesPerContact)
-	processed := 0
-	for _, historyMsg := range messages {
-		if processed >= maxHistoryMessagesPerContact {
+	checkpoint, err := h.db.GetHistorySyncCheckpoint(h.UserID, channelID)
+	if err != nil {
+		slog.Error("HistorySync: Failed to get checkpoint", "channel_id", channelID, "error", err)
+		return nil, messages
+	}
+	if checkpoint == nil || checkpoint.SyncedThrough == nil {
+		return checkpoint, messages
+	}
+
+	syncedThrough := checkpoint.SyncedThrough.Truncate(time.Second)
+	completed := checkpoint.Status == database.HistorySyncStatusCompleted
+	pending := 0
+	for ; pending < len(messages); pending++ {
+		ts := messages[pending].Timestamp
+		if ts.Before(syncedThrough) || (completed && !ts.After(*checkpoint.SyncedThrough)) {
 			break
 		}
+	}
+	return checkpoint, messages[:pending]
+}
 
-		evt, err := h.wClient.ParseWebMessage(chatJID, historyMsg.GetMessage())
-		if err != nil {
-			continue // Skip messages that can't be parsed
-		}
+// publishHistorySyncProgress reports a channel's checkpoint to the progress hook
+func (h *Handler) publishHistorySyncProgress(channelID int64) {
+	h.historySyncMu.Lock()
+	hook := h.historySyncProgressHook
+	h.historySyncMu.Unlock()
+	if hook == nil {
+		return
+	}
 
-		text := extractText(evt)
-		if text == "" {
-			continue
-		}
+	checkpoint, err := h.db.GetHistorySyncCheckpoint(h.UserID, channelID)
+	if err != nil || checkpoint == nil {
+		return
+	}
+	hook(h.UserID, checkpoint)
+}
 
-		// Get sender info
-		senderID := evt.Info.Sender.String()
-		senderName := evt.Info.Sender.User
-		if evt.Info.PushName != "" {
-			senderName = evt.Info.PushName
-		}
+// processConversationHistory stores messages from a single conversation, oldest first
+// so its checkpoint advances with each stored message
+func (h *Handler) processConversationHistory(channel *database.SourceChannel, chatJID types.JID, messages []HistoryMessage) bool {
+	identifier := chatJID.User
+
+	// Process messages (limit to maxHistoryMessagesPerContact)
+	if len(messages) > maxHistoryMessagesPerContact {
+		messages = messages[:maxHistoryMessagesPerContact]
+	}
+	processed := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
 
 		// Store message
-		_, err = h.db.StoreSourceMessage(
+		_, err := h.db.StoreSourceMessage(
 			source.SourceTypeWhatsApp,
 			channel.ID,
-			senderID,
-			senderName,
-			text,
+			msg.SenderID,
+			msg.SenderName,
+			msg.Text,
 			"", // no subject for WhatsApp
-			evt.Info.Timestamp,
+			msg.Timestamp,
 		)
 		if err != nil {
-			// Log but continue - might be duplicate
+			slog.Warn("HistorySync: Failed to store message", "identifier", identifier, "error", err)
 			continue
 		}
+		if err := h.db.AdvanceHistorySyncCheckpoint(h.UserID, channel.ID, msg.Timestamp); err != nil {
+			slog.Error("HistorySync: Failed to advance checkpoint", "identifier", identifier, "error", err)
+		}
 
 		processed++
 	}
@@ -517,9 +686,9 @@ func (h *Handler) processConversationHistory(channel *database.SourceChannel, ch
 	// Prune to keep only last N messages
 	if processed > 0 {
 		if err := h.db.PruneSourceMessages(h.UserID, source.SourceTypeWhatsApp, channel.ID, maxHistoryMessagesPerContact); err != nil {
-			fmt.Printf("HistorySync: Failed to prune messages for %s: %v\n", identifier, err)
+			slog.Error("HistorySync: Failed to prune messages", "identifier", identifier, "error", err)
 		}
-		fmt.Printf("HistorySync: Stored %d messages for %s\n", processed, identifier)
+		slog.Info("HistorySync: Stored messages", "processed", processed, "identifier", identifier)
 	}
 
 	return processed > 0
@@ -539,8 +708,8 @@ func (h *Handler) getOrCreateHistoryChannel(identifier string, jid types.JID) (*
 
 	// Get contact name from store if available
 	name := identifier
-	if h.wClient != nil && h.wClient.Store != nil && h.wClient.Store.Contacts != nil {
-		contact, err := h.wClient.Store.Contacts.GetContact(context.Background(), jid)
+	if contacts := h.contactLister(); contacts != nil {
+		contact, err := contacts.GetContact(context.Background(), jid)
 		if err == nil {
 			if contact.FullName != "" {
 				name = contact.FullName
@@ -564,7 +733,7 @@ func (h *Handler) getOrCreateHistoryChannel(identifier string, jid types.JID) (*
 
 	// Disable the channel - it's just for discovery, not event tracking
 	if err := h.db.UpdateSourceChannel(h.UserID, channel.ID, channel.Name, false); err != nil {
-		fmt.Printf("HistorySync: Warning - failed to disable new channel: %v\n", err)
+		slog.Warn("HistorySync: failed to disable new channel", "error", err)
 	}
 
 	return channel, nil
@@ -575,21 +744,22 @@ func (h *Handler) getOrCreateHistoryChannel(identifier string, jid types.JID) (*
 // refreshTopContactNames updates names for top 8 contacts by message history
 // This runs immediately after HistorySync to ensure the Add Source modal shows names ASAP
 func (h *Handler) refreshTopContactNames() {
-	if h.wClient == nil || h.wClient.Store == nil || h.wClient.Store.Contacts == nil {
+	contacts := h.contactLister()
+	if contacts == nil {
 		return
 	}
 
 	// Get all contacts from WhatsApp store (batch lookup)
-	allContacts, err := h.wClient.Store.Contacts.GetAllContacts(context.Background())
+	allContacts, err := contacts.GetAllContacts(context.Background())
 	if err != nil {
-		fmt.Printf("RefreshTopNames: Failed to get contacts: %v\n", err)
+		slog.Error("RefreshTopNames: Failed to get contacts", "error", err)
 		return
 	}
 
 	// Get top 8 contacts from message history (fast, user-scoped)
 	topContacts, err := h.db.GetTopContactsBySourceTypeForUser(h.UserID, source.SourceTypeWhatsApp, 8)
 	if err != nil {
-		fmt.Printf("RefreshTopNames: Failed to get top contacts: %v\n", err)
+		slog.Error("RefreshTopNames: Failed to get top contacts", "error", err)
 		return
 	}
 
@@ -621,28 +791,29 @@ func (h *Handler) refreshTopContactNames() {
 	}
 
 	if updated > 0 {
-		fmt.Printf("RefreshTopNames: Updated %d top contact names\n", updated)
+		slog.Info("RefreshTopNames: Updated top contact names", "updated", updated)
 	}
 }
 
 // refreshAllContactNames updates names for all contacts missing names
 // This runs in background after top contacts are done
 func (h *Handler) refreshAllContactNames() {
-	if h.wClient == nil || h.wClient.Store == nil || h.wClient.Store.Contacts == nil {
+	contacts := h.contactLister()
+	if contacts == nil {
 		return
 	}
 
 	// Get all contacts from WhatsApp store (batch lookup)
-	allContacts, err := h.wClient.Store.Contacts.GetAllContacts(context.Background())
+	allContacts, err := contacts.GetAllContacts(context.Background())
 	if err != nil {
-		fmt.Printf("RefreshAllNames: Failed to get contacts: %v\n", err)
+		slog.Error("RefreshAllNames: Failed to get contacts", "error", err)
 		return
 	}
 
 	// Get all WhatsApp channels
 	channels, err := h.db.ListSourceChannels(h.UserID, source.SourceTypeWhatsApp)
 	if err != nil {
-		fmt.Printf("RefreshAllNames: Failed to list channels: %v\n", err)
+		slog.Error("RefreshAllNames: Failed to list channels", "error", err)
 		return
 	}
 
@@ -674,6 +845,6 @@ func (h *Handler) refreshAllContactNames() {
 	}
 
 	if updated > 0 {
-		fmt.Printf("RefreshAllNames: Updated %d contact names\n", updated)
+		slog.Info("RefreshAllNames: Updated contact names", "updated", updated)
 	}
 }
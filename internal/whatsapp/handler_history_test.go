@@ -0,0 +1,102 @@
+package whatsapp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func historyConversation(phone string, timestamps ...time.Time) HistoryConversation {
+	jid := types.NewJID(phone, types.DefaultUserServer)
+	conv := HistoryConversation{ChatJID: jid, MessageCount: len(timestamps)}
+	// Newest first, like HistorySync
+	for i := len(timestamps) - 1; i >= 0; i-- {
+		conv.Messages = append(conv.Messages, HistoryMessage{
+			SenderID:   jid.String(),
+			SenderName: "Alice",
+			Text:       "message " + timestamps[i].Format(time.TimeOnly),
+			Timestamp:  timestamps[i],
+		})
+	}
+	if len(timestamps) > 0 {
+		conv.LastMessageAt = &timestamps[len(timestamps)-1]
+	}
+	return conv
+}
+
+func TestProcessHistorySync_ResumesFromCheckpoint(t *testing.T) {
+	db := database.NewTestDB(t)
+	user := database.CreateTestUser(t, db)
+	h := NewHandler(user.ID, db, false, nil)
+
+	var mu sync.Mutex
+	var progress []database.HistorySyncCheckpoint
+	h.SetHistorySyncProgressHook(func(userID int64, checkpoint *database.HistorySyncCheckpoint) {
+		require.Equal(t, user.ID, userID)
+		mu.Lock()
+		progress = append(progress, *checkpoint)
+		mu.Unlock()
+	})
+	takeProgress := func() []database.HistorySyncCheckpoint {
+		mu.Lock()
+		defer mu.Unlock()
+		taken := progress
+		progress = nil
+		return taken
+	}
+
+	first := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	second, third := first.Add(time.Minute), first.Add(2*time.Minute)
+
+	h.ProcessHistorySync([]HistoryConversation{historyConversation("15551230001", first, second)})
+	channel, err := db.GetSourceChannelByIdentifier(user.ID, source.SourceTypeWhatsApp, "15551230001")
+	require.NoError(t, err)
+	require.NotNil(t, channel)
+
+	updates := takeProgress()
+	require.Len(t, updates, 2)
+	assert.Equal(t, database.HistorySyncStatusPending, updates[0].Status)
+	assert.Equal(t, 2, updates[0].MessagesTotal)
+	assert.Equal(t, database.HistorySyncStatusCompleted, updates[1].Status)
+	assert.Equal(t, 100, updates[1].Percent)
+
+	t.Run("completed conversations are skipped", func(t *testing.T) {
+		h.ProcessHistorySync([]HistoryConversation{historyConversation("15551230001", first, second)})
+		assert.Empty(t, takeProgress())
+	})
+
+	t.Run("interrupted sync resumes after the checkpoint", func(t *testing.T) {
+		// A sync that stopped after storing the second message
+		require.NoError(t, db.StartHistorySyncCheckpoint(user.ID, channel.ID, 3))
+
+		h.ProcessHistorySync([]HistoryConversation{historyConversation("15551230001", first, second, third)})
+
+		updates := takeProgress()
+		require.Len(t, updates, 2)
+		// The checkpoint's second is stored again in case it held more messages
+		assert.Equal(t, 2, updates[0].MessagesTotal)
+		assert.Equal(t, database.HistorySyncStatusCompleted, updates[1].Status)
+		require.NotNil(t, updates[1].SyncedThrough)
+		assert.True(t, third.Equal(*updates[1].SyncedThrough))
+
+		messages, err := db.GetSourceMessageHistory(user.ID, source.SourceTypeWhatsApp, channel.ID, 10)
+		require.NoError(t, err)
+		assert.Len(t, messages, 3, "replayed messages are not stored twice")
+	})
+
+	t.Run("newer messages reopen a completed conversation", func(t *testing.T) {
+		fourth := first.Add(3 * time.Minute)
+		h.ProcessHistorySync([]HistoryConversation{historyConversation("15551230001", first, second, third, fourth)})
+
+		updates := takeProgress()
+		require.Len(t, updates, 2)
+		assert.Equal(t, 1, updates[0].MessagesTotal)
+		assert.True(t, fourth.Equal(*updates[1].SyncedThrough))
+	})
+}
@@ -0,0 +1,94 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+
+	"github.com/omriShneor/project_alfred/internal/source"
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// extractAttachments returns a reference to the media sent with a message. The
+// reference is the encoded media message, which holds the keys whatsmeow needs to
+// download and decrypt the file later.
+func extractAttachments(msg *events.Message) []source.Attachment {
+	m := msg.Message
+
+	var attachment source.Attachment
+	var media proto.Message
+	switch {
+	case m.GetImageMessage() != nil:
+		img := m.GetImageMessage()
+		attachment = source.Attachment{Kind: source.AttachmentKindImage, MimeType: img.GetMimetype(), SizeBytes: int64(img.GetFileLength())}
+		media = img
+	case m.GetVideoMessage() != nil:
+		vid := m.GetVideoMessage()
+		attachment = source.Attachment{Kind: source.AttachmentKindVideo, MimeType: vid.GetMimetype(), SizeBytes: int64(vid.GetFileLength())}
+		media = vid
+	case m.GetDocumentMessage() != nil:
+		doc := m.GetDocumentMessage()
+		attachment = source.Attachment{
+			Kind:      source.AttachmentKindDocument,
+			MimeType:  doc.GetMimetype(),
+			FileName:  doc.GetFileName(),
+			SizeBytes: int64(doc.GetFileLength()),
+		}
+		media = doc
+	default:
+		return nil
+	}
+
+	data, err := proto.Marshal(media)
+	if err != nil {
+		slog.Warn("WhatsApp: failed to encode media reference", "message_id", msg.Info.ID, "error", err)
+		return nil
+	}
+	attachment.SourceRef = base64.StdEncoding.EncodeToString(data)
+	return []source.Attachment{attachment}
+}
+
+// decodeMediaRef rebuilds the media message of an attachment reference
+func decodeMediaRef(kind, sourceRef string) (whatsmeow.DownloadableMessage, error) {
+	data, err := base64.StdEncoding.DecodeString(sourceRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid media reference: %w", err)
+	}
+
+	var media interface {
+		proto.Message
+		whatsmeow.DownloadableMessage
+	}
+	switch kind {
+	case source.AttachmentKindImage:
+		media = &waProto.ImageMessage{}
+	case source.AttachmentKindVideo:
+		media = &waProto.VideoMessage{}
+	case source.AttachmentKindDocument:
+		media = &waProto.DocumentMessage{}
+	default:
+		return nil, fmt.Errorf("unsupported attachment kind %q", kind)
+	}
+	if err := proto.Unmarshal(data, media); err != nil {
+		return nil, fmt.Errorf("invalid media reference: %w", err)
+	}
+	return media, nil
+}
+
+// DownloadMedia downloads and decrypts the file of an attachment stored from a message.
+// WhatsApp keeps media on its servers for a limited time, after which this fails.
+func (c *Client) DownloadMedia(ctx context.Context, kind, sourceRef string) ([]byte, error) {
+	media, err := decodeMediaRef(kind, sourceRef)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.WAClient.Download(ctx, media)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media: %w", err)
+	}
+	return data, nil
+}
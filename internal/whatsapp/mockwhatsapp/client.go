@@ -0,0 +1,417 @@
+// Package mockwhatsapp provides an in-process fake of the per-user WhatsApp client. It
+// pairs without a phone, serves a contact list set by the test and feeds HistorySync
+// conversations through the real handler, so integration tests can run the onboarding
+// and backfill flow end to end.
+package mockwhatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/omriShneor/project_alfred/internal/sse"
+	"github.com/omriShneor/project_alfred/internal/whatsapp"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// PairingCode is the code every fake client returns from PairWithPhone
+const PairingCode = "ALFR-3D00"
+
+var errNotLoggedIn = errors.New("not logged in")
+
+// Client is a fake WhatsApp account. Pairing only completes when the test calls
+// CompletePairing, standing in for the user entering the code on their phone.
+type Client struct {
+	handler *whatsapp.Handler
+
+	mu          sync.Mutex
+	phone       string
+	loggedIn    bool
+	connected   bool
+	contacts    map[types.JID]types.ContactInfo
+	media       map[string][]byte
+	sent        []SentMessage
+	pairedState *sse.State
+}
+
+// SentMessage is a text message the fake sent
+type SentMessage struct {
+	// Phone is the recipient's number without "+"
+	Phone string
+	Text  string
+}
+
+var _ whatsapp.Account = (*Client)(nil)
+
+// NewClient creates an unpaired fake that delivers to handler. handler may be nil.
+func NewClient(handler *whatsapp.Handler) *Client {
+	c := &Client{
+		handler:  handler,
+		contacts: make(map[types.JID]types.ContactInfo),
+		media:    make(map[string][]byte),
+	}
+	if handler != nil {
+		handler.SetContacts(c)
+		handler.SetTextSender(c.sendText)
+	}
+	return c
+}
+
+// IsLoggedIn reports whether pairing has completed
+func (c *Client) IsLoggedIn() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loggedIn
+}
+
+// IsConnected reports whether the fake is paired and connected
+func (c *Client) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+// Connect reconnects a paired fake
+func (c *Client) Connect() error {
+	c.mu.Lock()
+	if !c.loggedIn {
+		c.mu.Unlock()
+		return nil
+	}
+	c.connected = true
+	c.mu.Unlock()
+
+	c.emit(&events.Connected{})
+	return nil
+}
+
+// DeviceJID returns the linked device's JID once paired
+func (c *Client) DeviceJID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loggedIn {
+		return ""
+	}
+	return c.phone + ":1@s.whatsapp.net"
+}
+
+// PairWithPhone starts pairing and returns PairingCode
+func (c *Client) PairWithPhone(_ context.Context, phone string, state *sse.State) (string, error) {
+	if phone == "" {
+		return "", errors.New("phone number is required")
+	}
+
+	c.mu.Lock()
+	c.phone = phone
+	c.loggedIn = false
+	c.connected = false
+	c.pairedState = state
+	c.mu.Unlock()
+
+	return PairingCode, nil
+}
+
+// Reconnect reconnects a paired fake. An unpaired one shows a QR code and waits for
+// CompletePairing.
+func (c *Client) Reconnect(_ context.Context, state *sse.State) {
+	state.SetWhatsAppStatus("waiting")
+	state.SetWhatsAppError("")
+
+	if c.IsLoggedIn() {
+		_ = c.Connect()
+		state.SetWhatsAppStatus("connected")
+		return
+	}
+
+	c.mu.Lock()
+	c.pairedState = state
+	c.mu.Unlock()
+
+	dataURL, err := whatsapp.GenerateQRDataURL(PairingCode)
+	if err != nil {
+		state.SetWhatsAppError(fmt.Sprintf("Failed to generate QR: %v", err))
+		return
+	}
+	state.SetQR(dataURL)
+}
+
+// CompletePairing finishes a pending PairWithPhone or Reconnect as if the user had
+// confirmed it on their phone
+func (c *Client) CompletePairing() {
+	c.mu.Lock()
+	if c.phone == "" {
+		c.phone = "15550000000"
+	}
+	c.loggedIn = true
+	c.connected = true
+	state := c.pairedState
+	c.pairedState = nil
+	c.mu.Unlock()
+
+	if state != nil {
+		state.SetWhatsAppStatus("connected")
+	}
+	c.emit(&events.Connected{})
+}
+
+// Disconnect drops the connection but keeps the fake paired
+func (c *Client) Disconnect() {
+	c.mu.Lock()
+	wasConnected := c.connected
+	c.connected = false
+	c.mu.Unlock()
+
+	if wasConnected {
+		c.emit(&events.Disconnected{})
+	}
+}
+
+// Logout unpairs the fake and forgets its contacts
+func (c *Client) Logout() error {
+	c.mu.Lock()
+	if !c.loggedIn {
+		c.mu.Unlock()
+		return errNotLoggedIn
+	}
+	c.loggedIn = false
+	c.connected = false
+	c.contacts = make(map[types.JID]types.ContactInfo)
+	c.mu.Unlock()
+
+	c.emit(&events.LoggedOut{})
+	return nil
+}
+
+// Close does nothing; the fake keeps no session store
+func (c *Client) Close() error {
+	return nil
+}
+
+// AddContact adds a contact as if it had been synced from the phone. phone is the
+// number without "+".
+func (c *Client) AddContact(phone, fullName, pushName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contacts[userJID(phone)] = types.ContactInfo{Found: true, FullName: fullName, PushName: pushName}
+}
+
+// GetDiscoverableChannels returns the contacts as discoverable channels
+func (c *Client) GetDiscoverableChannels() ([]whatsapp.DiscoverableChannel, error) {
+	return whatsapp.ListDiscoverableChannels(context.Background(), c)
+}
+
+// GetAllContacts returns a copy of the contacts
+func (c *Client) GetAllContacts(_ context.Context) (map[types.JID]types.ContactInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	contacts := make(map[types.JID]types.ContactInfo, len(c.contacts))
+	for jid, contact := range c.contacts {
+		contacts[jid] = contact
+	}
+	return contacts, nil
+}
+
+// GetContact returns one contact; unknown ones come back with Found false
+func (c *Client) GetContact(_ context.Context, jid types.JID) (types.ContactInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.contacts[jid], nil
+}
+
+// AddMedia makes DownloadMedia return data for an attachment reference, as if the file
+// were still on WhatsApp's servers
+func (c *Client) AddMedia(sourceRef string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.media[sourceRef] = data
+}
+
+// DownloadMedia returns the data added for sourceRef with AddMedia
+func (c *Client) DownloadMedia(_ context.Context, _, sourceRef string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loggedIn {
+		return nil, errNotLoggedIn
+	}
+	data, ok := c.media[sourceRef]
+	if !ok {
+		return nil, fmt.Errorf("media not found")
+	}
+	return data, nil
+}
+
+// SetUserID sets the user whose data the handler stores
+func (c *Client) SetUserID(userID int64) {
+	if c.handler != nil {
+		c.handler.UserID = userID
+	}
+}
+
+// SetHistorySyncBackfillHook passes the hook on to the handler
+func (c *Client) SetHistorySyncBackfillHook(hook whatsapp.HistorySyncBackfillHook) {
+	if c.handler != nil {
+		c.handler.SetHistorySyncBackfillHook(hook)
+	}
+}
+
+// SetHistorySyncProgressHook passes the hook on to the handler
+func (c *Client) SetHistorySyncProgressHook(hook whatsapp.HistorySyncProgressHook) {
+	if c.handler != nil {
+		c.handler.SetHistorySyncProgressHook(hook)
+	}
+}
+
+// SetSelfChatCommandHook passes the hook on to the handler
+func (c *Client) SetSelfChatCommandHook(hook whatsapp.SelfChatCommandHook) {
+	if c.handler != nil {
+		c.handler.SetSelfChatCommandHook(hook)
+	}
+}
+
+// SendToSelf delivers text through the handler as a message the user sent to their
+// own chat. Commands run in the background; poll SentMessages for the reply.
+func (c *Client) SendToSelf(text string) error {
+	c.mu.Lock()
+	phone, loggedIn := c.phone, c.loggedIn
+	c.mu.Unlock()
+	if !loggedIn {
+		return errNotLoggedIn
+	}
+
+	self := userJID(phone)
+	c.emit(&events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: self, Sender: self, IsFromMe: true},
+			Timestamp:     time.Now(),
+		},
+		Message: &waProto.Message{Conversation: proto.String(text)},
+	})
+	return nil
+}
+
+// SentMessages returns the messages the fake has sent, oldest first
+func (c *Client) SentMessages() []SentMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]SentMessage(nil), c.sent...)
+}
+
+func (c *Client) sendText(_ context.Context, to types.JID, text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.connected {
+		return errNotLoggedIn
+	}
+	c.sent = append(c.sent, SentMessage{Phone: to.User, Text: text})
+	return nil
+}
+
+// Chat is one conversation of a simulated HistorySync
+type Chat struct {
+	// Phone is the contact's number without "+"
+	Phone    string
+	Messages []Message
+}
+
+// Message is a text message in a Chat. Messages without a sender name use the
+// contact's push name.
+type Message struct {
+	Text       string
+	SenderName string
+	Timestamp  time.Time
+}
+
+// EmitHistorySync stores chats through the handler as if WhatsApp had sent them after
+// pairing. It returns once the messages are stored; backfill of enabled channels runs
+// after the handler's usual debounce.
+func (c *Client) EmitHistorySync(chats ...Chat) error {
+	if !c.IsLoggedIn() {
+		return errNotLoggedIn
+	}
+	if c.handler == nil {
+		return errors.New("no handler")
+	}
+
+	conversations := make([]whatsapp.HistoryConversation, 0, len(chats))
+	for _, chat := range chats {
+		jid := userJID(chat.Phone)
+		contact, _ := c.GetContact(context.Background(), jid)
+
+		messages := make([]whatsapp.HistoryMessage, 0, len(chat.Messages))
+		for _, msg := range chat.Messages {
+			name := msg.SenderName
+			if name == "" {
+				name = contact.PushName
+			}
+			if name == "" {
+				name = chat.Phone
+			}
+			messages = append(messages, whatsapp.HistoryMessage{
+				SenderID:   jid.String(),
+				SenderName: name,
+				Text:       msg.Text,
+				Timestamp:  msg.Timestamp,
+			})
+		}
+		// HistorySync lists newest messages first
+		sort.Slice(messages, func(i, j int) bool {
+			return messages[i].Timestamp.After(messages[j].Timestamp)
+		})
+
+		conv := whatsapp.HistoryConversation{ChatJID: jid, MessageCount: len(messages), Messages: messages}
+		if len(messages) > 0 {
+			last := messages[0].Timestamp
+			conv.LastMessageAt = &last
+		}
+		conversations = append(conversations, conv)
+	}
+
+	c.handler.ProcessHistorySync(conversations)
+	return nil
+}
+
+func (c *Client) emit(evt interface{}) {
+	if c.handler != nil {
+		c.handler.HandleEvent(evt)
+	}
+}
+
+func userJID(phone string) types.JID {
+	return types.NewJID(phone, types.DefaultUserServer)
+}
+
+// Factory creates fakes for a ClientManager and keeps the latest one per user
+type Factory struct {
+	mu      sync.Mutex
+	clients map[int64]*Client
+}
+
+// NewFactory creates an empty factory
+func NewFactory() *Factory {
+	return &Factory{clients: make(map[int64]*Client)}
+}
+
+// New creates a fake for userID; it matches clients.ManagerConfig.NewWhatsAppClient
+func (f *Factory) New(userID int64, handler *whatsapp.Handler) (whatsapp.Account, error) {
+	client := NewClient(handler)
+	client.SetUserID(userID)
+
+	f.mu.Lock()
+	f.clients[userID] = client
+	f.mu.Unlock()
+	return client, nil
+}
+
+// Client returns the user's most recently created fake, or nil
+func (f *Factory) Client(userID int64) *Client {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.clients[userID]
+}
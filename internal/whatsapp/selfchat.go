@@ -0,0 +1,71 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// selfChatCommandTimeout bounds running a command and sending its reply
+const selfChatCommandTimeout = 30 * time.Second
+
+// SelfChatCommandHook runs a command the user sent to their own chat ("Message yourself")
+// and returns the reply to post there. handled is false for text that isn't a command.
+type SelfChatCommandHook func(ctx context.Context, userID int64, text string) (reply string, handled bool)
+
+// TextSender sends a plain text message to a chat
+type TextSender func(ctx context.Context, to types.JID, text string) error
+
+// SetSelfChatCommandHook configures the callback that runs commands from the self-chat
+func (h *Handler) SetSelfChatCommandHook(hook SelfChatCommandHook) {
+	h.selfChatMu.Lock()
+	defer h.selfChatMu.Unlock()
+	h.selfChatHook = hook
+}
+
+// SetTextSender makes the handler send replies through sender instead of the
+// WhatsApp client
+func (h *Handler) SetTextSender(sender TextSender) {
+	h.textSender = sender
+}
+
+func (h *Handler) selfChatCommandHook() SelfChatCommandHook {
+	h.selfChatMu.Lock()
+	defer h.selfChatMu.Unlock()
+	return h.selfChatHook
+}
+
+// isSelfChat reports whether a message is one the user sent to their own chat
+func isSelfChat(info types.MessageInfo) bool {
+	return info.IsFromMe && !info.IsGroup && info.Chat.User == info.Sender.User
+}
+
+// runSelfChatCommand runs text as a command and replies in the self-chat
+func (h *Handler) runSelfChatCommand(hook SelfChatCommandHook, chat types.JID, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), selfChatCommandTimeout)
+	defer cancel()
+
+	reply, handled := hook(ctx, h.UserID, text)
+	if !handled || reply == "" {
+		return
+	}
+	if err := h.sendText(ctx, chat, reply); err != nil {
+		slog.Error("WhatsApp: Failed to reply to self-chat command", "user_id", h.UserID, "error", err)
+	}
+}
+
+func (h *Handler) sendText(ctx context.Context, to types.JID, text string) error {
+	if h.textSender != nil {
+		return h.textSender(ctx, to, text)
+	}
+	if h.wClient == nil {
+		return fmt.Errorf("whatsapp client not set")
+	}
+	_, err := h.wClient.SendMessage(ctx, to, &waProto.Message{Conversation: proto.String(text)})
+	return err
+}
@@ -2,60 +2,153 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/omriShneor/project_alfred/internal/agent"
+	"github.com/omriShneor/project_alfred/internal/agent/assistant"
+	"github.com/omriShneor/project_alfred/internal/agent/bill"
+	"github.com/omriShneor/project_alfred/internal/agent/delivery"
 	"github.com/omriShneor/project_alfred/internal/agent/event"
+	"github.com/omriShneor/project_alfred/internal/agent/intents"
+	"github.com/omriShneor/project_alfred/internal/agent/occasion"
 	"github.com/omriShneor/project_alfred/internal/agent/reminder"
+	"github.com/omriShneor/project_alfred/internal/agent/travel"
+	"github.com/omriShneor/project_alfred/internal/auth"
+	"github.com/omriShneor/project_alfred/internal/backup"
 	"github.com/omriShneor/project_alfred/internal/clients"
 	"github.com/omriShneor/project_alfred/internal/config"
 	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/embeddings"
+	"github.com/omriShneor/project_alfred/internal/gcal"
+	"github.com/omriShneor/project_alfred/internal/geocode"
+	"github.com/omriShneor/project_alfred/internal/logging"
+	"github.com/omriShneor/project_alfred/internal/mute"
 	"github.com/omriShneor/project_alfred/internal/notify"
+	"github.com/omriShneor/project_alfred/internal/processor"
+	"github.com/omriShneor/project_alfred/internal/retention"
 	"github.com/omriShneor/project_alfred/internal/server"
+	"github.com/omriShneor/project_alfred/internal/sessionstore"
 	"github.com/omriShneor/project_alfred/internal/sse"
+	"github.com/omriShneor/project_alfred/internal/usage"
+	"github.com/omriShneor/project_alfred/internal/webhook"
 )
 
 func main() {
-	cfg := config.LoadFromEnv()
+	migrateCmd := flag.String("migrate", "", "run a migration command (up, status, verify, down) and exit")
+	migrateTo := flag.Int("migrate-to", -1, "target version for -migrate down")
+	configPath := flag.String("config", "", "YAML config file; environment variables override it (default: ./"+config.DefaultFile+" if present)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fatal("loading configuration", err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		slog.Warn("Invalid logging configuration, using defaults", "error", err)
+	}
+
+	if *migrateCmd != "" {
+		if err := runMigrateCommand(cfg.DBPath, *migrateCmd, *migrateTo, os.Stdout); err != nil {
+			fatal("running migrations", err)
+		}
+		return
+	}
 
 	// Phase 1: Core infrastructure
-	db, err := initDatabase(cfg)
+	encryptor, err := initEncryptor(cfg)
+	if err != nil {
+		slog.Warn("Encryption of messages, tokens and sessions disabled", "error", err)
+	}
+	db, err := initDatabase(cfg, encryptor)
 	if err != nil {
 		fatal("creating database", err)
 	}
 	defer db.Close()
 
 	state := sse.NewState()
+	streams := sse.NewStateManager()
 
-	notifyService := initNotifyService(db, cfg)
+	emailActions := initEmailActionSigner(cfg, encryptor)
+	notifyService := initNotifyService(db, cfg, emailActions)
+	notifyService.SetStreams(streams)
+	webhooks := webhook.NewDispatcher(db)
+	notifyService.SetWebhooks(webhooks)
 	notifyCtx, stopNotifyWorker := context.WithCancel(context.Background())
+	notifyService.StartDeliveryWorker(notifyCtx, 10*time.Second)
 	notifyService.StartDueReminderWorker(notifyCtx, time.Minute)
-
-	eventAnalyzer := initEventAnalyzer(cfg)
-	reminderAnalyzer := initReminderAnalyzer(cfg)
+	notifyService.StartEventNotificationWorker(notifyCtx, time.Minute)
+	notifyService.StartDigestWorker(notifyCtx, time.Minute)
+	webhooks.Start(notifyCtx, 30*time.Second)
+
+	retention.NewPruner(db, cfg.MessageRetentionDays).Start(notifyCtx, time.Duration(cfg.MessagePruneInterval)*time.Minute)
+	mute.NewScheduler(db, streams).Start(notifyCtx, time.Minute)
+	initChannelSuggester(db, cfg).Start(notifyCtx, time.Hour)
+
+	backups := initBackupManager(db, cfg)
+	backups.Start(notifyCtx, time.Duration(cfg.BackupInterval)*time.Minute)
+
+	relatedMessages := initRelatedMessages(notifyCtx, cfg, db)
+	initGeocoding(notifyCtx, cfg, db)
+
+	agent.SetProviderRateLimit(agent.ProviderAnthropic, cfg.AnthropicRateLimit)
+	agent.SetProviderRateLimit(agent.ProviderOpenAI, cfg.OpenAIRateLimit)
+	agent.SetProviderRetryPolicy(agent.ProviderAnthropic, agent.RetryPolicy{
+		MaxRetries: cfg.AnthropicMaxRetries,
+		BaseDelay:  time.Duration(cfg.AnthropicRetryBaseMS) * time.Millisecond,
+		MaxDelay:   time.Duration(cfg.AnthropicRetryMaxMS) * time.Millisecond,
+		Timeout:    time.Duration(cfg.AnthropicTimeoutSeconds) * time.Second,
+	})
+	agent.SetProviderCircuitBreaker(agent.ProviderAnthropic, cfg.AnthropicBreakerThreshold, time.Duration(cfg.AnthropicBreakerCooldownSeconds)*time.Second)
+	usageTracker := usage.NewTracker(db, notifyService, cfg.LLMMonthlyBudget)
+	eventAnalyzer := usageTracker.EventAnalyzer(initEventAnalyzer(cfg, db))
+	reminderAnalyzer := usageTracker.ReminderAnalyzer(initReminderAnalyzer(cfg, db))
+	travelAnalyzer := usageTracker.TravelAnalyzer(initTravelAnalyzer(cfg))
+	billAnalyzer := usageTracker.BillAnalyzer(initBillAnalyzer(cfg))
+	deliveryAnalyzer := usageTracker.DeliveryAnalyzer(initDeliveryAnalyzer(cfg))
+	occasionAnalyzer := usageTracker.OccasionAnalyzer(initOccasionAnalyzer(cfg))
+	assistantAgent := usageTracker.Assistant(initAssistant(cfg, db))
+	shadow := initShadowRunner(cfg, db)
 
 	srv := server.New(server.ServerConfig{
-		DB:              db,
-		OnboardingState: state,
-		Port:            cfg.HTTPPort,
-		ResendAPIKey:    cfg.ResendAPIKey,
-		DevMode:         cfg.DevMode,
-		CredentialsFile: cfg.GoogleCredentialsFile,
-		CredentialsJSON: cfg.GoogleCredentialsJSON,
+		DB:                   db,
+		OnboardingState:      state,
+		Streams:              streams,
+		Port:                 cfg.HTTPPort,
+		DevMode:              cfg.DevMode,
+		CredentialsFile:      cfg.GoogleCredentialsFile,
+		CredentialsJSON:      cfg.GoogleCredentialsJSON,
+		Encryptor:            encryptor,
+		AdminEmails:          cfg.AdminEmails,
+		MessageRetentionDays: cfg.MessageRetentionDays,
+		LLMTemperature:       cfg.ClaudeTemperature,
+		LLMMonthlyBudget:     cfg.LLMMonthlyBudget,
+		LLMProvider:          cfg.LLMProvider,
+		PublicURL:            cfg.PublicURL,
 	})
+	srv.RestoreOnboardingState()
+	srv.SetBackupManager(backups)
+	srv.SetEmailActionSigner(emailActions)
 	srv.InitializeClients(server.ClientsConfig{
 		NotifyService:    notifyService,
 		EventAnalyzer:    eventAnalyzer,
 		ReminderAnalyzer: reminderAnalyzer,
+		TravelAnalyzer:   travelAnalyzer,
+		BillAnalyzer:     billAnalyzer,
+		OccasionAnalyzer: occasionAnalyzer,
+		Assistant:        assistantAgent,
 	})
+	srv.StartGoogleTokenRefresher(notifyCtx, 5*time.Minute)
 	go func() {
 		if err := srv.Start(); err != nil && err != http.ErrServerClosed {
-			fmt.Fprintf(os.Stderr, "HTTP server error: %v\n", err)
+			slog.Error("HTTP server error", "error", err)
 		}
 	}()
 
@@ -63,17 +156,19 @@ func main() {
 
 	// Create ClientManager for per-user WhatsApp/Telegram clients
 	clientManager := clients.NewClientManager(db, &clients.ManagerConfig{
-		WhatsAppDBBasePath: cfg.WhatsAppDBPath,
-		TelegramDBBasePath: cfg.TelegramDBPath,
-		TelegramAPIID:      cfg.TelegramAPIID,
-		TelegramAPIHash:    cfg.TelegramAPIHash,
-		DebugAllMessages:   cfg.DebugAllMessages,
+		WhatsAppDBBasePath:   cfg.WhatsAppDBPath,
+		TelegramDBBasePath:   cfg.TelegramDBPath,
+		TelegramAPIID:        cfg.TelegramAPIID,
+		TelegramAPIHash:      cfg.TelegramAPIHash,
+		SessionCipher:        initSessionCipher(encryptor),
+		SessionFlushOnCommit: cfg.WhatsAppSessionFlushOnCommit,
+		DebugAllMessages:     cfg.DebugAllMessages,
 	}, notifyService, state)
 
 	// Create dev user if in dev mode (for unauthenticated testing)
 	if cfg.DevMode {
 		if err := ensureDevUser(db); err != nil {
-			fmt.Printf("Warning: Failed to create dev user: %v\n", err)
+			slog.Warn("Failed to create dev user", "error", err)
 		}
 	}
 
@@ -88,19 +183,28 @@ func main() {
 		NotifyService:    notifyService,
 		EventAnalyzer:    eventAnalyzer,
 		ReminderAnalyzer: reminderAnalyzer,
+		TravelAnalyzer:   travelAnalyzer,
+		BillAnalyzer:     billAnalyzer,
+		DeliveryAnalyzer: deliveryAnalyzer,
+		OccasionAnalyzer: occasionAnalyzer,
+		RelatedMessages:  relatedMessages,
+		Shadow:           shadow,
 		ClientManager:    clientManager,
+		Streams:          streams,
 	})
 	srv.SetUserServiceManager(userServiceManager)
 
 	// Start a single global processor for all users
 	if err := userServiceManager.StartGlobalProcessor(); err != nil {
-		fmt.Printf("Warning: Failed to start global processor: %v\n", err)
+		slog.Warn("Failed to start global processor", "error", err)
 	}
 
 	// Restore sessions for users who were previously connected
 	if err := clientManager.RestoreUserSessions(ctx); err != nil {
-		fmt.Printf("Warning: Failed to restore some user sessions: %v\n", err)
+		slog.Warn("Failed to restore some user sessions", "error", err)
 	}
+	// Reconnect dropped clients and unload idle unpaired ones
+	clientManager.StartMonitor()
 
 	// Start background services for eligible users (cached auth/sessions)
 	userServiceManager.StartServicesForEligibleUsers()
@@ -108,55 +212,458 @@ func main() {
 	waitForShutdown(srv, clientManager, userServiceManager, stopNotifyWorker)
 }
 
-func initDatabase(cfg *config.Config) (*database.DB, error) {
-	return database.New(cfg.DBPath)
+// initEncryptor returns the encryptor for OAuth tokens, message content and session
+// stores, keyed by the configured encryption key or else the Anthropic API key. Keys are
+// passed explicitly; secrets fetched from a secret manager never go into the environment.
+func initEncryptor(cfg *config.Config) (*auth.Encryptor, error) {
+	key, err := auth.DeriveEncryptionKey(cfg.EncryptionKey, cfg.AnthropicAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewEncryptor(key)
+}
+
+func initDatabase(cfg *config.Config, encryptor *auth.Encryptor) (*database.DB, error) {
+	db, err := database.New(cfg.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	if encryptor == nil {
+		return db, nil
+	}
+	if err := db.SetTokenEncryptionKeys(cfg.EncryptionKey, cfg.AnthropicAPIKey); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Encrypt message content at rest with the same key as OAuth tokens
+	db.SetMessageCipher(encryptor)
+	encrypted, err := db.EncryptStoredMessages(500)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to encrypt stored messages: %w", err)
+	}
+	if encrypted > 0 {
+		slog.Info("Encrypted stored messages", "encrypted", encrypted)
+	}
+	return db, nil
+}
+
+// initSessionCipher returns the cipher that encrypts WhatsApp and Telegram session stores
+// at rest, keyed like OAuth tokens, or nil to keep them in plaintext when no key is set
+func initSessionCipher(encryptor *auth.Encryptor) sessionstore.Cipher {
+	if encryptor == nil {
+		return nil
+	}
+	return encryptor
+}
+
+// initChannelSuggester scores untracked channels with the configured prefilter rules
+func initChannelSuggester(db *database.DB, cfg *config.Config) *processor.ChannelSuggester {
+	prefilter, err := processor.NewPrefilter(processor.PrefilterConfig{
+		Enabled:     cfg.PrefilterEnabled,
+		MinChars:    cfg.PrefilterMinChars,
+		SkipPhrases: cfg.PrefilterSkipPhrases,
+		SkipPattern: cfg.PrefilterSkipPattern,
+		KeepPattern: cfg.PrefilterKeepPattern,
+	})
+	if err != nil {
+		slog.Warn("Using default prefilter rules for channel suggestions", "error", err)
+	}
+	return processor.NewChannelSuggester(db, prefilter)
+}
+
+// initBackupManager returns nil unless a backup directory or S3 bucket is configured
+func initBackupManager(db *database.DB, cfg *config.Config) *backup.Manager {
+	var store backup.Store
+	var err error
+	switch {
+	case cfg.BackupS3Bucket != "":
+		store, err = backup.NewS3Store(backup.S3Config{
+			Bucket:          cfg.BackupS3Bucket,
+			Region:          cfg.BackupS3Region,
+			Endpoint:        cfg.BackupS3Endpoint,
+			Prefix:          cfg.BackupS3Prefix,
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretKey,
+			SessionToken:    cfg.AWSSessionToken,
+		})
+	case cfg.BackupDir != "":
+		store, err = backup.NewDirStore(cfg.BackupDir)
+	default:
+		return nil
+	}
+	if err != nil {
+		slog.Warn("database backups disabled", "error", err)
+		return nil
+	}
+
+	slog.Info("Database backups configured", "store", store, "interval_minutes", cfg.BackupInterval, "keep", cfg.BackupKeep)
+	return backup.NewManager(db, store, cfg.BackupKeep)
 }
 
-func initEventAnalyzer(cfg *config.Config) agent.EventAnalyzer {
-	if cfg.AnthropicAPIKey == "" {
-		fmt.Println("Warning: ANTHROPIC_API_KEY not set, event detection disabled")
+func initEventAnalyzer(cfg *config.Config, db *database.DB) agent.EventAnalyzer {
+	apiKey, model, keyEnv := cfg.LLMCredentials()
+	if apiKey == "" {
+		slog.Warn("API key not set, event detection disabled", "env", keyEnv)
 		return nil
 	}
 	eventAgent := event.NewAgent(event.Config{
-		APIKey:      cfg.AnthropicAPIKey,
-		Model:       cfg.ClaudeModel,
+		Provider:    cfg.LLMProvider,
+		APIKey:      apiKey,
+		Model:       model,
+		FastModel:   cfg.LLMFastModel(),
 		Temperature: cfg.ClaudeTemperature,
+		Calendar:    db,
+		Contacts:    db,
 	})
-	fmt.Println("Event agent configured (tool-calling mode)")
+	if !eventAgent.IsConfigured() {
+		slog.Warn("unsupported ALFRED_LLM_PROVIDER, event detection disabled", "provider", cfg.LLMProvider)
+		return nil
+	}
+	slog.Info("Event agent configured (tool-calling mode)", "provider", cfg.LLMProvider)
 	return eventAgent
 }
 
-func initReminderAnalyzer(cfg *config.Config) agent.ReminderAnalyzer {
-	if cfg.AnthropicAPIKey == "" {
-		fmt.Println("Warning: ANTHROPIC_API_KEY not set, reminder detection disabled")
+func initReminderAnalyzer(cfg *config.Config, db *database.DB) agent.ReminderAnalyzer {
+	apiKey, model, keyEnv := cfg.LLMCredentials()
+	if apiKey == "" {
+		slog.Warn("API key not set, reminder detection disabled", "env", keyEnv)
 		return nil
 	}
 	reminderAgent := reminder.NewAgent(reminder.Config{
-		APIKey:      cfg.AnthropicAPIKey,
-		Model:       cfg.ClaudeModel,
+		Provider:    cfg.LLMProvider,
+		APIKey:      apiKey,
+		Model:       model,
+		FastModel:   cfg.LLMFastModel(),
 		Temperature: cfg.ClaudeTemperature,
+		Contacts:    db,
 	})
-	fmt.Println("Reminder agent configured (tool-calling mode)")
+	if !reminderAgent.IsConfigured() {
+		slog.Warn("unsupported ALFRED_LLM_PROVIDER, reminder detection disabled", "provider", cfg.LLMProvider)
+		return nil
+	}
+	slog.Info("Reminder agent configured (tool-calling mode)", "provider", cfg.LLMProvider)
 	return reminderAgent
 }
 
-func initNotifyService(db *database.DB, cfg *config.Config) *notify.Service {
+func initTravelAnalyzer(cfg *config.Config) agent.TravelAnalyzer {
+	apiKey, model, keyEnv := cfg.LLMCredentials()
+	if apiKey == "" {
+		slog.Warn("API key not set, travel detection disabled", "env", keyEnv)
+		return nil
+	}
+	travelAgent := travel.NewAgent(travel.Config{
+		Provider:    cfg.LLMProvider,
+		APIKey:      apiKey,
+		Model:       model,
+		FastModel:   cfg.LLMFastModel(),
+		Temperature: cfg.ClaudeTemperature,
+	})
+	if !travelAgent.IsConfigured() {
+		slog.Warn("unsupported ALFRED_LLM_PROVIDER, travel detection disabled", "provider", cfg.LLMProvider)
+		return nil
+	}
+	slog.Info("Travel agent configured (tool-calling mode)", "provider", cfg.LLMProvider)
+	return travelAgent
+}
+
+func initBillAnalyzer(cfg *config.Config) agent.BillAnalyzer {
+	apiKey, model, keyEnv := cfg.LLMCredentials()
+	if apiKey == "" {
+		slog.Warn("API key not set, bill detection disabled", "env", keyEnv)
+		return nil
+	}
+	billAgent := bill.NewAgent(bill.Config{
+		Provider:    cfg.LLMProvider,
+		APIKey:      apiKey,
+		Model:       model,
+		FastModel:   cfg.LLMFastModel(),
+		Temperature: cfg.ClaudeTemperature,
+	})
+	if !billAgent.IsConfigured() {
+		slog.Warn("unsupported ALFRED_LLM_PROVIDER, bill detection disabled", "provider", cfg.LLMProvider)
+		return nil
+	}
+	slog.Info("Bill agent configured (tool-calling mode)", "provider", cfg.LLMProvider)
+	return billAgent
+}
+
+func initDeliveryAnalyzer(cfg *config.Config) agent.DeliveryAnalyzer {
+	apiKey, model, keyEnv := cfg.LLMCredentials()
+	if apiKey == "" {
+		slog.Warn("API key not set, delivery tracking disabled", "env", keyEnv)
+		return nil
+	}
+	deliveryAgent := delivery.NewAgent(delivery.Config{
+		Provider:    cfg.LLMProvider,
+		APIKey:      apiKey,
+		Model:       model,
+		FastModel:   cfg.LLMFastModel(),
+		Temperature: cfg.ClaudeTemperature,
+	})
+	if !deliveryAgent.IsConfigured() {
+		slog.Warn("unsupported ALFRED_LLM_PROVIDER, delivery tracking disabled", "provider", cfg.LLMProvider)
+		return nil
+	}
+	slog.Info("Delivery agent configured (tool-calling mode)", "provider", cfg.LLMProvider)
+	return deliveryAgent
+}
+
+func initOccasionAnalyzer(cfg *config.Config) agent.OccasionAnalyzer {
+	apiKey, model, keyEnv := cfg.LLMCredentials()
+	if apiKey == "" {
+		slog.Warn("API key not set, occasion detection disabled", "env", keyEnv)
+		return nil
+	}
+	occasionAgent := occasion.NewAgent(occasion.Config{
+		Provider:    cfg.LLMProvider,
+		APIKey:      apiKey,
+		Model:       model,
+		FastModel:   cfg.LLMFastModel(),
+		Temperature: cfg.ClaudeTemperature,
+	})
+	if !occasionAgent.IsConfigured() {
+		slog.Warn("unsupported ALFRED_LLM_PROVIDER, occasion detection disabled", "provider", cfg.LLMProvider)
+		return nil
+	}
+	slog.Info("Occasion agent configured (tool-calling mode)", "provider", cfg.LLMProvider)
+	return occasionAgent
+}
+
+func initAssistant(cfg *config.Config, db *database.DB) agent.Assistant {
+	apiKey, model, keyEnv := cfg.LLMCredentials()
+	if apiKey == "" {
+		slog.Warn("API key not set, assistant disabled", "env", keyEnv)
+		return nil
+	}
+	assistantCfg := assistant.Config{
+		Provider:    cfg.LLMProvider,
+		APIKey:      apiKey,
+		Model:       model,
+		FastModel:   cfg.LLMFastModel(),
+		Temperature: cfg.ClaudeTemperature,
+		Calendar:    db,
+		Reminders:   db,
+		History:     db,
+		Contacts:    db,
+	}
+	if cfg.GoogleCredentialsFile != "" {
+		assistantCfg.GoogleCalendar = gcal.NewEventLookup(cfg.GoogleCredentialsFile, db)
+	}
+	assistantAgent := assistant.NewAgent(assistantCfg)
+	if !assistantAgent.IsConfigured() {
+		slog.Warn("unsupported ALFRED_LLM_PROVIDER, assistant disabled", "provider", cfg.LLMProvider)
+		return nil
+	}
+	slog.Info("Assistant configured (tool-calling mode)", "provider", cfg.LLMProvider)
+	return assistantAgent
+}
+
+// initShadowRunner builds the shadow event and reminder agents from the ALFRED_SHADOW_*
+// settings. Returns nil unless ALFRED_SHADOW_ENABLED is set and the shadow provider has
+// an API key. Shadow agents are not metered against users' budgets.
+func initShadowRunner(cfg *config.Config, db *database.DB) *processor.ShadowRunner {
+	if !cfg.ShadowEnabled {
+		return nil
+	}
+	apiKey, model, keyEnv := cfg.ShadowLLMCredentials()
+	if apiKey == "" {
+		slog.Warn("API key not set, shadow mode disabled", "env", keyEnv)
+		return nil
+	}
+	eventPrompt, err := readPromptFile(cfg.ShadowEventPromptFile)
+	if err != nil {
+		slog.Warn("shadow mode disabled", "error", err)
+		return nil
+	}
+	reminderPrompt, err := readPromptFile(cfg.ShadowReminderPromptFile)
+	if err != nil {
+		slog.Warn("shadow mode disabled", "error", err)
+		return nil
+	}
+
+	eventAgent := event.NewAgent(event.Config{
+		Provider:     cfg.ShadowProvider,
+		APIKey:       apiKey,
+		Model:        model,
+		Temperature:  cfg.ShadowTemperature,
+		SystemPrompt: eventPrompt,
+		Calendar:     db,
+		Contacts:     db,
+	})
+	reminderAgent := reminder.NewAgent(reminder.Config{
+		Provider:     cfg.ShadowProvider,
+		APIKey:       apiKey,
+		Model:        model,
+		Temperature:  cfg.ShadowTemperature,
+		SystemPrompt: reminderPrompt,
+		Contacts:     db,
+	})
+	if !eventAgent.IsConfigured() || !reminderAgent.IsConfigured() {
+		slog.Warn("unsupported ALFRED_SHADOW_PROVIDER, shadow mode disabled", "provider", cfg.ShadowProvider)
+		return nil
+	}
+
+	shadow := processor.NewShadowRunner(db, cfg.ShadowLabel, cfg.ShadowSampleRate)
+	shadow.Register(&intents.EventModule{Analyzer: eventAgent})
+	shadow.Register(&intents.ReminderModule{Analyzer: reminderAgent})
+	slog.Info("Shadow mode enabled", "label", cfg.ShadowLabel, "provider", cfg.ShadowProvider, "model", model, "sample", cfg.ShadowSampleRate)
+	return shadow
+}
+
+// readPromptFile returns the contents of a prompt override file ("" when path is unset)
+func readPromptFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt file: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// initRelatedMessages starts the message_history embedding indexer and returns the
+// retriever the processor uses to add related earlier messages to event analysis.
+// Returns nil when ALFRED_RAG_ENABLED is off or OPENAI_API_KEY is missing.
+func initRelatedMessages(ctx context.Context, cfg *config.Config, db *database.DB) *embeddings.Retriever {
+	if !cfg.RAGEnabled {
+		return nil
+	}
+	if cfg.OpenAIAPIKey == "" {
+		slog.Warn("OPENAI_API_KEY not set, related message retrieval disabled")
+		return nil
+	}
+	embedder := embeddings.NewOpenAIEmbedder(cfg.OpenAIAPIKey, cfg.EmbeddingModel)
+	embeddings.NewIndexer(db, embedder).Start(ctx, time.Duration(cfg.EmbeddingIndexInterval)*time.Minute)
+	slog.Info("Related message retrieval enabled", "model", embedder.Model())
+	return embeddings.NewRetriever(db, embedder, cfg.RAGRelatedMessages)
+}
+
+// initGeocoding starts the background worker that resolves event locations to
+// coordinates and map links. Does nothing unless ALFRED_GEOCODER is set.
+func initGeocoding(ctx context.Context, cfg *config.Config, db *database.DB) {
+	if cfg.Geocoder == "" {
+		return
+	}
+	geocoder, err := geocode.New(cfg.Geocoder, cfg.GeocoderURL, cfg.GoogleMapsAPIKey)
+	if err != nil {
+		slog.Warn("Event location geocoding disabled", "error", err)
+		return
+	}
+	geocode.NewWorker(db, geocoder).Start(ctx, time.Duration(cfg.GeocodeInterval)*time.Minute)
+	slog.Info("Event location geocoding enabled", "geocoder", cfg.Geocoder)
+}
+
+// initEmailActionSigner creates the signer for one-click confirm/reject links in emails,
+// keyed by ALFRED_EMAIL_LINK_SECRET or else derived from the encryption key
+func initEmailActionSigner(cfg *config.Config, encryptor *auth.Encryptor) *notify.EmailActionSigner {
+	if cfg.EmailLinkSecret != "" {
+		return notify.NewEmailActionSigner([]byte(cfg.EmailLinkSecret))
+	}
+	if encryptor == nil {
+		slog.Warn("one-click email links disabled: no encryption key")
+		return nil
+	}
+	return notify.NewEmailActionSigner(encryptor.DeriveKey("email-actions"))
+}
+
+func initNotifyService(db *database.DB, cfg *config.Config, emailActions *notify.EmailActionSigner) *notify.Service {
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		publicURL = fmt.Sprintf("http://localhost:%d", cfg.HTTPPort)
+	}
+
+	var email notify.EmailNotifier
+	switch {
+	case cfg.ResendAPIKey != "":
+		email = notify.NewResendNotifier(cfg.ResendAPIKey, cfg.EmailFrom, publicURL)
+		slog.Info("Email notification service configured (Resend)")
+	case cfg.SMTPHost != "":
+		smtpNotifier, err := notify.NewSMTPNotifier(notify.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Security: cfg.SMTPSecurity,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.EmailFrom,
+		}, publicURL)
+		if err != nil {
+			slog.Warn("SMTP email disabled", "error", err)
+			break
+		}
+		email = smtpNotifier
+		slog.Info("Email notification service configured (SMTP)", "host", cfg.SMTPHost)
+	}
+
 	var emailNotifier notify.Notifier
-	if cfg.ResendAPIKey != "" {
-		emailNotifier = notify.NewResendNotifier(
-			cfg.ResendAPIKey,
-			cfg.EmailFrom,
-			fmt.Sprintf("http://localhost:%d", cfg.HTTPPort),
-		)
-		if emailNotifier != nil && emailNotifier.IsConfigured() {
-			fmt.Println("Email notification service configured (Resend)")
+	if email != nil {
+		if emailActions != nil {
+			email.SetActionSigner(emailActions)
 		}
+		emailNotifier = email
 	}
 
 	pushNotifier := notify.NewExpoPushNotifier()
-	fmt.Println("Push notification service configured (Expo)")
+	slog.Info("Push notification service configured (Expo)")
+
+	notifyService := notify.NewService(db, emailNotifier, pushNotifier)
+
+	if cfg.FCMCredentialsFile != "" {
+		fcm, err := notify.NewFCMPushNotifier(cfg.FCMCredentialsFile)
+		if err != nil {
+			slog.Warn("FCM push disabled", "error", err)
+		} else {
+			notifyService.SetPushProvider(database.PushProviderFCM, fcm)
+			slog.Info("Push notification service configured (FCM)")
+		}
+	}
+	if cfg.APNsKeyFile != "" {
+		apns, err := notify.NewAPNsPushNotifier(cfg.APNsKeyFile, cfg.APNsKeyID, cfg.APNsTeamID, cfg.APNsTopic, cfg.APNsSandbox)
+		if err != nil {
+			slog.Warn("APNs push disabled", "error", err)
+		} else {
+			notifyService.SetPushProvider(database.PushProviderAPNs, apns)
+			slog.Info("Push notification service configured (APNs)")
+		}
+	}
+
+	offsets := make([]time.Duration, 0, len(cfg.ReminderNotifyOffsets))
+	for _, minutes := range cfg.ReminderNotifyOffsets {
+		offsets = append(offsets, time.Duration(minutes)*time.Minute)
+	}
+	notifyService.SetDueReminderOffsets(offsets)
+	notifyService.SetPushBatchWindow(time.Duration(cfg.NotifyBatchWindowSeconds) * time.Second)
 
-	return notify.NewService(db, emailNotifier, pushNotifier)
+	if cfg.GoogleCredentialsFile != "" {
+		notifyService.SetDigestCalendar(digestCalendar{lookup: gcal.NewEventLookup(cfg.GoogleCredentialsFile, db)})
+	}
+
+	return notifyService
+}
+
+// digestCalendar lists users' Google Calendar events for the morning digest
+type digestCalendar struct {
+	lookup *gcal.EventLookup
+}
+
+func (c digestCalendar) ListDigestEvents(userID int64, start, end time.Time) ([]notify.DigestCalendarEvent, error) {
+	events, err := c.lookup.ListUserEvents(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]notify.DigestCalendarEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, notify.DigestCalendarEvent{
+			GoogleEventID: event.ID,
+			Title:         event.Summary,
+			StartTime:     event.StartTime,
+			AllDay:        event.AllDay,
+			Location:      event.Location,
+		})
+	}
+	return result, nil
 }
 
 // ensureDevUser creates the dev user (ID 1) if it doesn't exist
@@ -176,11 +683,11 @@ func ensureDevUser(db *database.DB) error {
 	if err == nil {
 		// User exists
 		if existingEmail == devEmail {
-			fmt.Printf("✅ Dev user already exists (ID: %d, Email: %s)\n", devUserID, devEmail)
+			slog.Info("Dev user already exists", "dev_user_id", devUserID, "dev_email", devEmail)
 			return nil
 		}
 		// User 1 exists but with different email - this is unexpected
-		fmt.Printf("⚠️  Warning: User ID 1 exists with different email (%s), not creating dev user\n", existingEmail)
+		slog.Warn("User ID 1 exists with different email, not creating dev user", "existing_email", existingEmail)
 		return nil
 	}
 
@@ -194,12 +701,12 @@ func ensureDevUser(db *database.DB) error {
 		return fmt.Errorf("failed to create dev user: %w", err)
 	}
 
-	fmt.Printf("✅ Dev user created (ID: %d, Email: %s)\n", devUserID, devEmail)
+	slog.Info("Dev user created", "dev_user_id", devUserID, "dev_email", devEmail)
 	return nil
 }
 
 func fatal(context string, err error) {
-	fmt.Fprintf(os.Stderr, "Error %s: %v\n", context, err)
+	slog.Error("Error "+context, "error", err)
 	os.Exit(1)
 }
 
@@ -213,7 +720,7 @@ func waitForShutdown(
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
 
-	fmt.Println("Shutting down...")
+	slog.Info("Shutting down...")
 	if stopNotifyWorker != nil {
 		stopNotifyWorker()
 	}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/omriShneor/project_alfred/internal/database"
+	"github.com/omriShneor/project_alfred/internal/database/migrations"
+)
+
+// runMigrateCommand handles the -migrate flag: it runs migrations.RunCommand on the
+// database at dbPath without starting the server
+func runMigrateCommand(dbPath, cmd string, target int, out io.Writer) error {
+	if cmd == "down" && target < 0 {
+		return fmt.Errorf("-migrate down requires -migrate-to <version>")
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return migrations.RunCommand(db.DB, cmd, target, out)
+}
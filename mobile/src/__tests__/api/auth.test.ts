@@ -82,59 +82,44 @@ describe('Auth API - Incremental Authorization', () => {
   });
 
   describe('exchangeAddScopesCode', () => {
-    it('exchanges code for Gmail scope without redirect URI', async () => {
+    it('exchanges code for Gmail scope with its state', async () => {
       const mockResponse = { status: 'scopes_added' };
       mockApiClient.post.mockResolvedValueOnce(mockResponse);
 
-      const result = await exchangeAddScopesCode('test-auth-code', ['gmail']);
+      const result = await exchangeAddScopesCode('test-auth-code', 'test-state', ['gmail']);
 
       expect(mockApiClient.post).toHaveBeenCalledWith('/api/auth/google/add-scopes/callback', {
         code: 'test-auth-code',
+        state: 'test-state',
         scopes: ['gmail'],
       });
       expect(result).toEqual(mockResponse);
     });
 
-    it('exchanges code for Calendar scope without redirect URI', async () => {
+    it('exchanges code for Calendar scope with its state', async () => {
       const mockResponse = { status: 'scopes_added' };
       mockApiClient.post.mockResolvedValueOnce(mockResponse);
 
-      const result = await exchangeAddScopesCode('test-auth-code', ['calendar']);
+      const result = await exchangeAddScopesCode('test-auth-code', 'test-state', ['calendar']);
 
       expect(mockApiClient.post).toHaveBeenCalledWith('/api/auth/google/add-scopes/callback', {
         code: 'test-auth-code',
+        state: 'test-state',
         scopes: ['calendar'],
       });
       expect(result).toEqual(mockResponse);
     });
 
-    it('exchanges code with redirect URI', async () => {
-      const mockResponse = { status: 'scopes_added' };
-      mockApiClient.post.mockResolvedValueOnce(mockResponse);
-
-      const result = await exchangeAddScopesCode(
-        'test-auth-code',
-        ['gmail'],
-        'https://example.com/callback'
-      );
-
-      expect(mockApiClient.post).toHaveBeenCalledWith('/api/auth/google/add-scopes/callback', {
-        code: 'test-auth-code',
-        scopes: ['gmail'],
-        redirect_uri: 'https://example.com/callback',
-      });
-      expect(result).toEqual(mockResponse);
-    });
-
     it('exchanges code for multiple scopes', async () => {
       const mockResponse = { status: 'scopes_added' };
       mockApiClient.post.mockResolvedValueOnce(mockResponse);
 
       const scopes: ScopeType[] = ['gmail', 'calendar'];
-      const result = await exchangeAddScopesCode('test-auth-code', scopes);
+      const result = await exchangeAddScopesCode('test-auth-code', 'test-state', scopes);
 
       expect(mockApiClient.post).toHaveBeenCalledWith('/api/auth/google/add-scopes/callback', {
         code: 'test-auth-code',
+        state: 'test-state',
         scopes: ['gmail', 'calendar'],
       });
       expect(result).toEqual(mockResponse);
@@ -144,16 +129,25 @@ describe('Auth API - Incremental Authorization', () => {
       const error = new Error('Invalid authorization code');
       mockApiClient.post.mockRejectedValueOnce(error);
 
-      await expect(exchangeAddScopesCode('invalid-code', ['gmail'])).rejects.toThrow(
+      await expect(exchangeAddScopesCode('invalid-code', 'test-state', ['gmail'])).rejects.toThrow(
         'Invalid authorization code'
       );
     });
 
+    it('handles an invalid or expired state', async () => {
+      const error = new Error('invalid or expired oauth state');
+      mockApiClient.post.mockRejectedValueOnce(error);
+
+      await expect(exchangeAddScopesCode('test-code', 'used-state', ['gmail'])).rejects.toThrow(
+        'invalid or expired oauth state'
+      );
+    });
+
     it('handles failed scope addition', async () => {
       const error = new Error('failed to add scopes: token exchange failed');
       mockApiClient.post.mockRejectedValueOnce(error);
 
-      await expect(exchangeAddScopesCode('test-code', ['calendar'])).rejects.toThrow(
+      await expect(exchangeAddScopesCode('test-code', 'test-state', ['calendar'])).rejects.toThrow(
         'failed to add scopes'
       );
     });
@@ -13,7 +13,7 @@ interface AddScopesResponse {
 
 interface AddScopesCallbackRequest {
   code: string;
-  redirect_uri?: string;
+  state: string;
   scopes: ScopeType[];
 }
 
@@ -50,16 +50,15 @@ export async function requestAdditionalScopes(
 
 /**
  * Exchange the authorization code after incremental authorization
- * This merges the new scopes with the user's existing scopes
+ * This merges the new scopes with the user's existing scopes.
+ * state is the one returned with the code; the backend accepts each state once
+ * and grants the scopes requested when the auth URL was issued.
  */
 export async function exchangeAddScopesCode(
   code: string,
-  scopes: ScopeType[],
-  redirectUri?: string
+  state: string,
+  scopes: ScopeType[]
 ): Promise<AddScopesCallbackResponse> {
-  const body: AddScopesCallbackRequest = { code, scopes };
-  if (redirectUri) {
-    body.redirect_uri = redirectUri;
-  }
+  const body: AddScopesCallbackRequest = { code, state, scopes };
   return apiClient.post<AddScopesCallbackResponse>('/api/auth/google/add-scopes/callback', body);
 }
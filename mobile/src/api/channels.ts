@@ -4,6 +4,8 @@ import type {
   CreateChannelRequest,
   UpdateChannelRequest,
   SourceTopContact,
+  ChannelImportContact,
+  ChannelImportResponse,
 } from '../types/channel';
 
 export async function listChannels(type?: string): Promise<Channel[]> {
@@ -26,6 +28,18 @@ export async function deleteChannel(id: number): Promise<void> {
   await apiClient.delete(`/api/whatsapp/channel/${id}`);
 }
 
+// importChannels tracks up to 500 WhatsApp and Telegram contacts at once. Nothing is
+// imported if any contact is invalid (ApiError code 'validation_failed').
+export async function importChannels(
+  contacts: ChannelImportContact[],
+  backfillDays?: number
+): Promise<ChannelImportResponse> {
+  return apiClient.post<ChannelImportResponse>('/api/channels/import', {
+    contacts,
+    backfill_days: backfillDays,
+  });
+}
+
 export async function getWhatsAppTopContacts(): Promise<SourceTopContact[]> {
   const response = await apiClient.get<{ contacts: SourceTopContact[] }>('/api/whatsapp/top-contacts');
   return response.contacts || [];
@@ -1,5 +1,10 @@
 import { API_BASE_URL } from '../config/api';
-import { getSessionToken, clearAllAuthData } from '../auth/storage';
+import {
+  getSessionToken,
+  getRefreshToken,
+  setAuthTokens,
+  clearAllAuthData,
+} from '../auth/storage';
 
 const TIMEOUT = 30000;
 
@@ -19,6 +24,58 @@ function notifyAuthError() {
   authListeners.forEach((listener) => listener());
 }
 
+// Access tokens are short-lived; concurrent 401s share a single refresh so the
+// rotated refresh token is only presented once (reuse revokes the session).
+let refreshInFlight: Promise<boolean> | null = null;
+
+export function refreshAccessToken(): Promise<boolean> {
+  if (!refreshInFlight) {
+    refreshInFlight = (async () => {
+      const refreshToken = await getRefreshToken();
+      if (!refreshToken) {
+        return false;
+      }
+
+      const response = await fetch(`${API_BASE_URL}/api/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      });
+      if (!response.ok) {
+        return false;
+      }
+
+      const data = await response.json();
+      await setAuthTokens(data.access_token, data.refresh_token);
+      return true;
+    })().finally(() => {
+      refreshInFlight = null;
+    });
+  }
+  return refreshInFlight;
+}
+
+// Bodies of GET responses that carried an ETag, keyed by URL. The server answers 304
+// when the body is unchanged and the cached copy is returned instead.
+const MAX_CACHED_RESPONSES = 50;
+const responseCache = new Map<string, { etag: string; data: unknown }>();
+
+// clearResponseCache forgets cached responses; call it when the user signs out
+export function clearResponseCache() {
+  responseCache.clear();
+}
+
+function cacheResponse(url: string, etag: string, data: unknown) {
+  responseCache.delete(url);
+  responseCache.set(url, { etag, data });
+  if (responseCache.size > MAX_CACHED_RESPONSES) {
+    const oldest = responseCache.keys().next().value;
+    if (oldest !== undefined) {
+      responseCache.delete(oldest);
+    }
+  }
+}
+
 interface RequestOptions {
   params?: Record<string, string | number | undefined>;
   body?: unknown;
@@ -28,7 +85,8 @@ interface RequestOptions {
 async function request<T>(
   method: string,
   path: string,
-  options: RequestOptions = {}
+  options: RequestOptions = {},
+  retried = false
 ): Promise<T> {
   const { params, body, skipAuth = false } = options;
 
@@ -54,8 +112,14 @@ async function request<T>(
   // Build headers
   const headers: Record<string, string> = {
     'Content-Type': 'application/json',
+    'X-Error-Format': 'envelope',
   };
 
+  const cached = method === 'GET' ? responseCache.get(url) : undefined;
+  if (cached) {
+    headers['If-None-Match'] = cached.etag;
+  }
+
   // Add auth header if we have a token and auth is not skipped
   if (!skipAuth) {
     const token = await getSessionToken();
@@ -78,22 +142,37 @@ async function request<T>(
 
     clearTimeout(timeoutId);
 
-    // Handle 401 Unauthorized - session expired
+    // Handle 401 Unauthorized - refresh the access token once, then give up
     if (response.status === 401) {
+      if (!skipAuth && !retried && (await refreshAccessToken())) {
+        return request<T>(method, path, options, true);
+      }
       if (__DEV__) {
         console.log('[API] Session expired, clearing auth data');
       }
       await clearAllAuthData();
+      clearResponseCache();
       notifyAuthError();
       throw new AuthError('Session expired');
     }
 
+    if (response.status === 304 && cached) {
+      cacheResponse(url, cached.etag, cached.data);
+      return cached.data as T;
+    }
+
     if (!response.ok) {
       const errorData = await response.json().catch(() => ({}));
       if (__DEV__) {
         console.error('[API Error]', errorData);
       }
-      throw new Error(errorData.error || `HTTP ${response.status}`);
+      const body: ErrorBody | undefined = errorData.error;
+      throw new ApiError(
+        response.status,
+        body?.code ?? 'unknown',
+        body?.message || `HTTP ${response.status}`,
+        body?.details
+      );
     }
 
     // Handle empty responses (204 No Content)
@@ -101,6 +180,12 @@ async function request<T>(
       return undefined as T;
     }
 
+    const etag = method === 'GET' ? response.headers.get('ETag') : null;
+    if (etag) {
+      const data = await response.json();
+      cacheResponse(url, etag, data);
+      return data;
+    }
     return response.json();
   } catch (error) {
     clearTimeout(timeoutId);
@@ -114,6 +199,31 @@ async function request<T>(
   }
 }
 
+// Error envelope the server sends when asked with X-Error-Format: envelope
+interface ErrorBody {
+  code: string;
+  message: string;
+  details?: Record<string, unknown>;
+}
+
+// ApiError is a failed API response. Branch on `code` (e.g. 'event_not_pending',
+// 'gcal_not_connected'); `message` is for display only.
+export class ApiError extends Error {
+  constructor(
+    public status: number,
+    public code: string,
+    message: string,
+    public details?: Record<string, unknown>
+  ) {
+    super(message);
+    this.name = 'ApiError';
+  }
+}
+
+export function isApiError(error: unknown, code?: string): error is ApiError {
+  return error instanceof ApiError && (code === undefined || error.code === code);
+}
+
 export class AuthError extends Error {
   constructor(message: string) {
     super(message);
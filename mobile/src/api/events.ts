@@ -6,6 +6,7 @@ import type {
   MessageHistory,
   Calendar,
 } from '../types/event';
+import type { ConvertToReminderRequest, Reminder } from '../types/reminder';
 
 export interface ListEventsParams {
   status?: string;
@@ -35,6 +36,13 @@ export async function rejectEvent(id: number): Promise<CalendarEvent> {
   return apiClient.post<CalendarEvent>(`/api/events/${id}/reject`);
 }
 
+export async function convertEventToReminder(
+  id: number,
+  data?: ConvertToReminderRequest
+): Promise<Reminder> {
+  return apiClient.post<Reminder>(`/api/events/${id}/convert-to-reminder`, data);
+}
+
 export async function getChannelHistory(
   channelId: number
 ): Promise<MessageHistory[]> {
@@ -3,6 +3,7 @@ import { apiClient } from './client';
 export interface GCalStatus {
   connected: boolean;
   has_scopes: boolean;
+  reauth_required: boolean;
   message: string;
 }
 
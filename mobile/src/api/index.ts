@@ -1,12 +1,22 @@
-export { apiClient } from './client';
+export { apiClient, ApiError, isApiError } from './client';
 export { getHealth, type HealthStatus } from './health';
 export { requestAdditionalScopes, exchangeAddScopesCode, type ScopeType } from './auth';
-export { listChannels, createChannel, updateChannel, deleteChannel } from './channels';
-export { listEvents, getEvent, updateEvent, confirmEvent, rejectEvent, getChannelHistory, listCalendars, type ListEventsParams } from './events';
+export { listChannels, createChannel, updateChannel, deleteChannel, importChannels } from './channels';
+export { listEvents, getEvent, updateEvent, confirmEvent, rejectEvent, convertEventToReminder, getChannelHistory, listCalendars, type ListEventsParams } from './events';
 export { getWhatsAppStatus, generatePairingCode, disconnectWhatsApp, reconnectWhatsApp, type WhatsAppStatus, type PairingCodeResponse } from './whatsapp';
 export { getGCalStatus, getOAuthURL, exchangeOAuthCode, disconnectGScope, getGCalSettings, updateGCalSettings, type GCalStatus, type GCalConnectResponse, type GCalSettings, type UpdateGCalSettingsRequest } from './gcal';
-export { getNotificationPrefs, updateEmailPrefs, registerPushToken, updatePushPrefs, type NotificationPreferences, type NotificationPrefsResponse } from './notifications';
-export { getOnboardingStatus, type OnboardingStatus } from './onboarding';
+export { getNotificationPrefs, updateEmailPrefs, registerPushToken, updatePushPrefs, listDevices, deleteDevice, getPendingCount, markNotificationsRead, type NotificationPreferences, type NotificationPrefsResponse, type PushDevice, type PendingCounts } from './notifications';
+export {
+  getOnboardingStatus,
+  getOnboardingSteps,
+  updateOnboardingSteps,
+  type OnboardingStatus,
+  type OnboardingStep,
+  type OnboardingStepName,
+  type OnboardingStepStatus,
+  type OnboardingSteps,
+  type OnboardingStepUpdate,
+} from './onboarding';
 export {
   getGmailStatus,
   listEmailSources,
@@ -28,6 +38,8 @@ export {
   getTelegramStatus,
   sendTelegramCode,
   verifyTelegramCode,
+  verifyTelegramPassword,
+  startTelegramQRLogin,
   disconnectTelegram,
   reconnectTelegram,
   discoverTelegramChannels,
@@ -30,14 +30,54 @@ export async function updateEmailPrefs(enabled: boolean, address: string): Promi
   });
 }
 
-export async function registerPushToken(token: string): Promise<{ status: string }> {
+export interface PushDevice {
+  id: number;
+  push_token: string;
+  platform?: string;
+  device_name?: string;
+  created_at: string;
+  last_seen_at: string;
+}
+
+export async function registerPushToken(
+  token: string,
+  platform?: string,
+  deviceName?: string
+): Promise<{ status: string }> {
   return apiClient.post<{ status: string }>('/api/notifications/push/register', {
     token,
+    platform,
+    device_name: deviceName,
   });
 }
 
+export async function listDevices(): Promise<PushDevice[]> {
+  return apiClient.get<PushDevice[]>('/api/devices');
+}
+
+export async function deleteDevice(id: number): Promise<{ status: string }> {
+  return apiClient.delete<{ status: string }>(`/api/devices/${id}`);
+}
+
 export async function updatePushPrefs(enabled: boolean): Promise<NotificationPreferences> {
   return apiClient.put<NotificationPreferences>('/api/notifications/push', {
     enabled,
   });
 }
+
+// What is waiting for the user, for the app icon badge and home-screen indicators
+export interface PendingCounts {
+  pending_events: number;
+  pending_reminders: number;
+  unread_notifications: number; // Delivered pushes not yet read, other than those about pending items
+  total: number;
+}
+
+export async function getPendingCount(): Promise<PendingCounts> {
+  return apiClient.get<PendingCounts>('/api/pending-count');
+}
+
+// Marks delivered pushes read (all of them when ids is left out) and returns the new counts
+export async function markNotificationsRead(ids?: number[]): Promise<PendingCounts> {
+  return apiClient.post<PendingCounts>('/api/notifications/read', ids ? { ids } : {});
+}
@@ -6,6 +6,13 @@ export interface OnboardingStatus {
     qr?: string;
     error?: string;
   };
+  telegram: {
+    // password_required after a QR or code login on an account with two-step verification
+    status: string;
+    qr_code?: string; // QR login code as a PNG data URL, while status is waiting
+    login_url?: string; // tg://login URL of the QR code, opens Telegram on this device
+    error?: string;
+  };
   gcal: {
     status: string;
     configured: boolean;
@@ -17,3 +24,41 @@ export interface OnboardingStatus {
 export async function getOnboardingStatus(): Promise<OnboardingStatus> {
   return apiClient.get<OnboardingStatus>('/api/onboarding/status');
 }
+
+export type OnboardingStepName =
+  | 'welcome'
+  | 'input_selection'
+  | 'whatsapp'
+  | 'telegram'
+  | 'gmail'
+  | 'google_calendar'
+  | 'source_configuration';
+
+export type OnboardingStepStatus = 'pending' | 'in_progress' | 'completed' | 'skipped';
+
+export interface OnboardingStep {
+  step: OnboardingStepName;
+  status: OnboardingStepStatus;
+  data?: Record<string, unknown>; // What the screen needs to resume, e.g. the selected inputs
+  updated_at?: string;
+}
+
+export interface OnboardingSteps {
+  current_step?: OnboardingStepName; // First step still to do; absent once onboarding is complete
+  complete: boolean;
+  steps: OnboardingStep[];
+}
+
+export interface OnboardingStepUpdate {
+  step: OnboardingStepName;
+  status: OnboardingStepStatus;
+  data?: Record<string, unknown>; // Left out keeps the stored data
+}
+
+export async function getOnboardingSteps(): Promise<OnboardingSteps> {
+  return apiClient.get<OnboardingSteps>('/api/onboarding/steps');
+}
+
+export async function updateOnboardingSteps(steps: OnboardingStepUpdate[]): Promise<OnboardingSteps> {
+  return apiClient.put<OnboardingSteps>('/api/onboarding/steps', { steps });
+}
@@ -5,6 +5,7 @@ import type {
   ReminderWithMessage,
   UpdateReminderRequest,
 } from '../types/reminder';
+import type { CalendarEvent, ConvertToEventRequest } from '../types/event';
 
 export interface ListRemindersParams {
   status?: string;
@@ -38,6 +39,13 @@ export async function rejectReminder(id: number): Promise<Reminder> {
   return apiClient.post<Reminder>(`/api/reminders/${id}/reject`);
 }
 
+export async function convertReminderToEvent(
+  id: number,
+  data?: ConvertToEventRequest
+): Promise<CalendarEvent> {
+  return apiClient.post<CalendarEvent>(`/api/reminders/${id}/convert-to-event`, data);
+}
+
 export async function completeReminder(id: number): Promise<Reminder> {
   return apiClient.post<Reminder>(`/api/reminders/${id}/complete`);
 }
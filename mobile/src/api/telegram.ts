@@ -4,6 +4,8 @@ import type { Channel, DiscoverableChannel, SourceTopContact } from '../types/ch
 // Telegram status response
 export interface TelegramStatus {
   connected: boolean;
+  // Set by verify-code for accounts with two-step verification
+  password_required?: boolean;
   message?: string;
 }
 
@@ -22,6 +24,16 @@ export async function verifyTelegramCode(code: string): Promise<TelegramStatus>
   return apiClient.post<TelegramStatus>('/api/telegram/verify-code', { code });
 }
 
+// Finish login with the account's two-step verification password
+export async function verifyTelegramPassword(password: string): Promise<TelegramStatus> {
+  return apiClient.post<TelegramStatus>('/api/telegram/password', { password });
+}
+
+// Start logging in by QR code; the code is polled from the onboarding status
+export async function startTelegramQRLogin(): Promise<{ status: string; message: string }> {
+  return apiClient.post<{ status: string; message: string }>('/api/telegram/qr-login');
+}
+
 // Disconnect Telegram
 export async function disconnectTelegram(): Promise<void> {
   await apiClient.post('/api/telegram/disconnect');
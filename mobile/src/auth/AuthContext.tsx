@@ -1,7 +1,8 @@
 import React, { createContext, useContext, useEffect, useState, useCallback } from 'react';
 import {
   getSessionToken,
-  setSessionToken,
+  getRefreshToken,
+  setAuthTokens,
   clearAllAuthData,
   getStoredUser,
   setStoredUser,
@@ -9,6 +10,7 @@ import {
   StoredUser,
 } from './storage';
 import { API_BASE_URL } from '../config/api';
+import { clearResponseCache, refreshAccessToken } from '../api/client';
 
 export interface User {
   id: number;
@@ -22,7 +24,7 @@ interface AuthContextType {
   user: User | null;
   isAuthenticated: boolean;
   isLoading: boolean;
-  login: (code: string, redirectUri: string) => Promise<void>;
+  login: (code: string, state: string) => Promise<void>;
   logout: () => Promise<void>;
   getToken: () => Promise<string | null>;
 }
@@ -49,11 +51,21 @@ export function AuthProvider({ children }: AuthProviderProps) {
         if (token && storedUser) {
           // Validate token is still valid by calling /api/auth/me
           try {
-            const response = await fetch(`${API_BASE_URL}/api/auth/me`, {
-              headers: {
-                Authorization: `Bearer ${token}`,
-              },
-            });
+            const fetchMe = (accessToken: string) =>
+              fetch(`${API_BASE_URL}/api/auth/me`, {
+                headers: {
+                  Authorization: `Bearer ${accessToken}`,
+                },
+              });
+
+            let response = await fetchMe(token);
+            // The access token has likely expired; renew it with the refresh token
+            if (response.status === 401 && (await refreshAccessToken())) {
+              const refreshed = await getSessionToken();
+              if (refreshed) {
+                response = await fetchMe(refreshed);
+              }
+            }
 
             if (response.ok) {
               const userData = await response.json();
@@ -87,7 +99,7 @@ export function AuthProvider({ children }: AuthProviderProps) {
     loadAuthState();
   }, []);
 
-  const login = useCallback(async (code: string, redirectUri: string) => {
+  const login = useCallback(async (code: string, state: string) => {
     setIsLoading(true);
     try {
       // Exchange the OAuth code for a session token
@@ -96,7 +108,7 @@ export function AuthProvider({ children }: AuthProviderProps) {
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify({ code, redirect_uri: redirectUri }),
+        body: JSON.stringify({ code, state }),
       });
 
       if (!response.ok) {
@@ -106,8 +118,8 @@ export function AuthProvider({ children }: AuthProviderProps) {
 
       const data = await response.json();
 
-      // Store the session token and user
-      await setSessionToken(data.session_token);
+      // Store the access/refresh tokens and user
+      await setAuthTokens(data.access_token, data.refresh_token);
 
       const newUser: User = {
         id: data.user.id,
@@ -134,15 +146,17 @@ export function AuthProvider({ children }: AuthProviderProps) {
   const logout = useCallback(async () => {
     setIsLoading(true);
     try {
-      const token = await getSessionToken();
-      if (token) {
-        // Notify server of logout
+      const [token, refreshToken] = await Promise.all([getSessionToken(), getRefreshToken()]);
+      if (token || refreshToken) {
+        // Notify server of logout so the session (and its refresh token) is revoked
         try {
           await fetch(`${API_BASE_URL}/api/auth/google/logout`, {
             method: 'POST',
             headers: {
-              Authorization: `Bearer ${token}`,
+              'Content-Type': 'application/json',
+              ...(token ? { Authorization: `Bearer ${token}` } : {}),
             },
+            body: JSON.stringify({ refresh_token: refreshToken ?? '' }),
           });
         } catch {
           // Ignore network errors during logout
@@ -150,6 +164,7 @@ export function AuthProvider({ children }: AuthProviderProps) {
       }
 
       await clearAllAuthData();
+      clearResponseCache();
       setUser(null);
     } finally {
       setIsLoading(false);
@@ -4,6 +4,8 @@ export {
   getSessionToken,
   setSessionToken,
   clearSessionToken,
+  getRefreshToken,
+  setAuthTokens,
   clearAllAuthData,
 } from './storage';
 export type { StoredUser } from './storage';
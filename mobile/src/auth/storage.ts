@@ -1,6 +1,7 @@
 import { Platform } from 'react-native';
 
 const SESSION_KEY = 'alfred_session_token';
+const REFRESH_KEY = 'alfred_refresh_token';
 const USER_KEY = 'alfred_user';
 
 // Web fallback using localStorage (less secure but works for development)
@@ -85,6 +86,25 @@ export async function clearSessionToken(): Promise<void> {
   await store.deleteItem(SESSION_KEY);
 }
 
+export async function getRefreshToken(): Promise<string | null> {
+  const store = await getSecureStore();
+  return store.getItem(REFRESH_KEY);
+}
+
+export async function clearRefreshToken(): Promise<void> {
+  const store = await getSecureStore();
+  await store.deleteItem(REFRESH_KEY);
+}
+
+// Stores the access token (sent as the bearer token) and the refresh token used to renew it
+export async function setAuthTokens(accessToken: string, refreshToken: string): Promise<void> {
+  const store = await getSecureStore();
+  await Promise.all([
+    store.setItem(SESSION_KEY, accessToken),
+    store.setItem(REFRESH_KEY, refreshToken),
+  ]);
+}
+
 export async function getStoredUser(): Promise<StoredUser | null> {
   const store = await getSecureStore();
   const userJson = await store.getItem(USER_KEY);
@@ -109,5 +129,5 @@ export async function clearStoredUser(): Promise<void> {
 }
 
 export async function clearAllAuthData(): Promise<void> {
-  await Promise.all([clearSessionToken(), clearStoredUser()]);
+  await Promise.all([clearSessionToken(), clearRefreshToken(), clearStoredUser()]);
 }
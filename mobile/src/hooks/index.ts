@@ -38,6 +38,8 @@ export {
   useTelegramStatus,
   useSendTelegramCode,
   useVerifyTelegramCode,
+  useVerifyTelegramPassword,
+  useStartTelegramQRLogin,
   useDisconnectTelegram,
   useReconnectTelegram,
   useDiscoverableTelegramChannels,
@@ -11,8 +11,8 @@ export function useRequestAdditionalScopes() {
 export function useExchangeAddScopesCode() {
   const queryClient = useQueryClient();
   return useMutation({
-    mutationFn: ({ code, scopes, redirectUri }: { code: string; scopes: ScopeType[]; redirectUri?: string }) =>
-      exchangeAddScopesCode(code, scopes, redirectUri),
+    mutationFn: ({ code, state, scopes }: { code: string; state: string; scopes: ScopeType[] }) =>
+      exchangeAddScopesCode(code, state, scopes),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['gcalStatus'] });
       queryClient.invalidateQueries({ queryKey: ['onboardingStatus'] });
@@ -12,11 +12,12 @@ import {
 } from '../api';
 import type { GCalSettings, UpdateGCalSettingsRequest } from '../api/gcal';
 
-export function useOnboardingStatus() {
+export function useOnboardingStatus(enabled = true) {
   return useQuery({
     queryKey: ['onboardingStatus'],
     queryFn: getOnboardingStatus,
     refetchInterval: 3000, // Poll every 3 seconds during onboarding
+    enabled,
   });
 }
 
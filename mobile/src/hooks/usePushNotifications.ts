@@ -3,7 +3,7 @@ import { Platform } from 'react-native';
 import * as Device from 'expo-device';
 import * as Notifications from 'expo-notifications';
 import Constants from 'expo-constants';
-import { registerPushToken, updatePushPrefs } from '../api/notifications';
+import { markNotificationsRead, registerPushToken, updatePushPrefs } from '../api/notifications';
 import { navigate } from '../navigation/navigationRef';
 
 export interface PushNotificationState {
@@ -38,6 +38,10 @@ export function usePushNotifications() {
     responseListener.current = Notifications.addNotificationResponseReceivedListener(response => {
       console.log('Notification response:', response);
       const data = response.notification.request.content.data;
+      // Opening a push reads it; the badge then shows what is still waiting
+      markNotificationsRead()
+        .then(counts => Notifications.setBadgeCountAsync(counts.total))
+        .catch(err => console.error('Error marking notifications read:', err));
       // Navigate based on screen specified in notification data
       if (data?.screen === 'Permissions') {
         console.log('Navigating to Connect Your Apps screen');
@@ -112,7 +116,7 @@ export function usePushNotifications() {
       const token = tokenData.data;
 
       // Register token with backend
-      await registerPushToken(token);
+      await registerPushToken(token, Platform.OS, Device.deviceName ?? undefined);
 
       // Enable push notifications
       await updatePushPrefs(true);
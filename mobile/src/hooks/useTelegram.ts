@@ -3,6 +3,8 @@ import {
   getTelegramStatus,
   sendTelegramCode,
   verifyTelegramCode,
+  verifyTelegramPassword,
+  startTelegramQRLogin,
   disconnectTelegram,
   reconnectTelegram,
   discoverTelegramChannels,
@@ -57,6 +59,29 @@ export function useVerifyTelegramCode() {
   });
 }
 
+// Hook to verify the two-step verification password
+export function useVerifyTelegramPassword() {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: (password: string) => verifyTelegramPassword(password),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: TELEGRAM_STATUS_KEY });
+      queryClient.invalidateQueries({ queryKey: ['appStatus'] });
+    },
+  });
+}
+
+// Hook to start a QR code login
+export function useStartTelegramQRLogin() {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: startTelegramQRLogin,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['onboardingStatus'] });
+    },
+  });
+}
+
 // Hook to disconnect Telegram
 export function useDisconnectTelegram() {
   const queryClient = useQueryClient();
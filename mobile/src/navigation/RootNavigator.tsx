@@ -37,8 +37,7 @@ export function RootNavigator() {
 
   // Handle OAuth callback deep link globally (for Google Calendar OAuth)
   const handleOAuthCallback = useCallback(
-    async (code: string) => {
-      const redirectUri = ExpoLinking.createURL('oauth/callback');
+    async (code: string, state: string) => {
       console.log('[RootNavigator] OAuth callback received!');
       console.log('[RootNavigator] Code (first 10 chars):', code.substring(0, 10) + '...');
       console.log('[RootNavigator] Is authenticated:', isAuthenticated);
@@ -48,7 +47,7 @@ export function RootNavigator() {
         // Otherwise, it's incremental auth for calendar/gmail scopes
         if (!isAuthenticated) {
           console.log('[RootNavigator] Calling login...');
-          await login(code, redirectUri);
+          await login(code, state);
           console.log('[RootNavigator] Login successful!');
         } else {
           // Already authenticated - this is incremental auth for calendar/gmail
@@ -56,8 +55,8 @@ export function RootNavigator() {
           console.log('[RootNavigator] Calling exchangeAddScopesCode for calendar...');
           await exchangeAddScopesCode.mutateAsync({
             code,
+            state,
             scopes: ['calendar' as ScopeType],
-            redirectUri: undefined // Backend will use HTTPS callback
           });
           console.log('[RootNavigator] Scopes added successfully!');
         }
@@ -78,7 +77,10 @@ export function RootNavigator() {
     const handleUrl = ({ url }: { url: string }) => {
       const parsed = ExpoLinking.parse(url);
       if (parsed.path === 'oauth/callback' && parsed.queryParams?.code) {
-        handleOAuthCallback(parsed.queryParams.code as string);
+        handleOAuthCallback(
+          parsed.queryParams.code as string,
+          (parsed.queryParams.state as string | undefined) ?? ''
+        );
       }
     };
 
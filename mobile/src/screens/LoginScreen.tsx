@@ -33,14 +33,16 @@ export function LoginScreen() {
         const url = result.url;
         console.log('[LoginScreen] Got success URL:', url);
 
-        // Extract code from URL
+        // Extract code and state from URL
         const codeMatch = url.match(/[?&]code=([^&]+)/);
+        const stateMatch = url.match(/[?&]state=([^&]+)/);
         if (codeMatch && codeMatch[1]) {
           const code = decodeURIComponent(codeMatch[1]);
+          const state = stateMatch?.[1] ? decodeURIComponent(stateMatch[1]) : '';
           console.log('[LoginScreen] Extracted code, logging in...');
 
           // Exchange code and create session via the login callback
-          await login(code, '');
+          await login(code, state);
           console.log('[LoginScreen] Login successful!');
         } else {
           throw new Error('No authorization code received');
@@ -19,6 +19,7 @@ import {
   useTelegramStatus,
   useSendTelegramCode,
   useVerifyTelegramCode,
+  useVerifyTelegramPassword,
   useDisconnectTelegram,
   useGmailStatus,
 } from '../hooks';
@@ -93,11 +94,14 @@ export function PreferencesScreen() {
   const { data: telegramStatus, isLoading: telegramLoading, refetch: refetchTelegramStatus } = useTelegramStatus();
   const sendTelegramCode = useSendTelegramCode();
   const verifyTelegramCode = useVerifyTelegramCode();
+  const verifyTelegramPassword = useVerifyTelegramPassword();
   const disconnectTelegram = useDisconnectTelegram();
   const [showTelegramConnect, setShowTelegramConnect] = useState(false);
   const [telegramPhoneNumber, setTelegramPhoneNumber] = useState('');
   const [telegramCode, setTelegramCode] = useState('');
   const [telegramCodeSent, setTelegramCodeSent] = useState(false);
+  const [telegramPassword, setTelegramPassword] = useState('');
+  const [telegramPasswordRequired, setTelegramPasswordRequired] = useState(false);
   const scrollViewRef = useRef<ScrollView>(null);
   const [accountsSectionY, setAccountsSectionY] = useState(0);
 
@@ -284,6 +288,8 @@ export function PreferencesScreen() {
       setTelegramCodeSent(false);
       setTelegramPhoneNumber('');
       setTelegramCode('');
+      setTelegramPassword('');
+      setTelegramPasswordRequired(false);
     }
   }, [telegramStatus?.connected]);
 
@@ -299,6 +305,8 @@ export function PreferencesScreen() {
         setTelegramCodeSent(false);
         setTelegramPhoneNumber('');
         setTelegramCode('');
+        setTelegramPassword('');
+        setTelegramPasswordRequired(false);
       };
     }, [])
   );
@@ -383,8 +391,9 @@ export function PreferencesScreen() {
       if (result.type === 'success' && result.url) {
         const parsed = ExpoLinking.parse(result.url);
         const code = parsed.queryParams?.code as string | undefined;
+        const state = (parsed.queryParams?.state as string | undefined) ?? '';
         if (code) {
-          await exchangeAddScopesCode(code, ['gmail'], backendCallbackUri);
+          await exchangeAddScopesCode(code, state, ['gmail']);
           refetchGmailStatus();
         }
       }
@@ -403,8 +412,9 @@ export function PreferencesScreen() {
       if (result.type === 'success' && result.url) {
         const parsed = ExpoLinking.parse(result.url);
         const code = parsed.queryParams?.code as string | undefined;
+        const state = (parsed.queryParams?.state as string | undefined) ?? '';
         if (code) {
-          await exchangeAddScopesCode(code, ['calendar'], backendCallbackUri);
+          await exchangeAddScopesCode(code, state, ['calendar']);
           refetchGcalStatus();
         }
       }
@@ -468,6 +478,8 @@ export function PreferencesScreen() {
     setTelegramCodeSent(false);
     setTelegramPhoneNumber('');
     setTelegramCode('');
+    setTelegramPassword('');
+    setTelegramPasswordRequired(false);
   };
 
   const handleSendTelegramCode = async () => {
@@ -478,6 +490,7 @@ export function PreferencesScreen() {
     try {
       await sendTelegramCode.mutateAsync(telegramPhoneNumber.trim());
       setTelegramCodeSent(true);
+      setTelegramPasswordRequired(false);
     } catch (error: any) {
       Alert.alert('Error', error.response?.data?.error || 'Failed to send verification code');
     }
@@ -489,13 +502,30 @@ export function PreferencesScreen() {
       return;
     }
     try {
-      await verifyTelegramCode.mutateAsync(telegramCode.trim());
+      const result = await verifyTelegramCode.mutateAsync(telegramCode.trim());
+      if (result.password_required) {
+        setTelegramPasswordRequired(true);
+        return;
+      }
       refetchTelegramStatus();
     } catch (error: any) {
       Alert.alert('Error', error.response?.data?.error || 'Failed to verify code');
     }
   };
 
+  const handleVerifyTelegramPassword = async () => {
+    if (!telegramPassword) {
+      Alert.alert('Error', 'Please enter your two-step verification password');
+      return;
+    }
+    try {
+      await verifyTelegramPassword.mutateAsync(telegramPassword);
+      refetchTelegramStatus();
+    } catch (error: any) {
+      Alert.alert('Error', error.response?.data?.error || 'Failed to verify password');
+    }
+  };
+
   const scrollToAccountsSection = useCallback(() => {
     scrollViewRef.current?.scrollTo({
       y: Math.max(accountsSectionY - 12, 0),
@@ -518,6 +548,8 @@ export function PreferencesScreen() {
       setTelegramCodeSent(false);
       setTelegramPhoneNumber('');
       setTelegramCode('');
+      setTelegramPassword('');
+      setTelegramPasswordRequired(false);
       scrollToAccountsSection();
       return;
     }
@@ -900,6 +932,28 @@ export function PreferencesScreen() {
                               style={styles.generateButton}
                             />
                           </>
+                        ) : telegramPasswordRequired ? (
+                          <>
+                            <Text style={styles.connectLabel}>
+                              Two-step verification is on. Enter your Telegram password
+                            </Text>
+                            <TextInput
+                              style={styles.input}
+                              value={telegramPassword}
+                              onChangeText={setTelegramPassword}
+                              placeholder="Password"
+                              placeholderTextColor={colors.textSecondary}
+                              secureTextEntry
+                              autoCapitalize="none"
+                              autoCorrect={false}
+                            />
+                            <Button
+                              title="Verify Password"
+                              onPress={handleVerifyTelegramPassword}
+                              loading={verifyTelegramPassword.isPending}
+                              style={styles.generateButton}
+                            />
+                          </>
                         ) : (
                           <>
                             <Text style={styles.connectLabel}>
@@ -10,6 +10,8 @@ import {
   TextInput,
   KeyboardAvoidingView,
   Platform,
+  Linking,
+  ActivityIndicator,
 } from 'react-native';
 import { SafeAreaView } from 'react-native-safe-area-context';
 import { useRoute, useNavigation, useFocusEffect } from '@react-navigation/native';
@@ -30,6 +32,9 @@ import {
   useTelegramStatus,
   useSendTelegramCode,
   useVerifyTelegramCode,
+  useVerifyTelegramPassword,
+  useStartTelegramQRLogin,
+  useOnboardingStatus,
 } from '../../hooks';
 import { useRequestAdditionalScopes, useExchangeAddScopesCode } from '../../hooks/useIncrementalAuth';
 import { ScopeType } from '../../api/auth';
@@ -134,6 +139,9 @@ export function ConnectionScreen() {
   const [telegramPhoneNumber, setTelegramPhoneNumber] = useState('');
   const [telegramCode, setTelegramCode] = useState('');
   const [telegramCodeSent, setTelegramCodeSent] = useState(false);
+  const [telegramPassword, setTelegramPassword] = useState('');
+  const [telegramPasswordRequired, setTelegramPasswordRequired] = useState(false);
+  const [telegramQRStarted, setTelegramQRStarted] = useState(false);
   const [googleScopeLoading, setGoogleScopeLoading] = useState(false);
   const previousWhatsAppConnected = React.useRef<boolean | null>(null);
 
@@ -145,6 +153,11 @@ export function ConnectionScreen() {
   const generatePairingCode = useGeneratePairingCode();
   const sendTelegramCode = useSendTelegramCode();
   const verifyTelegramCode = useVerifyTelegramCode();
+  const verifyTelegramPassword = useVerifyTelegramPassword();
+  const startTelegramQRLogin = useStartTelegramQRLogin();
+  // The QR code is only published in the onboarding status, so poll it while a QR login runs
+  const { data: onboardingStatus } = useOnboardingStatus(telegramQRStarted);
+  const telegramQR = telegramQRStarted ? onboardingStatus?.telegram : undefined;
   const requestAdditionalScopes = useRequestAdditionalScopes();
   const exchangeAddScopesCode = useExchangeAddScopesCode();
 
@@ -160,7 +173,9 @@ export function ConnectionScreen() {
       ? 'available'
       : 'needs_access';
   const whatsappStatus: IntegrationStatusType = waStatus?.connected ? 'available' : (pairingCode ? 'connecting' : 'pending');
-  const telegramStatusType: IntegrationStatusType = telegramStatus?.connected ? 'available' : (telegramCodeSent ? 'connecting' : 'pending');
+  const telegramStatusType: IntegrationStatusType = telegramStatus?.connected
+    ? 'available'
+    : (telegramCodeSent || telegramQRStarted ? 'connecting' : 'pending');
   const googleScopesSelected = React.useMemo(() => {
     const scopes: ScopeType[] = [];
     if (gmailEnabled) {
@@ -307,12 +322,33 @@ export function ConnectionScreen() {
     previousWhatsAppConnected.current = isConnected;
   }, [waStatus?.connected, whatsappEnabled, notifyWhatsAppConnected]);
 
+  // A QR login ends on the server; follow it into the password step or show its error
+  useEffect(() => {
+    if (!telegramQRStarted || !telegramQR) {
+      return;
+    }
+    if (telegramQR.status === 'password_required') {
+      setTelegramQRStarted(false);
+      setTelegramCodeSent(true);
+      setTelegramPasswordRequired(true);
+    } else if (telegramQR.status === 'connected') {
+      setTelegramQRStarted(false);
+      queryClient.invalidateQueries({ queryKey: ['telegramStatus'] });
+    } else if (telegramQR.status === 'error') {
+      setTelegramQRStarted(false);
+      Alert.alert('Error', telegramQR.error || 'QR login failed');
+    }
+  }, [telegramQRStarted, telegramQR, queryClient]);
+
   // Reset Telegram state when connected
   useEffect(() => {
     if (telegramStatus?.connected) {
       setTelegramCodeSent(false);
       setTelegramPhoneNumber('');
       setTelegramCode('');
+      setTelegramPassword('');
+      setTelegramPasswordRequired(false);
+      setTelegramQRStarted(false);
     }
   }, [telegramStatus?.connected]);
 
@@ -328,6 +364,9 @@ export function ConnectionScreen() {
         setTelegramCodeSent(false);
         setTelegramPhoneNumber('');
         setTelegramCode('');
+        setTelegramPassword('');
+        setTelegramPasswordRequired(false);
+        setTelegramQRStarted(false);
       };
     }, [])
   );
@@ -348,13 +387,14 @@ export function ConnectionScreen() {
       const result = await WebBrowser.openAuthSessionAsync(response.auth_url);
       if (result.type === 'success' && result.url) {
         const codeMatch = result.url.match(/[?&]code=([^&]+)/);
+        const stateMatch = result.url.match(/[?&]state=([^&]+)/);
         if (!codeMatch?.[1]) {
           throw new Error('No authorization code received');
         }
         await exchangeAddScopesCode.mutateAsync({
           code: decodeURIComponent(codeMatch[1]),
+          state: stateMatch?.[1] ? decodeURIComponent(stateMatch[1]) : '',
           scopes: scopesToRequest,
-          redirectUri: undefined,
         });
       }
     } catch (error: any) {
@@ -395,6 +435,7 @@ export function ConnectionScreen() {
     try {
       await sendTelegramCode.mutateAsync(telegramPhoneNumber.trim());
       setTelegramCodeSent(true);
+      setTelegramPasswordRequired(false);
     } catch (error: any) {
       Alert.alert('Error', error.response?.data?.error || 'Failed to send verification code');
     }
@@ -406,13 +447,39 @@ export function ConnectionScreen() {
       return;
     }
     try {
-      await verifyTelegramCode.mutateAsync(telegramCode.trim());
+      const result = await verifyTelegramCode.mutateAsync(telegramCode.trim());
+      if (result.password_required) {
+        setTelegramPasswordRequired(true);
+        return;
+      }
       queryClient.invalidateQueries({ queryKey: ['telegramStatus'] });
     } catch (error: any) {
       Alert.alert('Error', error.response?.data?.error || 'Failed to verify code');
     }
   };
 
+  const handleStartTelegramQRLogin = async () => {
+    try {
+      await startTelegramQRLogin.mutateAsync();
+      setTelegramQRStarted(true);
+    } catch (error: any) {
+      Alert.alert('Error', error.response?.data?.error || 'Failed to start QR login');
+    }
+  };
+
+  const handleVerifyTelegramPassword = async () => {
+    if (!telegramPassword) {
+      Alert.alert('Error', 'Please enter your two-step verification password');
+      return;
+    }
+    try {
+      await verifyTelegramPassword.mutateAsync(telegramPassword);
+      queryClient.invalidateQueries({ queryKey: ['telegramStatus'] });
+    } catch (error: any) {
+      Alert.alert('Error', error.response?.data?.error || 'Failed to verify password');
+    }
+  };
+
   const handleContinue = () => {
     // Navigate to SourceConfiguration instead of calling completeOnboarding
     navigation.navigate('SourceConfiguration', {
@@ -579,7 +646,36 @@ export function ConnectionScreen() {
 
               {telegramStatusType !== 'available' && (
                 <View style={styles.telegramSection}>
-                  {!telegramCodeSent ? (
+                  {telegramQRStarted ? (
+                    <>
+                      <View style={styles.pairingCodeContainer}>
+                        {telegramQR?.qr_code ? (
+                          <Image source={{ uri: telegramQR.qr_code }} style={styles.telegramQRCode} />
+                        ) : (
+                          <ActivityIndicator color={colors.primary} />
+                        )}
+                      </View>
+                      <Text style={styles.pairingInstructions}>
+                        In Telegram: Settings {'>'} Devices {'>'} Link Desktop Device
+                      </Text>
+                      <Text style={styles.pairingSubInstructions}>
+                        Scan this code from Telegram on another device to log in
+                      </Text>
+                      {telegramQR?.login_url && (
+                        <Button
+                          title="Open in Telegram"
+                          onPress={() => Linking.openURL(telegramQR.login_url!)}
+                          style={styles.generateButton}
+                        />
+                      )}
+                      <Button
+                        title="Use Phone Number Instead"
+                        variant="outline"
+                        onPress={() => setTelegramQRStarted(false)}
+                        style={styles.generateButton}
+                      />
+                    </>
+                  ) : !telegramCodeSent ? (
                     <>
                       <Text style={styles.phoneInputLabel}>
                         Phone number (include country code)
@@ -600,6 +696,35 @@ export function ConnectionScreen() {
                         loading={sendTelegramCode.isPending}
                         style={styles.generateButton}
                       />
+                      <Button
+                        title="Log In with QR Code"
+                        variant="outline"
+                        onPress={handleStartTelegramQRLogin}
+                        loading={startTelegramQRLogin.isPending}
+                        style={styles.generateButton}
+                      />
+                    </>
+                  ) : telegramPasswordRequired ? (
+                    <>
+                      <Text style={styles.phoneInputLabel}>
+                        Two-step verification is on. Enter your Telegram password
+                      </Text>
+                      <TextInput
+                        style={styles.phoneInput}
+                        value={telegramPassword}
+                        onChangeText={setTelegramPassword}
+                        placeholder="Password"
+                        placeholderTextColor={colors.textSecondary}
+                        secureTextEntry
+                        autoCapitalize="none"
+                        autoCorrect={false}
+                      />
+                      <Button
+                        title="Verify Password"
+                        onPress={handleVerifyTelegramPassword}
+                        loading={verifyTelegramPassword.isPending}
+                        style={styles.generateButton}
+                      />
                     </>
                   ) : (
                     <>
@@ -846,6 +971,10 @@ const styles = StyleSheet.create({
     letterSpacing: 4,
     fontFamily: 'monospace',
   },
+  telegramQRCode: {
+    width: 200,
+    height: 200,
+  },
   copyButton: {
     padding: 8,
     marginLeft: 12,
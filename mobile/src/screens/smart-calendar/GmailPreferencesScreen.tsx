@@ -81,10 +81,11 @@ export function GmailPreferencesScreen() {
         // Extract code from callback URL
         const parsed = ExpoLinking.parse(result.url);
         const code = parsed.queryParams?.code as string | undefined;
+        const state = (parsed.queryParams?.state as string | undefined) ?? '';
 
         if (code) {
           // Exchange code and add Gmail scopes
-          await exchangeAddScopesCode(code, ['gmail'], backendCallbackUri);
+          await exchangeAddScopesCode(code, state, ['gmail']);
           // Refresh Gmail status
           queryClient.invalidateQueries({ queryKey: ['gmailStatus'] });
           Alert.alert('Success', 'Gmail access authorized successfully!');
@@ -102,10 +102,11 @@ export function GoogleCalendarPreferencesScreen() {
         // Extract code from callback URL
         const parsed = ExpoLinking.parse(result.url);
         const code = parsed.queryParams?.code as string | undefined;
+        const state = (parsed.queryParams?.state as string | undefined) ?? '';
 
         if (code) {
           // Exchange code and add Calendar scopes
-          await exchangeAddScopesCode(code, ['calendar'], backendCallbackUri);
+          await exchangeAddScopesCode(code, state, ['calendar']);
           // Refresh GCal status
           queryClient.invalidateQueries({ queryKey: ['gcalStatus'] });
           Alert.alert('Success', 'Google Calendar access authorized successfully!');
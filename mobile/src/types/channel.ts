@@ -31,6 +31,27 @@ export interface UpdateChannelRequest {
   enabled?: boolean;
 }
 
+// A contact for POST /api/channels/import; source defaults to WhatsApp for a phone
+// and Telegram for a username
+export interface ChannelImportContact {
+  source?: 'whatsapp' | 'telegram';
+  name?: string;
+  phone?: string;
+  username?: string;
+}
+
+export interface ChannelImportResult {
+  status: 'created' | 'enabled' | 'unchanged';
+  channel: Channel;
+}
+
+export interface ChannelImportResponse {
+  created: number;
+  enabled: number;
+  unchanged: number;
+  channels: ChannelImportResult[];
+}
+
 // Top Contact for Add Source modal (WhatsApp/Telegram)
 export interface SourceTopContact {
   identifier: string;
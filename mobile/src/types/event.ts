@@ -53,6 +53,11 @@ export interface UpdateEventRequest {
   attendees?: { name: string; email?: string }[];
 }
 
+export interface ConvertToEventRequest {
+  start_time?: string; // Defaults to the reminder's due date
+  end_time?: string;
+}
+
 export interface MessageHistory {
   id: number;
   channel_id: number;
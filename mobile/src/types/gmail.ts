@@ -3,6 +3,7 @@ export interface GmailStatus {
   enabled: boolean;
   message: string;
   has_scopes: boolean;
+  reauth_required: boolean;
   poll_interval_minutes: number;
   last_poll_at?: string;
 }
@@ -46,6 +46,11 @@ export interface UpdateReminderRequest {
   priority?: ReminderPriority;
 }
 
+export interface ConvertToReminderRequest {
+  due_date?: string; // Left out for a reminder without a fixed time
+  priority?: ReminderPriority;
+}
+
 export interface CreateReminderRequest {
   title: string;
   description?: string;